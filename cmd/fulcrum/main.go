@@ -16,7 +16,11 @@ func main() {
 		os.Exit(1)
 	}
 	var jobMaintenanceWorker *app.JobMaintenanceWorker
+	var jobLeaseWorker *app.JobLeaseWorker
 	var agentsWorker *app.UnhealthyAgentsWorker
+	var webhookWorker *app.WebhookDeliveryWorker
+	var vaultWorker *app.VaultMaintenanceWorker
+	var tokenWorker *app.TokenMaintenanceWorker
 
 	if application.Config.JobMaintenance {
 		jobMaintenanceWorker = app.NewJobMaintenanceWorker(application)
@@ -26,6 +30,14 @@ func main() {
 		}
 	}
 
+	if application.Config.JobLeaseReclaim {
+		jobLeaseWorker = app.NewJobLeaseWorker(application)
+		if err := jobLeaseWorker.Run(); err != nil {
+			slog.Error("Failed to run job lease worker", "error", err)
+			os.Exit(1)
+		}
+	}
+
 	if application.Config.AgentMaintenance {
 		agentsWorker = app.NewUnhealthyAgentsWorker(application)
 		if err := agentsWorker.Run(); err != nil {
@@ -34,6 +46,30 @@ func main() {
 		}
 	}
 
+	if application.Config.WebhookDelivery {
+		webhookWorker = app.NewWebhookDeliveryWorker(application)
+		if err := webhookWorker.Run(); err != nil {
+			slog.Error("Failed to run webhook delivery worker", "error", err)
+			os.Exit(1)
+		}
+	}
+
+	if application.Config.VaultMaintenance {
+		vaultWorker = app.NewVaultMaintenanceWorker(application)
+		if err := vaultWorker.Run(); err != nil {
+			slog.Error("Failed to run vault maintenance worker", "error", err)
+			os.Exit(1)
+		}
+	}
+
+	if application.Config.TokenMaintenance {
+		tokenWorker = app.NewTokenMaintenanceWorker(application)
+		if err := tokenWorker.Run(); err != nil {
+			slog.Error("Failed to run token maintenance worker", "error", err)
+			os.Exit(1)
+		}
+	}
+
 	var apiServer *app.ApiServer
 	if application.Config.ApiServer {
 		apiServer = app.NewApiServer(application)
@@ -47,21 +83,68 @@ func main() {
 		}
 	}
 
+	var grpcServer *app.GRPCServer
+	if application.Config.GRPCServer {
+		grpcServer = app.NewGRPCServer(application)
+		if err := grpcServer.Start(); err != nil {
+			slog.Error("Failed to start gRPC server", "error", err)
+			os.Exit(1)
+		}
+	}
+
+	reload := make(chan os.Signal, 1)
+	signal.Notify(reload, syscall.SIGHUP)
+	go func() {
+		for range reload {
+			application.Reload()
+		}
+	}()
+
 	stop := make(chan os.Signal, 1)
 	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
 
 	<-stop
 	slog.Info("Shutting down server...")
 
+	forced := false
 	if apiServer != nil {
-		apiServer.Close()
+		if err := apiServer.Close(); err != nil {
+			forced = true
+		}
+	}
+
+	if grpcServer != nil {
+		if err := grpcServer.Close(); err != nil {
+			forced = true
+		}
 	}
 
 	if jobMaintenanceWorker != nil {
 		jobMaintenanceWorker.Close()
 	}
 
+	if jobLeaseWorker != nil {
+		jobLeaseWorker.Close()
+	}
+
 	if agentsWorker != nil {
 		agentsWorker.Close()
 	}
+
+	if webhookWorker != nil {
+		webhookWorker.Close()
+	}
+
+	if vaultWorker != nil {
+		vaultWorker.Close()
+	}
+
+	if tokenWorker != nil {
+		tokenWorker.Close()
+	}
+
+	if forced {
+		slog.Error("Shutdown did not complete gracefully")
+		os.Exit(1)
+	}
 }
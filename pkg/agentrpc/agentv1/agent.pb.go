@@ -0,0 +1,1097 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        (unknown)
+// source: fulcrum/agent/v1/agent.proto
+
+package agentv1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
+	structpb "google.golang.org/protobuf/types/known/structpb"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type Agent struct {
+	state             protoimpl.MessageState `protogen:"open.v1"`
+	Id                string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name              string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Status            string                 `protobuf:"bytes,3,opt,name=status,proto3" json:"status,omitempty"`
+	Draining          bool                   `protobuf:"varint,4,opt,name=draining,proto3" json:"draining,omitempty"`
+	ProviderId        string                 `protobuf:"bytes,5,opt,name=provider_id,json=providerId,proto3" json:"provider_id,omitempty"`
+	AgentTypeId       string                 `protobuf:"bytes,6,opt,name=agent_type_id,json=agentTypeId,proto3" json:"agent_type_id,omitempty"`
+	MaxConcurrentJobs *int32                 `protobuf:"varint,7,opt,name=max_concurrent_jobs,json=maxConcurrentJobs,proto3,oneof" json:"max_concurrent_jobs,omitempty"`
+	LastStatusUpdate  *timestamppb.Timestamp `protobuf:"bytes,8,opt,name=last_status_update,json=lastStatusUpdate,proto3" json:"last_status_update,omitempty"`
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
+}
+
+func (x *Agent) Reset() {
+	*x = Agent{}
+	mi := &file_fulcrum_agent_v1_agent_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Agent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Agent) ProtoMessage() {}
+
+func (x *Agent) ProtoReflect() protoreflect.Message {
+	mi := &file_fulcrum_agent_v1_agent_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Agent.ProtoReflect.Descriptor instead.
+func (*Agent) Descriptor() ([]byte, []int) {
+	return file_fulcrum_agent_v1_agent_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Agent) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Agent) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Agent) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *Agent) GetDraining() bool {
+	if x != nil {
+		return x.Draining
+	}
+	return false
+}
+
+func (x *Agent) GetProviderId() string {
+	if x != nil {
+		return x.ProviderId
+	}
+	return ""
+}
+
+func (x *Agent) GetAgentTypeId() string {
+	if x != nil {
+		return x.AgentTypeId
+	}
+	return ""
+}
+
+func (x *Agent) GetMaxConcurrentJobs() int32 {
+	if x != nil && x.MaxConcurrentJobs != nil {
+		return *x.MaxConcurrentJobs
+	}
+	return 0
+}
+
+func (x *Agent) GetLastStatusUpdate() *timestamppb.Timestamp {
+	if x != nil {
+		return x.LastStatusUpdate
+	}
+	return nil
+}
+
+type Telemetry struct {
+	state              protoimpl.MessageState `protogen:"open.v1"`
+	CpuUsage           float64                `protobuf:"fixed64,1,opt,name=cpu_usage,json=cpuUsage,proto3" json:"cpu_usage,omitempty"`
+	MemUsage           float64                `protobuf:"fixed64,2,opt,name=mem_usage,json=memUsage,proto3" json:"mem_usage,omitempty"`
+	ActiveServiceCount int32                  `protobuf:"varint,3,opt,name=active_service_count,json=activeServiceCount,proto3" json:"active_service_count,omitempty"`
+	MaxServices        int32                  `protobuf:"varint,4,opt,name=max_services,json=maxServices,proto3" json:"max_services,omitempty"`
+	unknownFields      protoimpl.UnknownFields
+	sizeCache          protoimpl.SizeCache
+}
+
+func (x *Telemetry) Reset() {
+	*x = Telemetry{}
+	mi := &file_fulcrum_agent_v1_agent_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Telemetry) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Telemetry) ProtoMessage() {}
+
+func (x *Telemetry) ProtoReflect() protoreflect.Message {
+	mi := &file_fulcrum_agent_v1_agent_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Telemetry.ProtoReflect.Descriptor instead.
+func (*Telemetry) Descriptor() ([]byte, []int) {
+	return file_fulcrum_agent_v1_agent_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Telemetry) GetCpuUsage() float64 {
+	if x != nil {
+		return x.CpuUsage
+	}
+	return 0
+}
+
+func (x *Telemetry) GetMemUsage() float64 {
+	if x != nil {
+		return x.MemUsage
+	}
+	return 0
+}
+
+func (x *Telemetry) GetActiveServiceCount() int32 {
+	if x != nil {
+		return x.ActiveServiceCount
+	}
+	return 0
+}
+
+func (x *Telemetry) GetMaxServices() int32 {
+	if x != nil {
+		return x.MaxServices
+	}
+	return 0
+}
+
+type RegisterRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Telemetry     *Telemetry             `protobuf:"bytes,1,opt,name=telemetry,proto3" json:"telemetry,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RegisterRequest) Reset() {
+	*x = RegisterRequest{}
+	mi := &file_fulcrum_agent_v1_agent_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RegisterRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RegisterRequest) ProtoMessage() {}
+
+func (x *RegisterRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_fulcrum_agent_v1_agent_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RegisterRequest.ProtoReflect.Descriptor instead.
+func (*RegisterRequest) Descriptor() ([]byte, []int) {
+	return file_fulcrum_agent_v1_agent_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *RegisterRequest) GetTelemetry() *Telemetry {
+	if x != nil {
+		return x.Telemetry
+	}
+	return nil
+}
+
+type UpdateStatusRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Status        string                 `protobuf:"bytes,1,opt,name=status,proto3" json:"status,omitempty"`
+	Telemetry     *Telemetry             `protobuf:"bytes,2,opt,name=telemetry,proto3" json:"telemetry,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdateStatusRequest) Reset() {
+	*x = UpdateStatusRequest{}
+	mi := &file_fulcrum_agent_v1_agent_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateStatusRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateStatusRequest) ProtoMessage() {}
+
+func (x *UpdateStatusRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_fulcrum_agent_v1_agent_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateStatusRequest.ProtoReflect.Descriptor instead.
+func (*UpdateStatusRequest) Descriptor() ([]byte, []int) {
+	return file_fulcrum_agent_v1_agent_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *UpdateStatusRequest) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *UpdateStatusRequest) GetTelemetry() *Telemetry {
+	if x != nil {
+		return x.Telemetry
+	}
+	return nil
+}
+
+type Job struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Id             string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	ServiceId      string                 `protobuf:"bytes,2,opt,name=service_id,json=serviceId,proto3" json:"service_id,omitempty"`
+	AgentId        string                 `protobuf:"bytes,3,opt,name=agent_id,json=agentId,proto3" json:"agent_id,omitempty"`
+	Action         string                 `protobuf:"bytes,4,opt,name=action,proto3" json:"action,omitempty"`
+	Params         *structpb.Struct       `protobuf:"bytes,5,opt,name=params,proto3" json:"params,omitempty"`
+	Status         string                 `protobuf:"bytes,6,opt,name=status,proto3" json:"status,omitempty"`
+	Priority       int32                  `protobuf:"varint,7,opt,name=priority,proto3" json:"priority,omitempty"`
+	Attempt        int32                  `protobuf:"varint,8,opt,name=attempt,proto3" json:"attempt,omitempty"`
+	LeaseId        *string                `protobuf:"bytes,9,opt,name=lease_id,json=leaseId,proto3,oneof" json:"lease_id,omitempty"`
+	LeaseExpiresAt *timestamppb.Timestamp `protobuf:"bytes,10,opt,name=lease_expires_at,json=leaseExpiresAt,proto3" json:"lease_expires_at,omitempty"`
+	ClaimedAt      *timestamppb.Timestamp `protobuf:"bytes,11,opt,name=claimed_at,json=claimedAt,proto3" json:"claimed_at,omitempty"`
+	CreatedAt      *timestamppb.Timestamp `protobuf:"bytes,12,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *Job) Reset() {
+	*x = Job{}
+	mi := &file_fulcrum_agent_v1_agent_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Job) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Job) ProtoMessage() {}
+
+func (x *Job) ProtoReflect() protoreflect.Message {
+	mi := &file_fulcrum_agent_v1_agent_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Job.ProtoReflect.Descriptor instead.
+func (*Job) Descriptor() ([]byte, []int) {
+	return file_fulcrum_agent_v1_agent_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *Job) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Job) GetServiceId() string {
+	if x != nil {
+		return x.ServiceId
+	}
+	return ""
+}
+
+func (x *Job) GetAgentId() string {
+	if x != nil {
+		return x.AgentId
+	}
+	return ""
+}
+
+func (x *Job) GetAction() string {
+	if x != nil {
+		return x.Action
+	}
+	return ""
+}
+
+func (x *Job) GetParams() *structpb.Struct {
+	if x != nil {
+		return x.Params
+	}
+	return nil
+}
+
+func (x *Job) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *Job) GetPriority() int32 {
+	if x != nil {
+		return x.Priority
+	}
+	return 0
+}
+
+func (x *Job) GetAttempt() int32 {
+	if x != nil {
+		return x.Attempt
+	}
+	return 0
+}
+
+func (x *Job) GetLeaseId() string {
+	if x != nil && x.LeaseId != nil {
+		return *x.LeaseId
+	}
+	return ""
+}
+
+func (x *Job) GetLeaseExpiresAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.LeaseExpiresAt
+	}
+	return nil
+}
+
+func (x *Job) GetClaimedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ClaimedAt
+	}
+	return nil
+}
+
+func (x *Job) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+type WatchJobsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchJobsRequest) Reset() {
+	*x = WatchJobsRequest{}
+	mi := &file_fulcrum_agent_v1_agent_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchJobsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchJobsRequest) ProtoMessage() {}
+
+func (x *WatchJobsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_fulcrum_agent_v1_agent_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchJobsRequest.ProtoReflect.Descriptor instead.
+func (*WatchJobsRequest) Descriptor() ([]byte, []int) {
+	return file_fulcrum_agent_v1_agent_proto_rawDescGZIP(), []int{5}
+}
+
+type ClaimJobRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	JobId         string                 `protobuf:"bytes,1,opt,name=job_id,json=jobId,proto3" json:"job_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ClaimJobRequest) Reset() {
+	*x = ClaimJobRequest{}
+	mi := &file_fulcrum_agent_v1_agent_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ClaimJobRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ClaimJobRequest) ProtoMessage() {}
+
+func (x *ClaimJobRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_fulcrum_agent_v1_agent_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ClaimJobRequest.ProtoReflect.Descriptor instead.
+func (*ClaimJobRequest) Descriptor() ([]byte, []int) {
+	return file_fulcrum_agent_v1_agent_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *ClaimJobRequest) GetJobId() string {
+	if x != nil {
+		return x.JobId
+	}
+	return ""
+}
+
+type RenewJobLeaseRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	JobId         string                 `protobuf:"bytes,1,opt,name=job_id,json=jobId,proto3" json:"job_id,omitempty"`
+	LeaseId       string                 `protobuf:"bytes,2,opt,name=lease_id,json=leaseId,proto3" json:"lease_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RenewJobLeaseRequest) Reset() {
+	*x = RenewJobLeaseRequest{}
+	mi := &file_fulcrum_agent_v1_agent_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RenewJobLeaseRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RenewJobLeaseRequest) ProtoMessage() {}
+
+func (x *RenewJobLeaseRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_fulcrum_agent_v1_agent_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RenewJobLeaseRequest.ProtoReflect.Descriptor instead.
+func (*RenewJobLeaseRequest) Descriptor() ([]byte, []int) {
+	return file_fulcrum_agent_v1_agent_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *RenewJobLeaseRequest) GetJobId() string {
+	if x != nil {
+		return x.JobId
+	}
+	return ""
+}
+
+func (x *RenewJobLeaseRequest) GetLeaseId() string {
+	if x != nil {
+		return x.LeaseId
+	}
+	return ""
+}
+
+type CompleteJobRequest struct {
+	state             protoimpl.MessageState `protogen:"open.v1"`
+	JobId             string                 `protobuf:"bytes,1,opt,name=job_id,json=jobId,proto3" json:"job_id,omitempty"`
+	AgentInstanceId   *string                `protobuf:"bytes,2,opt,name=agent_instance_id,json=agentInstanceId,proto3,oneof" json:"agent_instance_id,omitempty"`
+	AgentInstanceData *structpb.Struct       `protobuf:"bytes,3,opt,name=agent_instance_data,json=agentInstanceData,proto3" json:"agent_instance_data,omitempty"`
+	Properties        *structpb.Struct       `protobuf:"bytes,4,opt,name=properties,proto3" json:"properties,omitempty"`
+	LeaseId           *string                `protobuf:"bytes,5,opt,name=lease_id,json=leaseId,proto3,oneof" json:"lease_id,omitempty"`
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
+}
+
+func (x *CompleteJobRequest) Reset() {
+	*x = CompleteJobRequest{}
+	mi := &file_fulcrum_agent_v1_agent_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CompleteJobRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CompleteJobRequest) ProtoMessage() {}
+
+func (x *CompleteJobRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_fulcrum_agent_v1_agent_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CompleteJobRequest.ProtoReflect.Descriptor instead.
+func (*CompleteJobRequest) Descriptor() ([]byte, []int) {
+	return file_fulcrum_agent_v1_agent_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *CompleteJobRequest) GetJobId() string {
+	if x != nil {
+		return x.JobId
+	}
+	return ""
+}
+
+func (x *CompleteJobRequest) GetAgentInstanceId() string {
+	if x != nil && x.AgentInstanceId != nil {
+		return *x.AgentInstanceId
+	}
+	return ""
+}
+
+func (x *CompleteJobRequest) GetAgentInstanceData() *structpb.Struct {
+	if x != nil {
+		return x.AgentInstanceData
+	}
+	return nil
+}
+
+func (x *CompleteJobRequest) GetProperties() *structpb.Struct {
+	if x != nil {
+		return x.Properties
+	}
+	return nil
+}
+
+func (x *CompleteJobRequest) GetLeaseId() string {
+	if x != nil && x.LeaseId != nil {
+		return *x.LeaseId
+	}
+	return ""
+}
+
+type FailJobRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	JobId         string                 `protobuf:"bytes,1,opt,name=job_id,json=jobId,proto3" json:"job_id,omitempty"`
+	ErrorMessage  string                 `protobuf:"bytes,2,opt,name=error_message,json=errorMessage,proto3" json:"error_message,omitempty"`
+	LeaseId       *string                `protobuf:"bytes,3,opt,name=lease_id,json=leaseId,proto3,oneof" json:"lease_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FailJobRequest) Reset() {
+	*x = FailJobRequest{}
+	mi := &file_fulcrum_agent_v1_agent_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FailJobRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FailJobRequest) ProtoMessage() {}
+
+func (x *FailJobRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_fulcrum_agent_v1_agent_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FailJobRequest.ProtoReflect.Descriptor instead.
+func (*FailJobRequest) Descriptor() ([]byte, []int) {
+	return file_fulcrum_agent_v1_agent_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *FailJobRequest) GetJobId() string {
+	if x != nil {
+		return x.JobId
+	}
+	return ""
+}
+
+func (x *FailJobRequest) GetErrorMessage() string {
+	if x != nil {
+		return x.ErrorMessage
+	}
+	return ""
+}
+
+func (x *FailJobRequest) GetLeaseId() string {
+	if x != nil && x.LeaseId != nil {
+		return *x.LeaseId
+	}
+	return ""
+}
+
+type Histogram struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Bounds        []float64              `protobuf:"fixed64,1,rep,packed,name=bounds,proto3" json:"bounds,omitempty"`
+	Counts        []int64                `protobuf:"varint,2,rep,packed,name=counts,proto3" json:"counts,omitempty"`
+	Sum           float64                `protobuf:"fixed64,3,opt,name=sum,proto3" json:"sum,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Histogram) Reset() {
+	*x = Histogram{}
+	mi := &file_fulcrum_agent_v1_agent_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Histogram) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Histogram) ProtoMessage() {}
+
+func (x *Histogram) ProtoReflect() protoreflect.Message {
+	mi := &file_fulcrum_agent_v1_agent_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Histogram.ProtoReflect.Descriptor instead.
+func (*Histogram) Descriptor() ([]byte, []int) {
+	return file_fulcrum_agent_v1_agent_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *Histogram) GetBounds() []float64 {
+	if x != nil {
+		return x.Bounds
+	}
+	return nil
+}
+
+func (x *Histogram) GetCounts() []int64 {
+	if x != nil {
+		return x.Counts
+	}
+	return nil
+}
+
+func (x *Histogram) GetSum() float64 {
+	if x != nil {
+		return x.Sum
+	}
+	return 0
+}
+
+type SubmitMetricRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The service is given by ID or by the instance ID reported by the agent
+	//
+	// Types that are valid to be assigned to Target:
+	//
+	//	*SubmitMetricRequest_ServiceId
+	//	*SubmitMetricRequest_AgentInstanceId
+	Target        isSubmitMetricRequest_Target `protobuf_oneof:"target"`
+	TypeName      string                       `protobuf:"bytes,3,opt,name=type_name,json=typeName,proto3" json:"type_name,omitempty"`
+	ResourceId    string                       `protobuf:"bytes,4,opt,name=resource_id,json=resourceId,proto3" json:"resource_id,omitempty"`
+	Value         float64                      `protobuf:"fixed64,5,opt,name=value,proto3" json:"value,omitempty"`
+	Histogram     *Histogram                   `protobuf:"bytes,6,opt,name=histogram,proto3" json:"histogram,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SubmitMetricRequest) Reset() {
+	*x = SubmitMetricRequest{}
+	mi := &file_fulcrum_agent_v1_agent_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SubmitMetricRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SubmitMetricRequest) ProtoMessage() {}
+
+func (x *SubmitMetricRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_fulcrum_agent_v1_agent_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SubmitMetricRequest.ProtoReflect.Descriptor instead.
+func (*SubmitMetricRequest) Descriptor() ([]byte, []int) {
+	return file_fulcrum_agent_v1_agent_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *SubmitMetricRequest) GetTarget() isSubmitMetricRequest_Target {
+	if x != nil {
+		return x.Target
+	}
+	return nil
+}
+
+func (x *SubmitMetricRequest) GetServiceId() string {
+	if x != nil {
+		if x, ok := x.Target.(*SubmitMetricRequest_ServiceId); ok {
+			return x.ServiceId
+		}
+	}
+	return ""
+}
+
+func (x *SubmitMetricRequest) GetAgentInstanceId() string {
+	if x != nil {
+		if x, ok := x.Target.(*SubmitMetricRequest_AgentInstanceId); ok {
+			return x.AgentInstanceId
+		}
+	}
+	return ""
+}
+
+func (x *SubmitMetricRequest) GetTypeName() string {
+	if x != nil {
+		return x.TypeName
+	}
+	return ""
+}
+
+func (x *SubmitMetricRequest) GetResourceId() string {
+	if x != nil {
+		return x.ResourceId
+	}
+	return ""
+}
+
+func (x *SubmitMetricRequest) GetValue() float64 {
+	if x != nil {
+		return x.Value
+	}
+	return 0
+}
+
+func (x *SubmitMetricRequest) GetHistogram() *Histogram {
+	if x != nil {
+		return x.Histogram
+	}
+	return nil
+}
+
+type isSubmitMetricRequest_Target interface {
+	isSubmitMetricRequest_Target()
+}
+
+type SubmitMetricRequest_ServiceId struct {
+	ServiceId string `protobuf:"bytes,1,opt,name=service_id,json=serviceId,proto3,oneof"`
+}
+
+type SubmitMetricRequest_AgentInstanceId struct {
+	AgentInstanceId string `protobuf:"bytes,2,opt,name=agent_instance_id,json=agentInstanceId,proto3,oneof"`
+}
+
+func (*SubmitMetricRequest_ServiceId) isSubmitMetricRequest_Target() {}
+
+func (*SubmitMetricRequest_AgentInstanceId) isSubmitMetricRequest_Target() {}
+
+type SubmitMetricResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SubmitMetricResponse) Reset() {
+	*x = SubmitMetricResponse{}
+	mi := &file_fulcrum_agent_v1_agent_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SubmitMetricResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SubmitMetricResponse) ProtoMessage() {}
+
+func (x *SubmitMetricResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_fulcrum_agent_v1_agent_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SubmitMetricResponse.ProtoReflect.Descriptor instead.
+func (*SubmitMetricResponse) Descriptor() ([]byte, []int) {
+	return file_fulcrum_agent_v1_agent_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *SubmitMetricResponse) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+var File_fulcrum_agent_v1_agent_proto protoreflect.FileDescriptor
+
+const file_fulcrum_agent_v1_agent_proto_rawDesc = "" +
+	"\n" +
+	"\x1cfulcrum/agent/v1/agent.proto\x12\x10fulcrum.agent.v1\x1a\x1bgoogle/protobuf/empty.proto\x1a\x1cgoogle/protobuf/struct.proto\x1a\x1fgoogle/protobuf/timestamp.proto\"\xbb\x02\n" +
+	"\x05Agent\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12\x16\n" +
+	"\x06status\x18\x03 \x01(\tR\x06status\x12\x1a\n" +
+	"\bdraining\x18\x04 \x01(\bR\bdraining\x12\x1f\n" +
+	"\vprovider_id\x18\x05 \x01(\tR\n" +
+	"providerId\x12\"\n" +
+	"\ragent_type_id\x18\x06 \x01(\tR\vagentTypeId\x123\n" +
+	"\x13max_concurrent_jobs\x18\a \x01(\x05H\x00R\x11maxConcurrentJobs\x88\x01\x01\x12H\n" +
+	"\x12last_status_update\x18\b \x01(\v2\x1a.google.protobuf.TimestampR\x10lastStatusUpdateB\x16\n" +
+	"\x14_max_concurrent_jobs\"\x9a\x01\n" +
+	"\tTelemetry\x12\x1b\n" +
+	"\tcpu_usage\x18\x01 \x01(\x01R\bcpuUsage\x12\x1b\n" +
+	"\tmem_usage\x18\x02 \x01(\x01R\bmemUsage\x120\n" +
+	"\x14active_service_count\x18\x03 \x01(\x05R\x12activeServiceCount\x12!\n" +
+	"\fmax_services\x18\x04 \x01(\x05R\vmaxServices\"L\n" +
+	"\x0fRegisterRequest\x129\n" +
+	"\ttelemetry\x18\x01 \x01(\v2\x1b.fulcrum.agent.v1.TelemetryR\ttelemetry\"h\n" +
+	"\x13UpdateStatusRequest\x12\x16\n" +
+	"\x06status\x18\x01 \x01(\tR\x06status\x129\n" +
+	"\ttelemetry\x18\x02 \x01(\v2\x1b.fulcrum.agent.v1.TelemetryR\ttelemetry\"\xcf\x03\n" +
+	"\x03Job\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x1d\n" +
+	"\n" +
+	"service_id\x18\x02 \x01(\tR\tserviceId\x12\x19\n" +
+	"\bagent_id\x18\x03 \x01(\tR\aagentId\x12\x16\n" +
+	"\x06action\x18\x04 \x01(\tR\x06action\x12/\n" +
+	"\x06params\x18\x05 \x01(\v2\x17.google.protobuf.StructR\x06params\x12\x16\n" +
+	"\x06status\x18\x06 \x01(\tR\x06status\x12\x1a\n" +
+	"\bpriority\x18\a \x01(\x05R\bpriority\x12\x18\n" +
+	"\aattempt\x18\b \x01(\x05R\aattempt\x12\x1e\n" +
+	"\blease_id\x18\t \x01(\tH\x00R\aleaseId\x88\x01\x01\x12D\n" +
+	"\x10lease_expires_at\x18\n" +
+	" \x01(\v2\x1a.google.protobuf.TimestampR\x0eleaseExpiresAt\x129\n" +
+	"\n" +
+	"claimed_at\x18\v \x01(\v2\x1a.google.protobuf.TimestampR\tclaimedAt\x129\n" +
+	"\n" +
+	"created_at\x18\f \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAtB\v\n" +
+	"\t_lease_id\"\x12\n" +
+	"\x10WatchJobsRequest\"(\n" +
+	"\x0fClaimJobRequest\x12\x15\n" +
+	"\x06job_id\x18\x01 \x01(\tR\x05jobId\"H\n" +
+	"\x14RenewJobLeaseRequest\x12\x15\n" +
+	"\x06job_id\x18\x01 \x01(\tR\x05jobId\x12\x19\n" +
+	"\blease_id\x18\x02 \x01(\tR\aleaseId\"\xa1\x02\n" +
+	"\x12CompleteJobRequest\x12\x15\n" +
+	"\x06job_id\x18\x01 \x01(\tR\x05jobId\x12/\n" +
+	"\x11agent_instance_id\x18\x02 \x01(\tH\x00R\x0fagentInstanceId\x88\x01\x01\x12G\n" +
+	"\x13agent_instance_data\x18\x03 \x01(\v2\x17.google.protobuf.StructR\x11agentInstanceData\x127\n" +
+	"\n" +
+	"properties\x18\x04 \x01(\v2\x17.google.protobuf.StructR\n" +
+	"properties\x12\x1e\n" +
+	"\blease_id\x18\x05 \x01(\tH\x01R\aleaseId\x88\x01\x01B\x14\n" +
+	"\x12_agent_instance_idB\v\n" +
+	"\t_lease_id\"y\n" +
+	"\x0eFailJobRequest\x12\x15\n" +
+	"\x06job_id\x18\x01 \x01(\tR\x05jobId\x12#\n" +
+	"\rerror_message\x18\x02 \x01(\tR\ferrorMessage\x12\x1e\n" +
+	"\blease_id\x18\x03 \x01(\tH\x00R\aleaseId\x88\x01\x01B\v\n" +
+	"\t_lease_id\"M\n" +
+	"\tHistogram\x12\x16\n" +
+	"\x06bounds\x18\x01 \x03(\x01R\x06bounds\x12\x16\n" +
+	"\x06counts\x18\x02 \x03(\x03R\x06counts\x12\x10\n" +
+	"\x03sum\x18\x03 \x01(\x01R\x03sum\"\xfd\x01\n" +
+	"\x13SubmitMetricRequest\x12\x1f\n" +
+	"\n" +
+	"service_id\x18\x01 \x01(\tH\x00R\tserviceId\x12,\n" +
+	"\x11agent_instance_id\x18\x02 \x01(\tH\x00R\x0fagentInstanceId\x12\x1b\n" +
+	"\ttype_name\x18\x03 \x01(\tR\btypeName\x12\x1f\n" +
+	"\vresource_id\x18\x04 \x01(\tR\n" +
+	"resourceId\x12\x14\n" +
+	"\x05value\x18\x05 \x01(\x01R\x05value\x129\n" +
+	"\thistogram\x18\x06 \x01(\v2\x1b.fulcrum.agent.v1.HistogramR\thistogramB\b\n" +
+	"\x06target\"&\n" +
+	"\x14SubmitMetricResponse\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id2\xf7\x04\n" +
+	"\fAgentService\x12F\n" +
+	"\bRegister\x12!.fulcrum.agent.v1.RegisterRequest\x1a\x17.fulcrum.agent.v1.Agent\x12N\n" +
+	"\fUpdateStatus\x12%.fulcrum.agent.v1.UpdateStatusRequest\x1a\x17.fulcrum.agent.v1.Agent\x12H\n" +
+	"\tWatchJobs\x12\".fulcrum.agent.v1.WatchJobsRequest\x1a\x15.fulcrum.agent.v1.Job0\x01\x12D\n" +
+	"\bClaimJob\x12!.fulcrum.agent.v1.ClaimJobRequest\x1a\x15.fulcrum.agent.v1.Job\x12N\n" +
+	"\rRenewJobLease\x12&.fulcrum.agent.v1.RenewJobLeaseRequest\x1a\x15.fulcrum.agent.v1.Job\x12K\n" +
+	"\vCompleteJob\x12$.fulcrum.agent.v1.CompleteJobRequest\x1a\x16.google.protobuf.Empty\x12C\n" +
+	"\aFailJob\x12 .fulcrum.agent.v1.FailJobRequest\x1a\x16.google.protobuf.Empty\x12]\n" +
+	"\fSubmitMetric\x12%.fulcrum.agent.v1.SubmitMetricRequest\x1a&.fulcrum.agent.v1.SubmitMetricResponseB=Z;github.com/fulcrumproject/core/pkg/agentrpc/agentv1;agentv1b\x06proto3"
+
+var (
+	file_fulcrum_agent_v1_agent_proto_rawDescOnce sync.Once
+	file_fulcrum_agent_v1_agent_proto_rawDescData []byte
+)
+
+func file_fulcrum_agent_v1_agent_proto_rawDescGZIP() []byte {
+	file_fulcrum_agent_v1_agent_proto_rawDescOnce.Do(func() {
+		file_fulcrum_agent_v1_agent_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_fulcrum_agent_v1_agent_proto_rawDesc), len(file_fulcrum_agent_v1_agent_proto_rawDesc)))
+	})
+	return file_fulcrum_agent_v1_agent_proto_rawDescData
+}
+
+var file_fulcrum_agent_v1_agent_proto_msgTypes = make([]protoimpl.MessageInfo, 13)
+var file_fulcrum_agent_v1_agent_proto_goTypes = []any{
+	(*Agent)(nil),                 // 0: fulcrum.agent.v1.Agent
+	(*Telemetry)(nil),             // 1: fulcrum.agent.v1.Telemetry
+	(*RegisterRequest)(nil),       // 2: fulcrum.agent.v1.RegisterRequest
+	(*UpdateStatusRequest)(nil),   // 3: fulcrum.agent.v1.UpdateStatusRequest
+	(*Job)(nil),                   // 4: fulcrum.agent.v1.Job
+	(*WatchJobsRequest)(nil),      // 5: fulcrum.agent.v1.WatchJobsRequest
+	(*ClaimJobRequest)(nil),       // 6: fulcrum.agent.v1.ClaimJobRequest
+	(*RenewJobLeaseRequest)(nil),  // 7: fulcrum.agent.v1.RenewJobLeaseRequest
+	(*CompleteJobRequest)(nil),    // 8: fulcrum.agent.v1.CompleteJobRequest
+	(*FailJobRequest)(nil),        // 9: fulcrum.agent.v1.FailJobRequest
+	(*Histogram)(nil),             // 10: fulcrum.agent.v1.Histogram
+	(*SubmitMetricRequest)(nil),   // 11: fulcrum.agent.v1.SubmitMetricRequest
+	(*SubmitMetricResponse)(nil),  // 12: fulcrum.agent.v1.SubmitMetricResponse
+	(*timestamppb.Timestamp)(nil), // 13: google.protobuf.Timestamp
+	(*structpb.Struct)(nil),       // 14: google.protobuf.Struct
+	(*emptypb.Empty)(nil),         // 15: google.protobuf.Empty
+}
+var file_fulcrum_agent_v1_agent_proto_depIdxs = []int32{
+	13, // 0: fulcrum.agent.v1.Agent.last_status_update:type_name -> google.protobuf.Timestamp
+	1,  // 1: fulcrum.agent.v1.RegisterRequest.telemetry:type_name -> fulcrum.agent.v1.Telemetry
+	1,  // 2: fulcrum.agent.v1.UpdateStatusRequest.telemetry:type_name -> fulcrum.agent.v1.Telemetry
+	14, // 3: fulcrum.agent.v1.Job.params:type_name -> google.protobuf.Struct
+	13, // 4: fulcrum.agent.v1.Job.lease_expires_at:type_name -> google.protobuf.Timestamp
+	13, // 5: fulcrum.agent.v1.Job.claimed_at:type_name -> google.protobuf.Timestamp
+	13, // 6: fulcrum.agent.v1.Job.created_at:type_name -> google.protobuf.Timestamp
+	14, // 7: fulcrum.agent.v1.CompleteJobRequest.agent_instance_data:type_name -> google.protobuf.Struct
+	14, // 8: fulcrum.agent.v1.CompleteJobRequest.properties:type_name -> google.protobuf.Struct
+	10, // 9: fulcrum.agent.v1.SubmitMetricRequest.histogram:type_name -> fulcrum.agent.v1.Histogram
+	2,  // 10: fulcrum.agent.v1.AgentService.Register:input_type -> fulcrum.agent.v1.RegisterRequest
+	3,  // 11: fulcrum.agent.v1.AgentService.UpdateStatus:input_type -> fulcrum.agent.v1.UpdateStatusRequest
+	5,  // 12: fulcrum.agent.v1.AgentService.WatchJobs:input_type -> fulcrum.agent.v1.WatchJobsRequest
+	6,  // 13: fulcrum.agent.v1.AgentService.ClaimJob:input_type -> fulcrum.agent.v1.ClaimJobRequest
+	7,  // 14: fulcrum.agent.v1.AgentService.RenewJobLease:input_type -> fulcrum.agent.v1.RenewJobLeaseRequest
+	8,  // 15: fulcrum.agent.v1.AgentService.CompleteJob:input_type -> fulcrum.agent.v1.CompleteJobRequest
+	9,  // 16: fulcrum.agent.v1.AgentService.FailJob:input_type -> fulcrum.agent.v1.FailJobRequest
+	11, // 17: fulcrum.agent.v1.AgentService.SubmitMetric:input_type -> fulcrum.agent.v1.SubmitMetricRequest
+	0,  // 18: fulcrum.agent.v1.AgentService.Register:output_type -> fulcrum.agent.v1.Agent
+	0,  // 19: fulcrum.agent.v1.AgentService.UpdateStatus:output_type -> fulcrum.agent.v1.Agent
+	4,  // 20: fulcrum.agent.v1.AgentService.WatchJobs:output_type -> fulcrum.agent.v1.Job
+	4,  // 21: fulcrum.agent.v1.AgentService.ClaimJob:output_type -> fulcrum.agent.v1.Job
+	4,  // 22: fulcrum.agent.v1.AgentService.RenewJobLease:output_type -> fulcrum.agent.v1.Job
+	15, // 23: fulcrum.agent.v1.AgentService.CompleteJob:output_type -> google.protobuf.Empty
+	15, // 24: fulcrum.agent.v1.AgentService.FailJob:output_type -> google.protobuf.Empty
+	12, // 25: fulcrum.agent.v1.AgentService.SubmitMetric:output_type -> fulcrum.agent.v1.SubmitMetricResponse
+	18, // [18:26] is the sub-list for method output_type
+	10, // [10:18] is the sub-list for method input_type
+	10, // [10:10] is the sub-list for extension type_name
+	10, // [10:10] is the sub-list for extension extendee
+	0,  // [0:10] is the sub-list for field type_name
+}
+
+func init() { file_fulcrum_agent_v1_agent_proto_init() }
+func file_fulcrum_agent_v1_agent_proto_init() {
+	if File_fulcrum_agent_v1_agent_proto != nil {
+		return
+	}
+	file_fulcrum_agent_v1_agent_proto_msgTypes[0].OneofWrappers = []any{}
+	file_fulcrum_agent_v1_agent_proto_msgTypes[4].OneofWrappers = []any{}
+	file_fulcrum_agent_v1_agent_proto_msgTypes[8].OneofWrappers = []any{}
+	file_fulcrum_agent_v1_agent_proto_msgTypes[9].OneofWrappers = []any{}
+	file_fulcrum_agent_v1_agent_proto_msgTypes[11].OneofWrappers = []any{
+		(*SubmitMetricRequest_ServiceId)(nil),
+		(*SubmitMetricRequest_AgentInstanceId)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_fulcrum_agent_v1_agent_proto_rawDesc), len(file_fulcrum_agent_v1_agent_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   13,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_fulcrum_agent_v1_agent_proto_goTypes,
+		DependencyIndexes: file_fulcrum_agent_v1_agent_proto_depIdxs,
+		MessageInfos:      file_fulcrum_agent_v1_agent_proto_msgTypes,
+	}.Build()
+	File_fulcrum_agent_v1_agent_proto = out.File
+	file_fulcrum_agent_v1_agent_proto_goTypes = nil
+	file_fulcrum_agent_v1_agent_proto_depIdxs = nil
+}
@@ -0,0 +1,65 @@
+package agentrpc
+
+import (
+	"context"
+	"strings"
+
+	"github.com/fulcrumproject/core/pkg/auth"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// authenticate resolves the agent identity from the bearer token of the authorization metadata
+// The token is checked by the same authenticator as the REST API, only agents can use the protocol
+func authenticate(ctx context.Context, authenticator auth.Authenticator) (context.Context, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get("authorization")
+	if len(values) == 0 || !strings.HasPrefix(values[0], "Bearer ") {
+		return nil, status.Error(codes.Unauthenticated, "invalid token format, expected 'Bearer <token>'")
+	}
+	identity, err := authenticator.Authenticate(ctx, strings.TrimPrefix(values[0], "Bearer "))
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+	if identity == nil {
+		return nil, status.Error(codes.Unauthenticated, "identity not found")
+	}
+	if !identity.HasRole(auth.RoleAgent) || identity.Scope.AgentID == nil {
+		return nil, status.Errorf(codes.PermissionDenied, "access denied: user role '%s' is not authorized", identity.Role)
+	}
+	return auth.WithIdentity(ctx, identity), nil
+}
+
+// UnaryAuthInterceptor authenticates the agent of each unary call
+func UnaryAuthInterceptor(authenticator auth.Authenticator) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		ctx, err := authenticate(ctx, authenticator)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamAuthInterceptor authenticates the agent of each streaming call
+func StreamAuthInterceptor(authenticator auth.Authenticator) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := authenticate(ss.Context(), authenticator)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &contextStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+// contextStream carries the context set by the interceptors, e.g. holding the identity of the agent
+type contextStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *contextStream) Context() context.Context {
+	return s.ctx
+}
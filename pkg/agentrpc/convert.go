@@ -0,0 +1,116 @@
+package agentrpc
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/fulcrumproject/core/pkg/agentrpc/agentv1"
+	"github.com/fulcrumproject/core/pkg/domain"
+	"github.com/fulcrumproject/core/pkg/properties"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// agentToProto converts an agent to its protocol message
+func agentToProto(a *domain.Agent) *agentv1.Agent {
+	msg := &agentv1.Agent{
+		Id:               a.ID.String(),
+		Name:             a.Name,
+		Status:           string(a.Status),
+		Draining:         a.Draining,
+		ProviderId:       a.ProviderID.String(),
+		AgentTypeId:      a.AgentTypeID.String(),
+		LastStatusUpdate: timestamppb.New(a.LastStatusUpdate),
+	}
+	if a.MaxConcurrentJobs != nil {
+		limit := int32(*a.MaxConcurrentJobs)
+		msg.MaxConcurrentJobs = &limit
+	}
+	return msg
+}
+
+// jobToProto converts a job to its protocol message
+func jobToProto(j *domain.Job) (*agentv1.Job, error) {
+	msg := &agentv1.Job{
+		Id:             j.ID.String(),
+		ServiceId:      j.ServiceID.String(),
+		AgentId:        j.AgentID.String(),
+		Action:         j.Action,
+		Status:         string(j.Status),
+		Priority:       int32(j.Priority),
+		Attempt:        int32(j.Attempt),
+		LeaseExpiresAt: timestampToProto(j.LeaseExpiresAt),
+		ClaimedAt:      timestampToProto(j.ClaimedAt),
+		CreatedAt:      timestamppb.New(j.CreatedAt),
+	}
+	if j.LeaseID != nil {
+		leaseID := j.LeaseID.String()
+		msg.LeaseId = &leaseID
+	}
+	if j.Params != nil {
+		params, err := structpb.NewStruct(*j.Params)
+		if err != nil {
+			return nil, fmt.Errorf("invalid params of job %s: %w", j.ID, err)
+		}
+		msg.Params = params
+	}
+	return msg, nil
+}
+
+func timestampToProto(t *time.Time) *timestamppb.Timestamp {
+	if t == nil {
+		return nil
+	}
+	return timestamppb.New(*t)
+}
+
+// telemetryFromProto converts the reported telemetry, nil when not reported
+func telemetryFromProto(t *agentv1.Telemetry) *domain.AgentTelemetry {
+	if t == nil {
+		return nil
+	}
+	return &domain.AgentTelemetry{
+		CPUUsage:           t.CpuUsage,
+		MemUsage:           t.MemUsage,
+		ActiveServiceCount: int(t.ActiveServiceCount),
+		MaxServices:        int(t.MaxServices),
+	}
+}
+
+// histogramFromProto converts the reported histogram, nil when not reported
+func histogramFromProto(h *agentv1.Histogram) *domain.Histogram {
+	if h == nil {
+		return nil
+	}
+	return &domain.Histogram{Bounds: h.Bounds, Counts: h.Counts, Sum: h.Sum}
+}
+
+// jsonFromProto converts a struct to properties, nil when not set
+func jsonFromProto(s *structpb.Struct) *properties.JSON {
+	if s == nil {
+		return nil
+	}
+	value := properties.JSON(s.AsMap())
+	return &value
+}
+
+// parseUUID parses an identifier of a request
+func parseUUID(field, value string) (properties.UUID, error) {
+	id, err := properties.ParseUUID(value)
+	if err != nil {
+		return id, domain.NewInvalidInputErrorf("invalid %s: %s", field, value)
+	}
+	return id, nil
+}
+
+// parseOptionalUUID parses an optional identifier of a request
+func parseOptionalUUID(field string, value *string) (*properties.UUID, error) {
+	if value == nil {
+		return nil, nil
+	}
+	id, err := parseUUID(field, *value)
+	if err != nil {
+		return nil, err
+	}
+	return &id, nil
+}
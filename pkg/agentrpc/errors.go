@@ -0,0 +1,45 @@
+package agentrpc
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"github.com/fulcrumproject/core/pkg/domain"
+	"github.com/fulcrumproject/core/pkg/schema"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// statusFromDomain maps the domain errors to the gRPC status codes, as the REST API maps them to HTTP statuses
+func statusFromDomain(ctx context.Context, err error) error {
+	slog.ErrorContext(ctx, "gRPC domain error", "error", err)
+	if errors.As(err, &domain.PoolExhaustedError{}) {
+		return status.Error(codes.ResourceExhausted, err.Error())
+	}
+	if errors.As(err, &domain.AgentQuotaExceededError{}) {
+		return status.Error(codes.ResourceExhausted, err.Error())
+	}
+	if errors.As(err, &domain.SecretBackendUnavailableError{}) {
+		return status.Error(codes.Unavailable, err.Error())
+	}
+	if errors.As(err, &schema.ValidationError{}) {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	if errors.As(err, &domain.InvalidInputError{}) {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	if errors.As(err, &domain.NotFoundError{}) {
+		return status.Error(codes.NotFound, err.Error())
+	}
+	if errors.As(err, &domain.UnauthorizedError{}) {
+		return status.Error(codes.PermissionDenied, err.Error())
+	}
+	if errors.As(err, &domain.ConflictError{}) {
+		return status.Error(codes.Aborted, err.Error())
+	}
+	if errors.As(err, &domain.PreconditionFailedError{}) {
+		return status.Error(codes.FailedPrecondition, err.Error())
+	}
+	return status.Error(codes.Internal, err.Error())
+}
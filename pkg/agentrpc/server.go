@@ -0,0 +1,307 @@
+// Package agentrpc serves the agent protocol over gRPC
+//
+// The server is a thin adapter over the domain commanders and queriers also used
+// by the REST API, each call is authenticated and authorized as its REST route.
+package agentrpc
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/fulcrumproject/core/pkg/agentrpc/agentv1"
+	"github.com/fulcrumproject/core/pkg/auth"
+	"github.com/fulcrumproject/core/pkg/authz"
+	"github.com/fulcrumproject/core/pkg/domain"
+	"github.com/fulcrumproject/core/pkg/properties"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// watchJobsLimit caps the jobs read on each poll of a job feed, the free job slots of the agent cap them further
+const watchJobsLimit = 100
+
+// Server implements the gRPC agent service
+type Server struct {
+	agentv1.UnimplementedAgentServiceServer
+
+	agentCommander  domain.AgentCommander
+	jobQuerier      domain.JobQuerier
+	jobCommander    domain.JobCommander
+	serviceQuerier  domain.ServiceQuerier
+	metricCommander domain.MetricEntryCommander
+	authz           authz.Authorizer
+	jobFeedInterval time.Duration
+	feedsDone       chan struct{}
+	closeFeedsOnce  sync.Once
+}
+
+// NewServer creates the agent service, job feeds look for new pending jobs every jobFeedInterval
+func NewServer(
+	agentCommander domain.AgentCommander,
+	jobQuerier domain.JobQuerier,
+	jobCommander domain.JobCommander,
+	serviceQuerier domain.ServiceQuerier,
+	metricCommander domain.MetricEntryCommander,
+	authorizer authz.Authorizer,
+	jobFeedInterval time.Duration,
+) *Server {
+	return &Server{
+		agentCommander:  agentCommander,
+		jobQuerier:      jobQuerier,
+		jobCommander:    jobCommander,
+		serviceQuerier:  serviceQuerier,
+		metricCommander: metricCommander,
+		authz:           authorizer,
+		jobFeedInterval: jobFeedInterval,
+		feedsDone:       make(chan struct{}),
+	}
+}
+
+// NewGRPCServer creates a gRPC server authenticating the agents and serving the agent service
+func NewGRPCServer(server *Server, authenticator auth.Authenticator, opts ...grpc.ServerOption) *grpc.Server {
+	opts = append(opts,
+		grpc.ChainUnaryInterceptor(UnaryRequestIDInterceptor(), UnaryAuthInterceptor(authenticator)),
+		grpc.ChainStreamInterceptor(StreamRequestIDInterceptor(), StreamAuthInterceptor(authenticator)),
+	)
+	s := grpc.NewServer(opts...)
+	agentv1.RegisterAgentServiceServer(s, server)
+	return s
+}
+
+func (s *Server) Register(ctx context.Context, req *agentv1.RegisterRequest) (*agentv1.Agent, error) {
+	return s.updateStatus(ctx, domain.AgentConnected, req.Telemetry)
+}
+
+func (s *Server) UpdateStatus(ctx context.Context, req *agentv1.UpdateStatusRequest) (*agentv1.Agent, error) {
+	return s.updateStatus(ctx, domain.AgentStatus(req.Status), req.Telemetry)
+}
+
+func (s *Server) updateStatus(ctx context.Context, agentStatus domain.AgentStatus, telemetry *agentv1.Telemetry) (*agentv1.Agent, error) {
+	agent, err := s.agentCommander.UpdateStatus(ctx, domain.UpdateAgentStatusParams{
+		ID:        agentID(ctx),
+		Status:    agentStatus,
+		Telemetry: telemetryFromProto(telemetry),
+	})
+	if err != nil {
+		return nil, statusFromDomain(ctx, err)
+	}
+	return agentToProto(agent), nil
+}
+
+// WatchJobs offers the pending jobs of the agent until the stream is closed
+// Each poll reads at most the free job slots of the agent, a job is offered again
+// only once it stopped being pending, e.g. after a reclaim or a requeue.
+func (s *Server) WatchJobs(_ *agentv1.WatchJobsRequest, stream grpc.ServerStreamingServer[agentv1.Job]) error {
+	ctx := stream.Context()
+	if err := s.authorize(ctx, authz.ObjectTypeJob, authz.ActionListPending, &authz.AllwaysMatchObjectScope{}); err != nil {
+		return err
+	}
+	id := agentID(ctx)
+
+	ticker := time.NewTicker(s.jobFeedInterval)
+	defer ticker.Stop()
+	offered := make(map[properties.UUID]bool)
+	for {
+		jobs, err := s.jobQuerier.GetPendingJobsForAgent(ctx, id, watchJobsLimit)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return statusFromDomain(ctx, err)
+		}
+		pending := make(map[properties.UUID]bool, len(jobs))
+		for _, job := range jobs {
+			pending[job.ID] = true
+			if offered[job.ID] {
+				continue
+			}
+			msg, err := jobToProto(job)
+			if err != nil {
+				return statusFromDomain(ctx, err)
+			}
+			if err := stream.Send(msg); err != nil {
+				return err
+			}
+		}
+		offered = pending
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-s.feedsDone:
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// CloseFeeds ends the open job feeds, it is meant to be called when the server shuts down
+func (s *Server) CloseFeeds() {
+	s.closeFeedsOnce.Do(func() {
+		close(s.feedsDone)
+	})
+}
+
+func (s *Server) ClaimJob(ctx context.Context, req *agentv1.ClaimJobRequest) (*agentv1.Job, error) {
+	id, err := s.authorizeJob(ctx, req.JobId, authz.ActionClaim)
+	if err != nil {
+		return nil, err
+	}
+	job, err := s.jobCommander.Claim(ctx, id)
+	if err != nil {
+		return nil, statusFromDomain(ctx, err)
+	}
+	return s.jobResponse(ctx, job)
+}
+
+func (s *Server) RenewJobLease(ctx context.Context, req *agentv1.RenewJobLeaseRequest) (*agentv1.Job, error) {
+	id, err := s.authorizeJob(ctx, req.JobId, authz.ActionRenew)
+	if err != nil {
+		return nil, err
+	}
+	leaseID, err := parseUUID("lease_id", req.LeaseId)
+	if err != nil {
+		return nil, statusFromDomain(ctx, err)
+	}
+	job, err := s.jobCommander.RenewLease(ctx, domain.RenewJobLeaseParams{JobID: id, LeaseID: leaseID})
+	if err != nil {
+		return nil, statusFromDomain(ctx, err)
+	}
+	return s.jobResponse(ctx, job)
+}
+
+func (s *Server) CompleteJob(ctx context.Context, req *agentv1.CompleteJobRequest) (*emptypb.Empty, error) {
+	id, err := s.authorizeJob(ctx, req.JobId, authz.ActionComplete)
+	if err != nil {
+		return nil, err
+	}
+	leaseID, err := parseOptionalUUID("lease_id", req.LeaseId)
+	if err != nil {
+		return nil, statusFromDomain(ctx, err)
+	}
+	var props map[string]any
+	if req.Properties != nil {
+		props = req.Properties.AsMap()
+	}
+	err = s.jobCommander.Complete(ctx, domain.CompleteJobParams{
+		JobID:             id,
+		AgentInstanceData: jsonFromProto(req.AgentInstanceData),
+		AgentInstanceID:   req.AgentInstanceId,
+		Properties:        props,
+		LeaseID:           leaseID,
+	})
+	if err != nil {
+		return nil, statusFromDomain(ctx, err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (s *Server) FailJob(ctx context.Context, req *agentv1.FailJobRequest) (*emptypb.Empty, error) {
+	id, err := s.authorizeJob(ctx, req.JobId, authz.ActionFail)
+	if err != nil {
+		return nil, err
+	}
+	leaseID, err := parseOptionalUUID("lease_id", req.LeaseId)
+	if err != nil {
+		return nil, statusFromDomain(ctx, err)
+	}
+	err = s.jobCommander.Fail(ctx, domain.FailJobParams{
+		JobID:        id,
+		ErrorMessage: req.ErrorMessage,
+		LeaseID:      leaseID,
+	})
+	if err != nil {
+		return nil, statusFromDomain(ctx, err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+// SubmitMetric records a metric entry of a service of the agent, given by ID or by instance ID
+func (s *Server) SubmitMetric(ctx context.Context, req *agentv1.SubmitMetricRequest) (*agentv1.SubmitMetricResponse, error) {
+	if err := s.authorize(ctx, authz.ObjectTypeMetricEntry, authz.ActionCreate, &authz.AllwaysMatchObjectScope{}); err != nil {
+		return nil, err
+	}
+	id := agentID(ctx)
+
+	var (
+		entry *domain.MetricEntry
+		err   error
+	)
+	switch target := req.Target.(type) {
+	case *agentv1.SubmitMetricRequest_ServiceId:
+		serviceID, err := parseUUID("service_id", target.ServiceId)
+		if err != nil {
+			return nil, statusFromDomain(ctx, err)
+		}
+		service, err := s.serviceQuerier.Get(ctx, serviceID)
+		if err != nil {
+			return nil, statusFromDomain(ctx, err)
+		}
+		if service.AgentID != id {
+			return nil, status.Errorf(codes.PermissionDenied, "service %s is not assigned to the agent", serviceID)
+		}
+		entry, err = s.metricCommander.Create(ctx, domain.CreateMetricEntryParams{
+			TypeName:   req.TypeName,
+			AgentID:    id,
+			ServiceID:  serviceID,
+			ResourceID: req.ResourceId,
+			Value:      req.Value,
+			Histogram:  histogramFromProto(req.Histogram),
+		})
+		if err != nil {
+			return nil, statusFromDomain(ctx, err)
+		}
+	case *agentv1.SubmitMetricRequest_AgentInstanceId:
+		entry, err = s.metricCommander.CreateWithAgentInstanceID(ctx, domain.CreateMetricEntryWithAgentInstanceIDParams{
+			TypeName:        req.TypeName,
+			AgentID:         id,
+			AgentInstanceID: target.AgentInstanceId,
+			ResourceID:      req.ResourceId,
+			Value:           req.Value,
+			Histogram:       histogramFromProto(req.Histogram),
+		})
+		if err != nil {
+			return nil, statusFromDomain(ctx, err)
+		}
+	default:
+		return nil, status.Error(codes.InvalidArgument, "service_id or agent_instance_id is required")
+	}
+	return &agentv1.SubmitMetricResponse{Id: entry.ID.String()}, nil
+}
+
+// authorize checks that the agent is granted the action on the object, as the REST authorization middlewares
+func (s *Server) authorize(ctx context.Context, object authz.ObjectType, action authz.Action, scope authz.ObjectScope) error {
+	if err := s.authz.Authorize(auth.MustGetIdentity(ctx), action, object, scope); err != nil {
+		return status.Error(codes.PermissionDenied, err.Error())
+	}
+	return nil
+}
+
+// authorizeJob parses the job ID and authorizes the action from the scope of the job
+func (s *Server) authorizeJob(ctx context.Context, jobID string, action authz.Action) (properties.UUID, error) {
+	id, err := parseUUID("job_id", jobID)
+	if err != nil {
+		return id, statusFromDomain(ctx, err)
+	}
+	scope, err := s.jobQuerier.AuthScope(ctx, id)
+	if err != nil {
+		return id, status.Errorf(codes.PermissionDenied, "cannot load resource: %v", err)
+	}
+	return id, s.authorize(ctx, authz.ObjectTypeJob, action, scope)
+}
+
+func (s *Server) jobResponse(ctx context.Context, job *domain.Job) (*agentv1.Job, error) {
+	msg, err := jobToProto(job)
+	if err != nil {
+		return nil, statusFromDomain(ctx, err)
+	}
+	return msg, nil
+}
+
+// agentID returns the ID of the authenticated agent, the interceptors only let agents through
+func agentID(ctx context.Context) properties.UUID {
+	return *auth.MustGetIdentity(ctx).Scope.AgentID
+}
@@ -0,0 +1,279 @@
+package agentrpc
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/fulcrumproject/core/pkg/agentrpc/agentv1"
+	"github.com/fulcrumproject/core/pkg/auth"
+	"github.com/fulcrumproject/core/pkg/authz"
+	"github.com/fulcrumproject/core/pkg/domain"
+	"github.com/fulcrumproject/core/pkg/properties"
+	"github.com/fulcrumproject/core/pkg/tracing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+type testMocks struct {
+	authenticator  *auth.MockAuthenticator
+	agentCommander *domain.MockAgentCommander
+	jobQuerier     *domain.MockJobQuerier
+	jobCommander   *domain.MockJobCommander
+	serviceQuerier *domain.MockServiceQuerier
+	metricCmd      *domain.MockMetricEntryCommander
+}
+
+// setupClient serves the agent service in memory and returns a client authenticated as the agent
+func setupClient(t *testing.T, agentID properties.UUID) (agentv1.AgentServiceClient, *testMocks, context.Context) {
+	m := &testMocks{
+		authenticator:  auth.NewMockAuthenticator(t),
+		agentCommander: domain.NewMockAgentCommander(t),
+		jobQuerier:     domain.NewMockJobQuerier(t),
+		jobCommander:   domain.NewMockJobCommander(t),
+		serviceQuerier: domain.NewMockServiceQuerier(t),
+		metricCmd:      domain.NewMockMetricEntryCommander(t),
+	}
+	identity := &auth.Identity{ID: properties.NewUUID(), Role: auth.RoleAgent, Scope: auth.IdentityScope{AgentID: &agentID}}
+	m.authenticator.EXPECT().Authenticate(mock.Anything, "agent-token").Return(identity, nil).Maybe()
+
+	server := NewServer(m.agentCommander, m.jobQuerier, m.jobCommander, m.serviceQuerier, m.metricCmd,
+		authz.NewRuleBasedAuthorizer(authz.Rules), 10*time.Millisecond)
+	grpcServer := NewGRPCServer(server, m.authenticator)
+	listener := bufconn.Listen(1024 * 1024)
+	go grpcServer.Serve(listener)
+	t.Cleanup(func() {
+		server.CloseFeeds()
+		grpcServer.Stop()
+	})
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer agent-token")
+	return agentv1.NewAgentServiceClient(conn), m, ctx
+}
+
+func TestAuthentication(t *testing.T) {
+	agentID := properties.NewUUID()
+	participantID := properties.NewUUID()
+
+	tests := []struct {
+		name         string
+		setup        func(m *testMocks)
+		header       string
+		expectedCode codes.Code
+	}{
+		{name: "Missing token", expectedCode: codes.Unauthenticated},
+		{name: "Malformed token", header: "Basic abc", expectedCode: codes.Unauthenticated},
+		{
+			name:   "Invalid token",
+			header: "Bearer invalid",
+			setup: func(m *testMocks) {
+				m.authenticator.EXPECT().Authenticate(mock.Anything, "invalid").Return(nil, assert.AnError)
+			},
+			expectedCode: codes.Unauthenticated,
+		},
+		{
+			name:   "Not an agent",
+			header: "Bearer participant-token",
+			setup: func(m *testMocks) {
+				m.authenticator.EXPECT().Authenticate(mock.Anything, "participant-token").
+					Return(&auth.Identity{Role: auth.RoleParticipant, Scope: auth.IdentityScope{ParticipantID: &participantID}}, nil)
+			},
+			expectedCode: codes.PermissionDenied,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			client, m, _ := setupClient(t, agentID)
+			if tc.setup != nil {
+				tc.setup(m)
+			}
+			ctx := context.Background()
+			if tc.header != "" {
+				ctx = metadata.AppendToOutgoingContext(ctx, "authorization", tc.header)
+			}
+
+			_, err := client.Register(ctx, &agentv1.RegisterRequest{})
+			assert.Equal(t, tc.expectedCode, status.Code(err))
+
+			// Streams are authenticated as well
+			stream, err := client.WatchJobs(ctx, &agentv1.WatchJobsRequest{})
+			require.NoError(t, err)
+			_, err = stream.Recv()
+			assert.Equal(t, tc.expectedCode, status.Code(err))
+		})
+	}
+}
+
+func TestRegister(t *testing.T) {
+	agentID := properties.NewUUID()
+	client, m, ctx := setupClient(t, agentID)
+	m.agentCommander.EXPECT().UpdateStatus(mock.Anything, domain.UpdateAgentStatusParams{
+		ID:        agentID,
+		Status:    domain.AgentConnected,
+		Telemetry: &domain.AgentTelemetry{CPUUsage: 10, MaxServices: 5},
+	}).Return(&domain.Agent{BaseEntity: domain.BaseEntity{ID: agentID}, Status: domain.AgentConnected}, nil)
+
+	agent, err := client.Register(ctx, &agentv1.RegisterRequest{Telemetry: &agentv1.Telemetry{CpuUsage: 10, MaxServices: 5}})
+	require.NoError(t, err)
+	assert.Equal(t, agentID.String(), agent.Id)
+	assert.Equal(t, string(domain.AgentConnected), agent.Status)
+}
+
+func TestRequestID(t *testing.T) {
+	agentID := properties.NewUUID()
+	client, m, ctx := setupClient(t, agentID)
+	m.agentCommander.EXPECT().UpdateStatus(mock.MatchedBy(func(ctx context.Context) bool {
+		return tracing.RequestID(ctx) == "req-42"
+	}), mock.Anything).Return(&domain.Agent{BaseEntity: domain.BaseEntity{ID: agentID}}, nil)
+
+	var header metadata.MD
+	ctx = metadata.AppendToOutgoingContext(ctx, "x-request-id", "req-42")
+	_, err := client.Register(ctx, &agentv1.RegisterRequest{}, grpc.Header(&header))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"req-42"}, header.Get(tracing.HeaderRequestID))
+}
+
+func TestWatchJobs(t *testing.T) {
+	agentID := properties.NewUUID()
+	client, m, ctx := setupClient(t, agentID)
+	first := &domain.Job{BaseEntity: domain.BaseEntity{ID: properties.NewUUID()}, AgentID: agentID, Action: "create", Status: domain.JobPending}
+	second := &domain.Job{BaseEntity: domain.BaseEntity{ID: properties.NewUUID()}, AgentID: agentID, Action: "start", Status: domain.JobPending,
+		Params: &properties.JSON{"size": "large"}}
+
+	// The repository caps the pending jobs to the free job slots, a job still pending is offered once
+	m.jobQuerier.EXPECT().GetPendingJobsForAgent(mock.Anything, agentID, watchJobsLimit).Return([]*domain.Job{first}, nil).Twice()
+	m.jobQuerier.EXPECT().GetPendingJobsForAgent(mock.Anything, agentID, watchJobsLimit).Return([]*domain.Job{first, second}, nil)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stream, err := client.WatchJobs(ctx, &agentv1.WatchJobsRequest{})
+	require.NoError(t, err)
+
+	job, err := stream.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, first.ID.String(), job.Id)
+
+	job, err = stream.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, second.ID.String(), job.Id)
+	assert.Equal(t, "large", job.Params.AsMap()["size"])
+}
+
+func TestJobReports(t *testing.T) {
+	agentID := properties.NewUUID()
+	jobID := properties.NewUUID()
+	leaseID := properties.NewUUID()
+	scope := &authz.DefaultObjectScope{AgentID: &agentID}
+
+	t.Run("Claim", func(t *testing.T) {
+		client, m, ctx := setupClient(t, agentID)
+		m.jobQuerier.EXPECT().AuthScope(mock.Anything, jobID).Return(scope, nil)
+		m.jobCommander.EXPECT().Claim(mock.Anything, jobID).
+			Return(&domain.Job{BaseEntity: domain.BaseEntity{ID: jobID}, Status: domain.JobProcessing, LeaseID: &leaseID}, nil)
+
+		job, err := client.ClaimJob(ctx, &agentv1.ClaimJobRequest{JobId: jobID.String()})
+		require.NoError(t, err)
+		assert.Equal(t, leaseID.String(), job.GetLeaseId())
+	})
+
+	t.Run("Job of another agent", func(t *testing.T) {
+		client, m, ctx := setupClient(t, agentID)
+		otherAgentID := properties.NewUUID()
+		m.jobQuerier.EXPECT().AuthScope(mock.Anything, jobID).Return(&authz.DefaultObjectScope{AgentID: &otherAgentID}, nil)
+
+		_, err := client.ClaimJob(ctx, &agentv1.ClaimJobRequest{JobId: jobID.String()})
+		assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	})
+
+	t.Run("Invalid job ID", func(t *testing.T) {
+		client, _, ctx := setupClient(t, agentID)
+
+		_, err := client.ClaimJob(ctx, &agentv1.ClaimJobRequest{JobId: "not-a-uuid"})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+
+	t.Run("Complete with a stale lease", func(t *testing.T) {
+		client, m, ctx := setupClient(t, agentID)
+		m.jobQuerier.EXPECT().AuthScope(mock.Anything, jobID).Return(scope, nil)
+		instanceID := "vm-1"
+		m.jobCommander.EXPECT().Complete(mock.Anything, domain.CompleteJobParams{
+			JobID:           jobID,
+			AgentInstanceID: &instanceID,
+			LeaseID:         &leaseID,
+		}).Return(domain.NewConflictErrorf("lease no longer held"))
+
+		lease := leaseID.String()
+		_, err := client.CompleteJob(ctx, &agentv1.CompleteJobRequest{JobId: jobID.String(), AgentInstanceId: &instanceID, LeaseId: &lease})
+		assert.Equal(t, codes.Aborted, status.Code(err))
+	})
+
+	t.Run("Fail", func(t *testing.T) {
+		client, m, ctx := setupClient(t, agentID)
+		m.jobQuerier.EXPECT().AuthScope(mock.Anything, jobID).Return(scope, nil)
+		m.jobCommander.EXPECT().Fail(mock.Anything, domain.FailJobParams{JobID: jobID, ErrorMessage: "quota exceeded"}).Return(nil)
+
+		_, err := client.FailJob(ctx, &agentv1.FailJobRequest{JobId: jobID.String(), ErrorMessage: "quota exceeded"})
+		require.NoError(t, err)
+	})
+}
+
+func TestSubmitMetric(t *testing.T) {
+	agentID := properties.NewUUID()
+	serviceID := properties.NewUUID()
+
+	t.Run("By instance ID", func(t *testing.T) {
+		client, m, ctx := setupClient(t, agentID)
+		entryID := properties.NewUUID()
+		m.metricCmd.EXPECT().CreateWithAgentInstanceID(mock.Anything, domain.CreateMetricEntryWithAgentInstanceIDParams{
+			TypeName:        "cpu",
+			AgentID:         agentID,
+			AgentInstanceID: "vm-1",
+			ResourceID:      "disk-0",
+			Value:           42,
+		}).Return(&domain.MetricEntry{ID: entryID}, nil)
+
+		res, err := client.SubmitMetric(ctx, &agentv1.SubmitMetricRequest{
+			Target:     &agentv1.SubmitMetricRequest_AgentInstanceId{AgentInstanceId: "vm-1"},
+			TypeName:   "cpu",
+			ResourceId: "disk-0",
+			Value:      42,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, entryID.String(), res.Id)
+	})
+
+	t.Run("Service of another agent", func(t *testing.T) {
+		client, m, ctx := setupClient(t, agentID)
+		m.serviceQuerier.EXPECT().Get(mock.Anything, serviceID).
+			Return(&domain.Service{BaseEntity: domain.BaseEntity{ID: serviceID}, AgentID: properties.NewUUID()}, nil)
+
+		_, err := client.SubmitMetric(ctx, &agentv1.SubmitMetricRequest{
+			Target:   &agentv1.SubmitMetricRequest_ServiceId{ServiceId: serviceID.String()},
+			TypeName: "cpu",
+		})
+		assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	})
+
+	t.Run("Missing target", func(t *testing.T) {
+		client, _, ctx := setupClient(t, agentID)
+
+		_, err := client.SubmitMetric(ctx, &agentv1.SubmitMetricRequest{TypeName: "cpu"})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+}
@@ -0,0 +1,49 @@
+package agentrpc
+
+import (
+	"context"
+	"strings"
+
+	"github.com/fulcrumproject/core/pkg/tracing"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// requestID resolves the request ID of a call from its metadata as the REST API does from the headers,
+// and returns the context carrying it with the header echoing it to the agent
+func requestID(ctx context.Context) (context.Context, metadata.MD) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	id := tracing.ResolveRequestID(firstMetadata(md, tracing.HeaderRequestID), firstMetadata(md, tracing.HeaderTraceParent))
+	return tracing.WithRequestID(ctx, id), metadata.Pairs(tracing.HeaderRequestID, id)
+}
+
+// firstMetadata returns the first value of a metadata key, empty when missing
+func firstMetadata(md metadata.MD, key string) string {
+	values := md.Get(strings.ToLower(key))
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+// UnaryRequestIDInterceptor identifies each unary call with a request ID
+func UnaryRequestIDInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		ctx, header := requestID(ctx)
+		if err := grpc.SetHeader(ctx, header); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamRequestIDInterceptor identifies each streaming call with a request ID
+func StreamRequestIDInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, header := requestID(ss.Context())
+		if err := ss.SetHeader(header); err != nil {
+			return err
+		}
+		return handler(srv, &contextStream{ServerStream: ss, ctx: ctx})
+	}
+}
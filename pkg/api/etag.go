@@ -0,0 +1,53 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/fulcrumproject/core/pkg/domain"
+	"github.com/fulcrumproject/core/pkg/properties"
+)
+
+const (
+	headerETag    = "ETag"
+	headerIfMatch = "If-Match"
+)
+
+// versioned is implemented by entities embedding domain.BaseEntity
+type versioned interface {
+	GetVersion() int
+}
+
+// formatETag returns the entity tag of a version
+func formatETag(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+// setETag sets the ETag header from the entity version, entities without version are skipped
+func setETag(w http.ResponseWriter, entity any) {
+	if v, ok := entity.(versioned); ok {
+		w.Header().Set(headerETag, formatETag(v.GetVersion()))
+	}
+}
+
+// ifMatchContext returns a context requiring the entity to match the If-Match header when saved
+// The header must be a single entity tag as returned in ETag, "*" or no header skip the check
+func ifMatchContext(r *http.Request, id properties.UUID) (context.Context, error) {
+	value := strings.TrimSpace(r.Header.Get(headerIfMatch))
+	if value == "" || value == "*" {
+		return r.Context(), nil
+	}
+	tag := strings.TrimPrefix(value, "W/")
+	unquoted, err := strconv.Unquote(tag)
+	if err != nil || !strings.HasPrefix(tag, `"`) {
+		return nil, fmt.Errorf("invalid If-Match header: %s", value)
+	}
+	version, err := strconv.Atoi(unquoted)
+	if err != nil {
+		return nil, fmt.Errorf("invalid If-Match header: %s", value)
+	}
+	return domain.WithExpectedVersion(r.Context(), id, version), nil
+}
@@ -0,0 +1,125 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/fulcrumproject/core/pkg/domain"
+	"github.com/fulcrumproject/core/pkg/middlewares"
+	"github.com/fulcrumproject/core/pkg/properties"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIfMatchContext(t *testing.T) {
+	id := properties.NewUUID()
+
+	tests := []struct {
+		name            string
+		header          string
+		expectedVersion int
+		expectedCheck   bool
+		expectedError   bool
+	}{
+		{name: "No header", header: ""},
+		{name: "Any version", header: "*"},
+		{name: "Strong tag", header: `"3"`, expectedVersion: 3, expectedCheck: true},
+		{name: "Weak tag", header: `W/"4"`, expectedVersion: 4, expectedCheck: true},
+		{name: "Unquoted tag", header: "3", expectedError: true},
+		{name: "Not a version", header: `"abc"`, expectedError: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest("PATCH", "/test", nil)
+			if tc.header != "" {
+				req.Header.Set(headerIfMatch, tc.header)
+			}
+
+			ctx, err := ifMatchContext(req, id)
+			if tc.expectedError {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			version, check := domain.ExpectedVersion(ctx, id)
+			assert.Equal(t, tc.expectedCheck, check)
+			assert.Equal(t, tc.expectedVersion, version)
+		})
+	}
+}
+
+func TestUpdateIfMatch(t *testing.T) {
+	id := properties.NewUUID()
+
+	newRouter := func(update func(context.Context, properties.UUID, *UpdateServiceGroupReq) (*domain.ServiceGroup, error)) http.Handler {
+		r := chi.NewRouter()
+		r.With(middlewares.ID, middlewares.DecodeBody[UpdateServiceGroupReq]()).
+			Patch("/{id}", Update(update, ServiceGroupToRes))
+		r.With(middlewares.ID).
+			Get("/{id}", Get(func(ctx context.Context, id properties.UUID) (*domain.ServiceGroup, error) {
+				return &domain.ServiceGroup{BaseEntity: domain.BaseEntity{ID: id, Version: 7}}, nil
+			}, ServiceGroupToRes))
+		return r
+	}
+
+	t.Run("Get emits ETag", func(t *testing.T) {
+		router := newRouter(nil)
+		req := httptest.NewRequest("GET", "/"+id.String(), nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, `"7"`, w.Header().Get(headerETag))
+	})
+
+	t.Run("Matching version is applied", func(t *testing.T) {
+		router := newRouter(func(ctx context.Context, gotID properties.UUID, req *UpdateServiceGroupReq) (*domain.ServiceGroup, error) {
+			version, check := domain.ExpectedVersion(ctx, gotID)
+			assert.True(t, check)
+			assert.Equal(t, 7, version)
+			return &domain.ServiceGroup{BaseEntity: domain.BaseEntity{ID: gotID, Version: 8}, Name: *req.Name}, nil
+		})
+		req := httptest.NewRequest("PATCH", "/"+id.String(), strings.NewReader(`{"name":"new"}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set(headerIfMatch, `"7"`)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, `"8"`, w.Header().Get(headerETag))
+	})
+
+	t.Run("Stale version returns current version", func(t *testing.T) {
+		router := newRouter(func(ctx context.Context, gotID properties.UUID, req *UpdateServiceGroupReq) (*domain.ServiceGroup, error) {
+			return nil, domain.NewPreconditionFailedErrorf(9, "version 7 does not match current version 9")
+		})
+		req := httptest.NewRequest("PATCH", "/"+id.String(), strings.NewReader(`{"name":"new"}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set(headerIfMatch, `"7"`)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+		assert.Equal(t, `"9"`, w.Header().Get(headerETag))
+		var body map[string]any
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		assert.Equal(t, float64(9), body["currentVersion"])
+	})
+
+	t.Run("Invalid header", func(t *testing.T) {
+		router := newRouter(nil)
+		req := httptest.NewRequest("PATCH", "/"+id.String(), strings.NewReader(`{"name":"new"}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set(headerIfMatch, "7")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
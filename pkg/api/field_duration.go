@@ -0,0 +1,61 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// JSONDuration is a duration marshaled as a Go duration string, e.g. "1h30m"
+type JSONDuration time.Duration
+
+func (d JSONDuration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+func (d *JSONDuration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("invalid duration format")
+	}
+
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+
+	*d = JSONDuration(parsed)
+	return nil
+}
+
+// durationFromJSON converts an optional JSONDuration to a duration
+func durationFromJSON(d *JSONDuration) *time.Duration {
+	if d == nil {
+		return nil
+	}
+	value := time.Duration(*d)
+	return &value
+}
+
+// durationToJSON converts an optional duration to a JSONDuration
+func durationToJSON(d *time.Duration) *JSONDuration {
+	if d == nil {
+		return nil
+	}
+	value := JSONDuration(*d)
+	return &value
+}
+
+// parseDayDuration parses a Go duration, or a whole number of days such as "7d"
+func parseDayDuration(s string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %q", s)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	return time.ParseDuration(s)
+}
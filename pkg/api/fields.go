@@ -0,0 +1,141 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+)
+
+// paramFields restricts the serialized response to the listed fields
+const paramFields = "fields"
+
+var jsonMarshalerType = reflect.TypeFor[json.Marshaler]()
+
+// ParseFieldsRequest parses the comma separated fields parameter and checks each path against the response type R
+// Nested fields are addressed with dotted paths like properties.cpu, an empty result means all fields
+func ParseFieldsRequest[R any](r *http.Request) ([]string, error) {
+	value := r.URL.Query().Get(paramFields)
+	if value == "" {
+		return nil, nil
+	}
+
+	var fields, unknown []string
+	for _, f := range strings.Split(value, ",") {
+		f = strings.TrimSpace(f)
+		if f == "" {
+			continue
+		}
+		if !isKnownField(reflect.TypeFor[R](), strings.Split(f, ".")) {
+			unknown = append(unknown, f)
+			continue
+		}
+		fields = append(fields, f)
+	}
+	if len(unknown) > 0 {
+		return nil, fmt.Errorf("unknown fields: %s", strings.Join(unknown, ", "))
+	}
+	return fields, nil
+}
+
+// isKnownField walks the json names of t following the path
+// Any key is accepted below free form maps since their content is not known upfront
+func isKnownField(t reflect.Type, path []string) bool {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if len(path) == 0 {
+		return true
+	}
+	switch {
+	case t.Kind() == reflect.Map, t.Kind() == reflect.Interface:
+		return true
+	case t.Kind() != reflect.Struct, t.Implements(jsonMarshalerType), reflect.PointerTo(t).Implements(jsonMarshalerType):
+		return false
+	}
+	for i := range t.NumField() {
+		sf := t.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(sf.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if sf.Anonymous && name == "" {
+			if isKnownField(sf.Type, path) {
+				return true
+			}
+			continue
+		}
+		if name == "" {
+			name = sf.Name
+		}
+		if name == path[0] {
+			return isKnownField(sf.Type, path[1:])
+		}
+	}
+	return false
+}
+
+// SelectFields converts the response to a JSON object holding only the given fields
+func SelectFields(res any, fields []string) (map[string]any, error) {
+	data, err := json.Marshal(res)
+	if err != nil {
+		return nil, err
+	}
+	var src map[string]any
+	if err := json.Unmarshal(data, &src); err != nil {
+		return nil, err
+	}
+
+	dst := make(map[string]any)
+	for _, f := range fields {
+		pickField(src, dst, strings.Split(f, "."))
+	}
+	return dst, nil
+}
+
+// pickField copies the value at path from src to dst, missing values are skipped
+func pickField(src, dst map[string]any, path []string) {
+	value, ok := src[path[0]]
+	if !ok {
+		return
+	}
+	if len(path) == 1 {
+		dst[path[0]] = value
+		return
+	}
+	nestedSrc, ok := value.(map[string]any)
+	if !ok {
+		return
+	}
+	nestedDst, ok := dst[path[0]].(map[string]any)
+	if !ok {
+		nestedDst = make(map[string]any)
+		dst[path[0]] = nestedDst
+	}
+	pickField(nestedSrc, nestedDst, path[1:])
+}
+
+// SelectPageFields converts every item of the page response keeping only the given fields
+func SelectPageFields[R any](page *PageRes[R], fields []string) (*PageRes[map[string]any], error) {
+	items := make([]*map[string]any, len(page.Items))
+	for i, item := range page.Items {
+		selected, err := SelectFields(item, fields)
+		if err != nil {
+			return nil, err
+		}
+		items[i] = &selected
+	}
+	return &PageRes[map[string]any]{
+		Items:       items,
+		TotalItems:  page.TotalItems,
+		TotalPages:  page.TotalPages,
+		CurrentPage: page.CurrentPage,
+		HasNext:     page.HasNext,
+		HasPrev:     page.HasPrev,
+		NextCursor:  page.NextCursor,
+	}, nil
+}
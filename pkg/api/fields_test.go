@@ -0,0 +1,111 @@
+package api
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/fulcrumproject/core/pkg/helpers"
+	"github.com/fulcrumproject/core/pkg/properties"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseFieldsRequest(t *testing.T) {
+	tests := []struct {
+		name          string
+		queryString   string
+		expected      []string
+		expectedError string
+	}{
+		{
+			name:        "No fields",
+			queryString: "",
+			expected:    nil,
+		},
+		{
+			name:        "Top level fields",
+			queryString: "?fields=id,name,status",
+			expected:    []string{"id", "name", "status"},
+		},
+		{
+			name:        "Nested free form property",
+			queryString: "?fields=id,properties.cpu",
+			expected:    []string{"id", "properties.cpu"},
+		},
+		{
+			name:        "Nested struct field",
+			queryString: "?fields=agent.name",
+			expected:    []string{"agent.name"},
+		},
+		{
+			name:        "Blank entries are ignored",
+			queryString: "?fields=id,,name",
+			expected:    []string{"id", "name"},
+		},
+		{
+			name:          "Unknown fields are listed",
+			queryString:   "?fields=id,foo,agent.bar",
+			expectedError: "unknown fields: foo, agent.bar",
+		},
+		{
+			name:          "Path below a scalar",
+			queryString:   "?fields=name.first",
+			expectedError: "unknown fields: name.first",
+		},
+		{
+			name:          "Path below a time",
+			queryString:   "?fields=createdAt.year",
+			expectedError: "unknown fields: createdAt.year",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/test"+tc.queryString, nil)
+			fields, err := ParseFieldsRequest[ServiceRes](req)
+
+			if tc.expectedError != "" {
+				assert.EqualError(t, err, tc.expectedError)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tc.expected, fields)
+			}
+		})
+	}
+}
+
+func TestSelectFields(t *testing.T) {
+	props := properties.JSON{"cpu": 2, "memory": 1024}
+	res := &ServiceRes{
+		ID:         properties.NewUUID(),
+		Name:       "svc",
+		Status:     "Started",
+		Properties: &props,
+		Agent:      &AgentRes{Name: "agent"},
+	}
+
+	selected, err := SelectFields(res, []string{"name", "properties.cpu", "agent.name", "properties.missing"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{
+		"name":       "svc",
+		"properties": map[string]any{"cpu": float64(2)},
+		"agent":      map[string]any{"name": "agent"},
+	}, selected)
+}
+
+func TestSelectPageFields(t *testing.T) {
+	page := &PageRes[ServiceRes]{
+		Items:       []*ServiceRes{{Name: "a"}, {Name: "b"}},
+		TotalItems:  helpers.Int64Ptr(2),
+		TotalPages:  helpers.IntPtr(1),
+		CurrentPage: 1,
+	}
+
+	selected, err := SelectPageFields(page, []string{"name"})
+	require.NoError(t, err)
+	require.Len(t, selected.Items, 2)
+	assert.Equal(t, map[string]any{"name": "a"}, *selected.Items[0])
+	assert.Equal(t, map[string]any{"name": "b"}, *selected.Items[1])
+	assert.Equal(t, helpers.Int64Ptr(2), selected.TotalItems)
+	assert.Equal(t, 1, selected.CurrentPage)
+}
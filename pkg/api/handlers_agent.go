@@ -3,6 +3,7 @@ package api
 import (
 	"context"
 	"net/http"
+	"time"
 
 	"github.com/fulcrumproject/core/pkg/auth"
 	"github.com/fulcrumproject/core/pkg/authz"
@@ -14,12 +15,15 @@ import (
 )
 
 type CreateAgentReq struct {
-	Name             string           `json:"name"`
-	ProviderID       properties.UUID  `json:"providerId"`
-	AgentTypeID      properties.UUID  `json:"agentTypeId"`
-	Tags             []string         `json:"tags"`
-	Configuration    *properties.JSON `json:"configuration,omitempty"`
-	ServicePoolSetID *properties.UUID `json:"servicePoolSetId,omitempty"`
+	Name              string           `json:"name"`
+	ProviderID        properties.UUID  `json:"providerId"`
+	AgentTypeID       properties.UUID  `json:"agentTypeId"`
+	Region            string           `json:"region,omitempty"`
+	Tags              []string         `json:"tags"`
+	Capabilities      []string         `json:"capabilities,omitempty"`
+	Configuration     *properties.JSON `json:"configuration,omitempty"`
+	ServicePoolSetID  *properties.UUID `json:"servicePoolSetId,omitempty"`
+	MaxConcurrentJobs *int             `json:"maxConcurrentJobs,omitempty"`
 }
 
 // authz.ObjectScope implements authz.ObjectScopeProvider interface
@@ -28,15 +32,23 @@ func (r CreateAgentReq) ObjectScope() (authz.ObjectScope, error) {
 }
 
 type UpdateAgentReq struct {
-	Name             *string             `json:"name"`
-	Status           *domain.AgentStatus `json:"status"`
-	Tags             *[]string           `json:"tags"`
-	Configuration    *properties.JSON    `json:"configuration,omitempty"`
-	ServicePoolSetID *properties.UUID    `json:"servicePoolSetId,omitempty"`
+	Name              *string             `json:"name"`
+	Status            *domain.AgentStatus `json:"status"`
+	Region            *string             `json:"region,omitempty"`
+	Tags              *[]string           `json:"tags"`
+	Capabilities      *[]string           `json:"capabilities,omitempty"`
+	Configuration     *properties.JSON    `json:"configuration,omitempty"`
+	ServicePoolSetID  *properties.UUID    `json:"servicePoolSetId,omitempty"`
+	MaxConcurrentJobs *int                `json:"maxConcurrentJobs,omitempty"`
 }
 
 type UpdateAgentStatusReq struct {
-	Status domain.AgentStatus `json:"status"`
+	Status    domain.AgentStatus     `json:"status"`
+	Telemetry *domain.AgentTelemetry `json:"telemetry,omitempty"`
+}
+
+type DrainAgentReq struct {
+	Draining bool `json:"draining"`
 }
 
 type AgentHandler struct {
@@ -70,6 +82,11 @@ func (h *AgentHandler) Routes() func(r chi.Router) {
 			middlewares.AuthzFromBody[CreateAgentReq](authz.ObjectTypeAgent, authz.ActionCreate, h.authz),
 		).Post("/", Create(h.Create, AgentToRes))
 
+		// Health summary - simple authorization, the agents are scoped like the list
+		r.With(
+			middlewares.AuthzSimple(authz.ObjectTypeAgent, authz.ActionRead, h.authz),
+		).Get("/health-summary", h.HealthSummary)
+
 		// Resource-specific routes with ID
 		r.Group(func(r chi.Router) {
 			r.Use(middlewares.ID)
@@ -85,6 +102,12 @@ func (h *AgentHandler) Routes() func(r chi.Router) {
 				middlewares.AuthzFromID(authz.ObjectTypeAgent, authz.ActionUpdate, h.authz, h.querier.AuthScope),
 			).Patch("/{id}", Update(h.Update, AgentToRes))
 
+			// Drain endpoint - stops or resumes assigning new work to the agent
+			r.With(
+				middlewares.DecodeBody[DrainAgentReq](),
+				middlewares.AuthzFromID(authz.ObjectTypeAgent, authz.ActionUpdate, h.authz, h.querier.AuthScope),
+			).Post("/{id}/drain", Action(h.Drain, AgentToRes))
+
 			// Delete endpoint - authorize using agent's provider
 			r.With(
 				middlewares.AuthzFromID(authz.ObjectTypeAgent, authz.ActionDelete, h.authz, h.querier.AuthScope),
@@ -107,12 +130,15 @@ func (h *AgentHandler) Routes() func(r chi.Router) {
 // Adapter functions that convert request structs to commander method calls
 func (h *AgentHandler) Create(ctx context.Context, req *CreateAgentReq) (*domain.Agent, error) {
 	params := domain.CreateAgentParams{
-		Name:             req.Name,
-		ProviderID:       req.ProviderID,
-		AgentTypeID:      req.AgentTypeID,
-		Tags:             req.Tags,
-		Configuration:    req.Configuration,
-		ServicePoolSetID: req.ServicePoolSetID,
+		Name:              req.Name,
+		ProviderID:        req.ProviderID,
+		AgentTypeID:       req.AgentTypeID,
+		Region:            req.Region,
+		Tags:              req.Tags,
+		Capabilities:      req.Capabilities,
+		Configuration:     req.Configuration,
+		ServicePoolSetID:  req.ServicePoolSetID,
+		MaxConcurrentJobs: req.MaxConcurrentJobs,
 	}
 	return h.commander.Create(ctx, params)
 }
@@ -120,22 +146,31 @@ func (h *AgentHandler) Create(ctx context.Context, req *CreateAgentReq) (*domain
 // Adapter functions that convert request structs to commander method calls
 func (h *AgentHandler) Update(ctx context.Context, id properties.UUID, req *UpdateAgentReq) (*domain.Agent, error) {
 	params := domain.UpdateAgentParams{
-		ID:               id,
-		Name:             req.Name,
-		Status:           req.Status,
-		Tags:             req.Tags,
-		Configuration:    req.Configuration,
-		ServicePoolSetID: req.ServicePoolSetID,
+		ID:                id,
+		Name:              req.Name,
+		Status:            req.Status,
+		Region:            req.Region,
+		Tags:              req.Tags,
+		Capabilities:      req.Capabilities,
+		Configuration:     req.Configuration,
+		ServicePoolSetID:  req.ServicePoolSetID,
+		MaxConcurrentJobs: req.MaxConcurrentJobs,
 	}
 	return h.commander.Update(ctx, params)
 }
 
+// Adapter functions that convert request structs to commander method calls
+func (h *AgentHandler) Drain(ctx context.Context, id properties.UUID, req *DrainAgentReq) (*domain.Agent, error) {
+	return h.commander.SetDrain(ctx, id, req.Draining)
+}
+
 // Adapter functions that convert request structs to commander method calls
 func (h *AgentHandler) UpdateStatusMe(ctx context.Context, req *UpdateAgentStatusReq) (*domain.Agent, error) {
 	agentID := auth.MustGetIdentity(ctx).Scope.AgentID
 	params := domain.UpdateAgentStatusParams{
-		ID:     *agentID,
-		Status: req.Status,
+		ID:        *agentID,
+		Status:    req.Status,
+		Telemetry: req.Telemetry,
 	}
 	return h.commander.UpdateStatus(ctx, params)
 }
@@ -154,35 +189,64 @@ func (h *AgentHandler) GetMe(w http.ResponseWriter, r *http.Request) {
 	render.JSON(w, r, AgentToRes(agent))
 }
 
+// HealthSummary handles GET /agents/health-summary, the query parameters filter the agents like the list
+func (h *AgentHandler) HealthSummary(w http.ResponseWriter, r *http.Request) {
+	id := auth.MustGetIdentity(r.Context())
+	health, err := h.querier.HealthSummary(r.Context(), &id.Scope, r.URL.Query())
+	if err != nil {
+		render.Render(w, r, ErrDomain(err))
+		return
+	}
+	render.JSON(w, r, AgentHealthSummaryToRes(health, time.Now()))
+}
+
 // AgentRes represents the response body for agent operations
 type AgentRes struct {
-	ID               properties.UUID    `json:"id"`
-	Name             string             `json:"name"`
-	Status           domain.AgentStatus `json:"status"`
-	ProviderID       properties.UUID    `json:"providerId"`
-	AgentTypeID      properties.UUID    `json:"agentTypeId"`
-	Tags             []string           `json:"tags"`
-	Configuration    *properties.JSON   `json:"configuration,omitempty"`
-	ServicePoolSetID *properties.UUID   `json:"servicePoolSetId,omitempty"`
-	Participant      *ParticipantRes    `json:"participant,omitempty"`
-	AgentType        *AgentTypeRes      `json:"agentType,omitempty"`
-	CreatedAt        JSONUTCTime        `json:"createdAt"`
-	UpdatedAt        JSONUTCTime        `json:"updatedAt"`
+	ID                 properties.UUID    `json:"id"`
+	Name               string             `json:"name"`
+	Status             domain.AgentStatus `json:"status"`
+	LastStatusUpdate   JSONUTCTime        `json:"lastStatusUpdate"`
+	Draining           bool               `json:"draining"`
+	CPUUsage           float64            `json:"cpuUsage"`
+	MemUsage           float64            `json:"memUsage"`
+	ActiveServiceCount int                `json:"activeServiceCount"`
+	MaxServices        int                `json:"maxServices"`
+	MaxConcurrentJobs  *int               `json:"maxConcurrentJobs,omitempty"`
+	ProviderID         properties.UUID    `json:"providerId"`
+	AgentTypeID        properties.UUID    `json:"agentTypeId"`
+	Region             string             `json:"region"`
+	Tags               []string           `json:"tags"`
+	Capabilities       []string           `json:"capabilities"`
+	Configuration      *properties.JSON   `json:"configuration,omitempty"`
+	ServicePoolSetID   *properties.UUID   `json:"servicePoolSetId,omitempty"`
+	Participant        *ParticipantRes    `json:"participant,omitempty"`
+	AgentType          *AgentTypeRes      `json:"agentType,omitempty"`
+	CreatedAt          JSONUTCTime        `json:"createdAt"`
+	UpdatedAt          JSONUTCTime        `json:"updatedAt"`
 }
 
 // AgentToRes converts a domain.Agent to an AgentResponse
 func AgentToRes(a *domain.Agent) *AgentRes {
 	response := &AgentRes{
-		ID:               a.ID,
-		Name:             a.Name,
-		Status:           a.Status,
-		ProviderID:       a.ProviderID,
-		AgentTypeID:      a.AgentTypeID,
-		Tags:             []string(a.Tags),
-		Configuration:    a.Configuration,
-		ServicePoolSetID: a.ServicePoolSetID,
-		CreatedAt:        JSONUTCTime(a.CreatedAt),
-		UpdatedAt:        JSONUTCTime(a.UpdatedAt),
+		ID:                 a.ID,
+		Name:               a.Name,
+		Status:             a.Status,
+		LastStatusUpdate:   JSONUTCTime(a.LastStatusUpdate),
+		Draining:           a.Draining,
+		CPUUsage:           a.CPUUsage,
+		MemUsage:           a.MemUsage,
+		ActiveServiceCount: a.ActiveServiceCount,
+		MaxServices:        a.MaxServices,
+		MaxConcurrentJobs:  a.MaxConcurrentJobs,
+		ProviderID:         a.ProviderID,
+		AgentTypeID:        a.AgentTypeID,
+		Region:             a.Region,
+		Tags:               []string(a.Tags),
+		Capabilities:       []string(a.Capabilities),
+		Configuration:      a.Configuration,
+		ServicePoolSetID:   a.ServicePoolSetID,
+		CreatedAt:          JSONUTCTime(a.CreatedAt),
+		UpdatedAt:          JSONUTCTime(a.UpdatedAt),
 	}
 	if a.Provider != nil {
 		response.Participant = ParticipantToRes(a.Provider)
@@ -192,3 +256,41 @@ func AgentToRes(a *domain.Agent) *AgentRes {
 	}
 	return response
 }
+
+// AgentHealthSummaryRes represents the response body of the agent health summary
+type AgentHealthSummaryRes struct {
+	Items       []*AgentHealthRes `json:"items"`
+	GeneratedAt JSONUTCTime       `json:"generatedAt"`
+}
+
+// AgentHealthRes represents the health of an agent in the summary
+type AgentHealthRes struct {
+	ID                     properties.UUID    `json:"id"`
+	Name                   string             `json:"name"`
+	Status                 domain.AgentStatus `json:"status"`
+	Draining               bool               `json:"draining"`
+	LastSeenAt             JSONUTCTime        `json:"lastSeenAt"`
+	Disconnected           bool               `json:"disconnected"`
+	DisconnectedForSeconds int64              `json:"disconnectedForSeconds,omitempty"`
+	ActiveJobs             int64              `json:"activeJobs"`
+	ActiveServices         int64              `json:"activeServices"`
+}
+
+// AgentHealthSummaryToRes converts the agent health to the summary response as of now
+func AgentHealthSummaryToRes(health []domain.AgentHealth, now time.Time) *AgentHealthSummaryRes {
+	items := make([]*AgentHealthRes, 0, len(health))
+	for _, h := range health {
+		items = append(items, &AgentHealthRes{
+			ID:                     h.ID,
+			Name:                   h.Name,
+			Status:                 h.Status,
+			Draining:               h.Draining,
+			LastSeenAt:             JSONUTCTime(h.LastSeenAt),
+			Disconnected:           h.Disconnected(),
+			DisconnectedForSeconds: int64(h.DisconnectedFor(now).Seconds()),
+			ActiveJobs:             h.ActiveJobs,
+			ActiveServices:         h.ActiveServices,
+		})
+	}
+	return &AgentHealthSummaryRes{Items: items, GeneratedAt: JSONUTCTime(now)}
+}
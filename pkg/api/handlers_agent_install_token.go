@@ -187,7 +187,7 @@ func (h *AgentInstallTokenHandler) Fetch(w http.ResponseWriter, r *http.Request)
 
 	token := chi.URLParam(r, "token")
 	if token == "" {
-		slog.Info("install fetch → 404", "reason", "empty token")
+		slog.InfoContext(ctx, "install fetch → 404", "reason", "empty token")
 		render.Render(w, r, ErrNotFound())
 		return
 	}
@@ -195,22 +195,22 @@ func (h *AgentInstallTokenHandler) Fetch(w http.ResponseWriter, r *http.Request)
 	tok, err := h.querier.FindByHashedToken(ctx, domain.HashTokenValue(token))
 	if err != nil {
 		if errors.As(err, &domain.NotFoundError{}) {
-			slog.Info("install fetch → 404", "reason", "install token not found")
+			slog.InfoContext(ctx, "install fetch → 404", "reason", "install token not found")
 		} else {
-			slog.Warn("install fetch → 404", "reason", "install token lookup failed: "+err.Error())
+			slog.WarnContext(ctx, "install fetch → 404", "reason", "install token lookup failed: "+err.Error())
 		}
 		render.Render(w, r, ErrNotFound())
 		return
 	}
 	if tok.IsExpired() {
-		slog.Info("install fetch → 404", "reason", "install token expired")
+		slog.InfoContext(ctx, "install fetch → 404", "reason", "install token expired")
 		render.Render(w, r, ErrNotFound())
 		return
 	}
 
 	agent := tok.Agent
 	if agent == nil || agent.AgentType == nil || !agent.AgentType.HasInstallTemplates() {
-		slog.Info("install fetch → 404", "reason", "agent type has no install templates configured")
+		slog.InfoContext(ctx, "install fetch → 404", "reason", "agent type has no install templates configured")
 		render.Render(w, r, ErrNotFound())
 		return
 	}
@@ -222,14 +222,14 @@ func (h *AgentInstallTokenHandler) Fetch(w http.ResponseWriter, r *http.Request)
 
 	resolved, err := schema.ResolveSecrets(ctx, h.vault, agent.AgentType.ConfigurationSchema, data)
 	if err != nil {
-		slog.Warn("install fetch → 404", "reason", "vault resolution failed: "+err.Error())
+		slog.WarnContext(ctx, "install fetch → 404", "reason", "vault resolution failed: "+err.Error())
 		render.Render(w, r, ErrNotFound())
 		return
 	}
 
 	body, err := domain.RenderConfigTemplate(agent.AgentType, resolved)
 	if err != nil {
-		slog.Warn("install fetch → 404", "reason", "config template render failed: "+err.Error())
+		slog.WarnContext(ctx, "install fetch → 404", "reason", "config template render failed: "+err.Error())
 		render.Render(w, r, ErrNotFound())
 		return
 	}
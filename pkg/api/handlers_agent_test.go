@@ -1,6 +1,7 @@
 package api
 
 import (
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -13,6 +14,7 @@ import (
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 // TestHandleGetMe tests the handleGetMe method
@@ -105,6 +107,81 @@ func TestNewAgentHandler(t *testing.T) {
 	assert.Equal(t, authz, handler.authz)
 }
 
+// TestAgentHandleDrain tests the Drain adapter
+func TestAgentHandleDrain(t *testing.T) {
+	agentID := uuid.MustParse("550e8400-e29b-41d4-a716-446655440000")
+	commander := domain.NewMockAgentCommander(t)
+	commander.EXPECT().SetDrain(mock.Anything, agentID, true).Return(&domain.Agent{
+		BaseEntity: domain.BaseEntity{ID: agentID},
+		Draining:   true,
+	}, nil)
+
+	handler := NewAgentHandler(domain.NewMockAgentQuerier(t), commander, authz.NewMockAuthorizer(t))
+	req := httptest.NewRequest("POST", "/agents/"+agentID.String()+"/drain", nil)
+
+	agent, err := handler.Drain(req.Context(), agentID, &DrainAgentReq{Draining: true})
+	assert.NoError(t, err)
+	assert.True(t, AgentToRes(agent).Draining)
+}
+
+// TestAgentHandleHealthSummary tests the health summary in the scope of the caller
+func TestAgentHandleHealthSummary(t *testing.T) {
+	providerID := uuid.MustParse("660e8400-e29b-41d4-a716-446655440000")
+	identity := newMockAuthParticipant(providerID)
+	querier := domain.NewMockAgentQuerier(t)
+	querier.EXPECT().
+		HealthSummary(mock.Anything, &identity.Scope, map[string][]string{"status": {"Disconnected"}}).
+		Return([]domain.AgentHealth{{
+			ID:             uuid.MustParse("550e8400-e29b-41d4-a716-446655440000"),
+			Name:           "edge-1",
+			Status:         domain.AgentDisconnected,
+			LastSeenAt:     time.Now().Add(-10 * time.Minute),
+			ActiveJobs:     2,
+			ActiveServices: 5,
+		}}, nil)
+
+	handler := NewAgentHandler(querier, domain.NewMockAgentCommander(t), authz.NewMockAuthorizer(t))
+	req := httptest.NewRequest("GET", "/agents/health-summary?status=Disconnected", nil)
+	req = req.WithContext(auth.WithIdentity(req.Context(), identity))
+	w := httptest.NewRecorder()
+	handler.HealthSummary(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	var res AgentHealthSummaryRes
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+	require.Len(t, res.Items, 1)
+	item := res.Items[0]
+	assert.Equal(t, "edge-1", item.Name)
+	assert.True(t, item.Disconnected)
+	assert.InDelta(t, 600, item.DisconnectedForSeconds, 5)
+	assert.Equal(t, int64(2), item.ActiveJobs)
+	assert.Equal(t, int64(5), item.ActiveServices)
+}
+
+func TestAgentHealthSummaryToRes(t *testing.T) {
+	now := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	res := AgentHealthSummaryToRes([]domain.AgentHealth{
+		{Name: "connected", Status: domain.AgentConnected, LastSeenAt: now.Add(-time.Hour)},
+		{Name: "disconnected", Status: domain.AgentDisconnected, LastSeenAt: now.Add(-90 * time.Second)},
+	}, now)
+
+	assert.Equal(t, JSONUTCTime(now), res.GeneratedAt)
+	assert.False(t, res.Items[0].Disconnected)
+	assert.Zero(t, res.Items[0].DisconnectedForSeconds)
+	assert.True(t, res.Items[1].Disconnected)
+	assert.Equal(t, int64(90), res.Items[1].DisconnectedForSeconds)
+
+	assert.NotNil(t, AgentHealthSummaryToRes(nil, now).Items, "no agents is an empty list")
+}
+
+func TestAgentQuotaExceededIsConflict(t *testing.T) {
+	err := domain.NewAgentQuotaExceededError(uuid.MustParse("550e8400-e29b-41d4-a716-446655440000"), 3)
+	res, ok := ErrDomain(err).(*ErrRes)
+	assert.True(t, ok)
+	assert.Equal(t, http.StatusConflict, res.HTTPStatusCode)
+	assert.Equal(t, "agent quota exceeded: provider 550e8400-e29b-41d4-a716-446655440000 cannot register more than 3 agents", res.ErrorText)
+}
+
 // TestAgentToResponse tests the agentToResponse function
 func TestAgentToResponse(t *testing.T) {
 	// Create test agent
@@ -122,10 +199,15 @@ func TestAgentToResponse(t *testing.T) {
 		ProviderID:  uuid.MustParse("660e8400-e29b-41d4-a716-446655440000"),
 		AgentTypeID: uuid.MustParse("770e8400-e29b-41d4-a716-446655440000"),
 		Tags:        []string{"tag1", "tag2"},
+		Region:      "eu-west",
 		Configuration: &properties.JSON{
 			"timeout": 30,
 			"retries": 3,
 		},
+		CPUUsage:           42.5,
+		MemUsage:           61,
+		ActiveServiceCount: 4,
+		MaxServices:        10,
 	}
 
 	// Convert to response
@@ -138,7 +220,12 @@ func TestAgentToResponse(t *testing.T) {
 	assert.Equal(t, agent.ProviderID, response.ProviderID)
 	assert.Equal(t, agent.AgentTypeID, response.AgentTypeID)
 	assert.Equal(t, []string{"tag1", "tag2"}, response.Tags)
+	assert.Equal(t, "eu-west", response.Region)
 	assert.Equal(t, agent.Configuration, response.Configuration)
+	assert.Equal(t, 42.5, response.CPUUsage)
+	assert.Equal(t, 61.0, response.MemUsage)
+	assert.Equal(t, 4, response.ActiveServiceCount)
+	assert.Equal(t, 10, response.MaxServices)
 	assert.Equal(t, JSONUTCTime(createdAt), response.CreatedAt)
 	assert.Equal(t, JSONUTCTime(updatedAt), response.UpdatedAt)
 }
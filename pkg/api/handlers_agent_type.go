@@ -74,6 +74,8 @@ type CreateAgentTypeReq struct {
 	ConfigTemplate      string            `json:"configTemplate,omitempty"`
 	CmdTemplate         string            `json:"cmdTemplate,omitempty"`
 	ConfigContentType   string            `json:"configContentType,omitempty"`
+	Capabilities        []string          `json:"capabilities,omitempty"`
+	MaxConcurrentJobs   *int              `json:"maxConcurrentJobs,omitempty"`
 }
 
 // UpdateAgentTypeReq represents the request body for updating agent types
@@ -84,6 +86,8 @@ type UpdateAgentTypeReq struct {
 	ConfigTemplate      *string            `json:"configTemplate,omitempty"`
 	CmdTemplate         *string            `json:"cmdTemplate,omitempty"`
 	ConfigContentType   *string            `json:"configContentType,omitempty"`
+	Capabilities        *[]string          `json:"capabilities,omitempty"`
+	MaxConcurrentJobs   *int               `json:"maxConcurrentJobs,omitempty"`
 }
 
 // AgentTypeRes represents the response body for agent type operations
@@ -97,6 +101,8 @@ type AgentTypeRes struct {
 	ConfigTemplate      string            `json:"configTemplate"`
 	CmdTemplate         string            `json:"cmdTemplate"`
 	ConfigContentType   string            `json:"configContentType"`
+	Capabilities        []string          `json:"capabilities"`
+	MaxConcurrentJobs   *int              `json:"maxConcurrentJobs,omitempty"`
 }
 
 // AgentTypeToRes converts a domain.AgentType to an AgentTypeResponse
@@ -111,6 +117,8 @@ func AgentTypeToRes(at *domain.AgentType) *AgentTypeRes {
 		ConfigTemplate:      at.ConfigTemplate,
 		CmdTemplate:         at.CmdTemplate,
 		ConfigContentType:   at.ConfigContentType,
+		Capabilities:        []string(at.Capabilities),
+		MaxConcurrentJobs:   at.MaxConcurrentJobs,
 	}
 	for _, st := range at.ServiceTypes {
 		response.ServiceTypeIds = append(response.ServiceTypeIds, st.ID)
@@ -128,6 +136,8 @@ func (h *AgentTypeHandler) Create(ctx context.Context, req *CreateAgentTypeReq)
 		ConfigTemplate:      req.ConfigTemplate,
 		CmdTemplate:         req.CmdTemplate,
 		ConfigContentType:   req.ConfigContentType,
+		Capabilities:        req.Capabilities,
+		MaxConcurrentJobs:   req.MaxConcurrentJobs,
 	}
 	return h.commander.Create(ctx, params)
 }
@@ -141,6 +151,8 @@ func (h *AgentTypeHandler) Update(ctx context.Context, id properties.UUID, req *
 		ConfigTemplate:      req.ConfigTemplate,
 		CmdTemplate:         req.CmdTemplate,
 		ConfigContentType:   req.ConfigContentType,
+		Capabilities:        req.Capabilities,
+		MaxConcurrentJobs:   req.MaxConcurrentJobs,
 	}
 	return h.commander.Update(ctx, params)
 }
@@ -157,6 +157,7 @@ func TestAgentTypeToResponse(t *testing.T) {
 				Name: "TestServiceType",
 			},
 		},
+		Capabilities: []string{"gpu", "ssd"},
 	}
 
 	response := AgentTypeToRes(agentType)
@@ -167,4 +168,5 @@ func TestAgentTypeToResponse(t *testing.T) {
 	assert.Equal(t, JSONUTCTime(agentType.UpdatedAt), response.UpdatedAt)
 	assert.Len(t, response.ServiceTypeIds, 1)
 	assert.Equal(t, agentType.ServiceTypes[0].ID, response.ServiceTypeIds[0])
+	assert.Equal(t, []string{"gpu", "ssd"}, response.Capabilities)
 }
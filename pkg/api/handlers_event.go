@@ -1,12 +1,21 @@
 package api
 
 import (
+	"encoding/csv"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"log/slog"
+	"mime"
 	"net/http"
+	"slices"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/fulcrumproject/core/pkg/auth"
 	"github.com/fulcrumproject/core/pkg/authz"
 	"github.com/fulcrumproject/core/pkg/domain"
 	"github.com/fulcrumproject/core/pkg/middlewares"
@@ -23,12 +32,34 @@ const (
 	DefaultEventLimit           = 100  // default number of events to fetch
 	MaxEventLimit               = 1000 // maximum number of events to fetch
 	MinEventLimit               = 1    // minimum number of events to fetch
+
+	// Event stream configuration constants
+	StreamPollInterval      = time.Second      // interval between reads of new events
+	StreamHeartbeatInterval = 15 * time.Second // interval between keep-alive comments
+	StreamBatchSize         = 100              // maximum number of events read at once
+
+	// Event export configuration constants
+	ExportBatchSize = 1000 // number of events read and flushed at once
+)
+
+// paramAfterSequence is the list parameter returning the events after a sequence number
+const paramAfterSequence = "afterSequence"
+
+// Event export formats
+const (
+	EventExportFormatJSONL = "jsonl"
+	EventExportFormatCSV   = "csv"
 )
 
 type EventHandler struct {
 	querier                    domain.EventQuerier
 	eventSubscriptionCommander domain.EventSubscriptionCommander
 	authz                      authz.Authorizer
+	streamPollInterval         time.Duration
+	streamHeartbeatInterval    time.Duration
+	exportBatchSize            int
+	streamsDone                chan struct{}
+	closeStreamsOnce           sync.Once
 }
 
 func NewEventHandler(
@@ -40,6 +71,10 @@ func NewEventHandler(
 		querier:                    querier,
 		eventSubscriptionCommander: eventSubscriptionCommander,
 		authz:                      authz,
+		streamPollInterval:         StreamPollInterval,
+		streamHeartbeatInterval:    StreamHeartbeatInterval,
+		exportBatchSize:            ExportBatchSize,
+		streamsDone:                make(chan struct{}),
 	}
 }
 
@@ -49,7 +84,17 @@ func (h *EventHandler) Routes() func(r chi.Router) {
 		// List endpoint - simple authorization
 		r.With(
 			middlewares.AuthzSimple(authz.ObjectTypeEvent, authz.ActionRead, h.authz),
-		).Get("/", List(h.querier, EventToRes))
+		).Get("/", h.List)
+
+		// Live stream of new events as Server-Sent Events
+		r.With(
+			middlewares.AuthzSimple(authz.ObjectTypeEvent, authz.ActionRead, h.authz),
+		).Get("/stream", h.Stream)
+
+		// Bulk export of the events visible to the caller as JSON Lines or CSV
+		r.With(
+			middlewares.AuthzSimple(authz.ObjectTypeEvent, authz.ActionRead, h.authz),
+		).Get("/export", h.Export)
 
 		// Event consumption endpoint with leasing - requires admin role
 		r.With(
@@ -60,6 +105,16 @@ func (h *EventHandler) Routes() func(r chi.Router) {
 		r.With(
 			middlewares.AuthzSimple(authz.ObjectTypeEvent, authz.ActionAck, h.authz),
 		).Post("/ack", h.Acknowledge)
+
+		// Webhook configuration endpoint - requires admin role
+		r.With(
+			middlewares.AuthzSimple(authz.ObjectTypeEvent, authz.ActionSubscribe, h.authz),
+		).Post("/webhook", h.ConfigureWebhook)
+
+		// Event filter configuration endpoint - requires admin role
+		r.With(
+			middlewares.AuthzSimple(authz.ObjectTypeEvent, authz.ActionSubscribe, h.authz),
+		).Post("/filter", h.SetFilter)
 	}
 }
 
@@ -77,6 +132,7 @@ type EventRes struct {
 	Agent          *AgentRes            `json:"agent,omitempty"`
 	ConsumerID     *properties.UUID     `json:"consumerId,omitempty"`
 	Consumer       *ParticipantRes      `json:"consumer,omitempty"`
+	RequestID      string               `json:"requestId,omitempty"`
 	CreatedAt      JSONUTCTime          `json:"createdAt"`
 	UpdatedAt      JSONUTCTime          `json:"updatedAt"`
 }
@@ -93,6 +149,7 @@ func EventToRes(ae *domain.Event) *EventRes {
 		ProviderID:     ae.ProviderID,
 		AgentID:        ae.AgentID,
 		ConsumerID:     ae.ConsumerID,
+		RequestID:      ae.RequestID,
 		CreatedAt:      JSONUTCTime(ae.CreatedAt),
 		UpdatedAt:      JSONUTCTime(ae.UpdatedAt),
 	}
@@ -110,6 +167,106 @@ func EventToRes(ae *domain.Event) *EventRes {
 	return res
 }
 
+// eventResponder returns the conversion of the events to the response format
+func eventResponder(format domain.EventFormat) func(*domain.Event) any {
+	if format == domain.EventFormatCloudEvents {
+		return func(e *domain.Event) any { return domain.NewCloudEvent(e) }
+	}
+	return func(e *domain.Event) any { return EventToRes(e) }
+}
+
+// requestedEventFormat returns the event format negotiated with the Accept header,
+// application/cloudevents+json selects CloudEvents and application/json the native format
+func requestedEventFormat(r *http.Request, fallback domain.EventFormat) domain.EventFormat {
+	for _, accepted := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(accepted))
+		if err != nil {
+			continue
+		}
+		switch mediaType {
+		case domain.CloudEventsContentType:
+			return domain.EventFormatCloudEvents
+		case "application/json":
+			return domain.EventFormatNative
+		}
+	}
+	return fallback
+}
+
+// List returns the page of events, in the CloudEvents format when requested with the Accept header
+// The events after a sequence number are returned in sequence order when the afterSequence parameter is set
+func (h *EventHandler) List(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Query().Has(paramAfterSequence) {
+		h.listAfterSequence(w, r)
+		return
+	}
+	if requestedEventFormat(r, domain.EventFormatNative) == domain.EventFormatCloudEvents {
+		List(h.querier, domain.NewCloudEvent)(w, r)
+		return
+	}
+	List(h.querier, EventToRes)(w, r)
+}
+
+// EventReplayRes represents the events following a sequence number
+type EventReplayRes struct {
+	Items        []any `json:"items"`        // EventRes or domain.CloudEvent depending on the format
+	LastSequence int64 `json:"lastSequence"` // Cursor to resume from, the afterSequence parameter when there are no events
+	HasMore      bool  `json:"hasMore"`
+}
+
+// listAfterSequence returns the events visible to the caller after the afterSequence cursor, in sequence order
+// The sequence numbers have no gaps and are committed in order, so resuming from the last sequence never skips an event.
+// The pageSize parameter limits the number of events and the type parameter restricts them to comma separated types.
+func (h *EventHandler) listAfterSequence(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	after, err := strconv.ParseInt(q.Get(paramAfterSequence), 10, 64)
+	if err != nil || after < 0 {
+		render.Render(w, r, ErrInvalidRequest(fmt.Errorf("invalid %s parameter: %s", paramAfterSequence, q.Get(paramAfterSequence))))
+		return
+	}
+	limit := DefaultEventLimit
+	if value := q.Get(paramPageSize); value != "" {
+		limit, err = strconv.Atoi(value)
+		if err != nil || limit < MinEventLimit || limit > MaxEventLimit {
+			render.Render(w, r, ErrInvalidRequest(fmt.Errorf("%s parameter must be between %d and %d, got: %s", paramPageSize, MinEventLimit, MaxEventLimit, value)))
+			return
+		}
+	}
+
+	ctx := r.Context()
+	scope := &auth.MustGetIdentity(ctx).Scope
+	// One more event is read to know whether others follow
+	events, err := h.querier.ListScopedFromSequence(ctx, scope, after, parseEventTypes(r), limit+1)
+	if err != nil {
+		render.Render(w, r, ErrDomain(err))
+		return
+	}
+
+	res := EventReplayRes{Items: []any{}, LastSequence: after, HasMore: len(events) > limit}
+	if res.HasMore {
+		events = events[:limit]
+	}
+	toRes := eventResponder(requestedEventFormat(r, domain.EventFormatNative))
+	for _, event := range events {
+		res.Items = append(res.Items, toRes(event))
+		res.LastSequence = event.SequenceNumber
+	}
+	render.JSON(w, r, res)
+}
+
+// parseEventTypes returns the event types of the repeated or comma separated type query parameter
+func parseEventTypes(r *http.Request) []domain.EventType {
+	var types []domain.EventType
+	for _, value := range r.URL.Query()["type"] {
+		for _, t := range strings.Split(value, ",") {
+			if t = strings.TrimSpace(t); t != "" {
+				types = append(types, domain.EventType(t))
+			}
+		}
+	}
+	return types
+}
+
 // EventLeaseReq represents the request body for event lease operations
 type EventLeaseReq struct {
 	SubscriberID         string `json:"subscriberId" validate:"required"`
@@ -131,7 +288,7 @@ func (req *EventLeaseReq) Bind(r *http.Request) error {
 
 // EventLeaseRes represents the response body for event lease operations
 type EventLeaseRes struct {
-	Events                     []EventRes  `json:"events"`
+	Events                     []any       `json:"events"` // EventRes or domain.CloudEvent depending on the format
 	LeaseExpiresAt             JSONUTCTime `json:"leaseExpiresAt"`
 	LastEventSequenceProcessed int64       `json:"lastEventSequenceProcessed"`
 }
@@ -197,16 +354,17 @@ func (h *EventHandler) Lease(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Fetch events starting from the last processed sequence
-	events, err := h.querier.ListFromSequence(ctx, subscription.LastEventSequenceProcessed, limit)
+	events, err := h.querier.ListFromSequence(ctx, subscription.LastEventSequenceProcessed, subscription.Filter, limit)
 	if err != nil {
 		render.Render(w, r, ErrDomain(err))
 		return
 	}
 
-	// Convert events to response format
-	eventResponses := make([]EventRes, len(events))
+	// Convert events to the format of the subscription unless another one is requested
+	toRes := eventResponder(requestedEventFormat(r, subscription.EventFormat()))
+	eventResponses := make([]any, len(events))
 	for i, event := range events {
-		eventResponses[i] = *EventToRes(event)
+		eventResponses[i] = toRes(event)
 	}
 
 	response := EventLeaseRes{
@@ -288,3 +446,451 @@ func (h *EventHandler) Acknowledge(w http.ResponseWriter, r *http.Request) {
 
 	render.JSON(w, r, response)
 }
+
+// EventWebhookReq represents the request body for webhook configuration
+type EventWebhookReq struct {
+	SubscriberID string  `json:"subscriberId"`
+	CallbackURL  string  `json:"callbackUrl"`
+	Secret       *string `json:"secret,omitempty"` // Write-only, never returned
+	Format       *string `json:"format,omitempty"`
+}
+
+// Bind implements the render.Binder interface for EventWebhookReq
+func (req *EventWebhookReq) Bind(r *http.Request) error {
+	if req.SubscriberID == "" {
+		return fmt.Errorf("subscriberId is required")
+	}
+	if req.CallbackURL == "" {
+		return fmt.Errorf("callbackUrl is required")
+	}
+	if req.Format != nil {
+		if _, err := domain.ParseEventFormat(*req.Format); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// EventSubscriptionRes represents the response body for event subscription operations
+type EventSubscriptionRes struct {
+	ID                         properties.UUID `json:"id"`
+	SubscriberID               string          `json:"subscriberId"`
+	LastEventSequenceProcessed int64           `json:"lastEventSequenceProcessed"`
+	IsActive                   bool            `json:"isActive"`
+	Format                     string          `json:"format"`
+	CallbackURL                *string         `json:"callbackUrl,omitempty"`
+	HasSecret                  bool            `json:"hasSecret"`
+	LastDeliveryAt             *JSONUTCTime    `json:"lastDeliveryAt,omitempty"`
+	LastStatus                 *int            `json:"lastStatus,omitempty"`
+	LastError                  *string         `json:"lastError,omitempty"`
+	FailureCount               int             `json:"failureCount"`
+	NextDeliveryAt             *JSONUTCTime    `json:"nextDeliveryAt,omitempty"`
+	DeadLetteredAt             *JSONUTCTime    `json:"deadLetteredAt,omitempty"`
+	Filter                     EventFilterRes  `json:"filter"`
+}
+
+// EventFilterRes represents the event filter of a subscription, the empty fields match every event
+type EventFilterRes struct {
+	Types         []domain.EventType `json:"types"`
+	EntityID      *properties.UUID   `json:"entityId,omitempty"`
+	ProviderID    *properties.UUID   `json:"providerId,omitempty"`
+	ConsumerID    *properties.UUID   `json:"consumerId,omitempty"`
+	ParticipantID *properties.UUID   `json:"participantId,omitempty"` // Set on the notification subscriptions of the participants
+}
+
+// EventSubscriptionToRes converts a domain.EventSubscription to an EventSubscriptionRes
+func EventSubscriptionToRes(es *domain.EventSubscription) *EventSubscriptionRes {
+	return &EventSubscriptionRes{
+		ID:                         es.ID,
+		SubscriberID:               es.SubscriberID,
+		LastEventSequenceProcessed: es.LastEventSequenceProcessed,
+		IsActive:                   es.IsActive,
+		Format:                     string(es.EventFormat()),
+		CallbackURL:                es.CallbackURL,
+		HasSecret:                  es.SecretRef != nil,
+		LastDeliveryAt:             (*JSONUTCTime)(es.LastDeliveryAt),
+		LastStatus:                 es.LastStatus,
+		LastError:                  es.LastError,
+		FailureCount:               es.FailureCount,
+		NextDeliveryAt:             (*JSONUTCTime)(es.NextDeliveryAt),
+		DeadLetteredAt:             (*JSONUTCTime)(es.DeadLetteredAt),
+		Filter: EventFilterRes{
+			Types:         es.Filter.EventTypes(),
+			EntityID:      es.Filter.EntityID,
+			ProviderID:    es.Filter.ProviderID,
+			ConsumerID:    es.Filter.ConsumerID,
+			ParticipantID: es.Filter.ParticipantID,
+		},
+	}
+}
+
+// ConfigureWebhook sets the callback URL events are pushed to and re-enables a dead-lettered delivery
+func (h *EventHandler) ConfigureWebhook(w http.ResponseWriter, r *http.Request) {
+	var req EventWebhookReq
+	if err := render.Bind(r, &req); err != nil {
+		render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+
+	subscription, err := h.eventSubscriptionCommander.ConfigureWebhook(r.Context(), domain.ConfigureWebhookParams{
+		SubscriberID: req.SubscriberID,
+		CallbackURL:  req.CallbackURL,
+		Secret:       req.Secret,
+		Format:       (*domain.EventFormat)(req.Format),
+	})
+	if err != nil {
+		render.Render(w, r, ErrDomain(err))
+		return
+	}
+
+	render.JSON(w, r, EventSubscriptionToRes(subscription))
+}
+
+// EventFilterReq represents the request body for event filter configuration
+type EventFilterReq struct {
+	SubscriberID string             `json:"subscriberId"`
+	Types        []domain.EventType `json:"types,omitempty"`
+	EntityID     *properties.UUID   `json:"entityId,omitempty"`
+	ProviderID   *properties.UUID   `json:"providerId,omitempty"`
+	ConsumerID   *properties.UUID   `json:"consumerId,omitempty"`
+}
+
+// Bind implements the render.Binder interface for EventFilterReq
+func (req *EventFilterReq) Bind(r *http.Request) error {
+	if req.SubscriberID == "" {
+		return fmt.Errorf("subscriberId is required")
+	}
+	return nil
+}
+
+// SetFilter replaces the filter of the events leased by or delivered to the subscription
+func (h *EventHandler) SetFilter(w http.ResponseWriter, r *http.Request) {
+	var req EventFilterReq
+	if err := render.Bind(r, &req); err != nil {
+		render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+
+	subscription, err := h.eventSubscriptionCommander.SetFilter(r.Context(), domain.SetEventFilterParams{
+		SubscriberID: req.SubscriberID,
+		Filter:       domain.NewEventFilter(req.Types, req.EntityID, req.ProviderID, req.ConsumerID),
+	})
+	if err != nil {
+		render.Render(w, r, ErrDomain(err))
+		return
+	}
+
+	render.JSON(w, r, EventSubscriptionToRes(subscription))
+}
+
+// Stream pushes the new events visible to the caller as Server-Sent Events
+// The stream starts after the lastEventId query parameter or Last-Event-ID header, or from now,
+// and can be restricted to comma separated event types with the type query parameter.
+// The event data is in the CloudEvents format when requested with the Accept header.
+func (h *EventHandler) Stream(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		render.Render(w, r, ErrInternal(fmt.Errorf("streaming is not supported")))
+		return
+	}
+
+	ctx := r.Context()
+	scope := &auth.MustGetIdentity(ctx).Scope
+	toRes := eventResponder(requestedEventFormat(r, domain.EventFormatNative))
+
+	types := parseEventTypes(r)
+
+	lastEventID := r.URL.Query().Get("lastEventId")
+	if lastEventID == "" {
+		lastEventID = r.Header.Get("Last-Event-ID")
+	}
+	var last int64
+	if lastEventID != "" {
+		var err error
+		last, err = strconv.ParseInt(lastEventID, 10, 64)
+		if err != nil || last < 0 {
+			render.Render(w, r, ErrInvalidRequest(fmt.Errorf("invalid lastEventId: %s", lastEventID)))
+			return
+		}
+	} else {
+		var err error
+		if last, err = h.querier.LastSequenceNumber(ctx); err != nil {
+			render.Render(w, r, ErrDomain(err))
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	poll := time.NewTicker(h.streamPollInterval)
+	defer poll.Stop()
+	heartbeat := time.NewTicker(h.streamHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-h.streamsDone:
+			return
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		case <-poll.C:
+			events, err := h.querier.ListScopedFromSequence(ctx, scope, last, types, StreamBatchSize)
+			if err != nil {
+				if ctx.Err() == nil {
+					fmt.Fprintf(w, "event: error\ndata: %s\n\n", strconv.Quote(err.Error()))
+					flusher.Flush()
+				}
+				return
+			}
+			for _, event := range events {
+				data, err := json.Marshal(toRes(event))
+				if err != nil {
+					return
+				}
+				if _, err := fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.SequenceNumber, event.Type, data); err != nil {
+					return
+				}
+				last = event.SequenceNumber
+			}
+			if len(events) > 0 {
+				flusher.Flush()
+			}
+		}
+	}
+}
+
+// CloseStreams ends the open event streams, it is meant to be called when the server shuts down
+func (h *EventHandler) CloseStreams() {
+	h.closeStreamsOnce.Do(func() {
+		close(h.streamsDone)
+	})
+}
+
+// Export writes the events visible to the caller as JSON Lines (format=jsonl, the default) or CSV (format=csv)
+// Events are read in sequence order by batches that are flushed as soon as they are written, so exports
+// of any size are streamed without being held in memory. The start (inclusive) and end (exclusive) query
+// parameters restrict the creation time of the events. JSON Lines exports are in the CloudEvents format
+// when requested with the Accept header.
+func (h *EventHandler) Export(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	format := q.Get("format")
+	if format == "" {
+		format = EventExportFormatJSONL
+	}
+	if format != EventExportFormatJSONL && format != EventExportFormatCSV {
+		render.Render(w, r, ErrInvalidRequest(fmt.Errorf("invalid format %q: must be %s or %s", format, EventExportFormatJSONL, EventExportFormatCSV)))
+		return
+	}
+	start, err := parseOptionalTime(q.Get("start"))
+	if err != nil {
+		render.Render(w, r, ErrInvalidRequest(fmt.Errorf("invalid start: %w", err)))
+		return
+	}
+	end, err := parseOptionalTime(q.Get("end"))
+	if err != nil {
+		render.Render(w, r, ErrInvalidRequest(fmt.Errorf("invalid end: %w", err)))
+		return
+	}
+	if start != nil && end != nil && !start.Before(*end) {
+		render.Render(w, r, ErrInvalidRequest(fmt.Errorf("start must be before end")))
+		return
+	}
+
+	ctx := r.Context()
+	scope := &auth.MustGetIdentity(ctx).Scope
+
+	// The first batch is read before the headers are sent so failures still get an error status
+	events, err := h.querier.ListScopedInTimeRange(ctx, scope, 0, start, end, h.exportBatchSize)
+	if err != nil {
+		render.Render(w, r, ErrDomain(err))
+		return
+	}
+
+	var writer eventExportWriter
+	if format == EventExportFormatCSV {
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		w.Header().Set("Content-Disposition", `attachment; filename="events.csv"`)
+		writer = newCSVEventWriter(w)
+	} else {
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		w.Header().Set("Content-Disposition", `attachment; filename="events.jsonl"`)
+		writer = newJSONLEventWriter(w, eventResponder(requestedEventFormat(r, domain.EventFormatNative)))
+	}
+	w.WriteHeader(http.StatusOK)
+	flusher, _ := w.(http.Flusher)
+
+	for {
+		for _, event := range events {
+			if err := writer.Write(event); err != nil {
+				slog.ErrorContext(ctx, "Failed to write exported event", "error", err)
+				return
+			}
+		}
+		if err := writer.Flush(); err != nil {
+			slog.ErrorContext(ctx, "Failed to write exported events", "error", err)
+			return
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+		if len(events) < h.exportBatchSize {
+			return
+		}
+
+		last := events[len(events)-1].SequenceNumber
+		if events, err = h.querier.ListScopedInTimeRange(ctx, scope, last, start, end, h.exportBatchSize); err != nil {
+			// The status is already sent, the truncated export is only reported in the logs
+			slog.ErrorContext(ctx, "Failed to read exported events", "error", err)
+			return
+		}
+	}
+}
+
+// parseOptionalTime parses an RFC 3339 time, nil when the value is empty
+func parseOptionalTime(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// eventExportWriter encodes the exported events
+type eventExportWriter interface {
+	Write(event *domain.Event) error
+	Flush() error
+}
+
+// jsonlEventWriter writes one event response per line
+type jsonlEventWriter struct {
+	encoder *json.Encoder
+	toRes   func(*domain.Event) any
+}
+
+func newJSONLEventWriter(w io.Writer, toRes func(*domain.Event) any) *jsonlEventWriter {
+	return &jsonlEventWriter{encoder: json.NewEncoder(w), toRes: toRes}
+}
+
+func (w *jsonlEventWriter) Write(event *domain.Event) error {
+	return w.encoder.Encode(w.toRes(event))
+}
+
+func (w *jsonlEventWriter) Flush() error {
+	return nil
+}
+
+// eventCSVHeader lists the columns of the CSV export, the payload is flattened in the
+// changedPaths, diff and properties columns so every row has the same columns
+var eventCSVHeader = []string{
+	"id", "sequenceNumber", "createdAt", "type", "initiatorType", "initiatorId",
+	"entityId", "providerId", "agentId", "consumerId",
+	"changedPaths", "diff", "properties",
+}
+
+// csvEventWriter writes one event per row after the header row
+type csvEventWriter struct {
+	writer        *csv.Writer
+	headerWritten bool
+}
+
+func newCSVEventWriter(w io.Writer) *csvEventWriter {
+	return &csvEventWriter{writer: csv.NewWriter(w)}
+}
+
+func (w *csvEventWriter) Write(event *domain.Event) error {
+	if !w.headerWritten {
+		if err := w.writer.Write(eventCSVHeader); err != nil {
+			return err
+		}
+		w.headerWritten = true
+	}
+	record, err := eventCSVRecord(event)
+	if err != nil {
+		return err
+	}
+	return w.writer.Write(record)
+}
+
+func (w *csvEventWriter) Flush() error {
+	if !w.headerWritten {
+		if err := w.writer.Write(eventCSVHeader); err != nil {
+			return err
+		}
+		w.headerWritten = true
+	}
+	w.writer.Flush()
+	return w.writer.Error()
+}
+
+// eventCSVRecord flattens an event in the columns of eventCSVHeader
+// The diff is kept as its JSON Patch with the sorted list of the changed paths, the other
+// payload properties are serialized as a JSON object with sorted keys
+func eventCSVRecord(event *domain.Event) ([]string, error) {
+	var changedPaths, diff, props string
+	rest := properties.JSON{}
+	for key, value := range event.Payload {
+		if key != "diff" {
+			rest[key] = value
+			continue
+		}
+		data, err := json.Marshal(value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to serialize event %s diff: %w", event.ID, err)
+		}
+		diff = string(data)
+		var ops []struct {
+			Path string `json:"path"`
+		}
+		if err := json.Unmarshal(data, &ops); err == nil {
+			paths := make([]string, 0, len(ops))
+			for _, op := range ops {
+				paths = append(paths, op.Path)
+			}
+			slices.Sort(paths)
+			changedPaths = strings.Join(slices.Compact(paths), ";")
+		}
+	}
+	if len(rest) > 0 {
+		data, err := json.Marshal(rest)
+		if err != nil {
+			return nil, fmt.Errorf("failed to serialize event %s properties: %w", event.ID, err)
+		}
+		props = string(data)
+	}
+
+	return []string{
+		event.ID.String(),
+		strconv.FormatInt(event.SequenceNumber, 10),
+		event.CreatedAt.UTC().Format(ISO8601UTC),
+		string(event.Type),
+		string(event.InitiatorType),
+		event.InitiatorID,
+		optionalUUIDString(event.EntityID),
+		optionalUUIDString(event.ProviderID),
+		optionalUUIDString(event.AgentID),
+		optionalUUIDString(event.ConsumerID),
+		changedPaths,
+		diff,
+		props,
+	}, nil
+}
+
+func optionalUUIDString(id *properties.UUID) string {
+	if id == nil {
+		return ""
+	}
+	return id.String()
+}
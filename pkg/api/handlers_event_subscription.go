@@ -0,0 +1,120 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/fulcrumproject/core/pkg/authz"
+	"github.com/fulcrumproject/core/pkg/domain"
+	"github.com/fulcrumproject/core/pkg/middlewares"
+	"github.com/fulcrumproject/core/pkg/properties"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+)
+
+type EventSubscriptionHandler struct {
+	querier domain.EventSubscriptionQuerier
+	authz   authz.Authorizer
+}
+
+func NewEventSubscriptionHandler(
+	querier domain.EventSubscriptionQuerier,
+	authz authz.Authorizer,
+) *EventSubscriptionHandler {
+	return &EventSubscriptionHandler{
+		querier: querier,
+		authz:   authz,
+	}
+}
+
+// Routes returns the router with all event subscription routes registered
+func (h *EventSubscriptionHandler) Routes() func(r chi.Router) {
+	return func(r chi.Router) {
+		r.Group(func(r chi.Router) {
+			r.Use(middlewares.ID)
+
+			// Deliveries endpoint - authorize using the participants of the subscription filter
+			r.With(
+				middlewares.AuthzFromID(authz.ObjectTypeEvent, authz.ActionRead, h.authz, h.querier.AuthScope),
+			).Get("/{id}/deliveries", h.Deliveries)
+		})
+	}
+}
+
+// EventDeliveryRes represents a webhook delivery attempt
+type EventDeliveryRes struct {
+	ID             properties.UUID `json:"id"`
+	EventID        properties.UUID `json:"eventId"`
+	SequenceNumber int64           `json:"sequenceNumber"`
+	Attempt        int             `json:"attempt"`
+	StatusCode     *int            `json:"statusCode,omitempty"`
+	LatencyMs      int64           `json:"latencyMs"`
+	Error          *string         `json:"error,omitempty"`
+	Success        bool            `json:"success"`
+	AttemptedAt    JSONUTCTime     `json:"attemptedAt"`
+}
+
+// EventDeliveryToRes converts a domain.EventDelivery to an EventDeliveryRes
+func EventDeliveryToRes(d *domain.EventDelivery) *EventDeliveryRes {
+	return &EventDeliveryRes{
+		ID:             d.ID,
+		EventID:        d.EventID,
+		SequenceNumber: d.SequenceNumber,
+		Attempt:        d.Attempt,
+		StatusCode:     d.StatusCode,
+		LatencyMs:      d.LatencyMs,
+		Error:          d.Error,
+		Success:        d.Success,
+		AttemptedAt:    JSONUTCTime(d.CreatedAt),
+	}
+}
+
+// EventDeliverySummaryRes represents the delivery health of a subscription
+type EventDeliverySummaryRes struct {
+	SuccessRate    *float64     `json:"successRate"`
+	LastSuccessAt  *JSONUTCTime `json:"lastSuccessAt,omitempty"`
+	PendingRetries int          `json:"pendingRetries"`
+}
+
+// EventDeliveriesRes represents a page of the delivery attempts of a subscription with its delivery summary
+type EventDeliveriesRes struct {
+	*PageRes[EventDeliveryRes]
+	Summary EventDeliverySummaryRes `json:"summary"`
+}
+
+// Deliveries returns the latest webhook delivery attempts of a subscription and a summary of its delivery health
+func (h *EventSubscriptionHandler) Deliveries(w http.ResponseWriter, r *http.Request) {
+	id := middlewares.MustGetID(r.Context())
+	pag, err := ParsePageRequest(r)
+	if err != nil {
+		render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+
+	subscription, err := h.querier.Get(r.Context(), id)
+	if err != nil {
+		render.Render(w, r, ErrDomain(err))
+		return
+	}
+
+	result, err := h.querier.ListDeliveries(r.Context(), id, pag)
+	if err != nil {
+		render.Render(w, r, ErrDomain(err))
+		return
+	}
+
+	stats, err := h.querier.DeliveryStats(r.Context(), id)
+	if err != nil {
+		render.Render(w, r, ErrDomain(err))
+		return
+	}
+	summary := domain.NewEventDeliverySummary(subscription, stats)
+
+	render.JSON(w, r, EventDeliveriesRes{
+		PageRes: NewPageResponse(result, EventDeliveryToRes),
+		Summary: EventDeliverySummaryRes{
+			SuccessRate:    summary.SuccessRate,
+			LastSuccessAt:  (*JSONUTCTime)(summary.LastSuccessAt),
+			PendingRetries: summary.PendingRetries,
+		},
+	})
+}
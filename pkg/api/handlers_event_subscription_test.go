@@ -0,0 +1,117 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/fulcrumproject/core/pkg/authz"
+	"github.com/fulcrumproject/core/pkg/domain"
+	"github.com/fulcrumproject/core/pkg/helpers"
+	"github.com/fulcrumproject/core/pkg/middlewares"
+	"github.com/fulcrumproject/core/pkg/properties"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventSubscriptionHandlerRoutes(t *testing.T) {
+	querier := domain.NewMockEventSubscriptionQuerier(t)
+	authz := authz.NewMockAuthorizer(t)
+	handler := NewEventSubscriptionHandler(querier, authz)
+
+	r := chi.NewRouter()
+	handler.Routes()(r)
+
+	walkFunc := func(method string, route string, handler http.Handler, middlewares ...func(http.Handler) http.Handler) error {
+		switch {
+		case method == "GET" && route == "/{id}/deliveries":
+		default:
+			return fmt.Errorf("unexpected route: %s %s", method, route)
+		}
+		return nil
+	}
+	assert.NoError(t, chi.Walk(r, walkFunc))
+}
+
+func TestEventSubscriptionHandlerDeliveries(t *testing.T) {
+	subscriptionID := properties.NewUUID()
+	attemptedAt := time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC)
+	subscription := domain.NewEventSubscription("test-subscriber")
+	subscription.ID = subscriptionID
+	subscription.FailureCount = 1
+
+	tests := []struct {
+		name           string
+		query          string
+		mockSetup      func(querier *domain.MockEventSubscriptionQuerier)
+		expectedStatus int
+	}{
+		{
+			name: "Success",
+			mockSetup: func(querier *domain.MockEventSubscriptionQuerier) {
+				querier.EXPECT().Get(mock.Anything, subscriptionID).Return(subscription, nil)
+				querier.EXPECT().ListDeliveries(mock.Anything, subscriptionID, mock.Anything).Return(&domain.PageRes[domain.EventDelivery]{
+					Items: []domain.EventDelivery{
+						{ID: properties.NewUUID(), CreatedAt: attemptedAt, SequenceNumber: 12, Attempt: 2, StatusCode: helpers.IntPtr(503), LatencyMs: 120, Error: helpers.StringPtr("callback returned status 503")},
+					},
+					TotalItems: 1, TotalPages: 1, CurrentPage: 1,
+				}, nil)
+				querier.EXPECT().DeliveryStats(mock.Anything, subscriptionID).Return(&domain.EventDeliveryStats{Attempts: 4, Successes: 3, LastSuccessAt: &attemptedAt}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "Subscription not found",
+			mockSetup: func(querier *domain.MockEventSubscriptionQuerier) {
+				querier.EXPECT().Get(mock.Anything, subscriptionID).Return(nil, domain.NewNotFoundErrorf("event subscription"))
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "Invalid page",
+			query:          "?page=zero",
+			mockSetup:      func(querier *domain.MockEventSubscriptionQuerier) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			querier := domain.NewMockEventSubscriptionQuerier(t)
+			tc.mockSetup(querier)
+			handler := &EventSubscriptionHandler{querier: querier}
+
+			r := chi.NewRouter()
+			r.With(middlewares.ID).Get("/{id}/deliveries", handler.Deliveries)
+			req := httptest.NewRequest("GET", "/"+subscriptionID.String()+"/deliveries"+tc.query, nil)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			if tc.expectedStatus != http.StatusOK {
+				return
+			}
+			var res map[string]any
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+			items := res["items"].([]any)
+			require.Len(t, items, 1)
+			item := items[0].(map[string]any)
+			assert.Equal(t, float64(2), item["attempt"])
+			assert.Equal(t, float64(503), item["statusCode"])
+			assert.Equal(t, float64(120), item["latencyMs"])
+			assert.Equal(t, "callback returned status 503", item["error"])
+			assert.Equal(t, "2025-03-01T10:00:00Z", item["attemptedAt"])
+			assert.Equal(t, float64(1), res["totalItems"])
+
+			summary := res["summary"].(map[string]any)
+			assert.Equal(t, 0.75, summary["successRate"])
+			assert.Equal(t, "2025-03-01T10:00:00Z", summary["lastSuccessAt"])
+			assert.Equal(t, float64(1), summary["pendingRetries"])
+		})
+	}
+}
@@ -1,6 +1,9 @@
 package api
 
 import (
+	"bufio"
+	"context"
+	"encoding/csv"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -56,8 +59,12 @@ func TestEventyHandlerRoutes(t *testing.T) {
 		// Check expected routes exist
 		switch {
 		case method == "GET" && route == "/":
+		case method == "GET" && route == "/stream":
+		case method == "GET" && route == "/export":
 		case method == "POST" && route == "/lease":
 		case method == "POST" && route == "/ack":
+		case method == "POST" && route == "/webhook":
+		case method == "POST" && route == "/filter":
 		default:
 			return fmt.Errorf("unexpected route: %s %s", method, route)
 		}
@@ -112,6 +119,7 @@ func TestEventyToResponse(t *testing.T) {
 		Agent:         agent,
 		ConsumerID:    &consumerID,
 		Consumer:      consumer,
+		RequestID:     "req-42",
 	}
 
 	response := EventToRes(eventEntry)
@@ -124,6 +132,7 @@ func TestEventyToResponse(t *testing.T) {
 	assert.Equal(t, eventEntry.ProviderID, response.ProviderID)
 	assert.Equal(t, eventEntry.AgentID, response.AgentID)
 	assert.Equal(t, eventEntry.ConsumerID, response.ConsumerID)
+	assert.Equal(t, "req-42", response.RequestID)
 	assert.Equal(t, JSONUTCTime(eventEntry.CreatedAt), response.CreatedAt)
 	assert.Equal(t, JSONUTCTime(eventEntry.UpdatedAt), response.UpdatedAt)
 
@@ -181,7 +190,7 @@ func TestEventHandleLease(t *testing.T) {
 				updatedAt := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
 
 				querier.EXPECT().
-					ListFromSequence(mock.Anything, int64(100), 10).
+					ListFromSequence(mock.Anything, int64(100), domain.EventFilter{}, 10).
 					Return([]*domain.Event{
 						{
 							BaseEntity: domain.BaseEntity{
@@ -313,6 +322,66 @@ func TestEventHandleLease(t *testing.T) {
 	}
 }
 
+// TestEventHandleLease_CloudEvents tests the negotiation of the format of the leased events
+func TestEventHandleLease_CloudEvents(t *testing.T) {
+	eventID := properties.NewUUID()
+	testCases := []struct {
+		name       string
+		format     domain.EventFormat
+		accept     string
+		cloudEvent bool
+	}{
+		{name: "Native by default", format: domain.EventFormatNative},
+		{name: "Subscription format", format: domain.EventFormatCloudEvents, cloudEvent: true},
+		{name: "Accept header", format: domain.EventFormatNative, accept: "application/cloudevents+json", cloudEvent: true},
+		{name: "Accept header overrides the subscription", format: domain.EventFormatCloudEvents, accept: "application/json"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			querier := domain.NewMockEventQuerier(t)
+			cmd := domain.NewMockEventSubscriptionCommander(t)
+			leaseExpiresAt := time.Now().Add(5 * time.Minute)
+			instanceID := "instance-1"
+			cmd.EXPECT().AcquireLease(mock.Anything, mock.Anything).Return(&domain.EventSubscription{
+				SubscriberID:         "test-subscriber",
+				LeaseOwnerInstanceID: &instanceID,
+				LeaseExpiresAt:       &leaseExpiresAt,
+				IsActive:             true,
+				Format:               tc.format,
+			}, nil)
+			querier.EXPECT().ListFromSequence(mock.Anything, int64(0), domain.EventFilter{}, DefaultEventLimit).Return([]*domain.Event{
+				{BaseEntity: domain.BaseEntity{ID: eventID}, SequenceNumber: 1, Type: domain.EventTypeParticipantCreated},
+			}, nil)
+			handler := NewEventHandler(querier, cmd, authz.NewMockAuthorizer(t))
+
+			req := httptest.NewRequest("POST", "/lease", strings.NewReader(`{"subscriberId": "test-subscriber", "instanceId": "instance-1"}`))
+			req.Header.Set("Content-Type", "application/json")
+			if tc.accept != "" {
+				req.Header.Set("Accept", tc.accept)
+			}
+			req = req.WithContext(auth.WithIdentity(req.Context(), newMockAuthAgent()))
+			rr := httptest.NewRecorder()
+			handler.Lease(rr, req)
+
+			require.Equal(t, http.StatusOK, rr.Code)
+			var response struct {
+				Events []map[string]any `json:"events"`
+			}
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+			require.Len(t, response.Events, 1)
+			if tc.cloudEvent {
+				assert.Equal(t, "1.0", response.Events[0]["specversion"])
+				assert.Equal(t, "fulcrum.participant.created", response.Events[0]["type"])
+			} else {
+				assert.Equal(t, "participant.created", response.Events[0]["type"])
+				assert.NotContains(t, response.Events[0], "specversion")
+			}
+			assert.Equal(t, eventID.String(), response.Events[0]["id"])
+		})
+	}
+}
+
 // TestEventLeaseRequest_Bind tests the Bind method
 func TestEventLeaseRequest_Bind(t *testing.T) {
 	testCases := []struct {
@@ -563,3 +632,474 @@ func TestEventAckRequest_Bind(t *testing.T) {
 		})
 	}
 }
+
+// TestEventHandleConfigureWebhook tests the webhook configuration endpoint
+func TestEventHandleConfigureWebhook(t *testing.T) {
+	callbackURL := "https://example.com/hook"
+	secretRef := "secret-ref"
+
+	testCases := []struct {
+		name           string
+		requestBody    string
+		setupMock      func(*domain.MockEventSubscriptionCommander)
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:        "Success - webhook configured",
+			requestBody: `{"subscriberId": "test-subscriber", "callbackUrl": "https://example.com/hook", "secret": "s3cret"}`,
+			setupMock: func(cmd *domain.MockEventSubscriptionCommander) {
+				cmd.EXPECT().
+					ConfigureWebhook(mock.Anything, mock.MatchedBy(func(params domain.ConfigureWebhookParams) bool {
+						return params.SubscriberID == "test-subscriber" &&
+							params.CallbackURL == callbackURL &&
+							params.Secret != nil && *params.Secret == "s3cret"
+					})).
+					Return(&domain.EventSubscription{
+						SubscriberID:               "test-subscriber",
+						LastEventSequenceProcessed: 5,
+						IsActive:                   true,
+						CallbackURL:                &callbackURL,
+						SecretRef:                  &secretRef,
+					}, nil)
+			},
+			expectedStatus: 200,
+			expectedBody:   `{"id":"00000000-0000-0000-0000-000000000000","subscriberId":"test-subscriber","lastEventSequenceProcessed":5,"isActive":true,"format":"native","callbackUrl":"https://example.com/hook","hasSecret":true,"failureCount":0,"filter":{"types":[]}}`,
+		},
+		{
+			name:        "Success - CloudEvents format",
+			requestBody: `{"subscriberId": "test-subscriber", "callbackUrl": "https://example.com/hook", "format": "cloudevents"}`,
+			setupMock: func(cmd *domain.MockEventSubscriptionCommander) {
+				cmd.EXPECT().
+					ConfigureWebhook(mock.Anything, mock.MatchedBy(func(params domain.ConfigureWebhookParams) bool {
+						return params.Format != nil && *params.Format == domain.EventFormatCloudEvents
+					})).
+					Return(&domain.EventSubscription{
+						SubscriberID: "test-subscriber",
+						IsActive:     true,
+						CallbackURL:  &callbackURL,
+						Format:       domain.EventFormatCloudEvents,
+					}, nil)
+			},
+			expectedStatus: 200,
+			expectedBody:   `{"id":"00000000-0000-0000-0000-000000000000","subscriberId":"test-subscriber","lastEventSequenceProcessed":0,"isActive":true,"format":"cloudevents","callbackUrl":"https://example.com/hook","hasSecret":false,"failureCount":0,"filter":{"types":[]}}`,
+		},
+		{
+			name:           "Invalid request - unknown format",
+			requestBody:    `{"subscriberId": "test-subscriber", "callbackUrl": "https://example.com/hook", "format": "xml"}`,
+			setupMock:      func(cmd *domain.MockEventSubscriptionCommander) {},
+			expectedStatus: 400,
+			expectedBody:   `invalid event format`,
+		},
+		{
+			name:        "Invalid callback URL",
+			requestBody: `{"subscriberId": "test-subscriber", "callbackUrl": "ftp://example.com"}`,
+			setupMock: func(cmd *domain.MockEventSubscriptionCommander) {
+				cmd.EXPECT().
+					ConfigureWebhook(mock.Anything, mock.Anything).
+					Return(nil, domain.NewInvalidInputErrorf("invalid callback_url ftp://example.com: must be an absolute http or https URL"))
+			},
+			expectedStatus: 400,
+			expectedBody:   `must be an absolute http or https URL`,
+		},
+		{
+			name:           "Invalid request - missing callbackUrl",
+			requestBody:    `{"subscriberId": "test-subscriber"}`,
+			setupMock:      func(cmd *domain.MockEventSubscriptionCommander) {},
+			expectedStatus: 400,
+			expectedBody:   `"callbackUrl is required"`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			querier := domain.NewMockEventQuerier(t)
+			eventSubscriptionCmd := domain.NewMockEventSubscriptionCommander(t)
+			tc.setupMock(eventSubscriptionCmd)
+			authz := authz.NewMockAuthorizer(t)
+
+			handler := NewEventHandler(querier, eventSubscriptionCmd, authz)
+
+			req := httptest.NewRequest("POST", "/webhook", strings.NewReader(tc.requestBody))
+			req.Header.Set("Content-Type", "application/json")
+			req = req.WithContext(auth.WithIdentity(req.Context(), newMockAuthAgent()))
+			w := httptest.NewRecorder()
+
+			handler.ConfigureWebhook(w, req)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			if tc.expectedStatus == 200 {
+				assert.JSONEq(t, tc.expectedBody, w.Body.String())
+			} else {
+				assert.Contains(t, w.Body.String(), tc.expectedBody)
+			}
+		})
+	}
+}
+
+// TestEventHandleSetFilter tests the event filter configuration endpoint
+func TestEventHandleSetFilter(t *testing.T) {
+	providerID := properties.UUID(uuid.MustParse("550e8400-e29b-41d4-a716-446655440000"))
+
+	testCases := []struct {
+		name           string
+		requestBody    string
+		setupMock      func(*domain.MockEventSubscriptionCommander)
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:        "Success - filter configured",
+			requestBody: `{"subscriberId": "test-subscriber", "types": ["service.created", "service.updated"], "providerId": "550e8400-e29b-41d4-a716-446655440000"}`,
+			setupMock: func(cmd *domain.MockEventSubscriptionCommander) {
+				cmd.EXPECT().
+					SetFilter(mock.Anything, mock.MatchedBy(func(params domain.SetEventFilterParams) bool {
+						return params.SubscriberID == "test-subscriber" &&
+							len(params.Filter.Types) == 2 &&
+							params.Filter.ProviderID != nil && *params.Filter.ProviderID == providerID &&
+							params.Filter.EntityID == nil && params.Filter.ConsumerID == nil
+					})).
+					RunAndReturn(func(ctx context.Context, params domain.SetEventFilterParams) (*domain.EventSubscription, error) {
+						return &domain.EventSubscription{SubscriberID: params.SubscriberID, IsActive: true, Filter: params.Filter}, nil
+					})
+			},
+			expectedStatus: 200,
+			expectedBody:   `{"id":"00000000-0000-0000-0000-000000000000","subscriberId":"test-subscriber","lastEventSequenceProcessed":0,"isActive":true,"format":"native","hasSecret":false,"failureCount":0,"filter":{"types":["service.created","service.updated"],"providerId":"550e8400-e29b-41d4-a716-446655440000"}}`,
+		},
+		{
+			name:        "Another participant",
+			requestBody: `{"subscriberId": "test-subscriber", "providerId": "550e8400-e29b-41d4-a716-446655440000"}`,
+			setupMock: func(cmd *domain.MockEventSubscriptionCommander) {
+				cmd.EXPECT().
+					SetFilter(mock.Anything, mock.Anything).
+					Return(nil, domain.NewUnauthorizedErrorf("cannot subscribe to the events of participant 550e8400-e29b-41d4-a716-446655440000"))
+			},
+			expectedStatus: 403,
+			expectedBody:   `cannot subscribe to the events of participant`,
+		},
+		{
+			name:           "Invalid request - missing subscriberId",
+			requestBody:    `{"types": ["service.created"]}`,
+			setupMock:      func(cmd *domain.MockEventSubscriptionCommander) {},
+			expectedStatus: 400,
+			expectedBody:   `"subscriberId is required"`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			querier := domain.NewMockEventQuerier(t)
+			eventSubscriptionCmd := domain.NewMockEventSubscriptionCommander(t)
+			tc.setupMock(eventSubscriptionCmd)
+			authz := authz.NewMockAuthorizer(t)
+
+			handler := NewEventHandler(querier, eventSubscriptionCmd, authz)
+
+			req := httptest.NewRequest("POST", "/filter", strings.NewReader(tc.requestBody))
+			req.Header.Set("Content-Type", "application/json")
+			req = req.WithContext(auth.WithIdentity(req.Context(), newMockAuthAgent()))
+			w := httptest.NewRecorder()
+
+			handler.SetFilter(w, req)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			if tc.expectedStatus == 200 {
+				assert.JSONEq(t, tc.expectedBody, w.Body.String())
+			} else {
+				assert.Contains(t, w.Body.String(), tc.expectedBody)
+			}
+		})
+	}
+}
+
+// TestEventHandleListAfterSequence tests the replay of the events after a sequence number
+func TestEventHandleListAfterSequence(t *testing.T) {
+	participantID := properties.NewUUID()
+	scopeMatcher := mock.MatchedBy(func(scope *auth.IdentityScope) bool {
+		return scope.ParticipantID != nil && *scope.ParticipantID == participantID
+	})
+	newEvent := func(sequence int64) *domain.Event {
+		return &domain.Event{BaseEntity: domain.BaseEntity{ID: properties.NewUUID()}, SequenceNumber: sequence, Type: domain.EventTypeServiceCreated}
+	}
+
+	doRequest := func(querier *domain.MockEventQuerier, query string) *httptest.ResponseRecorder {
+		handler := NewEventHandler(querier, domain.NewMockEventSubscriptionCommander(t), authz.NewMockAuthorizer(t))
+		req := httptest.NewRequest("GET", "/events?"+query, nil)
+		req = req.WithContext(auth.WithIdentity(req.Context(), newMockAuthParticipant(participantID)))
+		w := httptest.NewRecorder()
+		handler.List(w, req)
+		return w
+	}
+
+	t.Run("Returns the events in sequence order with the cursor", func(t *testing.T) {
+		querier := domain.NewMockEventQuerier(t)
+		querier.EXPECT().ListScopedFromSequence(mock.Anything, scopeMatcher, int64(41), []domain.EventType(nil), 3).
+			Return([]*domain.Event{newEvent(42), newEvent(43), newEvent(44)}, nil)
+
+		w := doRequest(querier, "afterSequence=41&pageSize=2")
+
+		require.Equal(t, http.StatusOK, w.Code)
+		var res struct {
+			Items        []EventRes `json:"items"`
+			LastSequence int64      `json:"lastSequence"`
+			HasMore      bool       `json:"hasMore"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+		require.Len(t, res.Items, 2)
+		assert.Equal(t, int64(42), res.Items[0].SequenceNumber)
+		assert.Equal(t, int64(43), res.Items[1].SequenceNumber)
+		assert.Equal(t, int64(43), res.LastSequence)
+		assert.True(t, res.HasMore)
+	})
+
+	t.Run("Keeps the cursor without new events", func(t *testing.T) {
+		querier := domain.NewMockEventQuerier(t)
+		querier.EXPECT().ListScopedFromSequence(mock.Anything, scopeMatcher, int64(7), []domain.EventType{domain.EventTypeServiceCreated}, DefaultEventLimit+1).
+			Return(nil, nil)
+
+		w := doRequest(querier, "afterSequence=7&type=service.created")
+
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"items":[],"lastSequence":7,"hasMore":false}`, w.Body.String())
+	})
+
+	t.Run("Invalid cursor", func(t *testing.T) {
+		w := doRequest(domain.NewMockEventQuerier(t), "afterSequence=-1")
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Invalid page size", func(t *testing.T) {
+		w := doRequest(domain.NewMockEventQuerier(t), fmt.Sprintf("afterSequence=0&pageSize=%d", MaxEventLimit+1))
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+// TestEventHandleStream tests the Server-Sent Events stream
+func TestEventHandleStream(t *testing.T) {
+	participantID := properties.NewUUID()
+	identity := &auth.Identity{
+		ID:    properties.NewUUID(),
+		Name:  "test-participant",
+		Role:  auth.RoleParticipant,
+		Scope: auth.IdentityScope{ParticipantID: &participantID},
+	}
+
+	newServer := func(t *testing.T, handler *EventHandler) (*httptest.Server, chan struct{}) {
+		done := make(chan struct{})
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			defer close(done)
+			handler.Stream(w, r.WithContext(auth.WithIdentity(r.Context(), identity)))
+		}))
+		t.Cleanup(server.Close)
+		return server, done
+	}
+
+	newHandler := func(querier *domain.MockEventQuerier) *EventHandler {
+		handler := NewEventHandler(querier, domain.NewMockEventSubscriptionCommander(t), authz.NewMockAuthorizer(t))
+		handler.streamPollInterval = 10 * time.Millisecond
+		return handler
+	}
+
+	waitDone := func(t *testing.T, done chan struct{}) {
+		select {
+		case <-done:
+		case <-time.After(2 * time.Second):
+			t.Fatal("stream did not end")
+		}
+	}
+
+	t.Run("Streams new scoped events from now", func(t *testing.T) {
+		querier := domain.NewMockEventQuerier(t)
+		querier.EXPECT().LastSequenceNumber(mock.Anything).Return(10, nil)
+		scopeMatcher := mock.MatchedBy(func(scope *auth.IdentityScope) bool {
+			return scope.ParticipantID != nil && *scope.ParticipantID == participantID
+		})
+		typesMatcher := []domain.EventType{domain.EventTypeServiceCreated, domain.EventTypeServiceUpdated}
+		querier.EXPECT().ListScopedFromSequence(mock.Anything, scopeMatcher, int64(10), typesMatcher, StreamBatchSize).
+			Return([]*domain.Event{
+				{BaseEntity: domain.BaseEntity{ID: properties.NewUUID()}, SequenceNumber: 11, Type: domain.EventTypeServiceCreated},
+				{BaseEntity: domain.BaseEntity{ID: properties.NewUUID()}, SequenceNumber: 12, Type: domain.EventTypeServiceUpdated},
+			}, nil).Once()
+		querier.EXPECT().ListScopedFromSequence(mock.Anything, scopeMatcher, int64(12), typesMatcher, StreamBatchSize).
+			Return(nil, nil).Maybe()
+
+		server, done := newServer(t, newHandler(querier))
+		ctx, cancel := context.WithCancel(context.Background())
+		req, _ := http.NewRequestWithContext(ctx, "GET", server.URL+"?type=service.created,service.updated", nil)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+		reader := bufio.NewReader(resp.Body)
+		var lines []string
+		for len(lines) < 8 {
+			line, err := reader.ReadString('\n')
+			require.NoError(t, err)
+			lines = append(lines, strings.TrimSuffix(line, "\n"))
+		}
+		assert.Equal(t, "id: 11", lines[0])
+		assert.Equal(t, "event: service.created", lines[1])
+		assert.Contains(t, lines[2], `"sequenceNumber":11`)
+		assert.Equal(t, "", lines[3])
+		assert.Equal(t, "id: 12", lines[4])
+
+		// The handler returns once the client disconnects
+		cancel()
+		waitDone(t, done)
+	})
+
+	t.Run("Resumes from lastEventId", func(t *testing.T) {
+		querier := domain.NewMockEventQuerier(t)
+		querier.EXPECT().ListScopedFromSequence(mock.Anything, mock.Anything, int64(42), []domain.EventType(nil), StreamBatchSize).
+			Return(nil, nil)
+
+		handler := newHandler(querier)
+		server, done := newServer(t, handler)
+		resp, err := http.Get(server.URL + "?lastEventId=42")
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+		// Wait for a poll then end the stream as on server shutdown
+		time.Sleep(30 * time.Millisecond)
+		handler.CloseStreams()
+		waitDone(t, done)
+	})
+
+	t.Run("Invalid lastEventId", func(t *testing.T) {
+		server, _ := newServer(t, newHandler(domain.NewMockEventQuerier(t)))
+		resp, err := http.Get(server.URL + "?lastEventId=abc")
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+}
+
+func TestEventHandleExport(t *testing.T) {
+	participantID := properties.NewUUID()
+	identity := &auth.Identity{
+		ID:    properties.NewUUID(),
+		Name:  "test-participant",
+		Role:  auth.RoleParticipant,
+		Scope: auth.IdentityScope{ParticipantID: &participantID},
+	}
+	scopeMatcher := mock.MatchedBy(func(scope *auth.IdentityScope) bool {
+		return scope.ParticipantID != nil && *scope.ParticipantID == participantID
+	})
+	createdAt := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	agentID := properties.NewUUID()
+	newEvent := func(seq int64) *domain.Event {
+		return &domain.Event{
+			BaseEntity:     domain.BaseEntity{ID: properties.NewUUID(), CreatedAt: createdAt},
+			SequenceNumber: seq,
+			Type:           domain.EventTypeServiceUpdated,
+			InitiatorType:  domain.InitiatorTypeUser,
+			InitiatorID:    "user-1",
+			AgentID:        &agentID,
+			Payload: properties.JSON{
+				"diff": []any{
+					map[string]any{"op": "replace", "path": "/status", "value": "Started"},
+					map[string]any{"op": "replace", "path": "/name", "value": "new"},
+				},
+				"reason": "manual",
+			},
+		}
+	}
+
+	newHandler := func(querier *domain.MockEventQuerier) *EventHandler {
+		handler := NewEventHandler(querier, domain.NewMockEventSubscriptionCommander(t), authz.NewMockAuthorizer(t))
+		handler.exportBatchSize = 2
+		return handler
+	}
+
+	export := func(handler *EventHandler, query string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/export"+query, nil)
+		req = req.WithContext(auth.WithIdentity(req.Context(), identity))
+		w := httptest.NewRecorder()
+		handler.Export(w, req)
+		return w
+	}
+
+	t.Run("JSON Lines in batches", func(t *testing.T) {
+		querier := domain.NewMockEventQuerier(t)
+		querier.EXPECT().ListScopedInTimeRange(mock.Anything, scopeMatcher, int64(0), (*time.Time)(nil), (*time.Time)(nil), 2).
+			Return([]*domain.Event{newEvent(1), newEvent(2)}, nil)
+		querier.EXPECT().ListScopedInTimeRange(mock.Anything, scopeMatcher, int64(2), (*time.Time)(nil), (*time.Time)(nil), 2).
+			Return([]*domain.Event{newEvent(5)}, nil)
+
+		w := export(newHandler(querier), "")
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "application/x-ndjson", w.Header().Get("Content-Type"))
+		assert.True(t, w.Flushed)
+		lines := strings.Split(strings.TrimSuffix(w.Body.String(), "\n"), "\n")
+		require.Len(t, lines, 3)
+		var res EventRes
+		require.NoError(t, json.Unmarshal([]byte(lines[2]), &res))
+		assert.Equal(t, int64(5), res.SequenceNumber)
+	})
+
+	t.Run("CSV with time range", func(t *testing.T) {
+		start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+		end := time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)
+		querier := domain.NewMockEventQuerier(t)
+		querier.EXPECT().ListScopedInTimeRange(mock.Anything, scopeMatcher, int64(0), &start, &end, 2).
+			Return([]*domain.Event{newEvent(7)}, nil)
+
+		w := export(newHandler(querier), "?format=csv&start=2025-01-01T00:00:00Z&end=2025-02-01T00:00:00Z")
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "text/csv; charset=utf-8", w.Header().Get("Content-Type"))
+		records, err := csv.NewReader(w.Body).ReadAll()
+		require.NoError(t, err)
+		require.Len(t, records, 2)
+		assert.Equal(t, eventCSVHeader, records[0])
+		row := map[string]string{}
+		for i, column := range records[0] {
+			row[column] = records[1][i]
+		}
+		assert.Equal(t, "7", row["sequenceNumber"])
+		assert.Equal(t, "2025-01-02T03:04:05Z", row["createdAt"])
+		assert.Equal(t, "service.updated", row["type"])
+		assert.Equal(t, agentID.String(), row["agentId"])
+		assert.Equal(t, "", row["consumerId"])
+		assert.Equal(t, "/name;/status", row["changedPaths"])
+		assert.Contains(t, row["diff"], `"path":"/status"`)
+		assert.Equal(t, `{"reason":"manual"}`, row["properties"])
+	})
+
+	t.Run("Empty CSV has the header", func(t *testing.T) {
+		querier := domain.NewMockEventQuerier(t)
+		querier.EXPECT().ListScopedInTimeRange(mock.Anything, mock.Anything, int64(0), mock.Anything, mock.Anything, 2).
+			Return(nil, nil)
+
+		w := export(newHandler(querier), "?format=csv")
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, strings.Join(eventCSVHeader, ",")+"\n", w.Body.String())
+	})
+
+	t.Run("Query failure before streaming", func(t *testing.T) {
+		querier := domain.NewMockEventQuerier(t)
+		querier.EXPECT().ListScopedInTimeRange(mock.Anything, mock.Anything, int64(0), mock.Anything, mock.Anything, 2).
+			Return(nil, fmt.Errorf("database error"))
+
+		w := export(newHandler(querier), "")
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+	})
+
+	t.Run("Invalid parameters", func(t *testing.T) {
+		for _, query := range []string{
+			"?format=xml",
+			"?start=yesterday",
+			"?end=2025-13-01T00:00:00Z",
+			"?start=2025-02-01T00:00:00Z&end=2025-01-01T00:00:00Z",
+		} {
+			w := export(newHandler(domain.NewMockEventQuerier(t)), query)
+			assert.Equal(t, http.StatusBadRequest, w.Code, query)
+		}
+	})
+}
@@ -2,8 +2,12 @@ package api
 
 import (
 	"context"
+	"fmt"
 	"net/http"
+	"slices"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/fulcrumproject/core/pkg/auth"
 	"github.com/fulcrumproject/core/pkg/middlewares"
@@ -19,10 +23,34 @@ type CompleteJobReq struct {
 	AgentInstanceData *properties.JSON `json:"agentInstanceData"`
 	AgentInstanceID   *string          `json:"agentInstanceId"`
 	Properties        *properties.JSON `json:"properties,omitempty"`
+	LeaseID           *properties.UUID `json:"leaseId,omitempty"`
+	Diagnostics       *properties.JSON `json:"diagnostics,omitempty"`
 }
 
 type FailJobReq struct {
-	ErrorMessage string `json:"errorMessage"`
+	ErrorMessage string           `json:"errorMessage"`
+	LeaseID      *properties.UUID `json:"leaseId,omitempty"`
+	Diagnostics  *properties.JSON `json:"diagnostics,omitempty"`
+}
+
+type RenewJobReq struct {
+	LeaseID properties.UUID `json:"leaseId"`
+}
+
+type ReportJobStepReq struct {
+	Name              string               `json:"name"`
+	Status            domain.JobStepStatus `json:"status"`
+	Message           string               `json:"message,omitempty"`
+	LeaseID           *properties.UUID     `json:"leaseId,omitempty"`
+	Final             bool                 `json:"final,omitempty"`
+	AgentInstanceData *properties.JSON     `json:"agentInstanceData,omitempty"`
+	AgentInstanceID   *string              `json:"agentInstanceId,omitempty"`
+	Properties        *properties.JSON     `json:"properties,omitempty"`
+	Diagnostics       *properties.JSON     `json:"diagnostics,omitempty"`
+}
+
+type UpdateJobReq struct {
+	Priority int `json:"priority"`
 }
 
 // JobHandler handles HTTP requests for jobs
@@ -53,6 +81,16 @@ func (h *JobHandler) Routes() func(r chi.Router) {
 			middlewares.AuthzSimple(authz.ObjectTypeJob, authz.ActionRead, h.authz),
 		).Get("/", List(h.querier, JobToRes))
 
+		// List dead-lettered jobs - simple authorization
+		r.With(
+			middlewares.AuthzSimple(authz.ObjectTypeJob, authz.ActionRead, h.authz),
+		).Get("/dead-letter", h.DeadLetter)
+
+		// Duration stats of the finished jobs - simple authorization, the jobs are scoped like the list
+		r.With(
+			middlewares.AuthzSimple(authz.ObjectTypeJob, authz.ActionRead, h.authz),
+		).Get("/stats", h.Stats)
+
 		// Agent job polling - requires agent identity
 		r.With(
 			middlewares.MustHaveRoles(auth.RoleAgent),
@@ -68,11 +106,34 @@ func (h *JobHandler) Routes() func(r chi.Router) {
 				middlewares.AuthzFromID(authz.ObjectTypeJob, authz.ActionRead, h.authz, h.querier.AuthScope),
 			).Get("/{id}", Get(h.querier.Get, JobToRes))
 
+			// Update job priority - authorize from job ID
+			r.With(
+				middlewares.DecodeBody[UpdateJobReq](),
+				middlewares.AuthzFromID(authz.ObjectTypeJob, authz.ActionUpdate, h.authz, h.querier.AuthScope),
+			).Patch("/{id}", Update(h.Update, JobToRes))
+
+			// Requeue a dead-lettered job - authorize from job ID
+			r.With(
+				middlewares.AuthzFromID(authz.ObjectTypeJob, authz.ActionRequeue, h.authz, h.querier.AuthScope),
+			).Post("/{id}/requeue", ActionWithoutBody(h.commander.Requeue, JobToRes))
+
 			// Agent actions - require agent identity and authorize from job ID
 			r.With(
 				middlewares.MustHaveRoles(auth.RoleAgent),
 				middlewares.AuthzFromID(authz.ObjectTypeJob, authz.ActionClaim, h.authz, h.querier.AuthScope),
-			).Post("/{id}/claim", CommandWithoutBody(h.commander.Claim))
+			).Post("/{id}/claim", ActionWithoutBody(h.commander.Claim, JobToRes))
+
+			r.With(
+				middlewares.MustHaveRoles(auth.RoleAgent),
+				middlewares.DecodeBody[RenewJobReq](),
+				middlewares.AuthzFromID(authz.ObjectTypeJob, authz.ActionRenew, h.authz, h.querier.AuthScope),
+			).Post("/{id}/renew", Action(h.Renew, JobToRes))
+
+			r.With(
+				middlewares.MustHaveRoles(auth.RoleAgent),
+				middlewares.DecodeBody[ReportJobStepReq](),
+				middlewares.AuthzFromID(authz.ObjectTypeJob, authz.ActionReportStep, h.authz, h.querier.AuthScope),
+			).Post("/{id}/steps", Action(h.ReportStep, JobToRes))
 
 			r.With(
 				middlewares.MustHaveRoles(auth.RoleAgent),
@@ -120,6 +181,77 @@ func (h *JobHandler) Pending(w http.ResponseWriter, r *http.Request) {
 	render.JSON(w, r, jobResponses)
 }
 
+// DeadLetter handles GET /jobs/dead-letter, the list of the jobs that used up their attempts
+func (h *JobHandler) DeadLetter(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	q.Set("status", string(domain.JobDeadLettered))
+	r.URL.RawQuery = q.Encode()
+	List(h.querier, JobToRes)(w, r)
+}
+
+// Stats handles GET /jobs/stats, the execution time percentiles of the jobs finished in the range
+func (h *JobHandler) Stats(w http.ResponseWriter, r *http.Request) {
+	id := auth.MustGetIdentity(r.Context())
+	query, err := parseJobDurationStatsQuery(r, time.Now())
+	if err != nil {
+		render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+
+	query.Scope = &id.Scope
+	stats, err := h.querier.DurationStats(r.Context(), *query)
+	if err != nil {
+		render.Render(w, r, ErrDomain(err))
+		return
+	}
+	render.JSON(w, r, JobDurationStatsToRes(stats, query))
+}
+
+// parseJobDurationStatsQuery reads the action, from, to and groupBy parameters,
+// the range defaults to the last day and the groups can be repeated or comma separated
+func parseJobDurationStatsQuery(r *http.Request, now time.Time) (*domain.JobDurationStatsQuery, error) {
+	q := r.URL.Query()
+
+	to := now
+	if toStr := q.Get("to"); toStr != "" {
+		var err error
+		if to, err = time.Parse(time.RFC3339, toStr); err != nil {
+			return nil, fmt.Errorf("invalid to parameter: %w", err)
+		}
+	}
+	from := to.Add(-domain.JobDurationStatsDefaultRange)
+	if fromStr := q.Get("from"); fromStr != "" {
+		var err error
+		if from, err = time.Parse(time.RFC3339, fromStr); err != nil {
+			return nil, fmt.Errorf("invalid from parameter: %w", err)
+		}
+	}
+
+	var groups []domain.JobStatsGroup
+	for _, value := range q["groupBy"] {
+		for name := range strings.SplitSeq(value, ",") {
+			group, err := domain.ParseJobStatsGroup(strings.TrimSpace(name))
+			if err != nil {
+				return nil, err
+			}
+			if !slices.Contains(groups, group) {
+				groups = append(groups, group)
+			}
+		}
+	}
+
+	query := &domain.JobDurationStatsQuery{
+		Action:  q.Get("action"),
+		From:    from,
+		To:      to,
+		GroupBy: groups,
+	}
+	if err := query.Validate(); err != nil {
+		return nil, err
+	}
+	return query, nil
+}
+
 // Adapter functions for standard handlers
 func (h *JobHandler) Complete(ctx context.Context, id properties.UUID, req *CompleteJobReq) error {
 	// Convert properties from JSON to map if provided
@@ -133,38 +265,90 @@ func (h *JobHandler) Complete(ctx context.Context, id properties.UUID, req *Comp
 		AgentInstanceData: req.AgentInstanceData,
 		AgentInstanceID:   req.AgentInstanceID,
 		Properties:        properties,
+		LeaseID:           req.LeaseID,
+		Diagnostics:       req.Diagnostics,
 	}
 	return h.commander.Complete(ctx, params)
 }
 
+func (h *JobHandler) Renew(ctx context.Context, id properties.UUID, req *RenewJobReq) (*domain.Job, error) {
+	params := domain.RenewJobLeaseParams{
+		JobID:   id,
+		LeaseID: req.LeaseID,
+	}
+	return h.commander.RenewLease(ctx, params)
+}
+
+func (h *JobHandler) ReportStep(ctx context.Context, id properties.UUID, req *ReportJobStepReq) (*domain.Job, error) {
+	var properties map[string]any
+	if req.Properties != nil {
+		properties = *req.Properties
+	}
+
+	params := domain.ReportJobStepParams{
+		JobID:             id,
+		Name:              req.Name,
+		Status:            req.Status,
+		Message:           req.Message,
+		LeaseID:           req.LeaseID,
+		Final:             req.Final,
+		AgentInstanceData: req.AgentInstanceData,
+		AgentInstanceID:   req.AgentInstanceID,
+		Properties:        properties,
+		Diagnostics:       req.Diagnostics,
+	}
+	return h.commander.ReportStep(ctx, params)
+}
+
+func (h *JobHandler) Update(ctx context.Context, id properties.UUID, req *UpdateJobReq) (*domain.Job, error) {
+	params := domain.UpdateJobPriorityParams{
+		JobID:    id,
+		Priority: req.Priority,
+	}
+	return h.commander.UpdatePriority(ctx, params)
+}
+
 func (h *JobHandler) Fail(ctx context.Context, id properties.UUID, req *FailJobReq) error {
 	params := domain.FailJobParams{
 		JobID:        id,
 		ErrorMessage: req.ErrorMessage,
+		LeaseID:      req.LeaseID,
+		Diagnostics:  req.Diagnostics,
 	}
 	return h.commander.Fail(ctx, params)
 }
 
 // JobRes represents the response for a job
 type JobRes struct {
-	ID           properties.UUID  `json:"id"`
-	ProviderID   properties.UUID  `json:"providerId"`
-	ConsumerID   properties.UUID  `json:"consumerId"`
-	AgentID      properties.UUID  `json:"agentId"`
-	ServiceID    properties.UUID  `json:"serviceId"`
-	Action       string           `json:"action"`
-	Params       *properties.JSON `json:"params,omitempty"`
-	Status       domain.JobStatus `json:"status"`
-	Priority     int              `json:"priority"`
-	ErrorMessage string           `json:"errorMessage,omitempty"`
-	ClaimedAt    *JSONUTCTime     `json:"claimedAt,omitempty"`
-	CompletedAt  *JSONUTCTime     `json:"completedAt,omitempty"`
-	CreatedAt    JSONUTCTime      `json:"createdAt"`
-	UpdatedAt    JSONUTCTime      `json:"updatedAt"`
-	Service      *ServiceRes      `json:"service,omitempty"`
-	Agent        *AgentRes        `json:"agent,omitempty"`
-	Provider     *ParticipantRes  `json:"provider,omitempty"`
-	Consumer     *ParticipantRes  `json:"consumer,omitempty"`
+	ID             properties.UUID  `json:"id"`
+	ProviderID     properties.UUID  `json:"providerId"`
+	ConsumerID     properties.UUID  `json:"consumerId"`
+	AgentID        properties.UUID  `json:"agentId"`
+	ServiceID      properties.UUID  `json:"serviceId"`
+	Action         string           `json:"action"`
+	Params         *properties.JSON `json:"params,omitempty"`
+	Status         domain.JobStatus `json:"status"`
+	Priority       int              `json:"priority"`
+	Attempt        int              `json:"attempt"`
+	TargetState    *string          `json:"targetState,omitempty"`
+	RequestID      string           `json:"requestId,omitempty"`
+	ErrorMessage   string           `json:"errorMessage,omitempty"`
+	Diagnostics    *properties.JSON `json:"diagnostics,omitempty"`
+	Steps          []*JobStepRes    `json:"steps,omitempty"`
+	LastStepAt     *JSONUTCTime     `json:"lastStepAt,omitempty"`
+	ScheduledAt    *JSONUTCTime     `json:"scheduledAt,omitempty"`
+	RequeuedAt     *JSONUTCTime     `json:"requeuedAt,omitempty"`
+	ClaimedAt      *JSONUTCTime     `json:"claimedAt,omitempty"`
+	CompletedAt    *JSONUTCTime     `json:"completedAt,omitempty"`
+	Duration       *JSONDuration    `json:"duration,omitempty"`
+	LeaseID        *properties.UUID `json:"leaseId,omitempty"`
+	LeaseExpiresAt *JSONUTCTime     `json:"leaseExpiresAt,omitempty"`
+	CreatedAt      JSONUTCTime      `json:"createdAt"`
+	UpdatedAt      JSONUTCTime      `json:"updatedAt"`
+	Service        *ServiceRes      `json:"service,omitempty"`
+	Agent          *AgentRes        `json:"agent,omitempty"`
+	Provider       *ParticipantRes  `json:"provider,omitempty"`
+	Consumer       *ParticipantRes  `json:"consumer,omitempty"`
 }
 
 // JobToRes converts a job entity to a response
@@ -179,16 +363,39 @@ func JobToRes(job *domain.Job) *JobRes {
 		Params:       job.Params,
 		Status:       job.Status,
 		Priority:     job.Priority,
+		Attempt:      job.Attempt,
+		TargetState:  job.TargetState,
+		RequestID:    job.RequestID,
 		ErrorMessage: job.ErrorMessage,
+		Diagnostics:  job.Diagnostics,
+		LeaseID:      job.LeaseID,
 		CreatedAt:    JSONUTCTime(job.CreatedAt),
 		UpdatedAt:    JSONUTCTime(job.UpdatedAt),
 	}
+	for _, step := range job.Steps {
+		resp.Steps = append(resp.Steps, JobStepToRes(step))
+	}
+	if job.LastStepAt != nil {
+		resp.LastStepAt = (*JSONUTCTime)(job.LastStepAt)
+	}
+	if job.ScheduledAt != nil {
+		resp.ScheduledAt = (*JSONUTCTime)(job.ScheduledAt)
+	}
+	if job.RequeuedAt != nil {
+		resp.RequeuedAt = (*JSONUTCTime)(job.RequeuedAt)
+	}
 	if job.ClaimedAt != nil {
 		resp.ClaimedAt = (*JSONUTCTime)(job.ClaimedAt)
 	}
 	if job.CompletedAt != nil {
 		resp.CompletedAt = (*JSONUTCTime)(job.CompletedAt)
 	}
+	if duration, ok := job.Duration(); ok {
+		resp.Duration = durationToJSON(&duration)
+	}
+	if job.LeaseExpiresAt != nil {
+		resp.LeaseExpiresAt = (*JSONUTCTime)(job.LeaseExpiresAt)
+	}
 	if job.Service != nil {
 		resp.Service = ServiceToRes(job.Service)
 	}
@@ -203,3 +410,64 @@ func JobToRes(job *domain.Job) *JobRes {
 	}
 	return resp
 }
+
+// JobStepRes represents a step reported on a job
+type JobStepRes struct {
+	Name       string               `json:"name"`
+	Status     domain.JobStepStatus `json:"status"`
+	Message    string               `json:"message,omitempty"`
+	ReportedAt JSONUTCTime          `json:"reportedAt"`
+}
+
+// JobStepToRes converts a job step to a response
+func JobStepToRes(step domain.JobStep) *JobStepRes {
+	return &JobStepRes{
+		Name:       step.Name,
+		Status:     step.Status,
+		Message:    step.Message,
+		ReportedAt: JSONUTCTime(step.ReportedAt),
+	}
+}
+
+// JobDurationStatsRes represents the response body of the job duration stats
+type JobDurationStatsRes struct {
+	Items   []*JobDurationStatsItemRes `json:"items"`
+	From    JSONUTCTime                `json:"from"`
+	To      JSONUTCTime                `json:"to"`
+	GroupBy []domain.JobStatsGroup     `json:"groupBy"`
+}
+
+// JobDurationStatsItemRes represents the duration stats of a group of jobs
+type JobDurationStatsItemRes struct {
+	Action    string       `json:"action,omitempty"`
+	AgentType string       `json:"agentType,omitempty"`
+	Count     int64        `json:"count"`
+	P50       JSONDuration `json:"p50"`
+	P95       JSONDuration `json:"p95"`
+	P99       JSONDuration `json:"p99"`
+}
+
+// JobDurationStatsToRes converts the job duration stats of a query to the response
+func JobDurationStatsToRes(stats []domain.JobDurationStats, query *domain.JobDurationStatsQuery) *JobDurationStatsRes {
+	items := make([]*JobDurationStatsItemRes, 0, len(stats))
+	for _, s := range stats {
+		items = append(items, &JobDurationStatsItemRes{
+			Action:    s.Action,
+			AgentType: s.AgentType,
+			Count:     s.Count,
+			P50:       JSONDuration(s.P50),
+			P95:       JSONDuration(s.P95),
+			P99:       JSONDuration(s.P99),
+		})
+	}
+	groups := query.GroupBy
+	if groups == nil {
+		groups = []domain.JobStatsGroup{}
+	}
+	return &JobDurationStatsRes{
+		Items:   items,
+		From:    JSONUTCTime(query.From),
+		To:      JSONUTCTime(query.To),
+		GroupBy: groups,
+	}
+}
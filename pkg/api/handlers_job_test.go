@@ -14,6 +14,7 @@ import (
 	"github.com/fulcrumproject/core/pkg/authz"
 	"github.com/fulcrumproject/core/pkg/domain"
 	"github.com/fulcrumproject/core/pkg/middlewares"
+	"github.com/fulcrumproject/core/pkg/properties"
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
@@ -112,6 +113,9 @@ func TestJobHandleGetPendingJobs(t *testing.T) {
 // TestJobHandleClaimJob tests the handleClaimJob method
 func TestJobHandleClaimJob(t *testing.T) {
 	// Setup test cases
+	leaseID := uuid.MustParse("660e8400-e29b-41d4-a716-446655440000")
+	leaseExpiresAt := time.Date(2025, 1, 1, 12, 1, 0, 0, time.UTC)
+
 	testCases := []struct {
 		name           string
 		id             string
@@ -129,9 +133,9 @@ func TestJobHandleClaimJob(t *testing.T) {
 
 				commander.EXPECT().
 					Claim(mock.Anything, mock.Anything).
-					Return(nil)
+					Return(&domain.Job{Status: domain.JobProcessing, LeaseID: &leaseID, LeaseExpiresAt: &leaseExpiresAt}, nil)
 			},
-			expectedStatus: http.StatusNoContent,
+			expectedStatus: http.StatusOK,
 		},
 		{
 			name: "ClaimError",
@@ -144,7 +148,7 @@ func TestJobHandleClaimJob(t *testing.T) {
 
 				commander.EXPECT().
 					Claim(mock.Anything, mock.Anything).
-					Return(domain.NewInvalidInputErrorf("job already claimed"))
+					Return(nil, domain.NewInvalidInputErrorf("job already claimed"))
 			},
 			expectedStatus: http.StatusBadRequest,
 		},
@@ -175,11 +179,17 @@ func TestJobHandleClaimJob(t *testing.T) {
 
 			// Execute request with middleware
 			w := httptest.NewRecorder()
-			middlewareHandler := middlewares.ID(CommandWithoutBody(handler.commander.Claim))
+			middlewareHandler := middlewares.ID(ActionWithoutBody(handler.commander.Claim, JobToRes))
 			middlewareHandler.ServeHTTP(w, req)
 
 			// Assert response
 			assert.Equal(t, tc.expectedStatus, w.Code)
+			if tc.expectedStatus == http.StatusOK {
+				var response map[string]any
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+				assert.Equal(t, leaseID.String(), response["leaseId"])
+				assert.Equal(t, "2025-01-01T12:01:00Z", response["leaseExpiresAt"])
+			}
 		})
 	}
 }
@@ -362,6 +372,23 @@ func TestJobHandleFailJob(t *testing.T) {
 			},
 			expectedStatus: http.StatusNoContent,
 		},
+		{
+			name: "SuccessWithDiagnostics",
+			id:   "550e8400-e29b-41d4-a716-446655440000",
+			requestBody: `{
+				"errorMessage": "Resource allocation failed",
+				"diagnostics": {"code": "QUOTA_EXCEEDED", "logs": ["allocating vm", "quota exceeded"]}
+			}`,
+			mockSetup: func(querier *domain.MockJobQuerier, commander *domain.MockJobCommander, mockAuthz *authz.MockAuthorizer) {
+				commander.EXPECT().
+					Fail(mock.Anything, mock.MatchedBy(func(params domain.FailJobParams) bool {
+						return params.ErrorMessage == "Resource allocation failed" &&
+							params.Diagnostics != nil && (*params.Diagnostics)["code"] == "QUOTA_EXCEEDED"
+					})).
+					Return(nil)
+			},
+			expectedStatus: http.StatusNoContent,
+		},
 		{
 			name: "FailError",
 			id:   "550e8400-e29b-41d4-a716-446655440000",
@@ -417,6 +444,66 @@ func TestJobHandleFailJob(t *testing.T) {
 	}
 }
 
+// TestJobHandleUpdateJob tests the Update method
+func TestJobHandleUpdateJob(t *testing.T) {
+	testCases := []struct {
+		name           string
+		requestBody    string
+		mockSetup      func(commander *domain.MockJobCommander)
+		expectedStatus int
+	}{
+		{
+			name:        "Success",
+			requestBody: `{"priority": 10}`,
+			mockSetup: func(commander *domain.MockJobCommander) {
+				commander.EXPECT().
+					UpdatePriority(mock.Anything, mock.MatchedBy(func(params domain.UpdateJobPriorityParams) bool {
+						return params.Priority == 10
+					})).
+					Return(&domain.Job{Status: domain.JobPending, Priority: 10}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:        "AlreadyClaimed",
+			requestBody: `{"priority": 10}`,
+			mockSetup: func(commander *domain.MockJobCommander) {
+				commander.EXPECT().
+					UpdatePriority(mock.Anything, mock.Anything).
+					Return(nil, domain.NewConflictErrorf("job has changed status"))
+			},
+			expectedStatus: http.StatusConflict,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			commander := domain.NewMockJobCommander(t)
+			tc.mockSetup(commander)
+			handler := NewJobHandler(domain.NewMockJobQuerier(t), commander, authz.NewMockAuthorizer(t))
+
+			id := "550e8400-e29b-41d4-a716-446655440000"
+			req := httptest.NewRequest("PATCH", "/jobs/"+id, strings.NewReader(tc.requestBody))
+			req.Header.Set("Content-Type", "application/json")
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("id", id)
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+			req = req.WithContext(auth.WithIdentity(req.Context(), newMockAuthAdmin()))
+
+			w := httptest.NewRecorder()
+			middlewareHandler := middlewares.DecodeBody[UpdateJobReq]()(middlewares.ID(Update(handler.Update, JobToRes)))
+			middlewareHandler.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			if tc.expectedStatus == http.StatusOK {
+				var response map[string]any
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+				assert.Equal(t, float64(10), response["priority"])
+			}
+		})
+	}
+}
+
 // TestJobToResponse tests the jobToResponse function
 func TestJobToResponse(t *testing.T) {
 	// Setup test
@@ -439,6 +526,8 @@ func TestJobToResponse(t *testing.T) {
 		Priority:     1,
 		ClaimedAt:    &claimedAt,
 		ErrorMessage: "",
+		Diagnostics:  &properties.JSON{"code": "E42"},
+		RequestID:    "req-42",
 		Agent: &domain.Agent{
 			BaseEntity: domain.BaseEntity{
 				ID:        uuid.MustParse("850e8400-e29b-41d4-a716-446655440000"),
@@ -486,6 +575,14 @@ func TestJobToResponse(t *testing.T) {
 	assert.Equal(t, JSONUTCTime(updatedAt), response.UpdatedAt)
 	assert.Equal(t, (*JSONUTCTime)(&claimedAt), response.ClaimedAt)
 	assert.Nil(t, response.CompletedAt)
+	assert.Nil(t, response.Duration, "a job in flight has no duration")
+	assert.Equal(t, &properties.JSON{"code": "E42"}, response.Diagnostics)
+	assert.Equal(t, "req-42", response.RequestID)
+
+	completedAt := claimedAt.Add(150 * time.Second)
+	job.Status = domain.JobCompleted
+	job.CompletedAt = &completedAt
+	assert.Equal(t, JSONDuration(150*time.Second), *JobToRes(job).Duration)
 
 	// Assert relationships
 	require.NotNil(t, response.Agent)
@@ -518,6 +615,336 @@ func TestNewJobHandler(t *testing.T) {
 	assert.Equal(t, mockAuthz, handler.authz)
 }
 
+// TestJobHandleDeadLetter tests the DeadLetter method
+func TestJobHandleDeadLetter(t *testing.T) {
+	querier := domain.NewMockJobQuerier(t)
+	querier.EXPECT().
+		List(mock.Anything, mock.Anything, mock.MatchedBy(func(req *domain.PageReq) bool {
+			return assert.ObjectsAreEqual([]string{string(domain.JobDeadLettered)}, req.Filters["status"])
+		})).
+		Return(&domain.PageRes[domain.Job]{
+			Items: []domain.Job{{Action: "start", Status: domain.JobDeadLettered, Priority: 2, Attempt: 5}},
+		}, nil)
+	handler := NewJobHandler(querier, domain.NewMockJobCommander(t), authz.NewMockAuthorizer(t))
+
+	// The status filter of the request is overridden
+	req := httptest.NewRequest("GET", "/jobs/dead-letter?status=Pending", nil)
+	req = req.WithContext(auth.WithIdentity(req.Context(), newMockAuthAdmin()))
+	w := httptest.NewRecorder()
+	handler.DeadLetter(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	var response map[string]any
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	items := response["items"].([]any)
+	require.Len(t, items, 1)
+	assert.Equal(t, "DeadLettered", items[0].(map[string]any)["status"])
+	assert.Equal(t, float64(5), items[0].(map[string]any)["attempt"])
+}
+
+func TestJobHandleStats(t *testing.T) {
+	providerID := uuid.MustParse("660e8400-e29b-41d4-a716-446655440000")
+	identity := newMockAuthParticipant(providerID)
+	from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC)
+	querier := domain.NewMockJobQuerier(t)
+	querier.EXPECT().
+		DurationStats(mock.Anything, domain.JobDurationStatsQuery{
+			Action:  "create",
+			From:    from,
+			To:      to,
+			GroupBy: []domain.JobStatsGroup{domain.JobStatsGroupAction, domain.JobStatsGroupAgentType},
+			Scope:   &identity.Scope,
+		}).
+		Return([]domain.JobDurationStats{{
+			Action:    "create",
+			AgentType: "vm",
+			Count:     12,
+			P50:       90 * time.Second,
+			P95:       5 * time.Minute,
+			P99:       7 * time.Minute,
+		}}, nil)
+	handler := NewJobHandler(querier, domain.NewMockJobCommander(t), authz.NewMockAuthorizer(t))
+
+	req := httptest.NewRequest("GET", "/jobs/stats?action=create&from=2025-01-01T00:00:00Z&to=2025-01-02T00:00:00Z&groupBy=action,agentType&groupBy=action", nil)
+	req = req.WithContext(auth.WithIdentity(req.Context(), identity))
+	w := httptest.NewRecorder()
+	handler.Stats(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	var response map[string]any
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, "2025-01-01T00:00:00Z", response["from"])
+	assert.Equal(t, []any{"action", "agentType"}, response["groupBy"])
+	items := response["items"].([]any)
+	require.Len(t, items, 1)
+	item := items[0].(map[string]any)
+	assert.Equal(t, "create", item["action"])
+	assert.Equal(t, "vm", item["agentType"])
+	assert.Equal(t, float64(12), item["count"])
+	assert.Equal(t, "1m30s", item["p50"])
+	assert.Equal(t, "5m0s", item["p95"])
+	assert.Equal(t, "7m0s", item["p99"])
+}
+
+func TestJobHandleStatsInvalidQuery(t *testing.T) {
+	handler := NewJobHandler(domain.NewMockJobQuerier(t), domain.NewMockJobCommander(t), authz.NewMockAuthorizer(t))
+	for name, query := range map[string]string{
+		"unknown group":  "groupBy=service",
+		"invalid time":   "from=yesterday",
+		"reversed range": "from=2025-01-02T00:00:00Z&to=2025-01-01T00:00:00Z",
+		"range too long": "from=2024-01-01T00:00:00Z&to=2025-01-01T00:00:00Z",
+	} {
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/jobs/stats?"+query, nil)
+			req = req.WithContext(auth.WithIdentity(req.Context(), newMockAuthAdmin()))
+			w := httptest.NewRecorder()
+			handler.Stats(w, req)
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+		})
+	}
+}
+
+func TestParseJobDurationStatsQueryDefaults(t *testing.T) {
+	now := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	query, err := parseJobDurationStatsQuery(httptest.NewRequest("GET", "/jobs/stats", nil), now)
+	require.NoError(t, err)
+	assert.Equal(t, now, query.To)
+	assert.Equal(t, now.Add(-24*time.Hour), query.From)
+	assert.Empty(t, query.Action)
+	assert.Empty(t, query.GroupBy)
+}
+
+// TestJobHandleRequeue tests the requeue endpoint
+func TestJobHandleRequeue(t *testing.T) {
+	testCases := []struct {
+		name           string
+		mockSetup      func(commander *domain.MockJobCommander)
+		expectedStatus int
+	}{
+		{
+			name: "Success",
+			mockSetup: func(commander *domain.MockJobCommander) {
+				commander.EXPECT().
+					Requeue(mock.Anything, uuid.MustParse("550e8400-e29b-41d4-a716-446655440000")).
+					Return(&domain.Job{Status: domain.JobPending, Priority: 2, Attempt: 1}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "NotDeadLettered",
+			mockSetup: func(commander *domain.MockJobCommander) {
+				commander.EXPECT().
+					Requeue(mock.Anything, mock.Anything).
+					Return(nil, domain.NewInvalidInputErrorf("cannot requeue job in Completed status"))
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			commander := domain.NewMockJobCommander(t)
+			tc.mockSetup(commander)
+			handler := NewJobHandler(domain.NewMockJobQuerier(t), commander, authz.NewMockAuthorizer(t))
+
+			id := "550e8400-e29b-41d4-a716-446655440000"
+			req := httptest.NewRequest("POST", "/jobs/"+id+"/requeue", nil)
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("id", id)
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+			req = req.WithContext(auth.WithIdentity(req.Context(), newMockAuthAdmin()))
+
+			w := httptest.NewRecorder()
+			middlewares.ID(ActionWithoutBody(handler.commander.Requeue, JobToRes)).ServeHTTP(w, req)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			if tc.expectedStatus == http.StatusOK {
+				var response map[string]any
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+				assert.Equal(t, "Pending", response["status"])
+				assert.Equal(t, float64(1), response["attempt"])
+			}
+		})
+	}
+}
+
+// TestJobHandleRenew tests the lease renewal endpoint
+func TestJobHandleRenew(t *testing.T) {
+	leaseID := uuid.MustParse("660e8400-e29b-41d4-a716-446655440000")
+
+	testCases := []struct {
+		name           string
+		requestBody    string
+		mockSetup      func(commander *domain.MockJobCommander)
+		expectedStatus int
+	}{
+		{
+			name:        "Success",
+			requestBody: `{"leaseId": "660e8400-e29b-41d4-a716-446655440000"}`,
+			mockSetup: func(commander *domain.MockJobCommander) {
+				expiresAt := time.Now().Add(time.Minute)
+				commander.EXPECT().
+					RenewLease(mock.Anything, domain.RenewJobLeaseParams{
+						JobID:   uuid.MustParse("550e8400-e29b-41d4-a716-446655440000"),
+						LeaseID: leaseID,
+					}).
+					Return(&domain.Job{Status: domain.JobProcessing, LeaseID: &leaseID, LeaseExpiresAt: &expiresAt}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:        "LeaseNotHeld",
+			requestBody: `{"leaseId": "660e8400-e29b-41d4-a716-446655440000"}`,
+			mockSetup: func(commander *domain.MockJobCommander) {
+				commander.EXPECT().
+					RenewLease(mock.Anything, mock.Anything).
+					Return(nil, domain.NewConflictErrorf("lease is no longer held"))
+			},
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name:        "NotOwner",
+			requestBody: `{"leaseId": "660e8400-e29b-41d4-a716-446655440000"}`,
+			mockSetup: func(commander *domain.MockJobCommander) {
+				commander.EXPECT().
+					RenewLease(mock.Anything, mock.Anything).
+					Return(nil, domain.NewUnauthorizedErrorf("job is not assigned to the calling agent"))
+			},
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "InvalidBody",
+			requestBody:    `{"leaseId": "not-a-uuid"}`,
+			mockSetup:      func(commander *domain.MockJobCommander) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			commander := domain.NewMockJobCommander(t)
+			tc.mockSetup(commander)
+			handler := NewJobHandler(domain.NewMockJobQuerier(t), commander, authz.NewMockAuthorizer(t))
+
+			id := "550e8400-e29b-41d4-a716-446655440000"
+			req := httptest.NewRequest("POST", "/jobs/"+id+"/renew", strings.NewReader(tc.requestBody))
+			req.Header.Set("Content-Type", "application/json")
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("id", id)
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+			req = req.WithContext(auth.WithIdentity(req.Context(), newMockAuthAgent()))
+
+			w := httptest.NewRecorder()
+			middlewares.DecodeBody[RenewJobReq]()(middlewares.ID(Action(handler.Renew, JobToRes))).ServeHTTP(w, req)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			if tc.expectedStatus == http.StatusOK {
+				var response map[string]any
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+				assert.Equal(t, leaseID.String(), response["leaseId"])
+				assert.NotEmpty(t, response["leaseExpiresAt"])
+			}
+		})
+	}
+}
+
+// TestJobHandleReportStep tests the step reporting endpoint
+func TestJobHandleReportStep(t *testing.T) {
+	leaseID := uuid.MustParse("660e8400-e29b-41d4-a716-446655440000")
+
+	testCases := []struct {
+		name           string
+		requestBody    string
+		mockSetup      func(commander *domain.MockJobCommander)
+		expectedStatus int
+	}{
+		{
+			name:        "Success",
+			requestBody: `{"name": "create-disk", "status": "Completed", "leaseId": "660e8400-e29b-41d4-a716-446655440000"}`,
+			mockSetup: func(commander *domain.MockJobCommander) {
+				reportedAt := time.Now()
+				commander.EXPECT().
+					ReportStep(mock.Anything, domain.ReportJobStepParams{
+						JobID:   uuid.MustParse("550e8400-e29b-41d4-a716-446655440000"),
+						Name:    "create-disk",
+						Status:  domain.JobStepCompleted,
+						LeaseID: &leaseID,
+					}).
+					Return(&domain.Job{
+						Status:     domain.JobProcessing,
+						Steps:      []domain.JobStep{{Name: "create-disk", Status: domain.JobStepCompleted, ReportedAt: reportedAt}},
+						LastStepAt: &reportedAt,
+					}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:        "FinalStep",
+			requestBody: `{"name": "boot", "status": "Completed", "final": true, "agentInstanceId": "vm-1", "properties": {"ip": "10.0.0.1"}}`,
+			mockSetup: func(commander *domain.MockJobCommander) {
+				instanceID := "vm-1"
+				commander.EXPECT().
+					ReportStep(mock.Anything, domain.ReportJobStepParams{
+						JobID:           uuid.MustParse("550e8400-e29b-41d4-a716-446655440000"),
+						Name:            "boot",
+						Status:          domain.JobStepCompleted,
+						Final:           true,
+						AgentInstanceID: &instanceID,
+						Properties:      map[string]any{"ip": "10.0.0.1"},
+					}).
+					Return(&domain.Job{Status: domain.JobCompleted}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:        "NotProcessing",
+			requestBody: `{"name": "create-disk", "status": "Completed"}`,
+			mockSetup: func(commander *domain.MockJobCommander) {
+				commander.EXPECT().
+					ReportStep(mock.Anything, mock.Anything).
+					Return(nil, domain.NewConflictErrorf("cannot report a step of job in Pending status"))
+			},
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name:           "InvalidBody",
+			requestBody:    `{"name": 1}`,
+			mockSetup:      func(commander *domain.MockJobCommander) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			commander := domain.NewMockJobCommander(t)
+			tc.mockSetup(commander)
+			handler := NewJobHandler(domain.NewMockJobQuerier(t), commander, authz.NewMockAuthorizer(t))
+
+			id := "550e8400-e29b-41d4-a716-446655440000"
+			req := httptest.NewRequest("POST", "/jobs/"+id+"/steps", strings.NewReader(tc.requestBody))
+			req.Header.Set("Content-Type", "application/json")
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("id", id)
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+			req = req.WithContext(auth.WithIdentity(req.Context(), newMockAuthAgent()))
+
+			w := httptest.NewRecorder()
+			middlewares.DecodeBody[ReportJobStepReq]()(middlewares.ID(Action(handler.ReportStep, JobToRes))).ServeHTTP(w, req)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			if tc.name == "Success" {
+				var response map[string]any
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+				steps := response["steps"].([]any)
+				require.Len(t, steps, 1)
+				assert.Equal(t, "create-disk", steps[0].(map[string]any)["name"])
+				assert.NotEmpty(t, response["lastStepAt"])
+			}
+		})
+	}
+}
+
 // TestJobHandlerRoutes tests the Routes function
 func TestJobHandlerRoutes(t *testing.T) {
 	// Create mocks
@@ -545,10 +972,16 @@ func TestJobHandlerRoutes(t *testing.T) {
 		switch {
 		case method == "GET" && route == "/":
 		case method == "GET" && route == "/{id}":
+		case method == "PATCH" && route == "/{id}":
 		case method == "GET" && route == "/pending":
 		case method == "POST" && route == "/{id}/claim":
+		case method == "POST" && route == "/{id}/renew":
+		case method == "POST" && route == "/{id}/steps":
 		case method == "POST" && route == "/{id}/complete":
 		case method == "POST" && route == "/{id}/fail":
+		case method == "GET" && route == "/dead-letter":
+		case method == "GET" && route == "/stats":
+		case method == "POST" && route == "/{id}/requeue":
 		default:
 			return fmt.Errorf("unexpected route: %s %s", method, route)
 		}
@@ -16,35 +16,39 @@ import (
 )
 
 type CreateMetricEntryReq struct {
-	ServiceID       *properties.UUID `json:"serviceId,omitempty"`
-	AgentInstanceID *string          `json:"agentInstanceId,omitempty"`
-	ResourceID      string           `json:"resourceId"`
-	Value           float64          `json:"value"`
-	TypeName        string           `json:"typeName"`
-	MetricTypeID    properties.UUID  `json:"metricTypeId"`
-	EntityType      string           `json:"entityType"`
-	EntityID        properties.UUID  `json:"entityId"`
-	Timestamp       time.Time        `json:"timestamp"`
+	ServiceID       *properties.UUID  `json:"serviceId,omitempty"`
+	AgentInstanceID *string           `json:"agentInstanceId,omitempty"`
+	ResourceID      string            `json:"resourceId"`
+	Value           float64           `json:"value"`
+	Histogram       *domain.Histogram `json:"histogram,omitempty"`
+	TypeName        string            `json:"typeName"`
+	MetricTypeID    properties.UUID   `json:"metricTypeId"`
+	EntityType      string            `json:"entityType"`
+	EntityID        properties.UUID   `json:"entityId"`
+	Timestamp       time.Time         `json:"timestamp"`
 }
 
 type MetricEntryHandler struct {
-	querier        domain.MetricEntryQuerier
-	serviceQuerier domain.ServiceQuerier
-	commander      domain.MetricEntryCommander
-	authz          authz.Authorizer
+	querier           domain.MetricEntryQuerier
+	serviceQuerier    domain.ServiceQuerier
+	metricTypeQuerier domain.MetricTypeQuerier
+	commander         domain.MetricEntryCommander
+	authz             authz.Authorizer
 }
 
 func NewMetricEntryHandler(
 	querier domain.MetricEntryQuerier,
 	serviceQuerier domain.ServiceQuerier,
+	metricTypeQuerier domain.MetricTypeQuerier,
 	commander domain.MetricEntryCommander,
 	authz authz.Authorizer,
 ) *MetricEntryHandler {
 	return &MetricEntryHandler{
-		querier:        querier,
-		commander:      commander,
-		serviceQuerier: serviceQuerier,
-		authz:          authz,
+		querier:           querier,
+		commander:         commander,
+		serviceQuerier:    serviceQuerier,
+		metricTypeQuerier: metricTypeQuerier,
+		authz:             authz,
 	}
 }
 
@@ -94,6 +98,7 @@ func (h *MetricEntryHandler) Create(w http.ResponseWriter, r *http.Request) {
 			ServiceID:  *p.ServiceID,
 			ResourceID: p.ResourceID,
 			Value:      p.Value,
+			Histogram:  p.Histogram,
 		}
 		metricEntry, err = h.commander.Create(r.Context(), params)
 		if err != nil {
@@ -112,6 +117,7 @@ func (h *MetricEntryHandler) Create(w http.ResponseWriter, r *http.Request) {
 			AgentInstanceID: *p.AgentInstanceID,
 			ResourceID:      p.ResourceID,
 			Value:           p.Value,
+			Histogram:       p.Histogram,
 		}
 		metricEntry, err = h.commander.CreateWithAgentInstanceID(r.Context(), params)
 		if err != nil {
@@ -153,6 +159,19 @@ func (h *MetricEntryHandler) Aggregate(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Histogram aggregations only apply to histogram metric types and rates to counter ones
+	if aq.Aggregate.IsHistogram() || aq.Aggregate == domain.AggregateRate {
+		metricType, err := h.metricTypeQuerier.Get(r.Context(), aq.TypeID)
+		if err != nil {
+			render.Render(w, r, ErrDomain(err))
+			return
+		}
+		if err := metricType.ValidateAggregate(aq.Aggregate); err != nil {
+			render.Render(w, r, ErrInvalidRequest(err))
+			return
+		}
+	}
+
 	aq.Scope = &id.Scope
 	result, err := h.querier.Aggregate(r.Context(), *aq)
 	if err != nil {
@@ -233,19 +252,21 @@ func parseAggregateQuery(r *http.Request) (*domain.AggregateQuery, error) {
 
 // MetricEntryRes represents the response body for metric entry operations
 type MetricEntryRes struct {
-	ID         properties.UUID `json:"id"`
-	ProviderID properties.UUID `json:"providerId"`
-	ConsumerID properties.UUID `json:"consumerId"`
-	AgentID    properties.UUID `json:"agentId"`
-	ServiceID  properties.UUID `json:"serviceId"`
-	ResourceID string          `json:"resourceId"`
-	Value      float64         `json:"value"`
-	TypeID     string          `json:"typeId"`
-	CreatedAt  JSONUTCTime     `json:"createdAt"`
-	UpdatedAt  JSONUTCTime     `json:"updatedAt"`
-	Agent      *AgentRes       `json:"agent,omitempty"`
-	Service    *ServiceRes     `json:"service,omitempty"`
-	Type       *MetricTypeRes  `json:"type,omitempty"`
+	ID         properties.UUID   `json:"id"`
+	ProviderID properties.UUID   `json:"providerId"`
+	ConsumerID properties.UUID   `json:"consumerId"`
+	AgentID    properties.UUID   `json:"agentId"`
+	ServiceID  properties.UUID   `json:"serviceId"`
+	ResourceID string            `json:"resourceId"`
+	Value      float64           `json:"value"`
+	Histogram  *domain.Histogram `json:"histogram,omitempty"`
+	TypeID     string            `json:"typeId"`
+	Unit       string            `json:"unit,omitempty"` // Unit of the metric type, set when the type is loaded
+	CreatedAt  JSONUTCTime       `json:"createdAt"`
+	UpdatedAt  JSONUTCTime       `json:"updatedAt"`
+	Agent      *AgentRes         `json:"agent,omitempty"`
+	Service    *ServiceRes       `json:"service,omitempty"`
+	Type       *MetricTypeRes    `json:"type,omitempty"`
 }
 
 // MetricEntryToRes converts a domain.MetricEntry to a MetricEntryResponse
@@ -258,6 +279,7 @@ func MetricEntryToRes(me *domain.MetricEntry) *MetricEntryRes {
 		ServiceID:  me.ServiceID,
 		ResourceID: me.ResourceID,
 		Value:      me.Value,
+		Histogram:  me.Histogram,
 		TypeID:     me.TypeID.String(),
 		CreatedAt:  JSONUTCTime(me.CreatedAt),
 		UpdatedAt:  JSONUTCTime(me.UpdatedAt),
@@ -270,6 +292,7 @@ func MetricEntryToRes(me *domain.MetricEntry) *MetricEntryRes {
 	}
 	if me.Type != nil {
 		resp.Type = MetricTypeToRes(me.Type)
+		resp.Unit = me.Type.Unit
 	}
 	return resp
 }
@@ -25,13 +25,15 @@ import (
 func TestNewMetricEntryHandler(t *testing.T) {
 	querier := domain.NewMockMetricEntryQuerier(t)
 	serviceQuerier := domain.NewMockServiceQuerier(t)
+	metricTypeQuerier := domain.NewMockMetricTypeQuerier(t)
 	commander := domain.NewMockMetricEntryCommander(t)
 	authz := authz.NewMockAuthorizer(t)
 
-	handler := NewMetricEntryHandler(querier, serviceQuerier, commander, authz)
+	handler := NewMetricEntryHandler(querier, serviceQuerier, metricTypeQuerier, commander, authz)
 	assert.NotNil(t, handler)
 	assert.Equal(t, querier, handler.querier)
 	assert.Equal(t, serviceQuerier, handler.serviceQuerier)
+	assert.Equal(t, metricTypeQuerier, handler.metricTypeQuerier)
 	assert.Equal(t, commander, handler.commander)
 	assert.Equal(t, authz, handler.authz)
 }
@@ -45,7 +47,7 @@ func TestMetricEntryHandlerRoutes(t *testing.T) {
 	authz := authz.NewMockAuthorizer(t)
 
 	// Create the handler
-	handler := NewMetricEntryHandler(querier, serviceQuerier, commander, authz)
+	handler := NewMetricEntryHandler(querier, serviceQuerier, domain.NewMockMetricTypeQuerier(t), commander, authz)
 
 	// Execute
 	routeFunc := handler.Routes()
@@ -246,7 +248,7 @@ func TestMetricEntryHandleCreate(t *testing.T) {
 			tc.mockSetup(serviceQuerier, commander)
 
 			// Create the handler
-			handler := NewMetricEntryHandler(querier, serviceQuerier, commander, authz)
+			handler := NewMetricEntryHandler(querier, serviceQuerier, domain.NewMockMetricTypeQuerier(t), commander, authz)
 
 			// Create request with body
 			bodyBytes, err := json.Marshal(tc.requestBody)
@@ -296,7 +298,7 @@ func TestMetricEntryHandlerListResourceIDs(t *testing.T) {
 				HasPrev:     false,
 			}, nil)
 
-		handler := NewMetricEntryHandler(querier, serviceQuerier, commander, authzMock)
+		handler := NewMetricEntryHandler(querier, serviceQuerier, domain.NewMockMetricTypeQuerier(t), commander, authzMock)
 
 		req := httptest.NewRequest("GET", "/metric-entries/resource-ids?serviceId=svc-1&typeId=type-1&agentId=agent-1&page=1&pageSize=10", nil)
 		req = req.WithContext(auth.WithIdentity(req.Context(), newMockAuthAgent()))
@@ -323,7 +325,7 @@ func TestMetricEntryHandlerListResourceIDs(t *testing.T) {
 			ListResourceIDs(mock.Anything, mock.Anything, mock.Anything).
 			Return(nil, fmt.Errorf("database error"))
 
-		handler := NewMetricEntryHandler(querier, serviceQuerier, commander, authzMock)
+		handler := NewMetricEntryHandler(querier, serviceQuerier, domain.NewMockMetricTypeQuerier(t), commander, authzMock)
 
 		req := httptest.NewRequest("GET", "/metric-entries/resource-ids?serviceId=svc-1&typeId=type-1&agentId=agent-1&page=1&pageSize=10", nil)
 		req = req.WithContext(auth.WithIdentity(req.Context(), newMockAuthAgent()))
@@ -375,6 +377,7 @@ func TestMetricEntryToResponse(t *testing.T) {
 				ID: typeID,
 			},
 			Name: "cpu",
+			Unit: "percent",
 		},
 	}
 
@@ -404,6 +407,8 @@ func TestMetricEntryToResponse(t *testing.T) {
 	assert.NotNil(t, response.Type)
 	assert.Equal(t, metricEntry.Type.ID, response.Type.ID)
 	assert.Equal(t, metricEntry.Type.Name, response.Type.Name)
+	assert.Equal(t, "percent", response.Unit)
+	assert.Equal(t, "percent", response.Type.Unit)
 
 	// Test with nil relationships
 	metricEntry.Agent = nil
@@ -415,6 +420,7 @@ func TestMetricEntryToResponse(t *testing.T) {
 	assert.Nil(t, responseSparse.Agent)
 	assert.Nil(t, responseSparse.Service)
 	assert.Nil(t, responseSparse.Type)
+	assert.Empty(t, responseSparse.Unit)
 }
 
 // TestMetricEntryHandlerAggregate tests the Aggregate handler
@@ -450,7 +456,7 @@ func TestMetricEntryHandlerAggregate(t *testing.T) {
 				Bucket:    domain.AggregateBucketHour,
 			}, nil)
 
-		handler := NewMetricEntryHandler(querier, serviceQuerier, commander, authzMock)
+		handler := NewMetricEntryHandler(querier, serviceQuerier, domain.NewMockMetricTypeQuerier(t), commander, authzMock)
 		router := setupRouter(handler)
 
 		url := fmt.Sprintf("/aggregate/%s/%s/%s", serviceID, resourceID, typeID)
@@ -501,7 +507,7 @@ func TestMetricEntryHandlerAggregate(t *testing.T) {
 				End:       end,
 			}, nil)
 
-		handler := NewMetricEntryHandler(querier, serviceQuerier, commander, authzMock)
+		handler := NewMetricEntryHandler(querier, serviceQuerier, domain.NewMockMetricTypeQuerier(t), commander, authzMock)
 		router := setupRouter(handler)
 
 		url := fmt.Sprintf("/aggregate/%s/%s/%s?aggregateType=max&bucket=day&start=2026-03-01T00:00:00Z&end=2026-03-13T00:00:00Z", serviceID, resourceID, typeID)
@@ -539,7 +545,7 @@ func TestMetricEntryHandlerAggregate(t *testing.T) {
 				Bucket:    domain.AggregateBucketHour,
 			}, nil)
 
-		handler := NewMetricEntryHandler(querier, serviceQuerier, commander, authzMock)
+		handler := NewMetricEntryHandler(querier, serviceQuerier, domain.NewMockMetricTypeQuerier(t), commander, authzMock)
 		router := setupRouter(handler)
 
 		url := fmt.Sprintf("/aggregate/%s/%s/%s?aggregateType=diff", serviceID, resourceID, typeID)
@@ -564,7 +570,7 @@ func TestMetricEntryHandlerAggregate(t *testing.T) {
 		commander := domain.NewMockMetricEntryCommander(t)
 		authzMock := authz.NewMockAuthorizer(t)
 
-		handler := NewMetricEntryHandler(querier, serviceQuerier, commander, authzMock)
+		handler := NewMetricEntryHandler(querier, serviceQuerier, domain.NewMockMetricTypeQuerier(t), commander, authzMock)
 		router := setupRouter(handler)
 
 		url := fmt.Sprintf("/aggregate/not-a-uuid/%s/%s", resourceID, typeID)
@@ -583,7 +589,7 @@ func TestMetricEntryHandlerAggregate(t *testing.T) {
 		commander := domain.NewMockMetricEntryCommander(t)
 		authzMock := authz.NewMockAuthorizer(t)
 
-		handler := NewMetricEntryHandler(querier, serviceQuerier, commander, authzMock)
+		handler := NewMetricEntryHandler(querier, serviceQuerier, domain.NewMockMetricTypeQuerier(t), commander, authzMock)
 		router := setupRouter(handler)
 
 		url := fmt.Sprintf("/aggregate/%s/%s/not-a-uuid", serviceID, resourceID)
@@ -602,7 +608,7 @@ func TestMetricEntryHandlerAggregate(t *testing.T) {
 		commander := domain.NewMockMetricEntryCommander(t)
 		authzMock := authz.NewMockAuthorizer(t)
 
-		handler := NewMetricEntryHandler(querier, serviceQuerier, commander, authzMock)
+		handler := NewMetricEntryHandler(querier, serviceQuerier, domain.NewMockMetricTypeQuerier(t), commander, authzMock)
 		router := setupRouter(handler)
 
 		url := fmt.Sprintf("/aggregate/%s/%s/%s?aggregateType=invalid", serviceID, resourceID, typeID)
@@ -621,7 +627,7 @@ func TestMetricEntryHandlerAggregate(t *testing.T) {
 		commander := domain.NewMockMetricEntryCommander(t)
 		authzMock := authz.NewMockAuthorizer(t)
 
-		handler := NewMetricEntryHandler(querier, serviceQuerier, commander, authzMock)
+		handler := NewMetricEntryHandler(querier, serviceQuerier, domain.NewMockMetricTypeQuerier(t), commander, authzMock)
 		router := setupRouter(handler)
 
 		url := fmt.Sprintf("/aggregate/%s/%s/%s?bucket=invalid", serviceID, resourceID, typeID)
@@ -640,7 +646,7 @@ func TestMetricEntryHandlerAggregate(t *testing.T) {
 		commander := domain.NewMockMetricEntryCommander(t)
 		authzMock := authz.NewMockAuthorizer(t)
 
-		handler := NewMetricEntryHandler(querier, serviceQuerier, commander, authzMock)
+		handler := NewMetricEntryHandler(querier, serviceQuerier, domain.NewMockMetricTypeQuerier(t), commander, authzMock)
 		router := setupRouter(handler)
 
 		url := fmt.Sprintf("/aggregate/%s/%s/%s?start=not-a-date", serviceID, resourceID, typeID)
@@ -659,7 +665,7 @@ func TestMetricEntryHandlerAggregate(t *testing.T) {
 		commander := domain.NewMockMetricEntryCommander(t)
 		authzMock := authz.NewMockAuthorizer(t)
 
-		handler := NewMetricEntryHandler(querier, serviceQuerier, commander, authzMock)
+		handler := NewMetricEntryHandler(querier, serviceQuerier, domain.NewMockMetricTypeQuerier(t), commander, authzMock)
 		router := setupRouter(handler)
 
 		url := fmt.Sprintf("/aggregate/%s/%s/%s?end=not-a-date", serviceID, resourceID, typeID)
@@ -678,7 +684,7 @@ func TestMetricEntryHandlerAggregate(t *testing.T) {
 		commander := domain.NewMockMetricEntryCommander(t)
 		authzMock := authz.NewMockAuthorizer(t)
 
-		handler := NewMetricEntryHandler(querier, serviceQuerier, commander, authzMock)
+		handler := NewMetricEntryHandler(querier, serviceQuerier, domain.NewMockMetricTypeQuerier(t), commander, authzMock)
 		router := setupRouter(handler)
 
 		// minute bucket with > 24h range
@@ -698,7 +704,7 @@ func TestMetricEntryHandlerAggregate(t *testing.T) {
 		commander := domain.NewMockMetricEntryCommander(t)
 		authzMock := authz.NewMockAuthorizer(t)
 
-		handler := NewMetricEntryHandler(querier, serviceQuerier, commander, authzMock)
+		handler := NewMetricEntryHandler(querier, serviceQuerier, domain.NewMockMetricTypeQuerier(t), commander, authzMock)
 		router := setupRouter(handler)
 
 		url := fmt.Sprintf("/aggregate/%s/%s/%s?start=2026-03-13T00:00:00Z&end=2026-03-01T00:00:00Z", serviceID, resourceID, typeID)
@@ -721,7 +727,7 @@ func TestMetricEntryHandlerAggregate(t *testing.T) {
 			Aggregate(mock.Anything, mock.Anything).
 			Return(domain.AggregationResult{}, fmt.Errorf("database error"))
 
-		handler := NewMetricEntryHandler(querier, serviceQuerier, commander, authzMock)
+		handler := NewMetricEntryHandler(querier, serviceQuerier, domain.NewMockMetricTypeQuerier(t), commander, authzMock)
 		router := setupRouter(handler)
 
 		url := fmt.Sprintf("/aggregate/%s/%s/%s", serviceID, resourceID, typeID)
@@ -733,4 +739,104 @@ func TestMetricEntryHandlerAggregate(t *testing.T) {
 
 		assert.Equal(t, http.StatusInternalServerError, w.Code)
 	})
+
+	t.Run("Percentile of histogram metric type", func(t *testing.T) {
+		querier := domain.NewMockMetricEntryQuerier(t)
+		metricTypeQuerier := domain.NewMockMetricTypeQuerier(t)
+
+		metricTypeQuerier.EXPECT().
+			Get(mock.Anything, properties.UUID(typeID)).
+			Return(&domain.MetricType{Name: "request-latency", Kind: domain.MetricEntryKindHistogram}, nil)
+		querier.EXPECT().
+			Aggregate(mock.Anything, mock.MatchedBy(func(q domain.AggregateQuery) bool {
+				return q.Aggregate == domain.AggregateP95
+			})).
+			Return(domain.AggregationResult{
+				Data:      []domain.AggregateData{{"2026-03-13T00:00:00Z", 0.42}},
+				Aggregate: domain.AggregateP95,
+				Bucket:    domain.AggregateBucketHour,
+			}, nil)
+
+		handler := NewMetricEntryHandler(querier, domain.NewMockServiceQuerier(t), metricTypeQuerier, domain.NewMockMetricEntryCommander(t), authz.NewMockAuthorizer(t))
+		router := setupRouter(handler)
+
+		url := fmt.Sprintf("/aggregate/%s/%s/%s?aggregateType=p95", serviceID, resourceID, typeID)
+		req := httptest.NewRequest("GET", url, nil)
+		req = req.WithContext(auth.WithIdentity(req.Context(), newMockAuthAgent()))
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("Percentile of scalar metric type", func(t *testing.T) {
+		metricTypeQuerier := domain.NewMockMetricTypeQuerier(t)
+		metricTypeQuerier.EXPECT().
+			Get(mock.Anything, properties.UUID(typeID)).
+			Return(&domain.MetricType{Name: "cpu-usage", Kind: domain.MetricEntryKindScalar}, nil)
+
+		handler := NewMetricEntryHandler(domain.NewMockMetricEntryQuerier(t), domain.NewMockServiceQuerier(t), metricTypeQuerier, domain.NewMockMetricEntryCommander(t), authz.NewMockAuthorizer(t))
+		router := setupRouter(handler)
+
+		url := fmt.Sprintf("/aggregate/%s/%s/%s?aggregateType=p99", serviceID, resourceID, typeID)
+		req := httptest.NewRequest("GET", url, nil)
+		req = req.WithContext(auth.WithIdentity(req.Context(), newMockAuthAgent()))
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "aggregate p99 requires a histogram metric type, metric type cpu-usage is scalar")
+	})
+
+	t.Run("Rate of counter metric type", func(t *testing.T) {
+		querier := domain.NewMockMetricEntryQuerier(t)
+		metricTypeQuerier := domain.NewMockMetricTypeQuerier(t)
+
+		metricTypeQuerier.EXPECT().
+			Get(mock.Anything, properties.UUID(typeID)).
+			Return(&domain.MetricType{Name: "bytes-transferred", Kind: domain.MetricEntryKindScalar, Counter: true}, nil)
+		querier.EXPECT().
+			Aggregate(mock.Anything, mock.MatchedBy(func(q domain.AggregateQuery) bool {
+				return q.Aggregate == domain.AggregateRate
+			})).
+			Return(domain.AggregationResult{
+				Data:      []domain.AggregateData{{"2026-03-13T00:00:00Z", 1250.5}},
+				Aggregate: domain.AggregateRate,
+				Bucket:    domain.AggregateBucketHour,
+			}, nil)
+
+		handler := NewMetricEntryHandler(querier, domain.NewMockServiceQuerier(t), metricTypeQuerier, domain.NewMockMetricEntryCommander(t), authz.NewMockAuthorizer(t))
+		router := setupRouter(handler)
+
+		url := fmt.Sprintf("/aggregate/%s/%s/%s?aggregateType=rate", serviceID, resourceID, typeID)
+		req := httptest.NewRequest("GET", url, nil)
+		req = req.WithContext(auth.WithIdentity(req.Context(), newMockAuthAgent()))
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("Rate of metric type not a counter", func(t *testing.T) {
+		metricTypeQuerier := domain.NewMockMetricTypeQuerier(t)
+		metricTypeQuerier.EXPECT().
+			Get(mock.Anything, properties.UUID(typeID)).
+			Return(&domain.MetricType{Name: "cpu-usage", Kind: domain.MetricEntryKindScalar}, nil)
+
+		handler := NewMetricEntryHandler(domain.NewMockMetricEntryQuerier(t), domain.NewMockServiceQuerier(t), metricTypeQuerier, domain.NewMockMetricEntryCommander(t), authz.NewMockAuthorizer(t))
+		router := setupRouter(handler)
+
+		url := fmt.Sprintf("/aggregate/%s/%s/%s?aggregateType=rate", serviceID, resourceID, typeID)
+		req := httptest.NewRequest("GET", url, nil)
+		req = req.WithContext(auth.WithIdentity(req.Context(), newMockAuthAgent()))
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "aggregate rate requires a counter metric type, metric type cpu-usage is not a counter")
+	})
 }
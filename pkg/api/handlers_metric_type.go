@@ -13,10 +13,18 @@ import (
 type CreateMetricTypeReq struct {
 	Name       string                  `json:"name"`
 	EntityType domain.MetricEntityType `json:"entityType"`
+	Kind       domain.MetricEntryKind  `json:"kind"`
+	Unit       string                  `json:"unit"`
+	Min        *float64                `json:"min,omitempty"`
+	Max        *float64                `json:"max,omitempty"`
+	Counter    bool                    `json:"counter"`
 }
 
 type UpdateMetricTypeReq struct {
-	Name *string `json:"name"`
+	Name    *string              `json:"name"`
+	Unit    *string              `json:"unit"`
+	Bounds  *domain.MetricBounds `json:"bounds"` // Replaces both bounds, {} removes them
+	Counter *bool                `json:"counter"`
 }
 
 type MetricTypeHandler struct {
@@ -80,14 +88,22 @@ func (h *MetricTypeHandler) Create(ctx context.Context, req *CreateMetricTypeReq
 	params := domain.CreateMetricTypeParams{
 		Name:       req.Name,
 		EntityType: req.EntityType,
+		Kind:       req.Kind,
+		Unit:       req.Unit,
+		Min:        req.Min,
+		Max:        req.Max,
+		Counter:    req.Counter,
 	}
 	return h.commander.Create(ctx, params)
 }
 
 func (h *MetricTypeHandler) Update(ctx context.Context, id properties.UUID, req *UpdateMetricTypeReq) (*domain.MetricType, error) {
 	params := domain.UpdateMetricTypeParams{
-		ID:   id,
-		Name: req.Name,
+		ID:      id,
+		Name:    req.Name,
+		Unit:    req.Unit,
+		Bounds:  req.Bounds,
+		Counter: req.Counter,
 	}
 	return h.commander.Update(ctx, params)
 }
@@ -97,6 +113,11 @@ type MetricTypeRes struct {
 	ID         properties.UUID         `json:"id"`
 	Name       string                  `json:"name"`
 	EntityType domain.MetricEntityType `json:"entityType"`
+	Kind       domain.MetricEntryKind  `json:"kind"`
+	Unit       string                  `json:"unit"`
+	Min        *float64                `json:"min,omitempty"`
+	Max        *float64                `json:"max,omitempty"`
+	Counter    bool                    `json:"counter"`
 	CreatedAt  JSONUTCTime             `json:"createdAt"`
 	UpdatedAt  JSONUTCTime             `json:"updatedAt"`
 }
@@ -107,6 +128,11 @@ func MetricTypeToRes(mt *domain.MetricType) *MetricTypeRes {
 		ID:         mt.ID,
 		Name:       mt.Name,
 		EntityType: mt.EntityType,
+		Kind:       mt.Kind,
+		Unit:       mt.Unit,
+		Min:        mt.Min,
+		Max:        mt.Max,
+		Counter:    mt.Counter,
 		CreatedAt:  JSONUTCTime(mt.CreatedAt),
 		UpdatedAt:  JSONUTCTime(mt.UpdatedAt),
 	}
@@ -8,6 +8,7 @@ import (
 
 	"github.com/fulcrumproject/core/pkg/authz"
 	"github.com/fulcrumproject/core/pkg/domain"
+	"github.com/fulcrumproject/core/pkg/helpers"
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
@@ -78,6 +79,9 @@ func TestMetricTypeToResponse(t *testing.T) {
 		},
 		Name:       "CPU Usage",
 		EntityType: domain.MetricEntityType("service"),
+		Unit:       "percent",
+		Min:        helpers.FloatPtr(0),
+		Max:        helpers.FloatPtr(100),
 	}
 
 	// Convert to response
@@ -87,6 +91,9 @@ func TestMetricTypeToResponse(t *testing.T) {
 	assert.Equal(t, id, response.ID)
 	assert.Equal(t, "CPU Usage", response.Name)
 	assert.Equal(t, domain.MetricEntityType("service"), response.EntityType)
+	assert.Equal(t, "percent", response.Unit)
+	assert.Equal(t, helpers.FloatPtr(0), response.Min)
+	assert.Equal(t, helpers.FloatPtr(100), response.Max)
 	assert.Equal(t, JSONUTCTime(createdAt), response.CreatedAt)
 	assert.Equal(t, JSONUTCTime(updatedAt), response.UpdatedAt)
 }
@@ -2,39 +2,64 @@ package api
 
 import (
 	"context"
+	"errors"
+	"io"
+	"net/http"
 
 	"github.com/fulcrumproject/core/pkg/authz"
 	"github.com/fulcrumproject/core/pkg/domain"
 	"github.com/fulcrumproject/core/pkg/middlewares"
 	"github.com/fulcrumproject/core/pkg/properties"
 	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
 )
 
 type CreateParticipantReq struct {
-	Name   string                   `json:"name"`
-	Status domain.ParticipantStatus `json:"status"`
+	Name                   string                   `json:"name"`
+	Status                 domain.ParticipantStatus `json:"status"`
+	MaxAgents              *int                     `json:"maxAgents,omitempty"`
+	ContactEmail           *string                  `json:"contactEmail,omitempty"`
+	NotificationWebhookURL *string                  `json:"notificationWebhookUrl,omitempty"`
+	NotificationEventTypes *[]domain.EventType      `json:"notificationEventTypes,omitempty"`
 }
 
 type UpdateParticipantReq struct {
-	Name   *string                   `json:"name"`
-	Status *domain.ParticipantStatus `json:"status"`
+	Name                   *string                   `json:"name"`
+	Status                 *domain.ParticipantStatus `json:"status"`
+	MaxAgents              *int                      `json:"maxAgents,omitempty"`
+	ContactEmail           *string                   `json:"contactEmail,omitempty"`
+	NotificationWebhookURL *string                   `json:"notificationWebhookUrl,omitempty"`
+	NotificationEventTypes *[]domain.EventType       `json:"notificationEventTypes,omitempty"`
+}
+
+// RevokeTokensReq is the optional body of the token revocation, ConfirmSelf allows revoking the token of the caller
+type RevokeTokensReq struct {
+	ConfirmSelf bool `json:"confirmSelf"`
+}
+
+// RevokeTokensRes reports the number of revoked tokens
+type RevokeTokensRes struct {
+	Revoked int64 `json:"revoked"`
 }
 
 type ParticipantHandler struct {
-	querier   domain.ParticipantQuerier
-	commander domain.ParticipantCommander
-	authz     authz.Authorizer
+	querier        domain.ParticipantQuerier
+	commander      domain.ParticipantCommander
+	tokenCommander domain.TokenCommander
+	authz          authz.Authorizer
 }
 
 func NewParticipantHandler(
 	querier domain.ParticipantQuerier,
 	commander domain.ParticipantCommander,
+	tokenCommander domain.TokenCommander,
 	authz authz.Authorizer,
 ) *ParticipantHandler {
 	return &ParticipantHandler{
-		querier:   querier,
-		commander: commander,
-		authz:     authz,
+		querier:        querier,
+		commander:      commander,
+		tokenCommander: tokenCommander,
+		authz:          authz,
 	}
 }
 
@@ -71,6 +96,11 @@ func (h *ParticipantHandler) Routes() func(r chi.Router) {
 			r.With(
 				middlewares.AuthzFromID(authz.ObjectTypeParticipant, authz.ActionDelete, h.authz, h.querier.AuthScope),
 			).Delete("/{id}", Delete(h.querier, h.commander.Delete))
+
+			// Revoke tokens endpoint - authorize the deletion of tokens using participant's scope
+			r.With(
+				middlewares.AuthzFromID(authz.ObjectTypeToken, authz.ActionDelete, h.authz, h.querier.AuthScope),
+			).Post("/{id}/revoke-tokens", h.RevokeTokens)
 		})
 	}
 }
@@ -79,37 +109,75 @@ func (h *ParticipantHandler) Routes() func(r chi.Router) {
 
 func (h *ParticipantHandler) Create(ctx context.Context, req *CreateParticipantReq) (*domain.Participant, error) {
 	params := domain.CreateParticipantParams{
-		Name:   req.Name,
-		Status: req.Status,
+		Name:                   req.Name,
+		Status:                 req.Status,
+		MaxAgents:              req.MaxAgents,
+		ContactEmail:           req.ContactEmail,
+		NotificationWebhookURL: req.NotificationWebhookURL,
+		NotificationEventTypes: req.NotificationEventTypes,
 	}
 	return h.commander.Create(ctx, params)
 }
 
 func (h *ParticipantHandler) Update(ctx context.Context, id properties.UUID, req *UpdateParticipantReq) (*domain.Participant, error) {
 	params := domain.UpdateParticipantParams{
-		ID:     id,
-		Name:   req.Name,
-		Status: req.Status,
+		ID:                     id,
+		Name:                   req.Name,
+		Status:                 req.Status,
+		MaxAgents:              req.MaxAgents,
+		ContactEmail:           req.ContactEmail,
+		NotificationWebhookURL: req.NotificationWebhookURL,
+		NotificationEventTypes: req.NotificationEventTypes,
 	}
 	return h.commander.Update(ctx, params)
 }
 
+// RevokeTokens handles POST /participants/{id}/revoke-tokens, revoking all the tokens of the participant and its agents
+// The body is optional, it is only needed to confirm the revocation of the token used by the caller
+func (h *ParticipantHandler) RevokeTokens(w http.ResponseWriter, r *http.Request) {
+	id := middlewares.MustGetID(r.Context())
+	var req RevokeTokensReq
+	if err := render.DecodeJSON(r.Body, &req); err != nil && !errors.Is(err, io.EOF) {
+		render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+
+	count, err := h.tokenCommander.RevokeByParticipant(r.Context(), id, req.ConfirmSelf)
+	if err != nil {
+		render.Render(w, r, ErrDomain(err))
+		return
+	}
+	render.JSON(w, r, &RevokeTokensRes{Revoked: count})
+}
+
 // ParticipantRes represents the response body for participant operations
 type ParticipantRes struct {
-	ID        properties.UUID          `json:"id"`
-	Name      string                   `json:"name"`
-	Status    domain.ParticipantStatus `json:"status"`
-	CreatedAt JSONUTCTime              `json:"createdAt"`
-	UpdatedAt JSONUTCTime              `json:"updatedAt"`
+	ID                     properties.UUID          `json:"id"`
+	Name                   string                   `json:"name"`
+	Status                 domain.ParticipantStatus `json:"status"`
+	MaxAgents              *int                     `json:"maxAgents,omitempty"`
+	ContactEmail           *string                  `json:"contactEmail,omitempty"`
+	NotificationWebhookURL *string                  `json:"notificationWebhookUrl,omitempty"`
+	NotificationEventTypes []domain.EventType       `json:"notificationEventTypes"`
+	CreatedAt              JSONUTCTime              `json:"createdAt"`
+	UpdatedAt              JSONUTCTime              `json:"updatedAt"`
 }
 
 // ParticipantToRes converts a domain.Participant to a ParticipantResponse
 func ParticipantToRes(p *domain.Participant) *ParticipantRes {
+	notificationEventTypes := make([]domain.EventType, len(p.NotificationEventTypes))
+	for i, t := range p.NotificationEventTypes {
+		notificationEventTypes[i] = domain.EventType(t)
+	}
 	return &ParticipantRes{
-		ID:        p.ID,
-		Name:      p.Name,
-		Status:    p.Status,
-		CreatedAt: JSONUTCTime(p.CreatedAt),
-		UpdatedAt: JSONUTCTime(p.UpdatedAt),
+		ID:                     p.ID,
+		Name:                   p.Name,
+		Status:                 p.Status,
+		MaxAgents:              p.MaxAgents,
+		ContactEmail:           p.ContactEmail,
+		NotificationWebhookURL: p.NotificationWebhookURL,
+		NotificationEventTypes: notificationEventTypes,
+		CreatedAt:              JSONUTCTime(p.CreatedAt),
+		UpdatedAt:              JSONUTCTime(p.UpdatedAt),
 	}
 }
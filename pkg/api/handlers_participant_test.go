@@ -1,26 +1,35 @@
 package api
 
 import (
+	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/fulcrumproject/core/pkg/authz"
 	"github.com/fulcrumproject/core/pkg/domain"
+	"github.com/fulcrumproject/core/pkg/middlewares"
 	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 // TestNewParticipantHandler tests the constructor
 func TestNewParticipantHandler(t *testing.T) {
 	querier := domain.NewMockParticipantQuerier(t)
 	commander := domain.NewMockParticipantCommander(t)
+	tokenCommander := domain.NewMockTokenCommander(t)
 	authz := authz.NewMockAuthorizer(t)
 
-	handler := NewParticipantHandler(querier, commander, authz)
+	handler := NewParticipantHandler(querier, commander, tokenCommander, authz)
 	assert.NotNil(t, handler)
 	assert.Equal(t, querier, handler.querier)
 	assert.Equal(t, commander, handler.commander)
+	assert.Equal(t, tokenCommander, handler.tokenCommander)
 	assert.Equal(t, authz, handler.authz)
 }
 
@@ -32,7 +41,7 @@ func TestParticipantHandlerRoutes(t *testing.T) {
 	authz := authz.NewMockAuthorizer(t)
 
 	// Create the handler
-	handler := NewParticipantHandler(querier, commander, authz)
+	handler := NewParticipantHandler(querier, commander, domain.NewMockTokenCommander(t), authz)
 
 	// Execute
 	routeFunc := handler.Routes()
@@ -51,6 +60,7 @@ func TestParticipantHandlerRoutes(t *testing.T) {
 		case method == "GET" && route == "/{id}":
 		case method == "PATCH" && route == "/{id}":
 		case method == "DELETE" && route == "/{id}":
+		case method == "POST" && route == "/{id}/revoke-tokens":
 		default:
 			return fmt.Errorf("unexpected route: %s %s", method, route)
 		}
@@ -60,3 +70,70 @@ func TestParticipantHandlerRoutes(t *testing.T) {
 	err := chi.Walk(r, walkFunc)
 	assert.NoError(t, err)
 }
+
+// TestParticipantHandleRevokeTokens tests the revocation of the tokens of a participant
+func TestParticipantHandleRevokeTokens(t *testing.T) {
+	participantID := uuid.MustParse("550e8400-e29b-41d4-a716-446655440000")
+
+	tests := []struct {
+		name           string
+		body           string
+		setupMocks     func(tokenCommander *domain.MockTokenCommander)
+		expectedStatus int
+		expectedCount  int64
+	}{
+		{
+			name: "Without body",
+			setupMocks: func(tokenCommander *domain.MockTokenCommander) {
+				tokenCommander.EXPECT().RevokeByParticipant(mock.Anything, participantID, false).Return(int64(3), nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedCount:  3,
+		},
+		{
+			name: "Confirmed self revocation",
+			body: `{"confirmSelf": true}`,
+			setupMocks: func(tokenCommander *domain.MockTokenCommander) {
+				tokenCommander.EXPECT().RevokeByParticipant(mock.Anything, participantID, true).Return(int64(1), nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedCount:  1,
+		},
+		{
+			name: "Self revocation not confirmed",
+			body: `{}`,
+			setupMocks: func(tokenCommander *domain.MockTokenCommander) {
+				tokenCommander.EXPECT().RevokeByParticipant(mock.Anything, participantID, false).
+					Return(int64(0), domain.NewInvalidInputErrorf("confirm the self revocation to proceed"))
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Invalid body",
+			body:           `{"confirmSelf":`,
+			setupMocks:     func(tokenCommander *domain.MockTokenCommander) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tokenCommander := domain.NewMockTokenCommander(t)
+			tc.setupMocks(tokenCommander)
+			handler := NewParticipantHandler(domain.NewMockParticipantQuerier(t), domain.NewMockParticipantCommander(t), tokenCommander, authz.NewMockAuthorizer(t))
+
+			r := chi.NewRouter()
+			r.With(middlewares.ID).Post("/{id}/revoke-tokens", handler.RevokeTokens)
+			req := httptest.NewRequest("POST", "/"+participantID.String()+"/revoke-tokens", strings.NewReader(tc.body))
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			require.Equal(t, tc.expectedStatus, w.Code)
+			if tc.expectedStatus == http.StatusOK {
+				var res RevokeTokensRes
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+				assert.Equal(t, tc.expectedCount, res.Revoked)
+			}
+		})
+	}
+}
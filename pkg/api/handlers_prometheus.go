@@ -0,0 +1,157 @@
+package api
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/fulcrumproject/core/pkg/authz"
+	"github.com/fulcrumproject/core/pkg/domain"
+	"github.com/fulcrumproject/core/pkg/middlewares"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+)
+
+// PrometheusContentType is the content type of the Prometheus text exposition format
+const PrometheusContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// PrometheusHandler exposes the platform counters in the Prometheus text exposition format
+//
+// Labels are limited to service types, agent types, metric types and statuses so the
+// number of series stays bounded regardless of the number of services.
+type PrometheusHandler struct {
+	serviceQuerier    domain.ServiceQuerier
+	jobQuerier        domain.JobQuerier
+	agentQuerier      domain.AgentQuerier
+	metricTypeQuerier domain.MetricTypeQuerier
+	authz             authz.Authorizer
+}
+
+func NewPrometheusHandler(
+	serviceQuerier domain.ServiceQuerier,
+	jobQuerier domain.JobQuerier,
+	agentQuerier domain.AgentQuerier,
+	metricTypeQuerier domain.MetricTypeQuerier,
+	authz authz.Authorizer,
+) *PrometheusHandler {
+	return &PrometheusHandler{
+		serviceQuerier:    serviceQuerier,
+		jobQuerier:        jobQuerier,
+		agentQuerier:      agentQuerier,
+		metricTypeQuerier: metricTypeQuerier,
+		authz:             authz,
+	}
+}
+
+// Routes returns the router with the exposition route registered
+func (h *PrometheusHandler) Routes() func(r chi.Router) {
+	return func(r chi.Router) {
+		r.With(
+			middlewares.AuthzSimple(authz.ObjectTypeMetrics, authz.ActionRead, h.authz),
+		).Get("/", h.Expose)
+	}
+}
+
+// Expose writes the current service, job and agent counts as Prometheus gauges, with the metric types and their units
+func (h *PrometheusHandler) Expose(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	serviceCounts, err := h.serviceQuerier.CountByServiceTypeAndStatus(ctx)
+	if err != nil {
+		render.Render(w, r, ErrDomain(err))
+		return
+	}
+	jobCounts, err := h.jobQuerier.CountByStatus(ctx)
+	if err != nil {
+		render.Render(w, r, ErrDomain(err))
+		return
+	}
+	agentCounts, err := h.agentQuerier.CountByAgentTypeAndStatus(ctx)
+	if err != nil {
+		render.Render(w, r, ErrDomain(err))
+		return
+	}
+	metricTypes, err := h.metricTypeQuerier.FindAll(ctx)
+	if err != nil {
+		render.Render(w, r, ErrDomain(err))
+		return
+	}
+
+	var b strings.Builder
+
+	writePrometheusHeader(&b, "fulcrum_services", "Number of services by service type and status.")
+	for _, c := range serviceCounts {
+		writePrometheusSample(&b, "fulcrum_services", c.Count, "service_type", c.Group, "status", c.Status)
+	}
+
+	// Every job status is exposed so that absent statuses read as zero instead of missing series
+	jobs := make(map[string]int64, len(jobCounts))
+	for _, c := range jobCounts {
+		jobs[c.Status] = c.Count
+	}
+	writePrometheusHeader(&b, "fulcrum_jobs", "Number of jobs by status.")
+	for _, status := range []domain.JobStatus{
+		domain.JobScheduled, domain.JobPending, domain.JobProcessing,
+		domain.JobCompleted, domain.JobFailed, domain.JobCancelled,
+	} {
+		writePrometheusSample(&b, "fulcrum_jobs", jobs[string(status)], "status", string(status))
+	}
+
+	// Every agent status is exposed for each agent type having agents
+	agentTypes := []string{}
+	agents := map[string]map[string]int64{}
+	for _, c := range agentCounts {
+		if _, ok := agents[c.Group]; !ok {
+			agentTypes = append(agentTypes, c.Group)
+			agents[c.Group] = map[string]int64{}
+		}
+		agents[c.Group][c.Status] = c.Count
+	}
+	writePrometheusHeader(&b, "fulcrum_agents", "Number of agents by agent type and status.")
+	for _, agentType := range agentTypes {
+		for _, status := range []domain.AgentStatus{
+			domain.AgentNew, domain.AgentConnected, domain.AgentDisconnected,
+			domain.AgentError, domain.AgentDisabled,
+		} {
+			writePrometheusSample(&b, "fulcrum_agents", agents[agentType][string(status)], "agent_type", agentType, "status", string(status))
+		}
+	}
+
+	// Info series of the metric types, the labels carry the unit of their values
+	writePrometheusHeader(&b, "fulcrum_metric_type_info", "Metric types with the unit of their values, always 1.")
+	for _, mt := range metricTypes {
+		writePrometheusSample(&b, "fulcrum_metric_type_info", 1, "metric_type", mt.Name, "entity_type", string(mt.EntityType), "kind", string(mt.Kind), "unit", mt.Unit)
+	}
+
+	w.Header().Set("Content-Type", PrometheusContentType)
+	w.WriteHeader(http.StatusOK)
+	io.WriteString(w, b.String())
+}
+
+// writePrometheusHeader writes the HELP and TYPE lines of a gauge
+func writePrometheusHeader(b *strings.Builder, name, help string) {
+	fmt.Fprintf(b, "# HELP %s %s\n", name, help)
+	fmt.Fprintf(b, "# TYPE %s gauge\n", name)
+}
+
+// writePrometheusSample writes a sample line, labels are given as name and value pairs
+func writePrometheusSample(b *strings.Builder, name string, value int64, labels ...string) {
+	b.WriteString(name)
+	if len(labels) > 0 {
+		b.WriteByte('{')
+		for i := 0; i+1 < len(labels); i += 2 {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			fmt.Fprintf(b, `%s="%s"`, labels[i], escapePrometheusLabel(labels[i+1]))
+		}
+		b.WriteByte('}')
+	}
+	fmt.Fprintf(b, " %d\n", value)
+}
+
+// escapePrometheusLabel escapes backslashes, double quotes and line feeds of a label value
+func escapePrometheusLabel(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+}
@@ -0,0 +1,93 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/fulcrumproject/core/pkg/authz"
+	"github.com/fulcrumproject/core/pkg/domain"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// TestPrometheusHandlerRoutes tests that routes are properly registered
+func TestPrometheusHandlerRoutes(t *testing.T) {
+	handler := NewPrometheusHandler(
+		domain.NewMockServiceQuerier(t),
+		domain.NewMockJobQuerier(t),
+		domain.NewMockAgentQuerier(t),
+		domain.NewMockMetricTypeQuerier(t),
+		authz.NewMockAuthorizer(t),
+	)
+
+	r := chi.NewRouter()
+	handler.Routes()(r)
+
+	walkFunc := func(method string, route string, handler http.Handler, middlewares ...func(http.Handler) http.Handler) error {
+		switch {
+		case method == "GET" && route == "/":
+		default:
+			return fmt.Errorf("unexpected route: %s %s", method, route)
+		}
+		return nil
+	}
+	assert.NoError(t, chi.Walk(r, walkFunc))
+}
+
+// TestPrometheusHandleExpose tests the text exposition of the counters
+func TestPrometheusHandleExpose(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		serviceQuerier := domain.NewMockServiceQuerier(t)
+		jobQuerier := domain.NewMockJobQuerier(t)
+		agentQuerier := domain.NewMockAgentQuerier(t)
+		metricTypeQuerier := domain.NewMockMetricTypeQuerier(t)
+
+		serviceQuerier.EXPECT().CountByServiceTypeAndStatus(mock.Anything).Return([]domain.StatusCount{
+			{Group: "vm", Status: "Started", Count: 3},
+			{Group: `my "db"`, Status: "Stopped", Count: 1},
+		}, nil)
+		jobQuerier.EXPECT().CountByStatus(mock.Anything).Return([]domain.StatusCount{
+			{Status: string(domain.JobPending), Count: 2},
+			{Status: string(domain.JobFailed), Count: 1},
+		}, nil)
+		agentQuerier.EXPECT().CountByAgentTypeAndStatus(mock.Anything).Return([]domain.StatusCount{
+			{Group: "proxmox", Status: string(domain.AgentConnected), Count: 4},
+		}, nil)
+		metricTypeQuerier.EXPECT().FindAll(mock.Anything).Return([]*domain.MetricType{
+			{Name: "cpu", EntityType: domain.MetricEntityTypeService, Kind: domain.MetricEntryKindScalar, Unit: "percent"},
+		}, nil)
+
+		handler := NewPrometheusHandler(serviceQuerier, jobQuerier, agentQuerier, metricTypeQuerier, authz.NewMockAuthorizer(t))
+		req := httptest.NewRequest("GET", "/", nil)
+		w := httptest.NewRecorder()
+		handler.Expose(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, PrometheusContentType, w.Header().Get("Content-Type"))
+		body := w.Body.String()
+		assert.Contains(t, body, "# TYPE fulcrum_services gauge\n")
+		assert.Contains(t, body, `fulcrum_services{service_type="vm",status="Started"} 3`+"\n")
+		assert.Contains(t, body, `fulcrum_services{service_type="my \"db\"",status="Stopped"} 1`+"\n")
+		assert.Contains(t, body, `fulcrum_jobs{status="Pending"} 2`+"\n")
+		assert.Contains(t, body, `fulcrum_jobs{status="Processing"} 0`+"\n")
+		assert.Contains(t, body, `fulcrum_jobs{status="Failed"} 1`+"\n")
+		assert.Contains(t, body, `fulcrum_agents{agent_type="proxmox",status="Connected"} 4`+"\n")
+		assert.Contains(t, body, `fulcrum_agents{agent_type="proxmox",status="Disconnected"} 0`+"\n")
+		assert.Contains(t, body, `fulcrum_metric_type_info{metric_type="cpu",entity_type="Service",kind="scalar",unit="percent"} 1`+"\n")
+	})
+
+	t.Run("Query error", func(t *testing.T) {
+		serviceQuerier := domain.NewMockServiceQuerier(t)
+		serviceQuerier.EXPECT().CountByServiceTypeAndStatus(mock.Anything).Return(nil, fmt.Errorf("db down"))
+
+		handler := NewPrometheusHandler(serviceQuerier, domain.NewMockJobQuerier(t), domain.NewMockAgentQuerier(t), domain.NewMockMetricTypeQuerier(t), authz.NewMockAuthorizer(t))
+		req := httptest.NewRequest("GET", "/", nil)
+		w := httptest.NewRecorder()
+		handler.Expose(w, req)
+
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+	})
+}
@@ -2,28 +2,43 @@ package api
 
 import (
 	"context"
+	"fmt"
+	"mime"
 	"net/http"
+	"strconv"
+	"time"
 
+	"github.com/fulcrumproject/core/pkg/auth"
 	"github.com/fulcrumproject/core/pkg/authz"
 	"github.com/fulcrumproject/core/pkg/domain"
 	"github.com/fulcrumproject/core/pkg/middlewares"
 	"github.com/fulcrumproject/core/pkg/properties"
+	"github.com/fulcrumproject/core/pkg/schema"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/render"
+	"github.com/wI2L/jsondiff"
 )
 
+// serviceIncludes are the relations that can be nested in the service responses
+var serviceIncludes = []string{"agent", "group", "serviceType"}
+
 type ServiceHandler struct {
 	querier             domain.ServiceQuerier
 	agentQuerier        domain.AgentQuerier
 	serviceGroupQuerier domain.ServiceGroupQuerier
+	jobQuerier          domain.JobQuerier
+	eventQuerier        domain.EventQuerier
 	commander           domain.ServiceCommander
 	authz               authz.Authorizer
+	exportBatchSize     int
 }
 
 func NewServiceHandler(
 	querier domain.ServiceQuerier,
 	agentQuerier domain.AgentQuerier,
 	serviceGroupQuerier domain.ServiceGroupQuerier,
+	jobQuerier domain.JobQuerier,
+	eventQuerier domain.EventQuerier,
 	commander domain.ServiceCommander,
 	authz authz.Authorizer,
 ) *ServiceHandler {
@@ -31,8 +46,11 @@ func NewServiceHandler(
 		querier:             querier,
 		agentQuerier:        agentQuerier,
 		serviceGroupQuerier: serviceGroupQuerier,
+		jobQuerier:          jobQuerier,
+		eventQuerier:        eventQuerier,
 		commander:           commander,
 		authz:               authz,
+		exportBatchSize:     ServiceExportBatchSize,
 	}
 }
 
@@ -46,24 +64,94 @@ type CreateServiceReq struct {
 	AgentTags     []string         `json:"agentTags,omitempty"`
 	Name          string           `json:"name"`
 	Properties    properties.JSON  `json:"properties"`
+	// OperationTimeout overrides the default operation timeout of the service type
+	OperationTimeout *JSONDuration `json:"operationTimeout,omitempty"`
+	// IdleTimeout enables the auto-stop of the service once inactive for this long
+	IdleTimeout *JSONDuration `json:"idleTimeout,omitempty"`
+	// TargetState is the state the service is driven to once created, e.g. Started
+	TargetState string `json:"targetState,omitempty"`
+	// RequiredRegion restricts the placement of the service to the agents of the region
+	RequiredRegion *string `json:"requiredRegion,omitempty"`
+	// OptionIDs selects service options of the provider, their values are merged into the properties
+	OptionIDs []properties.UUID `json:"optionIds,omitempty"`
 }
 
 // UpdateServiceReq represents the request to update a service
 type UpdateServiceReq struct {
 	Name       *string          `json:"name,omitempty"`
 	Properties *properties.JSON `json:"properties,omitempty"`
+	// IdleTimeout replaces the idle timeout, "0s" disables the auto-stop
+	IdleTimeout *JSONDuration `json:"idleTimeout,omitempty"`
+	// RequiredRegion cannot be changed, it is only accepted with the region of the service
+	RequiredRegion *string `json:"requiredRegion,omitempty"`
+}
+
+// PreviewServiceUpdateReq represents the request to preview a service update
+type PreviewServiceUpdateReq struct {
+	Name       *string          `json:"name,omitempty"`
+	Properties *properties.JSON `json:"properties,omitempty"`
+}
+
+// CloneServiceReq represents the request to clone a service
+type CloneServiceReq struct {
+	Name string `json:"name"`
+}
+
+// SetServiceLabelsReq represents the request to change the labels of a service
+// A null value removes the label, the labels left out are kept
+type SetServiceLabelsReq struct {
+	Labels map[string]*string `json:"labels"`
+}
+
+// SetServiceDependenciesReq represents the request to replace the services a service depends on
+type SetServiceDependenciesReq struct {
+	DependsOn []properties.UUID `json:"dependsOn"`
+}
+
+// SetServiceMaintenanceReq represents the request to set or clear the maintenance mode of a service
+type SetServiceMaintenanceReq struct {
+	Maintenance bool `json:"maintenance"`
+}
+
+// ReportServiceOrphanedReq represents the report of an agent that the backing resource of a service no longer exists
+type ReportServiceOrphanedReq struct {
+	Reason string `json:"reason,omitempty"`
 }
 
+// ReconcileServiceReq represents the corrections of the agent-sourced properties reported by an agent
+type ReconcileServiceReq struct {
+	Properties properties.JSON `json:"properties"`
+}
+
+// contentTypeJSONPatch selects the JSON Patch (RFC 6902) variant of the service update
+const contentTypeJSONPatch = "application/json-patch+json"
+
+// PatchServiceReq is a JSON Patch document applied to the service properties
+type PatchServiceReq []properties.JSONPatchOperation
+
 // ServiceActionReq represents a status transition request
 type ServiceActionReq struct {
 	Action string `json:"action"`
 }
 
+// BatchServiceActionReq represents a request to apply several actions at once
+type BatchServiceActionReq struct {
+	Items []BatchServiceActionItemReq `json:"items"`
+}
+
+// BatchServiceActionItemReq represents a single action of a batch request
+type BatchServiceActionItemReq struct {
+	ServiceID   properties.UUID `json:"serviceId"`
+	Action      string          `json:"action"`
+	ScheduledAt *time.Time      `json:"scheduledAt,omitempty"`
+}
+
 // ServiceActionRequest represents a generic action request with optional properties
-// Used by the generic action endpoint (POST /services/{id}/actions/{action})
+// Used by the generic action endpoint (POST /services/{id}/{action}), the body can be left out
 // Authorization is handled via service ID from URL path (AuthzFromID middleware)
 type ServiceActionRequest struct {
-	Properties *properties.JSON `json:"properties,omitempty"`
+	Properties  *properties.JSON `json:"properties,omitempty"`
+	ScheduledAt *time.Time       `json:"scheduledAt,omitempty"`
 }
 
 // CreateServiceScopeExtractor creates an extractor that gets a combined scope from the request body
@@ -87,7 +175,17 @@ func (h *ServiceHandler) Routes() func(r chi.Router) {
 		// List - simple authorization
 		r.With(
 			middlewares.AuthzSimple(authz.ObjectTypeService, authz.ActionRead, h.authz),
-		).Get("/", List(h.querier, ServiceToRes))
+		).Get("/", h.List)
+
+		// Export - CSV of the listed services, same authorization as list
+		r.With(
+			middlewares.AuthzSimple(authz.ObjectTypeService, authz.ActionRead, h.authz),
+		).Get("/export.csv", h.Export)
+
+		// State machine - lifecycle transition graph of a service type, readable by whoever can read service types
+		r.With(
+			middlewares.AuthzSimple(authz.ObjectTypeServiceType, authz.ActionRead, h.authz),
+		).Get("/state-machine", h.StateMachine)
 
 		// Create - decode body + specialized scope extractor for authorization
 		r.With(
@@ -100,6 +198,34 @@ func (h *ServiceHandler) Routes() func(r chi.Router) {
 			),
 		).Post("/", h.Create)
 
+		// Validate - dry-run creation, same authorization as create
+		r.With(
+			middlewares.DecodeBody[CreateServiceReq](),
+			middlewares.AuthzFromExtractor(
+				authz.ObjectTypeService,
+				authz.ActionCreate,
+				h.authz,
+				CreateServiceScopeExtractor(h.serviceGroupQuerier, h.agentQuerier),
+			),
+		).Post("/validate", h.ValidateCreate)
+
+		// Estimate - cost of a creation validated like the dry-run, same authorization as create
+		r.With(
+			middlewares.DecodeBody[CreateServiceReq](),
+			middlewares.AuthzFromExtractor(
+				authz.ObjectTypeService,
+				authz.ActionCreate,
+				h.authz,
+				CreateServiceScopeExtractor(h.serviceGroupQuerier, h.agentQuerier),
+			),
+		).Post("/estimate", h.Estimate)
+
+		// Batch action - decode body, authorization is checked for each service
+		r.With(
+			middlewares.AuthzSimple(authz.ObjectTypeService, authz.ActionUpdate, h.authz),
+			middlewares.DecodeBody[BatchServiceActionReq](),
+		).Post("/batch/transition", h.BatchAction)
+
 		// Resource-specific routes
 		r.Group(func(r chi.Router) {
 			r.Use(middlewares.ID)
@@ -107,29 +233,169 @@ func (h *ServiceHandler) Routes() func(r chi.Router) {
 			// Get - authorize from resource ID
 			r.With(
 				middlewares.AuthzFromID(authz.ObjectTypeService, authz.ActionRead, h.authz, h.querier.AuthScope),
-			).Get("/{id}", Get(h.querier.Get, ServiceToRes))
+			).Get("/{id}", h.Get)
+
+			// History - jobs and events of the service, authorize from resource ID
+			r.With(
+				middlewares.AuthzFromID(authz.ObjectTypeService, authz.ActionRead, h.authz, h.querier.AuthScope),
+			).Get("/{id}/history", h.History)
+
+			// Update - authorize from resource ID + decode body, JSON Patch bodies patch the properties
+			update := middlewares.DecodeBody[UpdateServiceReq]()(Update(h.Update, ServiceToRes))
+			patch := middlewares.DecodeBody[PatchServiceReq]()(Update(h.Patch, ServiceToRes))
+			r.With(
+				middlewares.AuthzFromID(authz.ObjectTypeService, authz.ActionUpdate, h.authz, h.querier.AuthScope),
+			).Patch("/{id}", func(w http.ResponseWriter, r *http.Request) {
+				if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType == contentTypeJSONPatch {
+					patch.ServeHTTP(w, r)
+					return
+				}
+				update.ServeHTTP(w, r)
+			})
+
+			// Labels - decode body + authorize from resource ID, the properties are left untouched
+			r.With(
+				middlewares.DecodeBody[SetServiceLabelsReq](),
+				middlewares.AuthzFromID(authz.ObjectTypeService, authz.ActionUpdate, h.authz, h.querier.AuthScope),
+			).Patch("/{id}/labels", Update(h.SetLabels, ServiceToRes))
 
-			// Update - decode body + authorize from resource ID
+			// Dependencies - decode body + authorize from resource ID, replaces the services of the group it depends on
 			r.With(
-				middlewares.DecodeBody[UpdateServiceReq](),
+				middlewares.DecodeBody[SetServiceDependenciesReq](),
 				middlewares.AuthzFromID(authz.ObjectTypeService, authz.ActionUpdate, h.authz, h.querier.AuthScope),
-			).Patch("/{id}", Update(h.Update, ServiceToRes))
+			).Put("/{id}/dependencies", Update(h.SetDependencies, ServiceToRes))
+
+			// Maintenance - decode body + authorize from resource ID, freezes the transitions and updates
+			r.With(
+				middlewares.DecodeBody[SetServiceMaintenanceReq](),
+				middlewares.AuthzFromID(authz.ObjectTypeService, authz.ActionUpdate, h.authz, h.querier.AuthScope),
+			).Post("/{id}/maintenance", Update(h.SetMaintenance, ServiceToRes))
+
+			// Reconcile - agent corrections of the properties it reports, without a lifecycle action
+			r.With(
+				middlewares.DecodeBody[ReconcileServiceReq](),
+				middlewares.AuthzFromID(authz.ObjectTypeService, authz.ActionReconcile, h.authz, h.querier.AuthScope),
+			).Patch("/{id}/reconcile", Update(h.Reconcile, ServiceToRes))
+
+			// Orphaned - agent report that the backing resource is gone, flags the service without a transition
+			r.With(
+				middlewares.DecodeBody[ReportServiceOrphanedReq](),
+				middlewares.AuthzFromID(authz.ObjectTypeService, authz.ActionReportOrphaned, h.authz, h.querier.AuthScope),
+			).Post("/{id}/orphaned", Update(h.ReportOrphaned, ServiceToRes))
 
 			// Delete - authorize from resource ID
 			r.With(
 				middlewares.AuthzFromID(authz.ObjectTypeService, authz.ActionDelete, h.authz, h.querier.AuthScope),
 			).Delete("/{id}", CommandWithoutBody(h.Delete))
 
+			// Restore - bring back a deleted service within the restore window
+			r.With(
+				middlewares.AuthzFromID(authz.ObjectTypeService, authz.ActionDelete, h.authz, h.querier.AuthScope),
+			).Post("/{id}/restore", ActionWithoutBody(h.commander.Restore, ServiceToRes))
+
+			// Cancel - abort the operation in progress
+			r.With(
+				middlewares.AuthzFromID(authz.ObjectTypeService, authz.ActionUpdate, h.authz, h.querier.AuthScope),
+			).Post("/{id}/cancel", ActionWithoutBody(h.commander.CancelOperation, ServiceToRes))
+
+			// Preview - decode body + authorize from resource ID, computes an update without applying it
+			r.With(
+				middlewares.DecodeBody[PreviewServiceUpdateReq](),
+				middlewares.AuthzFromID(authz.ObjectTypeService, authz.ActionUpdate, h.authz, h.querier.AuthScope),
+			).Post("/{id}/preview", h.PreviewUpdate)
+
+			// Clone - decode body + authorize creation from the source service
+			r.With(
+				middlewares.DecodeBody[CloneServiceReq](),
+				middlewares.AuthzFromID(authz.ObjectTypeService, authz.ActionCreate, h.authz, h.querier.AuthScope),
+			).Post("/{id}/clone", h.Clone)
+
 			// Generic action - handle any lifecycle action (start, stop, restart, etc.)
 			// Note: "delete" action should use DELETE /{id}, "update" should use PATCH /{id}
 			r.With(
 				middlewares.ActionName,
+				middlewares.DecodeOptionalBody[ServiceActionRequest](),
 				middlewares.AuthzFromID(authz.ObjectTypeService, authz.ActionUpdate, h.authz, h.querier.AuthScope),
 			).Post("/{id}/{action}", h.GenericAction)
 		})
 	}
 }
 
+// List handles the service list, nesting the requested relations
+func (h *ServiceHandler) List(w http.ResponseWriter, r *http.Request) {
+	toRes, err := h.serviceToResIncluding(r)
+	if err != nil {
+		render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+	List(h.querier, toRes)(w, r)
+}
+
+// Get handles the service retrieval, nesting the requested relations
+// Deleted services are not found unless the includeDeleted query parameter is true
+func (h *ServiceHandler) Get(w http.ResponseWriter, r *http.Request) {
+	toRes, err := h.serviceToResIncluding(r)
+	if err != nil {
+		render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+	includeDeleted := false
+	if value := r.URL.Query().Get(domain.ServiceIncludeDeletedParam); value != "" {
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			render.Render(w, r, ErrInvalidRequest(fmt.Errorf("invalid %s parameter: %s", domain.ServiceIncludeDeletedParam, value)))
+			return
+		}
+		includeDeleted = parsed
+	}
+	get := func(ctx context.Context, id properties.UUID) (*domain.Service, error) {
+		svc, err := h.querier.Get(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if svc.IsDeleted() && !includeDeleted {
+			return nil, domain.NewNotFoundErrorf("service %s is deleted", id)
+		}
+		return svc, nil
+	}
+	Get(get, toRes)(w, r)
+}
+
+// serviceToResIncluding returns the conversion nesting the relations of the include parameter
+// A relation is only nested when the identity is authorized to read it, as when reading it directly
+func (h *ServiceHandler) serviceToResIncluding(r *http.Request) (func(*domain.Service) *ServiceRes, error) {
+	includes, err := ParseIncludeRequest(r, serviceIncludes...)
+	if err != nil {
+		return nil, err
+	}
+	identity := auth.MustGetIdentity(r.Context())
+	canRead := func(object authz.ObjectType, scope authz.ObjectScope) bool {
+		return h.authz.Authorize(identity, authz.ActionRead, object, scope) == nil
+	}
+
+	return func(s *domain.Service) *ServiceRes {
+		res := ServiceToRes(s)
+		res.Agent, res.Group, res.ServiceType = nil, nil, nil
+		for _, include := range includes {
+			switch include {
+			case "agent":
+				if s.Agent != nil && canRead(authz.ObjectTypeAgent, &authz.DefaultObjectScope{ProviderID: &s.Agent.ProviderID, AgentID: &s.Agent.ID}) {
+					res.Agent = AgentToRes(s.Agent)
+				}
+			case "group":
+				if s.Group != nil && canRead(authz.ObjectTypeServiceGroup, &authz.DefaultObjectScope{ConsumerID: &s.Group.ConsumerID}) {
+					res.Group = ServiceGroupToRes(s.Group)
+				}
+			case "serviceType":
+				if s.ServiceType != nil && canRead(authz.ObjectTypeServiceType, &authz.AllwaysMatchObjectScope{}) {
+					res.ServiceType = ServiceTypeToRes(s.ServiceType)
+				}
+			}
+		}
+		return res
+	}, nil
+}
+
 // Create handles service creation with custom logic for agent selection
 func (h *ServiceHandler) Create(w http.ResponseWriter, r *http.Request) {
 	// Get decoded body from context
@@ -144,8 +410,13 @@ func (h *ServiceHandler) Create(w http.ResponseWriter, r *http.Request) {
 			AgentID:       *body.AgentID,
 			ServiceTypeID: body.ServiceTypeID,
 			GroupID:       body.GroupID,
-			Name:          body.Name,
-			Properties:    body.Properties,
+			Name:             body.Name,
+			Properties:       body.Properties,
+			OperationTimeout: durationFromJSON(body.OperationTimeout),
+			IdleTimeout:      durationFromJSON(body.IdleTimeout),
+			TargetState:      body.TargetState,
+			RequiredRegion:   body.RequiredRegion,
+			OptionIDs:        body.OptionIDs,
 		}
 		service, err = h.commander.Create(
 			r.Context(),
@@ -157,8 +428,13 @@ func (h *ServiceHandler) Create(w http.ResponseWriter, r *http.Request) {
 			CreateServiceParams: domain.CreateServiceParams{
 				ServiceTypeID: body.ServiceTypeID,
 				GroupID:       body.GroupID,
-				Name:          body.Name,
-				Properties:    body.Properties,
+				Name:             body.Name,
+				Properties:       body.Properties,
+				OperationTimeout: durationFromJSON(body.OperationTimeout),
+				IdleTimeout:      durationFromJSON(body.IdleTimeout),
+				TargetState:      body.TargetState,
+				RequiredRegion:   body.RequiredRegion,
+				OptionIDs:        body.OptionIDs,
 			},
 			ServiceTags: body.AgentTags,
 		}
@@ -177,27 +453,168 @@ func (h *ServiceHandler) Create(w http.ResponseWriter, r *http.Request) {
 	render.JSON(w, r, ServiceToRes(service))
 }
 
+// ValidateCreate validates a service creation request without creating the service
+func (h *ServiceHandler) ValidateCreate(w http.ResponseWriter, r *http.Request) {
+	body := middlewares.MustGetBody[CreateServiceReq](r.Context())
+
+	result, err := h.commander.ValidateCreate(r.Context(), dryRunCreateParams(body))
+	if err != nil {
+		render.Render(w, r, ErrDomain(err))
+		return
+	}
+
+	render.JSON(w, r, ValidateCreateServiceToRes(result))
+}
+
+// Estimate returns the estimated cost of a service creation request without creating the service
+func (h *ServiceHandler) Estimate(w http.ResponseWriter, r *http.Request) {
+	body := middlewares.MustGetBody[CreateServiceReq](r.Context())
+
+	estimate, err := h.commander.EstimateCost(r.Context(), dryRunCreateParams(body))
+	if err != nil {
+		render.Render(w, r, ErrDomain(err))
+		return
+	}
+
+	render.JSON(w, r, CostEstimateToRes(estimate))
+}
+
+// dryRunCreateParams returns the parameters of a creation request that is validated or estimated without
+// creating the service, the agent is selected with the tags when the request does not name one
+func dryRunCreateParams(body CreateServiceReq) domain.CreateServiceWithTagsParams {
+	params := domain.CreateServiceWithTagsParams{
+		CreateServiceParams: domain.CreateServiceParams{
+			ServiceTypeID:    body.ServiceTypeID,
+			GroupID:          body.GroupID,
+			Name:             body.Name,
+			Properties:       body.Properties,
+			OperationTimeout: durationFromJSON(body.OperationTimeout),
+			IdleTimeout:      durationFromJSON(body.IdleTimeout),
+			TargetState:      body.TargetState,
+			RequiredRegion:   body.RequiredRegion,
+			OptionIDs:        body.OptionIDs,
+		},
+		ServiceTags: body.AgentTags,
+	}
+	if body.AgentID != nil {
+		params.AgentID = *body.AgentID
+	}
+	return params
+}
+
+// Clone handles the creation of a service copied from an existing one
+func (h *ServiceHandler) Clone(w http.ResponseWriter, r *http.Request) {
+	id := middlewares.MustGetID(r.Context())
+	body := middlewares.MustGetBody[CloneServiceReq](r.Context())
+
+	service, err := h.commander.Clone(r.Context(), id, body.Name)
+	if err != nil {
+		render.Render(w, r, ErrDomain(err))
+		return
+	}
+
+	render.Status(r, http.StatusCreated)
+	render.JSON(w, r, ServiceToRes(service))
+}
+
+// PreviewUpdate handles the preview of a service update, reporting its changes and the action applying them
+func (h *ServiceHandler) PreviewUpdate(w http.ResponseWriter, r *http.Request) {
+	id := middlewares.MustGetID(r.Context())
+	body := middlewares.MustGetBody[PreviewServiceUpdateReq](r.Context())
+
+	preview, err := h.commander.PreviewUpdate(r.Context(), id, body.Name, body.Properties)
+	if err != nil {
+		render.Render(w, r, ErrDomain(err))
+		return
+	}
+
+	render.JSON(w, r, ServiceUpdatePreviewToRes(preview))
+}
+
+// History handles the paginated timeline of the jobs and events of a service
+func (h *ServiceHandler) History(w http.ResponseWriter, r *http.Request) {
+	id := middlewares.MustGetID(r.Context())
+	identity := auth.MustGetIdentity(r.Context())
+	pag, err := ParsePageRequest(r)
+	if err != nil {
+		render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+
+	result, err := domain.ServiceHistory(r.Context(), h.jobQuerier, h.eventQuerier, &identity.Scope, id, pag)
+	if err != nil {
+		render.Render(w, r, ErrDomain(err))
+		return
+	}
+
+	render.JSON(w, r, NewPageResponse(result, ServiceHistoryEntryToRes))
+}
+
 // Adapter functions for standard handlers
 func (h *ServiceHandler) Update(ctx context.Context, id properties.UUID, req *UpdateServiceReq) (*domain.Service, error) {
 	params := domain.UpdateServiceParams{
-		ID:         id,
-		Name:       req.Name,
-		Properties: req.Properties,
+		ID:             id,
+		Name:           req.Name,
+		Properties:     req.Properties,
+		IdleTimeout:    durationFromJSON(req.IdleTimeout),
+		RequiredRegion: req.RequiredRegion,
 	}
 	return h.commander.Update(ctx, params)
 }
 
+// Patch applies a JSON Patch to the service properties and updates it with the changed properties
+func (h *ServiceHandler) Patch(ctx context.Context, id properties.UUID, req *PatchServiceReq) (*domain.Service, error) {
+	svc, err := h.querier.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	props, err := domain.PatchServiceProperties(svc.Properties, *req)
+	if err != nil {
+		return nil, err
+	}
+	if props == nil {
+		return svc, nil
+	}
+	return h.commander.Update(ctx, domain.UpdateServiceParams{ID: id, Properties: props})
+}
+
+// SetLabels applies the label changes of the request
+func (h *ServiceHandler) SetLabels(ctx context.Context, id properties.UUID, req *SetServiceLabelsReq) (*domain.Service, error) {
+	return h.commander.SetLabels(ctx, id, req.Labels)
+}
+
+// SetDependencies replaces the dependencies of the service with the ones of the request
+func (h *ServiceHandler) SetDependencies(ctx context.Context, id properties.UUID, req *SetServiceDependenciesReq) (*domain.Service, error) {
+	return h.commander.SetDependencies(ctx, id, req.DependsOn)
+}
+
+// SetMaintenance sets or clears the maintenance mode of the service
+func (h *ServiceHandler) SetMaintenance(ctx context.Context, id properties.UUID, req *SetServiceMaintenanceReq) (*domain.Service, error) {
+	return h.commander.SetMaintenance(ctx, id, req.Maintenance)
+}
+
+func (h *ServiceHandler) Reconcile(ctx context.Context, id properties.UUID, req *ReconcileServiceReq) (*domain.Service, error) {
+	return h.commander.Reconcile(ctx, id, req.Properties)
+}
+
+// ReportOrphaned flags the service whose backing resource the agent reports gone
+func (h *ServiceHandler) ReportOrphaned(ctx context.Context, id properties.UUID, req *ReportServiceOrphanedReq) (*domain.Service, error) {
+	return h.commander.ReportOrphaned(ctx, id, req.Reason)
+}
+
 // GenericAction handles generic lifecycle actions from the URL path
-// Can optionally accept a ServiceActionRequest body with properties
+// Can optionally accept a ServiceActionRequest body with properties and a schedule time
 func (h *ServiceHandler) GenericAction(w http.ResponseWriter, r *http.Request) {
 	id := middlewares.MustGetID(r.Context())
 	action := middlewares.MustGetActionName(r.Context())
+	body := middlewares.MustGetBody[ServiceActionRequest](r.Context())
 
 	// For now, all actions go through DoAction
 	// Future: check requestSchemaType in lifecycle and handle properties accordingly
 	params := domain.DoServiceActionParams{
-		ID:     id,
-		Action: action,
+		ID:          id,
+		Action:      action,
+		ScheduledAt: body.ScheduledAt,
 	}
 	service, err := h.commander.DoAction(r.Context(), params)
 
@@ -209,6 +626,39 @@ func (h *ServiceHandler) GenericAction(w http.ResponseWriter, r *http.Request) {
 	render.JSON(w, r, ServiceToRes(service))
 }
 
+// BatchAction handles several lifecycle actions validated and committed together
+func (h *ServiceHandler) BatchAction(w http.ResponseWriter, r *http.Request) {
+	body := middlewares.MustGetBody[BatchServiceActionReq](r.Context())
+	identity := auth.MustGetIdentity(r.Context())
+
+	// Authorize each service on its own scope
+	params := domain.BatchServiceActionParams{Items: make([]domain.DoServiceActionParams, len(body.Items))}
+	for i, item := range body.Items {
+		scope, err := h.querier.AuthScope(r.Context(), item.ServiceID)
+		if err != nil {
+			render.Render(w, r, ErrDomain(err))
+			return
+		}
+		if err := h.authz.Authorize(identity, authz.ActionUpdate, authz.ObjectTypeService, scope); err != nil {
+			render.Render(w, r, ErrUnauthorized(err))
+			return
+		}
+		params.Items[i] = domain.DoServiceActionParams{ID: item.ServiceID, Action: item.Action, ScheduledAt: item.ScheduledAt}
+	}
+
+	results, err := h.commander.BatchAction(r.Context(), params)
+	if err != nil && results == nil {
+		render.Render(w, r, ErrDomain(err))
+		return
+	}
+
+	// Item level failures reject the whole batch but still report each outcome
+	if err != nil {
+		render.Status(r, http.StatusBadRequest)
+	}
+	render.JSON(w, r, BatchServiceActionToRes(results))
+}
+
 func (h *ServiceHandler) Delete(ctx context.Context, id properties.UUID) error {
 	params := domain.DoServiceActionParams{
 		ID:     id,
@@ -228,11 +678,25 @@ type ServiceRes struct {
 	ServiceTypeID     properties.UUID  	 `json:"serviceTypeId"`
 	ServiceType 			*ServiceTypeRes    `json:"serviceType,omitempty"`
 	GroupID           properties.UUID  	 `json:"groupId"`
+	Group             *ServiceGroupRes 	 `json:"group,omitempty"`
 	AgentInstanceID   *string          	 `json:"agentInstanceId,omitempty"`
 	Name              string           	 `json:"name"`
 	Status            string           	 `json:"status"`
 	Properties        *properties.JSON 	 `json:"properties,omitempty"`
+	Labels            map[string]string  `json:"labels"`
+	SchemaVersion     int              	 `json:"schemaVersion"`
+	OperationTimeout  *JSONDuration      `json:"operationTimeout,omitempty"`
+	IdleTimeout       *JSONDuration      `json:"idleTimeout,omitempty"`
+	LastActivityAt    *JSONUTCTime       `json:"lastActivityAt,omitempty"`
+	Maintenance       bool               `json:"maintenance"`
+	Orphaned          bool               `json:"orphaned"`
+	OrphanedAt        *JSONUTCTime       `json:"orphanedAt,omitempty"`
+	Failed            bool               `json:"failed"`
+	FailedAt          *JSONUTCTime       `json:"failedAt,omitempty"`
+	DependsOn         []properties.UUID  `json:"dependsOn,omitempty"`
+	RequiredRegion    *string            `json:"requiredRegion,omitempty"`
 	AgentInstanceData *properties.JSON 	 `json:"agentInstanceData,omitempty"`
+	DeletedAt         *JSONUTCTime     	 `json:"deletedAt,omitempty"`
 	CreatedAt         JSONUTCTime      	 `json:"createdAt"`
 	UpdatedAt         JSONUTCTime      	 `json:"updatedAt"`
 }
@@ -250,11 +714,28 @@ func ServiceToRes(s *domain.Service) *ServiceRes {
 		Name:              s.Name,
 		Status:            s.Status,
 		Properties:        s.Properties,
+		Labels:            s.Labels,
+		SchemaVersion:     s.SchemaVersion,
+		OperationTimeout:  durationToJSON(s.OperationTimeout),
+		IdleTimeout:       durationToJSON(s.IdleTimeout),
+		LastActivityAt:    (*JSONUTCTime)(s.LastActivityAt),
+		Maintenance:       s.Maintenance,
+		Orphaned:          s.Orphaned,
+		OrphanedAt:        (*JSONUTCTime)(s.OrphanedAt),
+		Failed:            s.Failed,
+		FailedAt:          (*JSONUTCTime)(s.FailedAt),
+		DependsOn:         s.DependsOn,
+		RequiredRegion:    s.RequiredRegion,
 		AgentInstanceData: s.AgentInstanceData,
+		DeletedAt:         (*JSONUTCTime)(s.DeletedAt),
 		CreatedAt:         JSONUTCTime(s.CreatedAt),
 		UpdatedAt:         JSONUTCTime(s.UpdatedAt),
 	}
 
+	if resp.Labels == nil {
+		resp.Labels = map[string]string{}
+	}
+
 	if s.Agent != nil {
 		resp.Agent = AgentToRes(s.Agent)
 	}
@@ -263,5 +744,165 @@ func ServiceToRes(s *domain.Service) *ServiceRes {
 		resp.ServiceType = ServiceTypeToRes(s.ServiceType)
 	}
 
+	if s.Group != nil {
+		resp.Group = ServiceGroupToRes(s.Group)
+	}
+
+	return resp
+}
+
+// ValidateCreateServiceRes represents the response body of a dry-run service creation
+type ValidateCreateServiceRes struct {
+	Valid      bool                           `json:"valid"`
+	Properties properties.JSON                `json:"properties,omitempty"`
+	Errors     []schema.ValidationErrorDetail `json:"errors,omitempty"`
+}
+
+// ValidateCreateServiceToRes converts a domain.ValidateCreateServiceResult to a ValidateCreateServiceRes
+func ValidateCreateServiceToRes(result *domain.ValidateCreateServiceResult) *ValidateCreateServiceRes {
+	return &ValidateCreateServiceRes{
+		Valid:      result.Valid,
+		Properties: result.Properties,
+		Errors:     result.Errors,
+	}
+}
+
+// CostEstimateRes represents the response body of a service cost estimate
+type CostEstimateRes struct {
+	Total float64                `json:"total"`
+	Items []*CostEstimateItemRes `json:"items"`
+}
+
+// CostEstimateItemRes represents a line of the breakdown of a cost estimate
+type CostEstimateItemRes struct {
+	Kind     string           `json:"kind"`
+	Name     string           `json:"name"`
+	OptionID *properties.UUID `json:"optionId,omitempty"`
+	Quantity float64          `json:"quantity"`
+	UnitCost float64          `json:"unitCost"`
+	Cost     float64          `json:"cost"`
+}
+
+// CostEstimateToRes converts a domain.CostEstimate to a CostEstimateRes
+func CostEstimateToRes(estimate *domain.CostEstimate) *CostEstimateRes {
+	items := make([]*CostEstimateItemRes, 0, len(estimate.Items))
+	for _, item := range estimate.Items {
+		items = append(items, &CostEstimateItemRes{
+			Kind:     item.Kind,
+			Name:     item.Name,
+			OptionID: item.OptionID,
+			Quantity: item.Quantity,
+			UnitCost: item.UnitCost,
+			Cost:     item.Cost,
+		})
+	}
+	return &CostEstimateRes{Total: estimate.Total, Items: items}
+}
+
+// ServiceUpdatePreviewRes represents the response body of a service update preview
+type ServiceUpdatePreviewRes struct {
+	Valid             bool                           `json:"valid"`
+	Errors            []schema.ValidationErrorDetail `json:"errors,omitempty"`
+	Name              string                         `json:"name"`
+	ChangedProperties []string                       `json:"changedProperties"`
+	Diff              jsondiff.Patch                 `json:"diff"`
+	UpdateMode        string                         `json:"updateMode,omitempty"`
+	Action            string                         `json:"action,omitempty"`
+	NextStatus        string                         `json:"nextStatus,omitempty"`
+}
+
+// ServiceUpdatePreviewToRes converts a domain.ServiceUpdatePreview to a ServiceUpdatePreviewRes
+func ServiceUpdatePreviewToRes(preview *domain.ServiceUpdatePreview) *ServiceUpdatePreviewRes {
+	resp := &ServiceUpdatePreviewRes{
+		Valid:             preview.Valid,
+		Errors:            preview.Errors,
+		Name:              preview.Name,
+		ChangedProperties: preview.ChangedProperties,
+		Diff:              preview.Diff,
+		UpdateMode:        preview.UpdateMode,
+		Action:            preview.Action,
+		NextStatus:        preview.NextStatus,
+	}
+	if resp.ChangedProperties == nil {
+		resp.ChangedProperties = []string{}
+	}
+	if resp.Diff == nil {
+		resp.Diff = jsondiff.Patch{}
+	}
+	return resp
+}
+
+// ServiceHistoryEntryRes represents an item of the timeline of a service
+type ServiceHistoryEntryRes struct {
+	Kind          domain.ServiceHistoryKind `json:"kind"`
+	ID            properties.UUID           `json:"id"`
+	Time          JSONUTCTime               `json:"time"`
+	Action        string                    `json:"action,omitempty"`
+	Status        domain.JobStatus          `json:"status,omitempty"`
+	ErrorMessage  string                    `json:"errorMessage,omitempty"`
+	Attempt       int                       `json:"attempt,omitempty"`
+	ScheduledAt   *JSONUTCTime              `json:"scheduledAt,omitempty"`
+	ClaimedAt     *JSONUTCTime              `json:"claimedAt,omitempty"`
+	CompletedAt   *JSONUTCTime              `json:"completedAt,omitempty"`
+	Diagnostics   *properties.JSON          `json:"diagnostics,omitempty"`
+	EventType     domain.EventType          `json:"eventType,omitempty"`
+	InitiatorType domain.InitiatorType      `json:"initiatorType,omitempty"`
+	InitiatorID   string                    `json:"initiatorId,omitempty"`
+	Payload       properties.JSON           `json:"payload,omitempty"`
+}
+
+// ServiceHistoryEntryToRes converts a domain.ServiceHistoryEntry to a ServiceHistoryEntryRes
+func ServiceHistoryEntryToRes(e *domain.ServiceHistoryEntry) *ServiceHistoryEntryRes {
+	resp := &ServiceHistoryEntryRes{
+		Kind:          e.Kind,
+		ID:            e.ID,
+		Time:          JSONUTCTime(e.Time),
+		Action:        e.Action,
+		Status:        e.Status,
+		ErrorMessage:  e.ErrorMessage,
+		Attempt:       e.Attempt,
+		Diagnostics:   e.Diagnostics,
+		EventType:     e.EventType,
+		InitiatorType: e.InitiatorType,
+		InitiatorID:   e.InitiatorID,
+		Payload:       e.Payload,
+	}
+	if e.ScheduledAt != nil {
+		resp.ScheduledAt = (*JSONUTCTime)(e.ScheduledAt)
+	}
+	if e.ClaimedAt != nil {
+		resp.ClaimedAt = (*JSONUTCTime)(e.ClaimedAt)
+	}
+	if e.CompletedAt != nil {
+		resp.CompletedAt = (*JSONUTCTime)(e.CompletedAt)
+	}
+	return resp
+}
+
+// BatchServiceActionRes represents the response body of a batch action
+type BatchServiceActionRes struct {
+	Items []BatchServiceActionItemRes `json:"items"`
+}
+
+// BatchServiceActionItemRes represents the outcome of a single batch item
+type BatchServiceActionItemRes struct {
+	ServiceID properties.UUID `json:"serviceId"`
+	Status    string          `json:"status,omitempty"`
+	Error     render.Renderer `json:"error,omitempty"`
+}
+
+// BatchServiceActionToRes converts the batch results to a BatchServiceActionRes
+func BatchServiceActionToRes(results []domain.BatchServiceActionResult) *BatchServiceActionRes {
+	resp := &BatchServiceActionRes{Items: make([]BatchServiceActionItemRes, len(results))}
+	for i, result := range results {
+		item := BatchServiceActionItemRes{ServiceID: result.ID}
+		if result.Service != nil {
+			item.Status = result.Service.Status
+		}
+		if result.Err != nil {
+			item.Error = ErrDomain(result.Err)
+		}
+		resp.Items[i] = item
+	}
 	return resp
 }
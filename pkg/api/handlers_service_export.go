@@ -0,0 +1,246 @@
+package api
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/fulcrumproject/core/pkg/auth"
+	"github.com/fulcrumproject/core/pkg/domain"
+	"github.com/fulcrumproject/core/pkg/properties"
+	"github.com/go-chi/render"
+)
+
+const (
+	// ServiceExportBatchSize is the number of services read and flushed at once
+	ServiceExportBatchSize = 500
+
+	// paramExportAttributes lists the property paths exported as their own columns
+	paramExportAttributes = "attributes"
+
+	// serviceExportTimeFormat is the format of the export time in the file name
+	serviceExportTimeFormat = "20060102T150405Z"
+)
+
+// serviceCSVHeader lists the fixed columns of the CSV export, the requested attributes follow as attr.<path>
+// The labels and the properties are flattened in JSON objects with sorted keys so every row has the same columns
+var serviceCSVHeader = []string{
+	"id", "name", "status", "maintenance",
+	"providerId", "consumerId", "groupId", "agentId", "serviceTypeId", "serviceType",
+	"createdAt", "updatedAt", "labels", "properties",
+}
+
+// Export writes the services visible to the caller as CSV, honoring the filters of the list
+// Services are read oldest first by cursor pages that are flushed as soon as they are written, so exports
+// of any size are streamed without being held in memory. The attributes query parameter is a comma
+// separated list of property paths, nested with dots, each exported in its own attr.<path> column.
+func (h *ServiceHandler) Export(w http.ResponseWriter, r *http.Request) {
+	page, err := ParsePageRequest(r)
+	if err != nil {
+		render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+	attributes, err := parseExportAttributes(r.URL.Query().Get(paramExportAttributes))
+	if err != nil {
+		render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+	delete(page.Filters, paramExportAttributes)
+	page.Page = 0
+	page.PageSize = h.exportBatchSize
+	page.Cursor = &domain.PageCursor{}
+	page.SkipCount = true
+	page.Sort = true
+	page.SortFields = []domain.SortField{{Field: "createdAt", Asc: true}}
+	page.Include = []string{"serviceType"}
+
+	ctx := r.Context()
+	scope := &auth.MustGetIdentity(ctx).Scope
+
+	// The first batch is read before the headers are sent so failures still get an error status
+	result, err := h.querier.List(ctx, scope, page)
+	if err != nil {
+		render.Render(w, r, ErrDomain(err))
+		return
+	}
+
+	filename := fmt.Sprintf("services-%s.csv", time.Now().UTC().Format(serviceExportTimeFormat))
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	w.WriteHeader(http.StatusOK)
+	flusher, _ := w.(http.Flusher)
+
+	writer := newCSVServiceWriter(w, attributes)
+	for {
+		for i := range result.Items {
+			if err := writer.Write(&result.Items[i]); err != nil {
+				slog.ErrorContext(ctx, "Failed to write exported service", "error", err)
+				return
+			}
+		}
+		if err := writer.Flush(); err != nil {
+			slog.ErrorContext(ctx, "Failed to write exported services", "error", err)
+			return
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+		if !result.HasNext {
+			return
+		}
+
+		if page.Cursor, err = domain.ParsePageCursor(result.NextCursor); err != nil {
+			slog.ErrorContext(ctx, "Failed to read exported services", "error", err)
+			return
+		}
+		if result, err = h.querier.List(ctx, scope, page); err != nil {
+			// The status is already sent, the truncated export is only reported in the logs
+			slog.ErrorContext(ctx, "Failed to read exported services", "error", err)
+			return
+		}
+	}
+}
+
+// parseExportAttributes splits the comma separated property paths of the attribute columns
+func parseExportAttributes(value string) ([]string, error) {
+	if value == "" {
+		return nil, nil
+	}
+	var attributes []string
+	for _, attribute := range strings.Split(value, ",") {
+		attribute = strings.TrimSpace(attribute)
+		if attribute == "" || strings.HasPrefix(attribute, ".") || strings.HasSuffix(attribute, ".") || strings.Contains(attribute, "..") {
+			return nil, fmt.Errorf("invalid %s parameter: %s", paramExportAttributes, value)
+		}
+		attributes = append(attributes, attribute)
+	}
+	return attributes, nil
+}
+
+// csvServiceWriter writes one service per row after the header row
+type csvServiceWriter struct {
+	writer        *csv.Writer
+	attributes    []string
+	headerWritten bool
+}
+
+func newCSVServiceWriter(w io.Writer, attributes []string) *csvServiceWriter {
+	return &csvServiceWriter{writer: csv.NewWriter(w), attributes: attributes}
+}
+
+func (w *csvServiceWriter) Write(svc *domain.Service) error {
+	if err := w.writeHeader(); err != nil {
+		return err
+	}
+	record, err := serviceCSVRecord(svc, w.attributes)
+	if err != nil {
+		return err
+	}
+	return w.writer.Write(record)
+}
+
+func (w *csvServiceWriter) Flush() error {
+	if err := w.writeHeader(); err != nil {
+		return err
+	}
+	w.writer.Flush()
+	return w.writer.Error()
+}
+
+func (w *csvServiceWriter) writeHeader() error {
+	if w.headerWritten {
+		return nil
+	}
+	header := append([]string{}, serviceCSVHeader...)
+	for _, attribute := range w.attributes {
+		header = append(header, "attr."+attribute)
+	}
+	if err := w.writer.Write(header); err != nil {
+		return err
+	}
+	w.headerWritten = true
+	return nil
+}
+
+// serviceCSVRecord flattens a service in the columns of serviceCSVHeader followed by the attribute columns
+// The attributes missing from the properties are left empty, the strings are written as they are and the
+// other values as JSON
+func serviceCSVRecord(svc *domain.Service, attributes []string) ([]string, error) {
+	var serviceType, labels, props string
+	if svc.ServiceType != nil {
+		serviceType = svc.ServiceType.Name
+	}
+	if len(svc.Labels) > 0 {
+		data, err := json.Marshal(svc.Labels)
+		if err != nil {
+			return nil, fmt.Errorf("failed to serialize service %s labels: %w", svc.ID, err)
+		}
+		labels = string(data)
+	}
+	if svc.Properties != nil && len(*svc.Properties) > 0 {
+		data, err := json.Marshal(svc.Properties)
+		if err != nil {
+			return nil, fmt.Errorf("failed to serialize service %s properties: %w", svc.ID, err)
+		}
+		props = string(data)
+	}
+
+	record := []string{
+		svc.ID.String(),
+		svc.Name,
+		svc.Status,
+		fmt.Sprint(svc.Maintenance),
+		svc.ProviderID.String(),
+		svc.ConsumerID.String(),
+		svc.GroupID.String(),
+		svc.AgentID.String(),
+		svc.ServiceTypeID.String(),
+		serviceType,
+		svc.CreatedAt.UTC().Format(time.RFC3339),
+		svc.UpdatedAt.UTC().Format(time.RFC3339),
+		labels,
+		props,
+	}
+	for _, attribute := range attributes {
+		value, err := serviceAttributeValue(svc.Properties, attribute)
+		if err != nil {
+			return nil, fmt.Errorf("failed to serialize service %s attribute %s: %w", svc.ID, attribute, err)
+		}
+		record = append(record, value)
+	}
+	return record, nil
+}
+
+// serviceAttributeValue returns the CSV value of a property path, nested with dots
+func serviceAttributeValue(props *properties.JSON, path string) (string, error) {
+	if props == nil {
+		return "", nil
+	}
+	var value any = map[string]any(*props)
+	for _, key := range strings.Split(path, ".") {
+		object, ok := value.(map[string]any)
+		if !ok {
+			return "", nil
+		}
+		if value, ok = object[key]; !ok {
+			return "", nil
+		}
+	}
+	switch v := value.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	default:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return "", err
+		}
+		return string(data), nil
+	}
+}
@@ -0,0 +1,154 @@
+package api
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/fulcrumproject/core/pkg/auth"
+	"github.com/fulcrumproject/core/pkg/authz"
+	"github.com/fulcrumproject/core/pkg/domain"
+	"github.com/fulcrumproject/core/pkg/properties"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServiceHandleExport(t *testing.T) {
+	participantID := properties.NewUUID()
+	identity := &auth.Identity{
+		ID:    properties.NewUUID(),
+		Name:  "test-participant",
+		Role:  auth.RoleParticipant,
+		Scope: auth.IdentityScope{ParticipantID: &participantID},
+	}
+	scopeMatcher := mock.MatchedBy(func(scope *auth.IdentityScope) bool {
+		return scope.ParticipantID != nil && *scope.ParticipantID == participantID
+	})
+	createdAt := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	serviceType := &domain.ServiceType{BaseEntity: domain.BaseEntity{ID: properties.NewUUID()}, Name: "vm"}
+	newService := func(name string, props properties.JSON) domain.Service {
+		return domain.Service{
+			BaseEntity:    domain.BaseEntity{ID: properties.NewUUID(), CreatedAt: createdAt, UpdatedAt: createdAt},
+			Name:          name,
+			Status:        "Started",
+			Properties:    &props,
+			Labels:        map[string]string{"team": "billing", "env": "prod"},
+			ProviderID:    properties.NewUUID(),
+			ConsumerID:    participantID,
+			GroupID:       properties.NewUUID(),
+			AgentID:       properties.NewUUID(),
+			ServiceTypeID: serviceType.ID,
+			ServiceType:   serviceType,
+		}
+	}
+
+	newHandler := func(querier *domain.MockServiceQuerier) *ServiceHandler {
+		handler := NewServiceHandler(querier, nil, nil, nil, nil, nil, authz.NewMockAuthorizer(t))
+		handler.exportBatchSize = 2
+		return handler
+	}
+
+	export := func(handler *ServiceHandler, query string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/export.csv"+query, nil)
+		req = req.WithContext(auth.WithIdentity(req.Context(), identity))
+		w := httptest.NewRecorder()
+		handler.Export(w, req)
+		return w
+	}
+
+	t.Run("CSV in batches with filters and attributes", func(t *testing.T) {
+		first := newService("vm-1", properties.JSON{"cpu": float64(2), "disk": map[string]any{"size": "10G"}})
+		second := newService("vm-2", properties.JSON{"cpu": float64(4)})
+		third := newService("vm-3", nil)
+		nextCursor := domain.PageCursor{CreatedAt: createdAt, ID: second.ID}
+		querier := domain.NewMockServiceQuerier(t)
+		querier.EXPECT().List(mock.Anything, scopeMatcher, mock.MatchedBy(func(page *domain.PageReq) bool {
+			return page.Cursor.IsStart()
+		})).RunAndReturn(func(_ context.Context, _ *auth.IdentityScope, page *domain.PageReq) (*domain.PageRes[domain.Service], error) {
+			assert.Equal(t, 2, page.PageSize)
+			assert.True(t, page.SkipCount)
+			assert.Equal(t, []domain.SortField{{Field: "createdAt", Asc: true}}, page.SortOrder())
+			assert.Equal(t, map[string][]string{"currentStatus": {"Started"}}, page.Filters)
+			assert.Equal(t, []string{"serviceType"}, page.Include)
+			return &domain.PageRes[domain.Service]{Items: []domain.Service{first, second}, HasNext: true, NextCursor: nextCursor.Encode()}, nil
+		})
+		querier.EXPECT().List(mock.Anything, scopeMatcher, mock.MatchedBy(func(page *domain.PageReq) bool {
+			return *page.Cursor == nextCursor
+		})).Return(&domain.PageRes[domain.Service]{Items: []domain.Service{third}}, nil)
+
+		w := export(newHandler(querier), "?currentStatus=Started&attributes=cpu,disk.size,missing")
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "text/csv; charset=utf-8", w.Header().Get("Content-Type"))
+		assert.Regexp(t, regexp.MustCompile(`^attachment; filename="services-\d{8}T\d{6}Z\.csv"$`), w.Header().Get("Content-Disposition"))
+		assert.True(t, w.Flushed)
+		records, err := csv.NewReader(w.Body).ReadAll()
+		require.NoError(t, err)
+		require.Len(t, records, 4)
+		assert.Equal(t, append(append([]string{}, serviceCSVHeader...), "attr.cpu", "attr.disk.size", "attr.missing"), records[0])
+		row := map[string]string{}
+		for i, column := range records[0] {
+			row[column] = records[1][i]
+		}
+		assert.Equal(t, first.ID.String(), row["id"])
+		assert.Equal(t, "vm-1", row["name"])
+		assert.Equal(t, "Started", row["status"])
+		assert.Equal(t, "vm", row["serviceType"])
+		assert.Equal(t, participantID.String(), row["consumerId"])
+		assert.Equal(t, "2025-01-02T03:04:05Z", row["createdAt"])
+		assert.Equal(t, `{"env":"prod","team":"billing"}`, row["labels"])
+		assert.Equal(t, `{"cpu":2,"disk":{"size":"10G"}}`, row["properties"])
+		assert.Equal(t, "2", row["attr.cpu"])
+		assert.Equal(t, "10G", row["attr.disk.size"])
+		assert.Equal(t, "", row["attr.missing"])
+		assert.Equal(t, "vm-3", records[3][1])
+		assert.Equal(t, "", records[3][13])
+	})
+
+	t.Run("Header only when nothing matches", func(t *testing.T) {
+		querier := domain.NewMockServiceQuerier(t)
+		querier.EXPECT().List(mock.Anything, scopeMatcher, mock.Anything).Return(&domain.PageRes[domain.Service]{}, nil)
+
+		w := export(newHandler(querier), "")
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		records, err := csv.NewReader(w.Body).ReadAll()
+		require.NoError(t, err)
+		assert.Equal(t, [][]string{serviceCSVHeader}, records)
+	})
+
+	t.Run("Invalid attributes", func(t *testing.T) {
+		w := export(newHandler(domain.NewMockServiceQuerier(t)), "?attributes=cpu,,disk")
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("List error before streaming", func(t *testing.T) {
+		querier := domain.NewMockServiceQuerier(t)
+		querier.EXPECT().List(mock.Anything, scopeMatcher, mock.Anything).Return(nil, domain.NewInvalidInputErrorf("unsupported filter"))
+
+		w := export(newHandler(querier), "?unknown=1")
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.NotEqual(t, "text/csv; charset=utf-8", w.Header().Get("Content-Type"))
+	})
+
+	t.Run("Read error after streaming truncates the export", func(t *testing.T) {
+		querier := domain.NewMockServiceQuerier(t)
+		querier.EXPECT().List(mock.Anything, scopeMatcher, mock.Anything).
+			Return(&domain.PageRes[domain.Service]{Items: []domain.Service{newService("vm-1", nil)}, HasNext: true, NextCursor: domain.PageCursor{CreatedAt: createdAt}.Encode()}, nil).Once()
+		querier.EXPECT().List(mock.Anything, scopeMatcher, mock.Anything).Return(nil, errors.New("db down")).Once()
+
+		w := export(newHandler(querier), "")
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		records, err := csv.NewReader(w.Body).ReadAll()
+		require.NoError(t, err)
+		assert.Len(t, records, 2)
+	})
+}
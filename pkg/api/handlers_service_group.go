@@ -2,12 +2,14 @@ package api
 
 import (
 	"context"
+	"net/http"
 
 	"github.com/fulcrumproject/core/pkg/authz"
 	"github.com/fulcrumproject/core/pkg/domain"
 	"github.com/fulcrumproject/core/pkg/middlewares"
 	"github.com/fulcrumproject/core/pkg/properties"
 	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
 )
 
 type CreateServiceGroupReq struct {
@@ -23,21 +25,29 @@ type UpdateServiceGroupReq struct {
 	Name *string `json:"name"`
 }
 
+// ReassignServiceGroupReq represents the consumer a service group and its services are moved to
+type ReassignServiceGroupReq struct {
+	ConsumerID properties.UUID `json:"consumerId"`
+}
+
 type ServiceGroupHandler struct {
-	querier   domain.ServiceGroupQuerier
-	commander domain.ServiceGroupCommander
-	authz     authz.Authorizer
+	querier        domain.ServiceGroupQuerier
+	serviceQuerier domain.ServiceQuerier
+	commander      domain.ServiceGroupCommander
+	authz          authz.Authorizer
 }
 
 func NewServiceGroupHandler(
 	querier domain.ServiceGroupQuerier,
+	serviceQuerier domain.ServiceQuerier,
 	commander domain.ServiceGroupCommander,
 	authz authz.Authorizer,
 ) *ServiceGroupHandler {
 	return &ServiceGroupHandler{
-		commander: commander,
-		querier:   querier,
-		authz:     authz,
+		commander:      commander,
+		querier:        querier,
+		serviceQuerier: serviceQuerier,
+		authz:          authz,
 	}
 }
 
@@ -64,12 +74,23 @@ func (h *ServiceGroupHandler) Routes() func(r chi.Router) {
 				middlewares.AuthzFromID(authz.ObjectTypeServiceGroup, authz.ActionRead, h.authz, h.querier.AuthScope),
 			).Get("/{id}", Get(h.querier.Get, ServiceGroupToRes))
 
+			// Dependency graph endpoint - authorize using service group's scope
+			r.With(
+				middlewares.AuthzFromID(authz.ObjectTypeServiceGroup, authz.ActionRead, h.authz, h.querier.AuthScope),
+			).Get("/{id}/dependency-graph", h.DependencyGraph)
+
 			// Update endpoint - using standard Update handler
 			r.With(
 				middlewares.DecodeBody[UpdateServiceGroupReq](),
 				middlewares.AuthzFromID(authz.ObjectTypeServiceGroup, authz.ActionUpdate, h.authz, h.querier.AuthScope),
 			).Patch("/{id}", Update(h.Update, ServiceGroupToRes))
 
+			// Reassign endpoint - moves the group and its services to another consumer, admin only
+			r.With(
+				middlewares.DecodeBody[ReassignServiceGroupReq](),
+				middlewares.AuthzFromID(authz.ObjectTypeServiceGroup, authz.ActionReassign, h.authz, h.querier.AuthScope),
+			).Post("/{id}/reassign", Action(h.Reassign, ServiceGroupToRes))
+
 			// Delete endpoint - authorize using service group's scope
 			r.With(
 				middlewares.AuthzFromID(authz.ObjectTypeServiceGroup, authz.ActionDelete, h.authz, h.querier.AuthScope),
@@ -96,6 +117,27 @@ func (h *ServiceGroupHandler) Update(ctx context.Context, id properties.UUID, re
 	return h.commander.Update(ctx, params)
 }
 
+func (h *ServiceGroupHandler) Reassign(ctx context.Context, id properties.UUID, req *ReassignServiceGroupReq) (*domain.ServiceGroup, error) {
+	return h.commander.Reassign(ctx, id, req.ConsumerID)
+}
+
+// DependencyGraph handles the resolved dependency graph of the services of the group
+func (h *ServiceGroupHandler) DependencyGraph(w http.ResponseWriter, r *http.Request) {
+	id := middlewares.MustGetID(r.Context())
+	if _, err := h.querier.Get(r.Context(), id); err != nil {
+		render.Render(w, r, ErrDomain(err))
+		return
+	}
+
+	graph, err := domain.BuildServiceDependencyGraph(r.Context(), h.serviceQuerier, id)
+	if err != nil {
+		render.Render(w, r, ErrDomain(err))
+		return
+	}
+
+	render.JSON(w, r, ServiceDependencyGraphToRes(graph))
+}
+
 // ServiceGroupRes represents the response body for service group operations
 type ServiceGroupRes struct {
 	ID         properties.UUID `json:"id"`
@@ -122,3 +164,48 @@ func ServiceGroupToRes(sg *domain.ServiceGroup) *ServiceGroupRes {
 
 	return res
 }
+
+// ServiceDependencyGraphRes represents the resolved dependency graph of the services of a group
+type ServiceDependencyGraphRes struct {
+	GroupID  properties.UUID            `json:"groupId"`
+	Services []ServiceDependencyNodeRes `json:"services"`
+	// Order in which the services are started, the stop order is the reverse
+	Order []properties.UUID `json:"order"`
+}
+
+// ServiceDependencyNodeRes represents a service of a dependency graph
+type ServiceDependencyNodeRes struct {
+	ID        properties.UUID   `json:"id"`
+	Name      string            `json:"name"`
+	Status    string            `json:"status"`
+	DependsOn []properties.UUID `json:"dependsOn"`
+}
+
+// ServiceDependencyGraphToRes converts a domain.ServiceDependencyGraph to a ServiceDependencyGraphRes
+// The dependencies on services no longer in the group are left out
+func ServiceDependencyGraphToRes(graph *domain.ServiceDependencyGraph) *ServiceDependencyGraphRes {
+	inGraph := make(map[properties.UUID]bool, len(graph.Services))
+	for _, svc := range graph.Services {
+		inGraph[svc.ID] = true
+	}
+	res := &ServiceDependencyGraphRes{
+		GroupID:  graph.GroupID,
+		Services: make([]ServiceDependencyNodeRes, len(graph.Services)),
+		Order:    graph.Order,
+	}
+	for i, svc := range graph.Services {
+		node := ServiceDependencyNodeRes{
+			ID:        svc.ID,
+			Name:      svc.Name,
+			Status:    svc.Status,
+			DependsOn: []properties.UUID{},
+		}
+		for _, dep := range svc.DependsOn {
+			if inGraph[dep] {
+				node.DependsOn = append(node.DependsOn, dep)
+			}
+		}
+		res.Services[i] = node
+	}
+	return res
+}
@@ -1,27 +1,38 @@
 package api
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/fulcrumproject/core/pkg/auth"
 	"github.com/fulcrumproject/core/pkg/authz"
 	"github.com/fulcrumproject/core/pkg/domain"
+	"github.com/fulcrumproject/core/pkg/middlewares"
+	"github.com/fulcrumproject/core/pkg/properties"
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 // TestNewServiceGroupHandler tests the constructor
 func TestNewServiceGroupHandler(t *testing.T) {
 	querier := domain.NewMockServiceGroupQuerier(t)
+	serviceQuerier := domain.NewMockServiceQuerier(t)
 	commander := domain.NewMockServiceGroupCommander(t)
 	authz := authz.NewMockAuthorizer(t)
 
-	handler := NewServiceGroupHandler(querier, commander, authz)
+	handler := NewServiceGroupHandler(querier, serviceQuerier, commander, authz)
 	assert.NotNil(t, handler)
 	assert.Equal(t, querier, handler.querier)
+	assert.Equal(t, serviceQuerier, handler.serviceQuerier)
 	assert.Equal(t, commander, handler.commander)
 	assert.Equal(t, authz, handler.authz)
 }
@@ -34,7 +45,7 @@ func TestServiceGroupHandlerRoutes(t *testing.T) {
 	authz := authz.NewMockAuthorizer(t)
 
 	// Create the handler
-	handler := NewServiceGroupHandler(querier, commander, authz)
+	handler := NewServiceGroupHandler(querier, domain.NewMockServiceQuerier(t), commander, authz)
 
 	// Execute
 	routeFunc := handler.Routes()
@@ -51,7 +62,9 @@ func TestServiceGroupHandlerRoutes(t *testing.T) {
 		case method == "GET" && route == "/":
 		case method == "POST" && route == "/":
 		case method == "GET" && route == "/{id}":
+		case method == "GET" && route == "/{id}/dependency-graph":
 		case method == "PATCH" && route == "/{id}":
+		case method == "POST" && route == "/{id}/reassign":
 		case method == "DELETE" && route == "/{id}":
 		default:
 			return fmt.Errorf("unexpected route: %s %s", method, route)
@@ -91,3 +104,189 @@ func TestServiceGroupToResponse(t *testing.T) {
 	assert.Equal(t, JSONUTCTime(createdAt), response.CreatedAt)
 	assert.Equal(t, JSONUTCTime(updatedAt), response.UpdatedAt)
 }
+
+// TestServiceGroupHandleDependencyGraph tests the dependency graph of the services of a group
+func TestServiceGroupHandleDependencyGraph(t *testing.T) {
+	groupID := uuid.MustParse("550e8400-e29b-41d4-a716-446655440000")
+	dbID := uuid.MustParse("660e8400-e29b-41d4-a716-446655440000")
+	appID := uuid.MustParse("770e8400-e29b-41d4-a716-446655440000")
+	removedID := uuid.MustParse("880e8400-e29b-41d4-a716-446655440000")
+
+	testCases := []struct {
+		name           string
+		mockSetup      func(querier *domain.MockServiceGroupQuerier, serviceQuerier *domain.MockServiceQuerier)
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name: "Success",
+			mockSetup: func(querier *domain.MockServiceGroupQuerier, serviceQuerier *domain.MockServiceQuerier) {
+				querier.EXPECT().Get(mock.Anything, groupID).Return(&domain.ServiceGroup{BaseEntity: domain.BaseEntity{ID: groupID}}, nil)
+				serviceQuerier.EXPECT().FindByGroup(mock.Anything, groupID).Return([]*domain.Service{
+					{BaseEntity: domain.BaseEntity{ID: appID}, Name: "app", Status: "Stopped", DependsOn: []properties.UUID{dbID, removedID}},
+					{BaseEntity: domain.BaseEntity{ID: dbID}, Name: "db", Status: "Started"},
+				}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody: `{"groupId":"550e8400-e29b-41d4-a716-446655440000","services":[` +
+				`{"id":"770e8400-e29b-41d4-a716-446655440000","name":"app","status":"Stopped","dependsOn":["660e8400-e29b-41d4-a716-446655440000"]},` +
+				`{"id":"660e8400-e29b-41d4-a716-446655440000","name":"db","status":"Started","dependsOn":[]}],` +
+				`"order":["660e8400-e29b-41d4-a716-446655440000","770e8400-e29b-41d4-a716-446655440000"]}`,
+		},
+		{
+			name: "Not found",
+			mockSetup: func(querier *domain.MockServiceGroupQuerier, serviceQuerier *domain.MockServiceQuerier) {
+				querier.EXPECT().Get(mock.Anything, groupID).Return(nil, domain.NewNotFoundErrorf("service group not found"))
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			querier := domain.NewMockServiceGroupQuerier(t)
+			serviceQuerier := domain.NewMockServiceQuerier(t)
+			tc.mockSetup(querier, serviceQuerier)
+			handler := NewServiceGroupHandler(querier, serviceQuerier, domain.NewMockServiceGroupCommander(t), authz.NewMockAuthorizer(t))
+
+			req := httptest.NewRequest("GET", "/service-groups/"+groupID.String()+"/dependency-graph", nil)
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("id", groupID.String())
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+
+			w := httptest.NewRecorder()
+			middlewares.ID(http.HandlerFunc(handler.DependencyGraph)).ServeHTTP(w, req)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			if tc.expectedBody != "" {
+				assert.JSONEq(t, tc.expectedBody, w.Body.String())
+			}
+		})
+	}
+}
+
+// TestServiceGroupHandleDelete tests the delete route statuses
+func TestServiceGroupHandleDelete(t *testing.T) {
+	id := properties.NewUUID()
+
+	testCases := []struct {
+		name           string
+		mockSetup      func(querier *domain.MockServiceGroupQuerier, commander *domain.MockServiceGroupCommander)
+		expectedStatus int
+	}{
+		{
+			name: "Success",
+			mockSetup: func(querier *domain.MockServiceGroupQuerier, commander *domain.MockServiceGroupCommander) {
+				querier.EXPECT().Exists(mock.Anything, id).Return(true, nil)
+				commander.EXPECT().Delete(mock.Anything, id).Return(nil)
+			},
+			expectedStatus: http.StatusNoContent,
+		},
+		{
+			name: "Not found",
+			mockSetup: func(querier *domain.MockServiceGroupQuerier, commander *domain.MockServiceGroupCommander) {
+				querier.EXPECT().Exists(mock.Anything, id).Return(false, nil)
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name: "Group with services",
+			mockSetup: func(querier *domain.MockServiceGroupQuerier, commander *domain.MockServiceGroupCommander) {
+				querier.EXPECT().Exists(mock.Anything, id).Return(true, nil)
+				commander.EXPECT().Delete(mock.Anything, id).Return(domain.NewConflictErrorf("cannot delete service group with associated services"))
+			},
+			expectedStatus: http.StatusConflict,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			querier := domain.NewMockServiceGroupQuerier(t)
+			commander := domain.NewMockServiceGroupCommander(t)
+			tc.mockSetup(querier, commander)
+
+			req := httptest.NewRequest("DELETE", "/service-groups/"+id.String(), nil)
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("id", id.String())
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+
+			w := httptest.NewRecorder()
+			middlewares.ID(Delete(querier, commander.Delete)).ServeHTTP(w, req)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+		})
+	}
+}
+
+// TestServiceGroupHandleReassign tests that only the admins reassign a group to another consumer
+func TestServiceGroupHandleReassign(t *testing.T) {
+	id := properties.NewUUID()
+	consumerID := properties.NewUUID()
+	newConsumerID := properties.NewUUID()
+
+	testCases := []struct {
+		name           string
+		identity       *auth.Identity
+		mockSetup      func(querier *domain.MockServiceGroupQuerier, commander *domain.MockServiceGroupCommander)
+		expectedStatus int
+	}{
+		{
+			name:     "Admin",
+			identity: newMockAuthAdmin(),
+			mockSetup: func(querier *domain.MockServiceGroupQuerier, commander *domain.MockServiceGroupCommander) {
+				querier.EXPECT().AuthScope(mock.Anything, id).Return(&authz.DefaultObjectScope{ConsumerID: &consumerID}, nil)
+				commander.EXPECT().Reassign(mock.Anything, id, newConsumerID).Return(&domain.ServiceGroup{
+					BaseEntity: domain.BaseEntity{ID: id},
+					Name:       "web",
+					ConsumerID: newConsumerID,
+				}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:     "Consumer of the group",
+			identity: newMockAuthParticipant(consumerID),
+			mockSetup: func(querier *domain.MockServiceGroupQuerier, commander *domain.MockServiceGroupCommander) {
+				querier.EXPECT().AuthScope(mock.Anything, id).Return(&authz.DefaultObjectScope{ConsumerID: &consumerID}, nil).Maybe()
+			},
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:     "Invalid consumer",
+			identity: newMockAuthAdmin(),
+			mockSetup: func(querier *domain.MockServiceGroupQuerier, commander *domain.MockServiceGroupCommander) {
+				querier.EXPECT().AuthScope(mock.Anything, id).Return(&authz.DefaultObjectScope{ConsumerID: &consumerID}, nil)
+				commander.EXPECT().Reassign(mock.Anything, id, newConsumerID).
+					Return(nil, domain.NewInvalidInputErrorf("consumer with ID %s does not exist", newConsumerID))
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			querier := domain.NewMockServiceGroupQuerier(t)
+			commander := domain.NewMockServiceGroupCommander(t)
+			tc.mockSetup(querier, commander)
+			handler := NewServiceGroupHandler(querier, domain.NewMockServiceQuerier(t), commander, authz.NewRuleBasedAuthorizer(authz.Rules))
+			r := chi.NewRouter()
+			r.Route("/service-groups", handler.Routes())
+
+			body := fmt.Sprintf(`{"consumerId":"%s"}`, newConsumerID)
+			req := httptest.NewRequest("POST", "/service-groups/"+id.String()+"/reassign", strings.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+			req = req.WithContext(auth.WithIdentity(req.Context(), tc.identity))
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			require.Equal(t, tc.expectedStatus, w.Code, w.Body.String())
+			if tc.expectedStatus != http.StatusOK {
+				return
+			}
+			var res ServiceGroupRes
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+			assert.Equal(t, newConsumerID, res.ConsumerID)
+		})
+	}
+}
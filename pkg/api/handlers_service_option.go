@@ -17,6 +17,7 @@ type CreateServiceOptionReq struct {
 	Value               any             `json:"value"`
 	Enabled             *bool           `json:"enabled"`
 	DisplayOrder        int             `json:"displayOrder"`
+	Cost                float64         `json:"cost"`
 }
 
 func (r CreateServiceOptionReq) ObjectScope() (authz.ObjectScope, error) {
@@ -26,10 +27,11 @@ func (r CreateServiceOptionReq) ObjectScope() (authz.ObjectScope, error) {
 }
 
 type UpdateServiceOptionReq struct {
-	Name         *string `json:"name"`
-	Value        *any    `json:"value"`
-	Enabled      *bool   `json:"enabled"`
-	DisplayOrder *int    `json:"displayOrder"`
+	Name         *string  `json:"name"`
+	Value        *any     `json:"value"`
+	Enabled      *bool    `json:"enabled"`
+	DisplayOrder *int     `json:"displayOrder"`
+	Cost         *float64 `json:"cost"`
 }
 
 type ServiceOptionHandler struct {
@@ -97,6 +99,7 @@ func (h *ServiceOptionHandler) Create(ctx context.Context, req *CreateServiceOpt
 		Value:               req.Value,
 		Enabled:             req.Enabled,
 		DisplayOrder:        req.DisplayOrder,
+		Cost:                req.Cost,
 	}
 	return h.commander.Create(ctx, params)
 }
@@ -108,6 +111,7 @@ func (h *ServiceOptionHandler) Update(ctx context.Context, id properties.UUID, r
 		Value:        req.Value,
 		Enabled:      req.Enabled,
 		DisplayOrder: req.DisplayOrder,
+		Cost:         req.Cost,
 	}
 	return h.commander.Update(ctx, params)
 }
@@ -121,6 +125,7 @@ type ServiceOptionRes struct {
 	Value               any             `json:"value"`
 	Enabled             bool            `json:"enabled"`
 	DisplayOrder        int             `json:"displayOrder"`
+	Cost                float64         `json:"cost"`
 	CreatedAt           JSONUTCTime     `json:"createdAt"`
 	UpdatedAt           JSONUTCTime     `json:"updatedAt"`
 }
@@ -135,6 +140,7 @@ func ServiceOptionToRes(so *domain.ServiceOption) *ServiceOptionRes {
 		Value:               so.Value,
 		Enabled:             so.Enabled != nil && *so.Enabled,
 		DisplayOrder:        so.DisplayOrder,
+		Cost:                so.Cost,
 		CreatedAt:           JSONUTCTime(so.CreatedAt),
 		UpdatedAt:           JSONUTCTime(so.UpdatedAt),
 	}
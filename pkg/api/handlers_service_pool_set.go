@@ -11,12 +11,14 @@ import (
 )
 
 type CreateServicePoolSetReq struct {
-	Name       string          `json:"name"`
-	ProviderID properties.UUID `json:"providerId"`
+	Name                string          `json:"name"`
+	ProviderID          properties.UUID `json:"providerId"`
+	LowWatermarkPercent int             `json:"lowWatermarkPercent"`
 }
 
 type UpdateServicePoolSetReq struct {
-	Name *string `json:"name"`
+	Name                *string `json:"name"`
+	LowWatermarkPercent *int    `json:"lowWatermarkPercent"`
 }
 
 type ServicePoolSetHandler struct {
@@ -78,37 +80,41 @@ func (h *ServicePoolSetHandler) Routes() func(r chi.Router) {
 
 func (h *ServicePoolSetHandler) Create(ctx context.Context, req *CreateServicePoolSetReq) (*domain.ServicePoolSet, error) {
 	params := domain.CreateServicePoolSetParams{
-		Name:       req.Name,
-		ProviderID: req.ProviderID,
+		Name:                req.Name,
+		ProviderID:          req.ProviderID,
+		LowWatermarkPercent: req.LowWatermarkPercent,
 	}
 	return h.commander.Create(ctx, params)
 }
 
 func (h *ServicePoolSetHandler) Update(ctx context.Context, id properties.UUID, req *UpdateServicePoolSetReq) (*domain.ServicePoolSet, error) {
 	params := domain.UpdateServicePoolSetParams{
-		Name: req.Name,
+		Name:                req.Name,
+		LowWatermarkPercent: req.LowWatermarkPercent,
 	}
 	return h.commander.Update(ctx, id, params)
 }
 
 // ServicePoolSetRes represents the response body for service pool set operations
 type ServicePoolSetRes struct {
-	ID         properties.UUID `json:"id"`
-	Name       string          `json:"name"`
-	ProviderID properties.UUID `json:"providerID"`
-	Provider	 *ParticipantRes `json:"provider,omitempty"`
-	CreatedAt  JSONUTCTime     `json:"createdAt"`
-	UpdatedAt  JSONUTCTime     `json:"updatedAt"`
+	ID                  properties.UUID `json:"id"`
+	Name                string          `json:"name"`
+	ProviderID          properties.UUID `json:"providerID"`
+	Provider            *ParticipantRes `json:"provider,omitempty"`
+	LowWatermarkPercent int             `json:"lowWatermarkPercent"`
+	CreatedAt           JSONUTCTime     `json:"createdAt"`
+	UpdatedAt           JSONUTCTime     `json:"updatedAt"`
 }
 
 // ServicePoolSetToRes converts a domain.ServicePoolSet to a ServicePoolSetRes
 func ServicePoolSetToRes(ps *domain.ServicePoolSet) *ServicePoolSetRes {
 	response := &ServicePoolSetRes{
-		ID:         ps.ID,
-		Name:       ps.Name,
-		ProviderID: ps.ProviderID,
-		CreatedAt:  JSONUTCTime(ps.CreatedAt),
-		UpdatedAt:  JSONUTCTime(ps.UpdatedAt),
+		ID:                  ps.ID,
+		Name:                ps.Name,
+		ProviderID:          ps.ProviderID,
+		LowWatermarkPercent: ps.LowWatermarkPercent,
+		CreatedAt:           JSONUTCTime(ps.CreatedAt),
+		UpdatedAt:           JSONUTCTime(ps.UpdatedAt),
 	}
 
 	if ps.Provider != nil {
@@ -90,6 +90,7 @@ func TestServicePoolSetToRes(t *testing.T) {
 		Name:       "Production Pools",
 		ProviderID: properties.UUID(providerID),
 		Provider: provider,
+		LowWatermarkPercent: 15,
 	}
 
 	// Convert to response
@@ -101,6 +102,7 @@ func TestServicePoolSetToRes(t *testing.T) {
 	assert.Equal(t, properties.UUID(providerID), res.ProviderID)
 	assert.Equal(t, properties.UUID(providerID), res.Provider.ID)
 	assert.Equal(t, "Test participant", res.Provider.Name)
+	assert.Equal(t, 15, res.LowWatermarkPercent)
 	assert.Equal(t, JSONUTCTime(createdAt), res.CreatedAt)
 	assert.Equal(t, JSONUTCTime(updatedAt), res.UpdatedAt)
 }
@@ -0,0 +1,85 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/fulcrumproject/core/pkg/domain"
+	"github.com/fulcrumproject/core/pkg/properties"
+	"github.com/go-chi/render"
+)
+
+// paramStateMachineServiceType is the query parameter of the service type whose state machine is returned
+const paramStateMachineServiceType = "serviceTypeId"
+
+// ServiceStateMachineRes is the transition graph of the services of a service type
+type ServiceStateMachineRes struct {
+	ServiceTypeID properties.UUID            `json:"serviceTypeId"`
+	States        []ServiceStateMachineState `json:"states"`
+}
+
+// ServiceStateMachineState is a state of the services with the actions allowed from it
+type ServiceStateMachineState struct {
+	Name        string                          `json:"name"`
+	Initial     bool                            `json:"initial"`
+	Terminal    bool                            `json:"terminal"`
+	Running     bool                            `json:"running"`
+	Transitions []ServiceStateMachineTransition `json:"transitions"`
+}
+
+// ServiceStateMachineTransition is an action allowed from a state and the states it leads to
+type ServiceStateMachineTransition struct {
+	Action            string   `json:"action"`
+	To                string   `json:"to,omitempty"`
+	ErrorTo           []string `json:"errorTo,omitempty"`
+	Update            bool     `json:"update"`
+	RequestSchemaType string   `json:"requestSchemaType,omitempty"`
+}
+
+// ServiceStateMachineToRes converts the lifecycle graph of a service type to its response
+func ServiceStateMachineToRes(serviceTypeID properties.UUID, nodes []domain.LifecycleStateNode) *ServiceStateMachineRes {
+	res := &ServiceStateMachineRes{ServiceTypeID: serviceTypeID, States: make([]ServiceStateMachineState, len(nodes))}
+	for i, node := range nodes {
+		state := ServiceStateMachineState{
+			Name:        node.Name,
+			Initial:     node.Initial,
+			Terminal:    node.Terminal,
+			Running:     node.Running,
+			Transitions: make([]ServiceStateMachineTransition, len(node.Transitions)),
+		}
+		for j, edge := range node.Transitions {
+			state.Transitions[j] = ServiceStateMachineTransition{
+				Action:            edge.Action,
+				To:                edge.To,
+				ErrorTo:           edge.ErrorTo,
+				Update:            edge.Update,
+				RequestSchemaType: edge.RequestSchemaType,
+			}
+		}
+		res.States[i] = state
+	}
+	return res
+}
+
+// StateMachine handles the transition graph of the services of a service type, the actions allowed from
+// each state and the states they lead to, so clients only offer the actions the services accept
+func (h *ServiceHandler) StateMachine(w http.ResponseWriter, r *http.Request) {
+	value := r.URL.Query().Get(paramStateMachineServiceType)
+	if value == "" {
+		render.Render(w, r, ErrInvalidRequest(fmt.Errorf("%s parameter is required", paramStateMachineServiceType)))
+		return
+	}
+	serviceTypeID, err := properties.ParseUUID(value)
+	if err != nil {
+		render.Render(w, r, ErrInvalidRequest(fmt.Errorf("invalid %s parameter: %w", paramStateMachineServiceType, err)))
+		return
+	}
+
+	nodes, err := h.commander.StateMachine(r.Context(), serviceTypeID)
+	if err != nil {
+		render.Render(w, r, ErrDomain(err))
+		return
+	}
+
+	render.JSON(w, r, ServiceStateMachineToRes(serviceTypeID, nodes))
+}
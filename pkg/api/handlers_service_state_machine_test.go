@@ -0,0 +1,97 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/fulcrumproject/core/pkg/auth"
+	"github.com/fulcrumproject/core/pkg/authz"
+	"github.com/fulcrumproject/core/pkg/domain"
+	"github.com/fulcrumproject/core/pkg/properties"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServiceHandleStateMachine(t *testing.T) {
+	serviceTypeID := properties.NewUUID()
+	lifecycle := domain.LifecycleSchema{
+		States:       []domain.LifecycleState{{Name: "Started"}, {Name: "ColdUpdating"}, {Name: "Failed"}},
+		InitialState: "Started",
+		Actions: []domain.LifecycleAction{
+			{Name: domain.ServiceActionColdUpdate, Transitions: []domain.LifecycleTransition{
+				{From: "Started", To: "ColdUpdating"},
+				{From: "Started", To: "Failed", OnError: true},
+			}},
+			{Name: "settle", Transitions: []domain.LifecycleTransition{{From: "ColdUpdating", To: "Started"}}},
+		},
+	}
+
+	testCases := []struct {
+		name           string
+		query          string
+		mockSetup      func(commander *domain.MockServiceCommander)
+		expectedStatus int
+		checkResponse  func(t *testing.T, res ServiceStateMachineRes)
+	}{
+		{
+			name:  "Success",
+			query: "?serviceTypeId=" + serviceTypeID.String(),
+			mockSetup: func(commander *domain.MockServiceCommander) {
+				commander.EXPECT().StateMachine(mock.Anything, serviceTypeID).Return(lifecycle.StateMachine(), nil)
+			},
+			expectedStatus: http.StatusOK,
+			checkResponse: func(t *testing.T, res ServiceStateMachineRes) {
+				assert.Equal(t, serviceTypeID, res.ServiceTypeID)
+				require.Len(t, res.States, 3)
+				assert.True(t, res.States[0].Initial)
+				assert.Equal(t, []ServiceStateMachineTransition{
+					{Action: domain.ServiceActionColdUpdate, To: "ColdUpdating", ErrorTo: []string{"Failed"}, Update: true},
+				}, res.States[0].Transitions)
+				assert.Equal(t, "settle", res.States[1].Transitions[0].Action)
+				assert.Empty(t, res.States[2].Transitions)
+			},
+		},
+		{
+			name:           "MissingServiceType",
+			mockSetup:      func(commander *domain.MockServiceCommander) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "InvalidServiceType",
+			query:          "?serviceTypeId=abc",
+			mockSetup:      func(commander *domain.MockServiceCommander) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:  "ServiceTypeNotFound",
+			query: "?serviceTypeId=" + serviceTypeID.String(),
+			mockSetup: func(commander *domain.MockServiceCommander) {
+				commander.EXPECT().StateMachine(mock.Anything, serviceTypeID).Return(nil, domain.NewNotFoundErrorf("service type not found"))
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			commander := domain.NewMockServiceCommander(t)
+			tc.mockSetup(commander)
+			handler := NewServiceHandler(domain.NewMockServiceQuerier(t), domain.NewMockAgentQuerier(t), domain.NewMockServiceGroupQuerier(t), nil, nil, commander, authz.NewMockAuthorizer(t))
+
+			req := httptest.NewRequest("GET", "/services/state-machine"+tc.query, nil)
+			req = req.WithContext(auth.WithIdentity(req.Context(), newMockAuthAdmin()))
+			w := httptest.NewRecorder()
+			handler.StateMachine(w, req)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			if tc.checkResponse != nil {
+				var res ServiceStateMachineRes
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+				tc.checkResponse(t, res)
+			}
+		})
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
@@ -16,11 +17,13 @@ import (
 	"github.com/fulcrumproject/core/pkg/helpers"
 	"github.com/fulcrumproject/core/pkg/middlewares"
 	"github.com/fulcrumproject/core/pkg/properties"
+	"github.com/fulcrumproject/core/pkg/schema"
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
+	"github.com/wI2L/jsondiff"
 )
 
 // TestNewServiceHandler tests the constructor
@@ -28,14 +31,18 @@ func TestNewServiceHandler(t *testing.T) {
 	serviceQuerier := domain.NewMockServiceQuerier(t)
 	agentQuerier := domain.NewMockAgentQuerier(t)
 	serviceGroupQuerier := domain.NewMockServiceGroupQuerier(t)
+	jobQuerier := domain.NewMockJobQuerier(t)
+	eventQuerier := domain.NewMockEventQuerier(t)
 	commander := domain.NewMockServiceCommander(t)
 	authz := authz.NewMockAuthorizer(t)
 
-	handler := NewServiceHandler(serviceQuerier, agentQuerier, serviceGroupQuerier, commander, authz)
+	handler := NewServiceHandler(serviceQuerier, agentQuerier, serviceGroupQuerier, jobQuerier, eventQuerier, commander, authz)
 	assert.NotNil(t, handler)
 	assert.Equal(t, serviceQuerier, handler.querier)
 	assert.Equal(t, agentQuerier, handler.agentQuerier)
 	assert.Equal(t, serviceGroupQuerier, handler.serviceGroupQuerier)
+	assert.Equal(t, jobQuerier, handler.jobQuerier)
+	assert.Equal(t, eventQuerier, handler.eventQuerier)
 	assert.Equal(t, commander, handler.commander)
 	assert.Equal(t, authz, handler.authz)
 }
@@ -50,7 +57,7 @@ func TestServiceHandlerRoutes(t *testing.T) {
 	authz := authz.NewMockAuthorizer(t)
 
 	// Create the handler
-	handler := NewServiceHandler(serviceQuerier, agentQuerier, serviceGroupQuerier, commander, authz)
+	handler := NewServiceHandler(serviceQuerier, agentQuerier, serviceGroupQuerier, nil, nil, commander, authz)
 
 	// Execute
 	routeFunc := handler.Routes()
@@ -67,24 +74,61 @@ func TestServiceHandlerRoutes(t *testing.T) {
 		case method == "GET" && route == "/":
 			// Check for authorization middleware
 			assert.GreaterOrEqual(t, len(middlewares), 1, "List route should have at least authorization middleware")
+		case method == "GET" && route == "/export.csv":
+			// Check for authorization middleware
+			assert.GreaterOrEqual(t, len(middlewares), 1, "Export route should have authorization middleware")
+		case method == "GET" && route == "/state-machine":
+			// Check for authorization middleware
+			assert.GreaterOrEqual(t, len(middlewares), 1, "State machine route should have authorization middleware")
 		case method == "POST" && route == "/":
 			// Check for decode body and authorization middlewares
 			assert.GreaterOrEqual(t, len(middlewares), 1, "Create route should have body decoder and specialized extractor middlewares")
+		case method == "POST" && route == "/validate":
+		case method == "POST" && route == "/estimate":
+			// Check for decode body and authorization middlewares
+			assert.GreaterOrEqual(t, len(middlewares), 2, "Validate route should have body decoder and specialized extractor middlewares")
+		case method == "POST" && route == "/batch/transition":
+			// Check for authorization and decode body middlewares
+			assert.GreaterOrEqual(t, len(middlewares), 2, "Batch route should have authorization and body decoder middlewares")
 		case method == "GET" && route == "/{id}":
 			// Check for authorization middleware
 			assert.GreaterOrEqual(t, len(middlewares), 1, "Get route should have authorization middleware")
+		case method == "GET" && route == "/{id}/history":
+			// Check for authorization middleware
+			assert.GreaterOrEqual(t, len(middlewares), 1, "History route should have authorization middleware")
 		case method == "PATCH" && route == "/{id}":
 			// Check for decode body and authorization middlewares
 			assert.GreaterOrEqual(t, len(middlewares), 2, "Update route should have body decoder and authorization middlewares")
+		case method == "PATCH" && route == "/{id}/labels":
+		case method == "PUT" && route == "/{id}/dependencies":
+		case method == "POST" && route == "/{id}/maintenance":
+			// Check for decode body and authorization middlewares
+			assert.GreaterOrEqual(t, len(middlewares), 2, "Labels route should have body decoder and authorization middlewares")
+		case method == "PATCH" && route == "/{id}/reconcile":
+			// Check for decode body and authorization middlewares
+			assert.GreaterOrEqual(t, len(middlewares), 2, "Reconcile route should have body decoder and authorization middlewares")
+		case method == "POST" && route == "/{id}/orphaned":
+			// Check for decode body and authorization middlewares
+			assert.GreaterOrEqual(t, len(middlewares), 2, "Orphaned route should have body decoder and authorization middlewares")
 		case method == "DELETE" && route == "/{id}":
 			// Check for authorization middleware
 			assert.GreaterOrEqual(t, len(middlewares), 1, "Delete route should have authorization middleware")
+		case method == "POST" && route == "/{id}/restore":
+			// Check for authorization middleware
+			assert.GreaterOrEqual(t, len(middlewares), 1, "Restore route should have authorization middleware")
+		case method == "POST" && route == "/{id}/cancel":
+			// Check for authorization middleware
+			assert.GreaterOrEqual(t, len(middlewares), 1, "Cancel route should have authorization middleware")
+		case method == "POST" && route == "/{id}/clone":
+		case method == "POST" && route == "/{id}/preview":
+			// Check for decode body and authorization middlewares
+			assert.GreaterOrEqual(t, len(middlewares), 2, "Clone route should have body decoder and authorization middlewares")
 		case method == "POST" && route == "/{id}/retry":
 			// Check for authorization middleware
 			assert.GreaterOrEqual(t, len(middlewares), 1, "Retry route should have authorization middleware")
 		case method == "POST" && route == "/{id}/{action}":
-			// Generic action route - check for action name middleware and authorization
-			assert.GreaterOrEqual(t, len(middlewares), 2, "Generic action route should have action name middleware and authorization middleware")
+			// Generic action route - check for action name, body decoder and authorization middlewares
+			assert.GreaterOrEqual(t, len(middlewares), 3, "Generic action route should have action name, body decoder and authorization middlewares")
 		default:
 			return fmt.Errorf("unexpected route: %s %s", method, route)
 		}
@@ -112,6 +156,7 @@ func TestServiceHandleCreate(t *testing.T) {
 				GroupID:       uuid.MustParse("660e8400-e29b-41d4-a716-446655440000"),
 				ServiceTypeID: uuid.MustParse("770e8400-e29b-41d4-a716-446655440000"),
 				Properties:    properties.JSON{"prop": "value"},
+				TargetState:   "Started",
 			},
 			mockSetup: func(commander *domain.MockServiceCommander) {
 				// Setup the commander for successful creation
@@ -123,7 +168,8 @@ func TestServiceHandleCreate(t *testing.T) {
 				commander.EXPECT().
 					Create(mock.Anything, mock.MatchedBy(func(params domain.CreateServiceParams) bool {
 						return params.Name == "Test Service" &&
-							params.Properties["prop"] == "value"
+							params.Properties["prop"] == "value" &&
+							params.TargetState == "Started"
 					})).
 					Return(&domain.Service{
 						BaseEntity: domain.BaseEntity{
@@ -160,6 +206,40 @@ func TestServiceHandleCreate(t *testing.T) {
 			},
 			expectedStatus: http.StatusBadRequest,
 		},
+		{
+			name: "PoolExhausted",
+			request: CreateServiceReq{
+				Name:          "Test Service",
+				AgentID:       &[]properties.UUID{uuid.MustParse("550e8400-e29b-41d4-a716-446655440000")}[0],
+				GroupID:       uuid.MustParse("660e8400-e29b-41d4-a716-446655440000"),
+				ServiceTypeID: uuid.MustParse("770e8400-e29b-41d4-a716-446655440000"),
+				Properties:    properties.JSON{"prop": "value"},
+			},
+			mockSetup: func(commander *domain.MockServiceCommander) {
+				commander.EXPECT().
+					Create(mock.Anything, mock.Anything).
+					Return(nil, fmt.Errorf("ipAddress: failed to allocate from pool: %w",
+						domain.NewPoolExhaustedError(uuid.MustParse("bb0e8400-e29b-41d4-a716-446655440000"))))
+			},
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name: "SecretBackendUnavailable",
+			request: CreateServiceReq{
+				Name:          "Test Service",
+				AgentID:       &[]properties.UUID{uuid.MustParse("550e8400-e29b-41d4-a716-446655440000")}[0],
+				GroupID:       uuid.MustParse("660e8400-e29b-41d4-a716-446655440000"),
+				ServiceTypeID: uuid.MustParse("770e8400-e29b-41d4-a716-446655440000"),
+				Properties:    properties.JSON{"prop": "value"},
+			},
+			mockSetup: func(commander *domain.MockServiceCommander) {
+				commander.EXPECT().
+					Create(mock.Anything, mock.Anything).
+					Return(nil, fmt.Errorf("password: failed to store secret: %w",
+						domain.NewSecretBackendUnavailableErrorf("connection refused")))
+			},
+			expectedStatus: http.StatusServiceUnavailable,
+		},
 	}
 
 	for _, tc := range testCases {
@@ -173,7 +253,7 @@ func TestServiceHandleCreate(t *testing.T) {
 			tc.mockSetup(commander)
 
 			// Create the handler
-			handler := NewServiceHandler(serviceQuerier, agentQuerier, serviceGroupQuerier, commander, authz)
+			handler := NewServiceHandler(serviceQuerier, agentQuerier, serviceGroupQuerier, nil, nil, commander, authz)
 
 			// Create request with body
 			bodyBytes, err := json.Marshal(tc.request)
@@ -208,223 +288,545 @@ func TestServiceHandleCreate(t *testing.T) {
 	}
 }
 
-// TestServiceHandleUpdate tests the handleUpdate method
-func TestServiceHandleUpdate(t *testing.T) {
-	// Setup test cases
+func TestServiceHandleCreateTimeouts(t *testing.T) {
+	agentID := uuid.MustParse("550e8400-e29b-41d4-a716-446655440000")
+	groupID := uuid.MustParse("660e8400-e29b-41d4-a716-446655440000")
+	serviceTypeID := uuid.MustParse("770e8400-e29b-41d4-a716-446655440000")
+
+	newRequest := func(body string) *http.Request {
+		req := httptest.NewRequest("POST", "/services", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		return req.WithContext(auth.WithIdentity(req.Context(), newMockAuthAdmin()))
+	}
+	newHandler := func(commander *domain.MockServiceCommander) http.Handler {
+		handler := NewServiceHandler(domain.NewMockServiceQuerier(t), domain.NewMockAgentQuerier(t), domain.NewMockServiceGroupQuerier(t), nil, nil, commander, authz.NewMockAuthorizer(t))
+		return middlewares.DecodeBody[CreateServiceReq]()(http.HandlerFunc(handler.Create))
+	}
+
+	t.Run("Override", func(t *testing.T) {
+		commander := domain.NewMockServiceCommander(t)
+		commander.EXPECT().
+			Create(mock.Anything, mock.MatchedBy(func(params domain.CreateServiceParams) bool {
+				return params.OperationTimeout != nil && *params.OperationTimeout == 90*time.Minute &&
+					params.IdleTimeout != nil && *params.IdleTimeout == 8*time.Hour
+			})).
+			Return(&domain.Service{
+				BaseEntity:       domain.BaseEntity{ID: uuid.MustParse("aa0e8400-e29b-41d4-a716-446655440000")},
+				Name:             "Test Service",
+				Status:           "New",
+				AgentID:          agentID,
+				GroupID:          groupID,
+				ServiceTypeID:    serviceTypeID,
+				OperationTimeout: helpers.DurationPtr(90 * time.Minute),
+				IdleTimeout:      helpers.DurationPtr(8 * time.Hour),
+			}, nil)
+
+		body := fmt.Sprintf(`{"name":"Test Service","agentId":"%s","groupId":"%s","serviceTypeId":"%s","operationTimeout":"1h30m","idleTimeout":"8h"}`, agentID, groupID, serviceTypeID)
+		w := httptest.NewRecorder()
+		newHandler(commander).ServeHTTP(w, newRequest(body))
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+		var response map[string]any
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, "1h30m0s", response["operationTimeout"])
+		assert.Equal(t, "8h0m0s", response["idleTimeout"])
+	})
+
+	t.Run("InvalidDuration", func(t *testing.T) {
+		body := fmt.Sprintf(`{"name":"Test Service","agentId":"%s","groupId":"%s","serviceTypeId":"%s","operationTimeout":"soon"}`, agentID, groupID, serviceTypeID)
+		w := httptest.NewRecorder()
+		newHandler(domain.NewMockServiceCommander(t)).ServeHTTP(w, newRequest(body))
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+// TestServiceHandleCreateUpdateErrorBody tests that all the 400 responses of create and update have the same shape
+func TestServiceHandleCreateUpdateErrorBody(t *testing.T) {
+	id := uuid.MustParse("550e8400-e29b-41d4-a716-446655440000")
+	details := []schema.ValidationErrorDetail{
+		{Path: "cpu", Message: "cpu: value must be one of: [1, 2, 4]"},
+		{Path: "disk.size", Message: "disk.size: property is required"},
+	}
+	wrapped := domain.InvalidInputError{Err: fmt.Errorf("properties: %w", schema.NewValidationError(details))}
+
 	testCases := []struct {
 		name           string
-		id             string
-		request        UpdateServiceReq
+		update         bool
+		body           string
+		err            error
+		expectedStatus string
+		expectedErrors []any
+	}{
+		{
+			name:           "Create with wrapped validation error",
+			body:           `{"name":"web","agentId":"550e8400-e29b-41d4-a716-446655440000","groupId":"660e8400-e29b-41d4-a716-446655440000","serviceTypeId":"770e8400-e29b-41d4-a716-446655440000"}`,
+			err:            wrapped,
+			expectedStatus: "Validation failed",
+			expectedErrors: []any{
+				map[string]any{"path": "cpu", "message": "cpu: value must be one of: [1, 2, 4]"},
+				map[string]any{"path": "disk.size", "message": "disk.size: property is required"},
+			},
+		},
+		{
+			name:           "Create with other invalid input",
+			body:           `{"name":"web","agentId":"550e8400-e29b-41d4-a716-446655440000","groupId":"660e8400-e29b-41d4-a716-446655440000","serviceTypeId":"770e8400-e29b-41d4-a716-446655440000"}`,
+			err:            domain.NewInvalidInputErrorf("agent with ID %s does not exist", id),
+			expectedStatus: "Invalid request",
+			expectedErrors: []any{},
+		},
+		{
+			name:           "Create with malformed body",
+			body:           `{"name":`,
+			expectedStatus: "Invalid request",
+			expectedErrors: []any{},
+		},
+		{
+			name:           "Update with wrapped validation error",
+			update:         true,
+			body:           `{"properties":{"cpu":3}}`,
+			err:            wrapped,
+			expectedStatus: "Validation failed",
+			expectedErrors: []any{
+				map[string]any{"path": "cpu", "message": "cpu: value must be one of: [1, 2, 4]"},
+				map[string]any{"path": "disk.size", "message": "disk.size: property is required"},
+			},
+		},
+		{
+			name:           "Update with other invalid input",
+			update:         true,
+			body:           `{"properties":{"cpu":3}}`,
+			err:            domain.NewInvalidInputErrorf("cannot update service %s while there is an active job", id),
+			expectedStatus: "Invalid request",
+			expectedErrors: []any{},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			commander := domain.NewMockServiceCommander(t)
+			handler := NewServiceHandler(domain.NewMockServiceQuerier(t), nil, nil, nil, nil, commander, nil)
+
+			var h http.Handler
+			var req *http.Request
+			if tc.update {
+				if tc.err != nil {
+					commander.EXPECT().Update(mock.Anything, mock.Anything).Return(nil, tc.err)
+				}
+				req = httptest.NewRequest("PATCH", "/services/"+id.String(), bytes.NewReader([]byte(tc.body)))
+				rctx := chi.NewRouteContext()
+				rctx.URLParams.Add("id", id.String())
+				req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+				h = middlewares.DecodeBody[UpdateServiceReq]()(middlewares.ID(Update(handler.Update, ServiceToRes)))
+			} else {
+				if tc.err != nil {
+					commander.EXPECT().Create(mock.Anything, mock.Anything).Return(nil, tc.err)
+				}
+				req = httptest.NewRequest("POST", "/services", bytes.NewReader([]byte(tc.body)))
+				h = middlewares.DecodeBody[CreateServiceReq]()(http.HandlerFunc(handler.Create))
+			}
+			req.Header.Set("Content-Type", "application/json")
+			req = req.WithContext(auth.WithIdentity(req.Context(), newMockAuthAdmin()))
+
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+			var response map[string]any
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			assert.Equal(t, tc.expectedStatus, response["status"])
+			assert.NotEmpty(t, response["error"])
+			assert.Equal(t, false, response["valid"])
+			assert.Equal(t, tc.expectedErrors, response["errors"])
+		})
+	}
+}
+
+// TestServiceHandleValidateCreate tests the ValidateCreate method
+func TestServiceHandleValidateCreate(t *testing.T) {
+	testCases := []struct {
+		name           string
+		request        CreateServiceReq
 		mockSetup      func(commander *domain.MockServiceCommander)
 		expectedStatus int
+		checkResponse  func(t *testing.T, response map[string]any)
 	}{
 		{
-			name: "Success",
-			id:   "550e8400-e29b-41d4-a716-446655440000",
-			request: UpdateServiceReq{
-				Name:       helpers.StringPtr("Updated Service"),
-				Properties: helpers.JSONPtr(properties.JSON{"updated": "value"}),
+			name: "Valid",
+			request: CreateServiceReq{
+				Name:          "Test Service",
+				AgentID:       &[]properties.UUID{uuid.MustParse("550e8400-e29b-41d4-a716-446655440000")}[0],
+				GroupID:       uuid.MustParse("660e8400-e29b-41d4-a716-446655440000"),
+				ServiceTypeID: uuid.MustParse("770e8400-e29b-41d4-a716-446655440000"),
+				Properties:    properties.JSON{"prop": "value"},
 			},
 			mockSetup: func(commander *domain.MockServiceCommander) {
-				// Setup the commander for successful update
-				createdAt := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
-				updatedAt := time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC)
-				updatedName := "Updated Service"
-				updatedProps := properties.JSON{"updated": "value"}
-
 				commander.EXPECT().
-					Update(mock.Anything, mock.MatchedBy(func(params domain.UpdateServiceParams) bool {
-						return params.ID == uuid.MustParse("550e8400-e29b-41d4-a716-446655440000") &&
-							*params.Name == "Updated Service" &&
-							(*params.Properties)["updated"] == "value"
+					ValidateCreate(mock.Anything, mock.MatchedBy(func(params domain.CreateServiceWithTagsParams) bool {
+						return params.Name == "Test Service" &&
+							params.AgentID == uuid.MustParse("550e8400-e29b-41d4-a716-446655440000")
 					})).
-					Return(&domain.Service{
-						BaseEntity: domain.BaseEntity{
-							ID:        uuid.MustParse("550e8400-e29b-41d4-a716-446655440000"),
-							CreatedAt: createdAt,
-							UpdatedAt: updatedAt,
-						},
-						Name:       updatedName,
-						Status:     "Started",
-						Properties: &updatedProps,
+					Return(&domain.ValidateCreateServiceResult{
+						Valid:      true,
+						Properties: properties.JSON{"prop": "value", "size": float64(10)},
 					}, nil)
 			},
 			expectedStatus: http.StatusOK,
+			checkResponse: func(t *testing.T, response map[string]any) {
+				assert.Equal(t, true, response["valid"])
+				assert.Equal(t, map[string]any{"prop": "value", "size": float64(10)}, response["properties"])
+				assert.Nil(t, response["errors"])
+			},
 		},
 		{
-			name: "ValidationError",
-			id:   "550e8400-e29b-41d4-a716-446655440000",
-			request: UpdateServiceReq{
-				Name:       helpers.StringPtr("Invalid"),
-				Properties: helpers.JSONPtr(properties.JSON{"invalid": "data"}),
+			name: "Invalid",
+			request: CreateServiceReq{
+				Name:          "Test Service",
+				GroupID:       uuid.MustParse("660e8400-e29b-41d4-a716-446655440000"),
+				ServiceTypeID: uuid.MustParse("770e8400-e29b-41d4-a716-446655440000"),
+				AgentTags:     []string{"eu"},
+				Properties:    properties.JSON{"prop": 1},
 			},
 			mockSetup: func(commander *domain.MockServiceCommander) {
-				// Setup the commander to return a validation error
 				commander.EXPECT().
-					Update(mock.Anything, mock.Anything).
-					Return(nil, domain.NewInvalidInputErrorf("validation error"))
+					ValidateCreate(mock.Anything, mock.MatchedBy(func(params domain.CreateServiceWithTagsParams) bool {
+						return params.AgentID == uuid.Nil && len(params.ServiceTags) == 1
+					})).
+					Return(&domain.ValidateCreateServiceResult{
+						Valid:  false,
+						Errors: []schema.ValidationErrorDetail{{Path: "prop", Message: "must be a string"}},
+					}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			checkResponse: func(t *testing.T, response map[string]any) {
+				assert.Equal(t, false, response["valid"])
+				assert.Nil(t, response["properties"])
+				assert.Len(t, response["errors"], 1)
 			},
-			expectedStatus: http.StatusBadRequest,
 		},
 		{
-			name: "NotFound",
-			id:   "550e8400-e29b-41d4-a716-446655440000",
-			request: UpdateServiceReq{
-				Name:       helpers.StringPtr("Updated Service"),
-				Properties: helpers.JSONPtr(properties.JSON{"updated": "value"}),
+			name: "CommanderError",
+			request: CreateServiceReq{
+				Name:          "Test Service",
+				AgentID:       &[]properties.UUID{uuid.MustParse("550e8400-e29b-41d4-a716-446655440000")}[0],
+				GroupID:       uuid.MustParse("660e8400-e29b-41d4-a716-446655440000"),
+				ServiceTypeID: uuid.MustParse("770e8400-e29b-41d4-a716-446655440000"),
 			},
 			mockSetup: func(commander *domain.MockServiceCommander) {
-				// Setup the commander to return not found
 				commander.EXPECT().
-					Update(mock.Anything, mock.Anything).
-					Return(nil, domain.NewNotFoundErrorf("service not found"))
+					ValidateCreate(mock.Anything, mock.Anything).
+					Return(nil, domain.NewInvalidInputErrorf("agent type does not support service type"))
 			},
-			expectedStatus: http.StatusNotFound,
+			expectedStatus: http.StatusBadRequest,
 		},
 	}
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			// Setup mocks
 			serviceQuerier := domain.NewMockServiceQuerier(t)
 			agentQuerier := domain.NewMockAgentQuerier(t)
 			serviceGroupQuerier := domain.NewMockServiceGroupQuerier(t)
 			commander := domain.NewMockServiceCommander(t)
-			authz := authz.NewMockAuthorizer(t) // Not used in handler tests
+			authz := authz.NewMockAuthorizer(t)
 			tc.mockSetup(commander)
 
-			// Create the handler
-			handler := NewServiceHandler(serviceQuerier, agentQuerier, serviceGroupQuerier, commander, authz)
+			handler := NewServiceHandler(serviceQuerier, agentQuerier, serviceGroupQuerier, nil, nil, commander, authz)
 
-			// Create request
-			// Create request with body
 			bodyBytes, err := json.Marshal(tc.request)
 			require.NoError(t, err)
-			req := httptest.NewRequest("PATCH", "/services/"+tc.id, bytes.NewReader(bodyBytes))
+			req := httptest.NewRequest("POST", "/services/validate", bytes.NewReader(bodyBytes))
 			req.Header.Set("Content-Type", "application/json")
+			req = req.WithContext(auth.WithIdentity(req.Context(), newMockAuthAdmin()))
 
-			// Set up chi router context for URL parameters FIRST
-			rctx := chi.NewRouteContext()
-			rctx.URLParams.Add("id", tc.id)
-			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
-
-			// Add auth identity to context (always required)
-			authIdentity := newMockAuthAdmin()
-			req = req.WithContext(auth.WithIdentity(req.Context(), authIdentity))
-
-			// Execute request with middleware
 			w := httptest.NewRecorder()
-			middlewareHandler := middlewares.DecodeBody[UpdateServiceReq]()(middlewares.ID(Update(handler.Update, ServiceToRes)))
+			middlewareHandler := middlewares.DecodeBody[CreateServiceReq]()(http.HandlerFunc(handler.ValidateCreate))
 			middlewareHandler.ServeHTTP(w, req)
 
-			// Assert response
 			assert.Equal(t, tc.expectedStatus, w.Code)
-
-			if tc.expectedStatus == http.StatusOK {
+			if tc.checkResponse != nil {
 				var response map[string]any
-				err := json.Unmarshal(w.Body.Bytes(), &response)
-				require.NoError(t, err)
-
-				// Verify response structure
-				assert.Equal(t, tc.id, response["id"])
-				assert.Equal(t, "Updated Service", response["name"])
-				props, ok := response["properties"].(map[string]any)
-				require.True(t, ok)
-				assert.Equal(t, "value", props["updated"])
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+				tc.checkResponse(t, response)
 			}
 		})
 	}
 }
 
-// TestServiceHandleTransition tests handleStart, handleStop, and handleDelete via handleTransition
-func TestServiceHandleTransition(t *testing.T) {
-	// Setup test cases
+// TestServiceHandleEstimate tests the Estimate method
+func TestServiceHandleEstimate(t *testing.T) {
+	optionID := uuid.MustParse("880e8400-e29b-41d4-a716-446655440000")
+
 	testCases := []struct {
 		name           string
-		id             string
-		transitionTo   string
+		request        CreateServiceReq
 		mockSetup      func(commander *domain.MockServiceCommander)
 		expectedStatus int
+		checkResponse  func(t *testing.T, response map[string]any)
 	}{
 		{
-			name:         "SuccessfulStart",
-			id:           "550e8400-e29b-41d4-a716-446655440000",
-			transitionTo: "Started",
-			mockSetup: func(commander *domain.MockServiceCommander) {
-				// Setup the commander for successful transition
-				commander.EXPECT().
-					DoAction(mock.Anything, mock.MatchedBy(func(params domain.DoServiceActionParams) bool {
-						return params.ID == uuid.MustParse("550e8400-e29b-41d4-a716-446655440000") &&
-							params.Action == "start"
-					})).
-					Return(&domain.Service{
-						BaseEntity: domain.BaseEntity{
-							ID: uuid.MustParse("550e8400-e29b-41d4-a716-446655440000"),
-						},
-						Status: "Started",
-					}, nil)
+			name: "Success",
+			request: CreateServiceReq{
+				Name:          "Test Service",
+				GroupID:       uuid.MustParse("660e8400-e29b-41d4-a716-446655440000"),
+				ServiceTypeID: uuid.MustParse("770e8400-e29b-41d4-a716-446655440000"),
+				Properties:    properties.JSON{"cpu": 2},
+				OptionIDs:     []properties.UUID{optionID},
 			},
-			expectedStatus: http.StatusNoContent,
-		},
-		{
-			name:         "SuccessfulStop",
-			id:           "550e8400-e29b-41d4-a716-446655440000",
-			transitionTo: "Stopped",
 			mockSetup: func(commander *domain.MockServiceCommander) {
-				// Setup the commander for successful transition
 				commander.EXPECT().
-					DoAction(mock.Anything, mock.MatchedBy(func(params domain.DoServiceActionParams) bool {
-						return params.ID == uuid.MustParse("550e8400-e29b-41d4-a716-446655440000") &&
-							params.Action == "stop"
+					EstimateCost(mock.Anything, mock.MatchedBy(func(params domain.CreateServiceWithTagsParams) bool {
+						return params.Name == "Test Service" && len(params.OptionIDs) == 1 && params.OptionIDs[0] == optionID
 					})).
-					Return(&domain.Service{
-						BaseEntity: domain.BaseEntity{
-							ID: uuid.MustParse("550e8400-e29b-41d4-a716-446655440000"),
+					Return(&domain.CostEstimate{
+						Total: 25,
+						Items: []domain.CostEstimateItem{
+							{Kind: domain.CostItemOption, Name: "ubuntu", OptionID: &optionID, Quantity: 1, UnitCost: 5, Cost: 5},
+							{Kind: domain.CostItemProperty, Name: "cpu", Quantity: 2, UnitCost: 10, Cost: 20},
 						},
-						Status: "Stopped",
 					}, nil)
 			},
-			expectedStatus: http.StatusNoContent,
+			expectedStatus: http.StatusOK,
+			checkResponse: func(t *testing.T, response map[string]any) {
+				assert.Equal(t, float64(25), response["total"])
+				items := response["items"].([]any)
+				require.Len(t, items, 2)
+				assert.Equal(t, optionID.String(), items[0].(map[string]any)["optionId"])
+				assert.Equal(t, "cpu", items[1].(map[string]any)["name"])
+				assert.Equal(t, float64(20), items[1].(map[string]any)["cost"])
+			},
 		},
 		{
-			name:         "SuccessfulDelete",
-			id:           "550e8400-e29b-41d4-a716-446655440000",
-			transitionTo: "Deleted",
+			name: "InvalidProperties",
+			request: CreateServiceReq{
+				Name:          "Test Service",
+				GroupID:       uuid.MustParse("660e8400-e29b-41d4-a716-446655440000"),
+				ServiceTypeID: uuid.MustParse("770e8400-e29b-41d4-a716-446655440000"),
+				Properties:    properties.JSON{"cpu": "two"},
+			},
 			mockSetup: func(commander *domain.MockServiceCommander) {
-				// Setup the commander for successful transition
 				commander.EXPECT().
-					DoAction(mock.Anything, mock.MatchedBy(func(params domain.DoServiceActionParams) bool {
-						return params.ID == uuid.MustParse("550e8400-e29b-41d4-a716-446655440000")
-					})).
-					Return(&domain.Service{
-						BaseEntity: domain.BaseEntity{
-							ID: uuid.MustParse("550e8400-e29b-41d4-a716-446655440000"),
-						},
-						Status: "Deleted",
-					}, nil)
+					EstimateCost(mock.Anything, mock.Anything).
+					Return(nil, domain.InvalidInputError{Err: schema.NewValidationError([]schema.ValidationErrorDetail{{Path: "cpu", Message: "must be an integer"}})})
 			},
-			expectedStatus: http.StatusNoContent,
+			expectedStatus: http.StatusBadRequest,
 		},
-		{
-			name:         "InvalidStatusTransition",
-			id:           "550e8400-e29b-41d4-a716-446655440000",
-			transitionTo: "Started",
-			mockSetup: func(commander *domain.MockServiceCommander) {
-				// Setup the commander to return an error for invalid transition
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			commander := domain.NewMockServiceCommander(t)
+			tc.mockSetup(commander)
+
+			handler := NewServiceHandler(domain.NewMockServiceQuerier(t), domain.NewMockAgentQuerier(t), domain.NewMockServiceGroupQuerier(t), nil, nil, commander, authz.NewMockAuthorizer(t))
+
+			bodyBytes, err := json.Marshal(tc.request)
+			require.NoError(t, err)
+			req := httptest.NewRequest("POST", "/services/estimate", bytes.NewReader(bodyBytes))
+			req.Header.Set("Content-Type", "application/json")
+			req = req.WithContext(auth.WithIdentity(req.Context(), newMockAuthAdmin()))
+
+			w := httptest.NewRecorder()
+			middlewares.DecodeBody[CreateServiceReq]()(http.HandlerFunc(handler.Estimate)).ServeHTTP(w, req)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			if tc.checkResponse != nil {
+				var response map[string]any
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+				tc.checkResponse(t, response)
+			}
+		})
+	}
+}
+
+// TestServiceHandlePreviewUpdate tests the PreviewUpdate method
+func TestServiceHandlePreviewUpdate(t *testing.T) {
+	serviceID := uuid.MustParse("550e8400-e29b-41d4-a716-446655440000")
+
+	testCases := []struct {
+		name           string
+		request        PreviewServiceUpdateReq
+		mockSetup      func(commander *domain.MockServiceCommander)
+		expectedStatus int
+		checkResponse  func(t *testing.T, response map[string]any)
+	}{
+		{
+			name:    "Cold update",
+			request: PreviewServiceUpdateReq{Properties: &properties.JSON{"cpu": 4}},
+			mockSetup: func(commander *domain.MockServiceCommander) {
 				commander.EXPECT().
-					DoAction(mock.Anything, mock.Anything).
-					Return(nil, domain.NewInvalidInputErrorf("invalid status transition"))
+					PreviewUpdate(mock.Anything, serviceID, (*string)(nil), &properties.JSON{"cpu": float64(4)}).
+					Return(&domain.ServiceUpdatePreview{
+						Valid:             true,
+						Name:              "web",
+						ChangedProperties: []string{"cpu"},
+						Diff:              jsondiff.Patch{{Type: jsondiff.OperationReplace, Path: "/cpu", OldValue: float64(2), Value: float64(4)}},
+						UpdateMode:        "cold",
+						Action:            domain.ServiceActionColdUpdate,
+						NextStatus:        "Restarting",
+					}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			checkResponse: func(t *testing.T, response map[string]any) {
+				assert.Equal(t, true, response["valid"])
+				assert.Equal(t, []any{"cpu"}, response["changedProperties"])
+				assert.Equal(t, []any{map[string]any{"op": "replace", "path": "/cpu", "value": float64(4)}}, response["diff"])
+				assert.Equal(t, "cold", response["updateMode"])
+				assert.Equal(t, "coldUpdate", response["action"])
+				assert.Equal(t, "Restarting", response["nextStatus"])
+				assert.Nil(t, response["errors"])
+			},
+		},
+		{
+			name:    "Violations",
+			request: PreviewServiceUpdateReq{Name: helpers.StringPtr("web"), Properties: &properties.JSON{"region": "us"}},
+			mockSetup: func(commander *domain.MockServiceCommander) {
+				commander.EXPECT().
+					PreviewUpdate(mock.Anything, serviceID, helpers.StringPtr("web"), mock.Anything).
+					Return(&domain.ServiceUpdatePreview{
+						Name:              "web",
+						ChangedProperties: []string{"region"},
+						Errors:            []schema.ValidationErrorDetail{{Path: "region", Message: "property is immutable and cannot be changed"}},
+					}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			checkResponse: func(t *testing.T, response map[string]any) {
+				assert.Equal(t, false, response["valid"])
+				assert.Len(t, response["errors"], 1)
+				assert.Equal(t, []any{}, response["diff"])
+				assert.Nil(t, response["action"])
+			},
+		},
+		{
+			name:    "CommanderError",
+			request: PreviewServiceUpdateReq{Properties: &properties.JSON{"cpu": 4}},
+			mockSetup: func(commander *domain.MockServiceCommander) {
+				commander.EXPECT().
+					PreviewUpdate(mock.Anything, serviceID, (*string)(nil), mock.Anything).
+					Return(nil, domain.NewNotFoundErrorf("service not found"))
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			commander := domain.NewMockServiceCommander(t)
+			tc.mockSetup(commander)
+			handler := NewServiceHandler(domain.NewMockServiceQuerier(t), domain.NewMockAgentQuerier(t), domain.NewMockServiceGroupQuerier(t), nil, nil, commander, authz.NewMockAuthorizer(t))
+
+			bodyBytes, err := json.Marshal(tc.request)
+			require.NoError(t, err)
+			req := httptest.NewRequest("POST", "/services/"+serviceID.String()+"/preview", bytes.NewReader(bodyBytes))
+			req.Header.Set("Content-Type", "application/json")
+			req = req.WithContext(auth.WithIdentity(req.Context(), newMockAuthAdmin()))
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("id", serviceID.String())
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+
+			w := httptest.NewRecorder()
+			middlewareHandler := middlewares.ID(middlewares.DecodeBody[PreviewServiceUpdateReq]()(http.HandlerFunc(handler.PreviewUpdate)))
+			middlewareHandler.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			if tc.checkResponse != nil {
+				var response map[string]any
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+				tc.checkResponse(t, response)
+			}
+		})
+	}
+}
+
+// TestServiceHandleUpdate tests the handleUpdate method
+func TestServiceHandleUpdate(t *testing.T) {
+	// Setup test cases
+	testCases := []struct {
+		name           string
+		id             string
+		request        UpdateServiceReq
+		mockSetup      func(commander *domain.MockServiceCommander)
+		expectedStatus int
+	}{
+		{
+			name: "Success",
+			id:   "550e8400-e29b-41d4-a716-446655440000",
+			request: UpdateServiceReq{
+				Name:       helpers.StringPtr("Updated Service"),
+				Properties: helpers.JSONPtr(properties.JSON{"updated": "value"}),
+			},
+			mockSetup: func(commander *domain.MockServiceCommander) {
+				// Setup the commander for successful update
+				createdAt := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+				updatedAt := time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC)
+				updatedName := "Updated Service"
+				updatedProps := properties.JSON{"updated": "value"}
+
+				commander.EXPECT().
+					Update(mock.Anything, mock.MatchedBy(func(params domain.UpdateServiceParams) bool {
+						return params.ID == uuid.MustParse("550e8400-e29b-41d4-a716-446655440000") &&
+							*params.Name == "Updated Service" &&
+							(*params.Properties)["updated"] == "value"
+					})).
+					Return(&domain.Service{
+						BaseEntity: domain.BaseEntity{
+							ID:        uuid.MustParse("550e8400-e29b-41d4-a716-446655440000"),
+							CreatedAt: createdAt,
+							UpdatedAt: updatedAt,
+						},
+						Name:       updatedName,
+						Status:     "Started",
+						Properties: &updatedProps,
+					}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "DisableIdleTimeout",
+			id:   "550e8400-e29b-41d4-a716-446655440000",
+			request: UpdateServiceReq{
+				IdleTimeout: &[]JSONDuration{0}[0],
+			},
+			mockSetup: func(commander *domain.MockServiceCommander) {
+				commander.EXPECT().
+					Update(mock.Anything, mock.MatchedBy(func(params domain.UpdateServiceParams) bool {
+						return params.Name == nil && params.Properties == nil &&
+							params.IdleTimeout != nil && *params.IdleTimeout == 0
+					})).
+					Return(&domain.Service{
+						BaseEntity: domain.BaseEntity{ID: uuid.MustParse("550e8400-e29b-41d4-a716-446655440000")},
+						Name:       "Updated Service",
+						Status:     "Started",
+						Properties: &properties.JSON{"updated": "value"},
+					}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "ValidationError",
+			id:   "550e8400-e29b-41d4-a716-446655440000",
+			request: UpdateServiceReq{
+				Name:       helpers.StringPtr("Invalid"),
+				Properties: helpers.JSONPtr(properties.JSON{"invalid": "data"}),
+			},
+			mockSetup: func(commander *domain.MockServiceCommander) {
+				// Setup the commander to return a validation error
+				commander.EXPECT().
+					Update(mock.Anything, mock.Anything).
+					Return(nil, domain.NewInvalidInputErrorf("validation error"))
 			},
 			expectedStatus: http.StatusBadRequest,
 		},
 		{
-			name:         "ServiceNotFound",
-			id:           "550e8400-e29b-41d4-a716-446655440000",
-			transitionTo: "Started",
+			name: "NotFound",
+			id:   "550e8400-e29b-41d4-a716-446655440000",
+			request: UpdateServiceReq{
+				Name:       helpers.StringPtr("Updated Service"),
+				Properties: helpers.JSONPtr(properties.JSON{"updated": "value"}),
+			},
 			mockSetup: func(commander *domain.MockServiceCommander) {
 				// Setup the commander to return not found
 				commander.EXPECT().
-					DoAction(mock.Anything, mock.Anything).
+					Update(mock.Anything, mock.Anything).
 					Return(nil, domain.NewNotFoundErrorf("service not found"))
 			},
 			expectedStatus: http.StatusNotFound,
@@ -442,10 +844,14 @@ func TestServiceHandleTransition(t *testing.T) {
 			tc.mockSetup(commander)
 
 			// Create the handler
-			handler := NewServiceHandler(serviceQuerier, agentQuerier, serviceGroupQuerier, commander, authz)
+			handler := NewServiceHandler(serviceQuerier, agentQuerier, serviceGroupQuerier, nil, nil, commander, authz)
 
 			// Create request
-			req := httptest.NewRequest("POST", "/services/"+tc.id+"/action", nil)
+			// Create request with body
+			bodyBytes, err := json.Marshal(tc.request)
+			require.NoError(t, err)
+			req := httptest.NewRequest("PATCH", "/services/"+tc.id, bytes.NewReader(bodyBytes))
+			req.Header.Set("Content-Type", "application/json")
 
 			// Set up chi router context for URL parameters FIRST
 			rctx := chi.NewRouteContext()
@@ -458,27 +864,1004 @@ func TestServiceHandleTransition(t *testing.T) {
 
 			// Execute request with middleware
 			w := httptest.NewRecorder()
-			middlewareHandler := middlewares.ID(CommandWithoutBody(func(ctx context.Context, id properties.UUID) error {
-				// All transitions now use DoAction except delete
-				switch tc.transitionTo {
-				case "Deleted":
-					return handler.Delete(ctx, id)
-				case "Started":
-					params := domain.DoServiceActionParams{ID: id, Action: "start"}
-					_, err := commander.DoAction(ctx, params)
-					return err
-				case "Stopped":
-					params := domain.DoServiceActionParams{ID: id, Action: "stop"}
-					_, err := commander.DoAction(ctx, params)
-					return err
-				default:
-					return fmt.Errorf("unsupported transition: %v", tc.transitionTo)
-				}
-			}))
+			middlewareHandler := middlewares.DecodeBody[UpdateServiceReq]()(middlewares.ID(Update(handler.Update, ServiceToRes)))
 			middlewareHandler.ServeHTTP(w, req)
 
 			// Assert response
 			assert.Equal(t, tc.expectedStatus, w.Code)
+
+			if tc.expectedStatus == http.StatusOK {
+				var response map[string]any
+				err := json.Unmarshal(w.Body.Bytes(), &response)
+				require.NoError(t, err)
+
+				// Verify response structure
+				assert.Equal(t, tc.id, response["id"])
+				assert.Equal(t, "Updated Service", response["name"])
+				props, ok := response["properties"].(map[string]any)
+				require.True(t, ok)
+				assert.Equal(t, "value", props["updated"])
+			}
+		})
+	}
+}
+
+// TestServiceHandlePatch tests JSON Patch updates of the service properties
+func TestServiceHandlePatch(t *testing.T) {
+	id := uuid.MustParse("550e8400-e29b-41d4-a716-446655440000")
+	current := func() *domain.Service {
+		props := properties.JSON{"cpu": 2, "network": map[string]any{"zone": "eu"}}
+		return &domain.Service{BaseEntity: domain.BaseEntity{ID: id}, Status: "Started", Properties: &props}
+	}
+
+	testCases := []struct {
+		name           string
+		body           string
+		mockSetup      func(querier *domain.MockServiceQuerier, commander *domain.MockServiceCommander)
+		expectedStatus int
+		expectedError  string
+	}{
+		{
+			name: "Success updates only the changed properties",
+			body: `[{"op":"replace","path":"/network/zone","value":"us"}]`,
+			mockSetup: func(querier *domain.MockServiceQuerier, commander *domain.MockServiceCommander) {
+				querier.EXPECT().Get(mock.Anything, id).Return(current(), nil)
+				commander.EXPECT().
+					Update(mock.Anything, mock.MatchedBy(func(params domain.UpdateServiceParams) bool {
+						return params.ID == id && params.Name == nil && len(*params.Properties) == 1 &&
+							(*params.Properties)["network"].(map[string]any)["zone"] == "us"
+					})).
+					Return(current(), nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "No change skips the update",
+			body: `[{"op":"test","path":"/cpu","value":2}]`,
+			mockSetup: func(querier *domain.MockServiceQuerier, commander *domain.MockServiceCommander) {
+				querier.EXPECT().Get(mock.Anything, id).Return(current(), nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "Invalid path points at the failing operation",
+			body: `[{"op":"replace","path":"/cpu","value":4},{"op":"replace","path":"/missing/x","value":1}]`,
+			mockSetup: func(querier *domain.MockServiceQuerier, commander *domain.MockServiceCommander) {
+				querier.EXPECT().Get(mock.Anything, id).Return(current(), nil)
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "patch operation 1 (replace /missing/x)",
+		},
+		{
+			name: "Rejected property update",
+			body: `[{"op":"replace","path":"/cpu","value":4}]`,
+			mockSetup: func(querier *domain.MockServiceQuerier, commander *domain.MockServiceCommander) {
+				querier.EXPECT().Get(mock.Anything, id).Return(current(), nil)
+				commander.EXPECT().Update(mock.Anything, mock.Anything).
+					Return(nil, schema.NewValidationError([]schema.ValidationErrorDetail{{Path: "cpu", Message: "cpu: can only be set by agent"}}))
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "can only be set by agent",
+		},
+		{
+			name: "Not found",
+			body: `[{"op":"replace","path":"/cpu","value":4}]`,
+			mockSetup: func(querier *domain.MockServiceQuerier, commander *domain.MockServiceCommander) {
+				querier.EXPECT().Get(mock.Anything, id).Return(nil, domain.NewNotFoundErrorf("service not found"))
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			serviceQuerier := domain.NewMockServiceQuerier(t)
+			commander := domain.NewMockServiceCommander(t)
+			tc.mockSetup(serviceQuerier, commander)
+			handler := NewServiceHandler(serviceQuerier, nil, nil, nil, nil, commander, nil)
+
+			req := httptest.NewRequest("PATCH", "/services/"+id.String(), bytes.NewReader([]byte(tc.body)))
+			req.Header.Set("Content-Type", contentTypeJSONPatch)
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("id", id.String())
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+			req = req.WithContext(auth.WithIdentity(req.Context(), newMockAuthAdmin()))
+
+			w := httptest.NewRecorder()
+			middlewares.DecodeBody[PatchServiceReq]()(middlewares.ID(Update(handler.Patch, ServiceToRes))).ServeHTTP(w, req)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			if tc.expectedError != "" {
+				assert.Contains(t, w.Body.String(), tc.expectedError)
+			}
+		})
+	}
+}
+
+// TestServiceHandleTransition tests handleStart, handleStop, and handleDelete via handleTransition
+func TestServiceHandleTransition(t *testing.T) {
+	// Setup test cases
+	testCases := []struct {
+		name           string
+		id             string
+		transitionTo   string
+		mockSetup      func(commander *domain.MockServiceCommander)
+		expectedStatus int
+	}{
+		{
+			name:         "SuccessfulStart",
+			id:           "550e8400-e29b-41d4-a716-446655440000",
+			transitionTo: "Started",
+			mockSetup: func(commander *domain.MockServiceCommander) {
+				// Setup the commander for successful transition
+				commander.EXPECT().
+					DoAction(mock.Anything, mock.MatchedBy(func(params domain.DoServiceActionParams) bool {
+						return params.ID == uuid.MustParse("550e8400-e29b-41d4-a716-446655440000") &&
+							params.Action == "start"
+					})).
+					Return(&domain.Service{
+						BaseEntity: domain.BaseEntity{
+							ID: uuid.MustParse("550e8400-e29b-41d4-a716-446655440000"),
+						},
+						Status: "Started",
+					}, nil)
+			},
+			expectedStatus: http.StatusNoContent,
+		},
+		{
+			name:         "SuccessfulStop",
+			id:           "550e8400-e29b-41d4-a716-446655440000",
+			transitionTo: "Stopped",
+			mockSetup: func(commander *domain.MockServiceCommander) {
+				// Setup the commander for successful transition
+				commander.EXPECT().
+					DoAction(mock.Anything, mock.MatchedBy(func(params domain.DoServiceActionParams) bool {
+						return params.ID == uuid.MustParse("550e8400-e29b-41d4-a716-446655440000") &&
+							params.Action == "stop"
+					})).
+					Return(&domain.Service{
+						BaseEntity: domain.BaseEntity{
+							ID: uuid.MustParse("550e8400-e29b-41d4-a716-446655440000"),
+						},
+						Status: "Stopped",
+					}, nil)
+			},
+			expectedStatus: http.StatusNoContent,
+		},
+		{
+			name:         "SuccessfulDelete",
+			id:           "550e8400-e29b-41d4-a716-446655440000",
+			transitionTo: "Deleted",
+			mockSetup: func(commander *domain.MockServiceCommander) {
+				// Setup the commander for successful transition
+				commander.EXPECT().
+					DoAction(mock.Anything, mock.MatchedBy(func(params domain.DoServiceActionParams) bool {
+						return params.ID == uuid.MustParse("550e8400-e29b-41d4-a716-446655440000")
+					})).
+					Return(&domain.Service{
+						BaseEntity: domain.BaseEntity{
+							ID: uuid.MustParse("550e8400-e29b-41d4-a716-446655440000"),
+						},
+						Status: "Deleted",
+					}, nil)
+			},
+			expectedStatus: http.StatusNoContent,
+		},
+		{
+			name:         "InvalidStatusTransition",
+			id:           "550e8400-e29b-41d4-a716-446655440000",
+			transitionTo: "Started",
+			mockSetup: func(commander *domain.MockServiceCommander) {
+				// Setup the commander to return an error for invalid transition
+				commander.EXPECT().
+					DoAction(mock.Anything, mock.Anything).
+					Return(nil, domain.NewInvalidInputErrorf("invalid status transition"))
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:         "ServiceNotFound",
+			id:           "550e8400-e29b-41d4-a716-446655440000",
+			transitionTo: "Started",
+			mockSetup: func(commander *domain.MockServiceCommander) {
+				// Setup the commander to return not found
+				commander.EXPECT().
+					DoAction(mock.Anything, mock.Anything).
+					Return(nil, domain.NewNotFoundErrorf("service not found"))
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Setup mocks
+			serviceQuerier := domain.NewMockServiceQuerier(t)
+			agentQuerier := domain.NewMockAgentQuerier(t)
+			serviceGroupQuerier := domain.NewMockServiceGroupQuerier(t)
+			commander := domain.NewMockServiceCommander(t)
+			authz := authz.NewMockAuthorizer(t) // Not used in handler tests
+			tc.mockSetup(commander)
+
+			// Create the handler
+			handler := NewServiceHandler(serviceQuerier, agentQuerier, serviceGroupQuerier, nil, nil, commander, authz)
+
+			// Create request
+			req := httptest.NewRequest("POST", "/services/"+tc.id+"/action", nil)
+
+			// Set up chi router context for URL parameters FIRST
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("id", tc.id)
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+
+			// Add auth identity to context (always required)
+			authIdentity := newMockAuthAdmin()
+			req = req.WithContext(auth.WithIdentity(req.Context(), authIdentity))
+
+			// Execute request with middleware
+			w := httptest.NewRecorder()
+			middlewareHandler := middlewares.ID(CommandWithoutBody(func(ctx context.Context, id properties.UUID) error {
+				// All transitions now use DoAction except delete
+				switch tc.transitionTo {
+				case "Deleted":
+					return handler.Delete(ctx, id)
+				case "Started":
+					params := domain.DoServiceActionParams{ID: id, Action: "start"}
+					_, err := commander.DoAction(ctx, params)
+					return err
+				case "Stopped":
+					params := domain.DoServiceActionParams{ID: id, Action: "stop"}
+					_, err := commander.DoAction(ctx, params)
+					return err
+				default:
+					return fmt.Errorf("unsupported transition: %v", tc.transitionTo)
+				}
+			}))
+			middlewareHandler.ServeHTTP(w, req)
+
+			// Assert response
+			assert.Equal(t, tc.expectedStatus, w.Code)
+		})
+	}
+}
+
+// TestServiceHandleGenericAction tests the GenericAction method
+func TestServiceHandleGenericAction(t *testing.T) {
+	id := uuid.MustParse("550e8400-e29b-41d4-a716-446655440000")
+	scheduledAt := time.Date(2030, 1, 1, 2, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name           string
+		body           string
+		mockSetup      func(commander *domain.MockServiceCommander)
+		expectedStatus int
+	}{
+		{
+			name: "ImmediateWithoutBody",
+			mockSetup: func(commander *domain.MockServiceCommander) {
+				commander.EXPECT().
+					DoAction(mock.Anything, domain.DoServiceActionParams{ID: id, Action: "stop"}).
+					Return(&domain.Service{BaseEntity: domain.BaseEntity{ID: id}, Status: "Started"}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "Scheduled",
+			body: `{"scheduledAt":"2030-01-01T02:00:00Z"}`,
+			mockSetup: func(commander *domain.MockServiceCommander) {
+				commander.EXPECT().
+					DoAction(mock.Anything, mock.MatchedBy(func(params domain.DoServiceActionParams) bool {
+						return params.ID == id && params.Action == "stop" && params.ScheduledAt != nil && params.ScheduledAt.Equal(scheduledAt)
+					})).
+					Return(&domain.Service{BaseEntity: domain.BaseEntity{ID: id}, Status: "Started"}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "InvalidBody",
+			body:           `{"scheduledAt":"tomorrow"}`,
+			mockSetup:      func(commander *domain.MockServiceCommander) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "MalformedBody",
+			body:           `{"scheduledAt":`,
+			mockSetup:      func(commander *domain.MockServiceCommander) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "InMaintenance",
+			mockSetup: func(commander *domain.MockServiceCommander) {
+				commander.EXPECT().
+					DoAction(mock.Anything, domain.DoServiceActionParams{ID: id, Action: "stop"}).
+					Return(nil, domain.NewMaintenanceError(id))
+			},
+			expectedStatus: http.StatusLocked,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			serviceQuerier := domain.NewMockServiceQuerier(t)
+			agentQuerier := domain.NewMockAgentQuerier(t)
+			serviceGroupQuerier := domain.NewMockServiceGroupQuerier(t)
+			commander := domain.NewMockServiceCommander(t)
+			authz := authz.NewMockAuthorizer(t)
+			tc.mockSetup(commander)
+
+			handler := NewServiceHandler(serviceQuerier, agentQuerier, serviceGroupQuerier, nil, nil, commander, authz)
+
+			req := httptest.NewRequest("POST", "/services/"+id.String()+"/stop", bytes.NewBufferString(tc.body))
+			req.Header.Set("Content-Type", "application/json")
+
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("id", id.String())
+			rctx.URLParams.Add("action", "stop")
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+			req = req.WithContext(auth.WithIdentity(req.Context(), newMockAuthAdmin()))
+
+			w := httptest.NewRecorder()
+			middlewareHandler := middlewares.ID(middlewares.ActionName(middlewares.DecodeOptionalBody[ServiceActionRequest]()(http.HandlerFunc(handler.GenericAction))))
+			middlewareHandler.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+		})
+	}
+}
+
+// TestServiceHandleCancel tests the cancel operation route
+func TestServiceHandleCancel(t *testing.T) {
+	id := uuid.MustParse("550e8400-e29b-41d4-a716-446655440000")
+
+	testCases := []struct {
+		name           string
+		mockSetup      func(commander *domain.MockServiceCommander)
+		expectedStatus int
+	}{
+		{
+			name: "Success",
+			mockSetup: func(commander *domain.MockServiceCommander) {
+				commander.EXPECT().CancelOperation(mock.Anything, id).
+					Return(&domain.Service{BaseEntity: domain.BaseEntity{ID: id}, Status: "Started"}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "NoOperationInProgress",
+			mockSetup: func(commander *domain.MockServiceCommander) {
+				commander.EXPECT().CancelOperation(mock.Anything, id).
+					Return(nil, domain.NewInvalidInputErrorf("service has no operation in progress"))
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			commander := domain.NewMockServiceCommander(t)
+			tc.mockSetup(commander)
+
+			req := httptest.NewRequest("POST", "/services/"+id.String()+"/cancel", nil)
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("id", id.String())
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+			req = req.WithContext(auth.WithIdentity(req.Context(), newMockAuthAdmin()))
+
+			w := httptest.NewRecorder()
+			middlewares.ID(ActionWithoutBody(commander.CancelOperation, ServiceToRes)).ServeHTTP(w, req)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+		})
+	}
+}
+
+// TestServiceHandleGetInclude tests the related resources nested with the include parameter
+func TestServiceHandleGetInclude(t *testing.T) {
+	id := properties.NewUUID()
+	consumerID := properties.NewUUID()
+	providerID := properties.NewUUID()
+	service := &domain.Service{
+		BaseEntity:  domain.BaseEntity{ID: id},
+		Name:        "Test Service",
+		ProviderID:  providerID,
+		ConsumerID:  consumerID,
+		Agent:       &domain.Agent{BaseEntity: domain.BaseEntity{ID: properties.NewUUID()}, Name: "Agent", ProviderID: providerID},
+		Group:       &domain.ServiceGroup{BaseEntity: domain.BaseEntity{ID: properties.NewUUID()}, Name: "Group", ConsumerID: consumerID},
+		ServiceType: &domain.ServiceType{BaseEntity: domain.BaseEntity{ID: properties.NewUUID()}, Name: "Type"},
+	}
+	consumer := &auth.Identity{ID: properties.NewUUID(), Role: auth.RoleParticipant, Scope: auth.IdentityScope{ParticipantID: &consumerID}}
+
+	testCases := []struct {
+		name                string
+		identity            *auth.Identity
+		query               string
+		expectedStatus      int
+		expectedAgent       bool
+		expectedGroup       bool
+		expectedServiceType bool
+	}{
+		{name: "No include", identity: newMockAuthAdmin(), expectedStatus: http.StatusOK},
+		{
+			name:                "Admin includes all relations",
+			identity:            newMockAuthAdmin(),
+			query:               "?include=agent,group,serviceType",
+			expectedStatus:      http.StatusOK,
+			expectedAgent:       true,
+			expectedGroup:       true,
+			expectedServiceType: true,
+		},
+		{
+			name:                "Consumer cannot include the provider agent",
+			identity:            consumer,
+			query:               "?include=agent,group,serviceType",
+			expectedStatus:      http.StatusOK,
+			expectedGroup:       true,
+			expectedServiceType: true,
+		},
+		{name: "Relation not allowed", identity: newMockAuthAdmin(), query: "?include=provider", expectedStatus: http.StatusBadRequest},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			querier := domain.NewMockServiceQuerier(t)
+			if tc.expectedStatus == http.StatusOK {
+				querier.EXPECT().Get(mock.Anything, id).Return(service, nil)
+			}
+			handler := NewServiceHandler(querier, nil, nil, nil, nil, nil, authz.NewRuleBasedAuthorizer(authz.Rules))
+
+			req := httptest.NewRequest("GET", "/services/"+id.String()+tc.query, nil)
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("id", id.String())
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+			req = req.WithContext(auth.WithIdentity(req.Context(), tc.identity))
+
+			w := httptest.NewRecorder()
+			middlewares.ID(http.HandlerFunc(handler.Get)).ServeHTTP(w, req)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			if tc.expectedStatus != http.StatusOK {
+				return
+			}
+			var res ServiceRes
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+			assert.Equal(t, tc.expectedAgent, res.Agent != nil)
+			assert.Equal(t, tc.expectedGroup, res.Group != nil)
+			assert.Equal(t, tc.expectedServiceType, res.ServiceType != nil)
+		})
+	}
+}
+
+// TestServiceHandleListInclude tests that the list only loads the included relations
+func TestServiceHandleListInclude(t *testing.T) {
+	testCases := []struct {
+		name            string
+		query           string
+		expectedStatus  int
+		expectedInclude []string
+	}{
+		{name: "No include", expectedStatus: http.StatusOK},
+		{name: "Include", query: "?include=agent,group", expectedStatus: http.StatusOK, expectedInclude: []string{"agent", "group"}},
+		{name: "Relation not allowed", query: "?include=provider", expectedStatus: http.StatusBadRequest},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			querier := domain.NewMockServiceQuerier(t)
+			if tc.expectedStatus == http.StatusOK {
+				querier.EXPECT().
+					List(mock.Anything, mock.Anything, mock.MatchedBy(func(req *domain.PageReq) bool {
+						return assert.ObjectsAreEqual(tc.expectedInclude, req.Include)
+					})).
+					Return(&domain.PageRes[domain.Service]{Items: []domain.Service{}}, nil)
+			}
+			handler := NewServiceHandler(querier, nil, nil, nil, nil, nil, authz.NewRuleBasedAuthorizer(authz.Rules))
+
+			req := httptest.NewRequest("GET", "/services"+tc.query, nil)
+			req = req.WithContext(auth.WithIdentity(req.Context(), newMockAuthAdmin()))
+
+			w := httptest.NewRecorder()
+			handler.List(w, req)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+		})
+	}
+}
+
+// TestServiceHandleGetDeleted tests that deleted services are only found with the includeDeleted parameter
+func TestServiceHandleGetDeleted(t *testing.T) {
+	id := properties.NewUUID()
+	deletedAt := time.Now()
+	service := &domain.Service{BaseEntity: domain.BaseEntity{ID: id}, Name: "Test Service", Status: "Deleted", DeletedAt: &deletedAt}
+
+	testCases := []struct {
+		name           string
+		query          string
+		expectedStatus int
+	}{
+		{name: "Excluded by default", expectedStatus: http.StatusNotFound},
+		{name: "Included", query: "?includeDeleted=true", expectedStatus: http.StatusOK},
+		{name: "Invalid parameter", query: "?includeDeleted=maybe", expectedStatus: http.StatusBadRequest},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			querier := domain.NewMockServiceQuerier(t)
+			if tc.expectedStatus != http.StatusBadRequest {
+				querier.EXPECT().Get(mock.Anything, id).Return(service, nil)
+			}
+			handler := NewServiceHandler(querier, nil, nil, nil, nil, nil, authz.NewRuleBasedAuthorizer(authz.Rules))
+
+			req := httptest.NewRequest("GET", "/services/"+id.String()+tc.query, nil)
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("id", id.String())
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+			req = req.WithContext(auth.WithIdentity(req.Context(), newMockAuthAdmin()))
+
+			w := httptest.NewRecorder()
+			middlewares.ID(http.HandlerFunc(handler.Get)).ServeHTTP(w, req)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			if tc.expectedStatus != http.StatusOK {
+				return
+			}
+			var res ServiceRes
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+			assert.NotNil(t, res.DeletedAt)
+		})
+	}
+}
+
+// TestServiceHandleClone tests the Clone method
+func TestServiceHandleClone(t *testing.T) {
+	id := uuid.MustParse("550e8400-e29b-41d4-a716-446655440000")
+	cloneID := uuid.MustParse("550e8400-e29b-41d4-a716-446655440001")
+
+	testCases := []struct {
+		name           string
+		mockSetup      func(commander *domain.MockServiceCommander)
+		expectedStatus int
+	}{
+		{
+			name: "Success",
+			mockSetup: func(commander *domain.MockServiceCommander) {
+				commander.EXPECT().Clone(mock.Anything, id, "copy").
+					Return(&domain.Service{BaseEntity: domain.BaseEntity{ID: cloneID}, Name: "copy", Status: "New"}, nil)
+			},
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name: "SourceNotFound",
+			mockSetup: func(commander *domain.MockServiceCommander) {
+				commander.EXPECT().Clone(mock.Anything, id, "copy").
+					Return(nil, domain.NewNotFoundErrorf("service not found"))
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			commander := domain.NewMockServiceCommander(t)
+			tc.mockSetup(commander)
+			handler := NewServiceHandler(nil, nil, nil, nil, nil, commander, nil)
+
+			bodyBytes, err := json.Marshal(CloneServiceReq{Name: "copy"})
+			require.NoError(t, err)
+			req := httptest.NewRequest("POST", "/services/"+id.String()+"/clone", bytes.NewReader(bodyBytes))
+			req.Header.Set("Content-Type", "application/json")
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("id", id.String())
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+			req = req.WithContext(auth.WithIdentity(req.Context(), newMockAuthAdmin()))
+
+			w := httptest.NewRecorder()
+			middlewares.ID(middlewares.DecodeBody[CloneServiceReq]()(http.HandlerFunc(handler.Clone))).ServeHTTP(w, req)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			if tc.expectedStatus == http.StatusCreated {
+				var response map[string]any
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+				assert.Equal(t, cloneID.String(), response["id"])
+				assert.Equal(t, "copy", response["name"])
+			}
+		})
+	}
+}
+
+// TestServiceHandleSetLabels tests the label changes, a null value removes the label
+func TestServiceHandleSetLabels(t *testing.T) {
+	id := uuid.MustParse("550e8400-e29b-41d4-a716-446655440000")
+	prod := "prod"
+	changes := map[string]*string{"env": &prod, "tier": nil}
+
+	testCases := []struct {
+		name           string
+		mockSetup      func(commander *domain.MockServiceCommander)
+		expectedStatus int
+	}{
+		{
+			name: "Success",
+			mockSetup: func(commander *domain.MockServiceCommander) {
+				commander.EXPECT().SetLabels(mock.Anything, id, changes).
+					Return(&domain.Service{BaseEntity: domain.BaseEntity{ID: id}, Status: "Started", Labels: map[string]string{"env": "prod"}}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "InvalidLabel",
+			mockSetup: func(commander *domain.MockServiceCommander) {
+				commander.EXPECT().SetLabels(mock.Anything, id, changes).
+					Return(nil, domain.NewInvalidInputErrorf("invalid label key"))
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			commander := domain.NewMockServiceCommander(t)
+			tc.mockSetup(commander)
+			handler := NewServiceHandler(nil, nil, nil, nil, nil, commander, nil)
+
+			req := httptest.NewRequest("PATCH", "/services/"+id.String()+"/labels", strings.NewReader(`{"labels":{"env":"prod","tier":null}}`))
+			req.Header.Set("Content-Type", "application/json")
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("id", id.String())
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+			req = req.WithContext(auth.WithIdentity(req.Context(), newMockAuthAdmin()))
+
+			w := httptest.NewRecorder()
+			middlewares.ID(middlewares.DecodeBody[SetServiceLabelsReq]()(Update(handler.SetLabels, ServiceToRes))).ServeHTTP(w, req)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			if tc.expectedStatus == http.StatusOK {
+				var response map[string]any
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+				assert.Equal(t, map[string]any{"env": "prod"}, response["labels"])
+			}
+		})
+	}
+}
+
+// TestServiceHandleSetDependencies tests the SetDependencies method
+func TestServiceHandleSetDependencies(t *testing.T) {
+	id := uuid.MustParse("550e8400-e29b-41d4-a716-446655440000")
+	dbID := uuid.MustParse("660e8400-e29b-41d4-a716-446655440000")
+
+	testCases := []struct {
+		name           string
+		mockSetup      func(commander *domain.MockServiceCommander)
+		expectedStatus int
+	}{
+		{
+			name: "Success",
+			mockSetup: func(commander *domain.MockServiceCommander) {
+				commander.EXPECT().SetDependencies(mock.Anything, id, []properties.UUID{dbID}).
+					Return(&domain.Service{BaseEntity: domain.BaseEntity{ID: id}, Status: "Stopped", DependsOn: []properties.UUID{dbID}}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "Cycle",
+			mockSetup: func(commander *domain.MockServiceCommander) {
+				commander.EXPECT().SetDependencies(mock.Anything, id, []properties.UUID{dbID}).
+					Return(nil, domain.NewInvalidInputErrorf("service dependencies form a cycle between app, db"))
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			commander := domain.NewMockServiceCommander(t)
+			tc.mockSetup(commander)
+			handler := NewServiceHandler(nil, nil, nil, nil, nil, commander, nil)
+
+			req := httptest.NewRequest("PUT", "/services/"+id.String()+"/dependencies", strings.NewReader(`{"dependsOn":["`+dbID.String()+`"]}`))
+			req.Header.Set("Content-Type", "application/json")
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("id", id.String())
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+			req = req.WithContext(auth.WithIdentity(req.Context(), newMockAuthAdmin()))
+
+			w := httptest.NewRecorder()
+			middlewares.ID(middlewares.DecodeBody[SetServiceDependenciesReq]()(Update(handler.SetDependencies, ServiceToRes))).ServeHTTP(w, req)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			if tc.expectedStatus == http.StatusOK {
+				var response map[string]any
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+				assert.Equal(t, []any{dbID.String()}, response["dependsOn"])
+			}
+		})
+	}
+}
+
+// TestServiceHandleSetMaintenance tests the SetMaintenance method
+func TestServiceHandleSetMaintenance(t *testing.T) {
+	id := uuid.MustParse("550e8400-e29b-41d4-a716-446655440000")
+
+	testCases := []struct {
+		name           string
+		mockSetup      func(commander *domain.MockServiceCommander)
+		expectedStatus int
+	}{
+		{
+			name: "Success",
+			mockSetup: func(commander *domain.MockServiceCommander) {
+				commander.EXPECT().SetMaintenance(mock.Anything, id, true).
+					Return(&domain.Service{BaseEntity: domain.BaseEntity{ID: id}, Status: "Started", Maintenance: true}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "NotFound",
+			mockSetup: func(commander *domain.MockServiceCommander) {
+				commander.EXPECT().SetMaintenance(mock.Anything, id, true).
+					Return(nil, domain.NewNotFoundErrorf("service not found"))
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			commander := domain.NewMockServiceCommander(t)
+			tc.mockSetup(commander)
+			handler := NewServiceHandler(nil, nil, nil, nil, nil, commander, nil)
+
+			req := httptest.NewRequest("POST", "/services/"+id.String()+"/maintenance", strings.NewReader(`{"maintenance":true}`))
+			req.Header.Set("Content-Type", "application/json")
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("id", id.String())
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+			req = req.WithContext(auth.WithIdentity(req.Context(), newMockAuthAdmin()))
+
+			w := httptest.NewRecorder()
+			middlewares.ID(middlewares.DecodeBody[SetServiceMaintenanceReq]()(Update(handler.SetMaintenance, ServiceToRes))).ServeHTTP(w, req)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			if tc.expectedStatus == http.StatusOK {
+				var response map[string]any
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+				assert.Equal(t, true, response["maintenance"])
+			}
+		})
+	}
+}
+
+// TestServiceHandleReconcile tests the Reconcile method
+func TestServiceHandleReconcile(t *testing.T) {
+	id := uuid.MustParse("550e8400-e29b-41d4-a716-446655440000")
+	props := properties.JSON{"ipAddress": "10.0.0.9"}
+
+	testCases := []struct {
+		name           string
+		mockSetup      func(commander *domain.MockServiceCommander)
+		expectedStatus int
+	}{
+		{
+			name: "Success",
+			mockSetup: func(commander *domain.MockServiceCommander) {
+				commander.EXPECT().Reconcile(mock.Anything, id, props).
+					Return(&domain.Service{BaseEntity: domain.BaseEntity{ID: id}, Status: "Started", Properties: &props}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "UserProperty",
+			mockSetup: func(commander *domain.MockServiceCommander) {
+				commander.EXPECT().Reconcile(mock.Anything, id, props).
+					Return(nil, domain.InvalidInputError{Err: schema.NewValidationError([]schema.ValidationErrorDetail{
+						{Path: "ipAddress", Message: "property is provided by users and cannot be reconciled"},
+					})})
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			commander := domain.NewMockServiceCommander(t)
+			tc.mockSetup(commander)
+			handler := NewServiceHandler(nil, nil, nil, nil, nil, commander, nil)
+
+			req := httptest.NewRequest("PATCH", "/services/"+id.String()+"/reconcile", strings.NewReader(`{"properties":{"ipAddress":"10.0.0.9"}}`))
+			req.Header.Set("Content-Type", "application/json")
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("id", id.String())
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+			req = req.WithContext(auth.WithIdentity(req.Context(), newMockAuthAgent()))
+
+			w := httptest.NewRecorder()
+			middlewares.ID(middlewares.DecodeBody[ReconcileServiceReq]()(Update(handler.Reconcile, ServiceToRes))).ServeHTTP(w, req)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			var response map[string]any
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			if tc.expectedStatus == http.StatusOK {
+				assert.Equal(t, map[string]any{"ipAddress": "10.0.0.9"}, response["properties"])
+			} else {
+				assert.Equal(t, "ipAddress", response["errors"].([]any)[0].(map[string]any)["path"])
+			}
+		})
+	}
+}
+
+// TestServiceHandleReportOrphaned tests the ReportOrphaned method
+func TestServiceHandleReportOrphaned(t *testing.T) {
+	id := uuid.MustParse("550e8400-e29b-41d4-a716-446655440000")
+	orphanedAt := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	testCases := []struct {
+		name           string
+		body           string
+		mockSetup      func(commander *domain.MockServiceCommander)
+		expectedStatus int
+	}{
+		{
+			name: "Success",
+			body: `{"reason":"vm not found"}`,
+			mockSetup: func(commander *domain.MockServiceCommander) {
+				commander.EXPECT().ReportOrphaned(mock.Anything, id, "vm not found").
+					Return(&domain.Service{BaseEntity: domain.BaseEntity{ID: id}, Status: "Started", Orphaned: true, OrphanedAt: &orphanedAt}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "DeletedService",
+			body: `{}`,
+			mockSetup: func(commander *domain.MockServiceCommander) {
+				commander.EXPECT().ReportOrphaned(mock.Anything, id, "").
+					Return(nil, domain.NewInvalidInputErrorf("service %s is deleted", id))
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			commander := domain.NewMockServiceCommander(t)
+			tc.mockSetup(commander)
+			handler := NewServiceHandler(nil, nil, nil, nil, nil, commander, nil)
+
+			req := httptest.NewRequest("POST", "/services/"+id.String()+"/orphaned", strings.NewReader(tc.body))
+			req.Header.Set("Content-Type", "application/json")
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("id", id.String())
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+			req = req.WithContext(auth.WithIdentity(req.Context(), newMockAuthAgent()))
+
+			w := httptest.NewRecorder()
+			middlewares.ID(middlewares.DecodeBody[ReportServiceOrphanedReq]()(Update(handler.ReportOrphaned, ServiceToRes))).ServeHTTP(w, req)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			if tc.expectedStatus == http.StatusOK {
+				var response map[string]any
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+				assert.Equal(t, true, response["orphaned"])
+				assert.Equal(t, "2025-01-02T03:04:05Z", response["orphanedAt"])
+				assert.Equal(t, "Started", response["status"])
+			}
+		})
+	}
+}
+
+// TestServiceHandleBatchAction tests the BatchAction method
+func TestServiceHandleBatchAction(t *testing.T) {
+	svc1 := uuid.MustParse("550e8400-e29b-41d4-a716-446655440001")
+	svc2 := uuid.MustParse("550e8400-e29b-41d4-a716-446655440002")
+
+	testCases := []struct {
+		name           string
+		mockSetup      func(querier *domain.MockServiceQuerier, commander *domain.MockServiceCommander, authorizer *authz.MockAuthorizer)
+		expectedStatus int
+		checkBody      func(t *testing.T, body map[string]any)
+	}{
+		{
+			name: "Success",
+			mockSetup: func(querier *domain.MockServiceQuerier, commander *domain.MockServiceCommander, authorizer *authz.MockAuthorizer) {
+				querier.EXPECT().AuthScope(mock.Anything, mock.Anything).Return(&authz.DefaultObjectScope{}, nil).Times(2)
+				authorizer.EXPECT().Authorize(mock.Anything, authz.ActionUpdate, authz.ObjectTypeService, mock.Anything).Return(nil).Times(2)
+				commander.EXPECT().
+					BatchAction(mock.Anything, mock.MatchedBy(func(params domain.BatchServiceActionParams) bool {
+						return len(params.Items) == 2 && params.Items[0].ID == svc1 && params.Items[1].Action == "start"
+					})).
+					Return([]domain.BatchServiceActionResult{
+						{ID: svc1, Service: &domain.Service{Status: "Started"}},
+						{ID: svc2, Service: &domain.Service{Status: "Stopped"}},
+					}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			checkBody: func(t *testing.T, body map[string]any) {
+				items := body["items"].([]any)
+				require.Len(t, items, 2)
+				assert.Equal(t, svc1.String(), items[0].(map[string]any)["serviceId"])
+				assert.Equal(t, "Started", items[0].(map[string]any)["status"])
+				assert.Nil(t, items[0].(map[string]any)["error"])
+			},
+		},
+		{
+			name: "ItemRejected",
+			mockSetup: func(querier *domain.MockServiceQuerier, commander *domain.MockServiceCommander, authorizer *authz.MockAuthorizer) {
+				querier.EXPECT().AuthScope(mock.Anything, mock.Anything).Return(&authz.DefaultObjectScope{}, nil).Times(2)
+				authorizer.EXPECT().Authorize(mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil).Times(2)
+				commander.EXPECT().
+					BatchAction(mock.Anything, mock.Anything).
+					Return([]domain.BatchServiceActionResult{
+						{ID: svc1, Service: &domain.Service{Status: "Started"}},
+						{ID: svc2, Err: domain.NewInvalidInputErrorf("action not allowed")},
+					}, domain.NewInvalidInputErrorf("1 of 2 batch actions are not valid"))
+			},
+			expectedStatus: http.StatusBadRequest,
+			checkBody: func(t *testing.T, body map[string]any) {
+				items := body["items"].([]any)
+				require.Len(t, items, 2)
+				assert.Nil(t, items[0].(map[string]any)["error"])
+				itemErr := items[1].(map[string]any)["error"].(map[string]any)
+				assert.Contains(t, itemErr["error"], "action not allowed")
+			},
+		},
+		{
+			name: "DuplicateService",
+			mockSetup: func(querier *domain.MockServiceQuerier, commander *domain.MockServiceCommander, authorizer *authz.MockAuthorizer) {
+				querier.EXPECT().AuthScope(mock.Anything, mock.Anything).Return(&authz.DefaultObjectScope{}, nil).Times(2)
+				authorizer.EXPECT().Authorize(mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil).Times(2)
+				commander.EXPECT().
+					BatchAction(mock.Anything, mock.Anything).
+					Return(nil, domain.NewConflictErrorf("service appears more than once"))
+			},
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name: "Unauthorized",
+			mockSetup: func(querier *domain.MockServiceQuerier, commander *domain.MockServiceCommander, authorizer *authz.MockAuthorizer) {
+				querier.EXPECT().AuthScope(mock.Anything, svc1).Return(&authz.DefaultObjectScope{}, nil)
+				authorizer.EXPECT().Authorize(mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(fmt.Errorf("denied"))
+			},
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name: "ServiceNotFound",
+			mockSetup: func(querier *domain.MockServiceQuerier, commander *domain.MockServiceCommander, authorizer *authz.MockAuthorizer) {
+				querier.EXPECT().AuthScope(mock.Anything, svc1).Return(nil, domain.NewNotFoundErrorf("service not found"))
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			serviceQuerier := domain.NewMockServiceQuerier(t)
+			agentQuerier := domain.NewMockAgentQuerier(t)
+			serviceGroupQuerier := domain.NewMockServiceGroupQuerier(t)
+			commander := domain.NewMockServiceCommander(t)
+			authorizer := authz.NewMockAuthorizer(t)
+			tc.mockSetup(serviceQuerier, commander, authorizer)
+
+			handler := NewServiceHandler(serviceQuerier, agentQuerier, serviceGroupQuerier, nil, nil, commander, authorizer)
+
+			reqBody := BatchServiceActionReq{Items: []BatchServiceActionItemReq{
+				{ServiceID: svc1, Action: "stop"},
+				{ServiceID: svc2, Action: "start"},
+			}}
+			bodyBytes, err := json.Marshal(reqBody)
+			require.NoError(t, err)
+
+			req := httptest.NewRequest("POST", "/services/batch/transition", bytes.NewReader(bodyBytes))
+			req.Header.Set("Content-Type", "application/json")
+			req = req.WithContext(auth.WithIdentity(req.Context(), newMockAuthAdmin()))
+
+			w := httptest.NewRecorder()
+			middlewareHandler := middlewares.DecodeBody[BatchServiceActionReq]()(http.HandlerFunc(handler.BatchAction))
+			middlewareHandler.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			if tc.checkBody != nil {
+				var body map[string]any
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+				tc.checkBody(t, body)
+			}
 		})
 	}
 }
@@ -631,7 +2014,7 @@ func TestServicePropertyValidation(t *testing.T) {
 			tc.mockSetup(commander)
 
 			// Create the handler
-			handler := NewServiceHandler(serviceQuerier, agentQuerier, serviceGroupQuerier, commander, authz)
+			handler := NewServiceHandler(serviceQuerier, agentQuerier, serviceGroupQuerier, nil, nil, commander, authz)
 
 			var req *http.Request
 			var middlewareHandler http.Handler
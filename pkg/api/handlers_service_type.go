@@ -2,13 +2,18 @@ package api
 
 import (
 	"context"
+	"fmt"
+	"net/http"
+	"strconv"
 
+	"github.com/fulcrumproject/core/pkg/auth"
 	"github.com/fulcrumproject/core/pkg/authz"
 	"github.com/fulcrumproject/core/pkg/domain"
 	"github.com/fulcrumproject/core/pkg/middlewares"
 	"github.com/fulcrumproject/core/pkg/properties"
 	"github.com/fulcrumproject/core/pkg/schema"
 	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
 )
 
 type ServiceTypeHandler struct {
@@ -61,6 +66,22 @@ func (h *ServiceTypeHandler) Routes() func(r chi.Router) {
 				middlewares.AuthzFromID(authz.ObjectTypeServiceType, authz.ActionUpdate, h.authz, h.querier.AuthScope),
 			).Patch("/{id}", Update(h.Update, ServiceTypeToRes))
 
+			// Schema endpoint - authorize using service type's scope
+			r.With(
+				middlewares.AuthzFromID(authz.ObjectTypeServiceType, authz.ActionRead, h.authz, h.querier.AuthScope),
+			).Get("/{id}/schema", h.Schema)
+
+			// Migrate endpoint - admin only, dry run unless dryRun=false
+			r.With(
+				middlewares.AuthzFromID(authz.ObjectTypeServiceType, authz.ActionUpdate, h.authz, h.querier.AuthScope),
+			).Post("/{id}/migrate", h.Migrate)
+
+			// Clone endpoint - admin only
+			r.With(
+				middlewares.DecodeBody[CloneServiceTypeReq](),
+				middlewares.AuthzFromID(authz.ObjectTypeServiceType, authz.ActionCreate, h.authz, h.querier.AuthScope),
+			).Post("/{id}/clone", h.Clone)
+
 			// Delete endpoint - admin only
 			r.With(
 				middlewares.AuthzFromID(authz.ObjectTypeServiceType, authz.ActionDelete, h.authz, h.querier.AuthScope),
@@ -71,57 +92,294 @@ func (h *ServiceTypeHandler) Routes() func(r chi.Router) {
 
 // CreateServiceTypeReq represents the request body for creating service types
 type CreateServiceTypeReq struct {
-	Name            string                 `json:"name"`
-	PropertySchema  schema.Schema          `json:"propertySchema"`
-	LifecycleSchema domain.LifecycleSchema `json:"lifecycleSchema"`
+	Name                 string                 `json:"name"`
+	PropertySchema       schema.Schema          `json:"propertySchema"`
+	LifecycleSchema      domain.LifecycleSchema `json:"lifecycleSchema"`
+	RequiredCapabilities []string               `json:"requiredCapabilities,omitempty"`
+	OperationTimeout     *JSONDuration          `json:"operationTimeout,omitempty"`
+	// DefaultProperties are merged under the properties of the services created with the type
+	DefaultProperties properties.JSON `json:"defaultProperties,omitempty"`
+	// LabelSchema restricts the labels of the services of the type, omitted leaves them free-form
+	LabelSchema *schema.Schema `json:"labelSchema,omitempty"`
+	// PropertyCosts are the costs per unit of the numeric properties used by the cost estimates
+	PropertyCosts map[string]float64 `json:"propertyCosts,omitempty"`
 }
 
 // UpdateServiceTypeReq represents the request body for updating service types
 type UpdateServiceTypeReq struct {
-	Name            *string                 `json:"name"`
-	PropertySchema  *schema.Schema          `json:"propertySchema,omitempty"`
-	LifecycleSchema *domain.LifecycleSchema `json:"lifecycleSchema,omitempty"`
+	Name                 *string                 `json:"name"`
+	PropertySchema       *schema.Schema          `json:"propertySchema,omitempty"`
+	LifecycleSchema      *domain.LifecycleSchema `json:"lifecycleSchema,omitempty"`
+	RequiredCapabilities *[]string               `json:"requiredCapabilities,omitempty"`
+	// OperationTimeout replaces the default operation timeout, "0s" removes it
+	OperationTimeout *JSONDuration `json:"operationTimeout,omitempty"`
+	// DefaultProperties replaces the default properties, {} removes them
+	DefaultProperties *properties.JSON `json:"defaultProperties,omitempty"`
+	// LabelSchema replaces the label schema, a schema without properties removes it
+	LabelSchema *schema.Schema `json:"labelSchema,omitempty"`
+	// PropertyCosts replaces the property costs, {} removes them
+	PropertyCosts *map[string]float64 `json:"propertyCosts,omitempty"`
+}
+
+// CloneServiceTypeReq represents the request body for cloning a service type
+type CloneServiceTypeReq struct {
+	Name string `json:"name"`
 }
 
 // ServiceTypeRes represents the response body for service type operations
 type ServiceTypeRes struct {
-	ID              properties.UUID        `json:"id"`
-	Name            string                 `json:"name"`
-	PropertySchema  schema.Schema          `json:"propertySchema"`
-	LifecycleSchema domain.LifecycleSchema `json:"lifecycleSchema"`
-	CreatedAt       JSONUTCTime            `json:"createdAt"`
-	UpdatedAt       JSONUTCTime            `json:"updatedAt"`
+	ID                   properties.UUID        `json:"id"`
+	Name                 string                 `json:"name"`
+	PropertySchema       schema.Schema          `json:"propertySchema"`
+	LifecycleSchema      domain.LifecycleSchema `json:"lifecycleSchema"`
+	SchemaVersion        int                    `json:"schemaVersion"`
+	RequiredCapabilities []string               `json:"requiredCapabilities"`
+	OperationTimeout     *JSONDuration          `json:"operationTimeout,omitempty"`
+	DefaultProperties    properties.JSON        `json:"defaultProperties,omitempty"`
+	LabelSchema          *schema.Schema         `json:"labelSchema,omitempty"`
+	PropertyCosts        map[string]float64     `json:"propertyCosts,omitempty"`
+	CreatedAt            JSONUTCTime            `json:"createdAt"`
+	UpdatedAt            JSONUTCTime            `json:"updatedAt"`
 }
 
 // ServiceTypeToRes converts a domain.ServiceType to a ServiceTypeResponse
 func ServiceTypeToRes(st *domain.ServiceType) *ServiceTypeRes {
 	return &ServiceTypeRes{
-		ID:              st.ID,
-		Name:            st.Name,
-		PropertySchema:  st.PropertySchema,
-		LifecycleSchema: st.LifecycleSchema,
-		CreatedAt:       JSONUTCTime(st.CreatedAt),
-		UpdatedAt:       JSONUTCTime(st.UpdatedAt),
+		ID:                   st.ID,
+		Name:                 st.Name,
+		PropertySchema:       st.PropertySchema,
+		LifecycleSchema:      st.LifecycleSchema,
+		SchemaVersion:        st.SchemaVersion,
+		RequiredCapabilities: []string(st.RequiredCapabilities),
+		OperationTimeout:     durationToJSON(st.OperationTimeout),
+		DefaultProperties:    st.DefaultProperties,
+		LabelSchema:          st.LabelSchema,
+		PropertyCosts:        st.PropertyCosts,
+		CreatedAt:            JSONUTCTime(st.CreatedAt),
+		UpdatedAt:            JSONUTCTime(st.UpdatedAt),
+	}
+}
+
+// ServiceMigrationRes represents the response body of a service type migration
+type ServiceMigrationRes struct {
+	ServiceTypeID properties.UUID           `json:"serviceTypeId"`
+	SchemaVersion int                       `json:"schemaVersion"`
+	DryRun        bool                      `json:"dryRun"`
+	Checked       int                       `json:"checked"`
+	Migrated      int                       `json:"migrated"`
+	Failures      []ServiceMigrationFailRes `json:"failures"`
+}
+
+// ServiceMigrationFailRes represents a service that does not conform to the property schema
+type ServiceMigrationFailRes struct {
+	ServiceID     properties.UUID                `json:"serviceId"`
+	Name          string                         `json:"name"`
+	SchemaVersion int                            `json:"schemaVersion"`
+	Errors        []schema.ValidationErrorDetail `json:"errors"`
+}
+
+// ServiceMigrationToRes converts a domain.ServiceMigrationReport to a ServiceMigrationRes
+func ServiceMigrationToRes(report *domain.ServiceMigrationReport) *ServiceMigrationRes {
+	failures := make([]ServiceMigrationFailRes, len(report.Failures))
+	for i, f := range report.Failures {
+		failures[i] = ServiceMigrationFailRes{
+			ServiceID:     f.ServiceID,
+			Name:          f.Name,
+			SchemaVersion: f.SchemaVersion,
+			Errors:        f.Errors,
+		}
+	}
+	return &ServiceMigrationRes{
+		ServiceTypeID: report.ServiceTypeID,
+		SchemaVersion: report.SchemaVersion,
+		DryRun:        report.DryRun,
+		Checked:       report.Checked,
+		Migrated:      report.Migrated,
+		Failures:      failures,
+	}
+}
+
+// ServicePropertySchemaRes represents the resolved property schema of a service type
+type ServicePropertySchemaRes struct {
+	ServiceTypeID properties.UUID                `json:"serviceTypeId"`
+	SchemaVersion int                            `json:"schemaVersion"`
+	State         string                         `json:"state,omitempty"`
+	Actor         domain.ActorType               `json:"actor"`
+	Properties    []ServicePropertyDocRes        `json:"properties"`
+	Validators    []schema.SchemaValidatorConfig `json:"validators"`
+}
+
+// ServicePropertyDocRes represents a property of the resolved property schema
+type ServicePropertyDocRes struct {
+	Name        string                   `json:"name,omitempty"`
+	Path        string                   `json:"path"`
+	Type        string                   `json:"type"`
+	Label       string                   `json:"label,omitempty"`
+	Required    bool                     `json:"required"`
+	RequiredIf  *schema.RequiredIfConfig `json:"requiredIf,omitempty"`
+	Default     any                      `json:"default,omitempty"`
+	UpdateMode  string                   `json:"updateMode"`
+	Secret      *schema.SecretConfig     `json:"secret,omitempty"`
+	Sensitive   bool                     `json:"sensitive"`
+	Generator   *schema.GeneratorConfig  `json:"generator,omitempty"`
+	Validators  []schema.ValidatorConfig `json:"validators"`
+	Source      []domain.ActorType       `json:"source"`
+	Updatable   bool                     `json:"updatable"`
+	UpdatableIn []string                 `json:"updatableIn"`
+	Editable    bool                     `json:"editable"`
+	Properties  []ServicePropertyDocRes  `json:"properties,omitempty"`
+	Items       *ServicePropertyDocRes   `json:"items,omitempty"`
+}
+
+// ServicePropertySchemaToRes converts a domain.ServicePropertySchemaDoc to a ServicePropertySchemaRes
+func ServicePropertySchemaToRes(doc *domain.ServicePropertySchemaDoc) *ServicePropertySchemaRes {
+	validators := doc.Validators
+	if validators == nil {
+		validators = []schema.SchemaValidatorConfig{}
 	}
+	return &ServicePropertySchemaRes{
+		ServiceTypeID: doc.ServiceType.ID,
+		SchemaVersion: doc.SchemaVersion,
+		State:         doc.State,
+		Actor:         doc.Actor,
+		Properties:    servicePropertyDocsToRes(doc.Properties),
+		Validators:    validators,
+	}
+}
+
+func servicePropertyDocsToRes(docs []domain.ServicePropertyDoc) []ServicePropertyDocRes {
+	res := make([]ServicePropertyDocRes, len(docs))
+	for i, doc := range docs {
+		res[i] = *servicePropertyDocToRes(doc)
+	}
+	return res
+}
+
+func servicePropertyDocToRes(doc domain.ServicePropertyDoc) *ServicePropertyDocRes {
+	def := doc.Definition
+	updateMode := def.UpdateMode
+	if updateMode == "" {
+		updateMode = schema.UpdateModeHot
+	}
+	validators := def.Validators
+	if validators == nil {
+		validators = []schema.ValidatorConfig{}
+	}
+	res := &ServicePropertyDocRes{
+		Name:        doc.Name,
+		Path:        doc.Path,
+		Type:        def.Type,
+		Label:       def.Label,
+		Required:    def.Required,
+		RequiredIf:  def.RequiredIf,
+		Default:     def.Default,
+		UpdateMode:  updateMode,
+		Secret:      def.Secret,
+		Sensitive:   def.Sensitive,
+		Generator:   def.Generator,
+		Validators:  validators,
+		Source:      doc.Source,
+		Updatable:   doc.Updatable,
+		UpdatableIn: doc.UpdatableIn,
+		Editable:    doc.Editable,
+	}
+	if len(doc.Properties) > 0 {
+		res.Properties = servicePropertyDocsToRes(doc.Properties)
+	}
+	if doc.Items != nil {
+		res.Items = servicePropertyDocToRes(*doc.Items)
+	}
+	return res
+}
+
+// Schema returns the resolved property schema of the service type for the caller,
+// the editability of the properties is computed for an update in the state query parameter,
+// or for the creation of a service when it is missing
+func (h *ServiceTypeHandler) Schema(w http.ResponseWriter, r *http.Request) {
+	id := middlewares.MustGetID(r.Context())
+	identity := auth.MustGetIdentity(r.Context())
+
+	serviceType, err := h.querier.Get(r.Context(), id)
+	if err != nil {
+		render.Render(w, r, ErrDomain(err))
+		return
+	}
+
+	doc, err := domain.NewServicePropertySchemaDoc(r.Context(), h.engine, serviceType, domain.ActorTypeFromAuthRole(identity.Role), r.URL.Query().Get("state"))
+	if err != nil {
+		render.Render(w, r, ErrDomain(err))
+		return
+	}
+
+	render.JSON(w, r, ServicePropertySchemaToRes(doc))
+}
+
+// Migrate re-validates the services of the type against its property schema
+// The migration is a dry run unless the dryRun query parameter is false
+func (h *ServiceTypeHandler) Migrate(w http.ResponseWriter, r *http.Request) {
+	id := middlewares.MustGetID(r.Context())
+
+	dryRun := true
+	if value := r.URL.Query().Get("dryRun"); value != "" {
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			render.Render(w, r, ErrInvalidRequest(fmt.Errorf("invalid dryRun parameter: %s", value)))
+			return
+		}
+		dryRun = parsed
+	}
+
+	report, err := h.commander.MigrateServices(r.Context(), id, dryRun)
+	if err != nil {
+		render.Render(w, r, ErrDomain(err))
+		return
+	}
+
+	render.JSON(w, r, ServiceMigrationToRes(report))
+}
+
+// Clone creates a new service type from a deep copy of an existing one
+func (h *ServiceTypeHandler) Clone(w http.ResponseWriter, r *http.Request) {
+	id := middlewares.MustGetID(r.Context())
+	body := middlewares.MustGetBody[CloneServiceTypeReq](r.Context())
+
+	serviceType, err := h.commander.CloneFrom(r.Context(), id, body.Name)
+	if err != nil {
+		render.Render(w, r, ErrDomain(err))
+		return
+	}
+
+	render.Status(r, http.StatusCreated)
+	render.JSON(w, r, ServiceTypeToRes(serviceType))
 }
 
 // Adapter functions that convert request structs to commander method calls
 
 func (h *ServiceTypeHandler) Create(ctx context.Context, req *CreateServiceTypeReq) (*domain.ServiceType, error) {
 	params := domain.CreateServiceTypeParams{
-		Name:            req.Name,
-		PropertySchema:  req.PropertySchema,
-		LifecycleSchema: req.LifecycleSchema,
+		Name:                 req.Name,
+		PropertySchema:       req.PropertySchema,
+		LifecycleSchema:      req.LifecycleSchema,
+		RequiredCapabilities: req.RequiredCapabilities,
+		OperationTimeout:     durationFromJSON(req.OperationTimeout),
+		DefaultProperties:    req.DefaultProperties,
+		LabelSchema:          req.LabelSchema,
+		PropertyCosts:        req.PropertyCosts,
 	}
 	return h.commander.Create(ctx, params)
 }
 
 func (h *ServiceTypeHandler) Update(ctx context.Context, id properties.UUID, req *UpdateServiceTypeReq) (*domain.ServiceType, error) {
 	params := domain.UpdateServiceTypeParams{
-		ID:              id,
-		Name:            req.Name,
-		PropertySchema:  req.PropertySchema,
-		LifecycleSchema: req.LifecycleSchema,
+		ID:                   id,
+		Name:                 req.Name,
+		PropertySchema:       req.PropertySchema,
+		LifecycleSchema:      req.LifecycleSchema,
+		RequiredCapabilities: req.RequiredCapabilities,
+		OperationTimeout:     durationFromJSON(req.OperationTimeout),
+		DefaultProperties:    req.DefaultProperties,
+		LabelSchema:          req.LabelSchema,
+		PropertyCosts:        req.PropertyCosts,
 	}
 	return h.commander.Update(ctx, params)
 }
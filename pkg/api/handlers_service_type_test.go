@@ -2,18 +2,26 @@ package api
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/fulcrumproject/core/pkg/auth"
 	"github.com/fulcrumproject/core/pkg/authz"
 	"github.com/fulcrumproject/core/pkg/domain"
+	"github.com/fulcrumproject/core/pkg/helpers"
+	"github.com/fulcrumproject/core/pkg/middlewares"
+	"github.com/fulcrumproject/core/pkg/properties"
+	"github.com/fulcrumproject/core/pkg/schema"
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 // TestNewServiceTypeHandler tests the constructor
@@ -59,6 +67,9 @@ func TestServiceTypeHandlerRoutes(t *testing.T) {
 		case method == "GET" && route == "/{id}":
 		case method == "PATCH" && route == "/{id}":
 		case method == "DELETE" && route == "/{id}":
+		case method == "GET" && route == "/{id}/schema":
+		case method == "POST" && route == "/{id}/migrate":
+		case method == "POST" && route == "/{id}/clone":
 		case method == "POST" && route == "/{id}/validate":
 		default:
 			return fmt.Errorf("unexpected route: %s %s", method, route)
@@ -83,7 +94,11 @@ func TestServiceTypeToResponse(t *testing.T) {
 			CreatedAt: createdAt,
 			UpdatedAt: updatedAt,
 		},
-		Name: "VM Instance",
+		Name:                 "VM Instance",
+		SchemaVersion:        2,
+		RequiredCapabilities: []string{"gpu", "!shared"},
+		OperationTimeout:     helpers.DurationPtr(45 * time.Minute),
+		DefaultProperties:    properties.JSON{"tier": "standard"},
 	}
 
 	response := ServiceTypeToRes(serviceType)
@@ -91,6 +106,11 @@ func TestServiceTypeToResponse(t *testing.T) {
 	// Verify all fields are correctly mapped
 	assert.Equal(t, serviceType.ID, response.ID)
 	assert.Equal(t, serviceType.Name, response.Name)
+	assert.Equal(t, 2, response.SchemaVersion)
+	assert.Equal(t, []string{"gpu", "!shared"}, response.RequiredCapabilities)
+	require.NotNil(t, response.OperationTimeout)
+	assert.Equal(t, JSONDuration(45*time.Minute), *response.OperationTimeout)
+	assert.Equal(t, properties.JSON{"tier": "standard"}, response.DefaultProperties)
 	assert.Equal(t, JSONUTCTime(serviceType.CreatedAt), response.CreatedAt)
 	assert.Equal(t, JSONUTCTime(serviceType.UpdatedAt), response.UpdatedAt)
 }
@@ -101,13 +121,14 @@ func TestServiceTypeHandlerCreate(t *testing.T) {
 	handler := &ServiceTypeHandler{commander: commander}
 
 	req := &CreateServiceTypeReq{
-		Name: "Test Service Type",
+		Name:              "Test Service Type",
+		DefaultProperties: properties.JSON{"tier": "standard"},
 	}
 
 	// Set up mock expectation
 	commander.EXPECT().
 		Create(mock.Anything, mock.MatchedBy(func(params domain.CreateServiceTypeParams) bool {
-			return params.Name == "Test Service Type"
+			return params.Name == "Test Service Type" && params.DefaultProperties["tier"] == "standard"
 		})).
 		Return(&domain.ServiceType{
 			BaseEntity: domain.BaseEntity{ID: uuid.New()},
@@ -132,14 +153,17 @@ func TestServiceTypeHandlerUpdate(t *testing.T) {
 
 	serviceTypeID := uuid.MustParse("550e8400-e29b-41d4-a716-446655440000")
 	name := "Updated Service Type"
+	timeout := JSONDuration(0)
 	req := &UpdateServiceTypeReq{
-		Name: &name,
+		Name:             &name,
+		OperationTimeout: &timeout,
 	}
 
 	// Set up mock expectation
 	commander.EXPECT().
 		Update(mock.Anything, mock.MatchedBy(func(params domain.UpdateServiceTypeParams) bool {
-			return params.ID == serviceTypeID && params.Name != nil && *params.Name == "Updated Service Type"
+			return params.ID == serviceTypeID && params.Name != nil && *params.Name == "Updated Service Type" &&
+				params.OperationTimeout != nil && *params.OperationTimeout == 0
 		})).
 		Return(&domain.ServiceType{
 			BaseEntity: domain.BaseEntity{ID: serviceTypeID},
@@ -156,3 +180,204 @@ func TestServiceTypeHandlerUpdate(t *testing.T) {
 	assert.NoError(t, err)
 	assert.NotNil(t, result)
 }
+
+// TestServiceTypeHandlerMigrate tests the migration endpoint
+func TestServiceTypeHandlerMigrate(t *testing.T) {
+	serviceTypeID := uuid.MustParse("550e8400-e29b-41d4-a716-446655440000")
+	serviceID := uuid.MustParse("660e8400-e29b-41d4-a716-446655440000")
+
+	tests := []struct {
+		name           string
+		query          string
+		expectedDryRun bool
+		expectedStatus int
+	}{
+		{name: "Dry run by default", query: "", expectedDryRun: true, expectedStatus: http.StatusOK},
+		{name: "Explicit dry run", query: "?dryRun=true", expectedDryRun: true, expectedStatus: http.StatusOK},
+		{name: "Apply", query: "?dryRun=false", expectedDryRun: false, expectedStatus: http.StatusOK},
+		{name: "Invalid dryRun", query: "?dryRun=maybe", expectedStatus: http.StatusBadRequest},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			commander := domain.NewMockServiceTypeCommander(t)
+			handler := &ServiceTypeHandler{commander: commander}
+			if tc.expectedStatus == http.StatusOK {
+				commander.EXPECT().MigrateServices(mock.Anything, serviceTypeID, tc.expectedDryRun).Return(&domain.ServiceMigrationReport{
+					ServiceTypeID: serviceTypeID,
+					SchemaVersion: 3,
+					DryRun:        tc.expectedDryRun,
+					Checked:       2,
+					Migrated:      1,
+					Failures: []domain.ServiceMigrationFailure{{
+						ServiceID:     serviceID,
+						Name:          "web",
+						SchemaVersion: 2,
+						Errors:        []schema.ValidationErrorDetail{{Path: "cpu", Message: "required property is missing"}},
+					}},
+				}, nil)
+			}
+
+			r := chi.NewRouter()
+			r.With(middlewares.ID).Post("/{id}/migrate", handler.Migrate)
+			req := httptest.NewRequest("POST", "/"+serviceTypeID.String()+"/migrate"+tc.query, nil)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			if tc.expectedStatus != http.StatusOK {
+				return
+			}
+			var res ServiceMigrationRes
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+			assert.Equal(t, tc.expectedDryRun, res.DryRun)
+			assert.Equal(t, 3, res.SchemaVersion)
+			assert.Equal(t, 2, res.Checked)
+			assert.Equal(t, 1, res.Migrated)
+			require.Len(t, res.Failures, 1)
+			assert.Equal(t, serviceID, uuid.UUID(res.Failures[0].ServiceID))
+			assert.Equal(t, "cpu", res.Failures[0].Errors[0].Path)
+		})
+	}
+}
+
+// TestServiceTypeHandlerClone tests the Clone method
+func TestServiceTypeHandlerClone(t *testing.T) {
+	sourceID := uuid.MustParse("550e8400-e29b-41d4-a716-446655440000")
+	cloneID := uuid.MustParse("550e8400-e29b-41d4-a716-446655440001")
+
+	tests := []struct {
+		name           string
+		mockSetup      func(commander *domain.MockServiceTypeCommander)
+		expectedStatus int
+	}{
+		{
+			name: "Success",
+			mockSetup: func(commander *domain.MockServiceTypeCommander) {
+				commander.EXPECT().CloneFrom(mock.Anything, sourceID, "VM v2").
+					Return(&domain.ServiceType{BaseEntity: domain.BaseEntity{ID: cloneID}, Name: "VM v2", SchemaVersion: 1}, nil)
+			},
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name: "Source not found",
+			mockSetup: func(commander *domain.MockServiceTypeCommander) {
+				commander.EXPECT().CloneFrom(mock.Anything, sourceID, "VM v2").
+					Return(nil, domain.NewNotFoundErrorf("service type"))
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			commander := domain.NewMockServiceTypeCommander(t)
+			tc.mockSetup(commander)
+			handler := &ServiceTypeHandler{commander: commander}
+
+			r := chi.NewRouter()
+			r.With(middlewares.ID, middlewares.DecodeBody[CloneServiceTypeReq]()).Post("/{id}/clone", handler.Clone)
+			req := httptest.NewRequest("POST", "/"+sourceID.String()+"/clone", strings.NewReader(`{"name":"VM v2"}`))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			if tc.expectedStatus != http.StatusCreated {
+				return
+			}
+			var res ServiceTypeRes
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+			assert.Equal(t, cloneID, uuid.UUID(res.ID))
+			assert.Equal(t, "VM v2", res.Name)
+			assert.Equal(t, 1, res.SchemaVersion)
+		})
+	}
+}
+
+// TestServiceTypeHandlerSchema tests the property schema documentation endpoint
+func TestServiceTypeHandlerSchema(t *testing.T) {
+	serviceTypeID := uuid.MustParse("550e8400-e29b-41d4-a716-446655440000")
+	serviceType := &domain.ServiceType{
+		BaseEntity: domain.BaseEntity{ID: serviceTypeID},
+		Name:       "vm",
+		PropertySchema: schema.Schema{
+			Properties: map[string]schema.PropertyDefinition{
+				"cpu": {
+					Type:     "integer",
+					Label:    "CPU",
+					Required: true,
+					Authorizers: []schema.AuthorizerConfig{
+						{Type: "state", Config: map[string]any{"allowedStates": []any{"Stopped"}}},
+					},
+				},
+				"ip": {
+					Type: "string",
+					Authorizers: []schema.AuthorizerConfig{
+						{Type: "actor", Config: map[string]any{"actors": []any{"agent"}}},
+					},
+				},
+			},
+		},
+		LifecycleSchema: domain.LifecycleSchema{
+			States:       []domain.LifecycleState{{Name: "New"}, {Name: "Started"}, {Name: "Stopped"}},
+			InitialState: "New",
+		},
+		SchemaVersion: 4,
+	}
+
+	tests := []struct {
+		name            string
+		query           string
+		expectedStatus  int
+		expectedCPUEdit bool
+	}{
+		{name: "Creation", query: "", expectedStatus: http.StatusOK, expectedCPUEdit: true},
+		{name: "Update in a locked state", query: "?state=Started", expectedStatus: http.StatusOK, expectedCPUEdit: false},
+		{name: "Update in an allowed state", query: "?state=Stopped", expectedStatus: http.StatusOK, expectedCPUEdit: true},
+		{name: "Unknown state", query: "?state=Running", expectedStatus: http.StatusBadRequest},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			querier := domain.NewMockServiceTypeQuerier(t)
+			querier.EXPECT().Get(mock.Anything, serviceTypeID).Return(serviceType, nil)
+			handler := &ServiceTypeHandler{querier: querier, engine: domain.NewServicePropertyEngine(nil)}
+
+			r := chi.NewRouter()
+			r.With(middlewares.ID).Get("/{id}/schema", handler.Schema)
+			req := httptest.NewRequest("GET", "/"+serviceTypeID.String()+"/schema"+tc.query, nil)
+			req = req.WithContext(auth.WithIdentity(req.Context(), newMockAuthAdmin()))
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			if tc.expectedStatus != http.StatusOK {
+				return
+			}
+			var res map[string]any
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+			assert.Equal(t, serviceTypeID.String(), res["serviceTypeId"])
+			assert.Equal(t, float64(4), res["schemaVersion"])
+			assert.Equal(t, "user", res["actor"])
+			assert.Equal(t, []any{}, res["validators"])
+
+			props := res["properties"].([]any)
+			require.Len(t, props, 2)
+			cpu := props[0].(map[string]any)
+			assert.Equal(t, "cpu", cpu["name"])
+			assert.Equal(t, "CPU", cpu["label"])
+			assert.Equal(t, true, cpu["required"])
+			assert.Equal(t, "hot", cpu["updateMode"])
+			assert.Nil(t, cpu["source"])
+			assert.Equal(t, true, cpu["updatable"])
+			assert.Equal(t, []any{"Stopped"}, cpu["updatableIn"])
+			assert.Equal(t, tc.expectedCPUEdit, cpu["editable"])
+
+			ip := props[1].(map[string]any)
+			assert.Equal(t, []any{"agent"}, ip["source"])
+			assert.Nil(t, ip["updatableIn"])
+			assert.Equal(t, false, ip["editable"])
+		})
+	}
+}
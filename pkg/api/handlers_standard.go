@@ -20,6 +20,11 @@ func List[T domain.Entity, R any](querier domain.BaseEntityQuerier[T], toResp fu
 			render.Render(w, r, ErrInvalidRequest(err))
 			return
 		}
+		fields, err := ParseFieldsRequest[R](r)
+		if err != nil {
+			render.Render(w, r, ErrInvalidRequest(err))
+			return
+		}
 
 		result, err := querier.List(r.Context(), &id.Scope, pag)
 		if err != nil {
@@ -27,7 +32,17 @@ func List[T domain.Entity, R any](querier domain.BaseEntityQuerier[T], toResp fu
 			return
 		}
 
-		render.JSON(w, r, NewPageResponse(result, toResp))
+		res := NewPageResponse(result, toResp)
+		if len(fields) == 0 {
+			render.JSON(w, r, res)
+			return
+		}
+		selected, err := SelectPageFields(res, fields)
+		if err != nil {
+			render.Render(w, r, ErrInternal(err))
+			return
+		}
+		render.JSON(w, r, selected)
 	}
 }
 
@@ -35,6 +50,11 @@ func List[T domain.Entity, R any](querier domain.BaseEntityQuerier[T], toResp fu
 func Get[T domain.Entity, R any](get func(ctx context.Context, id properties.UUID) (*T, error), toResp func(*T) *R) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		id := middlewares.MustGetID(r.Context())
+		fields, err := ParseFieldsRequest[R](r)
+		if err != nil {
+			render.Render(w, r, ErrInvalidRequest(err))
+			return
+		}
 
 		entity, err := get(r.Context(), id)
 		if err != nil {
@@ -42,7 +62,18 @@ func Get[T domain.Entity, R any](get func(ctx context.Context, id properties.UUI
 			return
 		}
 
-		render.JSON(w, r, toResp(entity))
+		setETag(w, entity)
+		res := toResp(entity)
+		if len(fields) == 0 {
+			render.JSON(w, r, res)
+			return
+		}
+		selected, err := SelectFields(res, fields)
+		if err != nil {
+			render.Render(w, r, ErrInternal(err))
+			return
+		}
+		render.JSON(w, r, selected)
 	}
 }
 
@@ -62,7 +93,7 @@ func Delete[T domain.Entity](querier domain.BaseEntityQuerier[T], deleteFunc fun
 		}
 
 		if err := deleteFunc(r.Context(), id); err != nil {
-			render.Render(w, r, ErrInternal(err))
+			render.Render(w, r, ErrDomain(err))
 			return
 		}
 
@@ -84,6 +115,7 @@ func Create[Req any, T domain.Entity, R any](
 			return
 		}
 
+		setETag(w, entity)
 		render.Status(r, http.StatusCreated)
 		render.JSON(w, r, toResp(entity))
 	}
@@ -97,13 +129,19 @@ func Update[Req any, T domain.Entity, R any](
 	return func(w http.ResponseWriter, r *http.Request) {
 		id := middlewares.MustGetID(r.Context())
 		req := middlewares.MustGetBody[Req](r.Context())
+		ctx, err := ifMatchContext(r, id)
+		if err != nil {
+			render.Render(w, r, ErrInvalidRequest(err))
+			return
+		}
 
-		entity, err := updateFunc(r.Context(), id, &req)
+		entity, err := updateFunc(ctx, id, &req)
 		if err != nil {
 			render.Render(w, r, ErrDomain(err))
 			return
 		}
 
+		setETag(w, entity)
 		render.JSON(w, r, toResp(entity))
 	}
 }
@@ -116,13 +154,19 @@ func Action[Req any, T domain.Entity, R any](
 	return func(w http.ResponseWriter, r *http.Request) {
 		id := middlewares.MustGetID(r.Context())
 		req := middlewares.MustGetBody[Req](r.Context())
+		ctx, err := ifMatchContext(r, id)
+		if err != nil {
+			render.Render(w, r, ErrInvalidRequest(err))
+			return
+		}
 
-		entity, err := actionFunc(r.Context(), id, &req)
+		entity, err := actionFunc(ctx, id, &req)
 		if err != nil {
 			render.Render(w, r, ErrDomain(err))
 			return
 		}
 
+		setETag(w, entity)
 		render.JSON(w, r, toResp(entity))
 	}
 }
@@ -134,13 +178,19 @@ func ActionWithoutBody[T domain.Entity, R any](
 ) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		id := middlewares.MustGetID(r.Context())
+		ctx, err := ifMatchContext(r, id)
+		if err != nil {
+			render.Render(w, r, ErrInvalidRequest(err))
+			return
+		}
 
-		entity, err := actionFunc(r.Context(), id)
+		entity, err := actionFunc(ctx, id)
 		if err != nil {
 			render.Render(w, r, ErrDomain(err))
 			return
 		}
 
+		setETag(w, entity)
 		render.JSON(w, r, toResp(entity))
 	}
 }
@@ -3,6 +3,7 @@ package api
 import (
 	"context"
 	"errors"
+	"fmt"
 	"net/http"
 	"time"
 
@@ -12,8 +13,12 @@ import (
 	"github.com/fulcrumproject/core/pkg/middlewares"
 	"github.com/fulcrumproject/core/pkg/properties"
 	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
 )
 
+// defaultTokenExpiringWithin is the window of the expiring tokens query when none is given
+const defaultTokenExpiringWithin = 7 * 24 * time.Hour
+
 // Request types
 
 // CreateTokenReq represents a request to create a new token
@@ -22,6 +27,8 @@ type CreateTokenReq struct {
 	Role     auth.Role        `json:"role"`
 	ScopeID  *properties.UUID `json:"scopeId,omitempty"`
 	ExpireAt *time.Time       `json:"expireAt,omitempty"` // Match the original field name in tests
+	// GroupIDs restricts a participant token to these service groups, empty allows all of them
+	GroupIDs []properties.UUID `json:"groupIds,omitempty"`
 }
 
 // UpdateTokenReq represents a request to update a token
@@ -61,8 +68,8 @@ func CreateTokenScopeExtractor(agentQuerier domain.AgentQuerier) middlewares.Obj
 			return nil, errors.New("invalid role")
 		}
 
-		// Wrap the scope with TokenCreationScope to include target role info
-		return authz.NewTokenCreationScope(body.Role, wrappedScope), nil
+		// Wrap the scope with TokenCreationScope to include target role and group info
+		return authz.NewTokenCreationScope(body.Role, wrappedScope, body.GroupIDs...), nil
 	}
 }
 
@@ -98,6 +105,11 @@ func (h *TokenHandler) Routes() func(r chi.Router) {
 			middlewares.AuthzSimple(authz.ObjectTypeToken, authz.ActionRead, h.authz),
 		).Get("/", List(h.querier, TokenToRes))
 
+		// Expiring - the tokens in the scope of the caller expiring within the window
+		r.With(
+			middlewares.AuthzSimple(authz.ObjectTypeToken, authz.ActionRead, h.authz),
+		).Get("/expiring", h.Expiring)
+
 		// Create - using standard Create handler
 		r.With(
 			middlewares.DecodeBody[CreateTokenReq](),
@@ -137,6 +149,28 @@ func (h *TokenHandler) Routes() func(r chi.Router) {
 	}
 }
 
+// Expiring handles the query of the tokens expiring within the window of the within parameter, 7 days by default
+// The tokens already expired are reported apart from the ones about to expire
+func (h *TokenHandler) Expiring(w http.ResponseWriter, r *http.Request) {
+	id := auth.MustGetIdentity(r.Context())
+	within := defaultTokenExpiringWithin
+	if value := r.URL.Query().Get("within"); value != "" {
+		var err error
+		if within, err = parseDayDuration(value); err != nil || within <= 0 {
+			render.Render(w, r, ErrInvalidRequest(fmt.Errorf("invalid within parameter %q: must be a positive duration such as 7d or 12h", value)))
+			return
+		}
+	}
+
+	now := time.Now()
+	tokens, err := h.querier.FindExpiring(r.Context(), &id.Scope, now.Add(within))
+	if err != nil {
+		render.Render(w, r, ErrDomain(err))
+		return
+	}
+	render.JSON(w, r, ExpiringTokensToRes(tokens, within, now))
+}
+
 // Adapter functions that convert request structs to commander method calls
 
 func (h *TokenHandler) Create(ctx context.Context, req *CreateTokenReq) (*domain.Token, error) {
@@ -145,6 +179,7 @@ func (h *TokenHandler) Create(ctx context.Context, req *CreateTokenReq) (*domain
 		Role:     req.Role,
 		ExpireAt: req.ExpireAt,
 		ScopeID:  req.ScopeID,
+		GroupIDs: req.GroupIDs,
 	}
 	return h.commander.Create(ctx, params)
 }
@@ -164,10 +199,12 @@ type TokenRes struct {
 	Name          string           `json:"name"`
 	Role          auth.Role        `json:"role"`
 	ExpireAt      JSONUTCTime      `json:"expireAt"`
+	LastUsedAt    *JSONUTCTime     `json:"lastUsedAt,omitempty"`
 	ParticipantID *properties.UUID `json:"participantId,omitempty"`
 	Participant   *ParticipantRes	 `json:"participant,omitempty"`
 	AgentID       *properties.UUID `json:"agentId,omitempty"`
 	Agent					*AgentRes				 `json:"agent,omitempty"`
+	GroupIDs      []properties.UUID `json:"groupIds,omitempty"`
 	CreatedAt     JSONUTCTime      `json:"createdAt"`
 	UpdatedAt     JSONUTCTime      `json:"updatedAt"`
 	Value         string           `json:"value,omitempty"`
@@ -180,8 +217,10 @@ func TokenToRes(t *domain.Token) *TokenRes {
 		Name:          t.Name,
 		Role:          t.Role,
 		ExpireAt:      JSONUTCTime(t.ExpireAt),
+		LastUsedAt:    (*JSONUTCTime)(t.LastUsedAt),
 		ParticipantID: t.ParticipantID,
 		AgentID:       t.AgentID,
+		GroupIDs:      t.GroupIDs,
 		CreatedAt:     JSONUTCTime(t.CreatedAt),
 		UpdatedAt:     JSONUTCTime(t.UpdatedAt),
 		Value:         t.PlainValue, // Only populated on create/regenerate
@@ -197,3 +236,27 @@ func TokenToRes(t *domain.Token) *TokenRes {
 
 	return res
 }
+
+// ExpiringTokensRes lists the tokens expiring within a window, the expired ones apart
+type ExpiringTokensRes struct {
+	Within   JSONDuration `json:"within"`
+	Expiring []*TokenRes  `json:"expiring"`
+	Expired  []*TokenRes  `json:"expired"`
+}
+
+// ExpiringTokensToRes splits the tokens between the expired and the expiring ones at now
+func ExpiringTokensToRes(tokens []*domain.Token, within time.Duration, now time.Time) *ExpiringTokensRes {
+	res := &ExpiringTokensRes{
+		Within:   JSONDuration(within),
+		Expiring: []*TokenRes{},
+		Expired:  []*TokenRes{},
+	}
+	for _, token := range tokens {
+		if token.ExpireAt.After(now) {
+			res.Expiring = append(res.Expiring, TokenToRes(token))
+		} else {
+			res.Expired = append(res.Expired, TokenToRes(token))
+		}
+	}
+	return res
+}
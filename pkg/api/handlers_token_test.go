@@ -1,8 +1,10 @@
 package api
 
 import (
+	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/http/httptest"
 	"testing"
 	"time"
 
@@ -12,6 +14,8 @@ import (
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 // TestNewTokenHandler tests the constructor
@@ -55,6 +59,7 @@ func TestTokenHandlerRoutes(t *testing.T) {
 		switch {
 		case method == "GET" && route == "/":
 		case method == "POST" && route == "/":
+		case method == "GET" && route == "/expiring":
 		case method == "GET" && route == "/{id}":
 		case method == "PATCH" && route == "/{id}":
 		case method == "DELETE" && route == "/{id}":
@@ -85,6 +90,7 @@ func TestTokenToResponse(t *testing.T) {
 		Name:          "Test Token",
 		Role:          auth.RoleParticipant,
 		ExpireAt:      now.Add(time.Hour),
+		LastUsedAt:    &now,
 		ParticipantID: &participantID,
 		AgentID:       &agentID,
 		HashedValue:   "hashed_value",
@@ -97,9 +103,54 @@ func TestTokenToResponse(t *testing.T) {
 	assert.Equal(t, token.Name, response.Name)
 	assert.Equal(t, token.Role, response.Role)
 	assert.Equal(t, JSONUTCTime(token.ExpireAt), response.ExpireAt)
+	assert.Equal(t, (*JSONUTCTime)(&now), response.LastUsedAt)
 	assert.Equal(t, &participantID, response.ParticipantID)
 	assert.Equal(t, &agentID, response.AgentID)
 	assert.Equal(t, JSONUTCTime(token.CreatedAt), response.CreatedAt)
 	assert.Equal(t, JSONUTCTime(token.UpdatedAt), response.UpdatedAt)
 	assert.Equal(t, "plain_value", response.Value)
 }
+
+// TestTokenHandleExpiring tests the expiring tokens in the scope of the caller
+func TestTokenHandleExpiring(t *testing.T) {
+	participantID := uuid.MustParse("660e8400-e29b-41d4-a716-446655440000")
+	identity := newMockAuthParticipant(participantID)
+
+	t.Run("splits the expired tokens from the expiring ones", func(t *testing.T) {
+		now := time.Now()
+		expiring := &domain.Token{BaseEntity: domain.BaseEntity{ID: uuid.New()}, Name: "ci", ExpireAt: now.Add(24 * time.Hour)}
+		expired := &domain.Token{BaseEntity: domain.BaseEntity{ID: uuid.New()}, Name: "old", ExpireAt: now.Add(-time.Hour)}
+		querier := domain.NewMockTokenQuerier(t)
+		querier.EXPECT().
+			FindExpiring(mock.Anything, &identity.Scope, mock.MatchedBy(func(before time.Time) bool {
+				return before.Sub(now) >= 3*24*time.Hour && before.Sub(now) < 3*24*time.Hour+time.Minute
+			})).
+			Return([]*domain.Token{expired, expiring}, nil)
+
+		handler := NewTokenHandler(querier, domain.NewMockTokenCommander(t), domain.NewMockAgentQuerier(t), authz.NewMockAuthorizer(t))
+		req := httptest.NewRequest("GET", "/tokens/expiring?within=3d", nil)
+		req = req.WithContext(auth.WithIdentity(req.Context(), identity))
+		w := httptest.NewRecorder()
+		handler.Expiring(w, req)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		var res ExpiringTokensRes
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+		assert.Equal(t, JSONDuration(72*time.Hour), res.Within)
+		require.Len(t, res.Expiring, 1)
+		assert.Equal(t, "ci", res.Expiring[0].Name)
+		require.Len(t, res.Expired, 1)
+		assert.Equal(t, "old", res.Expired[0].Name)
+	})
+
+	t.Run("invalid window", func(t *testing.T) {
+		for _, within := range []string{"soon", "0d", "-1h"} {
+			handler := NewTokenHandler(domain.NewMockTokenQuerier(t), domain.NewMockTokenCommander(t), domain.NewMockAgentQuerier(t), authz.NewMockAuthorizer(t))
+			req := httptest.NewRequest("GET", "/tokens/expiring?within="+within, nil)
+			req = req.WithContext(auth.WithIdentity(req.Context(), identity))
+			w := httptest.NewRecorder()
+			handler.Expiring(w, req)
+			assert.Equal(t, http.StatusBadRequest, w.Code, within)
+		}
+	})
+}
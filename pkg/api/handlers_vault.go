@@ -2,36 +2,54 @@
 package api
 
 import (
+	"errors"
 	"fmt"
 	"log/slog"
 	"net/http"
 
-	"github.com/fulcrumproject/core/pkg/auth"
+	"github.com/fulcrumproject/core/pkg/authz"
+	"github.com/fulcrumproject/core/pkg/domain"
 	"github.com/fulcrumproject/core/pkg/middlewares"
 	"github.com/fulcrumproject/core/pkg/schema"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/render"
 )
 
-// VaultHandler handles vault secret resolution endpoints
+// VaultHandler handles vault secret resolution and rotation endpoints
 type VaultHandler struct {
-	vault schema.Vault
+	vault     schema.Vault
+	commander domain.VaultSecretCommander
+	authz     authz.Authorizer
 }
 
 // NewVaultHandler creates a new vault handler
-func NewVaultHandler(vault schema.Vault) *VaultHandler {
+func NewVaultHandler(vault schema.Vault, commander domain.VaultSecretCommander, authz authz.Authorizer) *VaultHandler {
 	return &VaultHandler{
-		vault: vault,
+		vault:     vault,
+		commander: commander,
+		authz:     authz,
 	}
 }
 
 // Routes returns the router configuration function with all vault routes registered
 func (h *VaultHandler) Routes() func(r chi.Router) {
 	return func(r chi.Router) {
-		// Only agents can resolve secrets
-		r.Use(middlewares.MustHaveRoles(auth.RoleAgent))
+		// Resolve secrets - agents by default
+		r.With(
+			middlewares.AuthzSimple(authz.ObjectTypeVaultSecret, authz.ActionRead, h.authz),
+		).Get("/{reference}", h.GetSecret)
 
-		r.Get("/{reference}", h.GetSecret)
+		// Agents check the secrets presented to them, previous versions are accepted during the rotation grace period
+		r.With(
+			middlewares.AuthzSimple(authz.ObjectTypeVaultSecret, authz.ActionVerify, h.authz),
+			middlewares.DecodeBody[VerifySecretReq](),
+		).Post("/{reference}/verify", h.VerifySecret)
+
+		// Rotate secrets - admins by default
+		r.With(
+			middlewares.AuthzSimple(authz.ObjectTypeVaultSecret, authz.ActionRotate, h.authz),
+			middlewares.DecodeBody[RotateSecretReq](),
+		).Post("/{reference}/rotate", h.RotateSecret)
 	}
 }
 
@@ -54,7 +72,11 @@ func (h *VaultHandler) GetSecret(w http.ResponseWriter, r *http.Request) {
 	// Retrieve secret from vault
 	value, err := h.vault.Get(ctx, reference)
 	if err != nil {
-		slog.Error("Failed to retrieve secret", "reference", reference, "error", err)
+		slog.ErrorContext(ctx, "Failed to retrieve secret", "reference", reference, "error", err)
+		if errors.As(err, &domain.SecretBackendUnavailableError{}) {
+			render.Render(w, r, ErrServiceUnavailable(err))
+			return
+		}
 		render.Render(w, r, ErrNotFound())
 		return
 	}
@@ -64,3 +86,50 @@ func (h *VaultHandler) GetSecret(w http.ResponseWriter, r *http.Request) {
 	render.JSON(w, r, GetSecretRes{Value: value})
 }
 
+
+// RotateSecretReq represents the request to rotate a secret
+type RotateSecretReq struct {
+	Value any `json:"value"`
+}
+
+// RotateSecret replaces the value of a secret keeping the previous one valid during the grace period
+// Only accessible by admins
+func (h *VaultHandler) RotateSecret(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	reference := chi.URLParam(r, "reference")
+	req := middlewares.MustGetBody[RotateSecretReq](ctx)
+
+	if err := h.commander.Rotate(ctx, reference, req.Value); err != nil {
+		render.Render(w, r, ErrDomain(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// VerifySecretReq represents the request to verify a secret presented to an agent
+type VerifySecretReq struct {
+	Value any `json:"value"`
+}
+
+// VerifySecretRes represents the response of a secret verification
+type VerifySecretRes struct {
+	Valid bool `json:"valid"`
+}
+
+// VerifySecret checks a value against the current and non-expired previous versions of a secret
+// Only accessible by authenticated agents
+func (h *VaultHandler) VerifySecret(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	reference := chi.URLParam(r, "reference")
+	req := middlewares.MustGetBody[VerifySecretReq](ctx)
+
+	valid, err := h.commander.Verify(ctx, reference, req.Value)
+	if err != nil {
+		render.Render(w, r, ErrDomain(err))
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, VerifySecretRes{Valid: valid})
+}
@@ -4,11 +4,15 @@ package api
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/fulcrumproject/core/pkg/auth"
+	"github.com/fulcrumproject/core/pkg/authz"
+	"github.com/fulcrumproject/core/pkg/domain"
 	"github.com/fulcrumproject/core/pkg/properties"
 	"github.com/fulcrumproject/core/pkg/schema"
 	"github.com/go-chi/chi/v5"
@@ -20,7 +24,7 @@ import (
 func TestVaultHandler_GetSecret(t *testing.T) {
 	// Setup
 	mockVault := schema.NewMockVault(t)
-	handler := NewVaultHandler(mockVault)
+	handler := NewVaultHandler(mockVault, nil, nil)
 
 	agentID := properties.NewUUID()
 	agentIdentity := &auth.Identity{
@@ -70,6 +74,16 @@ func TestVaultHandler_GetSecret(t *testing.T) {
 			expectedStatus: http.StatusNotFound,
 			expectedValue:  nil,
 		},
+		{
+			name:      "secret backend unavailable",
+			reference: "abc123def456",
+			setupMock: func() {
+				mockVault.On("Get", mock.Anything, "abc123def456").
+					Return(nil, domain.NewSecretBackendUnavailableErrorf("vault is sealed")).Once()
+			},
+			expectedStatus: http.StatusServiceUnavailable,
+			expectedValue:  nil,
+		},
 	}
 
 	for _, tt := range tests {
@@ -93,6 +107,9 @@ func TestVaultHandler_GetSecret(t *testing.T) {
 
 			// Verify response
 			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedStatus == http.StatusServiceUnavailable {
+				assert.Equal(t, "5", w.Header().Get("Retry-After"))
+			}
 
 			if tt.expectedStatus == http.StatusOK {
 				var res GetSecretRes
@@ -110,7 +127,7 @@ func TestVaultHandler_GetSecret(t *testing.T) {
 func TestVaultHandler_GetSecret_EmptyReference(t *testing.T) {
 	// Setup
 	mockVault := schema.NewMockVault(t)
-	handler := NewVaultHandler(mockVault)
+	handler := NewVaultHandler(mockVault, nil, nil)
 
 	agentID := properties.NewUUID()
 	agentIdentity := &auth.Identity{
@@ -143,8 +160,152 @@ func TestVaultHandler_GetSecret_EmptyReference(t *testing.T) {
 
 func TestNewVaultHandler(t *testing.T) {
 	mockVault := schema.NewMockVault(t)
-	handler := NewVaultHandler(mockVault)
+	commander := domain.NewMockVaultSecretCommander(t)
+	handler := NewVaultHandler(mockVault, commander, nil)
 
 	assert.NotNil(t, handler)
 	assert.Equal(t, mockVault, handler.vault)
+	assert.Equal(t, commander, handler.commander)
+}
+
+func TestVaultHandler_Routes(t *testing.T) {
+	handler := NewVaultHandler(schema.NewMockVault(t), domain.NewMockVaultSecretCommander(t), nil)
+
+	r := chi.NewRouter()
+	handler.Routes()(r)
+
+	err := chi.Walk(r, func(method string, route string, handler http.Handler, middlewares ...func(http.Handler) http.Handler) error {
+		switch {
+		case method == "GET" && route == "/{reference}":
+		case method == "POST" && route == "/{reference}/verify":
+		case method == "POST" && route == "/{reference}/rotate":
+		default:
+			return fmt.Errorf("unexpected route: %s %s", method, route)
+		}
+		return nil
+	})
+	assert.NoError(t, err)
+}
+
+func TestVaultHandler_RotateSecret(t *testing.T) {
+	tests := []struct {
+		name           string
+		identity       *auth.Identity
+		body           string
+		setupMock      func(commander *domain.MockVaultSecretCommander)
+		expectedStatus int
+	}{
+		{
+			name:     "Success",
+			identity: newMockAuthAdmin(),
+			body:     `{"value":"new-password"}`,
+			setupMock: func(commander *domain.MockVaultSecretCommander) {
+				commander.EXPECT().Rotate(mock.Anything, "abc123", "new-password").Return(nil)
+			},
+			expectedStatus: http.StatusNoContent,
+		},
+		{
+			name:     "Not found",
+			identity: newMockAuthAdmin(),
+			body:     `{"value":"new-password"}`,
+			setupMock: func(commander *domain.MockVaultSecretCommander) {
+				commander.EXPECT().Rotate(mock.Anything, "abc123", "new-password").
+					Return(domain.NewNotFoundErrorf("secret not found: abc123"))
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:     "Backend unavailable",
+			identity: newMockAuthAdmin(),
+			body:     `{"value":"new-password"}`,
+			setupMock: func(commander *domain.MockVaultSecretCommander) {
+				commander.EXPECT().Rotate(mock.Anything, "abc123", "new-password").
+					Return(domain.NewSecretBackendUnavailableErrorf("vault is sealed"))
+			},
+			expectedStatus: http.StatusServiceUnavailable,
+		},
+		{
+			name:           "Agents cannot rotate",
+			identity:       newMockAuthAgent(),
+			body:           `{"value":"new-password"}`,
+			setupMock:      func(commander *domain.MockVaultSecretCommander) {},
+			expectedStatus: http.StatusForbidden,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			commander := domain.NewMockVaultSecretCommander(t)
+			tc.setupMock(commander)
+
+			r := chi.NewRouter()
+			NewVaultHandler(schema.NewMockVault(t), commander, authz.NewRuleBasedAuthorizer(authz.Rules)).Routes()(r)
+
+			req := httptest.NewRequest(http.MethodPost, "/abc123/rotate", strings.NewReader(tc.body))
+			req.Header.Set("Content-Type", "application/json")
+			req = req.WithContext(auth.WithIdentity(req.Context(), tc.identity))
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+		})
+	}
+}
+
+func TestVaultHandler_VerifySecret(t *testing.T) {
+	tests := []struct {
+		name           string
+		identity       *auth.Identity
+		setupMock      func(commander *domain.MockVaultSecretCommander)
+		expectedStatus int
+		expectedValid  bool
+	}{
+		{
+			name:     "Valid",
+			identity: newMockAuthAgent(),
+			setupMock: func(commander *domain.MockVaultSecretCommander) {
+				commander.EXPECT().Verify(mock.Anything, "abc123", "old-password").Return(true, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedValid:  true,
+		},
+		{
+			name:     "Invalid",
+			identity: newMockAuthAgent(),
+			setupMock: func(commander *domain.MockVaultSecretCommander) {
+				commander.EXPECT().Verify(mock.Anything, "abc123", "old-password").Return(false, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedValid:  false,
+		},
+		{
+			name:           "Admins cannot verify",
+			identity:       newMockAuthAdmin(),
+			setupMock:      func(commander *domain.MockVaultSecretCommander) {},
+			expectedStatus: http.StatusForbidden,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			commander := domain.NewMockVaultSecretCommander(t)
+			tc.setupMock(commander)
+
+			r := chi.NewRouter()
+			NewVaultHandler(schema.NewMockVault(t), commander, authz.NewRuleBasedAuthorizer(authz.Rules)).Routes()(r)
+
+			req := httptest.NewRequest(http.MethodPost, "/abc123/verify", strings.NewReader(`{"value":"old-password"}`))
+			req.Header.Set("Content-Type", "application/json")
+			req = req.WithContext(auth.WithIdentity(req.Context(), tc.identity))
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			if tc.expectedStatus == http.StatusOK {
+				var res VerifySecretRes
+				require.NoError(t, json.NewDecoder(w.Body).Decode(&res))
+				assert.Equal(t, tc.expectedValid, res.Valid)
+			}
+		})
+	}
 }
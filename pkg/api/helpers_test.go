@@ -45,3 +45,13 @@ func newMockAuthAgentWithID(agentID properties.UUID) *auth.Identity {
 	}
 }
 
+func newMockAuthParticipant(participantID properties.UUID) *auth.Identity {
+	return &auth.Identity{
+		ID:   uuid.MustParse("950e8400-e29b-41d4-a716-446655440000"),
+		Name: "test-participant",
+		Role: auth.RoleParticipant,
+		Scope: auth.IdentityScope{
+			ParticipantID: &participantID,
+		},
+	}
+}
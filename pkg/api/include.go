@@ -0,0 +1,43 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// paramInclude nests the listed related resources in the response
+const paramInclude = "include"
+
+// ParseIncludeRequest parses the comma separated include parameter and checks each relation against the allowed ones
+func ParseIncludeRequest(r *http.Request, allowed ...string) ([]string, error) {
+	var includes, unknown []string
+	for _, inc := range splitIncludes(r.URL.Query().Get(paramInclude)) {
+		if !slices.Contains(allowed, inc) {
+			unknown = append(unknown, inc)
+			continue
+		}
+		includes = append(includes, inc)
+	}
+	if len(unknown) > 0 {
+		return nil, fmt.Errorf("cannot include %s, includable relations are: %s", strings.Join(unknown, ", "), strings.Join(allowed, ", "))
+	}
+	return includes, nil
+}
+
+// splitIncludes splits the include parameter, dropping the blank and the repeated relations
+func splitIncludes(value string) []string {
+	if value == "" {
+		return nil
+	}
+	var includes []string
+	for _, inc := range strings.Split(value, ",") {
+		inc = strings.TrimSpace(inc)
+		if inc == "" || slices.Contains(includes, inc) {
+			continue
+		}
+		includes = append(includes, inc)
+	}
+	return includes
+}
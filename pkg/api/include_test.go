@@ -0,0 +1,36 @@
+package api
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseIncludeRequest(t *testing.T) {
+	tests := []struct {
+		name          string
+		query         string
+		expected      []string
+		expectedError string
+	}{
+		{name: "No parameter", query: ""},
+		{name: "Allowed relations", query: "?include=agent,group", expected: []string{"agent", "group"}},
+		{name: "Spaces and duplicates", query: "?include=agent,%20agent,,serviceType", expected: []string{"agent", "serviceType"}},
+		{name: "Relation not allowed", query: "?include=agent,provider", expectedError: "cannot include provider"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/test"+tc.query, nil)
+			includes, err := ParseIncludeRequest(req, "agent", "group", "serviceType")
+			if tc.expectedError != "" {
+				assert.ErrorContains(t, err, tc.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, includes)
+		})
+	}
+}
@@ -1,8 +1,10 @@
 package api
 
 import (
+	"context"
 	"fmt"
 	"net/http"
+	"slices"
 	"strconv"
 	"strings"
 
@@ -18,6 +20,8 @@ const (
 	paramPage     = "page"
 	paramPageSize = "pageSize"
 	paramSort     = "sort"
+	paramCursor   = "cursor"
+	paramCount    = "count"
 )
 
 // Reserved parameters that should not be included in filters
@@ -25,10 +29,93 @@ var reservedParams = map[string]bool{
 	paramPage:     true,
 	paramPageSize: true,
 	paramSort:     true,
+	paramCursor:   true,
+	paramCount:    true,
+	paramFields:   true,
+	paramInclude:  true,
 }
 
+// PageSize is the page size of the lists requested without one and the largest one that can be requested
+type PageSize struct {
+	Default int
+	Max     int
+}
+
+// DefaultPageSize applies to the lists whose routes have no page size
+var DefaultPageSize = PageSize{Default: defaultPageSize, Max: maxPageSize}
+
+// PageSizes holds the page sizes of the entities, the default one applies to the entities without their own
+type PageSizes struct {
+	Default  PageSize
+	Entities map[string]PageSize
+}
+
+// ParsePageSizes parses the per entity page sizes, given as entity=max or entity=max:default, of the known entities
+// An entity without its own default keeps the global one, lowered to its max when larger
+func ParsePageSizes(defaultSize PageSize, entries []string, entities []string) (*PageSizes, error) {
+	sizes := &PageSizes{Default: defaultSize, Entities: make(map[string]PageSize, len(entries))}
+	for _, entry := range entries {
+		entity, value, ok := strings.Cut(entry, "=")
+		entity = strings.TrimSpace(entity)
+		if !ok || entity == "" {
+			return nil, fmt.Errorf("invalid page size %q: expected entity=max or entity=max:default", entry)
+		}
+		if !slices.Contains(entities, entity) {
+			return nil, fmt.Errorf("invalid page size %q: unknown entity %s", entry, entity)
+		}
+		if _, exists := sizes.Entities[entity]; exists {
+			return nil, fmt.Errorf("duplicate page size of entity %s", entity)
+		}
+
+		maxValue, defaultValue, hasDefault := strings.Cut(value, ":")
+		size := PageSize{Default: defaultSize.Default}
+		var err error
+		if size.Max, err = strconv.Atoi(strings.TrimSpace(maxValue)); err != nil || size.Max < 1 {
+			return nil, fmt.Errorf("invalid page size %q: max must be a positive integer", entry)
+		}
+		if hasDefault {
+			if size.Default, err = strconv.Atoi(strings.TrimSpace(defaultValue)); err != nil || size.Default < 1 || size.Default > size.Max {
+				return nil, fmt.Errorf("invalid page size %q: default must be a positive integer not exceeding the max", entry)
+			}
+		}
+		size.Default = min(size.Default, size.Max)
+		sizes.Entities[entity] = size
+	}
+	return sizes, nil
+}
+
+// For returns the page size of an entity
+func (s *PageSizes) For(entity string) PageSize {
+	if size, ok := s.Entities[entity]; ok {
+		return size
+	}
+	return s.Default
+}
+
+type pageSizeContextKey struct{}
+
+// WithPageSize sets the page size of the lists of the routes it is applied to
+func WithPageSize(size PageSize) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), pageSizeContextKey{}, size)))
+		})
+	}
+}
+
+// pageSizeFromContext returns the page size set on the route, DefaultPageSize when there is none
+func pageSizeFromContext(ctx context.Context) PageSize {
+	if size, ok := ctx.Value(pageSizeContextKey{}).(PageSize); ok {
+		return size
+	}
+	return DefaultPageSize
+}
+
+// ParsePageRequest parses the pagination, sort and filters of a list, the page size is checked against
+// the page size of the route
 func ParsePageRequest(r *http.Request) (*domain.PageReq, error) {
 	q := r.URL.Query()
+	size := pageSizeFromContext(r.Context())
 
 	// Pagination - strict validation
 	page := defaultPage
@@ -43,7 +130,7 @@ func ParsePageRequest(r *http.Request) (*domain.PageReq, error) {
 		page = parsedPage
 	}
 
-	pageSize := defaultPageSize
+	pageSize := size.Default
 	if pageSizeStr := q.Get(paramPageSize); pageSizeStr != "" {
 		parsedPageSize, err := strconv.Atoi(pageSizeStr)
 		if err != nil {
@@ -52,27 +139,64 @@ func ParsePageRequest(r *http.Request) (*domain.PageReq, error) {
 		if parsedPageSize < 1 {
 			return nil, fmt.Errorf("pageSize parameter must be greater than 0, got: %d", parsedPageSize)
 		}
-		if parsedPageSize > maxPageSize {
-			return nil, fmt.Errorf("pageSize parameter must not exceed %d, got: %d", maxPageSize, parsedPageSize)
+		if parsedPageSize > size.Max {
+			return nil, fmt.Errorf("pageSize parameter must not exceed %d, got: %d", size.Max, parsedPageSize)
 		}
 		pageSize = parsedPageSize
 	}
 
-	// Sort
+	// Sort - comma separated fields, each optionally prefixed with '+' or '-'
 	sort := q.Get(paramSort)
+	var sortFields []domain.SortField
+	if sort != "" {
+		seen := make(map[string]bool)
+		for _, part := range strings.Split(sort, ",") {
+			part = strings.TrimSpace(part)
+			field := domain.SortField{Field: part, Asc: true} // default to ascending if no prefix
+			if strings.HasPrefix(part, "+") {
+				field.Field = part[1:]
+			} else if strings.HasPrefix(part, "-") {
+				field.Field = part[1:]
+				field.Asc = false
+			}
+			if field.Field == "" {
+				return nil, fmt.Errorf("invalid sort parameter: %s", sort)
+			}
+			if seen[field.Field] {
+				return nil, fmt.Errorf("duplicate sort field: %s", field.Field)
+			}
+			seen[field.Field] = true
+			sortFields = append(sortFields, field)
+		}
+	}
 	var sortBy string
 	var sortAsc bool
-	if sort != "" {
-		if strings.HasPrefix(sort, "+") {
-			sortBy = sort[1:]
-			sortAsc = true
-		} else if strings.HasPrefix(sort, "-") {
-			sortBy = sort[1:]
-			sortAsc = false
-		} else {
-			sortBy = sort
-			sortAsc = true // default to ascending if no prefix
+	if len(sortFields) > 0 {
+		sortBy = sortFields[0].Field
+		sortAsc = sortFields[0].Asc
+	}
+
+	// Cursor - presence of the parameter switches to keyset pagination
+	var cursor *domain.PageCursor
+	if q.Has(paramCursor) {
+		if q.Has(paramPage) {
+			return nil, fmt.Errorf("cursor and page parameters cannot be combined")
 		}
+		parsedCursor, err := domain.ParsePageCursor(q.Get(paramCursor))
+		if err != nil {
+			return nil, fmt.Errorf("invalid cursor parameter: %s", q.Get(paramCursor))
+		}
+		cursor = parsedCursor
+	}
+
+	// Count - count=false skips the totals of the response
+	skipCount := false
+	if countStr := q.Get(paramCount); countStr != "" {
+		count, err := strconv.ParseBool(countStr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid count parameter: %s", countStr)
+		}
+		skipCount = !count
 	}
 
 	// Collect all non-reserved parameters as filters
@@ -85,19 +209,22 @@ func ParsePageRequest(r *http.Request) (*domain.PageReq, error) {
 
 	return &domain.PageReq{
 		Page: page, PageSize: pageSize,
-		Sort: sort != "", SortBy: sortBy, SortAsc: sortAsc,
-		Filters: filters,
+		Sort: len(sortFields) > 0, SortBy: sortBy, SortAsc: sortAsc, SortFields: sortFields,
+		Filters: filters, Cursor: cursor, SkipCount: skipCount,
+		Include: splitIncludes(q.Get(paramInclude)), // Checked against the relations of the entity by its handler
 	}, nil
 }
 
 // PageRes represents a generic paginated response
+// The totals are absent when the count was skipped
 type PageRes[T any] struct {
-	Items       []*T  `json:"items"`
-	TotalItems  int64 `json:"totalItems"`
-	TotalPages  int   `json:"totalPages"`
-	CurrentPage int   `json:"currentPage"`
-	HasNext     bool  `json:"hasNext"`
-	HasPrev     bool  `json:"hasPrev"`
+	Items       []*T   `json:"items"`
+	TotalItems  *int64 `json:"totalItems,omitempty"`
+	TotalPages  *int   `json:"totalPages,omitempty"`
+	CurrentPage int    `json:"currentPage"`
+	HasNext     bool   `json:"hasNext"`
+	HasPrev     bool   `json:"hasPrev"`
+	NextCursor  string `json:"nextCursor,omitempty"`
 }
 
 // NewPageResponse creates a new PaginatedResponse from a domain.PaginatedResult
@@ -107,12 +234,17 @@ func NewPageResponse[E any, R any](result *domain.PageRes[E], conv func(*E) *R)
 		items[i] = conv(&e)
 	}
 
-	return &PageRes[R]{
+	res := &PageRes[R]{
 		Items:       items,
-		TotalItems:  result.TotalItems,
-		TotalPages:  result.TotalPages,
 		CurrentPage: result.CurrentPage,
 		HasNext:     result.HasNext,
 		HasPrev:     result.HasPrev,
+		NextCursor:  result.NextCursor,
+	}
+	if !result.Uncounted {
+		totalItems, totalPages := result.TotalItems, result.TotalPages
+		res.TotalItems = &totalItems
+		res.TotalPages = &totalPages
 	}
+	return res
 }
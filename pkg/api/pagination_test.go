@@ -1,10 +1,14 @@
 package api
 
 import (
+	"encoding/json"
+	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/fulcrumproject/core/pkg/domain"
+	"github.com/fulcrumproject/core/pkg/properties"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -76,6 +80,16 @@ func TestParsePageRequest(t *testing.T) {
 			queryString:   "?pageSize=1001", // Max is 100 in the implementation
 			expectedError: true,             // Now returns error for oversized values
 		},
+		{
+			name:          "Invalid Cursor",
+			queryString:   "?cursor=invalid",
+			expectedError: true,
+		},
+		{
+			name:          "Cursor With Page",
+			queryString:   "?cursor=&page=2",
+			expectedError: true,
+		},
 	}
 
 	for _, tc := range tests {
@@ -94,6 +108,221 @@ func TestParsePageRequest(t *testing.T) {
 	}
 }
 
+func TestParsePageRequestReservedParams(t *testing.T) {
+	req := httptest.NewRequest("GET", "/test?name=web&fields=id,name&include=agent", nil)
+	pageReq, err := ParsePageRequest(req)
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]string{"name": {"web"}}, pageReq.Filters)
+	assert.Equal(t, []string{"agent"}, pageReq.Include)
+}
+
+func TestParsePageRequestInclude(t *testing.T) {
+	req := httptest.NewRequest("GET", "/test?include=agent,%20group,,agent", nil)
+	pageReq, err := ParsePageRequest(req)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"agent", "group"}, pageReq.Include)
+
+	pageReq, err = ParsePageRequest(httptest.NewRequest("GET", "/test", nil))
+	require.NoError(t, err)
+	assert.Nil(t, pageReq.Include)
+}
+
+func TestParsePageRequestSort(t *testing.T) {
+	tests := []struct {
+		name          string
+		queryString   string
+		expected      []domain.SortField
+		expectedError bool
+	}{
+		{
+			name:        "No sort",
+			queryString: "",
+			expected:    nil,
+		},
+		{
+			name:        "Single field defaults to ascending",
+			queryString: "?sort=name",
+			expected:    []domain.SortField{{Field: "name", Asc: true}},
+		},
+		{
+			name:        "Multiple fields",
+			queryString: "?sort=currentStatus,-updatedAt,%2Bname",
+			expected: []domain.SortField{
+				{Field: "currentStatus", Asc: true},
+				{Field: "updatedAt", Asc: false},
+				{Field: "name", Asc: true},
+			},
+		},
+		{
+			name:          "Empty field",
+			queryString:   "?sort=name,,-updatedAt",
+			expectedError: true,
+		},
+		{
+			name:          "Prefix without field",
+			queryString:   "?sort=-",
+			expectedError: true,
+		},
+		{
+			name:          "Duplicate field",
+			queryString:   "?sort=name,-name",
+			expectedError: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/test"+tc.queryString, nil)
+			pageReq, err := ParsePageRequest(req)
+
+			if tc.expectedError {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, pageReq.SortFields)
+			assert.Equal(t, tc.expected, pageReq.SortOrder())
+			if len(tc.expected) > 0 {
+				assert.Equal(t, tc.expected[0].Field, pageReq.SortBy)
+				assert.Equal(t, tc.expected[0].Asc, pageReq.SortAsc)
+			}
+		})
+	}
+}
+
+func TestParsePageRequestCursor(t *testing.T) {
+	t.Run("offset mode without cursor", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/test?page=2", nil)
+		pageReq, err := ParsePageRequest(req)
+		require.NoError(t, err)
+		assert.Nil(t, pageReq.Cursor)
+	})
+
+	t.Run("empty cursor starts from the first item", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/test?cursor=&pageSize=5", nil)
+		pageReq, err := ParsePageRequest(req)
+		require.NoError(t, err)
+		require.NotNil(t, pageReq.Cursor)
+		assert.True(t, pageReq.Cursor.IsStart())
+		assert.Equal(t, 5, pageReq.PageSize)
+		assert.NotContains(t, pageReq.Filters, "cursor")
+	})
+
+	t.Run("encoded cursor", func(t *testing.T) {
+		cursor := domain.PageCursor{CreatedAt: time.Date(2024, 1, 2, 3, 4, 5, 6000, time.UTC), ID: properties.NewUUID()}
+		req := httptest.NewRequest("GET", "/test?cursor="+cursor.Encode(), nil)
+		pageReq, err := ParsePageRequest(req)
+		require.NoError(t, err)
+		require.NotNil(t, pageReq.Cursor)
+		assert.Equal(t, cursor.ID, pageReq.Cursor.ID)
+		assert.True(t, cursor.CreatedAt.Equal(pageReq.Cursor.CreatedAt))
+	})
+}
+
+func TestParsePageRequestCount(t *testing.T) {
+	tests := []struct {
+		name          string
+		queryString   string
+		skipCount     bool
+		expectedError bool
+	}{
+		{name: "Counted by default", queryString: ""},
+		{name: "Count requested", queryString: "?count=true"},
+		{name: "Count skipped", queryString: "?count=false", skipCount: true},
+		{name: "Invalid value", queryString: "?count=maybe", expectedError: true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			pageReq, err := ParsePageRequest(httptest.NewRequest("GET", "/test"+tc.queryString, nil))
+			if tc.expectedError {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.skipCount, pageReq.SkipCount)
+			assert.NotContains(t, pageReq.Filters, "count")
+		})
+	}
+}
+
+func TestParsePageRequestEntityPageSize(t *testing.T) {
+	sizes, err := ParsePageSizes(DefaultPageSize, []string{"events=50:20", "services=500"}, []string{"events", "services", "jobs"})
+	require.NoError(t, err)
+
+	parse := func(entity, query string) (*domain.PageReq, error) {
+		var pageReq *domain.PageReq
+		var err error
+		handler := WithPageSize(sizes.For(entity))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			pageReq, err = ParsePageRequest(r)
+		}))
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/test"+query, nil))
+		return pageReq, err
+	}
+
+	t.Run("entity default", func(t *testing.T) {
+		pageReq, err := parse("events", "")
+		require.NoError(t, err)
+		assert.Equal(t, 20, pageReq.PageSize)
+	})
+
+	t.Run("entity max", func(t *testing.T) {
+		_, err := parse("events", "?pageSize=51")
+		assert.EqualError(t, err, "pageSize parameter must not exceed 50, got: 51")
+
+		pageReq, err := parse("services", "?pageSize=500")
+		require.NoError(t, err)
+		assert.Equal(t, 500, pageReq.PageSize)
+	})
+
+	t.Run("global default without entity page size", func(t *testing.T) {
+		pageReq, err := parse("jobs", "")
+		require.NoError(t, err)
+		assert.Equal(t, 10, pageReq.PageSize)
+
+		pageReq, err = parse("services", "")
+		require.NoError(t, err)
+		assert.Equal(t, 10, pageReq.PageSize, "the global default applies when the entity only sets its max")
+
+		_, err = parse("jobs", "?pageSize=101")
+		assert.EqualError(t, err, "pageSize parameter must not exceed 100, got: 101")
+	})
+}
+
+func TestParsePageSizes(t *testing.T) {
+	entities := []string{"events", "services"}
+	defaultSize := PageSize{Default: 25, Max: 100}
+
+	sizes, err := ParsePageSizes(defaultSize, []string{" events = 10 ", "services=200:50"}, entities)
+	require.NoError(t, err)
+	assert.Equal(t, PageSize{Default: 10, Max: 10}, sizes.For("events"), "the global default is lowered to the entity max")
+	assert.Equal(t, PageSize{Default: 50, Max: 200}, sizes.For("services"))
+	assert.Equal(t, defaultSize, sizes.For("jobs"))
+
+	for _, entries := range [][]string{
+		{"events"},
+		{"=10"},
+		{"unknown=10"},
+		{"events=0"},
+		{"events=abc"},
+		{"events=10:20"},
+		{"events=10:0"},
+		{"events=10", "events=20"},
+	} {
+		_, err := ParsePageSizes(defaultSize, entries, entities)
+		assert.Error(t, err, "entries %v", entries)
+	}
+}
+
+func TestNewPageResponseUncounted(t *testing.T) {
+	result := domain.NewUncountedPaginatedResult([]int{1, 2}, &domain.PageReq{Page: 2, PageSize: 2}, true)
+
+	res := NewPageResponse(result, func(i *int) *int { return i })
+
+	body, err := json.Marshal(res)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"items":[1,2],"currentPage":2,"hasNext":true,"hasPrev":true}`, string(body))
+}
+
 func TestNewPageResponse(t *testing.T) {
 	// Use a simple struct for testing
 	type TestItem struct {
@@ -157,7 +386,8 @@ func TestNewPageResponse(t *testing.T) {
 			for i, item := range apiResp.Items {
 				assert.Equal(t, tc.expectedItems[i], *item)
 			}
-			assert.Equal(t, tc.totalItems, apiResp.TotalItems)
+			require.NotNil(t, apiResp.TotalItems)
+			assert.Equal(t, tc.totalItems, *apiResp.TotalItems)
 			assert.Equal(t, tc.page, apiResp.CurrentPage)
 		})
 	}
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"log/slog"
 	"net/http"
+	"strconv"
 
 	"github.com/fulcrumproject/core/pkg/domain"
 	"github.com/fulcrumproject/core/pkg/schema"
@@ -14,23 +15,74 @@ import (
 type ErrRes struct {
 	Err            error `json:"-"` // low-level runtime error
 	HTTPStatusCode int   `json:"-"` // http response status code
+	domainErr      error // error of the commanders, logged on render with the request context
 
 	StatusText string `json:"status"`          // user-level status message
 	ErrorText  string `json:"error,omitempty"` // application-level error message
 }
 
-// ValidationErrRes represents a validation error response with detailed errors
+// PreconditionFailedErrRes represents a stale write response with the current version to refetch
+type PreconditionFailedErrRes struct {
+	ErrRes
+	CurrentVersion int `json:"currentVersion"`
+}
+
+// ServiceUnavailableErrRes represents a transient failure, the request can be retried after RetryAfter seconds
+type ServiceUnavailableErrRes struct {
+	ErrRes
+	RetryAfter int `json:"-"`
+}
+
+// unavailableRetryAfter is the delay in seconds suggested to clients when a dependency is unavailable
+const unavailableRetryAfter = 5
+
+// ValidationErrRes is the body of all the 400 responses, Errors details the invalid properties
+// and is empty when the request is invalid for a reason not related to a property
 type ValidationErrRes struct {
 	Err            error                          `json:"-"` // low-level runtime error
 	HTTPStatusCode int                            `json:"-"` // http response status code
 	StatusText     string                         `json:"status"`
+	ErrorText      string                         `json:"error,omitempty"`
 	Valid          bool                           `json:"valid"`
 	Errors         []schema.ValidationErrorDetail `json:"errors"`
+	domainErr      error
 }
 
+// ErrDomain maps an error of the commanders to its response, the error is logged when the response
+// is rendered so the log line carries the request ID
 func ErrDomain(err error) render.Renderer {
-	slog.Error("API domain error", "error", err)
-	if validationErr, ok := err.(schema.ValidationError); ok {
+	res := domainErrRes(err)
+	switch e := res.(type) {
+	case *ErrRes:
+		e.domainErr = err
+	case *PreconditionFailedErrRes:
+		e.domainErr = err
+	case *ServiceUnavailableErrRes:
+		e.domainErr = err
+	case *ValidationErrRes:
+		e.domainErr = err
+	}
+	return res
+}
+
+func domainErrRes(err error) render.Renderer {
+	// Checked before validation errors as pool allocations and secret storage
+	// failures surface wrapped in the validation error of the property
+	if errors.As(err, &domain.PoolExhaustedError{}) {
+		return ErrConflict(err)
+	}
+	if errors.As(err, &domain.AgentQuotaExceededError{}) {
+		return ErrConflict(err)
+	}
+	if errors.As(err, &domain.SecretBackendUnavailableError{}) {
+		return ErrServiceUnavailable(err)
+	}
+	if errors.As(err, &domain.MaintenanceError{}) {
+		return ErrLocked(err)
+	}
+	// Validation errors keep their details when the commanders wrap them
+	var validationErr schema.ValidationError
+	if errors.As(err, &validationErr) {
 		return ErrValidation(validationErr)
 	}
 	if errors.As(err, &domain.InvalidInputError{}) {
@@ -45,6 +97,10 @@ func ErrDomain(err error) render.Renderer {
 	if errors.As(err, &domain.ConflictError{}) {
 		return ErrConflict(err)
 	}
+	var preconditionErr domain.PreconditionFailedError
+	if errors.As(err, &preconditionErr) {
+		return ErrPreconditionFailed(preconditionErr)
+	}
 	return ErrInternal(err)
 }
 
@@ -57,12 +113,47 @@ func ErrConflict(err error) render.Renderer {
 	}
 }
 
-func ErrInvalidRequest(err error) render.Renderer {
+func ErrLocked(err error) render.Renderer {
 	return &ErrRes{
+		Err:            err,
+		HTTPStatusCode: http.StatusLocked,
+		StatusText:     "Locked",
+		ErrorText:      err.Error(),
+	}
+}
+
+func ErrServiceUnavailable(err error) render.Renderer {
+	return &ServiceUnavailableErrRes{
+		ErrRes: ErrRes{
+			Err:            err,
+			HTTPStatusCode: http.StatusServiceUnavailable,
+			StatusText:     "Service unavailable",
+			ErrorText:      err.Error(),
+		},
+		RetryAfter: unavailableRetryAfter,
+	}
+}
+
+func ErrPreconditionFailed(err domain.PreconditionFailedError) render.Renderer {
+	return &PreconditionFailedErrRes{
+		ErrRes: ErrRes{
+			Err:            err,
+			HTTPStatusCode: http.StatusPreconditionFailed,
+			StatusText:     "Precondition failed",
+			ErrorText:      err.Error(),
+		},
+		CurrentVersion: err.CurrentVersion,
+	}
+}
+
+func ErrInvalidRequest(err error) render.Renderer {
+	return &ValidationErrRes{
 		Err:            err,
 		HTTPStatusCode: http.StatusBadRequest,
 		StatusText:     "Invalid request",
 		ErrorText:      err.Error(),
+		Valid:          false,
+		Errors:         []schema.ValidationErrorDetail{},
 	}
 }
 
@@ -103,17 +194,41 @@ func ErrValidation(err schema.ValidationError) render.Renderer {
 		Err:            err,
 		HTTPStatusCode: http.StatusBadRequest,
 		StatusText:     "Validation failed",
+		ErrorText:      err.Error(),
 		Valid:          false,
 		Errors:         err.Errors,
 	}
 }
 
+// logDomainErr logs the domain error of a response, if any
+func logDomainErr(r *http.Request, err error) {
+	if err != nil {
+		slog.ErrorContext(r.Context(), "API domain error", "error", err)
+	}
+}
+
 func (e *ErrRes) Render(w http.ResponseWriter, r *http.Request) error {
+	logDomainErr(r, e.domainErr)
+	w.WriteHeader(e.HTTPStatusCode)
+	return nil
+}
+
+func (e *PreconditionFailedErrRes) Render(w http.ResponseWriter, r *http.Request) error {
+	logDomainErr(r, e.domainErr)
+	w.Header().Set(headerETag, formatETag(e.CurrentVersion))
+	w.WriteHeader(e.HTTPStatusCode)
+	return nil
+}
+
+func (e *ServiceUnavailableErrRes) Render(w http.ResponseWriter, r *http.Request) error {
+	logDomainErr(r, e.domainErr)
+	w.Header().Set("Retry-After", strconv.Itoa(e.RetryAfter))
 	w.WriteHeader(e.HTTPStatusCode)
 	return nil
 }
 
 func (e *ValidationErrRes) Render(w http.ResponseWriter, r *http.Request) error {
+	logDomainErr(r, e.domainErr)
 	w.WriteHeader(e.HTTPStatusCode)
 	return nil
 }
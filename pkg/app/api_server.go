@@ -2,30 +2,40 @@ package app
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"net/http"
+	"time"
 
+	"github.com/fulcrumproject/core/pkg/api"
+	"github.com/fulcrumproject/core/pkg/auth"
+	"github.com/fulcrumproject/core/pkg/config"
 	"github.com/fulcrumproject/core/pkg/health"
 	"github.com/fulcrumproject/core/pkg/middlewares"
-	"github.com/fulcrumproject/utils/logging"
+	"github.com/fulcrumproject/core/pkg/tracing"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
-	"github.com/go-chi/cors"
 	"github.com/go-chi/render"
 )
 
+// ErrForcedShutdown is returned when a server had to be forced closed as its requests did not end in time
+var ErrForcedShutdown = errors.New("shutdown timed out, connections forced closed")
+
 type ApiServer struct {
-	App          *App
-	Server       *http.Server
-	HealthServer *http.Server
+	App           *App
+	Server        *http.Server
+	HealthServer  *http.Server
+	HealthHandler *health.Handler
 }
 
 func NewApiServer(app *App) *ApiServer {
+	healthServer, healthHandler := BuildHealthServer(app)
 	return &ApiServer{
-		App:          app,
-		Server:       BuildHttpServer(app),
-		HealthServer: BuildHealthServer(app),
+		App:           app,
+		Server:        BuildHttpServer(app),
+		HealthServer:  healthServer,
+		HealthHandler: healthHandler,
 	}
 }
 
@@ -57,46 +67,44 @@ func (a *ApiServer) Start() error {
 	return nil
 }
 
-func (a *ApiServer) Close() {
-	serverCtx, serverStopCtx := context.WithCancel(context.Background())
-	go func() {
-		shutdownCtx, shutdownStopCtx := context.WithTimeout(serverCtx, a.App.Config.ShutdownTimeout)
-		go func() {
-			<-shutdownCtx.Done()
-			if shutdownCtx.Err() == context.DeadlineExceeded {
-				slog.Error("Server shutdown timed out")
-			}
-		}()
-		slog.Debug("HTTP Server shutdown started")
-		err := a.Server.Shutdown(shutdownCtx)
-		if err != nil {
-			slog.Error("Failed to shutdown server", "error", err)
-		}
-		serverStopCtx()
-		shutdownStopCtx()
-	}()
-	<-serverCtx.Done()
+// Close shuts the servers down gracefully: the readiness turns down for the drain delay so the load
+// balancers stop sending traffic, then the listener closes and the in-flight requests are waited for
+// up to the shutdown timeout, after which the remaining connections are forced closed
+// It returns ErrForcedShutdown when the forced close was needed
+func (a *ApiServer) Close() error {
+	slog.Info("HTTP Server draining", "delay", a.App.Config.ShutdownDrainDelay)
+	a.HealthHandler.Drain()
+	time.Sleep(a.App.Config.ShutdownDrainDelay)
+
+	var errs []error
+	slog.Debug("HTTP Server shutdown started")
+	if err := shutdown(a.Server, a.App.Config.ShutdownTimeout); err != nil {
+		slog.Error("Failed to shutdown server", "error", err)
+		errs = append(errs, err)
+	}
 	slog.Debug("HTTP Server shutdown completed")
 
-	serverCtx, serverStopCtx = context.WithCancel(context.Background())
-	go func() {
-		shutdownCtx, shutdownStopCtx := context.WithTimeout(serverCtx, a.App.Config.ShutdownTimeout)
-		go func() {
-			<-shutdownCtx.Done()
-			if shutdownCtx.Err() == context.DeadlineExceeded {
-				slog.Error("Health Server shutdown timed out")
-			}
-		}()
-		slog.Debug("HEALTH Server shutdown started")
-		err := a.HealthServer.Shutdown(shutdownCtx)
-		if err != nil {
-			slog.Error("Failed to shutdown health server", "error", err)
-		}
-		serverStopCtx()
-		shutdownStopCtx()
-	}()
-	<-serverCtx.Done()
+	slog.Debug("HEALTH Server shutdown started")
+	if err := shutdown(a.HealthServer, a.App.Config.ShutdownTimeout); err != nil {
+		slog.Error("Failed to shutdown health server", "error", err)
+		errs = append(errs, err)
+	}
 	slog.Debug("HEALTH Server shutdown completed")
+	return errors.Join(errs...)
+}
+
+// shutdown waits for the active requests of the server up to the timeout, then forces the connections closed
+func shutdown(server *http.Server, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	err := server.Shutdown(ctx)
+	if errors.Is(err, context.DeadlineExceeded) {
+		if closeErr := server.Close(); closeErr != nil {
+			return errors.Join(ErrForcedShutdown, closeErr)
+		}
+		return ErrForcedShutdown
+	}
+	return err
 }
 
 func BuildHttpServer(
@@ -105,23 +113,14 @@ func BuildHttpServer(
 	// Initialize router
 	r := chi.NewRouter()
 
-	// Basic CORS
-	// for more ideas, see: https://developer.github.com/v3/#cross-origin-resource-sharing
-	r.Use(cors.Handler(cors.Options{
-		// AllowedOrigins:   []string{"https://foo.com"}, // Use this to allow specific origin hosts
-		AllowedOrigins: []string{"https://*", "http://*"},
-		// AllowOriginFunc:  func(r *http.Request, origin string) bool { return true },
-		AllowedMethods:   []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token"},
-		ExposedHeaders:   []string{"Link"},
-		AllowCredentials: false,
-		MaxAge:           300, // Maximum value not ignored by any of major browsers
-	}))
+	// The request ID comes first so every response echoes it, CORS then answers the preflight requests
+	// of every route without authentication
+	r.Use(middlewares.RequestID)
+	r.Use(middlewares.CORS(corsPolicy(&app.Config.CORSConfig)))
 
 	// Middleware
 	r.Use(
-		middleware.RequestID,
-		middleware.RequestLogger(&logging.SlogFormatter{Logger: app.Logger}),
+		middleware.RequestLogger(&middlewares.RequestLogFormatter{Logger: app.Logger}),
 		middleware.RealIP,
 		middleware.Recoverer,
 		render.SetContentType(render.ContentTypeJSON),
@@ -132,44 +131,102 @@ func BuildHttpServer(
 	// API routes
 	r.Route("/api/v1", func(r chi.Router) {
 		r.Use(authMiddleware)
-		r.Route("/agent-types", app.AgentTypeHandler.Routes())
-		r.Route("/service-types", app.ServiceTypeHandler.Routes())
-		r.Route("/service-option-types", app.ServiceOptionTypeHandler.Routes())
-		r.Route("/service-options", app.ServiceOptionHandler.Routes())
-		r.Route("/service-pool-sets", app.ServicePoolSetHandler.Routes())
-		r.Route("/service-pools", app.ServicePoolHandler.Routes())
-		r.Route("/service-pool-values", app.ServicePoolValueHandler.Routes())
-		r.Route("/participants", app.ParticipantHandler.Routes())
-		r.Route("/agents", func(r chi.Router) {
-			app.AgentHandler.Routes()(r)
-			app.AgentInstallTokenHandler.Routes()(r)
-		})
-		r.Route("/config-pools", app.ConfigPoolHandler.Routes())
-		r.Route("/config-pool-values", app.ConfigPoolValueHandler.Routes())
-		r.Route("/service-groups", app.ServiceGroupHandler.Routes())
-		r.Route("/services", app.ServiceHandler.Routes())
-		r.Route("/metric-types", app.MetricTypeHandler.Routes())
-		r.Route("/metric-entries", app.MetricEntryHandler.Routes())
-		r.Route("/events", app.EventHandler.Routes())
-		r.Route("/jobs", app.JobHandler.Routes())
-		r.Route("/tokens", app.TokenHandler.Routes())
-		r.Route("/vault/secrets", app.VaultHandler.Routes())
+		if app.Config.RateLimitConfig.Enabled {
+			r.Use(middlewares.RateLimitByIdentity(app.RateLimiter, rateLimitPolicy(&app.Config.RateLimitConfig)))
+		}
+		r.Use(middlewares.Idempotency(app.IdempotencyStore, app.Config.IdempotencyKeyTTL, app.Config.IdempotencyKeyLease))
+		r.Route("/agent-types", entityRoutes(app.PageSizes, "agent-types", app.AgentTypeHandler.Routes()))
+		r.Route("/service-types", entityRoutes(app.PageSizes, "service-types", app.ServiceTypeHandler.Routes()))
+		r.Route("/service-option-types", entityRoutes(app.PageSizes, "service-option-types", app.ServiceOptionTypeHandler.Routes()))
+		r.Route("/service-options", entityRoutes(app.PageSizes, "service-options", app.ServiceOptionHandler.Routes()))
+		r.Route("/service-pool-sets", entityRoutes(app.PageSizes, "service-pool-sets", app.ServicePoolSetHandler.Routes()))
+		r.Route("/service-pools", entityRoutes(app.PageSizes, "service-pools", app.ServicePoolHandler.Routes()))
+		r.Route("/service-pool-values", entityRoutes(app.PageSizes, "service-pool-values", app.ServicePoolValueHandler.Routes()))
+		r.Route("/participants", entityRoutes(app.PageSizes, "participants", app.ParticipantHandler.Routes()))
+		r.Route("/agents", entityRoutes(app.PageSizes, "agents", app.AgentHandler.Routes(), app.AgentInstallTokenHandler.Routes()))
+		r.Route("/config-pools", entityRoutes(app.PageSizes, "config-pools", app.ConfigPoolHandler.Routes()))
+		r.Route("/config-pool-values", entityRoutes(app.PageSizes, "config-pool-values", app.ConfigPoolValueHandler.Routes()))
+		r.Route("/service-groups", entityRoutes(app.PageSizes, "service-groups", app.ServiceGroupHandler.Routes()))
+		r.Route("/services", entityRoutes(app.PageSizes, "services", app.ServiceHandler.Routes()))
+		r.Route("/metric-types", entityRoutes(app.PageSizes, "metric-types", app.MetricTypeHandler.Routes()))
+		r.Route("/metric-entries", entityRoutes(app.PageSizes, "metric-entries", app.MetricEntryHandler.Routes()))
+		r.Route("/events", entityRoutes(app.PageSizes, "events", app.EventHandler.Routes()))
+		r.Route("/event-subscriptions", entityRoutes(app.PageSizes, "event-subscriptions", app.EventSubscriptionHandler.Routes()))
+		r.Route("/jobs", entityRoutes(app.PageSizes, "jobs", app.JobHandler.Routes()))
+		r.Route("/tokens", entityRoutes(app.PageSizes, "tokens", app.TokenHandler.Routes()))
+		r.Route("/vault/secrets", entityRoutes(app.PageSizes, "vault-secrets", app.VaultHandler.Routes()))
+		r.Route("/metrics/prometheus", app.PrometheusHandler.Routes())
 		if app.KeycloakUserHandler != nil {
-			r.Route("/keycloak-users", app.KeycloakUserHandler.Routes())
+			r.Route("/keycloak-users", entityRoutes(app.PageSizes, "keycloak-users", app.KeycloakUserHandler.Routes()))
 		}
 	})
 
-	return &http.Server{
+	server := &http.Server{
 		Addr:    fmt.Sprintf(":%d", app.Config.Port),
 		Handler: r,
 	}
+	// Shutdown waits for active requests, event streams never end on their own
+	server.RegisterOnShutdown(app.EventHandler.CloseStreams)
+	return server
 }
 
-func BuildHealthServer(app *App) *http.Server {
+// pageSizeEntities lists the entities whose page size can be configured, named after their routes
+var pageSizeEntities = []string{
+	"agent-types", "service-types", "service-option-types", "service-options", "service-pool-sets", "service-pools",
+	"service-pool-values", "participants", "agents", "config-pools", "config-pool-values", "service-groups", "services",
+	"metric-types", "metric-entries", "events", "event-subscriptions", "jobs", "tokens", "vault-secrets", "keycloak-users",
+}
+
+// entityRoutes registers the routes of an entity, whose lists are paginated with the page size of the entity
+func entityRoutes(sizes *api.PageSizes, entity string, routes ...func(r chi.Router)) func(r chi.Router) {
+	return func(r chi.Router) {
+		r.Use(api.WithPageSize(sizes.For(entity)))
+		for _, route := range routes {
+			route(r)
+		}
+	}
+}
+
+// corsPolicy is the CORS policy of the configuration, the headers set by the API are always exposed
+func corsPolicy(cfg *config.CORSConfig) middlewares.CORSPolicy {
+	return middlewares.CORSPolicy{
+		AllowedOrigins:   cfg.AllowedOrigins,
+		AllowedMethods:   cfg.AllowedMethods,
+		AllowedHeaders:   cfg.AllowedHeaders,
+		ExposedHeaders:   []string{"Link", middlewares.HeaderIdempotentReplayed, tracing.HeaderRequestID},
+		AllowCredentials: cfg.AllowCredentials,
+		MaxAge:           cfg.MaxAge,
+	}
+}
+
+// rateLimitPolicy limits each identity by role, the pending jobs polling of the agents has its own bucket
+func rateLimitPolicy(cfg *config.RateLimitConfig) middlewares.RateLimitPolicy {
+	return func(r *http.Request, identity *auth.Identity) (string, middlewares.RateLimit, bool) {
+		switch identity.Role {
+		case auth.RoleAdmin:
+			return "api", middlewares.RateLimit{Rate: cfg.AdminRate, Burst: cfg.AdminBurst}, true
+		case auth.RoleParticipant:
+			return "api", middlewares.RateLimit{Rate: cfg.ParticipantRate, Burst: cfg.ParticipantBurst}, true
+		case auth.RoleAgent:
+			if r.Method == http.MethodGet && r.URL.Path == "/api/v1/jobs/pending" {
+				return "poll", middlewares.RateLimit{Rate: cfg.AgentPollRate, Burst: cfg.AgentPollBurst}, true
+			}
+			return "api", middlewares.RateLimit{Rate: cfg.AgentRate, Burst: cfg.AgentBurst}, true
+		}
+		return "", middlewares.RateLimit{}, false
+	}
+}
+
+func BuildHealthServer(app *App) (*http.Server, *health.Handler) {
 	// Initialize health checker and handlers
 	healthDeps := &health.PrimaryDependencies{
 		DB:             app.Db,
 		Authenticators: app.Authenticators,
+		Timeout:        app.Config.HealthCheckTimeout,
+	}
+	// The database backend is covered by the database check
+	if backend, ok := app.SecretBackend.(health.Dependency); ok {
+		healthDeps.SecretBackend = backend
 	}
 	healthChecker := health.NewHealthChecker(healthDeps)
 	healthHandler := health.NewHandler(healthChecker)
@@ -177,7 +234,7 @@ func BuildHealthServer(app *App) *http.Server {
 	// Setup health router
 	healthRouter := chi.NewRouter()
 	healthRouter.Use(
-		middleware.RequestID,
+		middlewares.RequestID,
 		middleware.RealIP,
 		middleware.Recoverer,
 		render.SetContentType(render.ContentTypeJSON),
@@ -188,5 +245,5 @@ func BuildHealthServer(app *App) *http.Server {
 	return &http.Server{
 		Addr:    fmt.Sprintf(":%d", app.Config.HealthPort),
 		Handler: healthRouter,
-	}
+	}, healthHandler
 }
@@ -4,11 +4,13 @@ import (
 	"context"
 	"encoding/hex"
 	"flag"
+	"fmt"
 	"log/slog"
 	"os"
 	"strings"
 	"sync"
 
+	"github.com/fulcrumproject/core/pkg/agentrpc"
 	"github.com/fulcrumproject/core/pkg/api"
 	"github.com/fulcrumproject/core/pkg/auth"
 	"github.com/fulcrumproject/core/pkg/authz"
@@ -16,10 +18,14 @@ import (
 	"github.com/fulcrumproject/core/pkg/database"
 	"github.com/fulcrumproject/core/pkg/domain"
 	"github.com/fulcrumproject/core/pkg/gormlock"
+	"github.com/fulcrumproject/core/pkg/hcvault"
 	"github.com/fulcrumproject/core/pkg/health"
 	"github.com/fulcrumproject/core/pkg/keycloak"
+	"github.com/fulcrumproject/core/pkg/middlewares"
 	"github.com/fulcrumproject/core/pkg/schema"
+	"github.com/fulcrumproject/core/pkg/tracing"
 	"github.com/fulcrumproject/utils/confbuilder"
+	"github.com/fulcrumproject/utils/gormpg"
 	"github.com/fulcrumproject/utils/logging"
 	"github.com/go-co-op/gocron/v2"
 	"gorm.io/gorm"
@@ -30,6 +36,7 @@ type App struct {
 	Db                       *gorm.DB
 	MetricDb                 *gorm.DB
 	Authenticators           []auth.Authenticator
+	OAuthAuthenticator       *keycloak.Authenticator
 	AgentTypeHandler         *api.AgentTypeHandler
 	AgentInstallTokenHandler *api.AgentInstallTokenHandler
 	ServiceTypeHandler       *api.ServiceTypeHandler
@@ -48,18 +55,29 @@ type App struct {
 	MetricEntryHandler       *api.MetricEntryHandler
 	MetricEntryRepo          *database.GormMetricEntryRepository
 	EventHandler             *api.EventHandler
+	EventSubscriptionHandler *api.EventSubscriptionHandler
 	JobHandler               *api.JobHandler
 	TokenHandler             *api.TokenHandler
 	VaultHandler             *api.VaultHandler
 	KeycloakUserHandler      *api.KeycloakUserHandler
+	PrometheusHandler        *api.PrometheusHandler
+	AgentRPCServer           *agentrpc.Server
 	HealthHandler            *health.Handler
 	Logger                   *slog.Logger
 	PropertyEngine           *schema.Engine[domain.ServicePropertyContext]
 	CompositeAuthenticator   *auth.CompositeAuthenticator
 	RuleBasedAuthorizer      *authz.RuleBasedAuthorizer
+	RateLimiter              middlewares.RateLimiter
+	PageSizes                *api.PageSizes
+	IdempotencyStore         domain.IdempotencyStore
 	Store                    domain.Store
 	ServiceCmd               domain.ServiceCommander
+	JobCmd                   domain.JobCommander
+	Vault                    schema.Vault
+	SecretBackend            domain.SecretBackend
+	VaultSecretCmd           domain.VaultSecretCommander
 	Scheduler                *gocron.Scheduler
+	LockerDb                 *gorm.DB
 	scheduleStarted          bool
 	WaitGroup                *sync.WaitGroup
 }
@@ -80,17 +98,48 @@ func readConfig() (*config.Config, error) {
 }
 
 func initLogger(cfg *config.Config) *slog.Logger {
-	logger := logging.NewLogger(&cfg.LogConfig)
+	// The lines logged with the context of a request carry its request ID
+	logger := slog.New(tracing.NewLogHandler(logging.NewLogger(&cfg.LogConfig).Handler()))
 	slog.SetDefault(logger)
 
 	slog.Debug("API_SERVER", "value", cfg.ApiServer)
 	slog.Debug("JOB_MAINTENANCE", "value", cfg.JobMaintenance)
 	slog.Debug("AGENT_MAINTENANCE", "value", cfg.AgentMaintenance)
+	slog.Debug("WEBHOOK_DELIVERY", "value", cfg.WebhookDelivery)
 	slog.Debug("KEYCLOAK_ADMIN", "value", cfg.KeycloakAdmin)
+	slog.Debug("VAULT_MAINTENANCE", "value", cfg.VaultMaintenance)
+	slog.Debug("TOKEN_MAINTENANCE", "value", cfg.TokenMaintenance)
 
 	return logger
 }
 
+// initSecretBackend creates the configured secret backend of the vault, it is nil when the database backend has no encryption key
+func initSecretBackend(cfg *config.Config, db *gorm.DB) (domain.SecretBackend, error) {
+	switch cfg.VaultBackend {
+	case config.VaultBackendHashiCorp:
+		if err := cfg.HashiCorpVault.Validate(); err != nil {
+			return nil, err
+		}
+		slog.Info("Vault initialized for secret storage", "backend", cfg.VaultBackend, "address", cfg.HashiCorpVault.Address)
+		return hcvault.NewKVBackend(&cfg.HashiCorpVault), nil
+	default:
+		if cfg.VaultEncryptionKey == "" {
+			slog.Warn("Vault encryption key not configured - secret properties will not work")
+			return nil, nil
+		}
+		vaultKey, err := hex.DecodeString(cfg.VaultEncryptionKey)
+		if err != nil {
+			return nil, fmt.Errorf("invalid vault encryption key (must be 64-character hex string): %w", err)
+		}
+		backend, err := database.NewSecretBackend(db, vaultKey)
+		if err != nil {
+			return nil, err
+		}
+		slog.Info("Vault initialized for secret storage", "backend", cfg.VaultBackend)
+		return backend, nil
+	}
+}
+
 func initDatabase(cfg *config.Config) (*gorm.DB, error) {
 	db, err := database.NewConnection(&cfg.DBConfig)
 	if err != nil {
@@ -99,6 +148,23 @@ func initDatabase(cfg *config.Config) (*gorm.DB, error) {
 	return db, nil
 }
 
+// initReadSelector connects to the read replica when enabled, the queriers read from the primary otherwise
+func initReadSelector(cfg *config.Config, db *gorm.DB) (*database.ReadSelector, error) {
+	if !cfg.ReadReplicaConfig.Enabled {
+		return nil, nil
+	}
+	replica, err := database.NewReadReplicaConnection(&gormpg.Conf{
+		DSN:       cfg.ReadReplicaConfig.DSN,
+		LogLevel:  cfg.DBConfig.LogLevel,
+		LogFormat: cfg.DBConfig.LogFormat,
+	})
+	if err != nil {
+		return nil, err
+	}
+	slog.Info("Read replica enabled", "stickiness", cfg.ReadReplicaConfig.Stickiness)
+	return database.NewReadSelector(db, replica, cfg.ReadReplicaConfig.Stickiness)
+}
+
 func initMetricDatabase(cfg *config.Config) (*gorm.DB, error) {
 	db, err := database.NewMetricConnection(&cfg.MetricDBConfig)
 	if err != nil {
@@ -134,6 +200,25 @@ func initScheduler(cfg *config.Config, lockerDb *gorm.DB) (*gocron.Scheduler, er
 	return &scheduler, nil
 }
 
+// initCustomRoles parses the custom roles of the configuration and registers them as valid roles
+func initCustomRoles(cfg *config.Config) (authz.RolePermissions, error) {
+	roles, err := authz.ParseRolePermissions(cfg.Roles, authz.Rules)
+	if err != nil {
+		return nil, err
+	}
+	for role, permissions := range roles {
+		auth.RegisterCustomRoles(role)
+		slog.Info("Custom role defined", "role", role, "permissions", permissions)
+	}
+	return roles, nil
+}
+
+// initPageSizes parses the page sizes of the lists once, each request is checked against the page size of its entity
+func initPageSizes(cfg *config.Config) (*api.PageSizes, error) {
+	defaultSize := api.PageSize{Default: cfg.PaginationConfig.DefaultPageSize, Max: cfg.PaginationConfig.MaxPageSize}
+	return api.ParsePageSizes(defaultSize, cfg.PaginationConfig.EntityPageSizes, pageSizeEntities)
+}
+
 func NewApp() *App {
 	cfg, err := readConfig()
 	if err != nil {
@@ -143,6 +228,26 @@ func NewApp() *App {
 
 	logger := initLogger(cfg)
 
+	// Custom roles must be valid before any identity is authenticated
+	customRoles, err := initCustomRoles(cfg)
+	if err != nil {
+		slog.Error("Invalid custom roles", "error", err)
+		return nil
+	}
+
+	pageSizes, err := initPageSizes(cfg)
+	if err != nil {
+		slog.Error("Invalid page sizes", "error", err)
+		return nil
+	}
+
+	// An insecure CORS policy, such as credentials with wildcard origins, fails the startup
+	cors := corsPolicy(&cfg.CORSConfig)
+	if err := cors.Validate(); err != nil {
+		slog.Error("Invalid CORS configuration", "error", err)
+		return nil
+	}
+
 	db, err := initDatabase(cfg)
 	if err != nil {
 		slog.Error("Failed to initialize database", "error", err)
@@ -155,6 +260,12 @@ func NewApp() *App {
 		return nil
 	}
 
+	reads, err := initReadSelector(cfg, db)
+	if err != nil {
+		slog.Error("Failed to initialize read replica", "error", err)
+		return nil
+	}
+
 	metricDb, err := initMetricDatabase(cfg)
 	if err != nil {
 		slog.Error("Failed to initialize metric database", "error", err)
@@ -174,24 +285,24 @@ func NewApp() *App {
 	}
 
 	store := database.NewGormStore(db)
+	// The handlers query through the read store, the commanders read and write on the primary
+	readStore := database.NewGormReadOnlyStore(db, reads)
 	metricEntryRepo := database.NewMetricEntryRepository(metricDb)
 
 	// Initialize vault for secret storage (optional)
+	secretBackend, err := initSecretBackend(cfg, db)
+	if err != nil {
+		slog.Error("Failed to initialize vault", "error", err)
+		os.Exit(1)
+	}
 	var vault schema.Vault
-	if cfg.VaultEncryptionKey != "" {
-		vaultKey, err := hex.DecodeString(cfg.VaultEncryptionKey)
-		if err != nil {
-			slog.Error("Invalid vault encryption key (must be 64-character hex string)", "error", err)
-			os.Exit(1)
-		}
-		vault, err = database.NewVault(db, vaultKey)
-		if err != nil {
-			slog.Error("Failed to initialize vault", "error", err)
-			os.Exit(1)
-		}
-		slog.Info("Vault initialized for secret storage")
-	} else {
-		slog.Warn("Vault encryption key not configured - secret properties will not work")
+	var vaultSecretCmd domain.VaultSecretCommander
+	if secretBackend != nil {
+		vault = domain.NewSecretVault(secretBackend)
+		vaultSecretCmd = domain.NewVaultSecretCommander(secretBackend, domain.SecretRotationPolicy{
+			GracePeriod:         cfg.VaultRotationConfig.GracePeriod,
+			MaxPreviousVersions: cfg.VaultRotationConfig.MaxPreviousVersions,
+		})
 	}
 
 	// Initialize schema engine for service property validation
@@ -200,28 +311,45 @@ func NewApp() *App {
 	// Initialize schema engine for agent configuration validation
 	agentConfigEngine := domain.NewAgentConfigSchemaEngine(vault)
 
-	serviceCmd := domain.NewServiceCommander(store, propertyEngine)
+	agentSelector, err := domain.NewAgentSelector(domain.AgentSelectionStrategy(cfg.ServiceConfig.AgentSelection))
+	if err != nil {
+		slog.Error("Failed to initialize agent selector", "error", err)
+		os.Exit(1)
+	}
+
+	costEstimator, err := domain.NewCostEstimator(domain.CostEstimationStrategy(cfg.ServiceConfig.CostEstimation))
+	if err != nil {
+		slog.Error("Failed to initialize cost estimator", "error", err)
+		os.Exit(1)
+	}
+
+	jobRetryPolicy := domain.JobRetryPolicy{
+		InitialBackoff: cfg.JobConfig.RetryInitialBackoff,
+		MaxBackoff:     cfg.JobConfig.RetryMaxBackoff,
+	}
+	serviceCmd := domain.NewServiceCommander(store, propertyEngine, agentSelector, cfg.ServiceConfig.RestoreWindow, jobRetryPolicy, costEstimator)
 	serviceTypeCmd := domain.NewServiceTypeCommander(store, propertyEngine)
 	serviceGroupCmd := domain.NewServiceGroupCommander(store)
 	serviceOptionTypeCmd := domain.NewServiceOptionTypeCommander(store)
 	serviceOptionCmd := domain.NewServiceOptionCommander(store)
 	participantCmd := domain.NewParticipantCommander(store)
 	agentTypeCmd := domain.NewAgentTypeCommander(store, agentConfigEngine)
-	jobCmd := domain.NewJobCommander(store, propertyEngine)
+	jobCmd := domain.NewJobCommander(store, propertyEngine, cfg.JobConfig.MaxAttempts, cfg.JobConfig.LeaseDuration, jobRetryPolicy)
 	metricEntryCmd := domain.NewMetricEntryCommander(store, metricEntryRepo)
 	metricTypeCmd := domain.NewMetricTypeCommander(store, metricEntryRepo)
 	installTokenCmd := domain.NewAgentInstallTokenCommander(store)
 	agentCmd := domain.NewAgentCommander(store, agentConfigEngine)
-	tokenCmd := domain.NewTokenCommander(store)
-	eventSubscriptionCmd := domain.NewEventSubscriptionCommander(store)
+	tokenCmd := domain.NewTokenCommander(store, cfg.TokenConfig.HashCost)
+	eventSubscriptionCmd := domain.NewEventSubscriptionCommander(store, vault)
 
 	// Initialize authenticators
 	authenticators := []auth.Authenticator{}
+	var oauthAuthenticator *keycloak.Authenticator
 
 	for _, authType := range cfg.Authenticators {
 		switch strings.TrimSpace(authType) {
 		case "token":
-			tokenAuth := database.NewTokenAuthenticator(store)
+			tokenAuth := database.NewTokenAuthenticator(store, cfg.TokenConfig.HashCost)
 			authenticators = append(authenticators, tokenAuth)
 			slog.Info("Token authentication enabled")
 		case "oauth":
@@ -232,6 +360,7 @@ func NewApp() *App {
 				os.Exit(1)
 			}
 			authenticators = append(authenticators, oauthAuth)
+			oauthAuthenticator = oauthAuth
 			slog.Info("OAuth authentication enabled", "issuer", cfg.OAuthConfig.GetIssuer())
 		default:
 			slog.Warn("Unknown authenticator type in config", "type", authType)
@@ -245,7 +374,7 @@ func NewApp() *App {
 
 	ath := auth.NewCompositeAuthenticator(authenticators...)
 
-	athz := authz.NewRuleBasedAuthorizer(authz.Rules)
+	athz := authz.NewRuleBasedAuthorizer(authz.Rules, customRoles)
 
 	var keycloakUserHandler *api.KeycloakUserHandler
 	if cfg.KeycloakAdmin {
@@ -270,35 +399,47 @@ func NewApp() *App {
 		MetricDb:                 metricDb,
 		Logger:                   logger,
 		Scheduler:                scheduler,
+		LockerDb:                 lockerDb,
 		scheduleStarted:          false,
 		WaitGroup:                &sync.WaitGroup{},
 		Store:                    store,
+		SecretBackend:            secretBackend,
 		Authenticators:           authenticators,
+		OAuthAuthenticator:       oauthAuthenticator,
 		CompositeAuthenticator:   ath,
 		RuleBasedAuthorizer:      athz,
-		ServiceTypeHandler:       api.NewServiceTypeHandler(store.ServiceTypeRepo(), serviceTypeCmd, athz, propertyEngine),
-		ServiceOptionTypeHandler: api.NewServiceOptionTypeHandler(store.ServiceOptionTypeRepo(), serviceOptionTypeCmd, athz),
-		ServiceOptionHandler:     api.NewServiceOptionHandler(store.ServiceOptionRepo(), serviceOptionCmd, athz),
-		ServicePoolSetHandler:    api.NewServicePoolSetHandler(store.ServicePoolSetRepo(), servicePoolSetCmd, athz),
-		ServicePoolHandler:       api.NewServicePoolHandler(store.ServicePoolRepo(), servicePoolCmd, athz),
-		ServicePoolValueHandler:  api.NewServicePoolValueHandler(store.ServicePoolValueRepo(), servicePoolValueCmd, athz),
-		ParticipantHandler:       api.NewParticipantHandler(store.ParticipantRepo(), participantCmd, athz),
-		AgentHandler:             api.NewAgentHandler(store.AgentRepo(), agentCmd, athz),
+		RateLimiter:              middlewares.NewMemoryRateLimiter(),
+		PageSizes:                pageSizes,
+		IdempotencyStore:         database.NewIdempotencyStore(db),
+		ServiceTypeHandler:       api.NewServiceTypeHandler(readStore.ServiceTypeQuerier(), serviceTypeCmd, athz, propertyEngine),
+		ServiceOptionTypeHandler: api.NewServiceOptionTypeHandler(readStore.ServiceOptionTypeQuerier(), serviceOptionTypeCmd, athz),
+		ServiceOptionHandler:     api.NewServiceOptionHandler(readStore.ServiceOptionQuerier(), serviceOptionCmd, athz),
+		ServicePoolSetHandler:    api.NewServicePoolSetHandler(readStore.ServicePoolSetQuerier(), servicePoolSetCmd, athz),
+		ServicePoolHandler:       api.NewServicePoolHandler(readStore.ServicePoolQuerier(), servicePoolCmd, athz),
+		ServicePoolValueHandler:  api.NewServicePoolValueHandler(readStore.ServicePoolValueQuerier(), servicePoolValueCmd, athz),
+		ParticipantHandler:       api.NewParticipantHandler(readStore.ParticipantQuerier(), participantCmd, tokenCmd, athz),
+		AgentHandler:             api.NewAgentHandler(readStore.AgentQuerier(), agentCmd, athz),
 		AgentInstallTokenHandler: api.NewAgentInstallTokenHandler(store.AgentInstallTokenRepo(), installTokenCmd, store.AgentRepo().AuthScope, athz, vault, cfg.PublicBaseURL),
-		ConfigPoolHandler:        api.NewConfigPoolHandler(store.ConfigPoolRepo(), configPoolCmd, athz),
-		ConfigPoolValueHandler:   api.NewConfigPoolValueHandler(store.ConfigPoolValueRepo(), store.ConfigPoolRepo(), configPoolValueCmd, athz),
-		AgentTypeHandler:         api.NewAgentTypeHandler(store.AgentTypeRepo(), agentTypeCmd, athz),
-		ServiceGroupHandler:      api.NewServiceGroupHandler(store.ServiceGroupRepo(), serviceGroupCmd, athz),
-		ServiceHandler:           api.NewServiceHandler(store.ServiceRepo(), store.AgentRepo(), store.ServiceGroupRepo(), serviceCmd, athz),
-		JobHandler:               api.NewJobHandler(store.JobRepo(), jobCmd, athz),
-		MetricTypeHandler:        api.NewMetricTypeHandler(store.MetricTypeRepo(), metricTypeCmd, athz),
-		MetricEntryHandler:       api.NewMetricEntryHandler(metricEntryRepo, store.ServiceRepo(), metricEntryCmd, athz),
+		ConfigPoolHandler:        api.NewConfigPoolHandler(readStore.ConfigPoolQuerier(), configPoolCmd, athz),
+		ConfigPoolValueHandler:   api.NewConfigPoolValueHandler(readStore.ConfigPoolValueQuerier(), readStore.ConfigPoolQuerier(), configPoolValueCmd, athz),
+		AgentTypeHandler:         api.NewAgentTypeHandler(readStore.AgentTypeQuerier(), agentTypeCmd, athz),
+		ServiceGroupHandler:      api.NewServiceGroupHandler(readStore.ServiceGroupQuerier(), readStore.ServiceQuerier(), serviceGroupCmd, athz),
+		ServiceHandler:           api.NewServiceHandler(readStore.ServiceQuerier(), readStore.AgentQuerier(), readStore.ServiceGroupQuerier(), readStore.JobQuerier(), readStore.EventQuerier(), serviceCmd, athz),
+		JobHandler:               api.NewJobHandler(readStore.JobQuerier(), jobCmd, athz),
+		MetricTypeHandler:        api.NewMetricTypeHandler(readStore.MetricTypeQuerier(), metricTypeCmd, athz),
+		MetricEntryHandler:       api.NewMetricEntryHandler(metricEntryRepo, readStore.ServiceQuerier(), readStore.MetricTypeQuerier(), metricEntryCmd, athz),
 		MetricEntryRepo:          metricEntryRepo,
-		EventHandler:             api.NewEventHandler(store.EventRepo(), eventSubscriptionCmd, athz),
-		TokenHandler:             api.NewTokenHandler(store.TokenRepo(), tokenCmd, store.AgentRepo(), athz),
-		VaultHandler:             api.NewVaultHandler(vault),
+		EventHandler:             api.NewEventHandler(readStore.EventQuerier(), eventSubscriptionCmd, athz),
+		EventSubscriptionHandler: api.NewEventSubscriptionHandler(readStore.EventSubscriptionQuerier(), athz),
+		TokenHandler:             api.NewTokenHandler(readStore.TokenQuerier(), tokenCmd, readStore.AgentQuerier(), athz),
+		VaultHandler:             api.NewVaultHandler(vault, vaultSecretCmd, athz),
 		KeycloakUserHandler:      keycloakUserHandler,
+		PrometheusHandler:        api.NewPrometheusHandler(store.ServiceRepo(), store.JobRepo(), store.AgentRepo(), store.MetricTypeRepo(), athz),
+		AgentRPCServer:           agentrpc.NewServer(agentCmd, store.JobRepo(), jobCmd, store.ServiceRepo(), metricEntryCmd, athz, cfg.GRPCConfig.JobFeedInterval),
 		ServiceCmd:               serviceCmd,
+		JobCmd:                   jobCmd,
+		Vault:                    vault,
+		VaultSecretCmd:           vaultSecretCmd,
 		PropertyEngine:           propertyEngine,
 	}
 }
@@ -310,3 +451,23 @@ func (a *App) StartScheduler() {
 	(*a.Scheduler).Start()
 	a.scheduleStarted = true
 }
+
+// newWorkerLeader creates the leader election of a worker when it is enabled, nil otherwise
+func (a *App) newWorkerLeader(name string) (*gormlock.AdvisoryLeader, error) {
+	if !a.Config.SchedulerLockerConfig.LeaderElection {
+		return nil, nil
+	}
+	return gormlock.NewAdvisoryLeader(a.LockerDb, a.Config.SchedulerLockerConfig.Name+":"+name)
+}
+
+// Reload applies the configuration that can change without a restart: the OAuth group mapping
+func (a *App) Reload() {
+	if a.OAuthAuthenticator == nil {
+		return
+	}
+	if err := a.OAuthAuthenticator.ReloadGroupMapping(); err != nil {
+		slog.Error("Failed to reload OAuth group mapping, keeping the current one", "error", err)
+		return
+	}
+	slog.Info("Configuration reloaded")
+}
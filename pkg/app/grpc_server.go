@@ -0,0 +1,63 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+
+	"github.com/fulcrumproject/core/pkg/agentrpc"
+	"google.golang.org/grpc"
+)
+
+// GRPCServer serves the agent protocol over gRPC
+type GRPCServer struct {
+	App    *App
+	Server *grpc.Server
+}
+
+func NewGRPCServer(app *App) *GRPCServer {
+	return &GRPCServer{
+		App:    app,
+		Server: agentrpc.NewGRPCServer(app.AgentRPCServer, app.CompositeAuthenticator),
+	}
+}
+
+func (g *GRPCServer) Start() error {
+	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", g.App.Config.GRPCConfig.Port))
+	if err != nil {
+		slog.Error("Failed to listen for gRPC", "error", err)
+		return err
+	}
+	go func() {
+		slog.Info("gRPC server starting", "port", g.App.Config.GRPCConfig.Port)
+		if err := g.Server.Serve(listener); err != nil {
+			slog.Error("Failed to serve gRPC", "error", err)
+		}
+	}()
+	return nil
+}
+
+// Close ends the job feeds, which never end on their own, and waits for the calls in progress
+// It returns ErrForcedShutdown when the calls had to be stopped
+func (g *GRPCServer) Close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), g.App.Config.ShutdownTimeout)
+	defer cancel()
+	stopped := make(chan struct{})
+	go func() {
+		slog.Debug("gRPC Server shutdown started")
+		g.App.AgentRPCServer.CloseFeeds()
+		g.Server.GracefulStop()
+		close(stopped)
+	}()
+	var err error
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		slog.Error("gRPC Server shutdown timed out")
+		g.Server.Stop()
+		err = ErrForcedShutdown
+	}
+	slog.Debug("gRPC Server shutdown completed")
+	return err
+}
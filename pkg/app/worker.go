@@ -2,17 +2,21 @@ package app
 
 import (
 	"context"
+	"fmt"
 	"log/slog"
 	"sync"
 	"time"
 
 	"github.com/fulcrumproject/core/pkg/config"
 	"github.com/fulcrumproject/core/pkg/domain"
+	"github.com/fulcrumproject/core/pkg/tracing"
+	"github.com/fulcrumproject/core/pkg/webhook"
 	"github.com/go-co-op/gocron/v2"
 )
 
 type UnhealthyAgentsWorker struct {
-	app *App
+	app      *App
+	schedule *workerSchedule
 }
 
 func NewUnhealthyAgentsWorker(app *App) *UnhealthyAgentsWorker {
@@ -22,22 +26,44 @@ func NewUnhealthyAgentsWorker(app *App) *UnhealthyAgentsWorker {
 }
 
 func (w *UnhealthyAgentsWorker) Run() error {
-	task := disconnectUnhealthyAgentsTask(&w.app.Config.AgentConfig, w.app.Store, w.app.WaitGroup)
-	err := scheduleWork(task, w.app.Scheduler, w.app.Config.AgentConfig.HealthTimeout, "agent_maintenance")
+	cfg := &w.app.Config.AgentConfig
+	schedule, err := newWorkerSchedule("agent_maintenance", cfg.MaintenanceInterval, cfg.MaintenanceJitter)
 	if err != nil {
+		slog.Error("Invalid agent maintenance schedule", "error", err)
+		return err
+	}
+	if schedule.leader, err = w.app.newWorkerLeader(schedule.name); err != nil {
+		slog.Error("Failed to create agent maintenance leader election", "error", err)
+		return err
+	}
+	task := disconnectUnhealthyAgentsTask(cfg, w.app.Store, w.app.WaitGroup)
+	if err := schedule.schedule(task, w.app.Scheduler); err != nil {
 		slog.Error("Failed to schedule work", "error", err)
 		return err
 	}
+	w.schedule = schedule
 	w.app.StartScheduler()
 	return nil
 }
 
+// Status returns the schedule and the last run of the worker, it is empty until the worker runs
+func (w *UnhealthyAgentsWorker) Status() WorkerStatus {
+	if w.schedule == nil {
+		return WorkerStatus{}
+	}
+	return w.schedule.Status()
+}
+
 func (w *UnhealthyAgentsWorker) Close() {
 	w.app.WaitGroup.Wait()
+	if w.schedule != nil {
+		w.schedule.close()
+	}
 }
 
 type JobMaintenanceWorker struct {
-	app *App
+	app      *App
+	schedule *workerSchedule
 }
 
 func NewJobMaintenanceWorker(app *App) *JobMaintenanceWorker {
@@ -47,18 +73,165 @@ func NewJobMaintenanceWorker(app *App) *JobMaintenanceWorker {
 }
 
 func (w *JobMaintenanceWorker) Run() error {
-	task := jobMaintenanceTask(&w.app.Config.JobConfig, w.app.Store, w.app.ServiceCmd, w.app.WaitGroup)
-	err := scheduleWork(task, w.app.Scheduler, w.app.Config.JobConfig.Maintenance, "job_maintenance")
+	schedule, err := newWorkerSchedule("job_maintenance", w.app.Config.JobConfig.Maintenance, w.app.Config.JobConfig.MaintenanceJitter)
 	if err != nil {
+		slog.Error("Invalid job maintenance schedule", "error", err)
+		return err
+	}
+	if schedule.leader, err = w.app.newWorkerLeader(schedule.name); err != nil {
+		slog.Error("Failed to create job maintenance leader election", "error", err)
+		return err
+	}
+	actionTimeouts, err := w.app.Config.JobConfig.ParseActionTimeouts()
+	if err != nil {
+		slog.Error("Invalid job action timeouts", "error", err)
+		return err
+	}
+	timeouts := domain.JobTimeouts{Default: w.app.Config.JobConfig.Timeout, Actions: actionTimeouts}
+
+	idleStopper := domain.NewServiceIdleStopper(w.app.Store, w.app.MetricEntryRepo)
+
+	task := jobMaintenanceTask(&w.app.Config.JobConfig, timeouts, w.app.Store, w.app.ServiceCmd, idleStopper, w.app.IdempotencyStore, w.app.WaitGroup)
+	if err := schedule.schedule(task, w.app.Scheduler); err != nil {
 		slog.Error("Failed to schedule work", "error", err)
 		return err
 	}
+	w.schedule = schedule
 	w.app.StartScheduler()
 	return nil
 }
 
+// Status returns the schedule and the last run of the worker, it is empty until the worker runs
+func (w *JobMaintenanceWorker) Status() WorkerStatus {
+	if w.schedule == nil {
+		return WorkerStatus{}
+	}
+	return w.schedule.Status()
+}
+
 func (w *JobMaintenanceWorker) Close() {
 	w.app.WaitGroup.Wait()
+	if w.schedule != nil {
+		w.schedule.close()
+	}
+}
+
+type JobLeaseWorker struct {
+	app *App
+}
+
+func NewJobLeaseWorker(app *App) *JobLeaseWorker {
+	return &JobLeaseWorker{
+		app: app,
+	}
+}
+
+func (w *JobLeaseWorker) Run() error {
+	if w.app.Config.JobConfig.LeaseDuration <= 0 {
+		err := fmt.Errorf("job lease reclaim requires a job lease duration")
+		slog.Error("Failed to schedule work", "error", err)
+		return err
+	}
+	task := jobLeaseReclaimTask(w.app.JobCmd, w.app.WaitGroup)
+	err := scheduleWork(task, w.app.Scheduler, w.app.Config.JobConfig.LeaseReclaim, "job_lease_reclaim")
+	if err != nil {
+		slog.Error("Failed to schedule work", "error", err)
+		return err
+	}
+	w.app.StartScheduler()
+	return nil
+}
+
+func (w *JobLeaseWorker) Close() {
+	w.app.WaitGroup.Wait()
+}
+
+type WebhookDeliveryWorker struct {
+	app *App
+}
+
+func NewWebhookDeliveryWorker(app *App) *WebhookDeliveryWorker {
+	return &WebhookDeliveryWorker{
+		app: app,
+	}
+}
+
+func (w *WebhookDeliveryWorker) Run() error {
+	cfg := &w.app.Config.WebhookConfig
+	policy := domain.WebhookRetryPolicy{
+		MaxAttempts:    cfg.MaxAttempts,
+		InitialBackoff: cfg.InitialBackoff,
+		MaxBackoff:     cfg.MaxBackoff,
+	}
+	deliverer := domain.NewEventWebhookDeliverer(w.app.Store, w.app.Vault, webhook.NewSender(cfg.Timeout), policy, cfg.BatchSize, cfg.DeliveryHistory)
+
+	task := webhookDeliveryTask(deliverer, w.app.WaitGroup)
+	err := scheduleWork(task, w.app.Scheduler, cfg.Interval, "webhook_delivery")
+	if err != nil {
+		slog.Error("Failed to schedule work", "error", err)
+		return err
+	}
+	w.app.StartScheduler()
+	return nil
+}
+
+func (w *WebhookDeliveryWorker) Close() {
+	w.app.WaitGroup.Wait()
+}
+
+type VaultMaintenanceWorker struct {
+	app *App
+}
+
+func NewVaultMaintenanceWorker(app *App) *VaultMaintenanceWorker {
+	return &VaultMaintenanceWorker{
+		app: app,
+	}
+}
+
+func (w *VaultMaintenanceWorker) Run() error {
+	if w.app.VaultSecretCmd == nil {
+		err := fmt.Errorf("vault maintenance requires a configured vault")
+		slog.Error("Failed to schedule work", "error", err)
+		return err
+	}
+	task := vaultMaintenanceTask(w.app.VaultSecretCmd, w.app.WaitGroup)
+	err := scheduleWork(task, w.app.Scheduler, w.app.Config.VaultRotationConfig.PurgeInterval, "vault_maintenance")
+	if err != nil {
+		slog.Error("Failed to schedule work", "error", err)
+		return err
+	}
+	w.app.StartScheduler()
+	return nil
+}
+
+func (w *VaultMaintenanceWorker) Close() {
+	w.app.WaitGroup.Wait()
+}
+
+type TokenMaintenanceWorker struct {
+	app *App
+}
+
+func NewTokenMaintenanceWorker(app *App) *TokenMaintenanceWorker {
+	return &TokenMaintenanceWorker{
+		app: app,
+	}
+}
+
+func (w *TokenMaintenanceWorker) Run() error {
+	task := tokenMaintenanceTask(&w.app.Config.TokenConfig, w.app.Store, w.app.WaitGroup)
+	err := scheduleWork(task, w.app.Scheduler, w.app.Config.TokenConfig.ReportInterval, "token_maintenance")
+	if err != nil {
+		slog.Error("Failed to schedule work", "error", err)
+		return err
+	}
+	w.app.StartScheduler()
+	return nil
+}
+
+func (w *TokenMaintenanceWorker) Close() {
+	w.app.WaitGroup.Wait()
 }
 
 func scheduleWork(task gocron.Task, scheduler *gocron.Scheduler, duration time.Duration, job_name string) error {
@@ -80,19 +253,24 @@ func scheduleWork(task gocron.Task, scheduler *gocron.Scheduler, duration time.D
 	return nil
 }
 
+// workerContext returns the context of a worker run, its request ID correlates the log lines, events and jobs of the run
+func workerContext() context.Context {
+	return tracing.WithRequestID(context.Background(), tracing.NewRequestID())
+}
+
 func disconnectUnhealthyAgentsTask(cfg *config.AgentConfig, store domain.Store, wg *sync.WaitGroup) gocron.Task {
 	task := gocron.NewTask(
 		func(cfg *config.AgentConfig, store domain.Store, wg *sync.WaitGroup) {
 			wg.Add(1)
 			defer wg.Done()
-			ctx := context.Background()
+			ctx := workerContext()
 
-			slog.Info("Checking agents health")
+			slog.InfoContext(ctx, "Checking agents health")
 			disconnectedCount, err := store.AgentRepo().MarkInactiveAgentsAsDisconnected(ctx, cfg.HealthTimeout)
 			if err != nil {
-				slog.Error("Error marking inactive agents as disconnected", "error", err)
+				slog.ErrorContext(ctx, "Error marking inactive agents as disconnected", "error", err)
 			} else if disconnectedCount > 0 {
-				slog.Info("Marked inactive agents as disconnected", "count", disconnectedCount)
+				slog.InfoContext(ctx, "Marked inactive agents as disconnected", "count", disconnectedCount)
 			}
 		},
 		cfg,
@@ -103,34 +281,197 @@ func disconnectUnhealthyAgentsTask(cfg *config.AgentConfig, store domain.Store,
 	return task
 }
 
-func jobMaintenanceTask(cfg *config.JobConfig, store domain.Store, serviceCmd domain.ServiceCommander, wg *sync.WaitGroup) gocron.Task {
+// jobRetentionPolicy returns the retention windows of the finished jobs
+func jobRetentionPolicy(cfg *config.JobConfig) domain.JobRetentionPolicy {
+	return domain.JobRetentionPolicy{
+		Succeeded:    cfg.Retention,
+		Failed:       cfg.FailedRetention,
+		DeadLettered: cfg.DeadLetterRetention,
+		BatchSize:    cfg.RetentionBatchSize,
+	}
+}
+
+func jobMaintenanceTask(cfg *config.JobConfig, timeouts domain.JobTimeouts, store domain.Store, serviceCmd domain.ServiceCommander, idleStopper *domain.ServiceIdleStopper, idempotencyStore domain.IdempotencyStore, wg *sync.WaitGroup) gocron.Task {
 	task := gocron.NewTask(
-		func(cfg *config.JobConfig, store domain.Store, serviceCmd domain.ServiceCommander, wg *sync.WaitGroup) {
+		func(cfg *config.JobConfig, timeouts domain.JobTimeouts, store domain.Store, serviceCmd domain.ServiceCommander, idleStopper *domain.ServiceIdleStopper, idempotencyStore domain.IdempotencyStore, wg *sync.WaitGroup) {
 			wg.Add(1)
 			defer wg.Done()
-			ctx := context.Background()
+			ctx := workerContext()
+
+			// Promote scheduled jobs whose time has come
+			slog.InfoContext(ctx, "Promoting scheduled jobs")
+			promotedCount, err := serviceCmd.PromoteScheduledJobs(ctx)
+			if err != nil {
+				slog.ErrorContext(ctx, "Failed to promote scheduled jobs", "error", err)
+			} else {
+				slog.InfoContext(ctx, "Scheduled jobs promoted", "count", promotedCount)
+			}
 
 			// Fail timeout jobs an services
-			slog.Info("Checking timeout jobs")
-			failedCount, err := serviceCmd.FailTimeoutServicesAndJobs(ctx, cfg.Timeout)
+			slog.InfoContext(ctx, "Checking timeout jobs")
+			failedCount, err := serviceCmd.FailTimeoutServicesAndJobs(ctx, timeouts, cfg.MaxAttempts)
+			if err != nil {
+				slog.ErrorContext(ctx, "Failed to timeout jobs and services", "error", err)
+			} else {
+				slog.InfoContext(ctx, "Timeout jobs processed", "failed_count", failedCount)
+			}
+
+			// Stop the services inactive beyond their idle timeout
+			slog.InfoContext(ctx, "Stopping idle services")
+			stoppedCount, err := idleStopper.StopIdle(ctx)
 			if err != nil {
-				slog.Error("Failed to timeout jobs and services", "error", err)
+				slog.ErrorContext(ctx, "Failed to stop idle services", "error", err)
 			} else {
-				slog.Info("Timeout jobs processed", "failed_count", failedCount)
+				slog.InfoContext(ctx, "Idle services stopped", "count", stoppedCount)
+			}
+
+			// Delete the finished jobs older than the retention window of their class
+			slog.InfoContext(ctx, "Deleting old jobs")
+			deletedCounts, err := domain.DeleteExpiredJobs(ctx, store.JobRepo(), jobRetentionPolicy(cfg), time.Now())
+			for _, class := range domain.JobRetentionClasses {
+				if count, ok := deletedCounts[class]; ok {
+					slog.InfoContext(ctx, "Old jobs deleted", "class", class, "count", count)
+				}
+			}
+			if err != nil {
+				slog.ErrorContext(ctx, "Failed to delete old jobs", "error", err)
 			}
 
-			// Delete completed/failed old jobs
-			slog.Info("Deleting old jobs")
-			deletedCount, err := store.JobRepo().DeleteOldCompletedJobs(ctx, cfg.Retention)
+			// Purge the services deleted before the restore window
+			slog.InfoContext(ctx, "Purging deleted services")
+			purgedCount, err := serviceCmd.PurgeDeletedServices(ctx)
 			if err != nil {
-				slog.Error("Failed to delete old jobs", "error", err)
+				slog.ErrorContext(ctx, "Failed to purge deleted services", "error", err)
 			} else {
-				slog.Info("Old jobs deleted", "count", deletedCount)
+				slog.InfoContext(ctx, "Deleted services purged", "count", purgedCount)
+			}
+
+			// Purge the expired idempotency keys
+			expiredCount, err := idempotencyStore.PurgeExpired(ctx, time.Now())
+			if err != nil {
+				slog.ErrorContext(ctx, "Failed to purge expired idempotency keys", "error", err)
+			} else if expiredCount > 0 {
+				slog.InfoContext(ctx, "Expired idempotency keys purged", "count", expiredCount)
 			}
 		},
 		cfg,
+		timeouts,
 		store,
 		serviceCmd,
+		idleStopper,
+		idempotencyStore,
+		wg,
+	)
+
+	return task
+}
+
+func jobLeaseReclaimTask(jobCmd domain.JobCommander, wg *sync.WaitGroup) gocron.Task {
+	task := gocron.NewTask(
+		func(jobCmd domain.JobCommander, wg *sync.WaitGroup) {
+			wg.Add(1)
+			defer wg.Done()
+			ctx := workerContext()
+
+			// Take back the jobs of the agents that stopped renewing their lease
+			reclaimedCount, err := jobCmd.ReclaimExpiredLeases(ctx)
+			if err != nil {
+				slog.ErrorContext(ctx, "Failed to reclaim expired job leases", "error", err)
+			}
+			if reclaimedCount > 0 {
+				slog.InfoContext(ctx, "Expired job leases reclaimed", "count", reclaimedCount)
+			}
+		},
+		jobCmd,
+		wg,
+	)
+
+	return task
+}
+
+func webhookDeliveryTask(deliverer *domain.EventWebhookDeliverer, wg *sync.WaitGroup) gocron.Task {
+	task := gocron.NewTask(
+		func(deliverer *domain.EventWebhookDeliverer, wg *sync.WaitGroup) {
+			wg.Add(1)
+			defer wg.Done()
+			ctx := workerContext()
+
+			deliveredCount, err := deliverer.DeliverDue(ctx)
+			if err != nil {
+				slog.ErrorContext(ctx, "Failed to deliver webhook events", "error", err)
+			}
+			if deliveredCount > 0 {
+				slog.InfoContext(ctx, "Webhook events delivered", "count", deliveredCount)
+			}
+		},
+		deliverer,
+		wg,
+	)
+
+	return task
+}
+
+func vaultMaintenanceTask(vaultSecretCmd domain.VaultSecretCommander, wg *sync.WaitGroup) gocron.Task {
+	task := gocron.NewTask(
+		func(vaultSecretCmd domain.VaultSecretCommander, wg *sync.WaitGroup) {
+			wg.Add(1)
+			defer wg.Done()
+			ctx := workerContext()
+
+			// Purge the previous secret versions whose rotation grace period elapsed
+			purgedCount, err := vaultSecretCmd.PurgeExpiredVersions(ctx)
+			if err != nil {
+				slog.ErrorContext(ctx, "Failed to purge expired secret versions", "error", err)
+			}
+			if purgedCount > 0 {
+				slog.InfoContext(ctx, "Expired secret versions purged", "count", purgedCount)
+			}
+		},
+		vaultSecretCmd,
+		wg,
+	)
+
+	return task
+}
+
+func tokenMaintenanceTask(cfg *config.TokenConfig, store domain.Store, wg *sync.WaitGroup) gocron.Task {
+	task := gocron.NewTask(
+		func(cfg *config.TokenConfig, store domain.Store, wg *sync.WaitGroup) {
+			wg.Add(1)
+			defer wg.Done()
+			ctx := workerContext()
+
+			// Report the tokens unused beyond the window, candidates for revocation
+			threshold := time.Now().Add(-cfg.UnusedWindow)
+			tokens, err := store.TokenRepo().FindUnusedSince(ctx, threshold)
+			if err != nil {
+				slog.ErrorContext(ctx, "Failed to find unused tokens", "error", err)
+				return
+			}
+			for _, token := range tokens {
+				slog.WarnContext(ctx, "Token unused",
+					"id", token.ID,
+					"name", token.Name,
+					"role", token.Role,
+					"lastUsedAt", token.LastUsedAt,
+					"createdAt", token.CreatedAt,
+					"expireAt", token.ExpireAt,
+				)
+			}
+			slog.InfoContext(ctx, "Unused tokens report", "count", len(tokens), "since", threshold)
+
+			// Warn the owners of the tokens expiring within the window, and of the expired ones
+			if cfg.ExpiryWarningWindow > 0 {
+				warnedCount, err := domain.WarnExpiringTokens(ctx, store, cfg.ExpiryWarningWindow, time.Now())
+				if err != nil {
+					slog.ErrorContext(ctx, "Failed to warn expiring tokens", "error", err)
+				} else if warnedCount > 0 {
+					slog.InfoContext(ctx, "Expiring tokens warned", "count", warnedCount)
+				}
+			}
+		},
+		cfg,
+		store,
 		wg,
 	)
 
@@ -0,0 +1,132 @@
+package app
+
+import (
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/fulcrumproject/core/pkg/gormlock"
+	"github.com/go-co-op/gocron/v2"
+	"github.com/google/uuid"
+)
+
+// WorkerStatus reports the schedule and the last run of a periodic worker, e.g. for health checks
+type WorkerStatus struct {
+	Name      string
+	Interval  time.Duration
+	Jitter    time.Duration
+	Running   bool
+	Leading   bool       // Whether the replica runs the worker, always true without leader election
+	LastRunAt *time.Time // Start of the last pass, nil before the first one
+}
+
+// workerSchedule runs a task every interval plus a random jitter, so the replicas of a
+// deployment do not all run it at the same instant, and tracks the runs of the task.
+// A tick occurring while the previous pass is still running is skipped.
+type workerSchedule struct {
+	name     string
+	interval time.Duration
+	jitter   time.Duration
+	now      func() time.Time
+	leader   *gormlock.AdvisoryLeader // Only the leader runs the task when set
+
+	mu        sync.Mutex
+	running   bool
+	lastRunAt *time.Time
+}
+
+// newWorkerSchedule validates the interval and the jitter of a worker schedule
+func newWorkerSchedule(name string, interval, jitter time.Duration) (*workerSchedule, error) {
+	if interval <= 0 {
+		return nil, fmt.Errorf("invalid %s interval %s: must be positive", name, interval)
+	}
+	if jitter < 0 {
+		return nil, fmt.Errorf("invalid %s jitter %s: cannot be negative", name, jitter)
+	}
+	return &workerSchedule{
+		name:     name,
+		interval: interval,
+		jitter:   jitter,
+		now:      time.Now,
+	}, nil
+}
+
+// definition returns the job definition running the task every interval to interval+jitter
+func (s *workerSchedule) definition() gocron.JobDefinition {
+	if s.jitter == 0 {
+		return gocron.DurationJob(s.interval)
+	}
+	return gocron.DurationRandomJob(s.interval, s.interval+s.jitter)
+}
+
+// schedule adds the task to the scheduler
+func (s *workerSchedule) schedule(task gocron.Task, scheduler *gocron.Scheduler) error {
+	options := []gocron.JobOption{
+		gocron.WithName(s.name),
+		gocron.WithSingletonMode(gocron.LimitModeReschedule),
+		gocron.WithEventListeners(
+			gocron.BeforeJobRunsSkipIfBeforeFuncErrors(s.begin),
+			gocron.AfterJobRuns(s.end),
+			gocron.AfterJobRunsWithError(func(jobID uuid.UUID, jobName string, _ error) { s.end(jobID, jobName) }),
+			gocron.AfterJobRunsWithPanic(func(jobID uuid.UUID, jobName string, _ any) { s.end(jobID, jobName) }),
+		),
+	}
+	if s.leader != nil {
+		// The leader replaces the scheduler locker, the other replicas try to take over at each tick
+		options = append(options, gocron.WithDistributedJobLocker(s.leader))
+	}
+	j, err := (*scheduler).NewJob(s.definition(), task, options...)
+	if err != nil {
+		slog.Error("Failed to create job", "error", err)
+		return err
+	}
+
+	slog.Info("Job ID", "id", j.ID(), "name", s.name, "interval", s.interval, "jitter", s.jitter)
+	return nil
+}
+
+// begin records the start of a pass, it fails while the previous pass is running so the tick is skipped
+func (s *workerSchedule) begin(_ uuid.UUID, _ string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.running {
+		slog.Warn("Skipping worker run, the previous one is still running", "name", s.name)
+		return fmt.Errorf("%s is still running", s.name)
+	}
+	now := s.now()
+	s.running = true
+	s.lastRunAt = &now
+	return nil
+}
+
+// end records the end of a pass
+func (s *workerSchedule) end(_ uuid.UUID, _ string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.running = false
+}
+
+// close gives up the leadership of the worker
+func (s *workerSchedule) close() {
+	if s.leader == nil {
+		return
+	}
+	if err := s.leader.Close(); err != nil {
+		slog.Error("Failed to release the worker leadership", "name", s.name, "error", err)
+	}
+}
+
+// Status returns the schedule and the last run of the worker
+func (s *workerSchedule) Status() WorkerStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return WorkerStatus{
+		Name:      s.name,
+		Interval:  s.interval,
+		Jitter:    s.jitter,
+		Running:   s.running,
+		Leading:   s.leader == nil || s.leader.Leading(),
+		LastRunAt: s.lastRunAt,
+	}
+}
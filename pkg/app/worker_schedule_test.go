@@ -0,0 +1,88 @@
+package app
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-co-op/gocron/v2"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewWorkerSchedule(t *testing.T) {
+	tests := []struct {
+		name     string
+		interval time.Duration
+		jitter   time.Duration
+		wantErr  string
+	}{
+		{name: "Valid", interval: time.Minute, jitter: time.Second},
+		{name: "Without jitter", interval: time.Minute},
+		{name: "Zero interval", interval: 0, wantErr: "invalid test interval 0s: must be positive"},
+		{name: "Negative interval", interval: -time.Second, wantErr: "invalid test interval -1s: must be positive"},
+		{name: "Negative jitter", interval: time.Minute, jitter: -time.Second, wantErr: "invalid test jitter -1s: cannot be negative"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			s, err := newWorkerSchedule("test", tc.interval, tc.jitter)
+			if tc.wantErr != "" {
+				assert.EqualError(t, err, tc.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, WorkerStatus{Name: "test", Interval: tc.interval, Jitter: tc.jitter, Leading: true}, s.Status())
+		})
+	}
+}
+
+func TestWorkerScheduleRuns(t *testing.T) {
+	s, err := newWorkerSchedule("test", time.Minute, 0)
+	require.NoError(t, err)
+	now := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	s.now = func() time.Time { return now }
+
+	require.NoError(t, s.begin(uuid.Nil, "test"))
+	status := s.Status()
+	assert.True(t, status.Running)
+	require.NotNil(t, status.LastRunAt)
+	assert.Equal(t, now, *status.LastRunAt)
+
+	// A tick during the running pass is skipped
+	now = now.Add(time.Minute)
+	assert.Error(t, s.begin(uuid.Nil, "test"))
+	assert.Equal(t, now.Add(-time.Minute), *s.Status().LastRunAt)
+
+	s.end(uuid.Nil, "test")
+	assert.False(t, s.Status().Running)
+	require.NoError(t, s.begin(uuid.Nil, "test"))
+	assert.Equal(t, now, *s.Status().LastRunAt)
+}
+
+func TestWorkerScheduleDoesNotOverlap(t *testing.T) {
+	scheduler, err := gocron.NewScheduler()
+	require.NoError(t, err)
+	defer scheduler.Shutdown()
+
+	s, err := newWorkerSchedule("test", 10*time.Millisecond, 5*time.Millisecond)
+	require.NoError(t, err)
+
+	var running, maxRunning, runs atomic.Int32
+	task := gocron.NewTask(func() {
+		current := running.Add(1)
+		defer running.Add(-1)
+		if current > maxRunning.Load() {
+			maxRunning.Store(current)
+		}
+		runs.Add(1)
+		time.Sleep(50 * time.Millisecond) // Longer than the interval
+	})
+	require.NoError(t, s.schedule(task, &scheduler))
+	scheduler.Start()
+
+	assert.Eventually(t, func() bool { return runs.Load() >= 2 }, 2*time.Second, 10*time.Millisecond)
+	assert.Equal(t, int32(1), maxRunning.Load())
+	assert.NotNil(t, s.Status().LastRunAt)
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"slices"
 
 	"github.com/fulcrumproject/core/pkg/properties"
 )
@@ -17,41 +18,68 @@ const (
 	RoleAgent       Role = "agent"
 )
 
-// Validate ensures the Role is one of the predefined values
-func (r Role) Validate() error {
+// customRoles are the roles defined by configuration in addition to the predefined ones
+var customRoles = map[Role]bool{}
+
+// RegisterCustomRoles makes the roles valid, it must be called at startup before authenticating any request
+func RegisterCustomRoles(roles ...Role) {
+	for _, role := range roles {
+		customRoles[role] = true
+	}
+}
+
+// IsPredefined reports whether the role is one of the predefined roles
+func (r Role) IsPredefined() bool {
 	switch r {
 	case RoleAdmin, RoleParticipant, RoleAgent:
-		return nil
+		return true
 	default:
-		return fmt.Errorf("invalid auth role: %s", r)
+		return false
 	}
 }
 
+// Validate ensures the Role is one of the predefined values or a registered custom role
+func (r Role) Validate() error {
+	if r.IsPredefined() || customRoles[r] {
+		return nil
+	}
+	return fmt.Errorf("invalid auth role: %s", r)
+}
+
 // Identity implements the Identifier interface
 type Identity struct {
-	ID    properties.UUID
-	Name  string
-	Role  Role
-	Scope IdentityScope
+	ID   properties.UUID
+	Name string
+	Role Role
+	// AdditionalRoles are granted on top of Role, e.g. to OAuth users belonging to several mapped groups
+	AdditionalRoles []Role
+	Scope           IdentityScope
+}
+
+// Roles returns the primary role followed by the additional roles
+func (m *Identity) Roles() []Role {
+	return append([]Role{m.Role}, m.AdditionalRoles...)
 }
 
 func (m *Identity) HasRole(role Role) bool {
-	return m.Role == role
+	return slices.Contains(m.Roles(), role)
 }
 
 // validateRoleRequirements ensures that role-specific ID requirements are met
 func (m *Identity) Validate() error {
-	switch m.Role {
-	case RoleParticipant:
-		if m.Scope.ParticipantID == nil {
-			return errors.New("participant role requires participant id")
-		}
-	case RoleAgent:
-		if m.Scope.ParticipantID == nil {
-			return errors.New("agent role requires participant id")
-		}
-		if m.Scope.AgentID == nil {
-			return errors.New("agent role requires agent id")
+	for _, role := range m.Roles() {
+		switch role {
+		case RoleParticipant:
+			if m.Scope.ParticipantID == nil {
+				return errors.New("participant role requires participant id")
+			}
+		case RoleAgent:
+			if m.Scope.ParticipantID == nil {
+				return errors.New("agent role requires participant id")
+			}
+			if m.Scope.AgentID == nil {
+				return errors.New("agent role requires agent id")
+			}
 		}
 	}
 
@@ -61,6 +89,13 @@ func (m *Identity) Validate() error {
 type IdentityScope struct {
 	ParticipantID *properties.UUID
 	AgentID       *properties.UUID
+	// GroupIDs restricts the identity to these service groups of its participant, empty allows all of them
+	GroupIDs []properties.UUID
+}
+
+// AllowsGroup reports whether the scope gives access to the service group
+func (s *IdentityScope) AllowsGroup(groupID properties.UUID) bool {
+	return len(s.GroupIDs) == 0 || slices.Contains(s.GroupIDs, groupID)
 }
 
 type Authenticator interface {
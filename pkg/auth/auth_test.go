@@ -53,6 +53,19 @@ func TestRole_Validate(t *testing.T) {
 	}
 }
 
+func TestRegisterCustomRoles(t *testing.T) {
+	role := Role("auditor-test")
+	defer delete(customRoles, role)
+
+	assert.Error(t, role.Validate())
+	assert.False(t, role.IsPredefined())
+
+	RegisterCustomRoles(role)
+	assert.NoError(t, role.Validate())
+	assert.False(t, role.IsPredefined())
+	assert.True(t, RoleAdmin.IsPredefined())
+}
+
 func TestIdentity_HasRole(t *testing.T) {
 	identity := &Identity{
 		Role: RoleAdmin,
@@ -88,6 +101,18 @@ func TestIdentity_HasRole(t *testing.T) {
 	}
 }
 
+func TestIdentity_HasRole_AdditionalRoles(t *testing.T) {
+	identity := &Identity{
+		Role:            RoleAdmin,
+		AdditionalRoles: []Role{RoleParticipant},
+	}
+
+	assert.Equal(t, []Role{RoleAdmin, RoleParticipant}, identity.Roles())
+	assert.True(t, identity.HasRole(RoleAdmin))
+	assert.True(t, identity.HasRole(RoleParticipant))
+	assert.False(t, identity.HasRole(RoleAgent))
+}
+
 func TestIdentity_Validate(t *testing.T) {
 	// Helper to create test UUIDs
 	testUUID := properties.NewUUID()
@@ -168,6 +193,15 @@ func TestIdentity_Validate(t *testing.T) {
 			expectError: true,
 			errorMsg:    "agent role requires agent id",
 		},
+		{
+			name: "Invalid additional participant role - missing participant ID",
+			identity: &Identity{
+				Role:            RoleAdmin,
+				AdditionalRoles: []Role{RoleParticipant},
+			},
+			expectError: true,
+			errorMsg:    "participant role requires participant id",
+		},
 	}
 
 	for _, tt := range tests {
@@ -182,3 +216,15 @@ func TestIdentity_Validate(t *testing.T) {
 		})
 	}
 }
+
+func TestIdentityScope_AllowsGroup(t *testing.T) {
+	groupID := properties.NewUUID()
+	otherID := properties.NewUUID()
+
+	unrestricted := IdentityScope{}
+	assert.True(t, unrestricted.AllowsGroup(groupID), "no group restriction allows all groups")
+
+	restricted := IdentityScope{GroupIDs: []properties.UUID{groupID}}
+	assert.True(t, restricted.AllowsGroup(groupID))
+	assert.False(t, restricted.AllowsGroup(otherID))
+}
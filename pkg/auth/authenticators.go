@@ -26,7 +26,7 @@ func (c *CompositeAuthenticator) Authenticate(ctx context.Context, token string)
 	for _, authenticator := range c.authenticators {
 		identity, err := authenticator.Authenticate(ctx, token)
 		if err != nil {
-			slog.Error("Authentication error", "error", err)
+			slog.ErrorContext(ctx, "Authentication error", "error", err)
 			continue
 		}
 		if identity != nil {
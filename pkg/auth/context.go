@@ -21,3 +21,9 @@ func MustGetIdentity(ctx context.Context) *Identity {
 	}
 	return id
 }
+
+// GetIdentity retrieves the authenticated identity from the context, nil when there is none
+func GetIdentity(ctx context.Context) *Identity {
+	id, _ := ctx.Value(identityContextKey).(*Identity)
+	return id
+}
@@ -14,35 +14,39 @@ type AuthorizationRule struct {
 	Object ObjectType
 }
 
-// RuleBasedAuthorizer implements the Authorizer interface using a set of predefined rules
+// RuleBasedAuthorizer implements the Authorizer interface using the permissions granted to the roles
 type RuleBasedAuthorizer struct {
-	rules []AuthorizationRule
+	roles RolePermissions
 }
 
-// NewRuleBasedAuthorizer creates a new RuleBasedAuthorizer with the given rules
-func NewRuleBasedAuthorizer(rules []AuthorizationRule) *RuleBasedAuthorizer {
+// NewRuleBasedAuthorizer creates a new RuleBasedAuthorizer granting the predefined roles the permissions of the rules
+// The custom roles are granted their own permissions, they cannot change the ones of the predefined roles
+func NewRuleBasedAuthorizer(rules []AuthorizationRule, customRoles ...RolePermissions) *RuleBasedAuthorizer {
+	roles := DefaultRolePermissions(rules)
+	for _, custom := range customRoles {
+		for role, permissions := range custom {
+			if !role.IsPredefined() {
+				roles[role] = permissions
+			}
+		}
+	}
 	return &RuleBasedAuthorizer{
-		rules: rules,
+		roles: roles,
 	}
 }
 
 // Authorize checks if the given identity is authorized to perform the action on the object
-// It matches against the predefined rules based on the identity's roles
+// It checks that one of the roles of the identity has a permission granting the action
 func (a *RuleBasedAuthorizer) Authorize(identity *auth.Identity, action Action, object ObjectType, objectContext ObjectScope) error {
 	// Check if the object context matches the identity (for context-specific authorization)
 	if objectContext != nil && !objectContext.Matches(identity) {
 		return fmt.Errorf("access denied: object context does not match identity")
 	}
 
-	// Check if any of the identity's roles match the authorization rules
-	for _, rule := range a.rules {
-		if rule.Action == action && rule.Object == object {
-			// Check if identity has any of the required roles
-			for _, requiredRole := range rule.Roles {
-				if identity.HasRole(requiredRole) {
-					return nil // Authorization successful
-				}
-			}
+	// Check if one of the identity's roles is granted the action
+	for _, role := range identity.Roles() {
+		if a.roles.Grants(role, object, action) {
+			return nil // Authorization successful
 		}
 	}
 
@@ -296,6 +296,46 @@ func TestRuleBasedAuthorizer_Authorize_NilObjectContext(t *testing.T) {
 	assert.NoError(t, err, "Should succeed when object context is nil")
 }
 
+func TestRuleBasedAuthorizer_Authorize_CustomRoles(t *testing.T) {
+	participantID := properties.NewUUID()
+	otherParticipantID := properties.NewUUID()
+	customRoles := RolePermissions{
+		"auditor":      {NewPermission(ObjectTypeEvent, ActionRead), NewPermission(ObjectTypeService, ActionRead)},
+		auth.RoleAdmin: {}, // predefined roles cannot be redefined
+	}
+	authorizer := NewRuleBasedAuthorizer(Rules, customRoles)
+
+	auditor := &auth.Identity{Role: "auditor"}
+	scopedAuditor := &auth.Identity{Role: "auditor", Scope: auth.IdentityScope{ParticipantID: &participantID}}
+
+	assert.NoError(t, authorizer.Authorize(auditor, ActionRead, ObjectTypeEvent, AllwaysMatchObjectScope{}))
+	assert.NoError(t, authorizer.Authorize(auditor, ActionRead, ObjectTypeService, &DefaultObjectScope{ProviderID: &otherParticipantID}))
+	assert.Error(t, authorizer.Authorize(auditor, ActionUpdate, ObjectTypeService, AllwaysMatchObjectScope{}))
+	assert.Error(t, authorizer.Authorize(auditor, ActionRead, ObjectTypeToken, AllwaysMatchObjectScope{}))
+
+	// Participant scoped custom roles only see their participant objects
+	assert.NoError(t, authorizer.Authorize(scopedAuditor, ActionRead, ObjectTypeService, &DefaultObjectScope{ConsumerID: &participantID}))
+	assert.Error(t, authorizer.Authorize(scopedAuditor, ActionRead, ObjectTypeService, &DefaultObjectScope{ConsumerID: &otherParticipantID}))
+
+	// The predefined roles keep the permissions of the rules
+	assert.NoError(t, authorizer.Authorize(&auth.Identity{Role: auth.RoleAdmin}, ActionDelete, ObjectTypeService, AllwaysMatchObjectScope{}))
+}
+
+func TestRuleBasedAuthorizer_Authorize_AdditionalRoles(t *testing.T) {
+	customRoles := RolePermissions{
+		"auditor": {NewPermission(ObjectTypeEvent, ActionRead)},
+		"billing": {NewPermission(ObjectTypeMetricEntry, ActionRead)},
+	}
+	authorizer := NewRuleBasedAuthorizer(Rules, customRoles)
+
+	identity := &auth.Identity{Role: "auditor", AdditionalRoles: []auth.Role{"billing"}}
+
+	// The identity is granted the union of the permissions of its roles
+	assert.NoError(t, authorizer.Authorize(identity, ActionRead, ObjectTypeEvent, AllwaysMatchObjectScope{}))
+	assert.NoError(t, authorizer.Authorize(identity, ActionRead, ObjectTypeMetricEntry, AllwaysMatchObjectScope{}))
+	assert.Error(t, authorizer.Authorize(identity, ActionRead, ObjectTypeService, AllwaysMatchObjectScope{}))
+}
+
 // mockObjectScope is a test helper that implements ObjectScope
 type mockObjectScope struct {
 	shouldMatch bool
@@ -3,21 +3,25 @@ package authz
 
 import (
 	"fmt"
+	"slices"
 
 	"github.com/fulcrumproject/core/pkg/auth"
+	"github.com/fulcrumproject/core/pkg/properties"
 )
 
-// TokenCreationScope wraps an ObjectScope and carries the target role for token creation
+// TokenCreationScope wraps an ObjectScope and carries the target role and service groups for token creation
 type TokenCreationScope struct {
 	ObjectScope // Embed to delegate Matches() automatically
 	targetRole  auth.Role
+	groupIDs    []properties.UUID
 }
 
-// NewTokenCreationScope creates a scope for token creation with target role information
-func NewTokenCreationScope(targetRole auth.Role, scope ObjectScope) *TokenCreationScope {
+// NewTokenCreationScope creates a scope for token creation with target role and service group information
+func NewTokenCreationScope(targetRole auth.Role, scope ObjectScope, groupIDs ...properties.UUID) *TokenCreationScope {
 	return &TokenCreationScope{
 		ObjectScope: scope,
 		targetRole:  targetRole,
+		groupIDs:    groupIDs,
 	}
 }
 
@@ -26,6 +30,11 @@ func (s *TokenCreationScope) TargetRole() auth.Role {
 	return s.targetRole
 }
 
+// GroupIDs returns the service groups the token being created is restricted to
+func (s *TokenCreationScope) GroupIDs() []properties.UUID {
+	return s.groupIDs
+}
+
 // TokenAuthorizer wraps the default authorizer and adds token-specific role validation
 type TokenAuthorizer struct {
 	wrapped Authorizer
@@ -39,7 +48,8 @@ func NewTokenAuthorizer(wrapped Authorizer) *TokenAuthorizer {
 }
 
 // Authorize performs authorization with token-specific role validation
-// For token creation, it first validates that the identity can create tokens with the target role,
+// For token creation, it first validates that the identity can create tokens with the target role
+// and, unless it is an admin, that the token is scoped to its own participant,
 // then delegates to the wrapped authorizer for scope validation
 func (a *TokenAuthorizer) Authorize(
 	identity *auth.Identity,
@@ -54,12 +64,11 @@ func (a *TokenAuthorizer) Authorize(
 		}
 
 		// Extract target role from TokenCreationScope if available
-		var targetRole auth.Role
-		if tcs, ok := objectScope.(*TokenCreationScope); ok {
-			targetRole = tcs.TargetRole()
-		} else {
+		tcs, ok := objectScope.(*TokenCreationScope)
+		if !ok {
 			return fmt.Errorf("access denied: no target role provided")
 		}
+		targetRole := tcs.TargetRole()
 
 		// Validate role permissions
 		if !canCreateTokenWithRole(identity, targetRole) {
@@ -69,6 +78,16 @@ func (a *TokenAuthorizer) Authorize(
 				targetRole,
 			)
 		}
+
+		// Self-service tokens are confined to the participant of the caller
+		if identity.Role != auth.RoleAdmin && !isOwnTokenScope(identity, tcs.ObjectScope) {
+			return fmt.Errorf("access denied: role %s can only create tokens scoped to its own participant", identity.Role)
+		}
+
+		// Tokens created by a caller restricted to some service groups are restricted to them too
+		if !isWithinGroupScope(identity, targetRole, tcs.GroupIDs()) {
+			return fmt.Errorf("access denied: tokens can only be restricted to service groups of the caller")
+		}
 	}
 
 	// Delegate to wrapped authorizer for standard authorization
@@ -94,3 +113,36 @@ func canCreateTokenWithRole(identity *auth.Identity, targetRole auth.Role) bool
 	// Agents cannot create tokens
 	return false
 }
+
+// isWithinGroupScope checks that a caller restricted to some service groups only creates participant
+// tokens restricted to a subset of them, since agent and unrestricted tokens would widen its access
+func isWithinGroupScope(identity *auth.Identity, targetRole auth.Role, groupIDs []properties.UUID) bool {
+	if len(identity.Scope.GroupIDs) == 0 {
+		return true
+	}
+	if targetRole != auth.RoleParticipant || len(groupIDs) == 0 {
+		return false
+	}
+	for _, groupID := range groupIDs {
+		if !slices.Contains(identity.Scope.GroupIDs, groupID) {
+			return false
+		}
+	}
+	return true
+}
+
+// isOwnTokenScope checks that the scope of the token being created belongs to the participant of the identity:
+// the participant itself for participant tokens, or one of its agents for agent tokens
+// Unlike the generic scope matching, a scope without participant never matches
+func isOwnTokenScope(identity *auth.Identity, scope ObjectScope) bool {
+	if identity.Scope.ParticipantID == nil {
+		return false
+	}
+	target, ok := scope.(*DefaultObjectScope)
+	if !ok || target == nil {
+		return false
+	}
+	own := *identity.Scope.ParticipantID
+	return (target.ParticipantID != nil && *target.ParticipantID == own) ||
+		(target.ProviderID != nil && *target.ProviderID == own)
+}
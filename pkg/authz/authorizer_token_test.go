@@ -0,0 +1,84 @@
+package authz
+
+import (
+	"testing"
+
+	"github.com/fulcrumproject/core/pkg/auth"
+	"github.com/fulcrumproject/core/pkg/properties"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokenAuthorizer_Create(t *testing.T) {
+	own := properties.NewUUID()
+	other := properties.NewUUID()
+	agentID := properties.NewUUID()
+	admin := &auth.Identity{Role: auth.RoleAdmin}
+	participant := &auth.Identity{Role: auth.RoleParticipant, Scope: auth.IdentityScope{ParticipantID: &own}}
+	agent := &auth.Identity{Role: auth.RoleAgent, Scope: auth.IdentityScope{ParticipantID: &own, AgentID: &agentID}}
+
+	authorizer := NewTokenAuthorizer(NewRuleBasedAuthorizer(Rules))
+
+	tests := []struct {
+		name        string
+		identity    *auth.Identity
+		targetRole  auth.Role
+		scope       ObjectScope
+		expectError bool
+	}{
+		{"Admin creates admin token", admin, auth.RoleAdmin, &AllwaysMatchObjectScope{}, false},
+		{"Admin creates token for any participant", admin, auth.RoleParticipant, &DefaultObjectScope{ParticipantID: &other}, false},
+		{"Participant creates own participant token", participant, auth.RoleParticipant, &DefaultObjectScope{ParticipantID: &own}, false},
+		{"Participant creates token for own agent", participant, auth.RoleAgent, &DefaultObjectScope{ProviderID: &own, AgentID: &agentID}, false},
+		{"Participant cannot create admin token", participant, auth.RoleAdmin, &AllwaysMatchObjectScope{}, true},
+		{"Participant cannot create token for another participant", participant, auth.RoleParticipant, &DefaultObjectScope{ParticipantID: &other}, true},
+		{"Participant cannot create token for another provider agent", participant, auth.RoleAgent, &DefaultObjectScope{ProviderID: &other, AgentID: &agentID}, true},
+		{"Participant cannot create unscoped token", participant, auth.RoleParticipant, &DefaultObjectScope{}, true},
+		{"Agent cannot create tokens", agent, auth.RoleAgent, &DefaultObjectScope{ProviderID: &own, AgentID: &agentID}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := authorizer.Authorize(tt.identity, ActionCreate, ObjectTypeToken, NewTokenCreationScope(tt.targetRole, tt.scope))
+			if tt.expectError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+
+	assert.Error(t, authorizer.Authorize(participant, ActionCreate, ObjectTypeToken, &DefaultObjectScope{ParticipantID: &own}), "the target role is required")
+}
+
+func TestTokenAuthorizer_CreateGroupRestricted(t *testing.T) {
+	own := properties.NewUUID()
+	agentID := properties.NewUUID()
+	groupID := properties.NewUUID()
+	otherGroupID := properties.NewUUID()
+	restricted := &auth.Identity{Role: auth.RoleParticipant, Scope: auth.IdentityScope{ParticipantID: &own, GroupIDs: []properties.UUID{groupID}}}
+	participantScope := &DefaultObjectScope{ParticipantID: &own}
+
+	authorizer := NewTokenAuthorizer(NewRuleBasedAuthorizer(Rules))
+
+	tests := []struct {
+		name        string
+		scope       *TokenCreationScope
+		expectError bool
+	}{
+		{"Token restricted to the same groups", NewTokenCreationScope(auth.RoleParticipant, participantScope, groupID), false},
+		{"Unrestricted token", NewTokenCreationScope(auth.RoleParticipant, participantScope), true},
+		{"Token restricted to another group", NewTokenCreationScope(auth.RoleParticipant, participantScope, groupID, otherGroupID), true},
+		{"Agent token", NewTokenCreationScope(auth.RoleAgent, &DefaultObjectScope{ProviderID: &own, AgentID: &agentID}, groupID), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := authorizer.Authorize(restricted, ActionCreate, ObjectTypeToken, tt.scope)
+			if tt.expectError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -37,6 +37,8 @@ type DefaultObjectScope struct {
 	ProviderID    *properties.UUID
 	ConsumerID    *properties.UUID
 	AgentID       *properties.UUID
+	// GroupID is the service group of the object, checked against the groups the identity is restricted to
+	GroupID *properties.UUID
 }
 
 // Matches checks if the given identity matches the object scope
@@ -50,6 +52,11 @@ func (target *DefaultObjectScope) Matches(id *auth.Identity) bool {
 		return true
 	}
 
+	// Group check: an identity restricted to some service groups cannot reach the objects of the others
+	if target.GroupID != nil && !id.Scope.AllowsGroup(*target.GroupID) {
+		return false
+	}
+
 	// If all fields are nil in the target scope, global access is allowed
 	if target.ParticipantID == nil && target.ProviderID == nil && target.ConsumerID == nil && target.AgentID == nil {
 		return true
@@ -265,3 +265,25 @@ func TestDefaultObjectScope_Matches(t *testing.T) {
 		})
 	}
 }
+
+func TestDefaultObjectScope_MatchesGroups(t *testing.T) {
+	participantID := properties.NewUUID()
+	groupID := properties.NewUUID()
+	otherGroupID := properties.NewUUID()
+	target := &DefaultObjectScope{ConsumerID: &participantID, GroupID: &groupID}
+
+	unrestricted := &auth.Identity{Role: auth.RoleParticipant, Scope: auth.IdentityScope{ParticipantID: &participantID}}
+	assert.True(t, target.Matches(unrestricted), "no group restriction allows all groups of the participant")
+
+	allowed := &auth.Identity{Role: auth.RoleParticipant, Scope: auth.IdentityScope{ParticipantID: &participantID, GroupIDs: []properties.UUID{groupID}}}
+	assert.True(t, target.Matches(allowed))
+
+	restricted := &auth.Identity{Role: auth.RoleParticipant, Scope: auth.IdentityScope{ParticipantID: &participantID, GroupIDs: []properties.UUID{otherGroupID}}}
+	assert.False(t, target.Matches(restricted), "groups out of the restriction are rejected")
+
+	otherParticipantID := properties.NewUUID()
+	otherParticipant := &auth.Identity{Role: auth.RoleParticipant, Scope: auth.IdentityScope{ParticipantID: &otherParticipantID, GroupIDs: []properties.UUID{groupID}}}
+	assert.False(t, target.Matches(otherParticipant), "the group restriction does not widen the participant scope")
+
+	assert.True(t, (&DefaultObjectScope{ConsumerID: &participantID}).Matches(restricted), "objects without group are not restricted")
+}
@@ -0,0 +1,133 @@
+// Permission-based role definitions
+package authz
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/fulcrumproject/core/pkg/auth"
+)
+
+// Permission grants an action on an object type, written as object:action
+type Permission string
+
+// NewPermission returns the permission granting the action on the object
+func NewPermission(object ObjectType, action Action) Permission {
+	return Permission(string(object) + ":" + string(action))
+}
+
+// Named permissions expanded to several actions when defining roles
+const (
+	permissionActionWrite = "write" // create, update and delete
+	permissionActionAny   = "*"     // every action of the object
+)
+
+// permissionAliases are the named permissions spanning several object types
+var permissionAliases = map[Permission][]Permission{
+	"audit:read": {
+		NewPermission(ObjectTypeEvent, ActionRead),
+	},
+	"metric:read": {
+		NewPermission(ObjectTypeMetricType, ActionRead),
+		NewPermission(ObjectTypeMetricEntry, ActionRead),
+		NewPermission(ObjectTypeMetrics, ActionRead),
+	},
+}
+
+// Expand returns the permissions granted by a permission of a role definition
+// Besides the aliases and the actions of the rules, object:write grants create, update and delete,
+// and object:* every action of the rules on the object
+func (p Permission) Expand(rules []AuthorizationRule) ([]Permission, error) {
+	if aliased, ok := permissionAliases[p]; ok {
+		return aliased, nil
+	}
+	object, action, ok := strings.Cut(string(p), ":")
+	if !ok || object == "" || action == "" {
+		return nil, fmt.Errorf("invalid permission %q: expected object:action", p)
+	}
+	if !slices.Contains(ObjectTypes, ObjectType(object)) {
+		return nil, fmt.Errorf("invalid permission %q: unknown object %s", p, object)
+	}
+
+	var actions []Action
+	for _, rule := range rules {
+		if rule.Object == ObjectType(object) && !slices.Contains(actions, rule.Action) {
+			actions = append(actions, rule.Action)
+		}
+	}
+	switch action {
+	case permissionActionAny:
+	case permissionActionWrite:
+		actions = slices.DeleteFunc(actions, func(a Action) bool {
+			return a != ActionCreate && a != ActionUpdate && a != ActionDelete
+		})
+	default:
+		if !slices.Contains(actions, Action(action)) {
+			return nil, fmt.Errorf("invalid permission %q: unknown action %s for object %s", p, action, object)
+		}
+		actions = []Action{Action(action)}
+	}
+
+	permissions := make([]Permission, 0, len(actions))
+	for _, a := range actions {
+		permissions = append(permissions, NewPermission(ObjectType(object), a))
+	}
+	return permissions, nil
+}
+
+// RolePermissions maps the roles to the permissions granted to them
+type RolePermissions map[auth.Role][]Permission
+
+// DefaultRolePermissions returns the permissions of the predefined roles as granted by the rules
+func DefaultRolePermissions(rules []AuthorizationRule) RolePermissions {
+	roles := RolePermissions{}
+	for _, rule := range rules {
+		for _, role := range rule.Roles {
+			roles[role] = append(roles[role], NewPermission(rule.Object, rule.Action))
+		}
+	}
+	return roles
+}
+
+// Grants reports whether the role is granted the action on the object
+func (r RolePermissions) Grants(role auth.Role, object ObjectType, action Action) bool {
+	return slices.Contains(r[role], NewPermission(object, action))
+}
+
+// ParseRolePermissions parses the custom role definitions, each as role=permission;permission,
+// expanding the permissions against the rules. Custom roles cannot redefine the predefined ones.
+func ParseRolePermissions(definitions []string, rules []AuthorizationRule) (RolePermissions, error) {
+	roles := make(RolePermissions, len(definitions))
+	for _, definition := range definitions {
+		name, list, ok := strings.Cut(definition, "=")
+		role := auth.Role(strings.TrimSpace(name))
+		if !ok || role == "" {
+			return nil, fmt.Errorf("invalid role definition %q: expected role=permission;permission", definition)
+		}
+		if role.IsPredefined() {
+			return nil, fmt.Errorf("invalid role definition %q: predefined role %s cannot be redefined", definition, role)
+		}
+		if _, exists := roles[role]; exists {
+			return nil, fmt.Errorf("invalid role definition %q: role %s is already defined", definition, role)
+		}
+		permissions := []Permission{}
+		for _, entry := range strings.Split(list, ";") {
+			entry = strings.TrimSpace(entry)
+			if entry == "" {
+				continue
+			}
+			expanded, err := Permission(entry).Expand(rules)
+			if err != nil {
+				return nil, fmt.Errorf("invalid role definition %q: %w", definition, err)
+			}
+			for _, p := range expanded {
+				if !slices.Contains(permissions, p) {
+					permissions = append(permissions, p)
+				}
+			}
+		}
+		roles[role] = permissions
+	}
+	return roles, nil
+}
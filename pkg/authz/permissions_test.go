@@ -0,0 +1,124 @@
+package authz
+
+import (
+	"testing"
+
+	"github.com/fulcrumproject/core/pkg/auth"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPermission_Expand(t *testing.T) {
+	tests := []struct {
+		name          string
+		permission    Permission
+		expected      []Permission
+		expectedError string
+	}{
+		{
+			name:       "Single action",
+			permission: "service:read",
+			expected:   []Permission{"service:read"},
+		},
+		{
+			name:       "Special action",
+			permission: "job:claim",
+			expected:   []Permission{"job:claim"},
+		},
+		{
+			name:       "Write",
+			permission: "service_type:write",
+			expected:   []Permission{"service_type:create", "service_type:update", "service_type:delete"},
+		},
+		{
+			name:       "Write only covers the actions of the object",
+			permission: "metric_entry:write",
+			expected:   []Permission{"metric_entry:create"},
+		},
+		{
+			name:       "Any action",
+			permission: "event_entry:*",
+			expected:   []Permission{"event_entry:read", "event_entry:lease", "event_entry:ack", "event_entry:subscribe"},
+		},
+		{
+			name:       "Alias",
+			permission: "metric:read",
+			expected:   []Permission{"metric_type:read", "metric_entry:read", "metrics:read"},
+		},
+		{
+			name:          "Missing action",
+			permission:    "service",
+			expectedError: "expected object:action",
+		},
+		{
+			name:          "Unknown object",
+			permission:    "billing:read",
+			expectedError: "unknown object billing",
+		},
+		{
+			name:          "Unknown action",
+			permission:    "service:claim",
+			expectedError: "unknown action claim",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			permissions, err := tc.permission.Expand(Rules)
+			if tc.expectedError != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tc.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			assert.ElementsMatch(t, tc.expected, permissions)
+		})
+	}
+}
+
+func TestDefaultRolePermissions(t *testing.T) {
+	roles := DefaultRolePermissions(Rules)
+
+	// Every rule is preserved as a permission of its roles
+	for _, rule := range Rules {
+		for _, role := range rule.Roles {
+			assert.True(t, roles.Grants(role, rule.Object, rule.Action), "%s %s:%s", role, rule.Object, rule.Action)
+		}
+	}
+	assert.False(t, roles.Grants(auth.RoleAgent, ObjectTypeToken, ActionCreate))
+	assert.False(t, roles.Grants(auth.RoleParticipant, ObjectTypeVaultSecret, ActionRead))
+}
+
+func TestParseRolePermissions(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		roles, err := ParseRolePermissions([]string{
+			"auditor=audit:read; service:read",
+			"billing = metric:read;service:read;metric:read",
+			"nobody=",
+		}, Rules)
+		require.NoError(t, err)
+
+		assert.ElementsMatch(t, []Permission{"event_entry:read", "service:read"}, roles["auditor"])
+		assert.ElementsMatch(t, []Permission{"metric_type:read", "metric_entry:read", "metrics:read", "service:read"}, roles["billing"])
+		assert.Empty(t, roles["nobody"])
+	})
+
+	tests := []struct {
+		name          string
+		definitions   []string
+		expectedError string
+	}{
+		{name: "Missing separator", definitions: []string{"auditor"}, expectedError: "expected role=permission"},
+		{name: "Missing role", definitions: []string{"=service:read"}, expectedError: "expected role=permission"},
+		{name: "Predefined role", definitions: []string{"admin=service:read"}, expectedError: "cannot be redefined"},
+		{name: "Duplicated role", definitions: []string{"auditor=service:read", "auditor=job:read"}, expectedError: "already defined"},
+		{name: "Invalid permission", definitions: []string{"auditor=billing:read"}, expectedError: "unknown object"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := ParseRolePermissions(tc.definitions, Rules)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tc.expectedError)
+		})
+	}
+}
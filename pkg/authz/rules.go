@@ -24,8 +24,35 @@ const (
 	ObjectTypeEvent             ObjectType = "event_entry"
 	ObjectTypeToken             ObjectType = "token"
 	ObjectTypeKeycloakUser      ObjectType = "keycloak_user"
+	ObjectTypeMetrics           ObjectType = "metrics"
+	ObjectTypeVaultSecret       ObjectType = "vault_secret"
 )
 
+// ObjectTypes lists all the object types, the permissions can only name these
+var ObjectTypes = []ObjectType{
+	ObjectTypeParticipant,
+	ObjectTypeAgent,
+	ObjectTypeAgentType,
+	ObjectTypeConfigPool,
+	ObjectTypeConfigPoolValue,
+	ObjectTypeService,
+	ObjectTypeServiceType,
+	ObjectTypeServiceGroup,
+	ObjectTypeServiceOptionType,
+	ObjectTypeServiceOption,
+	ObjectTypeServicePoolSet,
+	ObjectTypeServicePool,
+	ObjectTypeServicePoolValue,
+	ObjectTypeJob,
+	ObjectTypeMetricType,
+	ObjectTypeMetricEntry,
+	ObjectTypeEvent,
+	ObjectTypeToken,
+	ObjectTypeKeycloakUser,
+	ObjectTypeMetrics,
+	ObjectTypeVaultSecret,
+}
+
 const (
 	// Standard CRUD actions
 	ActionCreate Action = "create"
@@ -34,14 +61,23 @@ const (
 	ActionDelete Action = "delete"
 
 	// Special actions
-	ActionUpdateStatus  Action = "update_status"
-	ActionGenerateToken Action = "generate_token"
-	ActionClaim         Action = "claim"
-	ActionComplete      Action = "complete"
-	ActionFail          Action = "fail"
-	ActionListPending   Action = "list_pending"
-	ActionLease         Action = "lease"
-	ActionAck           Action = "ack"
+	ActionUpdateStatus   Action = "update_status"
+	ActionGenerateToken  Action = "generate_token"
+	ActionClaim          Action = "claim"
+	ActionComplete       Action = "complete"
+	ActionFail           Action = "fail"
+	ActionListPending    Action = "list_pending"
+	ActionLease          Action = "lease"
+	ActionAck            Action = "ack"
+	ActionSubscribe      Action = "subscribe"
+	ActionVerify         Action = "verify"
+	ActionRotate         Action = "rotate"
+	ActionRequeue        Action = "requeue"
+	ActionRenew          Action = "renew"
+	ActionReportStep     Action = "report_step"
+	ActionReconcile      Action = "reconcile"
+	ActionReportOrphaned Action = "report_orphaned"
+	ActionReassign       Action = "reassign"
 )
 
 // Default authorization rules for the system
@@ -70,6 +106,8 @@ var Rules = []AuthorizationRule{
 	{Object: ObjectTypeService, Action: ActionCreate, Roles: []auth.Role{auth.RoleAdmin, auth.RoleParticipant}},
 	{Object: ObjectTypeService, Action: ActionUpdate, Roles: []auth.Role{auth.RoleAdmin, auth.RoleParticipant}},
 	{Object: ObjectTypeService, Action: ActionDelete, Roles: []auth.Role{auth.RoleAdmin, auth.RoleParticipant}},
+	{Object: ObjectTypeService, Action: ActionReconcile, Roles: []auth.Role{auth.RoleAgent}},
+	{Object: ObjectTypeService, Action: ActionReportOrphaned, Roles: []auth.Role{auth.RoleAgent}},
 
 	// ServiceType permissions
 	{Object: ObjectTypeServiceType, Action: ActionRead, Roles: []auth.Role{auth.RoleAdmin, auth.RoleParticipant, auth.RoleAgent}},
@@ -82,6 +120,7 @@ var Rules = []AuthorizationRule{
 	{Object: ObjectTypeServiceGroup, Action: ActionCreate, Roles: []auth.Role{auth.RoleAdmin, auth.RoleParticipant}},
 	{Object: ObjectTypeServiceGroup, Action: ActionUpdate, Roles: []auth.Role{auth.RoleAdmin, auth.RoleParticipant}},
 	{Object: ObjectTypeServiceGroup, Action: ActionDelete, Roles: []auth.Role{auth.RoleAdmin, auth.RoleParticipant}},
+	{Object: ObjectTypeServiceGroup, Action: ActionReassign, Roles: []auth.Role{auth.RoleAdmin}},
 
 	// ServiceOptionType permissions (global resources - types readable by all, writable by admin only)
 	{Object: ObjectTypeServiceOptionType, Action: ActionRead, Roles: []auth.Role{auth.RoleAdmin, auth.RoleParticipant, auth.RoleAgent}},
@@ -115,10 +154,14 @@ var Rules = []AuthorizationRule{
 
 	// Job permissions
 	{Object: ObjectTypeJob, Action: ActionRead, Roles: []auth.Role{auth.RoleAdmin, auth.RoleParticipant, auth.RoleAgent}},
+	{Object: ObjectTypeJob, Action: ActionUpdate, Roles: []auth.Role{auth.RoleAdmin}},
 	{Object: ObjectTypeJob, Action: ActionClaim, Roles: []auth.Role{auth.RoleAgent}},
 	{Object: ObjectTypeJob, Action: ActionComplete, Roles: []auth.Role{auth.RoleAgent}},
 	{Object: ObjectTypeJob, Action: ActionFail, Roles: []auth.Role{auth.RoleAgent}},
+	{Object: ObjectTypeJob, Action: ActionRenew, Roles: []auth.Role{auth.RoleAgent}},
+	{Object: ObjectTypeJob, Action: ActionReportStep, Roles: []auth.Role{auth.RoleAgent}},
 	{Object: ObjectTypeJob, Action: ActionListPending, Roles: []auth.Role{auth.RoleAgent}},
+	{Object: ObjectTypeJob, Action: ActionRequeue, Roles: []auth.Role{auth.RoleAdmin}},
 
 	// MetricType permissions
 	{Object: ObjectTypeMetricType, Action: ActionRead, Roles: []auth.Role{auth.RoleAdmin, auth.RoleParticipant, auth.RoleAgent}},
@@ -134,6 +177,7 @@ var Rules = []AuthorizationRule{
 	{Object: ObjectTypeEvent, Action: ActionRead, Roles: []auth.Role{auth.RoleAdmin, auth.RoleParticipant}},
 	{Object: ObjectTypeEvent, Action: ActionLease, Roles: []auth.Role{auth.RoleAdmin}},
 	{Object: ObjectTypeEvent, Action: ActionAck, Roles: []auth.Role{auth.RoleAdmin}},
+	{Object: ObjectTypeEvent, Action: ActionSubscribe, Roles: []auth.Role{auth.RoleAdmin}},
 
 	// Token permissions
 	{Object: ObjectTypeToken, Action: ActionRead, Roles: []auth.Role{auth.RoleAdmin, auth.RoleParticipant}},
@@ -148,6 +192,14 @@ var Rules = []AuthorizationRule{
 	{Object: ObjectTypeKeycloakUser, Action: ActionUpdate, Roles: []auth.Role{auth.RoleAdmin}},
 	{Object: ObjectTypeKeycloakUser, Action: ActionDelete, Roles: []auth.Role{auth.RoleAdmin}},
 
+	// Metrics exposition permissions
+	{Object: ObjectTypeMetrics, Action: ActionRead, Roles: []auth.Role{auth.RoleAdmin}},
+
+	// VaultSecret permissions — agents resolve and verify secrets, admins rotate them
+	{Object: ObjectTypeVaultSecret, Action: ActionRead, Roles: []auth.Role{auth.RoleAgent}},
+	{Object: ObjectTypeVaultSecret, Action: ActionVerify, Roles: []auth.Role{auth.RoleAgent}},
+	{Object: ObjectTypeVaultSecret, Action: ActionRotate, Roles: []auth.Role{auth.RoleAdmin}},
+
 	// ConfigPool permissions — admin manages global + any participant; participant manages own
 	{Object: ObjectTypeConfigPool, Action: ActionRead, Roles: []auth.Role{auth.RoleAdmin, auth.RoleParticipant}},
 	{Object: ObjectTypeConfigPool, Action: ActionCreate, Roles: []auth.Role{auth.RoleAdmin, auth.RoleParticipant}},
@@ -1,9 +1,12 @@
 package config
 
 import (
+	"fmt"
 	"log/slog"
+	"strings"
 	"time"
 
+	"github.com/fulcrumproject/core/pkg/hcvault"
 	"github.com/fulcrumproject/core/pkg/keycloak"
 	"github.com/fulcrumproject/utils/gormpg"
 	"github.com/fulcrumproject/utils/logging"
@@ -13,26 +16,53 @@ const (
 	EnvPrefix = "FULCRUM_"
 )
 
+// Secret storage backends of the vault
+const (
+	VaultBackendDB        = "db"
+	VaultBackendHashiCorp = "hashicorp"
+)
+
 // Fulcrum configuration
 type Config struct {
 	Port                    uint                  `json:"port" env:"PORT" validate:"required,min=1,max=65535"`
 	ShutdownTimeout         time.Duration         `json:"shutdownTimeout" env:"SHUTDOWN_TIMEOUT"`
+	ShutdownDrainDelay      time.Duration         `json:"shutdownDrainDelay" env:"SHUTDOWN_DRAIN_DELAY" validate:"gte=0"` // Time between the readiness turning down and the listener closing
+	IdempotencyKeyTTL       time.Duration         `json:"idempotencyKeyTtl" env:"IDEMPOTENCY_KEY_TTL"`
+	IdempotencyKeyLease     time.Duration         `json:"idempotencyKeyLease" env:"IDEMPOTENCY_KEY_LEASE" validate:"gt=0"` // Longest time a request in progress holds its idempotency key
 	SchedulerLockerConfig   SchedulerLockerConfig `json:"schedulerLocker" validate:"required"`
 	SchedulerLockerDBConfig gormpg.Conf           `json:"schedulerLockerDb" env:"SCHEDULER_LOCKER_DB" validate:"required"`
 	HealthPort              uint                  `json:"healthPort" env:"HEALTH_PORT" validate:"required,min=1,max=65535"`
+	HealthCheckTimeout      time.Duration         `json:"healthCheckTimeout" env:"HEALTH_CHECK_TIMEOUT" validate:"gte=0"` // Longest time a readiness dependency check takes
 	Authenticators          []string              `json:"authenticators" env:"AUTHENTICATORS" validate:"omitempty,dive,oneof=oauth token"`
+	Roles                   []string              `json:"roles" env:"ROLES"` // Custom roles, as role=permission;permission
 	JobConfig               JobConfig             `json:"job" validate:"required"`
 	AgentConfig             AgentConfig           `json:"agent" validate:"required"`
+	ServiceConfig           ServiceConfig         `json:"service" validate:"required"`
+	WebhookConfig           WebhookConfig         `json:"webhook" validate:"required"`
 	LogConfig               logging.Conf          `json:"log" validate:"required"`
 	DBConfig                gormpg.Conf           `json:"db" env:"DB" validate:"required"`
 	MetricDBConfig          gormpg.Conf           `json:"metricDb" env:"METRIC_DB" validate:"required"`
+	ReadReplicaConfig       ReadReplicaConfig     `json:"readReplica" validate:"required"`
 	OAuthConfig             keycloak.Config       `json:"oauth" validate:"required"`
 	VaultEncryptionKey      string                `json:"vaultEncryptionKey" env:"VAULT_ENCRYPTION_KEY" validate:"omitempty,len=64"`
+	VaultBackend            string                `json:"vaultBackend" env:"VAULT_BACKEND" validate:"oneof=db hashicorp"`
+	HashiCorpVault          hcvault.Config        `json:"hashicorpVault"`
+	VaultRotationConfig     VaultRotationConfig   `json:"vaultRotation" validate:"required"`
+	RateLimitConfig         RateLimitConfig       `json:"rateLimit" validate:"required"`
+	CORSConfig              CORSConfig            `json:"cors" validate:"required"`
+	PaginationConfig        PaginationConfig      `json:"pagination" validate:"required"`
+	TokenConfig             TokenConfig           `json:"token" validate:"required"`
 	PublicBaseURL           string                `json:"publicBaseUrl" env:"PUBLIC_BASE_URL" validate:"required,url"`
 	ApiServer               bool                  `json:"apiServer" env:"API_SERVER" validate:"boolean"`
+	GRPCServer              bool                  `json:"grpcServer" env:"GRPC_SERVER" validate:"boolean"`
+	GRPCConfig              GRPCConfig            `json:"grpc" validate:"required"`
 	JobMaintenance          bool                  `json:"jobMaintenance" env:"JOB_MAINTENANCE" validate:"boolean"`
 	AgentMaintenance        bool                  `json:"agentMaintenance" env:"AGENT_MAINTENANCE" validate:"boolean"`
+	WebhookDelivery         bool                  `json:"webhookDelivery" env:"WEBHOOK_DELIVERY" validate:"boolean"`
 	KeycloakAdmin           bool                  `json:"keycloakAdmin" env:"KEYCLOAK_ADMIN" validate:"boolean"`
+	VaultMaintenance        bool                  `json:"vaultMaintenance" env:"VAULT_MAINTENANCE" validate:"boolean"`
+	TokenMaintenance        bool                  `json:"tokenMaintenance" env:"TOKEN_MAINTENANCE" validate:"boolean"`
+	JobLeaseReclaim         bool                  `json:"jobLeaseReclaim" env:"JOB_LEASE_RECLAIM" validate:"boolean"`
 }
 
 // Fulcrum scheduler locker configuration
@@ -40,23 +70,140 @@ type SchedulerLockerConfig struct {
 	Name          string        `json:"name" env:"SCHEDULER_LOCKER_NAME"`
 	CleanInterval time.Duration `json:"cleanInterval" env:"SCHEDULER_LOCKER_CLEAN_INTERVAL"`
 	TTL           time.Duration `json:"ttl" env:"SCHEDULER_LOCKER_TTL"`
+	// Run the job maintenance and unhealthy agents workers on a single replica elected with a Postgres advisory lock
+	LeaderElection bool `json:"leaderElection" env:"SCHEDULER_LEADER_ELECTION" validate:"boolean"`
+}
+
+// Fulcrum read replica configuration, the queries of the API read from the replica when enabled
+type ReadReplicaConfig struct {
+	Enabled    bool          `json:"enabled" env:"READ_REPLICA_ENABLED" validate:"boolean"`
+	DSN        string        `json:"dsn" env:"READ_REPLICA_DB_DSN" validate:"required_if=Enabled true"`
+	Stickiness time.Duration `json:"stickiness" env:"READ_REPLICA_STICKINESS" validate:"gte=0"` // How long an identity reads from the primary after a write
 }
 
 // Fulcrum Agent configuration
 type AgentConfig struct {
-	HealthTimeout time.Duration `json:"healthTimeout" env:"AGENT_HEALTH_TIMEOUT"`
+	HealthTimeout       time.Duration `json:"healthTimeout" env:"AGENT_HEALTH_TIMEOUT"`
+	MaintenanceInterval time.Duration `json:"maintenanceInterval" env:"AGENT_MAINTENANCE_INTERVAL" validate:"gt=0"` // How often the unhealthy agents are disconnected
+	MaintenanceJitter   time.Duration `json:"maintenanceJitter" env:"AGENT_MAINTENANCE_JITTER" validate:"gte=0"`    // Random delay added to each interval so replicas spread out
+}
+
+// Fulcrum event webhook delivery configuration
+type WebhookConfig struct {
+	Interval       time.Duration `json:"interval" env:"WEBHOOK_INTERVAL"`
+	Timeout        time.Duration `json:"timeout" env:"WEBHOOK_TIMEOUT"`
+	MaxAttempts    int           `json:"maxAttempts" env:"WEBHOOK_MAX_ATTEMPTS" validate:"min=1"`
+	InitialBackoff time.Duration `json:"initialBackoff" env:"WEBHOOK_INITIAL_BACKOFF"`
+	MaxBackoff     time.Duration `json:"maxBackoff" env:"WEBHOOK_MAX_BACKOFF"`
+	BatchSize      int           `json:"batchSize" env:"WEBHOOK_BATCH_SIZE" validate:"min=1"`
+	// Number of delivery attempts kept per subscription
+	DeliveryHistory int `json:"deliveryHistory" env:"WEBHOOK_DELIVERY_HISTORY" validate:"min=1"`
+}
+
+// Fulcrum vault secret rotation configuration
+type VaultRotationConfig struct {
+	GracePeriod         time.Duration `json:"gracePeriod" env:"VAULT_ROTATION_GRACE_PERIOD"`
+	MaxPreviousVersions int           `json:"maxPreviousVersions" env:"VAULT_ROTATION_MAX_PREVIOUS_VERSIONS" validate:"min=0"`
+	PurgeInterval       time.Duration `json:"purgeInterval" env:"VAULT_ROTATION_PURGE_INTERVAL"`
+}
+
+// Fulcrum API rate limiting configuration, rates are in requests per second of each identity
+type RateLimitConfig struct {
+	Enabled          bool    `json:"enabled" env:"RATE_LIMIT_ENABLED" validate:"boolean"`
+	AdminRate        float64 `json:"adminRate" env:"RATE_LIMIT_ADMIN_RATE" validate:"gt=0"`
+	AdminBurst       int     `json:"adminBurst" env:"RATE_LIMIT_ADMIN_BURST" validate:"min=1"`
+	ParticipantRate  float64 `json:"participantRate" env:"RATE_LIMIT_PARTICIPANT_RATE" validate:"gt=0"`
+	ParticipantBurst int     `json:"participantBurst" env:"RATE_LIMIT_PARTICIPANT_BURST" validate:"min=1"`
+	AgentRate        float64 `json:"agentRate" env:"RATE_LIMIT_AGENT_RATE" validate:"gt=0"`
+	AgentBurst       int     `json:"agentBurst" env:"RATE_LIMIT_AGENT_BURST" validate:"min=1"`
+	AgentPollRate    float64 `json:"agentPollRate" env:"RATE_LIMIT_AGENT_POLL_RATE" validate:"gt=0"`    // Pending jobs polling of the agents
+	AgentPollBurst   int     `json:"agentPollBurst" env:"RATE_LIMIT_AGENT_POLL_BURST" validate:"min=1"` // Pending jobs polling of the agents
+}
+
+// Fulcrum CORS configuration of the API for the browser clients served from other origins, no origin is allowed by default
+type CORSConfig struct {
+	AllowedOrigins   []string      `json:"allowedOrigins" env:"CORS_ALLOWED_ORIGINS"` // As scheme://host[:port], wildcards are rejected with credentials
+	AllowedMethods   []string      `json:"allowedMethods" env:"CORS_ALLOWED_METHODS"`
+	AllowedHeaders   []string      `json:"allowedHeaders" env:"CORS_ALLOWED_HEADERS"`
+	AllowCredentials bool          `json:"allowCredentials" env:"CORS_ALLOW_CREDENTIALS" validate:"boolean"`
+	MaxAge           time.Duration `json:"maxAge" env:"CORS_MAX_AGE" validate:"gte=0"` // How long the browsers cache the preflight responses
+}
+
+// Fulcrum pagination configuration of the lists
+type PaginationConfig struct {
+	DefaultPageSize int      `json:"defaultPageSize" env:"PAGE_SIZE_DEFAULT" validate:"gt=0"`                  // Page size of the lists requested without one
+	MaxPageSize     int      `json:"maxPageSize" env:"PAGE_SIZE_MAX" validate:"gt=0,gtefield=DefaultPageSize"` // Largest page size that can be requested
+	EntityPageSizes []string `json:"entityPageSizes" env:"PAGE_SIZE_ENTITIES"`                                 // Per entity overrides, as entity=max or entity=max:default
+}
+
+// Fulcrum gRPC agent protocol configuration
+type GRPCConfig struct {
+	Port            uint          `json:"port" env:"GRPC_PORT" validate:"required,min=1,max=65535"`
+	JobFeedInterval time.Duration `json:"jobFeedInterval" env:"GRPC_JOB_FEED_INTERVAL"` // How often the job feeds of the agents look for new pending jobs
+}
+
+// Fulcrum service configuration
+type ServiceConfig struct {
+	RestoreWindow  time.Duration `json:"restoreWindow" env:"SERVICE_RESTORE_WINDOW"`                                                         // How long a deleted service can be restored before it is purged
+	AgentSelection string        `json:"agentSelection" env:"SERVICE_AGENT_SELECTION" validate:"oneof=least-loaded round-robin bin-packing"` // How the agent of a service created without one is chosen
+	CostEstimation string        `json:"costEstimation" env:"SERVICE_COST_ESTIMATION" validate:"oneof=catalog disabled"`                     // How the cost of a service is estimated before its creation
+}
+
+// Fulcrum token maintenance configuration
+type TokenConfig struct {
+	UnusedWindow   time.Duration `json:"unusedWindow" env:"TOKEN_UNUSED_WINDOW"`             // Tokens not used for longer are reported as stale
+	ReportInterval time.Duration `json:"reportInterval" env:"TOKEN_REPORT_INTERVAL"`         // Interval of the stale tokens report
+	HashCost       int           `json:"hashCost" env:"TOKEN_HASH_COST" validate:"gte=1000"` // PBKDF2 iterations of the new token hashes, at least domain.MinTokenHashCost
+	// Tokens expiring within the window are warned by an event on each report, 0 disables the warnings
+	ExpiryWarningWindow time.Duration `json:"expiryWarningWindow" env:"TOKEN_EXPIRY_WARNING_WINDOW" validate:"gte=0"`
 }
 
 // Fulcrum Job configuration
 type JobConfig struct {
-	Maintenance time.Duration `json:"maintenance" env:"JOB_MAINTENANCE_INTERVAL"`
-	Retention   time.Duration `json:"retention" env:"JOB_RETENTION_INTERVAL"`
-	Timeout     time.Duration `json:"timeout" env:"JOB_TIMEOUT_INTERVAL"`
+	Maintenance         time.Duration `json:"maintenance" env:"JOB_MAINTENANCE_INTERVAL" validate:"gt=0"`
+	MaintenanceJitter   time.Duration `json:"maintenanceJitter" env:"JOB_MAINTENANCE_JITTER" validate:"gte=0"`               // Random delay added to each interval so replicas spread out
+	Retention           time.Duration `json:"retention" env:"JOB_RETENTION_INTERVAL" validate:"gte=0"`                       // How long the completed and cancelled jobs are kept, 0 keeps them
+	FailedRetention     time.Duration `json:"failedRetention" env:"JOB_FAILED_RETENTION_INTERVAL" validate:"gte=0"`          // How long the failed jobs are kept, 0 keeps them
+	DeadLetterRetention time.Duration `json:"deadLetterRetention" env:"JOB_DEAD_LETTER_RETENTION_INTERVAL" validate:"gte=0"` // How long the dead-lettered jobs are kept, 0 keeps them until requeued
+	RetentionBatchSize  int           `json:"retentionBatchSize" env:"JOB_RETENTION_BATCH_SIZE" validate:"gt=0"`             // Largest number of old jobs deleted by a single statement
+	Timeout             time.Duration `json:"timeout" env:"JOB_TIMEOUT_INTERVAL"`
+	ActionTimeouts      []string      `json:"actionTimeouts" env:"JOB_ACTION_TIMEOUTS"`            // Per action overrides of Timeout, as action=duration
+	MaxAttempts         int           `json:"maxAttempts" env:"JOB_MAX_ATTEMPTS" validate:"min=0"` // Consecutive failed attempts of an action before its job is dead-lettered, 0 disables it
+	LeaseDuration       time.Duration `json:"leaseDuration" env:"JOB_LEASE_DURATION"`              // How long a claim holds the job without renewal, 0 disables leasing
+	LeaseReclaim        time.Duration `json:"leaseReclaim" env:"JOB_LEASE_RECLAIM_INTERVAL"`       // How often the jobs with an expired lease are reclaimed
+	// Delay of the first retry of a failed action, doubled with every further attempt, 0 retries immediately
+	RetryInitialBackoff time.Duration `json:"retryInitialBackoff" env:"JOB_RETRY_INITIAL_BACKOFF" validate:"gte=0"`
+	// Longest delay of a retry
+	RetryMaxBackoff time.Duration `json:"retryMaxBackoff" env:"JOB_RETRY_MAX_BACKOFF" validate:"gte=0,gtefield=RetryInitialBackoff"`
+}
+
+// ParseActionTimeouts returns the per action timeout overrides
+func (c *JobConfig) ParseActionTimeouts() (map[string]time.Duration, error) {
+	timeouts := make(map[string]time.Duration, len(c.ActionTimeouts))
+	for _, entry := range c.ActionTimeouts {
+		action, value, ok := strings.Cut(entry, "=")
+		action = strings.TrimSpace(action)
+		if !ok || action == "" {
+			return nil, fmt.Errorf("invalid action timeout %q: expected action=duration", entry)
+		}
+		timeout, err := time.ParseDuration(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("invalid action timeout %q: %w", entry, err)
+		}
+		if timeout <= 0 {
+			return nil, fmt.Errorf("invalid action timeout %q: duration must be positive", entry)
+		}
+		timeouts[action] = timeout
+	}
+	return timeouts, nil
 }
 
 var Default = Config{
-	Port:            8080,
-	ShutdownTimeout: 30 * time.Second,
+	Port:                8080,
+	ShutdownTimeout:     30 * time.Second,
+	ShutdownDrainDelay:  5 * time.Second,
+	IdempotencyKeyTTL:   24 * time.Hour,
+	IdempotencyKeyLease: time.Minute,
 	SchedulerLockerConfig: SchedulerLockerConfig{
 		Name:          "fulcrum-scheduler",
 		CleanInterval: 30 * time.Minute,
@@ -67,15 +214,44 @@ var Default = Config{
 		LogLevel:  slog.LevelWarn,
 		LogFormat: "text",
 	},
-	HealthPort:     8081,
-	Authenticators: []string{"token"},
+	HealthPort:         8081,
+	HealthCheckTimeout: 2 * time.Second,
+	Authenticators:     []string{"token"},
 	JobConfig: JobConfig{
-		Maintenance: 24 * time.Hour,
-		Retention:   30 * 24 * time.Hour,
-		Timeout:     5 * time.Minute,
+		Maintenance:         24 * time.Hour,
+		MaintenanceJitter:   5 * time.Minute,
+		Retention:           30 * 24 * time.Hour,
+		FailedRetention:     90 * 24 * time.Hour,
+		RetentionBatchSize:  1000,
+		Timeout:             5 * time.Minute,
+		MaxAttempts:         5,
+		LeaseDuration:       time.Minute,
+		LeaseReclaim:        15 * time.Second,
+		RetryInitialBackoff: 30 * time.Second,
+		RetryMaxBackoff:     30 * time.Minute,
 	},
 	AgentConfig: AgentConfig{
-		HealthTimeout: 30 * time.Second,
+		HealthTimeout:       30 * time.Second,
+		MaintenanceInterval: 30 * time.Second,
+		MaintenanceJitter:   5 * time.Second,
+	},
+	GRPCConfig: GRPCConfig{
+		Port:            9090,
+		JobFeedInterval: 2 * time.Second,
+	},
+	ServiceConfig: ServiceConfig{
+		RestoreWindow:  7 * 24 * time.Hour,
+		AgentSelection: "least-loaded",
+		CostEstimation: "catalog",
+	},
+	WebhookConfig: WebhookConfig{
+		Interval:        10 * time.Second,
+		Timeout:         10 * time.Second,
+		MaxAttempts:     10,
+		InitialBackoff:  10 * time.Second,
+		MaxBackoff:      time.Hour,
+		BatchSize:       100,
+		DeliveryHistory: 100,
 	},
 	LogConfig: logging.Conf{
 		Level:  slog.LevelInfo,
@@ -91,8 +267,53 @@ var Default = Config{
 		LogLevel:  slog.LevelWarn,
 		LogFormat: "text",
 	},
+	ReadReplicaConfig: ReadReplicaConfig{
+		Stickiness: 5 * time.Second,
+	},
+	VaultBackend: VaultBackendDB,
+	HashiCorpVault: hcvault.Config{
+		MountPath:  "secret",
+		PathPrefix: "fulcrum",
+		Timeout:    10 * time.Second,
+	},
+	VaultRotationConfig: VaultRotationConfig{
+		GracePeriod:         24 * time.Hour,
+		MaxPreviousVersions: 3,
+		PurgeInterval:       time.Hour,
+	},
+	RateLimitConfig: RateLimitConfig{
+		Enabled:          true,
+		AdminRate:        50,
+		AdminBurst:       100,
+		ParticipantRate:  20,
+		ParticipantBurst: 40,
+		AgentRate:        10,
+		AgentBurst:       20,
+		AgentPollRate:    20,
+		AgentPollBurst:   40,
+	},
+	CORSConfig: CORSConfig{
+		AllowedMethods: []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+		AllowedHeaders: []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token", "Idempotency-Key"},
+		MaxAge:         5 * time.Minute,
+	},
+	PaginationConfig: PaginationConfig{
+		DefaultPageSize: 10,
+		MaxPageSize:     100,
+	},
+	TokenConfig: TokenConfig{
+		UnusedWindow:        90 * 24 * time.Hour,
+		ReportInterval:      24 * time.Hour,
+		HashCost:            10000,
+		ExpiryWarningWindow: 7 * 24 * time.Hour,
+	},
 	ApiServer:        true,
+	GRPCServer:       false,
 	JobMaintenance:   false,
 	AgentMaintenance: false,
+	WebhookDelivery:  false,
 	KeycloakAdmin:    false,
+	VaultMaintenance: false,
+	TokenMaintenance: false,
+	JobLeaseReclaim:  false,
 }
@@ -0,0 +1,84 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/fulcrumproject/core/pkg/gormlock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdvisoryLeader(t *testing.T) {
+	tdb := NewTestDB(t)
+	defer tdb.Cleanup(t)
+	ctx := context.Background()
+
+	newLeaders := func(t *testing.T, name string) (*gormlock.AdvisoryLeader, *gormlock.AdvisoryLeader) {
+		first, err := gormlock.NewAdvisoryLeader(tdb.DB, name)
+		require.NoError(t, err)
+		second, err := gormlock.NewAdvisoryLeader(tdb.DB, name)
+		require.NoError(t, err)
+		t.Cleanup(func() {
+			_ = first.Close()
+			_ = second.Close()
+		})
+		return first, second
+	}
+
+	t.Run("One leader per key", func(t *testing.T) {
+		first, second := newLeaders(t, "single")
+
+		require.NoError(t, first.IsLeader(ctx))
+		assert.ErrorIs(t, second.IsLeader(ctx), gormlock.ErrNotLeader)
+		assert.True(t, first.Leading())
+		assert.False(t, second.Leading())
+
+		// The leader keeps the leadership, the other replica keeps failing without waiting
+		require.NoError(t, first.IsLeader(ctx))
+		_, err := second.Lock(ctx, "job")
+		assert.ErrorIs(t, err, gormlock.ErrNotLeader)
+
+		// Another key has its own leader
+		other, err := gormlock.NewAdvisoryLeader(tdb.DB, "other")
+		require.NoError(t, err)
+		defer other.Close()
+		assert.NoError(t, other.IsLeader(ctx))
+	})
+
+	t.Run("Close hands the leadership over", func(t *testing.T) {
+		first, second := newLeaders(t, "handover")
+		require.NoError(t, first.IsLeader(ctx))
+		require.ErrorIs(t, second.IsLeader(ctx), gormlock.ErrNotLeader)
+
+		require.NoError(t, first.Close())
+		assert.False(t, first.Leading())
+		require.NoError(t, second.IsLeader(ctx))
+		assert.ErrorIs(t, first.IsLeader(ctx), gormlock.ErrNotLeader)
+	})
+
+	t.Run("Lost connection hands the leadership over", func(t *testing.T) {
+		first, second := newLeaders(t, "failover")
+		require.NoError(t, first.IsLeader(ctx))
+		require.ErrorIs(t, second.IsLeader(ctx), gormlock.ErrNotLeader)
+
+		// Kill the session holding the lock, as when the leader dies
+		var terminated bool
+		require.NoError(t, tdb.DB.Raw(`
+			SELECT pg_terminate_backend(pid) FROM pg_locks
+			WHERE locktype = 'advisory' AND granted AND pid <> pg_backend_pid()
+			  AND database = (SELECT oid FROM pg_database WHERE datname = current_database())
+		`).Scan(&terminated).Error)
+		require.True(t, terminated)
+
+		require.Eventually(t, func() bool {
+			return second.IsLeader(ctx) == nil
+		}, 5*time.Second, 50*time.Millisecond, "the other replica takes over on its next check")
+		assert.True(t, second.Leading())
+
+		// The previous leader finds its connection gone and the leadership taken
+		assert.ErrorIs(t, first.IsLeader(ctx), gormlock.ErrNotLeader)
+		assert.False(t, first.Leading())
+	})
+}
@@ -4,6 +4,9 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"log/slog"
+	"sync"
+	"time"
 
 	"github.com/fulcrumproject/core/pkg/auth"
 	"github.com/fulcrumproject/core/pkg/domain"
@@ -14,26 +17,45 @@ var (
 	ErrTokenInvalid = errors.New("invalid token")
 )
 
+const (
+	// tokenLastUsedTimeout bounds the background update of the last use of a token
+	tokenLastUsedTimeout = 5 * time.Second
+	// tokenHashCostsTTL is how long the costs in use are cached, the tokens are created at the configured
+	// cost that is always tried, so the cache only has to catch up with another configured cost
+	tokenHashCostsTTL = time.Minute
+)
+
 // GormTokenAuthenticator implements domain.Authenticator using GORM database
 type GormTokenAuthenticator struct {
-	store domain.Store
+	store     domain.Store
+	hashCost  int      // Cost the new tokens are hashed with, tried first
+	recording sync.Map // IDs of the tokens whose last use is being recorded
+	now       func() time.Time
+
+	// Costs of the token hashes in use, cached so the lookups missing at the configured cost don't query them
+	costsMu       sync.Mutex
+	costs         []int
+	costsLoadedAt time.Time
 }
 
-// NewTokenAuthenticator creates a new token authenticator
-func NewTokenAuthenticator(store domain.Store) *GormTokenAuthenticator {
+// NewTokenAuthenticator creates a new token authenticator for the tokens hashed at hashCost,
+// domain.DefaultTokenHashCost when zero, the tokens hashed at other costs are still accepted
+func NewTokenAuthenticator(store domain.Store, hashCost int) *GormTokenAuthenticator {
+	if hashCost == 0 {
+		hashCost = domain.DefaultTokenHashCost
+	}
 	return &GormTokenAuthenticator{
-		store: store,
+		store:    store,
+		hashCost: hashCost,
+		now:      time.Now,
 	}
 }
 
 // Authenticate extracts and validates the token from the HTTP request
 // Returns nil if authentication fails
 func (a *GormTokenAuthenticator) Authenticate(ctx context.Context, tokenValue string) (*auth.Identity, error) {
-	// Hash the token value
-	hashedValue := domain.HashTokenValue(tokenValue)
-
 	// Look up the token in the database
-	token, err := a.store.TokenRepo().FindByHashedValue(ctx, hashedValue)
+	token, cost, err := a.findToken(ctx, tokenValue)
 	if err != nil {
 		return nil, ErrTokenInvalid
 	}
@@ -43,6 +65,11 @@ func (a *GormTokenAuthenticator) Authenticate(ctx context.Context, tokenValue st
 		return nil, ErrTokenExpired
 	}
 
+	a.recordUse(ctx, token)
+	if cost != a.hashCost {
+		a.rehash(ctx, token, tokenValue)
+	}
+
 	// Create a new identity
 	return &auth.Identity{
 		ID:   token.ID,
@@ -51,10 +78,94 @@ func (a *GormTokenAuthenticator) Authenticate(ctx context.Context, tokenValue st
 		Scope: auth.IdentityScope{
 			ParticipantID: token.ParticipantID,
 			AgentID:       token.AgentID,
+			GroupIDs:      token.GroupIDs,
 		},
 	}, nil
 }
 
+// findToken looks the token up by its value hashed at the configured cost, then at the other costs in use
+// by the tokens created before the cost changed, and returns it with the cost it was found at
+func (a *GormTokenAuthenticator) findToken(ctx context.Context, tokenValue string) (*domain.Token, int, error) {
+	token, notFoundErr := a.findTokenWithCost(ctx, tokenValue, a.hashCost)
+	if notFoundErr == nil || !errors.As(notFoundErr, &domain.NotFoundError{}) {
+		return token, a.hashCost, notFoundErr
+	}
+	costs, err := a.hashCosts(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+	for _, cost := range costs {
+		if cost == a.hashCost {
+			continue
+		}
+		token, err := a.findTokenWithCost(ctx, tokenValue, cost)
+		if err == nil || !errors.As(err, &domain.NotFoundError{}) {
+			return token, cost, err
+		}
+	}
+	return nil, 0, notFoundErr
+}
+
+// hashCosts returns the costs of the token hashes in use, cached for tokenHashCostsTTL
+func (a *GormTokenAuthenticator) hashCosts(ctx context.Context) ([]int, error) {
+	a.costsMu.Lock()
+	defer a.costsMu.Unlock()
+	now := a.now()
+	if a.costs != nil && now.Sub(a.costsLoadedAt) < tokenHashCostsTTL {
+		return a.costs, nil
+	}
+	costs, err := a.store.TokenRepo().ListHashCosts(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if costs == nil {
+		costs = []int{}
+	}
+	a.costs = costs
+	a.costsLoadedAt = now
+	return costs, nil
+}
+
+// rehash hashes a token found at another cost at the configured one, so the lookups at the other costs
+// stop as the tokens are used
+func (a *GormTokenAuthenticator) rehash(ctx context.Context, token *domain.Token, tokenValue string) {
+	hashedValue, err := domain.HashTokenValueWithCost(tokenValue, a.hashCost)
+	if err == nil {
+		err = a.store.TokenRepo().UpdateHash(ctx, token.ID, hashedValue, a.hashCost)
+	}
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to rehash token", "id", token.ID, "error", err)
+	}
+}
+
+// findTokenWithCost looks the token up by its value hashed at the given cost
+func (a *GormTokenAuthenticator) findTokenWithCost(ctx context.Context, tokenValue string, cost int) (*domain.Token, error) {
+	hashedValue, err := domain.HashTokenValueWithCost(tokenValue, cost)
+	if err != nil {
+		return nil, err
+	}
+	return a.store.TokenRepo().FindByHashedValue(ctx, hashedValue)
+}
+
+// recordUse updates the last use of the token in background, at most once per domain.TokenLastUsedThrottle
+func (a *GormTokenAuthenticator) recordUse(ctx context.Context, token *domain.Token) {
+	now := a.now()
+	if !token.ShouldRecordUse(now) {
+		return
+	}
+	if _, busy := a.recording.LoadOrStore(token.ID, struct{}{}); busy {
+		return
+	}
+	go func() {
+		defer a.recording.Delete(token.ID)
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), tokenLastUsedTimeout)
+		defer cancel()
+		if err := a.store.TokenRepo().UpdateLastUsedAt(ctx, token.ID, now); err != nil {
+			slog.WarnContext(ctx, "Failed to record token use", "id", token.ID, "error", err)
+		}
+	}()
+}
+
 // Health checks if the token authenticator dependencies are healthy
 func (a *GormTokenAuthenticator) Health(ctx context.Context) error {
 	if a.store == nil {
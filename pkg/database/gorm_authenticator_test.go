@@ -0,0 +1,164 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/fulcrumproject/core/pkg/auth"
+	"github.com/fulcrumproject/core/pkg/domain"
+	"github.com/fulcrumproject/core/pkg/properties"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenAuthenticatorRecordsLastUse(t *testing.T) {
+	now := time.Now()
+	recent := now.Add(-10 * time.Second)
+	stale := now.Add(-2 * domain.TokenLastUsedThrottle)
+
+	tests := []struct {
+		name         string
+		lastUsedAt   *time.Time
+		expireAt     time.Time
+		expectRecord bool
+		expectError  error
+	}{
+		{name: "Never used", expireAt: now.Add(time.Hour), expectRecord: true},
+		{name: "Used before the throttle interval", lastUsedAt: &stale, expireAt: now.Add(time.Hour), expectRecord: true},
+		{name: "Used within the throttle interval", lastUsedAt: &recent, expireAt: now.Add(time.Hour)},
+		{name: "Expired", expireAt: now.Add(-time.Hour), expectError: ErrTokenExpired},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			token := &domain.Token{
+				BaseEntity: domain.BaseEntity{ID: properties.NewUUID()},
+				Name:       "token",
+				Role:       auth.RoleAdmin,
+				ExpireAt:   tc.expireAt,
+				LastUsedAt: tc.lastUsedAt,
+			}
+
+			repo := domain.NewMockTokenRepository(t)
+			store := domain.NewMockStore(t)
+			store.EXPECT().TokenRepo().Return(repo)
+			repo.EXPECT().FindByHashedValue(ctx, hashTestTokenValue(t, "value", domain.DefaultTokenHashCost)).Return(token, nil)
+			recorded := make(chan struct{})
+			if tc.expectRecord {
+				repo.EXPECT().UpdateLastUsedAt(mock.Anything, token.ID, now).
+					Run(func(context.Context, properties.UUID, time.Time) { close(recorded) }).
+					Return(nil)
+			}
+
+			authenticator := NewTokenAuthenticator(store, 0)
+			authenticator.now = func() time.Time { return now }
+			identity, err := authenticator.Authenticate(ctx, "value")
+
+			if tc.expectError != nil {
+				assert.ErrorIs(t, err, tc.expectError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, token.ID, identity.ID)
+			if tc.expectRecord {
+				select {
+				case <-recorded:
+				case <-time.After(time.Second):
+					t.Fatal("last use not recorded")
+				}
+			}
+		})
+	}
+}
+
+func TestTokenAuthenticatorHashCosts(t *testing.T) {
+	const configuredCost = 2000
+	notFound := domain.NewNotFoundErrorf("token not found")
+
+	tests := []struct {
+		name        string
+		hashedCost  int // Cost the stored token is hashed with, -1 when there is no token
+		costsInUse  []int
+		expectError error
+	}{
+		{name: "Configured cost", hashedCost: configuredCost},
+		{name: "Previous cost", hashedCost: domain.DefaultTokenHashCost, costsInUse: []int{configuredCost, domain.DefaultTokenHashCost}},
+		{name: "Legacy hash", hashedCost: 0, costsInUse: []int{0, configuredCost}},
+		{name: "Unknown token", hashedCost: -1, costsInUse: []int{configuredCost, 0}, expectError: ErrTokenInvalid},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			now := time.Now()
+			token := &domain.Token{
+				BaseEntity: domain.BaseEntity{ID: properties.NewUUID()},
+				Name:       "token",
+				Role:       auth.RoleAdmin,
+				ExpireAt:   now.Add(time.Hour),
+				LastUsedAt: &now,
+			}
+
+			repo := domain.NewMockTokenRepository(t)
+			store := domain.NewMockStore(t)
+			store.EXPECT().TokenRepo().Return(repo)
+			repo.EXPECT().FindByHashedValue(ctx, mock.Anything).RunAndReturn(func(_ context.Context, hashed string) (*domain.Token, error) {
+				if tc.hashedCost >= 0 && hashed == hashTestTokenValue(t, "value", tc.hashedCost) {
+					return token, nil
+				}
+				return nil, notFound
+			})
+			if tc.costsInUse != nil {
+				repo.EXPECT().ListHashCosts(ctx).Return(tc.costsInUse, nil)
+			}
+			if tc.hashedCost >= 0 && tc.hashedCost != configuredCost {
+				// The token found at another cost is hashed at the configured one
+				repo.EXPECT().UpdateHash(ctx, token.ID, hashTestTokenValue(t, "value", configuredCost), configuredCost).Return(nil)
+			}
+
+			authenticator := NewTokenAuthenticator(store, configuredCost)
+			authenticator.now = func() time.Time { return now }
+			identity, err := authenticator.Authenticate(ctx, "value")
+
+			if tc.expectError != nil {
+				assert.ErrorIs(t, err, tc.expectError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, token.ID, identity.ID)
+		})
+	}
+}
+
+func TestTokenAuthenticatorCachesHashCosts(t *testing.T) {
+	const configuredCost = 2000
+	ctx := context.Background()
+	now := time.Now()
+
+	repo := domain.NewMockTokenRepository(t)
+	store := domain.NewMockStore(t)
+	store.EXPECT().TokenRepo().Return(repo)
+	repo.EXPECT().FindByHashedValue(ctx, mock.Anything).Return(nil, domain.NewNotFoundErrorf("token not found"))
+	repo.EXPECT().ListHashCosts(ctx).Return([]int{configuredCost, 0}, nil).Times(2)
+
+	authenticator := NewTokenAuthenticator(store, configuredCost)
+	authenticator.now = func() time.Time { return now }
+	for range 3 {
+		_, err := authenticator.Authenticate(ctx, "unknown")
+		assert.ErrorIs(t, err, ErrTokenInvalid)
+	}
+
+	// The costs are read again once cached for tokenHashCostsTTL
+	now = now.Add(tokenHashCostsTTL)
+	_, err := authenticator.Authenticate(ctx, "unknown")
+	assert.ErrorIs(t, err, ErrTokenInvalid)
+}
+
+func hashTestTokenValue(t *testing.T, value string, cost int) string {
+	hashed, err := domain.HashTokenValueWithCost(value, cost)
+	require.NoError(t, err)
+	return hashed
+}
@@ -0,0 +1,128 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/fulcrumproject/core/pkg/domain"
+	"github.com/fulcrumproject/core/pkg/properties"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// idempotencyKey is the stored outcome of a request sent with an Idempotency-Key header
+type idempotencyKey struct {
+	Scope          string    `gorm:"primaryKey"`
+	IdempotencyKey string    `gorm:"primaryKey"`
+	RequestHash    string    `gorm:"not null"`
+	StatusCode     int       `gorm:"not null;default:0"`
+	Body           []byte    // Response body, holding the ID of the created entity
+	ExpiresAt      time.Time `gorm:"index;not null"`
+	ReservedAt     time.Time `gorm:"not null;default:CURRENT_TIMESTAMP"` // When the request in progress took the key
+	Token          string    `gorm:"not null;default:''"`                // Reservation of the request holding the key
+	CreatedAt      time.Time
+}
+
+func (idempotencyKey) TableName() string {
+	return "idempotency_keys"
+}
+
+// GormIdempotencyStore implements domain.IdempotencyStore on the idempotency_keys table
+type GormIdempotencyStore struct {
+	db *gorm.DB
+}
+
+// NewIdempotencyStore creates a new GormIdempotencyStore
+func NewIdempotencyStore(db *gorm.DB) *GormIdempotencyStore {
+	return &GormIdempotencyStore{db: db}
+}
+
+// Reserve inserts an in progress record for the key, an expired record is replaced
+//
+// A stale in progress record of the same request is taken over with a conditional update,
+// so only one of several concurrent retries gets the key.
+func (s *GormIdempotencyStore) Reserve(ctx context.Context, scope, key, requestHash string, expiresAt, staleBefore time.Time) (string, *domain.IdempotencyRecord, error) {
+	db := s.db.WithContext(ctx)
+	now := time.Now()
+	token := properties.NewUUID().String()
+
+	result := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&idempotencyKey{
+		Scope:          scope,
+		IdempotencyKey: key,
+		RequestHash:    requestHash,
+		ExpiresAt:      expiresAt,
+		ReservedAt:     now,
+		Token:          token,
+	})
+	if result.Error != nil {
+		return "", nil, result.Error
+	}
+	if result.RowsAffected == 1 {
+		return token, nil, nil
+	}
+
+	// The expired records not purged yet are replaced whatever their request
+	result = db.Model(&idempotencyKey{}).
+		Where("scope = ? AND idempotency_key = ?", scope, key).
+		Where(db.Where("expires_at < ?", now).
+			Or("status_code = 0 AND reserved_at < ? AND request_hash = ?", staleBefore, requestHash)).
+		Updates(map[string]any{
+			"request_hash": requestHash,
+			"status_code":  0,
+			"body":         nil,
+			"expires_at":   expiresAt,
+			"reserved_at":  now,
+			"token":        token,
+		})
+	if result.Error != nil {
+		return "", nil, result.Error
+	}
+	if result.RowsAffected == 1 {
+		return token, nil, nil
+	}
+
+	var stored idempotencyKey
+	if err := db.Take(&stored, "scope = ? AND idempotency_key = ?", scope, key).Error; err != nil {
+		return "", nil, err
+	}
+	return "", &domain.IdempotencyRecord{
+		RequestHash: stored.RequestHash,
+		StatusCode:  stored.StatusCode,
+		Body:        stored.Body,
+		ReservedAt:  stored.ReservedAt,
+	}, nil
+}
+
+// Complete stores the response of the request holding the reservation
+func (s *GormIdempotencyStore) Complete(ctx context.Context, scope, key, token string, statusCode int, body []byte) error {
+	result := s.db.WithContext(ctx).Model(&idempotencyKey{}).
+		Where("scope = ? AND idempotency_key = ? AND token = ? AND status_code = 0", scope, key, token).
+		Updates(map[string]any{"status_code": statusCode, "body": body})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return domain.NewConflictErrorf("idempotency key %s was taken over by another request", key)
+	}
+	return nil
+}
+
+// Release removes the record of the reservation
+func (s *GormIdempotencyStore) Release(ctx context.Context, scope, key, token string) error {
+	result := s.db.WithContext(ctx).
+		Where("scope = ? AND idempotency_key = ? AND token = ? AND status_code = 0", scope, key, token).
+		Delete(&idempotencyKey{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return domain.NewConflictErrorf("idempotency key %s was taken over by another request", key)
+	}
+	return nil
+}
+
+// PurgeExpired removes the records expired at the given time
+func (s *GormIdempotencyStore) PurgeExpired(ctx context.Context, at time.Time) (int64, error) {
+	result := s.db.WithContext(ctx).Where("expires_at < ?", at).Delete(&idempotencyKey{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,122 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/fulcrumproject/core/pkg/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGormIdempotencyStore(t *testing.T) {
+	tdb := NewTestDB(t)
+	defer tdb.Cleanup(t)
+
+	store := NewIdempotencyStore(tdb.DB)
+	ctx := context.Background()
+	expiresAt := time.Now().Add(time.Hour)
+	staleBefore := time.Now().Add(-time.Minute)
+
+	t.Run("Reserve, complete and replay", func(t *testing.T) {
+		token, record, err := store.Reserve(ctx, "admin:1", "key-1", "hash", expiresAt, staleBefore)
+		require.NoError(t, err)
+		assert.Nil(t, record, "the first request reserves the key")
+		require.NotEmpty(t, token)
+
+		other, record, err := store.Reserve(ctx, "admin:1", "key-1", "hash", expiresAt, staleBefore)
+		require.NoError(t, err)
+		assert.Empty(t, other)
+		require.NotNil(t, record)
+		assert.Equal(t, 0, record.StatusCode, "the first request is still in progress")
+
+		require.NoError(t, store.Complete(ctx, "admin:1", "key-1", token, 201, []byte(`{"id":"1"}`)))
+		_, record, err = store.Reserve(ctx, "admin:1", "key-1", "other", expiresAt, staleBefore)
+		require.NoError(t, err)
+		require.NotNil(t, record)
+		assert.Equal(t, "hash", record.RequestHash)
+		assert.Equal(t, 201, record.StatusCode)
+		assert.JSONEq(t, `{"id":"1"}`, string(record.Body))
+	})
+
+	t.Run("Keys are scoped", func(t *testing.T) {
+		_, record, err := store.Reserve(ctx, "admin:2", "key-1", "hash", expiresAt, staleBefore)
+		require.NoError(t, err)
+		assert.Nil(t, record)
+	})
+
+	t.Run("Released keys can be reserved again", func(t *testing.T) {
+		token, _, err := store.Reserve(ctx, "admin:1", "key-2", "hash", expiresAt, staleBefore)
+		require.NoError(t, err)
+		require.NoError(t, store.Release(ctx, "admin:1", "key-2", token))
+		_, record, err := store.Reserve(ctx, "admin:1", "key-2", "hash", expiresAt, staleBefore)
+		require.NoError(t, err)
+		assert.Nil(t, record)
+	})
+
+	t.Run("Expired keys can be reserved again and are purged", func(t *testing.T) {
+		_, _, err := store.Reserve(ctx, "admin:1", "key-3", "hash", time.Now().Add(-time.Second), staleBefore)
+		require.NoError(t, err)
+		_, record, err := store.Reserve(ctx, "admin:1", "key-3", "other", expiresAt, staleBefore)
+		require.NoError(t, err)
+		assert.Nil(t, record)
+
+		_, _, err = store.Reserve(ctx, "admin:1", "key-6", "hash", time.Now().Add(-time.Second), staleBefore)
+		require.NoError(t, err)
+		count, err := store.PurgeExpired(ctx, time.Now())
+		require.NoError(t, err)
+		assert.GreaterOrEqual(t, count, int64(1))
+		_, record, err = store.Reserve(ctx, "admin:1", "key-3", "other", expiresAt, staleBefore)
+		require.NoError(t, err)
+		assert.NotNil(t, record, "unexpired keys are kept")
+	})
+
+	t.Run("Stale reservations are taken over once by the same request", func(t *testing.T) {
+		first, _, err := store.Reserve(ctx, "admin:1", "key-4", "hash", expiresAt, staleBefore)
+		require.NoError(t, err)
+
+		// The request holding the key is still within its lease
+		_, record, err := store.Reserve(ctx, "admin:1", "key-4", "hash", expiresAt, staleBefore)
+		require.NoError(t, err)
+		require.NotNil(t, record)
+		assert.Equal(t, 0, record.StatusCode)
+
+		// Past the lease another request still cannot use the key
+		_, record, err = store.Reserve(ctx, "admin:1", "key-4", "other", expiresAt, time.Now().Add(time.Second))
+		require.NoError(t, err)
+		require.NotNil(t, record)
+		assert.Equal(t, "hash", record.RequestHash)
+
+		// The next retry takes the key, the following one sees it in progress again
+		retry, record, err := store.Reserve(ctx, "admin:1", "key-4", "hash", expiresAt, time.Now().Add(time.Second))
+		require.NoError(t, err)
+		assert.Nil(t, record)
+		require.NotEmpty(t, retry)
+		assert.NotEqual(t, first, retry)
+		_, record, err = store.Reserve(ctx, "admin:1", "key-4", "hash", expiresAt, staleBefore)
+		require.NoError(t, err)
+		require.NotNil(t, record)
+		assert.Equal(t, 0, record.StatusCode)
+
+		// The first request finishing late cannot overwrite the key of the retry
+		assert.ErrorAs(t, store.Complete(ctx, "admin:1", "key-4", first, 201, []byte(`{}`)), &domain.ConflictError{})
+		assert.ErrorAs(t, store.Release(ctx, "admin:1", "key-4", first), &domain.ConflictError{})
+		require.NoError(t, store.Complete(ctx, "admin:1", "key-4", retry, 201, []byte(`{"id":"2"}`)))
+		_, record, err = store.Reserve(ctx, "admin:1", "key-4", "hash", expiresAt, staleBefore)
+		require.NoError(t, err)
+		require.NotNil(t, record)
+		assert.JSONEq(t, `{"id":"2"}`, string(record.Body))
+	})
+
+	t.Run("Completed keys are never taken over", func(t *testing.T) {
+		token, _, err := store.Reserve(ctx, "admin:1", "key-5", "hash", expiresAt, staleBefore)
+		require.NoError(t, err)
+		require.NoError(t, store.Complete(ctx, "admin:1", "key-5", token, 201, []byte(`{}`)))
+
+		_, record, err := store.Reserve(ctx, "admin:1", "key-5", "hash", expiresAt, time.Now().Add(time.Second))
+		require.NoError(t, err)
+		require.NotNil(t, record)
+		assert.Equal(t, 201, record.StatusCode)
+	})
+}
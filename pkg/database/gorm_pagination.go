@@ -3,70 +3,238 @@ package database
 import (
 	"context"
 	"fmt"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/fulcrumproject/core/pkg/auth"
 	"github.com/fulcrumproject/core/pkg/domain"
+	"github.com/fulcrumproject/core/pkg/properties"
+	"github.com/lib/pq"
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
 )
 
 type PageFilterApplier func(db *gorm.DB, r *domain.PageReq) (*gorm.DB, error)
 
-type FilterFieldApplier func(db *gorm.DB, vv []string) (*gorm.DB, error)
+// FilterOperator is the comparison of a filter, given in the query as field[op]=value
+type FilterOperator string
 
+const (
+	FilterOpDefault FilterOperator = ""
+	FilterOpEq      FilterOperator = "eq"
+	FilterOpIn      FilterOperator = "in"
+	FilterOpGte     FilterOperator = "gte"
+	FilterOpLte     FilterOperator = "lte"
+	FilterOpLike    FilterOperator = "like"
+)
+
+// filterWildcard registers a field applier for every key below a prefix, e.g. attr.*
+const filterWildcard = ".*"
+
+// FieldFilter is a parsed filter of a single field
+type FieldFilter struct {
+	Key    string         // Key below a wildcard field, empty for plain fields
+	Op     FilterOperator // Comparison operator, default keeps the field specific behavior
+	Values []string       // Raw values, comma separated values are split for the in operator
+}
+
+type FilterFieldApplier func(db *gorm.DB, f FieldFilter) (*gorm.DB, error)
+
+// MapFilterApplier applies the filters of the fields allowlisted in the map
+// Unknown fields, operators and values are reported as invalid input
 func MapFilterApplier(fields map[string]FilterFieldApplier) PageFilterApplier {
 	return func(db *gorm.DB, r *domain.PageReq) (*gorm.DB, error) {
 		if len(r.Filters) == 0 {
 			return db, nil
 		}
 
-		var err error
-		for field, values := range r.Filters {
+		// Sorted to produce the same query for the same filters
+		params := make([]string, 0, len(r.Filters))
+		for param := range r.Filters {
+			params = append(params, param)
+		}
+		slices.Sort(params)
+
+		for _, param := range params {
+			field, op, err := parseFilterParam(param)
+			if err != nil {
+				return nil, err
+			}
+			filter := FieldFilter{Op: op, Values: r.Filters[param]}
 			applier, exists := fields[field]
 			if !exists {
-				return db, fmt.Errorf("cannot filter by field %s", field)
+				prefix, key, ok := strings.Cut(field, ".")
+				if ok && key != "" {
+					applier, exists = fields[prefix+filterWildcard]
+					filter.Key = key
+				}
 			}
-			if db, err = applier(db, values); err != nil {
-				return nil, err
+			if !exists {
+				return nil, domain.NewInvalidInputErrorf("cannot filter by field %s", field)
+			}
+			if op == FilterOpIn {
+				filter.Values = splitFilterValues(filter.Values)
+			}
+			if db, err = applier(db, filter); err != nil {
+				return nil, domain.NewInvalidInputErrorf("invalid filter %s: %w", param, err)
 			}
 		}
 		return db, nil
 	}
 }
 
+// parseFilterParam splits a query parameter like createdAt[gte] in field and operator
+func parseFilterParam(param string) (string, FilterOperator, error) {
+	field, rest, ok := strings.Cut(param, "[")
+	if !ok {
+		return param, FilterOpDefault, nil
+	}
+	opStr, ok := strings.CutSuffix(rest, "]")
+	if !ok || field == "" {
+		return "", "", domain.NewInvalidInputErrorf("invalid filter %s", param)
+	}
+	op := FilterOperator(opStr)
+	switch op {
+	case FilterOpEq, FilterOpIn, FilterOpGte, FilterOpLte, FilterOpLike:
+		return field, op, nil
+	}
+	return "", "", domain.NewInvalidInputErrorf("unsupported filter operator %s on field %s", opStr, field)
+}
+
+func splitFilterValues(vv []string) []string {
+	values := make([]string, 0, len(vv))
+	for _, v := range vv {
+		for _, part := range strings.Split(v, ",") {
+			if part = strings.TrimSpace(part); part != "" {
+				values = append(values, part)
+			}
+		}
+	}
+	return values
+}
+
+func unsupportedFilterOp(op FilterOperator) error {
+	return fmt.Errorf("unsupported operator %s", op)
+}
+
+// singleFilterValue returns the only value of comparison operators
+func singleFilterValue(f FieldFilter) (string, error) {
+	if len(f.Values) != 1 {
+		return "", fmt.Errorf("operator %s accepts a single value", f.Op)
+	}
+	return f.Values[0], nil
+}
+
 func MapSortApplier(fields map[string]string) PageFilterApplier {
 	return func(db *gorm.DB, r *domain.PageReq) (*gorm.DB, error) {
-		if !r.Sort {
-			return db, nil
+		for _, f := range r.SortOrder() {
+			column, exists := fields[f.Field]
+			if !exists {
+				return db, domain.NewInvalidInputErrorf("cannot sort by field %s", f.Field)
+			}
+			db = db.Order(clause.OrderByColumn{Column: clause.Column{Name: column}, Desc: !f.Asc})
 		}
-		field, exists := fields[r.SortBy]
-		if !exists {
-			return db, fmt.Errorf("cannot sort by field %s", field)
+		return db, nil
+	}
+}
+
+func parseFilterValues[T any](vv []string, t func(string) (T, error)) ([]T, error) {
+	values := make([]T, 0, len(vv))
+	for _, v := range vv {
+		value, err := t(v)
+		if err != nil {
+			return nil, err
 		}
-		return db.Order(clause.OrderByColumn{Column: clause.Column{Name: field}, Desc: !r.SortAsc}), nil
+		values = append(values, value)
 	}
+	return values, nil
 }
 
+// ParserInFilterFieldApplier matches the field against the parsed values, supports eq and in
 func ParserInFilterFieldApplier[T any](f string, t func(string) (T, error)) FilterFieldApplier {
-	return func(db *gorm.DB, vv []string) (*gorm.DB, error) {
-		if len(vv) == 0 {
-			return db, nil
-		}
-		values := make([]T, 0, len(vv))
-		for _, v := range vv {
+	return func(db *gorm.DB, ff FieldFilter) (*gorm.DB, error) {
+		switch ff.Op {
+		case FilterOpDefault, FilterOpIn:
+			if len(ff.Values) == 0 {
+				return db, nil
+			}
+			values, err := parseFilterValues(ff.Values, t)
+			if err != nil {
+				return nil, err
+			}
+			return db.Where(fmt.Sprintf("%s IN ?", f), values), nil
+		case FilterOpEq:
+			v, err := singleFilterValue(ff)
+			if err != nil {
+				return nil, err
+			}
 			value, err := t(v)
 			if err != nil {
 				return nil, err
 			}
-			values = append(values, value)
+			return db.Where(fmt.Sprintf("%s = ?", f), value), nil
+		}
+		return nil, unsupportedFilterOp(ff.Op)
+	}
+}
+
+// ParserRangeFilterFieldApplier extends ParserInFilterFieldApplier with the gte and lte comparisons
+func ParserRangeFilterFieldApplier[T any](f string, t func(string) (T, error)) FilterFieldApplier {
+	in := ParserInFilterFieldApplier(f, t)
+	return func(db *gorm.DB, ff FieldFilter) (*gorm.DB, error) {
+		var cmp string
+		switch ff.Op {
+		case FilterOpGte:
+			cmp = ">="
+		case FilterOpLte:
+			cmp = "<="
+		default:
+			return in(db, ff)
+		}
+		v, err := singleFilterValue(ff)
+		if err != nil {
+			return nil, err
 		}
-		return db.Where(fmt.Sprintf("%s IN ?", f), values), nil
+		value, err := t(v)
+		if err != nil {
+			return nil, err
+		}
+		return db.Where(fmt.Sprintf("%s %s ?", f, cmp), value), nil
 	}
 }
 
+// TimeRangeFilterFieldApplier filters a timestamp field by RFC3339 times or dates
+func TimeRangeFilterFieldApplier(f string) FilterFieldApplier {
+	return ParserRangeFilterFieldApplier(f, parseFilterTime)
+}
+
+func parseFilterTime(v string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(time.DateOnly, v)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %s, expected RFC3339 or YYYY-MM-DD", v)
+	}
+	return t, nil
+}
+
+func parseFilterString(v string) (string, error) {
+	return v, nil
+}
+
+// StringInFilterFieldApplier matches exact values by default, eq and in, substrings by like
 func StringInFilterFieldApplier(f string) FilterFieldApplier {
-	return ParserInFilterFieldApplier(f, func(v string) (string, error) { return v, nil })
+	in := ParserInFilterFieldApplier(f, parseFilterString)
+	contains := StringContainsInsensitiveFilterFieldApplier(f)
+	return func(db *gorm.DB, ff FieldFilter) (*gorm.DB, error) {
+		if ff.Op == FilterOpLike {
+			return contains(db, ff)
+		}
+		return in(db, ff)
+	}
 }
 
 // escapeLikePattern escapes SQL LIKE wildcard characters (%, _, \) in the input string
@@ -79,10 +247,22 @@ func escapeLikePattern(s string) string {
 	return s
 }
 
+// StringContainsInsensitiveFilterFieldApplier matches substrings by default and like, exact values by eq and in
 func StringContainsInsensitiveFilterFieldApplier(field string) FilterFieldApplier {
-	return func(db *gorm.DB, vv []string) (*gorm.DB, error) {
-		if len(vv) == 0 {
-			return db, nil
+	return containsInsensitiveFilter(field, nil, ParserInFilterFieldApplier(field, parseFilterString))
+}
+
+// containsInsensitiveFilter matches any of the values as case insensitive substring of expr
+// The alternatives are grouped so they compose with the other conditions of the query
+// Exact is used for the eq and in operators
+func containsInsensitiveFilter(expr string, args []any, exact FilterFieldApplier) FilterFieldApplier {
+	return func(db *gorm.DB, ff FieldFilter) (*gorm.DB, error) {
+		switch ff.Op {
+		case FilterOpDefault, FilterOpLike:
+		case FilterOpEq, FilterOpIn:
+			return exact(db, ff)
+		default:
+			return nil, unsupportedFilterOp(ff.Op)
 		}
 
 		var (
@@ -90,7 +270,7 @@ func StringContainsInsensitiveFilterFieldApplier(field string) FilterFieldApplie
 			set bool
 		)
 
-		for _, raw := range vv {
+		for _, raw := range ff.Values {
 			value := strings.TrimSpace(raw)
 			if value == "" {
 				continue
@@ -99,18 +279,66 @@ func StringContainsInsensitiveFilterFieldApplier(field string) FilterFieldApplie
 			// Escape LIKE wildcard characters before building the pattern
 			escapedValue := escapeLikePattern(strings.ToLower(value))
 			pattern := "%" + escapedValue + "%"
+			cond := fmt.Sprintf("LOWER(%s) LIKE ? ESCAPE '\\'", expr)
 			if !set {
-				q = db.Where(fmt.Sprintf("LOWER(%s) LIKE ? ESCAPE '\\'", field), pattern)
+				q = db.Session(&gorm.Session{NewDB: true}).Where(cond, append(slices.Clone(args), pattern)...)
 				set = true
 				continue
 			}
-			q = q.Or(fmt.Sprintf("LOWER(%s) LIKE ? ESCAPE '\\'", field), pattern)
+			q = q.Or(cond, append(slices.Clone(args), pattern)...)
 		}
 
 		if !set {
 			return db, nil
 		}
-		return q, nil
+		return db.Where(q), nil
+	}
+}
+
+// JSONFilterFieldApplier filters a wildcard field on the value at the key path of a JSONB column
+// Nested keys are separated by dots, numbers are compared numerically by gte and lte
+func JSONFilterFieldApplier(column string) FilterFieldApplier {
+	return func(db *gorm.DB, ff FieldFilter) (*gorm.DB, error) {
+		if ff.Key == "" {
+			return nil, fmt.Errorf("missing key")
+		}
+		path := pq.StringArray(strings.Split(ff.Key, "."))
+		if slices.Contains(path, "") {
+			return nil, fmt.Errorf("invalid key %s", ff.Key)
+		}
+		text := fmt.Sprintf("(%s #>> ?)", column)
+
+		switch ff.Op {
+		case FilterOpDefault, FilterOpIn:
+			if len(ff.Values) == 0 {
+				return db, nil
+			}
+			return db.Where(text+" IN ?", path, ff.Values), nil
+		case FilterOpEq:
+			v, err := singleFilterValue(ff)
+			if err != nil {
+				return nil, err
+			}
+			return db.Where(text+" = ?", path, v), nil
+		case FilterOpLike:
+			return containsInsensitiveFilter(text, []any{path}, nil)(db, ff)
+		case FilterOpGte, FilterOpLte:
+			v, err := singleFilterValue(ff)
+			if err != nil {
+				return nil, err
+			}
+			cmp := ">="
+			if ff.Op == FilterOpLte {
+				cmp = "<="
+			}
+			if n, err := strconv.ParseFloat(v, 64); err == nil {
+				// Non numeric values yield NULL instead of failing the cast
+				numeric := fmt.Sprintf("CASE WHEN jsonb_typeof(%s #> ?) = 'number' THEN (%s #>> ?)::numeric END", column, column)
+				return db.Where(fmt.Sprintf("%s %s ?", numeric, cmp), path, path, n), nil
+			}
+			return db.Where(fmt.Sprintf("%s %s ?", text, cmp), path, v), nil
+		}
+		return nil, unsupportedFilterOp(ff.Op)
 	}
 }
 
@@ -141,11 +369,25 @@ func listPaginated[T any](
 		q = authzFilterApplier(authIdentityScope, q)
 	}
 
-	// Get total count
+	// Get total count, unless skipped
 	var count int64
-	q = q.Count(&count)
-	if q.Error != nil {
-		return nil, q.Error
+	if !page.SkipCount {
+		q = q.Count(&count)
+		if q.Error != nil {
+			return nil, q.Error
+		}
+		if count == 0 {
+			// Nothing to read, neither the items nor their relations
+			if page.Cursor != nil {
+				return domain.NewCursorPaginatedResult(items, count, page, ""), nil
+			}
+			return domain.NewPaginatedResult(items, count, page), nil
+		}
+	}
+
+	// Cursor pagination uses a keyset on (created_at, id) instead of sorting and offset
+	if page.Cursor != nil {
+		return listCursorPaginated[T](q, page, count, preloadPaths)
 	}
 
 	// Apply sorting if a sort applier is provided
@@ -172,11 +414,77 @@ func listPaginated[T any](
 		return nil, err
 	}
 
+	// Without the count, the extra item fetched tells whether another page follows
+	if page.SkipCount {
+		hasNext := len(items) > page.PageSize
+		if hasNext {
+			items = items[:page.PageSize]
+		}
+		return domain.NewUncountedPaginatedResult(items, page, hasNext), nil
+	}
 	return domain.NewPaginatedResult(items, count, page), nil
 }
 
+// cursorEntity is implemented by entities that can be paginated with a cursor
+type cursorEntity interface {
+	GetID() properties.UUID
+	GetCreatedAt() time.Time
+}
+
+// listCursorPaginated fetches the page following the request cursor ordered by (created_at, id)
+// Newest items come first unless ascending createdAt sort is requested
+func listCursorPaginated[T any](q *gorm.DB, page *domain.PageReq, count int64, preloadPaths []string) (*domain.PageRes[T], error) {
+	desc := true
+	for i, f := range page.SortOrder() {
+		if i > 0 || f.Field != "createdAt" {
+			return nil, domain.NewInvalidInputErrorf("cannot sort by field %s with cursor pagination", f.Field)
+		}
+		desc = !f.Asc
+	}
+
+	createdAt := clause.Column{Table: clause.CurrentTable, Name: "created_at"}
+	id := clause.Column{Table: clause.CurrentTable, Name: "id"}
+	if !page.Cursor.IsStart() {
+		op := ">"
+		if desc {
+			op = "<"
+		}
+		q = q.Where(fmt.Sprintf("(?, ?) %s (?, ?)", op), createdAt, id, page.Cursor.CreatedAt, page.Cursor.ID)
+	}
+	q = q.Order(clause.OrderByColumn{Column: createdAt, Desc: desc}).
+		Order(clause.OrderByColumn{Column: id, Desc: desc}).
+		Limit(page.PageSize + 1)
+
+	for _, path := range preloadPaths {
+		q = q.Preload(path)
+	}
+
+	var items []T
+	if err := q.Find(&items).Error; err != nil {
+		return nil, err
+	}
+
+	// The extra item only tells whether another page follows
+	var nextCursor string
+	if len(items) > page.PageSize {
+		items = items[:page.PageSize]
+		last, ok := any(&items[len(items)-1]).(cursorEntity)
+		if !ok {
+			return nil, fmt.Errorf("cursor pagination is not supported for %T", items[0])
+		}
+		nextCursor = domain.PageCursor{CreatedAt: last.GetCreatedAt(), ID: last.GetID()}.Encode()
+	}
+
+	return domain.NewCursorPaginatedResult(items, count, page, nextCursor), nil
+}
+
+// applyPagination selects the items of the page, plus the first item of the next page when the count is skipped
 func applyPagination(db *gorm.DB, r *domain.PageReq) (*gorm.DB, error) {
 	offset := (r.Page - 1) * r.PageSize
-	db = db.Offset(offset).Limit(r.PageSize)
+	limit := r.PageSize
+	if r.SkipCount {
+		limit++
+	}
+	db = db.Offset(offset).Limit(limit)
 	return db, nil
 }
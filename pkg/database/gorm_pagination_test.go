@@ -0,0 +1,129 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/fulcrumproject/core/pkg/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// newDryRunDB returns a database that only builds statements, no connection is made
+func newDryRunDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost"}), &gorm.Config{DryRun: true, DisableAutomaticPing: true})
+	require.NoError(t, err)
+	return db
+}
+
+func TestMapFilterApplier(t *testing.T) {
+	db := newDryRunDB(t)
+
+	tests := []struct {
+		name          string
+		filters       map[string][]string
+		expectedSQL   string
+		expectedError string
+	}{
+		{
+			name:        "Default operator keeps field behavior",
+			filters:     map[string][]string{"currentStatus": {"Started", "Stopped"}},
+			expectedSQL: `WHERE services.status IN ('Started','Stopped') AND provider_id = 'p'`,
+		},
+		{
+			name:        "In operator splits comma separated values",
+			filters:     map[string][]string{"currentStatus[in]": {"Started,Stopped"}},
+			expectedSQL: `WHERE services.status IN ('Started','Stopped') AND provider_id = 'p'`,
+		},
+		{
+			name:        "Eq operator",
+			filters:     map[string][]string{"name[eq]": {"web"}},
+			expectedSQL: `WHERE services.name = 'web' AND provider_id = 'p'`,
+		},
+		{
+			name:        "Contains alternatives are grouped with the authz scope",
+			filters:     map[string][]string{"name": {"a", "b"}},
+			expectedSQL: `WHERE (LOWER(services.name) LIKE '%a%' ESCAPE '\' OR LOWER(services.name) LIKE '%b%' ESCAPE '\') AND provider_id = 'p'`,
+		},
+		{
+			name:        "Time range",
+			filters:     map[string][]string{"createdAt[gte]": {"2024-01-01"}, "createdAt[lte]": {"2024-02-01T10:00:00Z"}},
+			expectedSQL: `WHERE services.created_at >= '2024-01-01 00:00:00' AND services.created_at <= '2024-02-01 10:00:00' AND provider_id = 'p'`,
+		},
+		{
+			name:        "Attribute equality",
+			filters:     map[string][]string{"attr.tier": {"premium"}},
+			expectedSQL: `WHERE (services.properties #>> '{"tier"}') IN ('premium') AND provider_id = 'p'`,
+		},
+		{
+			name:        "Nested attribute like",
+			filters:     map[string][]string{"attr.network.zone[like]": {"EU"}},
+			expectedSQL: `WHERE LOWER((services.properties #>> '{"network","zone"}')) LIKE '%eu%' ESCAPE '\' AND provider_id = 'p'`,
+		},
+		{
+			name:        "Numeric attribute range",
+			filters:     map[string][]string{"attr.cpu[gte]": {"2"}},
+			expectedSQL: `WHERE CASE WHEN jsonb_typeof(services.properties #> '{"cpu"}') = 'number' THEN (services.properties #>> '{"cpu"}')::numeric END >= 2 AND provider_id = 'p'`,
+		},
+		{
+			name:          "Unknown field",
+			filters:       map[string][]string{"secret": {"x"}},
+			expectedError: "cannot filter by field secret",
+		},
+		{
+			name:          "Unsupported operator",
+			filters:       map[string][]string{"name[regex]": {"x"}},
+			expectedError: "unsupported filter operator regex on field name",
+		},
+		{
+			name:          "Operator not supported by the field",
+			filters:       map[string][]string{"groupId[gte]": {"x"}},
+			expectedError: "invalid filter groupId[gte]: unsupported operator gte",
+		},
+		{
+			name:          "Invalid value",
+			filters:       map[string][]string{"createdAt[gte]": {"yesterday"}},
+			expectedError: "invalid filter createdAt[gte]",
+		},
+		{
+			name:          "Comparison with several values",
+			filters:       map[string][]string{"createdAt[gte]": {"2024-01-01", "2024-02-01"}},
+			expectedError: "operator gte accepts a single value",
+		},
+		{
+			name:          "Malformed operator",
+			filters:       map[string][]string{"name[eq": {"x"}},
+			expectedError: "invalid filter name[eq",
+		},
+		{
+			name:          "Empty attribute key",
+			filters:       map[string][]string{"attr.a..b": {"x"}},
+			expectedError: "invalid key a..b",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var applyErr error
+			sql := db.ToSQL(func(tx *gorm.DB) *gorm.DB {
+				q, err := applyServiceFieldFilter(tx.Model(&domain.Service{}), &domain.PageReq{Filters: tc.filters})
+				if err != nil {
+					applyErr = err
+					return tx
+				}
+				var services []domain.Service
+				return q.Where("provider_id = ?", "p").Find(&services)
+			})
+
+			if tc.expectedError != "" {
+				require.Error(t, applyErr)
+				assert.ErrorAs(t, applyErr, &domain.InvalidInputError{})
+				assert.Contains(t, applyErr.Error(), tc.expectedError)
+				return
+			}
+			require.NoError(t, applyErr)
+			assert.Contains(t, sql, tc.expectedSQL)
+		})
+	}
+}
@@ -1,13 +1,17 @@
 package database
 
 import (
+	"errors"
 	"fmt"
+	"strings"
 
+	"github.com/jackc/pgx/v5/pgconn"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 
 	"github.com/fulcrumproject/core/pkg/domain"
 	"github.com/fulcrumproject/core/pkg/gormlock"
+	"github.com/fulcrumproject/core/pkg/properties"
 	"github.com/fulcrumproject/utils/gormpg"
 )
 
@@ -26,6 +30,11 @@ func NewLockerConnection(config *gormpg.Conf) (*gorm.DB, error) {
 	return connection(config, autoMigrateLocker)
 }
 
+// NewReadReplicaConnection creates a connection to a read replica of the primary database, it is not migrated
+func NewReadReplicaConnection(config *gormpg.Conf) (*gorm.DB, error) {
+	return connection(config, func(*gorm.DB) error { return nil })
+}
+
 func connection(config *gormpg.Conf, fn migrateFn) (*gorm.DB, error) {
 	db, err := gorm.Open(postgres.New(postgres.Config{
 		DSN: config.DSN,
@@ -53,6 +62,17 @@ func autoMigrate(db *gorm.DB) error {
 	if err := migrateConfigPoolScope(db); err != nil {
 		return err
 	}
+	if err := checkServiceGroupNames(db); err != nil {
+		return err
+	}
+	// The instance IDs were unique across all the agents, they are now unique per agent among the active services
+	if err := db.Exec("DROP INDEX IF EXISTS service_agent_instance_id_uniq").Error; err != nil {
+		return err
+	}
+
+	if err := migrateEventSequence(db); err != nil {
+		return err
+	}
 
 	err := db.AutoMigrate(
 		&domain.Token{},
@@ -74,19 +94,63 @@ func autoMigrate(db *gorm.DB) error {
 		&domain.MetricType{},
 		&domain.Event{},
 		&domain.EventSubscription{},
+		&domain.EventDelivery{},
 		&vaultSecret{},
+		&vaultSecretVersion{},
+		&idempotencyKey{},
 	)
 	if err != nil {
 		return err
 	}
 
+	if err := initEventSequence(db); err != nil {
+		return err
+	}
+
 	if err := backfillConfigPoolValueParticipant(db); err != nil {
 		return err
 	}
 
+	if err := backfillTokenHashCost(db); err != nil {
+		return err
+	}
+
 	return backfillServicePoolParticipant(db)
 }
 
+// migrateEventSequence creates the counter of the event sequence numbers and the function drawing from it,
+// they must exist before the events table whose sequence number defaults to the function
+func migrateEventSequence(db *gorm.DB) error {
+	if err := db.AutoMigrate(&eventSequence{}); err != nil {
+		return err
+	}
+	// The update locks the counter row until the creating transaction ends, so the numbers are
+	// committed in order and a rollback gives its number back, unlike a Postgres sequence.
+	// The writers of events are serialized on the row in exchange, see the event ordering in DESIGN.md
+	return db.Exec(`
+		CREATE OR REPLACE FUNCTION next_event_sequence() RETURNS bigint LANGUAGE sql AS $$
+			UPDATE event_sequences SET value = value + 1 WHERE id = 1 RETURNING value
+		$$
+	`).Error
+}
+
+// initEventSequence starts the counter after the existing events and replaces the serial default
+// of the sequence numbers by the counter. Idempotent, the counter is only initialized once.
+// Must run after AutoMigrate since the events table may be created there.
+func initEventSequence(db *gorm.DB) error {
+	if err := db.Exec(`
+		INSERT INTO event_sequences (id, value)
+		SELECT 1, COALESCE(MAX(sequence_number), 0) FROM events
+		ON CONFLICT (id) DO NOTHING
+	`).Error; err != nil {
+		return err
+	}
+	if err := db.Exec("ALTER TABLE events ALTER COLUMN sequence_number SET DEFAULT next_event_sequence()").Error; err != nil {
+		return err
+	}
+	return db.Exec("DROP SEQUENCE IF EXISTS events_sequence_number_seq").Error
+}
+
 // backfillConfigPoolValueParticipant copies participant_id from the parent pool onto
 // rows that predate the denormalization. Idempotent — the IS NULL guard keeps it safe
 // to re-run on every boot. Must run after AutoMigrate since the column it writes to
@@ -109,6 +173,25 @@ func backfillConfigPoolValueParticipant(db *gorm.DB) error {
 	return nil
 }
 
+// backfillTokenHashCost copies the cost of the PBKDF2 token hashes onto the hash_cost column of
+// the tokens that predate it. Idempotent, the legacy hashes keep their 0 cost.
+// Must run after AutoMigrate since the column it writes to is introduced there.
+func backfillTokenHashCost(db *gorm.DB) error {
+	res := db.Exec(`
+		UPDATE tokens
+		SET hash_cost = split_part(hashed_value, '$', 2)::int
+		WHERE hash_cost = 0
+		  AND hashed_value LIKE 'pbkdf2-sha256$%'
+	`)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected > 0 {
+		db.Logger.Info(db.Statement.Context, "backfilled hash_cost on %d tokens rows", res.RowsAffected)
+	}
+	return nil
+}
+
 // backfillServicePoolParticipant copies provider_id from the parent pool set onto
 // service_pools, then propagates to service_pool_values. Idempotent via IS NULL guards.
 // Must run after AutoMigrate since the columns it writes to are introduced there.
@@ -144,6 +227,39 @@ func backfillServicePoolParticipant(db *gorm.DB) error {
 	return nil
 }
 
+// checkServiceGroupNames fails the migration adding the unique index of the service names in a group
+// while active services share a name, listing them so they can be renamed first
+func checkServiceGroupNames(db *gorm.DB) error {
+	m := db.Migrator()
+	if !m.HasTable(&domain.Service{}) || m.HasIndex(&domain.Service{}, serviceGroupNameIndex) {
+		return nil
+	}
+	var duplicates []struct {
+		GroupID properties.UUID
+		Name    string
+		Count   int
+	}
+	err := db.Raw(`
+		SELECT group_id, name, COUNT(*) AS count
+		FROM services
+		WHERE deleted_at IS NULL
+		GROUP BY group_id, name
+		HAVING COUNT(*) > 1
+		ORDER BY group_id, name
+	`).Scan(&duplicates).Error
+	if err != nil {
+		return err
+	}
+	if len(duplicates) == 0 {
+		return nil
+	}
+	names := make([]string, len(duplicates))
+	for i, d := range duplicates {
+		names[i] = fmt.Sprintf("%q in group %s (%d services)", d.Name, d.GroupID, d.Count)
+	}
+	return fmt.Errorf("service names must be unique in their group, rename the services first: %s", strings.Join(names, ", "))
+}
+
 func migrateConfigPoolScope(db *gorm.DB) error {
 	m := db.Migrator()
 
@@ -205,3 +321,12 @@ func autoMigrateLocker(db *gorm.DB) error {
 		&gormlock.CronJobLock{},
 	)
 }
+
+// pgUniqueViolation is the SQLSTATE of a unique constraint violation
+const pgUniqueViolation = "23505"
+
+// isUniqueViolation reports whether err is the violation of the named unique constraint or index
+func isUniqueViolation(err error, constraint string) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == pgUniqueViolation && pgErr.ConstraintName == constraint
+}
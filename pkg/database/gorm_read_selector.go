@@ -0,0 +1,91 @@
+package database
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/fulcrumproject/core/pkg/auth"
+	"github.com/fulcrumproject/core/pkg/properties"
+)
+
+// ReadSelector selects the connection of the read queries of the queriers: the read replica, or the
+// primary for the identities that wrote within the stickiness window so they read their own writes
+//
+// The writes are tracked in memory, so an identity reads its own writes only from the API replica
+// that served them. A read served by another API replica may not see a write yet, until the read
+// replica catches up with the primary.
+type ReadSelector struct {
+	primary    *gorm.DB
+	replica    *gorm.DB
+	stickiness time.Duration
+	now        func() time.Time
+
+	mu     sync.Mutex
+	writes map[properties.UUID]time.Time // Last write of the identities within the stickiness window
+}
+
+// NewReadSelector creates the read selector and tracks the writes made on the primary
+func NewReadSelector(primary, replica *gorm.DB, stickiness time.Duration) (*ReadSelector, error) {
+	s := &ReadSelector{
+		primary:    primary,
+		replica:    replica,
+		stickiness: stickiness,
+		now:        time.Now,
+		writes:     make(map[properties.UUID]time.Time),
+	}
+	callbacks := primary.Callback()
+	if err := callbacks.Create().After("gorm:create").Register("fulcrum:track_create", s.trackWrite); err != nil {
+		return nil, err
+	}
+	if err := callbacks.Update().After("gorm:update").Register("fulcrum:track_update", s.trackWrite); err != nil {
+		return nil, err
+	}
+	if err := callbacks.Delete().After("gorm:delete").Register("fulcrum:track_delete", s.trackWrite); err != nil {
+		return nil, err
+	}
+	if err := callbacks.Raw().After("gorm:raw").Register("fulcrum:track_raw", s.trackWrite); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// trackWrite records the write of the identity of the statement
+func (s *ReadSelector) trackWrite(db *gorm.DB) {
+	if db.Error != nil || db.Statement.Context == nil {
+		return
+	}
+	s.markWrite(db.Statement.Context)
+}
+
+// markWrite records a write of the identity of the context, if any
+func (s *ReadSelector) markWrite(ctx context.Context) {
+	identity := auth.GetIdentity(ctx)
+	if identity == nil || s.stickiness == 0 {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	for id, at := range s.writes {
+		if now.Sub(at) >= s.stickiness {
+			delete(s.writes, id)
+		}
+	}
+	s.writes[identity.ID] = now
+}
+
+// Reader returns the connection of a read query
+func (s *ReadSelector) Reader(ctx context.Context) *gorm.DB {
+	if identity := auth.GetIdentity(ctx); identity != nil {
+		s.mu.Lock()
+		at, ok := s.writes[identity.ID]
+		s.mu.Unlock()
+		if ok && s.now().Sub(at) < s.stickiness {
+			return s.primary.WithContext(ctx)
+		}
+	}
+	return s.replica.WithContext(ctx)
+}
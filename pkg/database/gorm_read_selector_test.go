@@ -0,0 +1,67 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/fulcrumproject/core/pkg/auth"
+	"github.com/fulcrumproject/core/pkg/domain"
+	"github.com/fulcrumproject/core/pkg/properties"
+)
+
+func TestReadSelector(t *testing.T) {
+	primary := newDryRunDB(t)
+	replica := newDryRunDB(t)
+	reads, err := NewReadSelector(primary, replica, 5*time.Second)
+	require.NoError(t, err)
+	now := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	reads.now = func() time.Time { return now }
+
+	writer := &auth.Identity{ID: properties.NewUUID(), Role: auth.RoleAdmin}
+	reader := &auth.Identity{ID: properties.NewUUID(), Role: auth.RoleAdmin}
+	writerCtx := auth.WithIdentity(context.Background(), writer)
+	readerCtx := auth.WithIdentity(context.Background(), reader)
+
+	isPrimary := func(db *gorm.DB) bool { return db.Statement.ConnPool == primary.ConnPool }
+	// The dry run cannot open the default transaction of the writes
+	primary = primary.Session(&gorm.Session{SkipDefaultTransaction: true})
+
+	assert.False(t, isPrimary(reads.Reader(writerCtx)), "Reads go to the replica before any write")
+	assert.False(t, isPrimary(reads.Reader(context.Background())), "Reads without identity go to the replica")
+
+	// A write on the primary, in a transaction or not, makes the writer read from the primary
+	require.NoError(t, primary.WithContext(writerCtx).Create(&domain.ServiceGroup{Name: "group"}).Error)
+	assert.True(t, isPrimary(reads.Reader(writerCtx)), "The writer reads its own writes")
+	assert.False(t, isPrimary(reads.Reader(readerCtx)), "The other identities keep reading from the replica")
+
+	// Writes without identity, e.g. of the workers, are not tracked
+	require.NoError(t, primary.WithContext(context.Background()).Create(&domain.ServiceGroup{Name: "group"}).Error)
+	assert.False(t, isPrimary(reads.Reader(readerCtx)))
+
+	now = now.Add(5 * time.Second)
+	assert.False(t, isPrimary(reads.Reader(writerCtx)), "The writer reads from the replica after the stickiness window")
+
+	require.NoError(t, primary.WithContext(readerCtx).Model(&domain.ServiceGroup{}).Where("id = ?", properties.NewUUID()).Update("name", "renamed").Error)
+	assert.True(t, isPrimary(reads.Reader(readerCtx)))
+	assert.NotContains(t, reads.writes, writer.ID, "The expired writes are forgotten")
+}
+
+func TestGormReadOnlyStoreReads(t *testing.T) {
+	primary := newDryRunDB(t)
+	replica := newDryRunDB(t)
+	reads, err := NewReadSelector(primary, replica, time.Second)
+	require.NoError(t, err)
+
+	repo := NewGormReadOnlyStore(primary, reads).ServiceQuerier().(*GormServiceRepository)
+	assert.Same(t, reads, repo.reads)
+	assert.True(t, repo.readDB(context.Background()).Statement.ConnPool == replica.ConnPool)
+
+	repo = NewGormReadOnlyStore(primary, nil).ServiceQuerier().(*GormServiceRepository)
+	assert.Nil(t, repo.reads)
+	assert.True(t, repo.readDB(context.Background()).Statement.ConnPool == primary.ConnPool)
+}
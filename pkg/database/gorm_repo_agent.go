@@ -22,6 +22,7 @@ var applyAgentFilter = MapFilterApplier(map[string]FilterFieldApplier{
 	"status":      ParserInFilterFieldApplier("status", domain.ParseAgentStatus),
 	"providerId":  ParserInFilterFieldApplier("provider_id", properties.ParseUUID),
 	"agentTypeId": ParserInFilterFieldApplier("agent_type_id", properties.ParseUUID),
+	"region":      StringInFilterFieldApplier("region"),
 })
 
 var applyAgentSort = MapSortApplier(map[string]string{
@@ -61,6 +62,21 @@ func (r *GormAgentRepository) CountByAgentType(ctx context.Context, agentTypeID
 	return count, nil
 }
 
+// CountByAgentTypeAndStatus returns the number of agents grouped by agent type name and status
+func (r *GormAgentRepository) CountByAgentTypeAndStatus(ctx context.Context) ([]domain.StatusCount, error) {
+	var counts []domain.StatusCount
+	result := r.db.WithContext(ctx).Model(&domain.Agent{}).
+		Select("agent_types.name AS \"group\", agents.status AS status, COUNT(*) AS count").
+		Joins("JOIN agent_types ON agent_types.id = agents.agent_type_id").
+		Group("agent_types.name, agents.status").
+		Order("agent_types.name, agents.status").
+		Scan(&counts)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return counts, nil
+}
+
 func (r *GormAgentRepository) FindByServiceTypeAndTags(ctx context.Context, serviceTypeID properties.UUID, tags []string) ([]*domain.Agent, error) {
 	var agents []*domain.Agent
 
@@ -95,6 +111,29 @@ func (r *GormAgentRepository) MarkInactiveAgentsAsDisconnected(ctx context.Conte
 	return result.RowsAffected, result.Error
 }
 
+// HealthSummary reads the agents with their active job and service counts in a single query,
+// the counts are served by the indexes on the agent of the jobs and of the active services
+func (r *GormAgentRepository) HealthSummary(ctx context.Context, scope *auth.IdentityScope, filters map[string][]string) ([]domain.AgentHealth, error) {
+	q := r.db.WithContext(ctx).Model(&domain.Agent{}).
+		Select(`agents.id, agents.name, agents.status, agents.draining, agents.last_status_update AS last_seen_at,
+			(SELECT COUNT(*) FROM jobs WHERE jobs.agent_id = agents.id AND jobs.status IN ?) AS active_jobs,
+			(SELECT COUNT(*) FROM services WHERE services.agent_id = agents.id AND services.deleted_at IS NULL) AS active_services`,
+			[]domain.JobStatus{domain.JobPending, domain.JobProcessing})
+	q, err := applyAgentFilter(q, &domain.PageReq{Filters: filters})
+	if err != nil {
+		return nil, err
+	}
+	if scope != nil {
+		q = agentAuthzFilterApplier(scope, q)
+	}
+
+	var health []domain.AgentHealth
+	if err := q.Order("agents.name, agents.id").Scan(&health).Error; err != nil {
+		return nil, err
+	}
+	return health, nil
+}
+
 // agentAuthzFilterApplier applies authorization scoping to agent queries
 func agentAuthzFilterApplier(s *auth.IdentityScope, q *gorm.DB) *gorm.DB {
 	if s.ParticipantID != nil {
@@ -599,6 +599,94 @@ func TestAgentRepository(t *testing.T) {
 		})
 	})
 
+	t.Run("CountByAgentTypeAndStatus", func(t *testing.T) {
+		ctx := context.Background()
+
+		participant := createTestParticipant(t, domain.ParticipantEnabled)
+		require.NoError(t, participantRepo.Create(ctx, participant))
+		agentType := createTestAgentType(t)
+		require.NoError(t, agentTypeRepo.Create(ctx, agentType))
+
+		for _, status := range []domain.AgentStatus{domain.AgentConnected, domain.AgentConnected, domain.AgentDisconnected} {
+			require.NoError(t, agentRepo.Create(ctx, createTestAgent(t, participant.ID, agentType.ID, status)))
+		}
+
+		counts, err := agentRepo.CountByAgentTypeAndStatus(ctx)
+		require.NoError(t, err)
+		assert.Contains(t, counts, domain.StatusCount{Group: agentType.Name, Status: string(domain.AgentConnected), Count: 2})
+		assert.Contains(t, counts, domain.StatusCount{Group: agentType.Name, Status: string(domain.AgentDisconnected), Count: 1})
+	})
+
+	t.Run("HealthSummary", func(t *testing.T) {
+		ctx := context.Background()
+		serviceRepo := NewServiceRepository(tdb.DB)
+		jobRepo := NewJobRepository(tdb.DB)
+
+		provider := createTestParticipant(t, domain.ParticipantEnabled)
+		require.NoError(t, participantRepo.Create(ctx, provider))
+		consumer := createTestParticipant(t, domain.ParticipantEnabled)
+		require.NoError(t, participantRepo.Create(ctx, consumer))
+		agentType := createTestAgentType(t)
+		require.NoError(t, agentTypeRepo.Create(ctx, agentType))
+		serviceType := createTestServiceType(t)
+		require.NoError(t, NewServiceTypeRepository(tdb.DB).Create(ctx, serviceType))
+		group := createTestServiceGroup(t, consumer.ID)
+		require.NoError(t, NewServiceGroupRepository(tdb.DB).Create(ctx, group))
+
+		lastSeen := time.Now().Add(-10 * time.Minute).UTC().Truncate(time.Second)
+		busy := createTestAgentWithStatusUpdate(t, provider.ID, agentType.ID, domain.AgentConnected, lastSeen)
+		busy.Name = "a-busy"
+		require.NoError(t, agentRepo.Create(ctx, busy))
+		idle := createTestAgentWithStatusUpdate(t, provider.ID, agentType.ID, domain.AgentConnected, lastSeen)
+		idle.Name = "b-idle"
+		require.NoError(t, agentRepo.Create(ctx, idle))
+
+		// Three active services with jobs in several states and a deleted service on the busy agent
+		for range 3 {
+			svc := createTestService(t, serviceType.ID, group.ID, busy.ID, provider.ID, consumer.ID)
+			require.NoError(t, serviceRepo.Create(ctx, svc))
+			for _, status := range []domain.JobStatus{domain.JobPending, domain.JobProcessing, domain.JobCompleted} {
+				job := domain.NewJob(svc, "create", nil, 1)
+				job.Status = status
+				require.NoError(t, jobRepo.Create(ctx, job))
+			}
+		}
+		deleted := createTestService(t, serviceType.ID, group.ID, busy.ID, provider.ID, consumer.ID)
+		deletedAt := time.Now()
+		deleted.DeletedAt = &deletedAt
+		require.NoError(t, serviceRepo.Create(ctx, deleted))
+
+		// The health keeps the last time the agent was seen once it is marked as disconnected
+		_, err := agentRepo.MarkInactiveAgentsAsDisconnected(ctx, 5*time.Minute)
+		require.NoError(t, err)
+
+		scope := &auth.IdentityScope{ParticipantID: &provider.ID}
+		health, err := agentRepo.HealthSummary(ctx, scope, nil)
+		require.NoError(t, err)
+		require.Len(t, health, 2)
+		assert.Equal(t, domain.AgentHealth{
+			ID: busy.ID, Name: "a-busy", Status: domain.AgentDisconnected, LastSeenAt: health[0].LastSeenAt,
+			ActiveJobs: 6, ActiveServices: 3,
+		}, health[0])
+		assert.WithinDuration(t, lastSeen, health[0].LastSeenAt, time.Second)
+		assert.Equal(t, idle.ID, health[1].ID)
+		assert.Zero(t, health[1].ActiveJobs)
+		assert.Zero(t, health[1].ActiveServices)
+
+		health, err = agentRepo.HealthSummary(ctx, scope, map[string][]string{"name": {"idle"}})
+		require.NoError(t, err)
+		require.Len(t, health, 1)
+		assert.Equal(t, idle.ID, health[0].ID)
+
+		otherID := properties.NewUUID()
+		health, err = agentRepo.HealthSummary(ctx, &auth.IdentityScope{ParticipantID: &otherID}, nil)
+		require.NoError(t, err)
+		assert.Empty(t, health)
+
+		_, err = agentRepo.HealthSummary(ctx, scope, map[string][]string{"unknown": {"x"}})
+		assert.ErrorAs(t, err, &domain.InvalidInputError{})
+	})
+
 	t.Run("AuthScope", func(t *testing.T) {
 		t.Run("success - returns correct auth scope", func(t *testing.T) {
 			ctx := context.Background()
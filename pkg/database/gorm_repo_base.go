@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"errors"
 	"log/slog"
+	"slices"
 
 	"github.com/fulcrumproject/core/pkg/auth"
 	"github.com/fulcrumproject/core/pkg/authz"
@@ -27,7 +28,9 @@ type GormRepository[T Tabler] struct {
 	sortApplier        PageFilterApplier
 	findPreloadPaths   []string
 	listPreloadPaths   []string
+	listIncludePaths   map[string]string // Preload paths of the relations loaded only when in the include list of the page
 	authzFilterApplier AuthzFilterApplier
+	reads              *ReadSelector // Connection of Get, List, Count and Exists, the primary when nil
 }
 
 // NewGormRepository creates a new instance of GormRepository
@@ -57,7 +60,19 @@ func (r *GormRepository[T]) Create(ctx context.Context, entity *T) error {
 	return nil
 }
 
+// versionedEntity is implemented by entities embedding domain.BaseEntity
+type versionedEntity interface {
+	GetID() properties.UUID
+	GetVersion() int
+	SetVersion(version int)
+}
+
 func (r *GormRepository[T]) Save(ctx context.Context, entity *T) error {
+	if v, ok := any(entity).(versionedEntity); ok && v.GetID() != (properties.UUID{}) {
+		if err := r.bumpVersion(ctx, v); err != nil {
+			return err
+		}
+	}
 	result := r.db.WithContext(ctx).Save(entity)
 	if result.Error != nil {
 		return result.Error
@@ -65,6 +80,43 @@ func (r *GormRepository[T]) Save(ctx context.Context, entity *T) error {
 	return nil
 }
 
+// bumpVersion increments the stored version of the entity before it is saved
+// When the context carries an expected version for the entity a stale version fails the save
+func (r *GormRepository[T]) bumpVersion(ctx context.Context, entity versionedEntity) error {
+	table := (*new(T)).TableName()
+	db := r.db.WithContext(ctx)
+
+	query := "UPDATE " + table + " SET version = version + 1 WHERE id = ?"
+	args := []any{entity.GetID()}
+	expected, check := domain.ExpectedVersion(ctx, entity.GetID())
+	if check {
+		query += " AND version = ?"
+		args = append(args, expected)
+	}
+
+	var versions []int
+	if err := db.Raw(query+" RETURNING version", args...).Scan(&versions).Error; err != nil {
+		return err
+	}
+	if len(versions) == 1 {
+		entity.SetVersion(versions[0])
+		return nil
+	}
+	if !check {
+		// Not stored yet, the save creates it
+		return nil
+	}
+
+	var current []int
+	if err := db.Raw("SELECT version FROM "+table+" WHERE id = ?", entity.GetID()).Scan(&current).Error; err != nil {
+		return err
+	}
+	if len(current) == 0 {
+		return domain.NewNotFoundErrorf("%s %s", table, entity.GetID())
+	}
+	return domain.NewPreconditionFailedErrorf(current[0], "version %d does not match current version %d", expected, current[0])
+}
+
 func (r *GormRepository[T]) Delete(ctx context.Context, id properties.UUID) error {
 	result := r.db.WithContext(ctx).Delete(new(T), id)
 	if result.Error != nil {
@@ -73,10 +125,23 @@ func (r *GormRepository[T]) Delete(ctx context.Context, id properties.UUID) erro
 	return nil
 }
 
+// setReadSelector routes the reads of the repository with the read selector
+func (r *GormRepository[T]) setReadSelector(reads *ReadSelector) {
+	r.reads = reads
+}
+
+// readDB returns the connection of the reads, the authorization scopes are always read from the primary
+func (r *GormRepository[T]) readDB(ctx context.Context) *gorm.DB {
+	if r.reads == nil {
+		return r.db.WithContext(ctx)
+	}
+	return r.reads.Reader(ctx)
+}
+
 func (r *GormRepository[T]) Get(ctx context.Context, id properties.UUID) (*T, error) {
 	entity := new(T)
 	entityValue := *entity
-	db := r.db.WithContext(ctx)
+	db := r.readDB(ctx)
 
 	for _, path := range r.findPreloadPaths {
 		db = db.Preload(path)
@@ -96,19 +161,33 @@ func (r *GormRepository[T]) Get(ctx context.Context, id properties.UUID) (*T, er
 func (r *GormRepository[T]) List(ctx context.Context, authIdentityScope *auth.IdentityScope, page *domain.PageReq) (*domain.PageRes[T], error) {
 	return listPaginated[T](
 		ctx,
-		r.db,
+		r.readDB(ctx),
 		page,
 		r.filterApplier,
 		r.sortApplier,
 		r.authzFilterApplier,
-		r.listPreloadPaths,
+		r.listPreloads(page),
 		authIdentityScope,
 	)
 }
 
+// listPreloads returns the list preload paths followed by the ones of the included relations
+func (r *GormRepository[T]) listPreloads(page *domain.PageReq) []string {
+	if len(page.Include) == 0 || len(r.listIncludePaths) == 0 {
+		return r.listPreloadPaths
+	}
+	paths := slices.Clone(r.listPreloadPaths)
+	for _, include := range page.Include {
+		if path, ok := r.listIncludePaths[include]; ok && !slices.Contains(paths, path) {
+			paths = append(paths, path)
+		}
+	}
+	return paths
+}
+
 func (r *GormRepository[T]) Count(ctx context.Context) (int64, error) {
 	var count int64
-	db := r.db.WithContext(ctx).Model(new(T))
+	db := r.readDB(ctx).Model(new(T))
 
 	result := db.Count(&count)
 	if result.Error != nil {
@@ -122,7 +201,7 @@ func (r *GormRepository[T]) Exists(ctx context.Context, id properties.UUID) (boo
 	var exists bool
 	entity := new(T)
 	entityValue := *entity
-	db := r.db.WithContext(ctx)
+	db := r.readDB(ctx)
 
 	query := db.Select("1").
 		Table(entityValue.TableName()).
@@ -162,11 +241,17 @@ func providerConsumerAgentAuthzFilterApplier(s *auth.IdentityScope, q *gorm.DB)
 }
 
 // AuthScopeByFields retrieves auth scope for an entity with specified scope fields
+// The fields are the participant, provider, agent and consumer, optionally followed by the service group
 func (r *GormRepository[T]) AuthScopeByFields(ctx context.Context, id properties.UUID, scopeFields ...string) (authz.ObjectScope, error) {
 	var scope authz.DefaultObjectScope
 	entity := new(T)
 	entityValue := *entity
 
+	dest := []any{&scope.ParticipantID, &scope.ProviderID, &scope.AgentID, &scope.ConsumerID}
+	if len(scopeFields) > len(dest) {
+		dest = append(dest, &scope.GroupID)
+	}
+
 	slog.Info(entityValue.TableName())
 	err := r.db.
 		WithContext(ctx).
@@ -174,7 +259,7 @@ func (r *GormRepository[T]) AuthScopeByFields(ctx context.Context, id properties
 		Select(scopeFields).
 		Where("id = ?", id).
 		Row().
-		Scan(&scope.ParticipantID, &scope.ProviderID, &scope.AgentID, &scope.ConsumerID)
+		Scan(dest...)
 
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) || errors.Is(err, sql.ErrNoRows) {
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/fulcrumproject/core/pkg/auth"
 	"github.com/fulcrumproject/core/pkg/authz"
 	"github.com/fulcrumproject/core/pkg/properties"
 	"gorm.io/gorm"
@@ -12,6 +13,16 @@ import (
 	"github.com/fulcrumproject/core/pkg/domain"
 )
 
+// eventSequence is the single row counter the event sequence numbers are drawn from
+type eventSequence struct {
+	ID    int   `gorm:"primaryKey;autoIncrement:false"`
+	Value int64 `gorm:"not null"`
+}
+
+func (eventSequence) TableName() string {
+	return "event_sequences"
+}
+
 type GormEventRepository struct {
 	*GormRepository[domain.Event]
 }
@@ -20,6 +31,8 @@ var applyEventFilter = MapFilterApplier(map[string]FilterFieldApplier{
 	"initiatorType": StringInFilterFieldApplier("initiator_type"),
 	"initiatorId":   ParserInFilterFieldApplier("initiator_id", properties.ParseUUID),
 	"type":          StringContainsInsensitiveFilterFieldApplier("type"),
+	"entityId":      ParserInFilterFieldApplier("entity_id", properties.ParseUUID),
+	"requestId":     StringInFilterFieldApplier("request_id"),
 })
 
 var applyEventSort = MapSortApplier(map[string]string{
@@ -42,11 +55,28 @@ func NewEventRepository(db *gorm.DB) *GormEventRepository {
 	return repo
 }
 
-// ListFromSequence retrieves events starting from a specific sequence number
-func (r *GormEventRepository) ListFromSequence(ctx context.Context, fromSequenceNumber int64, limit int) ([]*domain.Event, error) {
+// ListFromSequence retrieves the events matching the filter starting from a specific sequence number
+// The filter is applied by the query, the events of the other subscribers are never read
+func (r *GormEventRepository) ListFromSequence(ctx context.Context, fromSequenceNumber int64, filter domain.EventFilter, limit int) ([]*domain.Event, error) {
+	db := r.db.WithContext(ctx).Where("sequence_number > ?", fromSequenceNumber)
+	if len(filter.Types) > 0 {
+		db = db.Where("type IN ?", []string(filter.Types))
+	}
+	if filter.EntityID != nil {
+		db = db.Where("entity_id = ?", *filter.EntityID)
+	}
+	if filter.ProviderID != nil {
+		db = db.Where("provider_id = ?", *filter.ProviderID)
+	}
+	if filter.ConsumerID != nil {
+		db = db.Where("consumer_id = ?", *filter.ConsumerID)
+	}
+	if filter.ParticipantID != nil {
+		db = db.Where("(provider_id = ? OR consumer_id = ? OR participant_id = ?)", *filter.ParticipantID, *filter.ParticipantID, *filter.ParticipantID)
+	}
+
 	var events []*domain.Event
-	result := r.db.WithContext(ctx).
-		Where("sequence_number > ?", fromSequenceNumber).
+	result := db.
 		Order("sequence_number ASC").
 		Limit(limit).
 		Find(&events)
@@ -58,6 +88,57 @@ func (r *GormEventRepository) ListFromSequence(ctx context.Context, fromSequence
 	return events, nil
 }
 
+// ListScopedFromSequence retrieves the events visible to the identity scope after a sequence number
+func (r *GormEventRepository) ListScopedFromSequence(ctx context.Context, scope *auth.IdentityScope, fromSequenceNumber int64, types []domain.EventType, limit int) ([]*domain.Event, error) {
+	db := r.db.WithContext(ctx).Where("sequence_number > ?", fromSequenceNumber)
+	if len(types) > 0 {
+		db = db.Where("type IN ?", types)
+	}
+	db = r.authzFilterApplier(scope, db)
+
+	var events []*domain.Event
+	result := db.
+		Order("sequence_number ASC").
+		Limit(limit).
+		Find(&events)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return events, nil
+}
+
+// ListScopedInTimeRange retrieves the events visible to the identity scope after a sequence number in a creation time range
+func (r *GormEventRepository) ListScopedInTimeRange(ctx context.Context, scope *auth.IdentityScope, fromSequenceNumber int64, start *time.Time, end *time.Time, limit int) ([]*domain.Event, error) {
+	db := r.db.WithContext(ctx).Where("sequence_number > ?", fromSequenceNumber)
+	if start != nil {
+		db = db.Where("created_at >= ?", *start)
+	}
+	if end != nil {
+		db = db.Where("created_at < ?", *end)
+	}
+	db = r.authzFilterApplier(scope, db)
+
+	var events []*domain.Event
+	result := db.
+		Order("sequence_number ASC").
+		Limit(limit).
+		Find(&events)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return events, nil
+}
+
+// LastSequenceNumber returns the sequence number of the most recent event
+func (r *GormEventRepository) LastSequenceNumber(ctx context.Context) (int64, error) {
+	var last int64
+	result := r.db.WithContext(ctx).Model(&domain.Event{}).Select("COALESCE(MAX(sequence_number), 0)").Scan(&last)
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	return last, nil
+}
+
 func (r *GormEventRepository) AuthScope(ctx context.Context, id properties.UUID) (authz.ObjectScope, error) {
 	return r.AuthScopeByFields(ctx, id, "null", "provider_id", "agent_id", "consumer_id")
 }
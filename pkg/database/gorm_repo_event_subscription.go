@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/fulcrumproject/core/pkg/auth"
 	"github.com/fulcrumproject/core/pkg/authz"
@@ -88,8 +89,14 @@ func (r *GormEventSubscriptionRepository) ExistsBySubscriberID(ctx context.Conte
 	return count > 0, nil
 }
 
-// DeleteBySubscriberID removes an event subscription by subscriber ID
+// DeleteBySubscriberID removes an event subscription by subscriber ID with its delivery history
 func (r *GormEventSubscriptionRepository) DeleteBySubscriberID(ctx context.Context, subscriberID string) error {
+	err := r.db.WithContext(ctx).
+		Where("subscription_id IN (?)", r.db.Model(&domain.EventSubscription{}).Select("id").Where("subscriber_id = ?", subscriberID)).
+		Delete(&domain.EventDelivery{}).Error
+	if err != nil {
+		return err
+	}
 	result := r.db.WithContext(ctx).Where("subscriber_id = ?", subscriberID).Delete(&domain.EventSubscription{})
 	if result.Error != nil {
 		return result.Error
@@ -112,8 +119,81 @@ func (r *GormEventSubscriptionRepository) ListExpiredLeases(ctx context.Context)
 	return subscriptions, nil
 }
 
+// ListDueWebhooks retrieves active webhook subscriptions whose next delivery is due
+func (r *GormEventSubscriptionRepository) ListDueWebhooks(ctx context.Context, now time.Time) ([]*domain.EventSubscription, error) {
+	var subscriptions []*domain.EventSubscription
+	result := r.db.WithContext(ctx).
+		Where("callback_url IS NOT NULL AND is_active AND dead_lettered_at IS NULL").
+		Where("next_delivery_at IS NULL OR next_delivery_at <= ?", now).
+		Order("created_at ASC").
+		Find(&subscriptions)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return subscriptions, nil
+}
+
+// RecordDelivery stores a webhook delivery attempt and removes the attempts of its subscription beyond the latest keep ones
+func (r *GormEventSubscriptionRepository) RecordDelivery(ctx context.Context, delivery *domain.EventDelivery, keep int) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(delivery).Error; err != nil {
+			return err
+		}
+		latest := tx.Model(&domain.EventDelivery{}).
+			Select("id").
+			Where("subscription_id = ?", delivery.SubscriptionID).
+			Order("created_at DESC, id DESC").
+			Limit(keep)
+		return tx.
+			Where("subscription_id = ? AND id NOT IN (?)", delivery.SubscriptionID, latest).
+			Delete(&domain.EventDelivery{}).Error
+	})
+}
+
+// ListDeliveries retrieves the recorded webhook delivery attempts of a subscription, latest first
+func (r *GormEventSubscriptionRepository) ListDeliveries(ctx context.Context, subscriptionID properties.UUID, page *domain.PageReq) (*domain.PageRes[domain.EventDelivery], error) {
+	return listPaginated[domain.EventDelivery](
+		ctx,
+		r.readDB(ctx).Where("subscription_id = ?", subscriptionID),
+		page,
+		nil,
+		applyEventDeliverySort,
+		nil,
+		nil,
+		nil,
+	)
+}
+
+// applyEventDeliverySort orders the delivery attempts from the latest one
+func applyEventDeliverySort(q *gorm.DB, _ *domain.PageReq) (*gorm.DB, error) {
+	return q.Order("created_at DESC, id DESC"), nil
+}
+
+// DeliveryStats aggregates the recorded webhook delivery attempts of a subscription
+func (r *GormEventSubscriptionRepository) DeliveryStats(ctx context.Context, subscriptionID properties.UUID) (*domain.EventDeliveryStats, error) {
+	var stats domain.EventDeliveryStats
+	err := r.readDB(ctx).
+		Model(&domain.EventDelivery{}).
+		Select(`COUNT(*) AS attempts,
+			COUNT(*) FILTER (WHERE success) AS successes,
+			MAX(created_at) FILTER (WHERE success) AS last_success_at`).
+		Where("subscription_id = ?", subscriptionID).
+		Scan(&stats).Error
+	if err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}
+
 // AuthScope returns the auth scope for the event subscription
+// Subscriptions restricted to the events of participants belong to them, the others are system-level resources
 func (r *GormEventSubscriptionRepository) AuthScope(ctx context.Context, id properties.UUID) (authz.ObjectScope, error) {
-	// Event subscriptions are system-level resources, no specific participant scope
-	return &authz.AllwaysMatchObjectScope{}, nil
+	scope, err := r.AuthScopeByFields(ctx, id, "null", "filter_provider_id", "null", "filter_consumer_id")
+	if err != nil {
+		return nil, err
+	}
+	if filter := scope.(*authz.DefaultObjectScope); filter.ProviderID == nil && filter.ConsumerID == nil {
+		return &authz.AdminOnlyObjectScope{}, nil
+	}
+	return scope, nil
 }
@@ -5,6 +5,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/fulcrumproject/core/pkg/authz"
 	"github.com/fulcrumproject/core/pkg/domain"
 	"github.com/fulcrumproject/core/pkg/properties"
 	"github.com/stretchr/testify/assert"
@@ -273,6 +274,44 @@ func TestEventSubscriptionRepository(t *testing.T) {
 		})
 	})
 
+	t.Run("ListDueWebhooks", func(t *testing.T) {
+		ctx := context.Background()
+		now := time.Now()
+		callbackURL := "https://example.com/hook"
+		later := now.Add(time.Hour)
+		earlier := now.Add(-time.Minute)
+
+		due := createTestEventSubscription(t, "webhook-due")
+		due.CallbackURL = &callbackURL
+		require.NoError(t, repo.Create(ctx, due))
+
+		retryDue := createTestEventSubscription(t, "webhook-retry-due")
+		retryDue.CallbackURL = &callbackURL
+		retryDue.NextDeliveryAt = &earlier
+		require.NoError(t, repo.Create(ctx, retryDue))
+
+		backingOff := createTestEventSubscription(t, "webhook-backing-off")
+		backingOff.CallbackURL = &callbackURL
+		backingOff.NextDeliveryAt = &later
+		require.NoError(t, repo.Create(ctx, backingOff))
+
+		deadLettered := createTestEventSubscription(t, "webhook-dead-lettered")
+		deadLettered.CallbackURL = &callbackURL
+		deadLettered.DeadLetteredAt = &earlier
+		require.NoError(t, repo.Create(ctx, deadLettered))
+
+		polling := createTestEventSubscription(t, "webhook-polling")
+		require.NoError(t, repo.Create(ctx, polling))
+
+		subscriptions, err := repo.ListDueWebhooks(ctx, now)
+		require.NoError(t, err)
+		ids := make([]properties.UUID, len(subscriptions))
+		for i, s := range subscriptions {
+			ids[i] = s.ID
+		}
+		assert.ElementsMatch(t, []properties.UUID{due.ID, retryDue.ID}, ids)
+	})
+
 	t.Run("Lease Operations", func(t *testing.T) {
 		t.Run("acquire and release lease", func(t *testing.T) {
 			ctx := context.Background()
@@ -357,7 +396,80 @@ func TestEventSubscriptionRepository(t *testing.T) {
 			// Assert
 			require.NoError(t, err)
 			assert.NotNil(t, scope)
-			// EventSubscriptions are system-level resources, so scope should be empty
+			// Subscriptions without participant filter are system-level resources
+			assert.IsType(t, &authz.AdminOnlyObjectScope{}, scope)
+		})
+
+		t.Run("participant filter", func(t *testing.T) {
+			ctx := context.Background()
+			consumerID := properties.NewUUID()
+			subscription := createTestEventSubscription(t, "authscope-participant-subscriber")
+			subscription.Filter = domain.NewEventFilter(nil, nil, nil, &consumerID)
+			require.NoError(t, repo.Create(ctx, subscription))
+
+			scope, err := repo.AuthScope(ctx, subscription.ID)
+			require.NoError(t, err)
+			defaultScope, ok := scope.(*authz.DefaultObjectScope)
+			require.True(t, ok)
+			assert.Nil(t, defaultScope.ProviderID)
+			assert.Equal(t, consumerID, *defaultScope.ConsumerID)
+		})
+	})
+
+	t.Run("Deliveries", func(t *testing.T) {
+		ctx := context.Background()
+		subscription := createTestEventSubscription(t, "deliveries-subscriber")
+		require.NoError(t, repo.Create(ctx, subscription))
+
+		start := time.Now().Add(-time.Hour).Truncate(time.Millisecond)
+		errMsg := "callback returned status 503"
+		record := func(i int, success bool) {
+			status := 200
+			if !success {
+				status = 503
+			}
+			delivery := &domain.EventDelivery{
+				CreatedAt:      start.Add(time.Duration(i) * time.Minute),
+				SubscriptionID: subscription.ID,
+				EventID:        properties.NewUUID(),
+				SequenceNumber: int64(i),
+				Attempt:        1,
+				StatusCode:     &status,
+				Success:        success,
+			}
+			if !success {
+				delivery.Error = &errMsg
+			}
+			require.NoError(t, repo.RecordDelivery(ctx, delivery, 3))
+		}
+		record(1, true)
+		record(2, true)
+		record(3, false)
+		record(4, true)
+
+		t.Run("keeps the latest attempts", func(t *testing.T) {
+			result, err := repo.ListDeliveries(ctx, subscription.ID, &domain.PageReq{Page: 1, PageSize: 10})
+			require.NoError(t, err)
+			assert.Equal(t, int64(3), result.TotalItems)
+			require.Len(t, result.Items, 3)
+			assert.Equal(t, []int64{4, 3, 2}, []int64{result.Items[0].SequenceNumber, result.Items[1].SequenceNumber, result.Items[2].SequenceNumber})
+			assert.Equal(t, 503, *result.Items[1].StatusCode)
+		})
+
+		t.Run("stats", func(t *testing.T) {
+			stats, err := repo.DeliveryStats(ctx, subscription.ID)
+			require.NoError(t, err)
+			assert.Equal(t, int64(3), stats.Attempts)
+			assert.Equal(t, int64(2), stats.Successes)
+			require.NotNil(t, stats.LastSuccessAt)
+			assert.WithinDuration(t, start.Add(4*time.Minute), *stats.LastSuccessAt, time.Millisecond)
+		})
+
+		t.Run("removed with the subscription", func(t *testing.T) {
+			require.NoError(t, repo.DeleteBySubscriberID(ctx, subscription.SubscriberID))
+			stats, err := repo.DeliveryStats(ctx, subscription.ID)
+			require.NoError(t, err)
+			assert.Equal(t, int64(0), stats.Attempts)
 		})
 	})
 }
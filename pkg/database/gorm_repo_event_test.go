@@ -2,14 +2,18 @@ package database
 
 import (
 	"context"
+	"errors"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/fulcrumproject/core/pkg/auth"
 	"github.com/fulcrumproject/core/pkg/authz"
 	"github.com/fulcrumproject/core/pkg/properties"
+	"github.com/fulcrumproject/core/pkg/tracing"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
 
 	"github.com/fulcrumproject/core/pkg/domain"
 	"github.com/google/uuid"
@@ -145,6 +149,32 @@ func TestEventRepository(t *testing.T) {
 			}
 		})
 
+		t.Run("success - list with request ID filter", func(t *testing.T) {
+			requestID := tracing.NewRequestID()
+			ctx := tracing.WithRequestID(context.Background(), requestID)
+			entry := &domain.Event{
+				InitiatorType: domain.InitiatorTypeUser,
+				InitiatorID:   "request-1",
+				Type:          domain.EventTypeAgentUpdated,
+			}
+			require.NoError(t, repo.Create(ctx, entry))
+
+			page := &domain.PageReq{
+				Page:     1,
+				PageSize: 10,
+				Filters:  map[string][]string{"requestId": {requestID}},
+			}
+
+			// Execute
+			result, err := repo.List(context.Background(), &auth.IdentityScope{}, page)
+
+			// Assert
+			require.NoError(t, err)
+			require.Len(t, result.Items, 1)
+			assert.Equal(t, entry.ID, result.Items[0].ID)
+			assert.Equal(t, requestID, result.Items[0].RequestID)
+		})
+
 		t.Run("success - list with sorting by sequence_number", func(t *testing.T) {
 			ctx := context.Background()
 
@@ -246,6 +276,227 @@ func TestEventRepository(t *testing.T) {
 		})
 	})
 
+	t.Run("ListScopedFromSequence", func(t *testing.T) {
+		ctx := context.Background()
+		start, err := repo.LastSequenceNumber(ctx)
+		require.NoError(t, err)
+
+		participantID := properties.NewUUID()
+		otherID := properties.NewUUID()
+		events := []*domain.Event{
+			{InitiatorType: domain.InitiatorTypeUser, InitiatorID: "u", Type: domain.EventTypeServiceCreated, ConsumerID: &participantID},
+			{InitiatorType: domain.InitiatorTypeUser, InitiatorID: "u", Type: domain.EventTypeServiceUpdated, ProviderID: &participantID},
+			{InitiatorType: domain.InitiatorTypeUser, InitiatorID: "u", Type: domain.EventTypeServiceCreated, ConsumerID: &otherID},
+		}
+		for _, e := range events {
+			require.NoError(t, repo.Create(ctx, e))
+		}
+
+		last, err := repo.LastSequenceNumber(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, events[2].SequenceNumber, last)
+
+		scope := &auth.IdentityScope{ParticipantID: &participantID}
+		result, err := repo.ListScopedFromSequence(ctx, scope, start, nil, 10)
+		require.NoError(t, err)
+		require.Len(t, result, 2)
+		assert.Equal(t, events[0].ID, result[0].ID)
+		assert.Equal(t, events[1].ID, result[1].ID)
+
+		result, err = repo.ListScopedFromSequence(ctx, scope, events[0].SequenceNumber, nil, 10)
+		require.NoError(t, err)
+		require.Len(t, result, 1)
+		assert.Equal(t, events[1].ID, result[0].ID)
+
+		result, err = repo.ListScopedFromSequence(ctx, scope, start, []domain.EventType{domain.EventTypeServiceCreated}, 10)
+		require.NoError(t, err)
+		require.Len(t, result, 1)
+		assert.Equal(t, events[0].ID, result[0].ID)
+
+		result, err = repo.ListScopedFromSequence(ctx, &auth.IdentityScope{}, start, nil, 10)
+		require.NoError(t, err)
+		assert.Len(t, result, 3)
+	})
+
+	t.Run("sequence numbers have no gaps", func(t *testing.T) {
+		ctx := context.Background()
+		newEvent := func() *domain.Event {
+			return &domain.Event{InitiatorType: domain.InitiatorTypeUser, InitiatorID: "u", Type: domain.EventTypeServiceCreated}
+		}
+
+		first := newEvent()
+		require.NoError(t, repo.Create(ctx, first))
+		assert.Positive(t, first.SequenceNumber)
+
+		// The number of a rolled back event is given back
+		err := testDB.DB.Transaction(func(tx *gorm.DB) error {
+			rolledBack := newEvent()
+			require.NoError(t, NewEventRepository(tx).Create(ctx, rolledBack))
+			assert.Equal(t, first.SequenceNumber+1, rolledBack.SequenceNumber)
+			return errors.New("rollback")
+		})
+		require.Error(t, err)
+
+		second := newEvent()
+		require.NoError(t, repo.Create(ctx, second))
+		assert.Equal(t, first.SequenceNumber+1, second.SequenceNumber)
+
+		last, err := repo.LastSequenceNumber(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, second.SequenceNumber, last)
+	})
+
+	t.Run("concurrent writers are never skipped by a reader", func(t *testing.T) {
+		ctx := context.Background()
+		start, err := repo.LastSequenceNumber(ctx)
+		require.NoError(t, err)
+
+		// Each writer holds its transaction open after drawing its number, a reader following the
+		// sequence must still see every event once the writers are done
+		const writers = 8
+		entityID := properties.NewUUID()
+		var wg sync.WaitGroup
+		errs := make(chan error, writers)
+		for range writers {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				errs <- testDB.DB.Transaction(func(tx *gorm.DB) error {
+					event := &domain.Event{InitiatorType: domain.InitiatorTypeSystem, InitiatorID: "w", Type: domain.EventTypeServiceUpdated, EntityID: &entityID}
+					if err := NewEventRepository(tx).Create(ctx, event); err != nil {
+						return err
+					}
+					time.Sleep(20 * time.Millisecond)
+					return nil
+				})
+			}()
+		}
+		done := make(chan struct{})
+		go func() {
+			wg.Wait()
+			close(done)
+		}()
+
+		var seen []*domain.Event
+		cursor := start
+		filter := domain.NewEventFilter(nil, &entityID, nil, nil)
+		for finished := false; !finished; {
+			select {
+			case <-done:
+				finished = true
+			default:
+			}
+			events, err := repo.ListFromSequence(ctx, cursor, filter, 100)
+			require.NoError(t, err)
+			for _, event := range events {
+				seen = append(seen, event)
+				cursor = event.SequenceNumber
+			}
+		}
+		close(errs)
+		for err := range errs {
+			require.NoError(t, err)
+		}
+
+		require.Len(t, seen, writers, "an event committed after a higher number was read would be skipped")
+		for i, event := range seen {
+			assert.Equal(t, start+int64(i)+1, event.SequenceNumber)
+		}
+	})
+
+	t.Run("ListFromSequence", func(t *testing.T) {
+		ctx := context.Background()
+		start, err := repo.LastSequenceNumber(ctx)
+		require.NoError(t, err)
+
+		providerID := properties.NewUUID()
+		consumerID := properties.NewUUID()
+		entityID := properties.NewUUID()
+		events := []*domain.Event{
+			{InitiatorType: domain.InitiatorTypeUser, InitiatorID: "u", Type: domain.EventTypeServiceCreated, EntityID: &entityID, ProviderID: &providerID, ConsumerID: &consumerID},
+			{InitiatorType: domain.InitiatorTypeUser, InitiatorID: "u", Type: domain.EventTypeServiceUpdated, EntityID: &entityID, ProviderID: &providerID, ConsumerID: &consumerID},
+			{InitiatorType: domain.InitiatorTypeUser, InitiatorID: "u", Type: domain.EventTypeServiceCreated, ProviderID: &providerID},
+		}
+		for _, e := range events {
+			require.NoError(t, repo.Create(ctx, e))
+		}
+
+		result, err := repo.ListFromSequence(ctx, start, domain.EventFilter{}, 10)
+		require.NoError(t, err)
+		assert.Len(t, result, 3)
+
+		result, err = repo.ListFromSequence(ctx, start, domain.NewEventFilter([]domain.EventType{domain.EventTypeServiceCreated}, nil, &providerID, nil), 10)
+		require.NoError(t, err)
+		require.Len(t, result, 2)
+		assert.Equal(t, events[0].ID, result[0].ID)
+		assert.Equal(t, events[2].ID, result[1].ID)
+
+		result, err = repo.ListFromSequence(ctx, start, domain.NewEventFilter(nil, &entityID, nil, &consumerID), 10)
+		require.NoError(t, err)
+		require.Len(t, result, 2)
+
+		result, err = repo.ListFromSequence(ctx, events[0].SequenceNumber, domain.NewEventFilter(nil, nil, nil, &consumerID), 10)
+		require.NoError(t, err)
+		require.Len(t, result, 1)
+		assert.Equal(t, events[1].ID, result[0].ID)
+
+		// The participant filter matches the participant as provider or consumer
+		result, err = repo.ListFromSequence(ctx, start, domain.EventFilter{ParticipantID: &consumerID}, 10)
+		require.NoError(t, err)
+		require.Len(t, result, 2)
+		assert.Equal(t, events[0].ID, result[0].ID)
+		assert.Equal(t, events[1].ID, result[1].ID)
+
+		result, err = repo.ListFromSequence(ctx, start, domain.EventFilter{Types: []string{string(domain.EventTypeServiceCreated)}, ParticipantID: &providerID}, 10)
+		require.NoError(t, err)
+		require.Len(t, result, 2)
+		assert.Equal(t, events[2].ID, result[1].ID)
+	})
+
+	t.Run("ListScopedInTimeRange", func(t *testing.T) {
+		ctx := context.Background()
+		start, err := repo.LastSequenceNumber(ctx)
+		require.NoError(t, err)
+
+		participantID := properties.NewUUID()
+		otherID := properties.NewUUID()
+		base := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+		events := []*domain.Event{
+			{BaseEntity: domain.BaseEntity{CreatedAt: base}, InitiatorType: domain.InitiatorTypeUser, InitiatorID: "u", Type: domain.EventTypeServiceCreated, ConsumerID: &participantID},
+			{BaseEntity: domain.BaseEntity{CreatedAt: base.Add(time.Hour)}, InitiatorType: domain.InitiatorTypeUser, InitiatorID: "u", Type: domain.EventTypeServiceUpdated, ProviderID: &participantID},
+			{BaseEntity: domain.BaseEntity{CreatedAt: base.Add(2 * time.Hour)}, InitiatorType: domain.InitiatorTypeUser, InitiatorID: "u", Type: domain.EventTypeServiceUpdated, ConsumerID: &participantID},
+			{BaseEntity: domain.BaseEntity{CreatedAt: base.Add(time.Hour)}, InitiatorType: domain.InitiatorTypeUser, InitiatorID: "u", Type: domain.EventTypeServiceCreated, ConsumerID: &otherID},
+		}
+		for _, e := range events {
+			require.NoError(t, repo.Create(ctx, e))
+		}
+
+		scope := &auth.IdentityScope{ParticipantID: &participantID}
+		result, err := repo.ListScopedInTimeRange(ctx, scope, start, nil, nil, 10)
+		require.NoError(t, err)
+		require.Len(t, result, 3)
+
+		// The start is inclusive and the end exclusive
+		from, to := base.Add(time.Hour), base.Add(2*time.Hour)
+		result, err = repo.ListScopedInTimeRange(ctx, scope, start, &from, &to, 10)
+		require.NoError(t, err)
+		require.Len(t, result, 1)
+		assert.Equal(t, events[1].ID, result[0].ID)
+
+		result, err = repo.ListScopedInTimeRange(ctx, &auth.IdentityScope{}, start, &from, nil, 10)
+		require.NoError(t, err)
+		assert.Len(t, result, 3)
+
+		// Batches continue after the last sequence number read
+		result, err = repo.ListScopedInTimeRange(ctx, scope, start, nil, nil, 2)
+		require.NoError(t, err)
+		require.Len(t, result, 2)
+		result, err = repo.ListScopedInTimeRange(ctx, scope, result[1].SequenceNumber, nil, nil, 2)
+		require.NoError(t, err)
+		require.Len(t, result, 1)
+		assert.Equal(t, events[2].ID, result[0].ID)
+	})
+
 	t.Run("AuthScope", func(t *testing.T) {
 		t.Run("success - returns correct auth scope", func(t *testing.T) {
 			ctx := context.Background()
@@ -2,11 +2,16 @@ package database
 
 import (
 	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/fulcrumproject/core/pkg/authz"
 	"github.com/fulcrumproject/core/pkg/properties"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 
 	"github.com/fulcrumproject/core/pkg/domain"
 )
@@ -20,6 +25,9 @@ var applyJobFilter = MapFilterApplier(map[string]FilterFieldApplier{
 	"status":    ParserInFilterFieldApplier("jobs.status", domain.ParseJobStatus),
 	"agentId":   ParserInFilterFieldApplier("jobs.agent_id", properties.ParseUUID),
 	"serviceId": ParserInFilterFieldApplier("jobs.service_id", properties.ParseUUID),
+	"requestId": StringInFilterFieldApplier("jobs.request_id"),
+	// Diagnostics are searched by key path, e.g. diagnostics.code=E42 or diagnostics.logs[like]=timeout
+	"diagnostics.*": JSONFilterFieldApplier("jobs.diagnostics"),
 })
 
 var applyJobSort = MapSortApplier(map[string]string{
@@ -44,9 +52,17 @@ func NewJobRepository(db *gorm.DB) *GormJobRepository {
 	return repo
 }
 
+// agentFreeJobSlotsSQL computes the jobs an agent can still claim, NULL when the agent has no limit
+// Takes the agent ID twice and the processing status as arguments
+const agentFreeJobSlotsSQL = `(SELECT agents.max_concurrent_jobs - (
+	SELECT COUNT(*) FROM jobs AS processing_jobs WHERE processing_jobs.agent_id = ? AND processing_jobs.status = ?
+) FROM agents WHERE agents.id = ?)`
+
 // GetPendingJobsForAgent retrieves pending jobs targeted for a specific agent
 // Returns only one pending job per service group with the highest priority
 // Excludes service groups that have any jobs currently in processing status
+// Returns nothing for draining agents, jobs already in processing are not affected
+// Returns at most the free job slots of the agent, computed in the query from its concurrency limit
 func (r *GormJobRepository) GetPendingJobsForAgent(ctx context.Context, agentID properties.UUID, limit int) ([]*domain.Job, error) {
 	var jobs []*domain.Job
 
@@ -63,13 +79,22 @@ func (r *GormJobRepository) GetPendingJobsForAgent(ctx context.Context, agentID
 		Table("jobs").
 		Select("jobs.*, ROW_NUMBER() OVER (PARTITION BY services.group_id ORDER BY jobs.priority DESC, jobs.created_at ASC) as rn").
 		Joins("JOIN services ON jobs.service_id = services.id").
+		Joins("JOIN agents ON jobs.agent_id = agents.id").
 		Where("jobs.agent_id = ? AND jobs.status = ?", agentID, domain.JobPending).
+		Where("agents.draining = ?", false).
 		Where("services.group_id NOT IN (?)", processingGroupsSubquery)
 
+	// Number the selected jobs in delivery order to keep only as many as the agent has free slots
+	positioned := r.db.WithContext(ctx).
+		Table("(?) as ranked_jobs", subquery).
+		Select("ranked_jobs.*, ROW_NUMBER() OVER (ORDER BY ranked_jobs.priority DESC, ranked_jobs.created_at ASC) as delivery_rank").
+		Where("ranked_jobs.rn = 1")
+
 	err := r.db.WithContext(ctx).
 		Preload("Service").
-		Table("(?) as ranked_jobs", subquery).
-		Where("ranked_jobs.rn = 1").
+		Table("(?) as positioned_jobs", positioned).
+		Where("positioned_jobs.delivery_rank <= COALESCE("+agentFreeJobSlotsSQL+", positioned_jobs.delivery_rank)", agentID, domain.JobProcessing, agentID).
+		Order("positioned_jobs.priority DESC, positioned_jobs.created_at ASC").
 		Limit(limit).
 		Find(&jobs).Error
 
@@ -79,13 +104,140 @@ func (r *GormJobRepository) GetPendingJobsForAgent(ctx context.Context, agentID
 	return jobs, nil
 }
 
+// ClaimIfCapacity saves the claimed job only if it is still pending and its agent has a free job slot
+// The agent row is locked so concurrent claims of the same agent are serialized, then the free
+// slots are checked by the update statement itself
+func (r *GormJobRepository) ClaimIfCapacity(ctx context.Context, job *domain.Job) (bool, error) {
+	claimed := false
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT id FROM agents WHERE id = ? FOR UPDATE", job.AgentID).Error; err != nil {
+			return err
+		}
+		result := tx.
+			Model(job).
+			Where("status = ?", domain.JobPending).
+			Where("COALESCE("+agentFreeJobSlotsSQL+", 1) > 0", job.AgentID, domain.JobProcessing, job.AgentID).
+			Select("*").
+			Updates(job)
+		if result.Error != nil {
+			return result.Error
+		}
+		claimed = result.RowsAffected == 1
+		return nil
+	})
+	return claimed, err
+}
+
+// SaveIfStatus saves the job only if its stored status still matches, the check and the update are a single statement
+func (r *GormJobRepository) SaveIfStatus(ctx context.Context, job *domain.Job, status domain.JobStatus) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Model(job).
+		Where("status = ?", status).
+		Select("*").
+		Updates(job)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// FailIfStatus fails the jobs still in the from status with a single statement and returns the IDs of the updated jobs
+// The lease is cleared in the same statement, the failed jobs are no longer reclaimed
+func (r *GormJobRepository) FailIfStatus(ctx context.Context, ids []properties.UUID, from domain.JobStatus, to domain.JobStatus, errorMessage string, completedAt time.Time) ([]properties.UUID, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	var updated []domain.Job
+	result := r.db.WithContext(ctx).
+		Model(&updated).
+		Clauses(clause.Returning{Columns: []clause.Column{{Name: "id"}}}).
+		Where("id IN ? AND status = ?", ids, from).
+		Updates(map[string]any{
+			"status":           to,
+			"error_message":    errorMessage,
+			"completed_at":     completedAt,
+			"lease_id":         nil,
+			"lease_expires_at": nil,
+			"version":          gorm.Expr("version + 1"),
+		})
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	updatedIDs := make([]properties.UUID, len(updated))
+	for i, job := range updated {
+		updatedIDs[i] = job.ID
+	}
+	return updatedIDs, nil
+}
+
+// SaveIfLeaseHeld saves the job only if it is still processing under the lease, the check and the update are a single statement
+func (r *GormJobRepository) SaveIfLeaseHeld(ctx context.Context, job *domain.Job, leaseID properties.UUID) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Model(job).
+		Where("status = ? AND lease_id = ?", domain.JobProcessing, leaseID).
+		Select("*").
+		Updates(job)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// SaveIfLeaseExpired saves the job only if the lease is still the current one and expired before the given time
+// A renewal or a report of the agent racing with the reclaim makes the save a no-op
+func (r *GormJobRepository) SaveIfLeaseExpired(ctx context.Context, job *domain.Job, leaseID properties.UUID, at time.Time) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Model(job).
+		Where("status = ? AND lease_id = ? AND lease_expires_at < ?", domain.JobProcessing, leaseID, at).
+		Select("*").
+		Updates(job)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// GetExpiredLeaseJobs retrieves processing jobs whose lease expired before the given time
+func (r *GormJobRepository) GetExpiredLeaseJobs(ctx context.Context, at time.Time) ([]*domain.Job, error) {
+	var jobs []*domain.Job
+	err := r.db.WithContext(ctx).
+		Where("status = ? AND lease_expires_at < ?", domain.JobProcessing, at).
+		Order("lease_expires_at").
+		Find(&jobs).Error
+	if err != nil {
+		return nil, err
+	}
+	return jobs, nil
+}
+
 // GetTimeOutJobs retrieves jobs that have been processing for too long and returns them
-func (r *GormJobRepository) GetTimeOutJobs(ctx context.Context, olderThan time.Duration) ([]*domain.Job, error) {
-	cutoffTime := time.Now().Add(-olderThan)
+// The cutoff time is selected per action in SQL, actions without an override use the default timeout
+func (r *GormJobRepository) GetTimeOutJobs(ctx context.Context, timeouts domain.JobTimeouts) ([]*domain.Job, error) {
+	now := time.Now()
+
+	// The operation timeout of the service takes precedence over the configured timeouts
+	cutoff := "CASE WHEN services.operation_timeout IS NOT NULL" +
+		" THEN ?::timestamptz - (services.operation_timeout / 1000) * INTERVAL '1 microsecond'"
+	args := []any{now}
+	if len(timeouts.Actions) > 0 {
+		actions := slices.Sorted(maps.Keys(timeouts.Actions))
+		cutoff += strings.Repeat(" WHEN jobs.action = ? THEN ?", len(actions))
+		for _, action := range actions {
+			args = append(args, action, now.Add(-timeouts.Actions[action]))
+		}
+	}
+	cutoff += " ELSE ? END"
+	args = append(args, now.Add(-timeouts.Default))
 
 	var timedOutJobs []*domain.Job
+	// Promoted and requeued jobs are measured from their latest scheduled or requeue time, not from their creation,
+	// a reclaimed job retried after a backoff is scheduled after its requeue
+	// A multi-step job is alive as long as the agent reports its steps, it is measured from its most recent step
 	err := r.db.WithContext(ctx).
-		Where("status IN ? AND created_at < ?", []domain.JobStatus{domain.JobProcessing, domain.JobPending}, cutoffTime).
+		Select("jobs.*").
+		Joins("JOIN services ON services.id = jobs.service_id").
+		Where("jobs.status IN ?", []domain.JobStatus{domain.JobProcessing, domain.JobPending}).
+		Where("GREATEST(jobs.requeued_at, jobs.scheduled_at, jobs.created_at, jobs.last_step_at) < "+cutoff, args...).
 		Find(&timedOutJobs).Error
 
 	if err != nil {
@@ -95,12 +247,23 @@ func (r *GormJobRepository) GetTimeOutJobs(ctx context.Context, olderThan time.D
 	return timedOutJobs, nil
 }
 
-// DeleteOldCompletedJobs removes completed or failed jobs older than the specified days
-func (r *GormJobRepository) DeleteOldCompletedJobs(ctx context.Context, olderThan time.Duration) (int, error) {
-	cutoffTime := time.Now().Add(-olderThan)
+// DeleteFinishedBefore deletes up to limit jobs in the given statuses completed before the given time
+// The oldest jobs are deleted first, by their IDs, so a single statement only locks a bounded number of rows.
+// With keepLast a job is only deleted once a newer job of its service, scheduled ones aside, exists
+func (r *GormJobRepository) DeleteFinishedBefore(ctx context.Context, statuses []domain.JobStatus, before time.Time, limit int, keepLast bool) (int, error) {
+	query := r.db.WithContext(ctx).
+		Model(&domain.Job{}).
+		Select("id").
+		Where("status IN ? AND completed_at < ?", statuses, before)
+	if keepLast {
+		query = query.Where(
+			"EXISTS (SELECT 1 FROM jobs newer WHERE newer.service_id = jobs.service_id AND newer.status <> ? AND newer.created_at > jobs.created_at)",
+			domain.JobScheduled,
+		)
+	}
 	result := r.db.WithContext(ctx).Exec(
-		"DELETE FROM jobs WHERE (status = ? OR status = ?) AND completed_at < ?",
-		domain.JobCompleted, domain.JobFailed, cutoffTime,
+		"DELETE FROM jobs WHERE id IN (?)",
+		query.Order("completed_at").Limit(limit),
 	)
 	if result.Error != nil {
 		return 0, result.Error
@@ -108,14 +271,19 @@ func (r *GormJobRepository) DeleteOldCompletedJobs(ctx context.Context, olderTha
 	return int(result.RowsAffected), nil
 }
 
+// DeleteByService removes all the jobs of a service, whatever their status
+func (r *GormJobRepository) DeleteByService(ctx context.Context, serviceID properties.UUID) error {
+	return r.db.WithContext(ctx).Where("service_id = ?", serviceID).Delete(&domain.Job{}).Error
+}
+
 // GetLastJobForService retrieves the most recent job for a specific service
-// Ordered by created_at descending to get the latest job
+// Ordered by created_at descending to get the latest job, scheduled jobs are ignored
 func (r *GormJobRepository) GetLastJobForService(ctx context.Context, serviceID properties.UUID) (*domain.Job, error) {
 	var job domain.Job
 	err := r.db.WithContext(ctx).
 		Preload("Agent").
 		Preload("Service").
-		Where("service_id = ?", serviceID).
+		Where("service_id = ? AND status <> ?", serviceID, domain.JobScheduled).
 		Order("created_at DESC").
 		First(&job).Error
 
@@ -128,6 +296,127 @@ func (r *GormJobRepository) GetLastJobForService(ctx context.Context, serviceID
 	return &job, nil
 }
 
+// GetScheduledJobsForService retrieves the scheduled jobs of a specific service
+func (r *GormJobRepository) GetScheduledJobsForService(ctx context.Context, serviceID properties.UUID) ([]*domain.Job, error) {
+	var jobs []*domain.Job
+	err := r.db.WithContext(ctx).
+		Where("service_id = ? AND status = ?", serviceID, domain.JobScheduled).
+		Order("scheduled_at ASC").
+		Find(&jobs).Error
+	if err != nil {
+		return nil, err
+	}
+	return jobs, nil
+}
+
+// GetDueScheduledJobs retrieves scheduled jobs whose scheduled time has passed
+func (r *GormJobRepository) GetDueScheduledJobs(ctx context.Context) ([]*domain.Job, error) {
+	var jobs []*domain.Job
+	err := r.db.WithContext(ctx).
+		Where("status = ? AND scheduled_at <= ?", domain.JobScheduled, time.Now()).
+		Order("scheduled_at ASC").
+		Find(&jobs).Error
+	if err != nil {
+		return nil, err
+	}
+	return jobs, nil
+}
+
+// CountByStatus returns the number of jobs grouped by status
+func (r *GormJobRepository) CountByStatus(ctx context.Context) ([]domain.StatusCount, error) {
+	var counts []domain.StatusCount
+	result := r.db.WithContext(ctx).Model(&domain.Job{}).
+		Select("status, COUNT(*) AS count").
+		Group("status").
+		Order("status").
+		Scan(&counts)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return counts, nil
+}
+
+// jobDurationStatsRow is a row of the duration stats, the percentiles are in seconds
+type jobDurationStatsRow struct {
+	Action    string
+	AgentType string
+	Count     int64
+	P50       float64
+	P95       float64
+	P99       float64
+}
+
+// jobDurationSQL is the execution time of a job in seconds
+const jobDurationSQL = "EXTRACT(EPOCH FROM jobs.completed_at - jobs.claimed_at)"
+
+// DurationStats computes the execution time percentiles in Postgres with percentile_cont,
+// only the finished jobs that were claimed by an agent have a duration
+func (r *GormJobRepository) DurationStats(ctx context.Context, query domain.JobDurationStatsQuery) ([]domain.JobDurationStats, error) {
+	if err := query.Validate(); err != nil {
+		return nil, domain.InvalidInputError{Err: err}
+	}
+
+	selects := []string{"COUNT(*) AS count"}
+	for _, p := range []struct {
+		column   string
+		fraction float64
+	}{{"p50", 0.5}, {"p95", 0.95}, {"p99", 0.99}} {
+		selects = append(selects, fmt.Sprintf("COALESCE(percentile_cont(%g) WITHIN GROUP (ORDER BY %s), 0) AS %s", p.fraction, jobDurationSQL, p.column))
+	}
+	var groups []string
+	if query.GroupedBy(domain.JobStatsGroupAction) {
+		selects = append(selects, "jobs.action AS action")
+		groups = append(groups, "jobs.action")
+	}
+	if query.GroupedBy(domain.JobStatsGroupAgentType) {
+		// A subquery rather than a join keeps the columns of the scope filter unambiguous
+		agentType := "COALESCE((SELECT agent_types.name FROM agents JOIN agent_types ON agent_types.id = agents.agent_type_id WHERE agents.id = jobs.agent_id), '')"
+		selects = append(selects, agentType+" AS agent_type")
+		groups = append(groups, agentType)
+	}
+
+	q := r.db.WithContext(ctx).Model(&domain.Job{}).
+		Select(strings.Join(selects, ", ")).
+		Where("jobs.status IN ?", domain.JobDurationStatsStatuses).
+		Where("jobs.claimed_at IS NOT NULL AND jobs.completed_at >= ? AND jobs.completed_at < ?", query.From, query.To)
+	if query.Action != "" {
+		q = q.Where("jobs.action = ?", query.Action)
+	}
+	if query.Scope != nil {
+		q = providerConsumerAgentAuthzFilterApplier(query.Scope, q)
+	}
+	if len(groups) > 0 {
+		q = q.Group(strings.Join(groups, ", ")).Order(strings.Join(groups, ", "))
+	}
+
+	var rows []jobDurationStatsRow
+	if err := q.Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	stats := make([]domain.JobDurationStats, 0, len(rows))
+	for _, row := range rows {
+		// The ungrouped query returns a single row even without jobs
+		if row.Count == 0 {
+			continue
+		}
+		stats = append(stats, domain.JobDurationStats{
+			Action:    row.Action,
+			AgentType: row.AgentType,
+			Count:     row.Count,
+			P50:       secondsToDuration(row.P50),
+			P95:       secondsToDuration(row.P95),
+			P99:       secondsToDuration(row.P99),
+		})
+	}
+	return stats, nil
+}
+
+// secondsToDuration converts a duration in seconds computed by Postgres, rounded to the microsecond
+func secondsToDuration(seconds float64) time.Duration {
+	return time.Duration(seconds * float64(time.Second)).Round(time.Microsecond)
+}
+
 func (r *GormJobRepository) AuthScope(ctx context.Context, id properties.UUID) (authz.ObjectScope, error) {
 	return r.AuthScopeByFields(ctx, id, "null", "provider_id", "agent_id", "consumer_id")
 }
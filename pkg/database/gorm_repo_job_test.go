@@ -201,6 +201,25 @@ func TestJobRepository(t *testing.T) {
 			}
 		})
 
+		t.Run("success - list with diagnostics filter", func(t *testing.T) {
+			job := domain.NewJob(service, "update", nil, 1)
+			job.Status = domain.JobFailed
+			job.Diagnostics = &properties.JSON{"code": "E42", "logs": "connection timeout"}
+			require.NoError(t, repo.Create(context.Background(), job))
+
+			page := &domain.PageReq{
+				Page:     1,
+				PageSize: 10,
+				Filters:  map[string][]string{"diagnostics.code": {"E42"}},
+			}
+
+			result, err := repo.List(context.Background(), &auth.IdentityScope{}, page)
+			require.NoError(t, err)
+			require.Len(t, result.Items, 1)
+			assert.Equal(t, job.ID, result.Items[0].ID)
+			assert.Equal(t, job.Diagnostics, result.Items[0].Diagnostics)
+		})
+
 		t.Run("success - list with sorting by priority", func(t *testing.T) {
 			page := &domain.PageReq{
 				Page:     1,
@@ -296,6 +315,136 @@ func TestJobRepository(t *testing.T) {
 		assert.Equal(t, 0, len(jobs2), "Should return no jobs since both service groups have processing jobs")
 	})
 
+	t.Run("GetPendingJobsForAgent orders by priority", func(t *testing.T) {
+		// Jobs in different service groups, so both are returned
+		jobs := make([]*domain.Job, 2)
+		for i, action := range []string{"create", "delete"} {
+			group := &domain.ServiceGroup{Name: "Priority Group " + action, ConsumerID: consumer.ID}
+			require.NoError(t, serviceGroupRepo.Create(context.Background(), group))
+			testService := createTestService(t, serviceType.ID, group.ID, agent.ID, provider.ID, consumer.ID)
+			require.NoError(t, serviceRepo.Create(context.Background(), testService))
+			jobs[i] = domain.NewJob(testService, action, nil, domain.DefaultJobPriority(action))
+			require.NoError(t, repo.Create(context.Background(), jobs[i]))
+		}
+
+		pending, err := repo.GetPendingJobsForAgent(context.Background(), agent.ID, 100)
+		require.NoError(t, err)
+
+		position := map[properties.UUID]int{}
+		for i, job := range pending {
+			position[job.ID] = i
+		}
+		require.Contains(t, position, jobs[0].ID)
+		require.Contains(t, position, jobs[1].ID)
+		assert.Less(t, position[jobs[1].ID], position[jobs[0].ID], "delete should come before create")
+	})
+
+	t.Run("GetPendingJobsForAgent skips draining agents", func(t *testing.T) {
+		drainingAgent := &domain.Agent{
+			Name:        "Draining Agent",
+			Status:      domain.AgentConnected,
+			ProviderID:  provider.ID,
+			AgentTypeID: agentType.ID,
+			Draining:    true,
+		}
+		require.NoError(t, agentRepo.Create(context.Background(), drainingAgent))
+
+		group := &domain.ServiceGroup{Name: "Draining Group", ConsumerID: consumer.ID}
+		require.NoError(t, serviceGroupRepo.Create(context.Background(), group))
+		testService := createTestService(t, serviceType.ID, group.ID, drainingAgent.ID, provider.ID, consumer.ID)
+		require.NoError(t, serviceRepo.Create(context.Background(), testService))
+		require.NoError(t, repo.Create(context.Background(), domain.NewJob(testService, "start", nil, 1)))
+
+		pending, err := repo.GetPendingJobsForAgent(context.Background(), drainingAgent.ID, 100)
+		require.NoError(t, err)
+		assert.Empty(t, pending)
+
+		drainingAgent.Draining = false
+		require.NoError(t, agentRepo.Save(context.Background(), drainingAgent))
+
+		pending, err = repo.GetPendingJobsForAgent(context.Background(), drainingAgent.ID, 100)
+		require.NoError(t, err)
+		assert.Len(t, pending, 1)
+	})
+
+	t.Run("GetPendingJobsForAgent and ClaimIfCapacity respect the concurrency limit", func(t *testing.T) {
+		limit := 1
+		limitedAgent := &domain.Agent{
+			Name:              "Limited Agent",
+			Status:            domain.AgentConnected,
+			ProviderID:        provider.ID,
+			AgentTypeID:       agentType.ID,
+			MaxConcurrentJobs: &limit,
+		}
+		require.NoError(t, agentRepo.Create(context.Background(), limitedAgent))
+
+		// Jobs in different service groups, so without a limit both are returned
+		jobs := make([]*domain.Job, 2)
+		for i, name := range []string{"Limited Group A", "Limited Group B"} {
+			group := &domain.ServiceGroup{Name: name, ConsumerID: consumer.ID}
+			require.NoError(t, serviceGroupRepo.Create(context.Background(), group))
+			testService := createTestService(t, serviceType.ID, group.ID, limitedAgent.ID, provider.ID, consumer.ID)
+			require.NoError(t, serviceRepo.Create(context.Background(), testService))
+			jobs[i] = domain.NewJob(testService, "start", nil, 1)
+			require.NoError(t, repo.Create(context.Background(), jobs[i]))
+		}
+
+		pending, err := repo.GetPendingJobsForAgent(context.Background(), limitedAgent.ID, 100)
+		require.NoError(t, err)
+		require.Len(t, pending, 1)
+
+		// The first claim takes the only slot, the second one is rejected
+		first := *jobs[0]
+		require.NoError(t, first.Claim())
+		claimed, err := repo.ClaimIfCapacity(context.Background(), &first)
+		require.NoError(t, err)
+		assert.True(t, claimed)
+
+		second := *jobs[1]
+		require.NoError(t, second.Claim())
+		claimed, err = repo.ClaimIfCapacity(context.Background(), &second)
+		require.NoError(t, err)
+		assert.False(t, claimed)
+
+		pending, err = repo.GetPendingJobsForAgent(context.Background(), limitedAgent.ID, 100)
+		require.NoError(t, err)
+		assert.Empty(t, pending)
+
+		// Zero pauses the assignment even with no job in processing
+		limit = 0
+		require.NoError(t, agentRepo.Save(context.Background(), limitedAgent))
+		require.NoError(t, repo.Save(context.Background(), jobs[0])) // back to pending
+		pending, err = repo.GetPendingJobsForAgent(context.Background(), limitedAgent.ID, 100)
+		require.NoError(t, err)
+		assert.Empty(t, pending)
+	})
+
+	t.Run("SaveIfStatus", func(t *testing.T) {
+		testService := createTestService(t, serviceType.ID, serviceGroup.ID, agent.ID, provider.ID, consumer.ID)
+		require.NoError(t, serviceRepo.Create(context.Background(), testService))
+		job := domain.NewJob(testService, "start", nil, 1)
+		require.NoError(t, repo.Create(context.Background(), job))
+
+		// First claim wins
+		first := *job
+		require.NoError(t, first.Claim())
+		saved, err := repo.SaveIfStatus(context.Background(), &first, domain.JobPending)
+		require.NoError(t, err)
+		assert.True(t, saved)
+
+		// A concurrent claim of the same pending job is rejected
+		second := *job
+		require.NoError(t, second.Claim())
+		saved, err = repo.SaveIfStatus(context.Background(), &second, domain.JobPending)
+		require.NoError(t, err)
+		assert.False(t, saved)
+
+		stored, err := repo.Get(context.Background(), job.ID)
+		require.NoError(t, err)
+		assert.Equal(t, domain.JobProcessing, stored.Status)
+		assert.Equal(t, first.ClaimedAt.Unix(), stored.ClaimedAt.Unix())
+	})
+
 	t.Run("GetTimeOutJobs", func(t *testing.T) {
 		// Create a job in processing status with an old created_at time
 		now := time.Now()
@@ -319,7 +468,7 @@ func TestJobRepository(t *testing.T) {
 		require.NoError(t, err)
 
 		// Call GetTimeOutJobs with a 1 hour threshold
-		timedOutJobs, err := repo.GetTimeOutJobs(context.Background(), 1*time.Hour)
+		timedOutJobs, err := repo.GetTimeOutJobs(context.Background(), domain.JobTimeouts{Default: 1 * time.Hour})
 		require.NoError(t, err)
 		assert.Equal(t, 1, len(timedOutJobs)) // Only the old job should be returned
 		assert.Equal(t, oldJob.ID, timedOutJobs[0].ID)
@@ -331,57 +480,387 @@ func TestJobRepository(t *testing.T) {
 		assert.NotContains(t, timedOutJobs, newJob.ID)
 	})
 
-	t.Run("DeleteOldCompletedJobs", func(t *testing.T) {
-		// Create completed jobs with varying completion times
+	t.Run("GetTimeOutJobs with action timeouts", func(t *testing.T) {
+		now := time.Now()
+
+		// Older than the default timeout but within its action override
+		slowJob := domain.NewJob(service, "provision", nil, 1)
+		slowJob.Status = domain.JobProcessing
+		slowJob.BaseEntity = domain.BaseEntity{CreatedAt: now.Add(-3 * time.Hour)}
+		require.NoError(t, repo.Create(context.Background(), slowJob))
+
+		// Within the default timeout but older than its action override
+		fastJob := domain.NewJob(service, "halt", nil, 1)
+		fastJob.Status = domain.JobProcessing
+		fastJob.BaseEntity = domain.BaseEntity{CreatedAt: now.Add(-20 * time.Minute)}
+		require.NoError(t, repo.Create(context.Background(), fastJob))
+
+		timedOutJobs, err := repo.GetTimeOutJobs(context.Background(), domain.JobTimeouts{
+			Default: 1 * time.Hour,
+			Actions: map[string]time.Duration{"provision": 4 * time.Hour, "halt": 10 * time.Minute},
+		})
+		require.NoError(t, err)
+
+		ids := make([]properties.UUID, len(timedOutJobs))
+		for i, job := range timedOutJobs {
+			ids[i] = job.ID
+		}
+		assert.Contains(t, ids, fastJob.ID)
+		assert.NotContains(t, ids, slowJob.ID)
+	})
+
+	t.Run("GetTimeOutJobs measures requeued jobs from the requeue", func(t *testing.T) {
+		requeued := domain.NewJob(service, "reboot", nil, 1)
+		requeued.Status = domain.JobDeadLettered
+		requeued.BaseEntity = domain.BaseEntity{CreatedAt: time.Now().Add(-3 * time.Hour)}
+		require.NoError(t, repo.Create(context.Background(), requeued))
+		require.NoError(t, requeued.Requeue())
+		require.NoError(t, repo.Save(context.Background(), requeued))
+
+		timedOutJobs, err := repo.GetTimeOutJobs(context.Background(), domain.JobTimeouts{Default: 1 * time.Hour})
+		require.NoError(t, err)
+		for _, job := range timedOutJobs {
+			assert.NotEqual(t, requeued.ID, job.ID)
+		}
+	})
+
+	t.Run("GetTimeOutJobs measures retries deferred after a requeue from their promotion", func(t *testing.T) {
+		retried := domain.NewJob(service, "reboot", nil, 1)
+		requeuedAt := time.Now().Add(-3 * time.Hour)
+		scheduledAt := time.Now().Add(-10 * time.Minute)
+		retried.BaseEntity = domain.BaseEntity{CreatedAt: time.Now().Add(-4 * time.Hour)}
+		retried.RequeuedAt = &requeuedAt
+		retried.ScheduledAt = &scheduledAt
+		require.NoError(t, repo.Create(context.Background(), retried))
+
+		timedOutJobs, err := repo.GetTimeOutJobs(context.Background(), domain.JobTimeouts{Default: 1 * time.Hour})
+		require.NoError(t, err)
+		for _, job := range timedOutJobs {
+			assert.NotEqual(t, retried.ID, job.ID)
+		}
+	})
+
+	t.Run("GetTimeOutJobs measures multi-step jobs from their most recent step", func(t *testing.T) {
+		stepping := domain.NewJob(service, "create", nil, 1)
+		stepping.Status = domain.JobProcessing
+		stepping.BaseEntity = domain.BaseEntity{CreatedAt: time.Now().Add(-3 * time.Hour)}
+		require.NoError(t, repo.Create(context.Background(), stepping))
+		require.NoError(t, stepping.AddStep("create-disk", domain.JobStepCompleted, ""))
+		require.NoError(t, repo.Save(context.Background(), stepping))
+
+		saved, err := repo.Get(context.Background(), stepping.ID)
+		require.NoError(t, err)
+		require.Len(t, saved.Steps, 1)
+		assert.Equal(t, "create-disk", saved.Steps[0].Name)
+
+		timedOutJobs, err := repo.GetTimeOutJobs(context.Background(), domain.JobTimeouts{Default: 1 * time.Hour})
+		require.NoError(t, err)
+		for _, job := range timedOutJobs {
+			assert.NotEqual(t, stepping.ID, job.ID)
+		}
+	})
+
+	t.Run("GetTimeOutJobs with service operation timeouts", func(t *testing.T) {
 		now := time.Now()
+		newServiceWithTimeout := func(name string, timeout time.Duration) *domain.Service {
+			svc := &domain.Service{
+				Name:             name,
+				Status:           "Started",
+				AgentID:          agent.ID,
+				ServiceTypeID:    serviceType.ID,
+				GroupID:          serviceGroup.ID,
+				ConsumerID:       consumer.ID,
+				ProviderID:       provider.ID,
+				OperationTimeout: &timeout,
+			}
+			require.NoError(t, serviceRepo.Create(context.Background(), svc))
+			return svc
+		}
+		patient := newServiceWithTimeout("Patient Service", 6*time.Hour)
+		hasty := newServiceWithTimeout("Hasty Service", 5*time.Minute)
+
+		// Older than the action timeout but within the operation timeout of its service
+		patientJob := domain.NewJob(patient, "provision", nil, 1)
+		patientJob.Status = domain.JobProcessing
+		patientJob.BaseEntity = domain.BaseEntity{CreatedAt: now.Add(-5 * time.Hour)}
+		require.NoError(t, repo.Create(context.Background(), patientJob))
+
+		// Within the default timeout but older than the operation timeout of its service
+		hastyJob := domain.NewJob(hasty, "create", nil, 1)
+		hastyJob.Status = domain.JobPending
+		hastyJob.BaseEntity = domain.BaseEntity{CreatedAt: now.Add(-10 * time.Minute)}
+		require.NoError(t, repo.Create(context.Background(), hastyJob))
+
+		timedOutJobs, err := repo.GetTimeOutJobs(context.Background(), domain.JobTimeouts{
+			Default: 1 * time.Hour,
+			Actions: map[string]time.Duration{"provision": 4 * time.Hour},
+		})
+		require.NoError(t, err)
 
-		// Create jobs with completion times at different intervals
-		oldCompletedJob := domain.NewJob(service, "stop", nil, 1)
-		oldCompletedJob.Status = domain.JobCompleted
-		oldCompletedTime := now.Add(-48 * time.Hour) // 2 days ago
-		oldCompletedJob.CompletedAt = &oldCompletedTime
-		require.NoError(t, repo.Create(context.Background(), oldCompletedJob))
-
-		oldFailedJob := domain.NewJob(service, "start", nil, 1)
-		oldFailedJob.Status = domain.JobFailed
-		oldFailedTime := now.Add(-36 * time.Hour) // 1.5 days ago
-		oldFailedJob.CompletedAt = &oldFailedTime
-		require.NoError(t, repo.Create(context.Background(), oldFailedJob))
-
-		recentCompletedJob := domain.NewJob(service, "update", nil, 1)
-		recentCompletedJob.Status = domain.JobCompleted
-		recentCompletedTime := now.Add(-12 * time.Hour) // 12 hours ago
-		recentCompletedJob.CompletedAt = &recentCompletedTime
-		require.NoError(t, repo.Create(context.Background(), recentCompletedJob))
+		ids := make([]properties.UUID, len(timedOutJobs))
+		for i, job := range timedOutJobs {
+			ids[i] = job.ID
+			assert.NotEmpty(t, job.Action, "the job columns must not be shadowed by the service ones")
+		}
+		assert.Contains(t, ids, hastyJob.ID)
+		assert.NotContains(t, ids, patientJob.ID)
+	})
 
+	t.Run("FailIfStatus releases the lease", func(t *testing.T) {
+		job := domain.NewJob(service, "resize", nil, 1)
+		require.NoError(t, job.Claim())
+		require.NoError(t, job.GrantLease(-time.Minute))
+		require.NoError(t, repo.Create(context.Background(), job))
+
+		updated, err := repo.FailIfStatus(context.Background(), []properties.UUID{job.ID}, domain.JobProcessing, domain.JobFailed, "timed out", time.Now())
+		require.NoError(t, err)
+		assert.Equal(t, []properties.UUID{job.ID}, updated)
+
+		failed, err := repo.Get(context.Background(), job.ID)
+		require.NoError(t, err)
+		assert.Equal(t, domain.JobFailed, failed.Status)
+		assert.Nil(t, failed.LeaseID)
+		assert.Nil(t, failed.LeaseExpiresAt)
+
+		expired, err := repo.GetExpiredLeaseJobs(context.Background(), time.Now())
+		require.NoError(t, err)
+		for _, j := range expired {
+			assert.NotEqual(t, job.ID, j.ID, "a failed job must not be reclaimed")
+		}
+	})
+
+	t.Run("Leases", func(t *testing.T) {
+		job := domain.NewJob(service, "resize", nil, 1)
+		require.NoError(t, job.Claim())
+		require.NoError(t, job.GrantLease(-time.Minute))
+		require.NoError(t, repo.Create(context.Background(), job))
+		leaseID := *job.LeaseID
+
+		expired, err := repo.GetExpiredLeaseJobs(context.Background(), time.Now())
+		require.NoError(t, err)
+		ids := make([]properties.UUID, len(expired))
+		for i, j := range expired {
+			ids[i] = j.ID
+		}
+		assert.Contains(t, ids, job.ID)
+
+		// A renewal moves the expiry past the reclaim cutoff
+		require.NoError(t, job.RenewLease(leaseID, time.Minute))
+		saved, err := repo.SaveIfLeaseHeld(context.Background(), job, leaseID)
+		require.NoError(t, err)
+		assert.True(t, saved)
+		require.NoError(t, job.ReclaimLease())
+		saved, err = repo.SaveIfLeaseExpired(context.Background(), job, leaseID, time.Now())
+		require.NoError(t, err)
+		assert.False(t, saved, "a renewed lease must not be reclaimed")
+
+		// Once reclaimed, reports carrying the previous lease are not saved
+		stored, err := repo.Get(context.Background(), job.ID)
+		require.NoError(t, err)
+		require.NoError(t, stored.ReclaimLease())
+		saved, err = repo.SaveIfLeaseExpired(context.Background(), stored, leaseID, time.Now().Add(2*time.Minute))
+		require.NoError(t, err)
+		assert.True(t, saved)
+		stored.Status = domain.JobProcessing
+		require.NoError(t, stored.Complete())
+		saved, err = repo.SaveIfLeaseHeld(context.Background(), stored, leaseID)
+		require.NoError(t, err)
+		assert.False(t, saved)
+
+		reclaimed, err := repo.Get(context.Background(), job.ID)
+		require.NoError(t, err)
+		assert.Equal(t, domain.JobPending, reclaimed.Status)
+		assert.Equal(t, 2, reclaimed.Attempt)
+		assert.Nil(t, reclaimed.LeaseID)
+	})
+
+	t.Run("CountByStatus", func(t *testing.T) {
+		before, err := repo.CountByStatus(context.Background())
+		require.NoError(t, err)
+
+		failedJob := domain.NewJob(service, "update", nil, 1)
+		failedJob.Status = domain.JobFailed
+		require.NoError(t, repo.Create(context.Background(), failedJob))
+
+		after, err := repo.CountByStatus(context.Background())
+		require.NoError(t, err)
+
+		count := func(counts []domain.StatusCount, status domain.JobStatus) int64 {
+			for _, c := range counts {
+				if c.Status == string(status) {
+					return c.Count
+				}
+			}
+			return 0
+		}
+		assert.Equal(t, count(before, domain.JobFailed)+1, count(after, domain.JobFailed))
+	})
+
+	t.Run("DurationStats", func(t *testing.T) {
+		// A range in the past keeps the jobs of the other tests out
+		to := time.Now().Add(-30 * 24 * time.Hour)
+		from := to.Add(-time.Hour)
+		newFinishedJob := func(action string, status domain.JobStatus, duration time.Duration) {
+			job := domain.NewJob(service, action, nil, 1)
+			job.Status = status
+			completedAt := to.Add(-time.Minute)
+			claimedAt := completedAt.Add(-duration)
+			job.ClaimedAt = &claimedAt
+			job.CompletedAt = &completedAt
+			require.NoError(t, repo.Create(context.Background(), job))
+		}
+		for i := 1; i <= 4; i++ {
+			newFinishedJob("stats-create", domain.JobCompleted, time.Duration(i)*time.Minute)
+		}
+		newFinishedJob("stats-delete", domain.JobFailed, 10*time.Second)
+		newFinishedJob("stats-delete", domain.JobCancelled, time.Hour)
+
+		// In flight, never claimed or finished out of range jobs are excluded
+		inFlight := domain.NewJob(service, "stats-create", nil, 1)
+		require.NoError(t, inFlight.Claim())
+		require.NoError(t, repo.Create(context.Background(), inFlight))
+		neverClaimed := domain.NewJob(service, "stats-create", nil, 1)
+		neverClaimed.Status = domain.JobFailed
+		failedAt := to.Add(-time.Minute)
+		neverClaimed.CompletedAt = &failedAt
+		require.NoError(t, repo.Create(context.Background(), neverClaimed))
+
+		stats, err := repo.DurationStats(context.Background(), domain.JobDurationStatsQuery{
+			From:    from,
+			To:      to,
+			GroupBy: []domain.JobStatsGroup{domain.JobStatsGroupAction, domain.JobStatsGroupAgentType},
+		})
+		require.NoError(t, err)
+		require.Len(t, stats, 2)
+		assert.Equal(t, "stats-create", stats[0].Action)
+		assert.Equal(t, agentType.Name, stats[0].AgentType)
+		assert.Equal(t, int64(4), stats[0].Count)
+		assert.Equal(t, 150*time.Second, stats[0].P50, "percentile_cont interpolates between 2m and 3m")
+		assert.Equal(t, int64(1), stats[1].Count, "cancelled jobs are excluded")
+		assert.Equal(t, 10*time.Second, stats[1].P99)
+
+		stats, err = repo.DurationStats(context.Background(), domain.JobDurationStatsQuery{Action: "stats-delete", From: from, To: to})
+		require.NoError(t, err)
+		require.Len(t, stats, 1)
+		assert.Empty(t, stats[0].Action)
+		assert.Equal(t, int64(1), stats[0].Count)
+
+		stats, err = repo.DurationStats(context.Background(), domain.JobDurationStatsQuery{From: to, To: to.Add(time.Hour)})
+		require.NoError(t, err)
+		assert.Empty(t, stats, "no finished jobs in range")
+
+		other := properties.NewUUID()
+		stats, err = repo.DurationStats(context.Background(), domain.JobDurationStatsQuery{From: from, To: to, Scope: &auth.IdentityScope{ParticipantID: &other}})
+		require.NoError(t, err)
+		assert.Empty(t, stats, "the jobs are scoped to the participant")
+	})
+
+	t.Run("DeleteFinishedBefore", func(t *testing.T) {
+		now := time.Now()
+		newFinishedJob := func(status domain.JobStatus, age time.Duration) *domain.Job {
+			job := domain.NewJob(service, "stop", nil, 1)
+			job.Status = status
+			completedAt := now.Add(-age)
+			job.CompletedAt = &completedAt
+			require.NoError(t, repo.Create(context.Background(), job))
+			return job
+		}
+
+		statuses := []domain.JobStatus{domain.JobCompleted, domain.JobCancelled}
+		before := now.Add(-24 * time.Hour)
+
+		// Clear the jobs finished by the previous tests
+		_, err := repo.DeleteFinishedBefore(context.Background(), statuses, before, 1000, false)
+		require.NoError(t, err)
+
+		oldestCompleted := newFinishedJob(domain.JobCompleted, 72*time.Hour)
+		oldCancelled := newFinishedJob(domain.JobCancelled, 60*time.Hour)
+		oldCompleted := newFinishedJob(domain.JobCompleted, 48*time.Hour)
+		oldFailed := newFinishedJob(domain.JobFailed, 48*time.Hour)
+		recentCompleted := newFinishedJob(domain.JobCompleted, 12*time.Hour)
 		pendingJob := domain.NewJob(service, "update", nil, 1)
-		pendingJob.Status = domain.JobPending
 		require.NoError(t, repo.Create(context.Background(), pendingJob))
 
-		// Call DeleteOldCompletedJobs with a 24-hour threshold
-		count, err := repo.DeleteOldCompletedJobs(context.Background(), 24*time.Hour)
+		// The first batch deletes the oldest jobs only
+		count, err := repo.DeleteFinishedBefore(context.Background(), statuses, before, 2, false)
+		require.NoError(t, err)
+		assert.Equal(t, 2, count)
+		for _, job := range []*domain.Job{oldestCompleted, oldCancelled} {
+			_, err = repo.Get(context.Background(), job.ID)
+			assert.IsType(t, domain.NotFoundError{}, err)
+		}
+		_, err = repo.Get(context.Background(), oldCompleted.ID)
+		assert.NoError(t, err, "The job beyond the batch is kept for the next one")
 
-		// Assert
+		count, err = repo.DeleteFinishedBefore(context.Background(), statuses, before, 2, false)
 		require.NoError(t, err)
-		assert.Equal(t, 2, count, "Should delete exactly two old jobs")
+		assert.Equal(t, 1, count)
+		_, err = repo.Get(context.Background(), oldCompleted.ID)
+		assert.IsType(t, domain.NotFoundError{}, err)
 
-		// Verify that old jobs were deleted
-		_, err = repo.Get(context.Background(), oldCompletedJob.ID)
-		assert.Error(t, err, "Old completed job should be deleted")
+		count, err = repo.DeleteFinishedBefore(context.Background(), statuses, before, 2, false)
+		require.NoError(t, err)
+		assert.Equal(t, 0, count)
+
+		// The other statuses, the recent and the active jobs are kept
+		for _, job := range []*domain.Job{oldFailed, recentCompleted, pendingJob} {
+			_, err = repo.Get(context.Background(), job.ID)
+			assert.NoError(t, err)
+		}
+	})
+
+	t.Run("DeleteFinishedBefore keeps the last job of a service", func(t *testing.T) {
+		before := time.Now().Add(-24 * time.Hour)
+		newDeadLetteredJob := func(svc *domain.Service, createdAt time.Time) *domain.Job {
+			job := domain.NewJob(svc, "stop", nil, 5)
+			job.Status = domain.JobDeadLettered
+			job.CreatedAt = createdAt
+			completedAt := createdAt.Add(time.Minute)
+			job.CompletedAt = &completedAt
+			require.NoError(t, repo.Create(context.Background(), job))
+			return job
+		}
+
+		parked := createTestService(t, serviceType.ID, serviceGroup.ID, agent.ID, provider.ID, consumer.ID)
+		require.NoError(t, serviceRepo.Create(context.Background(), parked))
+		lastJob := newDeadLetteredJob(parked, time.Now().Add(-72*time.Hour))
+
+		movedOn := createTestService(t, serviceType.ID, serviceGroup.ID, agent.ID, provider.ID, consumer.ID)
+		require.NoError(t, serviceRepo.Create(context.Background(), movedOn))
+		supersededJob := newDeadLetteredJob(movedOn, time.Now().Add(-72*time.Hour))
+		newDeadLetteredJob(movedOn, time.Now().Add(-48*time.Hour))
+
+		_, err := repo.DeleteFinishedBefore(context.Background(), []domain.JobStatus{domain.JobDeadLettered}, before, 1000, true)
+		require.NoError(t, err)
+
+		_, err = repo.Get(context.Background(), lastJob.ID)
+		assert.NoError(t, err, "the last job still refuses the dead-lettered action")
+		_, err = repo.Get(context.Background(), supersededJob.ID)
 		assert.IsType(t, domain.NotFoundError{}, err)
 
-		_, err = repo.Get(context.Background(), oldFailedJob.ID)
-		assert.Error(t, err, "Old failed job should be deleted")
+		count, err := repo.DeleteFinishedBefore(context.Background(), []domain.JobStatus{domain.JobDeadLettered}, before, 1000, false)
+		require.NoError(t, err)
+		assert.GreaterOrEqual(t, count, 2)
+		_, err = repo.Get(context.Background(), lastJob.ID)
 		assert.IsType(t, domain.NotFoundError{}, err)
+	})
 
-		// Verify that recent and pending jobs still exist
-		stillExists, err := repo.Get(context.Background(), recentCompletedJob.ID)
-		assert.NoError(t, err, "Recent completed job should still exist")
-		assert.Equal(t, recentCompletedJob.ID, stillExists.ID)
+	t.Run("DeleteByService", func(t *testing.T) {
+		purged := createTestService(t, serviceType.ID, serviceGroup.ID, agent.ID, provider.ID, consumer.ID)
+		require.NoError(t, serviceRepo.Create(context.Background(), purged))
+		kept := createTestService(t, serviceType.ID, serviceGroup.ID, agent.ID, provider.ID, consumer.ID)
+		require.NoError(t, serviceRepo.Create(context.Background(), kept))
+		purgedJob := domain.NewJob(purged, "delete", nil, 1)
+		require.NoError(t, repo.Create(context.Background(), purgedJob))
+		keptJob := domain.NewJob(kept, "delete", nil, 1)
+		require.NoError(t, repo.Create(context.Background(), keptJob))
 
-		stillExists, err = repo.Get(context.Background(), pendingJob.ID)
-		assert.NoError(t, err, "Pending job should still exist")
-		assert.Equal(t, pendingJob.ID, stillExists.ID)
+		require.NoError(t, repo.DeleteByService(context.Background(), purged.ID))
+
+		_, err := repo.Get(context.Background(), purgedJob.ID)
+		assert.IsType(t, domain.NotFoundError{}, err)
+		_, err = repo.Get(context.Background(), keptJob.ID)
+		assert.NoError(t, err, "Jobs of other services should be kept")
 	})
 
 	t.Run("GetLastJobForService", func(t *testing.T) {
@@ -462,6 +941,51 @@ func TestJobRepository(t *testing.T) {
 		})
 	})
 
+	t.Run("ScheduledJobs", func(t *testing.T) {
+		testService := createTestService(t, serviceType.ID, serviceGroup.ID, agent.ID, provider.ID, consumer.ID)
+		require.NoError(t, serviceRepo.Create(context.Background(), testService))
+
+		pendingJob := domain.NewJob(testService, "create", nil, 1)
+		require.NoError(t, repo.Create(context.Background(), pendingJob))
+
+		time.Sleep(10 * time.Millisecond)
+
+		dueAt := time.Now().Add(-time.Minute)
+		dueJob := domain.NewJob(testService, "stop", nil, 1)
+		dueJob.Status = domain.JobScheduled
+		dueJob.ScheduledAt = &dueAt
+		require.NoError(t, repo.Create(context.Background(), dueJob))
+
+		futureJob := domain.NewJob(testService, "start", nil, 1)
+		require.NoError(t, futureJob.Schedule(time.Now().Add(time.Hour)))
+		require.NoError(t, repo.Create(context.Background(), futureJob))
+
+		t.Run("GetLastJobForService ignores scheduled jobs", func(t *testing.T) {
+			lastJob, err := repo.GetLastJobForService(context.Background(), testService.ID)
+			require.NoError(t, err)
+			assert.Equal(t, pendingJob.ID, lastJob.ID)
+		})
+
+		t.Run("GetScheduledJobsForService", func(t *testing.T) {
+			jobs, err := repo.GetScheduledJobsForService(context.Background(), testService.ID)
+			require.NoError(t, err)
+			require.Len(t, jobs, 2)
+			assert.Equal(t, dueJob.ID, jobs[0].ID)
+			assert.Equal(t, futureJob.ID, jobs[1].ID)
+		})
+
+		t.Run("GetDueScheduledJobs", func(t *testing.T) {
+			jobs, err := repo.GetDueScheduledJobs(context.Background())
+			require.NoError(t, err)
+			ids := make([]properties.UUID, len(jobs))
+			for i, job := range jobs {
+				ids[i] = job.ID
+			}
+			assert.Contains(t, ids, dueJob.ID)
+			assert.NotContains(t, ids, futureJob.ID)
+		})
+	})
+
 	t.Run("AuthScope", func(t *testing.T) {
 		t.Run("success - returns correct auth scope", func(t *testing.T) {
 			ctx := context.Background()
@@ -54,6 +54,31 @@ func (r *GormMetricEntryRepository) CountByMetricType(ctx context.Context, typeI
 	return count, result.Error
 }
 
+// LastEntryTimes returns the time of the last entry of each of the services, served by the aggregate index
+func (r *GormMetricEntryRepository) LastEntryTimes(ctx context.Context, serviceIDs []properties.UUID) (map[properties.UUID]time.Time, error) {
+	if len(serviceIDs) == 0 {
+		return map[properties.UUID]time.Time{}, nil
+	}
+	var rows []struct {
+		ServiceID properties.UUID
+		LastAt    time.Time
+	}
+	result := r.db.WithContext(ctx).
+		Model(&domain.MetricEntry{}).
+		Select("service_id, MAX(created_at) AS last_at").
+		Where("service_id IN ?", serviceIDs).
+		Group("service_id").
+		Scan(&rows)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	times := make(map[properties.UUID]time.Time, len(rows))
+	for _, row := range rows {
+		times[row.ServiceID] = row.LastAt
+	}
+	return times, nil
+}
+
 // aggregateSQLExpr maps an AggregateType to its SQL expression.
 func aggregateSQLExpr(aggType domain.AggregateType) string {
 	switch aggType {
@@ -85,6 +110,12 @@ func (r *GormMetricEntryRepository) Aggregate(ctx context.Context, query domain.
 	if err := query.Bucket.Validate(); err != nil {
 		return domain.AggregationResult{}, err
 	}
+	if query.Aggregate.IsHistogram() {
+		return r.aggregateHistograms(ctx, query)
+	}
+	if query.Aggregate == domain.AggregateRate {
+		return r.aggregateRate(ctx, query)
+	}
 
 	selectStr := fmt.Sprintf("DATE_TRUNC('%s', created_at) as bucket_time, %s as agg_value", query.Bucket, aggregateSQLExpr(query.Aggregate))
 
@@ -116,11 +147,95 @@ func (r *GormMetricEntryRepository) Aggregate(ctx context.Context, query domain.
 	}, nil
 }
 
+type histogramBucketRow struct {
+	BucketTime time.Time
+	Histogram  *domain.Histogram
+}
+
+// aggregateHistograms merges the histograms of each bucket and reports either the merged histogram or its percentile
+func (r *GormMetricEntryRepository) aggregateHistograms(ctx context.Context, query domain.AggregateQuery) (domain.AggregationResult, error) {
+	selectStr := fmt.Sprintf("DATE_TRUNC('%s', created_at) as bucket_time, histogram", query.Bucket)
+
+	baseQuery := r.db.WithContext(ctx).
+		Model(&domain.MetricEntry{}).Select(selectStr).
+		Where("service_id = ? AND type_id = ? AND resource_id = ? AND created_at >= ? AND created_at <= ? AND histogram IS NOT NULL", query.ServiceID, query.TypeID, query.ResourceID, query.Start, query.End)
+
+	if query.Scope != nil {
+		baseQuery = providerConsumerAgentAuthzFilterApplier(query.Scope, baseQuery)
+	}
+
+	var rows []histogramBucketRow
+	if err := baseQuery.Order("bucket_time").Scan(&rows).Error; err != nil {
+		return domain.AggregationResult{}, err
+	}
+
+	data := []domain.AggregateData{}
+	for start := 0; start < len(rows); {
+		end := start
+		var histograms []*domain.Histogram
+		for ; end < len(rows) && rows[end].BucketTime.Equal(rows[start].BucketTime); end++ {
+			histograms = append(histograms, rows[end].Histogram)
+		}
+		merged, err := domain.MergeHistograms(histograms...)
+		if err != nil {
+			return domain.AggregationResult{}, domain.NewInvalidInputErrorf("bucket %s: %v", rows[start].BucketTime.Format(time.RFC3339), err)
+		}
+		var value any = merged
+		if q, ok := query.Aggregate.Quantile(); ok {
+			value = merged.Quantile(q)
+		}
+		data = append(data, domain.AggregateData{rows[start].BucketTime.Format(time.RFC3339), value})
+		start = end
+	}
+
+	return domain.AggregationResult{
+		Data:      data,
+		Aggregate: query.Aggregate,
+		Bucket:    query.Bucket,
+		Start:     query.Start,
+		End:       query.End,
+	}, nil
+}
+
+// aggregateRate computes the per-second rate of the counter series in each bucket
+// The last entry before the start is the baseline of the first interval, so the first bucket has a rate as well
+func (r *GormMetricEntryRepository) aggregateRate(ctx context.Context, query domain.AggregateQuery) (domain.AggregationResult, error) {
+	seriesQuery := func() *gorm.DB {
+		q := r.db.WithContext(ctx).
+			Model(&domain.MetricEntry{}).Select("created_at as time, value").
+			Where("service_id = ? AND type_id = ? AND resource_id = ?", query.ServiceID, query.TypeID, query.ResourceID)
+		if query.Scope != nil {
+			q = providerConsumerAgentAuthzFilterApplier(query.Scope, q)
+		}
+		return q
+	}
+
+	var baseline []domain.CounterSample
+	if err := seriesQuery().Where("created_at < ?", query.Start).Order("created_at DESC").Limit(1).Scan(&baseline).Error; err != nil {
+		return domain.AggregationResult{}, err
+	}
+	var samples []domain.CounterSample
+	if err := seriesQuery().Where("created_at >= ? AND created_at <= ?", query.Start, query.End).Order("created_at").Scan(&samples).Error; err != nil {
+		return domain.AggregationResult{}, err
+	}
+
+	return domain.AggregationResult{
+		Data:      domain.CounterRates(append(baseline, samples...), query.Bucket, query.Start, query.End),
+		Aggregate: query.Aggregate,
+		Bucket:    query.Bucket,
+		Start:     query.Start,
+		End:       query.End,
+	}, nil
+}
+
 // AggregateTotal performs a simple scalar aggregation returning a single float64
 func (r *GormMetricEntryRepository) AggregateTotal(ctx context.Context, aggregateType domain.AggregateType, serviceID properties.UUID, typeID properties.UUID, start time.Time, end time.Time) (float64, error) {
 	if err := aggregateType.Validate(); err != nil {
 		return 0, err
 	}
+	if aggregateType.IsHistogram() || aggregateType == domain.AggregateRate {
+		return 0, fmt.Errorf("aggregate %s is not a scalar aggregation", aggregateType)
+	}
 
 	var result float64
 	err := r.db.WithContext(ctx).
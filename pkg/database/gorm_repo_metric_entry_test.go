@@ -440,6 +440,69 @@ func TestMetricEntryRepository(t *testing.T) {
 			assert.Equal(t, domain.AggregateMax, result.Aggregate)
 			assert.Equal(t, domain.AggregateBucketHour, result.Bucket)
 		})
+
+		t.Run("success - merges histograms and computes percentiles", func(t *testing.T) {
+			histogramType := createTestMetricTypeForEntity(t, domain.MetricEntityTypeService)
+			histogramType.Kind = domain.MetricEntryKindHistogram
+			require.NoError(t, metricTypeRepo.Create(ctx, histogramType))
+
+			for _, counts := range [][]int64{{5, 3, 0}, {5, 6, 1}} {
+				entry := createTestMetricEntry(t, agent.ID, service.ID, histogramType.ID, provider.ID, consumer.ID)
+				entry.ResourceID = "histogram-resource"
+				require.NoError(t, entry.SetValue(histogramType, 0, &domain.Histogram{Bounds: []float64{0.1, 0.5}, Counts: counts}))
+				require.NoError(t, repo.Create(ctx, entry))
+			}
+
+			query := domain.AggregateQuery{
+				ServiceID:  service.ID,
+				ResourceID: "histogram-resource",
+				TypeID:     histogramType.ID,
+				Aggregate:  domain.AggregateHistogram,
+				Bucket:     domain.AggregateBucketDay,
+				Start:      time.Now().Add(-24 * time.Hour),
+				End:        time.Now().Add(time.Hour),
+			}
+			result, err := repo.Aggregate(ctx, query)
+			require.NoError(t, err)
+			require.Len(t, result.Data, 1)
+			assert.Equal(t, []int64{10, 9, 1}, result.Data[0][1].(*domain.Histogram).Counts)
+
+			query.Aggregate = domain.AggregateP50
+			result, err = repo.Aggregate(ctx, query)
+			require.NoError(t, err)
+			require.Len(t, result.Data, 1)
+			assert.InDelta(t, 0.1, result.Data[0][1], 1e-9)
+		})
+
+		t.Run("success - computes the rate of a counter with the baseline before the start", func(t *testing.T) {
+			counterType := createTestMetricTypeForEntity(t, domain.MetricEntityTypeService)
+			counterType.Counter = true
+			require.NoError(t, metricTypeRepo.Create(ctx, counterType))
+
+			hour := time.Now().UTC().Truncate(time.Hour).Add(-2 * time.Hour)
+			for i, value := range []float64{0, 1800, 3600, 100, 1900} {
+				entry := createTestMetricEntry(t, agent.ID, service.ID, counterType.ID, provider.ID, consumer.ID)
+				entry.ResourceID = "counter-resource"
+				entry.Value = value
+				entry.CreatedAt = hour.Add(time.Duration(i) * 30 * time.Minute)
+				require.NoError(t, repo.Create(ctx, entry))
+			}
+
+			result, err := repo.Aggregate(ctx, domain.AggregateQuery{
+				ServiceID:  service.ID,
+				ResourceID: "counter-resource",
+				TypeID:     counterType.ID,
+				Aggregate:  domain.AggregateRate,
+				Bucket:     domain.AggregateBucketHour,
+				Start:      hour.Add(15 * time.Minute),
+				End:        hour.Add(2 * time.Hour),
+			})
+			require.NoError(t, err)
+			require.Len(t, result.Data, 2)
+			assert.InDelta(t, 1.0, result.Data[0][1], 1e-9)
+			// The counter was reset before the value of 100
+			assert.InDelta(t, (100+1800)/3600.0, result.Data[1][1], 1e-9)
+		})
 	})
 
 	t.Run("ListResourceIDs", func(t *testing.T) {
@@ -619,6 +682,36 @@ func TestMetricEntryRepository(t *testing.T) {
 		})
 	})
 
+	t.Run("LastEntryTimes", func(t *testing.T) {
+		testDB.DB.Exec("DELETE FROM metric_entries")
+
+		last := time.Now().Add(-10 * time.Minute).Truncate(time.Microsecond)
+		for _, createdAt := range []time.Time{last.Add(-time.Hour), last} {
+			entry := &domain.MetricEntry{
+				CreatedAt:  createdAt,
+				AgentID:    agent.ID,
+				ServiceID:  service.ID,
+				ResourceID: "last-entry-test",
+				ProviderID: provider.ID,
+				ConsumerID: consumer.ID,
+				Value:      1,
+				TypeID:     metricTypeService.ID,
+			}
+			require.NoError(t, repo.Create(ctx, entry))
+		}
+
+		unmetered := properties.NewUUID()
+		times, err := repo.LastEntryTimes(ctx, []properties.UUID{service.ID, unmetered})
+		require.NoError(t, err)
+		require.Contains(t, times, service.ID)
+		assert.True(t, last.Equal(times[service.ID]))
+		assert.NotContains(t, times, unmetered)
+
+		times, err = repo.LastEntryTimes(ctx, nil)
+		require.NoError(t, err)
+		assert.Empty(t, times)
+	})
+
 	t.Run("AuthScope", func(t *testing.T) {
 		t.Run("success - returns correct auth scope", func(t *testing.T) {
 			// Create a metric entry with all scope fields set
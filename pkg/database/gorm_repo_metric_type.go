@@ -51,6 +51,16 @@ func (r *GormMetricTypeRepository) FindByName(ctx context.Context, name string)
 	return &entity, nil
 }
 
+// FindAll retrieves all the metric types ordered by name
+func (r *GormMetricTypeRepository) FindAll(ctx context.Context) ([]*domain.MetricType, error) {
+	var entities []*domain.MetricType
+	result := r.db.WithContext(ctx).Order("name").Find(&entities)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return entities, nil
+}
+
 func (r *GormMetricTypeRepository) AuthScope(ctx context.Context, id properties.UUID) (authz.ObjectScope, error) {
 	// Metric types don't have scoping IDs as they are global resources
 	return &authz.AllwaysMatchObjectScope{}, nil
@@ -7,6 +7,7 @@ import (
 
 	"github.com/fulcrumproject/core/pkg/auth"
 	"github.com/fulcrumproject/core/pkg/authz"
+	"github.com/fulcrumproject/core/pkg/helpers"
 	"github.com/fulcrumproject/core/pkg/properties"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -66,6 +67,33 @@ func TestMetricTypeRepository(t *testing.T) {
 		assert.Equal(t, metricType.EntityType, found.EntityType)
 	})
 
+	t.Run("FindAll", func(t *testing.T) {
+		metricType := &domain.MetricType{
+			Name:       "Network Latency",
+			EntityType: domain.MetricEntityTypeService,
+			Unit:       "ms",
+			Min:        helpers.FloatPtr(0),
+		}
+		err := repo.Create(context.Background(), metricType)
+		require.NoError(t, err)
+
+		all, err := repo.FindAll(context.Background())
+		require.NoError(t, err)
+		var found *domain.MetricType
+		for i, mt := range all {
+			if i > 0 {
+				assert.LessOrEqual(t, all[i-1].Name, mt.Name)
+			}
+			if mt.ID == metricType.ID {
+				found = mt
+			}
+		}
+		require.NotNil(t, found)
+		assert.Equal(t, "ms", found.Unit)
+		assert.Equal(t, helpers.FloatPtr(0), found.Min)
+		assert.Nil(t, found.Max)
+	})
+
 	t.Run("FindByName_NotFound", func(t *testing.T) {
 		// Try to find a non-existent metric type
 		found, err := repo.FindByName(context.Background(), "NonExistentMetricType")
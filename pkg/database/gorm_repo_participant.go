@@ -2,10 +2,12 @@ package database
 
 import (
 	"context"
+	"errors"
 
 	"github.com/fulcrumproject/core/pkg/authz"
 	"github.com/fulcrumproject/core/pkg/properties"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 
 	"github.com/fulcrumproject/core/pkg/domain"
 )
@@ -42,3 +44,19 @@ func NewParticipantRepository(db *gorm.DB) *GormParticipantRepository {
 func (r *GormParticipantRepository) AuthScope(ctx context.Context, id properties.UUID) (authz.ObjectScope, error) {
 	return r.AuthScopeByFields(ctx, id, "id as participant_id", "null", "null", "null")
 }
+
+// GetForUpdate retrieves the participant with its row locked until the end of the transaction,
+// e.g. to serialize the registrations of the agents of a provider checking its quota
+func (r *GormParticipantRepository) GetForUpdate(ctx context.Context, id properties.UUID) (*domain.Participant, error) {
+	var participant domain.Participant
+	err := r.db.WithContext(ctx).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Take(&participant, "id = ?", id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.NotFoundError{Err: err}
+		}
+		return nil, err
+	}
+	return &participant, nil
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/fulcrumproject/core/pkg/properties"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
 )
 
 func TestParticipantRepository(t *testing.T) {
@@ -61,6 +62,34 @@ func TestParticipantRepository(t *testing.T) {
 		})
 	})
 
+	t.Run("GetForUpdate", func(t *testing.T) {
+		t.Run("success", func(t *testing.T) {
+			ctx := context.Background()
+
+			limit := 3
+			participant := createTestParticipant(t, domain.ParticipantEnabled)
+			participant.MaxAgents = &limit
+			require.NoError(t, repo.Create(ctx, participant))
+
+			err := tdb.DB.Transaction(func(tx *gorm.DB) error {
+				found, err := NewParticipantRepository(tx).GetForUpdate(ctx, participant.ID)
+				require.NoError(t, err)
+				assert.Equal(t, participant.ID, found.ID)
+				require.NotNil(t, found.MaxAgents)
+				assert.Equal(t, limit, *found.MaxAgents)
+				return nil
+			})
+			require.NoError(t, err)
+		})
+
+		t.Run("not found", func(t *testing.T) {
+			found, err := repo.GetForUpdate(context.Background(), properties.NewUUID())
+
+			assert.Nil(t, found)
+			assert.ErrorAs(t, err, &domain.NotFoundError{})
+		})
+	})
+
 	t.Run("List", func(t *testing.T) {
 		t.Run("success - list all", func(t *testing.T) {
 			ctx := context.Background()
@@ -108,6 +137,84 @@ func TestParticipantRepository(t *testing.T) {
 			require.Len(t, result.Items, 1)
 			assert.Equal(t, participant1.ID, result.Items[0].ID)
 		})
+		t.Run("success - without count", func(t *testing.T) {
+			ctx := context.Background()
+
+			// Setup
+			marker := properties.NewUUID().String()
+			participant1 := createTestParticipant(t, domain.ParticipantEnabled)
+			participant1.Name = marker + " a"
+			require.NoError(t, repo.Create(ctx, participant1))
+			participant2 := createTestParticipant(t, domain.ParticipantEnabled)
+			participant2.Name = marker + " b"
+			require.NoError(t, repo.Create(ctx, participant2))
+
+			page := &domain.PageReq{
+				Filters:   map[string][]string{"name": {marker}},
+				Sort:      true,
+				SortBy:    "name",
+				SortAsc:   true,
+				Page:      1,
+				PageSize:  1,
+				SkipCount: true,
+			}
+
+			// Execute
+			first, err := repo.List(ctx, &auth.IdentityScope{}, page)
+			require.NoError(t, err)
+			page.Page = 2
+			second, err := repo.List(ctx, &auth.IdentityScope{}, page)
+			require.NoError(t, err)
+
+			// Assert - the extra item fetched is not returned
+			require.Len(t, first.Items, 1)
+			assert.Equal(t, participant1.ID, first.Items[0].ID)
+			assert.True(t, first.HasNext)
+			assert.True(t, first.Uncounted)
+			assert.Zero(t, first.TotalItems)
+			require.Len(t, second.Items, 1)
+			assert.Equal(t, participant2.ID, second.Items[0].ID)
+			assert.False(t, second.HasNext)
+			assert.True(t, second.HasPrev)
+		})
+		t.Run("success - cursor pagination", func(t *testing.T) {
+			ctx := context.Background()
+
+			// Setup
+			participant1 := createTestParticipant(t, domain.ParticipantEnabled)
+			require.NoError(t, repo.Create(ctx, participant1))
+			participant2 := createTestParticipant(t, domain.ParticipantEnabled)
+			require.NoError(t, repo.Create(ctx, participant2))
+
+			page := &domain.PageReq{
+				Page:     1,
+				PageSize: 1,
+				Cursor:   &domain.PageCursor{},
+			}
+
+			// Execute - newest first
+			first, err := repo.List(ctx, &auth.IdentityScope{}, page)
+			require.NoError(t, err)
+			require.Len(t, first.Items, 1)
+			assert.Equal(t, participant2.ID, first.Items[0].ID)
+			assert.True(t, first.HasNext)
+			require.NotEmpty(t, first.NextCursor)
+
+			// Rows inserted after the first page do not shift the next one
+			participant3 := createTestParticipant(t, domain.ParticipantEnabled)
+			require.NoError(t, repo.Create(ctx, participant3))
+
+			cursor, err := domain.ParsePageCursor(first.NextCursor)
+			require.NoError(t, err)
+			page.Cursor = cursor
+			second, err := repo.List(ctx, &auth.IdentityScope{}, page)
+
+			// Assert
+			require.NoError(t, err)
+			require.Len(t, second.Items, 1)
+			assert.Equal(t, participant1.ID, second.Items[0].ID)
+			assert.True(t, second.HasPrev)
+		})
 	})
 	t.Run("Save", func(t *testing.T) {
 		t.Run("success", func(t *testing.T) {
@@ -2,26 +2,138 @@ package database
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"maps"
+	"strconv"
+	"time"
 
+	"github.com/fulcrumproject/core/pkg/auth"
 	"github.com/fulcrumproject/core/pkg/authz"
 	"github.com/fulcrumproject/core/pkg/properties"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 
 	"github.com/fulcrumproject/core/pkg/domain"
 )
 
+const (
+	// serviceGroupNameIndex is the unique index of the names of the active services of a group
+	serviceGroupNameIndex = "service_group_name_uniq"
+	// serviceAgentInstanceIndex is the unique index of the instance IDs of the active services of an agent
+	serviceAgentInstanceIndex = "service_agent_instance_uniq"
+)
+
 type GormServiceRepository struct {
 	*GormRepository[domain.Service]
 }
 
-var applyServiceFilter = MapFilterApplier(map[string]FilterFieldApplier{
-	"name":          StringContainsInsensitiveFilterFieldApplier("services.name"),
-	"currentStatus": StringInFilterFieldApplier("services.status"),
+var applyServiceFieldFilter = MapFilterApplier(map[string]FilterFieldApplier{
+	"name":           StringContainsInsensitiveFilterFieldApplier("services.name"),
+	"currentStatus":  StringInFilterFieldApplier("services.status"),
+	"groupId":        ParserInFilterFieldApplier("services.group_id", properties.ParseUUID),
+	"serviceTypeId":  ParserInFilterFieldApplier("services.service_type_id", properties.ParseUUID),
+	"agentId":        ParserInFilterFieldApplier("services.agent_id", properties.ParseUUID),
+	"requiredRegion": StringInFilterFieldApplier("services.required_region"),
+	"orphaned":       ParserInFilterFieldApplier("services.orphaned", parseBool),
+	"createdAt":      TimeRangeFilterFieldApplier("services.created_at"),
+	"updatedAt":      TimeRangeFilterFieldApplier("services.updated_at"),
+	"attr.*":         JSONFilterFieldApplier("services.properties"),
 })
 
+// applyServiceFilter excludes the soft-deleted services unless the includeDeleted parameter is true
+// and keeps the services matching the labelSelector parameter, the other parameters filter the fields
+func applyServiceFilter(db *gorm.DB, r *domain.PageReq) (*gorm.DB, error) {
+	includeDeleted := false
+	if values, ok := r.Filters[domain.ServiceIncludeDeletedParam]; ok {
+		parsed, err := strconv.ParseBool(values[len(values)-1])
+		if err != nil {
+			return nil, domain.NewInvalidInputErrorf("invalid %s parameter: %s", domain.ServiceIncludeDeletedParam, values[len(values)-1])
+		}
+		includeDeleted = parsed
+		r = withoutFilter(r, domain.ServiceIncludeDeletedParam)
+	}
+	if !includeDeleted {
+		db = db.Where("services.deleted_at IS NULL")
+	}
+	if values, ok := r.Filters[domain.ServiceLabelSelectorParam]; ok {
+		// Repeated selectors must all match
+		for _, value := range values {
+			selector, err := domain.ParseLabelSelector(value)
+			if err != nil {
+				return nil, err
+			}
+			if db, err = applyLabelSelector(db, "services.labels", selector); err != nil {
+				return nil, err
+			}
+		}
+		r = withoutFilter(r, domain.ServiceLabelSelectorParam)
+	}
+	return applyServiceFieldFilter(db, r)
+}
+
+// withoutFilter returns a copy of the page request without the filter parameter
+func withoutFilter(r *domain.PageReq, param string) *domain.PageReq {
+	filtered := *r
+	filtered.Filters = maps.Clone(r.Filters)
+	delete(filtered.Filters, param)
+	return &filtered
+}
+
+// applyLabelSelector adds the requirements of the selector on the labels JSONB column
+// The equality requirements use the containment operator served by the GIN index of the column,
+// the negative ones also match the rows without labels
+func applyLabelSelector(db *gorm.DB, column string, selector domain.LabelSelector) (*gorm.DB, error) {
+	contains := fmt.Sprintf("%s @> ?::jsonb", column)
+	notContains := fmt.Sprintf("NOT (COALESCE(%s, '{}') @> ?::jsonb)", column)
+	for _, req := range selector {
+		switch req.Operator {
+		case domain.LabelOpEquals, domain.LabelOpIn:
+			var q *gorm.DB
+			for i, value := range req.Values {
+				label, err := labelJSON(req.Key, value)
+				if err != nil {
+					return nil, err
+				}
+				if i == 0 {
+					q = db.Session(&gorm.Session{NewDB: true}).Where(contains, label)
+					continue
+				}
+				q = q.Or(contains, label)
+			}
+			// Grouped so the alternatives compose with the other conditions of the query
+			db = db.Where(q)
+		case domain.LabelOpNotEquals, domain.LabelOpNotIn:
+			for _, value := range req.Values {
+				label, err := labelJSON(req.Key, value)
+				if err != nil {
+					return nil, err
+				}
+				db = db.Where(notContains, label)
+			}
+		case domain.LabelOpExists:
+			db = db.Where(fmt.Sprintf("jsonb_exists(%s, ?)", column), req.Key)
+		case domain.LabelOpDoesNotExist:
+			db = db.Where(fmt.Sprintf("NOT jsonb_exists(COALESCE(%s, '{}'), ?)", column), req.Key)
+		default:
+			return nil, domain.NewInvalidInputErrorf("unsupported label operator %s", req.Operator)
+		}
+	}
+	return db, nil
+}
+
+// labelJSON returns the JSON object holding the single label, for the containment operator
+func labelJSON(key, value string) (string, error) {
+	b, err := json.Marshal(map[string]string{key: value})
+	return string(b), err
+}
+
 var applyServiceSort = MapSortApplier(map[string]string{
-	"name": "services.name",
+	"name":          "services.name",
+	"currentStatus": "services.status",
+	"createdAt":     "services.created_at",
+	"updatedAt":     "services.updated_at",
 })
 
 // NewServiceRepository creates a new instance of ServiceRepository
@@ -31,11 +143,16 @@ func NewServiceRepository(db *gorm.DB) *GormServiceRepository {
 			db,
 			applyServiceFilter,
 			applyServiceSort,
-			providerConsumerAgentAuthzFilterApplier,
+			serviceAuthzFilterApplier,
 			[]string{"Agent", "ServiceType", "Group"}, // Find preload paths
-			[]string{"Agent", "ServiceType", "Group"}, // List preload paths
+			nil, // List preload paths, the relations are loaded only when included
 		),
 	}
+	repo.listIncludePaths = map[string]string{
+		"agent":       "Agent",
+		"group":       "Group",
+		"serviceType": "ServiceType",
+	}
 	return repo
 }
 
@@ -66,12 +183,104 @@ func (r *GormServiceRepository) CountByServiceType(ctx context.Context, serviceT
 	return count, nil
 }
 
+// CountByServiceTypeAndStatus returns the number of active services grouped by service type name and status
+func (r *GormServiceRepository) CountByServiceTypeAndStatus(ctx context.Context) ([]domain.StatusCount, error) {
+	var counts []domain.StatusCount
+	result := r.db.WithContext(ctx).Model(&domain.Service{}).
+		Select("service_types.name AS \"group\", services.status AS status, COUNT(*) AS count").
+		Joins("JOIN service_types ON service_types.id = services.service_type_id").
+		Where("services.deleted_at IS NULL").
+		Group("service_types.name, services.status").
+		Order("service_types.name, services.status").
+		Scan(&counts)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return counts, nil
+}
+
+// FindByServiceType retrieves the active services of a specific type
+func (r *GormServiceRepository) FindByServiceType(ctx context.Context, serviceTypeID properties.UUID) ([]*domain.Service, error) {
+	var services []*domain.Service
+	result := r.db.WithContext(ctx).
+		Where("service_type_id = ? AND deleted_at IS NULL", serviceTypeID).
+		Preload("Agent").
+		Order("created_at").
+		Find(&services)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return services, nil
+}
+
+// FindByGroup retrieves the active services of a group with their service type, in creation order
+func (r *GormServiceRepository) FindByGroup(ctx context.Context, groupID properties.UUID) ([]*domain.Service, error) {
+	var services []*domain.Service
+	result := r.db.WithContext(ctx).
+		Where("group_id = ? AND deleted_at IS NULL", groupID).
+		Preload("ServiceType").
+		Order("created_at").
+		Find(&services)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return services, nil
+}
+
+// LockForUpdate locks the rows of the services until the end of the transaction, in the order of their IDs
+// so transactions locking overlapping services don't deadlock
+func (r *GormServiceRepository) LockForUpdate(ctx context.Context, ids []properties.UUID) error {
+	var locked []properties.UUID
+	return r.db.WithContext(ctx).
+		Model(&domain.Service{}).
+		Where("id IN ?", ids).
+		Order("id").
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Pluck("id", &locked).Error
+}
+
+// UpdateSchemaVersion records the property schema version of the services without changing anything else
+func (r *GormServiceRepository) UpdateSchemaVersion(ctx context.Context, ids []properties.UUID, schemaVersion int) error {
+	return r.db.WithContext(ctx).Model(&domain.Service{}).
+		Where("id IN ?", ids).
+		UpdateColumn("schema_version", schemaVersion).Error
+}
+
+// FindDeletedBefore retrieves the services soft-deleted before the given time
+func (r *GormServiceRepository) FindDeletedBefore(ctx context.Context, before time.Time) ([]*domain.Service, error) {
+	var services []*domain.Service
+	result := r.db.WithContext(ctx).
+		Where("deleted_at < ?", before).
+		Order("deleted_at").
+		Find(&services)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return services, nil
+}
+
+// FindIdle retrieves the active services with an idle timeout and without a job completion since it,
+// the metric entries are kept in another database and checked by the caller
+func (r *GormServiceRepository) FindIdle(ctx context.Context, at time.Time) ([]*domain.Service, error) {
+	cutoff := "?::timestamptz - (services.idle_timeout / 1000) * INTERVAL '1 microsecond'"
+	var services []*domain.Service
+	result := r.db.WithContext(ctx).
+		Where("services.deleted_at IS NULL AND services.idle_timeout IS NOT NULL").
+		Where("COALESCE(services.last_activity_at, services.created_at) < "+cutoff, at).
+		Order("COALESCE(services.last_activity_at, services.created_at)").
+		Find(&services)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return services, nil
+}
+
 // FindByAgentInstanceID retrieves a service by its agent instance ID and agent ID
 func (r *GormServiceRepository) FindByAgentInstanceID(ctx context.Context, agentID properties.UUID, agentInstanceID string) (*domain.Service, error) {
 	var service domain.Service
 
 	result := r.db.WithContext(ctx).
-		Where("agent_instance_id = ? AND agent_id = ?", agentInstanceID, agentID).
+		Where("agent_instance_id = ? AND agent_id = ? AND deleted_at IS NULL", agentInstanceID, agentID).
 		Preload("Agent").
 		Preload("ServiceType").
 		Preload("Group").
@@ -86,6 +295,55 @@ func (r *GormServiceRepository) FindByAgentInstanceID(ctx context.Context, agent
 	return &service, nil
 }
 
+// FindByGroupAndName retrieves the active service of a group with the given name
+func (r *GormServiceRepository) FindByGroupAndName(ctx context.Context, groupID properties.UUID, name string) (*domain.Service, error) {
+	var service domain.Service
+
+	result := r.db.WithContext(ctx).
+		Where("group_id = ? AND name = ? AND deleted_at IS NULL", groupID, name).
+		First(&service)
+
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, domain.NotFoundError{Err: result.Error}
+		}
+		return nil, result.Error
+	}
+	return &service, nil
+}
+
+// Create creates the service, a name already used in its group or an instance ID already used on its agent is a conflict
+func (r *GormServiceRepository) Create(ctx context.Context, service *domain.Service) error {
+	return serviceConflict(r.GormRepository.Create(ctx, service), service)
+}
+
+// Save saves the service, a name already used in its group or an instance ID already used on its agent is a conflict
+func (r *GormServiceRepository) Save(ctx context.Context, service *domain.Service) error {
+	return serviceConflict(r.GormRepository.Save(ctx, service), service)
+}
+
+// serviceConflict translates the violations of the unique indexes of the services, the concurrent writes
+// passing the checks of the commanders all reach the index and all but the first one get the conflict
+func serviceConflict(err error, service *domain.Service) error {
+	switch {
+	case isUniqueViolation(err, serviceGroupNameIndex):
+		return domain.NewServiceNameTakenError(service.GroupID, service.Name)
+	case isUniqueViolation(err, serviceAgentInstanceIndex) && service.AgentInstanceID != nil:
+		return domain.NewAgentInstanceIDTakenError(service.AgentID, *service.AgentInstanceID)
+	}
+	return err
+}
+
+// serviceAuthzFilterApplier restricts the services to the participant or agent of the scope
+// and, when the scope is restricted to some service groups, to the services of these groups
+func serviceAuthzFilterApplier(s *auth.IdentityScope, q *gorm.DB) *gorm.DB {
+	q = providerConsumerAgentAuthzFilterApplier(s, q)
+	if len(s.GroupIDs) > 0 {
+		q = q.Where("services.group_id IN ?", s.GroupIDs)
+	}
+	return q
+}
+
 func (r *GormServiceRepository) AuthScope(ctx context.Context, id properties.UUID) (authz.ObjectScope, error) {
-	return r.AuthScopeByFields(ctx, id, "null", "provider_id", "agent_id", "consumer_id")
+	return r.AuthScopeByFields(ctx, id, "null", "provider_id", "agent_id", "consumer_id", "group_id")
 }
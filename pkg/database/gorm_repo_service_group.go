@@ -54,11 +54,14 @@ func (r *GormServiceGroupRepository) CountByService(ctx context.Context, service
 
 func serviceGroupAuthzFilterApplier(s *auth.IdentityScope, q *gorm.DB) *gorm.DB {
 	if s.ParticipantID != nil {
-		return q.Where("consumer_id = ?", s.ParticipantID)
+		q = q.Where("consumer_id = ?", s.ParticipantID)
+	}
+	if len(s.GroupIDs) > 0 {
+		q = q.Where("service_groups.id IN ?", s.GroupIDs)
 	}
 	return q
 }
 
 func (r *GormServiceGroupRepository) AuthScope(ctx context.Context, id properties.UUID) (authz.ObjectScope, error) {
-	return r.AuthScopeByFields(ctx, id, "null", "null", "null", "consumer_id")
+	return r.AuthScopeByFields(ctx, id, "null", "null", "null", "consumer_id", "id")
 }
@@ -232,6 +232,51 @@ func TestServiceGroupRepository(t *testing.T) {
 			require.NoError(t, err)
 			assert.Equal(t, "Updated Group", found.Name)
 		})
+
+		t.Run("bumps version", func(t *testing.T) {
+			ctx := context.Background()
+
+			serviceGroup := createTestServiceGroup(t, participant.ID)
+			require.NoError(t, repo.Create(ctx, serviceGroup))
+			assert.Equal(t, 1, serviceGroup.Version)
+
+			serviceGroup.Name = "Versioned Group"
+			require.NoError(t, repo.Save(ctx, serviceGroup))
+			assert.Equal(t, 2, serviceGroup.Version)
+
+			found, err := repo.Get(ctx, serviceGroup.ID)
+			require.NoError(t, err)
+			assert.Equal(t, 2, found.Version)
+		})
+
+		t.Run("matching expected version", func(t *testing.T) {
+			serviceGroup := createTestServiceGroup(t, participant.ID)
+			require.NoError(t, repo.Create(context.Background(), serviceGroup))
+
+			ctx := domain.WithExpectedVersion(context.Background(), serviceGroup.ID, 1)
+			serviceGroup.Name = "Matching Group"
+			require.NoError(t, repo.Save(ctx, serviceGroup))
+			assert.Equal(t, 2, serviceGroup.Version)
+		})
+
+		t.Run("stale expected version", func(t *testing.T) {
+			serviceGroup := createTestServiceGroup(t, participant.ID)
+			require.NoError(t, repo.Create(context.Background(), serviceGroup))
+			serviceGroup.Name = "Concurrent Group"
+			require.NoError(t, repo.Save(context.Background(), serviceGroup))
+
+			ctx := domain.WithExpectedVersion(context.Background(), serviceGroup.ID, 1)
+			serviceGroup.Name = "Stale Group"
+			err := repo.Save(ctx, serviceGroup)
+
+			var preconditionErr domain.PreconditionFailedError
+			require.ErrorAs(t, err, &preconditionErr)
+			assert.Equal(t, 2, preconditionErr.CurrentVersion)
+
+			found, err := repo.Get(context.Background(), serviceGroup.ID)
+			require.NoError(t, err)
+			assert.Equal(t, "Concurrent Group", found.Name)
+		})
 	})
 
 	t.Run("delete", func(t *testing.T) {
@@ -322,6 +367,28 @@ func TestServiceGroupRepository(t *testing.T) {
 			assert.NotNil(t, defaultScope.ConsumerID, "ConsumerID should not be nil")
 			assert.Equal(t, participant.ID, *defaultScope.ConsumerID, "ConsumerID should match the participant's ID")
 			assert.Nil(t, defaultScope.AgentID, "AgentID should be nil for service groups")
+			require.NotNil(t, defaultScope.GroupID, "GroupID should be the service group")
+			assert.Equal(t, serviceGroup.ID, *defaultScope.GroupID)
 		})
 	})
+
+	t.Run("List restricted to groups", func(t *testing.T) {
+		ctx := context.Background()
+
+		allowed := createTestServiceGroup(t, participant.ID)
+		require.NoError(t, repo.Create(ctx, allowed))
+		other := createTestServiceGroup(t, participant.ID)
+		require.NoError(t, repo.Create(ctx, other))
+
+		page := &domain.PageReq{Page: 1, PageSize: 100}
+		result, err := repo.List(ctx, &auth.IdentityScope{ParticipantID: &participant.ID, GroupIDs: []properties.UUID{allowed.ID}}, page)
+		require.NoError(t, err)
+		require.Len(t, result.Items, 1)
+		assert.Equal(t, allowed.ID, result.Items[0].ID)
+
+		// No group restriction lists all the groups of the participant
+		result, err = repo.List(ctx, &auth.IdentityScope{ParticipantID: &participant.ID}, page)
+		require.NoError(t, err)
+		assert.GreaterOrEqual(t, len(result.Items), 2)
+	})
 }
@@ -8,6 +8,7 @@ import (
 	"github.com/fulcrumproject/core/pkg/domain"
 	"github.com/fulcrumproject/core/pkg/properties"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type GormServicePoolRepository struct {
@@ -118,6 +119,45 @@ func (r *GormServicePoolRepository) Update(ctx context.Context, pool *domain.Ser
 	return r.Save(ctx, pool)
 }
 
+// ReserveValue allocates the first unused value of the pool in its own transaction
+// The candidate row is locked with FOR UPDATE SKIP LOCKED so concurrent reservations
+// pick different values instead of waiting on, and then double-allocating, the same one.
+func (r *GormServicePoolRepository) ReserveValue(
+	ctx context.Context,
+	poolID, serviceID properties.UUID,
+	propertyName string,
+) (*domain.ServicePoolValue, error) {
+	var value domain.ServicePoolValue
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("service_pool_id = ? AND service_id IS NULL", poolID).
+			Order("name ASC").
+			Limit(1).
+			Find(&value)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return domain.NewPoolExhaustedError(poolID)
+		}
+
+		value.Allocate(serviceID, propertyName)
+		value.Version++
+		value.UpdatedAt = *value.AllocatedAt
+		return tx.Model(&value).UpdateColumns(map[string]any{
+			"service_id":    value.ServiceID,
+			"property_name": value.PropertyName,
+			"allocated_at":  value.AllocatedAt,
+			"version":       value.Version,
+			"updated_at":    value.UpdatedAt,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &value, nil
+}
+
 // AuthScope returns the authorization scope for a service pool via its denormalized participant_id.
 func (r *GormServicePoolRepository) AuthScope(ctx context.Context, id properties.UUID) (authz.ObjectScope, error) {
 	return r.AuthScopeByFields(ctx, id, "participant_id", "null", "null", "null")
@@ -3,6 +3,7 @@ package database
 import (
 	"context"
 	"fmt"
+	"sync"
 	"testing"
 
 	"github.com/fulcrumproject/core/pkg/auth"
@@ -399,6 +400,82 @@ func TestServicePoolRepository(t *testing.T) {
 		})
 	})
 
+	t.Run("ReserveValue", func(t *testing.T) {
+		valueRepo := NewServicePoolValueRepository(tdb.DB)
+
+		t.Run("success - allocates values in name order until exhausted", func(t *testing.T) {
+			ctx := context.Background()
+
+			pool := createTestServicePool(t, poolSet.ID)
+			require.NoError(t, repo.Create(ctx, pool))
+			for _, name := range []string{"b", "a"} {
+				require.NoError(t, valueRepo.Create(ctx, &domain.ServicePoolValue{Name: name, Value: "10.0.0." + name, ServicePoolID: pool.ID}))
+			}
+
+			serviceID := properties.NewUUID()
+			first, err := repo.ReserveValue(ctx, pool.ID, serviceID, "ip")
+			require.NoError(t, err)
+			assert.Equal(t, "a", first.Name)
+			require.NotNil(t, first.ServiceID)
+			assert.Equal(t, serviceID, *first.ServiceID)
+			require.NotNil(t, first.PropertyName)
+			assert.Equal(t, "ip", *first.PropertyName)
+			assert.NotNil(t, first.AllocatedAt)
+
+			stored, err := valueRepo.Get(ctx, first.ID)
+			require.NoError(t, err)
+			require.NotNil(t, stored.ServiceID)
+			assert.Equal(t, serviceID, *stored.ServiceID)
+
+			second, err := repo.ReserveValue(ctx, pool.ID, serviceID, "ip")
+			require.NoError(t, err)
+			assert.Equal(t, "b", second.Name)
+
+			_, err = repo.ReserveValue(ctx, pool.ID, serviceID, "ip")
+			var exhausted domain.PoolExhaustedError
+			require.ErrorAs(t, err, &exhausted)
+			assert.Equal(t, pool.ID, exhausted.PoolID)
+		})
+
+		t.Run("success - concurrent reservations get distinct values", func(t *testing.T) {
+			ctx := context.Background()
+
+			pool := createTestServicePool(t, poolSet.ID)
+			require.NoError(t, repo.Create(ctx, pool))
+			const size = 5
+			for i := range size {
+				require.NoError(t, valueRepo.Create(ctx, &domain.ServicePoolValue{Name: fmt.Sprintf("v%d", i), Value: i, ServicePoolID: pool.ID}))
+			}
+
+			const workers = size + 3
+			var wg sync.WaitGroup
+			values := make([]*domain.ServicePoolValue, workers)
+			errs := make([]error, workers)
+			for i := range workers {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					values[i], errs[i] = repo.ReserveValue(ctx, pool.ID, properties.NewUUID(), "ip")
+				}()
+			}
+			wg.Wait()
+
+			reserved := map[properties.UUID]bool{}
+			exhausted := 0
+			for i := range workers {
+				if errs[i] != nil {
+					require.ErrorAs(t, errs[i], &domain.PoolExhaustedError{})
+					exhausted++
+					continue
+				}
+				assert.False(t, reserved[values[i].ID], "value %s reserved twice", values[i].Name)
+				reserved[values[i].ID] = true
+			}
+			assert.Len(t, reserved, size)
+			assert.Equal(t, workers-size, exhausted)
+		})
+	})
+
 	t.Run("AuthScope", func(t *testing.T) {
 		t.Run("returns DefaultObjectScope with denormalized ParticipantID", func(t *testing.T) {
 			ctx := context.Background()
@@ -148,6 +148,16 @@ func (r *GormServicePoolValueRepository) CountByPool(ctx context.Context, poolID
 	return count, nil
 }
 
+// CountAvailable counts the unallocated values of a pool
+func (r *GormServicePoolValueRepository) CountAvailable(ctx context.Context, poolID properties.UUID) (int64, error) {
+	var count int64
+	result := r.db.WithContext(ctx).Model(&domain.ServicePoolValue{}).Where("service_pool_id = ? AND service_id IS NULL", poolID).Count(&count)
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	return count, nil
+}
+
 func (r *GormServicePoolValueRepository) ReleaseByService(ctx context.Context, serviceID properties.UUID) error {
 	return r.db.WithContext(ctx).Model(&domain.ServicePoolValue{}).Where("service_id = ?", serviceID).Updates(map[string]any{
 		"service_id":    nil,
@@ -303,6 +303,26 @@ func TestServicePoolValueRepository(t *testing.T) {
 		})
 	})
 
+	t.Run("CountAvailable", func(t *testing.T) {
+		t.Run("success - counts only unallocated values", func(t *testing.T) {
+			uniquePool := createTestServicePool(t, poolSet.ID)
+			uniquePool.Type = fmt.Sprintf("count-avail-type-%s", uuid.New().String())
+			uniquePool.ParticipantID = &participant.ID
+			require.NoError(t, poolRepo.Create(ctx, uniquePool))
+
+			for range 2 {
+				require.NoError(t, repo.Create(ctx, createTestServicePoolValue(t, uniquePool.ID)))
+			}
+			allocated := createTestServicePoolValue(t, uniquePool.ID)
+			allocated.Allocate(properties.NewUUID(), "publicIp")
+			require.NoError(t, repo.Create(ctx, allocated))
+
+			count, err := repo.CountAvailable(ctx, uniquePool.ID)
+
+			require.NoError(t, err)
+			assert.Equal(t, int64(2), count)
+		})
+	})
 	t.Run("FindByService", func(t *testing.T) {
 		t.Run("success", func(t *testing.T) {
 			serviceID := properties.NewUUID()
@@ -2,13 +2,18 @@ package database
 
 import (
 	"context"
+	"fmt"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/fulcrumproject/core/pkg/auth"
 	"github.com/fulcrumproject/core/pkg/authz"
+	"github.com/fulcrumproject/core/pkg/helpers"
 	"github.com/fulcrumproject/core/pkg/properties"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
 
 	"github.com/fulcrumproject/core/pkg/domain"
 )
@@ -54,7 +59,7 @@ func TestServiceRepository(t *testing.T) {
 
 	t.Run("create", func(t *testing.T) {
 		service := &domain.Service{
-			Name:              "Test Service",
+			Name:              "Created Service",
 			Status:            "Started",
 			Properties:        &(properties.JSON{"key": "value"}),
 			AgentInstanceData: &(properties.JSON{"cpu": "1"}),
@@ -75,7 +80,7 @@ func TestServiceRepository(t *testing.T) {
 	t.Run("Get", func(t *testing.T) {
 		// Create a service
 		service := &domain.Service{
-			Name:              "Test Service",
+			Name:              "Get Service",
 			Status:            "Started",
 			Properties:        &(properties.JSON{"key": "value"}),
 			AgentInstanceData: &(properties.JSON{"cpu": "1"}),
@@ -116,7 +121,7 @@ func TestServiceRepository(t *testing.T) {
 	t.Run("Save", func(t *testing.T) {
 		// Create a service
 		service := &domain.Service{
-			Name:              "Test Service",
+			Name:              "Saved Service",
 			Status:            "Started",
 			Properties:        &(properties.JSON{"key": "value"}),
 			AgentInstanceData: &(properties.JSON{"cpu": "1"}),
@@ -150,7 +155,7 @@ func TestServiceRepository(t *testing.T) {
 	t.Run("delete", func(t *testing.T) {
 		// Create a service
 		service := &domain.Service{
-			Name:          "Test Service",
+			Name:          "Deleted Service",
 			Status:        "Started",
 			AgentID:       agent.ID,
 			ProviderID:    provider.ID, // Set ProviderID
@@ -188,6 +193,7 @@ func TestServiceRepository(t *testing.T) {
 			page := &domain.PageReq{
 				Page:     1,
 				PageSize: 10,
+				Include:  []string{"agent", "group", "serviceType"},
 			}
 
 			result, err := repo.List(context.Background(), &auth.IdentityScope{}, page)
@@ -199,6 +205,81 @@ func TestServiceRepository(t *testing.T) {
 			assert.NotNil(t, result.Items[0].Group)
 		})
 
+		t.Run("success - relations are loaded only when included", func(t *testing.T) {
+			page := &domain.PageReq{Page: 1, PageSize: 10}
+			result, err := repo.List(context.Background(), &auth.IdentityScope{}, page)
+			require.NoError(t, err)
+			require.NotEmpty(t, result.Items)
+			for _, item := range result.Items {
+				assert.Nil(t, item.Agent)
+				assert.Nil(t, item.ServiceType)
+				assert.Nil(t, item.Group)
+			}
+
+			page.Include = []string{"agent"}
+			result, err = repo.List(context.Background(), &auth.IdentityScope{}, page)
+			require.NoError(t, err)
+			require.NotEmpty(t, result.Items)
+			for _, item := range result.Items {
+				assert.NotNil(t, item.Agent)
+				assert.Nil(t, item.ServiceType)
+				assert.Nil(t, item.Group)
+			}
+		})
+
+		t.Run("success - included relations are loaded in bounded queries", func(t *testing.T) {
+			var queries int
+			countQueries := func(*gorm.DB) { queries++ }
+			require.NoError(t, testDB.DB.Callback().Query().After("gorm:query").Register("test:count_queries", countQueries))
+			defer testDB.DB.Callback().Query().Remove("test:count_queries")
+
+			include := []string{"agent", "group", "serviceType"}
+			page := &domain.PageReq{Page: 1, PageSize: 10, Include: include}
+			result, err := repo.List(context.Background(), &auth.IdentityScope{}, page)
+			require.NoError(t, err)
+			require.Greater(t, len(result.Items), 1)
+			// The count, the items and one query per relation, whatever the number of items
+			assert.Equal(t, 2+len(include), queries)
+
+			queries = 0
+			page = &domain.PageReq{Page: 1, PageSize: 10, Include: include, Filters: map[string][]string{"name": {"No such service"}}}
+			result, err = repo.List(context.Background(), &auth.IdentityScope{}, page)
+			require.NoError(t, err)
+			assert.Empty(t, result.Items)
+			assert.Equal(t, 1, queries, "An empty result must only be counted, not read with its relations")
+		})
+
+		t.Run("success - restricted to the groups of the identity scope", func(t *testing.T) {
+			page := &domain.PageReq{Page: 1, PageSize: 100}
+			otherGroupID := properties.NewUUID()
+			result, err := repo.List(context.Background(), &auth.IdentityScope{ParticipantID: &consumer.ID, GroupIDs: []properties.UUID{otherGroupID}}, page)
+			require.NoError(t, err)
+			assert.Empty(t, result.Items)
+
+			result, err = repo.List(context.Background(), &auth.IdentityScope{ParticipantID: &consumer.ID, GroupIDs: []properties.UUID{serviceGroup.ID}}, page)
+			require.NoError(t, err)
+			require.NotEmpty(t, result.Items)
+			for _, item := range result.Items {
+				assert.Equal(t, serviceGroup.ID, item.GroupID)
+			}
+		})
+
+		t.Run("success - included relations respect the identity scope", func(t *testing.T) {
+			otherConsumerID := properties.NewUUID()
+			page := &domain.PageReq{Page: 1, PageSize: 10, Include: []string{"agent", "group", "serviceType"}}
+			result, err := repo.List(context.Background(), &auth.IdentityScope{ParticipantID: &otherConsumerID}, page)
+			require.NoError(t, err)
+			assert.Empty(t, result.Items)
+
+			result, err = repo.List(context.Background(), &auth.IdentityScope{ParticipantID: &consumer.ID}, page)
+			require.NoError(t, err)
+			require.NotEmpty(t, result.Items)
+			for _, item := range result.Items {
+				assert.Equal(t, consumer.ID, item.ConsumerID)
+				assert.NotNil(t, item.Agent)
+			}
+		})
+
 		t.Run("success - list with name filter", func(t *testing.T) {
 			page := &domain.PageReq{
 				Page:     1,
@@ -212,6 +293,48 @@ func TestServiceRepository(t *testing.T) {
 			assert.Equal(t, "Service A", result.Items[0].Name)
 		})
 
+		t.Run("success - list with label selector", func(t *testing.T) {
+			newLabeled := func(name string, labels map[string]string) *domain.Service {
+				svc := &domain.Service{Name: name, Status: "Started", Labels: labels, AgentID: agent.ID, ProviderID: provider.ID, ConsumerID: consumer.ID, ServiceTypeID: serviceType.ID, GroupID: serviceGroup.ID}
+				require.NoError(t, repo.Create(context.Background(), svc))
+				return svc
+			}
+			gold := newLabeled("Labeled Gold", map[string]string{"env": "prod", "tier": "gold"})
+			silver := newLabeled("Labeled Silver", map[string]string{"env": "prod", "tier": "silver"})
+			dev := newLabeled("Labeled Dev", map[string]string{"env": "dev"})
+
+			list := func(scope *auth.IdentityScope, selector string) []string {
+				page := &domain.PageReq{Page: 1, PageSize: 100, Filters: map[string][]string{
+					domain.ServiceLabelSelectorParam: {selector},
+					"name":                           {"Labeled"},
+				}}
+				result, err := repo.List(context.Background(), scope, page)
+				require.NoError(t, err)
+				var names []string
+				for _, item := range result.Items {
+					names = append(names, item.Name)
+				}
+				return names
+			}
+
+			all := &auth.IdentityScope{}
+			assert.ElementsMatch(t, []string{gold.Name, silver.Name}, list(all, "label.env=prod"))
+			assert.ElementsMatch(t, []string{gold.Name, silver.Name}, list(all, "label.env=prod,label.tier in (gold,silver)"))
+			assert.ElementsMatch(t, []string{gold.Name}, list(all, "label.env=prod,label.tier notin (silver)"))
+			assert.ElementsMatch(t, []string{silver.Name, dev.Name}, list(all, "label.tier!=gold"))
+			assert.ElementsMatch(t, []string{gold.Name, silver.Name}, list(all, "label.tier"))
+			assert.ElementsMatch(t, []string{dev.Name}, list(all, "!label.tier"))
+
+			// The selector composes with the identity scope
+			otherConsumerID := properties.NewUUID()
+			assert.Empty(t, list(&auth.IdentityScope{ParticipantID: &otherConsumerID}, "label.tier in (gold,silver)"))
+			assert.ElementsMatch(t, []string{gold.Name, silver.Name}, list(&auth.IdentityScope{ParticipantID: &consumer.ID}, "label.tier in (gold,silver)"))
+
+			page := &domain.PageReq{Page: 1, PageSize: 10, Filters: map[string][]string{domain.ServiceLabelSelectorParam: {"env=prod"}}}
+			_, err := repo.List(context.Background(), all, page)
+			assert.ErrorAs(t, err, &domain.InvalidInputError{})
+		})
+
 		t.Run("success - list with name matching", func(t *testing.T){
 			firstService := &domain.Service{
 				Name: "VM Doe", 
@@ -277,6 +400,26 @@ func TestServiceRepository(t *testing.T) {
 			}
 		})
 
+		t.Run("success - list with orphaned filter", func(t *testing.T) {
+			orphaned := &domain.Service{Name: "Orphaned VM", Status: "Started", AgentID: agent.ID, ProviderID: provider.ID, ConsumerID: consumer.ID, ServiceTypeID: serviceType.ID, GroupID: serviceGroup.ID}
+			orphaned.MarkOrphaned()
+			require.NoError(t, repo.Create(context.Background(), orphaned))
+
+			page := &domain.PageReq{Page: 1, PageSize: 100, Filters: map[string][]string{"orphaned": {"true"}}}
+			result, err := repo.List(context.Background(), &auth.IdentityScope{}, page)
+			require.NoError(t, err)
+			require.Len(t, result.Items, 1)
+			assert.Equal(t, orphaned.ID, result.Items[0].ID)
+			assert.NotNil(t, result.Items[0].OrphanedAt)
+
+			page = &domain.PageReq{Page: 1, PageSize: 100, Filters: map[string][]string{"orphaned": {"false"}}}
+			result, err = repo.List(context.Background(), &auth.IdentityScope{}, page)
+			require.NoError(t, err)
+			for _, item := range result.Items {
+				assert.False(t, item.Orphaned)
+			}
+		})
+
 		t.Run("success - list with sorting", func(t *testing.T) {
 			page := &domain.PageReq{
 				Page:     1,
@@ -295,11 +438,49 @@ func TestServiceRepository(t *testing.T) {
 			}
 		})
 
+		t.Run("success - list with multi-field sorting", func(t *testing.T) {
+			page := &domain.PageReq{
+				Page:     1,
+				PageSize: 100,
+				Sort:     true,
+				SortFields: []domain.SortField{
+					{Field: "currentStatus", Asc: true},
+					{Field: "updatedAt", Asc: false},
+				},
+			}
+
+			result, err := repo.List(context.Background(), &auth.IdentityScope{}, page)
+			require.NoError(t, err)
+			assert.GreaterOrEqual(t, len(result.Items), 3)
+			// Verify status ascending, then most recently updated first
+			for i := 1; i < len(result.Items); i++ {
+				prev, curr := result.Items[i-1], result.Items[i]
+				assert.LessOrEqual(t, prev.Status, curr.Status)
+				if prev.Status == curr.Status {
+					assert.False(t, prev.UpdatedAt.Before(curr.UpdatedAt))
+				}
+			}
+		})
+
+		t.Run("error - sort by non allowlisted field", func(t *testing.T) {
+			page := &domain.PageReq{
+				Page:       1,
+				PageSize:   10,
+				Sort:       true,
+				SortFields: []domain.SortField{{Field: "name", Asc: true}, {Field: "agentInstanceData", Asc: true}},
+			}
+
+			result, err := repo.List(context.Background(), &auth.IdentityScope{}, page)
+			assert.Nil(t, result)
+			assert.ErrorAs(t, err, &domain.InvalidInputError{})
+			assert.ErrorContains(t, err, "agentInstanceData")
+		})
+
 		t.Run("success - list with pagination", func(t *testing.T) {
 			// Create multiple services
 			for i := 0; i < 5; i++ {
 				service := &domain.Service{
-					Name:          "Paginated Service",
+					Name:          fmt.Sprintf("Paginated Service %d", i),
 					Status:        "Started",
 					ProviderID:    provider.ID, // Set ProviderID
 					ConsumerID:    consumer.ID, // Set ConsumerID
@@ -385,6 +566,113 @@ func TestServiceRepository(t *testing.T) {
 		assert.Equal(t, int64(0), count, "Should return zero for non-existent agent")
 	})
 
+	t.Run("CountByServiceTypeAndStatus", func(t *testing.T) {
+		service := &domain.Service{
+			Name:          "Status Count Service",
+			Status:        "Stopped",
+			AgentID:       agent.ID,
+			ProviderID:    provider.ID,
+			ConsumerID:    consumer.ID,
+			ServiceTypeID: serviceType.ID,
+			GroupID:       serviceGroup.ID,
+		}
+		require.NoError(t, repo.Create(context.Background(), service))
+		deleted := &domain.Service{
+			Name:          "Deleted Status Count Service",
+			Status:        "Archived",
+			AgentID:       agent.ID,
+			ProviderID:    provider.ID,
+			ConsumerID:    consumer.ID,
+			ServiceTypeID: serviceType.ID,
+			GroupID:       serviceGroup.ID,
+		}
+		require.NoError(t, repo.Create(context.Background(), deleted))
+		deleted.SoftDelete("Archived")
+		require.NoError(t, repo.Save(context.Background(), deleted))
+
+		counts, err := repo.CountByServiceTypeAndStatus(context.Background())
+		require.NoError(t, err)
+		var found bool
+		for _, c := range counts {
+			if c.Group == serviceType.Name && c.Status == "Stopped" {
+				found = true
+				assert.GreaterOrEqual(t, c.Count, int64(1))
+			}
+			assert.NotEqual(t, "Archived", c.Status, "Should not count the deleted services")
+		}
+		assert.True(t, found, "Should count the services of the type in their status")
+	})
+
+	t.Run("FindByServiceType and UpdateSchemaVersion", func(t *testing.T) {
+		service := &domain.Service{
+			Name:          "Schema Version Service",
+			Status:        "Started",
+			AgentID:       agent.ID,
+			ProviderID:    provider.ID,
+			ConsumerID:    consumer.ID,
+			ServiceTypeID: serviceType.ID,
+			GroupID:       serviceGroup.ID,
+		}
+		require.NoError(t, repo.Create(context.Background(), service))
+		deleted := createTestService(t, serviceType.ID, serviceGroup.ID, agent.ID, provider.ID, consumer.ID)
+		require.NoError(t, repo.Create(context.Background(), deleted))
+		deleted.SoftDelete(deleted.Status)
+		require.NoError(t, repo.Save(context.Background(), deleted))
+
+		services, err := repo.FindByServiceType(context.Background(), serviceType.ID)
+		require.NoError(t, err)
+		var found *domain.Service
+		for _, s := range services {
+			assert.Equal(t, serviceType.ID, s.ServiceTypeID)
+			assert.NotEqual(t, deleted.ID, s.ID, "Should skip the deleted services")
+			if s.ID == service.ID {
+				found = s
+			}
+		}
+		require.NotNil(t, found, "Should find the service of the type")
+		assert.Equal(t, 1, found.SchemaVersion, "Should default to the first schema version")
+		assert.NotNil(t, found.Agent, "Should preload the agent")
+
+		require.NoError(t, repo.UpdateSchemaVersion(context.Background(), []properties.UUID{service.ID}, 3))
+		updated, err := repo.Get(context.Background(), service.ID)
+		require.NoError(t, err)
+		assert.Equal(t, 3, updated.SchemaVersion)
+	})
+
+	t.Run("FindByGroup", func(t *testing.T) {
+		group := &domain.ServiceGroup{Name: "Dependency Group", ConsumerID: consumer.ID}
+		require.NoError(t, serviceGroupRepo.Create(context.Background(), group))
+		newService := func(name string, dependsOn ...properties.UUID) *domain.Service {
+			service := &domain.Service{
+				Name:          name,
+				Status:        "Started",
+				AgentID:       agent.ID,
+				ProviderID:    provider.ID,
+				ConsumerID:    consumer.ID,
+				ServiceTypeID: serviceType.ID,
+				GroupID:       group.ID,
+				DependsOn:     dependsOn,
+			}
+			require.NoError(t, repo.Create(context.Background(), service))
+			return service
+		}
+		db := newService("db")
+		app := newService("app", db.ID)
+		deleted := newService("deleted")
+		now := time.Now()
+		deleted.DeletedAt = &now
+		require.NoError(t, repo.Save(context.Background(), deleted))
+
+		services, err := repo.FindByGroup(context.Background(), group.ID)
+		require.NoError(t, err)
+		require.Len(t, services, 2, "Should skip the deleted services")
+		assert.Equal(t, db.ID, services[0].ID)
+		assert.Equal(t, app.ID, services[1].ID)
+		assert.Equal(t, []properties.UUID{db.ID}, services[1].DependsOn)
+		assert.Nil(t, services[0].DependsOn)
+		require.NotNil(t, services[1].ServiceType, "Should preload the service type")
+	})
+
 	t.Run("FindByAgentInstanceID", func(t *testing.T) {
 		// Create a service with an agent instance ID
 		agentInstanceID := "inst-123456"
@@ -424,6 +712,202 @@ func TestServiceRepository(t *testing.T) {
 		assert.Nil(t, found, "Result should be nil")
 	})
 
+	t.Run("Soft-deleted services", func(t *testing.T) {
+		service := &domain.Service{
+			Name:          "Soft Deleted Service",
+			Status:        "Deleted",
+			AgentID:       agent.ID,
+			ProviderID:    provider.ID,
+			ConsumerID:    consumer.ID,
+			ServiceTypeID: serviceType.ID,
+			GroupID:       serviceGroup.ID,
+		}
+		require.NoError(t, repo.Create(context.Background(), service))
+		service.SoftDelete("Started")
+		require.NoError(t, repo.Save(context.Background(), service))
+
+		listIDs := func(filters map[string][]string) []properties.UUID {
+			page := &domain.PageReq{Page: 1, PageSize: 100, Filters: filters}
+			result, err := repo.List(context.Background(), &auth.IdentityScope{}, page)
+			require.NoError(t, err)
+			ids := make([]properties.UUID, len(result.Items))
+			for i, item := range result.Items {
+				ids[i] = item.ID
+			}
+			return ids
+		}
+		assert.NotContains(t, listIDs(nil), service.ID, "Deleted services should be excluded by default")
+		assert.NotContains(t, listIDs(map[string][]string{"name": {"Soft Deleted"}}), service.ID)
+		assert.Contains(t, listIDs(map[string][]string{"includeDeleted": {"true"}, "name": {"Soft Deleted"}}), service.ID)
+
+		_, err := repo.List(context.Background(), &auth.IdentityScope{}, &domain.PageReq{Page: 1, PageSize: 10, Filters: map[string][]string{"includeDeleted": {"maybe"}}})
+		assert.ErrorAs(t, err, &domain.InvalidInputError{})
+
+		deleted, err := repo.FindDeletedBefore(context.Background(), time.Now().Add(time.Minute))
+		require.NoError(t, err)
+		ids := make([]properties.UUID, len(deleted))
+		for i, s := range deleted {
+			ids[i] = s.ID
+		}
+		assert.Contains(t, ids, service.ID)
+
+		deleted, err = repo.FindDeletedBefore(context.Background(), time.Now().Add(-time.Minute))
+		require.NoError(t, err)
+		for _, s := range deleted {
+			assert.NotEqual(t, service.ID, s.ID, "Services deleted within the window should not be found")
+		}
+	})
+
+	t.Run("FindIdle", func(t *testing.T) {
+		newService := func(name string, idleTimeout *time.Duration, lastActivity time.Time) *domain.Service {
+			service := &domain.Service{
+				Name:           name,
+				Status:         "Started",
+				AgentID:        agent.ID,
+				ProviderID:     provider.ID,
+				ConsumerID:     consumer.ID,
+				ServiceTypeID:  serviceType.ID,
+				GroupID:        serviceGroup.ID,
+				IdleTimeout:    idleTimeout,
+				LastActivityAt: &lastActivity,
+			}
+			require.NoError(t, repo.Create(context.Background(), service))
+			return service
+		}
+		now := time.Now()
+		idle := newService("Idle Service", helpers.DurationPtr(time.Hour), now.Add(-2*time.Hour))
+		active := newService("Active Service", helpers.DurationPtr(time.Hour), now.Add(-30*time.Minute))
+		noPolicy := newService("Service Without Idle Policy", nil, now.Add(-48*time.Hour))
+		deleted := newService("Deleted Idle Service", helpers.DurationPtr(time.Hour), now.Add(-2*time.Hour))
+		deleted.SoftDelete("Started")
+		require.NoError(t, repo.Save(context.Background(), deleted))
+
+		services, err := repo.FindIdle(context.Background(), now)
+		require.NoError(t, err)
+		ids := make([]properties.UUID, len(services))
+		for i, s := range services {
+			ids[i] = s.ID
+		}
+		assert.Contains(t, ids, idle.ID)
+		assert.NotContains(t, ids, active.ID)
+		assert.NotContains(t, ids, noPolicy.ID, "Services without an idle timeout are never idle")
+		assert.NotContains(t, ids, deleted.ID)
+	})
+
+	t.Run("LockForUpdate", func(t *testing.T) {
+		ctx := context.Background()
+		service := createTestService(t, serviceType.ID, serviceGroup.ID, agent.ID, provider.ID, consumer.ID)
+		require.NoError(t, repo.Create(ctx, service))
+
+		err := testDB.DB.Transaction(func(tx *gorm.DB) error {
+			require.NoError(t, NewServiceRepository(tx).LockForUpdate(ctx, []properties.UUID{service.ID, properties.NewUUID()}))
+
+			// Another transaction cannot lock the service until this one ends
+			err := testDB.DB.Transaction(func(other *gorm.DB) error {
+				require.NoError(t, other.Exec("SET LOCAL lock_timeout = '100ms'").Error)
+				return NewServiceRepository(other).LockForUpdate(ctx, []properties.UUID{service.ID})
+			})
+			assert.Error(t, err)
+			return nil
+		})
+		require.NoError(t, err)
+
+		assert.NoError(t, repo.LockForUpdate(ctx, []properties.UUID{service.ID}))
+	})
+
+	t.Run("Name unique in the group", func(t *testing.T) {
+		service := createTestService(t, serviceType.ID, serviceGroup.ID, agent.ID, provider.ID, consumer.ID)
+		service.Name = "Unique Name Service"
+		require.NoError(t, repo.Create(context.Background(), service))
+
+		found, err := repo.FindByGroupAndName(context.Background(), serviceGroup.ID, service.Name)
+		require.NoError(t, err)
+		assert.Equal(t, service.ID, found.ID)
+
+		duplicate := createTestService(t, serviceType.ID, serviceGroup.ID, agent.ID, provider.ID, consumer.ID)
+		duplicate.Name = service.Name
+		err = repo.Create(context.Background(), duplicate)
+		assert.ErrorIs(t, err, domain.ErrServiceNameTaken)
+		assert.ErrorAs(t, err, &domain.ConflictError{})
+
+		// A rename to a taken name is a conflict too
+		renamed := createTestService(t, serviceType.ID, serviceGroup.ID, agent.ID, provider.ID, consumer.ID)
+		require.NoError(t, repo.Create(context.Background(), renamed))
+		renamed.Name = service.Name
+		assert.ErrorIs(t, repo.Save(context.Background(), renamed), domain.ErrServiceNameTaken)
+
+		// The name is free in another group and once the service is deleted
+		otherGroup := createTestServiceGroup(t, consumer.ID)
+		require.NoError(t, NewServiceGroupRepository(testDB.DB).Create(context.Background(), otherGroup))
+		elsewhere := createTestService(t, serviceType.ID, otherGroup.ID, agent.ID, provider.ID, consumer.ID)
+		elsewhere.Name = service.Name
+		require.NoError(t, repo.Create(context.Background(), elsewhere))
+
+		now := time.Now()
+		service.DeletedAt = &now
+		require.NoError(t, repo.Save(context.Background(), service))
+		_, err = repo.FindByGroupAndName(context.Background(), serviceGroup.ID, service.Name)
+		assert.ErrorAs(t, err, &domain.NotFoundError{})
+		require.NoError(t, repo.Create(context.Background(), duplicate))
+	})
+
+	t.Run("Agent instance ID unique on the agent", func(t *testing.T) {
+		instanceID := "inst-unique"
+		service := createTestService(t, serviceType.ID, serviceGroup.ID, agent.ID, provider.ID, consumer.ID)
+		service.AgentInstanceID = &instanceID
+		require.NoError(t, repo.Create(context.Background(), service))
+
+		// Saving the service again with its own instance ID is allowed
+		require.NoError(t, repo.Save(context.Background(), service))
+
+		duplicate := createTestService(t, serviceType.ID, serviceGroup.ID, agent.ID, provider.ID, consumer.ID)
+		require.NoError(t, repo.Create(context.Background(), duplicate))
+		duplicate.AgentInstanceID = &instanceID
+		err := repo.Save(context.Background(), duplicate)
+		assert.ErrorIs(t, err, domain.ErrAgentInstanceIDTaken)
+		assert.ErrorAs(t, err, &domain.ConflictError{})
+
+		// The instance ID is free on another agent and once the service is deleted
+		otherAgent := createTestAgent(t, provider.ID, agentType.ID, domain.AgentConnected)
+		require.NoError(t, NewAgentRepository(testDB.DB).Create(context.Background(), otherAgent))
+		elsewhere := createTestService(t, serviceType.ID, serviceGroup.ID, otherAgent.ID, provider.ID, consumer.ID)
+		elsewhere.AgentInstanceID = &instanceID
+		require.NoError(t, repo.Create(context.Background(), elsewhere))
+
+		now := time.Now()
+		service.DeletedAt = &now
+		require.NoError(t, repo.Save(context.Background(), service))
+		_, err = repo.FindByAgentInstanceID(context.Background(), agent.ID, instanceID)
+		assert.ErrorAs(t, err, &domain.NotFoundError{})
+		require.NoError(t, repo.Save(context.Background(), duplicate))
+	})
+
+	t.Run("Name unique in the group under concurrent creates", func(t *testing.T) {
+		const creates = 5
+		var wg sync.WaitGroup
+		errs := make([]error, creates)
+		for i := range creates {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				service := createTestService(t, serviceType.ID, serviceGroup.ID, agent.ID, provider.ID, consumer.ID)
+				service.Name = "Concurrent Service"
+				errs[i] = repo.Create(context.Background(), service)
+			}()
+		}
+		wg.Wait()
+
+		created := 0
+		for _, err := range errs {
+			if err == nil {
+				created++
+				continue
+			}
+			assert.ErrorIs(t, err, domain.ErrServiceNameTaken)
+		}
+		assert.Equal(t, 1, created)
+	})
+
 	t.Run("AuthScope", func(t *testing.T) {
 		service := createTestService(t, serviceType.ID, serviceGroup.ID, agent.ID, provider.ID, consumer.ID)
 		require.NoError(t, repo.Create(context.Background(), service))
@@ -437,5 +921,6 @@ func TestServiceRepository(t *testing.T) {
 		assert.Equal(t, provider.ID, *defaultScope.ProviderID, "Provider ID should match")
 		assert.Equal(t, consumer.ID, *defaultScope.ConsumerID, "Consumer ID should match")
 		assert.Equal(t, agent.ID, *defaultScope.AgentID, "Agent ID should match")
+		assert.Equal(t, serviceGroup.ID, *defaultScope.GroupID, "Group ID should match")
 	})
 }
@@ -3,7 +3,9 @@ package database
 import (
 	"context"
 	"log/slog"
+	"time"
 
+	"github.com/fulcrumproject/core/pkg/auth"
 	"github.com/fulcrumproject/core/pkg/authz"
 	"github.com/fulcrumproject/core/pkg/properties"
 	"gorm.io/gorm"
@@ -23,9 +25,10 @@ var applyTokenFilter = MapFilterApplier(map[string]FilterFieldApplier{
 })
 
 var applyTokenSort = MapSortApplier(map[string]string{
-	"name":      "name",
-	"expireAt":  "expire_at",
-	"createdAt": "created_at",
+	"name":       "name",
+	"expireAt":   "expire_at",
+	"lastUsedAt": "last_used_at",
+	"createdAt":  "created_at",
 })
 
 // NewTokenRepository creates a new instance of TokenRepository
@@ -60,26 +63,105 @@ func (r *GormTokenRepository) FindByHashedValue(ctx context.Context, hashedValue
 	return &token, nil
 }
 
-// DeleteByAgentID removes all tokens associated with an agent ID
-func (r *GormTokenRepository) DeleteByAgentID(ctx context.Context, agentID properties.UUID) error {
+// FindUnusedSince returns the tokens not used since the threshold, the ones never used must be created before it
+func (r *GormTokenRepository) FindUnusedSince(ctx context.Context, threshold time.Time) ([]*domain.Token, error) {
+	var tokens []*domain.Token
+	err := r.db.WithContext(ctx).
+		Where("last_used_at < ? OR (last_used_at IS NULL AND created_at < ?)", threshold, threshold).
+		Order("last_used_at ASC NULLS FIRST, created_at ASC").
+		Find(&tokens).Error
+	if err != nil {
+		return nil, err
+	}
+	return tokens, nil
+}
+
+// ListHashCosts returns the distinct costs the token values are hashed with, 0 for the legacy hashes
+func (r *GormTokenRepository) ListHashCosts(ctx context.Context) ([]int, error) {
+	var costs []int
+	err := r.db.WithContext(ctx).
+		Model(&domain.Token{}).
+		Distinct("hash_cost").
+		Pluck("hash_cost", &costs).Error
+	if err != nil {
+		return nil, err
+	}
+	return costs, nil
+}
+
+// UpdateLastUsedAt records the last use of a token, skipping the update when it was recorded less than domain.TokenLastUsedThrottle before
+// The condition keeps the throttling across concurrent requests and replicas, the version is left untouched
+func (r *GormTokenRepository) UpdateLastUsedAt(ctx context.Context, id properties.UUID, at time.Time) error {
+	return r.db.WithContext(ctx).
+		Model(&domain.Token{}).
+		Where("id = ? AND (last_used_at IS NULL OR last_used_at <= ?)", id, at.Add(-domain.TokenLastUsedThrottle)).
+		UpdateColumn("last_used_at", at).Error
+}
+
+// UpdateHash replaces the hash of a token value by its hash at another cost, the version is left untouched
+// as the token does not change
+func (r *GormTokenRepository) UpdateHash(ctx context.Context, id properties.UUID, hashedValue string, hashCost int) error {
+	return r.db.WithContext(ctx).
+		Model(&domain.Token{}).
+		Where("id = ?", id).
+		UpdateColumns(map[string]any{"hashed_value": hashedValue, "hash_cost": hashCost}).Error
+}
+
+// FindExpiring returns the tokens in the scope expiring before the threshold, the expired ones included, by expiry
+func (r *GormTokenRepository) FindExpiring(ctx context.Context, scope *auth.IdentityScope, before time.Time) ([]*domain.Token, error) {
+	q := r.db.WithContext(ctx).Preload("Participant").Preload("Agent").Where("expire_at < ?", before)
+	if scope != nil {
+		q = participantAuthzFilterApplier(scope, q)
+	}
+	var tokens []*domain.Token
+	if err := q.Order("expire_at ASC, id ASC").Find(&tokens).Error; err != nil {
+		return nil, err
+	}
+	return tokens, nil
+}
+
+// FindExpiryNoticeCandidates returns the tokens expiring before the threshold not yet warned to be expired
+func (r *GormTokenRepository) FindExpiryNoticeCandidates(ctx context.Context, before time.Time) ([]*domain.Token, error) {
+	var tokens []*domain.Token
+	err := r.db.WithContext(ctx).
+		Where("expire_at < ? AND expiry_notice <> ?", before, domain.TokenExpiryNoticeExpired).
+		Order("expire_at ASC").
+		Find(&tokens).Error
+	if err != nil {
+		return nil, err
+	}
+	return tokens, nil
+}
+
+// UpdateExpiryNotice records the expiration warning of a token, skipping the update when its expiry is no longer expireAt
+// The version is left untouched as the notice is not a change of the token
+func (r *GormTokenRepository) UpdateExpiryNotice(ctx context.Context, id properties.UUID, expireAt time.Time, notice domain.TokenExpiryNotice) error {
+	return r.db.WithContext(ctx).
+		Model(&domain.Token{}).
+		Where("id = ? AND expire_at = ?", id, expireAt).
+		UpdateColumn("expiry_notice", notice).Error
+}
+
+// DeleteByAgentID removes all tokens associated with an agent ID and returns their number
+func (r *GormTokenRepository) DeleteByAgentID(ctx context.Context, agentID properties.UUID) (int64, error) {
 	// Delete all tokens with the given agent ID
 	result := r.db.WithContext(ctx).Where("agent_id = ?", agentID).Delete(&domain.Token{})
 	if result.Error != nil {
-		return result.Error
+		return 0, result.Error
 	}
 
-	return nil
+	return result.RowsAffected, nil
 }
 
-// DeleteByParticipantID removes all tokens associated with a participant ID
-func (r *GormTokenRepository) DeleteByParticipantID(ctx context.Context, participantID properties.UUID) error {
+// DeleteByParticipantID removes all tokens associated with a participant ID and returns their number
+func (r *GormTokenRepository) DeleteByParticipantID(ctx context.Context, participantID properties.UUID) (int64, error) {
 	// Delete all tokens with the given participant ID
 	result := r.db.WithContext(ctx).Where("participant_id = ?", participantID).Delete(&domain.Token{})
 	if result.Error != nil {
-		return result.Error
+		return 0, result.Error
 	}
 
-	return nil
+	return result.RowsAffected, nil
 }
 
 // AuthScope returns the auth scope for the token
@@ -8,6 +8,7 @@ import (
 	"github.com/fulcrumproject/core/pkg/auth"
 	"github.com/fulcrumproject/core/pkg/authz"
 	"github.com/fulcrumproject/core/pkg/domain"
+	"github.com/fulcrumproject/core/pkg/properties"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -190,7 +191,7 @@ func TestTokenRepository(t *testing.T) {
 				ParticipantID: &participant.ID,
 			}
 
-			require.NoError(t, agentToken.GenerateTokenValue())
+			require.NoError(t, agentToken.GenerateTokenValue(domain.DefaultTokenHashCost))
 			require.NoError(t, repo.Create(ctx, agentToken))
 
 			otherAgentToken := createTestToken(t, auth.RoleAgent, nil)
@@ -369,6 +370,173 @@ func TestTokenRepository(t *testing.T) {
 		})
 	})
 
+	t.Run("UpdateLastUsedAt", func(t *testing.T) {
+		t.Run("success - throttled", func(t *testing.T) {
+			ctx := context.Background()
+
+			// Setup
+			token := createTestToken(t, auth.RoleAdmin, nil)
+			require.NoError(t, repo.Create(ctx, token))
+			usedAt := time.Now().UTC().Truncate(time.Microsecond)
+
+			// Execute
+			require.NoError(t, repo.UpdateLastUsedAt(ctx, token.ID, usedAt))
+			require.NoError(t, repo.UpdateLastUsedAt(ctx, token.ID, usedAt.Add(30*time.Second)))
+
+			// Assert - the second use is within the throttle interval
+			found, err := repo.Get(ctx, token.ID)
+			require.NoError(t, err)
+			require.NotNil(t, found.LastUsedAt)
+			assert.True(t, usedAt.Equal(*found.LastUsedAt))
+			assert.Equal(t, token.Version, found.Version)
+
+			// Execute - past the throttle interval
+			require.NoError(t, repo.UpdateLastUsedAt(ctx, token.ID, usedAt.Add(domain.TokenLastUsedThrottle)))
+
+			// Assert
+			found, err = repo.Get(ctx, token.ID)
+			require.NoError(t, err)
+			assert.True(t, usedAt.Add(domain.TokenLastUsedThrottle).Equal(*found.LastUsedAt))
+		})
+	})
+
+	t.Run("FindUnusedSince", func(t *testing.T) {
+		t.Run("success", func(t *testing.T) {
+			ctx := context.Background()
+
+			// Setup
+			now := time.Now()
+			neverUsed := createTestToken(t, auth.RoleAdmin, nil)
+			require.NoError(t, repo.Create(ctx, neverUsed))
+			usedLongAgo := createTestToken(t, auth.RoleAdmin, nil)
+			require.NoError(t, repo.Create(ctx, usedLongAgo))
+			require.NoError(t, repo.UpdateLastUsedAt(ctx, usedLongAgo.ID, now.Add(-48*time.Hour)))
+			usedRecently := createTestToken(t, auth.RoleAdmin, nil)
+			require.NoError(t, repo.Create(ctx, usedRecently))
+			require.NoError(t, repo.UpdateLastUsedAt(ctx, usedRecently.ID, now))
+
+			// Execute
+			tokens, err := repo.FindUnusedSince(ctx, now.Add(-time.Hour))
+			require.NoError(t, err)
+			ids := make(map[properties.UUID]bool, len(tokens))
+			for _, token := range tokens {
+				ids[token.ID] = true
+			}
+
+			// Assert - tokens created after the threshold are not stale yet
+			assert.False(t, ids[neverUsed.ID])
+			assert.True(t, ids[usedLongAgo.ID])
+			assert.False(t, ids[usedRecently.ID])
+
+			// Execute - a threshold after the creation reports the never used tokens
+			tokens, err = repo.FindUnusedSince(ctx, time.Now().Add(time.Minute))
+			require.NoError(t, err)
+			ids = make(map[properties.UUID]bool, len(tokens))
+			for _, token := range tokens {
+				ids[token.ID] = true
+			}
+			assert.True(t, ids[neverUsed.ID])
+			assert.True(t, ids[usedRecently.ID])
+		})
+	})
+
+	t.Run("expiring tokens", func(t *testing.T) {
+		ctx := context.Background()
+
+		// Setup
+		now := time.Now()
+		expired := createTestToken(t, auth.RoleAdmin, nil)
+		expired.ExpireAt = now.Add(-time.Hour)
+		require.NoError(t, repo.Create(ctx, expired))
+		expiring := createTestToken(t, auth.RoleParticipant, &participant.ID)
+		expiring.ExpireAt = now.Add(24 * time.Hour)
+		require.NoError(t, repo.Create(ctx, expiring))
+		later := createTestToken(t, auth.RoleAdmin, nil)
+		later.ExpireAt = now.Add(30 * 24 * time.Hour)
+		require.NoError(t, repo.Create(ctx, later))
+		before := now.Add(7 * 24 * time.Hour)
+
+		ids := func(tokens []*domain.Token) map[properties.UUID]bool {
+			ids := make(map[properties.UUID]bool, len(tokens))
+			for _, token := range tokens {
+				ids[token.ID] = true
+			}
+			return ids
+		}
+
+		// FindExpiring reports the expired and the expiring tokens in the scope
+		tokens, err := repo.FindExpiring(ctx, nil, before)
+		require.NoError(t, err)
+		found := ids(tokens)
+		assert.True(t, found[expired.ID])
+		assert.True(t, found[expiring.ID])
+		assert.False(t, found[later.ID])
+
+		tokens, err = repo.FindExpiring(ctx, &auth.IdentityScope{ParticipantID: &participant.ID}, before)
+		require.NoError(t, err)
+		found = ids(tokens)
+		assert.False(t, found[expired.ID])
+		assert.True(t, found[expiring.ID])
+
+		// The tokens warned to be expired are no longer candidates
+		require.NoError(t, repo.UpdateExpiryNotice(ctx, expired.ID, expired.ExpireAt, domain.TokenExpiryNoticeExpired))
+		require.NoError(t, repo.UpdateExpiryNotice(ctx, expiring.ID, expiring.ExpireAt, domain.TokenExpiryNoticeExpiring))
+		tokens, err = repo.FindExpiryNoticeCandidates(ctx, before)
+		require.NoError(t, err)
+		found = ids(tokens)
+		assert.False(t, found[expired.ID])
+		assert.True(t, found[expiring.ID])
+		assert.False(t, found[later.ID])
+
+		// The notice is not recorded once the expiry changed
+		require.NoError(t, repo.UpdateExpiryNotice(ctx, later.ID, now, domain.TokenExpiryNoticeExpired))
+		stored, err := repo.Get(ctx, later.ID)
+		require.NoError(t, err)
+		assert.Equal(t, domain.TokenExpiryNoticeNone, stored.ExpiryNotice)
+	})
+
+	t.Run("ListHashCosts", func(t *testing.T) {
+		t.Run("success", func(t *testing.T) {
+			ctx := context.Background()
+
+			// Setup - a token at a custom cost and a legacy one
+			token := createTestToken(t, auth.RoleAdmin, nil)
+			require.NoError(t, token.GenerateTokenValue(2000))
+			require.NoError(t, repo.Create(ctx, token))
+			legacy := createTestToken(t, auth.RoleAdmin, nil)
+			legacy.HashedValue = domain.HashTokenValue(legacy.PlainValue)
+			legacy.HashCost = 0
+			require.NoError(t, repo.Create(ctx, legacy))
+
+			// Execute
+			costs, err := repo.ListHashCosts(ctx)
+
+			// Assert
+			require.NoError(t, err)
+			assert.Contains(t, costs, 2000)
+			assert.Contains(t, costs, 0)
+			assert.Contains(t, costs, domain.DefaultTokenHashCost)
+		})
+	})
+
+	t.Run("UpdateHash", func(t *testing.T) {
+		ctx := context.Background()
+		legacy := createTestToken(t, auth.RoleAdmin, nil)
+		legacy.HashedValue = domain.HashTokenValue(legacy.PlainValue)
+		legacy.HashCost = 0
+		require.NoError(t, repo.Create(ctx, legacy))
+
+		hashed, err := domain.HashTokenValueWithCost(legacy.PlainValue, 2000)
+		require.NoError(t, err)
+		require.NoError(t, repo.UpdateHash(ctx, legacy.ID, hashed, 2000))
+
+		found, err := repo.FindByHashedValue(ctx, hashed)
+		require.NoError(t, err)
+		assert.Equal(t, legacy.ID, found.ID)
+		assert.Equal(t, 2000, found.HashCost)
+		assert.Equal(t, legacy.Version, found.Version, "the version is left untouched")
+	})
+
 	t.Run("DeleteByAgentID", func(t *testing.T) {
 		t.Run("success - deletes tokens with matching agent ID", func(t *testing.T) {
 			ctx := context.Background()
@@ -383,7 +551,7 @@ func TestTokenRepository(t *testing.T) {
 				AgentID:       &agent.ID,
 				ParticipantID: &participant.ID, // Agent tokens need participant ID too
 			}
-			require.NoError(t, agentToken1.GenerateTokenValue())
+			require.NoError(t, agentToken1.GenerateTokenValue(domain.DefaultTokenHashCost))
 			require.NoError(t, repo.Create(ctx, agentToken1))
 
 			agentToken2 := &domain.Token{
@@ -394,7 +562,7 @@ func TestTokenRepository(t *testing.T) {
 				AgentID:       &agent.ID,
 				ParticipantID: &participant.ID,
 			}
-			require.NoError(t, agentToken2.GenerateTokenValue())
+			require.NoError(t, agentToken2.GenerateTokenValue(domain.DefaultTokenHashCost))
 			require.NoError(t, repo.Create(ctx, agentToken2))
 
 			// Create a token with a different role that shouldn't be affected
@@ -402,10 +570,11 @@ func TestTokenRepository(t *testing.T) {
 			require.NoError(t, repo.Create(ctx, otherToken))
 
 			// Execute
-			err := repo.DeleteByAgentID(ctx, agent.ID)
+			count, err := repo.DeleteByAgentID(ctx, agent.ID)
 
 			// Assert
 			require.NoError(t, err)
+			assert.GreaterOrEqual(t, count, int64(2))
 
 			// Verify agent tokens are deleted
 			_, err = repo.Get(ctx, agentToken1.ID)
@@ -433,7 +602,7 @@ func TestTokenRepository(t *testing.T) {
 				ExpireAt:      time.Now().Add(24 * time.Hour),
 				ParticipantID: &participant.ID,
 			}
-			require.NoError(t, participantToken1.GenerateTokenValue())
+			require.NoError(t, participantToken1.GenerateTokenValue(domain.DefaultTokenHashCost))
 			require.NoError(t, repo.Create(ctx, participantToken1))
 
 			participantToken2 := &domain.Token{
@@ -443,7 +612,7 @@ func TestTokenRepository(t *testing.T) {
 				ExpireAt:      time.Now().Add(24 * time.Hour),
 				ParticipantID: &participant.ID,
 			}
-			require.NoError(t, participantToken2.GenerateTokenValue())
+			require.NoError(t, participantToken2.GenerateTokenValue(domain.DefaultTokenHashCost))
 			require.NoError(t, repo.Create(ctx, participantToken2))
 
 			// Create a token with a different role that shouldn't be affected
@@ -451,10 +620,11 @@ func TestTokenRepository(t *testing.T) {
 			require.NoError(t, repo.Create(ctx, otherToken))
 
 			// Execute
-			err := repo.DeleteByParticipantID(ctx, participant.ID)
+			count, err := repo.DeleteByParticipantID(ctx, participant.ID)
 
 			// Assert
 			require.NoError(t, err)
+			assert.GreaterOrEqual(t, count, int64(2))
 
 			// Verify participant tokens are deleted
 			_, err = repo.Get(ctx, participantToken1.ID)
@@ -482,7 +652,7 @@ func TestTokenRepository(t *testing.T) {
 				ExpireAt:      time.Now().Add(24 * time.Hour),
 				ParticipantID: &participant.ID,
 			}
-			require.NoError(t, consumerToken1.GenerateTokenValue())
+			require.NoError(t, consumerToken1.GenerateTokenValue(domain.DefaultTokenHashCost))
 			require.NoError(t, repo.Create(ctx, consumerToken1))
 
 			consumerToken2 := &domain.Token{
@@ -492,7 +662,7 @@ func TestTokenRepository(t *testing.T) {
 				ExpireAt:      time.Now().Add(24 * time.Hour),
 				ParticipantID: &participant.ID,
 			}
-			require.NoError(t, consumerToken2.GenerateTokenValue())
+			require.NoError(t, consumerToken2.GenerateTokenValue(domain.DefaultTokenHashCost))
 			require.NoError(t, repo.Create(ctx, consumerToken2))
 
 			// Create a token with a different role that shouldn't be affected
@@ -500,10 +670,11 @@ func TestTokenRepository(t *testing.T) {
 			require.NoError(t, repo.Create(ctx, otherToken))
 
 			// Execute
-			err := repo.DeleteByParticipantID(ctx, participant.ID)
+			count, err := repo.DeleteByParticipantID(ctx, participant.ID)
 
 			// Assert
 			require.NoError(t, err)
+			assert.GreaterOrEqual(t, count, int64(2))
 
 			// Verify consumer tokens are deleted
 			_, err = repo.Get(ctx, consumerToken1.ID)
@@ -534,7 +705,7 @@ func TestTokenRepository(t *testing.T) {
 				ExpireAt:      time.Now().Add(24 * time.Hour),
 				ParticipantID: &participant.ID,
 			}
-			require.NoError(t, participantToken.GenerateTokenValue())
+			require.NoError(t, participantToken.GenerateTokenValue(domain.DefaultTokenHashCost))
 			require.NoError(t, repo.Create(ctx, participantToken))
 
 			// For auth scope tests, test different types of tokens
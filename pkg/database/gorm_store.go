@@ -184,84 +184,94 @@ func (s *GormStore) ServicePoolValueRepo() domain.ServicePoolValueRepository {
 
 // GormReadOnlyStore implements the domain.ReadOnlyStore interface using GORM
 type GormReadOnlyStore struct {
-	db *gorm.DB
+	db    *gorm.DB
+	reads *ReadSelector
 }
 
 // Check if GormReadOnlyStore implements the domain.ReadOnlyStore interface
 var _ domain.ReadOnlyStore = (*GormReadOnlyStore)(nil)
 
-func NewGormReadOnlyStore(db *gorm.DB) *GormReadOnlyStore {
-	return &GormReadOnlyStore{db: db}
+// NewGormReadOnlyStore creates the queriers reading with the read selector, from the primary when it is nil
+func NewGormReadOnlyStore(db *gorm.DB, reads *ReadSelector) *GormReadOnlyStore {
+	return &GormReadOnlyStore{db: db, reads: reads}
+}
+
+// withReads routes the reads of the repository with the read selector of the store
+func withReads[R interface{ setReadSelector(*ReadSelector) }](s *GormReadOnlyStore, repo R) R {
+	if s.reads != nil {
+		repo.setReadSelector(s.reads)
+	}
+	return repo
 }
 
 func (s *GormReadOnlyStore) AgentTypeQuerier() domain.AgentTypeQuerier {
-	return NewAgentTypeRepository(s.db)
+	return withReads(s, NewAgentTypeRepository(s.db))
 }
 
 func (s *GormReadOnlyStore) AgentQuerier() domain.AgentQuerier {
-	return NewAgentRepository(s.db)
+	return withReads(s, NewAgentRepository(s.db))
 }
 
 func (s *GormReadOnlyStore) ConfigPoolQuerier() domain.ConfigPoolQuerier {
-	return NewConfigPoolRepository(s.db)
+	return withReads(s, NewConfigPoolRepository(s.db))
 }
 
 func (s *GormReadOnlyStore) ConfigPoolValueQuerier() domain.ConfigPoolValueQuerier {
-	return NewConfigPoolValueRepository(s.db)
+	return withReads(s, NewConfigPoolValueRepository(s.db))
 }
 
 func (s *GormReadOnlyStore) TokenQuerier() domain.TokenQuerier {
-	return NewTokenRepository(s.db)
+	return withReads(s, NewTokenRepository(s.db))
 }
 
 func (s *GormReadOnlyStore) ServiceTypeQuerier() domain.ServiceTypeQuerier {
-	return NewServiceTypeRepository(s.db)
+	return withReads(s, NewServiceTypeRepository(s.db))
 }
 
 func (s *GormReadOnlyStore) EventQuerier() domain.EventQuerier {
-	return NewEventRepository(s.db)
+	return withReads(s, NewEventRepository(s.db))
 }
 
 func (s *GormReadOnlyStore) EventSubscriptionQuerier() domain.EventSubscriptionQuerier {
-	return NewEventSubscriptionRepository(s.db)
+	return withReads(s, NewEventSubscriptionRepository(s.db))
 }
 
 func (s *GormReadOnlyStore) MetricTypeQuerier() domain.MetricTypeQuerier {
-	return NewMetricTypeRepository(s.db)
+	return withReads(s, NewMetricTypeRepository(s.db))
 }
 
 func (s *GormReadOnlyStore) ParticipantQuerier() domain.ParticipantQuerier {
-	return NewParticipantRepository(s.db)
+	return withReads(s, NewParticipantRepository(s.db))
 }
 
 func (s *GormReadOnlyStore) ServiceGroupQuerier() domain.ServiceGroupQuerier {
-	return NewServiceGroupRepository(s.db)
+	return withReads(s, NewServiceGroupRepository(s.db))
 }
 
 func (s *GormReadOnlyStore) ServiceQuerier() domain.ServiceQuerier {
-	return NewServiceRepository(s.db)
+	return withReads(s, NewServiceRepository(s.db))
 }
 
 func (s *GormReadOnlyStore) JobQuerier() domain.JobQuerier {
-	return NewJobRepository(s.db)
+	return withReads(s, NewJobRepository(s.db))
 }
 
 func (s *GormReadOnlyStore) ServiceOptionTypeQuerier() domain.ServiceOptionTypeQuerier {
-	return NewServiceOptionTypeRepository(s.db)
+	return withReads(s, NewServiceOptionTypeRepository(s.db))
 }
 
 func (s *GormReadOnlyStore) ServiceOptionQuerier() domain.ServiceOptionQuerier {
-	return NewServiceOptionRepository(s.db)
+	return withReads(s, NewServiceOptionRepository(s.db))
 }
 
 func (s *GormReadOnlyStore) ServicePoolSetQuerier() domain.ServicePoolSetQuerier {
-	return NewServicePoolSetRepository(s.db)
+	return withReads(s, NewServicePoolSetRepository(s.db))
 }
 
 func (s *GormReadOnlyStore) ServicePoolQuerier() domain.ServicePoolQuerier {
-	return NewServicePoolRepository(s.db)
+	return withReads(s, NewServicePoolRepository(s.db))
 }
 
 func (s *GormReadOnlyStore) ServicePoolValueQuerier() domain.ServicePoolValueQuerier {
-	return NewServicePoolValueRepository(s.db)
+	return withReads(s, NewServicePoolValueRepository(s.db))
 }
@@ -156,7 +156,7 @@ func createTestToken(t *testing.T, role auth.Role, scopeID *properties.UUID) *do
 			token.AgentID = scopeID
 		}
 	}
-	err := token.GenerateTokenValue()
+	err := token.GenerateTokenValue(domain.DefaultTokenHashCost)
 	if err != nil {
 		t.Fatalf("Failed to generate token value: %v", err)
 	}
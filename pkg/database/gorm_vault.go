@@ -6,13 +6,15 @@ import (
 	"crypto/aes"
 	"crypto/cipher"
 	"crypto/rand"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"time"
 
 	"github.com/fulcrumproject/core/pkg/domain"
 	"github.com/fulcrumproject/core/pkg/schema"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // vaultSecret entity (private, internal to this file)
@@ -20,12 +22,26 @@ type vaultSecret struct {
 	domain.BaseEntity
 	Reference      string `gorm:"uniqueIndex;not null"`
 	EncryptedValue []byte `gorm:"not null"`
+	CurrentVersion int    `gorm:"not null;default:1"`
 }
 
 func (vaultSecret) TableName() string {
 	return "vault_secrets"
 }
 
+// vaultSecretVersion is a previous value of a rotated secret, accepted until ExpiresAt
+type vaultSecretVersion struct {
+	domain.BaseEntity
+	Reference      string    `gorm:"uniqueIndex:idx_vault_secret_version;not null"`
+	SecretVersion  int       `gorm:"uniqueIndex:idx_vault_secret_version;not null"`
+	EncryptedValue []byte    `gorm:"not null"`
+	ExpiresAt      time.Time `gorm:"index;not null"`
+}
+
+func (vaultSecretVersion) TableName() string {
+	return "vault_secret_versions"
+}
+
 // vaultEncryption handles AES-256-GCM encryption/decryption
 type vaultEncryption struct {
 	key []byte // 32 bytes for AES-256
@@ -88,35 +104,38 @@ func (ve *vaultEncryption) Decrypt(ciphertext []byte) ([]byte, error) {
 	return plaintext, nil
 }
 
-// gormVault implements schema.Vault interface
-type gormVault struct {
+// gormSecretBackend implements domain.SecretBackend storing encrypted secrets in Postgres
+type gormSecretBackend struct {
 	db         *gorm.DB
 	encryption *vaultEncryption
 }
 
-// NewVault creates a new vault instance
-func NewVault(db *gorm.DB, encryptionKey []byte) (schema.Vault, error) {
+// NewSecretBackend creates a secret backend encrypting the secrets stored in the vault_secrets table
+func NewSecretBackend(db *gorm.DB, encryptionKey []byte) (domain.SecretBackend, error) {
 	encryption, err := newVaultEncryption(encryptionKey)
 	if err != nil {
 		return nil, err
 	}
 
-	return &gormVault{
+	return &gormSecretBackend{
 		db:         db,
 		encryption: encryption,
 	}, nil
 }
 
-// Save stores a secret securely in the vault
-func (v *gormVault) Save(ctx context.Context, reference string, value any, metadata map[string]any) error {
-	// Serialize value to JSON
-	jsonBytes, err := json.Marshal(value)
+// NewVault creates a new vault instance backed by the database
+func NewVault(db *gorm.DB, encryptionKey []byte) (schema.Vault, error) {
+	backend, err := NewSecretBackend(db, encryptionKey)
 	if err != nil {
-		return fmt.Errorf("failed to serialize secret: %w", err)
+		return nil, err
 	}
+	return domain.NewSecretVault(backend), nil
+}
 
-	// Encrypt JSON bytes
-	encrypted, err := v.encryption.Encrypt(jsonBytes)
+// Put encrypts and stores a secret
+func (b *gormSecretBackend) Put(ctx context.Context, reference string, data []byte) error {
+	// Encrypt serialized value
+	encrypted, err := b.encryption.Encrypt(data)
 	if err != nil {
 		return fmt.Errorf("failed to encrypt secret: %w", err)
 	}
@@ -128,47 +147,130 @@ func (v *gormVault) Save(ctx context.Context, reference string, value any, metad
 	}
 
 	// Save to database
-	if err := v.db.WithContext(ctx).Create(secret).Error; err != nil {
+	if err := b.db.WithContext(ctx).Create(secret).Error; err != nil {
 		return fmt.Errorf("failed to save secret: %w", err)
 	}
 
 	return nil
 }
 
-// Get retrieves and decrypts a secret from the vault
-func (v *gormVault) Get(ctx context.Context, reference string) (any, error) {
+// Get retrieves and decrypts a secret
+func (b *gormSecretBackend) Get(ctx context.Context, reference string) ([]byte, error) {
 	// Get secret from database
 	var secret vaultSecret
-	if err := v.db.WithContext(ctx).Where("reference = ?", reference).First(&secret).Error; err != nil {
+	if err := b.db.WithContext(ctx).Where("reference = ?", reference).First(&secret).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
-			return nil, fmt.Errorf("secret not found: %s", reference)
+			return nil, domain.NewNotFoundErrorf("secret not found: %s", reference)
 		}
 		return nil, fmt.Errorf("failed to retrieve secret: %w", err)
 	}
 
-	// Decrypt to JSON bytes
-	jsonBytes, err := v.encryption.Decrypt(secret.EncryptedValue)
+	// Decrypt to serialized value
+	data, err := b.encryption.Decrypt(secret.EncryptedValue)
 	if err != nil {
 		return nil, fmt.Errorf("failed to decrypt secret: %w", err)
 	}
 
-	// Deserialize JSON
-	var value any
-	if err := json.Unmarshal(jsonBytes, &value); err != nil {
-		return nil, fmt.Errorf("failed to deserialize secret: %w", err)
+	return data, nil
+}
+
+// Delete permanently removes a secret and its previous versions
+func (b *gormSecretBackend) Delete(ctx context.Context, reference string) error {
+	return b.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("reference = ?", reference).Delete(&vaultSecret{})
+		if result.Error != nil {
+			return fmt.Errorf("failed to delete secret: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return domain.NewNotFoundErrorf("secret not found: %s", reference)
+		}
+		if err := tx.Where("reference = ?", reference).Delete(&vaultSecretVersion{}).Error; err != nil {
+			return fmt.Errorf("failed to delete secret versions: %w", err)
+		}
+		return nil
+	})
+}
+
+// Rotate moves the current value to the previous versions and stores the new one
+// The secret row is locked so concurrent rotations are applied one after the other
+func (b *gormSecretBackend) Rotate(ctx context.Context, reference string, data []byte, graceUntil time.Time, keep int) error {
+	encrypted, err := b.encryption.Encrypt(data)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt secret: %w", err)
 	}
 
-	return value, nil
+	return b.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var secret vaultSecret
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("reference = ?", reference).
+			First(&secret).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return domain.NewNotFoundErrorf("secret not found: %s", reference)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to retrieve secret: %w", err)
+		}
+
+		if keep > 0 {
+			previous := &vaultSecretVersion{
+				Reference:      reference,
+				SecretVersion:  secret.CurrentVersion,
+				EncryptedValue: secret.EncryptedValue,
+				ExpiresAt:      graceUntil,
+			}
+			if err := tx.Create(previous).Error; err != nil {
+				return fmt.Errorf("failed to save secret version: %w", err)
+			}
+		}
+		// Drop the versions beyond the retained ones
+		if err := tx.Where("reference = ? AND secret_version <= ?", reference, secret.CurrentVersion-keep).
+			Delete(&vaultSecretVersion{}).Error; err != nil {
+			return fmt.Errorf("failed to delete secret versions: %w", err)
+		}
+
+		if err := tx.Model(&secret).UpdateColumns(map[string]any{
+			"encrypted_value": encrypted,
+			"current_version": secret.CurrentVersion + 1,
+			"version":         secret.Version + 1,
+			"updated_at":      time.Now(),
+		}).Error; err != nil {
+			return fmt.Errorf("failed to save secret: %w", err)
+		}
+		return nil
+	})
 }
 
-// Delete permanently removes a secret from the vault
-func (v *gormVault) Delete(ctx context.Context, reference string) error {
-	result := v.db.WithContext(ctx).Where("reference = ?", reference).Delete(&vaultSecret{})
-	if result.Error != nil {
-		return fmt.Errorf("failed to delete secret: %w", result.Error)
+// GetValid returns the current value followed by the previous versions not expired yet, newest first
+func (b *gormSecretBackend) GetValid(ctx context.Context, reference string, at time.Time) ([][]byte, error) {
+	current, err := b.Get(ctx, reference)
+	if err != nil {
+		return nil, err
 	}
-	if result.RowsAffected == 0 {
-		return fmt.Errorf("secret not found: %s", reference)
+
+	var versions []vaultSecretVersion
+	if err := b.db.WithContext(ctx).
+		Where("reference = ? AND expires_at > ?", reference, at).
+		Order("secret_version DESC").
+		Find(&versions).Error; err != nil {
+		return nil, fmt.Errorf("failed to retrieve secret versions: %w", err)
 	}
-	return nil
+
+	result := [][]byte{current}
+	for _, version := range versions {
+		data, err := b.encryption.Decrypt(version.EncryptedValue)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decrypt secret version %d: %w", version.SecretVersion, err)
+		}
+		result = append(result, data)
+	}
+	return result, nil
+}
+
+// PurgeExpired deletes the previous versions whose grace period elapsed
+func (b *gormSecretBackend) PurgeExpired(ctx context.Context, at time.Time) (int, error) {
+	result := b.db.WithContext(ctx).Where("expires_at <= ?", at).Delete(&vaultSecretVersion{})
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to purge expired secret versions: %w", result.Error)
+	}
+	return int(result.RowsAffected), nil
 }
@@ -5,7 +5,9 @@ import (
 	"crypto/rand"
 	"encoding/hex"
 	"testing"
+	"time"
 
+	"github.com/fulcrumproject/core/pkg/domain"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -265,3 +267,69 @@ func TestVaultEncryptionDifferentKeys(t *testing.T) {
 	_, err = enc2.Decrypt(ciphertext)
 	assert.Error(t, err)
 }
+
+func TestSecretBackendRotate(t *testing.T) {
+	tdb := NewTestDB(t)
+	defer tdb.Cleanup(t)
+
+	key := make([]byte, 32)
+	rand.Read(key)
+	backend, err := NewSecretBackend(tdb.DB, key)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	now := time.Now().Truncate(time.Second)
+	refBytes := make([]byte, 16)
+	rand.Read(refBytes)
+	reference := hex.EncodeToString(refBytes)
+
+	require.NoError(t, backend.Put(ctx, reference, []byte(`"v1"`)))
+	require.NoError(t, backend.Rotate(ctx, reference, []byte(`"v2"`), now.Add(time.Hour), 2))
+	require.NoError(t, backend.Rotate(ctx, reference, []byte(`"v3"`), now.Add(2*time.Hour), 2))
+
+	current, err := backend.Get(ctx, reference)
+	require.NoError(t, err)
+	assert.Equal(t, []byte(`"v3"`), current)
+
+	valid, err := backend.GetValid(ctx, reference, now)
+	require.NoError(t, err)
+	assert.Equal(t, [][]byte{[]byte(`"v3"`), []byte(`"v2"`), []byte(`"v1"`)}, valid)
+
+	// Expired versions are no longer valid
+	valid, err = backend.GetValid(ctx, reference, now.Add(90*time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, [][]byte{[]byte(`"v3"`), []byte(`"v2"`)}, valid)
+
+	// Rotating again drops the oldest version beyond the retained ones
+	require.NoError(t, backend.Rotate(ctx, reference, []byte(`"v4"`), now.Add(3*time.Hour), 2))
+	valid, err = backend.GetValid(ctx, reference, now)
+	require.NoError(t, err)
+	assert.Equal(t, [][]byte{[]byte(`"v4"`), []byte(`"v3"`), []byte(`"v2"`)}, valid)
+
+	// Purge removes the expired versions only
+	count, err := backend.PurgeExpired(ctx, now.Add(90*time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+	valid, err = backend.GetValid(ctx, reference, now)
+	require.NoError(t, err)
+	assert.Equal(t, [][]byte{[]byte(`"v4"`), []byte(`"v3"`)}, valid)
+
+	// Deleting the secret removes its versions
+	require.NoError(t, backend.Delete(ctx, reference))
+	var remaining int64
+	require.NoError(t, tdb.DB.Model(&vaultSecretVersion{}).Where("reference = ?", reference).Count(&remaining).Error)
+	assert.Equal(t, int64(0), remaining)
+}
+
+func TestSecretBackendRotateNotFound(t *testing.T) {
+	tdb := NewTestDB(t)
+	defer tdb.Cleanup(t)
+
+	key := make([]byte, 32)
+	rand.Read(key)
+	backend, err := NewSecretBackend(tdb.DB, key)
+	require.NoError(t, err)
+
+	err = backend.Rotate(context.Background(), "nonexistent-reference", []byte(`"v2"`), time.Now(), 1)
+	assert.ErrorAs(t, err, &domain.NotFoundError{})
+}
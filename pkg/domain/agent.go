@@ -2,10 +2,12 @@ package domain
 
 import (
 	"context"
-	"errors"
 	"fmt"
+	"reflect"
+	"slices"
 	"time"
 
+	"github.com/fulcrumproject/core/pkg/auth"
 	"github.com/fulcrumproject/core/pkg/properties"
 	"github.com/fulcrumproject/core/pkg/schema"
 	"github.com/google/uuid"
@@ -16,6 +18,9 @@ const (
 	EventTypeAgentCreated EventType = "agent.created"
 	EventTypeAgentUpdated EventType = "agent.updated"
 	EventTypeAgentDeleted EventType = "agent.deleted"
+
+	EventTypeAgentDrainStarted EventType = "agent.drain_started"
+	EventTypeAgentDrainStopped EventType = "agent.drain_stopped"
 )
 
 // AgentStatus represents the possible statuss of an Agent
@@ -57,9 +62,27 @@ type Agent struct {
 	Status           AgentStatus `json:"status" gorm:"not null"`
 	LastStatusUpdate time.Time   `json:"lastStatusUpdate" gorm:"index"`
 
+	// Draining agents finish their current jobs but receive no new work
+	Draining bool `json:"draining" gorm:"not null;default:false"`
+
+	// Resource telemetry reported with the heartbeat, MaxServices zero means no limit
+	CPUUsage           float64 `json:"cpuUsage" gorm:"not null;default:0"`
+	MemUsage           float64 `json:"memUsage" gorm:"not null;default:0"`
+	ActiveServiceCount int     `json:"activeServiceCount" gorm:"not null;default:0"`
+	MaxServices        int     `json:"maxServices" gorm:"not null;default:0"`
+
+	// MaxConcurrentJobs caps the jobs the agent processes at once, nil means no limit and zero pauses assignment
+	MaxConcurrentJobs *int `json:"maxConcurrentJobs,omitempty"`
+
+	// Region the agent runs in, the services requiring a region are only placed on the agents of that region
+	Region string `json:"region" gorm:"not null;default:'';index"`
+
 	// Tags representing capabilities or certifications of this agent
 	Tags pq.StringArray `json:"tags" gorm:"type:text[]"`
 
+	// Capabilities narrows the ones of the agent type, empty means all of them
+	Capabilities pq.StringArray `json:"capabilities" gorm:"type:text[]"`
+
 	// Configuration stores instance-specific configuration parameters as JSON
 	Configuration *properties.JSON `json:"configuration,omitempty" gorm:"type:jsonb"`
 
@@ -75,14 +98,17 @@ type Agent struct {
 // NewAgent creates a new agent with proper validation
 func NewAgent(params CreateAgentParams) *Agent {
 	return &Agent{
-		Name:             params.Name,
-		Status:           AgentDisconnected,
-		LastStatusUpdate: time.Now(),
-		ProviderID:       params.ProviderID,
-		AgentTypeID:      params.AgentTypeID,
-		Tags:             pq.StringArray(params.Tags),
-		Configuration:    params.Configuration,
-		ServicePoolSetID: params.ServicePoolSetID,
+		Name:              params.Name,
+		Status:            AgentDisconnected,
+		LastStatusUpdate:  time.Now(),
+		ProviderID:        params.ProviderID,
+		AgentTypeID:       params.AgentTypeID,
+		Region:            params.Region,
+		Tags:              pq.StringArray(params.Tags),
+		Capabilities:      pq.StringArray(params.Capabilities),
+		Configuration:     params.Configuration,
+		ServicePoolSetID:  params.ServicePoolSetID,
+		MaxConcurrentJobs: params.MaxConcurrentJobs,
 	}
 }
 
@@ -112,6 +138,10 @@ func (a *Agent) Validate() error {
 		return fmt.Errorf("provider ID cannot be empty")
 	}
 
+	if len(a.Region) > 100 {
+		return fmt.Errorf("region exceeds maximum length of 100 characters")
+	}
+
 	for i, tag := range []string(a.Tags) {
 		if len(tag) == 0 {
 			return fmt.Errorf("tag at index %d cannot be empty", i)
@@ -121,15 +151,96 @@ func (a *Agent) Validate() error {
 		}
 	}
 
+	if err := ValidateMaxConcurrentJobs(a.MaxConcurrentJobs); err != nil {
+		return err
+	}
+
+	return ValidateCapabilities(a.Capabilities)
+}
+
+// NoMaxConcurrentJobs given to an update removes the job concurrency limit, as a nil limit leaves it unchanged
+const NoMaxConcurrentJobs = -1
+
+// ValidateMaxConcurrentJobs ensures a job concurrency limit is not negative
+func ValidateMaxConcurrentJobs(limit *int) error {
+	if limit != nil && *limit < 0 {
+		return fmt.Errorf("max concurrent jobs cannot be negative")
+	}
+	return nil
+}
+
+// updateMaxConcurrentJobs returns the job concurrency limit after an update, nil keeps the current one and NoMaxConcurrentJobs removes it
+func updateMaxConcurrentJobs(current, update *int) *int {
+	switch {
+	case update == nil:
+		return current
+	case *update == NoMaxConcurrentJobs:
+		return nil
+	default:
+		return update
+	}
+}
+
+// ValidateCapabilitiesFor ensures the agent only narrows capabilities advertised by its type
+func (a *Agent) ValidateCapabilitiesFor(agentType *AgentType) error {
+	for _, c := range a.Capabilities {
+		if !slices.Contains(agentType.Capabilities, c) {
+			return fmt.Errorf("capability %q is not advertised by agent type %s", c, agentType.Name)
+		}
+	}
+	return nil
+}
+
+// EffectiveCapabilities returns the capabilities of the agent, falling back to the ones of its type
+func (a *Agent) EffectiveCapabilities() []string {
+	if len(a.Capabilities) > 0 {
+		return a.Capabilities
+	}
+	if a.AgentType != nil {
+		return a.AgentType.Capabilities
+	}
 	return nil
 }
 
+// MissingCapabilities returns the required capabilities the agent does not satisfy
+func (a *Agent) MissingCapabilities(required []string) []string {
+	return MissingCapabilities(a.EffectiveCapabilities(), required)
+}
+
 // UpdateStatus updates the agent's status and last update timestamp
 func (a *Agent) UpdateStatus(newStatus AgentStatus) {
 	a.Status = newStatus
 	a.LastStatusUpdate = time.Now()
 }
 
+// UpdateTelemetry stores the resource telemetry reported by the agent
+func (a *Agent) UpdateTelemetry(telemetry AgentTelemetry) {
+	a.CPUUsage = telemetry.CPUUsage
+	a.MemUsage = telemetry.MemUsage
+	a.ActiveServiceCount = telemetry.ActiveServiceCount
+	a.MaxServices = telemetry.MaxServices
+}
+
+// HasCapacity reports whether the agent can accept another service
+func (a *Agent) HasCapacity() bool {
+	return a.MaxServices == 0 || a.ActiveServiceCount < a.MaxServices
+}
+
+// Load returns the combined CPU and memory usage of the agent
+func (a *Agent) Load() float64 {
+	return a.CPUUsage + a.MemUsage
+}
+
+// SetDraining marks the agent as draining or returns it to normal operation
+// Returns true if the flag changed
+func (a *Agent) SetDraining(draining bool) bool {
+	if a.Draining == draining {
+		return false
+	}
+	a.Draining = draining
+	return true
+}
+
 // UpdateHeartbeat updates the last status update timestamp without changing the status
 func (a *Agent) UpdateHeartbeat() {
 	a.LastStatusUpdate = time.Now()
@@ -143,7 +254,7 @@ func (a *Agent) RegisterMetadata(name *string) {
 }
 
 // Update updates the agent's fields
-func (a *Agent) Update(name *string, tags *[]string, configuration *properties.JSON, servicePoolSetID *properties.UUID) bool {
+func (a *Agent) Update(name *string, tags *[]string, capabilities *[]string, configuration *properties.JSON, servicePoolSetID *properties.UUID) bool {
 	updated := false
 
 	if name != nil {
@@ -156,6 +267,11 @@ func (a *Agent) Update(name *string, tags *[]string, configuration *properties.J
 		updated = true
 	}
 
+	if capabilities != nil {
+		a.Capabilities = pq.StringArray(*capabilities)
+		updated = true
+	}
+
 	if configuration != nil {
 		a.Configuration = configuration
 		updated = true
@@ -183,29 +299,66 @@ type AgentCommander interface {
 
 	// UpdateStatus updates the agent status and the related timestamp
 	UpdateStatus(ctx context.Context, params UpdateAgentStatusParams) (*Agent, error)
+
+	// SetDrain starts or stops draining an agent
+	SetDrain(ctx context.Context, id properties.UUID, draining bool) (*Agent, error)
 }
 
 type CreateAgentParams struct {
 	Name             string           `json:"name"`
 	ProviderID       properties.UUID  `json:"providerId"`
 	AgentTypeID      properties.UUID  `json:"agentTypeId"`
+	Region           string           `json:"region,omitempty"`
 	Tags             []string         `json:"tags"`
+	Capabilities     []string         `json:"capabilities,omitempty"`
 	Configuration    *properties.JSON `json:"configuration,omitempty"`
 	ServicePoolSetID *properties.UUID `json:"servicePoolSetId,omitempty"`
+	// MaxConcurrentJobs defaults to the limit of the agent type when nil
+	MaxConcurrentJobs *int `json:"maxConcurrentJobs,omitempty"`
 }
 
 type UpdateAgentParams struct {
 	ID               properties.UUID  `json:"id"`
 	Name             *string          `json:"name,omitempty"`
 	Status           *AgentStatus     `json:"status,omitempty"`
+	Region           *string          `json:"region,omitempty"`
 	Tags             *[]string        `json:"tags,omitempty"`
+	Capabilities     *[]string        `json:"capabilities,omitempty"`
 	Configuration    *properties.JSON `json:"configuration,omitempty"`
 	ServicePoolSetID *properties.UUID `json:"servicePoolSetId,omitempty"`
+	// MaxConcurrentJobs is left unchanged when nil and removed with NoMaxConcurrentJobs
+	MaxConcurrentJobs *int `json:"maxConcurrentJobs,omitempty"`
 }
 
 type UpdateAgentStatusParams struct {
-	ID     properties.UUID `json:"id"`
-	Status AgentStatus     `json:"status"`
+	ID        properties.UUID `json:"id"`
+	Status    AgentStatus     `json:"status"`
+	Telemetry *AgentTelemetry `json:"telemetry,omitempty"`
+}
+
+// AgentTelemetry holds the resource usage reported by an agent with its status
+type AgentTelemetry struct {
+	CPUUsage           float64 `json:"cpuUsage"`
+	MemUsage           float64 `json:"memUsage"`
+	ActiveServiceCount int     `json:"activeServiceCount"`
+	MaxServices        int     `json:"maxServices"`
+}
+
+// Validate ensures the telemetry values are within range
+func (t AgentTelemetry) Validate() error {
+	if t.CPUUsage < 0 || t.CPUUsage > 100 {
+		return fmt.Errorf("cpu usage must be between 0 and 100")
+	}
+	if t.MemUsage < 0 || t.MemUsage > 100 {
+		return fmt.Errorf("memory usage must be between 0 and 100")
+	}
+	if t.ActiveServiceCount < 0 {
+		return fmt.Errorf("active service count cannot be negative")
+	}
+	if t.MaxServices < 0 {
+		return fmt.Errorf("max services cannot be negative")
+	}
+	return nil
 }
 
 // agentCommander is the concrete implementation of AgentCommander
@@ -263,8 +416,27 @@ func (s *agentCommander) Create(
 	// Create and save
 	var agent *Agent
 	err = s.store.Atomic(ctx, func(store Store) error {
+		// The provider is locked so that concurrent registrations cannot exceed its quota
+		provider, err := store.ParticipantRepo().GetForUpdate(ctx, params.ProviderID)
+		if err != nil {
+			return err
+		}
+		if provider.MaxAgents != nil {
+			count, err := store.AgentRepo().CountByProvider(ctx, provider.ID)
+			if err != nil {
+				return err
+			}
+			if err := provider.CheckAgentQuota(count); err != nil {
+				return err
+			}
+		}
+
 		agent = NewAgent(params)
 		agent.ID = agentID
+		if agent.MaxConcurrentJobs == nil && agentType.MaxConcurrentJobs != nil {
+			limit := *agentType.MaxConcurrentJobs
+			agent.MaxConcurrentJobs = &limit
+		}
 
 		// Validate and process configuration against schema
 		if agent.Configuration != nil {
@@ -297,6 +469,9 @@ func (s *agentCommander) Create(
 		if err := agent.Validate(); err != nil {
 			return InvalidInputError{Err: err}
 		}
+		if err := agent.ValidateCapabilitiesFor(agentType); err != nil {
+			return InvalidInputError{Err: err}
+		}
 		if err := store.AgentRepo().Create(ctx, agent); err != nil {
 			return err
 		}
@@ -342,11 +517,24 @@ func (s *agentCommander) Update(ctx context.Context,
 		}
 	}
 
+	// The services placed on the agent stay in the region they were created in
+	if params.Region != nil && *params.Region != agent.Region {
+		count, err := s.store.ServiceRepo().CountByAgent(ctx, agent.ID)
+		if err != nil {
+			return nil, err
+		}
+		if count > 0 {
+			return nil, NewInvalidInputErrorf("cannot change the region of agent %s while it hosts %d services", agent.ID, count)
+		}
+		agent.Region = *params.Region
+	}
+
 	// Update and validate
-	if params.Status != nil {
+	if params.Status != nil && *params.Status != agent.Status {
 		agent.UpdateStatus(*params.Status)
 	}
-	agent.Update(params.Name, params.Tags, params.Configuration, params.ServicePoolSetID)
+	agent.Update(params.Name, params.Tags, params.Capabilities, params.Configuration, params.ServicePoolSetID)
+	agent.MaxConcurrentJobs = updateMaxConcurrentJobs(agent.MaxConcurrentJobs, params.MaxConcurrentJobs)
 
 	// Save and event
 	err = s.store.Atomic(ctx, func(store Store) error {
@@ -383,6 +571,14 @@ func (s *agentCommander) Update(ctx context.Context,
 		if err := agent.Validate(); err != nil {
 			return InvalidInputError{Err: err}
 		}
+		if err := agent.ValidateCapabilitiesFor(agentType); err != nil {
+			return InvalidInputError{Err: err}
+		}
+
+		// Updates repeating the current values save nothing
+		if reflect.DeepEqual(&beforeAgent, agent) {
+			return CheckExpectedVersion(ctx, agent.ID, agent.Version)
+		}
 
 		if err := store.AgentRepo().Save(ctx, agent); err != nil {
 			return err
@@ -417,10 +613,10 @@ func (s *agentCommander) Delete(ctx context.Context, id properties.UUID) error {
 			return err
 		}
 		if numOfServices > 0 {
-			return errors.New("cannot delete agent with associated services")
+			return NewConflictErrorf("cannot delete agent with associated services")
 		}
 
-		if err := store.TokenRepo().DeleteByAgentID(ctx, id); err != nil {
+		if _, err := store.TokenRepo().DeleteByAgentID(ctx, id); err != nil {
 			return err
 		}
 
@@ -477,6 +673,12 @@ func (s *agentCommander) UpdateStatus(ctx context.Context, params UpdateAgentSta
 
 	// Update and validate
 	agent.UpdateStatus(params.Status)
+	if params.Telemetry != nil {
+		if err := params.Telemetry.Validate(); err != nil {
+			return nil, InvalidInputError{Err: err}
+		}
+		agent.UpdateTelemetry(*params.Telemetry)
+	}
 	if err := agent.Validate(); err != nil {
 		return nil, InvalidInputError{Err: err}
 	}
@@ -502,6 +704,38 @@ func (s *agentCommander) UpdateStatus(ctx context.Context, params UpdateAgentSta
 	return agent, nil
 }
 
+func (s *agentCommander) SetDrain(ctx context.Context, id properties.UUID, draining bool) (*Agent, error) {
+	// Find it
+	agent, err := s.store.AgentRepo().Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !agent.SetDraining(draining) {
+		return agent, nil
+	}
+
+	eventType := EventTypeAgentDrainStopped
+	if draining {
+		eventType = EventTypeAgentDrainStarted
+	}
+
+	// Save and event
+	err = s.store.Atomic(ctx, func(store Store) error {
+		if err := store.AgentRepo().Save(ctx, agent); err != nil {
+			return err
+		}
+		eventEntry, err := NewEvent(eventType, WithInitiatorCtx(ctx), WithAgent(agent))
+		if err != nil {
+			return err
+		}
+		return store.EventRepo().Create(ctx, eventEntry)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return agent, nil
+}
+
 type AgentRepository interface {
 	AgentQuerier
 	BaseEntityRepository[Agent]
@@ -519,6 +753,12 @@ type AgentQuerier interface {
 	// CountByAgentType returns the number of agents for a specific agent type
 	CountByAgentType(ctx context.Context, agentTypeID properties.UUID) (int64, error)
 
+	// CountByAgentTypeAndStatus returns the number of agents grouped by agent type name and status
+	CountByAgentTypeAndStatus(ctx context.Context) ([]StatusCount, error)
+
+	// HealthSummary returns the health of the agents in scope matching the filters, ordered by name
+	HealthSummary(ctx context.Context, scope *auth.IdentityScope, filters map[string][]string) ([]AgentHealth, error)
+
 	// FindByServiceTypeAndTags finds agents that support a service type and have all required tags
 	FindByServiceTypeAndTags(ctx context.Context, serviceTypeID properties.UUID, tags []string) ([]*Agent, error)
 }
@@ -20,14 +20,16 @@ type AgentConfigContext struct {
 func buildAgentConfigValidatorRegistry() map[string]schema.PropertyValidator[AgentConfigContext] {
 	return map[string]schema.PropertyValidator[AgentConfigContext]{
 		// Generic validators from pkg/schema
-		"minLength": &schema.MinLengthValidator[AgentConfigContext]{},
-		"maxLength": &schema.MaxLengthValidator[AgentConfigContext]{},
-		"pattern":   &schema.PatternValidator[AgentConfigContext]{},
-		"enum":      &schema.EnumValidator[AgentConfigContext]{},
-		"min":       &schema.MinValidator[AgentConfigContext]{},
-		"max":       &schema.MaxValidator[AgentConfigContext]{},
-		"minItems":  &schema.MinItemsValidator[AgentConfigContext]{},
-		"maxItems":  &schema.MaxItemsValidator[AgentConfigContext]{},
+		"minLength":   &schema.MinLengthValidator[AgentConfigContext]{},
+		"maxLength":   &schema.MaxLengthValidator[AgentConfigContext]{},
+		"pattern":     &schema.PatternValidator[AgentConfigContext]{},
+		"format":      &schema.FormatValidator[AgentConfigContext]{},
+		"enum":        &schema.EnumValidator[AgentConfigContext]{},
+		"min":         &schema.MinValidator[AgentConfigContext]{},
+		"max":         &schema.MaxValidator[AgentConfigContext]{},
+		"minItems":    &schema.MinItemsValidator[AgentConfigContext]{},
+		"maxItems":    &schema.MaxItemsValidator[AgentConfigContext]{},
+		"uniqueItems": &schema.UniqueItemsValidator[AgentConfigContext]{},
 
 		// Note: NO SourceValidator or MutableValidator
 		// Agent config doesn't have different actors or lifecycle states
@@ -46,6 +48,7 @@ func buildAgentConfigAuthorizerRegistry() map[string]schema.Authorizer[AgentConf
 func buildAgentConfigSchemaValidatorRegistry() map[string]schema.SchemaValidator[AgentConfigContext] {
 	return map[string]schema.SchemaValidator[AgentConfigContext]{
 		"exactlyOne": &schema.ExactlyOneValidator[AgentConfigContext]{},
+		"compare":    &schema.CompareValidator[AgentConfigContext]{},
 	}
 }
 
@@ -52,6 +52,9 @@ func TestAgentConfigSchemaValidatorRegistry(t *testing.T) {
 	if _, ok := validators["exactlyOne"]; !ok {
 		t.Error("Expected exactlyOne schema validator to be registered")
 	}
+	if _, ok := validators["compare"]; !ok {
+		t.Error("Expected compare schema validator to be registered")
+	}
 }
 
 func TestAgentConfigGeneratorRegistry(t *testing.T) {
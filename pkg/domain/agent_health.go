@@ -0,0 +1,33 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/fulcrumproject/core/pkg/properties"
+)
+
+// AgentHealth is the health of an agent: its status, when it was last seen and its workload
+type AgentHealth struct {
+	ID       properties.UUID
+	Name     string
+	Status   AgentStatus
+	Draining bool
+	// LastSeenAt is the last status update or heartbeat of the agent, it is kept when
+	// the agent is marked as disconnected for missing its heartbeats
+	LastSeenAt     time.Time
+	ActiveJobs     int64 // Pending and processing jobs
+	ActiveServices int64 // Services not deleted
+}
+
+// Disconnected reports whether the agent is disconnected
+func (h AgentHealth) Disconnected() bool {
+	return h.Status == AgentDisconnected
+}
+
+// DisconnectedFor returns how long a disconnected agent has not been seen, zero for the other agents
+func (h AgentHealth) DisconnectedFor(now time.Time) time.Duration {
+	if !h.Disconnected() || h.LastSeenAt.IsZero() {
+		return 0
+	}
+	return max(now.Sub(h.LastSeenAt), 0)
+}
@@ -97,7 +97,7 @@ func TestAgentInstallTokenCommander_Create(t *testing.T) {
 		assert.NotNil(t, tok.BootstrapTokenID, "BootstrapTokenID should be set")
 		assert.Equal(t, createdToken.ID, *tok.BootstrapTokenID)
 		assert.Equal(t, auth.RoleAgent, createdToken.Role)
-		assert.Equal(t, HashTokenValue(tok.PlainBootstrapToken), createdToken.HashedValue)
+		assert.True(t, createdToken.VerifyTokenValue(tok.PlainBootstrapToken))
 		assert.WithinDuration(t, tok.ExpiresAt, createdToken.ExpireAt, time.Second)
 		// The persisted entity must match the returned one — same hash, same ID.
 		assert.Equal(t, tok.TokenHashed, created.TokenHashed)
@@ -0,0 +1,171 @@
+package domain
+
+import (
+	"bytes"
+	"cmp"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync/atomic"
+
+	"github.com/fulcrumproject/core/pkg/properties"
+)
+
+// AgentSelectionStrategy names the policy choosing the agent of the services created without one
+type AgentSelectionStrategy string
+
+// Supported agent selection strategies
+const (
+	AgentSelectionLeastLoaded AgentSelectionStrategy = "least-loaded"
+	AgentSelectionRoundRobin  AgentSelectionStrategy = "round-robin"
+	AgentSelectionBinPacking  AgentSelectionStrategy = "bin-packing"
+)
+
+// ErrNoSuitableAgent is wrapped by the error returned when no agent can host a service created without one
+var ErrNoSuitableAgent = errors.New("no suitable agent")
+
+// NewNoSuitableAgentError returns the error of a service type without any suitable agent having the tags
+func NewNoSuitableAgentError(serviceTypeID properties.UUID, tags []string) InvalidInputError {
+	if len(tags) == 0 {
+		return InvalidInputError{Err: fmt.Errorf("%w for service type %s", ErrNoSuitableAgent, serviceTypeID)}
+	}
+	return InvalidInputError{Err: fmt.Errorf("%w for service type %s with tags %v", ErrNoSuitableAgent, serviceTypeID, tags)}
+}
+
+// ErrNoAgentInRegion is wrapped by the error returned when no suitable agent runs in the region required by a service
+var ErrNoAgentInRegion = errors.New("no agent in region")
+
+// NewNoAgentInRegionError returns the error of a service required in a region without any of the suitable agents,
+// listing the regions of the suitable agents so that the caller can choose another one
+func NewNoAgentInRegionError(serviceTypeID properties.UUID, region string, suitable []*Agent) InvalidInputError {
+	var regions []string
+	for _, a := range suitable {
+		if a.Region != "" && !slices.Contains(regions, a.Region) {
+			regions = append(regions, a.Region)
+		}
+	}
+	slices.Sort(regions)
+	if len(regions) == 0 {
+		return InvalidInputError{Err: fmt.Errorf("%w %s for service type %s, the suitable agents have no region", ErrNoAgentInRegion, region, serviceTypeID)}
+	}
+	return InvalidInputError{Err: fmt.Errorf("%w %s for service type %s, suitable agents run in regions %s", ErrNoAgentInRegion, region, serviceTypeID, strings.Join(regions, ", "))}
+}
+
+// Validate ensures the agent selection strategy is supported
+func (s AgentSelectionStrategy) Validate() error {
+	switch s {
+	case AgentSelectionLeastLoaded, AgentSelectionRoundRobin, AgentSelectionBinPacking:
+		return nil
+	}
+	return fmt.Errorf("invalid agent selection strategy %q: must be %s, %s or %s", s, AgentSelectionLeastLoaded, AgentSelectionRoundRobin, AgentSelectionBinPacking)
+}
+
+// AgentSelector chooses the agent of a service created without one
+type AgentSelector interface {
+	// Select returns one of the candidates for the service, the candidates are never empty
+	// and all of them are connected, not draining, capable and have capacity
+	Select(candidates []*Agent, params CreateServiceParams) (*Agent, error)
+}
+
+// NewAgentSelector returns the selector implementing the strategy
+func NewAgentSelector(strategy AgentSelectionStrategy) (AgentSelector, error) {
+	switch strategy {
+	case AgentSelectionLeastLoaded:
+		return LeastLoadedAgentSelector{}, nil
+	case AgentSelectionRoundRobin:
+		return &RoundRobinAgentSelector{}, nil
+	case AgentSelectionBinPacking:
+		return BinPackingAgentSelector{}, nil
+	}
+	return nil, strategy.Validate()
+}
+
+// SuitableAgents returns the agents able to host a new service of the type: connected, not draining,
+// with spare capacity and all the capabilities required by the service type
+func SuitableAgents(agents []*Agent, serviceType *ServiceType) []*Agent {
+	var suitable []*Agent
+	for _, a := range agents {
+		if a.Status != AgentConnected || a.Draining || !a.HasCapacity() {
+			continue
+		}
+		if len(a.MissingCapabilities(serviceType.RequiredCapabilities)) > 0 {
+			continue
+		}
+		suitable = append(suitable, a)
+	}
+	return suitable
+}
+
+// AgentsInRegion returns the agents running in the region
+func AgentsInRegion(agents []*Agent, region string) []*Agent {
+	var inRegion []*Agent
+	for _, a := range agents {
+		if a.Region == region {
+			inRegion = append(inRegion, a)
+		}
+	}
+	return inRegion
+}
+
+// LeastLoadedAgentSelector spreads the services on the agents, it chooses the agent with the lowest
+// combined CPU and memory usage, then with the fewest active services
+type LeastLoadedAgentSelector struct{}
+
+func (LeastLoadedAgentSelector) Select(candidates []*Agent, _ CreateServiceParams) (*Agent, error) {
+	return slices.MinFunc(candidates, func(a, b *Agent) int {
+		if c := cmp.Compare(a.Load(), b.Load()); c != 0 {
+			return c
+		}
+		if c := cmp.Compare(a.ActiveServiceCount, b.ActiveServiceCount); c != 0 {
+			return c
+		}
+		return compareAgentIDs(a, b)
+	}), nil
+}
+
+// RoundRobinAgentSelector assigns the services to the agents in turn, ordered by ID
+// The turn is kept in memory, so each replica of the API rotates on its own
+type RoundRobinAgentSelector struct {
+	next atomic.Uint64
+}
+
+func (s *RoundRobinAgentSelector) Select(candidates []*Agent, _ CreateServiceParams) (*Agent, error) {
+	sorted := slices.SortedFunc(slices.Values(candidates), compareAgentIDs)
+	turn := s.next.Add(1) - 1
+	return sorted[turn%uint64(len(sorted))], nil
+}
+
+// BinPackingAgentSelector fills the agents before using other ones, it chooses the agent with the
+// fewest free service slots, agents without a service limit come last, then the most loaded one
+type BinPackingAgentSelector struct{}
+
+func (BinPackingAgentSelector) Select(candidates []*Agent, _ CreateServiceParams) (*Agent, error) {
+	return slices.MinFunc(candidates, func(a, b *Agent) int {
+		if c := compareFreeSlots(a, b); c != 0 {
+			return c
+		}
+		if c := cmp.Compare(b.Load(), a.Load()); c != 0 {
+			return c
+		}
+		return compareAgentIDs(a, b)
+	}), nil
+}
+
+// compareFreeSlots orders the agents by free service slots, the agents without a limit last
+func compareFreeSlots(a, b *Agent) int {
+	switch {
+	case a.MaxServices == 0 && b.MaxServices == 0:
+		return 0
+	case a.MaxServices == 0:
+		return 1
+	case b.MaxServices == 0:
+		return -1
+	}
+	return cmp.Compare(a.MaxServices-a.ActiveServiceCount, b.MaxServices-b.ActiveServiceCount)
+}
+
+// compareAgentIDs orders the agents by ID so the selections do not depend on the order of the candidates
+func compareAgentIDs(a, b *Agent) int {
+	return bytes.Compare(a.ID[:], b.ID[:])
+}
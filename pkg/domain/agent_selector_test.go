@@ -0,0 +1,166 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/fulcrumproject/core/pkg/properties"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewAgentSelector(t *testing.T) {
+	tests := []struct {
+		strategy AgentSelectionStrategy
+		expected AgentSelector
+	}{
+		{AgentSelectionLeastLoaded, LeastLoadedAgentSelector{}},
+		{AgentSelectionRoundRobin, &RoundRobinAgentSelector{}},
+		{AgentSelectionBinPacking, BinPackingAgentSelector{}},
+	}
+	for _, tc := range tests {
+		t.Run(string(tc.strategy), func(t *testing.T) {
+			selector, err := NewAgentSelector(tc.strategy)
+			require.NoError(t, err)
+			assert.IsType(t, tc.expected, selector)
+		})
+	}
+
+	_, err := NewAgentSelector("random")
+	assert.EqualError(t, err, `invalid agent selection strategy "random": must be least-loaded, round-robin or bin-packing`)
+}
+
+func TestSuitableAgents(t *testing.T) {
+	serviceType := &ServiceType{RequiredCapabilities: []string{"gpu", "!arm"}}
+	gpu := &AgentType{Capabilities: []string{"gpu"}}
+
+	suitable := &Agent{AgentType: gpu, Status: AgentConnected}
+	limited := &Agent{AgentType: gpu, Status: AgentConnected, MaxServices: 3, ActiveServiceCount: 2}
+	agents := []*Agent{
+		suitable,
+		limited,
+		{AgentType: gpu, Status: AgentDisconnected},
+		{AgentType: gpu, Status: AgentError},
+		{AgentType: gpu, Status: AgentConnected, Draining: true},
+		{AgentType: gpu, Status: AgentConnected, MaxServices: 2, ActiveServiceCount: 2},
+		{AgentType: &AgentType{}, Status: AgentConnected},
+		{AgentType: gpu, Capabilities: []string{"gpu", "arm"}, Status: AgentConnected},
+	}
+
+	assert.Equal(t, []*Agent{suitable, limited}, SuitableAgents(agents, serviceType))
+	assert.Empty(t, SuitableAgents(nil, serviceType))
+}
+
+// newSelectorAgent returns an agent with an ID ordered by n
+func newSelectorAgent(n byte, load float64, active, max int) *Agent {
+	var id properties.UUID
+	id[0] = n
+	return &Agent{
+		BaseEntity:         BaseEntity{ID: id},
+		CPUUsage:           load,
+		ActiveServiceCount: active,
+		MaxServices:        max,
+	}
+}
+
+func TestLeastLoadedAgentSelector(t *testing.T) {
+	tests := []struct {
+		name       string
+		candidates []*Agent
+		expected   byte
+	}{
+		{
+			name:       "lowest load",
+			candidates: []*Agent{newSelectorAgent(1, 60, 1, 0), newSelectorAgent(2, 20, 5, 0), newSelectorAgent(3, 40, 0, 0)},
+			expected:   2,
+		},
+		{
+			name:       "fewest services on the same load",
+			candidates: []*Agent{newSelectorAgent(1, 20, 4, 0), newSelectorAgent(2, 20, 1, 0)},
+			expected:   2,
+		},
+		{
+			name:       "lowest ID on a tie",
+			candidates: []*Agent{newSelectorAgent(2, 20, 1, 0), newSelectorAgent(1, 20, 1, 0)},
+			expected:   1,
+		},
+		{
+			name:       "single candidate",
+			candidates: []*Agent{newSelectorAgent(3, 90, 9, 10)},
+			expected:   3,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			agent, err := LeastLoadedAgentSelector{}.Select(tc.candidates, CreateServiceParams{})
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, agent.ID[0])
+		})
+	}
+}
+
+func TestRoundRobinAgentSelector(t *testing.T) {
+	a, b, c := newSelectorAgent(1, 0, 0, 0), newSelectorAgent(2, 0, 0, 0), newSelectorAgent(3, 0, 0, 0)
+	selector := &RoundRobinAgentSelector{}
+
+	var selected []byte
+	for range 4 {
+		// The order of the candidates does not change the rotation
+		agent, err := selector.Select([]*Agent{c, a, b}, CreateServiceParams{})
+		require.NoError(t, err)
+		selected = append(selected, agent.ID[0])
+	}
+	assert.Equal(t, []byte{1, 2, 3, 1}, selected)
+
+	// The turn goes on when the candidates change: the fifth selection among two agents is the first one
+	agent, err := selector.Select([]*Agent{a, b}, CreateServiceParams{})
+	require.NoError(t, err)
+	assert.Equal(t, a.ID, agent.ID)
+}
+
+func TestBinPackingAgentSelector(t *testing.T) {
+	tests := []struct {
+		name       string
+		candidates []*Agent
+		expected   byte
+	}{
+		{
+			name:       "fewest free slots",
+			candidates: []*Agent{newSelectorAgent(1, 10, 2, 10), newSelectorAgent(2, 10, 8, 10), newSelectorAgent(3, 10, 2, 3)},
+			expected:   3,
+		},
+		{
+			name:       "unlimited agents last",
+			candidates: []*Agent{newSelectorAgent(1, 90, 50, 0), newSelectorAgent(2, 10, 1, 100)},
+			expected:   2,
+		},
+		{
+			name:       "most loaded on the same free slots",
+			candidates: []*Agent{newSelectorAgent(1, 30, 0, 0), newSelectorAgent(2, 70, 0, 0)},
+			expected:   2,
+		},
+		{
+			name:       "lowest ID on a tie",
+			candidates: []*Agent{newSelectorAgent(2, 50, 1, 4), newSelectorAgent(1, 50, 1, 4)},
+			expected:   1,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			agent, err := BinPackingAgentSelector{}.Select(tc.candidates, CreateServiceParams{})
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, agent.ID[0])
+		})
+	}
+}
+
+func TestAgentSelectorsIgnoreCandidateOrder(t *testing.T) {
+	candidates := []*Agent{newSelectorAgent(1, 20, 1, 4), newSelectorAgent(2, 20, 1, 4), newSelectorAgent(3, 20, 1, 4)}
+	reversed := []*Agent{candidates[2], candidates[1], candidates[0]}
+	for _, selector := range []AgentSelector{LeastLoadedAgentSelector{}, BinPackingAgentSelector{}} {
+		first, err := selector.Select(candidates, CreateServiceParams{})
+		require.NoError(t, err)
+		second, err := selector.Select(reversed, CreateServiceParams{})
+		require.NoError(t, err)
+		assert.Equal(t, first.ID, second.ID)
+	}
+}
@@ -79,6 +79,7 @@ func TestAgentCommander_CreateWithConfiguration(t *testing.T) {
 
 			participantRepo := NewMockParticipantRepository(t)
 			participantRepo.On("Exists", mock.Anything, mock.Anything).Return(true, nil).Maybe()
+			participantRepo.On("GetForUpdate", mock.Anything, mock.Anything).Return(&Participant{}, nil).Maybe()
 			ms.On("ParticipantRepo").Return(participantRepo).Maybe()
 
 			agentTypeRepo := NewMockAgentTypeRepository(t)
@@ -301,6 +302,132 @@ func TestAgentCommander_UpdateWithConfiguration(t *testing.T) {
 	})
 }
 
+func TestAgentCommander_UpdateUnchanged(t *testing.T) {
+	ctx := auth.WithIdentity(context.Background(), &auth.Identity{ID: properties.UUID(uuid.New()), Role: auth.RoleAdmin})
+	agentTypeID := properties.UUID(uuid.New())
+	newAgent := func() *Agent {
+		return &Agent{
+			BaseEntity:       BaseEntity{ID: properties.UUID(uuid.New())},
+			Name:             "Test Agent",
+			AgentTypeID:      agentTypeID,
+			ProviderID:       properties.UUID(uuid.New()),
+			Tags:             []string{"eu"},
+			Status:           AgentConnected,
+			LastStatusUpdate: time.Now(),
+		}
+	}
+	name := "Test Agent"
+	tags := []string{"eu"}
+	status := AgentConnected
+
+	tests := []struct {
+		name   string
+		params func(id properties.UUID) UpdateAgentParams
+		saved  bool
+	}{
+		{
+			name: "repeating the current values saves nothing",
+			params: func(id properties.UUID) UpdateAgentParams {
+				return UpdateAgentParams{ID: id, Name: &name, Tags: &tags, Status: &status}
+			},
+		},
+		{
+			name: "a new value is saved",
+			params: func(id properties.UUID) UpdateAgentParams {
+				newName := "Renamed Agent"
+				return UpdateAgentParams{ID: id, Name: &newName, Tags: &tags}
+			},
+			saved: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			existing := newAgent()
+			ms := setupMockStore(t)
+			agentRepo := NewMockAgentRepository(t)
+			agentRepo.EXPECT().Get(mock.Anything, existing.ID).Return(existing, nil)
+			ms.EXPECT().AgentRepo().Return(agentRepo)
+			agentTypeRepo := NewMockAgentTypeRepository(t)
+			agentTypeRepo.EXPECT().Get(mock.Anything, agentTypeID).Return(&AgentType{BaseEntity: BaseEntity{ID: agentTypeID}}, nil)
+			ms.EXPECT().AgentTypeRepo().Return(agentTypeRepo)
+			if tt.saved {
+				agentRepo.EXPECT().Save(mock.Anything, existing).Return(nil)
+				eventRepo := NewMockEventRepository(t)
+				eventRepo.EXPECT().Create(mock.Anything, mock.Anything).Return(nil)
+				ms.EXPECT().EventRepo().Return(eventRepo)
+			}
+
+			commander := NewAgentCommander(ms, NewAgentConfigSchemaEngine(nil))
+			if _, err := commander.Update(ctx, tt.params(existing.ID)); err != nil {
+				t.Fatalf("Update() error = %v", err)
+			}
+		})
+	}
+}
+
+func TestAgentCommander_UpdateRegion(t *testing.T) {
+	ctx := auth.WithIdentity(context.Background(), &auth.Identity{ID: properties.UUID(uuid.New()), Role: auth.RoleAdmin})
+	agentTypeID := properties.UUID(uuid.New())
+	region := "eu-west"
+
+	tests := []struct {
+		name     string
+		services int64
+		wantErr  bool
+	}{
+		{name: "agent without services moves to the region", services: 0},
+		{name: "agent hosting services keeps its region", services: 2, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			existing := &Agent{
+				BaseEntity:       BaseEntity{ID: properties.UUID(uuid.New())},
+				Name:             "Test Agent",
+				AgentTypeID:      agentTypeID,
+				ProviderID:       properties.UUID(uuid.New()),
+				Region:           "us-east",
+				Status:           AgentConnected,
+				LastStatusUpdate: time.Now(),
+			}
+			ms := setupMockStore(t)
+			agentRepo := NewMockAgentRepository(t)
+			agentRepo.EXPECT().Get(mock.Anything, existing.ID).Return(existing, nil)
+			ms.EXPECT().AgentRepo().Return(agentRepo)
+			agentTypeRepo := NewMockAgentTypeRepository(t)
+			agentTypeRepo.EXPECT().Get(mock.Anything, agentTypeID).Return(&AgentType{BaseEntity: BaseEntity{ID: agentTypeID}}, nil)
+			ms.EXPECT().AgentTypeRepo().Return(agentTypeRepo)
+			serviceRepo := NewMockServiceRepository(t)
+			serviceRepo.EXPECT().CountByAgent(mock.Anything, existing.ID).Return(tt.services, nil)
+			ms.EXPECT().ServiceRepo().Return(serviceRepo)
+			if !tt.wantErr {
+				agentRepo.EXPECT().Save(mock.Anything, existing).Return(nil)
+				eventRepo := NewMockEventRepository(t)
+				eventRepo.EXPECT().Create(mock.Anything, mock.Anything).Return(nil)
+				ms.EXPECT().EventRepo().Return(eventRepo)
+			}
+
+			agent, err := NewAgentCommander(ms, NewAgentConfigSchemaEngine(nil)).Update(ctx, UpdateAgentParams{ID: existing.ID, Region: &region})
+			if tt.wantErr {
+				if !errors.As(err, &InvalidInputError{}) {
+					t.Fatalf("Update() error = %v, want an invalid input error", err)
+				}
+				if existing.Region != "us-east" {
+					t.Errorf("Region = %s, want us-east", existing.Region)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Update() error = %v", err)
+			}
+			if agent.Region != region {
+				t.Errorf("Region = %s, want %s", agent.Region, region)
+			}
+		})
+	}
+}
+
 func TestAgentCommander_ServicePoolSetValidation(t *testing.T) {
 	providerID := properties.UUID(uuid.New())
 	otherProviderID := properties.UUID(uuid.New())
@@ -322,6 +449,7 @@ func TestAgentCommander_ServicePoolSetValidation(t *testing.T) {
 
 		participantRepo := NewMockParticipantRepository(t)
 		participantRepo.On("Exists", mock.Anything, mock.Anything).Return(true, nil).Maybe()
+		participantRepo.On("GetForUpdate", mock.Anything, mock.Anything).Return(&Participant{}, nil).Maybe()
 		ms.On("ParticipantRepo").Return(participantRepo).Maybe()
 
 		agentTypeRepo := NewMockAgentTypeRepository(t)
@@ -365,6 +493,7 @@ func TestAgentCommander_ServicePoolSetValidation(t *testing.T) {
 
 		participantRepo := NewMockParticipantRepository(t)
 		participantRepo.On("Exists", mock.Anything, mock.Anything).Return(true, nil).Maybe()
+		participantRepo.On("GetForUpdate", mock.Anything, mock.Anything).Return(&Participant{}, nil).Maybe()
 		ms.On("ParticipantRepo").Return(participantRepo).Maybe()
 
 		agentTypeRepo := NewMockAgentTypeRepository(t)
@@ -535,6 +664,7 @@ func TestAgentCommander_CreateWithPoolGenerator(t *testing.T) {
 
 			participantRepo := NewMockParticipantRepository(t)
 			participantRepo.On("Exists", mock.Anything, mock.Anything).Return(true, nil).Maybe()
+			participantRepo.On("GetForUpdate", mock.Anything, mock.Anything).Return(&Participant{}, nil).Maybe()
 			ms.On("ParticipantRepo").Return(participantRepo).Maybe()
 
 			agentTypeRepo := NewMockAgentTypeRepository(t)
@@ -683,7 +813,7 @@ func TestAgentCommander_DeleteReleasesPoolValues(t *testing.T) {
 			ms.On("ServiceRepo").Return(serviceRepo).Maybe()
 
 			tokenRepo := NewMockTokenRepository(t)
-			tokenRepo.On("DeleteByAgentID", mock.Anything, agentID).Return(nil).Maybe()
+			tokenRepo.On("DeleteByAgentID", mock.Anything, agentID).Return(int64(0), nil).Maybe()
 			ms.On("TokenRepo").Return(tokenRepo).Maybe()
 
 			configPoolValueRepo := NewMockConfigPoolValueRepository(t)
@@ -745,7 +875,7 @@ func TestAgent_Update_ServicePoolSetID(t *testing.T) {
 			t.Error("Expected agent to have old ServicePoolSet association")
 		}
 
-		updated := agent.Update(nil, nil, nil, &newPoolSetID)
+		updated := agent.Update(nil, nil, nil, nil, &newPoolSetID)
 
 		if !updated {
 			t.Error("Expected Update() to return true")
@@ -773,7 +903,7 @@ func TestAgent_Update_ServicePoolSetID(t *testing.T) {
 			ServicePoolSet:   nil,
 		}
 
-		updated := agent.Update(nil, nil, nil, &newPoolSetID)
+		updated := agent.Update(nil, nil, nil, nil, &newPoolSetID)
 
 		if !updated {
 			t.Error("Expected Update() to return true")
@@ -786,3 +916,348 @@ func TestAgent_Update_ServicePoolSetID(t *testing.T) {
 		}
 	})
 }
+
+func TestAgentTelemetry_Validate(t *testing.T) {
+	tests := []struct {
+		name      string
+		telemetry AgentTelemetry
+		wantErr   bool
+	}{
+		{"valid", AgentTelemetry{CPUUsage: 40, MemUsage: 60, ActiveServiceCount: 2, MaxServices: 10}, false},
+		{"unlimited services", AgentTelemetry{ActiveServiceCount: 5}, false},
+		{"cpu above 100", AgentTelemetry{CPUUsage: 101}, true},
+		{"negative memory", AgentTelemetry{MemUsage: -1}, true},
+		{"negative active services", AgentTelemetry{ActiveServiceCount: -1}, true},
+		{"negative max services", AgentTelemetry{MaxServices: -1}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.telemetry.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestAgent_HasCapacity(t *testing.T) {
+	tests := []struct {
+		name     string
+		agent    Agent
+		expected bool
+	}{
+		{"no limit", Agent{ActiveServiceCount: 100}, true},
+		{"below limit", Agent{ActiveServiceCount: 2, MaxServices: 3}, true},
+		{"at limit", Agent{ActiveServiceCount: 3, MaxServices: 3}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.agent.HasCapacity(); got != tt.expected {
+				t.Errorf("HasCapacity() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestValidateMaxConcurrentJobs(t *testing.T) {
+	zero, negative := 0, -1
+	if err := ValidateMaxConcurrentJobs(nil); err != nil {
+		t.Errorf("no limit: unexpected error %v", err)
+	}
+	if err := ValidateMaxConcurrentJobs(&zero); err != nil {
+		t.Errorf("zero limit: unexpected error %v", err)
+	}
+	if err := ValidateMaxConcurrentJobs(&negative); err == nil {
+		t.Error("negative limit: expected an error")
+	}
+
+	agent := &Agent{Name: "a", Status: AgentConnected, LastStatusUpdate: time.Now(), AgentTypeID: uuid.New(), ProviderID: uuid.New(), MaxConcurrentJobs: &negative}
+	if err := agent.Validate(); err == nil || !strings.Contains(err.Error(), "max concurrent jobs") {
+		t.Errorf("Validate() = %v, want max concurrent jobs error", err)
+	}
+}
+
+func TestUpdateMaxConcurrentJobs(t *testing.T) {
+	current, limit, none, negative := 4, 2, NoMaxConcurrentJobs, -2
+
+	if got := updateMaxConcurrentJobs(&current, nil); got == nil || *got != current {
+		t.Errorf("nil update = %v, want the current limit", got)
+	}
+	if got := updateMaxConcurrentJobs(&current, &limit); got == nil || *got != limit {
+		t.Errorf("new limit = %v, want %d", got, limit)
+	}
+	if got := updateMaxConcurrentJobs(&current, &none); got != nil {
+		t.Errorf("NoMaxConcurrentJobs = %v, want no limit", *got)
+	}
+	if err := ValidateMaxConcurrentJobs(updateMaxConcurrentJobs(&current, &negative)); err == nil {
+		t.Error("other negative limits: expected a validation error")
+	}
+}
+
+func TestAgentCommander_CreateMaxConcurrentJobsDefault(t *testing.T) {
+	typeLimit, agentLimit := 4, 1
+
+	tests := []struct {
+		name      string
+		typeLimit *int
+		limit     *int
+		expected  *int
+	}{
+		{name: "no limit", expected: nil},
+		{name: "inherited from the agent type", typeLimit: &typeLimit, expected: &typeLimit},
+		{name: "agent overrides the agent type", typeLimit: &typeLimit, limit: &agentLimit, expected: &agentLimit},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ms := setupMockStore(t)
+			participantRepo := NewMockParticipantRepository(t)
+			agentTypeRepo := NewMockAgentTypeRepository(t)
+			agentRepo := NewMockAgentRepository(t)
+			eventRepo := NewMockEventRepository(t)
+			ms.EXPECT().ParticipantRepo().Return(participantRepo)
+			ms.EXPECT().AgentTypeRepo().Return(agentTypeRepo)
+			ms.EXPECT().AgentRepo().Return(agentRepo)
+			ms.EXPECT().EventRepo().Return(eventRepo)
+			participantRepo.EXPECT().Exists(mock.Anything, mock.Anything).Return(true, nil)
+			participantRepo.EXPECT().GetForUpdate(mock.Anything, mock.Anything).Return(&Participant{}, nil)
+			agentTypeRepo.EXPECT().Get(mock.Anything, mock.Anything).Return(&AgentType{Name: "vm", MaxConcurrentJobs: tt.typeLimit}, nil)
+			agentRepo.EXPECT().Create(mock.Anything, mock.Anything).Return(nil)
+			eventRepo.EXPECT().Create(mock.Anything, mock.Anything).Return(nil)
+
+			ctx := auth.WithIdentity(context.Background(), &auth.Identity{Role: auth.RoleAdmin, ID: properties.UUID(uuid.New())})
+			agent, err := NewAgentCommander(ms, nil).Create(ctx, CreateAgentParams{
+				Name:              "agent",
+				ProviderID:        uuid.New(),
+				AgentTypeID:       uuid.New(),
+				MaxConcurrentJobs: tt.limit,
+			})
+			if err != nil {
+				t.Fatalf("Create() unexpected error: %v", err)
+			}
+			if (tt.expected == nil) != (agent.MaxConcurrentJobs == nil) || (tt.expected != nil && *tt.expected != *agent.MaxConcurrentJobs) {
+				t.Errorf("MaxConcurrentJobs = %v, want %v", agent.MaxConcurrentJobs, tt.expected)
+			}
+		})
+	}
+}
+
+func TestAgentCommander_UpdateStatusWithTelemetry(t *testing.T) {
+	ctx := auth.WithIdentity(context.Background(), &auth.Identity{ID: properties.UUID(uuid.New()), Role: auth.RoleAgent})
+
+	newAgent := func() *Agent {
+		return &Agent{
+			BaseEntity:       BaseEntity{ID: properties.UUID(uuid.New())},
+			Name:             "Test Agent",
+			AgentTypeID:      properties.UUID(uuid.New()),
+			ProviderID:       properties.UUID(uuid.New()),
+			Status:           AgentDisconnected,
+			LastStatusUpdate: time.Now().Add(-time.Hour),
+		}
+	}
+
+	t.Run("stores telemetry", func(t *testing.T) {
+		existing := newAgent()
+		ms := setupMockStore(t)
+		agentRepo := NewMockAgentRepository(t)
+		agentRepo.EXPECT().Get(mock.Anything, existing.ID).Return(existing, nil)
+		agentRepo.EXPECT().Save(mock.Anything, existing).Return(nil)
+		ms.EXPECT().AgentRepo().Return(agentRepo)
+		eventRepo := NewMockEventRepository(t)
+		eventRepo.EXPECT().Create(mock.Anything, mock.Anything).Return(nil)
+		ms.EXPECT().EventRepo().Return(eventRepo)
+
+		commander := NewAgentCommander(ms, NewAgentConfigSchemaEngine(nil))
+		agent, err := commander.UpdateStatus(ctx, UpdateAgentStatusParams{
+			ID:     existing.ID,
+			Status: AgentConnected,
+			Telemetry: &AgentTelemetry{
+				CPUUsage:           35.5,
+				MemUsage:           50,
+				ActiveServiceCount: 3,
+				MaxServices:        10,
+			},
+		})
+		if err != nil {
+			t.Fatalf("UpdateStatus() error = %v", err)
+		}
+		if agent.Status != AgentConnected {
+			t.Errorf("Expected status %s, got %s", AgentConnected, agent.Status)
+		}
+		if agent.CPUUsage != 35.5 || agent.MemUsage != 50 || agent.ActiveServiceCount != 3 || agent.MaxServices != 10 {
+			t.Errorf("Unexpected telemetry %+v", agent)
+		}
+		if time.Since(agent.LastStatusUpdate) > time.Minute {
+			t.Errorf("Expected last status update to be refreshed, got %v", agent.LastStatusUpdate)
+		}
+	})
+
+	t.Run("invalid telemetry", func(t *testing.T) {
+		existing := newAgent()
+		ms := setupMockStore(t)
+		agentRepo := NewMockAgentRepository(t)
+		agentRepo.EXPECT().Get(mock.Anything, existing.ID).Return(existing, nil)
+		ms.EXPECT().AgentRepo().Return(agentRepo)
+
+		commander := NewAgentCommander(ms, NewAgentConfigSchemaEngine(nil))
+		_, err := commander.UpdateStatus(ctx, UpdateAgentStatusParams{
+			ID:        existing.ID,
+			Status:    AgentConnected,
+			Telemetry: &AgentTelemetry{CPUUsage: 150},
+		})
+		var invalidInput InvalidInputError
+		if !errors.As(err, &invalidInput) {
+			t.Errorf("Expected InvalidInputError, got %v", err)
+		}
+	})
+}
+
+func TestAgentCommander_SetDrain(t *testing.T) {
+	ctx := auth.WithIdentity(context.Background(), &auth.Identity{ID: properties.UUID(uuid.New()), Role: auth.RoleAdmin})
+
+	newAgent := func(draining bool) *Agent {
+		return &Agent{
+			BaseEntity:       BaseEntity{ID: properties.UUID(uuid.New())},
+			Name:             "Test Agent",
+			Status:           AgentConnected,
+			LastStatusUpdate: time.Now(),
+			Draining:         draining,
+		}
+	}
+
+	tests := []struct {
+		name          string
+		initial       bool
+		draining      bool
+		expectedEvent EventType
+	}{
+		{"start draining", false, true, EventTypeAgentDrainStarted},
+		{"stop draining", true, false, EventTypeAgentDrainStopped},
+		{"already draining", true, true, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			existing := newAgent(tt.initial)
+			ms := setupMockStore(t)
+			agentRepo := NewMockAgentRepository(t)
+			agentRepo.EXPECT().Get(mock.Anything, existing.ID).Return(existing, nil)
+			ms.EXPECT().AgentRepo().Return(agentRepo)
+			if tt.expectedEvent != "" {
+				agentRepo.EXPECT().Save(mock.Anything, existing).Return(nil)
+				eventRepo := NewMockEventRepository(t)
+				eventRepo.EXPECT().Create(mock.Anything, mock.MatchedBy(func(e *Event) bool {
+					return e.Type == tt.expectedEvent && e.InitiatorType == InitiatorTypeUser
+				})).Return(nil)
+				ms.EXPECT().EventRepo().Return(eventRepo)
+			}
+
+			commander := NewAgentCommander(ms, NewAgentConfigSchemaEngine(nil))
+			agent, err := commander.SetDrain(ctx, existing.ID, tt.draining)
+			if err != nil {
+				t.Fatalf("SetDrain() error = %v", err)
+			}
+			if agent.Draining != tt.draining {
+				t.Errorf("Expected draining %v, got %v", tt.draining, agent.Draining)
+			}
+		})
+	}
+
+	t.Run("agent not found", func(t *testing.T) {
+		ms := NewMockStore(t)
+		agentRepo := NewMockAgentRepository(t)
+		agentRepo.EXPECT().Get(mock.Anything, mock.Anything).Return(nil, NewNotFoundErrorf("agent not found"))
+		ms.EXPECT().AgentRepo().Return(agentRepo)
+
+		commander := NewAgentCommander(ms, NewAgentConfigSchemaEngine(nil))
+		if _, err := commander.SetDrain(ctx, properties.UUID(uuid.New()), true); err == nil {
+			t.Error("Expected error, got nil")
+		}
+	})
+}
+
+func TestAgentCommander_CreateAgentQuota(t *testing.T) {
+	limit := 2
+	tests := []struct {
+		name     string
+		agents   int64
+		exceeded bool
+	}{
+		{name: "under the quota", agents: 1},
+		{name: "quota reached", agents: 2, exceeded: true},
+		{name: "quota lowered below the usage", agents: 4, exceeded: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			providerID := properties.UUID(uuid.New())
+			ms := setupMockStore(t)
+			participantRepo := NewMockParticipantRepository(t)
+			agentTypeRepo := NewMockAgentTypeRepository(t)
+			agentRepo := NewMockAgentRepository(t)
+			ms.EXPECT().ParticipantRepo().Return(participantRepo)
+			ms.EXPECT().AgentTypeRepo().Return(agentTypeRepo)
+			ms.EXPECT().AgentRepo().Return(agentRepo)
+			participantRepo.EXPECT().Exists(mock.Anything, providerID).Return(true, nil)
+			participantRepo.EXPECT().GetForUpdate(mock.Anything, providerID).Return(&Participant{BaseEntity: BaseEntity{ID: providerID}, MaxAgents: &limit}, nil)
+			agentTypeRepo.EXPECT().Get(mock.Anything, mock.Anything).Return(&AgentType{Name: "vm"}, nil)
+			agentRepo.EXPECT().CountByProvider(mock.Anything, providerID).Return(tt.agents, nil)
+			if !tt.exceeded {
+				eventRepo := NewMockEventRepository(t)
+				ms.EXPECT().EventRepo().Return(eventRepo)
+				agentRepo.EXPECT().Create(mock.Anything, mock.Anything).Return(nil)
+				eventRepo.EXPECT().Create(mock.Anything, mock.Anything).Return(nil)
+			}
+
+			ctx := auth.WithIdentity(context.Background(), &auth.Identity{Role: auth.RoleAdmin, ID: properties.UUID(uuid.New())})
+			_, err := NewAgentCommander(ms, nil).Create(ctx, CreateAgentParams{
+				Name:        "agent",
+				ProviderID:  providerID,
+				AgentTypeID: uuid.New(),
+			})
+			if !tt.exceeded {
+				if err != nil {
+					t.Fatalf("Create() unexpected error: %v", err)
+				}
+				return
+			}
+			var quotaErr AgentQuotaExceededError
+			if !errors.As(err, &quotaErr) {
+				t.Fatalf("Create() error = %v, want AgentQuotaExceededError", err)
+			}
+			if quotaErr.ProviderID != providerID {
+				t.Errorf("ProviderID = %v, want %v", quotaErr.ProviderID, providerID)
+			}
+			if errors.As(err, &InvalidInputError{}) {
+				t.Errorf("the quota error must not be a validation error")
+			}
+		})
+	}
+}
+
+func TestAgentHealth_DisconnectedFor(t *testing.T) {
+	now := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	tests := []struct {
+		name     string
+		health   AgentHealth
+		expected time.Duration
+	}{
+		{"Disconnected", AgentHealth{Status: AgentDisconnected, LastSeenAt: now.Add(-7 * time.Minute)}, 7 * time.Minute},
+		{"Connected", AgentHealth{Status: AgentConnected, LastSeenAt: now.Add(-7 * time.Minute)}, 0},
+		{"Error", AgentHealth{Status: AgentError, LastSeenAt: now.Add(-7 * time.Minute)}, 0},
+		{"Never seen", AgentHealth{Status: AgentDisconnected}, 0},
+		{"Seen after now", AgentHealth{Status: AgentDisconnected, LastSeenAt: now.Add(time.Second)}, 0},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tc.health.DisconnectedFor(now); got != tc.expected {
+				t.Errorf("DisconnectedFor() = %v, want %v", got, tc.expected)
+			}
+			if got := tc.health.Disconnected(); got != (tc.health.Status == AgentDisconnected) {
+				t.Errorf("Disconnected() = %v for status %s", got, tc.health.Status)
+			}
+		})
+	}
+}
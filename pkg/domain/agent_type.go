@@ -6,6 +6,7 @@ import (
 
 	"github.com/fulcrumproject/core/pkg/properties"
 	"github.com/fulcrumproject/core/pkg/schema"
+	"github.com/lib/pq"
 )
 
 const (
@@ -23,6 +24,12 @@ type AgentType struct {
 	ConfigTemplate      string        `json:"configTemplate" gorm:"type:text"`
 	CmdTemplate         string        `json:"cmdTemplate" gorm:"type:text"`
 	ConfigContentType   string        `json:"configContentType" gorm:"type:text;not null;default:'text/plain'"`
+
+	// Capabilities advertised by the agents of this type
+	Capabilities pq.StringArray `json:"capabilities" gorm:"type:text[]"`
+
+	// MaxConcurrentJobs is the default job concurrency limit of new agents of this type, nil means no limit
+	MaxConcurrentJobs *int `json:"maxConcurrentJobs,omitempty"`
 }
 
 // NewAgentType creates a new agent type without validation
@@ -47,6 +54,8 @@ func NewAgentType(params CreateAgentTypeParams) *AgentType {
 		ConfigTemplate:      params.ConfigTemplate,
 		CmdTemplate:         params.CmdTemplate,
 		ConfigContentType:   configContentType,
+		Capabilities:        pq.StringArray(params.Capabilities),
+		MaxConcurrentJobs:   params.MaxConcurrentJobs,
 	}
 }
 
@@ -67,6 +76,12 @@ func (at *AgentType) Validate() error {
 	if at.Name == "" {
 		return fmt.Errorf("agent type name cannot be empty")
 	}
+	if err := ValidateCapabilities(at.Capabilities); err != nil {
+		return err
+	}
+	if err := ValidateMaxConcurrentJobs(at.MaxConcurrentJobs); err != nil {
+		return err
+	}
 	return at.validateTemplates()
 }
 
@@ -81,6 +96,13 @@ func (at *AgentType) ValidateWithEngine(engine *schema.Engine[AgentConfigContext
 		return fmt.Errorf("configurationSchema: %w", err)
 	}
 
+	if err := ValidateCapabilities(at.Capabilities); err != nil {
+		return err
+	}
+	if err := ValidateMaxConcurrentJobs(at.MaxConcurrentJobs); err != nil {
+		return err
+	}
+
 	return at.validateTemplates()
 }
 
@@ -114,6 +136,10 @@ func (at *AgentType) Update(params UpdateAgentTypeParams) {
 			at.ConfigContentType = "text/plain"
 		}
 	}
+	if params.Capabilities != nil {
+		at.Capabilities = pq.StringArray(*params.Capabilities)
+	}
+	at.MaxConcurrentJobs = updateMaxConcurrentJobs(at.MaxConcurrentJobs, params.MaxConcurrentJobs)
 }
 
 // AgentTypeCommander defines the interface for agent type command operations
@@ -135,6 +161,8 @@ type CreateAgentTypeParams struct {
 	ConfigTemplate      string            `json:"configTemplate,omitempty"`
 	CmdTemplate         string            `json:"cmdTemplate,omitempty"`
 	ConfigContentType   string            `json:"configContentType,omitempty"`
+	Capabilities        []string          `json:"capabilities,omitempty"`
+	MaxConcurrentJobs   *int              `json:"maxConcurrentJobs,omitempty"`
 }
 
 type UpdateAgentTypeParams struct {
@@ -145,6 +173,8 @@ type UpdateAgentTypeParams struct {
 	ConfigTemplate      *string            `json:"configTemplate,omitempty"`
 	CmdTemplate         *string            `json:"cmdTemplate,omitempty"`
 	ConfigContentType   *string            `json:"configContentType,omitempty"`
+	Capabilities        *[]string          `json:"capabilities,omitempty"`
+	MaxConcurrentJobs   *int               `json:"maxConcurrentJobs,omitempty"`
 }
 
 // agentTypeCommander is the concrete implementation of AgentTypeCommander
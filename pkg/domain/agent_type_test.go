@@ -518,6 +518,25 @@ func TestAgentType_Update(t *testing.T) {
 		}
 	})
 
+	t.Run("set and remove the job concurrency limit", func(t *testing.T) {
+		limit, none := 4, NoMaxConcurrentJobs
+
+		agentType.Update(UpdateAgentTypeParams{MaxConcurrentJobs: &limit})
+		if agentType.MaxConcurrentJobs == nil || *agentType.MaxConcurrentJobs != limit {
+			t.Errorf("Expected limit %d, got %v", limit, agentType.MaxConcurrentJobs)
+		}
+
+		agentType.Update(UpdateAgentTypeParams{})
+		if agentType.MaxConcurrentJobs == nil {
+			t.Error("Expected the limit to be kept when omitted")
+		}
+
+		agentType.Update(UpdateAgentTypeParams{MaxConcurrentJobs: &none})
+		if agentType.MaxConcurrentJobs != nil {
+			t.Errorf("Expected no limit, got %d", *agentType.MaxConcurrentJobs)
+		}
+	})
+
 	t.Run("update name only", func(t *testing.T) {
 		newName := "Updated Agent Name"
 		updateParams := UpdateAgentTypeParams{
@@ -19,6 +19,7 @@ type BaseEntity struct {
 	ID        properties.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
 	CreatedAt time.Time       `json:"-" gorm:"not null;default:CURRENT_TIMESTAMP"`
 	UpdatedAt time.Time       `json:"-" gorm:"not null;default:CURRENT_TIMESTAMP"`
+	Version   int             `json:"-" gorm:"not null;default:1"` // Bumped on every save
 }
 
 // GetID returns the entity's ID
@@ -26,6 +27,52 @@ func (b BaseEntity) GetID() properties.UUID {
 	return b.ID
 }
 
+// GetCreatedAt returns the entity's creation time
+func (b BaseEntity) GetCreatedAt() time.Time {
+	return b.CreatedAt
+}
+
+// GetVersion returns the entity's version
+func (b BaseEntity) GetVersion() int {
+	return b.Version
+}
+
+// SetVersion sets the entity's version, used by repositories when saving
+func (b *BaseEntity) SetVersion(version int) {
+	b.Version = version
+}
+
+type expectedVersionKey struct{}
+
+type expectedVersion struct {
+	id      properties.UUID
+	version int
+}
+
+// WithExpectedVersion returns a context requiring the entity with the given ID to be at version when saved
+func WithExpectedVersion(ctx context.Context, id properties.UUID, version int) context.Context {
+	return context.WithValue(ctx, expectedVersionKey{}, expectedVersion{id: id, version: version})
+}
+
+// ExpectedVersion returns the version the entity with the given ID must have when saved, if any
+func ExpectedVersion(ctx context.Context, id properties.UUID) (int, bool) {
+	ev, ok := ctx.Value(expectedVersionKey{}).(expectedVersion)
+	if !ok || ev.id != id {
+		return 0, false
+	}
+	return ev.version, true
+}
+
+// CheckExpectedVersion fails when the context requires another version of the entity
+// Used by updates that save nothing, as the repositories check the version only when saving
+func CheckExpectedVersion(ctx context.Context, id properties.UUID, version int) error {
+	expected, ok := ExpectedVersion(ctx, id)
+	if ok && expected != version {
+		return NewPreconditionFailedErrorf(version, "version %d does not match current version %d", expected, version)
+	}
+	return nil
+}
+
 // BaseEntityRepository defines the interface for the BaseEntity repository
 type BaseEntityRepository[T Entity] interface {
 	BaseEntityQuerier[T]
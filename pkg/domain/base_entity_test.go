@@ -1,6 +1,7 @@
 package domain
 
 import (
+	"context"
 	"testing"
 	"time"
 
@@ -44,3 +45,25 @@ func TestBaseEntity_GetID(t *testing.T) {
 		}
 	})
 }
+
+func TestExpectedVersion(t *testing.T) {
+	id := properties.NewUUID()
+
+	t.Run("Should not require a version by default", func(t *testing.T) {
+		if _, ok := ExpectedVersion(context.Background(), id); ok {
+			t.Errorf("ExpectedVersion should not be set on an empty context")
+		}
+	})
+
+	t.Run("Should return the version of the matching entity only", func(t *testing.T) {
+		ctx := WithExpectedVersion(context.Background(), id, 3)
+
+		version, ok := ExpectedVersion(ctx, id)
+		if !ok || version != 3 {
+			t.Errorf("ExpectedVersion returned (%d, %v), expected (3, true)", version, ok)
+		}
+		if _, ok := ExpectedVersion(ctx, properties.NewUUID()); ok {
+			t.Errorf("ExpectedVersion should not apply to other entities")
+		}
+	})
+}
@@ -0,0 +1,76 @@
+package domain
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// CapabilityExclusionPrefix marks a required capability that the agent must not advertise
+const CapabilityExclusionPrefix = "!"
+
+// ValidateCapabilities ensures a list of advertised capabilities is well formed
+func ValidateCapabilities(capabilities []string) error {
+	seen := make(map[string]bool, len(capabilities))
+	for i, c := range capabilities {
+		if c == "" {
+			return fmt.Errorf("capability at index %d cannot be empty", i)
+		}
+		if len(c) > 100 {
+			return fmt.Errorf("capability at index %d exceeds maximum length of 100 characters", i)
+		}
+		if strings.HasPrefix(c, CapabilityExclusionPrefix) {
+			return fmt.Errorf("capability %q cannot start with %q", c, CapabilityExclusionPrefix)
+		}
+		if seen[c] {
+			return fmt.Errorf("duplicate capability %q", c)
+		}
+		seen[c] = true
+	}
+	return nil
+}
+
+// ValidateRequiredCapabilities ensures a list of required capabilities is well formed and non-contradictory
+// A requirement prefixed with "!" excludes agents advertising that capability
+func ValidateRequiredCapabilities(required []string) error {
+	include, exclude := SplitRequiredCapabilities(required)
+	if err := ValidateCapabilities(include); err != nil {
+		return err
+	}
+	if err := ValidateCapabilities(exclude); err != nil {
+		return err
+	}
+	for _, c := range include {
+		if slices.Contains(exclude, c) {
+			return fmt.Errorf("capability %q cannot be both required and excluded", c)
+		}
+	}
+	return nil
+}
+
+// SplitRequiredCapabilities separates the capabilities an agent must advertise from the ones it must not
+func SplitRequiredCapabilities(required []string) (include []string, exclude []string) {
+	for _, c := range required {
+		if name, ok := strings.CutPrefix(c, CapabilityExclusionPrefix); ok {
+			exclude = append(exclude, name)
+		} else {
+			include = append(include, c)
+		}
+	}
+	return include, exclude
+}
+
+// MissingCapabilities returns the requirements not satisfied by the advertised capabilities
+func MissingCapabilities(capabilities []string, required []string) []string {
+	var missing []string
+	for _, r := range required {
+		if name, ok := strings.CutPrefix(r, CapabilityExclusionPrefix); ok {
+			if slices.Contains(capabilities, name) {
+				missing = append(missing, r)
+			}
+		} else if !slices.Contains(capabilities, r) {
+			missing = append(missing, r)
+		}
+	}
+	return missing
+}
@@ -0,0 +1,69 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateCapabilities(t *testing.T) {
+	tests := []struct {
+		name         string
+		capabilities []string
+		wantErr      bool
+	}{
+		{"empty list", nil, false},
+		{"valid", []string{"gpu", "ssd"}, false},
+		{"empty capability", []string{"gpu", ""}, true},
+		{"duplicate", []string{"gpu", "gpu"}, true},
+		{"exclusion prefix", []string{"!gpu"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateCapabilities(tt.capabilities)
+			assert.Equal(t, tt.wantErr, err != nil, "error = %v", err)
+		})
+	}
+}
+
+func TestValidateRequiredCapabilities(t *testing.T) {
+	tests := []struct {
+		name     string
+		required []string
+		wantErr  bool
+	}{
+		{"empty list", nil, false},
+		{"required and excluded", []string{"gpu", "!shared"}, false},
+		{"contradictory", []string{"gpu", "!gpu"}, true},
+		{"duplicate exclusion", []string{"!gpu", "!gpu"}, true},
+		{"empty exclusion", []string{"!"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateRequiredCapabilities(tt.required)
+			assert.Equal(t, tt.wantErr, err != nil, "error = %v", err)
+		})
+	}
+}
+
+func TestMissingCapabilities(t *testing.T) {
+	assert.Empty(t, MissingCapabilities([]string{"gpu", "ssd"}, []string{"gpu", "!shared"}))
+	assert.Equal(t, []string{"gpu", "!shared"}, MissingCapabilities([]string{"shared"}, []string{"gpu", "!shared"}))
+	assert.Empty(t, MissingCapabilities(nil, nil))
+}
+
+func TestAgent_EffectiveCapabilities(t *testing.T) {
+	agentType := &AgentType{Capabilities: []string{"gpu", "ssd"}}
+
+	inheriting := &Agent{AgentType: agentType}
+	assert.Equal(t, []string{"gpu", "ssd"}, inheriting.EffectiveCapabilities())
+
+	narrowed := &Agent{AgentType: agentType, Capabilities: []string{"ssd"}}
+	assert.Equal(t, []string{"ssd"}, narrowed.EffectiveCapabilities())
+	assert.Equal(t, []string{"gpu"}, narrowed.MissingCapabilities([]string{"gpu"}))
+
+	assert.NoError(t, narrowed.ValidateCapabilitiesFor(agentType))
+	assert.Error(t, (&Agent{Capabilities: []string{"fpga"}}).ValidateCapabilitiesFor(agentType))
+}
@@ -2,6 +2,8 @@ package domain
 
 import (
 	"fmt"
+
+	"github.com/fulcrumproject/core/pkg/properties"
 )
 
 type NotFoundError struct {
@@ -67,3 +69,78 @@ func (e ConflictError) Error() string {
 func (e ConflictError) Unwrap() error {
 	return e.Err
 }
+
+// PreconditionFailedError reports a stale write, CurrentVersion is the stored version of the entity
+type PreconditionFailedError struct {
+	Err            error
+	CurrentVersion int
+}
+
+func NewPreconditionFailedErrorf(currentVersion int, format string, a ...any) PreconditionFailedError {
+	return PreconditionFailedError{Err: fmt.Errorf(format, a...), CurrentVersion: currentVersion}
+}
+
+func (e PreconditionFailedError) Error() string {
+	return fmt.Sprintf("precondition failed: %v", e.Err)
+}
+
+func (e PreconditionFailedError) Unwrap() error {
+	return e.Err
+}
+
+// PoolExhaustedError reports that a pool has no unallocated value left
+type PoolExhaustedError struct {
+	PoolID properties.UUID
+}
+
+func NewPoolExhaustedError(poolID properties.UUID) PoolExhaustedError {
+	return PoolExhaustedError{PoolID: poolID}
+}
+
+func (e PoolExhaustedError) Error() string {
+	return fmt.Sprintf("pool exhausted: no available values in pool %s", e.PoolID)
+}
+
+// AgentQuotaExceededError reports that a provider already has the maximum number of agents it can register
+type AgentQuotaExceededError struct {
+	ProviderID properties.UUID
+	MaxAgents  int
+}
+
+func NewAgentQuotaExceededError(providerID properties.UUID, maxAgents int) AgentQuotaExceededError {
+	return AgentQuotaExceededError{ProviderID: providerID, MaxAgents: maxAgents}
+}
+
+func (e AgentQuotaExceededError) Error() string {
+	return fmt.Sprintf("agent quota exceeded: provider %s cannot register more than %d agents", e.ProviderID, e.MaxAgents)
+}
+
+// MaintenanceError reports that a service is in maintenance and accepts no transition or update until it is cleared
+type MaintenanceError struct {
+	ServiceID properties.UUID
+}
+
+func NewMaintenanceError(serviceID properties.UUID) MaintenanceError {
+	return MaintenanceError{ServiceID: serviceID}
+}
+
+func (e MaintenanceError) Error() string {
+	return fmt.Sprintf("service %s is in maintenance", e.ServiceID)
+}
+
+// SecretBackendUnavailableError reports that the secret backend could not be reached, the operation can be retried
+type SecretBackendUnavailableError struct {
+	Err error
+}
+
+func NewSecretBackendUnavailableErrorf(format string, a ...any) SecretBackendUnavailableError {
+	return SecretBackendUnavailableError{Err: fmt.Errorf(format, a...)}
+}
+
+func (e SecretBackendUnavailableError) Error() string {
+	return fmt.Sprintf("secret backend unavailable: %v", e.Err)
+}
+
+func (e SecretBackendUnavailableError) Unwrap() error {
+	return e.Err
+}
@@ -198,6 +198,23 @@ func TestUnauthorizedError(t *testing.T) {
 	})
 }
 
+func TestPreconditionFailedError(t *testing.T) {
+	err := NewPreconditionFailedErrorf(4, "version %d does not match current version %d", 2, 4)
+	if err.CurrentVersion != 4 {
+		t.Errorf("Expected CurrentVersion to be 4, got %d", err.CurrentVersion)
+	}
+	expectedErrMsg := "precondition failed: version 2 does not match current version 4"
+	if err.Error() != expectedErrMsg {
+		t.Errorf("Expected err.Error() to be %q, got %q", expectedErrMsg, err.Error())
+	}
+
+	wrapped := fmt.Errorf("save failed: %w", err)
+	var target PreconditionFailedError
+	if !errors.As(wrapped, &target) || target.CurrentVersion != 4 {
+		t.Errorf("Expected errors.As to find the PreconditionFailedError with its version")
+	}
+}
+
 func TestErrorChaining(t *testing.T) {
 	// Test error wrapping and unwrapping through multiple levels
 	t.Run("Error wrapping chain", func(t *testing.T) {
@@ -8,7 +8,9 @@ import (
 
 	"github.com/fulcrumproject/core/pkg/auth"
 	"github.com/fulcrumproject/core/pkg/properties"
+	"github.com/fulcrumproject/core/pkg/tracing"
 	"github.com/wI2L/jsondiff"
+	"gorm.io/gorm"
 )
 
 // InitiatorType defines the type of actor that initiated the event
@@ -27,8 +29,8 @@ const (
 type Event struct {
 	BaseEntity
 
-	// For strict ordering of events
-	SequenceNumber int64 `json:"sequenceNumber" gorm:"autoIncrement;uniqueIndex;not null"`
+	// For strict ordering of events, assigned by the database in the creating transaction without gaps
+	SequenceNumber int64 `json:"sequenceNumber" gorm:"default:next_event_sequence();uniqueIndex;not null"`
 
 	InitiatorType InitiatorType `gorm:"not null"`
 	InitiatorID   string        `gorm:"not null"`
@@ -39,6 +41,9 @@ type Event struct {
 	// Target entity ID for the event
 	EntityID *properties.UUID `gorm:"index"`
 
+	// Request, agent call or worker run that caused the event, see BeforeCreate
+	RequestID string `gorm:"type:varchar(128);index"`
+
 	// Optional IDs for related entities and filtering
 	ParticipantID *properties.UUID `gorm:"type:uuid"`
 	Participant   *Participant     `json:"participant,omitempty" gorm:"foreignKey:ParticipantID"`
@@ -50,6 +55,15 @@ type Event struct {
 	Consumer      *Participant     `json:"consumer,omitempty" gorm:"foreignKey:ConsumerID"`
 }
 
+// BeforeCreate records the request ID of the context the event is created with,
+// so the audit trail of an action can be followed from the request that caused it
+func (e *Event) BeforeCreate(tx *gorm.DB) error {
+	if e.RequestID == "" {
+		e.RequestID = tracing.RequestID(tx.Statement.Context)
+	}
+	return nil
+}
+
 // EventOption defines a function that configures an EventEntry
 type EventOption func(*Event) error
 
@@ -224,6 +238,12 @@ func WithDiff(beforeEntity, afterEntity any) EventOption {
 			return fmt.Errorf("failed to generate diff: %w", err)
 		}
 
+		// Secrets and tokens must never be persisted in the events
+		sensitive := append(entitySensitivePaths(beforeEntity), entitySensitivePaths(afterEntity)...)
+		if err := redactPatch(patch, sensitive); err != nil {
+			return fmt.Errorf("failed to redact diff: %w", err)
+		}
+
 		e.Payload = properties.JSON{
 			"diff": patch,
 		}
@@ -273,8 +293,19 @@ type EventRepository interface {
 type EventQuerier interface {
 	BaseEntityQuerier[Event]
 
-	// ListFromSequence retrieves events starting from a specific sequence number
-	ListFromSequence(ctx context.Context, fromSequenceNumber int64, limit int) ([]*Event, error)
+	// ListFromSequence retrieves the events matching the filter starting from a specific sequence number
+	ListFromSequence(ctx context.Context, fromSequenceNumber int64, filter EventFilter, limit int) ([]*Event, error)
+
+	// ListScopedFromSequence retrieves the events visible to the identity scope after a sequence number,
+	// restricted to the given types when not empty
+	ListScopedFromSequence(ctx context.Context, scope *auth.IdentityScope, fromSequenceNumber int64, types []EventType, limit int) ([]*Event, error)
+
+	// ListScopedInTimeRange retrieves the events visible to the identity scope after a sequence number,
+	// created from the inclusive start to the exclusive end of the range when set
+	ListScopedInTimeRange(ctx context.Context, scope *auth.IdentityScope, fromSequenceNumber int64, start *time.Time, end *time.Time, limit int) ([]*Event, error)
+
+	// LastSequenceNumber returns the sequence number of the most recent event, 0 when there are none
+	LastSequenceNumber(ctx context.Context) (int64, error)
 
 	// ServiceUptime returns the uptime and downtime in seconds of a service in a time range
 	ServiceUptime(ctx context.Context, serviceID properties.UUID, start time.Time, end time.Time) (uptimeSeconds uint64, downtimeSeconds uint64, err error)
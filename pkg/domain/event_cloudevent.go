@@ -0,0 +1,116 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/fulcrumproject/core/pkg/properties"
+)
+
+// EventFormat defines the shape in which events are delivered to consumers
+type EventFormat string
+
+// Supported event formats
+const (
+	EventFormatNative      EventFormat = "native"
+	EventFormatCloudEvents EventFormat = "cloudevents"
+)
+
+const (
+	// CloudEventsContentType is the media type of a single event in the CloudEvents structured JSON mode
+	CloudEventsContentType = "application/cloudevents+json"
+	// CloudEventsSpecVersion is the version of the CloudEvents specification the events follow
+	CloudEventsSpecVersion = "1.0"
+	// CloudEventTypePrefix namespaces the event types in the CloudEvents type attribute
+	CloudEventTypePrefix = "fulcrum."
+	// CloudEventSourceRoot is the source of the events not related to a participant
+	CloudEventSourceRoot = "/fulcrum"
+)
+
+// Validate ensures the event format is supported
+func (f EventFormat) Validate() error {
+	switch f {
+	case EventFormatNative, EventFormatCloudEvents:
+		return nil
+	}
+	return fmt.Errorf("invalid event format %q: must be %s or %s", f, EventFormatNative, EventFormatCloudEvents)
+}
+
+// ParseEventFormat parses and validates an event format
+func ParseEventFormat(value string) (EventFormat, error) {
+	f := EventFormat(value)
+	return f, f.Validate()
+}
+
+// CloudEvent is an event in the CloudEvents 1.0 structured JSON format
+type CloudEvent struct {
+	SpecVersion     string         `json:"specversion"`
+	ID              string         `json:"id"`
+	Type            string         `json:"type"`
+	Source          string         `json:"source"`
+	Subject         string         `json:"subject,omitempty"`
+	Time            time.Time      `json:"time"`
+	DataContentType string         `json:"datacontenttype"`
+	Sequence        string         `json:"sequence"` // Sequence extension
+	Data            CloudEventData `json:"data"`
+}
+
+// CloudEventData carries the event fields that have no CloudEvents attribute
+type CloudEventData struct {
+	InitiatorType InitiatorType    `json:"initiatorType"`
+	InitiatorID   string           `json:"initiatorId"`
+	EntityID      *properties.UUID `json:"entityId,omitempty"`
+	ProviderID    *properties.UUID `json:"providerId,omitempty"`
+	AgentID       *properties.UUID `json:"agentId,omitempty"`
+	ConsumerID    *properties.UUID `json:"consumerId,omitempty"`
+	RequestID     string           `json:"requestId,omitempty"`
+	Properties    properties.JSON  `json:"properties"`
+}
+
+// NewCloudEvent maps an event to the CloudEvents format
+//
+// The mapping only depends on the stored event, so an event is always mapped to the same CloudEvent:
+//   - id is the event ID and type is the event type prefixed with CloudEventTypePrefix
+//   - source is the provider, consumer or participant the event belongs to, in this order of
+//     precedence, e.g. /fulcrum/providers/{id}, or CloudEventSourceRoot for the other events
+//   - subject is the ID of the target entity
+//   - sequence is the sequence number zero-padded to 20 digits so it orders lexicographically
+func NewCloudEvent(event *Event) *CloudEvent {
+	var subject string
+	if event.EntityID != nil {
+		subject = event.EntityID.String()
+	}
+	return &CloudEvent{
+		SpecVersion:     CloudEventsSpecVersion,
+		ID:              event.ID.String(),
+		Type:            CloudEventTypePrefix + string(event.Type),
+		Source:          cloudEventSource(event),
+		Subject:         subject,
+		Time:            event.CreatedAt.UTC(),
+		DataContentType: "application/json",
+		Sequence:        fmt.Sprintf("%020d", event.SequenceNumber),
+		Data: CloudEventData{
+			InitiatorType: event.InitiatorType,
+			InitiatorID:   event.InitiatorID,
+			EntityID:      event.EntityID,
+			ProviderID:    event.ProviderID,
+			AgentID:       event.AgentID,
+			ConsumerID:    event.ConsumerID,
+			RequestID:     event.RequestID,
+			Properties:    event.Payload,
+		},
+	}
+}
+
+// cloudEventSource returns the source attribute of an event
+func cloudEventSource(event *Event) string {
+	switch {
+	case event.ProviderID != nil:
+		return CloudEventSourceRoot + "/providers/" + event.ProviderID.String()
+	case event.ConsumerID != nil:
+		return CloudEventSourceRoot + "/consumers/" + event.ConsumerID.String()
+	case event.ParticipantID != nil:
+		return CloudEventSourceRoot + "/participants/" + event.ParticipantID.String()
+	}
+	return CloudEventSourceRoot
+}
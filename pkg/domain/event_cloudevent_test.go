@@ -0,0 +1,99 @@
+package domain
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/fulcrumproject/core/pkg/properties"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewCloudEvent(t *testing.T) {
+	eventID := properties.NewUUID()
+	entityID := properties.NewUUID()
+	providerID := properties.NewUUID()
+	consumerID := properties.NewUUID()
+	participantID := properties.NewUUID()
+	createdAt := time.Date(2025, 1, 2, 3, 4, 5, 0, time.FixedZone("CET", 3600))
+
+	event := &Event{
+		BaseEntity:     BaseEntity{ID: eventID, CreatedAt: createdAt},
+		SequenceNumber: 42,
+		InitiatorType:  InitiatorTypeUser,
+		InitiatorID:    "user-1",
+		Type:           EventTypeServiceCreated,
+		Payload:        properties.JSON{"reason": "manual"},
+		EntityID:       &entityID,
+		ProviderID:     &providerID,
+		ConsumerID:     &consumerID,
+		RequestID:      "req-42",
+	}
+
+	ce := NewCloudEvent(event)
+	assert.Equal(t, "1.0", ce.SpecVersion)
+	assert.Equal(t, eventID.String(), ce.ID)
+	assert.Equal(t, "fulcrum.service.created", ce.Type)
+	assert.Equal(t, "/fulcrum/providers/"+providerID.String(), ce.Source)
+	assert.Equal(t, entityID.String(), ce.Subject)
+	assert.Equal(t, createdAt.UTC(), ce.Time)
+	assert.Equal(t, "00000000000000000042", ce.Sequence)
+	assert.Equal(t, properties.JSON{"reason": "manual"}, ce.Data.Properties)
+	assert.Equal(t, "req-42", ce.Data.RequestID)
+
+	data, err := json.Marshal(ce)
+	require.NoError(t, err)
+	again, err := json.Marshal(NewCloudEvent(event))
+	require.NoError(t, err)
+	assert.Equal(t, string(data), string(again), "the mapping must be deterministic")
+	assert.JSONEq(t, `{
+		"specversion": "1.0",
+		"id": "`+eventID.String()+`",
+		"type": "fulcrum.service.created",
+		"source": "/fulcrum/providers/`+providerID.String()+`",
+		"subject": "`+entityID.String()+`",
+		"time": "2025-01-02T02:04:05Z",
+		"datacontenttype": "application/json",
+		"sequence": "00000000000000000042",
+		"data": {
+			"initiatorType": "user",
+			"initiatorId": "user-1",
+			"entityId": "`+entityID.String()+`",
+			"providerId": "`+providerID.String()+`",
+			"consumerId": "`+consumerID.String()+`",
+			"requestId": "req-42",
+			"properties": {"reason": "manual"}
+		}
+	}`, string(data))
+
+	t.Run("source", func(t *testing.T) {
+		tests := []struct {
+			name     string
+			event    *Event
+			expected string
+		}{
+			{"consumer", &Event{ConsumerID: &consumerID, ParticipantID: &participantID}, "/fulcrum/consumers/" + consumerID.String()},
+			{"participant", &Event{ParticipantID: &participantID}, "/fulcrum/participants/" + participantID.String()},
+			{"system", &Event{}, "/fulcrum"},
+		}
+		for _, tc := range tests {
+			t.Run(tc.name, func(t *testing.T) {
+				assert.Equal(t, tc.expected, NewCloudEvent(tc.event).Source)
+			})
+		}
+	})
+}
+
+func TestParseEventFormat(t *testing.T) {
+	f, err := ParseEventFormat("cloudevents")
+	require.NoError(t, err)
+	assert.Equal(t, EventFormatCloudEvents, f)
+
+	f, err = ParseEventFormat("native")
+	require.NoError(t, err)
+	assert.Equal(t, EventFormatNative, f)
+
+	_, err = ParseEventFormat("xml")
+	assert.EqualError(t, err, `invalid event format "xml": must be native or cloudevents`)
+}
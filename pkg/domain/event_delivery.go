@@ -0,0 +1,79 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/fulcrumproject/core/pkg/properties"
+)
+
+// EventDelivery records an attempt to deliver an event to the webhook of a subscription
+// Only the latest attempts of each subscription are kept, see EventSubscriptionRepository.RecordDelivery
+// Does not extend BaseEntity because it has a custom index on created_at and is never updated
+type EventDelivery struct {
+	ID properties.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	// Attempt time, also used to keep the latest attempts
+	CreatedAt time.Time `gorm:"not null;default:CURRENT_TIMESTAMP;index:event_delivery_subscription_created,priority:2"`
+
+	SubscriptionID properties.UUID `gorm:"type:uuid;not null;index:event_delivery_subscription_created,priority:1"`
+	EventID        properties.UUID `gorm:"type:uuid;not null"`
+	SequenceNumber int64           `gorm:"not null"`
+	Attempt        int             `gorm:"not null"` // 1 for the first attempt of the event, incremented on each retry
+	StatusCode     *int            // HTTP status of the response, nil when no response was received
+	LatencyMs      int64           `gorm:"not null"`
+	Error          *string
+	Success        bool `gorm:"not null"`
+}
+
+// NewEventDelivery creates the record of a delivery attempt of an event to a subscription
+func NewEventDelivery(subscription *EventSubscription, event *Event, status *int, deliveryErr error, latency time.Duration, now time.Time) *EventDelivery {
+	delivery := &EventDelivery{
+		SubscriptionID: subscription.ID,
+		EventID:        event.ID,
+		SequenceNumber: event.SequenceNumber,
+		Attempt:        subscription.FailureCount + 1,
+		StatusCode:     status,
+		LatencyMs:      latency.Milliseconds(),
+		Success:        deliveryErr == nil,
+		CreatedAt:      now,
+	}
+	if deliveryErr != nil {
+		msg := deliveryErr.Error()
+		delivery.Error = &msg
+	}
+	return delivery
+}
+
+// TableName returns the table name for the event delivery
+func (EventDelivery) TableName() string {
+	return "event_deliveries"
+}
+
+// EventDeliveryStats aggregates the recorded delivery attempts of a subscription
+type EventDeliveryStats struct {
+	Attempts      int64
+	Successes     int64
+	LastSuccessAt *time.Time
+}
+
+// EventDeliverySummary describes the delivery health of a subscription
+type EventDeliverySummary struct {
+	// SuccessRate is the ratio of successful recorded attempts, nil when no attempt was recorded
+	SuccessRate   *float64
+	LastSuccessAt *time.Time
+	// PendingRetries is the number of events whose failed delivery is scheduled again,
+	// at most one since the events of a subscription are delivered in order
+	PendingRetries int
+}
+
+// NewEventDeliverySummary builds the delivery summary of a subscription from the stats of its recorded attempts
+func NewEventDeliverySummary(subscription *EventSubscription, stats *EventDeliveryStats) *EventDeliverySummary {
+	summary := &EventDeliverySummary{LastSuccessAt: stats.LastSuccessAt}
+	if stats.Attempts > 0 {
+		rate := float64(stats.Successes) / float64(stats.Attempts)
+		summary.SuccessRate = &rate
+	}
+	if subscription.FailureCount > 0 && !subscription.IsDeadLettered() {
+		summary.PendingRetries = 1
+	}
+	return summary
+}
@@ -0,0 +1,150 @@
+package domain
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/fulcrumproject/core/pkg/properties"
+	"github.com/wI2L/jsondiff"
+)
+
+// RedactedValue replaces the values of the sensitive fields in the event diffs
+const RedactedValue = "***"
+
+// sensitivePaths returns, per entity type, the JSON Pointer paths of the fields redacted from the event diffs
+// The functions receive a pointer to the entity, the * token matches any array item or object property
+var sensitivePaths = map[reflect.Type]func(entity any) []string{
+	reflect.TypeFor[Token](): func(any) []string {
+		return jsonFieldPaths(reflect.TypeFor[Token](), "HashedValue", "PlainValue")
+	},
+	reflect.TypeFor[Service](): func(entity any) []string {
+		svc := entity.(*Service)
+		if svc.ServiceType == nil {
+			return nil
+		}
+		prefixes := jsonFieldPaths(reflect.TypeFor[Service](), "Properties")
+		if len(prefixes) == 0 {
+			return nil
+		}
+		paths := svc.ServiceType.PropertySchema.SensitivePaths()
+		for i, p := range paths {
+			paths[i] = prefixes[0] + p
+		}
+		return paths
+	},
+}
+
+// jsonFieldPaths returns the JSON Pointer paths of the named struct fields, skipping the ones not serialized
+func jsonFieldPaths(t reflect.Type, fieldNames ...string) []string {
+	var paths []string
+	for _, fieldName := range fieldNames {
+		field, ok := t.FieldByName(fieldName)
+		if !ok {
+			continue
+		}
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		paths = append(paths, "/"+strings.NewReplacer("~", "~0", "/", "~1").Replace(name))
+	}
+	return paths
+}
+
+// entitySensitivePaths returns the sensitive paths registered for the type of the entity
+func entitySensitivePaths(entity any) []string {
+	v := reflect.ValueOf(entity)
+	if !v.IsValid() || (v.Kind() == reflect.Pointer && v.IsNil()) {
+		return nil
+	}
+	if v.Kind() != reflect.Pointer {
+		ptr := reflect.New(v.Type())
+		ptr.Elem().Set(v)
+		v = ptr
+	}
+	fn, ok := sensitivePaths[v.Type().Elem()]
+	if !ok {
+		return nil
+	}
+	return fn(v.Interface())
+}
+
+// redactPatch replaces the values of the operations touching the sensitive paths with RedactedValue
+// Operations on a sensitive path or below are fully redacted, the values of operations on
+// one of its parents are redacted at the sensitive path only
+func redactPatch(patch jsondiff.Patch, paths []string) error {
+	if len(paths) == 0 {
+		return nil
+	}
+	patterns := make([][]string, 0, len(paths))
+	for _, p := range paths {
+		tokens, err := properties.ParseJSONPointer(p)
+		if err != nil {
+			return err
+		}
+		patterns = append(patterns, tokens)
+	}
+
+	for i := range patch {
+		op := &patch[i]
+		opTokens, err := properties.ParseJSONPointer(op.Path)
+		if err != nil {
+			return err
+		}
+		for _, pattern := range patterns {
+			if len(opTokens) >= len(pattern) {
+				if matchPointerTokens(pattern, opTokens[:len(pattern)]) {
+					op.Value = redactJSONValue(op.Value, nil)
+					op.OldValue = redactJSONValue(op.OldValue, nil)
+				}
+			} else if matchPointerTokens(pattern[:len(opTokens)], opTokens) {
+				op.Value = redactJSONValue(op.Value, pattern[len(opTokens):])
+				op.OldValue = redactJSONValue(op.OldValue, pattern[len(opTokens):])
+			}
+		}
+	}
+	return nil
+}
+
+// matchPointerTokens reports whether the tokens match the pattern, * matching any token
+func matchPointerTokens(pattern, tokens []string) bool {
+	if len(pattern) != len(tokens) {
+		return false
+	}
+	for i := range pattern {
+		if pattern[i] != "*" && pattern[i] != tokens[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// redactJSONValue replaces the decoded JSON value at the pattern path with RedactedValue
+// Absent and null values are left untouched
+func redactJSONValue(value any, pattern []string) any {
+	if value == nil {
+		return nil
+	}
+	if len(pattern) == 0 {
+		return RedactedValue
+	}
+	switch v := value.(type) {
+	case map[string]any:
+		for key, child := range v {
+			if pattern[0] == "*" || pattern[0] == key {
+				v[key] = redactJSONValue(child, pattern[1:])
+			}
+		}
+	case []any:
+		for i, child := range v {
+			if pattern[0] == "*" || pattern[0] == strconv.Itoa(i) {
+				v[i] = redactJSONValue(child, pattern[1:])
+			}
+		}
+	}
+	return value
+}
@@ -4,7 +4,15 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net/url"
+	"slices"
+	"strings"
 	"time"
+
+	"github.com/fulcrumproject/core/pkg/auth"
+	"github.com/fulcrumproject/core/pkg/properties"
+	"github.com/fulcrumproject/core/pkg/schema"
+	"github.com/lib/pq"
 )
 
 // EventSubscription represents a subscription for external systems to consume events
@@ -17,6 +25,120 @@ type EventSubscription struct {
 	LeaseAcquiredAt            *time.Time `json:"lease_acquired_at,omitempty"`
 	LeaseExpiresAt             *time.Time `json:"lease_expires_at,omitempty" gorm:"index"`
 	IsActive                   bool       `json:"is_active" gorm:"not null;default:true"`
+
+	// Format of the events leased or delivered to the subscriber
+	Format EventFormat `json:"format" gorm:"not null;default:native"`
+
+	// Webhook delivery, only used when CallbackURL is set
+	CallbackURL    *string    `json:"callback_url,omitempty"`
+	SecretRef      *string    `json:"-"` // Vault reference of the payload signing secret
+	LastDeliveryAt *time.Time `json:"last_delivery_at,omitempty"`
+	LastStatus     *int       `json:"last_status,omitempty"` // HTTP status of the last attempt, nil when no response was received
+	LastError      *string    `json:"last_error,omitempty"`
+	FailureCount   int        `json:"failure_count" gorm:"not null;default:0"`
+	NextDeliveryAt *time.Time `json:"next_delivery_at,omitempty" gorm:"index"`
+	DeadLetteredAt *time.Time `json:"dead_lettered_at,omitempty"`
+
+	// Filter of the leased and delivered events, an empty filter matches every event
+	Filter EventFilter `json:"filter" gorm:"embedded;embeddedPrefix:filter_"`
+}
+
+// EventFilter restricts the events of a subscription, each empty field matches every event
+type EventFilter struct {
+	Types      pq.StringArray   `json:"types,omitempty" gorm:"type:text[]"` // Event types, any of them matches
+	EntityID   *properties.UUID `json:"entity_id,omitempty" gorm:"type:uuid"`
+	ProviderID *properties.UUID `json:"provider_id,omitempty" gorm:"type:uuid"`
+	ConsumerID *properties.UUID `json:"consumer_id,omitempty" gorm:"type:uuid"`
+
+	// ParticipantID matches the events of the participant as provider, consumer or subject of the event
+	ParticipantID *properties.UUID `json:"participant_id,omitempty" gorm:"type:uuid"`
+}
+
+// NewEventFilter creates a filter of the given event types, entity and participants
+func NewEventFilter(types []EventType, entityID, providerID, consumerID *properties.UUID) EventFilter {
+	f := EventFilter{EntityID: entityID, ProviderID: providerID, ConsumerID: consumerID}
+	for _, t := range types {
+		f.Types = append(f.Types, string(t))
+	}
+	return f
+}
+
+// EventTypes returns the event types of the filter
+func (f EventFilter) EventTypes() []EventType {
+	types := make([]EventType, len(f.Types))
+	for i, t := range f.Types {
+		types[i] = EventType(t)
+	}
+	return types
+}
+
+// IsEmpty reports whether the filter matches every event
+func (f EventFilter) IsEmpty() bool {
+	return len(f.Types) == 0 && f.EntityID == nil && f.ProviderID == nil && f.ConsumerID == nil && f.ParticipantID == nil
+}
+
+// Matches reports whether the event passes every field of the filter
+func (f EventFilter) Matches(e *Event) bool {
+	if len(f.Types) > 0 && !slices.Contains(f.Types, string(e.Type)) {
+		return false
+	}
+	if f.ParticipantID != nil && !uuidMatches(f.ParticipantID, e.ProviderID) && !uuidMatches(f.ParticipantID, e.ConsumerID) &&
+		!uuidMatches(f.ParticipantID, e.ParticipantID) {
+		return false
+	}
+	return uuidMatches(f.EntityID, e.EntityID) && uuidMatches(f.ProviderID, e.ProviderID) && uuidMatches(f.ConsumerID, e.ConsumerID)
+}
+
+// uuidMatches reports whether the value equals the wanted one, any value matches when nothing is wanted
+func uuidMatches(want, value *properties.UUID) bool {
+	return want == nil || (value != nil && *value == *want)
+}
+
+// Validate ensures the event types of the filter are not blank
+func (f EventFilter) Validate() error {
+	for _, t := range f.Types {
+		if strings.TrimSpace(t) == "" {
+			return fmt.Errorf("filter event types cannot be empty")
+		}
+	}
+	return nil
+}
+
+// Authorize checks the participant scope of the filter against the identity configuring it,
+// an identity scoped to a participant only subscribes to the events where it is the provider or the consumer
+func (f EventFilter) Authorize(identity *auth.Identity) error {
+	participantID := identity.Scope.ParticipantID
+	if participantID == nil {
+		return nil
+	}
+	if f.ProviderID == nil && f.ConsumerID == nil && f.ParticipantID == nil {
+		return NewUnauthorizedErrorf("the filter must be restricted to the events of participant %s as provider or consumer", *participantID)
+	}
+	for _, id := range []*properties.UUID{f.ProviderID, f.ConsumerID, f.ParticipantID} {
+		if id != nil && *id != *participantID {
+			return NewUnauthorizedErrorf("cannot subscribe to the events of participant %s", *id)
+		}
+	}
+	return nil
+}
+
+// WebhookRetryPolicy defines the exponential backoff of failed webhook deliveries
+type WebhookRetryPolicy struct {
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+}
+
+// Backoff returns the delay before the next attempt after failureCount consecutive failures
+func (p WebhookRetryPolicy) Backoff(failureCount int) time.Duration {
+	backoff := p.InitialBackoff
+	for i := 1; i < failureCount && backoff < p.MaxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > p.MaxBackoff {
+		return p.MaxBackoff
+	}
+	return backoff
 }
 
 // NewEventSubscription creates a new EventSubscription without validation
@@ -25,6 +147,7 @@ func NewEventSubscription(subscriberID string) *EventSubscription {
 		SubscriberID:               subscriberID,
 		LastEventSequenceProcessed: 0,
 		IsActive:                   true,
+		Format:                     EventFormatNative,
 	}
 }
 
@@ -57,6 +180,31 @@ func (es *EventSubscription) Validate() error {
 			return fmt.Errorf("lease_acquired_at and lease_expires_at must be nil when lease_owner_instance_id is nil")
 		}
 	}
+	if es.Format != "" {
+		if err := es.Format.Validate(); err != nil {
+			return err
+		}
+	}
+	if es.CallbackURL != nil {
+		if err := validateCallbackURL(*es.CallbackURL); err != nil {
+			return err
+		}
+	}
+	if es.FailureCount < 0 {
+		return fmt.Errorf("failure_count cannot be negative")
+	}
+	return es.Filter.Validate()
+}
+
+// validateCallbackURL ensures the callback is an absolute http(s) URL
+func validateCallbackURL(callbackURL string) error {
+	u, err := url.Parse(callbackURL)
+	if err != nil {
+		return fmt.Errorf("invalid callback_url: %w", err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid callback_url %s: must be an absolute http or https URL", callbackURL)
+	}
 	return nil
 }
 
@@ -114,6 +262,62 @@ func (es *EventSubscription) HasActiveLease() bool {
 	return es.LeaseOwnerInstanceID != nil && !es.IsLeaseExpired()
 }
 
+// EventFormat returns the format of the events of the subscription, native when not set
+func (es *EventSubscription) EventFormat() EventFormat {
+	if es.Format == "" {
+		return EventFormatNative
+	}
+	return es.Format
+}
+
+// IsDeadLettered checks if webhook delivery stopped after too many failures
+func (es *EventSubscription) IsDeadLettered() bool {
+	return es.DeadLetteredAt != nil
+}
+
+// IsDeliveryDue checks if the webhook of the subscription should be called at the given time
+func (es *EventSubscription) IsDeliveryDue(now time.Time) bool {
+	return es.CallbackURL != nil && es.IsActive && !es.IsDeadLettered() &&
+		(es.NextDeliveryAt == nil || !es.NextDeliveryAt.After(now))
+}
+
+// EnableDelivery clears the failures and the dead-letter state so delivery resumes
+func (es *EventSubscription) EnableDelivery() {
+	es.FailureCount = 0
+	es.NextDeliveryAt = nil
+	es.DeadLetteredAt = nil
+}
+
+// RecordDeliverySuccess advances the subscription past a delivered event
+func (es *EventSubscription) RecordDeliverySuccess(sequenceNumber int64, status int, now time.Time) {
+	es.LastEventSequenceProcessed = sequenceNumber
+	es.LastDeliveryAt = &now
+	es.LastStatus = &status
+	es.LastError = nil
+	es.EnableDelivery()
+}
+
+// RecordDeliveryFailure schedules the next attempt of a failed delivery,
+// the subscription is dead-lettered once the policy attempts are exhausted
+func (es *EventSubscription) RecordDeliveryFailure(status *int, deliveryErr error, policy WebhookRetryPolicy, now time.Time) {
+	es.LastDeliveryAt = &now
+	es.LastStatus = status
+	if deliveryErr != nil {
+		msg := deliveryErr.Error()
+		es.LastError = &msg
+	} else {
+		es.LastError = nil
+	}
+	es.FailureCount++
+	if es.FailureCount >= policy.MaxAttempts {
+		es.DeadLetteredAt = &now
+		es.NextDeliveryAt = nil
+		return
+	}
+	next := now.Add(policy.Backoff(es.FailureCount))
+	es.NextDeliveryAt = &next
+}
+
 // EventSubscriptionCommander defines the interface for event subscription command operations
 type EventSubscriptionCommander interface {
 	// UpdateProgress updates the last event sequence processed
@@ -134,6 +338,12 @@ type EventSubscriptionCommander interface {
 	// SetActive sets the active status of the subscription
 	SetActive(ctx context.Context, params SetActiveParams) (*EventSubscription, error)
 
+	// ConfigureWebhook sets the callback URL of the subscription, creating it if needed, and re-enables delivery
+	ConfigureWebhook(ctx context.Context, params ConfigureWebhookParams) (*EventSubscription, error)
+
+	// SetFilter replaces the event filter of the subscription, creating it if needed
+	SetFilter(ctx context.Context, params SetEventFilterParams) (*EventSubscription, error)
+
 	// Delete removes an event subscription
 	Delete(ctx context.Context, subscriberID string) error
 }
@@ -165,15 +375,30 @@ type SetActiveParams struct {
 	IsActive     bool
 }
 
+type ConfigureWebhookParams struct {
+	SubscriberID string
+	CallbackURL  string
+	Secret       *string      // Signing secret, nil keeps the current one and empty removes it
+	Format       *EventFormat // Format of the delivered events, nil keeps the current one
+}
+
+type SetEventFilterParams struct {
+	SubscriberID string
+	Filter       EventFilter
+}
+
 // eventSubscriptionCommander is the concrete implementation of EventSubscriptionCommander
 type eventSubscriptionCommander struct {
 	store Store
+	vault schema.Vault
 }
 
 // NewEventSubscriptionCommander creates a new default EventSubscriptionCommander
-func NewEventSubscriptionCommander(store Store) EventSubscriptionCommander {
+// The vault stores the webhook signing secrets, it may be nil when no secret is used
+func NewEventSubscriptionCommander(store Store, vault schema.Vault) EventSubscriptionCommander {
 	return &eventSubscriptionCommander{
 		store: store,
+		vault: vault,
 	}
 }
 
@@ -328,6 +553,9 @@ func (c *eventSubscriptionCommander) SetActive(
 	}
 
 	subscription.Update(nil, nil, nil, nil, &params.IsActive)
+	if params.IsActive {
+		subscription.EnableDelivery()
+	}
 	if err := subscription.Validate(); err != nil {
 		return nil, InvalidInputError{Err: err}
 	}
@@ -338,13 +566,130 @@ func (c *eventSubscriptionCommander) SetActive(
 	return subscription, nil
 }
 
+func (c *eventSubscriptionCommander) ConfigureWebhook(
+	ctx context.Context,
+	params ConfigureWebhookParams,
+) (*EventSubscription, error) {
+	subscription, err := c.store.EventSubscriptionRepo().FindBySubscriberID(ctx, params.SubscriberID)
+	create := false
+	if err != nil {
+		var notFoundErr NotFoundError
+		if !errors.As(err, &notFoundErr) {
+			return nil, err
+		}
+		subscription = NewEventSubscription(params.SubscriberID)
+		create = true
+	}
+
+	subscription.CallbackURL = &params.CallbackURL
+	if params.Format != nil {
+		subscription.Format = *params.Format
+	}
+	subscription.EnableDelivery()
+	if err := subscription.Validate(); err != nil {
+		return nil, InvalidInputError{Err: err}
+	}
+
+	oldSecretRef := subscription.SecretRef
+	if params.Secret != nil {
+		subscription.SecretRef = nil
+		if *params.Secret != "" {
+			ref, err := c.storeSecret(ctx, subscription.SubscriberID, *params.Secret)
+			if err != nil {
+				return nil, err
+			}
+			subscription.SecretRef = &ref
+		}
+	}
+
+	if create {
+		err = c.store.EventSubscriptionRepo().Create(ctx, subscription)
+	} else {
+		err = c.store.EventSubscriptionRepo().Save(ctx, subscription)
+	}
+	if err != nil {
+		if subscription.SecretRef != nil && subscription.SecretRef != oldSecretRef {
+			c.deleteSecret(ctx, *subscription.SecretRef)
+		}
+		return nil, err
+	}
+	if oldSecretRef != nil && subscription.SecretRef != oldSecretRef {
+		c.deleteSecret(ctx, *oldSecretRef)
+	}
+	return subscription, nil
+}
+
+func (c *eventSubscriptionCommander) SetFilter(
+	ctx context.Context,
+	params SetEventFilterParams,
+) (*EventSubscription, error) {
+	if err := params.Filter.Authorize(auth.MustGetIdentity(ctx)); err != nil {
+		return nil, err
+	}
+
+	subscription, err := c.store.EventSubscriptionRepo().FindBySubscriberID(ctx, params.SubscriberID)
+	create := false
+	if err != nil {
+		var notFoundErr NotFoundError
+		if !errors.As(err, &notFoundErr) {
+			return nil, err
+		}
+		subscription = NewEventSubscription(params.SubscriberID)
+		create = true
+	}
+
+	subscription.Filter = params.Filter
+	if err := subscription.Validate(); err != nil {
+		return nil, InvalidInputError{Err: err}
+	}
+
+	if create {
+		err = c.store.EventSubscriptionRepo().Create(ctx, subscription)
+	} else {
+		err = c.store.EventSubscriptionRepo().Save(ctx, subscription)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return subscription, nil
+}
+
+// storeSecret saves a webhook signing secret in the vault and returns its reference
+func (c *eventSubscriptionCommander) storeSecret(ctx context.Context, subscriberID string, secret string) (string, error) {
+	if c.vault == nil {
+		return "", NewInvalidInputErrorf("vault is required for webhook secrets but not configured")
+	}
+	ref := properties.NewUUID().String()
+	if err := c.vault.Save(ctx, ref, secret, map[string]any{
+		"secretType":   "webhook",
+		"subscriberId": subscriberID,
+	}); err != nil {
+		return "", fmt.Errorf("failed to store webhook secret: %w", err)
+	}
+	return ref, nil
+}
+
+// deleteSecret removes a webhook signing secret from the vault
+func (c *eventSubscriptionCommander) deleteSecret(ctx context.Context, ref string) {
+	if c.vault == nil {
+		return
+	}
+	_ = c.vault.Delete(ctx, ref) // Best-effort cleanup
+}
+
 func (c *eventSubscriptionCommander) Delete(ctx context.Context, subscriberID string) error {
-	_, err := c.store.EventSubscriptionRepo().FindBySubscriberID(ctx, subscriberID)
+	subscription, err := c.store.EventSubscriptionRepo().FindBySubscriberID(ctx, subscriberID)
 	if err != nil {
 		return err
 	}
 
-	return c.store.EventSubscriptionRepo().DeleteBySubscriberID(ctx, subscriberID)
+	if err := c.store.EventSubscriptionRepo().DeleteBySubscriberID(ctx, subscriberID); err != nil {
+		return err
+	}
+	if subscription.SecretRef != nil {
+		c.deleteSecret(ctx, *subscription.SecretRef)
+	}
+	return nil
 }
 
 // EventSubscriptionRepository defines the interface for event subscription data operations
@@ -354,6 +699,9 @@ type EventSubscriptionRepository interface {
 
 	// DeleteBySubscriberID removes an entity by subscriber ID
 	DeleteBySubscriberID(ctx context.Context, subscriberID string) error
+
+	// RecordDelivery stores a webhook delivery attempt and removes the attempts of its subscription beyond the latest keep ones
+	RecordDelivery(ctx context.Context, delivery *EventDelivery, keep int) error
 }
 
 // EventSubscriptionQuerier defines the interface for event subscription query operations
@@ -368,4 +716,13 @@ type EventSubscriptionQuerier interface {
 
 	// ListExpiredLeases retrieves subscriptions with expired leases
 	ListExpiredLeases(ctx context.Context) ([]*EventSubscription, error)
+
+	// ListDueWebhooks retrieves active webhook subscriptions whose next delivery is due
+	ListDueWebhooks(ctx context.Context, now time.Time) ([]*EventSubscription, error)
+
+	// ListDeliveries retrieves the recorded webhook delivery attempts of a subscription, latest first
+	ListDeliveries(ctx context.Context, subscriptionID properties.UUID, page *PageReq) (*PageRes[EventDelivery], error)
+
+	// DeliveryStats aggregates the recorded webhook delivery attempts of a subscription
+	DeliveryStats(ctx context.Context, subscriptionID properties.UUID) (*EventDeliveryStats, error)
 }
@@ -1,11 +1,18 @@
 package domain
 
 import (
+	"context"
+	"fmt"
 	"testing"
 	"time"
 
+	"github.com/fulcrumproject/core/pkg/auth"
 	"github.com/fulcrumproject/core/pkg/helpers"
+	"github.com/fulcrumproject/core/pkg/properties"
+	"github.com/fulcrumproject/core/pkg/schema"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 func TestNewEventSubscription(t *testing.T) {
@@ -258,6 +265,331 @@ func TestEventSubscription_HasActiveLease(t *testing.T) {
 	assert.False(t, subscription.HasActiveLease())
 }
 
+func TestWebhookRetryPolicy_Backoff(t *testing.T) {
+	policy := WebhookRetryPolicy{MaxAttempts: 5, InitialBackoff: 10 * time.Second, MaxBackoff: time.Minute}
+
+	assert.Equal(t, 10*time.Second, policy.Backoff(1))
+	assert.Equal(t, 20*time.Second, policy.Backoff(2))
+	assert.Equal(t, 40*time.Second, policy.Backoff(3))
+	assert.Equal(t, time.Minute, policy.Backoff(4))
+	assert.Equal(t, time.Minute, policy.Backoff(50))
+}
+
+func TestEventSubscription_WebhookDelivery(t *testing.T) {
+	policy := WebhookRetryPolicy{MaxAttempts: 2, InitialBackoff: time.Second, MaxBackoff: time.Minute}
+	now := time.Now()
+	callbackURL := "https://example.com/hook"
+
+	t.Run("only webhook subscriptions are due", func(t *testing.T) {
+		subscription := NewEventSubscription("test-subscriber")
+		assert.False(t, subscription.IsDeliveryDue(now))
+
+		subscription.CallbackURL = &callbackURL
+		assert.True(t, subscription.IsDeliveryDue(now))
+
+		subscription.IsActive = false
+		assert.False(t, subscription.IsDeliveryDue(now))
+	})
+
+	t.Run("failures back off then dead-letter", func(t *testing.T) {
+		subscription := NewEventSubscription("test-subscriber")
+		subscription.CallbackURL = &callbackURL
+
+		status := 500
+		subscription.RecordDeliveryFailure(&status, fmt.Errorf("callback returned status 500"), policy, now)
+		assert.Equal(t, 1, subscription.FailureCount)
+		assert.Equal(t, &status, subscription.LastStatus)
+		assert.Equal(t, "callback returned status 500", *subscription.LastError)
+		assert.Equal(t, now.Add(time.Second), *subscription.NextDeliveryAt)
+		assert.False(t, subscription.IsDeliveryDue(now))
+		assert.True(t, subscription.IsDeliveryDue(now.Add(time.Second)))
+
+		subscription.RecordDeliveryFailure(nil, fmt.Errorf("connection refused"), policy, now)
+		assert.True(t, subscription.IsDeadLettered())
+		assert.Nil(t, subscription.LastStatus)
+		assert.Nil(t, subscription.NextDeliveryAt)
+		assert.False(t, subscription.IsDeliveryDue(now.Add(time.Hour)))
+
+		subscription.EnableDelivery()
+		assert.Equal(t, 0, subscription.FailureCount)
+		assert.True(t, subscription.IsDeliveryDue(now))
+	})
+
+	t.Run("success advances the sequence and clears failures", func(t *testing.T) {
+		subscription := NewEventSubscription("test-subscriber")
+		subscription.CallbackURL = &callbackURL
+		subscription.RecordDeliveryFailure(nil, fmt.Errorf("timeout"), policy, now)
+
+		subscription.RecordDeliverySuccess(42, 204, now)
+		assert.Equal(t, int64(42), subscription.LastEventSequenceProcessed)
+		assert.Equal(t, 204, *subscription.LastStatus)
+		assert.Nil(t, subscription.LastError)
+		assert.Equal(t, 0, subscription.FailureCount)
+		assert.Nil(t, subscription.NextDeliveryAt)
+		assert.Equal(t, now, *subscription.LastDeliveryAt)
+	})
+
+	t.Run("callback URL must be http", func(t *testing.T) {
+		subscription := NewEventSubscription("test-subscriber")
+		invalid := "ftp://example.com/hook"
+		subscription.CallbackURL = &invalid
+		assert.ErrorContains(t, subscription.Validate(), "must be an absolute http or https URL")
+
+		relative := "/hook"
+		subscription.CallbackURL = &relative
+		assert.Error(t, subscription.Validate())
+
+		subscription.CallbackURL = &callbackURL
+		assert.NoError(t, subscription.Validate())
+	})
+}
+
+func TestEventSubscriptionCommander_ConfigureWebhook(t *testing.T) {
+	ctx := context.Background()
+	callbackURL := "https://example.com/hook"
+	secret := "s3cret"
+	noSecret := ""
+
+	t.Run("creates the subscription and stores the secret in the vault", func(t *testing.T) {
+		store := NewMockStore(t)
+		repo := NewMockEventSubscriptionRepository(t)
+		vault := schema.NewMockVault(t)
+		store.EXPECT().EventSubscriptionRepo().Return(repo)
+		repo.EXPECT().FindBySubscriberID(ctx, "test-subscriber").Return(nil, NewNotFoundErrorf("event subscription"))
+		var ref string
+		vault.EXPECT().Save(ctx, mock.Anything, secret, mock.Anything).RunAndReturn(func(ctx context.Context, reference string, value any, metadata map[string]any) error {
+			ref = reference
+			return nil
+		})
+		repo.EXPECT().Create(ctx, mock.Anything).Return(nil)
+
+		subscription, err := NewEventSubscriptionCommander(store, vault).ConfigureWebhook(ctx, ConfigureWebhookParams{
+			SubscriberID: "test-subscriber",
+			CallbackURL:  callbackURL,
+			Secret:       &secret,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, callbackURL, *subscription.CallbackURL)
+		require.NotNil(t, subscription.SecretRef)
+		assert.Equal(t, ref, *subscription.SecretRef)
+	})
+
+	t.Run("rotating the secret deletes the previous one", func(t *testing.T) {
+		oldRef := "old-ref"
+		existing := NewEventSubscription("test-subscriber")
+		existing.SecretRef = &oldRef
+		existing.DeadLetteredAt = timePtr(time.Now())
+
+		store := NewMockStore(t)
+		repo := NewMockEventSubscriptionRepository(t)
+		vault := schema.NewMockVault(t)
+		store.EXPECT().EventSubscriptionRepo().Return(repo)
+		repo.EXPECT().FindBySubscriberID(ctx, "test-subscriber").Return(existing, nil)
+		vault.EXPECT().Save(ctx, mock.Anything, secret, mock.Anything).Return(nil)
+		repo.EXPECT().Save(ctx, existing).Return(nil)
+		vault.EXPECT().Delete(ctx, oldRef).Return(nil)
+
+		subscription, err := NewEventSubscriptionCommander(store, vault).ConfigureWebhook(ctx, ConfigureWebhookParams{
+			SubscriberID: "test-subscriber",
+			CallbackURL:  callbackURL,
+			Secret:       &secret,
+		})
+		require.NoError(t, err)
+		assert.NotEqual(t, oldRef, *subscription.SecretRef)
+		assert.False(t, subscription.IsDeadLettered())
+	})
+
+	t.Run("an empty secret removes it", func(t *testing.T) {
+		oldRef := "old-ref"
+		existing := NewEventSubscription("test-subscriber")
+		existing.SecretRef = &oldRef
+
+		store := NewMockStore(t)
+		repo := NewMockEventSubscriptionRepository(t)
+		vault := schema.NewMockVault(t)
+		store.EXPECT().EventSubscriptionRepo().Return(repo)
+		repo.EXPECT().FindBySubscriberID(ctx, "test-subscriber").Return(existing, nil)
+		repo.EXPECT().Save(ctx, existing).Return(nil)
+		vault.EXPECT().Delete(ctx, oldRef).Return(nil)
+
+		subscription, err := NewEventSubscriptionCommander(store, vault).ConfigureWebhook(ctx, ConfigureWebhookParams{
+			SubscriberID: "test-subscriber",
+			CallbackURL:  callbackURL,
+			Secret:       &noSecret,
+		})
+		require.NoError(t, err)
+		assert.Nil(t, subscription.SecretRef)
+	})
+
+	t.Run("a secret requires the vault", func(t *testing.T) {
+		store := NewMockStore(t)
+		repo := NewMockEventSubscriptionRepository(t)
+		store.EXPECT().EventSubscriptionRepo().Return(repo)
+		repo.EXPECT().FindBySubscriberID(ctx, "test-subscriber").Return(NewEventSubscription("test-subscriber"), nil)
+
+		_, err := NewEventSubscriptionCommander(store, nil).ConfigureWebhook(ctx, ConfigureWebhookParams{
+			SubscriberID: "test-subscriber",
+			CallbackURL:  callbackURL,
+			Secret:       &secret,
+		})
+		assert.ErrorAs(t, err, &InvalidInputError{})
+	})
+}
+
+func TestEventFilter_Matches(t *testing.T) {
+	entityID := properties.NewUUID()
+	providerID := properties.NewUUID()
+	consumerID := properties.NewUUID()
+	other := properties.NewUUID()
+	event := &Event{Type: EventTypeServiceCreated, EntityID: &entityID, ProviderID: &providerID, ConsumerID: &consumerID}
+
+	tests := []struct {
+		name   string
+		filter EventFilter
+		want   bool
+	}{
+		{"empty filter matches every event", EventFilter{}, true},
+		{"matching type", NewEventFilter([]EventType{EventTypeServiceUpdated, EventTypeServiceCreated}, nil, nil, nil), true},
+		{"other type", NewEventFilter([]EventType{EventTypeServiceUpdated}, nil, nil, nil), false},
+		{"matching entity", NewEventFilter(nil, &entityID, nil, nil), true},
+		{"other entity", NewEventFilter(nil, &other, nil, nil), false},
+		{"matching provider and consumer", NewEventFilter(nil, nil, &providerID, &consumerID), true},
+		{"other provider", NewEventFilter(nil, nil, &other, nil), false},
+		{"other consumer", NewEventFilter([]EventType{EventTypeServiceCreated}, nil, nil, &other), false},
+		{"participant as provider", EventFilter{ParticipantID: &providerID}, true},
+		{"participant as consumer", EventFilter{ParticipantID: &consumerID}, true},
+		{"other participant", EventFilter{ParticipantID: &other}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.filter.Matches(event))
+		})
+	}
+
+	t.Run("event without the participant", func(t *testing.T) {
+		assert.False(t, NewEventFilter(nil, nil, &providerID, nil).Matches(&Event{Type: EventTypeServiceCreated}))
+		assert.False(t, EventFilter{ParticipantID: &providerID}.Matches(&Event{Type: EventTypeServiceCreated}))
+	})
+
+	t.Run("event of the participant itself", func(t *testing.T) {
+		assert.True(t, EventFilter{ParticipantID: &providerID}.Matches(&Event{Type: EventTypeParticipantUpdated, ParticipantID: &providerID}))
+	})
+}
+
+func TestEventFilter_IsEmpty(t *testing.T) {
+	id := properties.NewUUID()
+	assert.True(t, EventFilter{}.IsEmpty())
+	assert.True(t, NewEventFilter(nil, nil, nil, nil).IsEmpty())
+	assert.False(t, NewEventFilter([]EventType{EventTypeServiceCreated}, nil, nil, nil).IsEmpty())
+	assert.False(t, NewEventFilter(nil, nil, nil, &id).IsEmpty())
+	assert.False(t, EventFilter{ParticipantID: &id}.IsEmpty())
+}
+
+func TestEventFilter_Validate(t *testing.T) {
+	assert.NoError(t, NewEventFilter([]EventType{EventTypeServiceCreated}, nil, nil, nil).Validate())
+	assert.Error(t, NewEventFilter([]EventType{" "}, nil, nil, nil).Validate())
+}
+
+func TestEventFilter_Authorize(t *testing.T) {
+	participantID := properties.NewUUID()
+	other := properties.NewUUID()
+	admin := &auth.Identity{Role: auth.RoleAdmin}
+	participant := &auth.Identity{Role: auth.RoleParticipant, Scope: auth.IdentityScope{ParticipantID: &participantID}}
+
+	tests := []struct {
+		name     string
+		identity *auth.Identity
+		filter   EventFilter
+		wantErr  bool
+	}{
+		{"admin without restriction", admin, EventFilter{}, false},
+		{"admin on any participant", admin, NewEventFilter(nil, nil, &other, nil), false},
+		{"participant as provider", participant, NewEventFilter(nil, nil, &participantID, nil), false},
+		{"participant as consumer", participant, NewEventFilter([]EventType{EventTypeServiceCreated}, nil, nil, &participantID), false},
+		{"participant without restriction", participant, EventFilter{}, true},
+		{"participant restricted to an entity only", participant, NewEventFilter(nil, &other, nil, nil), true},
+		{"participant on another provider", participant, NewEventFilter(nil, nil, &other, nil), true},
+		{"participant on another consumer", participant, NewEventFilter(nil, nil, &participantID, &other), true},
+		{"participant as any party", participant, EventFilter{ParticipantID: &participantID}, false},
+		{"participant as party of another", participant, EventFilter{ParticipantID: &other}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.filter.Authorize(tt.identity)
+			if tt.wantErr {
+				assert.ErrorAs(t, err, &UnauthorizedError{})
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestEventSubscriptionCommander_SetFilter(t *testing.T) {
+	participantID := properties.NewUUID()
+	adminCtx := auth.WithIdentity(context.Background(), &auth.Identity{ID: properties.NewUUID(), Role: auth.RoleAdmin})
+	filter := NewEventFilter([]EventType{EventTypeServiceCreated}, nil, &participantID, nil)
+
+	t.Run("creates the subscription with the filter", func(t *testing.T) {
+		store := NewMockStore(t)
+		repo := NewMockEventSubscriptionRepository(t)
+		store.EXPECT().EventSubscriptionRepo().Return(repo)
+		repo.EXPECT().FindBySubscriberID(adminCtx, "test-subscriber").Return(nil, NewNotFoundErrorf("event subscription"))
+		repo.EXPECT().Create(adminCtx, mock.Anything).Return(nil)
+
+		subscription, err := NewEventSubscriptionCommander(store, nil).SetFilter(adminCtx, SetEventFilterParams{
+			SubscriberID: "test-subscriber",
+			Filter:       filter,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "test-subscriber", subscription.SubscriberID)
+		assert.Equal(t, filter, subscription.Filter)
+	})
+
+	t.Run("an empty filter clears the existing one", func(t *testing.T) {
+		existing := NewEventSubscription("test-subscriber")
+		existing.Filter = filter
+
+		store := NewMockStore(t)
+		repo := NewMockEventSubscriptionRepository(t)
+		store.EXPECT().EventSubscriptionRepo().Return(repo)
+		repo.EXPECT().FindBySubscriberID(adminCtx, "test-subscriber").Return(existing, nil)
+		repo.EXPECT().Save(adminCtx, existing).Return(nil)
+
+		subscription, err := NewEventSubscriptionCommander(store, nil).SetFilter(adminCtx, SetEventFilterParams{SubscriberID: "test-subscriber"})
+		require.NoError(t, err)
+		assert.True(t, subscription.Filter.IsEmpty())
+	})
+
+	t.Run("rejects the events of another participant", func(t *testing.T) {
+		ctx := auth.WithIdentity(context.Background(), &auth.Identity{
+			ID:    properties.NewUUID(),
+			Role:  auth.RoleParticipant,
+			Scope: auth.IdentityScope{ParticipantID: func() *properties.UUID { id := properties.NewUUID(); return &id }()},
+		})
+		store := NewMockStore(t)
+
+		_, err := NewEventSubscriptionCommander(store, nil).SetFilter(ctx, SetEventFilterParams{
+			SubscriberID: "test-subscriber",
+			Filter:       filter,
+		})
+		assert.ErrorAs(t, err, &UnauthorizedError{})
+	})
+
+	t.Run("rejects blank event types", func(t *testing.T) {
+		store := NewMockStore(t)
+		repo := NewMockEventSubscriptionRepository(t)
+		store.EXPECT().EventSubscriptionRepo().Return(repo)
+		repo.EXPECT().FindBySubscriberID(adminCtx, "test-subscriber").Return(NewEventSubscription("test-subscriber"), nil)
+
+		_, err := NewEventSubscriptionCommander(store, nil).SetFilter(adminCtx, SetEventFilterParams{
+			SubscriberID: "test-subscriber",
+			Filter:       NewEventFilter([]EventType{""}, nil, nil, nil),
+		})
+		assert.ErrorAs(t, err, &InvalidInputError{})
+	})
+}
+
 // Helper functions
 func timePtr(t time.Time) *time.Time {
 	return &t
@@ -2,12 +2,19 @@ package domain
 
 import (
 	"context"
+	"encoding/json"
+	"reflect"
 	"testing"
 
 	"github.com/fulcrumproject/core/pkg/auth"
 	"github.com/fulcrumproject/core/pkg/properties"
+	"github.com/fulcrumproject/core/pkg/schema"
+	"github.com/fulcrumproject/core/pkg/tracing"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wI2L/jsondiff"
+	"gorm.io/gorm"
 )
 
 func TestEvent_Validate(t *testing.T) {
@@ -201,7 +208,111 @@ func TestNewEventWithDiff_ErrorHandling(t *testing.T) {
 	}
 }
 
+func TestNewEventWithDiff_RedactsServiceSensitiveProperties(t *testing.T) {
+	serviceType := &ServiceType{
+		Name: "db",
+		PropertySchema: schema.Schema{
+			Properties: map[string]schema.PropertyDefinition{
+				"size":     {Type: "integer"},
+				"password": {Type: "string", Sensitive: true},
+				"credentials": {Type: "object", Properties: map[string]schema.PropertyDefinition{
+					"user":   {Type: "string"},
+					"apiKey": {Type: "string", Sensitive: true},
+				}},
+			},
+		},
+	}
+	before := &Service{
+		Name:        "svc",
+		ServiceType: serviceType,
+		Properties:  &properties.JSON{"size": 1, "password": "old-secret"},
+	}
+	after := &Service{
+		Name:        "svc",
+		ServiceType: serviceType,
+		Properties: &properties.JSON{
+			"size":        2,
+			"password":    "new-secret",
+			"credentials": map[string]any{"user": "admin", "apiKey": "key-secret"},
+		},
+	}
+
+	entry, err := NewEvent(EventTypeServiceUpdated, WithDiff(before, after))
+	require.NoError(t, err)
+
+	patch, ok := entry.Payload["diff"].(jsondiff.Patch)
+	require.True(t, ok)
+	values := map[string]any{}
+	for _, op := range patch {
+		if op.Type != jsondiff.OperationTest {
+			values[op.Path] = op.Value
+		}
+	}
+	assert.Equal(t, float64(2), values["/properties/size"])
+	assert.Equal(t, RedactedValue, values["/properties/password"])
+	assert.Equal(t, map[string]any{"user": "admin", "apiKey": RedactedValue}, values["/properties/credentials"])
+
+	data, err := json.Marshal(entry.Payload)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "secret")
+}
+
+func TestNewEventWithDiff_RedactsRegisteredPaths(t *testing.T) {
+	type secretEntity struct {
+		Name    string           `json:"name"`
+		Secrets []map[string]any `json:"secrets"`
+		Token   string           `json:"token"`
+	}
+	entityType := reflect.TypeFor[secretEntity]()
+	sensitivePaths[entityType] = func(any) []string { return []string{"/token", "/secrets/*/value"} }
+	defer delete(sensitivePaths, entityType)
+
+	before := secretEntity{Name: "before", Token: "old-token"}
+	after := &secretEntity{
+		Name:    "after",
+		Token:   "new-token",
+		Secrets: []map[string]any{{"id": "a", "value": "v1"}},
+	}
+
+	entry, err := NewEvent(EventTypeAgentUpdated, WithDiff(before, after))
+	require.NoError(t, err)
+
+	data, err := json.Marshal(entry.Payload)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"after"`)
+	assert.Contains(t, string(data), `"id":"a"`)
+	assert.NotContains(t, string(data), "-token")
+	assert.NotContains(t, string(data), "v1")
+	assert.Contains(t, string(data), RedactedValue)
+}
+
+func TestNewEventWithDiff_TokenValuesNeverLeak(t *testing.T) {
+	before := &Token{Name: "token", Role: auth.RoleAdmin, HashedValue: "hashed-before", PlainValue: "plain-before"}
+	after := &Token{Name: "renamed", Role: auth.RoleAdmin, HashedValue: "hashed-after", PlainValue: "plain-after"}
+
+	entry, err := NewEvent(EventTypeTokenUpdated, WithDiff(before, after))
+	require.NoError(t, err)
+
+	data, err := json.Marshal(entry.Payload)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "renamed")
+	assert.NotContains(t, string(data), "hashed-")
+	assert.NotContains(t, string(data), "plain-")
+}
+
 func TestEvent_TableName(t *testing.T) {
 	eventEntry := Event{}
 	assert.Equal(t, "events", eventEntry.TableName())
 }
+
+func TestEvent_BeforeCreate(t *testing.T) {
+	tx := &gorm.DB{Statement: &gorm.Statement{Context: tracing.WithRequestID(context.Background(), "req-42")}}
+
+	event := &Event{}
+	require.NoError(t, event.BeforeCreate(tx))
+	assert.Equal(t, "req-42", event.RequestID)
+
+	event = &Event{RequestID: "req-1"}
+	require.NoError(t, event.BeforeCreate(tx))
+	assert.Equal(t, "req-1", event.RequestID, "keeps the request ID already set")
+}
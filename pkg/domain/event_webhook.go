@@ -0,0 +1,207 @@
+package domain
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/fulcrumproject/core/pkg/properties"
+	"github.com/fulcrumproject/core/pkg/schema"
+)
+
+// WebhookRequest is the delivery of a single event to a subscription callback
+type WebhookRequest struct {
+	URL          string
+	SubscriberID string
+	EventID      properties.UUID
+	RequestID    string // Request ID of the event, empty when it has none
+	Body         []byte
+	ContentType  string // Media type of the body
+	Secret       string // Signing secret of the subscription, empty when payloads are not signed
+}
+
+// WebhookSender posts event payloads to subscription callbacks
+type WebhookSender interface {
+	// Send posts the request and returns the HTTP status code, an error is returned when no response was received
+	Send(ctx context.Context, req WebhookRequest) (int, error)
+}
+
+// EventWebhookPayload is the body posted to the callback for each event
+type EventWebhookPayload struct {
+	ID             properties.UUID  `json:"id"`
+	SequenceNumber int64            `json:"sequenceNumber"`
+	SubscriberID   string           `json:"subscriberId"`
+	InitiatorType  InitiatorType    `json:"initiatorType"`
+	InitiatorID    string           `json:"initiatorId"`
+	Type           EventType        `json:"type"`
+	Properties     properties.JSON  `json:"properties"`
+	EntityID       *properties.UUID `json:"entityId,omitempty"`
+	ProviderID     *properties.UUID `json:"providerId,omitempty"`
+	AgentID        *properties.UUID `json:"agentId,omitempty"`
+	ConsumerID     *properties.UUID `json:"consumerId,omitempty"`
+	CreatedAt      time.Time        `json:"createdAt"`
+}
+
+// NewEventWebhookPayload builds the webhook payload of an event
+func NewEventWebhookPayload(subscriberID string, event *Event) *EventWebhookPayload {
+	return &EventWebhookPayload{
+		ID:             event.ID,
+		SequenceNumber: event.SequenceNumber,
+		SubscriberID:   subscriberID,
+		InitiatorType:  event.InitiatorType,
+		InitiatorID:    event.InitiatorID,
+		Type:           event.Type,
+		Properties:     event.Payload,
+		EntityID:       event.EntityID,
+		ProviderID:     event.ProviderID,
+		AgentID:        event.AgentID,
+		ConsumerID:     event.ConsumerID,
+		CreatedAt:      event.CreatedAt.UTC(),
+	}
+}
+
+// webhookBody encodes an event in the format of the subscription and returns it with its media type
+func webhookBody(subscription *EventSubscription, event *Event) ([]byte, string, error) {
+	if subscription.EventFormat() == EventFormatCloudEvents {
+		body, err := json.Marshal(NewCloudEvent(event))
+		return body, CloudEventsContentType, err
+	}
+	body, err := json.Marshal(NewEventWebhookPayload(subscription.SubscriberID, event))
+	return body, "application/json", err
+}
+
+// EventWebhookDeliverer pushes new events to the subscriptions configured with a callback URL
+//
+// Events are posted one at a time in sequence order and the subscription only
+// advances past an event once the callback answered with a 2xx status, so every
+// event is delivered at least once and in order. A failed delivery stops the
+// subscription until its backoff elapses, after MaxAttempts failures it is
+// dead-lettered until delivery is re-enabled. Every attempt is recorded, only the
+// latest historySize attempts of each subscription are kept.
+type EventWebhookDeliverer struct {
+	store       Store
+	vault       schema.Vault
+	sender      WebhookSender
+	policy      WebhookRetryPolicy
+	batchSize   int
+	historySize int
+	now         func() time.Time
+}
+
+// NewEventWebhookDeliverer creates a deliverer posting up to batchSize events per subscription on each run
+// and keeping the latest historySize delivery attempts of each subscription
+// The vault resolves the signing secrets, it may be nil when no subscription has a secret
+func NewEventWebhookDeliverer(store Store, vault schema.Vault, sender WebhookSender, policy WebhookRetryPolicy, batchSize, historySize int) *EventWebhookDeliverer {
+	return &EventWebhookDeliverer{
+		store:       store,
+		vault:       vault,
+		sender:      sender,
+		policy:      policy,
+		batchSize:   batchSize,
+		historySize: historySize,
+		now:         time.Now,
+	}
+}
+
+// DeliverDue delivers the pending events of every due subscription and returns the number of delivered events
+func (d *EventWebhookDeliverer) DeliverDue(ctx context.Context) (int, error) {
+	subscriptions, err := d.store.EventSubscriptionRepo().ListDueWebhooks(ctx, d.now())
+	if err != nil {
+		return 0, err
+	}
+
+	delivered := 0
+	var errs []error
+	for _, subscription := range subscriptions {
+		count, err := d.deliver(ctx, subscription)
+		delivered += count
+		if err != nil {
+			errs = append(errs, fmt.Errorf("subscription %s: %w", subscription.SubscriberID, err))
+		}
+	}
+	return delivered, errors.Join(errs...)
+}
+
+// deliver posts the pending events of a subscription until one fails
+func (d *EventWebhookDeliverer) deliver(ctx context.Context, subscription *EventSubscription) (int, error) {
+	secret, err := d.secret(ctx, subscription)
+	if err != nil {
+		return 0, err
+	}
+
+	events, err := d.store.EventRepo().ListFromSequence(ctx, subscription.LastEventSequenceProcessed, subscription.Filter, d.batchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	delivered := 0
+	for _, event := range events {
+		body, contentType, err := webhookBody(subscription, event)
+		if err != nil {
+			return delivered, err
+		}
+
+		start := d.now()
+		status, sendErr := d.sender.Send(ctx, WebhookRequest{
+			URL:          *subscription.CallbackURL,
+			SubscriberID: subscription.SubscriberID,
+			EventID:      event.ID,
+			RequestID:    event.RequestID,
+			Body:         body,
+			ContentType:  contentType,
+			Secret:       secret,
+		})
+		now := d.now()
+		success := sendErr == nil && status >= 200 && status < 300
+		var lastStatus *int
+		if sendErr == nil {
+			lastStatus = &status
+			if !success {
+				sendErr = fmt.Errorf("callback returned status %d", status)
+			}
+		}
+
+		// The attempt is recorded before the subscription moves on, so its number counts the previous failures
+		delivery := NewEventDelivery(subscription, event, lastStatus, sendErr, now.Sub(start), now)
+		if err := d.store.EventSubscriptionRepo().RecordDelivery(ctx, delivery, d.historySize); err != nil {
+			return delivered, err
+		}
+
+		if success {
+			subscription.RecordDeliverySuccess(event.SequenceNumber, status, now)
+		} else {
+			subscription.RecordDeliveryFailure(lastStatus, sendErr, d.policy, now)
+		}
+
+		// The expected version protects against concurrent changes, e.g. a re-configuration during the delivery
+		if err := d.store.EventSubscriptionRepo().Save(WithExpectedVersion(ctx, subscription.ID, subscription.Version), subscription); err != nil {
+			return delivered, err
+		}
+		if !success {
+			return delivered, nil
+		}
+		delivered++
+	}
+	return delivered, nil
+}
+
+// secret resolves the signing secret of the subscription from the vault
+func (d *EventWebhookDeliverer) secret(ctx context.Context, subscription *EventSubscription) (string, error) {
+	if subscription.SecretRef == nil {
+		return "", nil
+	}
+	if d.vault == nil {
+		return "", fmt.Errorf("vault is required to sign webhook payloads but not configured")
+	}
+	value, err := d.vault.Get(ctx, *subscription.SecretRef)
+	if err != nil {
+		return "", err
+	}
+	secret, ok := value.(string)
+	if !ok {
+		return "", fmt.Errorf("webhook secret %s is not a string", *subscription.SecretRef)
+	}
+	return secret, nil
+}
@@ -0,0 +1,231 @@
+package domain
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/fulcrumproject/core/pkg/properties"
+	"github.com/fulcrumproject/core/pkg/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventWebhookDeliverer_DeliverDue(t *testing.T) {
+	callbackURL := "https://example.com/hook"
+	policy := WebhookRetryPolicy{MaxAttempts: 3, InitialBackoff: time.Second, MaxBackoff: time.Minute}
+	now := time.Now()
+
+	newEvents := func() []*Event {
+		return []*Event{
+			{BaseEntity: BaseEntity{ID: properties.NewUUID()}, SequenceNumber: 11, Type: EventTypeServiceCreated},
+			{BaseEntity: BaseEntity{ID: properties.NewUUID()}, SequenceNumber: 12, Type: EventTypeServiceUpdated},
+		}
+	}
+
+	var deliveries []*EventDelivery
+	setup := func(t *testing.T, subscription *EventSubscription, events []*Event) (*MockEventSubscriptionRepository, *MockWebhookSender, *EventWebhookDeliverer) {
+		deliveries = nil
+		store := NewMockStore(t)
+		subscriptionRepo := NewMockEventSubscriptionRepository(t)
+		eventRepo := NewMockEventRepository(t)
+		sender := NewMockWebhookSender(t)
+
+		store.EXPECT().EventSubscriptionRepo().Return(subscriptionRepo).Maybe()
+		store.EXPECT().EventRepo().Return(eventRepo).Maybe()
+		subscriptionRepo.EXPECT().ListDueWebhooks(mock.Anything, now).Return([]*EventSubscription{subscription}, nil)
+		eventRepo.EXPECT().ListFromSequence(mock.Anything, int64(10), subscription.Filter, 50).Return(events, nil)
+		subscriptionRepo.EXPECT().RecordDelivery(mock.Anything, mock.Anything, 20).RunAndReturn(func(ctx context.Context, d *EventDelivery, keep int) error {
+			deliveries = append(deliveries, d)
+			return nil
+		}).Maybe()
+
+		deliverer := NewEventWebhookDeliverer(store, nil, sender, policy, 50, 20)
+		deliverer.now = func() time.Time { return now }
+		return subscriptionRepo, sender, deliverer
+	}
+
+	newSubscription := func() *EventSubscription {
+		subscription := NewEventSubscription("test-subscriber")
+		subscription.ID = properties.NewUUID()
+		subscription.Version = 3
+		subscription.CallbackURL = &callbackURL
+		subscription.LastEventSequenceProcessed = 10
+		return subscription
+	}
+
+	t.Run("delivers events in sequence order", func(t *testing.T) {
+		subscription := newSubscription()
+		events := newEvents()
+		subscriptionRepo, sender, deliverer := setup(t, subscription, events)
+
+		var sent []int64
+		sender.EXPECT().Send(mock.Anything, mock.Anything).RunAndReturn(func(ctx context.Context, req WebhookRequest) (int, error) {
+			assert.Equal(t, callbackURL, req.URL)
+			assert.Equal(t, "test-subscriber", req.SubscriberID)
+			assert.Equal(t, "application/json", req.ContentType)
+			var payload EventWebhookPayload
+			require.NoError(t, json.Unmarshal(req.Body, &payload))
+			assert.Equal(t, req.EventID, payload.ID)
+			sent = append(sent, payload.SequenceNumber)
+			return 200, nil
+		}).Times(2)
+		subscriptionRepo.EXPECT().Save(mock.Anything, subscription).RunAndReturn(func(ctx context.Context, es *EventSubscription) error {
+			version, check := ExpectedVersion(ctx, es.ID)
+			assert.True(t, check)
+			assert.Equal(t, es.Version, version)
+			return nil
+		}).Times(2)
+
+		delivered, err := deliverer.DeliverDue(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, 2, delivered)
+		assert.Equal(t, []int64{11, 12}, sent)
+		assert.Equal(t, int64(12), subscription.LastEventSequenceProcessed)
+		assert.Equal(t, 200, *subscription.LastStatus)
+
+		require.Len(t, deliveries, 2)
+		for i, d := range deliveries {
+			assert.Equal(t, subscription.ID, d.SubscriptionID)
+			assert.Equal(t, events[i].ID, d.EventID)
+			assert.Equal(t, events[i].SequenceNumber, d.SequenceNumber)
+			assert.Equal(t, 1, d.Attempt)
+			assert.Equal(t, 200, *d.StatusCode)
+			assert.True(t, d.Success)
+			assert.Nil(t, d.Error)
+		}
+	})
+
+	t.Run("resolves the signing secret", func(t *testing.T) {
+		subscription := newSubscription()
+		ref := "secret-ref"
+		subscription.SecretRef = &ref
+		subscriptionRepo, sender, deliverer := setup(t, subscription, newEvents()[:1])
+		vault := schema.NewMockVault(t)
+		vault.EXPECT().Get(mock.Anything, ref).Return("s3cret", nil)
+		deliverer.vault = vault
+
+		sender.EXPECT().Send(mock.Anything, mock.MatchedBy(func(req WebhookRequest) bool {
+			return req.Secret == "s3cret"
+		})).Return(200, nil).Once()
+		subscriptionRepo.EXPECT().Save(mock.Anything, subscription).Return(nil).Once()
+
+		delivered, err := deliverer.DeliverDue(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, 1, delivered)
+	})
+
+	t.Run("posts CloudEvents when the subscription asks for them", func(t *testing.T) {
+		subscription := newSubscription()
+		subscription.Format = EventFormatCloudEvents
+		events := newEvents()[:1]
+		subscriptionRepo, sender, deliverer := setup(t, subscription, events)
+
+		sender.EXPECT().Send(mock.Anything, mock.Anything).RunAndReturn(func(ctx context.Context, req WebhookRequest) (int, error) {
+			assert.Equal(t, CloudEventsContentType, req.ContentType)
+			var ce CloudEvent
+			require.NoError(t, json.Unmarshal(req.Body, &ce))
+			assert.Equal(t, events[0].ID.String(), ce.ID)
+			assert.Equal(t, "fulcrum.service.created", ce.Type)
+			return 200, nil
+		}).Once()
+		subscriptionRepo.EXPECT().Save(mock.Anything, subscription).Return(nil).Once()
+
+		delivered, err := deliverer.DeliverDue(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, 1, delivered)
+	})
+
+	t.Run("reads the events matching the subscription filter", func(t *testing.T) {
+		subscription := newSubscription()
+		providerID := properties.NewUUID()
+		subscription.Filter = NewEventFilter([]EventType{EventTypeServiceCreated}, nil, &providerID, nil)
+		// The repository applies the filter, the expectation of setup only matches with it
+		subscriptionRepo, sender, deliverer := setup(t, subscription, newEvents()[:1])
+
+		sender.EXPECT().Send(mock.Anything, mock.Anything).Return(200, nil).Once()
+		subscriptionRepo.EXPECT().Save(mock.Anything, subscription).Return(nil).Once()
+
+		delivered, err := deliverer.DeliverDue(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, 1, delivered)
+	})
+
+	t.Run("stops at the first failure", func(t *testing.T) {
+		subscription := newSubscription()
+		subscriptionRepo, sender, deliverer := setup(t, subscription, newEvents())
+
+		sender.EXPECT().Send(mock.Anything, mock.Anything).Return(503, nil).Once()
+		subscriptionRepo.EXPECT().Save(mock.Anything, subscription).Return(nil).Once()
+
+		delivered, err := deliverer.DeliverDue(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, 0, delivered)
+		assert.Equal(t, int64(10), subscription.LastEventSequenceProcessed)
+		assert.Equal(t, 1, subscription.FailureCount)
+		assert.Equal(t, 503, *subscription.LastStatus)
+		assert.Equal(t, now.Add(time.Second), *subscription.NextDeliveryAt)
+
+		require.Len(t, deliveries, 1)
+		assert.Equal(t, 1, deliveries[0].Attempt)
+		assert.Equal(t, 503, *deliveries[0].StatusCode)
+		assert.False(t, deliveries[0].Success)
+		assert.Equal(t, "callback returned status 503", *deliveries[0].Error)
+	})
+
+	t.Run("dead-letters after the last attempt", func(t *testing.T) {
+		subscription := newSubscription()
+		subscription.FailureCount = 2
+		subscriptionRepo, sender, deliverer := setup(t, subscription, newEvents())
+
+		sender.EXPECT().Send(mock.Anything, mock.Anything).Return(0, errors.New("connection refused")).Once()
+		subscriptionRepo.EXPECT().Save(mock.Anything, subscription).Return(nil).Once()
+
+		delivered, err := deliverer.DeliverDue(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, 0, delivered)
+		assert.True(t, subscription.IsDeadLettered())
+		assert.Nil(t, subscription.LastStatus)
+		assert.Equal(t, "connection refused", *subscription.LastError)
+
+		require.Len(t, deliveries, 1)
+		assert.Equal(t, 3, deliveries[0].Attempt)
+		assert.Nil(t, deliveries[0].StatusCode)
+		assert.Equal(t, "connection refused", *deliveries[0].Error)
+	})
+
+	t.Run("reports save errors", func(t *testing.T) {
+		subscription := newSubscription()
+		subscriptionRepo, sender, deliverer := setup(t, subscription, newEvents())
+
+		sender.EXPECT().Send(mock.Anything, mock.Anything).Return(200, nil).Once()
+		subscriptionRepo.EXPECT().Save(mock.Anything, subscription).Return(NewPreconditionFailedErrorf(4, "version 3 does not match current version 4")).Once()
+
+		delivered, err := deliverer.DeliverDue(context.Background())
+		assert.ErrorContains(t, err, "subscription test-subscriber")
+		assert.Equal(t, 0, delivered)
+	})
+}
+
+func TestNewEventDeliverySummary(t *testing.T) {
+	lastSuccess := time.Now()
+	subscription := NewEventSubscription("test-subscriber")
+
+	summary := NewEventDeliverySummary(subscription, &EventDeliveryStats{})
+	assert.Nil(t, summary.SuccessRate, "no rate without attempts")
+	assert.Equal(t, 0, summary.PendingRetries)
+
+	subscription.FailureCount = 2
+	summary = NewEventDeliverySummary(subscription, &EventDeliveryStats{Attempts: 4, Successes: 3, LastSuccessAt: &lastSuccess})
+	require.NotNil(t, summary.SuccessRate)
+	assert.InDelta(t, 0.75, *summary.SuccessRate, 1e-9)
+	assert.Equal(t, &lastSuccess, summary.LastSuccessAt)
+	assert.Equal(t, 1, summary.PendingRetries)
+
+	subscription.DeadLetteredAt = &lastSuccess
+	summary = NewEventDeliverySummary(subscription, &EventDeliveryStats{Attempts: 4, Successes: 3})
+	assert.Equal(t, 0, summary.PendingRetries, "dead-lettered deliveries are not retried")
+}
@@ -0,0 +1,32 @@
+// Idempotency keys of the API requests
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// IdempotencyRecord is the outcome of a request sent with an idempotency key
+type IdempotencyRecord struct {
+	RequestHash string
+	StatusCode  int // Zero while the request is in progress
+	Body        []byte
+	ReservedAt  time.Time // When the request in progress took the key
+}
+
+// IdempotencyStore keeps the outcome of the requests by identity and key until they expire
+//
+// A reservation is identified by the token returned by Reserve, Complete and Release return a
+// ConflictError when the key was taken over by another request in the meantime.
+type IdempotencyStore interface {
+	// Reserve stores an in progress record for the key and returns its reservation token, when the key is already used by an unexpired
+	// record it returns that record. An in progress record of the same request reserved before staleBefore was left by a request that
+	// never finished, the key is taken over instead.
+	Reserve(ctx context.Context, scope, key, requestHash string, expiresAt, staleBefore time.Time) (string, *IdempotencyRecord, error)
+	// Complete stores the response of the request holding the reservation
+	Complete(ctx context.Context, scope, key, token string, statusCode int, body []byte) error
+	// Release removes the record of the reservation so that the request can be retried
+	Release(ctx context.Context, scope, key, token string) error
+	// PurgeExpired removes the records expired at the given time and returns how many were removed
+	PurgeExpired(ctx context.Context, at time.Time) (int64, error)
+}
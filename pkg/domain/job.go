@@ -6,29 +6,49 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/fulcrumproject/core/pkg/auth"
 	"github.com/fulcrumproject/core/pkg/properties"
 	"github.com/fulcrumproject/core/pkg/schema"
+	"github.com/fulcrumproject/core/pkg/tracing"
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
 // JobStatus represents the current status of a job
 type JobStatus string
 
 const (
+	JobScheduled  JobStatus = "Scheduled"
 	JobPending    JobStatus = "Pending"
 	JobProcessing JobStatus = "Processing"
 	JobCompleted  JobStatus = "Completed"
 	JobFailed     JobStatus = "Failed"
+	JobCancelled  JobStatus = "Cancelled"
+	// JobDeadLettered is terminal, the action failed on every attempt and waits for an operator to requeue it
+	JobDeadLettered JobStatus = "DeadLettered"
 )
 
+// Event types
+const (
+	EventTypeJobDeadLettered EventType = "job.dead_lettered"
+	EventTypeJobRequeued     EventType = "job.requeued"
+	EventTypeJobReclaimed    EventType = "job.reclaimed"
+)
+
+// LeaseExpiredMessage is the error message of the jobs taken back from an agent that stopped renewing their lease
+const LeaseExpiredMessage = "Job lease expired before the agent reported its outcome"
+
 // Validate checks if the service status is valid
 func (s JobStatus) Validate() error {
 	switch s {
 	case
+		JobScheduled,
 		JobPending,
 		JobProcessing,
 		JobCompleted,
-		JobFailed:
+		JobFailed,
+		JobCancelled,
+		JobDeadLettered:
 		return nil
 	default:
 		return fmt.Errorf("invalid job status: %s", s)
@@ -44,6 +64,29 @@ func ParseJobStatus(s string) (JobStatus, error) {
 	return status, nil
 }
 
+// Job priorities, agents receive the jobs with the highest priority first
+const (
+	JobPriorityLow    = 1
+	JobPriorityNormal = 2
+	JobPriorityHigh   = 3
+	JobPriorityUrgent = 4
+)
+
+// DefaultJobPriority returns the priority of the jobs of an action
+// Deletes and stops drain before other operations so a backlog frees resources before creating new ones
+func DefaultJobPriority(action string) int {
+	switch action {
+	case "delete":
+		return JobPriorityUrgent
+	case ServiceActionStop:
+		return JobPriorityHigh
+	case "create":
+		return JobPriorityLow
+	default:
+		return JobPriorityNormal
+	}
+}
+
 // Job represents a task to be executed by an agent
 type Job struct {
 	BaseEntity
@@ -51,15 +94,34 @@ type Job struct {
 	Action   string           `gorm:"type:varchar(50);not null"`
 	Params   *properties.JSON `gorm:"type:jsonb"`
 	Priority int              `gorm:"not null;default:1"`
+	Attempt  int              `gorm:"not null;default:1"` // Consecutive attempts of the action, starting at 1
+	// State the service is driven to once the job completes, the follow-on job of the next action
+	// is created on completion and carries the target on; a failed job ends the sequence
+	TargetState *string `gorm:"type:varchar(50)"`
+	// Request, agent call or worker run that created the job, see BeforeCreate
+	RequestID string `gorm:"type:varchar(128)"`
 
 	// Status management
-	Status       JobStatus  `gorm:"type:varchar(20);not null"`
+	Status       JobStatus  `gorm:"type:varchar(20);not null;index:job_agent_status,priority:2"`
 	ErrorMessage string     `gorm:"type:text"`
-	ClaimedAt    *time.Time `gorm:""`
-	CompletedAt  *time.Time `gorm:""`
+	ScheduledAt  *time.Time `gorm:"index"`
+	RequeuedAt   *time.Time `gorm:""`
+	ClaimedAt    *time.Time `gorm:""`      // Start of the execution by the agent
+	CompletedAt  *time.Time `gorm:"index"` // End of the execution or cancellation
+
+	// Diagnostics reported by the agent with the outcome, redacted of the sensitive properties, see SetDiagnostics
+	Diagnostics *properties.JSON `gorm:"type:jsonb"`
+
+	// Steps reported by the agent while processing a multi-step action, see ReportStep
+	Steps      []JobStep  `gorm:"type:jsonb;serializer:json"`
+	LastStepAt *time.Time `gorm:""`
+
+	// Lease held by the agent processing the job, renewed while the agent works on it
+	LeaseID        *properties.UUID `gorm:"type:uuid"`
+	LeaseExpiresAt *time.Time       `gorm:"index"`
 
 	// Relationships
-	AgentID    properties.UUID `gorm:"not null"`
+	AgentID    properties.UUID `gorm:"not null;index:job_agent_status,priority:1"`
 	Agent      *Agent          `gorm:"foreignKey:AgentID"`
 	ServiceID  properties.UUID `gorm:"not null"`
 	Service    *Service        `gorm:"foreignKey:ServiceID"`
@@ -105,9 +167,81 @@ func NewJob(svc *Service, action string, params *properties.JSON, priority int)
 		Action:     action,
 		Params:     params,
 		Priority:   priority,
+		Attempt:    1,
 	}
 }
 
+// BeforeCreate records the request ID of the context the job is created with, a follow-on job
+// carries the ID of the agent call completing the job before it
+func (j *Job) BeforeCreate(tx *gorm.DB) error {
+	if j.RequestID == "" {
+		j.RequestID = tracing.RequestID(tx.Statement.Context)
+	}
+	return nil
+}
+
+// Schedule defers the job until the given time, agents won't see it before it's promoted
+func (j *Job) Schedule(at time.Time) error {
+	if j.Status != JobPending {
+		return fmt.Errorf("cannot schedule a job not in pending status")
+	}
+	if !at.After(time.Now()) {
+		return fmt.Errorf("scheduled time must be in the future")
+	}
+	j.Status = JobScheduled
+	j.ScheduledAt = &at
+	return nil
+}
+
+// DeferRetry holds a job retrying a failed attempt of its action until the backoff of its attempt elapsed
+// The job is scheduled like a deferred action, so the agents don't see it before it is promoted; a job
+// already scheduled later keeps its time
+func (j *Job) DeferRetry(policy JobRetryPolicy, now time.Time) {
+	delay := policy.Backoff(j.Attempt)
+	if delay <= 0 || (j.Status != JobPending && j.Status != JobScheduled) {
+		return
+	}
+	at := now.Add(delay)
+	if j.ScheduledAt != nil && j.ScheduledAt.After(at) {
+		return
+	}
+	j.Status = JobScheduled
+	j.ScheduledAt = &at
+}
+
+// Promote makes a scheduled job available to the agent
+func (j *Job) Promote() error {
+	if j.Status != JobScheduled {
+		return fmt.Errorf("cannot promote a job not in scheduled status")
+	}
+	j.Status = JobPending
+	return nil
+}
+
+// CancelSchedule cancels a scheduled job that will never be promoted, it never ran so it uses up no attempt
+func (j *Job) CancelSchedule(reason string) error {
+	if j.Status != JobScheduled {
+		return fmt.Errorf("cannot cancel a job not in scheduled status")
+	}
+	j.Status = JobCancelled
+	j.ErrorMessage = reason
+	now := time.Now()
+	j.CompletedAt = &now
+	return nil
+}
+
+// SetPriority changes the priority of a job not yet claimed
+func (j *Job) SetPriority(priority int) error {
+	if j.Status != JobPending && j.Status != JobScheduled {
+		return fmt.Errorf("cannot change the priority of a job not in pending or scheduled status")
+	}
+	if priority < 1 {
+		return fmt.Errorf("priority must be greater than 0")
+	}
+	j.Priority = priority
+	return nil
+}
+
 // Claim marks a job as claimed by an agent
 func (j *Job) Claim() error {
 	if j.Status != JobPending {
@@ -119,6 +253,77 @@ func (j *Job) Claim() error {
 	return nil
 }
 
+// GrantLease gives the claiming agent ownership of the processing job until the lease expires
+// Every claim issues a new lease ID, reports carrying a previous one are rejected
+func (j *Job) GrantLease(duration time.Duration) error {
+	if j.Status != JobProcessing {
+		return fmt.Errorf("cannot lease a job not in processing status")
+	}
+	leaseID := properties.NewUUID()
+	expiresAt := time.Now().Add(duration)
+	j.LeaseID = &leaseID
+	j.LeaseExpiresAt = &expiresAt
+	return nil
+}
+
+// RenewLease extends the lease held by the agent processing the job
+func (j *Job) RenewLease(leaseID properties.UUID, duration time.Duration) error {
+	if j.Status != JobProcessing {
+		return fmt.Errorf("cannot renew the lease of a job not in processing status")
+	}
+	if j.LeaseID == nil {
+		return fmt.Errorf("job has no lease to renew")
+	}
+	if err := j.CheckLease(&leaseID); err != nil {
+		return err
+	}
+	expiresAt := time.Now().Add(duration)
+	j.LeaseExpiresAt = &expiresAt
+	return nil
+}
+
+// CheckLease verifies that a report comes from the holder of the job lease
+// Jobs claimed without a lease accept any report
+func (j *Job) CheckLease(leaseID *properties.UUID) error {
+	if j.LeaseID == nil {
+		return nil
+	}
+	if leaseID == nil {
+		return fmt.Errorf("job is leased, the lease ID is required")
+	}
+	if *leaseID != *j.LeaseID {
+		return fmt.Errorf("lease %s is not the current lease of the job", *leaseID)
+	}
+	return nil
+}
+
+// IsLeaseExpired reports whether the job is processing under a lease that expired at the given time
+func (j *Job) IsLeaseExpired(at time.Time) bool {
+	return j.Status == JobProcessing && j.LeaseExpiresAt != nil && j.LeaseExpiresAt.Before(at)
+}
+
+// ReclaimLease takes a job back from an agent that stopped renewing its lease, it becomes pending for a new attempt
+func (j *Job) ReclaimLease() error {
+	if j.Status != JobProcessing || j.LeaseID == nil {
+		return fmt.Errorf("cannot reclaim a job not processing under a lease")
+	}
+	j.Status = JobPending
+	j.Attempt++
+	j.ErrorMessage = LeaseExpiredMessage
+	now := time.Now()
+	j.RequeuedAt = &now
+	j.ClaimedAt = nil
+	j.resetSteps()
+	j.releaseLease()
+	return nil
+}
+
+// releaseLease drops the lease of a job that is no longer processed
+func (j *Job) releaseLease() {
+	j.LeaseID = nil
+	j.LeaseExpiresAt = nil
+}
+
 // Complete marks a job as successfully completed
 func (j *Job) Complete() error {
 	if j.Status != JobProcessing {
@@ -127,6 +332,7 @@ func (j *Job) Complete() error {
 	j.Status = JobCompleted
 	now := time.Now()
 	j.CompletedAt = &now
+	j.releaseLease()
 	return nil
 }
 
@@ -137,9 +343,68 @@ func (j *Job) Fail(errorMessage string) error {
 	}
 	j.Status = JobFailed
 	j.ErrorMessage = errorMessage
+	now := time.Now()
+	j.CompletedAt = &now
+	j.releaseLease()
+	return nil
+}
+
+// Cancel aborts an active job, any later report from the agent will be rejected
+func (j *Job) Cancel(reason string) error {
+	if !j.IsActive() {
+		return fmt.Errorf("cannot cancel a job not in pending or processing status")
+	}
+	j.Status = JobCancelled
+	j.ErrorMessage = reason
+	now := time.Now()
+	j.CompletedAt = &now
+	j.releaseLease()
+	return nil
+}
+
+// ShouldDeadLetter reports whether a failed job used up its attempts, a zero max disables dead-lettering
+func (j *Job) ShouldDeadLetter(maxAttempts int) bool {
+	return j.Status == JobFailed && maxAttempts > 0 && j.Attempt >= maxAttempts
+}
+
+// DeadLetter parks a failed job that will not be retried automatically
+func (j *Job) DeadLetter() error {
+	if j.Status != JobFailed {
+		return fmt.Errorf("cannot dead-letter a job not in failed status")
+	}
+	j.Status = JobDeadLettered
+	if j.CompletedAt == nil {
+		now := time.Now()
+		j.CompletedAt = &now
+	}
+	return nil
+}
+
+// Requeue makes a dead-lettered job available to the agent again with a fresh attempt counter
+func (j *Job) Requeue() error {
+	if j.Status != JobDeadLettered {
+		return fmt.Errorf("cannot requeue a job not in dead-lettered status")
+	}
+	j.Status = JobPending
+	j.Attempt = 1
+	j.ErrorMessage = ""
+	now := time.Now()
+	j.RequeuedAt = &now
+	j.ClaimedAt = nil
+	j.CompletedAt = nil
+	j.resetSteps()
 	return nil
 }
 
+// Duration returns the execution time of a finished job, from its claim by the agent to its completion
+// Jobs never claimed or still in flight have no duration
+func (j *Job) Duration() (time.Duration, bool) {
+	if j.ClaimedAt == nil || j.CompletedAt == nil || j.IsActive() {
+		return 0, false
+	}
+	return max(j.CompletedAt.Sub(*j.ClaimedAt), 0), true
+}
+
 // IsActive checks if the job is active (blocks new job attempts for the same service)
 func (j *Job) IsActive() bool {
 	return j.Status == JobProcessing || j.Status == JobPending
@@ -147,14 +412,29 @@ func (j *Job) IsActive() bool {
 
 // JobCommander defines the interface for job command operations
 type JobCommander interface {
-	// Claim claims a job for an agent
-	Claim(ctx context.Context, jobID properties.UUID) error
+	// Claim claims a job for an agent, granting it a lease when leasing is enabled
+	Claim(ctx context.Context, jobID properties.UUID) (*Job, error)
+
+	// RenewLease extends the lease of a job held by the calling agent
+	RenewLease(ctx context.Context, params RenewJobLeaseParams) (*Job, error)
+
+	// ReclaimExpiredLeases makes the processing jobs whose lease expired pending again and returns their number
+	ReclaimExpiredLeases(ctx context.Context) (int, error)
 
 	// Complete marks a job as completed
 	Complete(ctx context.Context, params CompleteJobParams) error
 
 	// Fail marks a job as failed
 	Fail(ctx context.Context, params FailJobParams) error
+
+	// ReportStep records a step of a multi-step action, a failed step fails the job and the final step completes it
+	ReportStep(ctx context.Context, params ReportJobStepParams) (*Job, error)
+
+	// UpdatePriority changes the priority of a pending or scheduled job
+	UpdatePriority(ctx context.Context, params UpdateJobPriorityParams) (*Job, error)
+
+	// Requeue makes a dead-lettered job pending again
+	Requeue(ctx context.Context, jobID properties.UUID) (*Job, error)
 }
 
 type CompleteJobParams struct {
@@ -162,39 +442,196 @@ type CompleteJobParams struct {
 	AgentInstanceData *properties.JSON `json:"agentInstanceData"`
 	AgentInstanceID   *string          `json:"agentInstanceId"`
 	Properties        map[string]any   `json:"properties,omitempty"`
+	LeaseID           *properties.UUID `json:"leaseId,omitempty"`
+	Diagnostics       *properties.JSON `json:"diagnostics,omitempty"`
 }
 
 type FailJobParams struct {
-	JobID        properties.UUID `json:"jobId"`
-	ErrorMessage string          `json:"errorMessage"`
+	JobID        properties.UUID  `json:"jobId"`
+	ErrorMessage string           `json:"errorMessage"`
+	LeaseID      *properties.UUID `json:"leaseId,omitempty"`
+	// Diagnostics are structured details of the failure, such as logs, stack traces or provider error codes
+	Diagnostics *properties.JSON `json:"diagnostics,omitempty"`
+}
+
+type RenewJobLeaseParams struct {
+	JobID   properties.UUID `json:"jobId"`
+	LeaseID properties.UUID `json:"leaseId"`
+}
+
+type UpdateJobPriorityParams struct {
+	JobID    properties.UUID `json:"jobId"`
+	Priority int             `json:"priority"`
 }
 
 // jobCommander is the concrete implementation of JobCommander
 type jobCommander struct {
-	store  Store
-	engine *schema.Engine[ServicePropertyContext]
+	store         Store
+	engine        *schema.Engine[ServicePropertyContext]
+	maxAttempts   int
+	leaseDuration time.Duration
+	retry         JobRetryPolicy
 }
 
 // NewJobCommander creates a new command executor
+// Failed jobs are dead-lettered after maxAttempts consecutive attempts of their action, zero disables it.
+// Claimed jobs are leased for leaseDuration, zero disables leasing. The jobs reclaimed from an expired
+// lease are retried after the backoff of the retry policy.
 func NewJobCommander(
 	store Store,
 	engine *schema.Engine[ServicePropertyContext],
+	maxAttempts int,
+	leaseDuration time.Duration,
+	retry JobRetryPolicy,
 ) *jobCommander {
 	return &jobCommander{
-		store:  store,
-		engine: engine,
+		store:         store,
+		engine:        engine,
+		maxAttempts:   maxAttempts,
+		leaseDuration: leaseDuration,
+		retry:         retry,
 	}
 }
 
-func (s *jobCommander) Claim(ctx context.Context, jobID properties.UUID) error {
+func (s *jobCommander) Claim(ctx context.Context, jobID properties.UUID) (*Job, error) {
 	job, err := s.store.JobRepo().Get(ctx, jobID)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	if err := job.Claim(); err != nil {
-		return InvalidInputError{Err: err}
+		return nil, InvalidInputError{Err: err}
+	}
+	if s.leaseDuration > 0 {
+		if err := job.GrantLease(s.leaseDuration); err != nil {
+			return nil, InvalidInputError{Err: err}
+		}
+	}
+	// Concurrent polls may have returned the same job or more jobs than the agent can process,
+	// only the claims that find the job pending and a free slot win
+	claimed, err := s.store.JobRepo().ClaimIfCapacity(ctx, job)
+	if err != nil {
+		return nil, err
 	}
-	return s.store.JobRepo().Save(ctx, job)
+	if !claimed {
+		current, err := s.store.JobRepo().Get(ctx, jobID)
+		if err != nil {
+			return nil, err
+		}
+		if current.Status == JobPending {
+			return nil, NewConflictErrorf("agent %s has reached its limit of concurrent jobs", job.AgentID)
+		}
+		return nil, NewConflictErrorf("job %s has already been claimed", jobID)
+	}
+	return job, nil
+}
+
+func (s *jobCommander) RenewLease(ctx context.Context, params RenewJobLeaseParams) (*Job, error) {
+	job, err := s.store.JobRepo().Get(ctx, params.JobID)
+	if err != nil {
+		return nil, err
+	}
+	// Only the agent the job is assigned to can hold its lease
+	if err := checkJobAgent(ctx, job); err != nil {
+		return nil, err
+	}
+	if err := job.RenewLease(params.LeaseID, s.leaseDuration); err != nil {
+		return nil, NewConflictErrorf("cannot renew the lease of job %s: %v", job.ID, err)
+	}
+	// The lease may have been reclaimed since the job was read
+	saved, err := s.store.JobRepo().SaveIfLeaseHeld(ctx, job, params.LeaseID)
+	if err != nil {
+		return nil, err
+	}
+	if !saved {
+		return nil, NewConflictErrorf("lease %s of job %s is no longer held", params.LeaseID, job.ID)
+	}
+	return job, nil
+}
+
+func (s *jobCommander) ReclaimExpiredLeases(ctx context.Context) (int, error) {
+	now := time.Now()
+	expiredJobs, err := s.store.JobRepo().GetExpiredLeaseJobs(ctx, now)
+	if err != nil {
+		return 0, fmt.Errorf("failed to retrieve jobs with expired leases: %w", err)
+	}
+
+	counter := 0
+	for _, job := range expiredJobs {
+		leaseID := *job.LeaseID
+		reclaimed := false
+		err := s.store.Atomic(ctx, func(store Store) error {
+			// The last attempt fails the job instead, it is dead-lettered like any exhausted job
+			exhausted := s.maxAttempts > 0 && job.Attempt >= s.maxAttempts
+			if exhausted {
+				if err := job.Fail(LeaseExpiredMessage); err != nil {
+					return err
+				}
+			} else {
+				if err := job.ReclaimLease(); err != nil {
+					return err
+				}
+				job.DeferRetry(s.retry, now)
+			}
+			// The agent may have renewed the lease or reported the outcome since the job was read
+			saved, err := store.JobRepo().SaveIfLeaseExpired(ctx, job, leaseID, now)
+			if err != nil || !saved {
+				return err
+			}
+			if exhausted {
+				if err := deadLetterExhaustedJob(ctx, store, job, s.maxAttempts); err != nil {
+					return err
+				}
+				if err := store.JobRepo().Save(ctx, job); err != nil {
+					return err
+				}
+				if job.Status == JobDeadLettered {
+					if err := failDeadLetteredService(ctx, store, job); err != nil {
+						return err
+					}
+				}
+			}
+			reclaimed = true
+			eventEntry, err := NewEvent(EventTypeJobReclaimed, WithJob(job))
+			if err != nil {
+				return err
+			}
+			eventEntry.Payload = properties.JSON{
+				"serviceId": job.ServiceID,
+				"action":    job.Action,
+				"attempt":   job.Attempt,
+				"leaseId":   leaseID,
+				"status":    job.Status,
+			}
+			return store.EventRepo().Create(ctx, eventEntry)
+		})
+		if err != nil {
+			return counter, err
+		}
+		if reclaimed {
+			counter++
+		}
+	}
+
+	return counter, nil
+}
+
+func (s *jobCommander) UpdatePriority(ctx context.Context, params UpdateJobPriorityParams) (*Job, error) {
+	job, err := s.store.JobRepo().Get(ctx, params.JobID)
+	if err != nil {
+		return nil, err
+	}
+	if err := job.SetPriority(params.Priority); err != nil {
+		return nil, InvalidInputError{Err: err}
+	}
+	// The job may have been claimed in the meantime
+	saved, err := s.store.JobRepo().SaveIfStatus(ctx, job, job.Status)
+	if err != nil {
+		return nil, err
+	}
+	if !saved {
+		return nil, NewConflictErrorf("job %s has changed status", params.JobID)
+	}
+	return job, nil
 }
 
 func (s *jobCommander) Complete(ctx context.Context, params CompleteJobParams) error {
@@ -202,6 +639,17 @@ func (s *jobCommander) Complete(ctx context.Context, params CompleteJobParams) e
 	if err != nil {
 		return err
 	}
+	if job.Status == JobCancelled {
+		return NewConflictErrorf("job %s has been cancelled", job.ID)
+	}
+	if err := job.CheckLease(params.LeaseID); err != nil {
+		return NewConflictErrorf("cannot complete job %s: %v", job.ID, err)
+	}
+	return s.complete(ctx, job, params)
+}
+
+// complete records the completion of a job checked to be reported by its lease holder and transitions its service
+func (s *jobCommander) complete(ctx context.Context, job *Job, params CompleteJobParams) error {
 	svc, err := s.store.ServiceRepo().Get(ctx, job.ServiceID)
 	if err != nil {
 		return err
@@ -214,12 +662,23 @@ func (s *jobCommander) Complete(ctx context.Context, params CompleteJobParams) e
 		return err
 	}
 
+	leaseID := job.LeaseID
 	return s.store.Atomic(ctx, func(store Store) error {
+		// The reported instance ID must not be held by another service of the agent
+		if params.AgentInstanceID != nil {
+			if err := checkAgentInstanceIDAvailable(ctx, store, svc, *params.AgentInstanceID); err != nil {
+				return err
+			}
+		}
+
 		// Update job
 		if err := job.Complete(); err != nil {
 			return InvalidInputError{Err: err}
 		}
-		if err := store.JobRepo().Save(ctx, job); err != nil {
+		if err := job.SetDiagnostics(params.Diagnostics, serviceType.PropertySchema); err != nil {
+			return InvalidInputError{Err: err}
+		}
+		if err := saveLeasedJob(ctx, store, job, leaseID); err != nil {
 			return err
 		}
 
@@ -236,7 +695,12 @@ func (s *jobCommander) Complete(ctx context.Context, params CompleteJobParams) e
 		}
 
 		// Clear agent instance ID if service reached a terminal state to allow infrastructure ID reuse (e.g., Proxmox VM IDs)
-		if serviceType.LifecycleSchema.IsTerminalState(svc.Status) {
+		// A completed delete keeps the row and its instance ID aside for a restore until the service is purged
+		terminal := serviceType.LifecycleSchema.IsTerminalState(svc.Status)
+		softDeleted := terminal && job.Action == ServiceActionDelete
+		if softDeleted {
+			svc.SoftDelete(originalSvc.Status)
+		} else if terminal {
 			svc.AgentInstanceID = nil
 		}
 
@@ -252,8 +716,8 @@ func (s *jobCommander) Complete(ctx context.Context, params CompleteJobParams) e
 			s.engine.CleanupEphemeralSecrets(ctx, serviceType.PropertySchema, map[string]any(*svc.Properties))
 		}
 
-		// Release pool allocations if service reached a terminal state
-		if serviceType.LifecycleSchema.IsTerminalState(svc.Status) {
+		// Release pool allocations if service reached a terminal state, soft-deleted services release them when purged
+		if terminal && !softDeleted {
 			if err := store.ServicePoolValueRepo().ReleaseByService(ctx, svc.ID); err != nil {
 				return fmt.Errorf("failed to release pool values: %w", err)
 			}
@@ -273,15 +737,46 @@ func (s *jobCommander) Complete(ctx context.Context, params CompleteJobParams) e
 		if err := store.EventRepo().Create(ctx, eventEntry); err != nil {
 			return err
 		}
-		return err
+
+		return queueTargetStateJob(ctx, store, serviceType.LifecycleSchema, svc, job)
 	})
 }
 
+// queueTargetStateJob creates the job of the next action driving the service toward the target
+// state of the completed job, the sequence ends when the target is reached or no longer reachable,
+// or when the service was put in maintenance meanwhile
+func queueTargetStateJob(ctx context.Context, store Store, lifecycle LifecycleSchema, svc *Service, completed *Job) error {
+	if completed.TargetState == nil || svc.IsDeleted() || svc.Maintenance || lifecycle.IsTerminalState(svc.Status) {
+		return nil
+	}
+	path, err := lifecycle.PathTo(svc.Status, *completed.TargetState)
+	if err != nil || len(path) == 0 {
+		return nil
+	}
+	job := NewJob(svc, path[0], nil, DefaultJobPriority(path[0]))
+	job.TargetState = completed.TargetState
+	if err := job.Validate(); err != nil {
+		return err
+	}
+	return store.JobRepo().Create(ctx, job)
+}
+
 func (s *jobCommander) Fail(ctx context.Context, params FailJobParams) error {
 	job, err := s.store.JobRepo().Get(ctx, params.JobID)
 	if err != nil {
 		return err
 	}
+	if job.Status == JobCancelled {
+		return NewConflictErrorf("job %s has been cancelled", job.ID)
+	}
+	if err := job.CheckLease(params.LeaseID); err != nil {
+		return NewConflictErrorf("cannot fail job %s: %v", job.ID, err)
+	}
+	return s.fail(ctx, job, params)
+}
+
+// fail records the failure of a job checked to be reported by its lease holder and transitions its service
+func (s *jobCommander) fail(ctx context.Context, job *Job, params FailJobParams) error {
 	svc, err := s.store.ServiceRepo().Get(ctx, job.ServiceID)
 	if err != nil {
 		return err
@@ -294,12 +789,19 @@ func (s *jobCommander) Fail(ctx context.Context, params FailJobParams) error {
 		return err
 	}
 
+	leaseID := job.LeaseID
 	return s.store.Atomic(ctx, func(store Store) error {
 		// Update job
 		if err := job.Fail(params.ErrorMessage); err != nil {
 			return InvalidInputError{Err: err}
 		}
-		if err := store.JobRepo().Save(ctx, job); err != nil {
+		if err := job.SetDiagnostics(params.Diagnostics, serviceType.PropertySchema); err != nil {
+			return InvalidInputError{Err: err}
+		}
+		if err := deadLetterExhaustedJob(ctx, store, job, s.maxAttempts); err != nil {
+			return err
+		}
+		if err := saveLeasedJob(ctx, store, job, leaseID); err != nil {
 			return err
 		}
 
@@ -312,7 +814,12 @@ func (s *jobCommander) Fail(ctx context.Context, params FailJobParams) error {
 		if transitionErr != nil && !errors.Is(transitionErr, ErrNoLifecycleTransition) {
 			return InvalidInputError{Err: transitionErr}
 		}
-		if transitionErr == nil {
+		transitioned := transitionErr == nil
+		// The action will not be retried, the service is marked failed whatever the error transitions gave it
+		if job.Status == JobDeadLettered && svc.MarkFailed(serviceType.LifecycleSchema) {
+			transitioned = true
+		}
+		if transitioned {
 			if err := svc.Validate(); err != nil {
 				return InvalidInputError{Err: err}
 			}
@@ -342,27 +849,263 @@ func (s *jobCommander) Fail(ctx context.Context, params FailJobParams) error {
 		if err := store.EventRepo().Create(ctx, eventEntry); err != nil {
 			return err
 		}
-		return nil
+
+		// The failure has its own event so the participants can be notified of it
+		failedEntry, err := NewEvent(EventTypeServiceFailed, WithInitiatorCtx(ctx), WithService(svc))
+		if err != nil {
+			return err
+		}
+		failedEntry.Payload = properties.JSON{
+			"jobId":        job.ID,
+			"action":       job.Action,
+			"errorMessage": params.ErrorMessage,
+			"status":       svc.Status,
+		}
+		return store.EventRepo().Create(ctx, failedEntry)
+	})
+}
+
+func (s *jobCommander) Requeue(ctx context.Context, jobID properties.UUID) (*Job, error) {
+	job, err := s.store.JobRepo().Get(ctx, jobID)
+	if err != nil {
+		return nil, err
+	}
+	if job.Status != JobDeadLettered {
+		return nil, NewInvalidInputErrorf("cannot requeue job %s in %s status", job.ID, job.Status)
+	}
+
+	// The service may have moved on since the job was dead-lettered
+	svc, err := validateServiceAction(ctx, s.store, DoServiceActionParams{ID: job.ServiceID, Action: job.Action})
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.store.Atomic(ctx, func(store Store) error {
+		if err := job.Requeue(); err != nil {
+			return InvalidInputError{Err: err}
+		}
+		// A concurrent requeue may have already resurrected the job
+		saved, err := store.JobRepo().SaveIfStatus(ctx, job, JobDeadLettered)
+		if err != nil {
+			return err
+		}
+		if !saved {
+			return NewConflictErrorf("job %s has changed status", job.ID)
+		}
+		if svc.ClearFailed() {
+			if err := store.ServiceRepo().Save(ctx, svc); err != nil {
+				return err
+			}
+		}
+		eventEntry, err := NewEvent(EventTypeJobRequeued, WithInitiatorCtx(ctx), WithJob(job))
+		if err != nil {
+			return err
+		}
+		return store.EventRepo().Create(ctx, eventEntry)
 	})
+	if err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+// checkJobAgent verifies that the calling agent is the one the job is assigned to
+func checkJobAgent(ctx context.Context, job *Job) error {
+	identity := auth.MustGetIdentity(ctx)
+	if identity.Scope.AgentID == nil || *identity.Scope.AgentID != job.AgentID {
+		return NewUnauthorizedErrorf("job %s is not assigned to the calling agent", job.ID)
+	}
+	return nil
+}
+
+// saveLeasedJob saves the outcome of a job, a leased job is only saved while the lease is still held
+// so a job reclaimed in the meantime is not reported twice
+func saveLeasedJob(ctx context.Context, store Store, job *Job, leaseID *properties.UUID) error {
+	if leaseID == nil {
+		return store.JobRepo().Save(ctx, job)
+	}
+	saved, err := store.JobRepo().SaveIfLeaseHeld(ctx, job, *leaseID)
+	if err != nil {
+		return err
+	}
+	if !saved {
+		return NewConflictErrorf("lease %s of job %s is no longer held", *leaseID, job.ID)
+	}
+	return nil
+}
+
+// deadLetterExhaustedJob moves a failed job that used up its attempts to dead-letter and emits the event to alert on it
+// The event is initiated by the system, the decision is taken by the attempts policy rather than the reporter
+func deadLetterExhaustedJob(ctx context.Context, store Store, job *Job, maxAttempts int) error {
+	if !job.ShouldDeadLetter(maxAttempts) {
+		return nil
+	}
+	if err := job.DeadLetter(); err != nil {
+		return err
+	}
+	return createJobDeadLetteredEvent(ctx, store, job)
+}
+
+// createJobDeadLetteredEvent records the dead-lettering of a job
+func createJobDeadLetteredEvent(ctx context.Context, store Store, job *Job) error {
+	eventEntry, err := NewEvent(EventTypeJobDeadLettered, WithJob(job))
+	if err != nil {
+		return err
+	}
+	eventEntry.Payload = properties.JSON{
+		"serviceId":    job.ServiceID,
+		"action":       job.Action,
+		"attempt":      job.Attempt,
+		"errorMessage": job.ErrorMessage,
+	}
+	return store.EventRepo().Create(ctx, eventEntry)
+}
+
+// failDeadLetteredService marks failed the service of a job dead-lettered by the system, out of the failure report of its agent
+func failDeadLetteredService(ctx context.Context, store Store, job *Job) error {
+	svc, err := store.ServiceRepo().Get(ctx, job.ServiceID)
+	if err != nil {
+		return err
+	}
+	originalSvc := *svc
+	serviceType, err := store.ServiceTypeRepo().Get(ctx, svc.ServiceTypeID)
+	if err != nil {
+		return err
+	}
+	if !svc.MarkFailed(serviceType.LifecycleSchema) {
+		return nil
+	}
+	if err := store.ServiceRepo().Save(ctx, svc); err != nil {
+		return err
+	}
+	if serviceType.LifecycleSchema.IsTerminalState(svc.Status) {
+		if err := store.ServicePoolValueRepo().ReleaseByService(ctx, svc.ID); err != nil {
+			return fmt.Errorf("failed to release pool values: %w", err)
+		}
+	}
+	eventEntry, err := NewEvent(EventTypeServiceTransitioned, WithDiff(&originalSvc, svc), WithService(svc))
+	if err != nil {
+		return err
+	}
+	return store.EventRepo().Create(ctx, eventEntry)
+}
+
+// nextJobAttempt returns the attempt number of a new job of the action
+// A job retrying a failed one counts as a further attempt, a dead-lettered action must be requeued instead.
+// A job cancelled before the agent claimed it never ran, the new job takes its attempt over.
+func nextJobAttempt(ctx context.Context, store Store, svc *Service, action string) (int, error) {
+	last, err := store.JobRepo().GetLastJobForService(ctx, svc.ID)
+	if err != nil {
+		return 0, err
+	}
+	if last == nil || last.Action != action {
+		return 1, nil
+	}
+	switch last.Status {
+	case JobFailed:
+		return last.Attempt + 1, nil
+	case JobDeadLettered:
+		return 0, NewInvalidInputErrorf("action %s of service %s is dead-lettered in job %s, requeue it instead", action, svc.ID, last.ID)
+	case JobCancelled:
+		if last.ClaimedAt == nil {
+			return max(last.Attempt, 1), nil
+		}
+		return 1, nil
+	default:
+		return 1, nil
+	}
+}
+
+// JobRetryPolicy defines the exponential backoff of the jobs retrying a failed action
+type JobRetryPolicy struct {
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+}
+
+// Backoff returns the delay before an attempt of an action, the first attempt is never delayed
+// The delay doubles from InitialBackoff with every further attempt up to MaxBackoff, zero disables it
+func (p JobRetryPolicy) Backoff(attempt int) time.Duration {
+	if attempt <= 1 {
+		return 0
+	}
+	backoff := p.InitialBackoff
+	for i := 2; i < attempt && backoff < p.MaxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > p.MaxBackoff {
+		return p.MaxBackoff
+	}
+	return backoff
+}
+
+// JobTimeouts defines how long a job can stay pending or processing before it is failed
+// The operation timeout of the service of a job, when set, takes precedence over both
+type JobTimeouts struct {
+	Default time.Duration
+	Actions map[string]time.Duration // Overrides of the default timeout by action
+}
+
+// For returns the timeout that applies to an action
+func (t JobTimeouts) For(action string) time.Duration {
+	if timeout, ok := t.Actions[action]; ok {
+		return timeout
+	}
+	return t.Default
 }
 
 type JobRepository interface {
 	JobQuerier
 	BaseEntityRepository[Job]
 
-	// DeleteOldCompletedJobs removes completed or failed jobs older than the specified interval
-	DeleteOldCompletedJobs(ctx context.Context, olderThan time.Duration) (int, error)
+	// SaveIfStatus saves the job only if its stored status is still the given one, reporting whether it was saved
+	SaveIfStatus(ctx context.Context, job *Job, status JobStatus) (bool, error)
+
+	// ClaimIfCapacity saves a claimed job only if it is still pending and its agent is below its concurrency limit
+	ClaimIfCapacity(ctx context.Context, job *Job) (bool, error)
+
+	// FailIfStatus sets the failed or dead-lettered status, the error message and the completion time of the jobs
+	// still in the from status and releases their lease, with a single statement, and returns the IDs of the updated jobs
+	FailIfStatus(ctx context.Context, ids []properties.UUID, from JobStatus, to JobStatus, errorMessage string, completedAt time.Time) ([]properties.UUID, error)
+
+	// SaveIfLeaseHeld saves the job only if it is still processing under the given lease
+	SaveIfLeaseHeld(ctx context.Context, job *Job, leaseID properties.UUID) (bool, error)
+
+	// SaveIfLeaseExpired saves the job only if it is still processing under the given lease expired before the given time
+	SaveIfLeaseExpired(ctx context.Context, job *Job, leaseID properties.UUID, at time.Time) (bool, error)
+
+	// DeleteFinishedBefore deletes up to limit jobs in the given statuses completed before the given time,
+	// the oldest first, and returns their number; keepLast spares the jobs that are still the last of their service
+	DeleteFinishedBefore(ctx context.Context, statuses []JobStatus, before time.Time, limit int, keepLast bool) (int, error)
+
+	// DeleteByService removes all the jobs of a service
+	DeleteByService(ctx context.Context, serviceID properties.UUID) error
 }
 
 type JobQuerier interface {
 	BaseEntityQuerier[Job]
 
-	// GetPendingJobsForAgent retrieves pending jobs targeted for a specific agent
+	// GetPendingJobsForAgent retrieves pending jobs targeted for a specific agent, up to its free job slots
 	GetPendingJobsForAgent(ctx context.Context, agentID properties.UUID, limit int) ([]*Job, error)
 
-	// GetLastJobForService retrieves the last job for a specific service
+	// GetLastJobForService retrieves the last non scheduled job for a specific service
 	GetLastJobForService(ctx context.Context, serviceID properties.UUID) (*Job, error)
 
+	// GetScheduledJobsForService retrieves the scheduled jobs of a specific service
+	GetScheduledJobsForService(ctx context.Context, serviceID properties.UUID) ([]*Job, error)
+
+	// GetDueScheduledJobs retrieves scheduled jobs whose scheduled time has passed
+	GetDueScheduledJobs(ctx context.Context) ([]*Job, error)
+
+	// GetExpiredLeaseJobs retrieves processing jobs whose lease expired before the given time
+	GetExpiredLeaseJobs(ctx context.Context, at time.Time) ([]*Job, error)
+
 	// GetTimeOutJobs retrieves jobs that have been processing for too long and returns them
-	GetTimeOutJobs(ctx context.Context, olderThan time.Duration) ([]*Job, error)
+	// The operation timeout of the service of each job overrides the given timeouts
+	GetTimeOutJobs(ctx context.Context, timeouts JobTimeouts) ([]*Job, error)
+
+	// CountByStatus returns the number of jobs grouped by status
+	CountByStatus(ctx context.Context) ([]StatusCount, error)
+
+	// DurationStats returns the count and execution time percentiles of the jobs finished in the query range
+	DurationStats(ctx context.Context, query JobDurationStatsQuery) ([]JobDurationStats, error)
 }
@@ -0,0 +1,92 @@
+package domain
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/fulcrumproject/core/pkg/properties"
+	"github.com/fulcrumproject/core/pkg/schema"
+)
+
+// MaxJobDiagnosticsSize is the maximum size in bytes of the serialized diagnostics of a job
+const MaxJobDiagnosticsSize = 64 * 1024
+
+// SetDiagnostics records the diagnostics reported by the agent with the outcome of the job, such as logs,
+// stack traces or provider error codes, redacting the sensitive properties of the service type
+// The diagnostics are rejected when their serialized size exceeds MaxJobDiagnosticsSize
+func (j *Job) SetDiagnostics(diagnostics *properties.JSON, propertySchema schema.Schema) error {
+	if diagnostics == nil {
+		return nil
+	}
+	data, err := json.Marshal(diagnostics)
+	if err != nil {
+		return fmt.Errorf("invalid diagnostics: %w", err)
+	}
+	if len(data) > MaxJobDiagnosticsSize {
+		return fmt.Errorf("diagnostics of %d bytes exceed the maximum of %d bytes", len(data), MaxJobDiagnosticsSize)
+	}
+
+	// The decoded copy is redacted in place, leaving the reported diagnostics untouched
+	var redacted properties.JSON
+	if err := json.Unmarshal(data, &redacted); err != nil {
+		return fmt.Errorf("invalid diagnostics: %w", err)
+	}
+	if err := redactDiagnostics(redacted, propertySchema.SensitivePaths()); err != nil {
+		return err
+	}
+	j.Diagnostics = &redacted
+	return nil
+}
+
+// redactDiagnostics redacts the sensitive property paths, the ones redacted from the event diffs of the services,
+// at any depth of the diagnostics since agents dump the properties inside their own structures
+// The strings, such as log lines, are redacted of the name=value and name: value occurrences of the sensitive
+// properties
+func redactDiagnostics(diagnostics map[string]any, paths []string) error {
+	patterns := make([][]string, 0, len(paths))
+	var names []string
+	for _, p := range paths {
+		tokens, err := properties.ParseJSONPointer(p)
+		if err != nil {
+			return err
+		}
+		patterns = append(patterns, tokens)
+		if name := tokens[len(tokens)-1]; name != "*" && !slices.Contains(names, regexp.QuoteMeta(name)) {
+			names = append(names, regexp.QuoteMeta(name))
+		}
+	}
+	if len(patterns) == 0 {
+		return nil
+	}
+	var assignments *regexp.Regexp
+	if len(names) > 0 {
+		assignments = regexp.MustCompile(`(?i)("?\b(?:` + strings.Join(names, "|") + `)\b"?\s*[:=]\s*)("[^"]*"|'[^']*'|[^\s,;&]+)`)
+	}
+	redactDiagnosticsValue(diagnostics, patterns, assignments)
+	return nil
+}
+
+// redactDiagnosticsValue applies the sensitive patterns at the level of the value and below
+func redactDiagnosticsValue(value any, patterns [][]string, assignments *regexp.Regexp) any {
+	for _, pattern := range patterns {
+		value = redactJSONValue(value, pattern)
+	}
+	switch v := value.(type) {
+	case map[string]any:
+		for key, child := range v {
+			v[key] = redactDiagnosticsValue(child, patterns, assignments)
+		}
+	case []any:
+		for i, child := range v {
+			v[i] = redactDiagnosticsValue(child, patterns, assignments)
+		}
+	case string:
+		if assignments != nil {
+			return assignments.ReplaceAllString(v, "${1}"+RedactedValue)
+		}
+	}
+	return value
+}
@@ -0,0 +1,138 @@
+package domain
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fulcrumproject/core/pkg/auth"
+	"github.com/fulcrumproject/core/pkg/properties"
+	"github.com/fulcrumproject/core/pkg/schema"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJob_SetDiagnostics(t *testing.T) {
+	propertySchema := schema.Schema{Properties: map[string]schema.PropertyDefinition{
+		"name":     {Type: "string"},
+		"password": {Type: "string", Sensitive: true},
+		"database": {Type: "object", Properties: map[string]schema.PropertyDefinition{
+			"apiKey": {Type: "string", Sensitive: true},
+		}},
+	}}
+
+	t.Run("no diagnostics", func(t *testing.T) {
+		job := &Job{}
+		require.NoError(t, job.SetDiagnostics(nil, propertySchema))
+		assert.Nil(t, job.Diagnostics)
+	})
+
+	t.Run("redacts the sensitive properties at any depth", func(t *testing.T) {
+		job := &Job{}
+		reported := properties.JSON{
+			"code":     "QUOTA_EXCEEDED",
+			"password": "p1",
+			"request": map[string]any{
+				"name":     "web",
+				"password": "p2",
+				"database": map[string]any{"apiKey": "k1", "host": "db"},
+			},
+			"logs": []any{
+				"connecting with password=p3 to db",
+				`payload {"password": "p4", "name": "web"}`,
+				"apiKey: k2",
+			},
+		}
+
+		require.NoError(t, job.SetDiagnostics(&reported, propertySchema))
+		assert.Equal(t, properties.JSON{
+			"code":     "QUOTA_EXCEEDED",
+			"password": RedactedValue,
+			"request": map[string]any{
+				"name":     "web",
+				"password": RedactedValue,
+				"database": map[string]any{"apiKey": RedactedValue, "host": "db"},
+			},
+			"logs": []any{
+				"connecting with password=*** to db",
+				`payload {"password": ***, "name": "web"}`,
+				"apiKey: ***",
+			},
+		}, *job.Diagnostics)
+		assert.Equal(t, "p1", reported["password"], "the reported diagnostics are left untouched")
+	})
+
+	t.Run("without sensitive properties", func(t *testing.T) {
+		job := &Job{}
+		reported := properties.JSON{"logs": []any{"password=p1"}}
+
+		require.NoError(t, job.SetDiagnostics(&reported, schema.Schema{}))
+		assert.Equal(t, reported, *job.Diagnostics)
+	})
+
+	t.Run("too large", func(t *testing.T) {
+		job := &Job{}
+		reported := properties.JSON{"logs": strings.Repeat("x", MaxJobDiagnosticsSize)}
+
+		err := job.SetDiagnostics(&reported, propertySchema)
+		assert.ErrorContains(t, err, "exceed the maximum")
+		assert.Nil(t, job.Diagnostics)
+	})
+}
+
+func TestJobCommander_FailWithDiagnostics(t *testing.T) {
+	ctx := auth.WithIdentity(context.Background(), &auth.Identity{ID: uuid.New(), Role: auth.RoleAgent})
+	serviceType := &ServiceType{
+		BaseEntity: BaseEntity{ID: uuid.New()},
+		PropertySchema: schema.Schema{Properties: map[string]schema.PropertyDefinition{
+			"password": {Type: "string", Sensitive: true},
+		}},
+		LifecycleSchema: LifecycleSchema{
+			States:  []LifecycleState{{Name: "Started"}},
+			Actions: []LifecycleAction{{Name: "update", Transitions: []LifecycleTransition{{From: "Started", To: "Started"}}}},
+		},
+	}
+	svc := &Service{BaseEntity: BaseEntity{ID: uuid.New()}, ServiceTypeID: serviceType.ID, Status: "Started"}
+
+	setup := func(t *testing.T, job *Job) (*MockStore, *MockJobRepository) {
+		ms := setupMockStore(t)
+		jobRepo := NewMockJobRepository(t)
+		serviceRepo := NewMockServiceRepository(t)
+		serviceTypeRepo := NewMockServiceTypeRepository(t)
+		eventRepo := NewMockEventRepository(t)
+		ms.EXPECT().JobRepo().Return(jobRepo)
+		ms.EXPECT().ServiceRepo().Return(serviceRepo)
+		ms.EXPECT().ServiceTypeRepo().Return(serviceTypeRepo)
+		ms.EXPECT().EventRepo().Return(eventRepo).Maybe()
+		jobRepo.EXPECT().Get(mock.Anything, job.ID).Return(job, nil)
+		serviceRepo.EXPECT().Get(mock.Anything, svc.ID).Return(svc, nil)
+		serviceTypeRepo.EXPECT().Get(mock.Anything, serviceType.ID).Return(serviceType, nil)
+		eventRepo.EXPECT().Create(mock.Anything, mock.Anything).Return(nil).Maybe()
+		return ms, jobRepo
+	}
+
+	t.Run("stores the redacted diagnostics", func(t *testing.T) {
+		job := &Job{BaseEntity: BaseEntity{ID: uuid.New()}, Status: JobProcessing, Action: "update", ServiceID: svc.ID}
+		ms, jobRepo := setup(t, job)
+		jobRepo.EXPECT().Save(mock.Anything, job).Return(nil)
+
+		diagnostics := properties.JSON{"code": "E42", "logs": []any{"login with password=secret failed"}}
+		err := NewJobCommander(ms, nil, 0, time.Minute, JobRetryPolicy{}).Fail(ctx, FailJobParams{JobID: job.ID, ErrorMessage: "boom", Diagnostics: &diagnostics})
+		require.NoError(t, err)
+		assert.Equal(t, JobFailed, job.Status)
+		require.NotNil(t, job.Diagnostics)
+		assert.Equal(t, properties.JSON{"code": "E42", "logs": []any{"login with password=*** failed"}}, *job.Diagnostics)
+	})
+
+	t.Run("rejects oversized diagnostics", func(t *testing.T) {
+		job := &Job{BaseEntity: BaseEntity{ID: uuid.New()}, Status: JobProcessing, Action: "update", ServiceID: svc.ID}
+		ms, _ := setup(t, job)
+
+		diagnostics := properties.JSON{"logs": strings.Repeat("x", MaxJobDiagnosticsSize)}
+		err := NewJobCommander(ms, nil, 0, time.Minute, JobRetryPolicy{}).Fail(ctx, FailJobParams{JobID: job.ID, ErrorMessage: "boom", Diagnostics: &diagnostics})
+		assert.ErrorAs(t, err, &InvalidInputError{})
+	})
+}
@@ -0,0 +1,99 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// JobRetentionClass groups the statuses of the finished jobs sharing a retention window
+type JobRetentionClass string
+
+const (
+	// JobRetentionSucceeded covers the completed and the cancelled jobs
+	JobRetentionSucceeded JobRetentionClass = "succeeded"
+	// JobRetentionFailed covers the failed jobs
+	JobRetentionFailed JobRetentionClass = "failed"
+	// JobRetentionDeadLettered covers the dead-lettered jobs, waiting for an operator to requeue them
+	JobRetentionDeadLettered JobRetentionClass = "deadLettered"
+)
+
+// JobRetentionClasses are the retention classes in the order they are purged
+var JobRetentionClasses = []JobRetentionClass{JobRetentionSucceeded, JobRetentionFailed, JobRetentionDeadLettered}
+
+// Statuses returns the job statuses of the class
+func (c JobRetentionClass) Statuses() []JobStatus {
+	switch c {
+	case JobRetentionSucceeded:
+		return []JobStatus{JobCompleted, JobCancelled}
+	case JobRetentionFailed:
+		return []JobStatus{JobFailed}
+	case JobRetentionDeadLettered:
+		return []JobStatus{JobDeadLettered}
+	default:
+		return nil
+	}
+}
+
+// KeepsLastJob reports whether the jobs of the class are kept while they are the last job of their service
+// The last dead-lettered job is what refuses its action until an operator requeues it, purging it would
+// silently re-enable the action
+func (c JobRetentionClass) KeepsLastJob() bool {
+	return c == JobRetentionDeadLettered
+}
+
+// JobRetentionPolicy is how long the finished jobs are kept after their completion, per class
+// A zero window keeps the jobs of the class
+type JobRetentionPolicy struct {
+	Succeeded    time.Duration
+	Failed       time.Duration
+	DeadLettered time.Duration
+	BatchSize    int // Largest number of jobs deleted by a single statement
+}
+
+// Window returns the retention window of the class
+func (p JobRetentionPolicy) Window(class JobRetentionClass) time.Duration {
+	switch class {
+	case JobRetentionSucceeded:
+		return p.Succeeded
+	case JobRetentionFailed:
+		return p.Failed
+	case JobRetentionDeadLettered:
+		return p.DeadLettered
+	default:
+		return 0
+	}
+}
+
+// DeleteExpiredJobs deletes the finished jobs completed before the window of their class and returns
+// the number of deleted jobs per class
+// Each class is purged independently in batches of at most BatchSize jobs, so no statement holds
+// the locks of a large purge; the failure of a class does not prevent purging the others
+func DeleteExpiredJobs(ctx context.Context, repo JobRepository, policy JobRetentionPolicy, now time.Time) (map[JobRetentionClass]int, error) {
+	if policy.BatchSize <= 0 {
+		return nil, fmt.Errorf("job retention batch size must be positive")
+	}
+
+	deleted := make(map[JobRetentionClass]int)
+	var errs []error
+	for _, class := range JobRetentionClasses {
+		window := policy.Window(class)
+		if window <= 0 {
+			continue
+		}
+		before := now.Add(-window)
+		for {
+			count, err := repo.DeleteFinishedBefore(ctx, class.Statuses(), before, policy.BatchSize, class.KeepsLastJob())
+			deleted[class] += count
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s jobs: %w", class, err))
+				break
+			}
+			if count < policy.BatchSize {
+				break
+			}
+		}
+	}
+	return deleted, errors.Join(errs...)
+}
@@ -0,0 +1,54 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeleteExpiredJobs(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("purges each class in batches with its own window", func(t *testing.T) {
+		repo := NewMockJobRepository(t)
+		succeeded := []JobStatus{JobCompleted, JobCancelled}
+		failed := []JobStatus{JobFailed}
+		repo.EXPECT().DeleteFinishedBefore(ctx, succeeded, now.Add(-24*time.Hour), 2, false).Return(2, nil).Once()
+		repo.EXPECT().DeleteFinishedBefore(ctx, succeeded, now.Add(-24*time.Hour), 2, false).Return(1, nil).Once()
+		repo.EXPECT().DeleteFinishedBefore(ctx, failed, now.Add(-72*time.Hour), 2, false).Return(0, nil).Once()
+
+		deleted, err := DeleteExpiredJobs(ctx, repo, JobRetentionPolicy{
+			Succeeded: 24 * time.Hour,
+			Failed:    72 * time.Hour,
+			BatchSize: 2,
+		}, now)
+		require.NoError(t, err)
+		assert.Equal(t, map[JobRetentionClass]int{JobRetentionSucceeded: 3, JobRetentionFailed: 0}, deleted)
+	})
+
+	t.Run("a failing class does not stop the others", func(t *testing.T) {
+		repo := NewMockJobRepository(t)
+		repo.EXPECT().DeleteFinishedBefore(ctx, []JobStatus{JobCompleted, JobCancelled}, now.Add(-time.Hour), 10, false).Return(0, errors.New("db down")).Once()
+		// The last job of its service is what refuses a dead-lettered action, it is kept
+		repo.EXPECT().DeleteFinishedBefore(ctx, []JobStatus{JobDeadLettered}, now.Add(-2*time.Hour), 10, true).Return(4, nil).Once()
+
+		deleted, err := DeleteExpiredJobs(ctx, repo, JobRetentionPolicy{
+			Succeeded:    time.Hour,
+			DeadLettered: 2 * time.Hour,
+			BatchSize:    10,
+		}, now)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "succeeded jobs: db down")
+		assert.Equal(t, 4, deleted[JobRetentionDeadLettered])
+	})
+
+	t.Run("invalid batch size", func(t *testing.T) {
+		_, err := DeleteExpiredJobs(ctx, NewMockJobRepository(t), JobRetentionPolicy{Succeeded: time.Hour}, now)
+		assert.Error(t, err)
+	})
+}
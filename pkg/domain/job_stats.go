@@ -0,0 +1,87 @@
+package domain
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/fulcrumproject/core/pkg/auth"
+)
+
+const (
+	// JobDurationStatsDefaultRange is the range of the stats when no start is given
+	JobDurationStatsDefaultRange = 24 * time.Hour
+	// JobDurationStatsMaxRange is the largest range of the stats
+	JobDurationStatsMaxRange = 90 * 24 * time.Hour
+)
+
+// JobStatsGroup is a dimension the job duration stats can be grouped by
+type JobStatsGroup string
+
+const (
+	// JobStatsGroupAction groups the stats by service action
+	JobStatsGroupAction JobStatsGroup = "action"
+	// JobStatsGroupAgentType groups the stats by the agent type of the agent running the jobs
+	JobStatsGroupAgentType JobStatsGroup = "agentType"
+)
+
+func (g JobStatsGroup) Validate() error {
+	switch g {
+	case JobStatsGroupAction, JobStatsGroupAgentType:
+		return nil
+	default:
+		return fmt.Errorf("invalid job stats group: %s", g)
+	}
+}
+
+func ParseJobStatsGroup(s string) (JobStatsGroup, error) {
+	group := JobStatsGroup(s)
+	if err := group.Validate(); err != nil {
+		return "", err
+	}
+	return group, nil
+}
+
+// JobDurationStatsStatuses are the statuses of the jobs counted in the duration stats,
+// the jobs still in flight and the cancelled ones are left out
+var JobDurationStatsStatuses = []JobStatus{JobCompleted, JobFailed, JobDeadLettered}
+
+// JobDurationStatsQuery groups the parameters of a job duration stats query
+type JobDurationStatsQuery struct {
+	Action  string // Only the jobs of this action when set
+	From    time.Time
+	To      time.Time
+	GroupBy []JobStatsGroup
+	Scope   *auth.IdentityScope
+}
+
+// Validate checks the range and the groups of the query
+func (q JobDurationStatsQuery) Validate() error {
+	if !q.From.Before(q.To) {
+		return fmt.Errorf("from time must be before to time")
+	}
+	if q.To.Sub(q.From) > JobDurationStatsMaxRange {
+		return fmt.Errorf("time range exceeds maximum of %s", JobDurationStatsMaxRange)
+	}
+	for _, group := range q.GroupBy {
+		if err := group.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GroupedBy reports whether the query groups the stats by the given dimension
+func (q JobDurationStatsQuery) GroupedBy(group JobStatsGroup) bool {
+	return slices.Contains(q.GroupBy, group)
+}
+
+// JobDurationStats are the execution time percentiles of the jobs of a group
+type JobDurationStats struct {
+	Action    string // Set when grouped by action
+	AgentType string // Name of the agent type, set when grouped by agent type
+	Count     int64
+	P50       time.Duration
+	P95       time.Duration
+	P99       time.Duration
+}
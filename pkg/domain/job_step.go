@@ -0,0 +1,132 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/fulcrumproject/core/pkg/properties"
+)
+
+// JobStepStatus is the outcome of a step of a multi-step action
+type JobStepStatus string
+
+const (
+	JobStepCompleted JobStepStatus = "Completed"
+	JobStepFailed    JobStepStatus = "Failed"
+)
+
+// Validate checks if the step status is valid
+func (s JobStepStatus) Validate() error {
+	switch s {
+	case JobStepCompleted, JobStepFailed:
+		return nil
+	default:
+		return fmt.Errorf("invalid job step status: %s", s)
+	}
+}
+
+// JobStep is an intermediate step of an action reported by the agent, such as creating a disk or attaching a network
+type JobStep struct {
+	Name       string        `json:"name"`
+	Status     JobStepStatus `json:"status"`
+	Message    string        `json:"message,omitempty"`
+	ReportedAt time.Time     `json:"reportedAt"`
+}
+
+// ReportJobStepParams is the report of a step of a job, the final step carries the outcome of the whole action
+type ReportJobStepParams struct {
+	JobID   properties.UUID  `json:"jobId"`
+	Name    string           `json:"name"`
+	Status  JobStepStatus    `json:"status"`
+	Message string           `json:"message,omitempty"`
+	LeaseID *properties.UUID `json:"leaseId,omitempty"`
+	// Final marks the last step of the action, its completion completes the job with the data of a job completion
+	Final             bool             `json:"final,omitempty"`
+	AgentInstanceData *properties.JSON `json:"agentInstanceData,omitempty"`
+	AgentInstanceID   *string          `json:"agentInstanceId,omitempty"`
+	Properties        map[string]any   `json:"properties,omitempty"`
+	Diagnostics       *properties.JSON `json:"diagnostics,omitempty"`
+}
+
+// AddStep appends a step reported while the job is processing, each step is reported once
+func (j *Job) AddStep(name string, status JobStepStatus, message string) error {
+	if j.Status != JobProcessing {
+		return fmt.Errorf("cannot report a step of a job not in processing status")
+	}
+	if name == "" {
+		return fmt.Errorf("step name cannot be empty")
+	}
+	if err := status.Validate(); err != nil {
+		return err
+	}
+	if slices.ContainsFunc(j.Steps, func(s JobStep) bool { return s.Name == name }) {
+		return fmt.Errorf("step %s has already been reported", name)
+	}
+	now := time.Now()
+	j.Steps = append(j.Steps, JobStep{Name: name, Status: status, Message: message, ReportedAt: now})
+	j.LastStepAt = &now
+	return nil
+}
+
+// resetSteps drops the steps of a previous attempt of the action
+func (j *Job) resetSteps() {
+	j.Steps = nil
+	j.LastStepAt = nil
+}
+
+func (s *jobCommander) ReportStep(ctx context.Context, params ReportJobStepParams) (*Job, error) {
+	job, err := s.store.JobRepo().Get(ctx, params.JobID)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkJobAgent(ctx, job); err != nil {
+		return nil, err
+	}
+	if job.Status == JobCancelled {
+		return nil, NewConflictErrorf("job %s has been cancelled", job.ID)
+	}
+	if job.Status != JobProcessing {
+		return nil, NewConflictErrorf("cannot report a step of job %s in %s status", job.ID, job.Status)
+	}
+	if err := job.CheckLease(params.LeaseID); err != nil {
+		return nil, NewConflictErrorf("cannot report a step of job %s: %v", job.ID, err)
+	}
+	if err := job.AddStep(params.Name, params.Status, params.Message); err != nil {
+		return nil, InvalidInputError{Err: err}
+	}
+
+	// A failed step fails the whole action, the steps recorded with the job keep its context
+	if params.Status == JobStepFailed {
+		err := s.fail(ctx, job, FailJobParams{
+			JobID:        job.ID,
+			ErrorMessage: fmt.Sprintf("step %s failed: %s", params.Name, params.Message),
+			LeaseID:      params.LeaseID,
+			Diagnostics:  params.Diagnostics,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return job, nil
+	}
+	if params.Final {
+		err := s.complete(ctx, job, CompleteJobParams{
+			JobID:             job.ID,
+			AgentInstanceData: params.AgentInstanceData,
+			AgentInstanceID:   params.AgentInstanceID,
+			Properties:        params.Properties,
+			LeaseID:           params.LeaseID,
+			Diagnostics:       params.Diagnostics,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return job, nil
+	}
+
+	if err := saveLeasedJob(ctx, s.store, job, job.LeaseID); err != nil {
+		return nil, err
+	}
+	return job, nil
+}
@@ -0,0 +1,171 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/fulcrumproject/core/pkg/auth"
+	"github.com/fulcrumproject/core/pkg/properties"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJob_AddStep(t *testing.T) {
+	job := &Job{Status: JobPending, Attempt: 1}
+	assert.Error(t, job.AddStep("create-disk", JobStepCompleted, ""), "a pending job has no steps")
+
+	require.NoError(t, job.Claim())
+	require.NoError(t, job.AddStep("create-disk", JobStepCompleted, "disk ready"))
+	require.NoError(t, job.AddStep("attach-network", JobStepCompleted, ""))
+	require.Len(t, job.Steps, 2)
+	assert.Equal(t, "create-disk", job.Steps[0].Name)
+	assert.Equal(t, "disk ready", job.Steps[0].Message)
+	require.NotNil(t, job.LastStepAt)
+	assert.Equal(t, job.Steps[1].ReportedAt, *job.LastStepAt)
+
+	assert.Error(t, job.AddStep("create-disk", JobStepCompleted, ""), "a step is reported once")
+	assert.Error(t, job.AddStep("", JobStepCompleted, ""))
+	assert.Error(t, job.AddStep("boot", "Running", ""))
+
+	// A new attempt starts without the steps of the previous one
+	require.NoError(t, job.GrantLease(time.Minute))
+	require.NoError(t, job.ReclaimLease())
+	assert.Empty(t, job.Steps)
+	assert.Nil(t, job.LastStepAt)
+}
+
+func TestJobCommander_ReportStep(t *testing.T) {
+	agentID := properties.UUID(uuid.New())
+	ctx := auth.WithIdentity(context.Background(), &auth.Identity{ID: uuid.New(), Role: auth.RoleAgent, Scope: auth.IdentityScope{AgentID: &agentID}})
+	serviceType := &ServiceType{
+		BaseEntity: BaseEntity{ID: uuid.New()},
+		LifecycleSchema: LifecycleSchema{
+			States: []LifecycleState{{Name: "Creating"}, {Name: "Started"}, {Name: "Failed"}},
+			Actions: []LifecycleAction{{Name: "create", Transitions: []LifecycleTransition{
+				{From: "Creating", To: "Started"},
+				{From: "Creating", To: "Failed", OnError: true},
+			}}},
+		},
+	}
+	newJob := func() (*Job, *Service) {
+		svc := &Service{
+			BaseEntity: BaseEntity{ID: uuid.New()}, Name: "vm", ServiceTypeID: serviceType.ID, Status: "Creating",
+			AgentID: agentID, ProviderID: uuid.New(), ConsumerID: uuid.New(), GroupID: uuid.New(),
+		}
+		leaseID := properties.UUID(uuid.New())
+		job := &Job{
+			BaseEntity: BaseEntity{ID: uuid.New()}, Status: JobProcessing, Action: "create", Attempt: 1,
+			AgentID: agentID, ServiceID: svc.ID, LeaseID: &leaseID,
+		}
+		return job, svc
+	}
+	setup := func(t *testing.T, job *Job) (*MockStore, *MockJobRepository) {
+		ms := setupMockStore(t)
+		jobRepo := NewMockJobRepository(t)
+		ms.EXPECT().JobRepo().Return(jobRepo)
+		jobRepo.EXPECT().Get(mock.Anything, job.ID).Return(job, nil)
+		return ms, jobRepo
+	}
+	setupOutcome := func(t *testing.T, ms *MockStore, svc *Service) *MockServiceRepository {
+		serviceRepo := NewMockServiceRepository(t)
+		serviceTypeRepo := NewMockServiceTypeRepository(t)
+		eventRepo := NewMockEventRepository(t)
+		ms.EXPECT().ServiceRepo().Return(serviceRepo)
+		ms.EXPECT().ServiceTypeRepo().Return(serviceTypeRepo)
+		ms.EXPECT().EventRepo().Return(eventRepo)
+		serviceRepo.EXPECT().Get(mock.Anything, svc.ID).Return(svc, nil)
+		serviceTypeRepo.EXPECT().Get(mock.Anything, serviceType.ID).Return(serviceType, nil)
+		eventRepo.EXPECT().Create(mock.Anything, mock.Anything).Return(nil)
+		return serviceRepo
+	}
+
+	t.Run("records an intermediate step", func(t *testing.T) {
+		job, _ := newJob()
+		ms, jobRepo := setup(t, job)
+		jobRepo.EXPECT().SaveIfLeaseHeld(mock.Anything, job, *job.LeaseID).Return(true, nil)
+
+		result, err := NewJobCommander(ms, nil, 0, time.Minute, JobRetryPolicy{}).ReportStep(ctx, ReportJobStepParams{
+			JobID: job.ID, Name: "create-disk", Status: JobStepCompleted, LeaseID: job.LeaseID,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, JobProcessing, result.Status)
+		require.Len(t, result.Steps, 1)
+		assert.NotNil(t, result.LastStepAt)
+	})
+
+	t.Run("completes the job with the final step", func(t *testing.T) {
+		job, svc := newJob()
+		leaseID := *job.LeaseID
+		ms, jobRepo := setup(t, job)
+		serviceRepo := setupOutcome(t, ms, svc)
+		jobRepo.EXPECT().SaveIfLeaseHeld(mock.Anything, job, leaseID).Return(true, nil)
+		serviceRepo.EXPECT().Save(mock.Anything, svc).Return(nil)
+
+		result, err := NewJobCommander(ms, nil, 0, time.Minute, JobRetryPolicy{}).ReportStep(ctx, ReportJobStepParams{
+			JobID: job.ID, Name: "boot", Status: JobStepCompleted, LeaseID: &leaseID, Final: true,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, JobCompleted, result.Status)
+		assert.Equal(t, "Started", svc.Status)
+		require.Len(t, result.Steps, 1)
+	})
+
+	t.Run("fails the job with a failed step", func(t *testing.T) {
+		job, svc := newJob()
+		job.Steps = []JobStep{{Name: "create-disk", Status: JobStepCompleted, ReportedAt: time.Now()}}
+		leaseID := *job.LeaseID
+		ms, jobRepo := setup(t, job)
+		serviceRepo := setupOutcome(t, ms, svc)
+		jobRepo.EXPECT().SaveIfLeaseHeld(mock.Anything, job, leaseID).Return(true, nil)
+		serviceRepo.EXPECT().Save(mock.Anything, svc).Return(nil)
+
+		result, err := NewJobCommander(ms, nil, 0, time.Minute, JobRetryPolicy{}).ReportStep(ctx, ReportJobStepParams{
+			JobID: job.ID, Name: "attach-network", Status: JobStepFailed, Message: "no free address", LeaseID: &leaseID,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, JobFailed, result.Status)
+		assert.Equal(t, "step attach-network failed: no free address", result.ErrorMessage)
+		assert.Equal(t, "Failed", svc.Status)
+		require.Len(t, result.Steps, 2)
+		assert.Equal(t, JobStepFailed, result.Steps[1].Status)
+	})
+
+	t.Run("rejects another agent", func(t *testing.T) {
+		job, _ := newJob()
+		ms, _ := setup(t, job)
+		otherID := properties.UUID(uuid.New())
+		otherCtx := auth.WithIdentity(context.Background(), &auth.Identity{Role: auth.RoleAgent, Scope: auth.IdentityScope{AgentID: &otherID}})
+
+		_, err := NewJobCommander(ms, nil, 0, time.Minute, JobRetryPolicy{}).ReportStep(otherCtx, ReportJobStepParams{
+			JobID: job.ID, Name: "create-disk", Status: JobStepCompleted, LeaseID: job.LeaseID,
+		})
+		assert.True(t, errors.As(err, &UnauthorizedError{}))
+	})
+
+	t.Run("rejects a job not processing", func(t *testing.T) {
+		job, _ := newJob()
+		job.Status = JobPending
+		ms, _ := setup(t, job)
+
+		_, err := NewJobCommander(ms, nil, 0, time.Minute, JobRetryPolicy{}).ReportStep(ctx, ReportJobStepParams{
+			JobID: job.ID, Name: "create-disk", Status: JobStepCompleted,
+		})
+		assert.True(t, errors.As(err, &ConflictError{}))
+	})
+
+	t.Run("rejects a previous lease", func(t *testing.T) {
+		job, _ := newJob()
+		ms, _ := setup(t, job)
+		staleLeaseID := properties.UUID(uuid.New())
+
+		_, err := NewJobCommander(ms, nil, 0, time.Minute, JobRetryPolicy{}).ReportStep(ctx, ReportJobStepParams{
+			JobID: job.ID, Name: "create-disk", Status: JobStepCompleted, LeaseID: &staleLeaseID,
+		})
+		assert.True(t, errors.As(err, &ConflictError{}))
+		assert.Empty(t, job.Steps)
+	})
+}
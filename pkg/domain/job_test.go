@@ -1,10 +1,19 @@
 package domain
 
 import (
+	"context"
+	"errors"
 	"testing"
+	"time"
 
+	"github.com/fulcrumproject/core/pkg/auth"
+	"github.com/fulcrumproject/core/pkg/properties"
+	"github.com/fulcrumproject/core/pkg/tracing"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
 )
 
 func TestJobStatus_Validate(t *testing.T) {
@@ -14,6 +23,11 @@ func TestJobStatus_Validate(t *testing.T) {
 		wantErr    bool
 		errMessage string
 	}{
+		{
+			name:    "Valid JobScheduled",
+			status:  JobScheduled,
+			wantErr: false,
+		},
 		{
 			name:    "Valid JobPending",
 			status:  JobPending,
@@ -34,6 +48,11 @@ func TestJobStatus_Validate(t *testing.T) {
 			status:  JobFailed,
 			wantErr: false,
 		},
+		{
+			name:    "Valid JobCancelled",
+			status:  JobCancelled,
+			wantErr: false,
+		},
 		{
 			name:       "Invalid status",
 			status:     "InvalidStatus",
@@ -244,3 +263,783 @@ func TestNewJob(t *testing.T) {
 	assert.Equal(t, priority, job.Priority)
 }
 
+func TestJob_BeforeCreate(t *testing.T) {
+	job := &Job{}
+	tx := &gorm.DB{Statement: &gorm.Statement{Context: tracing.WithRequestID(context.Background(), "req-42")}}
+	require.NoError(t, job.BeforeCreate(tx))
+	assert.Equal(t, "req-42", job.RequestID)
+
+	job = &Job{}
+	require.NoError(t, job.BeforeCreate(&gorm.DB{Statement: &gorm.Statement{Context: context.Background()}}))
+	assert.Empty(t, job.RequestID)
+}
+
+
+func TestJob_Schedule(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     JobStatus
+		at         time.Time
+		wantErr    bool
+		errMessage string
+	}{
+		{
+			name:   "Schedule pending job in the future",
+			status: JobPending,
+			at:     time.Now().Add(time.Hour),
+		},
+		{
+			name:       "Schedule in the past",
+			status:     JobPending,
+			at:         time.Now().Add(-time.Hour),
+			wantErr:    true,
+			errMessage: "must be in the future",
+		},
+		{
+			name:       "Schedule processing job",
+			status:     JobProcessing,
+			at:         time.Now().Add(time.Hour),
+			wantErr:    true,
+			errMessage: "not in pending status",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			job := &Job{Status: tt.status}
+			err := job.Schedule(tt.at)
+			if tt.wantErr {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tt.errMessage)
+				assert.Equal(t, tt.status, job.Status)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, JobScheduled, job.Status)
+			assert.Equal(t, tt.at, *job.ScheduledAt)
+		})
+	}
+}
+
+func TestJob_PromoteAndCancelSchedule(t *testing.T) {
+	job := &Job{Status: JobPending}
+	assert.Error(t, job.Promote())
+	assert.Error(t, job.CancelSchedule("reason"))
+
+	job = &Job{Status: JobScheduled}
+	assert.NoError(t, job.Promote())
+	assert.Equal(t, JobPending, job.Status)
+
+	job = &Job{Status: JobScheduled}
+	assert.NoError(t, job.CancelSchedule("superseded"))
+	assert.Equal(t, JobCancelled, job.Status)
+	assert.Equal(t, "superseded", job.ErrorMessage)
+	assert.NotNil(t, job.CompletedAt)
+}
+
+func TestJob_Cancel(t *testing.T) {
+	for _, status := range []JobStatus{JobPending, JobProcessing} {
+		job := &Job{Status: status}
+		assert.NoError(t, job.Cancel("stop waiting"))
+		assert.Equal(t, JobCancelled, job.Status)
+		assert.Equal(t, "stop waiting", job.ErrorMessage)
+		assert.NotNil(t, job.CompletedAt)
+		assert.False(t, job.IsActive())
+	}
+
+	for _, status := range []JobStatus{JobScheduled, JobCompleted, JobFailed, JobCancelled} {
+		job := &Job{Status: status}
+		assert.Error(t, job.Cancel("stop waiting"))
+		assert.Equal(t, status, job.Status)
+	}
+}
+
+func TestJobCommander_ReportOnCancelledJob(t *testing.T) {
+	job := &Job{BaseEntity: BaseEntity{ID: uuid.New()}, Status: JobCancelled}
+
+	ms := NewMockStore(t)
+	jobRepo := NewMockJobRepository(t)
+	ms.EXPECT().JobRepo().Return(jobRepo)
+	jobRepo.EXPECT().Get(mock.Anything, job.ID).Return(job, nil)
+
+	cmd := NewJobCommander(ms, nil, 0, 0, JobRetryPolicy{})
+	err := cmd.Complete(context.Background(), CompleteJobParams{JobID: job.ID})
+	assert.True(t, errors.As(err, &ConflictError{}))
+
+	err = cmd.Fail(context.Background(), FailJobParams{JobID: job.ID, ErrorMessage: "boom"})
+	assert.True(t, errors.As(err, &ConflictError{}))
+}
+
+func TestJobTimeouts_For(t *testing.T) {
+	timeouts := JobTimeouts{
+		Default: 5 * time.Minute,
+		Actions: map[string]time.Duration{"create": time.Hour},
+	}
+
+	assert.Equal(t, time.Hour, timeouts.For("create"))
+	assert.Equal(t, 5*time.Minute, timeouts.For("stop"))
+	assert.Equal(t, 5*time.Minute, JobTimeouts{Default: 5 * time.Minute}.For("create"))
+}
+
+func TestDefaultJobPriority(t *testing.T) {
+	assert.Greater(t, DefaultJobPriority("delete"), DefaultJobPriority("stop"))
+	assert.Greater(t, DefaultJobPriority("stop"), DefaultJobPriority("start"))
+	assert.Greater(t, DefaultJobPriority("start"), DefaultJobPriority("create"))
+	assert.Equal(t, JobPriorityNormal, DefaultJobPriority("restart"))
+}
+
+func TestJob_SetPriority(t *testing.T) {
+	for _, status := range []JobStatus{JobPending, JobScheduled} {
+		job := &Job{Status: status, Priority: 1}
+		assert.NoError(t, job.SetPriority(5))
+		assert.Equal(t, 5, job.Priority)
+	}
+
+	for _, status := range []JobStatus{JobProcessing, JobCompleted, JobFailed, JobCancelled} {
+		job := &Job{Status: status, Priority: 1}
+		assert.Error(t, job.SetPriority(5))
+		assert.Equal(t, 1, job.Priority)
+	}
+
+	job := &Job{Status: JobPending, Priority: 1}
+	assert.Error(t, job.SetPriority(0))
+}
+
+func TestJobCommander_Claim(t *testing.T) {
+	setup := func(t *testing.T, claimed bool) (*jobCommander, *MockJobRepository, *Job) {
+		job := &Job{BaseEntity: BaseEntity{ID: uuid.New()}, Status: JobPending, AgentID: uuid.New()}
+		ms := NewMockStore(t)
+		jobRepo := NewMockJobRepository(t)
+		ms.EXPECT().JobRepo().Return(jobRepo)
+		jobRepo.EXPECT().Get(mock.Anything, job.ID).Return(job, nil).Once()
+		jobRepo.EXPECT().ClaimIfCapacity(mock.Anything, job).Return(claimed, nil)
+		return NewJobCommander(ms, nil, 0, 0, JobRetryPolicy{}), jobRepo, job
+	}
+
+	t.Run("claims a pending job", func(t *testing.T) {
+		cmd, _, job := setup(t, true)
+		result, err := cmd.Claim(context.Background(), job.ID)
+		require.NoError(t, err)
+		assert.Equal(t, JobProcessing, result.Status)
+		assert.Nil(t, result.LeaseID)
+	})
+
+	t.Run("grants a lease when leasing is enabled", func(t *testing.T) {
+		cmd, _, job := setup(t, true)
+		cmd.leaseDuration = time.Minute
+		result, err := cmd.Claim(context.Background(), job.ID)
+		require.NoError(t, err)
+		require.NotNil(t, result.LeaseID)
+		assert.WithinDuration(t, time.Now().Add(time.Minute), *result.LeaseExpiresAt, time.Second)
+	})
+
+	t.Run("concurrent claim loses", func(t *testing.T) {
+		cmd, jobRepo, job := setup(t, false)
+		jobRepo.EXPECT().Get(mock.Anything, job.ID).Return(&Job{BaseEntity: job.BaseEntity, Status: JobProcessing}, nil).Once()
+		_, err := cmd.Claim(context.Background(), job.ID)
+		assert.True(t, errors.As(err, &ConflictError{}))
+		assert.Contains(t, err.Error(), "already been claimed")
+	})
+
+	t.Run("agent without free job slots", func(t *testing.T) {
+		cmd, jobRepo, job := setup(t, false)
+		jobRepo.EXPECT().Get(mock.Anything, job.ID).Return(&Job{BaseEntity: job.BaseEntity, Status: JobPending}, nil).Once()
+		_, err := cmd.Claim(context.Background(), job.ID)
+		assert.True(t, errors.As(err, &ConflictError{}))
+		assert.Contains(t, err.Error(), "limit of concurrent jobs")
+	})
+}
+
+func TestJobRetryPolicy_Backoff(t *testing.T) {
+	policy := JobRetryPolicy{InitialBackoff: 30 * time.Second, MaxBackoff: 5 * time.Minute}
+
+	assert.Equal(t, time.Duration(0), policy.Backoff(1), "the first attempt is not a retry")
+	assert.Equal(t, 30*time.Second, policy.Backoff(2))
+	assert.Equal(t, time.Minute, policy.Backoff(3))
+	assert.Equal(t, 2*time.Minute, policy.Backoff(4))
+	assert.Equal(t, 4*time.Minute, policy.Backoff(5))
+	assert.Equal(t, 5*time.Minute, policy.Backoff(6))
+	assert.Equal(t, 5*time.Minute, policy.Backoff(100))
+	assert.Equal(t, time.Duration(0), JobRetryPolicy{}.Backoff(5), "no backoff retries immediately")
+}
+
+func TestJob_DeferRetry(t *testing.T) {
+	policy := JobRetryPolicy{InitialBackoff: time.Minute, MaxBackoff: time.Hour}
+	now := time.Now()
+
+	t.Run("first attempt stays pending", func(t *testing.T) {
+		job := &Job{Status: JobPending, Attempt: 1}
+		job.DeferRetry(policy, now)
+		assert.Equal(t, JobPending, job.Status)
+		assert.Nil(t, job.ScheduledAt)
+	})
+
+	t.Run("retry is scheduled after its backoff and promoted like a scheduled action", func(t *testing.T) {
+		job := &Job{Status: JobPending, Attempt: 3}
+		job.DeferRetry(policy, now)
+		assert.Equal(t, JobScheduled, job.Status)
+		assert.Equal(t, now.Add(2*time.Minute), *job.ScheduledAt)
+
+		require.NoError(t, job.Promote())
+		assert.Equal(t, JobPending, job.Status)
+	})
+
+	t.Run("later scheduled time is kept", func(t *testing.T) {
+		at := now.Add(time.Hour)
+		job := &Job{Status: JobScheduled, Attempt: 2, ScheduledAt: &at}
+		job.DeferRetry(policy, now)
+		assert.Equal(t, at, *job.ScheduledAt)
+	})
+
+	t.Run("earlier scheduled time is pushed back", func(t *testing.T) {
+		at := now.Add(10 * time.Second)
+		job := &Job{Status: JobScheduled, Attempt: 2, ScheduledAt: &at}
+		job.DeferRetry(policy, now)
+		assert.Equal(t, now.Add(time.Minute), *job.ScheduledAt)
+	})
+
+	t.Run("no backoff retries immediately", func(t *testing.T) {
+		job := &Job{Status: JobPending, Attempt: 3}
+		job.DeferRetry(JobRetryPolicy{}, now)
+		assert.Equal(t, JobPending, job.Status)
+		assert.Nil(t, job.ScheduledAt)
+	})
+}
+
+func TestJob_Lease(t *testing.T) {
+	job := &Job{Status: JobPending, Attempt: 1}
+	assert.Error(t, job.GrantLease(time.Minute), "a pending job cannot be leased")
+
+	require.NoError(t, job.Claim())
+	require.NoError(t, job.GrantLease(time.Minute))
+	leaseID := *job.LeaseID
+	assert.False(t, job.IsLeaseExpired(time.Now()))
+	assert.True(t, job.IsLeaseExpired(time.Now().Add(2*time.Minute)))
+
+	// Reports must carry the current lease
+	assert.NoError(t, job.CheckLease(&leaseID))
+	assert.Error(t, job.CheckLease(nil))
+	otherLeaseID := properties.UUID(uuid.New())
+	assert.Error(t, job.CheckLease(&otherLeaseID))
+	assert.Error(t, job.RenewLease(otherLeaseID, time.Hour))
+
+	require.NoError(t, job.RenewLease(leaseID, time.Hour))
+	assert.WithinDuration(t, time.Now().Add(time.Hour), *job.LeaseExpiresAt, time.Second)
+
+	require.NoError(t, job.ReclaimLease())
+	assert.Equal(t, JobPending, job.Status)
+	assert.Equal(t, 2, job.Attempt)
+	assert.Equal(t, LeaseExpiredMessage, job.ErrorMessage)
+	assert.NotNil(t, job.RequeuedAt)
+	assert.Nil(t, job.ClaimedAt)
+	assert.Nil(t, job.LeaseID)
+	assert.Nil(t, job.LeaseExpiresAt)
+	assert.Error(t, job.ReclaimLease(), "a pending job cannot be reclaimed")
+
+	// Jobs claimed without a lease accept any report but cannot be renewed
+	unleased := &Job{Status: JobProcessing}
+	assert.NoError(t, unleased.CheckLease(nil))
+	assert.Error(t, unleased.RenewLease(leaseID, time.Minute))
+	assert.Error(t, unleased.ReclaimLease())
+
+	// Reporting the outcome releases the lease
+	require.NoError(t, job.Claim())
+	require.NoError(t, job.GrantLease(time.Minute))
+	require.NoError(t, job.Complete())
+	assert.Nil(t, job.LeaseID)
+}
+
+func TestJobCommander_RenewLease(t *testing.T) {
+	agentID := properties.UUID(uuid.New())
+	leaseID := properties.UUID(uuid.New())
+	agentCtx := func(id properties.UUID) context.Context {
+		return auth.WithIdentity(context.Background(), &auth.Identity{Role: auth.RoleAgent, Scope: auth.IdentityScope{AgentID: &id}})
+	}
+	setup := func(t *testing.T) (*jobCommander, *MockJobRepository, *Job) {
+		expiresAt := time.Now().Add(10 * time.Second)
+		id := leaseID
+		job := &Job{BaseEntity: BaseEntity{ID: uuid.New()}, Status: JobProcessing, AgentID: agentID, LeaseID: &id, LeaseExpiresAt: &expiresAt}
+		ms := NewMockStore(t)
+		jobRepo := NewMockJobRepository(t)
+		ms.EXPECT().JobRepo().Return(jobRepo)
+		jobRepo.EXPECT().Get(mock.Anything, job.ID).Return(job, nil)
+		return NewJobCommander(ms, nil, 0, time.Minute, JobRetryPolicy{}), jobRepo, job
+	}
+
+	t.Run("extends the lease of the owner", func(t *testing.T) {
+		cmd, jobRepo, job := setup(t)
+		jobRepo.EXPECT().SaveIfLeaseHeld(mock.Anything, job, leaseID).Return(true, nil)
+
+		result, err := cmd.RenewLease(agentCtx(agentID), RenewJobLeaseParams{JobID: job.ID, LeaseID: leaseID})
+		require.NoError(t, err)
+		assert.WithinDuration(t, time.Now().Add(time.Minute), *result.LeaseExpiresAt, time.Second)
+	})
+
+	t.Run("rejects another agent", func(t *testing.T) {
+		cmd, _, job := setup(t)
+		_, err := cmd.RenewLease(agentCtx(properties.UUID(uuid.New())), RenewJobLeaseParams{JobID: job.ID, LeaseID: leaseID})
+		assert.True(t, errors.As(err, &UnauthorizedError{}))
+	})
+
+	t.Run("rejects a previous lease", func(t *testing.T) {
+		cmd, _, job := setup(t)
+		_, err := cmd.RenewLease(agentCtx(agentID), RenewJobLeaseParams{JobID: job.ID, LeaseID: properties.UUID(uuid.New())})
+		assert.True(t, errors.As(err, &ConflictError{}))
+	})
+
+	t.Run("lease reclaimed concurrently", func(t *testing.T) {
+		cmd, jobRepo, job := setup(t)
+		jobRepo.EXPECT().SaveIfLeaseHeld(mock.Anything, job, leaseID).Return(false, nil)
+
+		_, err := cmd.RenewLease(agentCtx(agentID), RenewJobLeaseParams{JobID: job.ID, LeaseID: leaseID})
+		assert.True(t, errors.As(err, &ConflictError{}))
+		assert.Contains(t, err.Error(), "no longer held")
+	})
+}
+
+func TestJobCommander_ReclaimExpiredLeases(t *testing.T) {
+	newExpiredJob := func(attempt int) *Job {
+		leaseID := properties.UUID(uuid.New())
+		expiredAt := time.Now().Add(-time.Minute)
+		return &Job{
+			BaseEntity: BaseEntity{ID: uuid.New()}, Status: JobProcessing, Action: "start", Attempt: attempt,
+			AgentID: uuid.New(), ServiceID: uuid.New(), LeaseID: &leaseID, LeaseExpiresAt: &expiredAt,
+		}
+	}
+	setup := func(t *testing.T, jobs ...*Job) (*MockStore, *MockJobRepository, *MockEventRepository) {
+		ms := setupMockStore(t)
+		jobRepo := NewMockJobRepository(t)
+		eventRepo := NewMockEventRepository(t)
+		ms.EXPECT().JobRepo().Return(jobRepo)
+		ms.EXPECT().EventRepo().Return(eventRepo).Maybe()
+		jobRepo.EXPECT().GetExpiredLeaseJobs(mock.Anything, mock.Anything).Return(jobs, nil)
+		return ms, jobRepo, eventRepo
+	}
+
+	t.Run("makes the job pending for a new attempt without backoff", func(t *testing.T) {
+		job := newExpiredJob(1)
+		leaseID := *job.LeaseID
+		ms, jobRepo, eventRepo := setup(t, job)
+		jobRepo.EXPECT().SaveIfLeaseExpired(mock.Anything, job, leaseID, mock.Anything).Return(true, nil)
+		eventRepo.EXPECT().Create(mock.Anything, mock.MatchedBy(func(e *Event) bool {
+			return e.Type == EventTypeJobReclaimed && *e.EntityID == job.ID
+		})).Return(nil)
+
+		count, err := NewJobCommander(ms, nil, 3, time.Minute, JobRetryPolicy{}).ReclaimExpiredLeases(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, 1, count)
+		assert.Equal(t, JobPending, job.Status)
+		assert.Equal(t, 2, job.Attempt)
+		assert.Nil(t, job.LeaseID)
+	})
+
+	t.Run("defers the new attempt by the backoff", func(t *testing.T) {
+		job := newExpiredJob(2)
+		ms, jobRepo, eventRepo := setup(t, job)
+		jobRepo.EXPECT().SaveIfLeaseExpired(mock.Anything, job, mock.Anything, mock.Anything).Return(true, nil)
+		eventRepo.EXPECT().Create(mock.Anything, mock.MatchedBy(func(e *Event) bool {
+			return e.Type == EventTypeJobReclaimed && e.Payload["status"] == JobScheduled
+		})).Return(nil)
+
+		retry := JobRetryPolicy{InitialBackoff: time.Minute, MaxBackoff: time.Hour}
+		count, err := NewJobCommander(ms, nil, 5, time.Minute, retry).ReclaimExpiredLeases(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, 1, count)
+		assert.Equal(t, JobScheduled, job.Status)
+		assert.Equal(t, 3, job.Attempt)
+		assert.WithinDuration(t, time.Now().Add(2*time.Minute), *job.ScheduledAt, time.Second)
+		assert.Nil(t, job.LeaseID)
+	})
+
+	t.Run("dead-letters the last attempt", func(t *testing.T) {
+		job := newExpiredJob(3)
+		ms, jobRepo, eventRepo := setup(t, job)
+		serviceType := &ServiceType{BaseEntity: BaseEntity{ID: uuid.New()}, LifecycleSchema: LifecycleSchema{States: []LifecycleState{{Name: "Started"}}}}
+		svc := &Service{BaseEntity: BaseEntity{ID: job.ServiceID}, Status: "Started", ServiceTypeID: serviceType.ID}
+		serviceRepo := NewMockServiceRepository(t)
+		serviceTypeRepo := NewMockServiceTypeRepository(t)
+		ms.EXPECT().ServiceRepo().Return(serviceRepo)
+		ms.EXPECT().ServiceTypeRepo().Return(serviceTypeRepo)
+		serviceRepo.EXPECT().Get(mock.Anything, svc.ID).Return(svc, nil)
+		serviceTypeRepo.EXPECT().Get(mock.Anything, serviceType.ID).Return(serviceType, nil)
+		serviceRepo.EXPECT().Save(mock.Anything, svc).Return(nil)
+		jobRepo.EXPECT().SaveIfLeaseExpired(mock.Anything, job, mock.Anything, mock.Anything).Return(true, nil)
+		jobRepo.EXPECT().Save(mock.Anything, job).Return(nil)
+		eventRepo.EXPECT().Create(mock.Anything, mock.MatchedBy(func(e *Event) bool {
+			return e.Type == EventTypeServiceTransitioned && *e.EntityID == svc.ID
+		})).Return(nil)
+		eventRepo.EXPECT().Create(mock.Anything, mock.MatchedBy(func(e *Event) bool {
+			return e.Type == EventTypeJobDeadLettered
+		})).Return(nil)
+		eventRepo.EXPECT().Create(mock.Anything, mock.MatchedBy(func(e *Event) bool {
+			return e.Type == EventTypeJobReclaimed
+		})).Return(nil)
+
+		count, err := NewJobCommander(ms, nil, 3, time.Minute, JobRetryPolicy{}).ReclaimExpiredLeases(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, 1, count)
+		assert.Equal(t, JobDeadLettered, job.Status)
+		assert.Equal(t, LeaseExpiredMessage, job.ErrorMessage)
+		// The lifecycle has no failed state, the service only gets the flag
+		assert.True(t, svc.Failed)
+		assert.Equal(t, "Started", svc.Status)
+	})
+
+	t.Run("skips a lease renewed concurrently", func(t *testing.T) {
+		job := newExpiredJob(1)
+		ms, jobRepo, _ := setup(t, job)
+		jobRepo.EXPECT().SaveIfLeaseExpired(mock.Anything, job, mock.Anything, mock.Anything).Return(false, nil)
+
+		count, err := NewJobCommander(ms, nil, 3, time.Minute, JobRetryPolicy{}).ReclaimExpiredLeases(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, 0, count)
+	})
+}
+
+func TestJobCommander_ReportWithStaleLease(t *testing.T) {
+	leaseID := properties.UUID(uuid.New())
+	job := &Job{BaseEntity: BaseEntity{ID: uuid.New()}, Status: JobProcessing, LeaseID: &leaseID}
+
+	ms := NewMockStore(t)
+	jobRepo := NewMockJobRepository(t)
+	ms.EXPECT().JobRepo().Return(jobRepo)
+	jobRepo.EXPECT().Get(mock.Anything, job.ID).Return(job, nil)
+
+	// The original agent comes back after its job was reclaimed and claimed again
+	staleLeaseID := properties.UUID(uuid.New())
+	cmd := NewJobCommander(ms, nil, 0, time.Minute, JobRetryPolicy{})
+	err := cmd.Complete(context.Background(), CompleteJobParams{JobID: job.ID, LeaseID: &staleLeaseID})
+	assert.True(t, errors.As(err, &ConflictError{}))
+
+	err = cmd.Fail(context.Background(), FailJobParams{JobID: job.ID, ErrorMessage: "boom"})
+	assert.True(t, errors.As(err, &ConflictError{}))
+}
+
+func TestJobCommander_CompleteWithTakenAgentInstanceID(t *testing.T) {
+	agentID := properties.UUID(uuid.New())
+	svc := &Service{BaseEntity: BaseEntity{ID: uuid.New()}, AgentID: agentID, ServiceTypeID: uuid.New()}
+	other := &Service{BaseEntity: BaseEntity{ID: uuid.New()}, AgentID: agentID}
+	job := &Job{BaseEntity: BaseEntity{ID: uuid.New()}, Status: JobProcessing, Action: "create", ServiceID: svc.ID}
+
+	ms := setupMockStore(t)
+	jobRepo := NewMockJobRepository(t)
+	serviceRepo := NewMockServiceRepository(t)
+	serviceTypeRepo := NewMockServiceTypeRepository(t)
+	ms.EXPECT().JobRepo().Return(jobRepo)
+	ms.EXPECT().ServiceRepo().Return(serviceRepo)
+	ms.EXPECT().ServiceTypeRepo().Return(serviceTypeRepo)
+	jobRepo.EXPECT().Get(mock.Anything, job.ID).Return(job, nil)
+	serviceRepo.EXPECT().Get(mock.Anything, svc.ID).Return(svc, nil)
+	serviceTypeRepo.EXPECT().Get(mock.Anything, svc.ServiceTypeID).Return(&ServiceType{}, nil)
+	serviceRepo.EXPECT().FindByAgentInstanceID(mock.Anything, agentID, "vm-1").Return(other, nil)
+
+	instanceID := "vm-1"
+	err := NewJobCommander(ms, nil, 0, time.Minute, JobRetryPolicy{}).Complete(context.Background(), CompleteJobParams{JobID: job.ID, AgentInstanceID: &instanceID})
+	assert.ErrorIs(t, err, ErrAgentInstanceIDTaken)
+	assert.ErrorAs(t, err, &ConflictError{})
+	assert.Equal(t, JobProcessing, job.Status, "the job must not be completed")
+}
+
+func TestCheckAgentInstanceIDAvailable(t *testing.T) {
+	agentID := properties.UUID(uuid.New())
+	svc := &Service{BaseEntity: BaseEntity{ID: uuid.New()}, AgentID: agentID}
+
+	tests := []struct {
+		name    string
+		found   *Service
+		findErr error
+		wantErr error
+	}{
+		{name: "Free", findErr: NewNotFoundErrorf("service not found")},
+		{name: "Reported again for the same service", found: svc},
+		{name: "Held by another service", found: &Service{BaseEntity: BaseEntity{ID: uuid.New()}}, wantErr: ErrAgentInstanceIDTaken},
+		{name: "Lookup failure", findErr: errors.New("boom"), wantErr: errors.New("boom")},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ms := NewMockStore(t)
+			serviceRepo := NewMockServiceRepository(t)
+			ms.EXPECT().ServiceRepo().Return(serviceRepo)
+			serviceRepo.EXPECT().FindByAgentInstanceID(mock.Anything, agentID, "vm-1").Return(tc.found, tc.findErr)
+
+			err := checkAgentInstanceIDAvailable(context.Background(), ms, svc, "vm-1")
+			switch {
+			case tc.wantErr == nil:
+				assert.NoError(t, err)
+			case errors.Is(tc.wantErr, ErrAgentInstanceIDTaken):
+				assert.ErrorIs(t, err, ErrAgentInstanceIDTaken)
+			default:
+				assert.EqualError(t, err, tc.wantErr.Error())
+			}
+		})
+	}
+}
+
+func TestJobCommander_UpdatePriority(t *testing.T) {
+	job := &Job{BaseEntity: BaseEntity{ID: uuid.New()}, Status: JobPending, Priority: 1}
+	ms := NewMockStore(t)
+	jobRepo := NewMockJobRepository(t)
+	ms.EXPECT().JobRepo().Return(jobRepo)
+	jobRepo.EXPECT().Get(mock.Anything, job.ID).Return(job, nil)
+	jobRepo.EXPECT().SaveIfStatus(mock.Anything, job, JobPending).Return(true, nil)
+
+	result, err := NewJobCommander(ms, nil, 0, 0, JobRetryPolicy{}).UpdatePriority(context.Background(), UpdateJobPriorityParams{JobID: job.ID, Priority: 10})
+	require.NoError(t, err)
+	assert.Equal(t, 10, result.Priority)
+}
+
+func TestJob_DeadLetterAndRequeue(t *testing.T) {
+	job := &Job{Status: JobFailed, Attempt: 2}
+	assert.False(t, job.ShouldDeadLetter(3))
+	assert.True(t, job.ShouldDeadLetter(2))
+	assert.False(t, job.ShouldDeadLetter(0), "zero max attempts disables dead-lettering")
+	assert.False(t, (&Job{Status: JobCompleted, Attempt: 5}).ShouldDeadLetter(1))
+
+	assert.Error(t, job.Requeue())
+	require.NoError(t, job.DeadLetter())
+	assert.Equal(t, JobDeadLettered, job.Status)
+	assert.NotNil(t, job.CompletedAt)
+	assert.False(t, job.IsActive())
+	assert.Error(t, job.DeadLetter())
+
+	job.ErrorMessage = "boom"
+	require.NoError(t, job.Requeue())
+	assert.Equal(t, JobPending, job.Status)
+	assert.Equal(t, 1, job.Attempt)
+	assert.Empty(t, job.ErrorMessage)
+	assert.NotNil(t, job.RequeuedAt)
+	assert.Nil(t, job.CompletedAt)
+}
+
+func TestJobCommander_FailLastAttemptFailsService(t *testing.T) {
+	ctx := auth.WithIdentity(context.Background(), &auth.Identity{ID: uuid.New(), Role: auth.RoleAgent})
+	serviceType := &ServiceType{
+		BaseEntity: BaseEntity{ID: uuid.New()},
+		LifecycleSchema: LifecycleSchema{
+			States:         []LifecycleState{{Name: "Stopped"}, {Name: "Started"}, {Name: "Failed"}},
+			Actions:        []LifecycleAction{{Name: "start", Transitions: []LifecycleTransition{{From: "Stopped", To: "Started"}}}},
+			InitialState:   "Stopped",
+			TerminalStates: []string{"Failed"},
+			FailedState:    "Failed",
+		},
+	}
+	svc := &Service{BaseEntity: BaseEntity{ID: uuid.New()}, Name: "svc", Status: "Stopped", ServiceTypeID: serviceType.ID,
+		GroupID: uuid.New(), ProviderID: uuid.New(), ConsumerID: uuid.New(), AgentID: uuid.New()}
+	job := &Job{BaseEntity: BaseEntity{ID: uuid.New()}, Status: JobProcessing, Action: "start", Attempt: 3, ServiceID: svc.ID}
+
+	ms := setupMockStore(t)
+	jobRepo := NewMockJobRepository(t)
+	serviceRepo := NewMockServiceRepository(t)
+	serviceTypeRepo := NewMockServiceTypeRepository(t)
+	poolValueRepo := NewMockServicePoolValueRepository(t)
+	eventRepo := NewMockEventRepository(t)
+	ms.EXPECT().JobRepo().Return(jobRepo)
+	ms.EXPECT().ServiceRepo().Return(serviceRepo)
+	ms.EXPECT().ServiceTypeRepo().Return(serviceTypeRepo)
+	ms.EXPECT().ServicePoolValueRepo().Return(poolValueRepo)
+	ms.EXPECT().EventRepo().Return(eventRepo)
+	jobRepo.EXPECT().Get(mock.Anything, job.ID).Return(job, nil)
+	jobRepo.EXPECT().Save(mock.Anything, job).Return(nil)
+	serviceRepo.EXPECT().Get(mock.Anything, svc.ID).Return(svc, nil)
+	serviceTypeRepo.EXPECT().Get(mock.Anything, serviceType.ID).Return(serviceType, nil)
+	serviceRepo.EXPECT().Save(mock.Anything, svc).Return(nil)
+	poolValueRepo.EXPECT().ReleaseByService(mock.Anything, svc.ID).Return(nil)
+	eventRepo.EXPECT().Create(mock.Anything, mock.Anything).Return(nil).Times(3)
+
+	// The lifecycle has no error transition for the action, the service still ends up failed
+	err := NewJobCommander(ms, nil, 3, time.Minute, JobRetryPolicy{}).Fail(ctx, FailJobParams{JobID: job.ID, ErrorMessage: "boom"})
+	require.NoError(t, err)
+	assert.Equal(t, JobDeadLettered, job.Status)
+	assert.Equal(t, "Failed", svc.Status)
+	assert.True(t, svc.Failed)
+	assert.NotNil(t, svc.FailedAt)
+}
+
+func TestJob_Duration(t *testing.T) {
+	job := &Job{Status: JobPending}
+	_, ok := job.Duration()
+	assert.False(t, ok, "a job never claimed has no duration")
+
+	require.NoError(t, job.Claim())
+	_, ok = job.Duration()
+	assert.False(t, ok, "a processing job has no duration")
+
+	require.NoError(t, job.Fail("boom"))
+	require.NotNil(t, job.CompletedAt, "failing records the completion time")
+	claimedAt := job.CompletedAt.Add(-2 * time.Minute)
+	job.ClaimedAt = &claimedAt
+	duration, ok := job.Duration()
+	assert.True(t, ok)
+	assert.Equal(t, 2*time.Minute, duration)
+}
+
+func TestJobDurationStatsQuery_Validate(t *testing.T) {
+	to := time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC)
+	valid := JobDurationStatsQuery{From: to.Add(-time.Hour), To: to, GroupBy: []JobStatsGroup{JobStatsGroupAgentType}}
+	assert.NoError(t, valid.Validate())
+	assert.True(t, valid.GroupedBy(JobStatsGroupAgentType))
+	assert.False(t, valid.GroupedBy(JobStatsGroupAction))
+
+	assert.Error(t, JobDurationStatsQuery{From: to, To: to}.Validate(), "empty range")
+	assert.Error(t, JobDurationStatsQuery{From: to.Add(-JobDurationStatsMaxRange - time.Hour), To: to}.Validate(), "range too long")
+	assert.Error(t, JobDurationStatsQuery{From: to.Add(-time.Hour), To: to, GroupBy: []JobStatsGroup{"service"}}.Validate(), "unknown group")
+
+	_, err := ParseJobStatsGroup("agentType")
+	assert.NoError(t, err)
+	_, err = ParseJobStatsGroup("agent")
+	assert.Error(t, err)
+}
+
+func TestNextJobAttempt(t *testing.T) {
+	svc := &Service{BaseEntity: BaseEntity{ID: uuid.New()}}
+
+	tests := []struct {
+		name     string
+		last     *Job
+		expected int
+		wantErr  bool
+	}{
+		{name: "No previous job", expected: 1},
+		{name: "Retry of a failed job", last: &Job{Action: "start", Status: JobFailed, Attempt: 2}, expected: 3},
+		{name: "Failed job of another action", last: &Job{Action: "stop", Status: JobFailed, Attempt: 2}, expected: 1},
+		{name: "Completed job", last: &Job{Action: "start", Status: JobCompleted, Attempt: 2}, expected: 1},
+		{name: "Dead-lettered action", last: &Job{Action: "start", Status: JobDeadLettered, Attempt: 3}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ms := NewMockStore(t)
+			jobRepo := NewMockJobRepository(t)
+			ms.EXPECT().JobRepo().Return(jobRepo)
+			jobRepo.EXPECT().GetLastJobForService(mock.Anything, svc.ID).Return(tt.last, nil)
+
+			attempt, err := nextJobAttempt(context.Background(), ms, svc, "start")
+			if tt.wantErr {
+				assert.True(t, errors.As(err, &InvalidInputError{}))
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, attempt)
+		})
+	}
+}
+
+func TestJobCommander_Requeue(t *testing.T) {
+	serviceType := &ServiceType{
+		BaseEntity: BaseEntity{ID: uuid.New()},
+		LifecycleSchema: LifecycleSchema{
+			States:       []LifecycleState{{Name: "Started"}, {Name: "Stopped"}},
+			InitialState: "Started",
+			Actions: []LifecycleAction{
+				{Name: "stop", Transitions: []LifecycleTransition{{From: "Started", To: "Stopped"}}},
+			},
+		},
+	}
+	svc := &Service{BaseEntity: BaseEntity{ID: uuid.New()}, Status: "Started", ServiceTypeID: serviceType.ID}
+
+	setup := func(t *testing.T, job *Job) (*MockStore, *MockJobRepository, *MockServiceRepository) {
+		ms := setupMockStore(t)
+		serviceRepo := NewMockServiceRepository(t)
+		serviceTypeRepo := NewMockServiceTypeRepository(t)
+		jobRepo := NewMockJobRepository(t)
+		ms.EXPECT().ServiceRepo().Return(serviceRepo).Maybe()
+		ms.EXPECT().ServiceTypeRepo().Return(serviceTypeRepo).Maybe()
+		ms.EXPECT().JobRepo().Return(jobRepo)
+		serviceRepo.EXPECT().Get(mock.Anything, svc.ID).Return(svc, nil).Maybe()
+		serviceTypeRepo.EXPECT().Get(mock.Anything, serviceType.ID).Return(serviceType, nil).Maybe()
+		jobRepo.EXPECT().Get(mock.Anything, job.ID).Return(job, nil)
+		jobRepo.EXPECT().GetLastJobForService(mock.Anything, svc.ID).Return(job, nil).Maybe()
+		return ms, jobRepo, serviceRepo
+	}
+
+	t.Run("requeues a dead-lettered job", func(t *testing.T) {
+		job := &Job{BaseEntity: BaseEntity{ID: uuid.New()}, Status: JobDeadLettered, Action: "stop", Attempt: 3, ServiceID: svc.ID, ErrorMessage: "boom"}
+		svc.MarkFailed(serviceType.LifecycleSchema)
+		defer svc.ClearFailed()
+		ms, jobRepo, serviceRepo := setup(t, job)
+		serviceRepo.EXPECT().Save(mock.Anything, svc).Return(nil)
+		eventRepo := NewMockEventRepository(t)
+		ms.EXPECT().EventRepo().Return(eventRepo)
+		jobRepo.EXPECT().SaveIfStatus(mock.Anything, job, JobDeadLettered).Return(true, nil)
+		eventRepo.EXPECT().Create(mock.Anything, mock.MatchedBy(func(e *Event) bool {
+			return e.Type == EventTypeJobRequeued && *e.EntityID == job.ID
+		})).Return(nil)
+
+		ctx := auth.WithIdentity(context.Background(), &auth.Identity{Role: auth.RoleAdmin, ID: properties.UUID(uuid.New())})
+		result, err := NewJobCommander(ms, nil, 3, 0, JobRetryPolicy{}).Requeue(ctx, job.ID)
+		require.NoError(t, err)
+		assert.Equal(t, JobPending, result.Status)
+		assert.Equal(t, 1, result.Attempt)
+		assert.Empty(t, result.ErrorMessage)
+		assert.False(t, svc.Failed, "the requeue clears the failed flag of the service")
+	})
+
+	t.Run("rejects a job not dead-lettered", func(t *testing.T) {
+		job := &Job{BaseEntity: BaseEntity{ID: uuid.New()}, Status: JobFailed, Action: "stop", ServiceID: svc.ID}
+		ms, _, _ := setup(t, job)
+
+		_, err := NewJobCommander(ms, nil, 3, 0, JobRetryPolicy{}).Requeue(context.Background(), job.ID)
+		assert.True(t, errors.As(err, &InvalidInputError{}))
+	})
+
+	t.Run("rejects an action the service no longer allows", func(t *testing.T) {
+		job := &Job{BaseEntity: BaseEntity{ID: uuid.New()}, Status: JobDeadLettered, Action: "start", ServiceID: svc.ID}
+		ms, _, _ := setup(t, job)
+
+		_, err := NewJobCommander(ms, nil, 3, 0, JobRetryPolicy{}).Requeue(context.Background(), job.ID)
+		assert.True(t, errors.As(err, &InvalidInputError{}))
+		assert.Equal(t, JobDeadLettered, job.Status)
+	})
+}
+
+func TestQueueTargetStateJob(t *testing.T) {
+	lifecycle := LifecycleSchema{
+		States: []LifecycleState{{Name: "New"}, {Name: "Created"}, {Name: "Started"}, {Name: "Deleted"}},
+		Actions: []LifecycleAction{
+			{Name: "create", Transitions: []LifecycleTransition{{From: "New", To: "Created"}}},
+			{Name: "start", Transitions: []LifecycleTransition{{From: "Created", To: "Started"}}},
+			{Name: "delete", Transitions: []LifecycleTransition{{From: "Created", To: "Deleted"}, {From: "Started", To: "Deleted"}}},
+		},
+		InitialState:   "New",
+		TerminalStates: []string{"Deleted"},
+	}
+	target := "Started"
+
+	t.Run("creates the job of the next action", func(t *testing.T) {
+		svc := &Service{BaseEntity: BaseEntity{ID: uuid.New()}, Status: "Created", AgentID: uuid.New()}
+		ms := NewMockStore(t)
+		jobRepo := NewMockJobRepository(t)
+		ms.EXPECT().JobRepo().Return(jobRepo)
+		jobRepo.EXPECT().Create(mock.Anything, mock.MatchedBy(func(job *Job) bool {
+			return job.Action == "start" && job.Status == JobPending && job.ServiceID == svc.ID &&
+				job.TargetState != nil && *job.TargetState == target
+		})).Return(nil)
+
+		completed := &Job{Action: "create", TargetState: &target}
+		require.NoError(t, queueTargetStateJob(context.Background(), ms, lifecycle, svc, completed))
+	})
+
+	t.Run("target reached", func(t *testing.T) {
+		svc := &Service{Status: "Started"}
+		completed := &Job{Action: "start", TargetState: &target}
+		assert.NoError(t, queueTargetStateJob(context.Background(), NewMockStore(t), lifecycle, svc, completed))
+	})
+
+	t.Run("no target", func(t *testing.T) {
+		svc := &Service{Status: "Created"}
+		assert.NoError(t, queueTargetStateJob(context.Background(), NewMockStore(t), lifecycle, svc, &Job{Action: "create"}))
+	})
+
+	t.Run("terminal state", func(t *testing.T) {
+		svc := &Service{Status: "Deleted"}
+		completed := &Job{Action: "delete", TargetState: &target}
+		assert.NoError(t, queueTargetStateJob(context.Background(), NewMockStore(t), lifecycle, svc, completed))
+	})
+
+	t.Run("service in maintenance", func(t *testing.T) {
+		svc := &Service{Status: "Created", Maintenance: true}
+		completed := &Job{Action: "create", TargetState: &target}
+		assert.NoError(t, queueTargetStateJob(context.Background(), NewMockStore(t), lifecycle, svc, completed))
+	})
+}
@@ -25,17 +25,52 @@ const (
 	AggregateAvg AggregateType = "avg"
 	// AggregateDiffMaxMin returns the difference between max and min values
 	AggregateDiffMaxMin AggregateType = "diff"
+	// AggregateHistogram returns the merged histogram of histogram entries
+	AggregateHistogram AggregateType = "histogram"
+	// AggregateP50 returns the approximate median of histogram entries
+	AggregateP50 AggregateType = "p50"
+	// AggregateP95 returns the approximate 95th percentile of histogram entries
+	AggregateP95 AggregateType = "p95"
+	// AggregateP99 returns the approximate 99th percentile of histogram entries
+	AggregateP99 AggregateType = "p99"
+	// AggregateRate returns the per-second increase of counter entries
+	AggregateRate AggregateType = "rate"
 )
 
 func (s AggregateType) Validate() error {
 	switch s {
-	case AggregateMin, AggregateMax, AggregateSum, AggregateAvg, AggregateDiffMaxMin:
+	case AggregateMin, AggregateMax, AggregateSum, AggregateAvg, AggregateDiffMaxMin,
+		AggregateHistogram, AggregateP50, AggregateP95, AggregateP99, AggregateRate:
 		return nil
 	default:
 		return fmt.Errorf("invalid aggregate type: %s", s)
 	}
 }
 
+// IsHistogram returns true when the aggregation applies to histogram entries only
+func (s AggregateType) IsHistogram() bool {
+	switch s {
+	case AggregateHistogram, AggregateP50, AggregateP95, AggregateP99:
+		return true
+	default:
+		return false
+	}
+}
+
+// Quantile returns the quantile computed by a percentile aggregation
+func (s AggregateType) Quantile() (float64, bool) {
+	switch s {
+	case AggregateP50:
+		return 0.5, true
+	case AggregateP95:
+		return 0.95, true
+	case AggregateP99:
+		return 0.99, true
+	default:
+		return 0, false
+	}
+}
+
 func ParseAggregateType(s string) (AggregateType, error) {
 	aggType := AggregateType(s)
 
@@ -154,8 +189,9 @@ type MetricEntry struct {
 	CreatedAt time.Time       `json:"-" gorm:"not null;default:CURRENT_TIMESTAMP;index:idx_metric_aggregate,priority:3"`
 	UpdatedAt time.Time       `json:"-" gorm:"not null;default:CURRENT_TIMESTAMP"`
 
-	ResourceID string  `gorm:"not null;index"`
-	Value      float64 `gorm:"not null"`
+	ResourceID string     `gorm:"not null;index"`
+	Value      float64    `gorm:"not null"`   // Number of observations for histogram entries
+	Histogram  *Histogram `gorm:"type:jsonb"` // Only set for histogram entries
 
 	// Relationships
 	TypeID     properties.UUID `gorm:"not null;index:idx_metric_aggregate,priority:2"`
@@ -201,6 +237,11 @@ func (m MetricEntry) GetID() properties.UUID {
 	return m.ID
 }
 
+// GetCreatedAt returns the entity's creation time
+func (m MetricEntry) GetCreatedAt() time.Time {
+	return m.CreatedAt
+}
+
 // BeforeCreate ensures properties.UUID is set before creating a record
 func (m *MetricEntry) BeforeCreate(tx *gorm.DB) error {
 	if uuid.UUID(m.ID) == uuid.Nil {
@@ -226,6 +267,32 @@ func (p *MetricEntry) Validate() error {
 	return nil
 }
 
+// SetValue sets the value of the entry according to the kind of its metric type
+// Scalar values must be within the bounds of the metric type, histogram entries require
+// a valid histogram and store its number of observations as value
+func (p *MetricEntry) SetValue(metricType *MetricType, value float64, histogram *Histogram) error {
+	if metricType.Kind != MetricEntryKindHistogram {
+		if histogram != nil {
+			return fmt.Errorf("metric type %s is not a histogram and does not accept histogram values", metricType.Name)
+		}
+		if err := metricType.ValidateValue(value); err != nil {
+			return err
+		}
+		p.Value = value
+		p.Histogram = nil
+		return nil
+	}
+	if histogram == nil {
+		return fmt.Errorf("metric type %s is a histogram and requires a histogram value", metricType.Name)
+	}
+	if err := histogram.Validate(); err != nil {
+		return err
+	}
+	p.Value = float64(histogram.Total())
+	p.Histogram = histogram
+	return nil
+}
+
 // MetricEntryCommander defines the interface for metric entry command operations
 type MetricEntryCommander interface {
 	// Create creates a new metric entry
@@ -241,6 +308,7 @@ type CreateMetricEntryParams struct {
 	ServiceID  properties.UUID `json:"serviceId"`
 	ResourceID string          `json:"resourceId"`
 	Value      float64         `json:"value"`
+	Histogram  *Histogram      `json:"histogram,omitempty"`
 }
 
 type CreateMetricEntryWithAgentInstanceIDParams struct {
@@ -249,6 +317,7 @@ type CreateMetricEntryWithAgentInstanceIDParams struct {
 	AgentInstanceID string          `json:"agentInstanceId"`
 	ResourceID      string          `json:"resourceId"`
 	Value           float64         `json:"value"`
+	Histogram       *Histogram      `json:"histogram,omitempty"`
 }
 
 // metricEntryCommander is the concrete implementation of MetricEntryCommander
@@ -312,6 +381,9 @@ func (s *metricEntryCommander) CreateWithAgentInstanceID(
 		metricType.ID,
 		params.Value,
 	)
+	if err := metricEntry.SetValue(metricType, params.Value, params.Histogram); err != nil {
+		return nil, InvalidInputError{Err: err}
+	}
 
 	if err := metricEntry.Validate(); err != nil {
 		return nil, InvalidInputError{Err: err}
@@ -321,6 +393,8 @@ func (s *metricEntryCommander) CreateWithAgentInstanceID(
 	if err := s.metricEntryRepo.Create(ctx, metricEntry); err != nil {
 		return nil, err
 	}
+	// Set once saved, the metric types are not stored with the entries
+	metricEntry.Type = metricType
 
 	return metricEntry, nil
 }
@@ -369,6 +443,9 @@ func (s *metricEntryCommander) Create(
 		metricType.ID,
 		params.Value,
 	)
+	if err := metricEntry.SetValue(metricType, params.Value, params.Histogram); err != nil {
+		return nil, InvalidInputError{Err: err}
+	}
 
 	if err := metricEntry.Validate(); err != nil {
 		return nil, InvalidInputError{Err: err}
@@ -378,6 +455,8 @@ func (s *metricEntryCommander) Create(
 	if err := s.metricEntryRepo.Create(ctx, metricEntry); err != nil {
 		return nil, err
 	}
+	// Set once saved, the metric types are not stored with the entries
+	metricEntry.Type = metricType
 
 	return metricEntry, nil
 }
@@ -401,4 +480,7 @@ type MetricEntryQuerier interface {
 
 	// ListResourceIDs returns the distinct resource IDs
 	ListResourceIDs(ctx context.Context, scope *auth.IdentityScope, page *PageReq) (*PageRes[string], error)
+
+	// LastEntryTimes returns the time of the last entry of each of the services, the services without entries are left out
+	LastEntryTimes(ctx context.Context, serviceIDs []properties.UUID) (map[properties.UUID]time.Time, error)
 }
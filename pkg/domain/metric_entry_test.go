@@ -4,6 +4,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/fulcrumproject/core/pkg/helpers"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 )
@@ -145,3 +146,60 @@ func TestAggregateBucket_DefaultStart(t *testing.T) {
 	assert.Equal(t, end.Add(-24*time.Hour), AggregateBucketMinute.DefaultStart(end))
 	assert.Equal(t, end.Add(-7*24*time.Hour), AggregateBucketHour.DefaultStart(end))
 }
+
+func TestMetricEntry_SetValue(t *testing.T) {
+	scalar := &MetricType{Name: "cpu-usage", Kind: MetricEntryKindScalar}
+	histogram := &MetricType{Name: "request-latency", Kind: MetricEntryKindHistogram}
+	validHistogram := &Histogram{Bounds: []float64{0.1, 0.5}, Counts: []int64{3, 2, 1}, Sum: 1.2}
+
+	t.Run("Scalar value", func(t *testing.T) {
+		entry := &MetricEntry{}
+		assert.NoError(t, entry.SetValue(scalar, 42, nil))
+		assert.Equal(t, 42.0, entry.Value)
+		assert.Nil(t, entry.Histogram)
+	})
+
+	t.Run("Histogram value stores the number of observations", func(t *testing.T) {
+		entry := &MetricEntry{}
+		assert.NoError(t, entry.SetValue(histogram, 0, validHistogram))
+		assert.Equal(t, 6.0, entry.Value)
+		assert.Equal(t, validHistogram, entry.Histogram)
+	})
+
+	t.Run("Histogram on scalar type", func(t *testing.T) {
+		err := (&MetricEntry{}).SetValue(scalar, 0, validHistogram)
+		assert.EqualError(t, err, "metric type cpu-usage is not a histogram and does not accept histogram values")
+	})
+
+	t.Run("Missing histogram", func(t *testing.T) {
+		err := (&MetricEntry{}).SetValue(histogram, 5, nil)
+		assert.EqualError(t, err, "metric type request-latency is a histogram and requires a histogram value")
+	})
+
+	t.Run("Scalar value out of the bounds", func(t *testing.T) {
+		bounded := &MetricType{Name: "cpu-usage", Kind: MetricEntryKindScalar, Unit: "percent", Min: helpers.FloatPtr(0), Max: helpers.FloatPtr(100)}
+		entry := &MetricEntry{}
+		assert.EqualError(t, entry.SetValue(bounded, -3, nil), "value -3 of metric type cpu-usage is below its minimum 0 percent")
+		assert.Zero(t, entry.Value)
+		assert.NoError(t, entry.SetValue(bounded, 42, nil))
+	})
+
+	t.Run("Invalid histogram", func(t *testing.T) {
+		err := (&MetricEntry{}).SetValue(histogram, 0, &Histogram{Bounds: []float64{0.5, 0.1}, Counts: []int64{1, 1, 1}})
+		assert.ErrorContains(t, err, "strictly increasing")
+	})
+}
+
+func TestAggregateType_Histogram(t *testing.T) {
+	for _, agg := range []AggregateType{AggregateHistogram, AggregateP50, AggregateP95, AggregateP99} {
+		assert.NoError(t, agg.Validate())
+		assert.True(t, agg.IsHistogram(), agg)
+	}
+	assert.False(t, AggregateAvg.IsHistogram())
+
+	q, ok := AggregateP95.Quantile()
+	assert.True(t, ok)
+	assert.Equal(t, 0.95, q)
+	_, ok = AggregateHistogram.Quantile()
+	assert.False(t, ok)
+}
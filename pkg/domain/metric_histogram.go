@@ -0,0 +1,143 @@
+package domain
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"math"
+	"slices"
+)
+
+// MetricEntryKind defines the shape of the values collected for a metric type
+type MetricEntryKind string
+
+const (
+	// MetricEntryKindScalar entries carry a single numeric value
+	MetricEntryKindScalar MetricEntryKind = "scalar"
+	// MetricEntryKindHistogram entries carry bucket boundaries and counts
+	MetricEntryKindHistogram MetricEntryKind = "histogram"
+)
+
+// Validate ensures the MetricEntryKind is one of the allowed values
+func (k MetricEntryKind) Validate() error {
+	switch k {
+	case MetricEntryKindScalar, MetricEntryKindHistogram:
+		return nil
+	default:
+		return fmt.Errorf("invalid %v metric entry kind", k)
+	}
+}
+
+// Histogram is a distribution of observations in cumulative-free buckets
+//
+// Bounds are the inclusive upper bounds of the buckets in strictly increasing order,
+// Counts has one more element than Bounds, the last one counting the observations
+// above the highest bound.
+type Histogram struct {
+	Bounds []float64 `json:"bounds"`
+	Counts []int64   `json:"counts"`
+	Sum    float64   `json:"sum"`
+}
+
+// Scan implements the sql.Scanner interface
+func (h *Histogram) Scan(value any) error {
+	if value == nil {
+		return nil
+	}
+
+	bytes, ok := value.([]byte)
+	if !ok {
+		return fmt.Errorf("failed to unmarshal Histogram value: %v", value)
+	}
+
+	return json.Unmarshal(bytes, h)
+}
+
+// Value implements the driver.Valuer interface
+func (h Histogram) Value() (driver.Value, error) {
+	return json.Marshal(h)
+}
+
+// Validate ensures the bounds are finite and monotonic and the counts match them
+func (h *Histogram) Validate() error {
+	if len(h.Bounds) == 0 {
+		return fmt.Errorf("histogram must have at least one bucket bound")
+	}
+	for i, b := range h.Bounds {
+		if math.IsNaN(b) || math.IsInf(b, 0) {
+			return fmt.Errorf("histogram bound %d must be a finite number", i)
+		}
+		if i > 0 && b <= h.Bounds[i-1] {
+			return fmt.Errorf("histogram bounds must be strictly increasing: bound %d (%v) is not greater than %v", i, b, h.Bounds[i-1])
+		}
+	}
+	if len(h.Counts) != len(h.Bounds)+1 {
+		return fmt.Errorf("histogram must have %d counts for %d bounds, got %d", len(h.Bounds)+1, len(h.Bounds), len(h.Counts))
+	}
+	for i, c := range h.Counts {
+		if c < 0 {
+			return fmt.Errorf("histogram count %d cannot be negative", i)
+		}
+	}
+	return nil
+}
+
+// Total returns the number of observations
+func (h *Histogram) Total() int64 {
+	var total int64
+	for _, c := range h.Counts {
+		total += c
+	}
+	return total
+}
+
+// Quantile returns the approximate value below which the q fraction of the observations falls
+//
+// The value is interpolated linearly within the bucket containing the quantile, the lowest
+// bucket starts at zero (or its bound when negative) and the overflow bucket reports the
+// highest bound. Empty histograms return 0.
+func (h *Histogram) Quantile(q float64) float64 {
+	total := h.Total()
+	if total == 0 {
+		return 0
+	}
+	rank := q * float64(total)
+	var cumulative int64
+	for i, c := range h.Counts {
+		if c == 0 || float64(cumulative+c) < rank {
+			cumulative += c
+			continue
+		}
+		if i == len(h.Bounds) {
+			return h.Bounds[len(h.Bounds)-1]
+		}
+		upper := h.Bounds[i]
+		lower := math.Min(0, upper)
+		if i > 0 {
+			lower = h.Bounds[i-1]
+		}
+		return lower + (upper-lower)*(rank-float64(cumulative))/float64(c)
+	}
+	return h.Bounds[len(h.Bounds)-1]
+}
+
+// MergeHistograms adds up the counts and sums of histograms sharing the same bounds
+func MergeHistograms(histograms ...*Histogram) (*Histogram, error) {
+	if len(histograms) == 0 {
+		return nil, fmt.Errorf("no histograms to merge")
+	}
+	merged := &Histogram{
+		Bounds: slices.Clone(histograms[0].Bounds),
+		Counts: make([]int64, len(histograms[0].Counts)),
+	}
+	for _, h := range histograms {
+		if !slices.Equal(h.Bounds, merged.Bounds) || len(h.Counts) != len(merged.Counts) {
+			return nil, fmt.Errorf("cannot merge histograms with different bucket bounds")
+		}
+		for i, c := range h.Counts {
+			merged.Counts[i] += c
+		}
+		merged.Sum += h.Sum
+	}
+	return merged, nil
+}
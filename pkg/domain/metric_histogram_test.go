@@ -0,0 +1,98 @@
+package domain
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetricEntryKind_Validate(t *testing.T) {
+	assert.NoError(t, MetricEntryKindScalar.Validate())
+	assert.NoError(t, MetricEntryKindHistogram.Validate())
+	assert.Error(t, MetricEntryKind("summary").Validate())
+}
+
+func TestHistogram_Validate(t *testing.T) {
+	tests := []struct {
+		name       string
+		histogram  *Histogram
+		errMessage string
+	}{
+		{
+			name:      "Valid histogram",
+			histogram: &Histogram{Bounds: []float64{-1, 0, 2.5}, Counts: []int64{0, 1, 2, 3}},
+		},
+		{
+			name:       "No bounds",
+			histogram:  &Histogram{Counts: []int64{1}},
+			errMessage: "histogram must have at least one bucket bound",
+		},
+		{
+			name:       "Decreasing bounds",
+			histogram:  &Histogram{Bounds: []float64{1, 0.5}, Counts: []int64{1, 1, 1}},
+			errMessage: "histogram bounds must be strictly increasing: bound 1 (0.5) is not greater than 1",
+		},
+		{
+			name:       "Duplicate bounds",
+			histogram:  &Histogram{Bounds: []float64{1, 1}, Counts: []int64{1, 1, 1}},
+			errMessage: "histogram bounds must be strictly increasing",
+		},
+		{
+			name:       "Infinite bound",
+			histogram:  &Histogram{Bounds: []float64{1, math.Inf(1)}, Counts: []int64{1, 1, 1}},
+			errMessage: "histogram bound 1 must be a finite number",
+		},
+		{
+			name:       "Counts not matching bounds",
+			histogram:  &Histogram{Bounds: []float64{1, 2}, Counts: []int64{1, 1}},
+			errMessage: "histogram must have 3 counts for 2 bounds, got 2",
+		},
+		{
+			name:       "Negative count",
+			histogram:  &Histogram{Bounds: []float64{1}, Counts: []int64{1, -1}},
+			errMessage: "histogram count 1 cannot be negative",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.histogram.Validate()
+			if tt.errMessage == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorContains(t, err, tt.errMessage)
+		})
+	}
+}
+
+func TestHistogram_Quantile(t *testing.T) {
+	// 100 observations: 50 up to 10, 40 up to 20, 9 up to 40 and 1 above
+	h := &Histogram{Bounds: []float64{10, 20, 40}, Counts: []int64{50, 40, 9, 1}}
+
+	assert.InDelta(t, 10.0, h.Quantile(0.5), 1e-9)
+	assert.InDelta(t, 16.25, h.Quantile(0.75), 1e-9)
+	assert.InDelta(t, 31.111, h.Quantile(0.95), 1e-3)
+	assert.InDelta(t, 40.0, h.Quantile(0.99), 1e-9)
+	assert.InDelta(t, 40.0, h.Quantile(1), 1e-9, "overflow bucket reports the highest bound")
+
+	assert.Equal(t, 0.0, (&Histogram{Bounds: []float64{1}, Counts: []int64{0, 0}}).Quantile(0.5))
+}
+
+func TestMergeHistograms(t *testing.T) {
+	a := &Histogram{Bounds: []float64{1, 2}, Counts: []int64{1, 2, 3}, Sum: 10}
+	b := &Histogram{Bounds: []float64{1, 2}, Counts: []int64{4, 0, 1}, Sum: 5}
+
+	merged, err := MergeHistograms(a, b)
+	require.NoError(t, err)
+	assert.Equal(t, &Histogram{Bounds: []float64{1, 2}, Counts: []int64{5, 2, 4}, Sum: 15}, merged)
+	assert.Equal(t, []int64{1, 2, 3}, a.Counts, "inputs must not change")
+
+	_, err = MergeHistograms(a, &Histogram{Bounds: []float64{1, 3}, Counts: []int64{1, 1, 1}})
+	assert.EqualError(t, err, "cannot merge histograms with different bucket bounds")
+
+	_, err = MergeHistograms()
+	assert.Error(t, err)
+}
@@ -0,0 +1,112 @@
+package domain
+
+import (
+	"time"
+)
+
+// CounterSample is a value of a counter series at the time it was recorded
+type CounterSample struct {
+	Time  time.Time
+	Value float64
+}
+
+// truncate returns the start of the bucket containing the time, in UTC like the buckets of the aggregations
+func (b AggregateBucket) truncate(t time.Time) time.Time {
+	t = t.UTC()
+	switch b {
+	case AggregateBucketMinute:
+		return t.Truncate(time.Minute)
+	case AggregateBucketHour:
+		return t.Truncate(time.Hour)
+	case AggregateBucketDay:
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	case AggregateBucketMonth:
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	default:
+		return t
+	}
+}
+
+// next returns the start of the bucket following the one starting at the time
+func (b AggregateBucket) next(start time.Time) time.Time {
+	switch b {
+	case AggregateBucketMinute:
+		return start.Add(time.Minute)
+	case AggregateBucketHour:
+		return start.Add(time.Hour)
+	case AggregateBucketDay:
+		return start.AddDate(0, 0, 1)
+	case AggregateBucketMonth:
+		return start.AddDate(0, 1, 0)
+	default:
+		return start
+	}
+}
+
+// counterIncrease returns the increase of a counter between two consecutive samples
+// A decrease means the counter was reset, or wrapped, and restarted from zero, so the increase since the
+// reset is the new value; what was counted between the previous sample and the reset is lost
+func counterIncrease(prev, cur float64) float64 {
+	if cur < prev {
+		return cur
+	}
+	return cur - prev
+}
+
+// CounterRates returns the per-second rate of a counter series in each bucket of the time range
+// The samples must be in time order, a sample before the start can be given as baseline of the first interval.
+// The increase between two consecutive samples is spread evenly over the time between them, so an interval
+// spanning several buckets, across a gap in the series, contributes to each of them in proportion of its
+// overlap. The rate of a bucket is its increase over the time of the bucket covered by the series, and the
+// buckets not covered at all, before the first sample, after the last one or without samples around them,
+// have no value rather than a zero rate.
+func CounterRates(samples []CounterSample, bucket AggregateBucket, start, end time.Time) []AggregateData {
+	type bucketRate struct {
+		start    time.Time
+		increase float64
+		covered  time.Duration
+	}
+	var buckets []*bucketRate
+
+	for i := 1; i < len(samples); i++ {
+		prev, cur := samples[i-1], samples[i]
+		elapsed := cur.Time.Sub(prev.Time)
+		if elapsed <= 0 {
+			continue
+		}
+		increase := counterIncrease(prev.Value, cur.Value)
+
+		from, to := prev.Time, cur.Time
+		if from.Before(start) {
+			from = start
+		}
+		if to.After(end) {
+			to = end
+		}
+		for b := bucket.truncate(from); b.Before(to); b = bucket.next(b) {
+			overlapStart, overlapEnd := b, bucket.next(b)
+			if overlapStart.Before(from) {
+				overlapStart = from
+			}
+			if overlapEnd.After(to) {
+				overlapEnd = to
+			}
+			overlap := overlapEnd.Sub(overlapStart)
+			if overlap <= 0 {
+				continue
+			}
+			if len(buckets) == 0 || !buckets[len(buckets)-1].start.Equal(b) {
+				buckets = append(buckets, &bucketRate{start: b})
+			}
+			current := buckets[len(buckets)-1]
+			current.increase += increase * float64(overlap) / float64(elapsed)
+			current.covered += overlap
+		}
+	}
+
+	data := make([]AggregateData, 0, len(buckets))
+	for _, b := range buckets {
+		data = append(data, AggregateData{b.start.Format(time.RFC3339), b.increase / b.covered.Seconds()})
+	}
+	return data
+}
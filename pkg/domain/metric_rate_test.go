@@ -0,0 +1,123 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCounterRates(t *testing.T) {
+	day := time.Date(2026, 3, 13, 0, 0, 0, 0, time.UTC)
+	at := func(d time.Duration) time.Time { return day.Add(d) }
+	// every returns the samples of a counter growing by increase every interval, starting at the value
+	every := func(from time.Duration, interval time.Duration, n int, value, increase float64) []CounterSample {
+		samples := make([]CounterSample, n)
+		for i := range samples {
+			samples[i] = CounterSample{Time: at(from + time.Duration(i)*interval), Value: value + float64(i)*increase}
+		}
+		return samples
+	}
+	assertRates := func(t *testing.T, expected map[time.Duration]float64, data []AggregateData) {
+		t.Helper()
+		require.Len(t, data, len(expected))
+		for _, d := range data {
+			bucket, err := time.Parse(time.RFC3339, d[0].(string))
+			require.NoError(t, err)
+			rate, ok := expected[bucket.Sub(day)]
+			require.True(t, ok, "unexpected bucket %s", d[0])
+			assert.InDelta(t, rate, d[1], 1e-9, "bucket %s", d[0])
+		}
+	}
+
+	t.Run("steady counter", func(t *testing.T) {
+		samples := every(0, 15*time.Minute, 9, 0, 900)
+
+		data := CounterRates(samples, AggregateBucketHour, at(0), at(2*time.Hour))
+		assertRates(t, map[time.Duration]float64{0: 1, time.Hour: 1}, data)
+	})
+
+	t.Run("32-bit counter wrap", func(t *testing.T) {
+		// The agent reports the bytes of an interface every 30s, the counter wraps past 2^32-1 after 00:00:30
+		samples := []CounterSample{
+			{Time: at(0), Value: 4294966000},
+			{Time: at(30 * time.Second), Value: 4294967000},
+			{Time: at(60 * time.Second), Value: 200},
+			{Time: at(90 * time.Second), Value: 1200},
+			{Time: at(120 * time.Second), Value: 2200},
+		}
+
+		data := CounterRates(samples, AggregateBucketMinute, at(0), at(2*time.Minute))
+		// The wrap counts as a reset from zero: the 296 bytes counted before the wrap are lost but the rate
+		// never turns negative nor spikes to the size of the counter
+		assertRates(t, map[time.Duration]float64{0: (1000 + 200) / 60.0, time.Minute: 2000 / 60.0}, data)
+		for _, d := range data {
+			assert.GreaterOrEqual(t, d[1], 0.0)
+		}
+	})
+
+	t.Run("restart of the agent resets the counter", func(t *testing.T) {
+		samples := append(every(0, 10*time.Minute, 6, 50000, 600), every(time.Hour, 10*time.Minute, 7, 0, 1200)...)
+
+		data := CounterRates(samples, AggregateBucketHour, at(0), at(2*time.Hour))
+		// The first interval after the restart counts from zero, like the following ones
+		assertRates(t, map[time.Duration]float64{0: (5*600 + 0) / 3600.0, time.Hour: 6 * 1200 / 3600.0}, data)
+	})
+
+	t.Run("gap in the series spreads the increase over the buckets it spans", func(t *testing.T) {
+		samples := []CounterSample{
+			{Time: at(0), Value: 0},
+			{Time: at(30 * time.Minute), Value: 1800},
+			// No entry for 3 hours, the agent was disconnected but the counter kept counting
+			{Time: at(3*time.Hour + 30*time.Minute), Value: 1800 + 3*3600*2},
+			{Time: at(4 * time.Hour), Value: 1800 + 3*3600*2 + 1800},
+		}
+
+		data := CounterRates(samples, AggregateBucketHour, at(0), at(4*time.Hour))
+		assertRates(t, map[time.Duration]float64{
+			0:             (1800 + 1800*2) / 3600.0,
+			time.Hour:     2,
+			2 * time.Hour: 2,
+			3 * time.Hour: (1800*2 + 1800) / 3600.0,
+		}, data)
+	})
+
+	t.Run("buckets without samples around them have no value", func(t *testing.T) {
+		samples := every(2*time.Hour, 30*time.Minute, 3, 100, 3600)
+
+		data := CounterRates(samples, AggregateBucketHour, at(0), at(5*time.Hour))
+		assertRates(t, map[time.Duration]float64{2 * time.Hour: 2}, data)
+	})
+
+	t.Run("baseline before the start", func(t *testing.T) {
+		samples := []CounterSample{
+			{Time: at(-10 * time.Minute), Value: 0},
+			{Time: at(10 * time.Minute), Value: 2400},
+			{Time: at(20 * time.Minute), Value: 3000},
+		}
+
+		data := CounterRates(samples, AggregateBucketHour, at(0), at(time.Hour))
+		// Half of the first interval is in the range, it counts for half of its increase
+		assertRates(t, map[time.Duration]float64{0: (1200 + 600) / 1200.0}, data)
+	})
+
+	t.Run("monthly buckets", func(t *testing.T) {
+		samples := []CounterSample{
+			{Time: time.Date(2026, 1, 31, 0, 0, 0, 0, time.UTC), Value: 0},
+			{Time: time.Date(2026, 2, 2, 0, 0, 0, 0, time.UTC), Value: 2 * 86400},
+		}
+
+		data := CounterRates(samples, AggregateBucketMonth, samples[0].Time, samples[1].Time)
+		require.Len(t, data, 2)
+		assert.Equal(t, "2026-01-01T00:00:00Z", data[0][0])
+		assert.Equal(t, "2026-02-01T00:00:00Z", data[1][0])
+		assert.InDelta(t, 1, data[0][1], 1e-9)
+		assert.InDelta(t, 1, data[1][1], 1e-9)
+	})
+
+	t.Run("not enough samples", func(t *testing.T) {
+		assert.Empty(t, CounterRates(nil, AggregateBucketHour, at(0), at(time.Hour)))
+		assert.Empty(t, CounterRates(every(0, 0, 2, 10, 0), AggregateBucketHour, at(0), at(time.Hour)))
+	})
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"math"
+	"strings"
 
 	"github.com/fulcrumproject/core/pkg/properties"
 )
@@ -40,13 +42,34 @@ type MetricType struct {
 	BaseEntity
 	Name       string           `json:"name" gorm:"not null;unique"`
 	EntityType MetricEntityType `json:"entityType" gorm:"not null"`
+	Kind       MetricEntryKind  `json:"kind" gorm:"type:text;not null;default:'scalar'"`
+	Unit       string           `json:"unit" gorm:"not null;default:''"` // Unit of the values, e.g. percent or bytes
+	Min        *float64         `json:"min,omitempty"`                   // Lowest accepted value, unbounded when nil
+	Max        *float64         `json:"max,omitempty"`                   // Highest accepted value, unbounded when nil
+	// Counter types report cumulative totals that only grow until reset, their entries can be aggregated as rates
+	Counter bool `json:"counter" gorm:"not null;default:false"`
+}
+
+// MetricBounds is the range of the accepted values of a metric type, each nil bound is unbounded
+type MetricBounds struct {
+	Min *float64 `json:"min,omitempty"`
+	Max *float64 `json:"max,omitempty"`
 }
 
 // NewMetricType creates a new metric type without validation
 func NewMetricType(params CreateMetricTypeParams) *MetricType {
+	kind := params.Kind
+	if kind == "" {
+		kind = MetricEntryKindScalar
+	}
 	return &MetricType{
 		Name:       params.Name,
 		EntityType: params.EntityType,
+		Kind:       kind,
+		Unit:       strings.TrimSpace(params.Unit),
+		Min:        params.Min,
+		Max:        params.Max,
+		Counter:    params.Counter,
 	}
 }
 
@@ -63,14 +86,74 @@ func (m *MetricType) Validate() error {
 	if m.Name == "" {
 		return fmt.Errorf("metric type name cannot be empty")
 	}
+	if err := m.Kind.Validate(); err != nil {
+		return fmt.Errorf("invalid kind: %w", err)
+	}
+	for _, bound := range []*float64{m.Min, m.Max} {
+		if bound != nil && (math.IsNaN(*bound) || math.IsInf(*bound, 0)) {
+			return fmt.Errorf("metric type bounds must be finite numbers")
+		}
+	}
+	if m.Min != nil && m.Max != nil && *m.Min > *m.Max {
+		return fmt.Errorf("metric type minimum %g is greater than its maximum %g", *m.Min, *m.Max)
+	}
+	if m.Counter && m.Kind != MetricEntryKindScalar {
+		return fmt.Errorf("only scalar metric types can be counters, metric type %s is %s", m.Name, m.Kind)
+	}
+	return nil
+}
+
+// ValidateValue ensures a submitted value is within the bounds of the metric type
+func (m *MetricType) ValidateValue(value float64) error {
+	if m.Min == nil && m.Max == nil {
+		return nil
+	}
+	if math.IsNaN(value) {
+		return fmt.Errorf("value of metric type %s must be a number", m.Name)
+	}
+	if m.Min != nil && value < *m.Min {
+		return fmt.Errorf("value %g of metric type %s is below its minimum %g%s", value, m.Name, *m.Min, m.unitSuffix())
+	}
+	if m.Max != nil && value > *m.Max {
+		return fmt.Errorf("value %g of metric type %s is above its maximum %g%s", value, m.Name, *m.Max, m.unitSuffix())
+	}
+	return nil
+}
+
+// unitSuffix returns the unit to append to the values in messages, empty without unit
+func (m *MetricType) unitSuffix() string {
+	if m.Unit == "" {
+		return ""
+	}
+	return " " + m.Unit
+}
+
+// ValidateAggregate ensures the aggregation applies to the kind of the metric type
+func (m *MetricType) ValidateAggregate(aggregate AggregateType) error {
+	if aggregate.IsHistogram() && m.Kind != MetricEntryKindHistogram {
+		return fmt.Errorf("aggregate %s requires a histogram metric type, metric type %s is %s", aggregate, m.Name, m.Kind)
+	}
+	if aggregate == AggregateRate && !m.Counter {
+		return fmt.Errorf("aggregate %s requires a counter metric type, metric type %s is not a counter", aggregate, m.Name)
+	}
 	return nil
 }
 
-// Update updates the metric type
-func (m *MetricType) Update(name *string) {
+// Update updates the metric type, the bounds are replaced together when given
+func (m *MetricType) Update(name *string, unit *string, bounds *MetricBounds, counter *bool) {
 	if name != nil {
 		m.Name = *name
 	}
+	if unit != nil {
+		m.Unit = strings.TrimSpace(*unit)
+	}
+	if bounds != nil {
+		m.Min = bounds.Min
+		m.Max = bounds.Max
+	}
+	if counter != nil {
+		m.Counter = *counter
+	}
 }
 
 // MetricTypeCommander defines the interface for metric type command operations
@@ -88,11 +171,19 @@ type MetricTypeCommander interface {
 type CreateMetricTypeParams struct {
 	Name       string           `json:"name"`
 	EntityType MetricEntityType `json:"entityType"`
+	Kind       MetricEntryKind  `json:"kind"` // Defaults to scalar
+	Unit       string           `json:"unit"`
+	Min        *float64         `json:"min"`
+	Max        *float64         `json:"max"`
+	Counter    bool             `json:"counter"`
 }
 
 type UpdateMetricTypeParams struct {
-	ID   properties.UUID `json:"id"`
-	Name *string         `json:"name"`
+	ID      properties.UUID `json:"id"`
+	Name    *string         `json:"name"`
+	Unit    *string         `json:"unit"`
+	Bounds  *MetricBounds   `json:"bounds"` // Replaces both bounds, an empty one removes them
+	Counter *bool           `json:"counter"`
 }
 
 // metricTypeCommander is the concrete implementation of MetricTypeCommander
@@ -160,7 +251,7 @@ func (s *metricTypeCommander) Update(ctx context.Context,
 	beforeMetricType := *metricType
 
 	// Update and validate
-	metricType.Update(params.Name)
+	metricType.Update(params.Name, params.Unit, params.Bounds, params.Counter)
 	if err := metricType.Validate(); err != nil {
 		return nil, InvalidInputError{Err: err}
 	}
@@ -233,4 +324,7 @@ type MetricTypeQuerier interface {
 
 	// FindByName retrieves a metric type by name
 	FindByName(ctx context.Context, name string) (*MetricType, error)
+
+	// FindAll retrieves all the metric types ordered by name
+	FindAll(ctx context.Context) ([]*MetricType, error)
 }
@@ -1,8 +1,10 @@
 package domain
 
 import (
+	"math"
 	"testing"
 
+	"github.com/fulcrumproject/core/pkg/helpers"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -68,14 +70,56 @@ func TestMetricType_Validate(t *testing.T) {
 			metricType: &MetricType{
 				Name:       "cpu-usage",
 				EntityType: MetricEntityTypeResource,
+				Kind:       MetricEntryKindScalar,
 			},
 			wantErr: false,
 		},
+		{
+			name: "Valid histogram metric type",
+			metricType: &MetricType{
+				Name:       "request-latency",
+				EntityType: MetricEntityTypeService,
+				Kind:       MetricEntryKindHistogram,
+			},
+			wantErr: false,
+		},
+		{
+			name: "Valid counter metric type",
+			metricType: &MetricType{
+				Name:       "bytes-transferred",
+				EntityType: MetricEntityTypeResource,
+				Kind:       MetricEntryKindScalar,
+				Counter:    true,
+			},
+			wantErr: false,
+		},
+		{
+			name: "Histogram counter",
+			metricType: &MetricType{
+				Name:       "request-latency",
+				EntityType: MetricEntityTypeService,
+				Kind:       MetricEntryKindHistogram,
+				Counter:    true,
+			},
+			wantErr:    true,
+			errMessage: "only scalar metric types can be counters",
+		},
+		{
+			name: "Invalid kind",
+			metricType: &MetricType{
+				Name:       "cpu-usage",
+				EntityType: MetricEntityTypeResource,
+				Kind:       "summary",
+			},
+			wantErr:    true,
+			errMessage: "invalid kind",
+		},
 		{
 			name: "Empty name",
 			metricType: &MetricType{
 				Name:       "",
 				EntityType: MetricEntityTypeResource,
+				Kind:       MetricEntryKindScalar,
 			},
 			wantErr:    true,
 			errMessage: "metric type name cannot be empty",
@@ -89,6 +133,41 @@ func TestMetricType_Validate(t *testing.T) {
 			wantErr:    true,
 			errMessage: "invalid entity type",
 		},
+		{
+			name: "Valid bounds",
+			metricType: &MetricType{
+				Name:       "cpu-usage",
+				EntityType: MetricEntityTypeResource,
+				Kind:       MetricEntryKindScalar,
+				Unit:       "percent",
+				Min:        helpers.FloatPtr(0),
+				Max:        helpers.FloatPtr(100),
+			},
+			wantErr: false,
+		},
+		{
+			name: "Minimum above maximum",
+			metricType: &MetricType{
+				Name:       "cpu-usage",
+				EntityType: MetricEntityTypeResource,
+				Kind:       MetricEntryKindScalar,
+				Min:        helpers.FloatPtr(100),
+				Max:        helpers.FloatPtr(0),
+			},
+			wantErr:    true,
+			errMessage: "metric type minimum 100 is greater than its maximum 0",
+		},
+		{
+			name: "Infinite bound",
+			metricType: &MetricType{
+				Name:       "cpu-usage",
+				EntityType: MetricEntityTypeResource,
+				Kind:       MetricEntryKindScalar,
+				Max:        helpers.FloatPtr(math.Inf(1)),
+			},
+			wantErr:    true,
+			errMessage: "bounds must be finite numbers",
+		},
 	}
 
 	for _, tt := range tests {
@@ -105,3 +184,52 @@ func TestMetricType_Validate(t *testing.T) {
 		})
 	}
 }
+
+func TestMetricType_ValidateValue(t *testing.T) {
+	unbounded := &MetricType{Name: "temperature"}
+	cpu := &MetricType{Name: "cpu-usage", Unit: "percent", Min: helpers.FloatPtr(0), Max: helpers.FloatPtr(100)}
+	positive := &MetricType{Name: "memory", Min: helpers.FloatPtr(0)}
+
+	assert.NoError(t, unbounded.ValidateValue(-273))
+	assert.NoError(t, cpu.ValidateValue(0))
+	assert.NoError(t, cpu.ValidateValue(100))
+	assert.NoError(t, positive.ValidateValue(1e12))
+	assert.EqualError(t, cpu.ValidateValue(-5), "value -5 of metric type cpu-usage is below its minimum 0 percent")
+	assert.EqualError(t, cpu.ValidateValue(100.5), "value 100.5 of metric type cpu-usage is above its maximum 100 percent")
+	assert.EqualError(t, positive.ValidateValue(-1), "value -1 of metric type memory is below its minimum 0")
+	assert.Error(t, positive.ValidateValue(math.NaN()))
+}
+
+func TestMetricType_Update(t *testing.T) {
+	mt := &MetricType{Name: "cpu", Min: helpers.FloatPtr(0), Max: helpers.FloatPtr(100)}
+
+	unit := " percent "
+	mt.Update(nil, &unit, nil, nil)
+	assert.Equal(t, "percent", mt.Unit)
+	assert.Equal(t, 100.0, *mt.Max)
+
+	mt.Update(nil, nil, &MetricBounds{Min: helpers.FloatPtr(0)}, nil)
+	assert.Equal(t, 0.0, *mt.Min)
+	assert.Nil(t, mt.Max)
+
+	mt.Update(nil, nil, &MetricBounds{}, nil)
+	assert.Nil(t, mt.Min)
+	assert.Nil(t, mt.Max)
+}
+
+func TestMetricType_ValidateAggregate(t *testing.T) {
+	scalar := &MetricType{Name: "cpu-usage", Kind: MetricEntryKindScalar}
+	histogram := &MetricType{Name: "request-latency", Kind: MetricEntryKindHistogram}
+
+	assert.NoError(t, scalar.ValidateAggregate(AggregateAvg))
+	assert.NoError(t, histogram.ValidateAggregate(AggregateP95))
+	assert.NoError(t, histogram.ValidateAggregate(AggregateHistogram))
+
+	err := scalar.ValidateAggregate(AggregateP99)
+	assert.EqualError(t, err, "aggregate p99 requires a histogram metric type, metric type cpu-usage is scalar")
+
+	counter := &MetricType{Name: "bytes-transferred", Kind: MetricEntryKindScalar, Counter: true}
+	assert.NoError(t, counter.ValidateAggregate(AggregateRate))
+	assert.NoError(t, counter.ValidateAggregate(AggregateMax))
+	assert.EqualError(t, scalar.ValidateAggregate(AggregateRate), "aggregate rate requires a counter metric type, metric type cpu-usage is not a counter")
+}
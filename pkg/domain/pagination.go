@@ -1,12 +1,42 @@
 package domain
 
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/fulcrumproject/core/pkg/properties"
+)
+
 type PageReq struct {
-	Filters  map[string][]string // Filters to be applied
-	Sort     bool                // Should sort
-	SortBy   string              // Field to sort by
-	SortAsc  bool                // Sort dir
-	Page     int                 // Current page number
-	PageSize int                 // Number of items per page
+	Filters    map[string][]string // Filters to be applied
+	Sort       bool                // Should sort
+	SortBy     string              // Field to sort by
+	SortAsc    bool                // Sort dir
+	SortFields []SortField         // Fields to sort by in order of precedence, takes over SortBy and SortAsc when set
+	Page       int                 // Current page number
+	PageSize   int                 // Number of items per page
+	Cursor     *PageCursor         // Keyset position, enables cursor pagination when not nil
+	Include    []string            // Relations to load with the items, none when empty
+	SkipCount  bool                // Skips counting the items, the result has no totals
+}
+
+// SortField is one field of a multi-field sort
+type SortField struct {
+	Field string
+	Asc   bool
+}
+
+// SortOrder returns the fields to sort by in order of precedence, empty when no sort is requested
+func (r *PageReq) SortOrder() []SortField {
+	if !r.Sort {
+		return nil
+	}
+	if len(r.SortFields) > 0 {
+		return r.SortFields
+	}
+	return []SortField{{Field: r.SortBy, Asc: r.SortAsc}}
 }
 
 type PageRes[T any] struct {
@@ -16,6 +46,49 @@ type PageRes[T any] struct {
 	CurrentPage int
 	HasNext     bool
 	HasPrev     bool
+	NextCursor  string
+	Uncounted   bool // The items were not counted, TotalItems and TotalPages are unknown
+}
+
+// PageCursor is the keyset position of cursor pagination, the zero value starts from the first item
+type PageCursor struct {
+	CreatedAt time.Time
+	ID        properties.UUID
+}
+
+// IsStart reports whether the cursor points to the first page
+func (c PageCursor) IsStart() bool {
+	return c.CreatedAt.IsZero() && c.ID == properties.UUID{}
+}
+
+// Encode returns the opaque string representation of the cursor
+func (c PageCursor) Encode() string {
+	raw := c.CreatedAt.UTC().Format(time.RFC3339Nano) + "|" + c.ID.String()
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// ParsePageCursor decodes a cursor returned as next cursor, an empty string starts from the first item
+func ParsePageCursor(value string) (*PageCursor, error) {
+	if value == "" {
+		return &PageCursor{}, nil
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+	createdAtStr, idStr, ok := strings.Cut(string(raw), "|")
+	if !ok {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+	createdAt, err := time.Parse(time.RFC3339Nano, createdAtStr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+	id, err := properties.ParseUUID(idStr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+	return &PageCursor{CreatedAt: createdAt, ID: id}, nil
 }
 
 // NewPaginatedResult creates a new PaginatedResult with calculated pagination fields
@@ -34,3 +107,29 @@ func NewPaginatedResult[T any](items []T, totalItems int64, page *PageReq) *Page
 		HasPrev:     page.Page > 1,
 	}
 }
+
+// NewUncountedPaginatedResult creates a new PaginatedResult without totals, hasNext is found
+// by the caller, usually by fetching one item more than the page size
+func NewUncountedPaginatedResult[T any](items []T, page *PageReq, hasNext bool) *PageRes[T] {
+	return &PageRes[T]{
+		Items:       items,
+		CurrentPage: page.Page,
+		HasNext:     hasNext,
+		HasPrev:     page.Page > 1,
+		Uncounted:   true,
+	}
+}
+
+// NewCursorPaginatedResult creates a new PaginatedResult for cursor pagination
+// nextCursor is empty when there are no more items, totalItems is ignored when the count is skipped
+func NewCursorPaginatedResult[T any](items []T, totalItems int64, page *PageReq, nextCursor string) *PageRes[T] {
+	result := NewPaginatedResult(items, totalItems, page)
+	if page.SkipCount {
+		result = NewUncountedPaginatedResult(items, page, false)
+	}
+	result.CurrentPage = 0
+	result.HasNext = nextCursor != ""
+	result.HasPrev = !page.Cursor.IsStart()
+	result.NextCursor = nextCursor
+	return result
+}
@@ -2,8 +2,11 @@ package domain
 
 import (
 	"testing"
+	"time"
 
+	"github.com/fulcrumproject/core/pkg/properties"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 type testItem struct {
@@ -149,3 +152,65 @@ func TestNewPaginatedResult(t *testing.T) {
 		})
 	}
 }
+
+func TestPageReq_SortOrder(t *testing.T) {
+	assert.Nil(t, (&PageReq{SortBy: "name"}).SortOrder())
+	assert.Equal(t, []SortField{{Field: "name", Asc: false}}, (&PageReq{Sort: true, SortBy: "name"}).SortOrder())
+
+	fields := []SortField{{Field: "name", Asc: true}, {Field: "createdAt", Asc: false}}
+	assert.Equal(t, fields, (&PageReq{Sort: true, SortBy: "name", SortAsc: true, SortFields: fields}).SortOrder())
+}
+
+func TestPageCursor(t *testing.T) {
+	t.Run("round trip", func(t *testing.T) {
+		cursor := PageCursor{CreatedAt: time.Date(2024, 5, 6, 7, 8, 9, 123456000, time.UTC), ID: properties.NewUUID()}
+		parsed, err := ParsePageCursor(cursor.Encode())
+		require.NoError(t, err)
+		assert.Equal(t, cursor.ID, parsed.ID)
+		assert.True(t, cursor.CreatedAt.Equal(parsed.CreatedAt))
+		assert.False(t, parsed.IsStart())
+	})
+
+	t.Run("empty value starts from the first item", func(t *testing.T) {
+		parsed, err := ParsePageCursor("")
+		require.NoError(t, err)
+		assert.True(t, parsed.IsStart())
+	})
+
+	t.Run("invalid values", func(t *testing.T) {
+		for _, value := range []string{"!!!", "bm8tc2VwYXJhdG9y", "eHwxMjM"} {
+			_, err := ParsePageCursor(value)
+			assert.Error(t, err, value)
+		}
+	})
+}
+
+func TestNewCursorPaginatedResult(t *testing.T) {
+	items := []testItem{{ID: 1, Name: "Item 1"}}
+
+	first := NewCursorPaginatedResult(items, 3, &PageReq{PageSize: 1, Cursor: &PageCursor{}}, "next")
+	assert.True(t, first.HasNext)
+	assert.False(t, first.HasPrev)
+	assert.Equal(t, "next", first.NextCursor)
+
+	last := NewCursorPaginatedResult(items, 3, &PageReq{PageSize: 1, Cursor: &PageCursor{ID: properties.NewUUID()}}, "")
+	assert.False(t, last.HasNext)
+	assert.True(t, last.HasPrev)
+	assert.Empty(t, last.NextCursor)
+}
+
+func TestNewUncountedPaginatedResult(t *testing.T) {
+	items := []testItem{{ID: 1, Name: "Item 1"}}
+
+	result := NewUncountedPaginatedResult(items, &PageReq{Page: 1, PageSize: 1}, true)
+	assert.True(t, result.Uncounted)
+	assert.True(t, result.HasNext)
+	assert.False(t, result.HasPrev)
+	assert.Zero(t, result.TotalItems)
+	assert.Zero(t, result.TotalPages)
+
+	cursor := NewCursorPaginatedResult(items, 0, &PageReq{PageSize: 1, Cursor: &PageCursor{}, SkipCount: true}, "next")
+	assert.True(t, cursor.Uncounted)
+	assert.True(t, cursor.HasNext)
+	assert.Equal(t, "next", cursor.NextCursor)
+}
@@ -4,7 +4,9 @@ import (
 	"context"
 	"fmt"
 
+	"github.com/fulcrumproject/core/pkg/auth"
 	"github.com/fulcrumproject/core/pkg/properties"
+	"github.com/lib/pq"
 )
 
 // ParticipantStatus represents the possible statuss of a Participant
@@ -45,16 +47,28 @@ type Participant struct {
 	Name   string            `json:"name" gorm:"not null"`
 	Status ParticipantStatus `json:"status" gorm:"not null"`
 
+	// MaxAgents caps the agents a provider can register, nil means no limit
+	// Lowering it below the current count only prevents new registrations
+	MaxAgents *int `json:"maxAgents,omitempty"`
+
+	// Contact and notification preferences, see participant_notification.go
+	ContactEmail           *string        `json:"contactEmail,omitempty"`
+	NotificationWebhookURL *string        `json:"notificationWebhookUrl,omitempty"`
+	NotificationEventTypes pq.StringArray `json:"notificationEventTypes,omitempty" gorm:"type:text[]"` // Opt-in, only these types are notified
+
 	// Relationships
 	Agents []Agent `json:"agents,omitempty" gorm:"foreignKey:ProviderID"` // Agent struct will be updated later
 }
 
 // NewParticipant creates a new Participant without validation
 func NewParticipant(params CreateParticipantParams) *Participant {
-	return &Participant{
-		Name:   params.Name,
-		Status: params.Status,
+	p := &Participant{
+		Name:      params.Name,
+		Status:    params.Status,
+		MaxAgents: params.MaxAgents,
 	}
+	p.SetNotifications(params.ContactEmail, params.NotificationWebhookURL, params.NotificationEventTypes)
+	return p
 }
 
 // TableName returns the table name for the participant
@@ -70,7 +84,10 @@ func (p *Participant) Validate() error {
 	if err := p.Status.Validate(); err != nil {
 		return err
 	}
-	return nil
+	if p.MaxAgents != nil && *p.MaxAgents < 0 {
+		return fmt.Errorf("max agents cannot be negative")
+	}
+	return p.validateNotifications()
 }
 
 // Update updates the participant fields if the pointers are non-nil
@@ -81,6 +98,18 @@ func (p *Participant) Update(params UpdateParticipantParams) {
 	if params.Status != nil {
 		p.Status = *params.Status
 	}
+	if params.MaxAgents != nil {
+		p.MaxAgents = params.MaxAgents
+	}
+	p.SetNotifications(params.ContactEmail, params.NotificationWebhookURL, params.NotificationEventTypes)
+}
+
+// CheckAgentQuota returns an AgentQuotaExceededError when the provider already has its maximum of agents
+func (p *Participant) CheckAgentQuota(agents int64) error {
+	if p.MaxAgents != nil && agents >= int64(*p.MaxAgents) {
+		return NewAgentQuotaExceededError(p.ID, *p.MaxAgents)
+	}
+	return nil
 }
 
 // ParticipantCommander defines the interface for participant command operations
@@ -96,14 +125,22 @@ type ParticipantCommander interface {
 }
 
 type CreateParticipantParams struct {
-	Name   string            `json:"name"`
-	Status ParticipantStatus `json:"status"`
+	Name                   string            `json:"name"`
+	Status                 ParticipantStatus `json:"status"`
+	MaxAgents              *int              `json:"maxAgents,omitempty"`
+	ContactEmail           *string           `json:"contactEmail,omitempty"`
+	NotificationWebhookURL *string           `json:"notificationWebhookUrl,omitempty"`
+	NotificationEventTypes *[]EventType      `json:"notificationEventTypes,omitempty"`
 }
 
 type UpdateParticipantParams struct {
-	ID     properties.UUID    `json:"id"`
-	Name   *string            `json:"name"`
-	Status *ParticipantStatus `json:"status"`
+	ID                     properties.UUID    `json:"id"`
+	Name                   *string            `json:"name"`
+	Status                 *ParticipantStatus `json:"status"`
+	MaxAgents              *int               `json:"maxAgents,omitempty"`
+	ContactEmail           *string            `json:"contactEmail,omitempty"`           // Empty removes the contact email
+	NotificationWebhookURL *string            `json:"notificationWebhookUrl,omitempty"` // Empty removes the webhook
+	NotificationEventTypes *[]EventType       `json:"notificationEventTypes,omitempty"` // Empty disables the notifications
 }
 
 // participantCommander is the concrete implementation of ParticipantCommander
@@ -133,6 +170,11 @@ func (c *participantCommander) Create(
 		if err := store.ParticipantRepo().Create(ctx, participant); err != nil {
 			return err
 		}
+		if participant.NotifiesWebhook() {
+			if err := syncParticipantNotifications(ctx, store, participant); err != nil {
+				return err
+			}
+		}
 		eventEntry, err := NewEvent(EventTypeParticipantCreated, WithInitiatorCtx(ctx), WithParticipant(participant))
 		if err != nil {
 			return err
@@ -158,6 +200,13 @@ func (c *participantCommander) Update(
 	}
 	beforeParticipant := *participant
 
+	// Participants can update themselves but not their own quota
+	if params.MaxAgents != nil {
+		if id := auth.GetIdentity(ctx); id == nil || !id.HasRole(auth.RoleAdmin) {
+			return nil, NewUnauthorizedErrorf("only admins can change the agent quota")
+		}
+	}
+
 	participant.Update(params)
 	if err := participant.Validate(); err != nil {
 		return nil, InvalidInputError{Err: err}
@@ -167,6 +216,11 @@ func (c *participantCommander) Update(
 		if err := store.ParticipantRepo().Save(ctx, participant); err != nil {
 			return err
 		}
+		if participant.notificationsChanged(&beforeParticipant) {
+			if err := syncParticipantNotifications(ctx, store, participant); err != nil {
+				return err
+			}
+		}
 		eventEntry, err := NewEvent(EventTypeParticipantUpdated, WithInitiatorCtx(ctx), WithDiff(&beforeParticipant, participant), WithParticipant(participant))
 		if err != nil {
 			return err
@@ -208,11 +262,16 @@ func (c *participantCommander) Delete(ctx context.Context, id properties.UUID) e
 		}
 
 		// Delete associated Tokens
-		// TokenRepository.DeleteByParticipantID() will be added in a later step as per the plan
-		if err := store.TokenRepo().DeleteByParticipantID(ctx, id); err != nil {
+		if _, err := store.TokenRepo().DeleteByParticipantID(ctx, id); err != nil {
 			return fmt.Errorf("failed to delete tokens for participant %s: %w", id, err)
 		}
 
+		if participant.NotifiesWebhook() {
+			if err := deleteParticipantNotifications(ctx, store, id); err != nil {
+				return fmt.Errorf("failed to delete notification subscription for participant %s: %w", id, err)
+			}
+		}
+
 		if err := store.ParticipantRepo().Delete(ctx, id); err != nil {
 			return err
 		}
@@ -225,6 +284,9 @@ func (c *participantCommander) Delete(ctx context.Context, id properties.UUID) e
 type ParticipantRepository interface {
 	ParticipantQuerier
 	BaseEntityRepository[Participant]
+
+	// GetForUpdate retrieves a participant and locks it until the end of the transaction
+	GetForUpdate(ctx context.Context, id properties.UUID) (*Participant, error)
 }
 
 // ParticipantQuerier defines the interface for participant query operations
@@ -0,0 +1,137 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/mail"
+	"slices"
+
+	"github.com/fulcrumproject/core/pkg/properties"
+)
+
+// EventTypeServiceFailed is emitted when a job of a service fails, whether or not its lifecycle moved the service
+const EventTypeServiceFailed EventType = "service.failed"
+
+// NotifiableEventTypes lists the event types a participant can be notified of
+var NotifiableEventTypes = []EventType{
+	EventTypeServiceFailed,
+	EventTypeJobDeadLettered,
+	EventTypePoolExhausted,
+	EventTypePoolLowWatermark,
+}
+
+// ParticipantNotificationSubscriberID returns the subscriber ID of the event subscription
+// delivering the notifications of a participant
+func ParticipantNotificationSubscriberID(participantID properties.UUID) string {
+	return fmt.Sprintf("participant-notifications-%s", participantID)
+}
+
+// SetNotifications sets the contact and notification preferences that are non-nil, empty values remove them
+func (p *Participant) SetNotifications(contactEmail, webhookURL *string, eventTypes *[]EventType) {
+	if contactEmail != nil {
+		p.ContactEmail = nil
+		if *contactEmail != "" {
+			p.ContactEmail = contactEmail
+		}
+	}
+	if webhookURL != nil {
+		p.NotificationWebhookURL = nil
+		if *webhookURL != "" {
+			p.NotificationWebhookURL = webhookURL
+		}
+	}
+	if eventTypes != nil {
+		p.NotificationEventTypes = nil
+		for _, t := range *eventTypes {
+			if !slices.Contains(p.NotificationEventTypes, string(t)) {
+				p.NotificationEventTypes = append(p.NotificationEventTypes, string(t))
+			}
+		}
+	}
+}
+
+// NotifiesWebhook reports whether the participant opted in to webhook notifications
+func (p *Participant) NotifiesWebhook() bool {
+	return p.NotificationWebhookURL != nil && len(p.NotificationEventTypes) > 0
+}
+
+// notificationsChanged reports whether the notification routing differs from the one of the previous participant
+func (p *Participant) notificationsChanged(before *Participant) bool {
+	sameWebhook := (p.NotificationWebhookURL == nil) == (before.NotificationWebhookURL == nil) &&
+		(p.NotificationWebhookURL == nil || *p.NotificationWebhookURL == *before.NotificationWebhookURL)
+	return !sameWebhook || !slices.Equal(p.NotificationEventTypes, before.NotificationEventTypes)
+}
+
+// validateNotifications ensures the contact fields are well formed and the event types can be notified
+func (p *Participant) validateNotifications() error {
+	if p.ContactEmail != nil {
+		address, err := mail.ParseAddress(*p.ContactEmail)
+		if err != nil || address.Address != *p.ContactEmail {
+			return fmt.Errorf("invalid contact email %q", *p.ContactEmail)
+		}
+	}
+	if p.NotificationWebhookURL != nil {
+		if err := validateCallbackURL(*p.NotificationWebhookURL); err != nil {
+			return fmt.Errorf("invalid notification webhook: %w", err)
+		}
+	}
+	for _, t := range p.NotificationEventTypes {
+		if !slices.Contains(NotifiableEventTypes, EventType(t)) {
+			return fmt.Errorf("event type %s cannot be notified, notifiable types are %v", t, NotifiableEventTypes)
+		}
+	}
+	// No mailer is configured, the webhook is the only delivery channel of the notifications
+	if len(p.NotificationEventTypes) > 0 && p.NotificationWebhookURL == nil {
+		return fmt.Errorf("notification event types require a notification webhook")
+	}
+	return nil
+}
+
+// syncParticipantNotifications routes the notifications of a participant through a webhook event subscription
+// The subscription delivers the opted-in event types where the participant is the provider or the consumer, it
+// is created starting after the latest event so past events are not notified, and removed once the participant
+// opts out of every type or removes its webhook.
+func syncParticipantNotifications(ctx context.Context, store Store, participant *Participant) error {
+	if !participant.NotifiesWebhook() {
+		return deleteParticipantNotifications(ctx, store, participant.ID)
+	}
+
+	subscriberID := ParticipantNotificationSubscriberID(participant.ID)
+	subscription, err := store.EventSubscriptionRepo().FindBySubscriberID(ctx, subscriberID)
+	create := false
+	if err != nil {
+		var notFoundErr NotFoundError
+		if !errors.As(err, &notFoundErr) {
+			return err
+		}
+		subscription = NewEventSubscription(subscriberID)
+		if subscription.LastEventSequenceProcessed, err = store.EventRepo().LastSequenceNumber(ctx); err != nil {
+			return err
+		}
+		create = true
+	}
+
+	callbackURL := *participant.NotificationWebhookURL
+	subscription.CallbackURL = &callbackURL
+	subscription.Filter = EventFilter{Types: participant.NotificationEventTypes, ParticipantID: &participant.ID}
+	subscription.EnableDelivery()
+	if err := subscription.Validate(); err != nil {
+		return InvalidInputError{Err: err}
+	}
+
+	if create {
+		return store.EventSubscriptionRepo().Create(ctx, subscription)
+	}
+	return store.EventSubscriptionRepo().Save(ctx, subscription)
+}
+
+// deleteParticipantNotifications removes the notification subscription of a participant if it exists
+func deleteParticipantNotifications(ctx context.Context, store Store, participantID properties.UUID) error {
+	err := store.EventSubscriptionRepo().DeleteBySubscriberID(ctx, ParticipantNotificationSubscriberID(participantID))
+	var notFoundErr NotFoundError
+	if err != nil && !errors.As(err, &notFoundErr) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,195 @@
+package domain
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/fulcrumproject/core/pkg/auth"
+	"github.com/fulcrumproject/core/pkg/helpers"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParticipant_ValidateNotifications(t *testing.T) {
+	tests := []struct {
+		name         string
+		email        *string
+		webhook      *string
+		types        []EventType
+		errorMessage string
+	}{
+		{name: "no notifications"},
+		{name: "contact email only", email: helpers.StringPtr("ops@example.com")},
+		{name: "webhook notifications", webhook: helpers.StringPtr("https://example.com/hook"), types: []EventType{EventTypeServiceFailed, EventTypePoolExhausted}},
+		{name: "invalid email", email: helpers.StringPtr("not-an-email"), errorMessage: "invalid contact email"},
+		{name: "email with a display name", email: helpers.StringPtr("Ops <ops@example.com>"), errorMessage: "invalid contact email"},
+		{name: "invalid webhook", webhook: helpers.StringPtr("ftp://example.com"), errorMessage: "invalid notification webhook"},
+		{name: "event type that cannot be notified", webhook: helpers.StringPtr("https://example.com/hook"), types: []EventType{EventTypeServiceCreated}, errorMessage: "cannot be notified"},
+		{name: "event types without webhook", email: helpers.StringPtr("ops@example.com"), types: []EventType{EventTypeServiceFailed}, errorMessage: "require a notification webhook"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewParticipant(CreateParticipantParams{
+				Name:                   "participant",
+				Status:                 ParticipantEnabled,
+				ContactEmail:           tt.email,
+				NotificationWebhookURL: tt.webhook,
+				NotificationEventTypes: &tt.types,
+			})
+			err := p.Validate()
+			if tt.errorMessage != "" {
+				assert.ErrorContains(t, err, tt.errorMessage)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestParticipant_SetNotifications(t *testing.T) {
+	p := &Participant{}
+	p.SetNotifications(helpers.StringPtr("ops@example.com"), helpers.StringPtr("https://example.com/hook"),
+		&[]EventType{EventTypeServiceFailed, EventTypeServiceFailed, EventTypePoolExhausted})
+	assert.Equal(t, "ops@example.com", *p.ContactEmail)
+	assert.Equal(t, []string{string(EventTypeServiceFailed), string(EventTypePoolExhausted)}, []string(p.NotificationEventTypes))
+	assert.True(t, p.NotifiesWebhook())
+
+	// Nil values keep the current preferences
+	p.SetNotifications(nil, nil, nil)
+	assert.True(t, p.NotifiesWebhook())
+
+	// Empty values remove them
+	p.SetNotifications(helpers.StringPtr(""), nil, &[]EventType{})
+	assert.Nil(t, p.ContactEmail)
+	assert.NotNil(t, p.NotificationWebhookURL)
+	assert.False(t, p.NotifiesWebhook())
+}
+
+func TestParticipantCommander_Notifications(t *testing.T) {
+	ctx := auth.WithIdentity(context.Background(), &auth.Identity{ID: uuid.New(), Role: auth.RoleAdmin})
+	webhook := "https://example.com/hook"
+
+	setup := func(t *testing.T, participant *Participant) (*MockStore, *MockEventSubscriptionRepository, *MockEventRepository) {
+		ms := setupMockStore(t)
+		participantRepo := NewMockParticipantRepository(t)
+		subscriptionRepo := NewMockEventSubscriptionRepository(t)
+		eventRepo := NewMockEventRepository(t)
+		ms.EXPECT().ParticipantRepo().Return(participantRepo)
+		ms.EXPECT().EventSubscriptionRepo().Return(subscriptionRepo)
+		ms.EXPECT().EventRepo().Return(eventRepo)
+		participantRepo.EXPECT().Get(mock.Anything, participant.ID).Return(participant, nil)
+		participantRepo.EXPECT().Save(mock.Anything, participant).Return(nil).Maybe()
+		eventRepo.EXPECT().Create(mock.Anything, mock.Anything).Return(nil)
+		return ms, subscriptionRepo, eventRepo
+	}
+
+	t.Run("opting in creates the webhook subscription after the latest event", func(t *testing.T) {
+		participant := &Participant{BaseEntity: BaseEntity{ID: uuid.New()}, Name: "consumer", Status: ParticipantEnabled}
+		ms, subscriptionRepo, eventRepo := setup(t, participant)
+		subscriberID := ParticipantNotificationSubscriberID(participant.ID)
+		subscriptionRepo.EXPECT().FindBySubscriberID(mock.Anything, subscriberID).Return(nil, NewNotFoundErrorf("not found"))
+		eventRepo.EXPECT().LastSequenceNumber(mock.Anything).Return(42, nil)
+		subscriptionRepo.EXPECT().Create(mock.Anything, mock.MatchedBy(func(s *EventSubscription) bool {
+			return s.SubscriberID == subscriberID && s.LastEventSequenceProcessed == 42 && *s.CallbackURL == webhook &&
+				*s.Filter.ParticipantID == participant.ID && len(s.Filter.Types) == 1 && s.Filter.Types[0] == string(EventTypeServiceFailed)
+		})).Return(nil)
+
+		_, err := NewParticipantCommander(ms).Update(ctx, UpdateParticipantParams{
+			ID:                     participant.ID,
+			NotificationWebhookURL: &webhook,
+			NotificationEventTypes: &[]EventType{EventTypeServiceFailed},
+		})
+		require.NoError(t, err)
+	})
+
+	t.Run("changing the types updates the subscription and resumes delivery", func(t *testing.T) {
+		participant := &Participant{
+			BaseEntity:             BaseEntity{ID: uuid.New()},
+			Name:                   "consumer",
+			Status:                 ParticipantEnabled,
+			NotificationWebhookURL: &webhook,
+			NotificationEventTypes: []string{string(EventTypeServiceFailed)},
+		}
+		ms, subscriptionRepo, _ := setup(t, participant)
+		deadLetteredAt := time.Now()
+		subscription := NewEventSubscription(ParticipantNotificationSubscriberID(participant.ID))
+		subscription.CallbackURL = &webhook
+		subscription.DeadLetteredAt = &deadLetteredAt
+		subscriptionRepo.EXPECT().FindBySubscriberID(mock.Anything, subscription.SubscriberID).Return(subscription, nil)
+		subscriptionRepo.EXPECT().Save(mock.Anything, subscription).Return(nil)
+
+		_, err := NewParticipantCommander(ms).Update(ctx, UpdateParticipantParams{
+			ID:                     participant.ID,
+			NotificationEventTypes: &[]EventType{EventTypeServiceFailed, EventTypePoolExhausted},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []string{string(EventTypeServiceFailed), string(EventTypePoolExhausted)}, []string(subscription.Filter.Types))
+		assert.False(t, subscription.IsDeadLettered())
+	})
+
+	t.Run("opting out of every type removes the subscription", func(t *testing.T) {
+		participant := &Participant{
+			BaseEntity:             BaseEntity{ID: uuid.New()},
+			Name:                   "consumer",
+			Status:                 ParticipantEnabled,
+			NotificationWebhookURL: &webhook,
+			NotificationEventTypes: []string{string(EventTypeServiceFailed)},
+		}
+		ms, subscriptionRepo, _ := setup(t, participant)
+		subscriptionRepo.EXPECT().DeleteBySubscriberID(mock.Anything, ParticipantNotificationSubscriberID(participant.ID)).Return(nil)
+
+		_, err := NewParticipantCommander(ms).Update(ctx, UpdateParticipantParams{ID: participant.ID, NotificationEventTypes: &[]EventType{}})
+		require.NoError(t, err)
+	})
+
+	t.Run("invalid email is rejected", func(t *testing.T) {
+		participant := &Participant{BaseEntity: BaseEntity{ID: uuid.New()}, Name: "consumer", Status: ParticipantEnabled}
+		ms := setupMockStore(t)
+		participantRepo := NewMockParticipantRepository(t)
+		ms.EXPECT().ParticipantRepo().Return(participantRepo)
+		participantRepo.EXPECT().Get(mock.Anything, participant.ID).Return(participant, nil)
+
+		_, err := NewParticipantCommander(ms).Update(ctx, UpdateParticipantParams{ID: participant.ID, ContactEmail: helpers.StringPtr("ops@")})
+		assert.ErrorAs(t, err, &InvalidInputError{})
+	})
+}
+
+func TestJobCommander_FailRecordsServiceFailed(t *testing.T) {
+	ctx := auth.WithIdentity(context.Background(), &auth.Identity{ID: uuid.New(), Role: auth.RoleAgent})
+	serviceType := &ServiceType{
+		BaseEntity: BaseEntity{ID: uuid.New()},
+		LifecycleSchema: LifecycleSchema{
+			States:  []LifecycleState{{Name: "Started"}},
+			Actions: []LifecycleAction{{Name: "update", Transitions: []LifecycleTransition{{From: "Started", To: "Started"}}}},
+		},
+	}
+	svc := &Service{BaseEntity: BaseEntity{ID: uuid.New()}, ServiceTypeID: serviceType.ID, Status: "Started", ProviderID: uuid.New(), ConsumerID: uuid.New()}
+	job := &Job{BaseEntity: BaseEntity{ID: uuid.New()}, Status: JobProcessing, Action: "update", ServiceID: svc.ID}
+
+	ms := setupMockStore(t)
+	jobRepo := NewMockJobRepository(t)
+	serviceRepo := NewMockServiceRepository(t)
+	serviceTypeRepo := NewMockServiceTypeRepository(t)
+	eventRepo := NewMockEventRepository(t)
+	ms.EXPECT().JobRepo().Return(jobRepo)
+	ms.EXPECT().ServiceRepo().Return(serviceRepo)
+	ms.EXPECT().ServiceTypeRepo().Return(serviceTypeRepo)
+	ms.EXPECT().EventRepo().Return(eventRepo)
+	jobRepo.EXPECT().Get(mock.Anything, job.ID).Return(job, nil)
+	jobRepo.EXPECT().Save(mock.Anything, job).Return(nil)
+	serviceRepo.EXPECT().Get(mock.Anything, svc.ID).Return(svc, nil)
+	serviceTypeRepo.EXPECT().Get(mock.Anything, serviceType.ID).Return(serviceType, nil)
+	eventRepo.EXPECT().Create(mock.Anything, mock.MatchedBy(func(e *Event) bool {
+		return e.Type == EventTypeServiceTransitioned
+	})).Return(nil).Once()
+	eventRepo.EXPECT().Create(mock.Anything, mock.MatchedBy(func(e *Event) bool {
+		return e.Type == EventTypeServiceFailed && *e.EntityID == svc.ID && *e.ConsumerID == svc.ConsumerID &&
+			e.Payload["jobId"] == job.ID && e.Payload["errorMessage"] == "boom"
+	})).Return(nil).Once()
+
+	err := NewJobCommander(ms, nil, 0, time.Minute, JobRetryPolicy{}).Fail(ctx, FailJobParams{JobID: job.ID, ErrorMessage: "boom"})
+	require.NoError(t, err)
+}
@@ -1,10 +1,15 @@
 package domain
 
 import (
+	"context"
+	"errors"
 	"testing"
 
+	"github.com/fulcrumproject/core/pkg/auth"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 func TestParticipantStatus_Validate(t *testing.T) {
@@ -123,6 +128,16 @@ func TestParticipant_Validate(t *testing.T) {
 			wantErr:     true,
 			errContains: "invalid participant status",
 		},
+		{
+			name: "Negative max agents",
+			participant: &Participant{
+				Name:      "test-participant",
+				Status:    ParticipantEnabled,
+				MaxAgents: func() *int { v := -1; return &v }(),
+			},
+			wantErr:     true,
+			errContains: "max agents cannot be negative",
+		},
 		{
 			name: "Valid with empty country code",
 			participant: &Participant{
@@ -147,3 +162,49 @@ func TestParticipant_Validate(t *testing.T) {
 		})
 	}
 }
+
+func TestParticipant_CheckAgentQuota(t *testing.T) {
+	limit := 2
+	p := &Participant{BaseEntity: BaseEntity{ID: uuid.New()}, MaxAgents: &limit}
+
+	assert.NoError(t, p.CheckAgentQuota(1))
+	var quotaErr AgentQuotaExceededError
+	require.ErrorAs(t, p.CheckAgentQuota(2), &quotaErr)
+	assert.Equal(t, p.ID, quotaErr.ProviderID)
+	assert.Equal(t, 2, quotaErr.MaxAgents)
+	// A cap lowered below the current count still blocks new agents
+	assert.ErrorAs(t, p.CheckAgentQuota(5), &AgentQuotaExceededError{})
+	assert.NoError(t, (&Participant{}).CheckAgentQuota(100), "no limit without a cap")
+}
+
+func TestParticipantCommander_UpdateMaxAgents(t *testing.T) {
+	participant := &Participant{BaseEntity: BaseEntity{ID: uuid.New()}, Name: "provider", Status: ParticipantEnabled}
+	limit := 3
+
+	t.Run("admin sets the cap", func(t *testing.T) {
+		ms := setupMockStore(t)
+		participantRepo := NewMockParticipantRepository(t)
+		eventRepo := NewMockEventRepository(t)
+		ms.EXPECT().ParticipantRepo().Return(participantRepo)
+		ms.EXPECT().EventRepo().Return(eventRepo)
+		participantRepo.EXPECT().Get(mock.Anything, participant.ID).Return(participant, nil)
+		participantRepo.EXPECT().Save(mock.Anything, mock.Anything).Return(nil)
+		eventRepo.EXPECT().Create(mock.Anything, mock.Anything).Return(nil)
+
+		ctx := auth.WithIdentity(context.Background(), &auth.Identity{ID: uuid.New(), Role: auth.RoleAdmin})
+		updated, err := NewParticipantCommander(ms).Update(ctx, UpdateParticipantParams{ID: participant.ID, MaxAgents: &limit})
+		require.NoError(t, err)
+		assert.Equal(t, &limit, updated.MaxAgents)
+	})
+
+	t.Run("participant cannot change its own cap", func(t *testing.T) {
+		ms := setupMockStore(t)
+		participantRepo := NewMockParticipantRepository(t)
+		ms.EXPECT().ParticipantRepo().Return(participantRepo)
+		participantRepo.EXPECT().Get(mock.Anything, participant.ID).Return(&Participant{BaseEntity: participant.BaseEntity}, nil)
+
+		ctx := auth.WithIdentity(context.Background(), &auth.Identity{ID: uuid.New(), Role: auth.RoleParticipant, Scope: auth.IdentityScope{ParticipantID: &participant.ID}})
+		_, err := NewParticipantCommander(ms).Update(ctx, UpdateParticipantParams{ID: participant.ID, MaxAgents: &limit})
+		assert.True(t, errors.As(err, &UnauthorizedError{}))
+	})
+}
@@ -0,0 +1,71 @@
+// Secret backend abstraction for the vault
+package domain
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/fulcrumproject/core/pkg/schema"
+)
+
+// SecretBackend stores the serialized secret values of the vault by reference
+//
+// Implementations return a NotFoundError for unknown references and a
+// SecretBackendUnavailableError when the backend cannot be reached.
+type SecretBackend interface {
+	// Put stores the data of a secret, replacing the current value when the reference exists
+	Put(ctx context.Context, reference string, data []byte) error
+	// Get returns the current data of a secret
+	Get(ctx context.Context, reference string) ([]byte, error)
+	// Delete permanently removes a secret and all its versions
+	Delete(ctx context.Context, reference string) error
+	// Rotate stores the data as the new current version of an existing secret, the replaced
+	// version stays valid until graceUntil and at most keep previous versions are retained
+	Rotate(ctx context.Context, reference string, data []byte, graceUntil time.Time, keep int) error
+	// GetValid returns the data of the current version followed by the previous versions not expired at the given time
+	GetValid(ctx context.Context, reference string, at time.Time) ([][]byte, error)
+	// PurgeExpired removes the previous versions expired at the given time and returns how many were removed
+	PurgeExpired(ctx context.Context, at time.Time) (int, error)
+}
+
+// SecretVault implements schema.Vault on top of a SecretBackend
+// Values are stored as JSON so every backend keeps the type of the secret
+type SecretVault struct {
+	backend SecretBackend
+}
+
+var _ schema.Vault = (*SecretVault)(nil)
+
+// NewSecretVault creates a vault storing its secrets in the backend
+func NewSecretVault(backend SecretBackend) *SecretVault {
+	return &SecretVault{backend: backend}
+}
+
+// Save stores a secret in the backend
+func (v *SecretVault) Save(ctx context.Context, reference string, value any, metadata map[string]any) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("failed to serialize secret: %w", err)
+	}
+	return v.backend.Put(ctx, reference, data)
+}
+
+// Get retrieves a secret from the backend
+func (v *SecretVault) Get(ctx context.Context, reference string) (any, error) {
+	data, err := v.backend.Get(ctx, reference)
+	if err != nil {
+		return nil, err
+	}
+	var value any
+	if err := json.Unmarshal(data, &value); err != nil {
+		return nil, fmt.Errorf("failed to deserialize secret: %w", err)
+	}
+	return value, nil
+}
+
+// Delete permanently removes a secret from the backend
+func (v *SecretVault) Delete(ctx context.Context, reference string) error {
+	return v.backend.Delete(ctx, reference)
+}
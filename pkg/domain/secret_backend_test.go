@@ -0,0 +1,55 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSecretVault(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Save serializes the value", func(t *testing.T) {
+		backend := NewMockSecretBackend(t)
+		backend.EXPECT().Put(ctx, "ref", []byte(`{"user":"admin"}`)).Return(nil)
+
+		err := NewSecretVault(backend).Save(ctx, "ref", map[string]any{"user": "admin"}, nil)
+		require.NoError(t, err)
+	})
+
+	t.Run("Get deserializes the value", func(t *testing.T) {
+		backend := NewMockSecretBackend(t)
+		backend.EXPECT().Get(ctx, "ref").Return([]byte(`[1,"two"]`), nil)
+
+		value, err := NewSecretVault(backend).Get(ctx, "ref")
+		require.NoError(t, err)
+		assert.Equal(t, []any{float64(1), "two"}, value)
+	})
+
+	t.Run("Get keeps backend errors", func(t *testing.T) {
+		backend := NewMockSecretBackend(t)
+		backend.EXPECT().Get(ctx, "ref").Return(nil, NewSecretBackendUnavailableErrorf("vault is sealed"))
+
+		_, err := NewSecretVault(backend).Get(ctx, "ref")
+		var unavailable SecretBackendUnavailableError
+		assert.True(t, errors.As(err, &unavailable))
+	})
+
+	t.Run("Get rejects corrupted data", func(t *testing.T) {
+		backend := NewMockSecretBackend(t)
+		backend.EXPECT().Get(ctx, "ref").Return([]byte(`{`), nil)
+
+		_, err := NewSecretVault(backend).Get(ctx, "ref")
+		assert.ErrorContains(t, err, "failed to deserialize secret")
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		backend := NewMockSecretBackend(t)
+		backend.EXPECT().Delete(ctx, "ref").Return(nil)
+
+		require.NoError(t, NewSecretVault(backend).Delete(ctx, "ref"))
+	})
+}
@@ -5,6 +5,9 @@ import (
 	"errors"
 	"fmt"
 	"maps"
+	"reflect"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/fulcrumproject/core/pkg/auth"
@@ -19,29 +22,149 @@ const (
 	EventTypeServiceUpdated      EventType = "service.updated"
 	EventTypeServiceTransitioned EventType = "service.transitioned"
 	EventTypeServiceRetried      EventType = "service.retried"
+	EventTypeServiceRestored     EventType = "service.restored"
+	EventTypeServiceAutoStopped  EventType = "service.auto_stopped"
+	EventTypeServiceReconciled   EventType = "service.reconciled"
+
+	EventTypeServiceMaintenanceEnabled  EventType = "service.maintenance_enabled"
+	EventTypeServiceMaintenanceDisabled EventType = "service.maintenance_disabled"
+
+	EventTypeServiceOperationCancelled EventType = "service.operation_cancelled"
+	EventTypeServiceOrphaned           EventType = "service.orphaned"
+)
+
+// ServiceActionDelete is the lifecycle action deleting a service, its completion soft-deletes the service
+const ServiceActionDelete = "delete"
+
+// ServiceActionStop is the lifecycle action stopping a service, requested by the idle auto-stop
+const ServiceActionStop = "stop"
+
+// ServiceIncludeDeletedParam is the query parameter including the soft-deleted services when set to true
+const ServiceIncludeDeletedParam = "includeDeleted"
+
+// ErrServiceNameTaken is wrapped by the conflict returned when another active service of the group has the name
+var ErrServiceNameTaken = errors.New("service name already used in the group")
+
+// NewServiceNameTakenError returns the conflict of a service name already used in the group
+func NewServiceNameTakenError(groupID properties.UUID, name string) ConflictError {
+	return ConflictError{Err: fmt.Errorf("%w: %q in group %s", ErrServiceNameTaken, name, groupID)}
+}
+
+// checkServiceNameAvailable returns a conflict when another active service of the group has the name of svc
+// The unique index on the group and the name is the source of truth, this check gives the same error early
+func checkServiceNameAvailable(ctx context.Context, store Store, svc *Service) error {
+	other, err := store.ServiceRepo().FindByGroupAndName(ctx, svc.GroupID, svc.Name)
+	if err != nil {
+		var notFound NotFoundError
+		if errors.As(err, &notFound) {
+			return nil
+		}
+		return err
+	}
+	if other.ID != svc.ID {
+		return NewServiceNameTakenError(svc.GroupID, svc.Name)
+	}
+	return nil
+}
+
+// ErrAgentInstanceIDTaken is wrapped by the conflict returned when another active service of the agent has the instance ID
+var ErrAgentInstanceIDTaken = errors.New("agent instance ID already used by another service of the agent")
+
+// NewAgentInstanceIDTakenError returns the conflict of an instance ID already used by another service of the agent
+func NewAgentInstanceIDTakenError(agentID properties.UUID, agentInstanceID string) ConflictError {
+	return ConflictError{Err: fmt.Errorf("%w: %q on agent %s", ErrAgentInstanceIDTaken, agentInstanceID, agentID)}
+}
+
+// checkAgentInstanceIDAvailable returns a conflict when another active service of the agent of svc has the instance ID
+// An agent reporting again the instance ID of the same service is not a conflict
+func checkAgentInstanceIDAvailable(ctx context.Context, store Store, svc *Service, agentInstanceID string) error {
+	other, err := store.ServiceRepo().FindByAgentInstanceID(ctx, svc.AgentID, agentInstanceID)
+	if err != nil {
+		var notFound NotFoundError
+		if errors.As(err, &notFound) {
+			return nil
+		}
+		return err
+	}
+	if other.ID != svc.ID {
+		return NewAgentInstanceIDTakenError(svc.AgentID, agentInstanceID)
+	}
+	return nil
+}
+
+// Lifecycle actions used to apply property updates, by update mode
+const (
+	ServiceActionUpdate     = "update"
+	ServiceActionWarmUpdate = "warmUpdate"
+	ServiceActionColdUpdate = "coldUpdate"
 )
 
+// UpdateActionForMode returns the lifecycle action that applies an update of the given mode
+func UpdateActionForMode(mode string) string {
+	switch mode {
+	case schema.UpdateModeWarm:
+		return ServiceActionWarmUpdate
+	case schema.UpdateModeCold:
+		return ServiceActionColdUpdate
+	default:
+		return ServiceActionUpdate
+	}
+}
+
+// IsUpdateAction checks if the action applies a property update
+func IsUpdateAction(action string) bool {
+	return action == ServiceActionUpdate || action == ServiceActionWarmUpdate || action == ServiceActionColdUpdate
+}
+
 // Service represents a service instance managed by an agent
 type Service struct {
 	BaseEntity
 
-	Name       string           `json:"name" gorm:"not null"`
+	Name       string           `json:"name" gorm:"not null;uniqueIndex:service_group_name_uniq,priority:2,where:deleted_at IS NULL"`
 	Status     string           `json:"status" gorm:"not null"`
 	Properties *properties.JSON `json:"properties,omitempty" gorm:"type:jsonb"`
+	// Labels index the service for the label selectors, they are not part of the properties
+	Labels map[string]string `json:"labels,omitempty" gorm:"type:jsonb;serializer:json;default:'{}';index:service_labels_gin,type:gin"`
+	// Version of the service type property schema the properties were last validated against
+	SchemaVersion int `json:"schemaVersion" gorm:"not null;default:1"`
+	// Time after which the jobs of the service time out, overriding the configured job timeouts
+	// Defaults to the operation timeout of the service type when the service is created
+	OperationTimeout *time.Duration `json:"operationTimeout,omitempty"`
+	// Inactivity after which the service is stopped, nil to never stop it automatically
+	IdleTimeout *time.Duration `json:"idleTimeout,omitempty"`
+	// Completion of the last job, the metric entries of the service count as activity as well
+	LastActivityAt *time.Time `json:"lastActivityAt,omitempty"`
+	// A service in maintenance accepts no transition or update, the jobs already in flight still complete
+	Maintenance bool `json:"maintenance" gorm:"not null;default:false"`
+	// Set when the agent reports the backing resource no longer exists, cleared when the agent reconciles it
+	Orphaned   bool       `json:"orphaned" gorm:"not null;default:false;index"`
+	OrphanedAt *time.Time `json:"orphanedAt,omitempty"`
+	// Set when an action of the service is dead-lettered, cleared when an operator requeues it
+	Failed   bool       `json:"failed" gorm:"not null;default:false;index"`
+	FailedAt *time.Time `json:"failedAt,omitempty"`
+	// Services of the same group that must be running before this one is started, and stopped after it
+	DependsOn []properties.UUID `json:"dependsOn,omitempty" gorm:"type:jsonb;serializer:json"`
+	// Region the agent of the service must run in for data residency, set on creation and never changed
+	RequiredRegion *string `json:"requiredRegion,omitempty"`
 
 	// Agent's native instance identifier for this service in their infrastructure system
-	AgentInstanceID *string `json:"agentInstanceId,omitempty" gorm:"uniqueIndex:service_agent_instance_id_uniq"`
+	AgentInstanceID *string `json:"agentInstanceId,omitempty" gorm:"uniqueIndex:service_agent_instance_uniq,priority:2,where:deleted_at IS NULL"`
 	// Safe place for the Agent to store data
 	AgentInstanceData *properties.JSON `json:"agentInstanceData,omitempty" gorm:"type:jsonb"`
 
+	// Soft-delete, the row is kept for the restore window after the delete action completed
+	DeletedAt              *time.Time `json:"deletedAt,omitempty" gorm:"index"`
+	DeletedFromStatus      string     `json:"-" gorm:"type:varchar(50)"` // Status restored by a restore
+	DeletedAgentInstanceID *string    `json:"-"`                         // Instance ID set aside so the agent can reuse it
+
 	// Relationships
 	ProviderID    properties.UUID `json:"providerId" gorm:"not null"`
 	Provider      *Participant    `json:"-" gorm:"foreignKey:ProviderID"`
 	ConsumerID    properties.UUID `json:"consumerId" gorm:"not null"`
 	Consumer      *Participant    `json:"-" gorm:"foreignKey:ConsumerID"`
-	GroupID       properties.UUID `gorm:"not null" json:"groupId"`
+	GroupID       properties.UUID `gorm:"not null;uniqueIndex:service_group_name_uniq,priority:1,where:deleted_at IS NULL" json:"groupId"`
 	Group         *ServiceGroup   `json:"-" gorm:"foreignKey:GroupID"`
-	AgentID       properties.UUID `json:"agentId" gorm:"not null"`
+	AgentID       properties.UUID `json:"agentId" gorm:"not null;uniqueIndex:service_agent_instance_uniq,priority:1,where:deleted_at IS NULL"`
 	Agent         *Agent          `json:"-" gorm:"foreignKey:AgentID"`
 	ServiceTypeID properties.UUID `json:"serviceTypeId" gorm:"not null"`
 	ServiceType   *ServiceType    `json:"-" gorm:"foreignKey:ServiceTypeID"`
@@ -55,14 +178,17 @@ func NewService(
 	initialStatus string,
 ) *Service {
 	return &Service{
-		ConsumerID:    group.ConsumerID,
-		GroupID:       group.ID,
-		ProviderID:    agent.ProviderID,
-		AgentID:       agent.ID,
-		ServiceTypeID: params.ServiceTypeID,
-		Name:          params.Name,
-		Status:        initialStatus,
-		Properties:    &params.Properties,
+		ConsumerID:       group.ConsumerID,
+		GroupID:          group.ID,
+		ProviderID:       agent.ProviderID,
+		AgentID:          agent.ID,
+		ServiceTypeID:    params.ServiceTypeID,
+		Name:             params.Name,
+		Status:           initialStatus,
+		Properties:       &params.Properties,
+		OperationTimeout: params.OperationTimeout,
+		IdleTimeout:      params.IdleTimeout,
+		RequiredRegion:   params.RequiredRegion,
 	}
 }
 
@@ -74,6 +200,8 @@ func (s *Service) HandleJobComplete(lifecycle LifecycleSchema, action string, er
 		return err
 	}
 	s.Status = nextStatus
+	now := time.Now()
+	s.LastActivityAt = &now
 
 	// Update agent data and agent instance ID if provided
 	if agentInstanceData != nil {
@@ -84,16 +212,64 @@ func (s *Service) HandleJobComplete(lifecycle LifecycleSchema, action string, er
 	}
 
 	// Update properties if the action is an update
-	if action == "update" {
+	if IsUpdateAction(action) {
 		s.Properties = params
 	}
 
 	return nil
 }
 
+// SetIdleTimeout changes the idle timeout, zero removes it, and reports whether it changed
+func (s *Service) SetIdleTimeout(timeout time.Duration) bool {
+	if timeout == 0 {
+		changed := s.IdleTimeout != nil
+		s.IdleTimeout = nil
+		return changed
+	}
+	if s.IdleTimeout != nil && *s.IdleTimeout == timeout {
+		return false
+	}
+	s.IdleTimeout = &timeout
+	return true
+}
+
+// SoftDelete marks the service deleted once its delete action completed, previousStatus is the status restored by Restore
+// The agent instance ID is set aside, freeing it for new services while the row is retained
+func (s *Service) SoftDelete(previousStatus string) {
+	now := time.Now()
+	s.DeletedAt = &now
+	s.DeletedFromStatus = previousStatus
+	s.DeletedAgentInstanceID = s.AgentInstanceID
+	s.AgentInstanceID = nil
+}
+
+// IsDeleted reports whether the service is soft-deleted
+func (s *Service) IsDeleted() bool {
+	return s.DeletedAt != nil
+}
+
+// Restore brings a soft-deleted service back to its status before the deletion, within the restore window
+func (s *Service) Restore(window time.Duration) error {
+	if s.DeletedAt == nil {
+		return fmt.Errorf("service is not deleted")
+	}
+	if time.Since(*s.DeletedAt) > window {
+		return fmt.Errorf("service was deleted more than %s ago and can no longer be restored", window)
+	}
+	if s.DeletedFromStatus == "" {
+		return fmt.Errorf("service status before the deletion is unknown")
+	}
+	s.Status = s.DeletedFromStatus
+	s.AgentInstanceID = s.DeletedAgentInstanceID
+	s.DeletedAt = nil
+	s.DeletedFromStatus = ""
+	s.DeletedAgentInstanceID = nil
+	return nil
+}
+
 // Update updates the service
 func (s *Service) Update(name *string, properties *properties.JSON) (update bool, action bool, err error) {
-	if name != nil {
+	if name != nil && *name != s.Name {
 		s.Name = *name
 		update = true
 	}
@@ -152,6 +328,66 @@ func ApplyAgentPropertyUpdates(
 	return nil
 }
 
+// SetLabels applies the label changes, a nil value removes the label
+// It reports whether the labels changed
+func (s *Service) SetLabels(changes map[string]*string) (bool, error) {
+	labels := maps.Clone(s.Labels)
+	if labels == nil {
+		labels = make(map[string]string)
+	}
+	for key, value := range changes {
+		if value == nil {
+			delete(labels, key)
+			continue
+		}
+		labels[key] = *value
+	}
+	if err := ValidateLabels(labels); err != nil {
+		return false, err
+	}
+	if maps.Equal(labels, s.Labels) {
+		return false, nil
+	}
+	s.Labels = labels
+	return true, nil
+}
+
+// SetMaintenance sets or clears the maintenance mode and reports whether it changed
+func (s *Service) SetMaintenance(enabled bool) bool {
+	if s.Maintenance == enabled {
+		return false
+	}
+	s.Maintenance = enabled
+	return true
+}
+
+// MarkFailed flags the service whose action used up its attempts and moves it to the failed state of the lifecycle if any,
+// it reports whether the service changed
+func (s *Service) MarkFailed(lifecycle LifecycleSchema) bool {
+	changed := false
+	if !s.Failed {
+		now := time.Now()
+		s.Failed = true
+		s.FailedAt = &now
+		changed = true
+	}
+	if lifecycle.FailedState != "" && s.Status != lifecycle.FailedState {
+		s.Status = lifecycle.FailedState
+		changed = true
+	}
+	return changed
+}
+
+// ClearFailed removes the failed flag and reports whether it changed
+func (s *Service) ClearFailed() bool {
+	if !s.Failed {
+		return false
+	}
+	s.Failed = false
+	s.FailedAt = nil
+	return true
+}
+
 // Validate a service
 func (s *Service) Validate() error {
 	if s.Name == "" {
@@ -169,7 +405,16 @@ func (s *Service) Validate() error {
 	if s.ServiceTypeID == uuid.Nil {
 		return errors.New("service type ID cannot be nil")
 	}
-	return nil
+	if err := ValidateOperationTimeout(s.OperationTimeout); err != nil {
+		return err
+	}
+	if s.IdleTimeout != nil && *s.IdleTimeout <= 0 {
+		return fmt.Errorf("idle timeout must be positive, got %s", *s.IdleTimeout)
+	}
+	if s.RequiredRegion != nil && *s.RequiredRegion == "" {
+		return errors.New("service required region cannot be empty")
+	}
+	return ValidateLabels(s.Labels)
 }
 
 // TableName returns the table name for the service
@@ -185,30 +430,99 @@ type ServiceCommander interface {
 	// CreateWithTags handles service creation using agent discovery by tags
 	CreateWithTags(ctx context.Context, params CreateServiceWithTagsParams) (*Service, error)
 
+	// ValidateCreate runs the service creation validation without persisting the service or its job
+	ValidateCreate(ctx context.Context, params CreateServiceWithTagsParams) (*ValidateCreateServiceResult, error)
+
+	// EstimateCost estimates the cost of a service creation validated like ValidateCreate without creating it
+	EstimateCost(ctx context.Context, params CreateServiceWithTagsParams) (*CostEstimate, error)
+
+	// Clone creates a new service with the type, group and properties of an existing one
+	Clone(ctx context.Context, id properties.UUID, name string) (*Service, error)
+
 	// Update handles service updates and creates a job for the agent
 	Update(ctx context.Context, params UpdateServiceParams) (*Service, error)
 
+	// PreviewUpdate computes the property changes of an update and the action applying them without persisting
+	PreviewUpdate(ctx context.Context, id properties.UUID, name *string, props *properties.JSON) (*ServiceUpdatePreview, error)
+
+	// StateMachine returns the lifecycle transition graph of a service type, as enforced on its services
+	StateMachine(ctx context.Context, serviceTypeID properties.UUID) ([]LifecycleStateNode, error)
+
 	// DoAction handles service actions
 	DoAction(ctx context.Context, params DoServiceActionParams) (*Service, error)
 
-	// FailTimeoutServicesAndJobs fails services and jobs that have timed out
-	FailTimeoutServicesAndJobs(ctx context.Context, timeout time.Duration) (int, error)
+	// CancelOperation cancels the active job of a service
+	CancelOperation(ctx context.Context, id properties.UUID) (*Service, error)
+
+	// BatchAction validates and applies several service actions atomically
+	BatchAction(ctx context.Context, params BatchServiceActionParams) ([]BatchServiceActionResult, error)
+
+	// FailTimeoutServicesAndJobs fails services and jobs that have timed out, dead-lettering the jobs that used up maxAttempts.
+	// The jobs are failed in one transaction, on error none is and the count is zero
+	FailTimeoutServicesAndJobs(ctx context.Context, timeouts JobTimeouts, maxAttempts int) (int, error)
+
+	// PromoteScheduledJobs makes the scheduled jobs whose time has come available to agents
+	PromoteScheduledJobs(ctx context.Context) (int, error)
+
+	// Restore brings back a soft-deleted service within the restore window
+	Restore(ctx context.Context, id properties.UUID) (*Service, error)
+
+	// SetLabels applies label changes without going through the property update, a nil value removes the label
+	SetLabels(ctx context.Context, id properties.UUID, changes map[string]*string) (*Service, error)
+
+	// SetDependencies replaces the services of the same group the service depends on, rejecting cycles
+	SetDependencies(ctx context.Context, id properties.UUID, dependsOn []properties.UUID) (*Service, error)
+
+	// SetMaintenance sets or clears the maintenance mode, freezing the transitions and updates of the service
+	SetMaintenance(ctx context.Context, id properties.UUID, enabled bool) (*Service, error)
+
+	// Reconcile applies the corrections of the agent-sourced properties reported by the agent of the service,
+	// without any lifecycle action
+	Reconcile(ctx context.Context, id properties.UUID, props properties.JSON) (*Service, error)
+
+	// ReportOrphaned flags the service whose backing resource the agent reports gone, leaving its status as is
+	ReportOrphaned(ctx context.Context, id properties.UUID, reason string) (*Service, error)
+
+	// PurgeDeletedServices hard-deletes the services soft-deleted before the restore window and returns their number
+	PurgeDeletedServices(ctx context.Context) (int, error)
 }
 
 // serviceCommander is the concrete implementation of ServiceCommander
 type serviceCommander struct {
-	store  Store
-	engine *schema.Engine[ServicePropertyContext]
+	store         Store
+	engine        *schema.Engine[ServicePropertyContext]
+	selector      AgentSelector
+	restoreWindow time.Duration
+	retry         JobRetryPolicy
+	estimator     CostEstimator
 }
 
 // NewServiceCommander creates a new commander for services
+// The selector chooses the agent of the services created without one, the least loaded agent when nil
+// Deleted services can be restored during restoreWindow, they are purged afterwards
+// The jobs retrying a failed action are deferred by the backoff of the retry policy
+// The estimator prices the services before their creation, the catalog costs when nil
 func NewServiceCommander(
 	store Store,
 	engine *schema.Engine[ServicePropertyContext],
+	selector AgentSelector,
+	restoreWindow time.Duration,
+	retry JobRetryPolicy,
+	estimator CostEstimator,
 ) *serviceCommander {
+	if selector == nil {
+		selector = LeastLoadedAgentSelector{}
+	}
+	if estimator == nil {
+		estimator = CatalogCostEstimator{}
+	}
 	return &serviceCommander{
-		store:  store,
-		engine: engine,
+		store:         store,
+		engine:        engine,
+		selector:      selector,
+		restoreWindow: restoreWindow,
+		retry:         retry,
+		estimator:     estimator,
 	}
 }
 
@@ -218,6 +532,17 @@ type CreateServiceParams struct {
 	GroupID       properties.UUID `json:"groupId"`
 	Name          string          `json:"name"`
 	Properties    properties.JSON `json:"targetProperties"`
+	// OperationTimeout overrides the one of the service type when set
+	OperationTimeout *time.Duration `json:"operationTimeout,omitempty"`
+	// IdleTimeout enables the auto-stop of the service once inactive for this long
+	IdleTimeout *time.Duration `json:"idleTimeout,omitempty"`
+	// TargetState is the state the service is driven to once created, through the follow-on
+	// actions of its lifecycle, empty to stay in the state reached by the creation
+	TargetState string `json:"targetState,omitempty"`
+	// RequiredRegion restricts the agents the service can be placed on to the ones of the region
+	RequiredRegion *string `json:"requiredRegion,omitempty"`
+	// OptionIDs are the service options of the provider selected for the service, resolved into properties
+	OptionIDs []properties.UUID `json:"optionIds,omitempty"`
 }
 
 type CreateServiceWithTagsParams struct {
@@ -225,21 +550,53 @@ type CreateServiceWithTagsParams struct {
 	ServiceTags []string `json:"agentTags,omitempty"`
 }
 
+// ValidateCreateServiceResult holds the outcome of a dry-run service creation
+type ValidateCreateServiceResult struct {
+	Valid      bool
+	Properties properties.JSON
+	Errors     []schema.ValidationErrorDetail
+}
+
 type UpdateServiceParams struct {
 	ID         properties.UUID  `json:"id"`
 	Name       *string          `json:"name,omitempty"`
 	Properties *properties.JSON `json:"properties,omitempty"`
+	// IdleTimeout replaces the idle timeout, zero disables the auto-stop
+	IdleTimeout *time.Duration `json:"idleTimeout,omitempty"`
+	// RequiredRegion is only accepted when unchanged, the region of a service is fixed on creation
+	RequiredRegion *string `json:"requiredRegion,omitempty"`
 }
 
 type DoServiceActionParams struct {
-	ID     properties.UUID `json:"id"`
-	Action string          `json:"action"`
+	ID          properties.UUID `json:"id"`
+	Action      string          `json:"action"`
+	ScheduledAt *time.Time      `json:"scheduledAt,omitempty"`
+}
+
+type BatchServiceActionParams struct {
+	Items []DoServiceActionParams `json:"items"`
+}
+
+// BatchServiceActionResult holds the outcome of a single item of a batch action
+type BatchServiceActionResult struct {
+	ID      properties.UUID
+	Service *Service
+	Err     error
 }
 
 func (s *serviceCommander) Create(
 	ctx context.Context,
 	params CreateServiceParams,
 ) (*Service, error) {
+	if params.AgentID == uuid.Nil {
+		agent, err := selectAgent(ctx, s.store, s.selector, CreateServiceWithTagsParams{CreateServiceParams: params})
+		if err != nil {
+			return nil, err
+		}
+		params.AgentID = agent.ID
+		return CreateServiceWithAgent(ctx, s.store, s.engine, agent, params)
+	}
+
 	agent, err := s.store.AgentRepo().Get(ctx, params.AgentID)
 	if err != nil {
 		return nil, NewInvalidInputErrorf("agent with ID %s does not exist", params.AgentID)
@@ -252,26 +609,123 @@ func (s *serviceCommander) CreateWithTags(
 	ctx context.Context,
 	params CreateServiceWithTagsParams,
 ) (*Service, error) {
-	return CreateServiceWithTags(ctx, s.store, s.engine, params)
+	return CreateServiceWithTags(ctx, s.store, s.engine, s.selector, params)
+}
+
+func (s *serviceCommander) Clone(ctx context.Context, id properties.UUID, name string) (*Service, error) {
+	source, err := s.store.ServiceRepo().Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	serviceType, err := s.store.ServiceTypeRepo().Get(ctx, source.ServiceTypeID)
+	if err != nil {
+		return nil, err
+	}
+
+	params := CreateServiceParams{
+		AgentID:          source.AgentID,
+		ServiceTypeID:    source.ServiceTypeID,
+		GroupID:          source.GroupID,
+		Name:             name,
+		Properties:       cloneableProperties(serviceType.PropertySchema, source.Properties),
+		OperationTimeout: source.OperationTimeout,
+		IdleTimeout:      source.IdleTimeout,
+		RequiredRegion:   source.RequiredRegion,
+	}
+	return s.Create(ctx, params)
+}
+
+// cloneableProperties returns the properties of a service that can be copied to a new one
+// Agent-sourced, generated and secret values are left out so the new service obtains its own
+func cloneableProperties(propertySchema schema.Schema, props *properties.JSON) properties.JSON {
+	result := make(properties.JSON)
+	if props == nil {
+		return result
+	}
+	for name, value := range *props {
+		def, ok := propertySchema.Properties[name]
+		if !ok || def.Generator != nil || def.Secret != nil || isAgentSourcedProperty(def) {
+			continue
+		}
+		result[name] = value
+	}
+	return result
+}
+
+// isAgentSourcedProperty reports whether a property can only be set by agents
+func isAgentSourcedProperty(def schema.PropertyDefinition) bool {
+	for _, authorizer := range def.Authorizers {
+		if authorizer.Type == schema.AuthorizerTypeActor && !slices.Contains(authorizer.Actors(), string(ActorUser)) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *serviceCommander) ValidateCreate(
+	ctx context.Context,
+	params CreateServiceWithTagsParams,
+) (*ValidateCreateServiceResult, error) {
+	var agent *Agent
+	if params.AgentID != uuid.Nil {
+		var err error
+		agent, err = s.store.AgentRepo().Get(ctx, params.AgentID)
+		if err != nil {
+			return nil, NewInvalidInputErrorf("agent with ID %s does not exist", params.AgentID)
+		}
+	} else {
+		var err error
+		agent, err = selectAgent(ctx, s.store, s.selector, params)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return ValidateCreateServiceWithAgent(ctx, s.store, s.engine, agent, params.CreateServiceParams)
 }
 
 func CreateServiceWithTags(
 	ctx context.Context,
 	store Store,
 	engine *schema.Engine[ServicePropertyContext],
+	selector AgentSelector,
 	params CreateServiceWithTagsParams,
 ) (*Service, error) {
-	agents, err := store.AgentRepo().FindByServiceTypeAndTags(ctx, params.ServiceTypeID, params.ServiceTags)
+	agent, err := selectAgent(ctx, store, selector, params)
+	if err != nil {
+		return nil, err
+	}
+
+	params.AgentID = agent.ID
+	return CreateServiceWithAgent(ctx, store, engine, agent, params.CreateServiceParams)
+}
+
+// selectAgent chooses with the selector the agent of a service among the suitable agents
+// supporting its service type, having the requested tags and running in the required region
+func selectAgent(ctx context.Context, store Store, selector AgentSelector, params CreateServiceWithTagsParams) (*Agent, error) {
+	serviceType, err := store.ServiceTypeRepo().Get(ctx, params.ServiceTypeID)
 	if err != nil {
 		return nil, err
 	}
 
-	if len(agents) == 0 {
-		return nil, NewInvalidInputErrorf("no agent found for service type %s with tags %v", params.ServiceTypeID, params.ServiceTags)
+	agents, err := store.AgentRepo().FindByServiceTypeAndTags(ctx, params.ServiceTypeID, params.ServiceTags)
+	if err != nil {
+		return nil, err
 	}
 
-	agent := agents[0]
-	return CreateServiceWithAgent(ctx, store, engine, agent, params.CreateServiceParams)
+	suitable := SuitableAgents(agents, serviceType)
+	if len(suitable) == 0 {
+		return nil, NewNoSuitableAgentError(params.ServiceTypeID, params.ServiceTags)
+	}
+	candidates := suitable
+	if params.RequiredRegion != nil {
+		candidates = AgentsInRegion(suitable, *params.RequiredRegion)
+		if len(candidates) == 0 {
+			return nil, NewNoAgentInRegionError(params.ServiceTypeID, *params.RequiredRegion, suitable)
+		}
+	}
+	return selector.Select(candidates, params.CreateServiceParams)
 }
 
 func CreateServiceWithAgent(
@@ -281,65 +735,19 @@ func CreateServiceWithAgent(
 	agent *Agent,
 	params CreateServiceParams,
 ) (*Service, error) {
-	group, err := store.ServiceGroupRepo().Get(ctx, params.GroupID)
+	svc, serviceType, err := prepareServiceCreate(ctx, store, agent, params)
 	if err != nil {
 		return nil, err
 	}
-
-	// Load ServiceType to get property schema
-	serviceType, err := store.ServiceTypeRepo().Get(ctx, params.ServiceTypeID)
+	params.Properties, err = resolveCreateProperties(ctx, store, svc, serviceType, params)
 	if err != nil {
 		return nil, err
 	}
 
-	// Extract actor from auth context
-	identity := auth.MustGetIdentity(ctx)
-	actor := ActorTypeFromAuthRole(identity.Role)
-
-	// Generate service ID upfront so pool generators can use it for allocation tracking
-	serviceID := properties.UUID(uuid.New())
-
-	// Check if the agent's type supports the requested service type
-	supported := false
-	for _, agentServiceType := range agent.AgentType.ServiceTypes {
-		if agentServiceType.ID == params.ServiceTypeID {
-			supported = true
-			break
-		}
-	}
-	if !supported {
-		return nil, NewInvalidInputErrorf("agent type %s does not support service type %s", agent.AgentType.Name, params.ServiceTypeID)
-	}
-
-	// Get initial state from lifecycle schema (always present)
-	initialState := serviceType.LifecycleSchema.InitialState
-
-	svc := NewService(
-		agent,
-		group,
-		params,
-		initialState,
-	)
-	// Set the pre-generated ID
-	svc.ID = serviceID
-
-	if err := svc.Validate(); err != nil {
-		return nil, InvalidInputError{Err: err}
-	}
-
 	err = store.Atomic(ctx, func(txStore Store) error {
 		// Validate and process properties using schema engine WITHIN transaction
 		// This ensures pool allocations happen within the same transaction
-		schemaCtx := ServicePropertyContext{
-			Actor:            actor,
-			Store:            txStore, // Use transactional store
-			ProviderID:       agent.ProviderID,
-			ConsumerID:       svc.ConsumerID,
-			GroupID:          svc.GroupID,
-			ServicePoolSetID: agent.ServicePoolSetID,
-			ServiceID:        &serviceID,
-			ServiceStatus:    "", // empty during create
-		}
+		schemaCtx := newServiceCreateSchemaContext(ctx, txStore, agent, svc)
 
 		validatedProperties, err := engine.ApplyCreate(ctx, schemaCtx, serviceType.PropertySchema, params.Properties)
 		if err != nil {
@@ -360,7 +768,11 @@ func CreateServiceWithAgent(
 		if svc.Properties != nil {
 			finalProps = *svc.Properties
 		}
-		job := NewJob(svc, "create", &finalProps, 1)
+		job := NewJob(svc, "create", &finalProps, DefaultJobPriority("create"))
+		if params.TargetState != "" {
+			target := params.TargetState
+			job.TargetState = &target
+		}
 		if err := job.Validate(); err != nil {
 			return err
 		}
@@ -379,64 +791,321 @@ func CreateServiceWithAgent(
 		return err
 	})
 	if err != nil {
+		var exhausted PoolExhaustedError
+		if errors.As(err, &exhausted) {
+			if eventErr := recordPoolExhausted(ctx, store, exhausted.PoolID, svc, serviceType); eventErr != nil {
+				return nil, errors.Join(err, eventErr)
+			}
+		}
 		return nil, err
 	}
 
 	return svc, nil
 }
 
-func (s *serviceCommander) Update(ctx context.Context, params UpdateServiceParams) (*Service, error) {
-	return UpdateService(ctx, s.store, s.engine, params)
+// recordPoolExhausted creates the exhaustion event of a pool after a failed allocation
+// The allocation transaction is rolled back with the service creation, so the event is
+// stored in its own transaction together with a fresh read of the exhausted pool.
+// The event belongs to the provider of the pool and the consumer of the refused service.
+func recordPoolExhausted(ctx context.Context, store Store, poolID properties.UUID, svc *Service, serviceType *ServiceType) error {
+	return store.Atomic(ctx, func(store Store) error {
+		pool, err := store.ServicePoolRepo().Get(ctx, poolID)
+		if err != nil {
+			return err
+		}
+		event, err := NewEvent(EventTypePoolExhausted, WithServicePool(pool))
+		if err != nil {
+			return err
+		}
+		event.ProviderID = &svc.ProviderID
+		event.ConsumerID = &svc.ConsumerID
+		event.Payload = properties.JSON{
+			"servicePoolSetId": pool.ServicePoolSetID,
+			"poolType":         pool.Type,
+			"serviceTypeId":    serviceType.ID,
+		}
+		return store.EventRepo().Create(ctx, event)
+	})
 }
 
-func UpdateService(ctx context.Context, store Store, engine *schema.Engine[ServicePropertyContext], params UpdateServiceParams) (*Service, error) {
-	// Find it
-	svc, err := store.ServiceRepo().Get(ctx, params.ID)
+// errDryRun rolls back the transaction of a dry-run service creation
+var errDryRun = errors.New("dry run")
+
+// ValidateCreateServiceWithAgent runs the service creation validation without persisting anything
+// Pool allocations are rolled back and the secrets stored in the vault are removed
+func ValidateCreateServiceWithAgent(
+	ctx context.Context,
+	store Store,
+	engine *schema.Engine[ServicePropertyContext],
+	agent *Agent,
+	params CreateServiceParams,
+) (*ValidateCreateServiceResult, error) {
+	svc, serviceType, err := prepareServiceCreate(ctx, store, agent, params)
 	if err != nil {
 		return nil, err
 	}
-
-	// Load ServiceType to get property schema and lifecycle
-	serviceType, err := store.ServiceTypeRepo().Get(ctx, svc.ServiceTypeID)
+	params.Properties, err = resolveCreateProperties(ctx, store, svc, serviceType, params)
 	if err != nil {
 		return nil, err
 	}
 
-	// Load agent to get pool set (needed for context, even if not updating properties)
-	agent, err := store.AgentRepo().Get(ctx, svc.AgentID)
-	if err != nil {
+	var validatedProperties map[string]any
+	err = store.Atomic(ctx, func(txStore Store) error {
+		schemaCtx := newServiceCreateSchemaContext(ctx, txStore, agent, svc)
+
+		validatedProperties, err = engine.ApplyCreate(ctx, schemaCtx, serviceType.PropertySchema, params.Properties)
+		if err != nil {
+			return err
+		}
+		return errDryRun
+	})
+
+	var validationErr schema.ValidationError
+	switch {
+	case errors.Is(err, errDryRun):
+		engine.CleanupVaultSecrets(ctx, validatedProperties)
+		return &ValidateCreateServiceResult{Valid: true, Properties: validatedProperties}, nil
+	case errors.As(err, &validationErr):
+		return &ValidateCreateServiceResult{Valid: false, Errors: validationErr.Errors}, nil
+	default:
 		return nil, err
 	}
+}
 
-	// Extract actor from auth context (needed for context)
-	identity := auth.MustGetIdentity(ctx)
-	actor := ActorTypeFromAuthRole(identity.Role)
-
-	// Update, if needed
-	originalSvc := *svc
-	update, action, err := svc.Update(params.Name, params.Properties)
+// resolveCreateProperties returns the properties of a new service before the schema engine: the values
+// of the selected service options are merged into the given properties, then both over the defaults
+// The defaults are merged before the schema engine so they are validated like the given properties
+func resolveCreateProperties(
+	ctx context.Context,
+	store Store,
+	svc *Service,
+	serviceType *ServiceType,
+	params CreateServiceParams,
+) (properties.JSON, error) {
+	props, err := ResolveServiceOptions(ctx, store, serviceType, svc.ProviderID, params.Properties, params.OptionIDs)
 	if err != nil {
 		return nil, err
 	}
-	if err := svc.Validate(); err != nil {
-		return nil, InvalidInputError{Err: err}
+	return serviceType.ApplyDefaultProperties(props), nil
+}
+
+// prepareServiceCreate loads the service dependencies and builds the new service
+func prepareServiceCreate(
+	ctx context.Context,
+	store Store,
+	agent *Agent,
+	params CreateServiceParams,
+) (*Service, *ServiceType, error) {
+	group, err := store.ServiceGroupRepo().Get(ctx, params.GroupID)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	// Save, event and create job
-	err = store.Atomic(ctx, func(txStore Store) error {
-		// Validate and process properties if provided WITHIN transaction
-		if params.Properties != nil {
-			// Build schema context with transactional store
-			schemaCtx := ServicePropertyContext{
-				Actor:            actor,
-				Store:            txStore, // Use transactional store
-				ProviderID:       svc.ProviderID,
-				ConsumerID:       svc.ConsumerID,
-				GroupID:          svc.GroupID,
-				ServicePoolSetID: agent.ServicePoolSetID,
-				ServiceID:        &svc.ID,
-				ServiceStatus:    svc.Status,
-			}
+	// Load ServiceType to get property schema
+	serviceType, err := store.ServiceTypeRepo().Get(ctx, params.ServiceTypeID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := checkServicePlacement(agent, serviceType, params.RequiredRegion); err != nil {
+		return nil, nil, err
+	}
+
+	// Get initial state from lifecycle schema (always present)
+	initialState := serviceType.LifecycleSchema.InitialState
+
+	if params.TargetState != "" {
+		if err := checkTargetStateReachable(serviceType.LifecycleSchema, params.TargetState); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	svc := NewService(
+		agent,
+		group,
+		params,
+		initialState,
+	)
+	// Generate service ID upfront so pool generators can use it for allocation tracking
+	svc.ID = properties.UUID(uuid.New())
+	svc.SchemaVersion = serviceType.SchemaVersion
+	if svc.OperationTimeout == nil && serviceType.OperationTimeout != nil {
+		timeout := *serviceType.OperationTimeout
+		svc.OperationTimeout = &timeout
+	}
+
+	if err := svc.Validate(); err != nil {
+		return nil, nil, InvalidInputError{Err: err}
+	}
+	if err := checkServiceNameAvailable(ctx, store, svc); err != nil {
+		return nil, nil, err
+	}
+
+	return svc, serviceType, nil
+}
+
+// checkTargetStateReachable verifies that the follow-on actions of the creation can drive a new
+// service from the state reached by the creation to the target state
+func checkTargetStateReachable(lifecycle LifecycleSchema, target string) error {
+	if !lifecycle.HasState(target) {
+		return NewInvalidInputErrorf("target state %s is not defined by the lifecycle", target)
+	}
+	created, err := lifecycle.ResolveNextState(lifecycle.InitialState, "create", nil)
+	if err != nil {
+		return InvalidInputError{Err: err}
+	}
+	if _, err := lifecycle.PathTo(created, target); err != nil {
+		return NewInvalidInputErrorf("target state %s cannot be reached after the creation: %v", target, err)
+	}
+	return nil
+}
+
+// checkServicePlacement verifies that an agent can host a service of the service type required in the region
+func checkServicePlacement(agent *Agent, serviceType *ServiceType, requiredRegion *string) error {
+	// Check if the agent's type supports the requested service type
+	supported := false
+	for _, agentServiceType := range agent.AgentType.ServiceTypes {
+		if agentServiceType.ID == serviceType.ID {
+			supported = true
+			break
+		}
+	}
+	if !supported {
+		return NewInvalidInputErrorf("agent type %s does not support service type %s", agent.AgentType.Name, serviceType.ID)
+	}
+
+	if agent.Draining {
+		return NewInvalidInputErrorf("agent %s is draining and does not accept new services", agent.ID)
+	}
+
+	if missing := agent.MissingCapabilities(serviceType.RequiredCapabilities); len(missing) > 0 {
+		return NewInvalidInputErrorf("agent %s is missing required capabilities: %s", agent.ID, strings.Join(missing, ", "))
+	}
+
+	if requiredRegion != nil && agent.Region != *requiredRegion {
+		return InvalidInputError{Err: fmt.Errorf("%w %s: agent %s runs in region %q", ErrNoAgentInRegion, *requiredRegion, agent.ID, agent.Region)}
+	}
+	return nil
+}
+
+// newServiceCreateSchemaContext builds the schema context used to process the properties of a new service
+func newServiceCreateSchemaContext(ctx context.Context, store Store, agent *Agent, svc *Service) ServicePropertyContext {
+	// Extract actor from auth context
+	identity := auth.MustGetIdentity(ctx)
+	return ServicePropertyContext{
+		Actor:            ActorTypeFromAuthRole(identity.Role),
+		Store:            store,
+		ProviderID:       agent.ProviderID,
+		ConsumerID:       svc.ConsumerID,
+		GroupID:          svc.GroupID,
+		ServicePoolSetID: agent.ServicePoolSetID,
+		ServiceID:        &svc.ID,
+		ServiceStatus:    "", // empty during create
+	}
+}
+
+func (s *serviceCommander) Update(ctx context.Context, params UpdateServiceParams) (*Service, error) {
+	return UpdateService(ctx, s.store, s.engine, params, s.retry)
+}
+
+func UpdateService(ctx context.Context, store Store, engine *schema.Engine[ServicePropertyContext], params UpdateServiceParams, retry JobRetryPolicy) (*Service, error) {
+	svc, _, err := updateService(ctx, store, engine, params, retry, false)
+	if err != nil {
+		return nil, err
+	}
+	return svc, nil
+}
+
+// updateService applies an update and describes it in the returned preview, filled as far as the update went
+// With dryRun the whole update runs in a transaction that is rolled back with errDryRun, so the preview of
+// an update goes through the very same checks and mode determination as its application
+func updateService(
+	ctx context.Context,
+	store Store,
+	engine *schema.Engine[ServicePropertyContext],
+	params UpdateServiceParams,
+	retry JobRetryPolicy,
+	dryRun bool,
+) (*Service, *ServiceUpdatePreview, error) {
+	// Find it
+	svc, err := store.ServiceRepo().Get(ctx, params.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if svc.Maintenance {
+		return nil, nil, NewMaintenanceError(svc.ID)
+	}
+	if params.RequiredRegion != nil && (svc.RequiredRegion == nil || *svc.RequiredRegion != *params.RequiredRegion) {
+		return nil, nil, NewInvalidInputErrorf("the region of service %s cannot be changed after its creation", svc.ID)
+	}
+
+	// Load ServiceType to get property schema and lifecycle
+	serviceType, err := store.ServiceTypeRepo().Get(ctx, svc.ServiceTypeID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Load agent to get pool set (needed for context, even if not updating properties)
+	agent, err := store.AgentRepo().Get(ctx, svc.AgentID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Extract actor from auth context (needed for context)
+	identity := auth.MustGetIdentity(ctx)
+	actor := ActorTypeFromAuthRole(identity.Role)
+
+	// Properties repeating the current values are not updated
+	if params.Properties != nil {
+		params.Properties, err = ChangedServiceProperties(svc.Properties, *params.Properties)
+		if err != nil {
+			return nil, nil, InvalidInputError{Err: err}
+		}
+	}
+
+	// The most disruptive mode among the changed properties selects the update action
+	updateAction := ServiceActionUpdate
+	preview := &ServiceUpdatePreview{Name: svc.Name}
+	if params.Properties != nil {
+		preview.UpdateMode = serviceType.PropertySchema.RequiredUpdateMode(*params.Properties)
+		preview.ChangedProperties = slices.Sorted(maps.Keys(*params.Properties))
+		updateAction = UpdateActionForMode(preview.UpdateMode)
+	}
+
+	// Update, if needed
+	originalSvc := *svc
+	update, action, err := svc.Update(params.Name, params.Properties)
+	if err != nil {
+		return nil, preview, err
+	}
+	preview.Name = svc.Name
+	if params.IdleTimeout != nil && svc.SetIdleTimeout(*params.IdleTimeout) {
+		update = true
+	}
+	if err := svc.Validate(); err != nil {
+		return nil, preview, InvalidInputError{Err: err}
+	}
+	if svc.Name != originalSvc.Name {
+		if err := checkServiceNameAvailable(ctx, store, svc); err != nil {
+			return nil, preview, err
+		}
+	}
+
+	// Save, event and create job
+	err = store.Atomic(ctx, func(txStore Store) error {
+		// Validate and process properties if provided WITHIN transaction
+		if params.Properties != nil {
+			// Build schema context with transactional store
+			schemaCtx := ServicePropertyContext{
+				Actor:            actor,
+				Store:            txStore, // Use transactional store
+				ProviderID:       svc.ProviderID,
+				ConsumerID:       svc.ConsumerID,
+				GroupID:          svc.GroupID,
+				ServicePoolSetID: agent.ServicePoolSetID,
+				ServiceID:        &svc.ID,
+				ServiceStatus:    svc.Status,
+			}
 
 			// Convert existing properties to map
 			oldProperties := map[string]any(*svc.Properties)
@@ -448,6 +1117,15 @@ func UpdateService(ctx context.Context, store Store, engine *schema.Engine[Servi
 			}
 			convertedProperties := properties.JSON(validatedProperties)
 			params.Properties = &convertedProperties
+			if preview.Diff, err = servicePropertiesDiff(serviceType.PropertySchema, oldProperties, validatedProperties); err != nil {
+				return err
+			}
+
+			// The properties now conform to the current schema
+			if svc.SchemaVersion != serviceType.SchemaVersion {
+				svc.SchemaVersion = serviceType.SchemaVersion
+				update = true
+			}
 		}
 		if update {
 			if err := txStore.ServiceRepo().Save(ctx, svc); err != nil {
@@ -468,7 +1146,7 @@ func UpdateService(ctx context.Context, store Store, engine *schema.Engine[Servi
 			}
 
 			// Check if the service is in a valid state to be updated with a job
-			if err := serviceType.LifecycleSchema.ValidateActionAllowed(svc.Status, "update"); err != nil {
+			if err := serviceType.LifecycleSchema.ValidateActionAllowed(svc.Status, updateAction); err != nil {
 				return InvalidInputError{Err: err}
 			}
 
@@ -478,35 +1156,319 @@ func UpdateService(ctx context.Context, store Store, engine *schema.Engine[Servi
 				return err
 			}
 
+			// An immediate update supersedes any scheduled action
+			if err := cancelScheduledJobs(ctx, txStore, svc, updateAction); err != nil {
+				return err
+			}
+
 			// Create new job
-			job := NewJob(svc, "update", params.Properties, 1)
+			job := NewJob(svc, updateAction, params.Properties, DefaultJobPriority(updateAction))
+			if job.Attempt, err = nextJobAttempt(ctx, txStore, svc, updateAction); err != nil {
+				return err
+			}
+			job.DeferRetry(retry, time.Now())
 			if err := job.Validate(); err != nil {
 				return err
 			}
 			if err := txStore.JobRepo().Create(ctx, job); err != nil {
 				return err
 			}
+			// The transition exists since the action is allowed
+			preview.Action = updateAction
+			preview.NextStatus, _ = serviceType.LifecycleSchema.ResolveNextState(svc.Status, updateAction, nil)
+		}
+		if dryRun {
+			return errDryRun
 		}
 		return nil
 	})
+	if dryRun && errors.Is(err, errDryRun) {
+		// The secrets stored for the new values are not referenced once the update is rolled back
+		if params.Properties != nil {
+			engine.CleanupAddedVaultSecrets(ctx, *originalSvc.Properties, *params.Properties)
+		}
+		return svc, preview, nil
+	}
+	if err != nil {
+		return nil, preview, err
+	}
+
+	return svc, preview, nil
+}
+
+// PatchServiceProperties applies a JSON Patch to the service properties and returns the
+// top level properties it changed, to be used as partial properties of an update
+// Returns nil when the patch changes nothing, removing a top level property is not supported
+func PatchServiceProperties(current *properties.JSON, ops []properties.JSONPatchOperation) (*properties.JSON, error) {
+	var currentMap map[string]any
+	if current != nil {
+		currentMap = *current
+	}
+	// Applying no operations normalizes the current values for the comparison
+	before, err := properties.ApplyJSONPatch(currentMap, nil)
 	if err != nil {
 		return nil, err
 	}
+	after, err := properties.ApplyJSONPatch(currentMap, ops)
+	if err != nil {
+		return nil, InvalidInputError{Err: err}
+	}
 
-	return svc, nil
+	for _, name := range slices.Sorted(maps.Keys(before)) {
+		if _, ok := after[name]; !ok {
+			return nil, NewInvalidInputErrorf("patch operation %d: removing property %s is not supported", lastPatchOperationOn(ops, name), name)
+		}
+	}
+
+	return changedProperties(before, after), nil
+}
+
+// ChangedServiceProperties returns the top level properties of a partial update whose values
+// differ from the current ones, nil when the update changes nothing
+func ChangedServiceProperties(current *properties.JSON, update properties.JSON) (*properties.JSON, error) {
+	var currentMap map[string]any
+	if current != nil {
+		currentMap = *current
+	}
+	// Applying no operations normalizes the values for the comparison
+	before, err := properties.ApplyJSONPatch(currentMap, nil)
+	if err != nil {
+		return nil, err
+	}
+	after, err := properties.ApplyJSONPatch(update, nil)
+	if err != nil {
+		return nil, err
+	}
+	return changedProperties(before, after), nil
+}
+
+// changedProperties returns the top level properties of after differing from before, nil when none
+func changedProperties(before, after map[string]any) *properties.JSON {
+	changed := make(properties.JSON)
+	for name, value := range after {
+		if !reflect.DeepEqual(before[name], value) {
+			changed[name] = value
+		}
+	}
+	if len(changed) == 0 {
+		return nil
+	}
+	return &changed
+}
+
+// lastPatchOperationOn returns the index of the last operation touching the top level property
+func lastPatchOperationOn(ops []properties.JSONPatchOperation, name string) int {
+	for i := len(ops) - 1; i >= 0; i-- {
+		for _, pointer := range []string{ops[i].Path, ops[i].From} {
+			tokens, err := properties.ParseJSONPointer(pointer)
+			if err == nil && len(tokens) > 0 && tokens[0] == name {
+				return i
+			}
+		}
+	}
+	return len(ops) - 1
+}
+
+func (s *serviceCommander) StateMachine(ctx context.Context, serviceTypeID properties.UUID) ([]LifecycleStateNode, error) {
+	serviceType, err := s.store.ServiceTypeRepo().Get(ctx, serviceTypeID)
+	if err != nil {
+		return nil, err
+	}
+	return serviceType.LifecycleSchema.StateMachine(), nil
 }
 
 func (s *serviceCommander) DoAction(ctx context.Context, params DoServiceActionParams) (*Service, error) {
-	return DoServiceAction(ctx, s.store, params)
+	return DoServiceAction(ctx, s.store, params, s.retry)
+}
+
+// DoServiceAction validates the action and creates its job in a single transaction, the service is locked
+// so a concurrent action sees the job and is refused
+func DoServiceAction(ctx context.Context, store Store, params DoServiceActionParams, retry JobRetryPolicy) (*Service, error) {
+	var svc *Service
+	err := store.Atomic(ctx, func(store Store) error {
+		if err := store.ServiceRepo().LockForUpdate(ctx, []properties.UUID{params.ID}); err != nil {
+			return err
+		}
+		var err error
+		if svc, err = validateServiceAction(ctx, store, params); err != nil {
+			return err
+		}
+		return createServiceActionJob(ctx, store, svc, params, retry)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return svc, nil
+}
+
+func (s *serviceCommander) CancelOperation(ctx context.Context, id properties.UUID) (*Service, error) {
+	svc, err := s.store.ServiceRepo().Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	// A service in maintenance is frozen
+	if svc.Maintenance {
+		return nil, NewMaintenanceError(svc.ID)
+	}
+
+	// A service is in transition only while its single active job runs
+	job, err := s.store.JobRepo().GetLastJobForService(ctx, svc.ID)
+	if err != nil {
+		return nil, err
+	}
+	if job == nil || !job.IsActive() {
+		return nil, NewInvalidInputErrorf("service %s has no operation in progress", svc.ID)
+	}
+
+	// The service status only moves when a job completes, so it's still the last stable state
+	status := job.Status
+	err = s.store.Atomic(ctx, func(store Store) error {
+		if err := job.Cancel("operation cancelled by user"); err != nil {
+			return InvalidInputError{Err: err}
+		}
+		// The agent may have reported the outcome or the job timed out since it was read
+		saved, err := store.JobRepo().SaveIfStatus(ctx, job, status)
+		if err != nil {
+			return err
+		}
+		if !saved {
+			return NewConflictErrorf("job %s has changed status", job.ID)
+		}
+
+		eventEntry, err := NewEvent(EventTypeServiceOperationCancelled, WithInitiatorCtx(ctx), WithService(svc))
+		if err != nil {
+			return err
+		}
+		eventEntry.Payload = properties.JSON{
+			"jobId":  job.ID,
+			"action": job.Action,
+		}
+		return store.EventRepo().Create(ctx, eventEntry)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return svc, nil
+}
+
+func (s *serviceCommander) BatchAction(ctx context.Context, params BatchServiceActionParams) ([]BatchServiceActionResult, error) {
+	return BatchServiceAction(ctx, s.store, params, s.retry)
+}
+
+// BatchServiceAction validates every action before creating any job, then creates all the jobs,
+// in a single transaction holding the locks of the services so concurrent actions cannot create
+// other active jobs in between. When at least one item is not valid, nothing is committed and
+// the per-item results report the failures.
+func BatchServiceAction(ctx context.Context, store Store, params BatchServiceActionParams, retry JobRetryPolicy) ([]BatchServiceActionResult, error) {
+	if len(params.Items) == 0 {
+		return nil, NewInvalidInputErrorf("batch must contain at least one item")
+	}
+
+	// Two actions on the same service would create two concurrent jobs
+	seen := make(map[properties.UUID]bool, len(params.Items))
+	for _, item := range params.Items {
+		if seen[item.ID] {
+			return nil, NewConflictErrorf("service %s appears more than once in the batch", item.ID)
+		}
+		seen[item.ID] = true
+	}
+
+	ids := make([]properties.UUID, 0, len(params.Items))
+	for _, item := range params.Items {
+		ids = append(ids, item.ID)
+	}
+
+	results := make([]BatchServiceActionResult, len(params.Items))
+	failed := 0
+	err := store.Atomic(ctx, func(store Store) error {
+		if err := store.ServiceRepo().LockForUpdate(ctx, ids); err != nil {
+			return err
+		}
+		for i, item := range params.Items {
+			svc, err := validateServiceAction(ctx, store, item)
+			results[i] = BatchServiceActionResult{ID: item.ID, Service: svc, Err: err}
+			if err != nil {
+				failed++
+			}
+		}
+		if failed > 0 {
+			return NewInvalidInputErrorf("%d of %d batch actions are not valid", failed, len(params.Items))
+		}
+		for i, item := range params.Items {
+			if err := createServiceActionJob(ctx, store, results[i].Service, item, retry); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		if failed > 0 {
+			return results, err
+		}
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// createServiceActionJob creates the job of an action, deferred when a schedule time is given.
+// An immediate action supersedes the scheduled ones, which are cancelled. A job retrying a failed
+// action is deferred by the backoff of its attempt.
+func createServiceActionJob(ctx context.Context, store Store, svc *Service, params DoServiceActionParams, retry JobRetryPolicy) error {
+	job := NewJob(svc, params.Action, nil, DefaultJobPriority(params.Action))
+	attempt, err := nextJobAttempt(ctx, store, svc, params.Action)
+	if err != nil {
+		return err
+	}
+	job.Attempt = attempt
+	if params.ScheduledAt != nil {
+		if err := job.Schedule(*params.ScheduledAt); err != nil {
+			return InvalidInputError{Err: err}
+		}
+	} else if err := cancelScheduledJobs(ctx, store, svc, params.Action); err != nil {
+		return err
+	}
+	job.DeferRetry(retry, time.Now())
+	if err := job.Validate(); err != nil {
+		return err
+	}
+	return store.JobRepo().Create(ctx, job)
+}
+
+// cancelScheduledJobs cancels the scheduled jobs of a service superseded by an immediate action
+func cancelScheduledJobs(ctx context.Context, store Store, svc *Service, action string) error {
+	jobs, err := store.JobRepo().GetScheduledJobsForService(ctx, svc.ID)
+	if err != nil {
+		return err
+	}
+	for _, job := range jobs {
+		reason := fmt.Sprintf("scheduled job cancelled: action %q was requested on the service before the scheduled time", action)
+		if err := job.CancelSchedule(reason); err != nil {
+			return err
+		}
+		if err := store.JobRepo().Save(ctx, job); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
-func DoServiceAction(ctx context.Context, store Store, params DoServiceActionParams) (*Service, error) {
+// validateServiceAction loads the service and checks the action is allowed by its lifecycle
+func validateServiceAction(ctx context.Context, store Store, params DoServiceActionParams) (*Service, error) {
 	// Find it
 	svc, err := store.ServiceRepo().Get(ctx, params.ID)
 	if err != nil {
 		return nil, err
 	}
 
+	// A service in maintenance is frozen
+	if svc.Maintenance {
+		return nil, NewMaintenanceError(svc.ID)
+	}
+
 	// Load ServiceType to get lifecycle schema
 	serviceType, err := store.ServiceTypeRepo().Get(ctx, svc.ServiceTypeID)
 	if err != nil {
@@ -523,24 +1485,13 @@ func DoServiceAction(ctx context.Context, store Store, params DoServiceActionPar
 		return nil, InvalidInputError{Err: err}
 	}
 
-	// If pending job exists, fail it
-	err = checkHasNotActiveJob(ctx, store, svc)
-	if err != nil {
+	// Check the services it depends on are running when starting it, and the ones depending on it are not when stopping it
+	if err := checkServiceDependencies(ctx, store, svc, serviceType.LifecycleSchema, params.Action); err != nil {
 		return nil, err
 	}
 
-	// Create the new job
-	err = store.Atomic(ctx, func(store Store) error {
-		job := NewJob(svc, params.Action, nil, 1)
-		if err := job.Validate(); err != nil {
-			return err
-		}
-		if err := store.JobRepo().Create(ctx, job); err != nil {
-			return err
-		}
-		return err
-	})
-	if err != nil {
+	// If pending job exists, fail it
+	if err := checkHasNotActiveJob(ctx, store, svc); err != nil {
 		return nil, err
 	}
 
@@ -558,42 +1509,440 @@ func checkHasNotActiveJob(ctx context.Context, store Store, svc *Service) error
 	return nil
 }
 
-func (s *serviceCommander) FailTimeoutServicesAndJobs(ctx context.Context, timeout time.Duration) (int, error) {
-	timedOutJobs, err := s.store.JobRepo().GetTimeOutJobs(ctx, timeout)
+// FailTimeoutServicesAndJobs fails the timed out jobs in a single transaction with one update per
+// status change, jobs moved on by their agent or a concurrent pass since they were read are skipped
+// An error rolls back the whole transaction, so no job is reported failed rather than the ones updated before it
+func (s *serviceCommander) FailTimeoutServicesAndJobs(ctx context.Context, timeouts JobTimeouts, maxAttempts int) (int, error) {
+	timedOutJobs, err := s.store.JobRepo().GetTimeOutJobs(ctx, timeouts)
 	if err != nil {
 		return 0, fmt.Errorf("failed to retrive timeout jobs: %v", err)
 	}
+	if len(timedOutJobs) == 0 {
+		return 0, nil
+	}
 
-	counter := 0
+	// Group the jobs by their current and new status, in the order they were read
+	type statusChange struct {
+		from JobStatus
+		to   JobStatus
+	}
+	var changes []statusChange
+	groups := make(map[statusChange][]*Job)
 	errorMsg := "Job marked as failed due to exceeding maximum processing time"
+	now := time.Now()
 	for _, job := range timedOutJobs {
-		// Update job to failed
+		from := job.Status
 		job.Status = JobFailed
 		job.ErrorMessage = errorMsg
-		now := time.Now()
 		job.CompletedAt = &now
-		if err := s.store.JobRepo().Save(ctx, job); err != nil {
+		if job.ShouldDeadLetter(maxAttempts) {
+			if err := job.DeadLetter(); err != nil {
+				return 0, err
+			}
+		}
+		change := statusChange{from: from, to: job.Status}
+		if _, ok := groups[change]; !ok {
+			changes = append(changes, change)
+		}
+		groups[change] = append(groups[change], job)
+	}
+
+	counter := 0
+	err = s.store.Atomic(ctx, func(store Store) error {
+		counter = 0
+		for _, change := range changes {
+			jobs := groups[change]
+			ids := make([]properties.UUID, len(jobs))
+			for i, job := range jobs {
+				ids[i] = job.ID
+			}
+			// A concurrent maintenance pass or agent report may have already moved some jobs on
+			updated, err := store.JobRepo().FailIfStatus(ctx, ids, change.from, change.to, errorMsg, now)
+			if err != nil {
+				return err
+			}
+			counter += len(updated)
+			if change.to != JobDeadLettered {
+				continue
+			}
+			for _, job := range jobs {
+				if !slices.Contains(updated, job.ID) {
+					continue
+				}
+				if err := createJobDeadLetteredEvent(ctx, store, job); err != nil {
+					return err
+				}
+				if err := failDeadLetteredService(ctx, store, job); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return counter, nil
+}
+
+func (s *serviceCommander) Restore(ctx context.Context, id properties.UUID) (*Service, error) {
+	svc, err := s.store.ServiceRepo().Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !svc.IsDeleted() {
+		return nil, NewInvalidInputErrorf("service %s is not deleted", id)
+	}
+	// The deleted services stay with their consumer when the group is reassigned, they cannot come back
+	// into a group owned by another consumer
+	group, err := s.store.ServiceGroupRepo().Get(ctx, svc.GroupID)
+	if err != nil {
+		var notFound NotFoundError
+		if errors.As(err, &notFound) {
+			return nil, NewInvalidInputErrorf("group %s of service %s no longer exists", svc.GroupID, id)
+		}
+		return nil, err
+	}
+	if group.ConsumerID != svc.ConsumerID {
+		return nil, NewConflictErrorf("group %s of service %s was reassigned to another consumer", group.ID, id)
+	}
+	serviceType, err := s.store.ServiceTypeRepo().Get(ctx, svc.ServiceTypeID)
+	if err != nil {
+		return nil, err
+	}
+
+	// The service must still be placeable on its agent, as when it was created
+	agent, err := s.store.AgentRepo().Get(ctx, svc.AgentID)
+	if err != nil {
+		var notFound NotFoundError
+		if errors.As(err, &notFound) {
+			return nil, NewInvalidInputErrorf("agent %s of service %s no longer exists", svc.AgentID, id)
+		}
+		return nil, err
+	}
+	if agent.Status == AgentDisabled {
+		return nil, NewInvalidInputErrorf("agent %s of service %s is disabled", agent.ID, id)
+	}
+	if err := checkServicePlacement(agent, serviceType, svc.RequiredRegion); err != nil {
+		return nil, err
+	}
+
+	// The backing resource is gone once the agent reused its instance ID for another service
+	if svc.DeletedAgentInstanceID != nil {
+		other, err := s.store.ServiceRepo().FindByAgentInstanceID(ctx, agent.ID, *svc.DeletedAgentInstanceID)
+		if err == nil {
+			return nil, NewConflictErrorf("instance %s of service %s is now used by service %s", *svc.DeletedAgentInstanceID, id, other.ID)
+		}
+		var notFound NotFoundError
+		if !errors.As(err, &notFound) {
+			return nil, err
+		}
+	}
+
+	originalSvc := *svc
+	if err := svc.Restore(s.restoreWindow); err != nil {
+		return nil, InvalidInputError{Err: err}
+	}
+	if err := checkServiceNameAvailable(ctx, s.store, svc); err != nil {
+		return nil, err
+	}
+
+	err = s.store.Atomic(ctx, func(store Store) error {
+		if err := store.ServiceRepo().Save(ctx, svc); err != nil {
+			return err
+		}
+		eventEntry, err := NewEvent(EventTypeServiceRestored, WithInitiatorCtx(ctx), WithDiff(&originalSvc, svc), WithService(svc))
+		if err != nil {
+			return err
+		}
+		return store.EventRepo().Create(ctx, eventEntry)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return svc, nil
+}
+
+func (s *serviceCommander) SetLabels(ctx context.Context, id properties.UUID, changes map[string]*string) (*Service, error) {
+	svc, err := s.store.ServiceRepo().Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if svc.IsDeleted() {
+		return nil, NewInvalidInputErrorf("service %s is deleted", id)
+	}
+
+	originalSvc := *svc
+	changed, err := svc.SetLabels(changes)
+	if err != nil {
+		return nil, InvalidInputError{Err: err}
+	}
+	if !changed {
+		return svc, nil
+	}
+	serviceType, err := s.store.ServiceTypeRepo().Get(ctx, svc.ServiceTypeID)
+	if err != nil {
+		return nil, err
+	}
+	if serviceType.LabelSchema != nil {
+		if err := validateLabelsWithSchema(ctx, s.store, s.engine, svc, *serviceType.LabelSchema); err != nil {
+			return nil, InvalidInputError{Err: err}
+		}
+	}
+
+	err = s.store.Atomic(ctx, func(store Store) error {
+		if err := store.ServiceRepo().Save(ctx, svc); err != nil {
+			return err
+		}
+		eventEntry, err := NewEvent(EventTypeServiceUpdated, WithInitiatorCtx(ctx), WithDiff(&originalSvc, svc), WithService(svc))
+		if err != nil {
+			return err
+		}
+		return store.EventRepo().Create(ctx, eventEntry)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return svc, nil
+}
+
+func (s *serviceCommander) SetDependencies(ctx context.Context, id properties.UUID, dependsOn []properties.UUID) (*Service, error) {
+	svc, err := s.store.ServiceRepo().Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if svc.IsDeleted() {
+		return nil, NewInvalidInputErrorf("service %s is deleted", id)
+	}
+	siblings, err := s.store.ServiceRepo().FindByGroup(ctx, svc.GroupID)
+	if err != nil {
+		return nil, err
+	}
+
+	originalSvc := *svc
+	changed, err := svc.SetDependencies(dependsOn, siblings)
+	if err != nil {
+		return nil, InvalidInputError{Err: err}
+	}
+	if !changed {
+		return svc, nil
+	}
+
+	err = s.store.Atomic(ctx, func(store Store) error {
+		if err := store.ServiceRepo().Save(ctx, svc); err != nil {
+			return err
+		}
+		eventEntry, err := NewEvent(EventTypeServiceUpdated, WithInitiatorCtx(ctx), WithDiff(&originalSvc, svc), WithService(svc))
+		if err != nil {
+			return err
+		}
+		return store.EventRepo().Create(ctx, eventEntry)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return svc, nil
+}
+
+func (s *serviceCommander) SetMaintenance(ctx context.Context, id properties.UUID, enabled bool) (*Service, error) {
+	svc, err := s.store.ServiceRepo().Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if svc.IsDeleted() {
+		return nil, NewInvalidInputErrorf("service %s is deleted", id)
+	}
+
+	originalSvc := *svc
+	if !svc.SetMaintenance(enabled) {
+		return svc, nil
+	}
+
+	eventType := EventTypeServiceMaintenanceDisabled
+	if enabled {
+		eventType = EventTypeServiceMaintenanceEnabled
+	}
+	err = s.store.Atomic(ctx, func(store Store) error {
+		if err := store.ServiceRepo().Save(ctx, svc); err != nil {
+			return err
+		}
+		eventEntry, err := NewEvent(eventType, WithInitiatorCtx(ctx), WithDiff(&originalSvc, svc), WithService(svc))
+		if err != nil {
+			return err
+		}
+		return store.EventRepo().Create(ctx, eventEntry)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return svc, nil
+}
+
+func (s *serviceCommander) Reconcile(ctx context.Context, id properties.UUID, props properties.JSON) (*Service, error) {
+	svc, err := s.store.ServiceRepo().Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if svc.IsDeleted() {
+		return nil, NewInvalidInputErrorf("service %s is deleted", id)
+	}
+	serviceType, err := s.store.ServiceTypeRepo().Get(ctx, svc.ServiceTypeID)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkReconcilableProperties(serviceType.PropertySchema, props); err != nil {
+		return nil, InvalidInputError{Err: err}
+	}
+
+	// Properties repeating the current values are not reconciled
+	changed, err := ChangedServiceProperties(svc.Properties, props)
+	if err != nil {
+		return nil, InvalidInputError{Err: err}
+	}
+	// The reconcile of an orphaned service confirms its resource exists again
+	if changed == nil && !svc.Orphaned {
+		return svc, nil
+	}
+
+	originalSvc := *svc
+	if svc.Properties != nil {
+		originalProps := maps.Clone(*svc.Properties)
+		originalSvc.Properties = &originalProps
+	}
+	svc.ClearOrphaned()
+	err = s.store.Atomic(ctx, func(store Store) error {
+		if changed != nil {
+			if err := ApplyAgentPropertyUpdates(ctx, store, s.engine, svc, serviceType, *changed); err != nil {
+				return InvalidInputError{Err: err}
+			}
+		}
+		if err := store.ServiceRepo().Save(ctx, svc); err != nil {
+			return err
+		}
+		eventEntry, err := NewEvent(EventTypeServiceReconciled, WithInitiatorCtx(ctx), WithDiff(&originalSvc, svc), WithService(svc))
+		if err != nil {
+			return err
+		}
+		return store.EventRepo().Create(ctx, eventEntry)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return svc, nil
+}
+
+// checkReconcilableProperties reports the properties unknown to the schema and those provided by the users,
+// only the properties the users cannot set are reported by the agents
+func checkReconcilableProperties(propertySchema schema.Schema, props properties.JSON) error {
+	var details []schema.ValidationErrorDetail
+	for _, name := range slices.Sorted(maps.Keys(props)) {
+		def, ok := propertySchema.Properties[name]
+		switch {
+		case !ok:
+			details = append(details, schema.ValidationErrorDetail{Path: name, Message: "unknown property"})
+		case !isAgentSourcedProperty(def):
+			details = append(details, schema.ValidationErrorDetail{Path: name, Message: "property is provided by users and cannot be reconciled"})
+		}
+	}
+	if len(details) > 0 {
+		return schema.NewValidationError(details)
+	}
+	return nil
+}
+
+func (s *serviceCommander) PurgeDeletedServices(ctx context.Context) (int, error) {
+	services, err := s.store.ServiceRepo().FindDeletedBefore(ctx, time.Now().Add(-s.restoreWindow))
+	if err != nil {
+		return 0, fmt.Errorf("failed to retrieve deleted services: %w", err)
+	}
+
+	counter := 0
+	for _, svc := range services {
+		// Pool values and secrets were kept for a restore, they are released with the service
+		err := s.store.Atomic(ctx, func(store Store) error {
+			if err := store.ServicePoolValueRepo().ReleaseByService(ctx, svc.ID); err != nil {
+				return fmt.Errorf("failed to release pool values: %w", err)
+			}
+			if err := store.JobRepo().DeleteByService(ctx, svc.ID); err != nil {
+				return err
+			}
+			return store.ServiceRepo().Delete(ctx, svc.ID)
+		})
+		if err != nil {
 			return counter, err
 		}
+		// Best-effort, as for the other secret cleanups
+		if svc.Properties != nil {
+			s.engine.CleanupVaultSecrets(ctx, map[string]any(*svc.Properties))
+		}
 		counter++
 	}
 
 	return counter, nil
 }
 
+func (s *serviceCommander) PromoteScheduledJobs(ctx context.Context) (int, error) {
+	dueJobs, err := s.store.JobRepo().GetDueScheduledJobs(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to retrieve due scheduled jobs: %v", err)
+	}
+
+	counter := 0
+	for _, job := range dueJobs {
+		// The service may have moved on since the action was scheduled, a service in maintenance keeps it for later
+		_, err := validateServiceAction(ctx, s.store, DoServiceActionParams{ID: job.ServiceID, Action: job.Action})
+		if errors.As(err, &MaintenanceError{}) {
+			continue
+		}
+		if err != nil {
+			err = job.CancelSchedule(fmt.Sprintf("scheduled job cancelled: %v", err))
+		} else {
+			err = job.Promote()
+		}
+		if err != nil {
+			return counter, err
+		}
+		// A concurrent maintenance pass may have already promoted the job, which may even be claimed since
+		saved, err := s.store.JobRepo().SaveIfStatus(ctx, job, JobScheduled)
+		if err != nil {
+			return counter, err
+		}
+		if saved && job.Status == JobPending {
+			counter++
+		}
+	}
+
+	return counter, nil
+}
+
 // ServiceRepository defines the interface for the Service repository
 type ServiceRepository interface {
 	ServiceQuerier
 	BaseEntityRepository[Service]
+
+	// UpdateSchemaVersion records the property schema version of the services without changing anything else
+	UpdateSchemaVersion(ctx context.Context, ids []properties.UUID, schemaVersion int) error
+
+	// FindDeletedBefore retrieves the services soft-deleted before the given time
+	FindDeletedBefore(ctx context.Context, before time.Time) ([]*Service, error)
+
+	// FindIdle retrieves the active services with an idle timeout and without a job completed, or created, for longer than it
+	FindIdle(ctx context.Context, at time.Time) ([]*Service, error)
+
+	// LockForUpdate locks the rows of the services until the end of the transaction, in the order of their IDs
+	LockForUpdate(ctx context.Context, ids []properties.UUID) error
 }
 
 // ServiceQuerier defines the interface for the Service read-only queries
 type ServiceQuerier interface {
 	BaseEntityQuerier[Service]
 
-	// FindByAgentInstanceID retrieves a service by its agent instance ID and agent ID
+	// FindByAgentInstanceID retrieves the active service of an agent with the given agent instance ID
 	FindByAgentInstanceID(ctx context.Context, agentID properties.UUID, agentInstanceID string) (*Service, error)
 
+	// FindByGroupAndName retrieves the active service of a group with the given name
+	FindByGroupAndName(ctx context.Context, groupID properties.UUID, name string) (*Service, error)
+
 	// CountByGroup returns the number of services in a specific group
 	CountByGroup(ctx context.Context, groupID properties.UUID) (int64, error)
 
@@ -602,4 +1951,13 @@ type ServiceQuerier interface {
 
 	// CountByServiceType returns the number of services of a specific type
 	CountByServiceType(ctx context.Context, serviceTypeID properties.UUID) (int64, error)
+
+	// CountByServiceTypeAndStatus returns the number of active services grouped by service type name and status
+	CountByServiceTypeAndStatus(ctx context.Context) ([]StatusCount, error)
+
+	// FindByServiceType retrieves the active services of a specific type
+	FindByServiceType(ctx context.Context, serviceTypeID properties.UUID) ([]*Service, error)
+
+	// FindByGroup retrieves the active services of a group with their service type
+	FindByGroup(ctx context.Context, groupID properties.UUID) ([]*Service, error)
 }
@@ -0,0 +1,171 @@
+package domain
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/fulcrumproject/core/pkg/properties"
+	"github.com/fulcrumproject/core/pkg/schema"
+)
+
+// CostEstimationStrategy names the policy estimating the cost of the services before they are created
+type CostEstimationStrategy string
+
+// Supported cost estimation strategies
+const (
+	CostEstimationCatalog  CostEstimationStrategy = "catalog"
+	CostEstimationDisabled CostEstimationStrategy = "disabled"
+)
+
+// Kinds of the items of a cost estimate
+const (
+	CostItemOption   = "option"
+	CostItemProperty = "property"
+)
+
+// Validate ensures the cost estimation strategy is supported
+func (s CostEstimationStrategy) Validate() error {
+	switch s {
+	case CostEstimationCatalog, CostEstimationDisabled:
+		return nil
+	}
+	return fmt.Errorf("invalid cost estimation strategy %q: must be %s or %s", s, CostEstimationCatalog, CostEstimationDisabled)
+}
+
+// CostEstimateInput is a service creation validated like a creation, the estimators price it
+type CostEstimateInput struct {
+	ServiceType *ServiceType
+	// Options selected on the creation, in the order they were selected
+	Options []*ServiceOption
+	// Properties of the service as they would be created, with the options and defaults merged in
+	Properties properties.JSON
+}
+
+// CostEstimateItem is a line of the breakdown of a cost estimate
+type CostEstimateItem struct {
+	Kind     string
+	Name     string
+	OptionID *properties.UUID
+	Quantity float64
+	UnitCost float64
+	Cost     float64
+}
+
+// CostEstimate is the estimated cost of a service and its breakdown
+type CostEstimate struct {
+	Total float64
+	Items []CostEstimateItem
+}
+
+// CostEstimator estimates the cost of a service before it is created
+type CostEstimator interface {
+	// Estimate returns the cost of the service creation, the input already passed the creation validation
+	Estimate(ctx context.Context, input CostEstimateInput) (*CostEstimate, error)
+}
+
+// NewCostEstimator returns the estimator implementing the strategy
+func NewCostEstimator(strategy CostEstimationStrategy) (CostEstimator, error) {
+	switch strategy {
+	case CostEstimationCatalog:
+		return CatalogCostEstimator{}, nil
+	case CostEstimationDisabled:
+		return DisabledCostEstimator{}, nil
+	}
+	return nil, strategy.Validate()
+}
+
+// CatalogCostEstimator sums the costs defined in the catalog: the cost of each selected service option
+// and, for each property cost of the service type, the value of the numeric property times its unit cost
+type CatalogCostEstimator struct{}
+
+func (CatalogCostEstimator) Estimate(_ context.Context, input CostEstimateInput) (*CostEstimate, error) {
+	estimate := &CostEstimate{Items: []CostEstimateItem{}}
+	add := func(item CostEstimateItem) {
+		item.Cost = item.Quantity * item.UnitCost
+		estimate.Items = append(estimate.Items, item)
+		estimate.Total += item.Cost
+	}
+
+	for _, option := range input.Options {
+		if option.Cost == 0 {
+			continue
+		}
+		id := option.ID
+		add(CostEstimateItem{Kind: CostItemOption, Name: option.Name, OptionID: &id, Quantity: 1, UnitCost: option.Cost})
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(input.ServiceType.PropertyCosts)) {
+		value, ok := input.Properties[name]
+		if !ok || value == nil {
+			continue
+		}
+		quantity, err := costQuantity(value)
+		if err != nil {
+			return nil, fmt.Errorf("property %s: %w", name, err)
+		}
+		add(CostEstimateItem{Kind: CostItemProperty, Name: name, Quantity: quantity, UnitCost: input.ServiceType.PropertyCosts[name]})
+	}
+	return estimate, nil
+}
+
+// costQuantity returns the number of units of a numeric property value
+func costQuantity(value any) (float64, error) {
+	switch v := value.(type) {
+	case float64:
+		return v, nil
+	case float32:
+		return float64(v), nil
+	case int:
+		return float64(v), nil
+	case int64:
+		return float64(v), nil
+	case json.Number:
+		return v.Float64()
+	}
+	return 0, fmt.Errorf("value %v is not a number", value)
+}
+
+// DisabledCostEstimator rejects the estimates, for the deployments without a pricing
+type DisabledCostEstimator struct{}
+
+func (DisabledCostEstimator) Estimate(context.Context, CostEstimateInput) (*CostEstimate, error) {
+	return nil, NewInvalidInputErrorf("cost estimation is disabled")
+}
+
+// EstimateCost estimates the cost of a service creation with the estimator of the commander
+// The creation goes through the same option resolution and validation as a creation first, an invalid
+// creation is rejected with its validation errors instead of being estimated
+func (s *serviceCommander) EstimateCost(ctx context.Context, params CreateServiceWithTagsParams) (*CostEstimate, error) {
+	result, err := s.ValidateCreate(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+	if !result.Valid {
+		return nil, InvalidInputError{Err: schema.NewValidationError(result.Errors)}
+	}
+
+	serviceType, err := s.store.ServiceTypeRepo().Get(ctx, params.ServiceTypeID)
+	if err != nil {
+		return nil, err
+	}
+	options := make([]*ServiceOption, 0, len(params.OptionIDs))
+	for _, id := range params.OptionIDs {
+		if slices.ContainsFunc(options, func(o *ServiceOption) bool { return o.ID == id }) {
+			continue
+		}
+		option, err := s.store.ServiceOptionRepo().Get(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		options = append(options, option)
+	}
+
+	return s.estimator.Estimate(ctx, CostEstimateInput{
+		ServiceType: serviceType,
+		Options:     options,
+		Properties:  result.Properties,
+	})
+}
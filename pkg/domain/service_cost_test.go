@@ -0,0 +1,153 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/fulcrumproject/core/pkg/auth"
+	"github.com/fulcrumproject/core/pkg/helpers"
+	"github.com/fulcrumproject/core/pkg/properties"
+	"github.com/fulcrumproject/core/pkg/schema"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewCostEstimator(t *testing.T) {
+	estimator, err := NewCostEstimator(CostEstimationCatalog)
+	require.NoError(t, err)
+	assert.IsType(t, CatalogCostEstimator{}, estimator)
+
+	estimator, err = NewCostEstimator(CostEstimationDisabled)
+	require.NoError(t, err)
+	_, err = estimator.Estimate(context.Background(), CostEstimateInput{})
+	assert.ErrorAs(t, err, &InvalidInputError{})
+
+	_, err = NewCostEstimator("cheapest")
+	assert.Error(t, err)
+}
+
+func TestCatalogCostEstimator(t *testing.T) {
+	serviceType := &ServiceType{PropertyCosts: map[string]float64{"memory": 0.5, "cpu": 10, "disk": 0.1}}
+	ubuntu := &ServiceOption{BaseEntity: BaseEntity{ID: uuid.New()}, Name: "Ubuntu", Cost: 5}
+	free := &ServiceOption{BaseEntity: BaseEntity{ID: uuid.New()}, Name: "Free tier"}
+
+	t.Run("sums the option and property costs", func(t *testing.T) {
+		estimate, err := CatalogCostEstimator{}.Estimate(context.Background(), CostEstimateInput{
+			ServiceType: serviceType,
+			Options:     []*ServiceOption{ubuntu, free},
+			Properties:  properties.JSON{"cpu": float64(2), "memory": 8, "name": "web"},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 29.0, estimate.Total)
+		assert.Equal(t, []CostEstimateItem{
+			{Kind: CostItemOption, Name: "Ubuntu", OptionID: &ubuntu.ID, Quantity: 1, UnitCost: 5, Cost: 5},
+			{Kind: CostItemProperty, Name: "cpu", Quantity: 2, UnitCost: 10, Cost: 20},
+			{Kind: CostItemProperty, Name: "memory", Quantity: 8, UnitCost: 0.5, Cost: 4},
+		}, estimate.Items)
+	})
+
+	t.Run("nothing to price", func(t *testing.T) {
+		estimate, err := CatalogCostEstimator{}.Estimate(context.Background(), CostEstimateInput{ServiceType: &ServiceType{}})
+		require.NoError(t, err)
+		assert.Zero(t, estimate.Total)
+		assert.Empty(t, estimate.Items)
+	})
+
+	t.Run("non numeric value", func(t *testing.T) {
+		_, err := CatalogCostEstimator{}.Estimate(context.Background(), CostEstimateInput{
+			ServiceType: serviceType,
+			Properties:  properties.JSON{"cpu": "two"},
+		})
+		assert.Error(t, err)
+	})
+}
+
+func TestServiceCommander_EstimateCost(t *testing.T) {
+	ctx := auth.WithIdentity(context.Background(), &auth.Identity{ID: uuid.New(), Role: auth.RoleAdmin})
+	serviceType := &ServiceType{
+		BaseEntity: BaseEntity{ID: uuid.New()},
+		PropertySchema: schema.Schema{Properties: map[string]schema.PropertyDefinition{
+			"name": {Type: "string", Required: true},
+			"cpu":  {Type: "integer", Default: 2},
+			"os":   {Type: "string", Validators: []schema.ValidatorConfig{{Type: "serviceOption", Config: map[string]any{"value": "os"}}}},
+		}},
+		LifecycleSchema: LifecycleSchema{InitialState: "New"},
+		PropertyCosts:   map[string]float64{"cpu": 10},
+	}
+	agent := &Agent{
+		BaseEntity: BaseEntity{ID: uuid.New()},
+		ProviderID: uuid.New(),
+		AgentType:  &AgentType{Name: "vm", ServiceTypes: []ServiceType{*serviceType}},
+	}
+	group := &ServiceGroup{BaseEntity: BaseEntity{ID: uuid.New()}, ConsumerID: uuid.New()}
+	osType := &ServiceOptionType{BaseEntity: BaseEntity{ID: uuid.New()}, Type: "os"}
+	ubuntu := &ServiceOption{
+		BaseEntity:          BaseEntity{ID: uuid.New()},
+		ProviderID:          agent.ProviderID,
+		ServiceOptionTypeID: osType.ID,
+		Name:                "Ubuntu",
+		Value:               "ubuntu-24.04",
+		Enabled:             helpers.BoolPtr(true),
+		Cost:                5,
+	}
+
+	// Only lookups are expected, the estimate creates nothing
+	setup := func(t *testing.T) *MockStore {
+		ms := setupMockStore(t)
+		agentRepo := NewMockAgentRepository(t)
+		groupRepo := NewMockServiceGroupRepository(t)
+		serviceTypeRepo := NewMockServiceTypeRepository(t)
+		serviceRepo := NewMockServiceRepository(t)
+		optionRepo := NewMockServiceOptionRepository(t)
+		optionTypeRepo := NewMockServiceOptionTypeRepository(t)
+		ms.EXPECT().AgentRepo().Return(agentRepo)
+		ms.EXPECT().ServiceGroupRepo().Return(groupRepo)
+		ms.EXPECT().ServiceTypeRepo().Return(serviceTypeRepo)
+		ms.EXPECT().ServiceRepo().Return(serviceRepo)
+		ms.EXPECT().ServiceOptionRepo().Return(optionRepo)
+		ms.EXPECT().ServiceOptionTypeRepo().Return(optionTypeRepo)
+		agentRepo.EXPECT().Get(mock.Anything, agent.ID).Return(agent, nil)
+		groupRepo.EXPECT().Get(mock.Anything, group.ID).Return(group, nil)
+		serviceTypeRepo.EXPECT().Get(mock.Anything, serviceType.ID).Return(serviceType, nil)
+		serviceRepo.EXPECT().FindByGroupAndName(mock.Anything, group.ID, "svc").Return(nil, NewNotFoundErrorf("service not found"))
+		optionRepo.EXPECT().Get(mock.Anything, ubuntu.ID).Return(ubuntu, nil)
+		optionTypeRepo.EXPECT().Get(mock.Anything, osType.ID).Return(osType, nil)
+		optionTypeRepo.EXPECT().FindByType(mock.Anything, "os").Return(osType, nil).Maybe()
+		optionRepo.EXPECT().ListByProviderAndType(mock.Anything, agent.ProviderID, osType.ID).Return([]*ServiceOption{ubuntu}, nil).Maybe()
+		return ms
+	}
+	params := func(props properties.JSON) CreateServiceWithTagsParams {
+		return CreateServiceWithTagsParams{CreateServiceParams: CreateServiceParams{
+			AgentID:       agent.ID,
+			ServiceTypeID: serviceType.ID,
+			GroupID:       group.ID,
+			Name:          "svc",
+			Properties:    props,
+			OptionIDs:     []properties.UUID{ubuntu.ID},
+		}}
+	}
+
+	t.Run("estimates the resolved properties with the defaults", func(t *testing.T) {
+		cmd := NewServiceCommander(setup(t), NewServicePropertyEngine(nil), nil, 0, JobRetryPolicy{}, nil)
+
+		estimate, err := cmd.EstimateCost(ctx, params(properties.JSON{"name": "web"}))
+		require.NoError(t, err)
+		assert.Equal(t, 25.0, estimate.Total)
+		require.Len(t, estimate.Items, 2)
+		assert.Equal(t, CostItemOption, estimate.Items[0].Kind)
+		assert.Equal(t, "cpu", estimate.Items[1].Name)
+		assert.Equal(t, 2.0, estimate.Items[1].Quantity)
+	})
+
+	t.Run("invalid creation is not estimated", func(t *testing.T) {
+		cmd := NewServiceCommander(setup(t), NewServicePropertyEngine(nil), nil, 0, JobRetryPolicy{}, nil)
+
+		_, err := cmd.EstimateCost(ctx, params(properties.JSON{}))
+		var validationErr schema.ValidationError
+		require.True(t, errors.As(err, &validationErr))
+		assert.Equal(t, "name", validationErr.Errors[0].Path)
+	})
+}
@@ -0,0 +1,183 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/fulcrumproject/core/pkg/properties"
+)
+
+// ServiceDependencyGraph is the dependency graph of the services of a group
+type ServiceDependencyGraph struct {
+	GroupID properties.UUID
+	// Services of the group, each one with the dependencies it declares
+	Services []*Service
+	// Order in which the services are started, dependencies first, the stop order is the reverse
+	Order []properties.UUID
+}
+
+// BuildServiceDependencyGraph resolves the dependency graph of the active services of a group
+func BuildServiceDependencyGraph(ctx context.Context, querier ServiceQuerier, groupID properties.UUID) (*ServiceDependencyGraph, error) {
+	services, err := querier.FindByGroup(ctx, groupID)
+	if err != nil {
+		return nil, err
+	}
+	order, err := resolveDependencyOrder(services)
+	if err != nil {
+		return nil, err
+	}
+	return &ServiceDependencyGraph{
+		GroupID:  groupID,
+		Services: services,
+		Order:    order,
+	}, nil
+}
+
+// SetDependencies replaces the dependencies of the service with siblings of its group, given with their current
+// dependencies, and reports whether they changed
+// The dependencies must be other services of the group and must not introduce a cycle
+func (s *Service) SetDependencies(dependsOn []properties.UUID, siblings []*Service) (bool, error) {
+	var deps []properties.UUID
+	for _, id := range dependsOn {
+		if !slices.Contains(deps, id) {
+			deps = append(deps, id)
+		}
+	}
+	for _, id := range deps {
+		if id == s.ID {
+			return false, fmt.Errorf("service %s cannot depend on itself", s.ID)
+		}
+		if !slices.ContainsFunc(siblings, func(sibling *Service) bool { return sibling.ID == id }) {
+			return false, fmt.Errorf("dependency %s is not a service of group %s", id, s.GroupID)
+		}
+	}
+	if slices.Equal(deps, s.DependsOn) {
+		return false, nil
+	}
+
+	// Check the whole group with the new dependencies of the service
+	updated := *s
+	updated.DependsOn = deps
+	graph := make([]*Service, 0, len(siblings)+1)
+	graph = append(graph, &updated)
+	for _, sibling := range siblings {
+		if sibling.ID != s.ID {
+			graph = append(graph, sibling)
+		}
+	}
+	if _, err := resolveDependencyOrder(graph); err != nil {
+		return false, err
+	}
+
+	s.DependsOn = deps
+	return true, nil
+}
+
+// resolveDependencyOrder sorts the services so that each one comes after its dependencies, keeping the given
+// order otherwise, and fails when the dependencies form a cycle
+// The dependencies on services missing from the list, removed since they were set, are ignored
+func resolveDependencyOrder(services []*Service) ([]properties.UUID, error) {
+	byID := make(map[properties.UUID]*Service, len(services))
+	for _, svc := range services {
+		byID[svc.ID] = svc
+	}
+
+	pending := make(map[properties.UUID]int, len(services))
+	dependents := make(map[properties.UUID][]properties.UUID, len(services))
+	for _, svc := range services {
+		for _, dep := range svc.DependsOn {
+			if _, ok := byID[dep]; !ok {
+				continue
+			}
+			pending[svc.ID]++
+			dependents[dep] = append(dependents[dep], svc.ID)
+		}
+	}
+
+	order := make([]properties.UUID, 0, len(services))
+	done := make(map[properties.UUID]bool, len(services))
+	for len(order) < len(services) {
+		progress := false
+		for _, svc := range services {
+			if done[svc.ID] || pending[svc.ID] > 0 {
+				continue
+			}
+			done[svc.ID] = true
+			order = append(order, svc.ID)
+			for _, dependent := range dependents[svc.ID] {
+				pending[dependent]--
+			}
+			progress = true
+		}
+		if !progress {
+			var cycle []string
+			for _, svc := range services {
+				if !done[svc.ID] {
+					cycle = append(cycle, svc.Name)
+				}
+			}
+			return nil, fmt.Errorf("service dependencies form a cycle between %s", strings.Join(cycle, ", "))
+		}
+	}
+	return order, nil
+}
+
+// checkServiceDependencies checks the live status of the services related to the action of a service:
+// an action making the service running requires its dependencies to be running, and an action making it no
+// longer running requires the services depending on it to be no longer running
+// The running states are the ones of the lifecycle of each service type
+func checkServiceDependencies(ctx context.Context, store Store, svc *Service, lifecycle LifecycleSchema, action string) error {
+	next, err := lifecycle.ResolveNextState(svc.Status, action, nil)
+	if err != nil {
+		// The action has only error transitions from this state
+		return nil
+	}
+	running := lifecycle.IsRunningStatus(svc.Status)
+	starting := !running && lifecycle.IsRunningStatus(next)
+	stopping := running && !lifecycle.IsRunningStatus(next)
+	if !stopping && !(starting && len(svc.DependsOn) > 0) {
+		return nil
+	}
+
+	siblings, err := store.ServiceRepo().FindByGroup(ctx, svc.GroupID)
+	if err != nil {
+		return err
+	}
+	for _, sibling := range siblings {
+		var related bool
+		if starting {
+			related = slices.Contains(svc.DependsOn, sibling.ID)
+		} else {
+			related = slices.Contains(sibling.DependsOn, svc.ID)
+		}
+		if !related {
+			continue
+		}
+		siblingRunning, err := isServiceRunning(ctx, store, sibling)
+		if err != nil {
+			return err
+		}
+		if starting && !siblingRunning {
+			return NewInvalidInputErrorf("cannot %s service %s: its dependency %s is %s", action, svc.Name, sibling.Name, sibling.Status)
+		}
+		if stopping && siblingRunning {
+			return NewInvalidInputErrorf("cannot %s service %s: service %s depends on it and is %s", action, svc.Name, sibling.Name, sibling.Status)
+		}
+	}
+	return nil
+}
+
+// isServiceRunning reports whether the status of the service is a running state of its lifecycle
+func isServiceRunning(ctx context.Context, store Store, svc *Service) (bool, error) {
+	serviceType := svc.ServiceType
+	if serviceType == nil {
+		var err error
+		serviceType, err = store.ServiceTypeRepo().Get(ctx, svc.ServiceTypeID)
+		if err != nil {
+			return false, err
+		}
+	}
+	return serviceType.LifecycleSchema.IsRunningStatus(svc.Status), nil
+}
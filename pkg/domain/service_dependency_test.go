@@ -0,0 +1,215 @@
+package domain
+
+import (
+	"context"
+	"testing"
+
+	"github.com/fulcrumproject/core/pkg/auth"
+	"github.com/fulcrumproject/core/pkg/properties"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestService_SetDependencies(t *testing.T) {
+	groupID := uuid.New()
+	newService := func(name string, dependsOn ...properties.UUID) *Service {
+		return &Service{BaseEntity: BaseEntity{ID: uuid.New()}, Name: name, GroupID: groupID, DependsOn: dependsOn}
+	}
+
+	t.Run("sets the dependencies without duplicates", func(t *testing.T) {
+		db, cache, app := newService("db"), newService("cache"), newService("app")
+
+		changed, err := app.SetDependencies([]properties.UUID{db.ID, cache.ID, db.ID}, []*Service{db, cache, app})
+		require.NoError(t, err)
+		assert.True(t, changed)
+		assert.Equal(t, []properties.UUID{db.ID, cache.ID}, app.DependsOn)
+
+		changed, err = app.SetDependencies([]properties.UUID{db.ID, cache.ID}, []*Service{db, cache, app})
+		require.NoError(t, err)
+		assert.False(t, changed)
+	})
+
+	t.Run("removes the dependencies", func(t *testing.T) {
+		db := newService("db")
+		app := newService("app", db.ID)
+
+		changed, err := app.SetDependencies(nil, []*Service{db, app})
+		require.NoError(t, err)
+		assert.True(t, changed)
+		assert.Nil(t, app.DependsOn)
+	})
+
+	t.Run("self dependency", func(t *testing.T) {
+		app := newService("app")
+
+		_, err := app.SetDependencies([]properties.UUID{app.ID}, []*Service{app})
+		assert.ErrorContains(t, err, "cannot depend on itself")
+	})
+
+	t.Run("service outside of the group", func(t *testing.T) {
+		app := newService("app")
+
+		_, err := app.SetDependencies([]properties.UUID{uuid.New()}, []*Service{app})
+		assert.ErrorContains(t, err, "is not a service of group")
+		assert.Nil(t, app.DependsOn)
+	})
+
+	t.Run("cycle", func(t *testing.T) {
+		db := newService("db")
+		app := newService("app", db.ID)
+		web := newService("web", app.ID)
+
+		_, err := db.SetDependencies([]properties.UUID{web.ID}, []*Service{db, app, web})
+		assert.ErrorContains(t, err, "cycle")
+		assert.Nil(t, db.DependsOn)
+	})
+}
+
+func TestResolveDependencyOrder(t *testing.T) {
+	db := &Service{BaseEntity: BaseEntity{ID: uuid.New()}, Name: "db"}
+	cache := &Service{BaseEntity: BaseEntity{ID: uuid.New()}, Name: "cache"}
+	app := &Service{BaseEntity: BaseEntity{ID: uuid.New()}, Name: "app", DependsOn: []properties.UUID{db.ID, cache.ID}}
+	web := &Service{BaseEntity: BaseEntity{ID: uuid.New()}, Name: "web", DependsOn: []properties.UUID{app.ID, uuid.New()}}
+
+	order, err := resolveDependencyOrder([]*Service{web, app, db, cache})
+	require.NoError(t, err)
+	assert.Equal(t, []properties.UUID{db.ID, cache.ID, app.ID, web.ID}, order)
+}
+
+func TestDoServiceAction_Dependencies(t *testing.T) {
+	ctx := context.Background()
+
+	serviceType := &ServiceType{
+		BaseEntity: BaseEntity{ID: uuid.New()},
+		LifecycleSchema: LifecycleSchema{
+			States:        []LifecycleState{{Name: "Started"}, {Name: "Stopped"}},
+			InitialState:  "Stopped",
+			RunningStates: []string{"Started"},
+			Actions: []LifecycleAction{
+				{Name: "start", Transitions: []LifecycleTransition{{From: "Stopped", To: "Started"}}},
+				{Name: "stop", Transitions: []LifecycleTransition{{From: "Started", To: "Stopped"}}},
+			},
+		},
+	}
+	groupID := uuid.New()
+	newService := func(name, status string, dependsOn ...properties.UUID) *Service {
+		return &Service{
+			BaseEntity:    BaseEntity{ID: uuid.New()},
+			Name:          name,
+			Status:        status,
+			GroupID:       groupID,
+			AgentID:       uuid.New(),
+			ServiceTypeID: serviceType.ID,
+			ServiceType:   serviceType,
+			DependsOn:     dependsOn,
+		}
+	}
+
+	setup := func(t *testing.T, svc *Service, siblings ...*Service) (*MockStore, *MockJobRepository) {
+		ms := setupMockStore(t)
+		serviceRepo := NewMockServiceRepository(t)
+		serviceTypeRepo := NewMockServiceTypeRepository(t)
+		jobRepo := NewMockJobRepository(t)
+		ms.EXPECT().ServiceRepo().Return(serviceRepo).Maybe()
+		ms.EXPECT().ServiceTypeRepo().Return(serviceTypeRepo).Maybe()
+		ms.EXPECT().JobRepo().Return(jobRepo).Maybe()
+		serviceRepo.EXPECT().Get(mock.Anything, svc.ID).Return(svc, nil)
+		serviceRepo.EXPECT().LockForUpdate(mock.Anything, []properties.UUID{svc.ID}).Return(nil).Maybe()
+		serviceRepo.EXPECT().FindByGroup(mock.Anything, groupID).Return(append(siblings, svc), nil).Maybe()
+		serviceTypeRepo.EXPECT().Get(mock.Anything, serviceType.ID).Return(serviceType, nil)
+		jobRepo.EXPECT().GetLastJobForService(mock.Anything, svc.ID).Return(nil, nil).Maybe()
+		jobRepo.EXPECT().GetScheduledJobsForService(mock.Anything, svc.ID).Return(nil, nil).Maybe()
+		return ms, jobRepo
+	}
+
+	t.Run("start refused while a dependency is not running", func(t *testing.T) {
+		db := newService("db", "Stopped")
+		app := newService("app", "Stopped", db.ID)
+		ms, _ := setup(t, app, db)
+
+		_, err := DoServiceAction(ctx, ms, DoServiceActionParams{ID: app.ID, Action: "start"}, JobRetryPolicy{})
+		assert.ErrorAs(t, err, &InvalidInputError{})
+		assert.ErrorContains(t, err, "its dependency db is Stopped")
+	})
+
+	t.Run("start allowed once the dependencies are running", func(t *testing.T) {
+		db := newService("db", "Started")
+		app := newService("app", "Stopped", db.ID)
+		ms, jobRepo := setup(t, app, db)
+		jobRepo.EXPECT().Create(mock.Anything, mock.Anything).Return(nil)
+
+		_, err := DoServiceAction(ctx, ms, DoServiceActionParams{ID: app.ID, Action: "start"}, JobRetryPolicy{})
+		require.NoError(t, err)
+	})
+
+	t.Run("start ignores the removed dependencies", func(t *testing.T) {
+		app := newService("app", "Stopped", uuid.New())
+		ms, jobRepo := setup(t, app)
+		jobRepo.EXPECT().Create(mock.Anything, mock.Anything).Return(nil)
+
+		_, err := DoServiceAction(ctx, ms, DoServiceActionParams{ID: app.ID, Action: "start"}, JobRetryPolicy{})
+		require.NoError(t, err)
+	})
+
+	t.Run("stop refused while a dependent is running", func(t *testing.T) {
+		db := newService("db", "Started")
+		app := newService("app", "Started", db.ID)
+		ms, _ := setup(t, db, app)
+
+		_, err := DoServiceAction(ctx, ms, DoServiceActionParams{ID: db.ID, Action: "stop"}, JobRetryPolicy{})
+		assert.ErrorAs(t, err, &InvalidInputError{})
+		assert.ErrorContains(t, err, "service app depends on it and is Started")
+	})
+
+	t.Run("stop allowed once the dependents are stopped", func(t *testing.T) {
+		db := newService("db", "Started")
+		app := newService("app", "Stopped", db.ID)
+		ms, jobRepo := setup(t, db, app)
+		jobRepo.EXPECT().Create(mock.Anything, mock.Anything).Return(nil)
+
+		_, err := DoServiceAction(ctx, ms, DoServiceActionParams{ID: db.ID, Action: "stop"}, JobRetryPolicy{})
+		require.NoError(t, err)
+	})
+}
+
+func TestServiceCommander_SetDependencies(t *testing.T) {
+	ctx := auth.WithIdentity(context.Background(), &auth.Identity{ID: uuid.New(), Role: auth.RoleAdmin})
+	groupID := uuid.New()
+
+	setup := func(t *testing.T, svc *Service, siblings ...*Service) (*MockStore, *MockServiceRepository, *MockEventRepository) {
+		ms := setupMockStore(t)
+		serviceRepo := NewMockServiceRepository(t)
+		eventRepo := NewMockEventRepository(t)
+		ms.EXPECT().ServiceRepo().Return(serviceRepo).Maybe()
+		ms.EXPECT().EventRepo().Return(eventRepo).Maybe()
+		serviceRepo.EXPECT().Get(mock.Anything, svc.ID).Return(svc, nil)
+		serviceRepo.EXPECT().FindByGroup(mock.Anything, groupID).Return(append(siblings, svc), nil).Maybe()
+		return ms, serviceRepo, eventRepo
+	}
+
+	t.Run("sets the dependencies", func(t *testing.T) {
+		db := &Service{BaseEntity: BaseEntity{ID: uuid.New()}, Name: "db", GroupID: groupID}
+		app := &Service{BaseEntity: BaseEntity{ID: uuid.New()}, Name: "app", GroupID: groupID}
+		ms, serviceRepo, eventRepo := setup(t, app, db)
+		serviceRepo.EXPECT().Save(mock.Anything, app).Return(nil)
+		eventRepo.EXPECT().Create(mock.Anything, mock.MatchedBy(func(e *Event) bool {
+			return e.Type == EventTypeServiceUpdated
+		})).Return(nil)
+
+		result, err := NewServiceCommander(ms, nil, nil, 0, JobRetryPolicy{}, nil).SetDependencies(ctx, app.ID, []properties.UUID{db.ID})
+		require.NoError(t, err)
+		assert.Equal(t, []properties.UUID{db.ID}, result.DependsOn)
+	})
+
+	t.Run("cycle is rejected", func(t *testing.T) {
+		db := &Service{BaseEntity: BaseEntity{ID: uuid.New()}, Name: "db", GroupID: groupID}
+		app := &Service{BaseEntity: BaseEntity{ID: uuid.New()}, Name: "app", GroupID: groupID, DependsOn: []properties.UUID{db.ID}}
+		ms, _, _ := setup(t, db, app)
+
+		_, err := NewServiceCommander(ms, nil, nil, 0, JobRetryPolicy{}, nil).SetDependencies(ctx, db.ID, []properties.UUID{app.ID})
+		assert.ErrorAs(t, err, &InvalidInputError{})
+		assert.ErrorContains(t, err, "cycle")
+	})
+}
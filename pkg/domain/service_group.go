@@ -12,6 +12,8 @@ const (
 	EventTypeServiceGroupCreated EventType = "service_group.created"
 	EventTypeServiceGroupUpdated EventType = "service_group.updated"
 	EventTypeServiceGroupDeleted EventType = "service_group.deleted"
+	// Recorded when an admin moves a service group and its services to another consumer
+	EventTypeServiceGroupReassigned EventType = "service_group.reassigned"
 )
 
 // ServiceGroup represents a group of related services
@@ -68,6 +70,9 @@ type ServiceGroupCommander interface {
 
 	// Delete removes a service group by ID after checking for dependencies
 	Delete(ctx context.Context, id properties.UUID) error
+
+	// Reassign moves a service group and all its services to another consumer
+	Reassign(ctx context.Context, id properties.UUID, consumerID properties.UUID) (*ServiceGroup, error)
 }
 
 // serviceGroupCommander is the concrete implementation of ServiceGroupCommander
@@ -150,6 +155,10 @@ func (s *serviceGroupCommander) Update(ctx context.Context, params UpdateService
 	if err := sg.Validate(); err != nil {
 		return nil, InvalidInputError{Err: err}
 	}
+	// Updates repeating the current values save nothing
+	if beforeSgCopy.Name == sg.Name {
+		return sg, CheckExpectedVersion(ctx, sg.ID, sg.Version)
+	}
 
 	// Save and event
 	err = s.store.Atomic(ctx, func(store Store) error {
@@ -187,7 +196,7 @@ func (s *serviceGroupCommander) Delete(ctx context.Context, id properties.UUID)
 		return err
 	}
 	if numOfServices > 0 {
-		return errors.New("cannot delete service group with associated services")
+		return NewConflictErrorf("cannot delete service group with associated services")
 	}
 
 	// Delete and event
@@ -208,6 +217,74 @@ func (s *serviceGroupCommander) Delete(ctx context.Context, id properties.UUID)
 	})
 }
 
+// Reassign moves the group and its active services to another consumer in one transaction
+// The services in a transitional state are moved as well: the consumer is ownership metadata the agents
+// never see, so their jobs in progress complete unaffected. The jobs and the metric entries keep the
+// consumer they were recorded with, and the deleted services stay with the previous consumer, Restore
+// refuses to bring them back into the reassigned group.
+func (s *serviceGroupCommander) Reassign(ctx context.Context, id properties.UUID, consumerID properties.UUID) (*ServiceGroup, error) {
+	// Validate references
+	sg, err := s.store.ServiceGroupRepo().Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	consumer, err := s.store.ParticipantRepo().Get(ctx, consumerID)
+	if err != nil {
+		if errors.As(err, &NotFoundError{}) {
+			return nil, NewInvalidInputErrorf("consumer with ID %s does not exist", consumerID)
+		}
+		return nil, err
+	}
+	if consumer.Status != ParticipantEnabled {
+		return nil, NewInvalidInputErrorf("consumer %s is %s", consumer.Name, consumer.Status)
+	}
+	// Reassigning to the current consumer saves nothing
+	if sg.ConsumerID == consumerID {
+		return sg, CheckExpectedVersion(ctx, sg.ID, sg.Version)
+	}
+
+	beforeSgCopy := *sg
+	sg.ConsumerID = consumerID
+	sg.Participant = consumer
+
+	// Save the group and its services and event
+	err = s.store.Atomic(ctx, func(store Store) error {
+		if err := store.ServiceGroupRepo().Save(ctx, sg); err != nil {
+			return err
+		}
+
+		services, err := store.ServiceRepo().FindByGroup(ctx, id)
+		if err != nil {
+			return err
+		}
+		for _, svc := range services {
+			beforeSvcCopy := *svc
+			svc.ConsumerID = consumerID
+			if err := store.ServiceRepo().Save(ctx, svc); err != nil {
+				return err
+			}
+			eventEntry, err := NewEvent(EventTypeServiceUpdated, WithInitiatorCtx(ctx), WithDiff(&beforeSvcCopy, svc), WithService(svc))
+			if err != nil {
+				return err
+			}
+			if err := store.EventRepo().Create(ctx, eventEntry); err != nil {
+				return err
+			}
+		}
+
+		eventEntry, err := NewEvent(EventTypeServiceGroupReassigned, WithInitiatorCtx(ctx), WithDiff(&beforeSgCopy, sg), WithServiceGroup(sg))
+		if err != nil {
+			return err
+		}
+		return store.EventRepo().Create(ctx, eventEntry)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return sg, nil
+}
+
 // ServiceGroupRepository defines the interface for the ServiceGroup repository
 type ServiceGroupRepository interface {
 	ServiceGroupQuerier
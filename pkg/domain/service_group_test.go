@@ -1,10 +1,15 @@
 package domain
 
 import (
+	"context"
 	"testing"
 
+	"github.com/fulcrumproject/core/pkg/auth"
+	"github.com/fulcrumproject/core/pkg/properties"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 func TestServiceGroup_Validate(t *testing.T) {
@@ -63,3 +68,152 @@ func TestServiceGroup_TableName(t *testing.T) {
 	sg := ServiceGroup{}
 	assert.Equal(t, "service_groups", sg.TableName())
 }
+
+func TestServiceGroupCommander_Update(t *testing.T) {
+	ctx := auth.WithIdentity(context.Background(), &auth.Identity{ID: properties.NewUUID(), Role: auth.RoleAdmin})
+	newGroup := func() *ServiceGroup {
+		return &ServiceGroup{BaseEntity: BaseEntity{ID: properties.NewUUID(), Version: 3}, Name: "web", ConsumerID: properties.NewUUID()}
+	}
+
+	t.Run("saves a new name", func(t *testing.T) {
+		group := newGroup()
+		ms := setupMockStore(t)
+		repo := NewMockServiceGroupRepository(t)
+		repo.EXPECT().Get(mock.Anything, group.ID).Return(group, nil)
+		repo.EXPECT().Save(mock.Anything, group).Return(nil)
+		ms.EXPECT().ServiceGroupRepo().Return(repo)
+		eventRepo := NewMockEventRepository(t)
+		eventRepo.EXPECT().Create(mock.Anything, mock.MatchedBy(func(e *Event) bool {
+			return e.Type == EventTypeServiceGroupUpdated
+		})).Return(nil)
+		ms.EXPECT().EventRepo().Return(eventRepo)
+
+		name := "api"
+		updated, err := NewServiceGroupCommander(ms).Update(ctx, UpdateServiceGroupParams{ID: group.ID, Name: &name})
+		require.NoError(t, err)
+		assert.Equal(t, "api", updated.Name)
+	})
+
+	t.Run("repeating the current name saves nothing", func(t *testing.T) {
+		group := newGroup()
+		ms := NewMockStore(t)
+		repo := NewMockServiceGroupRepository(t)
+		repo.EXPECT().Get(mock.Anything, group.ID).Return(group, nil)
+		ms.EXPECT().ServiceGroupRepo().Return(repo)
+
+		name := "web"
+		updated, err := NewServiceGroupCommander(ms).Update(ctx, UpdateServiceGroupParams{ID: group.ID, Name: &name})
+		require.NoError(t, err)
+		assert.Equal(t, group, updated)
+	})
+
+	t.Run("repeating the current name checks the expected version", func(t *testing.T) {
+		group := newGroup()
+		ms := NewMockStore(t)
+		repo := NewMockServiceGroupRepository(t)
+		repo.EXPECT().Get(mock.Anything, group.ID).Return(group, nil)
+		ms.EXPECT().ServiceGroupRepo().Return(repo)
+
+		name := "web"
+		_, err := NewServiceGroupCommander(ms).Update(WithExpectedVersion(ctx, group.ID, 2), UpdateServiceGroupParams{ID: group.ID, Name: &name})
+		var precondition PreconditionFailedError
+		require.ErrorAs(t, err, &precondition)
+		assert.Equal(t, 3, precondition.CurrentVersion)
+	})
+}
+
+func TestServiceGroupCommander_DeleteWithServices(t *testing.T) {
+	ctx := auth.WithIdentity(context.Background(), &auth.Identity{ID: properties.NewUUID(), Role: auth.RoleAdmin})
+	group := &ServiceGroup{BaseEntity: BaseEntity{ID: properties.NewUUID()}, Name: "web", ConsumerID: properties.NewUUID()}
+	ms := NewMockStore(t)
+	repo := NewMockServiceGroupRepository(t)
+	repo.EXPECT().Get(mock.Anything, group.ID).Return(group, nil)
+	ms.EXPECT().ServiceGroupRepo().Return(repo)
+	serviceRepo := NewMockServiceRepository(t)
+	serviceRepo.EXPECT().CountByGroup(mock.Anything, group.ID).Return(int64(2), nil)
+	ms.EXPECT().ServiceRepo().Return(serviceRepo)
+
+	err := NewServiceGroupCommander(ms).Delete(ctx, group.ID)
+	assert.ErrorAs(t, err, &ConflictError{})
+}
+
+func TestServiceGroupCommander_Reassign(t *testing.T) {
+	ctx := auth.WithIdentity(context.Background(), &auth.Identity{ID: properties.NewUUID(), Role: auth.RoleAdmin})
+	consumer := &Participant{BaseEntity: BaseEntity{ID: properties.NewUUID()}, Name: "acme", Status: ParticipantEnabled}
+	newGroup := func() *ServiceGroup {
+		return &ServiceGroup{BaseEntity: BaseEntity{ID: properties.NewUUID(), Version: 3}, Name: "web", ConsumerID: properties.NewUUID()}
+	}
+	setup := func(t *testing.T, group *ServiceGroup, participant *Participant, err error) (*MockStore, *MockServiceGroupRepository) {
+		ms := setupMockStore(t)
+		repo := NewMockServiceGroupRepository(t)
+		repo.EXPECT().Get(mock.Anything, group.ID).Return(group, nil)
+		ms.EXPECT().ServiceGroupRepo().Return(repo)
+		participantRepo := NewMockParticipantRepository(t)
+		participantRepo.EXPECT().Get(mock.Anything, consumer.ID).Return(participant, err)
+		ms.EXPECT().ParticipantRepo().Return(participantRepo)
+		return ms, repo
+	}
+
+	t.Run("moves the group and its services", func(t *testing.T) {
+		group := newGroup()
+		previousConsumerID := group.ConsumerID
+		services := []*Service{
+			{BaseEntity: BaseEntity{ID: properties.NewUUID()}, Name: "db", Status: "Started", GroupID: group.ID, ConsumerID: previousConsumerID},
+			// Services in a transitional state are moved as well
+			{BaseEntity: BaseEntity{ID: properties.NewUUID()}, Name: "app", Status: "Creating", GroupID: group.ID, ConsumerID: previousConsumerID},
+		}
+		ms, repo := setup(t, group, consumer, nil)
+		repo.EXPECT().Save(mock.Anything, group).Return(nil)
+		serviceRepo := NewMockServiceRepository(t)
+		serviceRepo.EXPECT().FindByGroup(mock.Anything, group.ID).Return(services, nil)
+		for _, svc := range services {
+			serviceRepo.EXPECT().Save(mock.Anything, svc).Return(nil)
+		}
+		ms.EXPECT().ServiceRepo().Return(serviceRepo)
+		eventRepo := NewMockEventRepository(t)
+		eventRepo.EXPECT().Create(mock.Anything, mock.MatchedBy(func(e *Event) bool {
+			return e.Type == EventTypeServiceUpdated && *e.ConsumerID == consumer.ID
+		})).Return(nil).Times(2)
+		eventRepo.EXPECT().Create(mock.Anything, mock.MatchedBy(func(e *Event) bool {
+			return e.Type == EventTypeServiceGroupReassigned && *e.EntityID == group.ID && *e.ConsumerID == consumer.ID
+		})).Return(nil)
+		ms.EXPECT().EventRepo().Return(eventRepo)
+
+		reassigned, err := NewServiceGroupCommander(ms).Reassign(ctx, group.ID, consumer.ID)
+		require.NoError(t, err)
+		assert.Equal(t, consumer.ID, reassigned.ConsumerID)
+		for _, svc := range services {
+			assert.Equal(t, consumer.ID, svc.ConsumerID)
+		}
+	})
+
+	t.Run("consumer not found", func(t *testing.T) {
+		group := newGroup()
+		ms, _ := setup(t, group, nil, NewNotFoundErrorf("participant not found"))
+
+		_, err := NewServiceGroupCommander(ms).Reassign(ctx, group.ID, consumer.ID)
+		assert.ErrorAs(t, err, &InvalidInputError{})
+		assert.ErrorContains(t, err, "does not exist")
+	})
+
+	t.Run("disabled consumer", func(t *testing.T) {
+		group := newGroup()
+		disabled := *consumer
+		disabled.Status = ParticipantDisabled
+		ms, _ := setup(t, group, &disabled, nil)
+
+		_, err := NewServiceGroupCommander(ms).Reassign(ctx, group.ID, consumer.ID)
+		assert.ErrorAs(t, err, &InvalidInputError{})
+		assert.ErrorContains(t, err, "consumer acme is Disabled")
+	})
+
+	t.Run("current consumer saves nothing", func(t *testing.T) {
+		group := newGroup()
+		group.ConsumerID = consumer.ID
+		ms, _ := setup(t, group, consumer, nil)
+
+		reassigned, err := NewServiceGroupCommander(ms).Reassign(ctx, group.ID, consumer.ID)
+		require.NoError(t, err)
+		assert.Equal(t, group, reassigned)
+	})
+}
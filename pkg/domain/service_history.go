@@ -0,0 +1,139 @@
+package domain
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/fulcrumproject/core/pkg/auth"
+	"github.com/fulcrumproject/core/pkg/properties"
+)
+
+// ServiceHistoryKind identifies the source of a service history entry
+type ServiceHistoryKind string
+
+const (
+	ServiceHistoryKindJob   ServiceHistoryKind = "job"
+	ServiceHistoryKindEvent ServiceHistoryKind = "event"
+)
+
+// maxServiceHistoryDepth bounds the entries read from each source to build a page,
+// the pages ending past it are refused
+const maxServiceHistoryDepth = 1000
+
+// ServiceHistoryEntry is a single item of the timeline of a service, built from a job or an event
+type ServiceHistoryEntry struct {
+	Kind ServiceHistoryKind
+	ID   properties.UUID
+	Time time.Time
+
+	// Job fields
+	Action       string
+	Status       JobStatus
+	ErrorMessage string
+	Attempt      int
+	ScheduledAt  *time.Time
+	ClaimedAt    *time.Time
+	CompletedAt  *time.Time
+	Diagnostics  *properties.JSON
+
+	// Event fields
+	EventType     EventType
+	InitiatorType InitiatorType
+	InitiatorID   string
+	Payload       properties.JSON
+}
+
+// ServiceHistory returns the jobs and events of a service merged in chronological order
+// Both sources are read through their queriers, sorted by creation time, and merged page by page
+func ServiceHistory(
+	ctx context.Context,
+	jobQuerier JobQuerier,
+	eventQuerier EventQuerier,
+	scope *auth.IdentityScope,
+	serviceID properties.UUID,
+	page *PageReq,
+) (*PageRes[ServiceHistoryEntry], error) {
+	// The first page*pageSize items of each source are enough to build the requested page
+	limit := page.Page * page.PageSize
+	if limit > maxServiceHistoryDepth {
+		return nil, NewInvalidInputErrorf("the history can only be paged through its first %d entries", maxServiceHistoryDepth)
+	}
+
+	jobs, err := jobQuerier.List(ctx, scope, &PageReq{
+		Filters:  map[string][]string{"serviceId": {serviceID.String()}},
+		Sort:     true,
+		SortBy:   "createdAt",
+		SortAsc:  true,
+		Page:     1,
+		PageSize: limit,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	events, err := eventQuerier.List(ctx, scope, &PageReq{
+		Filters:  map[string][]string{"entityId": {serviceID.String()}},
+		Sort:     true,
+		SortBy:   "createdAt",
+		SortAsc:  true,
+		Page:     1,
+		PageSize: limit,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]ServiceHistoryEntry, 0, len(jobs.Items)+len(events.Items))
+	attempts := make(map[string]int)
+	for i := range jobs.Items {
+		entries = append(entries, jobHistoryEntry(&jobs.Items[i], attempts))
+	}
+	for i := range events.Items {
+		entries = append(entries, eventHistoryEntry(&events.Items[i]))
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Time.Before(entries[j].Time)
+	})
+
+	start := min((page.Page-1)*page.PageSize, len(entries))
+	end := min(start+page.PageSize, len(entries))
+
+	return NewPaginatedResult(entries[start:end], jobs.TotalItems+events.TotalItems, page), nil
+}
+
+// jobHistoryEntry converts a job to a history entry
+// attempts counts the consecutive failed jobs per action, a successful job resets the count
+func jobHistoryEntry(job *Job, attempts map[string]int) ServiceHistoryEntry {
+	attempts[job.Action]++
+	entry := ServiceHistoryEntry{
+		Kind:         ServiceHistoryKindJob,
+		ID:           job.ID,
+		Time:         job.CreatedAt,
+		Action:       job.Action,
+		Status:       job.Status,
+		ErrorMessage: job.ErrorMessage,
+		Attempt:      attempts[job.Action],
+		ScheduledAt:  job.ScheduledAt,
+		ClaimedAt:    job.ClaimedAt,
+		CompletedAt:  job.CompletedAt,
+		Diagnostics:  job.Diagnostics,
+	}
+	if job.Status == JobCompleted {
+		attempts[job.Action] = 0
+	}
+	return entry
+}
+
+// eventHistoryEntry converts an event to a history entry
+func eventHistoryEntry(event *Event) ServiceHistoryEntry {
+	return ServiceHistoryEntry{
+		Kind:          ServiceHistoryKindEvent,
+		ID:            event.ID,
+		Time:          event.CreatedAt,
+		EventType:     event.Type,
+		InitiatorType: event.InitiatorType,
+		InitiatorID:   event.InitiatorID,
+		Payload:       event.Payload,
+	}
+}
@@ -0,0 +1,96 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/fulcrumproject/core/pkg/auth"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServiceHistory(t *testing.T) {
+	ctx := context.Background()
+	scope := &auth.IdentityScope{}
+	serviceID := uuid.New()
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	at := func(minutes int) BaseEntity {
+		return BaseEntity{ID: uuid.New(), CreatedAt: base.Add(time.Duration(minutes) * time.Minute)}
+	}
+
+	jobs := []Job{
+		{BaseEntity: at(1), Action: "create", Status: JobCompleted},
+		{BaseEntity: at(3), Action: "start", Status: JobFailed, ErrorMessage: "boot failed"},
+		{BaseEntity: at(5), Action: "start", Status: JobFailed, ErrorMessage: "boot failed again"},
+	}
+	events := []Event{
+		{BaseEntity: at(0), Type: EventTypeServiceCreated, InitiatorType: InitiatorTypeUser},
+		{BaseEntity: at(2), Type: EventTypeServiceTransitioned, InitiatorType: InitiatorTypeSystem},
+	}
+
+	setup := func(t *testing.T) (*MockJobQuerier, *MockEventQuerier) {
+		jobQuerier := NewMockJobQuerier(t)
+		eventQuerier := NewMockEventQuerier(t)
+		jobQuerier.EXPECT().List(ctx, scope, mock.MatchedBy(func(req *PageReq) bool {
+			return req.Filters["serviceId"][0] == serviceID.String() && req.SortBy == "createdAt" && req.SortAsc
+		})).Return(&PageRes[Job]{Items: jobs, TotalItems: int64(len(jobs))}, nil)
+		eventQuerier.EXPECT().List(ctx, scope, mock.MatchedBy(func(req *PageReq) bool {
+			return req.Filters["entityId"][0] == serviceID.String() && req.SortBy == "createdAt" && req.SortAsc
+		})).Return(&PageRes[Event]{Items: events, TotalItems: int64(len(events))}, nil)
+		return jobQuerier, eventQuerier
+	}
+
+	t.Run("merges jobs and events chronologically", func(t *testing.T) {
+		jobQuerier, eventQuerier := setup(t)
+
+		result, err := ServiceHistory(ctx, jobQuerier, eventQuerier, scope, serviceID, &PageReq{Page: 1, PageSize: 10})
+		require.NoError(t, err)
+		require.Len(t, result.Items, 5)
+		assert.Equal(t, int64(5), result.TotalItems)
+
+		kinds := []ServiceHistoryKind{}
+		for _, e := range result.Items {
+			kinds = append(kinds, e.Kind)
+		}
+		assert.Equal(t, []ServiceHistoryKind{
+			ServiceHistoryKindEvent, ServiceHistoryKindJob, ServiceHistoryKindEvent, ServiceHistoryKindJob, ServiceHistoryKindJob,
+		}, kinds)
+
+		assert.Equal(t, EventTypeServiceCreated, result.Items[0].EventType)
+		assert.Equal(t, 1, result.Items[1].Attempt)
+		assert.Equal(t, "boot failed", result.Items[3].ErrorMessage)
+		assert.Equal(t, 1, result.Items[3].Attempt)
+		assert.Equal(t, "boot failed again", result.Items[4].ErrorMessage)
+		assert.Equal(t, 2, result.Items[4].Attempt)
+	})
+
+	t.Run("second page", func(t *testing.T) {
+		jobQuerier, eventQuerier := setup(t)
+
+		result, err := ServiceHistory(ctx, jobQuerier, eventQuerier, scope, serviceID, &PageReq{Page: 2, PageSize: 2})
+		require.NoError(t, err)
+		require.Len(t, result.Items, 2)
+		assert.Equal(t, "start", result.Items[1].Action)
+		assert.Equal(t, 3, result.TotalPages)
+		assert.True(t, result.HasNext)
+	})
+
+	t.Run("page past the maximum depth", func(t *testing.T) {
+		// The sources are not read
+		_, err := ServiceHistory(ctx, NewMockJobQuerier(t), NewMockEventQuerier(t), scope, serviceID, &PageReq{Page: 11, PageSize: 100})
+		require.Error(t, err)
+		assert.True(t, errors.As(err, &InvalidInputError{}))
+	})
+
+	t.Run("querier error", func(t *testing.T) {
+		jobQuerier := NewMockJobQuerier(t)
+		jobQuerier.EXPECT().List(ctx, scope, mock.Anything).Return(nil, errors.New("db error"))
+
+		_, err := ServiceHistory(ctx, jobQuerier, NewMockEventQuerier(t), scope, serviceID, &PageReq{Page: 1, PageSize: 10})
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,103 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/fulcrumproject/core/pkg/properties"
+)
+
+// ServiceIdleStopper stops the services inactive beyond their idle timeout through their lifecycle stop action
+// The activity of a service is its last completed job, or its creation, and its last metric entry
+type ServiceIdleStopper struct {
+	store   Store
+	metrics MetricEntryQuerier
+}
+
+// NewServiceIdleStopper creates a new stopper of the idle services
+// The metric entries are read separately as they are stored in their own database
+func NewServiceIdleStopper(store Store, metrics MetricEntryQuerier) *ServiceIdleStopper {
+	return &ServiceIdleStopper{
+		store:   store,
+		metrics: metrics,
+	}
+}
+
+// StopIdle creates a stop job for each idle service and returns their number
+// The services whose lifecycle does not allow stopping from their status, having an active job or in maintenance, are skipped
+func (s *ServiceIdleStopper) StopIdle(ctx context.Context) (int, error) {
+	now := time.Now()
+	candidates, err := s.store.ServiceRepo().FindIdle(ctx, now)
+	if err != nil {
+		return 0, fmt.Errorf("failed to retrieve idle services: %w", err)
+	}
+	if len(candidates) == 0 {
+		return 0, nil
+	}
+
+	ids := make([]properties.UUID, len(candidates))
+	for i, svc := range candidates {
+		ids[i] = svc.ID
+	}
+	lastEntries, err := s.metrics.LastEntryTimes(ctx, ids)
+	if err != nil {
+		return 0, fmt.Errorf("failed to retrieve the last metric entries: %w", err)
+	}
+
+	counter := 0
+	for _, candidate := range candidates {
+		if last, ok := lastEntries[candidate.ID]; ok && now.Sub(last) < *candidate.IdleTimeout {
+			continue
+		}
+		stopped, err := s.stop(ctx, candidate.ID, lastEntries[candidate.ID])
+		if err != nil {
+			var invalidInput InvalidInputError
+			if errors.As(err, &invalidInput) || errors.As(err, &MaintenanceError{}) {
+				continue
+			}
+			return counter, err
+		}
+		if stopped {
+			counter++
+		}
+	}
+
+	return counter, nil
+}
+
+// stop creates the stop job of an idle service with its auto-stop event
+func (s *ServiceIdleStopper) stop(ctx context.Context, id properties.UUID, lastEntry time.Time) (bool, error) {
+	params := DoServiceActionParams{ID: id, Action: ServiceActionStop}
+	svc, err := validateServiceAction(ctx, s.store, params)
+	if err != nil {
+		return false, err
+	}
+	// The policy may have been removed since the services were read
+	if svc.IdleTimeout == nil {
+		return false, nil
+	}
+
+	err = s.store.Atomic(ctx, func(store Store) error {
+		// The idle passes already space the retries of a failed stop
+		if err := createServiceActionJob(ctx, store, svc, params, JobRetryPolicy{}); err != nil {
+			return err
+		}
+		eventEntry, err := NewEvent(EventTypeServiceAutoStopped, WithService(svc))
+		if err != nil {
+			return err
+		}
+		eventEntry.Payload = properties.JSON{
+			"action":         ServiceActionStop,
+			"status":         svc.Status,
+			"idleTimeout":    svc.IdleTimeout.String(),
+			"lastActivityAt": svc.LastActivityAt,
+		}
+		if !lastEntry.IsZero() {
+			eventEntry.Payload["lastMetricAt"] = lastEntry
+		}
+		return store.EventRepo().Create(ctx, eventEntry)
+	})
+	return err == nil, err
+}
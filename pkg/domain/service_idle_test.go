@@ -0,0 +1,109 @@
+package domain
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/fulcrumproject/core/pkg/helpers"
+	"github.com/fulcrumproject/core/pkg/properties"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServiceIdleStopper_StopIdle(t *testing.T) {
+	ctx := context.Background()
+	serviceType := &ServiceType{
+		BaseEntity: BaseEntity{ID: uuid.New()},
+		LifecycleSchema: LifecycleSchema{
+			States:       []LifecycleState{{Name: "Started"}, {Name: "Stopped"}},
+			InitialState: "Started",
+			Actions: []LifecycleAction{
+				{Name: "stop", Transitions: []LifecycleTransition{{From: "Started", To: "Stopped"}}},
+			},
+		},
+	}
+	newService := func(status string) *Service {
+		lastActivity := time.Now().Add(-2 * time.Hour)
+		return &Service{
+			BaseEntity:     BaseEntity{ID: uuid.New()},
+			Status:         status,
+			ServiceTypeID:  serviceType.ID,
+			AgentID:        uuid.New(),
+			IdleTimeout:    helpers.DurationPtr(time.Hour),
+			LastActivityAt: &lastActivity,
+		}
+	}
+	idle := newService("Started")
+	// Still reporting metrics
+	metered := newService("Started")
+	// Not stoppable from its status
+	stopped := newService("Stopped")
+	// Busy with another operation
+	busy := newService("Started")
+	activeJob := &Job{BaseEntity: BaseEntity{ID: uuid.New()}, Status: JobProcessing, Action: "resize", ServiceID: busy.ID}
+	lastMetric := time.Now().Add(-90 * time.Minute)
+
+	ms := setupMockStore(t)
+	serviceRepo := NewMockServiceRepository(t)
+	serviceTypeRepo := NewMockServiceTypeRepository(t)
+	jobRepo := NewMockJobRepository(t)
+	eventRepo := NewMockEventRepository(t)
+	metrics := NewMockMetricEntryQuerier(t)
+	ms.EXPECT().ServiceRepo().Return(serviceRepo)
+	ms.EXPECT().ServiceTypeRepo().Return(serviceTypeRepo)
+	ms.EXPECT().JobRepo().Return(jobRepo)
+	ms.EXPECT().EventRepo().Return(eventRepo)
+	serviceRepo.EXPECT().FindIdle(mock.Anything, mock.AnythingOfType("time.Time")).Return([]*Service{idle, metered, stopped, busy}, nil)
+	metrics.EXPECT().LastEntryTimes(mock.Anything, []properties.UUID{idle.ID, metered.ID, stopped.ID, busy.ID}).
+		Return(map[properties.UUID]time.Time{idle.ID: lastMetric, metered.ID: time.Now().Add(-time.Minute)}, nil)
+	serviceRepo.EXPECT().Get(mock.Anything, idle.ID).Return(idle, nil)
+	serviceRepo.EXPECT().Get(mock.Anything, stopped.ID).Return(stopped, nil)
+	serviceRepo.EXPECT().Get(mock.Anything, busy.ID).Return(busy, nil)
+	serviceTypeRepo.EXPECT().Get(mock.Anything, serviceType.ID).Return(serviceType, nil)
+	jobRepo.EXPECT().GetLastJobForService(mock.Anything, idle.ID).Return(nil, nil)
+	jobRepo.EXPECT().GetLastJobForService(mock.Anything, busy.ID).Return(activeJob, nil)
+	jobRepo.EXPECT().GetScheduledJobsForService(mock.Anything, idle.ID).Return(nil, nil)
+	var created *Job
+	jobRepo.EXPECT().Create(mock.Anything, mock.AnythingOfType("*domain.Job")).RunAndReturn(func(_ context.Context, job *Job) error {
+		created = job
+		return nil
+	}).Once()
+	var event *Event
+	eventRepo.EXPECT().Create(mock.Anything, mock.AnythingOfType("*domain.Event")).RunAndReturn(func(_ context.Context, e *Event) error {
+		event = e
+		return nil
+	}).Once()
+
+	count, err := NewServiceIdleStopper(ms, metrics).StopIdle(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	require.NotNil(t, created)
+	assert.Equal(t, ServiceActionStop, created.Action)
+	assert.Equal(t, idle.ID, created.ServiceID)
+	assert.Equal(t, JobPending, created.Status)
+	assert.Equal(t, JobPriorityHigh, created.Priority)
+
+	require.NotNil(t, event)
+	assert.Equal(t, EventTypeServiceAutoStopped, event.Type)
+	assert.Equal(t, InitiatorTypeSystem, event.InitiatorType)
+	assert.Equal(t, idle.ID, *event.EntityID)
+	assert.Equal(t, "1h0m0s", event.Payload["idleTimeout"])
+	assert.Equal(t, lastMetric, event.Payload["lastMetricAt"])
+	assert.Equal(t, "Started", idle.Status, "the status only changes when the agent completes the stop job")
+}
+
+func TestServiceIdleStopper_NoIdleServices(t *testing.T) {
+	ms := NewMockStore(t)
+	serviceRepo := NewMockServiceRepository(t)
+	ms.EXPECT().ServiceRepo().Return(serviceRepo)
+	serviceRepo.EXPECT().FindIdle(mock.Anything, mock.Anything).Return(nil, nil)
+
+	// The metrics are not queried
+	count, err := NewServiceIdleStopper(ms, NewMockMetricEntryQuerier(t)).StopIdle(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 0, count)
+}
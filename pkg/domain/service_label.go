@@ -0,0 +1,378 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/fulcrumproject/core/pkg/schema"
+)
+
+// ServiceLabelSelectorParam is the query parameter filtering the services by label selector
+const ServiceLabelSelectorParam = "labelSelector"
+
+// labelSelectorKeyPrefix prefixes the label keys in the selectors, e.g. label.env=prod
+const labelSelectorKeyPrefix = "label."
+
+// Limits of the service labels
+const (
+	MaxServiceLabels   = 64
+	maxLabelNameLength = 63
+)
+
+// ValidateLabelKey ensures a label key is 1 to 63 alphanumeric, '-', '_', '.' or '/' characters,
+// starting and ending with an alphanumeric character
+func ValidateLabelKey(key string) error {
+	if key == "" {
+		return fmt.Errorf("label key cannot be empty")
+	}
+	if !isLabelName(key) {
+		return fmt.Errorf("invalid label key %q: must be at most %d alphanumeric, '-', '_', '.' or '/' characters, starting and ending with an alphanumeric character", key, maxLabelNameLength)
+	}
+	return nil
+}
+
+// ValidateLabelValue ensures a label value is empty or follows the rules of the label keys
+func ValidateLabelValue(value string) error {
+	if value != "" && !isLabelName(value) {
+		return fmt.Errorf("invalid label value %q: must be empty or at most %d alphanumeric, '-', '_', '.' or '/' characters, starting and ending with an alphanumeric character", value, maxLabelNameLength)
+	}
+	return nil
+}
+
+// ValidateLabels ensures the labels have valid keys and values and do not exceed the limit
+func ValidateLabels(labels map[string]string) error {
+	if len(labels) > MaxServiceLabels {
+		return fmt.Errorf("too many labels: %d, the limit is %d", len(labels), MaxServiceLabels)
+	}
+	for _, key := range slices.Sorted(maps.Keys(labels)) {
+		if err := ValidateLabelKey(key); err != nil {
+			return err
+		}
+		if err := ValidateLabelValue(labels[key]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// labelSchemaPathPrefix prefixes the paths of the label validation errors, telling them apart from the property ones
+const labelSchemaPathPrefix = "labels."
+
+// ValidateLabelSchema ensures a label schema only defines optional string properties named as label keys
+// Labels are strings set after the service creation, so neither other types nor requirements can hold
+func ValidateLabelSchema(labelSchema schema.Schema) error {
+	for _, key := range slices.Sorted(maps.Keys(labelSchema.Properties)) {
+		if err := ValidateLabelKey(key); err != nil {
+			return err
+		}
+		propDef := labelSchema.Properties[key]
+		if propDef.Type != "string" {
+			return fmt.Errorf("label %q must be of type string, got %q", key, propDef.Type)
+		}
+		if propDef.Required || propDef.RequiredIf != nil {
+			return fmt.Errorf("label %q cannot be required", key)
+		}
+		if propDef.Secret != nil || propDef.Generator != nil {
+			return fmt.Errorf("label %q cannot be secret or generated", key)
+		}
+	}
+	return nil
+}
+
+// validateLabelsWithSchema checks the labels of a service against the label schema of its type
+// Labels not defined in the schema are rejected, the errors are reported at the "labels." prefixed paths
+func validateLabelsWithSchema(
+	ctx context.Context,
+	store Store,
+	engine *schema.Engine[ServicePropertyContext],
+	svc *Service,
+	labelSchema schema.Schema,
+) error {
+	var details []schema.ValidationErrorDetail
+	values := make(map[string]any, len(svc.Labels))
+	for _, key := range slices.Sorted(maps.Keys(svc.Labels)) {
+		if _, ok := labelSchema.Properties[key]; !ok {
+			details = append(details, schema.ValidationErrorDetail{
+				Path:    labelSchemaPathPrefix + key,
+				Message: "label is not defined in the label schema",
+			})
+			continue
+		}
+		values[key] = svc.Labels[key]
+	}
+
+	if err := engine.ValidateProperties(ctx, newServiceMigrationSchemaContext(store, svc), labelSchema, values); err != nil {
+		var validationErr schema.ValidationError
+		if !errors.As(err, &validationErr) {
+			return err
+		}
+		for _, detail := range validationErr.Errors {
+			details = append(details, schema.ValidationErrorDetail{
+				Path:    labelSchemaPathPrefix + detail.Path,
+				Message: detail.Message,
+			})
+		}
+	}
+
+	if len(details) > 0 {
+		return schema.NewValidationError(details)
+	}
+	return nil
+}
+
+func isLabelName(s string) bool {
+	if len(s) > maxLabelNameLength || !isLabelAlphanumeric(s[0]) || !isLabelAlphanumeric(s[len(s)-1]) {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if !isLabelNameChar(s[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func isLabelAlphanumeric(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
+
+func isLabelNameChar(c byte) bool {
+	return isLabelAlphanumeric(c) || c == '-' || c == '_' || c == '.' || c == '/'
+}
+
+// LabelOperator is the comparison of a label requirement
+type LabelOperator string
+
+const (
+	LabelOpEquals       LabelOperator = "="
+	LabelOpNotEquals    LabelOperator = "!="
+	LabelOpIn           LabelOperator = "in"
+	LabelOpNotIn        LabelOperator = "notin"
+	LabelOpExists       LabelOperator = "exists"
+	LabelOpDoesNotExist LabelOperator = "!exists"
+)
+
+// LabelRequirement is a condition on a single label
+// The negative operators, != and notin, also match the services without the label
+type LabelRequirement struct {
+	Key      string
+	Operator LabelOperator
+	Values   []string // A single value for = and !=, none for exists and !exists
+}
+
+// Matches reports whether the labels satisfy the requirement
+func (r LabelRequirement) Matches(labels map[string]string) bool {
+	value, ok := labels[r.Key]
+	switch r.Operator {
+	case LabelOpEquals, LabelOpIn:
+		return ok && slices.Contains(r.Values, value)
+	case LabelOpNotEquals, LabelOpNotIn:
+		return !ok || !slices.Contains(r.Values, value)
+	case LabelOpExists:
+		return ok
+	case LabelOpDoesNotExist:
+		return !ok
+	}
+	return false
+}
+
+// LabelSelector is a conjunction of label requirements
+type LabelSelector []LabelRequirement
+
+// Matches reports whether the labels satisfy all the requirements
+func (s LabelSelector) Matches(labels map[string]string) bool {
+	for _, r := range s {
+		if !r.Matches(labels) {
+			return false
+		}
+	}
+	return true
+}
+
+// ParseLabelSelector parses a comma separated list of label requirements:
+//
+//	label.env=prod            label env is prod (== is accepted as well)
+//	label.env!=prod           label env is not prod or missing
+//	label.tier in (gold,silver)
+//	label.tier notin (bronze)
+//	label.env                 label env is set
+//	!label.env                label env is not set
+//
+// The parsing is strict, anything else, including empty requirements, is an invalid input
+func ParseLabelSelector(selector string) (LabelSelector, error) {
+	p := labelSelectorParser{s: selector}
+	result, err := p.parse()
+	if err != nil {
+		return nil, NewInvalidInputErrorf("invalid label selector %q: %w", selector, err)
+	}
+	return result, nil
+}
+
+// labelSelectorParser is a recursive descent parser of the label selectors
+type labelSelectorParser struct {
+	s   string
+	pos int
+}
+
+func (p *labelSelectorParser) parse() (LabelSelector, error) {
+	var selector LabelSelector
+	for {
+		r, err := p.parseRequirement()
+		if err != nil {
+			return nil, err
+		}
+		selector = append(selector, r)
+		p.skipSpaces()
+		if p.done() {
+			return selector, nil
+		}
+		if !p.consume(",") {
+			return nil, p.errorf("expected ','")
+		}
+	}
+}
+
+func (p *labelSelectorParser) parseRequirement() (LabelRequirement, error) {
+	p.skipSpaces()
+	if p.consume("!") {
+		key, err := p.parseKey()
+		if err != nil {
+			return LabelRequirement{}, err
+		}
+		return LabelRequirement{Key: key, Operator: LabelOpDoesNotExist}, nil
+	}
+
+	key, err := p.parseKey()
+	if err != nil {
+		return LabelRequirement{}, err
+	}
+	spaced := p.skipSpaces()
+	switch {
+	case p.done() || p.peek() == ',':
+		return LabelRequirement{Key: key, Operator: LabelOpExists}, nil
+	case p.consume("=="), p.consume("="):
+		return p.parseSingleValue(key, LabelOpEquals)
+	case p.consume("!="):
+		return p.parseSingleValue(key, LabelOpNotEquals)
+	}
+
+	// The set operators are words, separated from the key
+	if spaced {
+		word := p.parseWord()
+		switch LabelOperator(word) {
+		case LabelOpIn, LabelOpNotIn:
+			values, err := p.parseValueSet()
+			if err != nil {
+				return LabelRequirement{}, err
+			}
+			return LabelRequirement{Key: key, Operator: LabelOperator(word), Values: values}, nil
+		}
+		if word != "" {
+			return LabelRequirement{}, fmt.Errorf("unknown operator %q at position %d", word, p.pos-len(word))
+		}
+	}
+	return LabelRequirement{}, p.errorf("expected an operator")
+}
+
+// parseKey parses a prefixed label key like label.env
+func (p *labelSelectorParser) parseKey() (string, error) {
+	p.skipSpaces()
+	if !p.consume(labelSelectorKeyPrefix) {
+		return "", p.errorf(fmt.Sprintf("expected a label key prefixed by %q", labelSelectorKeyPrefix))
+	}
+	key := p.parseName()
+	if err := ValidateLabelKey(key); err != nil {
+		return "", err
+	}
+	return key, nil
+}
+
+func (p *labelSelectorParser) parseSingleValue(key string, op LabelOperator) (LabelRequirement, error) {
+	p.skipSpaces()
+	value := p.parseName()
+	if err := ValidateLabelValue(value); err != nil {
+		return LabelRequirement{}, err
+	}
+	return LabelRequirement{Key: key, Operator: op, Values: []string{value}}, nil
+}
+
+// parseValueSet parses a parenthesized comma separated list of values like (gold,silver)
+func (p *labelSelectorParser) parseValueSet() ([]string, error) {
+	p.skipSpaces()
+	if !p.consume("(") {
+		return nil, p.errorf("expected '('")
+	}
+	var values []string
+	for {
+		p.skipSpaces()
+		value := p.parseName()
+		if value == "" {
+			return nil, p.errorf("expected a value")
+		}
+		if err := ValidateLabelValue(value); err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+		p.skipSpaces()
+		if p.consume(")") {
+			return values, nil
+		}
+		if !p.consume(",") {
+			return nil, p.errorf("expected ',' or ')'")
+		}
+	}
+}
+
+// parseName parses the longest run of label name characters, possibly empty
+func (p *labelSelectorParser) parseName() string {
+	start := p.pos
+	for !p.done() && isLabelNameChar(p.s[p.pos]) {
+		p.pos++
+	}
+	return p.s[start:p.pos]
+}
+
+// parseWord parses the longest run of letters, possibly empty
+func (p *labelSelectorParser) parseWord() string {
+	start := p.pos
+	for !p.done() && (p.s[p.pos] >= 'a' && p.s[p.pos] <= 'z' || p.s[p.pos] >= 'A' && p.s[p.pos] <= 'Z') {
+		p.pos++
+	}
+	return p.s[start:p.pos]
+}
+
+// skipSpaces skips the spaces and reports whether there were any
+func (p *labelSelectorParser) skipSpaces() bool {
+	start := p.pos
+	for !p.done() && p.s[p.pos] == ' ' {
+		p.pos++
+	}
+	return p.pos > start
+}
+
+func (p *labelSelectorParser) consume(token string) bool {
+	if strings.HasPrefix(p.s[p.pos:], token) {
+		p.pos += len(token)
+		return true
+	}
+	return false
+}
+
+func (p *labelSelectorParser) peek() byte {
+	return p.s[p.pos]
+}
+
+func (p *labelSelectorParser) done() bool {
+	return p.pos >= len(p.s)
+}
+
+func (p *labelSelectorParser) errorf(expected string) error {
+	if p.done() {
+		return fmt.Errorf("%s at the end", expected)
+	}
+	return fmt.Errorf("%s at position %d", expected, p.pos)
+}
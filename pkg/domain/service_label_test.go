@@ -0,0 +1,159 @@
+package domain
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateLabels(t *testing.T) {
+	tests := []struct {
+		name    string
+		labels  map[string]string
+		wantErr string
+	}{
+		{name: "Empty", labels: nil},
+		{name: "Valid", labels: map[string]string{"env": "prod", "team/owner": "core-infra", "tier": "", "a.b_c-d": "1.2"}},
+		{name: "Empty key", labels: map[string]string{"": "prod"}, wantErr: "label key cannot be empty"},
+		{name: "Key with space", labels: map[string]string{"my env": "prod"}, wantErr: `invalid label key "my env"`},
+		{name: "Key ending with a dash", labels: map[string]string{"env-": "prod"}, wantErr: `invalid label key "env-"`},
+		{name: "Key too long", labels: map[string]string{strings.Repeat("k", 64): "prod"}, wantErr: "invalid label key"},
+		{name: "Value with comma", labels: map[string]string{"env": "a,b"}, wantErr: `invalid label value "a,b"`},
+		{name: "Value starting with a dot", labels: map[string]string{"env": ".prod"}, wantErr: `invalid label value ".prod"`},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := ValidateLabels(tc.labels)
+			if tc.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorContains(t, err, tc.wantErr)
+		})
+	}
+
+	tooMany := make(map[string]string)
+	for i := range MaxServiceLabels + 1 {
+		tooMany[strings.Repeat("k", i+1)] = "v"
+	}
+	assert.EqualError(t, ValidateLabels(tooMany), "too many labels: 65, the limit is 64")
+}
+
+func TestParseLabelSelector(t *testing.T) {
+	tests := []struct {
+		selector string
+		expected LabelSelector
+	}{
+		{"label.env=prod", LabelSelector{{Key: "env", Operator: LabelOpEquals, Values: []string{"prod"}}}},
+		{"label.env==prod", LabelSelector{{Key: "env", Operator: LabelOpEquals, Values: []string{"prod"}}}},
+		{"label.env=", LabelSelector{{Key: "env", Operator: LabelOpEquals, Values: []string{""}}}},
+		{"label.env!=prod", LabelSelector{{Key: "env", Operator: LabelOpNotEquals, Values: []string{"prod"}}}},
+		{"label.env", LabelSelector{{Key: "env", Operator: LabelOpExists}}},
+		{"!label.env", LabelSelector{{Key: "env", Operator: LabelOpDoesNotExist}}},
+		{"label.tier notin (bronze)", LabelSelector{{Key: "tier", Operator: LabelOpNotIn, Values: []string{"bronze"}}}},
+		{
+			"label.env=prod,label.tier in (gold,silver)",
+			LabelSelector{
+				{Key: "env", Operator: LabelOpEquals, Values: []string{"prod"}},
+				{Key: "tier", Operator: LabelOpIn, Values: []string{"gold", "silver"}},
+			},
+		},
+		{
+			" label.team/owner = core , label.tier in( gold , silver ) , !label.legacy ",
+			LabelSelector{
+				{Key: "team/owner", Operator: LabelOpEquals, Values: []string{"core"}},
+				{Key: "tier", Operator: LabelOpIn, Values: []string{"gold", "silver"}},
+				{Key: "legacy", Operator: LabelOpDoesNotExist},
+			},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.selector, func(t *testing.T) {
+			selector, err := ParseLabelSelector(tc.selector)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, selector)
+		})
+	}
+}
+
+func TestParseLabelSelectorErrors(t *testing.T) {
+	tests := []struct {
+		selector string
+		wantErr  string
+	}{
+		{"", "expected a label key prefixed by \"label.\" at the end"},
+		{"env=prod", "expected a label key prefixed by \"label.\" at position 0"},
+		{"label.=prod", "label key cannot be empty"},
+		{"label.env=prod,", "expected a label key prefixed by \"label.\" at the end"},
+		{"label.env=prod,,label.tier", "expected a label key prefixed by \"label.\" at position 15"},
+		{"label.env=prod label.tier", "expected ',' at position 15"},
+		{"label.env=prod)", "expected ',' at position 14"},
+		{"label.env=a b", "expected ',' at position 12"},
+		{"label.env<3", "expected an operator at position 9"},
+		{"label.tier in gold", "expected '(' at position 14"},
+		{"label.tier in (gold", "expected ',' or ')' at the end"},
+		{"label.tier in ()", "expected a value at position 15"},
+		{"label.tier in (gold,)", "expected a value at position 20"},
+		{"label.tier like (gold)", `unknown operator "like" at position 11`},
+		{"label.tier in (go ld)", "expected ',' or ')' at position 18"},
+		{"label.tierin (gold)", "expected an operator at position 13"},
+		{"label.env=-prod", `invalid label value "-prod"`},
+		{"!label.env=prod", "expected ',' at position 10"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.selector, func(t *testing.T) {
+			_, err := ParseLabelSelector(tc.selector)
+			assert.ErrorAs(t, err, &InvalidInputError{})
+			assert.ErrorContains(t, err, tc.wantErr)
+		})
+	}
+}
+
+func TestLabelSelectorMatches(t *testing.T) {
+	labels := map[string]string{"env": "prod", "tier": "gold"}
+	tests := []struct {
+		selector string
+		expected bool
+	}{
+		{"label.env=prod", true},
+		{"label.env=dev", false},
+		{"label.env!=dev", true},
+		{"label.region!=eu", true},
+		{"label.tier in (gold,silver)", true},
+		{"label.region in (eu)", false},
+		{"label.tier notin (gold)", false},
+		{"label.region notin (eu)", true},
+		{"label.env", true},
+		{"!label.env", false},
+		{"!label.region", true},
+		{"label.env=prod,label.tier in (silver)", false},
+	}
+	for _, tc := range tests {
+		t.Run(tc.selector, func(t *testing.T) {
+			selector, err := ParseLabelSelector(tc.selector)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, selector.Matches(labels))
+		})
+	}
+}
+
+func TestService_SetLabels(t *testing.T) {
+	prod := "prod"
+	svc := &Service{}
+
+	changed, err := svc.SetLabels(map[string]*string{"env": &prod})
+	require.NoError(t, err)
+	assert.True(t, changed)
+	assert.Equal(t, map[string]string{"env": "prod"}, svc.Labels)
+
+	changed, err = svc.SetLabels(map[string]*string{"env": &prod, "missing": nil})
+	require.NoError(t, err)
+	assert.False(t, changed)
+
+	changed, err = svc.SetLabels(map[string]*string{"env": nil})
+	require.NoError(t, err)
+	assert.True(t, changed)
+	assert.Empty(t, svc.Labels)
+}
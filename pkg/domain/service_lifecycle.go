@@ -22,6 +22,8 @@ type LifecycleSchema struct {
 	InitialState   string            `json:"initialState"`
 	TerminalStates []string          `json:"terminalStates"`
 	RunningStates  []string          `json:"runningStates,omitempty"`
+	// State the services are moved to when an action is dead-lettered, they only get the failed flag when empty
+	FailedState string `json:"failedState,omitempty"`
 }
 
 // Scan implements the sql.Scanner interface
@@ -141,6 +143,104 @@ func (ls *LifecycleSchema) ValidateActionAllowed(currentState string, action str
 	return fmt.Errorf("action %q is not allowed from state %q", action, currentState)
 }
 
+// PathTo returns the shortest sequence of actions driving a service from a state to the target state
+// through successful transitions, the actions requiring a request payload are not part of any path
+func (ls *LifecycleSchema) PathTo(from string, target string) ([]string, error) {
+	if from == target {
+		return []string{}, nil
+	}
+	type step struct {
+		state string
+		path  []string
+	}
+	visited := map[string]bool{from: true}
+	queue := []step{{state: from, path: []string{}}}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, action := range ls.Actions {
+			if action.RequestSchemaType != "" {
+				continue
+			}
+			for _, transition := range action.Transitions {
+				if transition.OnError || transition.From != current.state || visited[transition.To] {
+					continue
+				}
+				path := append(slices.Clone(current.path), action.Name)
+				if transition.To == target {
+					return path, nil
+				}
+				visited[transition.To] = true
+				queue = append(queue, step{state: transition.To, path: path})
+			}
+		}
+	}
+	return nil, fmt.Errorf("state %q is not reachable from state %q", target, from)
+}
+
+// LifecycleStateNode is a state of the lifecycle with the actions allowed from it
+type LifecycleStateNode struct {
+	Name        string
+	Initial     bool
+	Terminal    bool
+	Running     bool
+	Transitions []LifecycleEdge
+}
+
+// LifecycleEdge is an action allowed from a state and the states it leads to
+type LifecycleEdge struct {
+	Action            string
+	To                string   // State reached on success, empty when the action only has error transitions
+	ErrorTo           []string // States reached on failure, in the order their transitions are matched
+	Update            bool     // The action applies a property update
+	RequestSchemaType string
+}
+
+// StateMachine returns the transition graph of the lifecycle, every state with the actions allowed from it
+// The graph is derived with ValidateActionAllowed and ResolveNextState, which validate the service
+// actions and transitions, so it cannot disagree with them. States and actions keep their lifecycle order.
+func (ls *LifecycleSchema) StateMachine() []LifecycleStateNode {
+	nodes := make([]LifecycleStateNode, 0, len(ls.States))
+	for _, state := range ls.States {
+		node := LifecycleStateNode{
+			Name:        state.Name,
+			Initial:     state.Name == ls.InitialState,
+			Terminal:    ls.IsTerminalState(state.Name),
+			Running:     ls.IsRunningStatus(state.Name),
+			Transitions: []LifecycleEdge{},
+		}
+		for _, action := range ls.Actions {
+			if ls.ValidateActionAllowed(state.Name, action.Name) != nil {
+				continue
+			}
+			edge := LifecycleEdge{Action: action.Name, Update: IsUpdateAction(action.Name), RequestSchemaType: action.RequestSchemaType}
+			edge.To, _ = ls.ResolveNextState(state.Name, action.Name, nil)
+			for _, transition := range action.Transitions {
+				if transition.From == state.Name && transition.OnError && !slices.Contains(edge.ErrorTo, transition.To) {
+					edge.ErrorTo = append(edge.ErrorTo, transition.To)
+				}
+			}
+			node.Transitions = append(node.Transitions, edge)
+		}
+		nodes = append(nodes, node)
+	}
+	return nodes
+}
+
+// HasState checks if the lifecycle defines the state
+func (ls *LifecycleSchema) HasState(state string) bool {
+	return slices.ContainsFunc(ls.States, func(s LifecycleState) bool {
+		return s.Name == state
+	})
+}
+
+// HasAction checks if the lifecycle defines the action
+func (ls *LifecycleSchema) HasAction(action string) bool {
+	return slices.ContainsFunc(ls.Actions, func(a LifecycleAction) bool {
+		return a.Name == action
+	})
+}
+
 // IsTerminalState checks if a state is a terminal state in the lifecycle
 func (ls *LifecycleSchema) IsTerminalState(state string) bool {
 	return slices.Contains(ls.TerminalStates, state)
@@ -192,6 +292,11 @@ func (ls *LifecycleSchema) Validate() error {
 		}
 	}
 
+	// Validate failed state exists
+	if ls.FailedState != "" && !stateNames[ls.FailedState] {
+		return fmt.Errorf("lifecycle failed state %q does not exist in states list", ls.FailedState)
+	}
+
 	// Validate actions
 	if len(ls.Actions) == 0 {
 		return fmt.Errorf("lifecycle must have at least one action")
@@ -3,6 +3,7 @@ package domain
 
 import (
 	"errors"
+	"slices"
 	"testing"
 )
 
@@ -394,3 +395,154 @@ func TestIsTerminalState_EmptyTerminalStates(t *testing.T) {
 		t.Error("IsTerminalState() should return false when terminal states list is empty")
 	}
 }
+
+func TestPathTo(t *testing.T) {
+	lifecycle := &LifecycleSchema{
+		States: []LifecycleState{
+			{Name: "New"},
+			{Name: "Created"},
+			{Name: "Started"},
+			{Name: "Stopped"},
+			{Name: "Failed"},
+			{Name: "Resized"},
+		},
+		Actions: []LifecycleAction{
+			{Name: "create", Transitions: []LifecycleTransition{{From: "New", To: "Created"}, {From: "New", To: "Failed", OnError: true}}},
+			{Name: "start", Transitions: []LifecycleTransition{{From: "Created", To: "Started"}, {From: "Stopped", To: "Started"}}},
+			{Name: "stop", Transitions: []LifecycleTransition{{From: "Started", To: "Stopped"}}},
+			{Name: "resize", RequestSchemaType: "resize", Transitions: []LifecycleTransition{{From: "Created", To: "Resized"}}},
+		},
+		InitialState: "New",
+	}
+
+	tests := []struct {
+		name    string
+		from    string
+		target  string
+		want    []string
+		wantErr bool
+	}{
+		{name: "Same state", from: "Created", target: "Created", want: []string{}},
+		{name: "Single action", from: "Created", target: "Started", want: []string{"start"}},
+		{name: "Several actions", from: "Created", target: "Stopped", want: []string{"start", "stop"}},
+		{name: "Error transitions are not followed", from: "New", target: "Failed", wantErr: true},
+		{name: "Actions with a request payload are not followed", from: "Created", target: "Resized", wantErr: true},
+		{name: "Unreachable state", from: "Started", target: "Created", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path, err := lifecycle.PathTo(tt.from, tt.target)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("PathTo() expected an error, got path %v", path)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("PathTo() error = %v", err)
+			}
+			if !slices.Equal(path, tt.want) {
+				t.Errorf("expected path %v, got %v", tt.want, path)
+			}
+		})
+	}
+}
+
+func TestLifecycleSchema_StateMachine(t *testing.T) {
+	lifecycle := &LifecycleSchema{
+		States: []LifecycleState{
+			{Name: "New"},
+			{Name: "Started"},
+			{Name: "HotUpdating"},
+			{Name: "ColdUpdating"},
+			{Name: "Failed"},
+			{Name: "Deleted"},
+		},
+		Actions: []LifecycleAction{
+			{Name: "create", Transitions: []LifecycleTransition{
+				{From: "New", To: "Started"},
+				{From: "New", To: "Failed", OnError: true, OnErrorRegexp: "QUOTA_.*"},
+				{From: "New", To: "Failed", OnError: true},
+			}},
+			{Name: ServiceActionWarmUpdate, Transitions: []LifecycleTransition{{From: "Started", To: "HotUpdating"}}},
+			{Name: ServiceActionColdUpdate, Transitions: []LifecycleTransition{{From: "Started", To: "ColdUpdating"}}},
+			{Name: "settle", Transitions: []LifecycleTransition{
+				{From: "HotUpdating", To: "Started"},
+				{From: "ColdUpdating", To: "Started"},
+			}},
+			{Name: "delete", Transitions: []LifecycleTransition{
+				{From: "Started", To: "Deleted"},
+				{From: "Failed", To: "Deleted"},
+				{From: "ColdUpdating", To: "Failed", OnError: true},
+			}},
+		},
+		InitialState:   "New",
+		TerminalStates: []string{"Deleted"},
+		RunningStates:  []string{"Started", "HotUpdating"},
+	}
+
+	nodes := lifecycle.StateMachine()
+	if len(nodes) != len(lifecycle.States) {
+		t.Fatalf("expected %d states, got %d", len(lifecycle.States), len(nodes))
+	}
+
+	// Every state and action pair agrees with the validation of the actions and the resolution of their transitions
+	for _, node := range nodes {
+		for _, action := range lifecycle.Actions {
+			i := slices.IndexFunc(node.Transitions, func(e LifecycleEdge) bool { return e.Action == action.Name })
+			allowed := lifecycle.ValidateActionAllowed(node.Name, action.Name) == nil
+			if allowed != (i >= 0) {
+				t.Errorf("action %s from %s: allowed %v but listed %v", action.Name, node.Name, allowed, i >= 0)
+			}
+			if i >= 0 {
+				to, _ := lifecycle.ResolveNextState(node.Name, action.Name, nil)
+				if node.Transitions[i].To != to {
+					t.Errorf("action %s from %s: expected target %q, got %q", action.Name, node.Name, to, node.Transitions[i].To)
+				}
+			}
+		}
+	}
+
+	byName := map[string]LifecycleStateNode{}
+	for _, node := range nodes {
+		byName[node.Name] = node
+	}
+	if n := byName["New"]; !n.Initial || len(n.Transitions) != 1 || !slices.Equal(n.Transitions[0].ErrorTo, []string{"Failed"}) {
+		t.Errorf("unexpected New state: %+v", n)
+	}
+	started := byName["Started"]
+	if !started.Running || len(started.Transitions) != 3 {
+		t.Fatalf("unexpected Started state: %+v", started)
+	}
+	if e := started.Transitions[1]; e.Action != ServiceActionColdUpdate || e.To != "ColdUpdating" || !e.Update {
+		t.Errorf("unexpected cold update transition: %+v", e)
+	}
+	if e := started.Transitions[2]; e.Action != "delete" || e.Update {
+		t.Errorf("unexpected delete transition: %+v", e)
+	}
+	// Only an error transition leaves the state, the action is allowed without a success target
+	cold := byName["ColdUpdating"]
+	if len(cold.Transitions) != 2 || cold.Transitions[1].To != "" || !slices.Equal(cold.Transitions[1].ErrorTo, []string{"Failed"}) {
+		t.Errorf("unexpected ColdUpdating state: %+v", cold)
+	}
+	if n := byName["Deleted"]; !n.Terminal || len(n.Transitions) != 0 {
+		t.Errorf("unexpected Deleted state: %+v", n)
+	}
+}
+
+func TestLifecycleSchema_ValidateFailedState(t *testing.T) {
+	lifecycle := LifecycleSchema{
+		States:       []LifecycleState{{Name: "Started"}, {Name: "Failed"}},
+		Actions:      []LifecycleAction{{Name: "start", Transitions: []LifecycleTransition{{From: "Started", To: "Started"}}}},
+		InitialState: "Started",
+		FailedState:  "Failed",
+	}
+	if err := lifecycle.Validate(); err != nil {
+		t.Errorf("Validate() unexpected error: %v", err)
+	}
+
+	lifecycle.FailedState = "Broken"
+	if err := lifecycle.Validate(); err == nil {
+		t.Error("Validate() should reject a failed state missing from the states")
+	}
+}
@@ -3,9 +3,14 @@ package domain
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"maps"
+	"slices"
+	"strings"
 
 	"github.com/fulcrumproject/core/pkg/properties"
+	"github.com/fulcrumproject/core/pkg/schema"
 	"github.com/google/uuid"
 )
 
@@ -24,6 +29,8 @@ type ServiceOption struct {
 	Value               any             `json:"value" gorm:"type:jsonb;serializer:json;not null"`
 	Enabled             *bool           `json:"enabled" gorm:"not null;default:true"`
 	DisplayOrder        int             `json:"displayOrder" gorm:"default:0"`
+	// Cost added to the estimate of a service selecting the option, see CatalogCostEstimator
+	Cost float64 `json:"cost" gorm:"not null;default:0"`
 }
 
 // NewServiceOption creates a new service option without validation
@@ -35,6 +42,7 @@ func NewServiceOption(params CreateServiceOptionParams) *ServiceOption {
 		Value:               params.Value,
 		Enabled:             params.Enabled,
 		DisplayOrder:        params.DisplayOrder,
+		Cost:                params.Cost,
 	}
 }
 
@@ -57,6 +65,9 @@ func (so *ServiceOption) Validate() error {
 	if so.Value == nil {
 		return fmt.Errorf("service option value cannot be nil")
 	}
+	if so.Cost < 0 {
+		return fmt.Errorf("service option cost cannot be negative")
+	}
 	return nil
 }
 
@@ -74,9 +85,92 @@ func (so *ServiceOption) Update(params UpdateServiceOptionParams) {
 	if params.DisplayOrder != nil {
 		so.DisplayOrder = *params.DisplayOrder
 	}
+	if params.Cost != nil {
+		so.Cost = *params.Cost
+	}
 	// ProviderID and ServiceOptionTypeID cannot be updated
 }
 
+// ResolveServiceOptions returns the given properties with the values of the selected service options merged in
+// Each option must be an enabled option of the provider whose type is the serviceOption validator of exactly one
+// property of the service type. Two options setting the same property conflict, as does an option contradicting
+// a given value; the result still goes through the full property schema validation
+func ResolveServiceOptions(
+	ctx context.Context,
+	store Store,
+	serviceType *ServiceType,
+	providerID properties.UUID,
+	props properties.JSON,
+	optionIDs []properties.UUID,
+) (properties.JSON, error) {
+	if len(optionIDs) == 0 {
+		return props, nil
+	}
+
+	result := maps.Clone(props)
+	if result == nil {
+		result = make(properties.JSON)
+	}
+	selected := make(map[string]*ServiceOption)
+	for _, id := range optionIDs {
+		option, err := store.ServiceOptionRepo().Get(ctx, id)
+		if err != nil {
+			if errors.As(err, &NotFoundError{}) {
+				return nil, NewInvalidInputErrorf("service option %s does not exist", id)
+			}
+			return nil, err
+		}
+		if option.ProviderID != providerID {
+			return nil, NewInvalidInputErrorf("service option %q (%s) is not offered by the provider of the service", option.Name, id)
+		}
+		if option.Enabled == nil || !*option.Enabled {
+			return nil, NewInvalidInputErrorf("service option %q (%s) is disabled", option.Name, id)
+		}
+		optionType, err := store.ServiceOptionTypeRepo().Get(ctx, option.ServiceOptionTypeID)
+		if err != nil {
+			return nil, err
+		}
+
+		names := serviceOptionProperties(serviceType.PropertySchema, optionType.Type)
+		switch len(names) {
+		case 0:
+			return nil, NewInvalidInputErrorf("service option %q (%s) of type %s does not apply to service type %s", option.Name, id, optionType.Type, serviceType.Name)
+		case 1:
+		default:
+			return nil, NewInvalidInputErrorf("service option %q (%s) of type %s is ambiguous for service type %s, it applies to the properties %s", option.Name, id, optionType.Type, serviceType.Name, strings.Join(names, ", "))
+		}
+		name := names[0]
+
+		if previous, ok := selected[name]; ok {
+			if previous.ID == option.ID {
+				continue
+			}
+			return nil, NewInvalidInputErrorf("service options %q (%s) and %q (%s) conflict, both set property %s", previous.Name, previous.ID, option.Name, id, name)
+		}
+		if given, ok := props[name]; ok && !valuesEqual(given, option.Value) {
+			return nil, NewInvalidInputErrorf("service option %q (%s) conflicts with the value given to property %s", option.Name, id, name)
+		}
+		selected[name] = option
+		result[name] = option.Value
+	}
+	return result, nil
+}
+
+// serviceOptionProperties returns the sorted names of the properties validated by the serviceOption validator of the option type
+func serviceOptionProperties(propertySchema schema.Schema, optionType string) []string {
+	var names []string
+	for name, def := range propertySchema.Properties {
+		for _, validator := range def.Validators {
+			if validator.Type == "serviceOption" && validator.Config["value"] == optionType {
+				names = append(names, name)
+				break
+			}
+		}
+	}
+	slices.Sort(names)
+	return names
+}
+
 // ServiceOptionRepository defines the interface for the ServiceOption repository
 type ServiceOptionRepository interface {
 	ServiceOptionQuerier
@@ -122,6 +216,7 @@ type CreateServiceOptionParams struct {
 	Value               any             `json:"value"`
 	Enabled             *bool           `json:"enabled"`
 	DisplayOrder        int             `json:"displayOrder"`
+	Cost                float64         `json:"cost"`
 }
 
 type UpdateServiceOptionParams struct {
@@ -130,6 +225,7 @@ type UpdateServiceOptionParams struct {
 	Value        *any            `json:"value"`
 	Enabled      *bool           `json:"enabled"`
 	DisplayOrder *int            `json:"displayOrder"`
+	Cost         *float64        `json:"cost"`
 }
 
 // serviceOptionCommander is the concrete implementation of ServiceOptionCommander
@@ -2,12 +2,16 @@
 package domain
 
 import (
+	"context"
 	"testing"
 
 	"github.com/fulcrumproject/core/pkg/helpers"
 	"github.com/fulcrumproject/core/pkg/properties"
 	"github.com/google/uuid"
+	"github.com/fulcrumproject/core/pkg/schema"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 func TestServiceOption_TableName(t *testing.T) {
@@ -118,6 +122,19 @@ func TestServiceOption_Validate(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "Negative cost",
+			option: &ServiceOption{
+				ProviderID:          providerID,
+				ServiceOptionTypeID: optionTypeID,
+				Name:                "Ubuntu 22.04",
+				Value:               "ubuntu-22.04",
+				Enabled:             helpers.BoolPtr(true),
+				Cost:                -1,
+			},
+			wantErr:    true,
+			errMessage: "cost cannot be negative",
+		},
 	}
 
 	for _, tt := range tests {
@@ -220,3 +237,100 @@ func TestServiceOption_Update_Partial(t *testing.T) {
 	assert.Equal(t, 5, option.DisplayOrder)
 }
 
+func TestResolveServiceOptions(t *testing.T) {
+	ctx := context.Background()
+	providerID := properties.UUID(uuid.New())
+	osType := &ServiceOptionType{BaseEntity: BaseEntity{ID: uuid.New()}, Type: "os"}
+	sizeType := &ServiceOptionType{BaseEntity: BaseEntity{ID: uuid.New()}, Type: "machine_type"}
+	diskType := &ServiceOptionType{BaseEntity: BaseEntity{ID: uuid.New()}, Type: "disk"}
+	serviceType := &ServiceType{
+		Name: "VM",
+		PropertySchema: schema.Schema{Properties: map[string]schema.PropertyDefinition{
+			"os":   {Type: "string", Validators: []schema.ValidatorConfig{{Type: "serviceOption", Config: map[string]any{"value": "os"}}}},
+			"size": {Type: "string", Validators: []schema.ValidatorConfig{{Type: "serviceOption", Config: map[string]any{"value": "machine_type"}}}},
+			"name": {Type: "string"},
+		}},
+	}
+	newOption := func(name string, optionType *ServiceOptionType, value any) *ServiceOption {
+		return &ServiceOption{
+			BaseEntity:          BaseEntity{ID: uuid.New()},
+			ProviderID:          providerID,
+			ServiceOptionTypeID: optionType.ID,
+			Name:                name,
+			Value:               value,
+			Enabled:             helpers.BoolPtr(true),
+		}
+	}
+	ubuntu := newOption("Ubuntu", osType, "ubuntu-24.04")
+	debian := newOption("Debian", osType, "debian-12")
+	large := newOption("Large", sizeType, "large")
+	ssd := newOption("SSD", diskType, "ssd")
+	disabled := newOption("Windows", osType, "windows")
+	disabled.Enabled = helpers.BoolPtr(false)
+	foreign := newOption("Alpine", osType, "alpine")
+	foreign.ProviderID = properties.UUID(uuid.New())
+	missing := properties.UUID(uuid.New())
+
+	setup := func(t *testing.T) *MockStore {
+		ms := NewMockStore(t)
+		optionRepo := NewMockServiceOptionRepository(t)
+		optionTypeRepo := NewMockServiceOptionTypeRepository(t)
+		ms.EXPECT().ServiceOptionRepo().Return(optionRepo).Maybe()
+		ms.EXPECT().ServiceOptionTypeRepo().Return(optionTypeRepo).Maybe()
+		for _, option := range []*ServiceOption{ubuntu, debian, large, ssd, disabled, foreign} {
+			optionRepo.EXPECT().Get(mock.Anything, option.ID).Return(option, nil).Maybe()
+		}
+		optionRepo.EXPECT().Get(mock.Anything, missing).Return(nil, NewNotFoundErrorf("service option not found")).Maybe()
+		for _, optionType := range []*ServiceOptionType{osType, sizeType, diskType} {
+			optionTypeRepo.EXPECT().Get(mock.Anything, optionType.ID).Return(optionType, nil).Maybe()
+		}
+		return ms
+	}
+
+	t.Run("merges the option values into the properties", func(t *testing.T) {
+		given := properties.JSON{"name": "vm-1", "os": "ubuntu-24.04"}
+		props, err := ResolveServiceOptions(ctx, setup(t), serviceType, providerID, given, []properties.UUID{ubuntu.ID, large.ID})
+		require.NoError(t, err)
+		assert.Equal(t, properties.JSON{"name": "vm-1", "os": "ubuntu-24.04", "size": "large"}, props)
+		assert.Equal(t, properties.JSON{"name": "vm-1", "os": "ubuntu-24.04"}, given, "the given properties are left untouched")
+	})
+
+	t.Run("no options", func(t *testing.T) {
+		given := properties.JSON{"name": "vm-1"}
+		props, err := ResolveServiceOptions(ctx, NewMockStore(t), serviceType, providerID, given, nil)
+		require.NoError(t, err)
+		assert.Equal(t, given, props)
+	})
+
+	tests := []struct {
+		name      string
+		given     properties.JSON
+		optionIDs []properties.UUID
+		wantErr   string
+	}{
+		{name: "Unknown option", optionIDs: []properties.UUID{missing}, wantErr: "does not exist"},
+		{name: "Option of another provider", optionIDs: []properties.UUID{foreign.ID}, wantErr: "is not offered by the provider of the service"},
+		{name: "Disabled option", optionIDs: []properties.UUID{disabled.ID}, wantErr: "is disabled"},
+		{name: "Option not applying to the service type", optionIDs: []properties.UUID{ssd.ID}, wantErr: "of type disk does not apply to service type VM"},
+		{name: "Conflicting options", optionIDs: []properties.UUID{ubuntu.ID, debian.ID}, wantErr: "conflict, both set property os"},
+		{name: "Option conflicting with a given value", given: properties.JSON{"os": "fedora"}, optionIDs: []properties.UUID{ubuntu.ID}, wantErr: "conflicts with the value given to property os"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := ResolveServiceOptions(ctx, setup(t), serviceType, providerID, tc.given, tc.optionIDs)
+			assert.ErrorAs(t, err, &InvalidInputError{})
+			assert.ErrorContains(t, err, tc.wantErr)
+		})
+	}
+
+	t.Run("option type of several properties", func(t *testing.T) {
+		ambiguous := *serviceType
+		ambiguous.PropertySchema = schema.Schema{Properties: map[string]schema.PropertyDefinition{
+			"os":        serviceType.PropertySchema.Properties["os"],
+			"backupOs":  serviceType.PropertySchema.Properties["os"],
+			"otherName": {Type: "string"},
+		}}
+		_, err := ResolveServiceOptions(ctx, setup(t), &ambiguous, providerID, nil, []properties.UUID{ubuntu.ID})
+		assert.ErrorContains(t, err, "is ambiguous for service type VM, it applies to the properties backupOs, os")
+	})
+}
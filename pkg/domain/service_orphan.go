@@ -0,0 +1,64 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"github.com/fulcrumproject/core/pkg/properties"
+)
+
+// MarkOrphaned flags the service as orphaned and reports whether it changed, the first report is kept
+func (s *Service) MarkOrphaned() bool {
+	if s.Orphaned {
+		return false
+	}
+	now := time.Now()
+	s.Orphaned = true
+	s.OrphanedAt = &now
+	return true
+}
+
+// ClearOrphaned removes the orphaned flag and reports whether it changed
+func (s *Service) ClearOrphaned() bool {
+	if !s.Orphaned {
+		return false
+	}
+	s.Orphaned = false
+	s.OrphanedAt = nil
+	return true
+}
+
+// ReportOrphaned flags the service when its agent reports the backing resource no longer exists
+// The status is left as is, the operators decide to delete the service or to have the agent reconcile it
+func (s *serviceCommander) ReportOrphaned(ctx context.Context, id properties.UUID, reason string) (*Service, error) {
+	svc, err := s.store.ServiceRepo().Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if svc.IsDeleted() {
+		return nil, NewInvalidInputErrorf("service %s is deleted", id)
+	}
+
+	originalSvc := *svc
+	if !svc.MarkOrphaned() {
+		return svc, nil
+	}
+
+	err = s.store.Atomic(ctx, func(store Store) error {
+		if err := store.ServiceRepo().Save(ctx, svc); err != nil {
+			return err
+		}
+		eventEntry, err := NewEvent(EventTypeServiceOrphaned, WithInitiatorCtx(ctx), WithDiff(&originalSvc, svc), WithService(svc))
+		if err != nil {
+			return err
+		}
+		if reason != "" {
+			eventEntry.Payload["reason"] = reason
+		}
+		return store.EventRepo().Create(ctx, eventEntry)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return svc, nil
+}
@@ -0,0 +1,91 @@
+package domain
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/fulcrumproject/core/pkg/auth"
+	"github.com/fulcrumproject/core/pkg/properties"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestService_MarkOrphaned(t *testing.T) {
+	svc := &Service{Status: "Started"}
+
+	require.True(t, svc.MarkOrphaned())
+	require.NotNil(t, svc.OrphanedAt)
+	orphanedAt := *svc.OrphanedAt
+	assert.True(t, svc.Orphaned)
+	assert.Equal(t, "Started", svc.Status)
+
+	// The first report is kept
+	assert.False(t, svc.MarkOrphaned())
+	assert.Equal(t, orphanedAt, *svc.OrphanedAt)
+
+	assert.True(t, svc.ClearOrphaned())
+	assert.False(t, svc.Orphaned)
+	assert.Nil(t, svc.OrphanedAt)
+	assert.False(t, svc.ClearOrphaned())
+}
+
+func TestServiceCommander_ReportOrphaned(t *testing.T) {
+	agentID := properties.UUID(uuid.New())
+	identity := &auth.Identity{ID: uuid.New(), Role: auth.RoleAgent, Scope: auth.IdentityScope{AgentID: &agentID}}
+	ctx := auth.WithIdentity(context.Background(), identity)
+
+	setup := func(t *testing.T, svc *Service) (*MockStore, *MockServiceRepository, *MockEventRepository) {
+		ms := setupMockStore(t)
+		serviceRepo := NewMockServiceRepository(t)
+		eventRepo := NewMockEventRepository(t)
+		ms.EXPECT().ServiceRepo().Return(serviceRepo).Maybe()
+		ms.EXPECT().EventRepo().Return(eventRepo).Maybe()
+		serviceRepo.EXPECT().Get(mock.Anything, svc.ID).Return(svc, nil)
+		return ms, serviceRepo, eventRepo
+	}
+
+	t.Run("flags the service with an event", func(t *testing.T) {
+		svc := &Service{BaseEntity: BaseEntity{ID: uuid.New()}, Status: "Started", AgentID: agentID}
+		ms, serviceRepo, eventRepo := setup(t, svc)
+		serviceRepo.EXPECT().Save(mock.Anything, svc).Return(nil)
+		var event *Event
+		eventRepo.EXPECT().Create(mock.Anything, mock.Anything).
+			Run(func(_ context.Context, e *Event) { event = e }).
+			Return(nil)
+
+		result, err := NewServiceCommander(ms, nil, nil, 0, JobRetryPolicy{}, nil).ReportOrphaned(ctx, svc.ID, "vm not found")
+		require.NoError(t, err)
+		assert.True(t, result.Orphaned)
+		assert.NotNil(t, result.OrphanedAt)
+		assert.Equal(t, "Started", result.Status)
+
+		require.NotNil(t, event)
+		assert.Equal(t, EventTypeServiceOrphaned, event.Type)
+		assert.Equal(t, identity.ID.String(), event.InitiatorID)
+		assert.Equal(t, "vm not found", event.Payload["reason"])
+		assert.Contains(t, event.Payload, "diff")
+	})
+
+	t.Run("an orphaned service is not reported again", func(t *testing.T) {
+		orphanedAt := time.Now()
+		svc := &Service{BaseEntity: BaseEntity{ID: uuid.New()}, AgentID: agentID, Orphaned: true, OrphanedAt: &orphanedAt}
+		ms, _, _ := setup(t, svc)
+
+		result, err := NewServiceCommander(ms, nil, nil, 0, JobRetryPolicy{}, nil).ReportOrphaned(ctx, svc.ID, "")
+		require.NoError(t, err)
+		assert.Equal(t, &orphanedAt, result.OrphanedAt)
+	})
+
+	t.Run("deleted service", func(t *testing.T) {
+		deletedAt := time.Now()
+		svc := &Service{BaseEntity: BaseEntity{ID: uuid.New()}, AgentID: agentID, DeletedAt: &deletedAt}
+		ms, _, _ := setup(t, svc)
+
+		_, err := NewServiceCommander(ms, nil, nil, 0, JobRetryPolicy{}, nil).ReportOrphaned(ctx, svc.ID, "")
+		assert.ErrorAs(t, err, &InvalidInputError{})
+		assert.False(t, svc.Orphaned)
+	})
+}
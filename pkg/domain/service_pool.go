@@ -14,6 +14,10 @@ const (
 	EventTypeServicePoolCreated EventType = "service_pool.created"
 	EventTypeServicePoolUpdated EventType = "service_pool.updated"
 	EventTypeServicePoolDeleted EventType = "service_pool.deleted"
+	// EventTypePoolExhausted is emitted when an allocation fails because the pool has no free value
+	EventTypePoolExhausted EventType = "service_pool.exhausted"
+	// EventTypePoolLowWatermark is emitted when an allocation drops the free capacity of the pool below the low watermark of its pool set
+	EventTypePoolLowWatermark EventType = "service_pool.low_watermark"
 )
 
 // PoolGeneratorType represents the type of pool generator
@@ -123,6 +127,10 @@ type ServicePoolRepository interface {
 	Create(ctx context.Context, pool *ServicePool) error
 	Update(ctx context.Context, pool *ServicePool) error
 	Delete(ctx context.Context, id properties.UUID) error
+
+	// ReserveValue atomically allocates the first unused value of the pool to the service property
+	// Concurrent reservations never return the same value, PoolExhaustedError is returned when none is left
+	ReserveValue(ctx context.Context, poolID, serviceID properties.UUID, propertyName string) (*ServicePoolValue, error)
 }
 
 // ServicePoolQuerier provides read-only access to ServicePool entities
@@ -25,12 +25,14 @@ type PoolGeneratorFactory interface {
 
 // DefaultGeneratorFactory is the default implementation of PoolGeneratorFactory
 type DefaultGeneratorFactory struct {
+	poolRepo  ServicePoolRepository
 	valueRepo ServicePoolValueRepository
 }
 
 // NewDefaultGeneratorFactory creates a new DefaultGeneratorFactory
-func NewDefaultGeneratorFactory(valueRepo ServicePoolValueRepository) *DefaultGeneratorFactory {
+func NewDefaultGeneratorFactory(poolRepo ServicePoolRepository, valueRepo ServicePoolValueRepository) *DefaultGeneratorFactory {
 	return &DefaultGeneratorFactory{
+		poolRepo:  poolRepo,
 		valueRepo: valueRepo,
 	}
 }
@@ -39,7 +41,7 @@ func NewDefaultGeneratorFactory(valueRepo ServicePoolValueRepository) *DefaultGe
 func (f *DefaultGeneratorFactory) CreateGenerator(pool *ServicePool) (PoolGenerator, error) {
 	switch pool.GeneratorType {
 	case PoolGeneratorList:
-		return NewListGenerator(f.valueRepo, pool.ID), nil
+		return NewListGenerator(f.poolRepo, f.valueRepo, pool.ID), nil
 	case PoolGeneratorSubnet:
 		if pool.GeneratorConfig == nil {
 			return nil, NewInvalidInputErrorf("subnet pool missing generator config")
@@ -11,17 +11,31 @@ import (
 // ListGenerator allocates values from a pre-configured list
 type ListGenerator struct {
 	*PoolListGenerator[*ServicePoolValue]
-	repo ServicePoolValueRepository
+	poolRepo ServicePoolRepository
+	repo     ServicePoolValueRepository
+	poolID   properties.UUID
 }
 
 // NewListGenerator creates a new list-based generator
-func NewListGenerator(valueRepo ServicePoolValueRepository, poolID properties.UUID) *ListGenerator {
+func NewListGenerator(poolRepo ServicePoolRepository, valueRepo ServicePoolValueRepository, poolID properties.UUID) *ListGenerator {
 	return &ListGenerator{
 		PoolListGenerator: NewPoolListGenerator(valueRepo, poolID),
+		poolRepo:          poolRepo,
 		repo:              valueRepo,
+		poolID:            poolID,
 	}
 }
 
+// Allocate reserves an unused value of the list with a row lock, so services created
+// concurrently against the same pool never receive the same value
+func (g *ListGenerator) Allocate(ctx context.Context, serviceID properties.UUID, propertyName string) (any, error) {
+	value, err := g.poolRepo.ReserveValue(ctx, g.poolID, serviceID, propertyName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reserve value: %w", err)
+	}
+	return value.Value, nil
+}
+
 func (g *ListGenerator) Release(ctx context.Context, serviceID properties.UUID) error {
 	allocatedValues, err := g.repo.FindByService(ctx, serviceID)
 	if err != nil {
@@ -3,6 +3,7 @@ package domain
 
 import (
 	"context"
+	"errors"
 	"testing"
 	"time"
 
@@ -22,50 +23,45 @@ func TestListGenerator_Allocate(t *testing.T) {
 
 	tests := []struct {
 		name          string
-		setupMock     func(*MockServicePoolValueRepository)
+		setupMock     func(*MockServicePoolRepository)
 		expectedValue any
 		expectErr     bool
 		errMsg        string
+		exhausted     bool
 	}{
 		{
-			name: "Success - allocate first available value",
-			setupMock: func(repo *MockServicePoolValueRepository) {
-				availableValue := &ServicePoolValue{
-					BaseEntity:    BaseEntity{ID: properties.UUID(uuid.New())},
-					Name:          "IP 1",
-					Value:         "192.168.1.10",
-					ServicePoolID: poolID,
-				}
-				repo.EXPECT().
-					FindAvailable(ctx, poolID).
-					Return([]*ServicePoolValue{availableValue}, nil)
-
+			name: "Success - reserve first available value",
+			setupMock: func(repo *MockServicePoolRepository) {
 				repo.EXPECT().
-					Update(ctx, mock.MatchedBy(func(v *ServicePoolValue) bool {
-						return v.ServiceID != nil && *v.ServiceID == serviceID &&
-							v.PropertyName != nil && *v.PropertyName == propertyName &&
-							v.AllocatedAt != nil
-					})).
-					Return(nil)
+					ReserveValue(ctx, poolID, serviceID, propertyName).
+					Return(&ServicePoolValue{
+						BaseEntity:    BaseEntity{ID: properties.UUID(uuid.New())},
+						Name:          "IP 1",
+						Value:         "192.168.1.10",
+						ServicePoolID: poolID,
+						ServiceID:     &serviceID,
+						PropertyName:  &propertyName,
+					}, nil)
 			},
 			expectedValue: "192.168.1.10",
 			expectErr:     false,
 		},
 		{
-			name: "Error - no available values",
-			setupMock: func(repo *MockServicePoolValueRepository) {
+			name: "Error - pool exhausted",
+			setupMock: func(repo *MockServicePoolRepository) {
 				repo.EXPECT().
-					FindAvailable(ctx, poolID).
-					Return([]*ServicePoolValue{}, nil)
+					ReserveValue(ctx, poolID, serviceID, propertyName).
+					Return(nil, NewPoolExhaustedError(poolID))
 			},
 			expectErr: true,
 			errMsg:    "no available values in pool",
+			exhausted: true,
 		},
 		{
-			name: "Error - repository query fails",
-			setupMock: func(repo *MockServicePoolValueRepository) {
+			name: "Error - reservation fails",
+			setupMock: func(repo *MockServicePoolRepository) {
 				repo.EXPECT().
-					FindAvailable(ctx, poolID).
+					ReserveValue(ctx, poolID, serviceID, propertyName).
 					Return(nil, NewInvalidInputErrorf("database error"))
 			},
 			expectErr: true,
@@ -75,15 +71,16 @@ func TestListGenerator_Allocate(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			repo := NewMockServicePoolValueRepository(t)
-			tt.setupMock(repo)
+			poolRepo := NewMockServicePoolRepository(t)
+			tt.setupMock(poolRepo)
 
-			generator := NewListGenerator(repo, poolID)
+			generator := NewListGenerator(poolRepo, NewMockServicePoolValueRepository(t), poolID)
 			value, err := generator.Allocate(ctx, serviceID, propertyName)
 
 			if tt.expectErr {
 				require.Error(t, err)
 				assert.Contains(t, err.Error(), tt.errMsg)
+				assert.Equal(t, tt.exhausted, errors.As(err, &PoolExhaustedError{}))
 				assert.Nil(t, value)
 			} else {
 				require.NoError(t, err)
@@ -171,7 +168,7 @@ func TestListGenerator_Release(t *testing.T) {
 			repo := NewMockServicePoolValueRepository(t)
 			tt.setupMock(repo)
 
-			generator := NewListGenerator(repo, poolID)
+			generator := NewListGenerator(NewMockServicePoolRepository(t), repo, poolID)
 			err := generator.Release(ctx, serviceID)
 
 			if tt.expectErr {
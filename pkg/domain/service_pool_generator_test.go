@@ -11,8 +11,9 @@ import (
 )
 
 func TestDefaultGeneratorFactory_CreateGenerator(t *testing.T) {
+	poolRepo := NewMockServicePoolRepository(t)
 	valueRepo := NewMockServicePoolValueRepository(t)
-	factory := NewDefaultGeneratorFactory(valueRepo)
+	factory := NewDefaultGeneratorFactory(poolRepo, valueRepo)
 
 	tests := []struct {
 		name         string
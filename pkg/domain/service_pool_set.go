@@ -21,24 +21,30 @@ type ServicePoolSet struct {
 	Name       string          `json:"name" gorm:"not null"`
 	ProviderID properties.UUID `json:"providerId" gorm:"not null;index"`
 	Provider   *Participant    `json:"-" gorm:"foreignKey:ProviderID"`
+
+	// LowWatermarkPercent is the free capacity percentage of a pool below which a low-watermark event is emitted, 0 disables it
+	LowWatermarkPercent int `json:"lowWatermarkPercent" gorm:"not null;default:0"`
 }
 
 // CreateServicePoolSetParams defines parameters for creating a ServicePoolSet
 type CreateServicePoolSetParams struct {
-	Name       string
-	ProviderID properties.UUID
+	Name                string
+	ProviderID          properties.UUID
+	LowWatermarkPercent int
 }
 
 // UpdateServicePoolSetParams defines parameters for updating a ServicePoolSet
 type UpdateServicePoolSetParams struct {
-	Name *string
+	Name                *string
+	LowWatermarkPercent *int
 }
 
 // NewServicePoolSet creates a new service pool set without validation
 func NewServicePoolSet(params CreateServicePoolSetParams) *ServicePoolSet {
 	return &ServicePoolSet{
-		Name:       params.Name,
-		ProviderID: params.ProviderID,
+		Name:                params.Name,
+		ProviderID:          params.ProviderID,
+		LowWatermarkPercent: params.LowWatermarkPercent,
 	}
 }
 
@@ -55,6 +61,9 @@ func (sps *ServicePoolSet) Validate() error {
 	if sps.ProviderID == (properties.UUID{}) {
 		return fmt.Errorf("provider ID cannot be empty")
 	}
+	if sps.LowWatermarkPercent < 0 || sps.LowWatermarkPercent > 100 {
+		return fmt.Errorf("low watermark percent must be between 0 and 100")
+	}
 	return nil
 }
 
@@ -63,6 +72,14 @@ func (sps *ServicePoolSet) Update(params UpdateServicePoolSetParams) {
 	if params.Name != nil {
 		sps.Name = *params.Name
 	}
+	if params.LowWatermarkPercent != nil {
+		sps.LowWatermarkPercent = *params.LowWatermarkPercent
+	}
+}
+
+// BelowLowWatermark returns true when the available values are under the low watermark of the pool capacity
+func (sps *ServicePoolSet) BelowLowWatermark(available, total int64) bool {
+	return sps.LowWatermarkPercent > 0 && total > 0 && available*100 < int64(sps.LowWatermarkPercent)*total
 }
 
 // ServicePoolSetRepository manages ServicePoolSet entities
@@ -4,6 +4,7 @@ package domain
 import (
 	"testing"
 
+	"github.com/fulcrumproject/core/pkg/helpers"
 	"github.com/fulcrumproject/core/pkg/properties"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
@@ -59,6 +60,35 @@ func TestServicePoolSet_Validate(t *testing.T) {
 			wantError: true,
 			errorMsg:  "provider ID cannot be empty",
 		},
+		{
+			name: "low watermark at bounds",
+			poolSet: &ServicePoolSet{
+				Name:                "Test Pool Set",
+				ProviderID:          providerID,
+				LowWatermarkPercent: 100,
+			},
+			wantError: false,
+		},
+		{
+			name: "negative low watermark",
+			poolSet: &ServicePoolSet{
+				Name:                "Test Pool Set",
+				ProviderID:          providerID,
+				LowWatermarkPercent: -1,
+			},
+			wantError: true,
+			errorMsg:  "low watermark percent must be between 0 and 100",
+		},
+		{
+			name: "low watermark above 100",
+			poolSet: &ServicePoolSet{
+				Name:                "Test Pool Set",
+				ProviderID:          providerID,
+				LowWatermarkPercent: 101,
+			},
+			wantError: true,
+			errorMsg:  "low watermark percent must be between 0 and 100",
+		},
 	}
 
 	for _, tt := range tests {
@@ -74,6 +104,37 @@ func TestServicePoolSet_Validate(t *testing.T) {
 	}
 }
 
+func TestServicePoolSet_Update(t *testing.T) {
+	poolSet := &ServicePoolSet{Name: "Pool Set", LowWatermarkPercent: 10}
+
+	poolSet.Update(UpdateServicePoolSetParams{LowWatermarkPercent: helpers.IntPtr(25)})
+	assert.Equal(t, "Pool Set", poolSet.Name)
+	assert.Equal(t, 25, poolSet.LowWatermarkPercent)
+}
+
+func TestServicePoolSet_BelowLowWatermark(t *testing.T) {
+	tests := []struct {
+		name      string
+		percent   int
+		available int64
+		total     int64
+		want      bool
+	}{
+		{name: "disabled", percent: 0, available: 0, total: 10, want: false},
+		{name: "above threshold", percent: 20, available: 3, total: 10, want: false},
+		{name: "at threshold", percent: 20, available: 2, total: 10, want: false},
+		{name: "below threshold", percent: 20, available: 1, total: 10, want: true},
+		{name: "empty pool", percent: 20, available: 0, total: 0, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			poolSet := &ServicePoolSet{LowWatermarkPercent: tt.percent}
+			assert.Equal(t, tt.want, poolSet.BelowLowWatermark(tt.available, tt.total))
+		})
+	}
+}
+
 func TestServicePoolSet_TableName(t *testing.T) {
 	poolSet := &ServicePoolSet{}
 	assert.Equal(t, "service_pool_sets", poolSet.TableName())
@@ -0,0 +1,153 @@
+// Documentation of the service property schema for the clients building forms
+package domain
+
+import (
+	"context"
+	"slices"
+	"sort"
+
+	"github.com/fulcrumproject/core/pkg/schema"
+)
+
+// ServicePropertySchemaDoc is the resolved property schema of a service type
+type ServicePropertySchemaDoc struct {
+	ServiceType   *ServiceType
+	State         string // Service state the editability is computed against, empty for creation
+	Actor         ActorType
+	Properties    []ServicePropertyDoc
+	Validators    []schema.SchemaValidatorConfig
+	SchemaVersion int
+}
+
+// ServicePropertyDoc describes a property with the rules the engine enforces on it
+type ServicePropertyDoc struct {
+	Name       string
+	Path       string // Dot separated path from the root of the properties, [] for the array items
+	Definition schema.PropertyDefinition
+	// Source lists the actors allowed to set the property, nil when any actor is
+	Source []ActorType
+	// Updatable is false for the immutable properties
+	Updatable bool
+	// UpdatableIn lists the service states allowing updates, nil when any state does
+	UpdatableIn []string
+	// Editable tells whether the actor can set the property, on creation or on update in the state
+	Editable   bool
+	Properties []ServicePropertyDoc
+	Items      *ServicePropertyDoc
+}
+
+// NewServicePropertySchemaDoc resolves the property schema of the service type for the actor,
+// the editability is computed by the authorizers of the engine for an update in the given state,
+// or for the creation when the state is empty
+func NewServicePropertySchemaDoc(
+	ctx context.Context,
+	engine *schema.Engine[ServicePropertyContext],
+	serviceType *ServiceType,
+	actor ActorType,
+	state string,
+) (*ServicePropertySchemaDoc, error) {
+	if state != "" && !serviceType.LifecycleSchema.HasState(state) {
+		return nil, NewInvalidInputErrorf("state %s is not defined by the lifecycle of service type %s", state, serviceType.Name)
+	}
+
+	operation := schema.OperationCreate
+	if state != "" {
+		operation = schema.OperationUpdate
+	}
+	b := servicePropertyDocBuilder{
+		engine:    engine,
+		schemaCtx: ServicePropertyContext{Actor: actor, ServiceStatus: state},
+		operation: operation,
+	}
+	return &ServicePropertySchemaDoc{
+		ServiceType:   serviceType,
+		State:         state,
+		Actor:         actor,
+		Properties:    b.properties(ctx, "", serviceType.PropertySchema.Properties, true),
+		Validators:    serviceType.PropertySchema.Validators,
+		SchemaVersion: serviceType.SchemaVersion,
+	}, nil
+}
+
+type servicePropertyDocBuilder struct {
+	engine    *schema.Engine[ServicePropertyContext]
+	schemaCtx ServicePropertyContext
+	operation schema.Operation
+}
+
+// properties documents the properties sorted by name, a property is only editable within an editable parent
+func (b servicePropertyDocBuilder) properties(ctx context.Context, parentPath string, defs map[string]schema.PropertyDefinition, parentEditable bool) []ServicePropertyDoc {
+	if len(defs) == 0 {
+		return nil
+	}
+	names := make([]string, 0, len(defs))
+	for name := range defs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	docs := make([]ServicePropertyDoc, len(names))
+	for i, name := range names {
+		path := name
+		if parentPath != "" {
+			path = parentPath + "." + name
+		}
+		docs[i] = b.property(ctx, name, path, defs[name], parentEditable, true)
+	}
+	return docs
+}
+
+// property documents a property, its own rules are only checked when the engine enforces them
+func (b servicePropertyDocBuilder) property(ctx context.Context, name, path string, def schema.PropertyDefinition, parentEditable, checked bool) ServicePropertyDoc {
+	doc := ServicePropertyDoc{
+		Name:       name,
+		Path:       path,
+		Definition: def,
+		Updatable:  !def.Immutable,
+	}
+	for _, authorizer := range def.Authorizers {
+		switch authorizer.Type {
+		case schema.AuthorizerTypeActor:
+			actors := make([]ActorType, 0)
+			for _, actor := range authorizer.Actors() {
+				actors = append(actors, ActorType(actor))
+			}
+			doc.Source = intersect(doc.Source, actors)
+		case "state":
+			doc.UpdatableIn = intersect(doc.UpdatableIn, authorizer.Strings("allowedStates"))
+		}
+	}
+
+	doc.Editable = parentEditable
+	if checked {
+		doc.Editable = doc.Editable &&
+			(b.operation == schema.OperationCreate || doc.Updatable) &&
+			b.engine.Authorize(ctx, b.schemaCtx, b.operation, path, def) == nil
+	}
+
+	// The definition is documented through the nested docs
+	doc.Definition.Properties = nil
+	doc.Definition.Items = nil
+	doc.Properties = b.properties(ctx, path, def.Properties, doc.Editable)
+	if def.Items != nil {
+		// The engine only authorizes the array as a whole, not its items
+		items := b.property(ctx, "", path+"[]", *def.Items, doc.Editable, false)
+		doc.Items = &items
+	}
+	return doc
+}
+
+// intersect returns the values present in both slices, as several authorizers of a type must all pass;
+// a nil current slice stands for no restriction yet
+func intersect[T comparable](current, values []T) []T {
+	if current == nil {
+		return values
+	}
+	result := make([]T, 0)
+	for _, v := range current {
+		if slices.Contains(values, v) {
+			result = append(result, v)
+		}
+	}
+	return result
+}
@@ -0,0 +1,146 @@
+package domain
+
+import (
+	"context"
+	"testing"
+
+	"github.com/fulcrumproject/core/pkg/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newSchemaDocServiceType() *ServiceType {
+	return &ServiceType{
+		Name: "vm",
+		PropertySchema: schema.Schema{
+			Properties: map[string]schema.PropertyDefinition{
+				"cpu": {
+					Type:     "integer",
+					Label:    "CPU",
+					Required: true,
+					Default:  2,
+					Authorizers: []schema.AuthorizerConfig{
+						{Type: "state", Config: map[string]any{"allowedStates": []any{"New", "Stopped"}}},
+					},
+					Validators: []schema.ValidatorConfig{{Type: "min", Config: map[string]any{"value": 1}}},
+				},
+				"image": {Type: "string", Immutable: true},
+				"ip": {
+					Type: "string",
+					Authorizers: []schema.AuthorizerConfig{
+						{Type: "actor", Config: map[string]any{"actors": []any{"agent", "system"}}},
+					},
+				},
+				"network": {
+					Type: "object",
+					Authorizers: []schema.AuthorizerConfig{
+						{Type: "state", Config: map[string]any{"allowedStates": []any{"Stopped"}}},
+					},
+					Properties: map[string]schema.PropertyDefinition{
+						"vlan": {Type: "integer"},
+					},
+				},
+				"tags": {
+					Type:  "array",
+					Items: &schema.PropertyDefinition{Type: "string"},
+				},
+			},
+		},
+		LifecycleSchema: LifecycleSchema{
+			States:       []LifecycleState{{Name: "New"}, {Name: "Started"}, {Name: "Stopped"}},
+			InitialState: "New",
+		},
+		SchemaVersion: 2,
+	}
+}
+
+func TestNewServicePropertySchemaDoc(t *testing.T) {
+	engine := NewServicePropertyEngine(nil)
+	serviceType := newSchemaDocServiceType()
+
+	findProp := func(t *testing.T, docs []ServicePropertyDoc, name string) ServicePropertyDoc {
+		t.Helper()
+		for _, doc := range docs {
+			if doc.Name == name {
+				return doc
+			}
+		}
+		require.Failf(t, "property not found", "property %s", name)
+		return ServicePropertyDoc{}
+	}
+
+	t.Run("creation", func(t *testing.T) {
+		doc, err := NewServicePropertySchemaDoc(context.Background(), engine, serviceType, ActorUser, "")
+		require.NoError(t, err)
+		assert.Equal(t, 2, doc.SchemaVersion)
+		assert.Empty(t, doc.State)
+
+		names := make([]string, len(doc.Properties))
+		for i, p := range doc.Properties {
+			names[i] = p.Name
+		}
+		assert.Equal(t, []string{"cpu", "image", "ip", "network", "tags"}, names)
+
+		cpu := findProp(t, doc.Properties, "cpu")
+		assert.Equal(t, "CPU", cpu.Definition.Label)
+		assert.Equal(t, 2, cpu.Definition.Default)
+		assert.True(t, cpu.Updatable)
+		assert.Equal(t, []string{"New", "Stopped"}, cpu.UpdatableIn)
+		assert.Nil(t, cpu.Source)
+		assert.True(t, cpu.Editable)
+
+		image := findProp(t, doc.Properties, "image")
+		assert.False(t, image.Updatable)
+		assert.True(t, image.Editable, "immutable properties are set on creation")
+
+		ip := findProp(t, doc.Properties, "ip")
+		assert.Equal(t, []ActorType{ActorAgent, ActorSystem}, ip.Source)
+		assert.False(t, ip.Editable)
+
+		network := findProp(t, doc.Properties, "network")
+		require.Len(t, network.Properties, 1)
+		assert.Equal(t, "network.vlan", network.Properties[0].Path)
+		assert.True(t, network.Properties[0].Editable)
+		assert.Nil(t, network.Definition.Properties)
+
+		tags := findProp(t, doc.Properties, "tags")
+		require.NotNil(t, tags.Items)
+		assert.Equal(t, "tags[]", tags.Items.Path)
+		assert.True(t, tags.Items.Editable)
+	})
+
+	t.Run("update in a state", func(t *testing.T) {
+		doc, err := NewServicePropertySchemaDoc(context.Background(), engine, serviceType, ActorUser, "Started")
+		require.NoError(t, err)
+		assert.Equal(t, "Started", doc.State)
+
+		assert.False(t, findProp(t, doc.Properties, "cpu").Editable)
+		assert.False(t, findProp(t, doc.Properties, "image").Editable)
+		assert.False(t, findProp(t, doc.Properties, "ip").Editable)
+		network := findProp(t, doc.Properties, "network")
+		assert.False(t, network.Editable)
+		assert.False(t, network.Properties[0].Editable, "nested properties follow their parent")
+		assert.True(t, findProp(t, doc.Properties, "tags").Editable)
+	})
+
+	t.Run("update in a state allowing the changes", func(t *testing.T) {
+		doc, err := NewServicePropertySchemaDoc(context.Background(), engine, serviceType, ActorUser, "Stopped")
+		require.NoError(t, err)
+
+		assert.True(t, findProp(t, doc.Properties, "cpu").Editable)
+		assert.True(t, findProp(t, doc.Properties, "network").Properties[0].Editable)
+	})
+
+	t.Run("agent actor", func(t *testing.T) {
+		doc, err := NewServicePropertySchemaDoc(context.Background(), engine, serviceType, ActorAgent, "Started")
+		require.NoError(t, err)
+
+		assert.True(t, findProp(t, doc.Properties, "ip").Editable)
+	})
+
+	t.Run("unknown state", func(t *testing.T) {
+		_, err := NewServicePropertySchemaDoc(context.Background(), engine, serviceType, ActorUser, "Running")
+		require.Error(t, err)
+		assert.IsType(t, InvalidInputError{}, err)
+	})
+}
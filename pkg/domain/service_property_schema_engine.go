@@ -16,14 +16,16 @@ func buildServicePropertyAuthorizerRegistry() map[string]schema.Authorizer[Servi
 func buildServicePropertyValidatorRegistry() map[string]schema.PropertyValidator[ServicePropertyContext] {
 	return map[string]schema.PropertyValidator[ServicePropertyContext]{
 		// Generic validators from pkg/schema
-		"minLength": &schema.MinLengthValidator[ServicePropertyContext]{},
-		"maxLength": &schema.MaxLengthValidator[ServicePropertyContext]{},
-		"pattern":   &schema.PatternValidator[ServicePropertyContext]{},
-		"enum":      &schema.EnumValidator[ServicePropertyContext]{},
-		"min":       &schema.MinValidator[ServicePropertyContext]{},
-		"max":       &schema.MaxValidator[ServicePropertyContext]{},
-		"minItems":  &schema.MinItemsValidator[ServicePropertyContext]{},
-		"maxItems":  &schema.MaxItemsValidator[ServicePropertyContext]{},
+		"minLength":   &schema.MinLengthValidator[ServicePropertyContext]{},
+		"maxLength":   &schema.MaxLengthValidator[ServicePropertyContext]{},
+		"pattern":     &schema.PatternValidator[ServicePropertyContext]{},
+		"format":      &schema.FormatValidator[ServicePropertyContext]{},
+		"enum":        &schema.EnumValidator[ServicePropertyContext]{},
+		"min":         &schema.MinValidator[ServicePropertyContext]{},
+		"max":         &schema.MaxValidator[ServicePropertyContext]{},
+		"minItems":    &schema.MinItemsValidator[ServicePropertyContext]{},
+		"maxItems":    &schema.MaxItemsValidator[ServicePropertyContext]{},
+		"uniqueItems": &schema.UniqueItemsValidator[ServicePropertyContext]{},
 
 		// Domain-specific validators
 		"serviceOption":    NewServiceOptionValidator(),
@@ -35,6 +37,7 @@ func buildServicePropertyValidatorRegistry() map[string]schema.PropertyValidator
 func buildServicePropertySchemaValidatorRegistry() map[string]schema.SchemaValidator[ServicePropertyContext] {
 	return map[string]schema.SchemaValidator[ServicePropertyContext]{
 		"exactlyOne":   &schema.ExactlyOneValidator[ServicePropertyContext]{},
+		"compare":      &schema.CompareValidator[ServicePropertyContext]{},
 		"uniqueValues": &schema.UniqueValuesValidator[ServicePropertyContext]{},
 	}
 }
@@ -5,6 +5,7 @@ import (
 	"context"
 	"fmt"
 
+	"github.com/fulcrumproject/core/pkg/properties"
 	"github.com/fulcrumproject/core/pkg/schema"
 	"github.com/google/uuid"
 )
@@ -71,7 +72,7 @@ func (g *SchemaPoolGenerator) Generate(
 	}
 
 	// Create generator and allocate
-	factory := NewDefaultGeneratorFactory(schemaCtx.Store.ServicePoolValueRepo())
+	factory := NewDefaultGeneratorFactory(schemaCtx.Store.ServicePoolRepo(), schemaCtx.Store.ServicePoolValueRepo())
 	generator, err := factory.CreateGenerator(targetPool)
 	if err != nil {
 		return nil, false, fmt.Errorf("%s: failed to create generator for pool: %w", propPath, err)
@@ -83,9 +84,55 @@ func (g *SchemaPoolGenerator) Generate(
 		return nil, false, fmt.Errorf("%s: failed to allocate from pool: %w", propPath, err)
 	}
 
+	// Checked in the allocation transaction so the event matches the pool state it reports
+	if targetPool.GeneratorType == PoolGeneratorList {
+		if err := emitPoolLowWatermark(ctx, schemaCtx.Store, *poolSetID, targetPool); err != nil {
+			return nil, false, fmt.Errorf("%s: %w", propPath, err)
+		}
+	}
+
 	return allocatedValue, true, nil
 }
 
+// emitPoolLowWatermark creates a low-watermark event when the last allocation dropped
+// the free capacity of the pool below the threshold of its pool set
+func emitPoolLowWatermark(ctx context.Context, store Store, poolSetID properties.UUID, pool *ServicePool) error {
+	poolSet, err := store.ServicePoolSetRepo().Get(ctx, poolSetID)
+	if err != nil {
+		return fmt.Errorf("failed to get pool set: %w", err)
+	}
+	if poolSet.LowWatermarkPercent == 0 {
+		return nil
+	}
+
+	total, err := store.ServicePoolValueRepo().CountByPool(ctx, pool.ID)
+	if err != nil {
+		return fmt.Errorf("failed to count pool values: %w", err)
+	}
+	available, err := store.ServicePoolValueRepo().CountAvailable(ctx, pool.ID)
+	if err != nil {
+		return fmt.Errorf("failed to count available pool values: %w", err)
+	}
+
+	// Only the allocation crossing the threshold emits, not every allocation below it
+	if !poolSet.BelowLowWatermark(available, total) || poolSet.BelowLowWatermark(available+1, total) {
+		return nil
+	}
+
+	event, err := NewEvent(EventTypePoolLowWatermark, WithServicePool(pool))
+	if err != nil {
+		return err
+	}
+	event.Payload = properties.JSON{
+		"servicePoolSetId":    poolSet.ID,
+		"poolType":            pool.Type,
+		"available":           available,
+		"total":               total,
+		"lowWatermarkPercent": poolSet.LowWatermarkPercent,
+	}
+	return store.EventRepo().Create(ctx, event)
+}
+
 // ValidateConfig validates the pool generator configuration
 func (g *SchemaPoolGenerator) ValidateConfig(propPath string, config map[string]any) error {
 	_, err := parsePoolTypeConfig(config)
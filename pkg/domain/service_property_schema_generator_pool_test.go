@@ -46,11 +46,12 @@ func TestSchemaPoolGenerator_Generate(t *testing.T) {
 				}
 
 				poolRepo.On("ListByPoolSet", ctx, poolSetID).Return([]*ServicePool{pool}, nil)
-				valueRepo.On("FindAvailable", ctx, poolID).Return([]*ServicePoolValue{
-					{BaseEntity: BaseEntity{ID: uuid.New()}, Value: "192.168.1.10"},
-				}, nil)
-				valueRepo.On("Update", ctx, mock.AnythingOfType("*domain.ServicePoolValue")).Return(nil)
+				poolRepo.On("ReserveValue", ctx, poolID, serviceID, "testProp").Return(
+					&ServicePoolValue{BaseEntity: BaseEntity{ID: uuid.New()}, Value: "192.168.1.10"}, nil)
+				poolSetRepo := NewMockServicePoolSetRepository(t)
+				poolSetRepo.On("Get", ctx, poolSetID).Return(&ServicePoolSet{BaseEntity: BaseEntity{ID: poolSetID}}, nil)
 
+				store.On("ServicePoolSetRepo").Return(poolSetRepo)
 				store.On("ServicePoolRepo").Return(poolRepo)
 				store.On("ServicePoolValueRepo").Return(valueRepo)
 			},
@@ -58,6 +59,104 @@ func TestSchemaPoolGenerator_Generate(t *testing.T) {
 			wantGen:   true,
 			wantErr:   false,
 		},
+		{
+			name:             "allocation crossing the low watermark emits an event",
+			config:           map[string]any{"poolType": "public_ip"},
+			currentValue:     nil,
+			servicePoolSetID: &poolSetID,
+			serviceID:        &serviceID,
+			setupMock: func(store *MockStore) {
+				poolRepo := NewMockServicePoolRepository(t)
+				valueRepo := NewMockServicePoolValueRepository(t)
+				poolSetRepo := NewMockServicePoolSetRepository(t)
+
+				pool := &ServicePool{
+					BaseEntity:    BaseEntity{ID: poolID},
+					Type:          "public_ip",
+					PropertyType:  "string",
+					GeneratorType: PoolGeneratorList,
+				}
+
+				poolRepo.On("ListByPoolSet", ctx, poolSetID).Return([]*ServicePool{pool}, nil)
+				poolRepo.On("ReserveValue", ctx, poolID, serviceID, "testProp").Return(
+					&ServicePoolValue{BaseEntity: BaseEntity{ID: uuid.New()}, Value: "192.168.1.10"}, nil)
+				poolSetRepo.On("Get", ctx, poolSetID).Return(&ServicePoolSet{BaseEntity: BaseEntity{ID: poolSetID}, LowWatermarkPercent: 20}, nil)
+				valueRepo.On("CountByPool", ctx, poolID).Return(int64(10), nil)
+				valueRepo.On("CountAvailable", ctx, poolID).Return(int64(1), nil)
+				eventRepo := NewMockEventRepository(t)
+				eventRepo.On("Create", ctx, mock.MatchedBy(func(e *Event) bool {
+					return e.Type == EventTypePoolLowWatermark && *e.EntityID == poolID &&
+						e.Payload["available"] == int64(1) && e.Payload["total"] == int64(10)
+				})).Return(nil)
+				store.On("EventRepo").Return(eventRepo)
+
+				store.On("ServicePoolRepo").Return(poolRepo)
+				store.On("ServicePoolValueRepo").Return(valueRepo)
+				store.On("ServicePoolSetRepo").Return(poolSetRepo)
+			},
+			wantValue: "192.168.1.10",
+			wantGen:   true,
+			wantErr:   false,
+		},
+		{
+			name:             "allocation already below the low watermark does not emit again",
+			config:           map[string]any{"poolType": "public_ip"},
+			currentValue:     nil,
+			servicePoolSetID: &poolSetID,
+			serviceID:        &serviceID,
+			setupMock: func(store *MockStore) {
+				poolRepo := NewMockServicePoolRepository(t)
+				valueRepo := NewMockServicePoolValueRepository(t)
+				poolSetRepo := NewMockServicePoolSetRepository(t)
+
+				pool := &ServicePool{
+					BaseEntity:    BaseEntity{ID: poolID},
+					Type:          "public_ip",
+					PropertyType:  "string",
+					GeneratorType: PoolGeneratorList,
+				}
+
+				poolRepo.On("ListByPoolSet", ctx, poolSetID).Return([]*ServicePool{pool}, nil)
+				poolRepo.On("ReserveValue", ctx, poolID, serviceID, "testProp").Return(
+					&ServicePoolValue{BaseEntity: BaseEntity{ID: uuid.New()}, Value: "192.168.1.10"}, nil)
+				poolSetRepo.On("Get", ctx, poolSetID).Return(&ServicePoolSet{BaseEntity: BaseEntity{ID: poolSetID}, LowWatermarkPercent: 20}, nil)
+				valueRepo.On("CountByPool", ctx, poolID).Return(int64(10), nil)
+				valueRepo.On("CountAvailable", ctx, poolID).Return(int64(0), nil)
+
+				store.On("ServicePoolRepo").Return(poolRepo)
+				store.On("ServicePoolValueRepo").Return(valueRepo)
+				store.On("ServicePoolSetRepo").Return(poolSetRepo)
+			},
+			wantValue: "192.168.1.10",
+			wantGen:   true,
+			wantErr:   false,
+		},
+		{
+			name:             "pool exhausted",
+			config:           map[string]any{"poolType": "public_ip"},
+			currentValue:     nil,
+			servicePoolSetID: &poolSetID,
+			serviceID:        &serviceID,
+			setupMock: func(store *MockStore) {
+				poolRepo := NewMockServicePoolRepository(t)
+				valueRepo := NewMockServicePoolValueRepository(t)
+
+				pool := &ServicePool{
+					BaseEntity:    BaseEntity{ID: poolID},
+					Type:          "public_ip",
+					PropertyType:  "string",
+					GeneratorType: PoolGeneratorList,
+				}
+
+				poolRepo.On("ListByPoolSet", ctx, poolSetID).Return([]*ServicePool{pool}, nil)
+				poolRepo.On("ReserveValue", ctx, poolID, serviceID, "testProp").Return(nil, NewPoolExhaustedError(poolID))
+
+				store.On("ServicePoolRepo").Return(poolRepo)
+				store.On("ServicePoolValueRepo").Return(valueRepo)
+			},
+			wantErr:   true,
+			errSubstr: "pool exhausted",
+		},
 		{
 			name:             "skip generation when value exists",
 			config:           map[string]any{"poolType": "public_ip"},
@@ -2,13 +2,20 @@ package domain
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/fulcrumproject/core/pkg/auth"
 	"github.com/fulcrumproject/core/pkg/helpers"
 	"github.com/fulcrumproject/core/pkg/properties"
 	"github.com/fulcrumproject/core/pkg/schema"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 func TestService_TableName(t *testing.T) {
@@ -162,6 +169,21 @@ func TestService_Validate(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "Non-positive operation timeout",
+			service: &Service{
+				Name:             "Web Server",
+				Status:           "New",
+				GroupID:          validID,
+				AgentID:          validID,
+				ServiceTypeID:    validID,
+				ProviderID:       validID,
+				ConsumerID:       validID,
+				OperationTimeout: helpers.DurationPtr(0),
+			},
+			wantErr:    true,
+			errMessage: "operation timeout must be positive",
+		},
 	}
 
 	for _, tt := range tests {
@@ -305,3 +327,1808 @@ func TestApplyAgentPropertyUpdates(t *testing.T) {
 		})
 	}
 }
+
+func TestBatchServiceAction(t *testing.T) {
+	ctx := context.Background()
+
+	serviceType := &ServiceType{
+		BaseEntity: BaseEntity{ID: uuid.New()},
+		Name:       "vm",
+		LifecycleSchema: LifecycleSchema{
+			States:       []LifecycleState{{Name: "Started"}, {Name: "Stopped"}, {Name: "Deleted"}},
+			InitialState: "Started",
+			Actions: []LifecycleAction{
+				{Name: "stop", Transitions: []LifecycleTransition{{From: "Started", To: "Stopped"}}},
+				{Name: "start", Transitions: []LifecycleTransition{{From: "Stopped", To: "Started"}}},
+			},
+			TerminalStates: []string{"Deleted"},
+		},
+	}
+	agentID := uuid.New()
+	started := &Service{BaseEntity: BaseEntity{ID: uuid.New()}, Status: "Started", ServiceTypeID: serviceType.ID, AgentID: agentID}
+	stopped := &Service{BaseEntity: BaseEntity{ID: uuid.New()}, Status: "Stopped", ServiceTypeID: serviceType.ID, AgentID: agentID}
+
+	setup := func(t *testing.T) (*MockStore, *MockServiceRepository, *MockJobRepository) {
+		ms := setupMockStore(t)
+		serviceRepo := NewMockServiceRepository(t)
+		serviceTypeRepo := NewMockServiceTypeRepository(t)
+		jobRepo := NewMockJobRepository(t)
+		ms.EXPECT().ServiceRepo().Return(serviceRepo).Maybe()
+		ms.EXPECT().ServiceTypeRepo().Return(serviceTypeRepo).Maybe()
+		ms.EXPECT().JobRepo().Return(jobRepo).Maybe()
+		serviceRepo.EXPECT().Get(mock.Anything, started.ID).Return(started, nil).Maybe()
+		serviceRepo.EXPECT().Get(mock.Anything, stopped.ID).Return(stopped, nil).Maybe()
+		serviceTypeRepo.EXPECT().Get(mock.Anything, serviceType.ID).Return(serviceType, nil).Maybe()
+		jobRepo.EXPECT().GetLastJobForService(mock.Anything, mock.Anything).Return(nil, nil).Maybe()
+		jobRepo.EXPECT().GetScheduledJobsForService(mock.Anything, mock.Anything).Return(nil, nil).Maybe()
+		return ms, serviceRepo, jobRepo
+	}
+
+	t.Run("all valid creates one job per item", func(t *testing.T) {
+		ms, serviceRepo, jobRepo := setup(t)
+		// The services are locked before the validation, in the same transaction as the jobs
+		serviceRepo.EXPECT().LockForUpdate(mock.Anything, []properties.UUID{started.ID, stopped.ID}).Return(nil).Once()
+		jobRepo.EXPECT().Create(mock.Anything, mock.Anything).Return(nil).Times(2)
+
+		results, err := BatchServiceAction(ctx, ms, BatchServiceActionParams{Items: []DoServiceActionParams{
+			{ID: started.ID, Action: "stop"},
+			{ID: stopped.ID, Action: "start"},
+		}}, JobRetryPolicy{})
+		require.NoError(t, err)
+		require.Len(t, results, 2)
+		assert.NoError(t, results[0].Err)
+		assert.Equal(t, started, results[0].Service)
+		assert.Equal(t, stopped, results[1].Service)
+	})
+
+	t.Run("one invalid item rejects the whole batch", func(t *testing.T) {
+		ms, serviceRepo, _ := setup(t)
+		serviceRepo.EXPECT().LockForUpdate(mock.Anything, mock.Anything).Return(nil).Once()
+
+		results, err := BatchServiceAction(ctx, ms, BatchServiceActionParams{Items: []DoServiceActionParams{
+			{ID: started.ID, Action: "stop"},
+			{ID: stopped.ID, Action: "stop"},
+		}}, JobRetryPolicy{})
+		require.Error(t, err)
+		assert.True(t, errors.As(err, &InvalidInputError{}))
+		require.Len(t, results, 2)
+		assert.NoError(t, results[0].Err)
+		assert.Error(t, results[1].Err)
+	})
+
+	t.Run("duplicate service is a conflict", func(t *testing.T) {
+		ms, _, _ := setup(t)
+
+		results, err := BatchServiceAction(ctx, ms, BatchServiceActionParams{Items: []DoServiceActionParams{
+			{ID: started.ID, Action: "stop"},
+			{ID: started.ID, Action: "stop"},
+		}}, JobRetryPolicy{})
+		require.Error(t, err)
+		assert.True(t, errors.As(err, &ConflictError{}))
+		assert.Nil(t, results)
+	})
+
+	t.Run("empty batch", func(t *testing.T) {
+		ms, _, _ := setup(t)
+
+		_, err := BatchServiceAction(ctx, ms, BatchServiceActionParams{}, JobRetryPolicy{})
+		assert.True(t, errors.As(err, &InvalidInputError{}))
+	})
+}
+
+func TestDoServiceAction_RetryBackoff(t *testing.T) {
+	ctx := context.Background()
+
+	serviceType := &ServiceType{
+		BaseEntity: BaseEntity{ID: uuid.New()},
+		LifecycleSchema: LifecycleSchema{
+			States:       []LifecycleState{{Name: "Started"}, {Name: "Stopped"}},
+			InitialState: "Started",
+			Actions: []LifecycleAction{
+				{Name: "stop", Transitions: []LifecycleTransition{{From: "Started", To: "Stopped"}}},
+			},
+		},
+	}
+	svc := &Service{BaseEntity: BaseEntity{ID: uuid.New()}, Status: "Started", ServiceTypeID: serviceType.ID, AgentID: uuid.New()}
+	retry := JobRetryPolicy{InitialBackoff: time.Minute, MaxBackoff: time.Hour}
+
+	setup := func(t *testing.T, last *Job) (*MockStore, *MockJobRepository) {
+		ms := setupMockStore(t)
+		serviceRepo := NewMockServiceRepository(t)
+		serviceTypeRepo := NewMockServiceTypeRepository(t)
+		jobRepo := NewMockJobRepository(t)
+		ms.EXPECT().ServiceRepo().Return(serviceRepo).Maybe()
+		ms.EXPECT().ServiceTypeRepo().Return(serviceTypeRepo).Maybe()
+		ms.EXPECT().JobRepo().Return(jobRepo).Maybe()
+		serviceRepo.EXPECT().Get(mock.Anything, svc.ID).Return(svc, nil)
+		serviceRepo.EXPECT().LockForUpdate(mock.Anything, []properties.UUID{svc.ID}).Return(nil).Maybe()
+		serviceTypeRepo.EXPECT().Get(mock.Anything, serviceType.ID).Return(serviceType, nil)
+		jobRepo.EXPECT().GetLastJobForService(mock.Anything, svc.ID).Return(last, nil)
+		jobRepo.EXPECT().GetScheduledJobsForService(mock.Anything, svc.ID).Return(nil, nil).Maybe()
+		return ms, jobRepo
+	}
+
+	t.Run("first attempt is pending at once", func(t *testing.T) {
+		ms, jobRepo := setup(t, nil)
+		jobRepo.EXPECT().Create(mock.Anything, mock.MatchedBy(func(job *Job) bool {
+			return job.Status == JobPending && job.Attempt == 1 && job.ScheduledAt == nil
+		})).Return(nil)
+
+		_, err := DoServiceAction(ctx, ms, DoServiceActionParams{ID: svc.ID, Action: "stop"}, retry)
+		require.NoError(t, err)
+	})
+
+	t.Run("retry of a failed action waits for its backoff", func(t *testing.T) {
+		ms, jobRepo := setup(t, &Job{BaseEntity: BaseEntity{ID: uuid.New()}, Action: "stop", Status: JobFailed, Attempt: 2})
+		jobRepo.EXPECT().Create(mock.Anything, mock.MatchedBy(func(job *Job) bool {
+			return job.Status == JobScheduled && job.Attempt == 3 &&
+				job.ScheduledAt.Sub(time.Now()) > time.Minute && job.ScheduledAt.Sub(time.Now()) <= 2*time.Minute
+		})).Return(nil)
+
+		_, err := DoServiceAction(ctx, ms, DoServiceActionParams{ID: svc.ID, Action: "stop"}, retry)
+		require.NoError(t, err)
+	})
+
+	t.Run("retry without backoff is pending at once", func(t *testing.T) {
+		ms, jobRepo := setup(t, &Job{BaseEntity: BaseEntity{ID: uuid.New()}, Action: "stop", Status: JobFailed, Attempt: 2})
+		jobRepo.EXPECT().Create(mock.Anything, mock.MatchedBy(func(job *Job) bool {
+			return job.Status == JobPending && job.Attempt == 3
+		})).Return(nil)
+
+		_, err := DoServiceAction(ctx, ms, DoServiceActionParams{ID: svc.ID, Action: "stop"}, JobRetryPolicy{})
+		require.NoError(t, err)
+	})
+
+	t.Run("superseded retry uses up no attempt", func(t *testing.T) {
+		// The retry of attempt 3 was cancelled by another action before the agent claimed it
+		ms, jobRepo := setup(t, &Job{BaseEntity: BaseEntity{ID: uuid.New()}, Action: "stop", Status: JobCancelled, Attempt: 3})
+		jobRepo.EXPECT().Create(mock.Anything, mock.MatchedBy(func(job *Job) bool {
+			return job.Attempt == 3
+		})).Return(nil)
+
+		_, err := DoServiceAction(ctx, ms, DoServiceActionParams{ID: svc.ID, Action: "stop"}, JobRetryPolicy{})
+		require.NoError(t, err)
+	})
+
+	t.Run("cancelled job claimed by the agent starts over", func(t *testing.T) {
+		claimedAt := time.Now()
+		ms, jobRepo := setup(t, &Job{BaseEntity: BaseEntity{ID: uuid.New()}, Action: "stop", Status: JobCancelled, Attempt: 3, ClaimedAt: &claimedAt})
+		jobRepo.EXPECT().Create(mock.Anything, mock.MatchedBy(func(job *Job) bool {
+			return job.Attempt == 1
+		})).Return(nil)
+
+		_, err := DoServiceAction(ctx, ms, DoServiceActionParams{ID: svc.ID, Action: "stop"}, JobRetryPolicy{})
+		require.NoError(t, err)
+	})
+
+	t.Run("dead-lettered action is not retried", func(t *testing.T) {
+		ms, _ := setup(t, &Job{BaseEntity: BaseEntity{ID: uuid.New()}, Action: "stop", Status: JobDeadLettered, Attempt: 5})
+
+		_, err := DoServiceAction(ctx, ms, DoServiceActionParams{ID: svc.ID, Action: "stop"}, retry)
+		assert.ErrorAs(t, err, &InvalidInputError{})
+		assert.ErrorContains(t, err, "requeue it instead")
+	})
+}
+
+func TestDoServiceAction_Scheduled(t *testing.T) {
+	ctx := context.Background()
+
+	serviceType := &ServiceType{
+		BaseEntity: BaseEntity{ID: uuid.New()},
+		LifecycleSchema: LifecycleSchema{
+			States:       []LifecycleState{{Name: "Started"}, {Name: "Stopped"}},
+			InitialState: "Started",
+			Actions: []LifecycleAction{
+				{Name: "stop", Transitions: []LifecycleTransition{{From: "Started", To: "Stopped"}}},
+			},
+		},
+	}
+	svc := &Service{BaseEntity: BaseEntity{ID: uuid.New()}, Status: "Started", ServiceTypeID: serviceType.ID, AgentID: uuid.New()}
+
+	setup := func(t *testing.T) (*MockStore, *MockJobRepository) {
+		ms := setupMockStore(t)
+		serviceRepo := NewMockServiceRepository(t)
+		serviceTypeRepo := NewMockServiceTypeRepository(t)
+		jobRepo := NewMockJobRepository(t)
+		ms.EXPECT().ServiceRepo().Return(serviceRepo).Maybe()
+		ms.EXPECT().ServiceTypeRepo().Return(serviceTypeRepo).Maybe()
+		ms.EXPECT().JobRepo().Return(jobRepo).Maybe()
+		serviceRepo.EXPECT().Get(mock.Anything, svc.ID).Return(svc, nil).Maybe()
+		serviceRepo.EXPECT().LockForUpdate(mock.Anything, []properties.UUID{svc.ID}).Return(nil).Maybe()
+		serviceTypeRepo.EXPECT().Get(mock.Anything, serviceType.ID).Return(serviceType, nil).Maybe()
+		jobRepo.EXPECT().GetLastJobForService(mock.Anything, svc.ID).Return(nil, nil).Maybe()
+		return ms, jobRepo
+	}
+
+	t.Run("scheduled action creates a scheduled job", func(t *testing.T) {
+		ms, jobRepo := setup(t)
+		at := time.Now().Add(time.Hour)
+		jobRepo.EXPECT().Create(mock.Anything, mock.MatchedBy(func(job *Job) bool {
+			return job.Status == JobScheduled && job.ScheduledAt.Equal(at) && job.Action == "stop"
+		})).Return(nil)
+
+		_, err := DoServiceAction(ctx, ms, DoServiceActionParams{ID: svc.ID, Action: "stop", ScheduledAt: &at}, JobRetryPolicy{})
+		require.NoError(t, err)
+	})
+
+	t.Run("scheduled action still validates the lifecycle", func(t *testing.T) {
+		ms, _ := setup(t)
+		at := time.Now().Add(time.Hour)
+
+		_, err := DoServiceAction(ctx, ms, DoServiceActionParams{ID: svc.ID, Action: "start", ScheduledAt: &at}, JobRetryPolicy{})
+		assert.True(t, errors.As(err, &InvalidInputError{}))
+	})
+
+	t.Run("scheduled time in the past", func(t *testing.T) {
+		ms, _ := setup(t)
+		at := time.Now().Add(-time.Hour)
+
+		_, err := DoServiceAction(ctx, ms, DoServiceActionParams{ID: svc.ID, Action: "stop", ScheduledAt: &at}, JobRetryPolicy{})
+		assert.True(t, errors.As(err, &InvalidInputError{}))
+	})
+
+	t.Run("immediate action cancels scheduled jobs", func(t *testing.T) {
+		ms, jobRepo := setup(t)
+		at := time.Now().Add(time.Hour)
+		scheduled := &Job{BaseEntity: BaseEntity{ID: uuid.New()}, Status: JobScheduled, ScheduledAt: &at, Action: "stop"}
+		jobRepo.EXPECT().GetScheduledJobsForService(mock.Anything, svc.ID).Return([]*Job{scheduled}, nil)
+		jobRepo.EXPECT().Save(mock.Anything, scheduled).Return(nil)
+		jobRepo.EXPECT().Create(mock.Anything, mock.MatchedBy(func(job *Job) bool {
+			return job.Status == JobPending
+		})).Return(nil)
+
+		_, err := DoServiceAction(ctx, ms, DoServiceActionParams{ID: svc.ID, Action: "stop"}, JobRetryPolicy{})
+		require.NoError(t, err)
+		assert.Equal(t, JobCancelled, scheduled.Status)
+		assert.Contains(t, scheduled.ErrorMessage, "scheduled job cancelled")
+	})
+}
+
+func TestServiceCommander_PromoteScheduledJobs(t *testing.T) {
+	ctx := context.Background()
+
+	serviceType := &ServiceType{
+		BaseEntity: BaseEntity{ID: uuid.New()},
+		LifecycleSchema: LifecycleSchema{
+			States:       []LifecycleState{{Name: "Started"}, {Name: "Stopped"}},
+			InitialState: "Started",
+			Actions: []LifecycleAction{
+				{Name: "stop", Transitions: []LifecycleTransition{{From: "Started", To: "Stopped"}}},
+			},
+		},
+	}
+	started := &Service{BaseEntity: BaseEntity{ID: uuid.New()}, Status: "Started", ServiceTypeID: serviceType.ID}
+	stopped := &Service{BaseEntity: BaseEntity{ID: uuid.New()}, Status: "Stopped", ServiceTypeID: serviceType.ID}
+	due := &Job{BaseEntity: BaseEntity{ID: uuid.New()}, Status: JobScheduled, Action: "stop", ServiceID: started.ID}
+	stale := &Job{BaseEntity: BaseEntity{ID: uuid.New()}, Status: JobScheduled, Action: "stop", ServiceID: stopped.ID}
+	frozen := &Service{BaseEntity: BaseEntity{ID: uuid.New()}, Status: "Started", ServiceTypeID: serviceType.ID, Maintenance: true}
+	held := &Job{BaseEntity: BaseEntity{ID: uuid.New()}, Status: JobScheduled, Action: "stop", ServiceID: frozen.ID}
+
+	ms := NewMockStore(t)
+	serviceRepo := NewMockServiceRepository(t)
+	serviceTypeRepo := NewMockServiceTypeRepository(t)
+	jobRepo := NewMockJobRepository(t)
+	ms.EXPECT().ServiceRepo().Return(serviceRepo)
+	ms.EXPECT().ServiceTypeRepo().Return(serviceTypeRepo)
+	ms.EXPECT().JobRepo().Return(jobRepo)
+	serviceRepo.EXPECT().Get(mock.Anything, started.ID).Return(started, nil)
+	serviceRepo.EXPECT().Get(mock.Anything, stopped.ID).Return(stopped, nil)
+	serviceRepo.EXPECT().Get(mock.Anything, frozen.ID).Return(frozen, nil)
+	serviceTypeRepo.EXPECT().Get(mock.Anything, serviceType.ID).Return(serviceType, nil)
+	jobRepo.EXPECT().GetDueScheduledJobs(mock.Anything).Return([]*Job{due, stale, held}, nil)
+	jobRepo.EXPECT().GetLastJobForService(mock.Anything, started.ID).Return(nil, nil)
+	jobRepo.EXPECT().SaveIfStatus(mock.Anything, mock.Anything, JobScheduled).Return(true, nil).Times(2)
+
+	cmd := NewServiceCommander(ms, nil, nil, 0, JobRetryPolicy{}, nil)
+	count, err := cmd.PromoteScheduledJobs(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+	assert.Equal(t, JobPending, due.Status)
+	assert.Equal(t, JobCancelled, stale.Status)
+	assert.Contains(t, stale.ErrorMessage, "scheduled job cancelled")
+	assert.Equal(t, JobScheduled, held.Status, "Should keep the jobs of the services in maintenance for later")
+}
+
+func TestService_IdleActivity(t *testing.T) {
+	svc := &Service{Status: "Stopped"}
+	lifecycle := LifecycleSchema{
+		States:  []LifecycleState{{Name: "Stopped"}, {Name: "Started"}},
+		Actions: []LifecycleAction{{Name: "start", Transitions: []LifecycleTransition{{From: "Stopped", To: "Started"}}}},
+	}
+
+	assert.True(t, svc.SetIdleTimeout(time.Hour))
+	assert.False(t, svc.SetIdleTimeout(time.Hour))
+	assert.True(t, svc.SetIdleTimeout(30*time.Minute))
+	assert.Equal(t, 30*time.Minute, *svc.IdleTimeout)
+
+	// A completed job is an activity
+	require.NoError(t, svc.HandleJobComplete(lifecycle, "start", nil, nil, nil, nil))
+	require.NotNil(t, svc.LastActivityAt)
+	assert.WithinDuration(t, time.Now(), *svc.LastActivityAt, time.Second)
+
+	// Zero disables the auto-stop
+	assert.True(t, svc.SetIdleTimeout(0))
+	assert.Nil(t, svc.IdleTimeout)
+	assert.False(t, svc.SetIdleTimeout(0))
+}
+
+func TestService_MarkFailed(t *testing.T) {
+	lifecycle := LifecycleSchema{States: []LifecycleState{{Name: "Started"}, {Name: "Failed"}}}
+
+	// Without a failed state in the lifecycle the status is left as is
+	svc := &Service{Status: "Started"}
+	require.True(t, svc.MarkFailed(lifecycle))
+	require.NotNil(t, svc.FailedAt)
+	failedAt := *svc.FailedAt
+	assert.True(t, svc.Failed)
+	assert.Equal(t, "Started", svc.Status)
+	assert.False(t, svc.MarkFailed(lifecycle))
+	assert.Equal(t, failedAt, *svc.FailedAt)
+
+	lifecycle.FailedState = "Failed"
+	assert.True(t, svc.MarkFailed(lifecycle))
+	assert.Equal(t, "Failed", svc.Status)
+	assert.Equal(t, failedAt, *svc.FailedAt)
+
+	assert.True(t, svc.ClearFailed())
+	assert.False(t, svc.Failed)
+	assert.Nil(t, svc.FailedAt)
+	assert.False(t, svc.ClearFailed())
+}
+
+func TestServiceCommander_FailTimeoutServicesAndJobs(t *testing.T) {
+	ctx := context.Background()
+	serviceID := uuid.New()
+	newJobs := func() (retried, exhausted, reported, pending *Job) {
+		retried = &Job{BaseEntity: BaseEntity{ID: uuid.New()}, Status: JobProcessing, Action: "start", Attempt: 1, ServiceID: serviceID}
+		exhausted = &Job{BaseEntity: BaseEntity{ID: uuid.New()}, Status: JobProcessing, Action: "start", Attempt: 3, ServiceID: uuid.New()}
+		reported = &Job{BaseEntity: BaseEntity{ID: uuid.New()}, Status: JobProcessing, Action: "start", Attempt: 1, ServiceID: uuid.New()}
+		// A second timed out job of the same service
+		pending = &Job{BaseEntity: BaseEntity{ID: uuid.New()}, Status: JobPending, Action: "stop", Attempt: 1, ServiceID: serviceID}
+		return
+	}
+
+	t.Run("fails the jobs with one update per status change", func(t *testing.T) {
+		retried, exhausted, reported, pending := newJobs()
+		serviceType := &ServiceType{
+			BaseEntity: BaseEntity{ID: uuid.New()},
+			LifecycleSchema: LifecycleSchema{
+				States:      []LifecycleState{{Name: "Started"}, {Name: "Failed"}},
+				FailedState: "Failed",
+			},
+		}
+		exhaustedSvc := &Service{BaseEntity: BaseEntity{ID: exhausted.ServiceID}, Status: "Started", ServiceTypeID: serviceType.ID}
+		ms := setupMockStore(t)
+		jobRepo := NewMockJobRepository(t)
+		eventRepo := NewMockEventRepository(t)
+		serviceRepo := NewMockServiceRepository(t)
+		serviceTypeRepo := NewMockServiceTypeRepository(t)
+		ms.EXPECT().JobRepo().Return(jobRepo)
+		ms.EXPECT().EventRepo().Return(eventRepo)
+		ms.EXPECT().ServiceRepo().Return(serviceRepo)
+		ms.EXPECT().ServiceTypeRepo().Return(serviceTypeRepo)
+		serviceRepo.EXPECT().Get(mock.Anything, exhausted.ServiceID).Return(exhaustedSvc, nil).Once()
+		serviceTypeRepo.EXPECT().Get(mock.Anything, serviceType.ID).Return(serviceType, nil).Once()
+		serviceRepo.EXPECT().Save(mock.Anything, exhaustedSvc).Return(nil).Once()
+		eventRepo.EXPECT().Create(mock.Anything, mock.MatchedBy(func(e *Event) bool {
+			return e.Type == EventTypeServiceTransitioned && *e.EntityID == exhaustedSvc.ID
+		})).Return(nil).Once()
+		jobRepo.EXPECT().GetTimeOutJobs(mock.Anything, mock.Anything).Return([]*Job{retried, exhausted, reported, pending}, nil)
+		errorMsg := "Job marked as failed due to exceeding maximum processing time"
+		// The reported job was completed by its agent or failed by another maintenance pass in the meantime
+		jobRepo.EXPECT().FailIfStatus(mock.Anything, []properties.UUID{retried.ID, reported.ID}, JobProcessing, JobFailed, errorMsg, mock.Anything).
+			Return([]properties.UUID{retried.ID}, nil).Once()
+		jobRepo.EXPECT().FailIfStatus(mock.Anything, []properties.UUID{exhausted.ID}, JobProcessing, JobDeadLettered, errorMsg, mock.Anything).
+			Return([]properties.UUID{exhausted.ID}, nil).Once()
+		jobRepo.EXPECT().FailIfStatus(mock.Anything, []properties.UUID{pending.ID}, JobPending, JobFailed, errorMsg, mock.Anything).
+			Return([]properties.UUID{pending.ID}, nil).Once()
+		eventRepo.EXPECT().Create(mock.Anything, mock.MatchedBy(func(e *Event) bool {
+			return e.Type == EventTypeJobDeadLettered && *e.EntityID == exhausted.ID && e.Payload["attempt"] == 3
+		})).Return(nil).Once()
+
+		count, err := NewServiceCommander(ms, nil, nil, 0, JobRetryPolicy{}, nil).FailTimeoutServicesAndJobs(ctx, JobTimeouts{Default: time.Minute}, 3)
+		require.NoError(t, err)
+		assert.Equal(t, 3, count)
+		assert.Equal(t, JobFailed, retried.Status)
+		assert.Equal(t, JobDeadLettered, exhausted.Status)
+		assert.Equal(t, "Failed", exhaustedSvc.Status)
+		assert.True(t, exhaustedSvc.Failed)
+		assert.Equal(t, JobFailed, pending.Status)
+		assert.Equal(t, errorMsg, pending.ErrorMessage)
+	})
+
+	t.Run("reports no job when the transaction is rolled back", func(t *testing.T) {
+		retried, exhausted, _, _ := newJobs()
+		ms := setupMockStore(t)
+		jobRepo := NewMockJobRepository(t)
+		ms.EXPECT().JobRepo().Return(jobRepo)
+		jobRepo.EXPECT().GetTimeOutJobs(mock.Anything, mock.Anything).Return([]*Job{retried, exhausted}, nil)
+		jobRepo.EXPECT().FailIfStatus(mock.Anything, []properties.UUID{retried.ID}, JobProcessing, JobFailed, mock.Anything, mock.Anything).
+			Return([]properties.UUID{retried.ID}, nil).Once()
+		jobRepo.EXPECT().FailIfStatus(mock.Anything, []properties.UUID{exhausted.ID}, JobProcessing, JobDeadLettered, mock.Anything, mock.Anything).
+			Return(nil, errors.New("db error")).Once()
+
+		count, err := NewServiceCommander(ms, nil, nil, 0, JobRetryPolicy{}, nil).FailTimeoutServicesAndJobs(ctx, JobTimeouts{Default: time.Minute}, 3)
+		assert.EqualError(t, err, "db error")
+		// The update of the retried job is rolled back with the failed one
+		assert.Equal(t, 0, count)
+	})
+
+	t.Run("does nothing without timed out jobs", func(t *testing.T) {
+		ms := NewMockStore(t)
+		jobRepo := NewMockJobRepository(t)
+		ms.EXPECT().JobRepo().Return(jobRepo)
+		jobRepo.EXPECT().GetTimeOutJobs(mock.Anything, mock.Anything).Return(nil, nil)
+
+		count, err := NewServiceCommander(ms, nil, nil, 0, JobRetryPolicy{}, nil).FailTimeoutServicesAndJobs(ctx, JobTimeouts{Default: time.Minute}, 3)
+		require.NoError(t, err)
+		assert.Equal(t, 0, count)
+	})
+}
+
+func TestServiceCommander_CancelOperation(t *testing.T) {
+	ctx := auth.WithIdentity(context.Background(), &auth.Identity{ID: uuid.New(), Role: auth.RoleAdmin})
+	svc := &Service{BaseEntity: BaseEntity{ID: uuid.New()}, Status: "Started", AgentID: uuid.New()}
+
+	setup := func(t *testing.T, svc *Service, lastJob *Job) (*MockStore, *MockJobRepository, *MockEventRepository) {
+		ms := setupMockStore(t)
+		serviceRepo := NewMockServiceRepository(t)
+		jobRepo := NewMockJobRepository(t)
+		eventRepo := NewMockEventRepository(t)
+		ms.EXPECT().ServiceRepo().Return(serviceRepo).Maybe()
+		ms.EXPECT().JobRepo().Return(jobRepo).Maybe()
+		ms.EXPECT().EventRepo().Return(eventRepo).Maybe()
+		serviceRepo.EXPECT().Get(mock.Anything, svc.ID).Return(svc, nil)
+		jobRepo.EXPECT().GetLastJobForService(mock.Anything, svc.ID).Return(lastJob, nil).Maybe()
+		return ms, jobRepo, eventRepo
+	}
+
+	t.Run("cancels the active job", func(t *testing.T) {
+		job := &Job{BaseEntity: BaseEntity{ID: uuid.New()}, Status: JobProcessing, Action: "start"}
+		ms, jobRepo, eventRepo := setup(t, svc, job)
+		jobRepo.EXPECT().SaveIfStatus(mock.Anything, job, JobProcessing).Return(true, nil)
+		eventRepo.EXPECT().Create(mock.Anything, mock.MatchedBy(func(e *Event) bool {
+			return e.Type == EventTypeServiceOperationCancelled && e.Payload["action"] == "start"
+		})).Return(nil)
+
+		result, err := NewServiceCommander(ms, nil, nil, 0, JobRetryPolicy{}, nil).CancelOperation(ctx, svc.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "Started", result.Status)
+		assert.Equal(t, JobCancelled, job.Status)
+	})
+
+	t.Run("no active job", func(t *testing.T) {
+		job := &Job{BaseEntity: BaseEntity{ID: uuid.New()}, Status: JobCompleted, Action: "start"}
+		ms, _, _ := setup(t, svc, job)
+
+		_, err := NewServiceCommander(ms, nil, nil, 0, JobRetryPolicy{}, nil).CancelOperation(ctx, svc.ID)
+		assert.True(t, errors.As(err, &InvalidInputError{}))
+	})
+
+	t.Run("no job at all", func(t *testing.T) {
+		ms, _, _ := setup(t, svc, nil)
+
+		_, err := NewServiceCommander(ms, nil, nil, 0, JobRetryPolicy{}, nil).CancelOperation(ctx, svc.ID)
+		assert.True(t, errors.As(err, &InvalidInputError{}))
+	})
+
+	t.Run("job moved on concurrently", func(t *testing.T) {
+		job := &Job{BaseEntity: BaseEntity{ID: uuid.New()}, Status: JobPending, Action: "start"}
+		ms, jobRepo, _ := setup(t, svc, job)
+		// The agent claimed the job in the meantime
+		jobRepo.EXPECT().SaveIfStatus(mock.Anything, job, JobPending).Return(false, nil)
+
+		_, err := NewServiceCommander(ms, nil, nil, 0, JobRetryPolicy{}, nil).CancelOperation(ctx, svc.ID)
+		assert.True(t, errors.As(err, &ConflictError{}))
+	})
+
+	t.Run("service in maintenance", func(t *testing.T) {
+		frozen := &Service{BaseEntity: BaseEntity{ID: uuid.New()}, Status: "Started", Maintenance: true}
+		job := &Job{BaseEntity: BaseEntity{ID: uuid.New()}, Status: JobProcessing, Action: "start"}
+		ms, _, _ := setup(t, frozen, job)
+
+		_, err := NewServiceCommander(ms, nil, nil, 0, JobRetryPolicy{}, nil).CancelOperation(ctx, frozen.ID)
+		assert.True(t, errors.As(err, &MaintenanceError{}))
+		assert.Equal(t, JobProcessing, job.Status)
+	})
+}
+
+func TestServiceCommander_ValidateCreate(t *testing.T) {
+	ctx := auth.WithIdentity(context.Background(), &auth.Identity{ID: uuid.New(), Role: auth.RoleAdmin})
+	serviceType := &ServiceType{
+		BaseEntity: BaseEntity{ID: uuid.New()},
+		PropertySchema: schema.Schema{Properties: map[string]schema.PropertyDefinition{
+			"name": {Type: "string", Required: true},
+			"size": {Type: "integer", Default: 10},
+		}},
+		LifecycleSchema: LifecycleSchema{InitialState: "New"},
+	}
+	agent := &Agent{
+		BaseEntity: BaseEntity{ID: uuid.New()},
+		ProviderID: uuid.New(),
+		AgentType:  &AgentType{Name: "vm", ServiceTypes: []ServiceType{*serviceType}},
+	}
+	group := &ServiceGroup{BaseEntity: BaseEntity{ID: uuid.New()}, ConsumerID: uuid.New()}
+
+	// Only lookups are expected: any write to the service or job repositories fails the test
+	setup := func(t *testing.T) *MockStore {
+		ms := setupMockStore(t)
+		agentRepo := NewMockAgentRepository(t)
+		groupRepo := NewMockServiceGroupRepository(t)
+		serviceTypeRepo := NewMockServiceTypeRepository(t)
+		serviceRepo := NewMockServiceRepository(t)
+		ms.EXPECT().AgentRepo().Return(agentRepo)
+		ms.EXPECT().ServiceGroupRepo().Return(groupRepo)
+		ms.EXPECT().ServiceTypeRepo().Return(serviceTypeRepo)
+		ms.EXPECT().ServiceRepo().Return(serviceRepo)
+		agentRepo.EXPECT().Get(mock.Anything, agent.ID).Return(agent, nil)
+		groupRepo.EXPECT().Get(mock.Anything, group.ID).Return(group, nil)
+		serviceTypeRepo.EXPECT().Get(mock.Anything, serviceType.ID).Return(serviceType, nil)
+		serviceRepo.EXPECT().FindByGroupAndName(mock.Anything, group.ID, "svc").Return(nil, NewNotFoundErrorf("service not found"))
+		return ms
+	}
+	params := func(props properties.JSON) CreateServiceWithTagsParams {
+		return CreateServiceWithTagsParams{CreateServiceParams: CreateServiceParams{
+			AgentID:       agent.ID,
+			ServiceTypeID: serviceType.ID,
+			GroupID:       group.ID,
+			Name:          "svc",
+			Properties:    props,
+		}}
+	}
+
+	t.Run("valid properties with defaults", func(t *testing.T) {
+		cmd := NewServiceCommander(setup(t), NewServicePropertyEngine(nil), nil, 0, JobRetryPolicy{}, nil)
+
+		result, err := cmd.ValidateCreate(ctx, params(properties.JSON{"name": "web"}))
+		require.NoError(t, err)
+		assert.True(t, result.Valid)
+		assert.Equal(t, "web", result.Properties["name"])
+		assert.EqualValues(t, 10, result.Properties["size"])
+		assert.Empty(t, result.Errors)
+	})
+
+	t.Run("invalid properties", func(t *testing.T) {
+		cmd := NewServiceCommander(setup(t), NewServicePropertyEngine(nil), nil, 0, JobRetryPolicy{}, nil)
+
+		result, err := cmd.ValidateCreate(ctx, params(properties.JSON{"size": "big"}))
+		require.NoError(t, err)
+		assert.False(t, result.Valid)
+		assert.Nil(t, result.Properties)
+		assert.NotEmpty(t, result.Errors)
+	})
+}
+
+func TestServiceCommander_ValidateCreateWithOptions(t *testing.T) {
+	ctx := auth.WithIdentity(context.Background(), &auth.Identity{ID: uuid.New(), Role: auth.RoleAdmin})
+	serviceType := &ServiceType{
+		BaseEntity: BaseEntity{ID: uuid.New()},
+		PropertySchema: schema.Schema{Properties: map[string]schema.PropertyDefinition{
+			"name": {Type: "string", Required: true},
+			"os":   {Type: "string", Validators: []schema.ValidatorConfig{{Type: "serviceOption", Config: map[string]any{"value": "os"}}}},
+		}},
+		LifecycleSchema: LifecycleSchema{InitialState: "New"},
+	}
+	agent := &Agent{
+		BaseEntity: BaseEntity{ID: uuid.New()},
+		ProviderID: uuid.New(),
+		AgentType:  &AgentType{Name: "vm", ServiceTypes: []ServiceType{*serviceType}},
+	}
+	group := &ServiceGroup{BaseEntity: BaseEntity{ID: uuid.New()}, ConsumerID: uuid.New()}
+	osType := &ServiceOptionType{BaseEntity: BaseEntity{ID: uuid.New()}, Type: "os"}
+	ubuntu := &ServiceOption{
+		BaseEntity:          BaseEntity{ID: uuid.New()},
+		ProviderID:          agent.ProviderID,
+		ServiceOptionTypeID: osType.ID,
+		Name:                "Ubuntu",
+		Value:               "ubuntu-24.04",
+		Enabled:             helpers.BoolPtr(true),
+	}
+
+	setup := func(t *testing.T) *MockStore {
+		ms := setupMockStore(t)
+		agentRepo := NewMockAgentRepository(t)
+		groupRepo := NewMockServiceGroupRepository(t)
+		serviceTypeRepo := NewMockServiceTypeRepository(t)
+		serviceRepo := NewMockServiceRepository(t)
+		optionRepo := NewMockServiceOptionRepository(t)
+		optionTypeRepo := NewMockServiceOptionTypeRepository(t)
+		ms.EXPECT().AgentRepo().Return(agentRepo)
+		ms.EXPECT().ServiceGroupRepo().Return(groupRepo)
+		ms.EXPECT().ServiceTypeRepo().Return(serviceTypeRepo)
+		ms.EXPECT().ServiceRepo().Return(serviceRepo)
+		ms.EXPECT().ServiceOptionRepo().Return(optionRepo)
+		ms.EXPECT().ServiceOptionTypeRepo().Return(optionTypeRepo)
+		agentRepo.EXPECT().Get(mock.Anything, agent.ID).Return(agent, nil)
+		groupRepo.EXPECT().Get(mock.Anything, group.ID).Return(group, nil)
+		serviceTypeRepo.EXPECT().Get(mock.Anything, serviceType.ID).Return(serviceType, nil)
+		serviceRepo.EXPECT().FindByGroupAndName(mock.Anything, group.ID, "svc").Return(nil, NewNotFoundErrorf("service not found"))
+		optionRepo.EXPECT().Get(mock.Anything, ubuntu.ID).Return(ubuntu, nil)
+		optionTypeRepo.EXPECT().Get(mock.Anything, osType.ID).Return(osType, nil)
+		// The serviceOption validator checks the resolved value again
+		optionTypeRepo.EXPECT().FindByType(mock.Anything, "os").Return(osType, nil)
+		optionRepo.EXPECT().ListByProviderAndType(mock.Anything, agent.ProviderID, osType.ID).Return([]*ServiceOption{ubuntu}, nil)
+		return ms
+	}
+	params := func(props properties.JSON) CreateServiceWithTagsParams {
+		return CreateServiceWithTagsParams{CreateServiceParams: CreateServiceParams{
+			AgentID:       agent.ID,
+			ServiceTypeID: serviceType.ID,
+			GroupID:       group.ID,
+			Name:          "svc",
+			Properties:    props,
+			OptionIDs:     []properties.UUID{ubuntu.ID},
+		}}
+	}
+
+	t.Run("options resolved into the properties", func(t *testing.T) {
+		cmd := NewServiceCommander(setup(t), NewServicePropertyEngine(nil), nil, 0, JobRetryPolicy{}, nil)
+
+		result, err := cmd.ValidateCreate(ctx, params(properties.JSON{"name": "web"}))
+		require.NoError(t, err)
+		assert.True(t, result.Valid)
+		assert.Equal(t, "ubuntu-24.04", result.Properties["os"])
+	})
+
+	t.Run("resolved properties still validated by the schema", func(t *testing.T) {
+		cmd := NewServiceCommander(setup(t), NewServicePropertyEngine(nil), nil, 0, JobRetryPolicy{}, nil)
+
+		result, err := cmd.ValidateCreate(ctx, params(properties.JSON{}))
+		require.NoError(t, err)
+		assert.False(t, result.Valid)
+		require.Len(t, result.Errors, 1)
+		assert.Equal(t, "name", result.Errors[0].Path)
+	})
+}
+
+func TestServiceCommander_CreatePoolExhausted(t *testing.T) {
+	ctx := auth.WithIdentity(context.Background(), &auth.Identity{ID: uuid.New(), Role: auth.RoleAdmin})
+	serviceType := &ServiceType{
+		BaseEntity: BaseEntity{ID: uuid.New()},
+		PropertySchema: schema.Schema{Properties: map[string]schema.PropertyDefinition{
+			"ip": {Type: "string", Generator: &schema.GeneratorConfig{Type: "pool", Config: map[string]any{"poolType": "public_ip"}}},
+		}},
+		LifecycleSchema: LifecycleSchema{InitialState: "New"},
+	}
+	poolSetID := uuid.New()
+	agent := &Agent{
+		BaseEntity:       BaseEntity{ID: uuid.New()},
+		ProviderID:       uuid.New(),
+		ServicePoolSetID: &poolSetID,
+		AgentType:        &AgentType{Name: "vm", ServiceTypes: []ServiceType{*serviceType}},
+	}
+	group := &ServiceGroup{BaseEntity: BaseEntity{ID: uuid.New()}, ConsumerID: uuid.New()}
+	pool := &ServicePool{
+		BaseEntity:       BaseEntity{ID: uuid.New()},
+		Type:             "public_ip",
+		PropertyType:     "string",
+		GeneratorType:    PoolGeneratorList,
+		ServicePoolSetID: poolSetID,
+	}
+
+	ms := setupMockStore(t)
+	agentRepo := NewMockAgentRepository(t)
+	groupRepo := NewMockServiceGroupRepository(t)
+	serviceTypeRepo := NewMockServiceTypeRepository(t)
+	poolRepo := NewMockServicePoolRepository(t)
+	valueRepo := NewMockServicePoolValueRepository(t)
+	eventRepo := NewMockEventRepository(t)
+	serviceRepo := NewMockServiceRepository(t)
+	ms.EXPECT().AgentRepo().Return(agentRepo)
+	ms.EXPECT().ServiceGroupRepo().Return(groupRepo)
+	ms.EXPECT().ServiceTypeRepo().Return(serviceTypeRepo)
+	ms.EXPECT().ServiceRepo().Return(serviceRepo)
+	serviceRepo.EXPECT().FindByGroupAndName(mock.Anything, group.ID, "svc").Return(nil, NewNotFoundErrorf("service not found"))
+	ms.EXPECT().ServicePoolRepo().Return(poolRepo)
+	ms.EXPECT().ServicePoolValueRepo().Return(valueRepo)
+	ms.EXPECT().EventRepo().Return(eventRepo)
+	agentRepo.EXPECT().Get(mock.Anything, agent.ID).Return(agent, nil)
+	groupRepo.EXPECT().Get(mock.Anything, group.ID).Return(group, nil)
+	serviceTypeRepo.EXPECT().Get(mock.Anything, serviceType.ID).Return(serviceType, nil)
+	poolRepo.EXPECT().ListByPoolSet(mock.Anything, poolSetID).Return([]*ServicePool{pool}, nil)
+	poolRepo.EXPECT().ReserveValue(mock.Anything, pool.ID, mock.Anything, "ip").Return(nil, NewPoolExhaustedError(pool.ID))
+	poolRepo.EXPECT().Get(mock.Anything, pool.ID).Return(pool, nil)
+	// Only the exhaustion event is stored, the service is never created
+	eventRepo.EXPECT().Create(mock.Anything, mock.MatchedBy(func(e *Event) bool {
+		return e.Type == EventTypePoolExhausted && *e.EntityID == pool.ID &&
+			*e.ProviderID == agent.ProviderID && *e.ConsumerID == group.ConsumerID &&
+			e.Payload["poolType"] == "public_ip" && e.Payload["serviceTypeId"] == serviceType.ID
+	})).Return(nil).Once()
+
+	_, err := NewServiceCommander(ms, NewServicePropertyEngine(nil), nil, 0, JobRetryPolicy{}, nil).Create(ctx, CreateServiceParams{
+		AgentID:       agent.ID,
+		ServiceTypeID: serviceType.ID,
+		GroupID:       group.ID,
+		Name:          "svc",
+		Properties:    properties.JSON{},
+	})
+	require.Error(t, err)
+	assert.ErrorAs(t, err, &PoolExhaustedError{})
+}
+
+func TestServiceCommander_Clone(t *testing.T) {
+	ctx := auth.WithIdentity(context.Background(), &auth.Identity{ID: uuid.New(), Role: auth.RoleAdmin})
+	serviceType := &ServiceType{
+		BaseEntity: BaseEntity{ID: uuid.New()},
+		PropertySchema: schema.Schema{Properties: map[string]schema.PropertyDefinition{
+			"size": {Type: "integer"},
+			"ip": {Type: "string", Authorizers: []schema.AuthorizerConfig{
+				{Type: "actor", Config: map[string]any{"actors": []any{"agent"}}},
+			}},
+			"password": {Type: "string", Secret: &schema.SecretConfig{Type: "persistent"}},
+		}},
+		LifecycleSchema: LifecycleSchema{InitialState: "New"},
+	}
+	agent := &Agent{
+		BaseEntity: BaseEntity{ID: uuid.New()},
+		ProviderID: uuid.New(),
+		AgentType:  &AgentType{Name: "vm", ServiceTypes: []ServiceType{*serviceType}},
+	}
+	group := &ServiceGroup{BaseEntity: BaseEntity{ID: uuid.New()}, ConsumerID: uuid.New()}
+	source := &Service{
+		BaseEntity:    BaseEntity{ID: uuid.New()},
+		Name:          "source",
+		Status:        "Failed",
+		AgentID:       agent.ID,
+		ServiceTypeID: serviceType.ID,
+		GroupID:       group.ID,
+		Properties:    &properties.JSON{"size": 2, "ip": "10.0.0.1", "password": "vault://secret"},
+	}
+
+	ms := setupMockStore(t)
+	serviceRepo := NewMockServiceRepository(t)
+	serviceTypeRepo := NewMockServiceTypeRepository(t)
+	agentRepo := NewMockAgentRepository(t)
+	groupRepo := NewMockServiceGroupRepository(t)
+	jobRepo := NewMockJobRepository(t)
+	eventRepo := NewMockEventRepository(t)
+	ms.EXPECT().ServiceRepo().Return(serviceRepo)
+	ms.EXPECT().ServiceTypeRepo().Return(serviceTypeRepo)
+	ms.EXPECT().AgentRepo().Return(agentRepo)
+	ms.EXPECT().ServiceGroupRepo().Return(groupRepo)
+	ms.EXPECT().JobRepo().Return(jobRepo)
+	ms.EXPECT().EventRepo().Return(eventRepo)
+	serviceRepo.EXPECT().Get(mock.Anything, source.ID).Return(source, nil)
+	serviceTypeRepo.EXPECT().Get(mock.Anything, serviceType.ID).Return(serviceType, nil)
+	agentRepo.EXPECT().Get(mock.Anything, agent.ID).Return(agent, nil)
+	groupRepo.EXPECT().Get(mock.Anything, group.ID).Return(group, nil)
+	serviceRepo.EXPECT().FindByGroupAndName(mock.Anything, group.ID, mock.Anything).Return(nil, NewNotFoundErrorf("service not found"))
+	serviceRepo.EXPECT().Create(mock.Anything, mock.AnythingOfType("*domain.Service")).Return(nil)
+	jobRepo.EXPECT().Create(mock.Anything, mock.MatchedBy(func(j *Job) bool { return j.Action == "create" })).Return(nil)
+	eventRepo.EXPECT().Create(mock.Anything, mock.Anything).Return(nil)
+
+	clone, err := NewServiceCommander(ms, NewServicePropertyEngine(nil), nil, 0, JobRetryPolicy{}, nil).Clone(ctx, source.ID, "copy")
+	require.NoError(t, err)
+	assert.NotEqual(t, source.ID, clone.ID)
+	assert.Equal(t, "copy", clone.Name)
+	assert.Equal(t, "New", clone.Status)
+	assert.Equal(t, group.ID, clone.GroupID)
+	assert.Equal(t, properties.JSON{"size": 2}, *clone.Properties)
+}
+
+func TestServiceCommander_CreateOperationTimeout(t *testing.T) {
+	ctx := auth.WithIdentity(context.Background(), &auth.Identity{ID: uuid.New(), Role: auth.RoleAdmin})
+	serviceType := &ServiceType{
+		BaseEntity:       BaseEntity{ID: uuid.New()},
+		LifecycleSchema:  LifecycleSchema{InitialState: "New"},
+		OperationTimeout: helpers.DurationPtr(2 * time.Hour),
+	}
+	agent := &Agent{
+		BaseEntity: BaseEntity{ID: uuid.New()},
+		ProviderID: uuid.New(),
+		AgentType:  &AgentType{Name: "vm", ServiceTypes: []ServiceType{*serviceType}},
+	}
+	group := &ServiceGroup{BaseEntity: BaseEntity{ID: uuid.New()}, ConsumerID: uuid.New()}
+
+	setup := func(t *testing.T) (*MockStore, *MockServiceRepository) {
+		ms := setupMockStore(t)
+		agentRepo := NewMockAgentRepository(t)
+		groupRepo := NewMockServiceGroupRepository(t)
+		serviceTypeRepo := NewMockServiceTypeRepository(t)
+		serviceRepo := NewMockServiceRepository(t)
+		ms.EXPECT().AgentRepo().Return(agentRepo)
+		ms.EXPECT().ServiceGroupRepo().Return(groupRepo)
+		ms.EXPECT().ServiceTypeRepo().Return(serviceTypeRepo)
+		ms.EXPECT().ServiceRepo().Return(serviceRepo).Maybe()
+		agentRepo.EXPECT().Get(mock.Anything, agent.ID).Return(agent, nil)
+		groupRepo.EXPECT().Get(mock.Anything, group.ID).Return(group, nil)
+		serviceTypeRepo.EXPECT().Get(mock.Anything, serviceType.ID).Return(serviceType, nil)
+		return ms, serviceRepo
+	}
+	expectCreate := func(ms *MockStore, serviceRepo *MockServiceRepository) {
+		jobRepo := NewMockJobRepository(t)
+		eventRepo := NewMockEventRepository(t)
+		ms.EXPECT().JobRepo().Return(jobRepo)
+		ms.EXPECT().EventRepo().Return(eventRepo)
+		serviceRepo.EXPECT().FindByGroupAndName(mock.Anything, group.ID, "svc").Return(nil, NewNotFoundErrorf("service not found"))
+		serviceRepo.EXPECT().Create(mock.Anything, mock.AnythingOfType("*domain.Service")).Return(nil)
+		jobRepo.EXPECT().Create(mock.Anything, mock.Anything).Return(nil)
+		eventRepo.EXPECT().Create(mock.Anything, mock.Anything).Return(nil)
+	}
+	params := func(timeout *time.Duration) CreateServiceParams {
+		return CreateServiceParams{
+			AgentID:          agent.ID,
+			ServiceTypeID:    serviceType.ID,
+			GroupID:          group.ID,
+			Name:             "svc",
+			Properties:       properties.JSON{},
+			OperationTimeout: timeout,
+		}
+	}
+
+	t.Run("defaults to the service type", func(t *testing.T) {
+		ms, serviceRepo := setup(t)
+		expectCreate(ms, serviceRepo)
+
+		svc, err := NewServiceCommander(ms, NewServicePropertyEngine(nil), nil, 0, JobRetryPolicy{}, nil).Create(ctx, params(nil))
+		require.NoError(t, err)
+		require.NotNil(t, svc.OperationTimeout)
+		assert.Equal(t, 2*time.Hour, *svc.OperationTimeout)
+		assert.NotSame(t, serviceType.OperationTimeout, svc.OperationTimeout, "the service must not share the service type value")
+	})
+
+	t.Run("overridden at creation", func(t *testing.T) {
+		ms, serviceRepo := setup(t)
+		expectCreate(ms, serviceRepo)
+
+		svc, err := NewServiceCommander(ms, NewServicePropertyEngine(nil), nil, 0, JobRetryPolicy{}, nil).Create(ctx, params(helpers.DurationPtr(15*time.Minute)))
+		require.NoError(t, err)
+		require.NotNil(t, svc.OperationTimeout)
+		assert.Equal(t, 15*time.Minute, *svc.OperationTimeout)
+	})
+
+	t.Run("invalid override", func(t *testing.T) {
+		ms, _ := setup(t)
+
+		_, err := NewServiceCommander(ms, NewServicePropertyEngine(nil), nil, 0, JobRetryPolicy{}, nil).Create(ctx, params(helpers.DurationPtr(-time.Minute)))
+		var invalidInput InvalidInputError
+		require.ErrorAs(t, err, &invalidInput)
+		assert.ErrorContains(t, err, "operation timeout must be positive")
+	})
+}
+
+func TestServiceCommander_CreateDefaultProperties(t *testing.T) {
+	ctx := auth.WithIdentity(context.Background(), &auth.Identity{ID: uuid.New(), Role: auth.RoleAdmin})
+	newServiceType := func(defaults properties.JSON) *ServiceType {
+		return &ServiceType{
+			BaseEntity:      BaseEntity{ID: uuid.New()},
+			LifecycleSchema: LifecycleSchema{InitialState: "New"},
+			PropertySchema: schema.Schema{Properties: map[string]schema.PropertyDefinition{
+				"cpu":  {Type: "integer", Required: true},
+				"tier": {Type: "string"},
+				"disk": {Type: "object", Properties: map[string]schema.PropertyDefinition{
+					"size": {Type: "integer"},
+					"type": {Type: "string"},
+				}},
+			}},
+			DefaultProperties: defaults,
+		}
+	}
+	group := &ServiceGroup{BaseEntity: BaseEntity{ID: uuid.New()}, ConsumerID: uuid.New()}
+
+	setup := func(t *testing.T, serviceType *ServiceType) (*MockStore, *MockServiceRepository, *Agent) {
+		agent := &Agent{
+			BaseEntity: BaseEntity{ID: uuid.New()},
+			ProviderID: uuid.New(),
+			AgentType:  &AgentType{Name: "vm", ServiceTypes: []ServiceType{*serviceType}},
+		}
+		ms := setupMockStore(t)
+		agentRepo := NewMockAgentRepository(t)
+		groupRepo := NewMockServiceGroupRepository(t)
+		serviceTypeRepo := NewMockServiceTypeRepository(t)
+		serviceRepo := NewMockServiceRepository(t)
+		ms.EXPECT().AgentRepo().Return(agentRepo)
+		ms.EXPECT().ServiceGroupRepo().Return(groupRepo)
+		ms.EXPECT().ServiceTypeRepo().Return(serviceTypeRepo)
+		ms.EXPECT().ServiceRepo().Return(serviceRepo).Maybe()
+		agentRepo.EXPECT().Get(mock.Anything, agent.ID).Return(agent, nil)
+		groupRepo.EXPECT().Get(mock.Anything, group.ID).Return(group, nil)
+		serviceTypeRepo.EXPECT().Get(mock.Anything, serviceType.ID).Return(serviceType, nil)
+		serviceRepo.EXPECT().FindByGroupAndName(mock.Anything, group.ID, "svc").Return(nil, NewNotFoundErrorf("service not found"))
+		return ms, serviceRepo, agent
+	}
+	params := func(serviceType *ServiceType, agent *Agent, props properties.JSON) CreateServiceParams {
+		return CreateServiceParams{
+			AgentID:       agent.ID,
+			ServiceTypeID: serviceType.ID,
+			GroupID:       group.ID,
+			Name:          "svc",
+			Properties:    props,
+		}
+	}
+
+	t.Run("merged under the given properties", func(t *testing.T) {
+		serviceType := newServiceType(properties.JSON{"cpu": float64(2), "tier": "standard", "disk": map[string]any{"size": float64(10), "type": "ssd"}})
+		ms, serviceRepo, agent := setup(t, serviceType)
+		jobRepo := NewMockJobRepository(t)
+		eventRepo := NewMockEventRepository(t)
+		ms.EXPECT().JobRepo().Return(jobRepo)
+		ms.EXPECT().EventRepo().Return(eventRepo)
+		serviceRepo.EXPECT().Create(mock.Anything, mock.AnythingOfType("*domain.Service")).Return(nil)
+		jobRepo.EXPECT().Create(mock.Anything, mock.Anything).Return(nil)
+		eventRepo.EXPECT().Create(mock.Anything, mock.Anything).Return(nil)
+
+		svc, err := NewServiceCommander(ms, NewServicePropertyEngine(nil), nil, 0, JobRetryPolicy{}, nil).Create(ctx, params(serviceType, agent, properties.JSON{
+			"tier": "premium",
+			"disk": map[string]any{"size": float64(20)},
+		}))
+		require.NoError(t, err)
+		require.NotNil(t, svc.Properties)
+		assert.Equal(t, float64(2), (*svc.Properties)["cpu"])
+		assert.Equal(t, "premium", (*svc.Properties)["tier"])
+		assert.Equal(t, map[string]any{"size": float64(20), "type": "ssd"}, (*svc.Properties)["disk"])
+
+		// Changing the defaults afterwards leaves the service untouched
+		serviceType.DefaultProperties["disk"].(map[string]any)["type"] = "hdd"
+		assert.Equal(t, "ssd", (*svc.Properties)["disk"].(map[string]any)["type"])
+	})
+
+	t.Run("defaults are validated", func(t *testing.T) {
+		serviceType := newServiceType(properties.JSON{"cpu": "two"})
+		ms, _, agent := setup(t, serviceType)
+
+		_, err := NewServiceCommander(ms, NewServicePropertyEngine(nil), nil, 0, JobRetryPolicy{}, nil).Create(ctx, params(serviceType, agent, properties.JSON{}))
+		var validationErr schema.ValidationError
+		assert.ErrorAs(t, err, &validationErr)
+	})
+
+	t.Run("required properties without defaults fail", func(t *testing.T) {
+		serviceType := newServiceType(nil)
+		ms, _, agent := setup(t, serviceType)
+
+		_, err := NewServiceCommander(ms, NewServicePropertyEngine(nil), nil, 0, JobRetryPolicy{}, nil).Create(ctx, params(serviceType, agent, properties.JSON{}))
+		var validationErr schema.ValidationError
+		assert.ErrorAs(t, err, &validationErr)
+	})
+
+	t.Run("defaults satisfy the required properties", func(t *testing.T) {
+		serviceType := newServiceType(properties.JSON{"cpu": float64(2)})
+		ms, serviceRepo, agent := setup(t, serviceType)
+		jobRepo := NewMockJobRepository(t)
+		eventRepo := NewMockEventRepository(t)
+		ms.EXPECT().JobRepo().Return(jobRepo)
+		ms.EXPECT().EventRepo().Return(eventRepo)
+		serviceRepo.EXPECT().Create(mock.Anything, mock.AnythingOfType("*domain.Service")).Return(nil)
+		jobRepo.EXPECT().Create(mock.Anything, mock.Anything).Return(nil)
+		eventRepo.EXPECT().Create(mock.Anything, mock.Anything).Return(nil)
+
+		svc, err := NewServiceCommander(ms, NewServicePropertyEngine(nil), nil, 0, JobRetryPolicy{}, nil).Create(ctx, params(serviceType, agent, properties.JSON{}))
+		require.NoError(t, err)
+		require.NotNil(t, svc.Properties)
+		assert.Equal(t, float64(2), (*svc.Properties)["cpu"])
+	})
+}
+
+func TestServiceCommander_CreateWithTargetState(t *testing.T) {
+	ctx := auth.WithIdentity(context.Background(), &auth.Identity{ID: uuid.New(), Role: auth.RoleAdmin})
+	serviceType := &ServiceType{
+		BaseEntity: BaseEntity{ID: uuid.New()},
+		LifecycleSchema: LifecycleSchema{
+			States: []LifecycleState{{Name: "New"}, {Name: "Created"}, {Name: "Started"}},
+			Actions: []LifecycleAction{
+				{Name: "create", Transitions: []LifecycleTransition{{From: "New", To: "Created"}}},
+				{Name: "start", Transitions: []LifecycleTransition{{From: "Created", To: "Started"}}},
+			},
+			InitialState: "New",
+		},
+	}
+	agent := &Agent{
+		BaseEntity: BaseEntity{ID: uuid.New()},
+		AgentType:  &AgentType{Name: "vm", ServiceTypes: []ServiceType{*serviceType}},
+	}
+	group := &ServiceGroup{BaseEntity: BaseEntity{ID: uuid.New()}}
+
+	setup := func(t *testing.T) (*MockStore, *MockServiceRepository) {
+		ms := setupMockStore(t)
+		agentRepo := NewMockAgentRepository(t)
+		groupRepo := NewMockServiceGroupRepository(t)
+		serviceTypeRepo := NewMockServiceTypeRepository(t)
+		serviceRepo := NewMockServiceRepository(t)
+		ms.EXPECT().AgentRepo().Return(agentRepo)
+		ms.EXPECT().ServiceGroupRepo().Return(groupRepo)
+		ms.EXPECT().ServiceTypeRepo().Return(serviceTypeRepo)
+		ms.EXPECT().ServiceRepo().Return(serviceRepo).Maybe()
+		agentRepo.EXPECT().Get(mock.Anything, agent.ID).Return(agent, nil)
+		groupRepo.EXPECT().Get(mock.Anything, group.ID).Return(group, nil)
+		serviceTypeRepo.EXPECT().Get(mock.Anything, serviceType.ID).Return(serviceType, nil)
+		return ms, serviceRepo
+	}
+	params := func(target string) CreateServiceParams {
+		return CreateServiceParams{
+			AgentID:       agent.ID,
+			ServiceTypeID: serviceType.ID,
+			GroupID:       group.ID,
+			Name:          "svc",
+			Properties:    properties.JSON{},
+			TargetState:   target,
+		}
+	}
+
+	t.Run("the create job carries the target state", func(t *testing.T) {
+		ms, serviceRepo := setup(t)
+		jobRepo := NewMockJobRepository(t)
+		eventRepo := NewMockEventRepository(t)
+		ms.EXPECT().JobRepo().Return(jobRepo)
+		ms.EXPECT().EventRepo().Return(eventRepo)
+		serviceRepo.EXPECT().FindByGroupAndName(mock.Anything, group.ID, "svc").Return(nil, NewNotFoundErrorf("service not found"))
+		serviceRepo.EXPECT().Create(mock.Anything, mock.AnythingOfType("*domain.Service")).Return(nil)
+		jobRepo.EXPECT().Create(mock.Anything, mock.MatchedBy(func(job *Job) bool {
+			return job.Action == "create" && job.TargetState != nil && *job.TargetState == "Started"
+		})).Return(nil)
+		eventRepo.EXPECT().Create(mock.Anything, mock.Anything).Return(nil)
+
+		svc, err := NewServiceCommander(ms, NewServicePropertyEngine(nil), nil, 0, JobRetryPolicy{}, nil).Create(ctx, params("Started"))
+		require.NoError(t, err)
+		assert.Equal(t, "New", svc.Status)
+	})
+
+	t.Run("unknown state", func(t *testing.T) {
+		ms, _ := setup(t)
+
+		_, err := NewServiceCommander(ms, NewServicePropertyEngine(nil), nil, 0, JobRetryPolicy{}, nil).Create(ctx, params("Running"))
+		require.ErrorAs(t, err, &InvalidInputError{})
+		assert.ErrorContains(t, err, "not defined by the lifecycle")
+	})
+
+	t.Run("state not reachable after the creation", func(t *testing.T) {
+		ms, _ := setup(t)
+
+		_, err := NewServiceCommander(ms, NewServicePropertyEngine(nil), nil, 0, JobRetryPolicy{}, nil).Create(ctx, params("New"))
+		require.ErrorAs(t, err, &InvalidInputError{})
+		assert.ErrorContains(t, err, "cannot be reached after the creation")
+	})
+}
+
+func TestServiceCommander_NameTaken(t *testing.T) {
+	ctx := auth.WithIdentity(context.Background(), &auth.Identity{ID: uuid.New(), Role: auth.RoleAdmin})
+	serviceType := &ServiceType{
+		BaseEntity:      BaseEntity{ID: uuid.New()},
+		LifecycleSchema: LifecycleSchema{InitialState: "New"},
+	}
+	agent := &Agent{
+		BaseEntity: BaseEntity{ID: uuid.New()},
+		ProviderID: uuid.New(),
+		AgentType:  &AgentType{Name: "vm", ServiceTypes: []ServiceType{*serviceType}},
+	}
+	group := &ServiceGroup{BaseEntity: BaseEntity{ID: uuid.New()}, ConsumerID: uuid.New()}
+	other := &Service{BaseEntity: BaseEntity{ID: uuid.New()}, Name: "web", GroupID: group.ID}
+
+	t.Run("create", func(t *testing.T) {
+		ms := setupMockStore(t)
+		agentRepo := NewMockAgentRepository(t)
+		groupRepo := NewMockServiceGroupRepository(t)
+		serviceTypeRepo := NewMockServiceTypeRepository(t)
+		serviceRepo := NewMockServiceRepository(t)
+		ms.EXPECT().AgentRepo().Return(agentRepo)
+		ms.EXPECT().ServiceGroupRepo().Return(groupRepo)
+		ms.EXPECT().ServiceTypeRepo().Return(serviceTypeRepo)
+		ms.EXPECT().ServiceRepo().Return(serviceRepo)
+		agentRepo.EXPECT().Get(mock.Anything, agent.ID).Return(agent, nil)
+		groupRepo.EXPECT().Get(mock.Anything, group.ID).Return(group, nil)
+		serviceTypeRepo.EXPECT().Get(mock.Anything, serviceType.ID).Return(serviceType, nil)
+		serviceRepo.EXPECT().FindByGroupAndName(mock.Anything, group.ID, "web").Return(other, nil)
+
+		_, err := NewServiceCommander(ms, NewServicePropertyEngine(nil), nil, 0, JobRetryPolicy{}, nil).Create(ctx, CreateServiceParams{
+			AgentID:       agent.ID,
+			ServiceTypeID: serviceType.ID,
+			GroupID:       group.ID,
+			Name:          "web",
+		})
+		assert.ErrorIs(t, err, ErrServiceNameTaken)
+		assert.ErrorAs(t, err, &ConflictError{})
+	})
+
+	t.Run("rename", func(t *testing.T) {
+		svc := &Service{
+			BaseEntity:    BaseEntity{ID: uuid.New()},
+			Name:          "api",
+			Status:        "Started",
+			AgentID:       agent.ID,
+			ServiceTypeID: serviceType.ID,
+			GroupID:       group.ID,
+		}
+		ms := setupMockStore(t)
+		agentRepo := NewMockAgentRepository(t)
+		serviceTypeRepo := NewMockServiceTypeRepository(t)
+		serviceRepo := NewMockServiceRepository(t)
+		ms.EXPECT().AgentRepo().Return(agentRepo)
+		ms.EXPECT().ServiceTypeRepo().Return(serviceTypeRepo)
+		ms.EXPECT().ServiceRepo().Return(serviceRepo)
+		serviceRepo.EXPECT().Get(mock.Anything, svc.ID).Return(svc, nil)
+		serviceTypeRepo.EXPECT().Get(mock.Anything, serviceType.ID).Return(serviceType, nil)
+		agentRepo.EXPECT().Get(mock.Anything, agent.ID).Return(agent, nil)
+		serviceRepo.EXPECT().FindByGroupAndName(mock.Anything, group.ID, "web").Return(other, nil)
+
+		name := "web"
+		_, err := NewServiceCommander(ms, NewServicePropertyEngine(nil), nil, 0, JobRetryPolicy{}, nil).Update(ctx, UpdateServiceParams{ID: svc.ID, Name: &name})
+		assert.ErrorIs(t, err, ErrServiceNameTaken)
+		assert.ErrorAs(t, err, &ConflictError{})
+	})
+}
+
+func TestSelectAgent(t *testing.T) {
+	ctx := context.Background()
+	serviceType := &ServiceType{BaseEntity: BaseEntity{ID: uuid.New()}, RequiredCapabilities: []string{"gpu"}}
+	params := CreateServiceWithTagsParams{
+		CreateServiceParams: CreateServiceParams{ServiceTypeID: serviceType.ID},
+		ServiceTags:         []string{"eu"},
+	}
+	capableType := &AgentType{Capabilities: []string{"gpu"}}
+
+	setup := func(t *testing.T, agents []*Agent) *MockStore {
+		ms := NewMockStore(t)
+		serviceTypeRepo := NewMockServiceTypeRepository(t)
+		serviceTypeRepo.EXPECT().Get(ctx, serviceType.ID).Return(serviceType, nil)
+		ms.EXPECT().ServiceTypeRepo().Return(serviceTypeRepo)
+		agentRepo := NewMockAgentRepository(t)
+		agentRepo.EXPECT().FindByServiceTypeAndTags(ctx, serviceType.ID, []string{"eu"}).Return(agents, nil)
+		ms.EXPECT().AgentRepo().Return(agentRepo)
+		return ms
+	}
+	connected := func(a *Agent) *Agent {
+		a.ID = uuid.New()
+		a.Status = AgentConnected
+		return a
+	}
+
+	t.Run("selects among the suitable agents", func(t *testing.T) {
+		draining := connected(&Agent{AgentType: capableType, Draining: true})
+		incapable := connected(&Agent{AgentType: &AgentType{}})
+		full := connected(&Agent{AgentType: capableType, MaxServices: 2, ActiveServiceCount: 2})
+		disconnected := &Agent{BaseEntity: BaseEntity{ID: uuid.New()}, AgentType: capableType, Status: AgentDisconnected}
+		available := connected(&Agent{AgentType: capableType})
+
+		selector := &recordingAgentSelector{}
+		agent, err := selectAgent(ctx, setup(t, []*Agent{draining, incapable, full, disconnected, available}), selector, params)
+		require.NoError(t, err)
+		assert.Equal(t, available.ID, agent.ID)
+		assert.Equal(t, []*Agent{available}, selector.candidates)
+		assert.Equal(t, params.CreateServiceParams, selector.params)
+	})
+
+	t.Run("no suitable agent", func(t *testing.T) {
+		_, err := selectAgent(ctx, setup(t, []*Agent{connected(&Agent{AgentType: capableType, Draining: true})}), &recordingAgentSelector{}, params)
+		assert.ErrorIs(t, err, ErrNoSuitableAgent)
+		assert.ErrorAs(t, err, &InvalidInputError{})
+	})
+
+	t.Run("selects among the suitable agents of the required region", func(t *testing.T) {
+		us := connected(&Agent{AgentType: capableType, Region: "us-east"})
+		eu := connected(&Agent{AgentType: capableType, Region: "eu-west"})
+		euFull := connected(&Agent{AgentType: capableType, Region: "eu-west", MaxServices: 1, ActiveServiceCount: 1})
+
+		regionParams := params
+		regionParams.RequiredRegion = helpers.StringPtr("eu-west")
+		selector := &recordingAgentSelector{}
+		agent, err := selectAgent(ctx, setup(t, []*Agent{us, eu, euFull}), selector, regionParams)
+		require.NoError(t, err)
+		assert.Equal(t, eu.ID, agent.ID)
+		assert.Equal(t, []*Agent{eu}, selector.candidates)
+	})
+
+	t.Run("no agent in the required region", func(t *testing.T) {
+		us := connected(&Agent{AgentType: capableType, Region: "us-east"})
+		ap := connected(&Agent{AgentType: capableType, Region: "ap-south"})
+		euDraining := connected(&Agent{AgentType: capableType, Region: "eu-west", Draining: true})
+
+		regionParams := params
+		regionParams.RequiredRegion = helpers.StringPtr("eu-west")
+		_, err := selectAgent(ctx, setup(t, []*Agent{us, ap, euDraining}), &recordingAgentSelector{}, regionParams)
+		assert.ErrorIs(t, err, ErrNoAgentInRegion)
+		assert.NotErrorIs(t, err, ErrNoSuitableAgent)
+		assert.ErrorAs(t, err, &InvalidInputError{})
+		assert.ErrorContains(t, err, "suitable agents run in regions ap-south, us-east")
+	})
+}
+
+func TestCheckServicePlacement_Region(t *testing.T) {
+	serviceType := &ServiceType{BaseEntity: BaseEntity{ID: uuid.New()}}
+	agent := &Agent{
+		BaseEntity: BaseEntity{ID: uuid.New()},
+		Region:     "us-east",
+		AgentType:  &AgentType{ServiceTypes: []ServiceType{*serviceType}},
+	}
+
+	require.NoError(t, checkServicePlacement(agent, serviceType, nil))
+	require.NoError(t, checkServicePlacement(agent, serviceType, helpers.StringPtr("us-east")))
+
+	err := checkServicePlacement(agent, serviceType, helpers.StringPtr("eu-west"))
+	assert.ErrorIs(t, err, ErrNoAgentInRegion)
+	assert.ErrorAs(t, err, &InvalidInputError{})
+}
+
+// recordingAgentSelector selects the first candidate and records its arguments
+type recordingAgentSelector struct {
+	candidates []*Agent
+	params     CreateServiceParams
+}
+
+func (s *recordingAgentSelector) Select(candidates []*Agent, params CreateServiceParams) (*Agent, error) {
+	s.candidates = candidates
+	s.params = params
+	return candidates[0], nil
+}
+
+func TestServiceCommander_UpdateRegion(t *testing.T) {
+	ctx := auth.WithIdentity(context.Background(), &auth.Identity{ID: uuid.New(), Role: auth.RoleAdmin})
+
+	for _, required := range []*string{nil, helpers.StringPtr("us-east")} {
+		svc := &Service{BaseEntity: BaseEntity{ID: uuid.New()}, Status: "Started", RequiredRegion: required}
+		ms := setupMockStore(t)
+		serviceRepo := NewMockServiceRepository(t)
+		ms.EXPECT().ServiceRepo().Return(serviceRepo)
+		serviceRepo.EXPECT().Get(mock.Anything, svc.ID).Return(svc, nil)
+
+		_, err := NewServiceCommander(ms, nil, nil, 0, JobRetryPolicy{}, nil).Update(ctx, UpdateServiceParams{ID: svc.ID, RequiredRegion: helpers.StringPtr("eu-west")})
+		assert.ErrorAs(t, err, &InvalidInputError{})
+		assert.ErrorContains(t, err, "cannot be changed after its creation")
+		assert.Equal(t, required, svc.RequiredRegion)
+	}
+}
+
+func TestServiceCommander_CreateMissingCapabilities(t *testing.T) {
+	ctx := auth.WithIdentity(context.Background(), &auth.Identity{ID: uuid.New(), Role: auth.RoleAdmin})
+	serviceType := &ServiceType{
+		BaseEntity:           BaseEntity{ID: uuid.New()},
+		LifecycleSchema:      LifecycleSchema{InitialState: "New"},
+		RequiredCapabilities: []string{"gpu", "!shared"},
+	}
+	agent := &Agent{
+		BaseEntity: BaseEntity{ID: uuid.New()},
+		AgentType:  &AgentType{Name: "vm", ServiceTypes: []ServiceType{*serviceType}, Capabilities: []string{"ssd", "shared"}},
+	}
+	group := &ServiceGroup{BaseEntity: BaseEntity{ID: uuid.New()}}
+
+	ms := NewMockStore(t)
+	agentRepo := NewMockAgentRepository(t)
+	groupRepo := NewMockServiceGroupRepository(t)
+	serviceTypeRepo := NewMockServiceTypeRepository(t)
+	ms.EXPECT().AgentRepo().Return(agentRepo)
+	ms.EXPECT().ServiceGroupRepo().Return(groupRepo)
+	ms.EXPECT().ServiceTypeRepo().Return(serviceTypeRepo)
+	agentRepo.EXPECT().Get(mock.Anything, agent.ID).Return(agent, nil)
+	groupRepo.EXPECT().Get(mock.Anything, group.ID).Return(group, nil)
+	serviceTypeRepo.EXPECT().Get(mock.Anything, serviceType.ID).Return(serviceType, nil)
+
+	cmd := NewServiceCommander(ms, NewServicePropertyEngine(nil), nil, 0, JobRetryPolicy{}, nil)
+	_, err := cmd.Create(ctx, CreateServiceParams{
+		AgentID:       agent.ID,
+		ServiceTypeID: serviceType.ID,
+		GroupID:       group.ID,
+		Name:          "svc",
+	})
+	require.ErrorAs(t, err, &InvalidInputError{})
+	assert.Contains(t, err.Error(), "gpu, !shared")
+}
+
+func TestService_SoftDeleteRestore(t *testing.T) {
+	instanceID := "vm-100"
+	svc := &Service{Status: "Deleted", AgentInstanceID: &instanceID}
+
+	assert.Error(t, svc.Restore(time.Hour), "a service that is not deleted cannot be restored")
+
+	svc.SoftDelete("Stopped")
+	assert.True(t, svc.IsDeleted())
+	assert.Nil(t, svc.AgentInstanceID)
+	assert.Equal(t, &instanceID, svc.DeletedAgentInstanceID)
+
+	require.NoError(t, svc.Restore(time.Hour))
+	assert.False(t, svc.IsDeleted())
+	assert.Equal(t, "Stopped", svc.Status)
+	assert.Equal(t, &instanceID, svc.AgentInstanceID)
+	assert.Nil(t, svc.DeletedAgentInstanceID)
+
+	svc.SoftDelete("Started")
+	deletedAt := time.Now().Add(-2 * time.Hour)
+	svc.DeletedAt = &deletedAt
+	assert.Error(t, svc.Restore(time.Hour), "the restore window elapsed")
+}
+
+func TestServiceCommander_Restore(t *testing.T) {
+	ctx := auth.WithIdentity(context.Background(), &auth.Identity{ID: uuid.New(), Role: auth.RoleAdmin})
+	serviceType := &ServiceType{BaseEntity: BaseEntity{ID: uuid.New()}}
+	instanceID := "vm-100"
+
+	newFixtures := func() (*Service, *Agent) {
+		deletedAt := time.Now().Add(-time.Hour)
+		agent := &Agent{
+			BaseEntity: BaseEntity{ID: uuid.New()},
+			Status:     AgentConnected,
+			AgentType:  &AgentType{Name: "vm", ServiceTypes: []ServiceType{*serviceType}},
+		}
+		svc := &Service{
+			BaseEntity:             BaseEntity{ID: uuid.New()},
+			Status:                 "Deleted",
+			GroupID:                uuid.New(),
+			ConsumerID:             uuid.New(),
+			AgentID:                agent.ID,
+			ServiceTypeID:          serviceType.ID,
+			DeletedAt:              &deletedAt,
+			DeletedFromStatus:      "Started",
+			DeletedAgentInstanceID: &instanceID,
+		}
+		return svc, agent
+	}
+
+	setup := func(t *testing.T, svc *Service, agent *Agent) (*MockStore, *MockServiceRepository, *MockEventRepository) {
+		ms := setupMockStore(t)
+		serviceRepo := NewMockServiceRepository(t)
+		groupRepo := NewMockServiceGroupRepository(t)
+		serviceTypeRepo := NewMockServiceTypeRepository(t)
+		agentRepo := NewMockAgentRepository(t)
+		eventRepo := NewMockEventRepository(t)
+		ms.EXPECT().ServiceRepo().Return(serviceRepo).Maybe()
+		ms.EXPECT().ServiceGroupRepo().Return(groupRepo).Maybe()
+		ms.EXPECT().ServiceTypeRepo().Return(serviceTypeRepo).Maybe()
+		ms.EXPECT().AgentRepo().Return(agentRepo).Maybe()
+		ms.EXPECT().EventRepo().Return(eventRepo).Maybe()
+		serviceRepo.EXPECT().Get(mock.Anything, svc.ID).Return(svc, nil)
+		groupRepo.EXPECT().Get(mock.Anything, svc.GroupID).
+			Return(&ServiceGroup{BaseEntity: BaseEntity{ID: svc.GroupID}, ConsumerID: svc.ConsumerID}, nil).Maybe()
+		serviceTypeRepo.EXPECT().Get(mock.Anything, serviceType.ID).Return(serviceType, nil).Maybe()
+		agentRepo.EXPECT().Get(mock.Anything, agent.ID).Return(agent, nil).Maybe()
+		return ms, serviceRepo, eventRepo
+	}
+
+	t.Run("name taken in the group", func(t *testing.T) {
+		svc, agent := newFixtures()
+		ms, serviceRepo, _ := setup(t, svc, agent)
+		serviceRepo.EXPECT().FindByAgentInstanceID(mock.Anything, agent.ID, instanceID).Return(nil, NewNotFoundErrorf("service not found"))
+		serviceRepo.EXPECT().FindByGroupAndName(mock.Anything, svc.GroupID, svc.Name).Return(&Service{BaseEntity: BaseEntity{ID: uuid.New()}}, nil)
+
+		_, err := NewServiceCommander(ms, nil, nil, 24*time.Hour, JobRetryPolicy{}, nil).Restore(ctx, svc.ID)
+		assert.ErrorIs(t, err, ErrServiceNameTaken)
+		assert.ErrorAs(t, err, &ConflictError{})
+	})
+
+	t.Run("restores the service", func(t *testing.T) {
+		svc, agent := newFixtures()
+		ms, serviceRepo, eventRepo := setup(t, svc, agent)
+		serviceRepo.EXPECT().FindByAgentInstanceID(mock.Anything, agent.ID, instanceID).Return(nil, NewNotFoundErrorf("service not found"))
+		serviceRepo.EXPECT().FindByGroupAndName(mock.Anything, svc.GroupID, svc.Name).Return(nil, NewNotFoundErrorf("service not found"))
+		serviceRepo.EXPECT().Save(mock.Anything, svc).Return(nil)
+		eventRepo.EXPECT().Create(mock.Anything, mock.MatchedBy(func(e *Event) bool {
+			return e.Type == EventTypeServiceRestored
+		})).Return(nil)
+
+		result, err := NewServiceCommander(ms, nil, nil, 24*time.Hour, JobRetryPolicy{}, nil).Restore(ctx, svc.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "Started", result.Status)
+		assert.Equal(t, &instanceID, result.AgentInstanceID)
+		assert.False(t, result.IsDeleted())
+	})
+
+	t.Run("not deleted", func(t *testing.T) {
+		svc, agent := newFixtures()
+		svc.DeletedAt = nil
+		ms, _, _ := setup(t, svc, agent)
+
+		_, err := NewServiceCommander(ms, nil, nil, 24*time.Hour, JobRetryPolicy{}, nil).Restore(ctx, svc.ID)
+		assert.ErrorAs(t, err, &InvalidInputError{})
+	})
+
+	t.Run("agent no longer placeable", func(t *testing.T) {
+		svc, agent := newFixtures()
+		agent.Draining = true
+		ms, _, _ := setup(t, svc, agent)
+
+		_, err := NewServiceCommander(ms, nil, nil, 24*time.Hour, JobRetryPolicy{}, nil).Restore(ctx, svc.ID)
+		assert.ErrorAs(t, err, &InvalidInputError{})
+		assert.Contains(t, err.Error(), "draining")
+	})
+
+	t.Run("agent disabled", func(t *testing.T) {
+		svc, agent := newFixtures()
+		agent.Status = AgentDisabled
+		ms, _, _ := setup(t, svc, agent)
+
+		_, err := NewServiceCommander(ms, nil, nil, 24*time.Hour, JobRetryPolicy{}, nil).Restore(ctx, svc.ID)
+		assert.ErrorAs(t, err, &InvalidInputError{})
+	})
+
+	t.Run("instance reused", func(t *testing.T) {
+		svc, agent := newFixtures()
+		ms, serviceRepo, _ := setup(t, svc, agent)
+		serviceRepo.EXPECT().FindByAgentInstanceID(mock.Anything, agent.ID, instanceID).
+			Return(&Service{BaseEntity: BaseEntity{ID: uuid.New()}}, nil)
+
+		_, err := NewServiceCommander(ms, nil, nil, 24*time.Hour, JobRetryPolicy{}, nil).Restore(ctx, svc.ID)
+		assert.ErrorAs(t, err, &ConflictError{})
+	})
+
+	t.Run("group reassigned since the deletion", func(t *testing.T) {
+		svc, _ := newFixtures()
+		group := &ServiceGroup{BaseEntity: BaseEntity{ID: svc.GroupID}, ConsumerID: svc.ConsumerID}
+		newConsumer := &Participant{BaseEntity: BaseEntity{ID: uuid.New()}, Name: "acme", Status: ParticipantEnabled}
+
+		// The reassignment moves the active services only
+		ms := setupMockStore(t)
+		groupRepo := NewMockServiceGroupRepository(t)
+		groupRepo.EXPECT().Get(mock.Anything, group.ID).Return(group, nil)
+		groupRepo.EXPECT().Save(mock.Anything, group).Return(nil)
+		participantRepo := NewMockParticipantRepository(t)
+		participantRepo.EXPECT().Get(mock.Anything, newConsumer.ID).Return(newConsumer, nil)
+		serviceRepo := NewMockServiceRepository(t)
+		serviceRepo.EXPECT().FindByGroup(mock.Anything, group.ID).Return([]*Service{}, nil)
+		serviceRepo.EXPECT().Get(mock.Anything, svc.ID).Return(svc, nil)
+		eventRepo := NewMockEventRepository(t)
+		eventRepo.EXPECT().Create(mock.Anything, mock.Anything).Return(nil)
+		ms.EXPECT().ServiceGroupRepo().Return(groupRepo)
+		ms.EXPECT().ParticipantRepo().Return(participantRepo)
+		ms.EXPECT().ServiceRepo().Return(serviceRepo)
+		ms.EXPECT().EventRepo().Return(eventRepo)
+		_, err := NewServiceGroupCommander(ms).Reassign(ctx, group.ID, newConsumer.ID)
+		require.NoError(t, err)
+
+		_, err = NewServiceCommander(ms, nil, nil, 24*time.Hour, JobRetryPolicy{}, nil).Restore(ctx, svc.ID)
+		assert.ErrorAs(t, err, &ConflictError{})
+		assert.ErrorContains(t, err, "reassigned")
+		assert.True(t, svc.IsDeleted(), "the service stays deleted")
+	})
+
+	t.Run("restore window elapsed", func(t *testing.T) {
+		svc, agent := newFixtures()
+		ms, serviceRepo, _ := setup(t, svc, agent)
+		serviceRepo.EXPECT().FindByAgentInstanceID(mock.Anything, agent.ID, instanceID).Return(nil, NewNotFoundErrorf("service not found"))
+
+		_, err := NewServiceCommander(ms, nil, nil, time.Minute, JobRetryPolicy{}, nil).Restore(ctx, svc.ID)
+		assert.ErrorAs(t, err, &InvalidInputError{})
+	})
+}
+
+func TestServiceCommander_PurgeDeletedServices(t *testing.T) {
+	ms := setupMockStore(t)
+	serviceRepo := NewMockServiceRepository(t)
+	jobRepo := NewMockJobRepository(t)
+	poolValueRepo := NewMockServicePoolValueRepository(t)
+	ms.EXPECT().ServiceRepo().Return(serviceRepo)
+	ms.EXPECT().JobRepo().Return(jobRepo)
+	ms.EXPECT().ServicePoolValueRepo().Return(poolValueRepo)
+
+	svc := &Service{BaseEntity: BaseEntity{ID: uuid.New()}}
+	serviceRepo.EXPECT().FindDeletedBefore(mock.Anything, mock.MatchedBy(func(before time.Time) bool {
+		return time.Until(before) < -23*time.Hour
+	})).Return([]*Service{svc}, nil)
+	poolValueRepo.EXPECT().ReleaseByService(mock.Anything, svc.ID).Return(nil)
+	jobRepo.EXPECT().DeleteByService(mock.Anything, svc.ID).Return(nil)
+	serviceRepo.EXPECT().Delete(mock.Anything, svc.ID).Return(nil)
+
+	count, err := NewServiceCommander(ms, NewServicePropertyEngine(nil), nil, 24*time.Hour, JobRetryPolicy{}, nil).PurgeDeletedServices(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+}
+
+func TestServiceCommander_SetLabels(t *testing.T) {
+	ctx := auth.WithIdentity(context.Background(), &auth.Identity{ID: uuid.New(), Role: auth.RoleAdmin})
+	prod, gold := "prod", "gold"
+
+	labelSchema := &schema.Schema{Properties: map[string]schema.PropertyDefinition{
+		"env": {Type: "string", Validators: []schema.ValidatorConfig{{Type: "enum", Config: map[string]any{"values": []any{"dev", "prod"}}}}},
+	}}
+
+	setupWithSchema := func(t *testing.T, svc *Service, labelSchema *schema.Schema) (*MockStore, *MockServiceRepository, *MockEventRepository) {
+		ms := setupMockStore(t)
+		serviceRepo := NewMockServiceRepository(t)
+		serviceTypeRepo := NewMockServiceTypeRepository(t)
+		eventRepo := NewMockEventRepository(t)
+		ms.EXPECT().ServiceRepo().Return(serviceRepo).Maybe()
+		ms.EXPECT().ServiceTypeRepo().Return(serviceTypeRepo).Maybe()
+		ms.EXPECT().EventRepo().Return(eventRepo).Maybe()
+		serviceRepo.EXPECT().Get(mock.Anything, svc.ID).Return(svc, nil)
+		serviceTypeRepo.EXPECT().Get(mock.Anything, svc.ServiceTypeID).Return(&ServiceType{LabelSchema: labelSchema}, nil).Maybe()
+		return ms, serviceRepo, eventRepo
+	}
+	setup := func(t *testing.T, svc *Service) (*MockStore, *MockServiceRepository, *MockEventRepository) {
+		return setupWithSchema(t, svc, nil)
+	}
+
+	t.Run("sets and removes labels", func(t *testing.T) {
+		svc := &Service{BaseEntity: BaseEntity{ID: uuid.New()}, Status: "Started", Labels: map[string]string{"env": "dev", "tier": "silver"}}
+		ms, serviceRepo, eventRepo := setup(t, svc)
+		serviceRepo.EXPECT().Save(mock.Anything, svc).Return(nil)
+		eventRepo.EXPECT().Create(mock.Anything, mock.MatchedBy(func(e *Event) bool {
+			return e.Type == EventTypeServiceUpdated
+		})).Return(nil)
+
+		result, err := NewServiceCommander(ms, nil, nil, 0, JobRetryPolicy{}, nil).SetLabels(ctx, svc.ID, map[string]*string{"env": &prod, "tier": nil, "region": &gold})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"env": "prod", "region": "gold"}, result.Labels)
+		assert.Equal(t, "Started", result.Status)
+	})
+
+	t.Run("unchanged labels are not saved", func(t *testing.T) {
+		svc := &Service{BaseEntity: BaseEntity{ID: uuid.New()}, Labels: map[string]string{"env": "prod"}}
+		ms, _, _ := setup(t, svc)
+
+		result, err := NewServiceCommander(ms, nil, nil, 0, JobRetryPolicy{}, nil).SetLabels(ctx, svc.ID, map[string]*string{"env": &prod, "tier": nil})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"env": "prod"}, result.Labels)
+	})
+
+	t.Run("invalid label", func(t *testing.T) {
+		svc := &Service{BaseEntity: BaseEntity{ID: uuid.New()}}
+		ms, _, _ := setup(t, svc)
+		invalid := "not valid"
+
+		_, err := NewServiceCommander(ms, nil, nil, 0, JobRetryPolicy{}, nil).SetLabels(ctx, svc.ID, map[string]*string{"env": &invalid})
+		assert.ErrorAs(t, err, &InvalidInputError{})
+		assert.Nil(t, svc.Labels)
+	})
+
+	t.Run("deleted service", func(t *testing.T) {
+		deletedAt := time.Now()
+		svc := &Service{BaseEntity: BaseEntity{ID: uuid.New()}, DeletedAt: &deletedAt}
+		ms, _, _ := setup(t, svc)
+
+		_, err := NewServiceCommander(ms, nil, nil, 0, JobRetryPolicy{}, nil).SetLabels(ctx, svc.ID, map[string]*string{"env": &prod})
+		assert.ErrorAs(t, err, &InvalidInputError{})
+	})
+
+	t.Run("labels conforming to the label schema", func(t *testing.T) {
+		svc := &Service{BaseEntity: BaseEntity{ID: uuid.New()}, ServiceTypeID: uuid.New()}
+		ms, serviceRepo, eventRepo := setupWithSchema(t, svc, labelSchema)
+		serviceRepo.EXPECT().Save(mock.Anything, svc).Return(nil)
+		eventRepo.EXPECT().Create(mock.Anything, mock.Anything).Return(nil)
+
+		result, err := NewServiceCommander(ms, NewServicePropertyEngine(nil), nil, 0, JobRetryPolicy{}, nil).SetLabels(ctx, svc.ID, map[string]*string{"env": &prod})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"env": "prod"}, result.Labels)
+	})
+
+	t.Run("labels violating the label schema", func(t *testing.T) {
+		svc := &Service{BaseEntity: BaseEntity{ID: uuid.New()}, ServiceTypeID: uuid.New()}
+		ms, _, _ := setupWithSchema(t, svc, labelSchema)
+
+		_, err := NewServiceCommander(ms, NewServicePropertyEngine(nil), nil, 0, JobRetryPolicy{}, nil).SetLabels(ctx, svc.ID, map[string]*string{"env": &gold, "tier": &gold})
+		assert.ErrorAs(t, err, &InvalidInputError{})
+		var validationErr schema.ValidationError
+		require.ErrorAs(t, err, &validationErr)
+		paths := make([]string, 0, len(validationErr.Errors))
+		for _, detail := range validationErr.Errors {
+			paths = append(paths, detail.Path)
+		}
+		assert.ElementsMatch(t, []string{"labels.env", "labels.tier"}, paths)
+	})
+}
+
+func TestServiceCommander_SetMaintenance(t *testing.T) {
+	ctx := auth.WithIdentity(context.Background(), &auth.Identity{ID: uuid.New(), Role: auth.RoleAdmin})
+
+	setup := func(t *testing.T, svc *Service) (*MockStore, *MockServiceRepository, *MockEventRepository) {
+		ms := setupMockStore(t)
+		serviceRepo := NewMockServiceRepository(t)
+		eventRepo := NewMockEventRepository(t)
+		ms.EXPECT().ServiceRepo().Return(serviceRepo).Maybe()
+		ms.EXPECT().EventRepo().Return(eventRepo).Maybe()
+		serviceRepo.EXPECT().Get(mock.Anything, svc.ID).Return(svc, nil)
+		serviceRepo.EXPECT().LockForUpdate(mock.Anything, []properties.UUID{svc.ID}).Return(nil).Maybe()
+		return ms, serviceRepo, eventRepo
+	}
+
+	t.Run("sets and clears the maintenance with an event each", func(t *testing.T) {
+		svc := &Service{BaseEntity: BaseEntity{ID: uuid.New()}, Status: "Started"}
+		ms, serviceRepo, eventRepo := setup(t, svc)
+		serviceRepo.EXPECT().Save(mock.Anything, svc).Return(nil).Times(2)
+		eventRepo.EXPECT().Create(mock.Anything, mock.MatchedBy(func(e *Event) bool {
+			return e.Type == EventTypeServiceMaintenanceEnabled && e.InitiatorType == InitiatorTypeUser
+		})).Return(nil).Once()
+		eventRepo.EXPECT().Create(mock.Anything, mock.MatchedBy(func(e *Event) bool {
+			return e.Type == EventTypeServiceMaintenanceDisabled
+		})).Return(nil).Once()
+		cmd := NewServiceCommander(ms, nil, nil, 0, JobRetryPolicy{}, nil)
+
+		result, err := cmd.SetMaintenance(ctx, svc.ID, true)
+		require.NoError(t, err)
+		assert.True(t, result.Maintenance)
+
+		result, err = cmd.SetMaintenance(ctx, svc.ID, false)
+		require.NoError(t, err)
+		assert.False(t, result.Maintenance)
+	})
+
+	t.Run("unchanged maintenance is not saved", func(t *testing.T) {
+		svc := &Service{BaseEntity: BaseEntity{ID: uuid.New()}, Maintenance: true}
+		ms, _, _ := setup(t, svc)
+
+		result, err := NewServiceCommander(ms, nil, nil, 0, JobRetryPolicy{}, nil).SetMaintenance(ctx, svc.ID, true)
+		require.NoError(t, err)
+		assert.True(t, result.Maintenance)
+	})
+
+	t.Run("actions and updates are refused in maintenance", func(t *testing.T) {
+		svc := &Service{BaseEntity: BaseEntity{ID: uuid.New()}, Status: "Started", Maintenance: true}
+		ms, _, _ := setup(t, svc)
+		cmd := NewServiceCommander(ms, nil, nil, 0, JobRetryPolicy{}, nil)
+
+		_, err := cmd.DoAction(ctx, DoServiceActionParams{ID: svc.ID, Action: "stop"})
+		assert.ErrorAs(t, err, &MaintenanceError{})
+
+		name := "renamed"
+		_, err = cmd.Update(ctx, UpdateServiceParams{ID: svc.ID, Name: &name})
+		assert.ErrorAs(t, err, &MaintenanceError{})
+		assert.Equal(t, "", svc.Name)
+	})
+}
+
+func TestServiceCommander_Reconcile(t *testing.T) {
+	ctx := auth.WithIdentity(context.Background(), &auth.Identity{ID: uuid.New(), Role: auth.RoleAgent})
+	engine := NewServicePropertyEngine(nil)
+	serviceType := &ServiceType{
+		BaseEntity: BaseEntity{ID: uuid.New()},
+		PropertySchema: schema.Schema{
+			Properties: map[string]schema.PropertyDefinition{
+				"cpu": {Type: "integer"},
+				"ipAddress": {
+					Type: "string",
+					Authorizers: []schema.AuthorizerConfig{
+						{Type: "actor", Config: map[string]any{"actors": []any{"agent"}}},
+					},
+				},
+				"hostId": {
+					Type: "string",
+					Authorizers: []schema.AuthorizerConfig{
+						{Type: "actor", Config: map[string]any{"actors": []any{"system"}}},
+					},
+				},
+			},
+		},
+	}
+
+	setup := func(t *testing.T, svc *Service) (*MockStore, *MockServiceRepository, *MockEventRepository) {
+		ms := setupMockStore(t)
+		serviceRepo := NewMockServiceRepository(t)
+		serviceTypeRepo := NewMockServiceTypeRepository(t)
+		eventRepo := NewMockEventRepository(t)
+		ms.EXPECT().ServiceRepo().Return(serviceRepo).Maybe()
+		ms.EXPECT().ServiceTypeRepo().Return(serviceTypeRepo).Maybe()
+		ms.EXPECT().EventRepo().Return(eventRepo).Maybe()
+		serviceRepo.EXPECT().Get(mock.Anything, svc.ID).Return(svc, nil)
+		serviceTypeRepo.EXPECT().Get(mock.Anything, serviceType.ID).Return(serviceType, nil).Maybe()
+		return ms, serviceRepo, eventRepo
+	}
+	newService := func() *Service {
+		return &Service{
+			BaseEntity:    BaseEntity{ID: uuid.New()},
+			ServiceTypeID: serviceType.ID,
+			Status:        "Started",
+			Properties:    &properties.JSON{"cpu": float64(2), "ipAddress": "10.0.0.1"},
+		}
+	}
+
+	t.Run("updates the agent properties without a job", func(t *testing.T) {
+		svc := newService()
+		ms, serviceRepo, eventRepo := setup(t, svc)
+		serviceRepo.EXPECT().Save(mock.Anything, svc).Return(nil)
+		eventRepo.EXPECT().Create(mock.Anything, mock.MatchedBy(func(e *Event) bool {
+			payload, _ := json.Marshal(e.Payload)
+			return e.Type == EventTypeServiceReconciled && strings.Contains(string(payload), `"value":"10.0.0.9"`)
+		})).Return(nil)
+
+		result, err := NewServiceCommander(ms, engine, nil, 0, JobRetryPolicy{}, nil).Reconcile(ctx, svc.ID, properties.JSON{"ipAddress": "10.0.0.9"})
+		require.NoError(t, err)
+		assert.Equal(t, properties.JSON{"cpu": float64(2), "ipAddress": "10.0.0.9"}, *result.Properties)
+		assert.Equal(t, "Started", result.Status)
+	})
+
+	t.Run("unchanged properties are not saved", func(t *testing.T) {
+		svc := newService()
+		ms, _, _ := setup(t, svc)
+
+		_, err := NewServiceCommander(ms, engine, nil, 0, JobRetryPolicy{}, nil).Reconcile(ctx, svc.ID, properties.JSON{"ipAddress": "10.0.0.1"})
+		require.NoError(t, err)
+	})
+
+	t.Run("clears the orphaned flag with unchanged properties", func(t *testing.T) {
+		orphanedAt := time.Now()
+		svc := newService()
+		svc.Orphaned, svc.OrphanedAt = true, &orphanedAt
+		ms, serviceRepo, eventRepo := setup(t, svc)
+		serviceRepo.EXPECT().Save(mock.Anything, svc).Return(nil)
+		eventRepo.EXPECT().Create(mock.Anything, mock.MatchedBy(func(e *Event) bool {
+			return e.Type == EventTypeServiceReconciled
+		})).Return(nil)
+
+		result, err := NewServiceCommander(ms, engine, nil, 0, JobRetryPolicy{}, nil).Reconcile(ctx, svc.ID, properties.JSON{"ipAddress": "10.0.0.1"})
+		require.NoError(t, err)
+		assert.False(t, result.Orphaned)
+		assert.Nil(t, result.OrphanedAt)
+	})
+
+	t.Run("user and unknown properties are rejected", func(t *testing.T) {
+		svc := newService()
+		ms, _, _ := setup(t, svc)
+
+		_, err := NewServiceCommander(ms, engine, nil, 0, JobRetryPolicy{}, nil).Reconcile(ctx, svc.ID, properties.JSON{"cpu": 4, "disk": 10, "ipAddress": "10.0.0.9"})
+		var validationErr schema.ValidationError
+		require.ErrorAs(t, err, &validationErr)
+		assert.ErrorAs(t, err, &InvalidInputError{})
+		assert.Equal(t, []schema.ValidationErrorDetail{
+			{Path: "cpu", Message: "property is provided by users and cannot be reconciled"},
+			{Path: "disk", Message: "unknown property"},
+		}, validationErr.Errors)
+		assert.Equal(t, "10.0.0.1", (*svc.Properties)["ipAddress"])
+	})
+
+	t.Run("properties of other actors are rejected", func(t *testing.T) {
+		svc := newService()
+		ms, _, _ := setup(t, svc)
+
+		_, err := NewServiceCommander(ms, engine, nil, 0, JobRetryPolicy{}, nil).Reconcile(ctx, svc.ID, properties.JSON{"hostId": "h1"})
+		assert.ErrorAs(t, err, &InvalidInputError{})
+	})
+
+	t.Run("deleted service", func(t *testing.T) {
+		deletedAt := time.Now()
+		svc := newService()
+		svc.DeletedAt = &deletedAt
+		ms, _, _ := setup(t, svc)
+
+		_, err := NewServiceCommander(ms, engine, nil, 0, JobRetryPolicy{}, nil).Reconcile(ctx, svc.ID, properties.JSON{"ipAddress": "10.0.0.9"})
+		assert.ErrorAs(t, err, &InvalidInputError{})
+	})
+}
+
+func TestPatchServiceProperties(t *testing.T) {
+	current := &properties.JSON{"cpu": 2, "network": map[string]any{"zone": "eu"}, "tags": []any{"a"}}
+
+	t.Run("returns only changed top level properties", func(t *testing.T) {
+		changed, err := PatchServiceProperties(current, []properties.JSONPatchOperation{
+			{Op: properties.JSONPatchAdd, Path: "/tags/-", Value: "b"},
+			{Op: properties.JSONPatchAdd, Path: "/memory", Value: 512},
+			{Op: properties.JSONPatchTest, Path: "/cpu", Value: 2},
+		})
+		require.NoError(t, err)
+		require.NotNil(t, changed)
+		assert.Equal(t, properties.JSON{"tags": []any{"a", "b"}, "memory": float64(512)}, *changed)
+		assert.Equal(t, []any{"a"}, (*current)["tags"], "current properties must not change")
+	})
+
+	t.Run("returns nil when nothing changes", func(t *testing.T) {
+		changed, err := PatchServiceProperties(current, []properties.JSONPatchOperation{
+			{Op: properties.JSONPatchReplace, Path: "/cpu", Value: 2},
+		})
+		require.NoError(t, err)
+		assert.Nil(t, changed)
+	})
+
+	t.Run("rejects removing a top level property", func(t *testing.T) {
+		_, err := PatchServiceProperties(current, []properties.JSONPatchOperation{
+			{Op: properties.JSONPatchReplace, Path: "/cpu", Value: 4},
+			{Op: properties.JSONPatchRemove, Path: "/network"},
+		})
+		assert.ErrorAs(t, err, &InvalidInputError{})
+		assert.ErrorContains(t, err, "patch operation 1: removing property network is not supported")
+	})
+
+	t.Run("reports invalid operations as invalid input", func(t *testing.T) {
+		_, err := PatchServiceProperties(nil, []properties.JSONPatchOperation{
+			{Op: properties.JSONPatchReplace, Path: "/cpu", Value: 4},
+		})
+		assert.ErrorAs(t, err, &InvalidInputError{})
+		assert.ErrorContains(t, err, "patch operation 0")
+	})
+}
+
+func TestChangedServiceProperties(t *testing.T) {
+	current := &properties.JSON{"cpu": 2, "network": map[string]any{"zone": "eu"}}
+
+	t.Run("returns only the properties with a new value", func(t *testing.T) {
+		changed, err := ChangedServiceProperties(current, properties.JSON{"cpu": 2, "network": map[string]any{"zone": "us"}})
+		require.NoError(t, err)
+		require.NotNil(t, changed)
+		assert.Equal(t, properties.JSON{"network": map[string]any{"zone": "us"}}, *changed)
+	})
+
+	t.Run("returns nil when the update repeats the current values", func(t *testing.T) {
+		changed, err := ChangedServiceProperties(current, properties.JSON{"cpu": float64(2), "network": map[string]any{"zone": "eu"}})
+		require.NoError(t, err)
+		assert.Nil(t, changed)
+	})
+
+	t.Run("returns new properties of a service without properties", func(t *testing.T) {
+		changed, err := ChangedServiceProperties(nil, properties.JSON{"cpu": 2})
+		require.NoError(t, err)
+		require.NotNil(t, changed)
+		assert.Equal(t, properties.JSON{"cpu": float64(2)}, *changed)
+	})
+}
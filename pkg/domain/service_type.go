@@ -2,10 +2,16 @@ package domain
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"maps"
+	"slices"
+	"time"
 
 	"github.com/fulcrumproject/core/pkg/properties"
 	"github.com/fulcrumproject/core/pkg/schema"
+	"github.com/lib/pq"
 )
 
 const (
@@ -20,14 +26,39 @@ type ServiceType struct {
 	Name            string          `json:"name" gorm:"not null;unique"`
 	PropertySchema  schema.Schema   `json:"propertySchema" gorm:"type:jsonb;not null"`
 	LifecycleSchema LifecycleSchema `json:"lifecycleSchema" gorm:"type:jsonb;not null"`
+
+	// Version of the property schema, incremented each time the schema changes
+	SchemaVersion int `json:"schemaVersion" gorm:"not null;default:1"`
+
+	// Capabilities the agent of a service must advertise, "!" prefixed ones it must not
+	RequiredCapabilities pq.StringArray `json:"requiredCapabilities" gorm:"type:text[]"`
+
+	// Default operation timeout of the services of the type, nil to use the configured job timeouts
+	OperationTimeout *time.Duration `json:"operationTimeout,omitempty"`
+
+	// Properties merged under the ones given when a service of the type is created, the given ones win
+	// They are copied into each service, so changing them leaves the existing services untouched
+	DefaultProperties properties.JSON `json:"defaultProperties,omitempty" gorm:"type:jsonb"`
+
+	// Schema the labels of the services of the type must conform to, nil leaves the labels free-form
+	LabelSchema *schema.Schema `json:"labelSchema,omitempty" gorm:"type:jsonb"`
+
+	// Cost per unit of the numeric properties of the services of the type, see CatalogCostEstimator
+	PropertyCosts map[string]float64 `json:"propertyCosts,omitempty" gorm:"type:jsonb;serializer:json"`
 }
 
 // NewServiceType creates a new service type without validation
 func NewServiceType(params CreateServiceTypeParams) *ServiceType {
 	return &ServiceType{
-		Name:            params.Name,
-		PropertySchema:  params.PropertySchema,
-		LifecycleSchema: params.LifecycleSchema,
+		Name:                 params.Name,
+		PropertySchema:       params.PropertySchema,
+		LifecycleSchema:      params.LifecycleSchema,
+		SchemaVersion:        1,
+		RequiredCapabilities: pq.StringArray(params.RequiredCapabilities),
+		OperationTimeout:     params.OperationTimeout,
+		DefaultProperties:    params.DefaultProperties,
+		LabelSchema:          params.LabelSchema,
+		PropertyCosts:        params.PropertyCosts,
 	}
 }
 
@@ -47,6 +78,51 @@ func (st *ServiceType) Validate() error {
 		return fmt.Errorf("lifecycle schema validation failed: %w", err)
 	}
 
+	// Properties updated warm or cold need the matching lifecycle action
+	for _, mode := range st.PropertySchema.UpdateModes() {
+		action := UpdateActionForMode(mode)
+		if !st.LifecycleSchema.HasAction(action) {
+			return fmt.Errorf("lifecycle must define action %q for %s update properties", action, mode)
+		}
+	}
+
+	if err := ValidateRequiredCapabilities(st.RequiredCapabilities); err != nil {
+		return fmt.Errorf("required capabilities: %w", err)
+	}
+
+	for name := range st.DefaultProperties {
+		if _, ok := st.PropertySchema.Properties[name]; !ok {
+			return fmt.Errorf("default property %q is not defined in the property schema", name)
+		}
+	}
+
+	if st.LabelSchema != nil {
+		if err := ValidateLabelSchema(*st.LabelSchema); err != nil {
+			return fmt.Errorf("label schema: %w", err)
+		}
+	}
+
+	for name, cost := range st.PropertyCosts {
+		def, ok := st.PropertySchema.Properties[name]
+		if !ok {
+			return fmt.Errorf("property cost %q is not defined in the property schema", name)
+		}
+		if def.Type != "integer" && def.Type != "number" {
+			return fmt.Errorf("property cost %q must be set on an integer or number property, not %s", name, def.Type)
+		}
+		if cost < 0 {
+			return fmt.Errorf("property cost %q cannot be negative", name)
+		}
+	}
+
+	return ValidateOperationTimeout(st.OperationTimeout)
+}
+
+// ValidateOperationTimeout ensures an operation timeout, when set, is positive
+func ValidateOperationTimeout(timeout *time.Duration) error {
+	if timeout != nil && *timeout <= 0 {
+		return fmt.Errorf("operation timeout must be positive, got %s", *timeout)
+	}
 	return nil
 }
 
@@ -56,11 +132,97 @@ func (st *ServiceType) Update(params UpdateServiceTypeParams) {
 		st.Name = *params.Name
 	}
 	if params.PropertySchema != nil {
+		if !samePropertySchema(st.PropertySchema, *params.PropertySchema) {
+			st.SchemaVersion++
+		}
 		st.PropertySchema = *params.PropertySchema
 	}
 	if params.LifecycleSchema != nil {
 		st.LifecycleSchema = *params.LifecycleSchema
 	}
+	if params.RequiredCapabilities != nil {
+		st.RequiredCapabilities = pq.StringArray(*params.RequiredCapabilities)
+	}
+	if params.OperationTimeout != nil {
+		if *params.OperationTimeout == 0 {
+			st.OperationTimeout = nil
+		} else {
+			timeout := *params.OperationTimeout
+			st.OperationTimeout = &timeout
+		}
+	}
+	if params.DefaultProperties != nil {
+		if len(*params.DefaultProperties) == 0 {
+			st.DefaultProperties = nil
+		} else {
+			st.DefaultProperties = *params.DefaultProperties
+		}
+	}
+	if params.LabelSchema != nil {
+		if len(params.LabelSchema.Properties) == 0 {
+			st.LabelSchema = nil
+		} else {
+			labelSchema := *params.LabelSchema
+			st.LabelSchema = &labelSchema
+		}
+	}
+	if params.PropertyCosts != nil {
+		if len(*params.PropertyCosts) == 0 {
+			st.PropertyCosts = nil
+		} else {
+			st.PropertyCosts = *params.PropertyCosts
+		}
+	}
+}
+
+// ApplyDefaultProperties returns the given properties merged over a copy of the default properties
+// The merge is deep: nested objects present in both are merged key by key, any other given value wins
+func (st *ServiceType) ApplyDefaultProperties(props properties.JSON) properties.JSON {
+	if len(st.DefaultProperties) == 0 {
+		return props
+	}
+	return mergeProperties(st.DefaultProperties, props)
+}
+
+// mergeProperties deep merges overrides over a copy of base, neither of them is modified
+func mergeProperties(base, overrides map[string]any) map[string]any {
+	result := make(map[string]any, len(base)+len(overrides))
+	for key, value := range base {
+		result[key] = copyPropertyValue(value)
+	}
+	for key, value := range overrides {
+		baseObject, baseIsObject := result[key].(map[string]any)
+		object, isObject := value.(map[string]any)
+		if baseIsObject && isObject {
+			result[key] = mergeProperties(baseObject, object)
+		} else {
+			result[key] = value
+		}
+	}
+	return result
+}
+
+// copyPropertyValue deep copies the objects and arrays of a property value
+func copyPropertyValue(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		return mergeProperties(v, nil)
+	case []any:
+		copied := make([]any, len(v))
+		for i, item := range v {
+			copied[i] = copyPropertyValue(item)
+		}
+		return copied
+	default:
+		return v
+	}
+}
+
+// samePropertySchema compares two property schemas by their JSON encoding
+func samePropertySchema(a, b schema.Schema) bool {
+	aJSON, errA := json.Marshal(a)
+	bJSON, errB := json.Marshal(b)
+	return errA == nil && errB == nil && string(aJSON) == string(bJSON)
 }
 
 // ServiceTypeRepository defines the interface for the ServiceType repository
@@ -84,19 +246,62 @@ type ServiceTypeCommander interface {
 
 	// Delete removes a service type by ID after checking for dependencies
 	Delete(ctx context.Context, id properties.UUID) error
+
+	// MigrateServices re-validates the services of a type against its current property schema
+	// Properties are never changed: the services that conform are recorded at the current
+	// schema version unless dryRun is set, the ones that don't are reported
+	MigrateServices(ctx context.Context, serviceTypeID properties.UUID, dryRun bool) (*ServiceMigrationReport, error)
+
+	// CloneFrom creates a new service type named newName from a deep copy of the source type,
+	// the source type and its services are left untouched
+	CloneFrom(ctx context.Context, sourceID properties.UUID, newName string) (*ServiceType, error)
+}
+
+// ServiceMigrationReport is the outcome of the re-validation of the services of a type
+type ServiceMigrationReport struct {
+	ServiceTypeID properties.UUID
+	SchemaVersion int
+	DryRun        bool
+	// Checked is the number of services validated, services in a terminal state are skipped
+	Checked int
+	// Migrated is the number of conforming services recorded (or to record) at the schema version
+	Migrated int
+	Failures []ServiceMigrationFailure
+}
+
+// ServiceMigrationFailure reports a service whose properties do not conform to the schema
+type ServiceMigrationFailure struct {
+	ServiceID     properties.UUID
+	Name          string
+	SchemaVersion int
+	Errors        []schema.ValidationErrorDetail
 }
 
 type CreateServiceTypeParams struct {
-	Name            string          `json:"name"`
-	PropertySchema  schema.Schema   `json:"propertySchema"`
-	LifecycleSchema LifecycleSchema `json:"lifecycleSchema"`
+	Name                 string             `json:"name"`
+	PropertySchema       schema.Schema      `json:"propertySchema"`
+	LifecycleSchema      LifecycleSchema    `json:"lifecycleSchema"`
+	RequiredCapabilities []string           `json:"requiredCapabilities,omitempty"`
+	OperationTimeout     *time.Duration     `json:"operationTimeout,omitempty"`
+	DefaultProperties    properties.JSON    `json:"defaultProperties,omitempty"`
+	LabelSchema          *schema.Schema     `json:"labelSchema,omitempty"`
+	PropertyCosts        map[string]float64 `json:"propertyCosts,omitempty"`
 }
 
 type UpdateServiceTypeParams struct {
-	ID              properties.UUID  `json:"id"`
-	Name            *string          `json:"name"`
-	PropertySchema  *schema.Schema   `json:"propertySchema,omitempty"`
-	LifecycleSchema *LifecycleSchema `json:"lifecycleSchema,omitempty"`
+	ID                   properties.UUID  `json:"id"`
+	Name                 *string          `json:"name"`
+	PropertySchema       *schema.Schema   `json:"propertySchema,omitempty"`
+	LifecycleSchema      *LifecycleSchema `json:"lifecycleSchema,omitempty"`
+	RequiredCapabilities *[]string        `json:"requiredCapabilities,omitempty"`
+	// OperationTimeout replaces the default operation timeout, zero removes it
+	OperationTimeout *time.Duration `json:"operationTimeout,omitempty"`
+	// DefaultProperties replaces the default properties, an empty object removes them
+	DefaultProperties *properties.JSON `json:"defaultProperties,omitempty"`
+	// LabelSchema replaces the label schema, a schema without properties removes it
+	LabelSchema *schema.Schema `json:"labelSchema,omitempty"`
+	// PropertyCosts replaces the property costs, an empty object removes them
+	PropertyCosts *map[string]float64 `json:"propertyCosts,omitempty"`
 }
 
 // serviceTypeCommander is the concrete implementation of ServiceTypeCommander
@@ -126,6 +331,11 @@ func (c *serviceTypeCommander) Create(
 		if err := c.engine.ValidateSchema(serviceType.PropertySchema); err != nil {
 			return InvalidInputError{Err: fmt.Errorf("invalid property schema: %w", err)}
 		}
+		if serviceType.LabelSchema != nil {
+			if err := c.engine.ValidateSchema(*serviceType.LabelSchema); err != nil {
+				return InvalidInputError{Err: fmt.Errorf("invalid label schema: %w", err)}
+			}
+		}
 
 		// Validate service type (includes lifecycle validation)
 		if err := serviceType.Validate(); err != nil {
@@ -173,6 +383,11 @@ func (c *serviceTypeCommander) Update(
 	if err := c.engine.ValidateSchema(serviceType.PropertySchema); err != nil {
 		return nil, InvalidInputError{Err: fmt.Errorf("invalid property schema: %w", err)}
 	}
+	if serviceType.LabelSchema != nil {
+		if err := c.engine.ValidateSchema(*serviceType.LabelSchema); err != nil {
+			return nil, InvalidInputError{Err: fmt.Errorf("invalid label schema: %w", err)}
+		}
+	}
 
 	// Validate service type (includes lifecycle validation)
 	if err := serviceType.Validate(); err != nil {
@@ -233,3 +448,134 @@ func (c *serviceTypeCommander) Delete(ctx context.Context, id properties.UUID) e
 		return nil
 	})
 }
+
+// MigrateServices re-validates the services of a type against its current property schema
+func (c *serviceTypeCommander) MigrateServices(ctx context.Context, serviceTypeID properties.UUID, dryRun bool) (*ServiceMigrationReport, error) {
+	serviceType, err := c.store.ServiceTypeRepo().Get(ctx, serviceTypeID)
+	if err != nil {
+		return nil, err
+	}
+
+	services, err := c.store.ServiceRepo().FindByServiceType(ctx, serviceTypeID)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &ServiceMigrationReport{
+		ServiceTypeID: serviceTypeID,
+		SchemaVersion: serviceType.SchemaVersion,
+		DryRun:        dryRun,
+		Failures:      []ServiceMigrationFailure{},
+	}
+	var migrate []properties.UUID
+	for _, svc := range services {
+		if serviceType.LifecycleSchema.IsTerminalState(svc.Status) {
+			continue
+		}
+		report.Checked++
+
+		var props map[string]any
+		if svc.Properties != nil {
+			props = *svc.Properties
+		}
+		err := c.engine.ValidateProperties(ctx, newServiceMigrationSchemaContext(c.store, svc), serviceType.PropertySchema, props)
+		if err != nil {
+			var validationErr schema.ValidationError
+			if !errors.As(err, &validationErr) {
+				return nil, fmt.Errorf("failed to validate service %s: %w", svc.ID, err)
+			}
+			report.Failures = append(report.Failures, ServiceMigrationFailure{
+				ServiceID:     svc.ID,
+				Name:          svc.Name,
+				SchemaVersion: svc.SchemaVersion,
+				Errors:        validationErr.Errors,
+			})
+			continue
+		}
+		if svc.SchemaVersion != serviceType.SchemaVersion {
+			migrate = append(migrate, svc.ID)
+		}
+	}
+	report.Migrated = len(migrate)
+
+	if dryRun || len(migrate) == 0 {
+		return report, nil
+	}
+	if err := c.store.ServiceRepo().UpdateSchemaVersion(ctx, migrate, serviceType.SchemaVersion); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// CloneFrom creates a new service type named newName from a deep copy of the source type
+func (c *serviceTypeCommander) CloneFrom(ctx context.Context, sourceID properties.UUID, newName string) (*ServiceType, error) {
+	source, err := c.store.ServiceTypeRepo().Get(ctx, sourceID)
+	if err != nil {
+		return nil, err
+	}
+
+	params, err := cloneServiceTypeParams(source, newName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to copy service type %s: %w", sourceID, err)
+	}
+	return c.Create(ctx, params)
+}
+
+// cloneServiceTypeParams returns the creation parameters of a deep copy of a service type
+// The schemas are copied through their JSON encoding so the validator configs, and with them
+// the references to the service option types, are recreated instead of shared with the source
+func cloneServiceTypeParams(source *ServiceType, name string) (CreateServiceTypeParams, error) {
+	var params CreateServiceTypeParams
+	if err := deepCopyJSON(source.PropertySchema, &params.PropertySchema); err != nil {
+		return params, fmt.Errorf("property schema: %w", err)
+	}
+	if err := deepCopyJSON(source.LifecycleSchema, &params.LifecycleSchema); err != nil {
+		return params, fmt.Errorf("lifecycle schema: %w", err)
+	}
+	params.Name = name
+	if source.RequiredCapabilities != nil {
+		params.RequiredCapabilities = slices.Clone([]string(source.RequiredCapabilities))
+	}
+	if source.OperationTimeout != nil {
+		timeout := *source.OperationTimeout
+		params.OperationTimeout = &timeout
+	}
+	if len(source.DefaultProperties) > 0 {
+		params.DefaultProperties = mergeProperties(source.DefaultProperties, nil)
+	}
+	if source.LabelSchema != nil {
+		if err := deepCopyJSON(source.LabelSchema, &params.LabelSchema); err != nil {
+			return params, fmt.Errorf("label schema: %w", err)
+		}
+	}
+	if len(source.PropertyCosts) > 0 {
+		params.PropertyCosts = maps.Clone(source.PropertyCosts)
+	}
+	return params, nil
+}
+
+// deepCopyJSON copies src into dst through their JSON encoding
+func deepCopyJSON(src, dst any) error {
+	data, err := json.Marshal(src)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, dst)
+}
+
+// newServiceMigrationSchemaContext builds the schema context used to re-validate the properties of an existing service
+func newServiceMigrationSchemaContext(store Store, svc *Service) ServicePropertyContext {
+	schemaCtx := ServicePropertyContext{
+		Actor:         ActorSystem,
+		Store:         store,
+		ProviderID:    svc.ProviderID,
+		ConsumerID:    svc.ConsumerID,
+		GroupID:       svc.GroupID,
+		ServiceID:     &svc.ID,
+		ServiceStatus: svc.Status,
+	}
+	if svc.Agent != nil {
+		schemaCtx.ServicePoolSetID = svc.Agent.ServicePoolSetID
+	}
+	return schemaCtx
+}
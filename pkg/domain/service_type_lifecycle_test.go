@@ -535,3 +535,37 @@ func TestServiceType_Validate_WithInvalidLifecycle(t *testing.T) {
 		t.Error("Validate() should fail for service type with invalid lifecycle")
 	}
 }
+
+func TestServiceType_Validate_UpdateModeActions(t *testing.T) {
+	lifecycle := func(actions ...string) LifecycleSchema {
+		ls := LifecycleSchema{
+			States:       []LifecycleState{{Name: "New"}, {Name: "Running"}},
+			InitialState: "New",
+		}
+		for _, action := range actions {
+			ls.Actions = append(ls.Actions, LifecycleAction{
+				Name:        action,
+				Transitions: []LifecycleTransition{{From: "New", To: "Running"}},
+			})
+		}
+		return ls
+	}
+	propertySchema := schema.Schema{Properties: map[string]schema.PropertyDefinition{
+		"name": {Type: "string"},
+		"cpu":  {Type: "integer", UpdateMode: schema.UpdateModeWarm},
+	}}
+
+	st := &ServiceType{
+		Name:            "TestService",
+		PropertySchema:  propertySchema,
+		LifecycleSchema: lifecycle("create", "update"),
+	}
+	if err := st.Validate(); err == nil {
+		t.Error("Validate() should fail when the warm update action is missing")
+	}
+
+	st.LifecycleSchema = lifecycle("create", "update", ServiceActionWarmUpdate)
+	if err := st.Validate(); err != nil {
+		t.Errorf("Validate() should not fail when the warm update action is defined: %v", err)
+	}
+}
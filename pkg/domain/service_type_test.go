@@ -1,10 +1,18 @@
 package domain
 
 import (
+	"context"
 	"testing"
+	"time"
 
+	"github.com/fulcrumproject/core/pkg/auth"
+	"github.com/fulcrumproject/core/pkg/helpers"
+	"github.com/fulcrumproject/core/pkg/properties"
+	"github.com/fulcrumproject/core/pkg/schema"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 func TestServiceType_TableName(t *testing.T) {
@@ -54,3 +62,364 @@ func TestServiceTypeBasics(t *testing.T) {
 
 // Note: Schema validation tests have been moved to pkg/schema package tests
 // Domain-specific validators (source, mutable) are tested in service_property_schema_validators_test.go
+
+func TestServiceType_ValidateRequiredCapabilities(t *testing.T) {
+	newServiceType := func(required []string) *ServiceType {
+		return &ServiceType{
+			Name: "Web Server",
+			LifecycleSchema: LifecycleSchema{
+				States: []LifecycleState{{Name: "New"}, {Name: "Started"}},
+				Actions: []LifecycleAction{
+					{Name: "create", Transitions: []LifecycleTransition{{From: "New", To: "Started"}}},
+				},
+				InitialState: "New",
+			},
+			RequiredCapabilities: required,
+		}
+	}
+
+	assert.NoError(t, newServiceType([]string{"gpu", "!shared"}).Validate())
+	assert.ErrorContains(t, newServiceType([]string{"gpu", "!gpu"}).Validate(), "required capabilities")
+}
+
+func TestServiceType_UpdateSchemaVersion(t *testing.T) {
+	propertySchema := schema.Schema{Properties: map[string]schema.PropertyDefinition{
+		"cpu": {Type: "integer"},
+	}}
+	st := NewServiceType(CreateServiceTypeParams{Name: "VM", PropertySchema: propertySchema})
+	assert.Equal(t, 1, st.SchemaVersion)
+
+	// Same schema and other fields keep the version
+	name := "Virtual Machine"
+	same := schema.Schema{Properties: map[string]schema.PropertyDefinition{
+		"cpu": {Type: "integer"},
+	}}
+	st.Update(UpdateServiceTypeParams{Name: &name, PropertySchema: &same})
+	assert.Equal(t, 1, st.SchemaVersion)
+
+	// A changed schema increments it
+	tightened := schema.Schema{Properties: map[string]schema.PropertyDefinition{
+		"cpu": {Type: "integer", Required: true},
+	}}
+	st.Update(UpdateServiceTypeParams{PropertySchema: &tightened})
+	assert.Equal(t, 2, st.SchemaVersion)
+	assert.Equal(t, tightened, st.PropertySchema)
+}
+
+func TestServiceType_OperationTimeout(t *testing.T) {
+	lifecycle := LifecycleSchema{
+		States:       []LifecycleState{{Name: "New"}},
+		Actions:      []LifecycleAction{{Name: "create", Transitions: []LifecycleTransition{{From: "New", To: "New"}}}},
+		InitialState: "New",
+	}
+	st := NewServiceType(CreateServiceTypeParams{Name: "VM", LifecycleSchema: lifecycle, OperationTimeout: helpers.DurationPtr(time.Hour)})
+	require.NoError(t, st.Validate())
+	assert.Equal(t, time.Hour, *st.OperationTimeout)
+
+	// Omitted keeps the timeout
+	st.Update(UpdateServiceTypeParams{})
+	assert.Equal(t, time.Hour, *st.OperationTimeout)
+
+	st.Update(UpdateServiceTypeParams{OperationTimeout: helpers.DurationPtr(30 * time.Minute)})
+	assert.Equal(t, 30*time.Minute, *st.OperationTimeout)
+
+	// Zero removes it
+	st.Update(UpdateServiceTypeParams{OperationTimeout: helpers.DurationPtr(0)})
+	assert.Nil(t, st.OperationTimeout)
+
+	st.OperationTimeout = helpers.DurationPtr(-time.Second)
+	assert.ErrorContains(t, st.Validate(), "operation timeout must be positive")
+}
+
+func TestServiceType_DefaultProperties(t *testing.T) {
+	lifecycle := LifecycleSchema{
+		States:       []LifecycleState{{Name: "New"}},
+		Actions:      []LifecycleAction{{Name: "create", Transitions: []LifecycleTransition{{From: "New", To: "New"}}}},
+		InitialState: "New",
+	}
+	propertySchema := schema.Schema{Properties: map[string]schema.PropertyDefinition{
+		"tier": {Type: "string"},
+		"disk": {Type: "object"},
+	}}
+	newDefaults := func() properties.JSON {
+		return properties.JSON{"tier": "standard", "disk": map[string]any{"size": float64(10), "type": "ssd"}}
+	}
+	st := NewServiceType(CreateServiceTypeParams{Name: "VM", LifecycleSchema: lifecycle, PropertySchema: propertySchema, DefaultProperties: newDefaults()})
+	require.NoError(t, st.Validate())
+
+	t.Run("deep merges the given properties over the defaults", func(t *testing.T) {
+		props := st.ApplyDefaultProperties(properties.JSON{"disk": map[string]any{"size": float64(20)}, "name": "vm-1"})
+		assert.Equal(t, properties.JSON{
+			"tier": "standard",
+			"disk": map[string]any{"size": float64(20), "type": "ssd"},
+			"name": "vm-1",
+		}, props)
+
+		// The merged properties do not share the defaults
+		props["disk"].(map[string]any)["type"] = "hdd"
+		assert.Equal(t, newDefaults(), st.DefaultProperties)
+	})
+
+	t.Run("given value replaces a default of another type", func(t *testing.T) {
+		props := st.ApplyDefaultProperties(properties.JSON{"disk": nil})
+		assert.Equal(t, properties.JSON{"tier": "standard", "disk": nil}, props)
+	})
+
+	t.Run("update replaces and removes the defaults", func(t *testing.T) {
+		st := NewServiceType(CreateServiceTypeParams{Name: "VM", LifecycleSchema: lifecycle, PropertySchema: propertySchema, DefaultProperties: newDefaults()})
+
+		// Omitted keeps the defaults
+		st.Update(UpdateServiceTypeParams{})
+		assert.Equal(t, newDefaults(), st.DefaultProperties)
+
+		st.Update(UpdateServiceTypeParams{DefaultProperties: &properties.JSON{"tier": "premium"}})
+		assert.Equal(t, properties.JSON{"tier": "premium"}, st.DefaultProperties)
+
+		// Empty removes them
+		st.Update(UpdateServiceTypeParams{DefaultProperties: &properties.JSON{}})
+		assert.Nil(t, st.DefaultProperties)
+		assert.Equal(t, properties.JSON{"name": "vm-1"}, st.ApplyDefaultProperties(properties.JSON{"name": "vm-1"}))
+	})
+
+	t.Run("defaults must be defined in the property schema", func(t *testing.T) {
+		st := NewServiceType(CreateServiceTypeParams{Name: "VM", LifecycleSchema: lifecycle, PropertySchema: propertySchema, DefaultProperties: properties.JSON{"cpu": 2}})
+		assert.ErrorContains(t, st.Validate(), `default property "cpu" is not defined`)
+	})
+}
+
+func TestServiceType_PropertyCosts(t *testing.T) {
+	newServiceType := func(costs map[string]float64) *ServiceType {
+		return NewServiceType(CreateServiceTypeParams{
+			Name: "VM",
+			PropertySchema: schema.Schema{Properties: map[string]schema.PropertyDefinition{
+				"cpu":  {Type: "integer"},
+				"name": {Type: "string"},
+			}},
+			LifecycleSchema: LifecycleSchema{
+				States:       []LifecycleState{{Name: "New"}},
+				Actions:      []LifecycleAction{{Name: "create", Transitions: []LifecycleTransition{{From: "New", To: "New"}}}},
+				InitialState: "New",
+			},
+			PropertyCosts: costs,
+		})
+	}
+
+	assert.NoError(t, newServiceType(map[string]float64{"cpu": 10}).Validate())
+	assert.ErrorContains(t, newServiceType(map[string]float64{"memory": 1}).Validate(), "not defined")
+	assert.ErrorContains(t, newServiceType(map[string]float64{"name": 1}).Validate(), "integer or number")
+	assert.ErrorContains(t, newServiceType(map[string]float64{"cpu": -1}).Validate(), "negative")
+
+	// Empty removes them
+	st := newServiceType(map[string]float64{"cpu": 10})
+	st.Update(UpdateServiceTypeParams{PropertyCosts: &map[string]float64{}})
+	assert.Nil(t, st.PropertyCosts)
+}
+
+func TestServiceType_LabelSchema(t *testing.T) {
+	lifecycle := LifecycleSchema{
+		States:       []LifecycleState{{Name: "New"}},
+		Actions:      []LifecycleAction{{Name: "create", Transitions: []LifecycleTransition{{From: "New", To: "New"}}}},
+		InitialState: "New",
+	}
+	propertySchema := schema.Schema{Properties: map[string]schema.PropertyDefinition{"tier": {Type: "string"}}}
+	labelSchema := schema.Schema{Properties: map[string]schema.PropertyDefinition{"env": {Type: "string"}}}
+
+	t.Run("update replaces and removes the label schema", func(t *testing.T) {
+		st := NewServiceType(CreateServiceTypeParams{Name: "VM", LifecycleSchema: lifecycle, PropertySchema: propertySchema, LabelSchema: &labelSchema})
+		require.NoError(t, st.Validate())
+
+		// Omitted keeps the label schema
+		st.Update(UpdateServiceTypeParams{})
+		assert.Equal(t, &labelSchema, st.LabelSchema)
+
+		team := schema.Schema{Properties: map[string]schema.PropertyDefinition{"team": {Type: "string"}}}
+		st.Update(UpdateServiceTypeParams{LabelSchema: &team})
+		assert.Equal(t, &team, st.LabelSchema)
+		assert.Equal(t, 1, st.SchemaVersion)
+
+		// Empty removes it
+		st.Update(UpdateServiceTypeParams{LabelSchema: &schema.Schema{}})
+		assert.Nil(t, st.LabelSchema)
+	})
+
+	t.Run("invalid label schema", func(t *testing.T) {
+		tests := []struct {
+			name    string
+			propDef schema.PropertyDefinition
+			key     string
+			wantErr string
+		}{
+			{name: "Invalid key", key: "my env", propDef: schema.PropertyDefinition{Type: "string"}, wantErr: `invalid label key "my env"`},
+			{name: "Not a string", key: "replicas", propDef: schema.PropertyDefinition{Type: "integer"}, wantErr: `label "replicas" must be of type string`},
+			{name: "Required", key: "env", propDef: schema.PropertyDefinition{Type: "string", Required: true}, wantErr: `label "env" cannot be required`},
+		}
+		for _, tc := range tests {
+			t.Run(tc.name, func(t *testing.T) {
+				invalid := schema.Schema{Properties: map[string]schema.PropertyDefinition{tc.key: tc.propDef}}
+				st := NewServiceType(CreateServiceTypeParams{Name: "VM", LifecycleSchema: lifecycle, PropertySchema: propertySchema, LabelSchema: &invalid})
+				assert.ErrorContains(t, st.Validate(), tc.wantErr)
+			})
+		}
+	})
+}
+
+func TestServiceTypeCommander_MigrateServices(t *testing.T) {
+	ctx := context.Background()
+	serviceTypeID := properties.NewUUID()
+	serviceType := &ServiceType{
+		BaseEntity: BaseEntity{ID: serviceTypeID},
+		Name:       "VM",
+		PropertySchema: schema.Schema{Properties: map[string]schema.PropertyDefinition{
+			"cpu": {Type: "integer", Required: true},
+		}},
+		LifecycleSchema: LifecycleSchema{
+			States:         []LifecycleState{{Name: "New"}, {Name: "Started"}, {Name: "Deleted"}},
+			InitialState:   "New",
+			TerminalStates: []string{"Deleted"},
+		},
+		SchemaVersion: 2,
+	}
+	newService := func(name, status string, version int, props properties.JSON) *Service {
+		return &Service{
+			BaseEntity:    BaseEntity{ID: properties.NewUUID()},
+			Name:          name,
+			Status:        status,
+			SchemaVersion: version,
+			Properties:    &props,
+			ServiceTypeID: serviceTypeID,
+		}
+	}
+	outdated := newService("outdated", "Started", 1, properties.JSON{"cpu": 2})
+	current := newService("current", "Started", 2, properties.JSON{"cpu": 4})
+	invalid := newService("invalid", "Started", 1, properties.JSON{})
+	deleted := newService("deleted", "Deleted", 1, properties.JSON{})
+	services := []*Service{outdated, current, invalid, deleted}
+
+	setup := func(t *testing.T) (*MockStore, *MockServiceRepository) {
+		store := NewMockStore(t)
+		serviceTypeRepo := NewMockServiceTypeRepository(t)
+		serviceRepo := NewMockServiceRepository(t)
+		store.EXPECT().ServiceTypeRepo().Return(serviceTypeRepo)
+		store.EXPECT().ServiceRepo().Return(serviceRepo)
+		serviceTypeRepo.EXPECT().Get(ctx, serviceTypeID).Return(serviceType, nil)
+		serviceRepo.EXPECT().FindByServiceType(ctx, serviceTypeID).Return(services, nil)
+		return store, serviceRepo
+	}
+
+	assertReport := func(t *testing.T, report *ServiceMigrationReport, dryRun bool) {
+		assert.Equal(t, serviceTypeID, report.ServiceTypeID)
+		assert.Equal(t, 2, report.SchemaVersion)
+		assert.Equal(t, dryRun, report.DryRun)
+		assert.Equal(t, 3, report.Checked)
+		assert.Equal(t, 1, report.Migrated)
+		require.Len(t, report.Failures, 1)
+		assert.Equal(t, invalid.ID, report.Failures[0].ServiceID)
+		assert.Equal(t, 1, report.Failures[0].SchemaVersion)
+		assert.Equal(t, []schema.ValidationErrorDetail{{Path: "cpu", Message: "required property is missing"}}, report.Failures[0].Errors)
+	}
+
+	t.Run("Dry run does not record versions", func(t *testing.T) {
+		store, _ := setup(t)
+
+		report, err := NewServiceTypeCommander(store, NewServicePropertyEngine(nil)).MigrateServices(ctx, serviceTypeID, true)
+		require.NoError(t, err)
+		assertReport(t, report, true)
+	})
+
+	t.Run("Records the version of the conforming services", func(t *testing.T) {
+		store, serviceRepo := setup(t)
+		serviceRepo.EXPECT().UpdateSchemaVersion(ctx, []properties.UUID{outdated.ID}, 2).Return(nil)
+
+		report, err := NewServiceTypeCommander(store, NewServicePropertyEngine(nil)).MigrateServices(ctx, serviceTypeID, false)
+		require.NoError(t, err)
+		assertReport(t, report, false)
+		assert.Equal(t, "outdated", outdated.Name, "properties are left untouched")
+		assert.Equal(t, properties.JSON{}, *invalid.Properties)
+	})
+
+	t.Run("Unknown service type", func(t *testing.T) {
+		store := NewMockStore(t)
+		serviceTypeRepo := NewMockServiceTypeRepository(t)
+		store.EXPECT().ServiceTypeRepo().Return(serviceTypeRepo)
+		serviceTypeRepo.EXPECT().Get(ctx, serviceTypeID).Return(nil, NewNotFoundErrorf("service type"))
+
+		_, err := NewServiceTypeCommander(store, NewServicePropertyEngine(nil)).MigrateServices(ctx, serviceTypeID, true)
+		assert.ErrorAs(t, err, &NotFoundError{})
+	})
+}
+
+func TestServiceTypeCommander_CloneFrom(t *testing.T) {
+	ctx := auth.WithIdentity(context.Background(), &auth.Identity{Role: auth.RoleAdmin, ID: properties.NewUUID()})
+	sourceID := properties.NewUUID()
+	newSource := func() *ServiceType {
+		return &ServiceType{
+			BaseEntity: BaseEntity{ID: sourceID},
+			Name:       "VM",
+			PropertySchema: schema.Schema{Properties: map[string]schema.PropertyDefinition{
+				"os": {Type: "string", Validators: []schema.ValidatorConfig{
+					{Type: "serviceOption", Config: map[string]any{"value": "os"}},
+				}},
+			}},
+			LifecycleSchema: LifecycleSchema{
+				States:       []LifecycleState{{Name: "New"}},
+				Actions:      []LifecycleAction{{Name: "create", Transitions: []LifecycleTransition{{From: "New", To: "New"}}}},
+				InitialState: "New",
+			},
+			SchemaVersion:        3,
+			RequiredCapabilities: []string{"gpu"},
+			OperationTimeout:     helpers.DurationPtr(time.Hour),
+			DefaultProperties:    properties.JSON{"os": map[string]any{"name": "linux"}},
+		}
+	}
+
+	t.Run("Creates a deep copy", func(t *testing.T) {
+		source := newSource()
+		store := setupMockStore(t)
+		serviceTypeRepo := NewMockServiceTypeRepository(t)
+		eventRepo := NewMockEventRepository(t)
+		store.EXPECT().ServiceTypeRepo().Return(serviceTypeRepo)
+		store.EXPECT().EventRepo().Return(eventRepo)
+		serviceTypeRepo.EXPECT().Get(ctx, sourceID).Return(source, nil)
+		serviceTypeRepo.EXPECT().Create(ctx, mock.Anything).Return(nil)
+		eventRepo.EXPECT().Create(ctx, mock.Anything).Return(nil)
+
+		clone, err := NewServiceTypeCommander(store, NewServicePropertyEngine(nil)).CloneFrom(ctx, sourceID, "VM v2")
+		require.NoError(t, err)
+		assert.Equal(t, "VM v2", clone.Name)
+		assert.Equal(t, 1, clone.SchemaVersion)
+		assert.Equal(t, source.PropertySchema, clone.PropertySchema)
+		assert.Equal(t, source.LifecycleSchema, clone.LifecycleSchema)
+		assert.Equal(t, source.RequiredCapabilities, clone.RequiredCapabilities)
+		assert.Equal(t, time.Hour, *clone.OperationTimeout)
+		assert.Equal(t, source.DefaultProperties, clone.DefaultProperties)
+
+		// Mutating the clone leaves the source untouched
+		clone.PropertySchema.Properties["os"].Validators[0].Config["value"] = "image"
+		clone.PropertySchema.Properties["cpu"] = schema.PropertyDefinition{Type: "integer"}
+		clone.LifecycleSchema.States[0].Name = "Pending"
+		clone.RequiredCapabilities[0] = "tpu"
+		*clone.OperationTimeout = time.Minute
+		clone.DefaultProperties["os"].(map[string]any)["name"] = "windows"
+		assert.Equal(t, newSource(), source)
+	})
+
+	t.Run("Invalid name", func(t *testing.T) {
+		store := setupMockStore(t)
+		serviceTypeRepo := NewMockServiceTypeRepository(t)
+		store.EXPECT().ServiceTypeRepo().Return(serviceTypeRepo)
+		serviceTypeRepo.EXPECT().Get(ctx, sourceID).Return(newSource(), nil)
+
+		_, err := NewServiceTypeCommander(store, NewServicePropertyEngine(nil)).CloneFrom(ctx, sourceID, "")
+		assert.ErrorAs(t, err, &InvalidInputError{})
+	})
+
+	t.Run("Unknown source", func(t *testing.T) {
+		store := NewMockStore(t)
+		serviceTypeRepo := NewMockServiceTypeRepository(t)
+		store.EXPECT().ServiceTypeRepo().Return(serviceTypeRepo)
+		serviceTypeRepo.EXPECT().Get(ctx, sourceID).Return(nil, NewNotFoundErrorf("service type"))
+
+		_, err := NewServiceTypeCommander(store, NewServicePropertyEngine(nil)).CloneFrom(ctx, sourceID, "VM v2")
+		assert.ErrorAs(t, err, &NotFoundError{})
+	})
+}
@@ -0,0 +1,71 @@
+package domain
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/fulcrumproject/core/pkg/properties"
+	"github.com/fulcrumproject/core/pkg/schema"
+	"github.com/wI2L/jsondiff"
+)
+
+// ServiceUpdatePreview describes the outcome of a service update without applying it
+type ServiceUpdatePreview struct {
+	// Valid is false when the update would be rejected, Errors then lists the violations
+	Valid  bool
+	Errors []schema.ValidationErrorDetail
+	// Name of the service once updated
+	Name string
+	// ChangedProperties lists the sorted top level properties whose value changes
+	ChangedProperties []string
+	// Diff is the JSON Patch from the current to the updated properties, with the sensitive values redacted
+	Diff jsondiff.Patch
+	// UpdateMode is the most disruptive update mode of the changed properties, empty when none changes
+	UpdateMode string
+	// Action is the lifecycle action of the job applying the update, empty when no job is needed
+	Action string
+	// NextStatus is the status of the service once the job applying the update succeeds
+	NextStatus string
+}
+
+// PreviewUpdate computes the changes of a service update and the action applying them without persisting anything
+// The update goes through the same code as Update in a rolled back transaction so both cannot disagree,
+// the property violations and the lifecycle refusals are reported in the preview rather than as errors
+func (s *serviceCommander) PreviewUpdate(ctx context.Context, id properties.UUID, name *string, props *properties.JSON) (*ServiceUpdatePreview, error) {
+	_, preview, err := updateService(ctx, s.store, s.engine, UpdateServiceParams{ID: id, Name: name, Properties: props}, s.retry, true)
+
+	var validationErr schema.ValidationError
+	var invalidInputErr InvalidInputError
+	switch {
+	case err == nil:
+		preview.Valid = true
+	case errors.As(err, &validationErr):
+		preview.Errors = validationErr.Errors
+	case errors.As(err, &invalidInputErr) && preview != nil:
+		preview.Errors = []schema.ValidationErrorDetail{{Message: invalidInputErr.Error()}}
+	default:
+		return nil, err
+	}
+	return preview, nil
+}
+
+// servicePropertiesDiff returns the JSON Patch from the old to the new properties, redacting the sensitive values
+func servicePropertiesDiff(propertySchema schema.Schema, oldProperties, newProperties map[string]any) (jsondiff.Patch, error) {
+	before, err := json.Marshal(oldProperties)
+	if err != nil {
+		return nil, err
+	}
+	after, err := json.Marshal(newProperties)
+	if err != nil {
+		return nil, err
+	}
+	patch, err := jsondiff.CompareJSON(before, after, jsondiff.Invertible())
+	if err != nil {
+		return nil, err
+	}
+	if err := redactPatch(patch, propertySchema.SensitivePaths()); err != nil {
+		return nil, err
+	}
+	return patch, nil
+}
@@ -0,0 +1,152 @@
+package domain
+
+import (
+	"context"
+	"testing"
+
+	"github.com/fulcrumproject/core/pkg/auth"
+	"github.com/fulcrumproject/core/pkg/properties"
+	"github.com/fulcrumproject/core/pkg/schema"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServiceCommander_PreviewUpdate(t *testing.T) {
+	ctx := auth.WithIdentity(context.Background(), &auth.Identity{ID: uuid.New(), Role: auth.RoleAdmin})
+	engine := NewServicePropertyEngine(nil)
+	serviceType := &ServiceType{
+		BaseEntity: BaseEntity{ID: uuid.New()},
+		PropertySchema: schema.Schema{Properties: map[string]schema.PropertyDefinition{
+			"cpu":      {Type: "integer", UpdateMode: schema.UpdateModeCold},
+			"replicas": {Type: "integer"},
+			"region":   {Type: "string", Immutable: true},
+		}},
+		LifecycleSchema: LifecycleSchema{
+			States:       []LifecycleState{{Name: "Started"}, {Name: "Restarting"}, {Name: "Stopped"}},
+			InitialState: "Stopped",
+			Actions: []LifecycleAction{
+				{Name: ServiceActionUpdate, Transitions: []LifecycleTransition{{From: "Started", To: "Started"}}},
+				{Name: ServiceActionColdUpdate, Transitions: []LifecycleTransition{{From: "Started", To: "Restarting"}}},
+			},
+		},
+	}
+	agent := &Agent{BaseEntity: BaseEntity{ID: uuid.New()}, ProviderID: uuid.New()}
+	newService := func(status string) *Service {
+		return &Service{
+			BaseEntity:    BaseEntity{ID: uuid.New()},
+			Name:          "web",
+			Status:        status,
+			GroupID:       uuid.New(),
+			AgentID:       agent.ID,
+			ServiceTypeID: serviceType.ID,
+			Properties:    &properties.JSON{"cpu": float64(2), "replicas": float64(1), "region": "eu"},
+		}
+	}
+
+	setup := func(t *testing.T, svc *Service) (*MockStore, *MockJobRepository) {
+		ms := setupMockStore(t)
+		serviceRepo := NewMockServiceRepository(t)
+		serviceTypeRepo := NewMockServiceTypeRepository(t)
+		agentRepo := NewMockAgentRepository(t)
+		jobRepo := NewMockJobRepository(t)
+		ms.EXPECT().ServiceRepo().Return(serviceRepo)
+		ms.EXPECT().ServiceTypeRepo().Return(serviceTypeRepo)
+		ms.EXPECT().AgentRepo().Return(agentRepo)
+		ms.EXPECT().JobRepo().Return(jobRepo).Maybe()
+		serviceRepo.EXPECT().Get(mock.Anything, svc.ID).Return(svc, nil)
+		serviceTypeRepo.EXPECT().Get(mock.Anything, serviceType.ID).Return(serviceType, nil)
+		agentRepo.EXPECT().Get(mock.Anything, agent.ID).Return(agent, nil)
+		jobRepo.EXPECT().GetLastJobForService(mock.Anything, svc.ID).Return(nil, nil).Maybe()
+		jobRepo.EXPECT().GetScheduledJobsForService(mock.Anything, svc.ID).Return(nil, nil).Maybe()
+		return ms, jobRepo
+	}
+
+	t.Run("cold update", func(t *testing.T) {
+		svc := newService("Started")
+		ms, jobRepo := setup(t, svc)
+		jobRepo.EXPECT().Create(mock.Anything, mock.Anything).Return(nil)
+
+		preview, err := NewServiceCommander(ms, engine, nil, 0, JobRetryPolicy{}, nil).PreviewUpdate(ctx, svc.ID, nil, &properties.JSON{"cpu": 4, "replicas": 1})
+		require.NoError(t, err)
+		assert.True(t, preview.Valid)
+		assert.Empty(t, preview.Errors)
+		assert.Equal(t, "web", preview.Name)
+		assert.Equal(t, []string{"cpu"}, preview.ChangedProperties)
+		assert.Equal(t, schema.UpdateModeCold, preview.UpdateMode)
+		assert.Equal(t, ServiceActionColdUpdate, preview.Action)
+		assert.Equal(t, "Restarting", preview.NextStatus)
+		require.Len(t, preview.Diff, 2)
+		assert.Equal(t, "/cpu", preview.Diff[1].Path)
+		assert.EqualValues(t, 4, preview.Diff[1].Value)
+		assert.Equal(t, "Started", svc.Status)
+	})
+
+	t.Run("hot update", func(t *testing.T) {
+		svc := newService("Started")
+		ms, jobRepo := setup(t, svc)
+		jobRepo.EXPECT().Create(mock.Anything, mock.Anything).Return(nil)
+
+		preview, err := NewServiceCommander(ms, engine, nil, 0, JobRetryPolicy{}, nil).PreviewUpdate(ctx, svc.ID, nil, &properties.JSON{"replicas": 3})
+		require.NoError(t, err)
+		assert.True(t, preview.Valid)
+		assert.Equal(t, schema.UpdateModeHot, preview.UpdateMode)
+		assert.Equal(t, ServiceActionUpdate, preview.Action)
+		assert.Equal(t, "Started", preview.NextStatus)
+	})
+
+	t.Run("same action as the update", func(t *testing.T) {
+		svc := newService("Started")
+		ms, jobRepo := setup(t, svc)
+		var applied *Job
+		jobRepo.EXPECT().Create(mock.Anything, mock.Anything).RunAndReturn(func(_ context.Context, job *Job) error {
+			applied = job
+			return nil
+		})
+		cmd := NewServiceCommander(ms, engine, nil, 0, JobRetryPolicy{}, nil)
+
+		preview, err := cmd.PreviewUpdate(ctx, svc.ID, nil, &properties.JSON{"cpu": 4})
+		require.NoError(t, err)
+		_, err = cmd.Update(ctx, UpdateServiceParams{ID: svc.ID, Properties: &properties.JSON{"cpu": 4}})
+		require.NoError(t, err)
+		require.NotNil(t, applied)
+		assert.Equal(t, preview.Action, applied.Action)
+	})
+
+	t.Run("immutable property", func(t *testing.T) {
+		svc := newService("Started")
+		ms, _ := setup(t, svc)
+
+		preview, err := NewServiceCommander(ms, engine, nil, 0, JobRetryPolicy{}, nil).PreviewUpdate(ctx, svc.ID, nil, &properties.JSON{"region": "us"})
+		require.NoError(t, err)
+		assert.False(t, preview.Valid)
+		require.Len(t, preview.Errors, 1)
+		assert.Equal(t, "region", preview.Errors[0].Path)
+		assert.Equal(t, []string{"region"}, preview.ChangedProperties)
+	})
+
+	t.Run("update not allowed in the state", func(t *testing.T) {
+		svc := newService("Stopped")
+		ms, _ := setup(t, svc)
+
+		preview, err := NewServiceCommander(ms, engine, nil, 0, JobRetryPolicy{}, nil).PreviewUpdate(ctx, svc.ID, nil, &properties.JSON{"cpu": 4})
+		require.NoError(t, err)
+		assert.False(t, preview.Valid)
+		require.Len(t, preview.Errors, 1)
+		assert.Equal(t, schema.UpdateModeCold, preview.UpdateMode)
+		assert.Empty(t, preview.Action)
+	})
+
+	t.Run("service in maintenance", func(t *testing.T) {
+		svc := newService("Started")
+		svc.Maintenance = true
+		ms := NewMockStore(t)
+		serviceRepo := NewMockServiceRepository(t)
+		ms.EXPECT().ServiceRepo().Return(serviceRepo)
+		serviceRepo.EXPECT().Get(mock.Anything, svc.ID).Return(svc, nil)
+
+		_, err := NewServiceCommander(ms, engine, nil, 0, JobRetryPolicy{}, nil).PreviewUpdate(ctx, svc.ID, nil, &properties.JSON{"cpu": 4})
+		assert.ErrorAs(t, err, &MaintenanceError{})
+	})
+}
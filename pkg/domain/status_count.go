@@ -0,0 +1,8 @@
+package domain
+
+// StatusCount is the number of entities in a status, within a group such as their type when grouped
+type StatusCount struct {
+	Group  string
+	Status string
+	Count  int64
+}
@@ -2,10 +2,15 @@ package domain
 
 import (
 	"context"
+	"crypto/pbkdf2"
 	"crypto/rand"
 	"crypto/sha256"
+	"crypto/subtle"
 	"encoding/base64"
+	"errors"
 	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/fulcrumproject/core/pkg/auth"
@@ -17,23 +22,52 @@ const (
 	EventTypeTokenUpdated     EventType = "token.updated"
 	EventTypeTokenDeleted     EventType = "token.deleted"
 	EventTypeTokenRegenerated EventType = "token.regenerate"
+	EventTypeTokensRevoked    EventType = "token.revoked"  // All the tokens of a participant or an agent were revoked at once
+	EventTypeTokenExpiring    EventType = "token.expiring" // The token expires within the warning window
+	EventTypeTokenExpired     EventType = "token.expired"  // The token is past its expiry
 )
 
+// TokenLastUsedThrottle is the minimum interval between two updates of the last use of a token
+const TokenLastUsedThrottle = time.Minute
+
+// Cost of the token hashes, the number of PBKDF2-SHA256 iterations
+const (
+	DefaultTokenHashCost = 10000
+	// MinTokenHashCost is the lowest cost accepted for new hashes
+	MinTokenHashCost = 1000
+)
+
+// tokenHashScheme prefixes the self-describing token hashes: "pbkdf2-sha256$<cost>$<key>"
+// Hashes without the prefix are the legacy unsalted SHA-256 ones, reported with cost 0
+const tokenHashScheme = "pbkdf2-sha256"
+
+// tokenHashSalt is the fixed salt of the token hashes, the hash must be deterministic to look a token up by its value
+// A per token salt adds nothing since the values are 256-bit random
+var tokenHashSalt = []byte("fulcrum-token")
+
 // Token represents an authentication token
 type Token struct {
 	BaseEntity
 
-	Name        string    `json:"name" gorm:"not null"`
-	Role        auth.Role `json:"role" gorm:"not null"`
-	PlainValue  string    `json:"-" gorm:"-"`
-	HashedValue string    `json:"-" gorm:"not null"`
-	ExpireAt    time.Time `json:"expireAt" gorm:"not null"`
+	Name        string     `json:"name" gorm:"not null"`
+	Role        auth.Role  `json:"role" gorm:"not null"`
+	PlainValue  string     `json:"-" gorm:"-"`
+	HashedValue string     `json:"-" gorm:"not null"`
+	HashCost    int        `json:"-" gorm:"not null;default:0;index"` // Cost of HashedValue, 0 for the legacy hashes
+	ExpireAt    time.Time  `json:"expireAt" gorm:"not null"`
+	LastUsedAt  *time.Time `json:"lastUsedAt,omitempty"`
 
 	// Relationships
 	ParticipantID *properties.UUID `json:"participantId,omitempty"`           // New field
 	Participant   *Participant     `json:"-" gorm:"foreignKey:ParticipantID"` // New field
 	AgentID       *properties.UUID `json:"agentId,omitempty"`
 	Agent         *Agent           `json:"-" gorm:"foreignKey:AgentID"`
+
+	// Service groups of the participant the token is restricted to, empty allows all of them
+	GroupIDs []properties.UUID `json:"groupIds,omitempty" gorm:"type:jsonb;serializer:json"`
+
+	// Last expiration warning emitted for the current expiry, reset when the expiry changes
+	ExpiryNotice TokenExpiryNotice `json:"-" gorm:"not null;default:''"`
 }
 
 // NewToken is an helper method to create a token with appropriate scope settings
@@ -77,10 +111,28 @@ func NewToken(
 			}
 			token.AgentID = params.ScopeID
 			token.ParticipantID = &agent.ProviderID
+		case auth.RoleAdmin:
+		default:
+			// Custom roles are scoped to a participant when a scope is given
+			exists, err := store.ParticipantRepo().Exists(ctx, *params.ScopeID)
+			if err != nil {
+				return nil, err
+			}
+			if !exists {
+				return nil, NewInvalidInputErrorf("invalid participant ID: %v", params.ScopeID)
+			}
+			token.ParticipantID = params.ScopeID
+		}
+	}
+
+	if len(params.GroupIDs) > 0 {
+		if err := validateTokenGroups(ctx, store, token, params.GroupIDs); err != nil {
+			return nil, err
 		}
+		token.GroupIDs = params.GroupIDs
 	}
 
-	err := token.GenerateTokenValue()
+	err := token.GenerateTokenValue(params.HashCost)
 	if err != nil {
 		return nil, err
 	}
@@ -93,6 +145,23 @@ func NewToken(
 	return token, nil
 }
 
+// validateTokenGroups ensures the service groups restricting a token belong to its participant
+func validateTokenGroups(ctx context.Context, store Store, token *Token, groupIDs []properties.UUID) error {
+	if token.ParticipantID == nil || token.AgentID != nil {
+		return NewInvalidInputErrorf("only tokens scoped to a participant can be restricted to service groups")
+	}
+	for _, groupID := range groupIDs {
+		group, err := store.ServiceGroupRepo().Get(ctx, groupID)
+		if err != nil {
+			return NewInvalidInputErrorf("invalid service group ID: %v", groupID)
+		}
+		if group.ConsumerID != *token.ParticipantID {
+			return NewInvalidInputErrorf("service group %v does not belong to participant %v", groupID, *token.ParticipantID)
+		}
+	}
+	return nil
+}
+
 // TableName returns the table name for the token
 func (Token) TableName() string {
 	return "tokens"
@@ -117,7 +186,7 @@ func (t *Token) Validate() error {
 	switch t.Role {
 	case auth.RoleAdmin:
 		// No scope ID needed for admin
-		if t.ParticipantID != nil || t.AgentID != nil { // Updated to check ParticipantID
+		if t.ParticipantID != nil || t.AgentID != nil || len(t.GroupIDs) > 0 { // Updated to check ParticipantID
 			return fmt.Errorf("fulcrum admin tokens should not have any scope IDs")
 		}
 	case auth.RoleParticipant: // New Role (assuming it's defined)
@@ -136,6 +205,14 @@ func (t *Token) Validate() error {
 		if t.ParticipantID == nil { // Agent's ParticipantID
 			return fmt.Errorf("participant ID is required for agent role")
 		}
+		if len(t.GroupIDs) > 0 {
+			return fmt.Errorf("agent tokens cannot be restricted to service groups")
+		}
+	default:
+		// Custom roles are either global or scoped to a participant
+		if t.AgentID != nil {
+			return fmt.Errorf("custom role tokens cannot have an agent ID")
+		}
 	}
 
 	return nil
@@ -146,15 +223,32 @@ func (t *Token) IsExpired() bool {
 	return time.Now().After(t.ExpireAt)
 }
 
-// GenerateTokenValue creates a secure random token and sets the HashedValue field
+// ShouldRecordUse reports whether a use at now must be recorded, uses are recorded at most once per TokenLastUsedThrottle
+func (t *Token) ShouldRecordUse(now time.Time) bool {
+	return t.LastUsedAt == nil || now.Sub(*t.LastUsedAt) >= TokenLastUsedThrottle
+}
+
+// GenerateTokenValue creates a secure random token and sets the HashedValue field hashed at the given cost,
+// DefaultTokenHashCost when zero
 // The plain text value is only returned and never stored in the entity
-func (t *Token) GenerateTokenValue() error {
+func (t *Token) GenerateTokenValue(cost int) error {
+	if cost == 0 {
+		cost = DefaultTokenHashCost
+	}
+	if err := ValidateTokenHashCost(cost); err != nil {
+		return err
+	}
 	plain, err := generateSecureToken()
 	if err != nil {
 		return err
 	}
+	hashed, err := HashTokenValueWithCost(plain, cost)
+	if err != nil {
+		return err
+	}
 	t.PlainValue = plain
-	t.HashedValue = HashTokenValue(plain)
+	t.HashedValue = hashed
+	t.HashCost = cost
 	return nil
 }
 
@@ -167,9 +261,17 @@ func generateSecureToken() (string, error) {
 	return base64.URLEncoding.EncodeToString(buf), nil
 }
 
-// VerifyTokenValue checks if a token matches the stored hash
+// VerifyTokenValue checks if a token matches the stored hash, at the cost the hash was made with
 func (t *Token) VerifyTokenValue(value string) bool {
-	return t.HashedValue == HashTokenValue(value)
+	cost, err := TokenHashCost(t.HashedValue)
+	if err != nil {
+		return false
+	}
+	hashed, err := HashTokenValueWithCost(value, cost)
+	if err != nil {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(t.HashedValue), []byte(hashed)) == 1
 }
 
 // HashTokenValue creates a secure hash of a token value
@@ -178,12 +280,56 @@ func HashTokenValue(value string) string {
 	return base64.StdEncoding.EncodeToString(hash[:])
 }
 
+// HashTokenValueWithCost creates the self-describing hash of a token value at the given cost,
+// the legacy HashTokenValue when the cost is 0
+func HashTokenValueWithCost(value string, cost int) (string, error) {
+	if cost == 0 {
+		return HashTokenValue(value), nil
+	}
+	key, err := pbkdf2.Key(sha256.New, value, tokenHashSalt, cost, sha256.Size)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash token: %w", err)
+	}
+	return fmt.Sprintf("%s$%d$%s", tokenHashScheme, cost, base64.StdEncoding.EncodeToString(key)), nil
+}
+
+// TokenHashCost returns the cost a token hash was made with, 0 for the legacy hashes
+func TokenHashCost(hashed string) (int, error) {
+	scheme, rest, found := strings.Cut(hashed, "$")
+	if !found {
+		return 0, nil
+	}
+	if scheme != tokenHashScheme {
+		return 0, fmt.Errorf("unknown token hash scheme %q", scheme)
+	}
+	costStr, _, found := strings.Cut(rest, "$")
+	if !found {
+		return 0, fmt.Errorf("malformed token hash")
+	}
+	cost, err := strconv.Atoi(costStr)
+	if err != nil || cost <= 0 {
+		return 0, fmt.Errorf("invalid token hash cost %q", costStr)
+	}
+	return cost, nil
+}
+
+// ValidateTokenHashCost checks that a cost is high enough to hash new tokens
+func ValidateTokenHashCost(cost int) error {
+	if cost < MinTokenHashCost {
+		return fmt.Errorf("token hash cost %d is lower than the minimum %d", cost, MinTokenHashCost)
+	}
+	return nil
+}
+
 // Update updates the token properties
 func (t *Token) Update(params UpdateTokenParams) error {
 	if params.Name != nil {
 		t.Name = *params.Name
 	}
 	if params.ExpireAt != nil {
+		if !params.ExpireAt.Equal(t.ExpireAt) {
+			t.ExpiryNotice = TokenExpiryNoticeNone
+		}
 		t.ExpireAt = *params.ExpireAt
 	}
 	return t.Validate()
@@ -202,6 +348,14 @@ type TokenCommander interface {
 
 	// Regenerate regenerates the token value
 	Regenerate(ctx context.Context, id properties.UUID) (*Token, error)
+
+	// RevokeByParticipant deletes all the tokens of a participant, including the ones of its agents, and returns their number
+	// The token authenticating the caller is only revoked when confirmSelf is set
+	RevokeByParticipant(ctx context.Context, participantID properties.UUID, confirmSelf bool) (int64, error)
+
+	// RevokeByAgent deletes all the tokens of an agent and returns their number
+	// The token authenticating the caller is only revoked when confirmSelf is set
+	RevokeByAgent(ctx context.Context, agentID properties.UUID, confirmSelf bool) (int64, error)
 }
 
 type CreateTokenParams struct {
@@ -209,6 +363,10 @@ type CreateTokenParams struct {
 	Role     auth.Role        `json:"role"`
 	ExpireAt *time.Time       `json:"expireAt"`
 	ScopeID  *properties.UUID `json:"scopeId"`
+	// GroupIDs restricts a participant scoped token to these service groups
+	GroupIDs []properties.UUID `json:"groupIds,omitempty"`
+	// HashCost is the cost the token value is hashed with, DefaultTokenHashCost when zero
+	HashCost int `json:"-"`
 }
 
 type UpdateTokenParams struct {
@@ -219,15 +377,19 @@ type UpdateTokenParams struct {
 
 // tokenCommander is the concrete implementation of TokenCommander
 type tokenCommander struct {
-	store Store
+	store    Store
+	hashCost int
 }
 
-// NewTokenCommander creates a new TokenCommander
+// NewTokenCommander creates a new TokenCommander hashing the created and regenerated tokens at hashCost,
+// DefaultTokenHashCost when zero
 func NewTokenCommander(
 	store Store,
+	hashCost int,
 ) TokenCommander {
 	return &tokenCommander{
-		store: store,
+		store:    store,
+		hashCost: hashCost,
 	}
 }
 
@@ -239,6 +401,7 @@ func (s *tokenCommander) Create(
 	var token *Token
 	err := s.store.Atomic(ctx, func(store Store) error {
 		var err error
+		params.HashCost = s.hashCost
 		token, err = NewToken(ctx, store, params)
 		if err != nil {
 			return err
@@ -250,6 +413,7 @@ func (s *tokenCommander) Create(
 		if err != nil {
 			return err
 		}
+		eventEntry.Payload = tokenCreatedPayload(auth.MustGetIdentity(ctx), token)
 		if err := store.EventRepo().Create(ctx, eventEntry); err != nil {
 			return err
 		}
@@ -262,6 +426,25 @@ func (s *tokenCommander) Create(
 	return token, nil
 }
 
+// tokenCreatedPayload audits the token and the identity creating it,
+// a token created by a non admin identity is a self-service token
+func tokenCreatedPayload(creator *auth.Identity, token *Token) properties.JSON {
+	createdBy := map[string]any{
+		"id":   creator.ID.String(),
+		"name": creator.Name,
+		"role": string(creator.Role),
+	}
+	if creator.Scope.ParticipantID != nil {
+		createdBy["participantId"] = creator.Scope.ParticipantID.String()
+	}
+	return properties.JSON{
+		"name":        token.Name,
+		"role":        string(token.Role),
+		"selfService": creator.Role != auth.RoleAdmin,
+		"createdBy":   createdBy,
+	}
+}
+
 func (s *tokenCommander) Update(ctx context.Context,
 	params UpdateTokenParams,
 ) (*Token, error) {
@@ -331,7 +514,7 @@ func (s *tokenCommander) Regenerate(ctx context.Context, id properties.UUID) (*T
 
 	// Regenerate, save and event
 	err = s.store.Atomic(ctx, func(store Store) error {
-		if err := token.GenerateTokenValue(); err != nil {
+		if err := token.GenerateTokenValue(s.hashCost); err != nil {
 			return err
 		}
 		if err := store.TokenRepo().Save(ctx, token); err != nil {
@@ -354,15 +537,108 @@ func (s *tokenCommander) Regenerate(ctx context.Context, id properties.UUID) (*T
 	return token, nil
 }
 
+func (s *tokenCommander) RevokeByParticipant(ctx context.Context, participantID properties.UUID, confirmSelf bool) (int64, error) {
+	participant, err := s.store.ParticipantRepo().Get(ctx, participantID)
+	if err != nil {
+		return 0, err
+	}
+	err = s.checkSelfRevocation(ctx, confirmSelf, func(token *Token) bool {
+		return token.ParticipantID != nil && *token.ParticipantID == participantID
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	var count int64
+	err = s.store.Atomic(ctx, func(store Store) error {
+		if count, err = store.TokenRepo().DeleteByParticipantID(ctx, participantID); err != nil {
+			return err
+		}
+		return createTokensRevokedEvent(ctx, store, count, WithParticipant(participant))
+	})
+	if err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+func (s *tokenCommander) RevokeByAgent(ctx context.Context, agentID properties.UUID, confirmSelf bool) (int64, error) {
+	agent, err := s.store.AgentRepo().Get(ctx, agentID)
+	if err != nil {
+		return 0, err
+	}
+	err = s.checkSelfRevocation(ctx, confirmSelf, func(token *Token) bool {
+		return token.AgentID != nil && *token.AgentID == agentID
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	var count int64
+	err = s.store.Atomic(ctx, func(store Store) error {
+		if count, err = store.TokenRepo().DeleteByAgentID(ctx, agentID); err != nil {
+			return err
+		}
+		return createTokensRevokedEvent(ctx, store, count, WithAgent(agent))
+	})
+	if err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// checkSelfRevocation refuses to revoke the token authenticating the caller without confirmation,
+// so that a caller does not lock itself out by mistake
+// The identities not authenticated by a token, e.g. OAuth ones, are never revoked
+func (s *tokenCommander) checkSelfRevocation(ctx context.Context, confirmSelf bool, revoked func(*Token) bool) error {
+	identity := auth.GetIdentity(ctx)
+	if confirmSelf || identity == nil {
+		return nil
+	}
+	token, err := s.store.TokenRepo().Get(ctx, identity.ID)
+	if err != nil {
+		if errors.As(err, &NotFoundError{}) {
+			return nil
+		}
+		return err
+	}
+	if revoked(token) {
+		return NewInvalidInputErrorf("the revocation includes the token of the caller, confirm the self revocation to proceed")
+	}
+	return nil
+}
+
+// createTokensRevokedEvent records the bulk revocation of the tokens of an entity
+func createTokensRevokedEvent(ctx context.Context, store Store, count int64, entity EventOption) error {
+	eventEntry, err := NewEvent(EventTypeTokensRevoked, WithInitiatorCtx(ctx), entity)
+	if err != nil {
+		return err
+	}
+	eventEntry.Payload = properties.JSON{"count": count}
+	return store.EventRepo().Create(ctx, eventEntry)
+}
+
 type TokenRepository interface {
 	TokenQuerier
 	BaseEntityRepository[Token]
 
-	// DeleteByParticipantID removes all tokens associated with a participant ID
-	DeleteByParticipantID(ctx context.Context, participantID properties.UUID) error
+	// DeleteByParticipantID removes all tokens associated with a participant ID and returns their number
+	DeleteByParticipantID(ctx context.Context, participantID properties.UUID) (int64, error)
 
-	// DeleteByAgentID removes all tokens associated with an agent ID
-	DeleteByAgentID(ctx context.Context, agentID properties.UUID) error
+	// DeleteByAgentID removes all tokens associated with an agent ID and returns their number
+	DeleteByAgentID(ctx context.Context, agentID properties.UUID) (int64, error)
+
+	// UpdateLastUsedAt records the last use of a token, skipping the update when it was recorded less than TokenLastUsedThrottle before
+	UpdateLastUsedAt(ctx context.Context, id properties.UUID, at time.Time) error
+
+	// UpdateHash replaces the hash of a token value by its hash at another cost
+	UpdateHash(ctx context.Context, id properties.UUID, hashedValue string, hashCost int) error
+
+	// FindExpiryNoticeCandidates returns the tokens expiring before the threshold not yet warned to be expired
+	FindExpiryNoticeCandidates(ctx context.Context, before time.Time) ([]*Token, error)
+
+	// UpdateExpiryNotice records the expiration warning of a token, skipping the update when its expiry is no longer expireAt
+	UpdateExpiryNotice(ctx context.Context, id properties.UUID, expireAt time.Time, notice TokenExpiryNotice) error
 }
 
 type TokenQuerier interface {
@@ -370,4 +646,13 @@ type TokenQuerier interface {
 
 	// FindByHashedValue finds a token by its hashed value
 	FindByHashedValue(ctx context.Context, hashedValue string) (*Token, error)
+
+	// FindUnusedSince returns the tokens not used since the threshold, the ones never used must be created before it
+	FindUnusedSince(ctx context.Context, threshold time.Time) ([]*Token, error)
+
+	// ListHashCosts returns the distinct costs the token values are hashed with, 0 for the legacy hashes
+	ListHashCosts(ctx context.Context) ([]int, error)
+
+	// FindExpiring returns the tokens in the scope expiring before the threshold, the expired ones included, by expiry
+	FindExpiring(ctx context.Context, scope *auth.IdentityScope, before time.Time) ([]*Token, error)
 }
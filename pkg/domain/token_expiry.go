@@ -0,0 +1,86 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/fulcrumproject/core/pkg/properties"
+)
+
+// TokenExpiryNotice is the last expiration warning emitted for the current expiry of a token
+type TokenExpiryNotice string
+
+const (
+	// TokenExpiryNoticeNone is the notice of a token not warned yet
+	TokenExpiryNoticeNone TokenExpiryNotice = ""
+	// TokenExpiryNoticeExpiring is the notice of a token warned to expire within the warning window
+	TokenExpiryNoticeExpiring TokenExpiryNotice = "expiring"
+	// TokenExpiryNoticeExpired is the notice of a token warned to be expired
+	TokenExpiryNoticeExpired TokenExpiryNotice = "expired"
+)
+
+// ExpiryNoticeAt returns the notice the token deserves at now: expired once the expiry is past,
+// expiring when it falls within the window and none otherwise
+func (t *Token) ExpiryNoticeAt(now time.Time, window time.Duration) TokenExpiryNotice {
+	switch {
+	case !t.ExpireAt.After(now):
+		return TokenExpiryNoticeExpired
+	case t.ExpireAt.Before(now.Add(window)):
+		return TokenExpiryNoticeExpiring
+	default:
+		return TokenExpiryNoticeNone
+	}
+}
+
+// WarnExpiringTokens emits a token.expiring event for each token expiring within the window and a token.expired
+// event for each token past its expiry, and returns the number of warnings emitted
+// The notice recorded on each token makes every warning fire once per expiry: changing the expiry of a token resets it
+func WarnExpiringTokens(ctx context.Context, store Store, window time.Duration, now time.Time) (int, error) {
+	tokens, err := store.TokenRepo().FindExpiryNoticeCandidates(ctx, now.Add(window))
+	if err != nil {
+		return 0, fmt.Errorf("failed to retrieve expiring tokens: %w", err)
+	}
+
+	counter := 0
+	for _, token := range tokens {
+		notice := token.ExpiryNoticeAt(now, window)
+		if notice == TokenExpiryNoticeNone || notice == token.ExpiryNotice {
+			continue
+		}
+		if err := warnTokenExpiry(ctx, store, token, notice); err != nil {
+			return counter, err
+		}
+		counter++
+	}
+	return counter, nil
+}
+
+// warnTokenExpiry records the notice of a token with its warning event
+func warnTokenExpiry(ctx context.Context, store Store, token *Token, notice TokenExpiryNotice) error {
+	eventType := EventTypeTokenExpiring
+	if notice == TokenExpiryNoticeExpired {
+		eventType = EventTypeTokenExpired
+	}
+	return store.Atomic(ctx, func(store Store) error {
+		if err := store.TokenRepo().UpdateExpiryNotice(ctx, token.ID, token.ExpireAt, notice); err != nil {
+			return err
+		}
+		eventEntry, err := NewEvent(eventType, WithToken(token))
+		if err != nil {
+			return err
+		}
+		eventEntry.Payload = properties.JSON{
+			"name":     token.Name,
+			"role":     token.Role,
+			"expireAt": token.ExpireAt,
+		}
+		if token.ParticipantID != nil {
+			eventEntry.Payload["participantId"] = token.ParticipantID
+		}
+		if token.AgentID != nil {
+			eventEntry.Payload["agentId"] = token.AgentID
+		}
+		return store.EventRepo().Create(ctx, eventEntry)
+	})
+}
@@ -0,0 +1,107 @@
+package domain
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/fulcrumproject/core/pkg/auth"
+	"github.com/fulcrumproject/core/pkg/properties"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestToken_ExpiryNoticeAt(t *testing.T) {
+	now := time.Now()
+	window := 7 * 24 * time.Hour
+
+	tests := []struct {
+		name     string
+		expireAt time.Time
+		want     TokenExpiryNotice
+	}{
+		{name: "Beyond the window", expireAt: now.Add(8 * 24 * time.Hour), want: TokenExpiryNoticeNone},
+		{name: "Within the window", expireAt: now.Add(24 * time.Hour), want: TokenExpiryNoticeExpiring},
+		{name: "Expiring now", expireAt: now, want: TokenExpiryNoticeExpired},
+		{name: "Expired", expireAt: now.Add(-time.Hour), want: TokenExpiryNoticeExpired},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			token := &Token{ExpireAt: tc.expireAt}
+			assert.Equal(t, tc.want, token.ExpiryNoticeAt(now, window))
+		})
+	}
+}
+
+func TestToken_UpdateResetsExpiryNotice(t *testing.T) {
+	expireAt := time.Now().Add(time.Hour)
+	token := &Token{Name: "ci", Role: auth.RoleAdmin, HashedValue: "hash", ExpireAt: expireAt, ExpiryNotice: TokenExpiryNoticeExpiring}
+
+	// The same expiry keeps the notice
+	require.NoError(t, token.Update(UpdateTokenParams{ExpireAt: &expireAt}))
+	assert.Equal(t, TokenExpiryNoticeExpiring, token.ExpiryNotice)
+
+	extended := expireAt.Add(30 * 24 * time.Hour)
+	require.NoError(t, token.Update(UpdateTokenParams{ExpireAt: &extended}))
+	assert.Equal(t, TokenExpiryNoticeNone, token.ExpiryNotice)
+}
+
+func TestWarnExpiringTokens(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	window := 7 * 24 * time.Hour
+	participantID := properties.UUID(uuid.New())
+	agentID := properties.UUID(uuid.New())
+
+	newToken := func(expireAt time.Time, notice TokenExpiryNotice) *Token {
+		return &Token{
+			BaseEntity:    BaseEntity{ID: uuid.New()},
+			Name:          "token",
+			Role:          auth.RoleAgent,
+			ExpireAt:      expireAt,
+			ParticipantID: &participantID,
+			AgentID:       &agentID,
+			ExpiryNotice:  notice,
+		}
+	}
+	expiring := newToken(now.Add(24*time.Hour), TokenExpiryNoticeNone)
+	// Already warned in a previous pass
+	warned := newToken(now.Add(48*time.Hour), TokenExpiryNoticeExpiring)
+	// Warned while expiring, now expired
+	expired := newToken(now.Add(-time.Hour), TokenExpiryNoticeExpiring)
+
+	ms := setupMockStore(t)
+	tokenRepo := NewMockTokenRepository(t)
+	eventRepo := NewMockEventRepository(t)
+	ms.EXPECT().TokenRepo().Return(tokenRepo)
+	ms.EXPECT().EventRepo().Return(eventRepo)
+	tokenRepo.EXPECT().FindExpiryNoticeCandidates(mock.Anything, now.Add(window)).Return([]*Token{expiring, warned, expired}, nil)
+	tokenRepo.EXPECT().UpdateExpiryNotice(mock.Anything, expiring.ID, expiring.ExpireAt, TokenExpiryNoticeExpiring).Return(nil)
+	tokenRepo.EXPECT().UpdateExpiryNotice(mock.Anything, expired.ID, expired.ExpireAt, TokenExpiryNoticeExpired).Return(nil)
+	var events []*Event
+	eventRepo.EXPECT().Create(mock.Anything, mock.Anything).
+		Run(func(_ context.Context, e *Event) { events = append(events, e) }).
+		Return(nil)
+
+	count, err := WarnExpiringTokens(ctx, ms, window, now)
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+	require.Len(t, events, 2)
+
+	assert.Equal(t, EventTypeTokenExpiring, events[0].Type)
+	assert.Equal(t, expiring.ID, *events[0].EntityID)
+	assert.Equal(t, &participantID, events[0].ParticipantID)
+	assert.Equal(t, &agentID, events[0].AgentID)
+	assert.Equal(t, properties.JSON{
+		"name":          "token",
+		"role":          auth.RoleAgent,
+		"expireAt":      expiring.ExpireAt,
+		"participantId": &participantID,
+		"agentId":       &agentID,
+	}, events[0].Payload)
+
+	assert.Equal(t, EventTypeTokenExpired, events[1].Type)
+	assert.Equal(t, expired.ID, *events[1].EntityID)
+}
@@ -1,12 +1,17 @@
 package domain
 
 import (
+	"context"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/fulcrumproject/core/pkg/auth"
+	"github.com/fulcrumproject/core/pkg/properties"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 func TestToken_TableName(t *testing.T) {
@@ -165,6 +170,24 @@ func TestToken_Validate(t *testing.T) {
 	}
 }
 
+func TestToken_ValidateCustomRole(t *testing.T) {
+	role := auth.Role("token-test-auditor")
+	auth.RegisterCustomRoles(role)
+	validID := uuid.New()
+
+	token := &Token{Name: "Auditor", Role: role, HashedValue: "hashedvalue", ExpireAt: time.Now().Add(time.Hour)}
+	assert.NoError(t, token.Validate(), "global custom role token")
+
+	token.ParticipantID = &validID
+	assert.NoError(t, token.Validate(), "participant scoped custom role token")
+
+	token.AgentID = &validID
+	assert.ErrorContains(t, token.Validate(), "custom role tokens cannot have an agent ID")
+
+	unknown := &Token{Name: "Unknown", Role: "unregistered", HashedValue: "hashedvalue", ExpireAt: time.Now().Add(time.Hour)}
+	assert.Error(t, unknown.Validate())
+}
+
 func TestToken_IsExpired(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -194,18 +217,55 @@ func TestToken_IsExpired(t *testing.T) {
 	}
 }
 
+func TestToken_ShouldRecordUse(t *testing.T) {
+	now := time.Now()
+	recent := now.Add(-30 * time.Second)
+	old := now.Add(-TokenLastUsedThrottle)
+
+	tests := []struct {
+		name       string
+		lastUsedAt *time.Time
+		want       bool
+	}{
+		{name: "Never used", lastUsedAt: nil, want: true},
+		{name: "Used within the throttle interval", lastUsedAt: &recent, want: false},
+		{name: "Used at the end of the throttle interval", lastUsedAt: &old, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token := &Token{LastUsedAt: tt.lastUsedAt}
+			assert.Equal(t, tt.want, token.ShouldRecordUse(now))
+		})
+	}
+}
+
 func TestToken_GenerateTokenValue(t *testing.T) {
 	token := &Token{}
-	err := token.GenerateTokenValue()
+	err := token.GenerateTokenValue(0)
 	assert.NoError(t, err)
 	assert.NotEmpty(t, token.PlainValue)
 	assert.NotEmpty(t, token.HashedValue)
 	assert.NotEqual(t, token.PlainValue, token.HashedValue)
+	cost, err := TokenHashCost(token.HashedValue)
+	require.NoError(t, err)
+	assert.Equal(t, DefaultTokenHashCost, cost)
+	assert.Equal(t, DefaultTokenHashCost, token.HashCost)
+
+	err = token.GenerateTokenValue(2000)
+	require.NoError(t, err)
+	cost, err = TokenHashCost(token.HashedValue)
+	require.NoError(t, err)
+	assert.Equal(t, 2000, cost)
+	assert.Equal(t, 2000, token.HashCost)
+
+	err = token.GenerateTokenValue(MinTokenHashCost - 1)
+	assert.ErrorContains(t, err, "lower than the minimum")
 }
 
 func TestToken_VerifyTokenValue(t *testing.T) {
 	token := &Token{}
-	err := token.GenerateTokenValue()
+	err := token.GenerateTokenValue(0)
 	assert.NoError(t, err)
 
 	validValue := token.PlainValue
@@ -213,6 +273,14 @@ func TestToken_VerifyTokenValue(t *testing.T) {
 
 	assert.True(t, token.VerifyTokenValue(validValue))
 	assert.False(t, token.VerifyTokenValue(invalidValue))
+
+	// Tokens hashed at another cost or with the legacy hash still verify
+	other := &Token{}
+	require.NoError(t, other.GenerateTokenValue(2000))
+	assert.True(t, other.VerifyTokenValue(other.PlainValue))
+	legacy := &Token{HashedValue: HashTokenValue(validValue)}
+	assert.True(t, legacy.VerifyTokenValue(validValue))
+	assert.False(t, legacy.VerifyTokenValue(invalidValue))
 }
 
 func TestHashTokenValue(t *testing.T) {
@@ -228,3 +296,247 @@ func TestHashTokenValue(t *testing.T) {
 	assert.NotEqual(t, hash1, hash2)
 	assert.Equal(t, hash1, hash1Again)
 }
+
+func TestHashTokenValueWithCost(t *testing.T) {
+	hash1, err := HashTokenValueWithCost("token1", 2000)
+	require.NoError(t, err)
+	hash1Again, err := HashTokenValueWithCost("token1", 2000)
+	require.NoError(t, err)
+	hash1Default, err := HashTokenValueWithCost("token1", DefaultTokenHashCost)
+	require.NoError(t, err)
+	legacy, err := HashTokenValueWithCost("token1", 0)
+	require.NoError(t, err)
+
+	assert.True(t, strings.HasPrefix(hash1, "pbkdf2-sha256$2000$"))
+	assert.Equal(t, hash1, hash1Again)
+	assert.NotEqual(t, hash1, hash1Default)
+	assert.Equal(t, HashTokenValue("token1"), legacy)
+}
+
+func TestTokenHashCost(t *testing.T) {
+	tests := []struct {
+		name     string
+		hashed   string
+		expected int
+		wantErr  bool
+	}{
+		{name: "Legacy", hashed: HashTokenValue("token"), expected: 0},
+		{name: "PBKDF2", hashed: "pbkdf2-sha256$5000$a2V5", expected: 5000},
+		{name: "Unknown scheme", hashed: "bcrypt$10$a2V5", wantErr: true},
+		{name: "Missing key", hashed: "pbkdf2-sha256$5000", wantErr: true},
+		{name: "Invalid cost", hashed: "pbkdf2-sha256$abc$a2V5", wantErr: true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			cost, err := TokenHashCost(tc.hashed)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, cost)
+		})
+	}
+}
+
+func TestTokenCommander_CreateAuditsCreator(t *testing.T) {
+	participantID := properties.NewUUID()
+	creator := &auth.Identity{
+		ID:    properties.NewUUID(),
+		Name:  "ops",
+		Role:  auth.RoleParticipant,
+		Scope: auth.IdentityScope{ParticipantID: &participantID},
+	}
+
+	ms := setupMockStore(t)
+	participantRepo := NewMockParticipantRepository(t)
+	participantRepo.EXPECT().Exists(mock.Anything, participantID).Return(true, nil)
+	ms.EXPECT().ParticipantRepo().Return(participantRepo)
+	tokenRepo := NewMockTokenRepository(t)
+	tokenRepo.EXPECT().Create(mock.Anything, mock.Anything).Return(nil)
+	ms.EXPECT().TokenRepo().Return(tokenRepo)
+	var event *Event
+	eventRepo := NewMockEventRepository(t)
+	eventRepo.EXPECT().Create(mock.Anything, mock.Anything).RunAndReturn(func(_ context.Context, e *Event) error {
+		event = e
+		return nil
+	})
+	ms.EXPECT().EventRepo().Return(eventRepo)
+
+	ctx := auth.WithIdentity(context.Background(), creator)
+	token, err := NewTokenCommander(ms, 0).Create(ctx, CreateTokenParams{Name: "ci", Role: auth.RoleParticipant, ScopeID: &participantID})
+	require.NoError(t, err)
+	assert.Equal(t, &participantID, token.ParticipantID)
+
+	require.NotNil(t, event)
+	assert.Equal(t, EventTypeTokenCreated, event.Type)
+	assert.Equal(t, creator.ID.String(), event.InitiatorID)
+	assert.Equal(t, true, event.Payload["selfService"])
+	assert.Equal(t, "participant", event.Payload["role"])
+	createdBy := event.Payload["createdBy"].(map[string]any)
+	assert.Equal(t, "ops", createdBy["name"])
+	assert.Equal(t, "participant", createdBy["role"])
+	assert.Equal(t, participantID.String(), createdBy["participantId"])
+	assert.NotContains(t, event.Payload, "value", "the token value is never audited")
+}
+
+func TestNewToken_GroupRestriction(t *testing.T) {
+	ctx := context.Background()
+	participantID := properties.NewUUID()
+	groupID := properties.NewUUID()
+	otherGroupID := properties.NewUUID()
+
+	setup := func(t *testing.T) *MockStore {
+		ms := NewMockStore(t)
+		participantRepo := NewMockParticipantRepository(t)
+		participantRepo.EXPECT().Exists(mock.Anything, participantID).Return(true, nil).Maybe()
+		ms.EXPECT().ParticipantRepo().Return(participantRepo).Maybe()
+		groupRepo := NewMockServiceGroupRepository(t)
+		groupRepo.EXPECT().Get(mock.Anything, groupID).Return(&ServiceGroup{BaseEntity: BaseEntity{ID: groupID}, ConsumerID: participantID}, nil).Maybe()
+		groupRepo.EXPECT().Get(mock.Anything, otherGroupID).Return(&ServiceGroup{BaseEntity: BaseEntity{ID: otherGroupID}, ConsumerID: properties.NewUUID()}, nil).Maybe()
+		ms.EXPECT().ServiceGroupRepo().Return(groupRepo).Maybe()
+		return ms
+	}
+
+	t.Run("Groups of the participant", func(t *testing.T) {
+		token, err := NewToken(ctx, setup(t), CreateTokenParams{Name: "ops", Role: auth.RoleParticipant, ScopeID: &participantID, GroupIDs: []properties.UUID{groupID}})
+		require.NoError(t, err)
+		assert.Equal(t, []properties.UUID{groupID}, token.GroupIDs)
+	})
+
+	t.Run("Group of another participant", func(t *testing.T) {
+		_, err := NewToken(ctx, setup(t), CreateTokenParams{Name: "ops", Role: auth.RoleParticipant, ScopeID: &participantID, GroupIDs: []properties.UUID{otherGroupID}})
+		assert.ErrorAs(t, err, &InvalidInputError{})
+	})
+
+	t.Run("Admin token", func(t *testing.T) {
+		_, err := NewToken(ctx, setup(t), CreateTokenParams{Name: "ops", Role: auth.RoleAdmin, GroupIDs: []properties.UUID{groupID}})
+		assert.ErrorAs(t, err, &InvalidInputError{})
+	})
+}
+
+func TestTokenCommander_RevokeByParticipant(t *testing.T) {
+	participant := &Participant{BaseEntity: BaseEntity{ID: properties.NewUUID()}, Name: "acme"}
+	callerToken := &Token{BaseEntity: BaseEntity{ID: properties.NewUUID()}, Role: auth.RoleParticipant, ParticipantID: &participant.ID}
+	otherParticipantID := properties.NewUUID()
+	withCaller := func(id properties.UUID, role auth.Role) context.Context {
+		return auth.WithIdentity(context.Background(), &auth.Identity{ID: id, Name: "caller", Role: role})
+	}
+
+	setup := func(t *testing.T) (*MockStore, *MockTokenRepository) {
+		ms := setupMockStore(t)
+		participantRepo := NewMockParticipantRepository(t)
+		tokenRepo := NewMockTokenRepository(t)
+		ms.EXPECT().ParticipantRepo().Return(participantRepo)
+		ms.EXPECT().TokenRepo().Return(tokenRepo)
+		participantRepo.EXPECT().Get(mock.Anything, participant.ID).Return(participant, nil)
+		return ms, tokenRepo
+	}
+	expectRevoke := func(t *testing.T, ms *MockStore, tokenRepo *MockTokenRepository, count int64) {
+		eventRepo := NewMockEventRepository(t)
+		ms.EXPECT().EventRepo().Return(eventRepo)
+		tokenRepo.EXPECT().DeleteByParticipantID(mock.Anything, participant.ID).Return(count, nil)
+		eventRepo.EXPECT().Create(mock.Anything, mock.MatchedBy(func(e *Event) bool {
+			return e.Type == EventTypeTokensRevoked && *e.ParticipantID == participant.ID && e.Payload["count"] == count
+		})).Return(nil)
+	}
+
+	t.Run("revokes the tokens of another participant", func(t *testing.T) {
+		ms, tokenRepo := setup(t)
+		adminToken := &Token{BaseEntity: BaseEntity{ID: properties.NewUUID()}, Role: auth.RoleAdmin}
+		tokenRepo.EXPECT().Get(mock.Anything, adminToken.ID).Return(adminToken, nil)
+		expectRevoke(t, ms, tokenRepo, 3)
+
+		count, err := NewTokenCommander(ms, 0).RevokeByParticipant(withCaller(adminToken.ID, auth.RoleAdmin), participant.ID, false)
+		require.NoError(t, err)
+		assert.Equal(t, int64(3), count)
+	})
+
+	t.Run("caller not authenticated by a token", func(t *testing.T) {
+		ms, tokenRepo := setup(t)
+		userID := properties.NewUUID()
+		tokenRepo.EXPECT().Get(mock.Anything, userID).Return(nil, NewNotFoundErrorf("token not found"))
+		expectRevoke(t, ms, tokenRepo, 1)
+
+		count, err := NewTokenCommander(ms, 0).RevokeByParticipant(withCaller(userID, auth.RoleAdmin), participant.ID, false)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), count)
+	})
+
+	t.Run("self revocation requires confirmation", func(t *testing.T) {
+		ms, tokenRepo := setup(t)
+		tokenRepo.EXPECT().Get(mock.Anything, callerToken.ID).Return(callerToken, nil)
+
+		_, err := NewTokenCommander(ms, 0).RevokeByParticipant(withCaller(callerToken.ID, auth.RoleParticipant), participant.ID, false)
+		assert.ErrorAs(t, err, &InvalidInputError{})
+		assert.ErrorContains(t, err, "confirm the self revocation")
+	})
+
+	t.Run("confirmed self revocation", func(t *testing.T) {
+		ms, tokenRepo := setup(t)
+		expectRevoke(t, ms, tokenRepo, 2)
+
+		count, err := NewTokenCommander(ms, 0).RevokeByParticipant(withCaller(callerToken.ID, auth.RoleParticipant), participant.ID, true)
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), count)
+	})
+
+	t.Run("caller token of another participant", func(t *testing.T) {
+		ms, tokenRepo := setup(t)
+		other := &Token{BaseEntity: BaseEntity{ID: properties.NewUUID()}, Role: auth.RoleParticipant, ParticipantID: &otherParticipantID}
+		tokenRepo.EXPECT().Get(mock.Anything, other.ID).Return(other, nil)
+		expectRevoke(t, ms, tokenRepo, 0)
+
+		count, err := NewTokenCommander(ms, 0).RevokeByParticipant(withCaller(other.ID, auth.RoleParticipant), participant.ID, false)
+		require.NoError(t, err)
+		assert.Equal(t, int64(0), count)
+	})
+
+	t.Run("unknown participant", func(t *testing.T) {
+		ms := NewMockStore(t)
+		participantRepo := NewMockParticipantRepository(t)
+		ms.EXPECT().ParticipantRepo().Return(participantRepo)
+		participantRepo.EXPECT().Get(mock.Anything, participant.ID).Return(nil, NewNotFoundErrorf("participant not found"))
+
+		_, err := NewTokenCommander(ms, 0).RevokeByParticipant(withCaller(callerToken.ID, auth.RoleAdmin), participant.ID, true)
+		assert.ErrorAs(t, err, &NotFoundError{})
+	})
+}
+
+func TestTokenCommander_RevokeByAgent(t *testing.T) {
+	agent := &Agent{BaseEntity: BaseEntity{ID: properties.NewUUID()}, ProviderID: properties.NewUUID()}
+	agentToken := &Token{BaseEntity: BaseEntity{ID: properties.NewUUID()}, Role: auth.RoleAgent, AgentID: &agent.ID, ParticipantID: &agent.ProviderID}
+	ctx := auth.WithIdentity(context.Background(), &auth.Identity{ID: agentToken.ID, Name: "agent", Role: auth.RoleAgent})
+
+	setup := func(t *testing.T) (*MockStore, *MockTokenRepository) {
+		ms := setupMockStore(t)
+		agentRepo := NewMockAgentRepository(t)
+		tokenRepo := NewMockTokenRepository(t)
+		ms.EXPECT().AgentRepo().Return(agentRepo)
+		ms.EXPECT().TokenRepo().Return(tokenRepo)
+		agentRepo.EXPECT().Get(mock.Anything, agent.ID).Return(agent, nil)
+		return ms, tokenRepo
+	}
+
+	t.Run("self revocation requires confirmation", func(t *testing.T) {
+		ms, tokenRepo := setup(t)
+		tokenRepo.EXPECT().Get(mock.Anything, agentToken.ID).Return(agentToken, nil)
+
+		_, err := NewTokenCommander(ms, 0).RevokeByAgent(ctx, agent.ID, false)
+		assert.ErrorAs(t, err, &InvalidInputError{})
+	})
+
+	t.Run("confirmed", func(t *testing.T) {
+		ms, tokenRepo := setup(t)
+		eventRepo := NewMockEventRepository(t)
+		ms.EXPECT().EventRepo().Return(eventRepo)
+		tokenRepo.EXPECT().DeleteByAgentID(mock.Anything, agent.ID).Return(int64(2), nil)
+		eventRepo.EXPECT().Create(mock.Anything, mock.MatchedBy(func(e *Event) bool {
+			return e.Type == EventTypeTokensRevoked && *e.AgentID == agent.ID && e.Payload["count"] == int64(2)
+		})).Return(nil)
+
+		count, err := NewTokenCommander(ms, 0).RevokeByAgent(ctx, agent.ID, true)
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), count)
+	})
+}
@@ -0,0 +1,96 @@
+// Vault secret rotation
+package domain
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// SecretRotationPolicy controls the previous versions kept when a secret is rotated
+type SecretRotationPolicy struct {
+	GracePeriod         time.Duration // How long a replaced version is still accepted
+	MaxPreviousVersions int           // Number of previous versions retained, older ones are dropped even within the grace period
+}
+
+// VaultSecretCommander defines the operations on the secrets of the vault
+type VaultSecretCommander interface {
+	// Rotate replaces the value of a secret, the previous value stays valid during the grace period
+	Rotate(ctx context.Context, reference string, value any) error
+	// Verify reports whether the value matches the current or a non-expired previous version of the secret
+	Verify(ctx context.Context, reference string, value any) (bool, error)
+	// PurgeExpiredVersions removes the previous versions whose grace period elapsed
+	PurgeExpiredVersions(ctx context.Context) (int, error)
+}
+
+// vaultSecretCommander is the concrete implementation of VaultSecretCommander
+type vaultSecretCommander struct {
+	backend SecretBackend
+	policy  SecretRotationPolicy
+	now     func() time.Time
+}
+
+// NewVaultSecretCommander creates a new VaultSecretCommander rotating the secrets of the backend with the policy
+func NewVaultSecretCommander(backend SecretBackend, policy SecretRotationPolicy) *vaultSecretCommander {
+	return &vaultSecretCommander{
+		backend: backend,
+		policy:  policy,
+		now:     time.Now,
+	}
+}
+
+func (c *vaultSecretCommander) Rotate(ctx context.Context, reference string, value any) error {
+	if value == nil {
+		return NewInvalidInputErrorf("secret value is required")
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return NewInvalidInputErrorf("failed to serialize secret: %v", err)
+	}
+	return c.backend.Rotate(ctx, reference, data, c.now().Add(c.policy.GracePeriod), c.policy.MaxPreviousVersions)
+}
+
+func (c *vaultSecretCommander) Verify(ctx context.Context, reference string, value any) (bool, error) {
+	presented, err := canonicalSecret(value)
+	if err != nil {
+		return false, NewInvalidInputErrorf("failed to serialize secret: %v", err)
+	}
+	versions, err := c.backend.GetValid(ctx, reference, c.now())
+	if err != nil {
+		return false, err
+	}
+
+	// Every version is compared in constant time so the response time does not reveal the matching one
+	match := 0
+	for _, data := range versions {
+		var stored any
+		if err := json.Unmarshal(data, &stored); err != nil {
+			return false, fmt.Errorf("failed to deserialize secret: %w", err)
+		}
+		expected, err := canonicalSecret(stored)
+		if err != nil {
+			return false, err
+		}
+		match |= subtle.ConstantTimeCompare(presented, expected)
+	}
+	return match == 1, nil
+}
+
+func (c *vaultSecretCommander) PurgeExpiredVersions(ctx context.Context) (int, error) {
+	return c.backend.PurgeExpired(ctx, c.now())
+}
+
+// canonicalSecret serializes a secret value so equal values have equal bytes, e.g. object keys are sorted
+func canonicalSecret(value any) ([]byte, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	var normalized any
+	if err := json.Unmarshal(data, &normalized); err != nil {
+		return nil, err
+	}
+	return json.Marshal(normalized)
+}
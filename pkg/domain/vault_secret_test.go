@@ -0,0 +1,97 @@
+package domain
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVaultSecretCommander_Rotate(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	policy := SecretRotationPolicy{GracePeriod: time.Hour, MaxPreviousVersions: 2}
+
+	t.Run("Stores the new version with the grace deadline", func(t *testing.T) {
+		backend := NewMockSecretBackend(t)
+		backend.EXPECT().Rotate(ctx, "ref", []byte(`{"password":"new"}`), now.Add(time.Hour), 2).Return(nil)
+
+		cmd := NewVaultSecretCommander(backend, policy)
+		cmd.now = func() time.Time { return now }
+		require.NoError(t, cmd.Rotate(ctx, "ref", map[string]any{"password": "new"}))
+	})
+
+	t.Run("Value is required", func(t *testing.T) {
+		cmd := NewVaultSecretCommander(NewMockSecretBackend(t), policy)
+		err := cmd.Rotate(ctx, "ref", nil)
+		assert.ErrorAs(t, err, &InvalidInputError{})
+	})
+
+	t.Run("Backend errors are returned", func(t *testing.T) {
+		backend := NewMockSecretBackend(t)
+		backend.EXPECT().Rotate(ctx, "missing", mock.Anything, mock.Anything, 2).
+			Return(NewNotFoundErrorf("secret not found: missing"))
+
+		err := NewVaultSecretCommander(backend, policy).Rotate(ctx, "missing", "new")
+		assert.ErrorAs(t, err, &NotFoundError{})
+	})
+}
+
+func TestVaultSecretCommander_Verify(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		value    any
+		expected bool
+	}{
+		{name: "Current version", value: "current", expected: true},
+		{name: "Previous version in grace period", value: "previous", expected: true},
+		{name: "Object with different key order", value: map[string]any{"b": 2, "a": 1}, expected: true},
+		{name: "Unknown value", value: "expired", expected: false},
+		{name: "Different type", value: 1, expected: false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			backend := NewMockSecretBackend(t)
+			backend.EXPECT().GetValid(ctx, "ref", now).Return([][]byte{
+				[]byte(`"current"`),
+				[]byte(`"previous"`),
+				[]byte(`{"a":1,"b":2}`),
+			}, nil)
+
+			cmd := NewVaultSecretCommander(backend, SecretRotationPolicy{GracePeriod: time.Hour})
+			cmd.now = func() time.Time { return now }
+			valid, err := cmd.Verify(ctx, "ref", tc.value)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, valid)
+		})
+	}
+
+	t.Run("Unknown secret", func(t *testing.T) {
+		backend := NewMockSecretBackend(t)
+		backend.EXPECT().GetValid(ctx, "missing", mock.Anything).Return(nil, NewNotFoundErrorf("secret not found: missing"))
+
+		_, err := NewVaultSecretCommander(backend, SecretRotationPolicy{}).Verify(ctx, "missing", "value")
+		assert.ErrorAs(t, err, &NotFoundError{})
+	})
+}
+
+func TestVaultSecretCommander_PurgeExpiredVersions(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	backend := NewMockSecretBackend(t)
+	backend.EXPECT().PurgeExpired(ctx, now).Return(3, nil)
+
+	cmd := NewVaultSecretCommander(backend, SecretRotationPolicy{})
+	cmd.now = func() time.Time { return now }
+	count, err := cmd.PurgeExpiredVersions(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 3, count)
+}
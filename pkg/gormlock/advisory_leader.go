@@ -0,0 +1,126 @@
+package gormlock
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"hash/fnv"
+	"sync"
+
+	"github.com/go-co-op/gocron/v2"
+	"gorm.io/gorm"
+)
+
+var (
+	_ gocron.Locker  = (*AdvisoryLeader)(nil)
+	_ gocron.Elector = (*AdvisoryLeader)(nil)
+)
+
+// ErrNotLeader is returned when another replica holds the leadership
+var ErrNotLeader = errors.New("not the leader")
+
+// AdvisoryLeader elects a single leader among the replicas with a Postgres session advisory lock
+//
+// The lock is held on a dedicated connection for as long as the replica stays the leader, so
+// Postgres releases it as soon as the leader dies or loses its connection and the next replica
+// trying to acquire it takes over. The other replicas only try to acquire the lock, they never
+// wait for it, so the check is a single cheap query.
+type AdvisoryLeader struct {
+	db  *sql.DB
+	key int64
+
+	mu   sync.Mutex
+	conn *sql.Conn // Connection holding the lock, nil when not the leader
+}
+
+// NewAdvisoryLeader creates the election of the leader of name, the lock key is derived from the name
+func NewAdvisoryLeader(db *gorm.DB, name string) (*AdvisoryLeader, error) {
+	if db == nil {
+		return nil, ErrGormCantBeNull
+	}
+	if name == "" {
+		return nil, ErrWorkerIsRequired
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	return &AdvisoryLeader{db: sqlDB, key: advisoryLockKey(name)}, nil
+}
+
+// advisoryLockKey maps a name to an advisory lock key
+func advisoryLockKey(name string) int64 {
+	h := fnv.New64a()
+	h.Write([]byte(name))
+	return int64(h.Sum64())
+}
+
+// IsLeader returns nil when the replica is the leader, acquiring the leadership when it is free
+func (l *AdvisoryLeader) IsLeader(ctx context.Context) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.conn != nil {
+		// The session holds the lock as long as its connection is alive
+		if err := l.conn.PingContext(ctx); err == nil {
+			return nil
+		}
+		_ = l.conn.Close()
+		l.conn = nil
+	}
+
+	conn, err := l.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	var acquired bool
+	if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", l.key).Scan(&acquired); err != nil {
+		_ = conn.Close()
+		return err
+	}
+	if !acquired {
+		_ = conn.Close()
+		return ErrNotLeader
+	}
+	l.conn = conn
+	return nil
+}
+
+// Leading reports whether the replica held the leadership at its last check
+func (l *AdvisoryLeader) Leading() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.conn != nil
+}
+
+// Lock implements gocron.Locker, only the leader runs the job
+// The leadership is kept after the run, the returned lock does not release it
+func (l *AdvisoryLeader) Lock(ctx context.Context, _ string) (gocron.Lock, error) {
+	if err := l.IsLeader(ctx); err != nil {
+		return nil, err
+	}
+	return leaderLock{}, nil
+}
+
+// Close gives up the leadership so another replica takes over without waiting for the connection to drop
+func (l *AdvisoryLeader) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.conn == nil {
+		return nil
+	}
+	_, err := l.conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", l.key)
+	err = errors.Join(err, l.conn.Close())
+	l.conn = nil
+	return err
+}
+
+var _ gocron.Lock = leaderLock{}
+
+// leaderLock is the lock of a run of the leader
+type leaderLock struct{}
+
+func (leaderLock) Unlock(context.Context) error {
+	return nil
+}
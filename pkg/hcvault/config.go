@@ -0,0 +1,59 @@
+package hcvault
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+type Config struct {
+	Address            string        `json:"address" env:"HASHICORP_VAULT_ADDRESS"`
+	Token              string        `json:"token" env:"HASHICORP_VAULT_TOKEN"`
+	Namespace          string        `json:"namespace" env:"HASHICORP_VAULT_NAMESPACE"`
+	MountPath          string        `json:"mountPath" env:"HASHICORP_VAULT_MOUNT_PATH"`
+	PathPrefix         string        `json:"pathPrefix" env:"HASHICORP_VAULT_PATH_PREFIX"`
+	Timeout            time.Duration `json:"timeout" env:"HASHICORP_VAULT_TIMEOUT"`
+	InsecureSkipVerify bool          `json:"insecureSkipVerify" env:"HASHICORP_VAULT_INSECURE_SKIP_VERIFY"`
+}
+
+// Validate checks the settings required to reach the KV v2 engine
+func (c *Config) Validate() error {
+	if c.Address == "" {
+		return fmt.Errorf("hashicorp vault address is required")
+	}
+	if c.Token == "" {
+		return fmt.Errorf("hashicorp vault token is required")
+	}
+	if c.MountPath == "" {
+		return fmt.Errorf("hashicorp vault mount path is required")
+	}
+	return nil
+}
+
+// GetDataPath returns the API path of the versioned data of a secret
+func (c *Config) GetDataPath(reference string) string {
+	return fmt.Sprintf("/v1/%s/data/%s", strings.Trim(c.MountPath, "/"), c.secretPath(reference))
+}
+
+// GetMetadataPath returns the API path of the metadata and versions of a secret
+func (c *Config) GetMetadataPath(reference string) string {
+	return fmt.Sprintf("/v1/%s/metadata/%s", strings.Trim(c.MountPath, "/"), c.secretPath(reference))
+}
+
+// GetDestroyPath returns the API path permanently removing versions of a secret
+func (c *Config) GetDestroyPath(reference string) string {
+	return fmt.Sprintf("/v1/%s/destroy/%s", strings.Trim(c.MountPath, "/"), c.secretPath(reference))
+}
+
+// GetListPath returns the API path listing the secrets below the path prefix
+func (c *Config) GetListPath() string {
+	return fmt.Sprintf("/v1/%s/metadata/%s", strings.Trim(c.MountPath, "/"), strings.Trim(c.PathPrefix, "/"))
+}
+
+func (c *Config) secretPath(reference string) string {
+	prefix := strings.Trim(c.PathPrefix, "/")
+	if prefix == "" {
+		return reference
+	}
+	return prefix + "/" + reference
+}
@@ -0,0 +1,33 @@
+package hcvault
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfig_Paths(t *testing.T) {
+	cfg := &Config{MountPath: "/secret/", PathPrefix: "/fulcrum/"}
+	assert.Equal(t, "/v1/secret/data/fulcrum/ref", cfg.GetDataPath("ref"))
+	assert.Equal(t, "/v1/secret/metadata/fulcrum/ref", cfg.GetMetadataPath("ref"))
+
+	cfg.PathPrefix = ""
+	assert.Equal(t, "/v1/secret/data/ref", cfg.GetDataPath("ref"))
+}
+
+func TestConfig_Validate(t *testing.T) {
+	valid := Config{Address: "https://vault:8200", Token: "token", MountPath: "secret"}
+	assert.NoError(t, valid.Validate())
+
+	missingAddress := valid
+	missingAddress.Address = ""
+	assert.ErrorContains(t, missingAddress.Validate(), "address is required")
+
+	missingToken := valid
+	missingToken.Token = ""
+	assert.ErrorContains(t, missingToken.Validate(), "token is required")
+
+	missingMount := valid
+	missingMount.MountPath = ""
+	assert.ErrorContains(t, missingMount.Validate(), "mount path is required")
+}
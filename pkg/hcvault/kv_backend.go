@@ -0,0 +1,164 @@
+package hcvault
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/fulcrumproject/core/pkg/domain"
+	"resty.dev/v3"
+)
+
+// KVBackend implements domain.SecretBackend on a HashiCorp Vault KV v2 secrets engine
+//
+// Each secret is stored at its reference below the configured path prefix, writes
+// create a new version of the secret and reads return the latest one. Rotations keep
+// the previous versions in the engine, the end of their grace period is recorded in
+// the custom metadata of the secret.
+type KVBackend struct {
+	config *Config
+	client *resty.Client
+}
+
+var _ domain.SecretBackend = (*KVBackend)(nil)
+
+// kvData is the payload of a secret, the serialized value is kept as raw JSON
+type kvData struct {
+	Value json.RawMessage `json:"value"`
+}
+
+type kvWriteReq struct {
+	Options *kvWriteOptions `json:"options,omitempty"`
+	Data    kvData          `json:"data"`
+}
+
+type kvWriteOptions struct {
+	CAS int `json:"cas"`
+}
+
+type kvReadRes struct {
+	Data struct {
+		Data     *kvData `json:"data"`
+		Metadata struct {
+			Version int `json:"version"`
+		} `json:"metadata"`
+	} `json:"data"`
+}
+
+type kvErrorBody struct {
+	Errors []string `json:"errors"`
+}
+
+// NewKVBackend creates a backend calling the Vault HTTP API with the configured token
+func NewKVBackend(cfg *Config) *KVBackend {
+	client := resty.New().
+		SetBaseURL(strings.TrimRight(cfg.Address, "/")).
+		SetHeader("Content-Type", "application/json").
+		SetHeader("X-Vault-Token", cfg.Token).
+		SetError(&kvErrorBody{})
+
+	if cfg.Namespace != "" {
+		client.SetHeader("X-Vault-Namespace", cfg.Namespace)
+	}
+	if cfg.Timeout > 0 {
+		client.SetTimeout(cfg.Timeout)
+	}
+	if cfg.InsecureSkipVerify {
+		client.SetTLSClientConfig(&tls.Config{InsecureSkipVerify: true})
+	}
+
+	return &KVBackend{config: cfg, client: client}
+}
+
+// Put writes a new version of the secret
+func (b *KVBackend) Put(ctx context.Context, reference string, data []byte) error {
+	res, err := b.client.R().
+		SetContext(ctx).
+		SetBody(kvWriteReq{Data: kvData{Value: data}}).
+		Post(b.config.GetDataPath(reference))
+	if err != nil {
+		return domain.NewSecretBackendUnavailableErrorf("failed to write secret %s: %w", reference, err)
+	}
+	if res.IsError() {
+		return responseError(res, "write secret %s", reference)
+	}
+	return nil
+}
+
+// Get reads the latest version of the secret
+func (b *KVBackend) Get(ctx context.Context, reference string) ([]byte, error) {
+	return b.getVersion(ctx, reference, 0)
+}
+
+// getVersion reads a version of the secret, the latest one when version is 0
+func (b *KVBackend) getVersion(ctx context.Context, reference string, version int) ([]byte, error) {
+	var result kvReadRes
+	req := b.client.R().
+		SetContext(ctx).
+		SetResult(&result)
+	if version > 0 {
+		req.SetQueryParam("version", strconv.Itoa(version))
+	}
+	res, err := req.Get(b.config.GetDataPath(reference))
+	if err != nil {
+		return nil, domain.NewSecretBackendUnavailableErrorf("failed to read secret %s: %w", reference, err)
+	}
+	if res.StatusCode() == http.StatusNotFound {
+		return nil, domain.NewNotFoundErrorf("secret not found: %s", reference)
+	}
+	if res.IsError() {
+		return nil, responseError(res, "read secret %s", reference)
+	}
+	// The version is deleted or destroyed
+	if result.Data.Data == nil || len(result.Data.Data.Value) == 0 {
+		return nil, domain.NewNotFoundErrorf("secret not found: %s", reference)
+	}
+	return result.Data.Data.Value, nil
+}
+
+// Delete permanently removes every version of the secret
+func (b *KVBackend) Delete(ctx context.Context, reference string) error {
+	res, err := b.client.R().
+		SetContext(ctx).
+		Delete(b.config.GetMetadataPath(reference))
+	if err != nil {
+		return domain.NewSecretBackendUnavailableErrorf("failed to delete secret %s: %w", reference, err)
+	}
+	if res.IsError() {
+		return responseError(res, "delete secret %s", reference)
+	}
+	return nil
+}
+
+// Health checks that the vault is reachable, initialized and unsealed, standby nodes are healthy
+func (b *KVBackend) Health(ctx context.Context) error {
+	res, err := b.client.R().
+		SetContext(ctx).
+		SetQueryParam("standbyok", "true").
+		Get("/v1/sys/health")
+	if err != nil {
+		return domain.NewSecretBackendUnavailableErrorf("failed to reach vault: %w", err)
+	}
+	if res.StatusCode() != http.StatusOK {
+		return domain.NewSecretBackendUnavailableErrorf("vault is not healthy (status %d)", res.StatusCode())
+	}
+	return nil
+}
+
+// responseError converts an error response, server side failures, a sealed vault
+// and rate limiting are reported as unavailability so the caller can retry
+func responseError(res *resty.Response, format string, a ...any) error {
+	detail := res.String()
+	if body, ok := res.Error().(*kvErrorBody); ok && len(body.Errors) > 0 {
+		detail = strings.Join(body.Errors, "; ")
+	}
+	op := fmt.Sprintf(format, a...)
+	if res.StatusCode() >= http.StatusInternalServerError || res.StatusCode() == http.StatusTooManyRequests {
+		return domain.NewSecretBackendUnavailableErrorf("failed to %s (status %d): %s", op, res.StatusCode(), detail)
+	}
+	return fmt.Errorf("failed to %s (status %d): %s", op, res.StatusCode(), detail)
+}
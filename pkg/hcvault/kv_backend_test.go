@@ -0,0 +1,232 @@
+package hcvault
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/fulcrumproject/core/pkg/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testToken = "test-token"
+
+// fakeKV is an in-memory KV v2 engine mounted at "secret"
+type fakeKV struct {
+	mu       sync.Mutex
+	versions map[string][]json.RawMessage // Destroyed versions are nil
+	custom   map[string]map[string]string
+}
+
+func newFakeKV() *fakeKV {
+	return &fakeKV{versions: map[string][]json.RawMessage{}, custom: map[string]map[string]string{}}
+}
+
+func (f *fakeKV) handler(t *testing.T) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/secret/data/", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, testToken, r.Header.Get("X-Vault-Token"))
+		path := r.URL.Path[len("/v1/secret/data/"):]
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		switch r.Method {
+		case http.MethodPost:
+			var req kvWriteReq
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			if req.Options != nil && req.Options.CAS != len(f.versions[path]) {
+				w.WriteHeader(http.StatusBadRequest)
+				jsonResponse(w, kvErrorBody{Errors: []string{"check-and-set parameter did not match the current version"}})
+				return
+			}
+			f.versions[path] = append(f.versions[path], req.Data.Value)
+			jsonResponse(w, map[string]any{"data": map[string]any{"version": len(f.versions[path])}})
+		case http.MethodGet:
+			versions := f.versions[path]
+			version := len(versions)
+			if v := r.URL.Query().Get("version"); v != "" {
+				version, _ = strconv.Atoi(v)
+			}
+			if version == 0 || version > len(versions) {
+				w.WriteHeader(http.StatusNotFound)
+				jsonResponse(w, kvErrorBody{Errors: []string{}})
+				return
+			}
+			var data *kvData
+			if versions[version-1] != nil {
+				data = &kvData{Value: versions[version-1]}
+			}
+			jsonResponse(w, map[string]any{"data": map[string]any{
+				"data":     data,
+				"metadata": map[string]any{"version": version},
+			}})
+		}
+	})
+	mux.HandleFunc("/v1/secret/metadata/", func(w http.ResponseWriter, r *http.Request) {
+		path := r.URL.Path[len("/v1/secret/metadata/"):]
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		switch {
+		case r.Method == http.MethodGet && r.URL.Query().Get("list") == "true":
+			keys := []string{}
+			for key := range f.versions {
+				if rest, ok := strings.CutPrefix(key, path+"/"); ok {
+					keys = append(keys, rest)
+				}
+			}
+			if len(keys) == 0 {
+				w.WriteHeader(http.StatusNotFound)
+				jsonResponse(w, kvErrorBody{Errors: []string{}})
+				return
+			}
+			jsonResponse(w, map[string]any{"data": map[string]any{"keys": append(keys, "folder/")}})
+		case r.Method == http.MethodGet:
+			if len(f.versions[path]) == 0 {
+				w.WriteHeader(http.StatusNotFound)
+				jsonResponse(w, kvErrorBody{Errors: []string{}})
+				return
+			}
+			jsonResponse(w, map[string]any{"data": kvMetadata{CurrentVersion: len(f.versions[path]), CustomMetadata: f.custom[path]}})
+		case r.Method == http.MethodPost:
+			var req kvMetadataReq
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			f.custom[path] = req.CustomMetadata
+			w.WriteHeader(http.StatusNoContent)
+		case r.Method == http.MethodDelete:
+			delete(f.versions, path)
+			delete(f.custom, path)
+			w.WriteHeader(http.StatusNoContent)
+		}
+	})
+	mux.HandleFunc("POST /v1/secret/destroy/", func(w http.ResponseWriter, r *http.Request) {
+		path := r.URL.Path[len("/v1/secret/destroy/"):]
+		var req kvDestroyReq
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		for _, version := range req.Versions {
+			f.versions[path][version-1] = nil
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+	return mux
+}
+
+func jsonResponse(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+func setupTestBackend(t *testing.T, handler http.Handler) (*KVBackend, *httptest.Server) {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	return NewKVBackend(&Config{
+		Address:    server.URL,
+		Token:      testToken,
+		MountPath:  "secret",
+		PathPrefix: "fulcrum",
+	}), server
+}
+
+func TestKVBackend_PutGetDelete(t *testing.T) {
+	kv := newFakeKV()
+	backend, _ := setupTestBackend(t, kv.handler(t))
+	vault := domain.NewSecretVault(backend)
+	ctx := context.Background()
+
+	require.NoError(t, vault.Save(ctx, "ref1", map[string]any{"user": "admin"}, nil))
+	value, err := vault.Get(ctx, "ref1")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"user": "admin"}, value)
+
+	// Writes create new versions, reads return the latest one
+	require.NoError(t, vault.Save(ctx, "ref1", "rotated", nil))
+	assert.Len(t, kv.versions["fulcrum/ref1"], 2)
+	value, err = vault.Get(ctx, "ref1")
+	require.NoError(t, err)
+	assert.Equal(t, "rotated", value)
+
+	require.NoError(t, vault.Delete(ctx, "ref1"))
+	_, err = vault.Get(ctx, "ref1")
+	assert.ErrorAs(t, err, &domain.NotFoundError{})
+}
+
+func TestKVBackend_Errors(t *testing.T) {
+	tests := []struct {
+		name        string
+		status      int
+		unavailable bool
+	}{
+		{name: "Sealed vault", status: http.StatusServiceUnavailable, unavailable: true},
+		{name: "Internal error", status: http.StatusInternalServerError, unavailable: true},
+		{name: "Rate limited", status: http.StatusTooManyRequests, unavailable: true},
+		{name: "Permission denied", status: http.StatusForbidden, unavailable: false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			backend, _ := setupTestBackend(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(tc.status)
+				json.NewEncoder(w).Encode(kvErrorBody{Errors: []string{"backend error"}})
+			}))
+
+			_, err := backend.Get(context.Background(), "ref")
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "backend error")
+			assert.Equal(t, tc.unavailable, isUnavailable(err))
+
+			err = backend.Put(context.Background(), "ref", []byte(`"value"`))
+			require.Error(t, err)
+			assert.Equal(t, tc.unavailable, isUnavailable(err))
+		})
+	}
+
+	t.Run("Unreachable vault", func(t *testing.T) {
+		backend, server := setupTestBackend(t, http.NotFoundHandler())
+		server.Close()
+
+		_, err := backend.Get(context.Background(), "ref")
+		assert.True(t, isUnavailable(err))
+		assert.True(t, isUnavailable(backend.Put(context.Background(), "ref", []byte(`"value"`))))
+		assert.True(t, isUnavailable(backend.Delete(context.Background(), "ref")))
+	})
+}
+
+func isUnavailable(err error) bool {
+	var unavailable domain.SecretBackendUnavailableError
+	return err != nil && errors.As(err, &unavailable)
+}
+
+func TestKVBackend_Health(t *testing.T) {
+	t.Run("Unsealed vault", func(t *testing.T) {
+		backend, _ := setupTestBackend(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "/v1/sys/health", r.URL.Path)
+			assert.Equal(t, "true", r.URL.Query().Get("standbyok"))
+			jsonResponse(w, map[string]any{"initialized": true, "sealed": false})
+		}))
+		assert.NoError(t, backend.Health(context.Background()))
+	})
+
+	t.Run("Sealed vault", func(t *testing.T) {
+		backend, _ := setupTestBackend(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}))
+		err := backend.Health(context.Background())
+		assert.True(t, isUnavailable(err))
+		assert.Contains(t, err.Error(), "status 503")
+	})
+
+	t.Run("Unreachable vault", func(t *testing.T) {
+		backend, server := setupTestBackend(t, http.NotFoundHandler())
+		server.Close()
+		assert.True(t, isUnavailable(backend.Health(context.Background())))
+	})
+}
@@ -0,0 +1,270 @@
+package hcvault
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fulcrumproject/core/pkg/domain"
+)
+
+// graceKeyPrefix prefixes the custom metadata keys holding the end of the grace period of a previous version
+const graceKeyPrefix = "fulcrum_grace_until_v"
+
+type kvMetadata struct {
+	CurrentVersion int               `json:"current_version"`
+	CustomMetadata map[string]string `json:"custom_metadata"`
+}
+
+type kvMetadataRes struct {
+	Data kvMetadata `json:"data"`
+}
+
+type kvMetadataReq struct {
+	MaxVersions    int               `json:"max_versions,omitempty"`
+	CustomMetadata map[string]string `json:"custom_metadata"`
+}
+
+type kvDestroyReq struct {
+	Versions []int `json:"versions"`
+}
+
+type kvListRes struct {
+	Data struct {
+		Keys []string `json:"keys"`
+	} `json:"data"`
+}
+
+// Rotate writes a new version of the secret and records until when the replaced one is accepted
+//
+// The write uses check-and-set on the version read from the metadata so a concurrent write
+// fails the rotation, the engine retains keep previous versions and the older ones are destroyed.
+func (b *KVBackend) Rotate(ctx context.Context, reference string, data []byte, graceUntil time.Time, keep int) error {
+	metadata, err := b.getMetadata(ctx, reference)
+	if err != nil {
+		return err
+	}
+	previous := metadata.CurrentVersion
+
+	res, err := b.client.R().
+		SetContext(ctx).
+		SetBody(kvWriteReq{Options: &kvWriteOptions{CAS: previous}, Data: kvData{Value: data}}).
+		Post(b.config.GetDataPath(reference))
+	if err != nil {
+		return domain.NewSecretBackendUnavailableErrorf("failed to rotate secret %s: %w", reference, err)
+	}
+	if res.IsError() {
+		return responseError(res, "rotate secret %s", reference)
+	}
+
+	deadlines := graceDeadlines(metadata.CustomMetadata)
+	if keep > 0 {
+		deadlines[previous] = graceUntil
+	}
+	destroy := []int{}
+	for version := range deadlines {
+		if version <= previous-keep {
+			delete(deadlines, version)
+			destroy = append(destroy, version)
+		}
+	}
+	if keep == 0 {
+		destroy = append(destroy, previous)
+	}
+
+	if err := b.putMetadata(ctx, reference, keep+1, withGraceDeadlines(metadata.CustomMetadata, deadlines)); err != nil {
+		return err
+	}
+	return b.destroy(ctx, reference, destroy)
+}
+
+// GetValid reads the latest version and the previous versions still in their grace period
+func (b *KVBackend) GetValid(ctx context.Context, reference string, at time.Time) ([][]byte, error) {
+	metadata, err := b.getMetadata(ctx, reference)
+	if err != nil {
+		return nil, err
+	}
+	current, err := b.Get(ctx, reference)
+	if err != nil {
+		return nil, err
+	}
+
+	result := [][]byte{current}
+	for _, version := range validVersions(graceDeadlines(metadata.CustomMetadata), metadata.CurrentVersion, at) {
+		data, err := b.getVersion(ctx, reference, version)
+		// Versions destroyed outside of Fulcrum are no longer accepted
+		if errors.As(err, &domain.NotFoundError{}) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, data)
+	}
+	return result, nil
+}
+
+// PurgeExpired destroys the expired previous versions of every secret below the path prefix
+func (b *KVBackend) PurgeExpired(ctx context.Context, at time.Time) (int, error) {
+	var list kvListRes
+	res, err := b.client.R().
+		SetContext(ctx).
+		SetQueryParam("list", "true").
+		SetResult(&list).
+		Get(b.config.GetListPath())
+	if err != nil {
+		return 0, domain.NewSecretBackendUnavailableErrorf("failed to list secrets: %w", err)
+	}
+	if res.StatusCode() == http.StatusNotFound {
+		return 0, nil
+	}
+	if res.IsError() {
+		return 0, responseError(res, "list secrets")
+	}
+
+	purged := 0
+	var errs []error
+	for _, key := range list.Data.Keys {
+		// Folders are not created by Fulcrum
+		if strings.HasSuffix(key, "/") {
+			continue
+		}
+		count, err := b.purgeSecret(ctx, key, at)
+		purged += count
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return purged, errors.Join(errs...)
+}
+
+// purgeSecret destroys the expired previous versions of a secret and forgets their grace deadlines
+func (b *KVBackend) purgeSecret(ctx context.Context, reference string, at time.Time) (int, error) {
+	metadata, err := b.getMetadata(ctx, reference)
+	if err != nil {
+		return 0, err
+	}
+	deadlines := graceDeadlines(metadata.CustomMetadata)
+	expired := []int{}
+	for version, deadline := range deadlines {
+		if !deadline.After(at) {
+			delete(deadlines, version)
+			expired = append(expired, version)
+		}
+	}
+	if len(expired) == 0 {
+		return 0, nil
+	}
+
+	if err := b.destroy(ctx, reference, expired); err != nil {
+		return 0, err
+	}
+	if err := b.putMetadata(ctx, reference, 0, withGraceDeadlines(metadata.CustomMetadata, deadlines)); err != nil {
+		return 0, err
+	}
+	return len(expired), nil
+}
+
+func (b *KVBackend) getMetadata(ctx context.Context, reference string) (*kvMetadata, error) {
+	var result kvMetadataRes
+	res, err := b.client.R().
+		SetContext(ctx).
+		SetResult(&result).
+		Get(b.config.GetMetadataPath(reference))
+	if err != nil {
+		return nil, domain.NewSecretBackendUnavailableErrorf("failed to read secret metadata %s: %w", reference, err)
+	}
+	if res.StatusCode() == http.StatusNotFound {
+		return nil, domain.NewNotFoundErrorf("secret not found: %s", reference)
+	}
+	if res.IsError() {
+		return nil, responseError(res, "read secret metadata %s", reference)
+	}
+	return &result.Data, nil
+}
+
+// putMetadata replaces the custom metadata of the secret, maxVersions is left unchanged when 0
+func (b *KVBackend) putMetadata(ctx context.Context, reference string, maxVersions int, custom map[string]string) error {
+	res, err := b.client.R().
+		SetContext(ctx).
+		SetBody(kvMetadataReq{MaxVersions: maxVersions, CustomMetadata: custom}).
+		Post(b.config.GetMetadataPath(reference))
+	if err != nil {
+		return domain.NewSecretBackendUnavailableErrorf("failed to write secret metadata %s: %w", reference, err)
+	}
+	if res.IsError() {
+		return responseError(res, "write secret metadata %s", reference)
+	}
+	return nil
+}
+
+func (b *KVBackend) destroy(ctx context.Context, reference string, versions []int) error {
+	if len(versions) == 0 {
+		return nil
+	}
+	slices.Sort(versions)
+	res, err := b.client.R().
+		SetContext(ctx).
+		SetBody(kvDestroyReq{Versions: versions}).
+		Post(b.config.GetDestroyPath(reference))
+	if err != nil {
+		return domain.NewSecretBackendUnavailableErrorf("failed to destroy secret versions %s: %w", reference, err)
+	}
+	if res.IsError() {
+		return responseError(res, "destroy secret versions %s", reference)
+	}
+	return nil
+}
+
+// graceDeadlines parses the grace deadlines recorded in the custom metadata, ignoring unrelated keys
+func graceDeadlines(custom map[string]string) map[int]time.Time {
+	deadlines := map[int]time.Time{}
+	for key, value := range custom {
+		suffix, ok := strings.CutPrefix(key, graceKeyPrefix)
+		if !ok {
+			continue
+		}
+		version, err := strconv.Atoi(suffix)
+		if err != nil {
+			continue
+		}
+		deadline, err := time.Parse(time.RFC3339Nano, value)
+		if err != nil {
+			continue
+		}
+		deadlines[version] = deadline
+	}
+	return deadlines
+}
+
+// withGraceDeadlines returns the custom metadata with its grace deadlines replaced by the given ones
+func withGraceDeadlines(custom map[string]string, deadlines map[int]time.Time) map[string]string {
+	result := map[string]string{}
+	for key, value := range custom {
+		if !strings.HasPrefix(key, graceKeyPrefix) {
+			result[key] = value
+		}
+	}
+	for version, deadline := range deadlines {
+		result[fmt.Sprintf("%s%d", graceKeyPrefix, version)] = deadline.UTC().Format(time.RFC3339Nano)
+	}
+	return result
+}
+
+// validVersions returns the previous versions not expired at the given time, newest first
+func validVersions(deadlines map[int]time.Time, current int, at time.Time) []int {
+	versions := []int{}
+	for version, deadline := range deadlines {
+		if version < current && deadline.After(at) {
+			versions = append(versions, version)
+		}
+	}
+	slices.Sort(versions)
+	slices.Reverse(versions)
+	return versions
+}
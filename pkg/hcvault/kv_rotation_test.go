@@ -0,0 +1,90 @@
+package hcvault
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/fulcrumproject/core/pkg/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKVBackend_Rotate(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	kv := newFakeKV()
+	kv.custom["fulcrum/ref1"] = map[string]string{"owner": "team-a"}
+	backend, _ := setupTestBackend(t, kv.handler(t))
+	require.NoError(t, backend.Put(ctx, "ref1", []byte(`"v1"`)))
+
+	require.NoError(t, backend.Rotate(ctx, "ref1", []byte(`"v2"`), now.Add(time.Hour), 2))
+	require.NoError(t, backend.Rotate(ctx, "ref1", []byte(`"v3"`), now.Add(2*time.Hour), 2))
+
+	valid, err := backend.GetValid(ctx, "ref1", now)
+	require.NoError(t, err)
+	assert.Equal(t, [][]byte{[]byte(`"v3"`), []byte(`"v2"`), []byte(`"v1"`)}, valid)
+	assert.Equal(t, "team-a", kv.custom["fulcrum/ref1"]["owner"], "unrelated custom metadata is kept")
+
+	// Only the versions still in their grace period are accepted
+	valid, err = backend.GetValid(ctx, "ref1", now.Add(90*time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, [][]byte{[]byte(`"v3"`), []byte(`"v2"`)}, valid)
+
+	// A third rotation drops the oldest version beyond the retained ones
+	require.NoError(t, backend.Rotate(ctx, "ref1", []byte(`"v4"`), now.Add(3*time.Hour), 2))
+	assert.Nil(t, kv.versions["fulcrum/ref1"][0])
+	valid, err = backend.GetValid(ctx, "ref1", now)
+	require.NoError(t, err)
+	assert.Equal(t, [][]byte{[]byte(`"v4"`), []byte(`"v3"`), []byte(`"v2"`)}, valid)
+}
+
+func TestKVBackend_RotateWithoutPreviousVersions(t *testing.T) {
+	ctx := context.Background()
+	kv := newFakeKV()
+	backend, _ := setupTestBackend(t, kv.handler(t))
+	require.NoError(t, backend.Put(ctx, "ref1", []byte(`"v1"`)))
+
+	require.NoError(t, backend.Rotate(ctx, "ref1", []byte(`"v2"`), time.Now().Add(time.Hour), 0))
+
+	valid, err := backend.GetValid(ctx, "ref1", time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, [][]byte{[]byte(`"v2"`)}, valid)
+	assert.Nil(t, kv.versions["fulcrum/ref1"][0])
+}
+
+func TestKVBackend_RotateNotFound(t *testing.T) {
+	backend, _ := setupTestBackend(t, newFakeKV().handler(t))
+
+	err := backend.Rotate(context.Background(), "missing", []byte(`"v2"`), time.Now(), 1)
+	assert.ErrorAs(t, err, &domain.NotFoundError{})
+}
+
+func TestKVBackend_PurgeExpired(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	kv := newFakeKV()
+	backend, _ := setupTestBackend(t, kv.handler(t))
+
+	count, err := backend.PurgeExpired(ctx, now)
+	require.NoError(t, err)
+	assert.Equal(t, 0, count, "no secrets stored yet")
+
+	require.NoError(t, backend.Put(ctx, "ref1", []byte(`"a1"`)))
+	require.NoError(t, backend.Rotate(ctx, "ref1", []byte(`"a2"`), now.Add(-time.Minute), 3))
+	require.NoError(t, backend.Rotate(ctx, "ref1", []byte(`"a3"`), now.Add(time.Hour), 3))
+	require.NoError(t, backend.Put(ctx, "ref2", []byte(`"b1"`)))
+
+	count, err = backend.PurgeExpired(ctx, now)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+	assert.Nil(t, kv.versions["fulcrum/ref1"][0])
+	assert.NotNil(t, kv.versions["fulcrum/ref1"][1])
+	assert.Equal(t, map[string]string{graceKeyPrefix + "2": now.Add(time.Hour).Format(time.RFC3339Nano)}, kv.custom["fulcrum/ref1"])
+
+	count, err = backend.PurgeExpired(ctx, now)
+	require.NoError(t, err)
+	assert.Equal(t, 0, count)
+}
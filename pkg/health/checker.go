@@ -3,6 +3,8 @@ package health
 import (
 	"context"
 	"fmt"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/fulcrumproject/core/pkg/auth"
@@ -19,6 +21,14 @@ const (
 
 // CheckResult represents the result of a health check
 type CheckResult struct {
+	Status       Status             `json:"status"`
+	Error        string             `json:"error,omitempty"`
+	Dependencies []DependencyResult `json:"dependencies,omitempty"`
+}
+
+// DependencyResult represents the result of the check of a single dependency
+type DependencyResult struct {
+	Name   string `json:"name"`
 	Status Status `json:"status"`
 	Error  string `json:"error,omitempty"`
 }
@@ -29,10 +39,20 @@ type Checker interface {
 	CheckReadiness(ctx context.Context) CheckResult
 }
 
+// Dependency is an external service checked by the readiness
+type Dependency interface {
+	Health(ctx context.Context) error
+}
+
+// DefaultCheckTimeout is the longest time a dependency check takes when no timeout is configured
+const DefaultCheckTimeout = 2 * time.Second
+
 // PrimaryDependencies holds references to primary dependencies
 type PrimaryDependencies struct {
 	DB             *gorm.DB
 	Authenticators []auth.Authenticator
+	SecretBackend  Dependency    // Nil when the secrets are kept in the database
+	Timeout        time.Duration // Longest time a single dependency check takes
 }
 
 // HealthChecker implements the Checker interface
@@ -47,36 +67,79 @@ func NewHealthChecker(deps *PrimaryDependencies) *HealthChecker {
 	}
 }
 
-// CheckHealth performs health checks on primary dependencies
+// CheckHealth reports the liveness of the process, it does not depend on the dependencies
+// so that a dependency outage does not get the process restarted
 func (h *HealthChecker) CheckHealth(ctx context.Context) CheckResult {
-	return h.checkPrimaryDependencies(ctx)
+	return CheckResult{Status: StatusUP}
 }
 
-// CheckReadiness performs readiness checks on primary dependencies
+// CheckReadiness checks the primary dependencies and reports the status of each of them
 func (h *HealthChecker) CheckReadiness(ctx context.Context) CheckResult {
 	return h.checkPrimaryDependencies(ctx)
 }
 
-// checkPrimaryDependencies checks all primary dependencies
+// dependencyCheck is the check of a dependency with the prefix of its failures in the aggregated error
+type dependencyCheck struct {
+	name   string
+	prefix string
+	check  func(ctx context.Context) error
+}
+
+// checkPrimaryDependencies checks all primary dependencies concurrently
 func (h *HealthChecker) checkPrimaryDependencies(ctx context.Context) CheckResult {
-	// Check database connectivity
-	if err := h.checkDatabase(ctx); err != nil {
-		return CheckResult{
-			Status: StatusDOWN,
-			Error:  fmt.Sprintf("Database check failed: %v", err),
-		}
+	checks := []dependencyCheck{
+		{name: "database", prefix: "Database check failed", check: h.checkDatabase},
+		{name: "authentication", prefix: "Authentication check failed", check: h.checkAuthentication},
+	}
+	if h.deps.SecretBackend != nil {
+		checks = append(checks, dependencyCheck{name: "secretBackend", prefix: "Secret backend check failed", check: h.deps.SecretBackend.Health})
 	}
 
-	// Check authentication services
-	if err := h.checkAuthentication(ctx); err != nil {
-		return CheckResult{
-			Status: StatusDOWN,
-			Error:  fmt.Sprintf("Authentication check failed: %v", err),
+	errs := make([]error, len(checks))
+	var wg sync.WaitGroup
+	for i, c := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = h.checkWithTimeout(ctx, c.check)
+		}()
+	}
+	wg.Wait()
+
+	result := CheckResult{Status: StatusUP, Dependencies: make([]DependencyResult, len(checks))}
+	var failures []string
+	for i, c := range checks {
+		result.Dependencies[i] = DependencyResult{Name: c.name, Status: StatusUP}
+		if errs[i] != nil {
+			result.Status = StatusDOWN
+			result.Dependencies[i].Status = StatusDOWN
+			result.Dependencies[i].Error = errs[i].Error()
+			failures = append(failures, fmt.Sprintf("%s: %v", c.prefix, errs[i]))
 		}
 	}
+	result.Error = strings.Join(failures, "; ")
+	return result
+}
+
+// checkWithTimeout runs the check with the dependency timeout, it returns on the timeout even
+// when the check does not honor the cancellation of its context
+func (h *HealthChecker) checkWithTimeout(ctx context.Context, check func(ctx context.Context) error) error {
+	timeout := h.deps.Timeout
+	if timeout <= 0 {
+		timeout = DefaultCheckTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
 
-	return CheckResult{
-		Status: StatusUP,
+	done := make(chan error, 1)
+	go func() {
+		done <- check(ctx)
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return fmt.Errorf("check timed out after %s", timeout)
 	}
 }
 
@@ -92,11 +155,7 @@ func (h *HealthChecker) checkDatabase(ctx context.Context) error {
 		return fmt.Errorf("failed to get underlying database: %w", err)
 	}
 
-	// Create a context with timeout for the ping
-	pingCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
-	defer cancel()
-
-	if err := sqlDB.PingContext(pingCtx); err != nil {
+	if err := sqlDB.PingContext(ctx); err != nil {
 		return fmt.Errorf("database ping failed: %w", err)
 	}
 
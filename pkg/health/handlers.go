@@ -3,6 +3,7 @@ package health
 import (
 	"context"
 	"net/http"
+	"sync/atomic"
 	"time"
 
 	"github.com/go-chi/render"
@@ -10,12 +11,30 @@ import (
 
 // Res represents the HTTP response for health endpoints
 type Res struct {
+	Status       string          `json:"status"`
+	Dependencies []DependencyRes `json:"dependencies,omitempty"`
+}
+
+// DependencyRes represents the status of a dependency in the readiness response
+type DependencyRes struct {
+	Name   string `json:"name"`
 	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// resFromResult converts a check result to its response
+func resFromResult(result CheckResult) Res {
+	res := Res{Status: string(result.Status)}
+	for _, dep := range result.Dependencies {
+		res.Dependencies = append(res.Dependencies, DependencyRes{Name: dep.Name, Status: string(dep.Status), Error: dep.Error})
+	}
+	return res
 }
 
 // Handler provides HTTP handlers for health endpoints
 type Handler struct {
-	checker Checker
+	checker  Checker
+	draining atomic.Bool
 }
 
 // NewHandler creates a new health handler
@@ -32,9 +51,7 @@ func (h *Handler) HealthHandler(w http.ResponseWriter, r *http.Request) {
 
 	result := h.checker.CheckHealth(ctx)
 
-	response := Res{
-		Status: string(result.Status),
-	}
+	response := resFromResult(result)
 
 	if result.Status == StatusUP {
 		w.WriteHeader(http.StatusOK)
@@ -45,17 +62,24 @@ func (h *Handler) HealthHandler(w http.ResponseWriter, r *http.Request) {
 	render.JSON(w, r, response)
 }
 
+// Drain reports the server as not ready from now on, so the load balancers stop sending it traffic
+// while it shuts down; the liveness is not affected
+func (h *Handler) Drain() {
+	h.draining.Store(true)
+}
+
 // ReadinessHandler handles GET /ready requests
 func (h *Handler) ReadinessHandler(w http.ResponseWriter, r *http.Request) {
 	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
 	defer cancel()
 
-	result := h.checker.CheckReadiness(ctx)
-
-	response := Res{
-		Status: string(result.Status),
+	result := CheckResult{Status: StatusDOWN}
+	if !h.draining.Load() {
+		result = h.checker.CheckReadiness(ctx)
 	}
 
+	response := resFromResult(result)
+
 	if result.Status == StatusUP {
 		w.WriteHeader(http.StatusOK)
 	} else {
@@ -122,3 +122,25 @@ func TestReadinessHandler_DOWN(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, "DOWN", response.Status)
 }
+
+func TestReadinessHandler_Draining(t *testing.T) {
+	mockChecker := &MockChecker{
+		healthResult:    CheckResult{Status: StatusUP},
+		readinessResult: CheckResult{Status: StatusUP},
+	}
+	handler := NewHandler(mockChecker)
+	handler.Drain()
+
+	w := httptest.NewRecorder()
+	handler.ReadinessHandler(w, httptest.NewRequest(http.MethodGet, "/ready", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+
+	var response Res
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, "DOWN", response.Status)
+
+	// The server is still alive while draining
+	w = httptest.NewRecorder()
+	handler.HealthHandler(w, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+}
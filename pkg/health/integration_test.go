@@ -42,14 +42,14 @@ func TestHealthEndpoints_Integration(t *testing.T) {
 		{
 			name:           "Health endpoint with unhealthy dependencies",
 			endpoint:       "/healthz",
-			expectedStatus: http.StatusServiceUnavailable,
-			expectedBody:   `{"status":"DOWN"}`,
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"status":"UP"}`,
 		},
 		{
 			name:           "Readiness endpoint with unhealthy dependencies",
 			endpoint:       "/ready",
 			expectedStatus: http.StatusServiceUnavailable,
-			expectedBody:   `{"status":"DOWN"}`,
+			expectedBody:   `{"status":"DOWN","dependencies":[{"name":"database","status":"DOWN","error":"database connection is nil"},{"name":"authentication","status":"UP"}]}`,
 		},
 	}
 
@@ -66,6 +66,7 @@ func TestHealthEndpoints_Integration(t *testing.T) {
 			assert.Equal(t, tt.expectedStatus, w.Code)
 
 			// Assert response body
+			assert.JSONEq(t, tt.expectedBody, w.Body.String())
 			var response Res
 			err := json.Unmarshal(w.Body.Bytes(), &response)
 			require.NoError(t, err)
@@ -1,6 +1,10 @@
 package helpers
 
-import "github.com/fulcrumproject/core/pkg/properties"
+import (
+	"time"
+
+	"github.com/fulcrumproject/core/pkg/properties"
+)
 
 // StringPtr returns a pointer to the given string
 func StringPtr(s string) *string {
@@ -12,11 +16,21 @@ func IntPtr(i int) *int {
 	return &i
 }
 
+// Int64Ptr returns a pointer to the given int64
+func Int64Ptr(i int64) *int64 {
+	return &i
+}
+
 // BoolPtr returns a pointer to the given bool
 func BoolPtr(b bool) *bool {
 	return &b
 }
 
+// FloatPtr returns a pointer to the given float64
+func FloatPtr(f float64) *float64 {
+	return &f
+}
+
 // JSONPtr returns a pointer to the given JSON
 func JSONPtr(j properties.JSON) *properties.JSON {
 	return &j
@@ -26,3 +40,8 @@ func JSONPtr(j properties.JSON) *properties.JSON {
 func UUIDPtr(u properties.UUID) *properties.UUID {
 	return &u
 }
+
+// DurationPtr returns a pointer to the given duration
+func DurationPtr(d time.Duration) *time.Duration {
+	return &d
+}
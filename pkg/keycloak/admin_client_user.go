@@ -332,7 +332,7 @@ func setAttribute(attrs map[string][]string, key string, val *string) {
 
 func (a *AdminClient) compensatingDelete(ctx context.Context, userID string) {
 	if err := a.Delete(ctx, userID); err != nil {
-		slog.Error("failed compensating delete of keycloak user", "userID", userID, "error", err)
+		slog.ErrorContext(ctx, "failed compensating delete of keycloak user", "userID", userID, "error", err)
 	}
 }
 
@@ -32,11 +32,13 @@ type Authenticator struct {
 	config   *Config
 	provider *oidc.Provider
 	verifier *oidc.IDTokenVerifier
+	groups   *GroupMapper // Maps the groups claim to roles, nil when the roles are read from the role claims
+	client   *http.Client // Client reaching the provider, used by the health check
 }
 
 // NewAuthenticator creates a new OIDC JWT authenticator for Keycloak
 func NewAuthenticator(ctx context.Context, cfg *Config) (*Authenticator, error) {
-
+	client := http.DefaultClient
 	if cfg.InsecureSkipVerify {
 		customClient := &http.Client{
 			Transport: &http.Transport{
@@ -47,6 +49,7 @@ func NewAuthenticator(ctx context.Context, cfg *Config) (*Authenticator, error)
 		}
 
 		ctx = oidc.ClientContext(ctx, customClient)
+		client = customClient
 	}
 
 	if !cfg.ValidateIssuer {
@@ -73,13 +76,30 @@ func NewAuthenticator(ctx context.Context, cfg *Config) (*Authenticator, error)
 
 	verifier := provider.Verifier(verifierConfig)
 
+	var groups *GroupMapper
+	if cfg.GroupMappingFile != "" {
+		if groups, err = NewGroupMapper(cfg.GroupMappingFile); err != nil {
+			return nil, err
+		}
+	}
+
 	return &Authenticator{
 		config:   cfg,
 		provider: provider,
 		verifier: verifier,
+		groups:   groups,
+		client:   client,
 	}, nil
 }
 
+// ReloadGroupMapping reloads the group mapping file, it does nothing when no mapping is configured
+func (a *Authenticator) ReloadGroupMapping() error {
+	if a.groups == nil {
+		return nil
+	}
+	return a.groups.Reload()
+}
+
 // Authenticate extracts and validates the JWT token against Keycloak
 // Returns nil if authentication fails
 func (a *Authenticator) Authenticate(ctx context.Context, tokenString string) (*auth.Identity, error) {
@@ -89,28 +109,49 @@ func (a *Authenticator) Authenticate(ctx context.Context, tokenString string) (*
 		return nil, err
 	}
 
-	// Parse and validate the subject as UUID (identity ID)
-	id, err := properties.ParseUUID(idToken.Subject)
-	if err != nil {
-		return nil, err
-	}
-
-	// Extract custom claims
+	// Extract custom claims, the raw ones hold the configurable groups and participant claims
 	var claims Claims
 	if err := idToken.Claims(&claims); err != nil {
 		return nil, err
 	}
+	var raw map[string]any
+	if err := idToken.Claims(&raw); err != nil {
+		return nil, err
+	}
 
-	// Extract role from custom claim or realm roles
-	role, err := a.extractRole(&claims)
+	return a.identityFromClaims(idToken.Subject, &claims, raw)
+}
+
+// identityFromClaims builds the identity of the subject of a verified token
+func (a *Authenticator) identityFromClaims(subject string, claims *Claims, raw map[string]any) (*auth.Identity, error) {
+	// Parse and validate the subject as UUID (identity ID)
+	id, err := properties.ParseUUID(subject)
 	if err != nil {
 		return nil, err
 	}
 
+	// Extract the roles from the mapped groups, or the role from custom claim or realm roles
+	var role auth.Role
+	var additionalRoles []auth.Role
+	if a.groups != nil {
+		roles := a.groups.Roles(claimStrings(raw, a.config.GetGroupsClaim()))
+		if len(roles) == 0 {
+			return nil, errors.New("no role mapped to the groups of the user")
+		}
+		role, additionalRoles = roles[0], roles[1:]
+	} else {
+		if role, err = a.extractRole(claims); err != nil {
+			return nil, err
+		}
+	}
+
 	// Parse optional participant ID
 	var participantID *properties.UUID
-	if claims.ParticipantID != "" {
-		pid, err := properties.ParseUUID(claims.ParticipantID)
+	if claim := claimStrings(raw, a.config.GetParticipantClaim()); len(claim) > 0 {
+		if len(claim) > 1 {
+			return nil, fmt.Errorf("claim %s must hold a single participant ID", a.config.GetParticipantClaim())
+		}
+		pid, err := properties.ParseUUID(claim[0])
 		if err != nil {
 			return nil, err
 		}
@@ -133,14 +174,15 @@ func (a *Authenticator) Authenticate(ctx context.Context, tokenString string) (*
 		name = claims.PreferredUsername
 	}
 	if name == "" {
-		name = idToken.Subject // Fallback to subject if no name available
+		name = subject // Fallback to subject if no name available
 	}
 
 	// Create the identity
 	identity := &auth.Identity{
-		ID:   id,
-		Name: name,
-		Role: role,
+		ID:              id,
+		Name:            name,
+		Role:            role,
+		AdditionalRoles: additionalRoles,
 		Scope: auth.IdentityScope{
 			ParticipantID: participantID,
 			AgentID:       agentID,
@@ -186,7 +228,27 @@ func (a *Authenticator) extractRole(claims *Claims) (auth.Role, error) {
 	return "", errors.New("no valid role found in token")
 }
 
-// Health checks if the Keycloak/OIDC provider is accessible
+// claimStrings returns the values of a string or string array claim, ignoring empty values
+func claimStrings(raw map[string]any, name string) []string {
+	switch v := raw[name].(type) {
+	case string:
+		if v != "" {
+			return []string{v}
+		}
+	case []any:
+		values := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok && s != "" {
+				values = append(values, s)
+			}
+		}
+		return values
+	}
+	return nil
+}
+
+// Health checks if the Keycloak/OIDC provider is accessible by fetching its signing keys,
+// the call is bounded by the deadline of the context
 func (a *Authenticator) Health(ctx context.Context) error {
 	if a.provider == nil {
 		return fmt.Errorf("OIDC provider is not initialized")
@@ -196,13 +258,24 @@ func (a *Authenticator) Health(ctx context.Context) error {
 		return fmt.Errorf("OIDC verifier is not initialized")
 	}
 
-	// Try to fetch the provider's configuration to verify connectivity
-	// This is a lightweight check that verifies the provider is reachable
-	// without requiring authentication
-	endpoint := a.provider.Endpoint()
-	if endpoint.AuthURL == "" {
-		return fmt.Errorf("OIDC provider endpoint is not configured")
+	var claims struct {
+		JWKSURL string `json:"jwks_uri"`
+	}
+	if err := a.provider.Claims(&claims); err != nil || claims.JWKSURL == "" {
+		return fmt.Errorf("OIDC provider JWKS endpoint is not configured")
 	}
 
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, claims.JWKSURL, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create JWKS request: %w", err)
+	}
+	res, err := a.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("OIDC provider JWKS endpoint is not reachable: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("OIDC provider JWKS endpoint returned status %d", res.StatusCode)
+	}
 	return nil
 }
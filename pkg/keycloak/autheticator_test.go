@@ -4,7 +4,9 @@ import (
 	"testing"
 
 	"github.com/fulcrumproject/core/pkg/auth"
+	"github.com/fulcrumproject/core/pkg/properties"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestAuthenticator_extractRole(t *testing.T) {
@@ -203,3 +205,94 @@ func TestAuthenticator_extractRole(t *testing.T) {
 		})
 	}
 }
+
+func TestAuthenticator_identityFromClaims_GroupMapping(t *testing.T) {
+	subject := properties.NewUUID()
+	participantID := properties.NewUUID()
+	authenticator := &Authenticator{
+		config: &Config{ClientID: "test-client", GroupsClaim: "teams", ParticipantClaim: "tenant"},
+		groups: &GroupMapper{},
+	}
+	authenticator.groups.mapping.Store(&GroupMapping{
+		"/admins":    {auth.RoleAdmin},
+		"/customers": {auth.RoleParticipant},
+	})
+
+	tests := []struct {
+		name            string
+		claims          *Claims
+		raw             map[string]any
+		expectedRole    auth.Role
+		expectedRoles   []auth.Role
+		expectedScope   *properties.UUID
+		expectedErrText string
+	}{
+		{
+			name:          "Union of the mapped groups",
+			raw:           map[string]any{"teams": []any{"/customers", "/admins"}, "tenant": participantID.String()},
+			expectedRole:  auth.RoleAdmin,
+			expectedRoles: []auth.Role{auth.RoleParticipant},
+			expectedScope: &participantID,
+		},
+		{
+			name:         "Single group as string",
+			raw:          map[string]any{"teams": "/admins"},
+			expectedRole: auth.RoleAdmin,
+		},
+		{
+			name:            "No matching group is denied",
+			claims:          &Claims{Role: "admin"},
+			raw:             map[string]any{"teams": []any{"/other"}, "role": "admin"},
+			expectedErrText: "no role mapped",
+		},
+		{
+			name:            "No groups claim is denied",
+			raw:             map[string]any{},
+			expectedErrText: "no role mapped",
+		},
+		{
+			name:            "Participant role requires the scope claim",
+			raw:             map[string]any{"teams": []any{"/customers"}, "participant_id": participantID.String()},
+			expectedErrText: "participant role requires participant id",
+		},
+		{
+			name:            "Invalid scope claim",
+			raw:             map[string]any{"teams": []any{"/customers"}, "tenant": "not-a-uuid"},
+			expectedErrText: "invalid",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims := tt.claims
+			if claims == nil {
+				claims = &Claims{}
+			}
+			identity, err := authenticator.identityFromClaims(subject.String(), claims, tt.raw)
+			if tt.expectedErrText != "" {
+				assert.ErrorContains(t, err, tt.expectedErrText)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, subject, identity.ID)
+			assert.Equal(t, tt.expectedRole, identity.Role)
+			assert.ElementsMatch(t, tt.expectedRoles, identity.AdditionalRoles)
+			assert.Equal(t, tt.expectedScope, identity.Scope.ParticipantID)
+		})
+	}
+}
+
+func TestAuthenticator_identityFromClaims_RoleClaims(t *testing.T) {
+	subject := properties.NewUUID()
+	participantID := properties.NewUUID()
+	authenticator := &Authenticator{config: &Config{ClientID: "test-client"}}
+
+	identity, err := authenticator.identityFromClaims(subject.String(),
+		&Claims{Role: "participant", PreferredUsername: "jdoe"},
+		map[string]any{"participant_id": participantID.String()})
+	require.NoError(t, err)
+	assert.Equal(t, auth.RoleParticipant, identity.Role)
+	assert.Empty(t, identity.AdditionalRoles)
+	assert.Equal(t, "jdoe", identity.Name)
+	assert.Equal(t, &participantID, identity.Scope.ParticipantID)
+}
@@ -11,6 +11,25 @@ type Config struct {
 	ValidateIssuer     bool   `json:"validateIssuer" env:"OAUTH_VALIDATE_ISSUER"`
 	InsecureSkipVerify bool   `json:"insecureSkipVerify" env:"OAUTH_INSECURE_SKIP_VERIFY"`
 	RestyDebug         bool   `json:"restyDebug" env:"OAUTH_RESTY_DEBUG"`
+	GroupMappingFile   string `json:"groupMappingFile" env:"OAUTH_GROUP_MAPPING_FILE"`
+	GroupsClaim        string `json:"groupsClaim" env:"OAUTH_GROUPS_CLAIM"`
+	ParticipantClaim   string `json:"participantClaim" env:"OAUTH_PARTICIPANT_CLAIM"`
+}
+
+// GetGroupsClaim returns the claim holding the groups of the user, "groups" by default
+func (c *Config) GetGroupsClaim() string {
+	if c.GroupsClaim == "" {
+		return "groups"
+	}
+	return c.GroupsClaim
+}
+
+// GetParticipantClaim returns the claim holding the participant scope, "participant_id" by default
+func (c *Config) GetParticipantClaim() string {
+	if c.ParticipantClaim == "" {
+		return "participant_id"
+	}
+	return c.ParticipantClaim
 }
 
 // GetJWKSURL returns the JWKS endpoint URL for the Keycloak realm
@@ -0,0 +1,103 @@
+package keycloak
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"sync/atomic"
+
+	"github.com/fulcrumproject/core/pkg/auth"
+)
+
+// GroupMapping maps the values of the groups claim to the roles granted to their members
+type GroupMapping map[string][]auth.Role
+
+// Validate ensures every mapped role is a predefined or registered custom role
+func (m GroupMapping) Validate() error {
+	for group, roles := range m {
+		if len(roles) == 0 {
+			return fmt.Errorf("group %q has no roles", group)
+		}
+		for _, role := range roles {
+			if err := role.Validate(); err != nil {
+				return fmt.Errorf("group %q: %w", group, err)
+			}
+		}
+	}
+	return nil
+}
+
+// Roles returns the union of the roles mapped to the groups, most privileged predefined roles first
+func (m GroupMapping) Roles(groups []string) []auth.Role {
+	var roles []auth.Role
+	for _, group := range groups {
+		for _, role := range m[group] {
+			if !slices.Contains(roles, role) {
+				roles = append(roles, role)
+			}
+		}
+	}
+	slices.SortFunc(roles, func(a, b auth.Role) int {
+		if pa, pb := rolePriority(a), rolePriority(b); pa != pb {
+			return pa - pb
+		}
+		return cmp.Compare(a, b)
+	})
+	return roles
+}
+
+// rolePriority orders the predefined roles before the custom ones
+func rolePriority(role auth.Role) int {
+	switch role {
+	case auth.RoleAdmin:
+		return 0
+	case auth.RoleParticipant:
+		return 1
+	case auth.RoleAgent:
+		return 2
+	default:
+		return 3
+	}
+}
+
+// GroupMapper holds the group mapping loaded from a JSON file
+//
+// The mapping can be reloaded while requests are authenticated, a mapping that
+// fails to load or validate leaves the current one in place.
+type GroupMapper struct {
+	path    string
+	mapping atomic.Pointer[GroupMapping]
+}
+
+// NewGroupMapper creates a mapper and loads the mapping from the file
+func NewGroupMapper(path string) (*GroupMapper, error) {
+	m := &GroupMapper{path: path}
+	if err := m.Reload(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Reload reads the mapping file again and replaces the current mapping
+func (m *GroupMapper) Reload() error {
+	data, err := os.ReadFile(m.path)
+	if err != nil {
+		return fmt.Errorf("failed to read group mapping: %w", err)
+	}
+	var mapping GroupMapping
+	if err := json.Unmarshal(data, &mapping); err != nil {
+		return fmt.Errorf("failed to parse group mapping %s: %w", m.path, err)
+	}
+	if err := mapping.Validate(); err != nil {
+		return fmt.Errorf("invalid group mapping %s: %w", m.path, err)
+	}
+	m.mapping.Store(&mapping)
+	return nil
+}
+
+// Roles returns the roles granted by the current mapping to the members of the groups
+func (m *GroupMapper) Roles(groups []string) []auth.Role {
+	return m.mapping.Load().Roles(groups)
+}
@@ -0,0 +1,69 @@
+package keycloak
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/fulcrumproject/core/pkg/auth"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGroupMapping_Roles(t *testing.T) {
+	auth.RegisterCustomRoles("auditor")
+	mapping := GroupMapping{
+		"/auditors":      {"auditor"},
+		"/operators":     {auth.RoleParticipant, "auditor"},
+		"/fulcrum-admin": {auth.RoleAdmin},
+	}
+
+	tests := []struct {
+		name     string
+		groups   []string
+		expected []auth.Role
+	}{
+		{name: "No groups", groups: nil, expected: nil},
+		{name: "Unmapped groups", groups: []string{"/other"}, expected: nil},
+		{name: "Single group", groups: []string{"/auditors"}, expected: []auth.Role{"auditor"}},
+		{
+			name:     "Union of the groups, predefined roles first",
+			groups:   []string{"/auditors", "/other", "/operators", "/fulcrum-admin"},
+			expected: []auth.Role{auth.RoleAdmin, auth.RoleParticipant, "auditor"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, mapping.Roles(tt.groups))
+		})
+	}
+}
+
+func TestGroupMapping_Validate(t *testing.T) {
+	assert.NoError(t, GroupMapping{"/admins": {auth.RoleAdmin}}.Validate())
+	assert.ErrorContains(t, GroupMapping{"/admins": {}}.Validate(), "has no roles")
+	assert.ErrorContains(t, GroupMapping{"/admins": {"unknown"}}.Validate(), "invalid auth role")
+}
+
+func TestGroupMapper_Reload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "groups.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"/admins": ["admin"]}`), 0o600))
+
+	mapper, err := NewGroupMapper(path)
+	require.NoError(t, err)
+	assert.Equal(t, []auth.Role{auth.RoleAdmin}, mapper.Roles([]string{"/admins"}))
+
+	// A valid mapping replaces the current one
+	require.NoError(t, os.WriteFile(path, []byte(`{"/admins": ["participant"]}`), 0o600))
+	require.NoError(t, mapper.Reload())
+	assert.Equal(t, []auth.Role{auth.RoleParticipant}, mapper.Roles([]string{"/admins"}))
+
+	// An invalid mapping keeps the current one
+	require.NoError(t, os.WriteFile(path, []byte(`{"/admins": ["unknown"]}`), 0o600))
+	assert.Error(t, mapper.Reload())
+	assert.Equal(t, []auth.Role{auth.RoleParticipant}, mapper.Roles([]string{"/admins"}))
+
+	_, err = NewGroupMapper(filepath.Join(t.TempDir(), "missing.json"))
+	assert.Error(t, err)
+}
@@ -9,6 +9,7 @@ import (
 
 	"github.com/fulcrumproject/core/pkg/auth"
 	"github.com/fulcrumproject/core/pkg/authz"
+	"github.com/fulcrumproject/core/pkg/domain"
 	"github.com/fulcrumproject/core/pkg/properties"
 	"github.com/fulcrumproject/core/pkg/response"
 	"github.com/go-chi/render"
@@ -72,6 +73,11 @@ func AuthzFromExtractor(
 			// Extract scope using the provided extractor
 			scope, err := extractor(r)
 			if err != nil {
+				// A missing resource is reported as such, so clients can tell it is gone
+				if errors.As(err, &domain.NotFoundError{}) {
+					render.Render(w, r, response.ErrNotFound(err))
+					return
+				}
 				render.Render(w, r, response.ErrUnauthorized(err))
 				return
 			}
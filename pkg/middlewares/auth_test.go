@@ -9,6 +9,7 @@ import (
 
 	"github.com/fulcrumproject/core/pkg/auth"
 	"github.com/fulcrumproject/core/pkg/authz"
+	"github.com/fulcrumproject/core/pkg/domain"
 	"github.com/fulcrumproject/core/pkg/properties"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -322,6 +323,18 @@ func TestAuthzFromID(t *testing.T) {
 			},
 			expectedStatus: http.StatusForbidden,
 		},
+		{
+			name: "Resource does not exist",
+			loaderSetup: func() ObjectScopeLoader {
+				return func(ctx context.Context, id properties.UUID) (authz.ObjectScope, error) {
+					return nil, domain.NewNotFoundErrorf("user %s", id)
+				}
+			},
+			authorizerSetup: func() *mockAuthorizer {
+				return &mockAuthorizer{}
+			},
+			expectedStatus: http.StatusNotFound,
+		},
 	}
 
 	for _, tt := range tests {
@@ -0,0 +1,83 @@
+package middlewares
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/go-chi/cors"
+)
+
+// corsMethods are the methods a CORS policy can allow
+var corsMethods = []string{
+	http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete, http.MethodOptions,
+}
+
+// CORSPolicy is the cross-origin policy of the browser requests
+type CORSPolicy struct {
+	// Origins allowed as scheme://host[:port], a single * matches any part of the origin, e.g. https://*.example.com
+	// No origin is allowed when empty
+	AllowedOrigins   []string
+	AllowedMethods   []string
+	AllowedHeaders   []string
+	ExposedHeaders   []string
+	AllowCredentials bool
+	// How long the browsers cache the preflight responses, 0 leaves it to the browsers
+	MaxAge time.Duration
+}
+
+// Validate rejects the malformed origins and methods, and the wildcard origins when credentials are allowed
+// as they would let any matching site send requests with the credentials of the users
+func (p *CORSPolicy) Validate() error {
+	for _, origin := range p.AllowedOrigins {
+		if strings.Count(origin, "*") > 1 {
+			return fmt.Errorf("invalid CORS origin %q: a single wildcard is supported", origin)
+		}
+		if strings.Contains(origin, "*") && p.AllowCredentials {
+			return fmt.Errorf("invalid CORS origin %q: wildcard origins cannot be allowed with credentials, list the origins", origin)
+		}
+		if origin == "*" {
+			continue
+		}
+		u, err := url.Parse(origin)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" ||
+			u.Path != "" || u.RawQuery != "" || u.Fragment != "" || u.User != nil {
+			return fmt.Errorf("invalid CORS origin %q: expected scheme://host[:port]", origin)
+		}
+	}
+	for _, method := range p.AllowedMethods {
+		if !slices.Contains(corsMethods, strings.ToUpper(method)) {
+			return fmt.Errorf("invalid CORS method %q", method)
+		}
+	}
+	for _, header := range p.AllowedHeaders {
+		if strings.TrimSpace(header) == "" {
+			return fmt.Errorf("invalid CORS header: cannot be empty")
+		}
+	}
+	if p.MaxAge < 0 {
+		return fmt.Errorf("invalid CORS max age %s: cannot be negative", p.MaxAge)
+	}
+	return nil
+}
+
+// CORS applies the policy to the requests, answering the preflight requests of every route before the
+// authentication, the policy must be valid
+func CORS(policy CORSPolicy) func(http.Handler) http.Handler {
+	options := cors.Options{
+		AllowedOrigins:   policy.AllowedOrigins,
+		AllowedMethods:   policy.AllowedMethods,
+		AllowedHeaders:   policy.AllowedHeaders,
+		ExposedHeaders:   policy.ExposedHeaders,
+		AllowCredentials: policy.AllowCredentials,
+		MaxAge:           int(policy.MaxAge.Seconds()),
+	}
+	// Without origins the library allows them all
+	if len(policy.AllowedOrigins) == 0 {
+		options.AllowOriginFunc = func(*http.Request, string) bool { return false }
+	}
+	return cors.Handler(options)
+}
@@ -0,0 +1,102 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCORSPolicy_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  CORSPolicy
+		wantErr bool
+	}{
+		{name: "Locked down", policy: CORSPolicy{}},
+		{name: "Listed origins with credentials", policy: CORSPolicy{
+			AllowedOrigins:   []string{"https://console.example.com", "http://localhost:3000"},
+			AllowedMethods:   []string{"GET", "post"},
+			AllowCredentials: true,
+		}},
+		{name: "Wildcard origins without credentials", policy: CORSPolicy{AllowedOrigins: []string{"*", "https://*.example.com"}}},
+		{name: "Wildcard with credentials", policy: CORSPolicy{AllowedOrigins: []string{"*"}, AllowCredentials: true}, wantErr: true},
+		{name: "Subdomain wildcard with credentials", policy: CORSPolicy{AllowedOrigins: []string{"https://*.example.com"}, AllowCredentials: true}, wantErr: true},
+		{name: "Several wildcards", policy: CORSPolicy{AllowedOrigins: []string{"https://*.*.example.com"}}, wantErr: true},
+		{name: "Origin with a path", policy: CORSPolicy{AllowedOrigins: []string{"https://console.example.com/"}}, wantErr: true},
+		{name: "Origin without scheme", policy: CORSPolicy{AllowedOrigins: []string{"console.example.com"}}, wantErr: true},
+		{name: "Unknown method", policy: CORSPolicy{AllowedMethods: []string{"TRACE"}}, wantErr: true},
+		{name: "Empty header", policy: CORSPolicy{AllowedHeaders: []string{" "}}, wantErr: true},
+		{name: "Negative max age", policy: CORSPolicy{MaxAge: -time.Second}, wantErr: true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.policy.Validate()
+			if tc.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestCORS(t *testing.T) {
+	reached := false
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reached = true
+		w.WriteHeader(http.StatusUnauthorized)
+	})
+	policy := CORSPolicy{
+		AllowedOrigins:   []string{"https://console.example.com"},
+		AllowedMethods:   []string{"GET", "POST"},
+		AllowedHeaders:   []string{"Authorization", "Content-Type"},
+		ExposedHeaders:   []string{"Link"},
+		AllowCredentials: true,
+		MaxAge:           5 * time.Minute,
+	}
+	preflight := func(handler http.Handler, origin string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodOptions, "/api/v1/services/unknown", nil)
+		req.Header.Set("Origin", origin)
+		req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+		req.Header.Set("Access-Control-Request-Headers", "Authorization")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("preflight of an allowed origin", func(t *testing.T) {
+		reached = false
+		w := preflight(CORS(policy)(next), "https://console.example.com")
+		assert.False(t, reached, "preflight requests do not reach the routes")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "https://console.example.com", w.Header().Get("Access-Control-Allow-Origin"))
+		assert.Equal(t, "true", w.Header().Get("Access-Control-Allow-Credentials"))
+		assert.Equal(t, "POST", w.Header().Get("Access-Control-Allow-Methods"))
+		assert.Equal(t, "300", w.Header().Get("Access-Control-Max-Age"))
+	})
+
+	t.Run("preflight of another origin", func(t *testing.T) {
+		w := preflight(CORS(policy)(next), "https://evil.example.com")
+		assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+	})
+
+	t.Run("actual request", func(t *testing.T) {
+		reached = false
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/services", nil)
+		req.Header.Set("Origin", "https://console.example.com")
+		w := httptest.NewRecorder()
+		CORS(policy)(next).ServeHTTP(w, req)
+		assert.True(t, reached)
+		assert.Equal(t, "https://console.example.com", w.Header().Get("Access-Control-Allow-Origin"))
+		assert.Equal(t, "Link", w.Header().Get("Access-Control-Expose-Headers"))
+	})
+
+	t.Run("no origin allowed by default", func(t *testing.T) {
+		w := preflight(CORS(CORSPolicy{})(next), "https://console.example.com")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+	})
+}
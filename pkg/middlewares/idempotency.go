@@ -0,0 +1,135 @@
+package middlewares
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/fulcrumproject/core/pkg/auth"
+	"github.com/fulcrumproject/core/pkg/domain"
+	"github.com/fulcrumproject/core/pkg/response"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+)
+
+const (
+	HeaderIdempotencyKey     = "Idempotency-Key"
+	HeaderIdempotentReplayed = "Idempotent-Replayed"
+
+	// maxIdempotencyKeyLength bounds the keys chosen by the clients
+	maxIdempotencyKeyLength = 255
+)
+
+var (
+	ErrIdempotencyKeyReused     = errors.New("idempotency key already used for a different request")
+	ErrIdempotencyKeyInProgress = errors.New("a request with the same idempotency key is in progress")
+)
+
+// Idempotency replays the response of the first POST request sent with the same Idempotency-Key header, it must run after Auth
+//
+// Keys are scoped to the authenticated identity and expire after ttl. The request
+// body is read and restored, so DecodeBody can run afterwards. Only successful
+// responses are stored: a failed request releases its key and can be retried.
+// Reusing a key for a different request, or while the first one is still being
+// processed, is answered with 409. A request holds its key for at most lease: the
+// reservation of a process that died before finishing is then taken over by a retry
+// of the same request, and the late outcome of the first request is discarded.
+func Idempotency(store domain.IdempotencyStore, ttl, lease time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(HeaderIdempotencyKey)
+			if key == "" || r.Method != http.MethodPost {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if len(key) > maxIdempotencyKeyLength {
+				render.Render(w, r, response.ErrInvalidRequest(fmt.Errorf("idempotency key cannot be longer than %d characters", maxIdempotencyKeyLength)))
+				return
+			}
+
+			body, err := io.ReadAll(r.Body)
+			if err != nil {
+				render.Render(w, r, response.ErrInvalidRequest(err))
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			identity := auth.MustGetIdentity(r.Context())
+			scope := string(identity.Role) + ":" + identity.ID.String()
+			hash := idempotencyRequestHash(r, body)
+
+			now := time.Now()
+			token, record, err := store.Reserve(r.Context(), scope, key, hash, now.Add(ttl), now.Add(-lease))
+			if err != nil {
+				render.Render(w, r, response.ErrInternal(err))
+				return
+			}
+			if record != nil {
+				switch {
+				case record.RequestHash != hash:
+					render.Render(w, r, response.ErrConflict(ErrIdempotencyKeyReused))
+				case record.StatusCode == 0:
+					render.Render(w, r, response.ErrConflict(ErrIdempotencyKeyInProgress))
+				default:
+					w.Header().Set("Content-Type", "application/json")
+					w.Header().Set(HeaderIdempotentReplayed, "true")
+					w.WriteHeader(record.StatusCode)
+					w.Write(record.Body)
+				}
+				return
+			}
+
+			// The key is released when the request fails, panics included, the context may be canceled by then
+			ctx := context.WithoutCancel(r.Context())
+			completed := false
+			defer func() {
+				if completed {
+					return
+				}
+				err := store.Release(ctx, scope, key, token)
+				if errors.As(err, &domain.ConflictError{}) {
+					slog.WarnContext(ctx, "Idempotency key taken over before the request failed", "key", key)
+				} else if err != nil {
+					slog.ErrorContext(ctx, "Failed to release idempotency key", "key", key, "error", err)
+				}
+			}()
+
+			var buf bytes.Buffer
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			ww.Tee(&buf)
+			next.ServeHTTP(ww, r)
+
+			status := ww.Status()
+			if status < 200 || status >= 300 {
+				return
+			}
+			err = store.Complete(ctx, scope, key, token, status, buf.Bytes())
+			if errors.As(err, &domain.ConflictError{}) {
+				// The retry holding the key now records its own outcome
+				slog.WarnContext(ctx, "Idempotency key taken over before the request completed", "key", key)
+				completed = true
+				return
+			}
+			if err != nil {
+				slog.ErrorContext(ctx, "Failed to store idempotent response", "key", key, "error", err)
+				return
+			}
+			completed = true
+		})
+	}
+}
+
+// idempotencyRequestHash identifies a request by its method, path and body
+func idempotencyRequestHash(r *http.Request, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(r.Method + " " + r.URL.Path + "\n"))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,230 @@
+package middlewares
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fulcrumproject/core/pkg/auth"
+	"github.com/fulcrumproject/core/pkg/domain"
+	"github.com/fulcrumproject/core/pkg/properties"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryIdempotencyStore is an in-memory IdempotencyStore for tests
+type memoryIdempotencyStore struct {
+	mu      sync.Mutex
+	records map[string]*memoryIdempotencyRecord
+	tokens  int
+}
+
+type memoryIdempotencyRecord struct {
+	domain.IdempotencyRecord
+	token string
+}
+
+func newMemoryIdempotencyStore() *memoryIdempotencyStore {
+	return &memoryIdempotencyStore{records: map[string]*memoryIdempotencyRecord{}}
+}
+
+func (s *memoryIdempotencyStore) Reserve(ctx context.Context, scope, key, requestHash string, expiresAt, staleBefore time.Time) (string, *domain.IdempotencyRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if record, ok := s.records[scope+"/"+key]; ok {
+		stale := record.StatusCode == 0 && record.ReservedAt.Before(staleBefore) && record.RequestHash == requestHash
+		if !stale {
+			return "", &record.IdempotencyRecord, nil
+		}
+	}
+	s.tokens++
+	token := strconv.Itoa(s.tokens)
+	s.records[scope+"/"+key] = &memoryIdempotencyRecord{
+		IdempotencyRecord: domain.IdempotencyRecord{RequestHash: requestHash, ReservedAt: time.Now()},
+		token:             token,
+	}
+	return token, nil, nil
+}
+
+func (s *memoryIdempotencyStore) Complete(ctx context.Context, scope, key, token string, statusCode int, body []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	record, ok := s.records[scope+"/"+key]
+	if !ok || record.token != token || record.StatusCode != 0 {
+		return domain.NewConflictErrorf("taken over")
+	}
+	record.StatusCode, record.Body = statusCode, body
+	return nil
+}
+
+func (s *memoryIdempotencyStore) Release(ctx context.Context, scope, key, token string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	record, ok := s.records[scope+"/"+key]
+	if !ok || record.token != token || record.StatusCode != 0 {
+		return domain.NewConflictErrorf("taken over")
+	}
+	delete(s.records, scope+"/"+key)
+	return nil
+}
+
+func (s *memoryIdempotencyStore) PurgeExpired(ctx context.Context, at time.Time) (int64, error) {
+	return 0, nil
+}
+
+func TestIdempotency(t *testing.T) {
+	admin := &auth.Identity{ID: properties.NewUUID(), Role: auth.RoleAdmin}
+	other := &auth.Identity{ID: properties.NewUUID(), Role: auth.RoleAdmin}
+
+	newHandler := func(status int, calls *int) http.Handler {
+		// DecodeBody runs after the middleware and still sees the body
+		return Idempotency(newMemoryIdempotencyStore(), time.Hour, time.Minute)(DecodeBody[map[string]any]()(
+			http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				*calls++
+				body := MustGetBody[map[string]any](r.Context())
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(status)
+				w.Write([]byte(`{"name":"` + body["name"].(string) + `","call":` + strconv.Itoa(*calls) + `}`))
+			})))
+	}
+	send := func(h http.Handler, identity *auth.Identity, method, key, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/services", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if key != "" {
+			req.Header.Set(HeaderIdempotencyKey, key)
+		}
+		req = req.WithContext(auth.WithIdentity(req.Context(), identity))
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("Repeated key replays the response", func(t *testing.T) {
+		calls := 0
+		h := newHandler(http.StatusCreated, &calls)
+
+		first := send(h, admin, "POST", "key", `{"name":"svc"}`)
+		require.Equal(t, http.StatusCreated, first.Code)
+		second := send(h, admin, "POST", "key", `{"name":"svc"}`)
+
+		assert.Equal(t, 1, calls)
+		assert.Equal(t, http.StatusCreated, second.Code)
+		assert.Equal(t, first.Body.String(), second.Body.String())
+		assert.Equal(t, "true", second.Header().Get(HeaderIdempotentReplayed))
+	})
+
+	t.Run("Different body for the same key is a conflict", func(t *testing.T) {
+		calls := 0
+		h := newHandler(http.StatusCreated, &calls)
+
+		send(h, admin, "POST", "key", `{"name":"svc"}`)
+		w := send(h, admin, "POST", "key", `{"name":"other"}`)
+
+		assert.Equal(t, 1, calls)
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+
+	t.Run("Keys are scoped to the identity", func(t *testing.T) {
+		calls := 0
+		h := newHandler(http.StatusCreated, &calls)
+
+		send(h, admin, "POST", "key", `{"name":"svc"}`)
+		w := send(h, other, "POST", "key", `{"name":"svc"}`)
+
+		assert.Equal(t, 2, calls)
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.Empty(t, w.Header().Get(HeaderIdempotentReplayed))
+	})
+
+	t.Run("Failed requests release the key", func(t *testing.T) {
+		calls := 0
+		h := newHandler(http.StatusBadRequest, &calls)
+
+		send(h, admin, "POST", "key", `{"name":"svc"}`)
+		send(h, admin, "POST", "key", `{"name":"svc"}`)
+
+		assert.Equal(t, 2, calls)
+	})
+
+	t.Run("Requests without key or not POST are not tracked", func(t *testing.T) {
+		calls := 0
+		h := newHandler(http.StatusCreated, &calls)
+
+		send(h, admin, "POST", "", `{"name":"svc"}`)
+		send(h, admin, "POST", "", `{"name":"svc"}`)
+		send(h, admin, "PATCH", "key", `{"name":"svc"}`)
+		send(h, admin, "PATCH", "key", `{"name":"svc"}`)
+
+		assert.Equal(t, 4, calls)
+	})
+
+	t.Run("Request in progress is a conflict", func(t *testing.T) {
+		store := newMemoryIdempotencyStore()
+		h := Idempotency(store, time.Hour, time.Minute)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusCreated)
+		}))
+		hash := idempotencyRequestHash(httptest.NewRequest("POST", "/services", nil), []byte(`{}`))
+		_, _, err := store.Reserve(context.Background(), "admin:"+admin.ID.String(), "key", hash, time.Now().Add(time.Hour), time.Now())
+		require.NoError(t, err)
+
+		w := send(h, admin, "POST", "key", `{}`)
+		assert.Equal(t, http.StatusConflict, w.Code)
+		assert.Contains(t, w.Body.String(), "in progress")
+	})
+
+	t.Run("Request in progress past its lease is taken over", func(t *testing.T) {
+		store := newMemoryIdempotencyStore()
+		calls := 0
+		h := Idempotency(store, time.Hour, time.Minute)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls++
+			w.WriteHeader(http.StatusCreated)
+		}))
+		hash := idempotencyRequestHash(httptest.NewRequest("POST", "/services", nil), []byte(`{}`))
+		_, _, err := store.Reserve(context.Background(), "admin:"+admin.ID.String(), "key", hash, time.Now().Add(time.Hour), time.Now())
+		require.NoError(t, err)
+		// The process holding the key died two minutes ago
+		store.records["admin:"+admin.ID.String()+"/key"].ReservedAt = time.Now().Add(-2 * time.Minute)
+
+		// Another request cannot take the key over
+		w := send(h, admin, "POST", "key", `{"name":"other"}`)
+		assert.Equal(t, http.StatusConflict, w.Code)
+		assert.Contains(t, w.Body.String(), "different request")
+		assert.Equal(t, 0, calls)
+
+		w = send(h, admin, "POST", "key", `{}`)
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("Outcome of a request taken over is discarded", func(t *testing.T) {
+		store := newMemoryIdempotencyStore()
+		scope := "admin:" + admin.ID.String()
+		h := Idempotency(store, time.Hour, time.Minute)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// A retry takes the key over while the request is still running past its lease
+			token, _, err := store.Reserve(r.Context(), scope, "key", store.records[scope+"/key"].RequestHash, time.Now().Add(time.Hour), time.Now().Add(time.Second))
+			require.NoError(t, err)
+			require.NotEmpty(t, token)
+			w.WriteHeader(http.StatusCreated)
+		}))
+
+		w := send(h, admin, "POST", "key", `{}`)
+		assert.Equal(t, http.StatusCreated, w.Code)
+		record := store.records[scope+"/key"]
+		assert.Equal(t, 0, record.StatusCode, "the retry still holds the key")
+		assert.Equal(t, "2", record.token)
+	})
+
+	t.Run("Key too long", func(t *testing.T) {
+		calls := 0
+		h := newHandler(http.StatusCreated, &calls)
+
+		w := send(h, admin, "POST", strings.Repeat("k", 256), `{"name":"svc"}`)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, 0, calls)
+	})
+}
@@ -0,0 +1,138 @@
+package middlewares
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/fulcrumproject/core/pkg/auth"
+	"github.com/fulcrumproject/core/pkg/response"
+	"github.com/go-chi/render"
+)
+
+var ErrRateLimited = errors.New("rate limit exceeded")
+
+// RateLimit is a token bucket refilled at Rate tokens per second up to Burst tokens
+type RateLimit struct {
+	Rate  float64
+	Burst int
+}
+
+// RateLimiter takes tokens from the bucket identified by key
+// Implementations shared across replicas (e.g. Redis) can replace the in-process MemoryRateLimiter
+type RateLimiter interface {
+	// Allow takes a token from the bucket, when none is left it returns false and the time until the next one
+	Allow(ctx context.Context, key string, limit RateLimit) (bool, time.Duration, error)
+}
+
+// RateLimitPolicy returns the bucket and the limit of a request, ok is false for requests that are not limited
+type RateLimitPolicy func(r *http.Request, identity *auth.Identity) (bucket string, limit RateLimit, ok bool)
+
+// RateLimitByIdentity limits the requests of each authenticated identity, it must run after Auth
+// Exceeded limits are answered with 429 and a Retry-After header, limiter failures let the request through
+func RateLimitByIdentity(limiter RateLimiter, policy RateLimitPolicy) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			identity := auth.MustGetIdentity(r.Context())
+			bucket, limit, ok := policy(r, identity)
+			if !ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			key := bucket + ":" + string(identity.Role) + ":" + identity.ID.String()
+			allowed, retryAfter, err := limiter.Allow(r.Context(), key, limit)
+			if err != nil {
+				slog.WarnContext(r.Context(), "Rate limiter failed, request not limited", "bucket", bucket, "error", err)
+				next.ServeHTTP(w, r)
+				return
+			}
+			if !allowed {
+				w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+				render.Render(w, r, response.ErrTooManyRequests(ErrRateLimited))
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// MemoryRateLimiter keeps the token buckets in process memory
+type MemoryRateLimiter struct {
+	mu        sync.Mutex
+	buckets   map[string]*tokenBucket
+	lastPrune time.Time
+	now       func() time.Time
+}
+
+type tokenBucket struct {
+	tokens  float64
+	updated time.Time
+	limit   RateLimit
+}
+
+// memoryRateLimiterPruneInterval is how often the buckets refilled to their burst are dropped
+const memoryRateLimiterPruneInterval = time.Minute
+
+// NewMemoryRateLimiter creates an in-process rate limiter
+func NewMemoryRateLimiter() *MemoryRateLimiter {
+	return &MemoryRateLimiter{
+		buckets: make(map[string]*tokenBucket),
+		now:     time.Now,
+	}
+}
+
+// Allow implements RateLimiter
+func (l *MemoryRateLimiter) Allow(_ context.Context, key string, limit RateLimit) (bool, time.Duration, error) {
+	if limit.Rate <= 0 || limit.Burst <= 0 {
+		return false, 0, errors.New("rate limit must have a positive rate and burst")
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.prune(now)
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &tokenBucket{tokens: float64(limit.Burst), updated: now}
+		l.buckets[key] = b
+	}
+	b.limit = limit
+	b.refill(now)
+
+	if b.tokens < 1 {
+		wait := time.Duration((1 - b.tokens) / limit.Rate * float64(time.Second))
+		return false, wait, nil
+	}
+	b.tokens--
+	return true, 0, nil
+}
+
+// refill adds the tokens accrued since the last update
+func (b *tokenBucket) refill(now time.Time) {
+	if elapsed := now.Sub(b.updated).Seconds(); elapsed > 0 {
+		b.tokens = math.Min(float64(b.limit.Burst), b.tokens+elapsed*b.limit.Rate)
+	}
+	b.updated = now
+}
+
+// prune drops the full buckets, they behave as new ones
+func (l *MemoryRateLimiter) prune(now time.Time) {
+	if now.Sub(l.lastPrune) < memoryRateLimiterPruneInterval {
+		return
+	}
+	l.lastPrune = now
+	for key, b := range l.buckets {
+		b.refill(now)
+		if b.tokens >= float64(b.limit.Burst) {
+			delete(l.buckets, key)
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package middlewares
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/fulcrumproject/core/pkg/auth"
+	"github.com/fulcrumproject/core/pkg/properties"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryRateLimiter(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	limiter := NewMemoryRateLimiter()
+	limiter.now = func() time.Time { return now }
+	limit := RateLimit{Rate: 2, Burst: 3}
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		allowed, _, err := limiter.Allow(ctx, "a", limit)
+		require.NoError(t, err)
+		assert.True(t, allowed, "request %d within the burst", i)
+	}
+
+	allowed, retryAfter, err := limiter.Allow(ctx, "a", limit)
+	require.NoError(t, err)
+	assert.False(t, allowed)
+	assert.Equal(t, 500*time.Millisecond, retryAfter)
+
+	// Other keys have their own bucket
+	allowed, _, err = limiter.Allow(ctx, "b", limit)
+	require.NoError(t, err)
+	assert.True(t, allowed)
+
+	// Tokens are refilled at the configured rate
+	now = now.Add(500 * time.Millisecond)
+	allowed, _, err = limiter.Allow(ctx, "a", limit)
+	require.NoError(t, err)
+	assert.True(t, allowed)
+	allowed, _, err = limiter.Allow(ctx, "a", limit)
+	require.NoError(t, err)
+	assert.False(t, allowed)
+
+	// Full buckets are pruned
+	now = now.Add(time.Hour)
+	_, _, err = limiter.Allow(ctx, "c", limit)
+	require.NoError(t, err)
+	assert.Len(t, limiter.buckets, 1)
+
+	_, _, err = limiter.Allow(ctx, "a", RateLimit{})
+	assert.Error(t, err)
+}
+
+type mockRateLimiter struct {
+	allowed    bool
+	retryAfter time.Duration
+	err        error
+	keys       []string
+}
+
+func (m *mockRateLimiter) Allow(_ context.Context, key string, _ RateLimit) (bool, time.Duration, error) {
+	m.keys = append(m.keys, key)
+	return m.allowed, m.retryAfter, m.err
+}
+
+func TestRateLimitByIdentity(t *testing.T) {
+	identity := &auth.Identity{ID: properties.NewUUID(), Role: auth.RoleAgent}
+	policy := func(r *http.Request, id *auth.Identity) (string, RateLimit, bool) {
+		if r.URL.Path == "/health" {
+			return "", RateLimit{}, false
+		}
+		return "api", RateLimit{Rate: 1, Burst: 1}, true
+	}
+
+	tests := []struct {
+		name           string
+		path           string
+		limiter        *mockRateLimiter
+		expectedStatus int
+		expectedRetry  string
+		expectedKeys   []string
+	}{
+		{
+			name:           "Allowed",
+			path:           "/jobs",
+			limiter:        &mockRateLimiter{allowed: true},
+			expectedStatus: http.StatusOK,
+			expectedKeys:   []string{"api:agent:" + identity.ID.String()},
+		},
+		{
+			name:           "Exceeded",
+			path:           "/jobs",
+			limiter:        &mockRateLimiter{retryAfter: 1500 * time.Millisecond},
+			expectedStatus: http.StatusTooManyRequests,
+			expectedRetry:  "2",
+			expectedKeys:   []string{"api:agent:" + identity.ID.String()},
+		},
+		{
+			name:           "Not limited",
+			path:           "/health",
+			limiter:        &mockRateLimiter{},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Limiter failure lets the request through",
+			path:           "/jobs",
+			limiter:        &mockRateLimiter{err: errors.New("redis down")},
+			expectedStatus: http.StatusOK,
+			expectedKeys:   []string{"api:agent:" + identity.ID.String()},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			handler := RateLimitByIdentity(tc.limiter, policy)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			}))
+			req := httptest.NewRequest("GET", tc.path, nil)
+			req = req.WithContext(auth.WithIdentity(req.Context(), identity))
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			assert.Equal(t, tc.expectedRetry, w.Header().Get("Retry-After"))
+			assert.Equal(t, tc.expectedKeys, tc.limiter.keys)
+		})
+	}
+}
@@ -2,8 +2,12 @@ package middlewares
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"mime"
 	"net/http"
+	"strings"
 
 	"github.com/fulcrumproject/core/pkg/properties"
 	"github.com/fulcrumproject/core/pkg/response"
@@ -73,15 +77,32 @@ func MustGetActionName(ctx context.Context) string {
 // DecodeBody is middleware that decodes the request body into a struct
 // and stores it in the request context for later middlewares and handlers
 func DecodeBody[T any]() func(http.Handler) http.Handler {
+	return decodeBody[T](false)
+}
+
+// DecodeOptionalBody is DecodeBody for the requests whose body can be left out,
+// a request without a body decodes to the zero value of the struct
+func DecodeOptionalBody[T any]() func(http.Handler) http.Handler {
+	return decodeBody[T](true)
+}
+
+func decodeBody[T any](optional bool) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			// Create a new instance of the target type
 			v := new(T)
 
-			// Decode the request body into the target
-			if err := render.Decode(r, v); err != nil {
-				render.Render(w, r, response.ErrInvalidRequest(err))
-				return
+			// Decode the request body into the target, structured JSON types like application/json-patch+json included
+			if !optional || r.ContentLength != 0 {
+				decode := render.Decode
+				if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); strings.HasSuffix(mediaType, "+json") {
+					decode = func(r *http.Request, v any) error { return render.DecodeJSON(r.Body, v) }
+				}
+				// A chunked request has no length until its body is read
+				if err := decode(r, v); err != nil && !(optional && errors.Is(err, io.EOF)) {
+					render.Render(w, r, response.ErrInvalidRequest(err))
+					return
+				}
 			}
 
 			// Store the decoded body in the context
@@ -0,0 +1,37 @@
+package middlewares
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+
+	"github.com/fulcrumproject/core/pkg/tracing"
+	"github.com/fulcrumproject/utils/logging"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// RequestID identifies each request with the X-Request-Id header, the trace ID of its OpenTelemetry
+// trace context or a new ID, in this order, see tracing.ResolveRequestID
+// The ID is carried by the context, where the log lines, events and jobs of the request pick it up,
+// and echoed in the X-Request-Id header of the response
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := tracing.ResolveRequestID(r.Header.Get(tracing.HeaderRequestID), r.Header.Get(tracing.HeaderTraceParent))
+		w.Header().Set(tracing.HeaderRequestID, id)
+
+		ctx := tracing.WithRequestID(r.Context(), id)
+		// The chi middlewares reading the request ID see the same one
+		ctx = context.WithValue(ctx, middleware.RequestIDKey, id)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// RequestLogFormatter logs each request with its request ID, it must run after RequestID
+type RequestLogFormatter struct {
+	Logger *slog.Logger
+}
+
+func (f *RequestLogFormatter) NewLogEntry(r *http.Request) middleware.LogEntry {
+	formatter := &logging.SlogFormatter{Logger: f.Logger.With(tracing.LogKey, tracing.RequestID(r.Context()))}
+	return formatter.NewLogEntry(r)
+}
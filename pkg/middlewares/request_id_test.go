@@ -0,0 +1,68 @@
+package middlewares
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/fulcrumproject/core/pkg/tracing"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestID(t *testing.T) {
+	var requestID, chiRequestID string
+	handler := RequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID = tracing.RequestID(r.Context())
+		chiRequestID = middleware.GetReqID(r.Context())
+	}))
+	serve := func(headers map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/services", nil)
+		for name, value := range headers {
+			req.Header.Set(name, value)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("accepts the request ID of the client", func(t *testing.T) {
+		w := serve(map[string]string{tracing.HeaderRequestID: "req-42"})
+		assert.Equal(t, "req-42", requestID)
+		assert.Equal(t, "req-42", chiRequestID)
+		assert.Equal(t, "req-42", w.Header().Get(tracing.HeaderRequestID))
+	})
+
+	t.Run("uses the trace ID of the trace context", func(t *testing.T) {
+		w := serve(map[string]string{tracing.HeaderTraceParent: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"})
+		assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", requestID)
+		assert.Equal(t, requestID, w.Header().Get(tracing.HeaderRequestID))
+	})
+
+	t.Run("generates one", func(t *testing.T) {
+		w := serve(nil)
+		assert.NotEmpty(t, requestID)
+		assert.Equal(t, requestID, w.Header().Get(tracing.HeaderRequestID))
+	})
+}
+
+func TestRequestLogFormatter(t *testing.T) {
+	var buf bytes.Buffer
+	formatter := &RequestLogFormatter{Logger: slog.New(slog.NewJSONHandler(&buf, nil))}
+	handler := RequestID(middleware.RequestLogger(formatter)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/services", nil)
+	req.Header.Set(tracing.HeaderRequestID, "req-42")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	var line map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &line))
+	assert.Equal(t, "req-42", line[tracing.LogKey])
+	assert.Equal(t, float64(http.StatusNoContent), line["status"])
+}
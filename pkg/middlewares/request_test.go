@@ -245,6 +245,46 @@ func TestDecodeBody(t *testing.T) {
 	}
 }
 
+func TestDecodeOptionalBody(t *testing.T) {
+	type TestStruct struct {
+		Name string `json:"name"`
+	}
+
+	tests := []struct {
+		name           string
+		body           string
+		contentType    string
+		expectedStatus int
+		expectedName   string
+	}{
+		{name: "Without body", expectedStatus: http.StatusOK},
+		{name: "Empty JSON body", contentType: "application/json", expectedStatus: http.StatusOK},
+		{name: "Valid JSON body", body: `{"name":"test"}`, contentType: "application/json", expectedStatus: http.StatusOK, expectedName: "test"},
+		{name: "Malformed JSON body", body: `{"name":`, contentType: "application/json", expectedStatus: http.StatusBadRequest},
+		{name: "Body of a wrong type", body: `{"name":1}`, contentType: "application/json", expectedStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var captured TestStruct
+			testHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				captured = MustGetBody[TestStruct](r.Context())
+				w.WriteHeader(http.StatusOK)
+			})
+
+			req := httptest.NewRequest("POST", "/test", bytes.NewReader([]byte(tt.body)))
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			w := httptest.NewRecorder()
+			DecodeOptionalBody[TestStruct]()(testHandler).ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.Equal(t, tt.expectedName, captured.Name)
+		})
+	}
+}
+
 func TestMustGetBody(t *testing.T) {
 	type TestStruct struct {
 		Name  string `json:"name"`
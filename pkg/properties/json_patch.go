@@ -0,0 +1,286 @@
+package properties
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// JSON Patch operations (RFC 6902)
+const (
+	JSONPatchAdd     = "add"
+	JSONPatchRemove  = "remove"
+	JSONPatchReplace = "replace"
+	JSONPatchMove    = "move"
+	JSONPatchCopy    = "copy"
+	JSONPatchTest    = "test"
+)
+
+// JSONPatchOperation is a single operation of a JSON Patch document
+type JSONPatchOperation struct {
+	Op    string `json:"op"`
+	Path  string `json:"path"`
+	From  string `json:"from,omitempty"`
+	Value any    `json:"value,omitempty"`
+}
+
+// JSONPatchError reports the operation of a patch that could not be applied
+type JSONPatchError struct {
+	Index int
+	Op    JSONPatchOperation
+	Err   error
+}
+
+func (e *JSONPatchError) Error() string {
+	return fmt.Sprintf("patch operation %d (%s %s): %v", e.Index, e.Op.Op, e.Op.Path, e.Err)
+}
+
+func (e *JSONPatchError) Unwrap() error {
+	return e.Err
+}
+
+// ApplyJSONPatch applies the operations in order to a copy of the document
+// The document is left untouched, the patched document must still be an object
+func ApplyJSONPatch(doc map[string]any, ops []JSONPatchOperation) (map[string]any, error) {
+	if doc == nil {
+		doc = map[string]any{}
+	}
+	root, err := normalizeJSON(doc)
+	if err != nil {
+		return nil, err
+	}
+	for i, op := range ops {
+		if root, err = applyJSONPatchOperation(root, op); err != nil {
+			return nil, &JSONPatchError{Index: i, Op: op, Err: err}
+		}
+	}
+	result, ok := root.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("patched document must be an object")
+	}
+	return result, nil
+}
+
+// ParseJSONPointer splits a JSON Pointer (RFC 6901) in its unescaped reference tokens
+func ParseJSONPointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return []string{}, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("invalid pointer %q: must start with /", pointer)
+	}
+	tokens := strings.Split(pointer[1:], "/")
+	for i, t := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(t, "~1", "/"), "~0", "~")
+	}
+	return tokens, nil
+}
+
+func applyJSONPatchOperation(root any, op JSONPatchOperation) (any, error) {
+	path, err := ParseJSONPointer(op.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch op.Op {
+	case JSONPatchAdd:
+		value, err := normalizeJSON(op.Value)
+		if err != nil {
+			return nil, err
+		}
+		return jsonAdd(root, path, value)
+	case JSONPatchRemove:
+		root, _, err := jsonRemove(root, path)
+		return root, err
+	case JSONPatchReplace:
+		value, err := normalizeJSON(op.Value)
+		if err != nil {
+			return nil, err
+		}
+		if root, _, err = jsonRemove(root, path); err != nil {
+			return nil, err
+		}
+		return jsonAdd(root, path, value)
+	case JSONPatchMove:
+		from, err := ParseJSONPointer(op.From)
+		if err != nil {
+			return nil, err
+		}
+		if len(path) > len(from) && reflect.DeepEqual(path[:len(from)], from) {
+			return nil, fmt.Errorf("cannot move %s into one of its children", op.From)
+		}
+		root, value, err := jsonRemove(root, from)
+		if err != nil {
+			return nil, err
+		}
+		return jsonAdd(root, path, value)
+	case JSONPatchCopy:
+		from, err := ParseJSONPointer(op.From)
+		if err != nil {
+			return nil, err
+		}
+		value, err := jsonGet(root, from)
+		if err != nil {
+			return nil, err
+		}
+		if value, err = normalizeJSON(value); err != nil {
+			return nil, err
+		}
+		return jsonAdd(root, path, value)
+	case JSONPatchTest:
+		value, err := jsonGet(root, path)
+		if err != nil {
+			return nil, err
+		}
+		expected, err := normalizeJSON(op.Value)
+		if err != nil {
+			return nil, err
+		}
+		if !reflect.DeepEqual(value, expected) {
+			return nil, fmt.Errorf("test failed: value differs")
+		}
+		return root, nil
+	}
+	return nil, fmt.Errorf("unsupported operation %q", op.Op)
+}
+
+// jsonGet returns the value at path
+func jsonGet(node any, path []string) (any, error) {
+	for _, key := range path {
+		switch n := node.(type) {
+		case map[string]any:
+			value, ok := n[key]
+			if !ok {
+				return nil, fmt.Errorf("path not found: %s", key)
+			}
+			node = value
+		case []any:
+			idx, err := jsonArrayIndex(key, len(n)-1)
+			if err != nil {
+				return nil, err
+			}
+			node = n[idx]
+		default:
+			return nil, fmt.Errorf("path not found: %s", key)
+		}
+	}
+	return node, nil
+}
+
+// jsonAdd sets the value at path, inserting it in arrays, and returns the updated node
+func jsonAdd(node any, path []string, value any) (any, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+	key := path[0]
+	switch n := node.(type) {
+	case map[string]any:
+		if len(path) == 1 {
+			n[key] = value
+			return n, nil
+		}
+		child, ok := n[key]
+		if !ok {
+			return nil, fmt.Errorf("path not found: %s", key)
+		}
+		updated, err := jsonAdd(child, path[1:], value)
+		if err != nil {
+			return nil, err
+		}
+		n[key] = updated
+		return n, nil
+	case []any:
+		if len(path) == 1 {
+			idx := len(n)
+			if key != "-" {
+				var err error
+				if idx, err = jsonArrayIndex(key, len(n)); err != nil {
+					return nil, err
+				}
+			}
+			n = append(n, nil)
+			copy(n[idx+1:], n[idx:])
+			n[idx] = value
+			return n, nil
+		}
+		idx, err := jsonArrayIndex(key, len(n)-1)
+		if err != nil {
+			return nil, err
+		}
+		updated, err := jsonAdd(n[idx], path[1:], value)
+		if err != nil {
+			return nil, err
+		}
+		n[idx] = updated
+		return n, nil
+	}
+	return nil, fmt.Errorf("path not found: %s", key)
+}
+
+// jsonRemove deletes the value at path and returns the updated node and the removed value
+func jsonRemove(node any, path []string) (any, any, error) {
+	if len(path) == 0 {
+		return nil, nil, fmt.Errorf("cannot remove the whole document")
+	}
+	key := path[0]
+	switch n := node.(type) {
+	case map[string]any:
+		child, ok := n[key]
+		if !ok {
+			return nil, nil, fmt.Errorf("path not found: %s", key)
+		}
+		if len(path) == 1 {
+			delete(n, key)
+			return n, child, nil
+		}
+		updated, removed, err := jsonRemove(child, path[1:])
+		if err != nil {
+			return nil, nil, err
+		}
+		n[key] = updated
+		return n, removed, nil
+	case []any:
+		idx, err := jsonArrayIndex(key, len(n)-1)
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(path) == 1 {
+			removed := n[idx]
+			return append(n[:idx], n[idx+1:]...), removed, nil
+		}
+		updated, removed, err := jsonRemove(n[idx], path[1:])
+		if err != nil {
+			return nil, nil, err
+		}
+		n[idx] = updated
+		return n, removed, nil
+	}
+	return nil, nil, fmt.Errorf("path not found: %s", key)
+}
+
+// jsonArrayIndex parses an array index token, accepting values up to last
+func jsonArrayIndex(token string, last int) (int, error) {
+	idx, err := strconv.Atoi(token)
+	if err != nil || idx < 0 || (len(token) > 1 && token[0] == '0') {
+		return 0, fmt.Errorf("invalid array index %s", token)
+	}
+	if idx > last {
+		return 0, fmt.Errorf("array index %d out of bounds", idx)
+	}
+	return idx, nil
+}
+
+// normalizeJSON returns a deep copy of the value with the types produced by JSON decoding
+func normalizeJSON(v any) (any, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var normalized any
+	if err := json.Unmarshal(data, &normalized); err != nil {
+		return nil, err
+	}
+	return normalized, nil
+}
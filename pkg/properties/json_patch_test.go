@@ -0,0 +1,125 @@
+package properties
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyJSONPatch(t *testing.T) {
+	doc := func() map[string]any {
+		return map[string]any{
+			"cpu":  2,
+			"tags": []any{"a", "b"},
+			"net":  map[string]any{"zone": "eu", "a/b": 1},
+		}
+	}
+
+	tests := []struct {
+		name          string
+		ops           []JSONPatchOperation
+		expected      map[string]any
+		expectedIndex int
+		expectedError string
+	}{
+		{
+			name: "Add and replace",
+			ops: []JSONPatchOperation{
+				{Op: JSONPatchAdd, Path: "/memory", Value: 1024},
+				{Op: JSONPatchReplace, Path: "/cpu", Value: 4},
+			},
+			expected: map[string]any{
+				"cpu": float64(4), "memory": float64(1024),
+				"tags": []any{"a", "b"}, "net": map[string]any{"zone": "eu", "a/b": float64(1)},
+			},
+		},
+		{
+			name: "Array insert, append and remove",
+			ops: []JSONPatchOperation{
+				{Op: JSONPatchAdd, Path: "/tags/0", Value: "first"},
+				{Op: JSONPatchAdd, Path: "/tags/-", Value: "last"},
+				{Op: JSONPatchRemove, Path: "/tags/1"},
+			},
+			expected: map[string]any{
+				"cpu": float64(2), "tags": []any{"first", "b", "last"},
+				"net": map[string]any{"zone": "eu", "a/b": float64(1)},
+			},
+		},
+		{
+			name: "Escaped pointer, move, copy and test",
+			ops: []JSONPatchOperation{
+				{Op: JSONPatchTest, Path: "/net/a~1b", Value: 1},
+				{Op: JSONPatchMove, From: "/net/zone", Path: "/zone"},
+				{Op: JSONPatchCopy, From: "/zone", Path: "/net/zone"},
+			},
+			expected: map[string]any{
+				"cpu": float64(2), "tags": []any{"a", "b"}, "zone": "eu",
+				"net": map[string]any{"zone": "eu", "a/b": float64(1)},
+			},
+		},
+		{
+			name: "Failing operation index",
+			ops: []JSONPatchOperation{
+				{Op: JSONPatchReplace, Path: "/cpu", Value: 4},
+				{Op: JSONPatchReplace, Path: "/missing", Value: 1},
+			},
+			expectedIndex: 1,
+			expectedError: "patch operation 1 (replace /missing): path not found: missing",
+		},
+		{
+			name:          "Failed test",
+			ops:           []JSONPatchOperation{{Op: JSONPatchTest, Path: "/cpu", Value: 3}},
+			expectedError: "test failed",
+		},
+		{
+			name:          "Unsupported operation",
+			ops:           []JSONPatchOperation{{Op: "merge", Path: "/cpu"}},
+			expectedError: `unsupported operation "merge"`,
+		},
+		{
+			name:          "Invalid pointer",
+			ops:           []JSONPatchOperation{{Op: JSONPatchRemove, Path: "cpu"}},
+			expectedError: "must start with /",
+		},
+		{
+			name:          "Array index out of bounds",
+			ops:           []JSONPatchOperation{{Op: JSONPatchAdd, Path: "/tags/5", Value: "x"}},
+			expectedError: "array index 5 out of bounds",
+		},
+		{
+			name:          "Move into a child",
+			ops:           []JSONPatchOperation{{Op: JSONPatchMove, From: "/net", Path: "/net/inner"}},
+			expectedError: "cannot move /net into one of its children",
+		},
+		{
+			name:          "Remove the document",
+			ops:           []JSONPatchOperation{{Op: JSONPatchRemove, Path: ""}},
+			expectedError: "cannot remove the whole document",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			original := doc()
+			result, err := ApplyJSONPatch(original, tc.ops)
+
+			assert.Equal(t, doc(), original, "the input document must not change")
+			if tc.expectedError != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tc.expectedError)
+				var patchErr *JSONPatchError
+				require.ErrorAs(t, err, &patchErr)
+				assert.Equal(t, tc.expectedIndex, patchErr.Index)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, result)
+		})
+	}
+}
+
+func TestApplyJSONPatchRootMustBeObject(t *testing.T) {
+	_, err := ApplyJSONPatch(nil, []JSONPatchOperation{{Op: JSONPatchReplace, Path: "", Value: []any{1}}})
+	assert.Error(t, err)
+}
@@ -18,8 +18,14 @@ type ErrRes struct {
 
 	HTTPStatusCode int    `json:"-"`      // http response status code
 	StatusText     string `json:"status"` // user-level status message
+}
 
-	ValidationErrors []ValidationError `json:"validationErrors,omitempty"` // validation errors if any
+// InvalidRequestErrRes is the body of the 400 responses, ValidationErrors details the invalid fields
+// and is empty when the request is invalid for a reason not related to a field
+type InvalidRequestErrRes struct {
+	ErrRes
+	Valid            bool              `json:"valid"`
+	ValidationErrors []ValidationError `json:"errors"`
 }
 
 type ValidationError struct {
@@ -33,20 +39,25 @@ func (e *ErrRes) Render(w http.ResponseWriter, r *http.Request) error {
 }
 
 func ErrInvalidRequest(err error) render.Renderer {
-	return &ErrRes{
-		Err:            err,
-		ErrorText:      err.Error(),
-		HTTPStatusCode: http.StatusBadRequest,
-		StatusText:     "Invalid request",
+	return &InvalidRequestErrRes{
+		ErrRes: ErrRes{
+			Err:            err,
+			ErrorText:      err.Error(),
+			HTTPStatusCode: http.StatusBadRequest,
+			StatusText:     "Invalid request",
+		},
+		ValidationErrors: []ValidationError{},
 	}
 }
 
 func MultiErrInvalidRequest(validationErrs []ValidationError) render.Renderer {
-	return &ErrRes{
-		Err:              ErrInvalidFields,
-		ErrorText:        ErrInvalidFields.Error(),
-		HTTPStatusCode:   http.StatusBadRequest,
-		StatusText:       "Invalid request",
+	return &InvalidRequestErrRes{
+		ErrRes: ErrRes{
+			Err:            ErrInvalidFields,
+			ErrorText:      ErrInvalidFields.Error(),
+			HTTPStatusCode: http.StatusBadRequest,
+			StatusText:     "Invalid request",
+		},
 		ValidationErrors: validationErrs,
 	}
 }
@@ -86,3 +97,21 @@ func ErrUnauthorized(err error) render.Renderer {
 		StatusText:     "Forbidden",
 	}
 }
+
+func ErrTooManyRequests(err error) render.Renderer {
+	return &ErrRes{
+		Err:            err,
+		ErrorText:      err.Error(),
+		HTTPStatusCode: http.StatusTooManyRequests,
+		StatusText:     "Too many requests",
+	}
+}
+
+func ErrConflict(err error) render.Renderer {
+	return &ErrRes{
+		Err:            err,
+		ErrorText:      err.Error(),
+		HTTPStatusCode: http.StatusConflict,
+		StatusText:     "Conflict",
+	}
+}
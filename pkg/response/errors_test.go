@@ -1,6 +1,7 @@
 package response
 
 import (
+	"encoding/json"
 	"errors"
 	"net/http"
 	"net/http/httptest"
@@ -55,14 +56,19 @@ func TestErrInvalidRequest(t *testing.T) {
 	testErr := errors.New("test validation error")
 
 	renderer := ErrInvalidRequest(testErr)
-	errResp, ok := renderer.(*ErrRes)
-	require.True(t, ok, "Expected *ErrResponse type")
+	errResp, ok := renderer.(*InvalidRequestErrRes)
+	require.True(t, ok, "Expected *InvalidRequestErrRes type")
 
 	assert.Equal(t, testErr, errResp.Err, "Err should match the input error")
 	assert.Equal(t, testErr.Error(), errResp.ErrorText, "ErrorText should match error message")
 	assert.Equal(t, http.StatusBadRequest, errResp.HTTPStatusCode, "HTTPStatusCode should be BadRequest")
 	assert.Equal(t, "Invalid request", errResp.StatusText, "StatusText should be 'Invalid request'")
-	assert.Nil(t, errResp.ValidationErrors, "ValidationErrors should be nil")
+	assert.Empty(t, errResp.ValidationErrors, "ValidationErrors should be empty")
+
+	// The details array is always present so that all the 400 responses have the same shape
+	body, err := json.Marshal(errResp)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"status":"Invalid request","error":"test validation error","valid":false,"errors":[]}`, string(body))
 }
 
 func TestMultiErrInvalidRequest(t *testing.T) {
@@ -72,8 +78,8 @@ func TestMultiErrInvalidRequest(t *testing.T) {
 	}
 
 	renderer := MultiErrInvalidRequest(validationErrs)
-	errResp, ok := renderer.(*ErrRes)
-	require.True(t, ok, "Expected *ErrResponse type")
+	errResp, ok := renderer.(*InvalidRequestErrRes)
+	require.True(t, ok, "Expected *InvalidRequestErrRes type")
 
 	assert.Equal(t, ErrInvalidFields, errResp.Err, "Err should be ErrInvalidFields")
 	assert.Equal(t, ErrInvalidFields.Error(), errResp.ErrorText, "ErrorText should match ErrInvalidFields message")
@@ -57,13 +57,34 @@ func (e *Engine[C]) CleanupVaultSecrets(ctx context.Context, properties map[stri
 	for _, ref := range references {
 		if err := e.vault.Delete(ctx, ref); err != nil {
 			// Log error but continue - this is best-effort cleanup
-			slog.Warn("Failed to delete vault secret during cleanup", "reference", ref, "error", err)
+			slog.WarnContext(ctx, "Failed to delete vault secret during cleanup", "reference", ref, "error", err)
 		} else {
-			slog.Debug("Deleted vault secret", "reference", ref)
+			slog.DebugContext(ctx, "Deleted vault secret", "reference", ref)
 		}
 	}
 }
 
+// CleanupAddedVaultSecrets deletes the vault secrets referenced in the properties but not in the old properties,
+// the secrets stored by an update that is not applied
+// This is a best-effort operation - errors are logged but don't fail the operation
+func (e *Engine[C]) CleanupAddedVaultSecrets(ctx context.Context, oldProperties, properties map[string]any) {
+	if e.vault == nil || properties == nil {
+		return
+	}
+
+	kept := make(map[string]bool)
+	for _, ref := range extractVaultReferences(oldProperties) {
+		kept[ref] = true
+	}
+	added := make(map[string]any)
+	for _, ref := range extractVaultReferences(properties) {
+		if !kept[ref] {
+			added[ref] = VaultRefPrefix + ref
+		}
+	}
+	e.CleanupVaultSecrets(ctx, added)
+}
+
 // CleanupEphemeralSecrets deletes only ephemeral vault secrets referenced in the properties
 // Uses the schema to identify which properties are ephemeral secrets
 // This is a best-effort operation - errors are logged but don't fail the operation
@@ -193,6 +214,86 @@ func (e *Engine[C]) ApplyUpdate(
 	return e.apply(ctx, schemaCtx, OperationUpdate, schema, oldProperties, newProperties)
 }
 
+// ValidateProperties checks that stored properties conform to the schema
+// Only types, validators and requirements are checked: authorizers, immutability, defaults,
+// generators and secret processing are skipped and the properties are left untouched,
+// which allows re-validating existing values against a changed schema
+func (e *Engine[C]) ValidateProperties(
+	ctx context.Context,
+	schemaCtx C,
+	schema Schema,
+	properties map[string]any,
+) error {
+	var validationErrors []ValidationErrorDetail
+
+	for propName, propDef := range schema.Properties {
+		value := properties[propName]
+		if value == nil {
+			if propDef.Required {
+				validationErrors = append(validationErrors, ValidationErrorDetail{
+					Path:    propName,
+					Message: "required property is missing",
+				})
+			}
+			continue
+		}
+		if isVaultReference(value, propDef.Secret) {
+			continue
+		}
+		if err := e.validateStoredValue(ctx, schemaCtx, propName, propDef, value); err != nil {
+			if details, ok := nestedValidationDetails(propName, err); ok {
+				validationErrors = append(validationErrors, details...)
+				continue
+			}
+			validationErrors = append(validationErrors, ValidationErrorDetail{
+				Path:    propName,
+				Message: err.Error(),
+			})
+		}
+	}
+
+	validationErrors = append(validationErrors, checkRequiredIf(schema, properties, validationErrors)...)
+	validationErrors = append(validationErrors, e.validateSchema(ctx, schemaCtx, OperationUpdate, schema.Validators, properties, properties)...)
+
+	if len(validationErrors) > 0 {
+		return NewValidationError(validationErrors)
+	}
+	return nil
+}
+
+// validateStoredValue validates a stored value and its nested structure as an unchanged update
+func (e *Engine[C]) validateStoredValue(
+	ctx context.Context,
+	schemaCtx C,
+	propName string,
+	propDef PropertyDefinition,
+	value any,
+) error {
+	if err := e.validatePropertyValue(ctx, schemaCtx, OperationUpdate, propName, propDef, value, value); err != nil {
+		return err
+	}
+
+	switch {
+	case propDef.Type == "object" && len(propDef.Properties) > 0:
+		objValue, _ := value.(map[string]any)
+		return e.ValidateProperties(ctx, schemaCtx, Schema{Properties: propDef.Properties}, objValue)
+
+	case propDef.Type == "array" && propDef.Items != nil:
+		arrValue, _ := value.([]any)
+		for i, item := range arrValue {
+			itemPropName := fmt.Sprintf("%s[%d]", propName, i)
+			if err := e.validateStoredValue(ctx, schemaCtx, itemPropName, *propDef.Items, item); err != nil {
+				if details, ok := nestedValidationDetails(fmt.Sprintf("[%d]", i), err); ok {
+					return NewValidationError(details)
+				}
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
 // apply is the internal implementation that processes properties according to schema
 func (e *Engine[C]) apply(
 	ctx context.Context,
@@ -204,6 +305,7 @@ func (e *Engine[C]) apply(
 ) (map[string]any, error) {
 	result := make(map[string]any)
 	var validationErrors []ValidationErrorDetail
+	var causes []error
 
 	// Process each property, collecting all validation errors
 	for propName, propDef := range schema.Properties {
@@ -212,6 +314,12 @@ func (e *Engine[C]) apply(
 
 		finalValue, err := e.processProperty(ctx, schemaCtx, operation, propName, propDef, oldValue, newValue)
 		if err != nil {
+			causes = append(causes, err)
+			// Errors of nested objects are reported at their own paths
+			if details, ok := nestedValidationDetails(propName, err); ok {
+				validationErrors = append(validationErrors, details...)
+				continue
+			}
 			validationErrors = append(validationErrors, ValidationErrorDetail{
 				Path:    propName,
 				Message: err.Error(),
@@ -230,22 +338,64 @@ func (e *Engine[C]) apply(
 		}
 	}
 
+	// Check conditional requirements once all the properties of the object are resolved
+	validationErrors = append(validationErrors, checkRequiredIf(schema, result, validationErrors)...)
+
 	// Run schema-level validators (cross-property validation)
-	if err := e.validateSchema(ctx, schemaCtx, operation, schema.Validators, oldProperties, result); err != nil {
-		validationErrors = append(validationErrors, ValidationErrorDetail{
-			Path:    "",
-			Message: err.Error(),
-		})
-	}
+	validationErrors = append(validationErrors, e.validateSchema(ctx, schemaCtx, operation, schema.Validators, oldProperties, result)...)
 
 	// Return all validation errors at once
 	if len(validationErrors) > 0 {
-		return nil, NewValidationError(validationErrors)
+		return nil, ValidationError{Errors: validationErrors, causes: causes}
 	}
 
 	return result, nil
 }
 
+// checkRequiredIf reports the properties missing while the sibling value of their requiredIf condition matches
+// Properties already failing are skipped, as are conditions on siblings that are missing or failed
+func checkRequiredIf(schema Schema, result map[string]any, validationErrors []ValidationErrorDetail) []ValidationErrorDetail {
+	var details []ValidationErrorDetail
+	for propName, propDef := range schema.Properties {
+		cond := propDef.RequiredIf
+		if cond == nil || result[propName] != nil || hasValidationError(validationErrors, propName) {
+			continue
+		}
+		siblingValue, ok := result[cond.Property]
+		if !ok || !jsonEqual(siblingValue, cond.Value) {
+			continue
+		}
+		details = append(details, ValidationErrorDetail{
+			Path:    propName,
+			Message: fmt.Sprintf(MsgRequiredIfMissing, cond.Property, cond.Value),
+		})
+	}
+	return details
+}
+
+// hasValidationError returns true when an error is reported at the path or below it
+func hasValidationError(validationErrors []ValidationErrorDetail, path string) bool {
+	for _, d := range validationErrors {
+		if d.Path == path || strings.HasPrefix(d.Path, path+".") || strings.HasPrefix(d.Path, path+"[") {
+			return true
+		}
+	}
+	return false
+}
+
+// jsonEqual compares two values by their JSON encoding, so that numbers of different Go types match
+func jsonEqual(a, b any) bool {
+	aJSON, err := json.Marshal(a)
+	if err != nil {
+		return false
+	}
+	bJSON, err := json.Marshal(b)
+	if err != nil {
+		return false
+	}
+	return string(aJSON) == string(bJSON)
+}
+
 // processProperty handles the complete processing of a single property
 func (e *Engine[C]) processProperty(
 	ctx context.Context,
@@ -351,6 +501,18 @@ func (e *Engine[C]) runAuthorizers(
 	return nil
 }
 
+// Authorize runs the authorizers of a property as if a new value was provided,
+// telling whether the actor and state of the context allow setting it
+func (e *Engine[C]) Authorize(
+	ctx context.Context,
+	schemaCtx C,
+	operation Operation,
+	propPath string,
+	propDef PropertyDefinition,
+) error {
+	return e.runAuthorizers(ctx, schemaCtx, operation, propPath, propDef, true)
+}
+
 // validatePropertyValue performs type validation and runs all validators
 func (e *Engine[C]) validatePropertyValue(
 	ctx context.Context,
@@ -512,12 +674,18 @@ func (e *Engine[C]) processNestedArray(
 
 		// Validate the item
 		if err := e.validatePropertyValue(ctx, schemaCtx, operation, itemPropName, *propDef.Items, oldItem, item); err != nil {
+			if details, ok := nestedValidationDetails(fmt.Sprintf("[%d]", i), err); ok {
+				return nil, ValidationError{Errors: details, causes: []error{err}}
+			}
 			return nil, err
 		}
 
 		// Process nested structures in array items
 		processedItem, err := e.processNestedStructure(ctx, schemaCtx, operation, itemPropName, *propDef.Items, oldItem, item)
 		if err != nil {
+			if details, ok := nestedValidationDetails(fmt.Sprintf("[%d]", i), err); ok {
+				return nil, ValidationError{Errors: details, causes: []error{err}}
+			}
 			return nil, err
 		}
 
@@ -563,21 +731,29 @@ func (e *Engine[C]) processSecret(
 	return VaultRefPrefix + reference, nil
 }
 
-// validateSchema runs schema-level validators
+// validateSchema runs schema-level validators and returns the errors of all of them
+// Validators returning a ValidationError report their own paths, other errors are reported on the object
 func (e *Engine[C]) validateSchema(
 	ctx context.Context,
 	schemaCtx C,
 	operation Operation,
 	validators []SchemaValidatorConfig,
 	oldProperties, newProperties map[string]any,
-) error {
+) []ValidationErrorDetail {
+	var details []ValidationErrorDetail
 	for _, validatorCfg := range validators {
 		validator := e.schemaValidators[validatorCfg.Type]
-		if err := validator.Validate(ctx, schemaCtx, operation, oldProperties, newProperties, validatorCfg.Config); err != nil {
-			return err
+		err := validator.Validate(ctx, schemaCtx, operation, oldProperties, newProperties, validatorCfg.Config)
+		if err == nil {
+			continue
+		}
+		if nested, ok := nestedValidationDetails("", err); ok {
+			details = append(details, nested...)
+			continue
 		}
+		details = append(details, ValidationErrorDetail{Path: "", Message: err.Error()})
 	}
-	return nil
+	return details
 }
 
 // ValidateSchema validates that the schema definition is valid
@@ -595,6 +771,9 @@ func (e *Engine[C]) ValidateSchema(schema Schema) error {
 		if err := e.validatePropertyDefinition(propName, propDef); err != nil {
 			return err
 		}
+		if err := validateRequiredIf(propName, propName, propDef, schema.Properties); err != nil {
+			return err
+		}
 	}
 
 	// Validate schema-level validators exist and have valid config
@@ -654,6 +833,9 @@ func (e *Engine[C]) validatePropertyDefinition(propPath string, propDef Property
 			if err := e.validatePropertyDefinition(nestedPath, nestedDef); err != nil {
 				return err
 			}
+			if err := validateRequiredIf(nestedPath, nestedName, nestedDef, propDef.Properties); err != nil {
+				return err
+			}
 		}
 	}
 
@@ -705,6 +887,32 @@ func (e *Engine[C]) validatePropertyDefinition(propPath string, propDef Property
 		}
 	}
 
+	// 12. Update mode must be valid
+	if _, ok := updateModeRank[propDef.UpdateMode]; !ok {
+		return fmt.Errorf("%s: update mode must be 'hot', 'warm' or 'cold'", propPath)
+	}
+
+	return nil
+}
+
+// validateRequiredIf ensures the requiredIf condition references a sibling property with a value
+func validateRequiredIf(propPath, propName string, propDef PropertyDefinition, siblings map[string]PropertyDefinition) error {
+	cond := propDef.RequiredIf
+	if cond == nil {
+		return nil
+	}
+	if cond.Property == "" {
+		return fmt.Errorf("%s: requiredIf requires 'property'", propPath)
+	}
+	if cond.Property == propName {
+		return fmt.Errorf("%s: requiredIf cannot reference the property itself", propPath)
+	}
+	if _, ok := siblings[cond.Property]; !ok {
+		return fmt.Errorf("%s: requiredIf references unknown sibling property '%s'", propPath, cond.Property)
+	}
+	if cond.Value == nil {
+		return fmt.Errorf("%s: requiredIf requires 'value'", propPath)
+	}
 	return nil
 }
 
@@ -3,6 +3,7 @@ package schema
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"testing"
 
@@ -18,18 +19,21 @@ type TestContext struct {
 // Helper to create a basic engine for testing
 func newTestEngine() *Engine[TestContext] {
 	validators := map[string]PropertyValidator[TestContext]{
-		"minLength": &MinLengthValidator[TestContext]{},
-		"maxLength": &MaxLengthValidator[TestContext]{},
-		"pattern":   &PatternValidator[TestContext]{},
-		"min":       &MinValidator[TestContext]{},
-		"max":       &MaxValidator[TestContext]{},
-		"enum":      &EnumValidator[TestContext]{},
-		"minItems":  &MinItemsValidator[TestContext]{},
-		"maxItems":  &MaxItemsValidator[TestContext]{},
+		"minLength":   &MinLengthValidator[TestContext]{},
+		"maxLength":   &MaxLengthValidator[TestContext]{},
+		"pattern":     &PatternValidator[TestContext]{},
+		"format":      &FormatValidator[TestContext]{},
+		"min":         &MinValidator[TestContext]{},
+		"max":         &MaxValidator[TestContext]{},
+		"enum":        &EnumValidator[TestContext]{},
+		"minItems":    &MinItemsValidator[TestContext]{},
+		"maxItems":    &MaxItemsValidator[TestContext]{},
+		"uniqueItems": &UniqueItemsValidator[TestContext]{},
 	}
 
 	schemaValidators := map[string]SchemaValidator[TestContext]{
 		"exactlyOne": &ExactlyOneValidator[TestContext]{},
+		"compare":    &CompareValidator[TestContext]{},
 	}
 
 	generators := map[string]Generator[TestContext]{}
@@ -509,6 +513,405 @@ func TestEngine_ApplyCreate_MultipleValidationErrors(t *testing.T) {
 	}
 }
 
+func TestEngine_Apply_RequiredIf(t *testing.T) {
+	engine := newTestEngine()
+	ctx := context.Background()
+	testCtx := TestContext{Actor: "user"}
+
+	schema := Schema{
+		Properties: map[string]PropertyDefinition{
+			"backupEnabled": {Type: "boolean"},
+			"backupSchedule": {
+				Type:       "string",
+				RequiredIf: &RequiredIfConfig{Property: "backupEnabled", Value: true},
+			},
+			"network": {
+				Type: "object",
+				Properties: map[string]PropertyDefinition{
+					"mode": {Type: "string"},
+					"cidr": {
+						Type:       "string",
+						RequiredIf: &RequiredIfConfig{Property: "mode", Value: "static"},
+					},
+				},
+			},
+			"disks": {
+				Type: "array",
+				Items: &PropertyDefinition{
+					Type: "object",
+					Properties: map[string]PropertyDefinition{
+						"size":      {Type: "integer"},
+						"encrypted": {Type: "boolean"},
+						"keyId": {
+							Type:       "string",
+							RequiredIf: &RequiredIfConfig{Property: "encrypted", Value: true},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	tests := []struct {
+		name          string
+		oldProperties map[string]any
+		properties    map[string]any
+		expectedPaths map[string]string
+	}{
+		{
+			name:       "condition not met",
+			properties: map[string]any{"backupEnabled": false},
+		},
+		{
+			name:       "condition met and field present",
+			properties: map[string]any{"backupEnabled": true, "backupSchedule": "0 2 * * *"},
+		},
+		{
+			name:          "condition met and field missing",
+			properties:    map[string]any{"backupEnabled": true},
+			expectedPaths: map[string]string{"backupSchedule": "property is required when backupEnabled is true"},
+		},
+		{
+			name:          "nested object resolves siblings in its scope",
+			properties:    map[string]any{"network": map[string]any{"mode": "static"}},
+			expectedPaths: map[string]string{"network.cidr": "property is required when mode is static"},
+		},
+		{
+			name: "array items resolve siblings in their scope",
+			properties: map[string]any{"disks": []any{
+				map[string]any{"size": 10},
+				map[string]any{"size": 20, "encrypted": true},
+			}},
+			expectedPaths: map[string]string{"disks[1].keyId": "property is required when encrypted is true"},
+		},
+		{
+			name:          "update keeps the existing value",
+			oldProperties: map[string]any{"backupEnabled": false, "backupSchedule": "0 2 * * *"},
+			properties:    map[string]any{"backupEnabled": true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var err error
+			if tt.oldProperties != nil {
+				_, err = engine.ApplyUpdate(ctx, testCtx, schema, tt.oldProperties, tt.properties)
+			} else {
+				_, err = engine.ApplyCreate(ctx, testCtx, schema, tt.properties)
+			}
+			if len(tt.expectedPaths) == 0 {
+				if err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				return
+			}
+
+			validationErr, ok := err.(ValidationError)
+			if !ok {
+				t.Fatalf("expected ValidationError, got %T: %v", err, err)
+			}
+			if len(validationErr.Errors) != len(tt.expectedPaths) {
+				t.Fatalf("expected %d errors, got %v", len(tt.expectedPaths), validationErr.Errors)
+			}
+			for _, detail := range validationErr.Errors {
+				if msg, ok := tt.expectedPaths[detail.Path]; !ok || msg != detail.Message {
+					t.Errorf("unexpected error %s: %s", detail.Path, detail.Message)
+				}
+			}
+		})
+	}
+}
+
+func TestEngine_Apply_CompareValidator(t *testing.T) {
+	engine := newTestEngine()
+	ctx := context.Background()
+	testCtx := TestContext{Actor: "user"}
+
+	schema := Schema{
+		Properties: map[string]PropertyDefinition{
+			"minReplicas": {Type: "integer"},
+			"maxReplicas": {Type: "integer"},
+			"name":        {Type: "string"},
+		},
+		Validators: []SchemaValidatorConfig{
+			{Type: "compare", Config: map[string]any{"field": "maxReplicas", "operator": "gte", "other": "minReplicas"}},
+			{Type: "exactlyOne", Config: map[string]any{"properties": []any{"name", "minReplicas"}}},
+		},
+	}
+
+	tests := []struct {
+		name          string
+		oldProperties map[string]any
+		properties    map[string]any
+		expectedPaths map[string]string
+	}{
+		{
+			name:       "create - relationship holds",
+			properties: map[string]any{"minReplicas": 1, "maxReplicas": 3},
+		},
+		{
+			name:       "create - missing operand is skipped",
+			properties: map[string]any{"minReplicas": 1},
+		},
+		{
+			name:       "create - relationship violated with other validator failing",
+			properties: map[string]any{"minReplicas": 5, "maxReplicas": 3, "name": "web"},
+			expectedPaths: map[string]string{
+				"maxReplicas": "maxReplicas must be greater than or equal to minReplicas (3 vs 5)",
+				"":            "only one of [name minReplicas] can be provided, got: [name minReplicas]",
+			},
+		},
+		{
+			name:          "update - new value checked against the existing one",
+			oldProperties: map[string]any{"minReplicas": 2, "maxReplicas": 4},
+			properties:    map[string]any{"maxReplicas": 1},
+			expectedPaths: map[string]string{
+				"maxReplicas": "maxReplicas must be greater than or equal to minReplicas (1 vs 2)",
+			},
+		},
+		{
+			name:          "update - relationship holds",
+			oldProperties: map[string]any{"minReplicas": 2, "maxReplicas": 4},
+			properties:    map[string]any{"minReplicas": 3},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var err error
+			if tt.oldProperties != nil {
+				_, err = engine.ApplyUpdate(ctx, testCtx, schema, tt.oldProperties, tt.properties)
+			} else {
+				_, err = engine.ApplyCreate(ctx, testCtx, schema, tt.properties)
+			}
+			if len(tt.expectedPaths) == 0 {
+				if err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				return
+			}
+
+			validationErr, ok := err.(ValidationError)
+			if !ok {
+				t.Fatalf("expected ValidationError, got %T: %v", err, err)
+			}
+			if len(validationErr.Errors) != len(tt.expectedPaths) {
+				t.Fatalf("expected %d errors, got %v", len(tt.expectedPaths), validationErr.Errors)
+			}
+			for _, detail := range validationErr.Errors {
+				if msg, ok := tt.expectedPaths[detail.Path]; !ok || msg != detail.Message {
+					t.Errorf("unexpected error %s: %s", detail.Path, detail.Message)
+				}
+			}
+		})
+	}
+}
+
+func TestEngine_ValidateProperties(t *testing.T) {
+	engine := newTestEngine()
+	ctx := context.Background()
+	testCtx := TestContext{Actor: "user"}
+
+	schema := Schema{
+		Properties: map[string]PropertyDefinition{
+			"name": {Type: "string", Required: true, Validators: []ValidatorConfig{
+				{Type: "minLength", Config: map[string]any{"value": 3}},
+			}},
+			"size": {Type: "integer", Default: 10},
+			"network": {
+				Type: "object",
+				Properties: map[string]PropertyDefinition{
+					"cidr": {Type: "string", Required: true},
+				},
+			},
+			"disks": {
+				Type: "array",
+				Items: &PropertyDefinition{
+					Type: "object",
+					Properties: map[string]PropertyDefinition{
+						"size": {Type: "integer", Validators: []ValidatorConfig{
+							{Type: "min", Config: map[string]any{"value": 1}},
+						}},
+					},
+				},
+			},
+			"password": {Type: "string", Secret: &SecretConfig{Type: "persistent"}, Validators: []ValidatorConfig{
+				{Type: "minLength", Config: map[string]any{"value": 12}},
+			}},
+		},
+	}
+
+	tests := []struct {
+		name          string
+		properties    map[string]any
+		expectedPaths map[string]string
+	}{
+		{
+			name: "conforming properties",
+			properties: map[string]any{
+				"name":     "web",
+				"network":  map[string]any{"cidr": "10.0.0.0/24"},
+				"disks":    []any{map[string]any{"size": 5}},
+				"password": VaultRefPrefix + "abc",
+			},
+		},
+		{
+			name:       "missing default is not generated",
+			properties: map[string]any{"name": "web"},
+		},
+		{
+			name: "non-conforming values are reported at their paths",
+			properties: map[string]any{
+				"name":    "ab",
+				"size":    "large",
+				"network": map[string]any{},
+				"disks":   []any{map[string]any{"size": 0}},
+			},
+			expectedPaths: map[string]string{
+				"name":          "name: string length 2 is less than minimum 3",
+				"size":          "size: expected integer, got string",
+				"network.cidr":  "required property is missing",
+				"disks[0].size": "size: value 0 is less than minimum 1",
+			},
+		},
+		{
+			name:          "missing required property",
+			properties:    map[string]any{},
+			expectedPaths: map[string]string{"name": "required property is missing"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := engine.ValidateProperties(ctx, testCtx, schema, tt.properties)
+			if len(tt.expectedPaths) == 0 {
+				if err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				return
+			}
+
+			validationErr, ok := err.(ValidationError)
+			if !ok {
+				t.Fatalf("expected ValidationError, got %T: %v", err, err)
+			}
+			if len(validationErr.Errors) != len(tt.expectedPaths) {
+				t.Fatalf("expected %d errors, got %v", len(tt.expectedPaths), validationErr.Errors)
+			}
+			for _, detail := range validationErr.Errors {
+				if msg, ok := tt.expectedPaths[detail.Path]; !ok || msg != detail.Message {
+					t.Errorf("unexpected error %s: %s", detail.Path, detail.Message)
+				}
+			}
+		})
+	}
+}
+
+func TestEngine_Apply_UniqueItemsBy(t *testing.T) {
+	engine := newTestEngine()
+	ctx := context.Background()
+	testCtx := TestContext{Actor: "user"}
+
+	portMapping := &PropertyDefinition{
+		Type: "object",
+		Properties: map[string]PropertyDefinition{
+			"port":     {Type: "integer"},
+			"protocol": {Type: "string"},
+		},
+	}
+	schema := Schema{
+		Properties: map[string]PropertyDefinition{
+			"ports": {
+				Type:       "array",
+				Items:      portMapping,
+				Validators: []ValidatorConfig{{Type: "uniqueItems", Config: map[string]any{"by": "port"}}},
+			},
+			"listeners": {
+				Type: "array",
+				Items: &PropertyDefinition{
+					Type: "object",
+					Properties: map[string]PropertyDefinition{
+						"ports": {
+							Type:       "array",
+							Items:      portMapping,
+							Validators: []ValidatorConfig{{Type: "uniqueItems", Config: map[string]any{"by": "port"}}},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	_, err := engine.ApplyCreate(ctx, testCtx, schema, map[string]any{
+		"ports": []any{
+			map[string]any{"port": 80, "protocol": "tcp"},
+			map[string]any{"port": 80, "protocol": "udp"},
+			map[string]any{"port": 443, "protocol": "tcp"},
+			map[string]any{"port": 443, "protocol": "udp"},
+		},
+		"listeners": []any{
+			map[string]any{"ports": []any{
+				map[string]any{"port": 22},
+				map[string]any{"protocol": "tcp"},
+			}},
+		},
+	})
+
+	validationErr, ok := err.(ValidationError)
+	if !ok {
+		t.Fatalf("expected ValidationError, got %T: %v", err, err)
+	}
+	expected := map[string]string{
+		"ports[1].port":              "duplicate port 80, already used by item 0",
+		"ports[3].port":              "duplicate port 443, already used by item 2",
+		"listeners[0].ports[1].port": "port is required to check item uniqueness",
+	}
+	if len(validationErr.Errors) != len(expected) {
+		t.Fatalf("expected %d errors, got %v", len(expected), validationErr.Errors)
+	}
+	for _, detail := range validationErr.Errors {
+		if msg, ok := expected[detail.Path]; !ok || msg != detail.Message {
+			t.Errorf("unexpected error %s: %s", detail.Path, detail.Message)
+		}
+	}
+}
+
+func TestEngine_ValidateSchema_RequiredIf(t *testing.T) {
+	engine := newTestEngine()
+
+	tests := []struct {
+		name       string
+		requiredIf *RequiredIfConfig
+		wantErr    string
+	}{
+		{name: "valid", requiredIf: &RequiredIfConfig{Property: "enabled", Value: true}},
+		{name: "missing property", requiredIf: &RequiredIfConfig{Value: true}, wantErr: "value: requiredIf requires 'property'"},
+		{name: "self reference", requiredIf: &RequiredIfConfig{Property: "value", Value: true}, wantErr: "value: requiredIf cannot reference the property itself"},
+		{name: "unknown sibling", requiredIf: &RequiredIfConfig{Property: "other", Value: true}, wantErr: "value: requiredIf references unknown sibling property 'other'"},
+		{name: "missing value", requiredIf: &RequiredIfConfig{Property: "enabled"}, wantErr: "value: requiredIf requires 'value'"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := engine.ValidateSchema(Schema{
+				Properties: map[string]PropertyDefinition{
+					"enabled": {Type: "boolean"},
+					"value":   {Type: "string", RequiredIf: tt.requiredIf},
+				},
+			})
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("expected error %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
 func TestEngine_ValidateSchema(t *testing.T) {
 	engine := newTestEngine()
 
@@ -585,6 +988,54 @@ func TestEngine_ValidateSchema(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "unknown format",
+			schema: Schema{
+				Properties: map[string]PropertyDefinition{
+					"email": {
+						Type: "string",
+						Validators: []ValidatorConfig{
+							{Type: "format", Config: map[string]any{"value": "phone"}},
+						},
+					},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "known format",
+			schema: Schema{
+				Properties: map[string]PropertyDefinition{
+					"email": {
+						Type: "string",
+						Validators: []ValidatorConfig{
+							{Type: "format", Config: map[string]any{"value": "email"}},
+						},
+					},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "valid update modes",
+			schema: Schema{
+				Properties: map[string]PropertyDefinition{
+					"name":   {Type: "string", UpdateMode: UpdateModeHot},
+					"cpu":    {Type: "integer", UpdateMode: UpdateModeWarm},
+					"region": {Type: "string", UpdateMode: UpdateModeCold},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "invalid update mode",
+			schema: Schema{
+				Properties: map[string]PropertyDefinition{
+					"cpu": {Type: "integer", UpdateMode: "lukewarm"},
+				},
+			},
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -597,6 +1048,44 @@ func TestEngine_ValidateSchema(t *testing.T) {
 	}
 }
 
+func TestSchema_RequiredUpdateMode(t *testing.T) {
+	s := Schema{
+		Properties: map[string]PropertyDefinition{
+			"name":   {Type: "string"},
+			"cpu":    {Type: "integer", UpdateMode: UpdateModeWarm},
+			"memory": {Type: "integer", UpdateMode: UpdateModeWarm},
+			"region": {Type: "string", UpdateMode: UpdateModeCold},
+		},
+	}
+
+	tests := []struct {
+		name       string
+		properties map[string]any
+		want       string
+	}{
+		{name: "no properties", properties: map[string]any{}, want: UpdateModeHot},
+		{name: "hot only", properties: map[string]any{"name": "a"}, want: UpdateModeHot},
+		{name: "warm", properties: map[string]any{"name": "a", "cpu": 2}, want: UpdateModeWarm},
+		{name: "cold wins over warm", properties: map[string]any{"cpu": 2, "region": "eu"}, want: UpdateModeCold},
+		{name: "unknown property", properties: map[string]any{"other": 1}, want: UpdateModeHot},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := s.RequiredUpdateMode(tt.properties); got != tt.want {
+				t.Errorf("RequiredUpdateMode() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if got := fmt.Sprint(s.UpdateModes()); got != "[warm cold]" {
+		t.Errorf("UpdateModes() = %v, want [warm cold]", got)
+	}
+	if got := (Schema{Properties: map[string]PropertyDefinition{"name": {Type: "string"}}}).UpdateModes(); len(got) != 0 {
+		t.Errorf("UpdateModes() = %v, want none", got)
+	}
+}
+
 func TestEngine_WithMockValidator(t *testing.T) {
 	// This test demonstrates using MockPropertyValidator
 	mockValidator := &MockPropertyValidator[TestContext]{}
@@ -689,6 +1178,38 @@ func TestEngine_WithMockGenerator(t *testing.T) {
 	mockGenerator.AssertExpectations(t)
 }
 
+func TestEngine_ApplyCreate_GeneratorErrorCause(t *testing.T) {
+	errExhausted := errors.New("pool exhausted")
+	mockGenerator := &MockGenerator[TestContext]{}
+	mockGenerator.On("Generate", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return(nil, false, fmt.Errorf("address: %w", errExhausted))
+
+	engine := NewEngine(nil, nil, nil, map[string]Generator[TestContext]{"testGen": mockGenerator}, nil)
+	schema := Schema{
+		Properties: map[string]PropertyDefinition{
+			"network": {
+				Type: "object",
+				Properties: map[string]PropertyDefinition{
+					"address": {Type: "string", Generator: &GeneratorConfig{Type: "testGen"}},
+				},
+			},
+		},
+	}
+
+	_, err := engine.ApplyCreate(context.Background(), TestContext{Actor: "user"}, schema, map[string]any{"network": map[string]any{}})
+
+	var validationErr ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("expected ValidationError, got %v", err)
+	}
+	if len(validationErr.Errors) != 1 || validationErr.Errors[0].Path != "network.address" {
+		t.Errorf("unexpected details: %+v", validationErr.Errors)
+	}
+	if !errors.Is(err, errExhausted) {
+		t.Errorf("expected the generator error to be preserved as a cause, got %v", err)
+	}
+}
+
 func TestExtractVaultReferences(t *testing.T) {
 	tests := []struct {
 		name       string
@@ -883,6 +1404,28 @@ func TestEngine_CleanupVaultSecrets(t *testing.T) {
 	}
 }
 
+func TestEngine_CleanupAddedVaultSecrets(t *testing.T) {
+	mockVault := NewMockVault(t)
+	engine := NewEngine[TestContext](nil, nil, nil, nil, mockVault)
+	ctx := context.Background()
+
+	oldProperties := map[string]any{
+		"apiKey":   "vault://abc123",
+		"database": map[string]any{"password": "vault://def456"},
+	}
+	properties := map[string]any{
+		"apiKey":   "vault://ghi789",
+		"database": map[string]any{"password": "vault://def456", "host": "db"},
+	}
+
+	// Only the secret missing from the old properties is deleted
+	mockVault.On("Delete", mock.Anything, "ghi789").Return(nil).Once()
+
+	engine.CleanupAddedVaultSecrets(ctx, oldProperties, properties)
+
+	mockVault.AssertExpectations(t)
+}
+
 func TestEngine_CleanupVaultSecrets_WithErrors(t *testing.T) {
 	mockVault := NewMockVault(t)
 	engine := NewEngine[TestContext](nil, nil, nil, nil, mockVault)
@@ -1,11 +1,30 @@
 // Validation error types for schema processing
 package schema
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// MsgRequiredIfMissing is the message of a property missing while its requiredIf condition holds
+const MsgRequiredIfMissing = "property is required when %s is %v"
+
+// Messages of the format validator, one per format so that clients can localize them
+const (
+	MsgFormatEmail    = "%s: value is not a valid email address"
+	MsgFormatIPv4     = "%s: value is not a valid IPv4 address"
+	MsgFormatIPv6     = "%s: value is not a valid IPv6 address"
+	MsgFormatCIDR     = "%s: value is not a valid CIDR block"
+	MsgFormatHostname = "%s: value is not a valid hostname"
+	MsgFormatUUID     = "%s: value is not a valid UUID"
+	MsgFormatURI      = "%s: value is not a valid URI"
+)
 
 // ValidationError represents a collection of validation errors
 type ValidationError struct {
 	Errors []ValidationErrorDetail `json:"errors"`
+	causes []error
 }
 
 // ValidationErrorDetail represents a single validation error with its path
@@ -30,3 +49,34 @@ func (e ValidationError) Error() string {
 	return fmt.Sprintf("validation failed: %d errors", len(e.Errors))
 }
 
+// Unwrap returns the errors the details were built from, so typed errors raised
+// while processing a property (e.g. by a generator) remain detectable with errors.As
+func (e ValidationError) Unwrap() []error {
+	return e.causes
+}
+
+
+// nestedValidationDetails returns the details of a nested validation error with paths prefixed by the parent path
+func nestedValidationDetails(prefix string, err error) ([]ValidationErrorDetail, bool) {
+	var validationErr ValidationError
+	if !errors.As(err, &validationErr) {
+		return nil, false
+	}
+	details := make([]ValidationErrorDetail, len(validationErr.Errors))
+	for i, d := range validationErr.Errors {
+		details[i] = ValidationErrorDetail{Path: joinPath(prefix, d.Path), Message: d.Message}
+	}
+	return details, true
+}
+
+// joinPath appends a relative property path to a parent path
+func joinPath(parent, path string) string {
+	switch {
+	case path == "":
+		return parent
+	case parent == "" || strings.HasPrefix(path, "["):
+		return parent + path
+	default:
+		return parent + "." + path
+	}
+}
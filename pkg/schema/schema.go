@@ -7,6 +7,8 @@ import (
 	"database/sql/driver"
 	"encoding/json"
 	"fmt"
+	"slices"
+	"strings"
 )
 
 // Operation represents the type of write operation being performed
@@ -17,6 +19,20 @@ const (
 	OperationUpdate Operation = "update"
 )
 
+// Update modes of a property, from the least to the most disruptive
+const (
+	UpdateModeHot  = "hot"  // Applied live, default
+	UpdateModeWarm = "warm" // Applied with a brief pause of the service
+	UpdateModeCold = "cold" // Applied with a full stop of the service
+)
+
+var updateModeRank = map[string]int{
+	"":             0,
+	UpdateModeHot:  0,
+	UpdateModeWarm: 1,
+	UpdateModeCold: 2,
+}
+
 // Schema defines the structure and validation rules for a set of properties
 type Schema struct {
 	Properties map[string]PropertyDefinition `json:"properties"` // Property definitions
@@ -44,6 +60,58 @@ func (s *Schema) Scan(value any) error {
 	return json.Unmarshal(bytes, s)
 }
 
+// RequiredUpdateMode returns the most disruptive update mode among the given top-level properties
+func (s Schema) RequiredUpdateMode(properties map[string]any) string {
+	mode := UpdateModeHot
+	for name := range properties {
+		propMode := s.Properties[name].UpdateMode
+		if updateModeRank[propMode] > updateModeRank[mode] {
+			mode = propMode
+		}
+	}
+	return mode
+}
+
+// UpdateModes returns the update modes used by the top-level properties, hot excluded
+func (s Schema) UpdateModes() []string {
+	var modes []string
+	for _, mode := range []string{UpdateModeWarm, UpdateModeCold} {
+		for _, propDef := range s.Properties {
+			if propDef.UpdateMode == mode {
+				modes = append(modes, mode)
+				break
+			}
+		}
+	}
+	return modes
+}
+
+// SensitivePaths returns the sorted JSON Pointer paths of the sensitive properties
+// The items of sensitive arrays are matched by the * token
+func (s Schema) SensitivePaths() []string {
+	paths := []string{}
+	for name, propDef := range s.Properties {
+		paths = appendSensitivePaths(paths, "/"+pointerTokenEscaper.Replace(name), propDef)
+	}
+	slices.Sort(paths)
+	return paths
+}
+
+var pointerTokenEscaper = strings.NewReplacer("~", "~0", "/", "~1")
+
+func appendSensitivePaths(paths []string, path string, propDef PropertyDefinition) []string {
+	if propDef.Sensitive {
+		return append(paths, path)
+	}
+	for name, child := range propDef.Properties {
+		paths = appendSensitivePaths(paths, path+"/"+pointerTokenEscaper.Replace(name), child)
+	}
+	if propDef.Items != nil {
+		paths = appendSensitivePaths(paths, path+"/*", *propDef.Items)
+	}
+	return paths
+}
+
 // SchemaValidatorConfig defines a schema-level validator configuration
 type SchemaValidatorConfig struct {
 	Type   string         `json:"type"`   // "exactlyOne", etc.
@@ -58,6 +126,12 @@ type PropertyDefinition struct {
 	Required  bool   `json:"required"`  // Must be present
 	Immutable bool   `json:"immutable"` // Cannot be updated after creation
 
+	// Conditional requirement on a sibling property value
+	RequiredIf *RequiredIfConfig `json:"requiredIf,omitempty"`
+
+	// How a change is applied: hot (default), warm or cold
+	UpdateMode string `json:"updateMode,omitempty"`
+
 	// Authorization rules (all must pass - AND logic)
 	Authorizers []AuthorizerConfig `json:"authorizers,omitempty"`
 
@@ -67,6 +141,9 @@ type PropertyDefinition struct {
 	// Secret handling (vault integration)
 	Secret *SecretConfig `json:"secret,omitempty"`
 
+	// Sensitive values are redacted from the event diffs
+	Sensitive bool `json:"sensitive,omitempty"`
+
 	// Value generation (zero or one)
 	Generator *GeneratorConfig `json:"generator,omitempty"`
 
@@ -78,6 +155,12 @@ type PropertyDefinition struct {
 	Items      *PropertyDefinition           `json:"items,omitempty"`      // For type: array
 }
 
+// RequiredIfConfig makes a property required when a sibling property has a given value
+type RequiredIfConfig struct {
+	Property string `json:"property"` // Sibling property in the same object
+	Value    any    `json:"value"`    // Value of the sibling making the property required
+}
+
 // SecretConfig defines secret handling configuration
 type SecretConfig struct {
 	Type string `json:"type"` // "persistent" or "ephemeral"
@@ -101,6 +184,29 @@ type AuthorizerConfig struct {
 	Config map[string]any `json:"config"` // Type-specific configuration
 }
 
+// AuthorizerTypeActor is the type of the authorizers restricting the actors allowed to set a property
+const AuthorizerTypeActor = "actor"
+
+// Actors returns the actors allowed by an actor authorizer, nil for an authorizer of another type
+func (c AuthorizerConfig) Actors() []string {
+	if c.Type != AuthorizerTypeActor {
+		return nil
+	}
+	return c.Strings("actors")
+}
+
+// Strings returns the strings of an array of the configuration, the values of another type are skipped
+func (c AuthorizerConfig) Strings(key string) []string {
+	raw, _ := c.Config[key].([]any)
+	values := make([]string, 0, len(raw))
+	for _, v := range raw {
+		if s, ok := v.(string); ok {
+			values = append(values, s)
+		}
+	}
+	return values
+}
+
 // Authorizer checks if an operation is authorized
 // C is the context type specific to the domain (e.g., ServicePropertyContext)
 type Authorizer[C any] interface {
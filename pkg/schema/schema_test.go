@@ -0,0 +1,65 @@
+package schema
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSensitivePaths(t *testing.T) {
+	sch := Schema{
+		Properties: map[string]PropertyDefinition{
+			"password": {Type: "string", Sensitive: true},
+			"size":     {Type: "integer"},
+			"a/b~c":    {Type: "string", Sensitive: true},
+			"nested": {
+				Type: "object",
+				Properties: map[string]PropertyDefinition{
+					"apiKey": {Type: "string", Sensitive: true},
+					"user":   {Type: "string"},
+				},
+			},
+			"keys": {
+				Type: "array",
+				Items: &PropertyDefinition{
+					Type: "object",
+					Properties: map[string]PropertyDefinition{
+						"value": {Type: "string", Sensitive: true},
+					},
+				},
+			},
+			"certificate": {
+				Type:      "object",
+				Sensitive: true,
+				Properties: map[string]PropertyDefinition{
+					"key": {Type: "string", Sensitive: true},
+				},
+			},
+		},
+	}
+
+	expected := []string{"/a~1b~0c", "/certificate", "/keys/*/value", "/nested/apiKey", "/password"}
+	if got := sch.SensitivePaths(); !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected %v, got %v", expected, got)
+	}
+
+	if got := (Schema{}).SensitivePaths(); len(got) != 0 {
+		t.Errorf("expected no paths, got %v", got)
+	}
+}
+
+func TestAuthorizerConfig_Actors(t *testing.T) {
+	actor := AuthorizerConfig{Type: AuthorizerTypeActor, Config: map[string]any{"actors": []any{"agent", 1, "system"}}}
+	if got := actor.Actors(); !reflect.DeepEqual(got, []string{"agent", "system"}) {
+		t.Errorf("Actors() = %v, want [agent system]", got)
+	}
+
+	state := AuthorizerConfig{Type: "state", Config: map[string]any{"actors": []any{"user"}}}
+	if got := state.Actors(); got != nil {
+		t.Errorf("Actors() of a state authorizer = %v, want nil", got)
+	}
+
+	missing := AuthorizerConfig{Type: AuthorizerTypeActor}
+	if got := missing.Actors(); len(got) != 0 {
+		t.Errorf("Actors() without configuration = %v, want empty", got)
+	}
+}
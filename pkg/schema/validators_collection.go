@@ -1,8 +1,9 @@
-// Collection validators for enum, minItems, and maxItems
+// Collection validators for enum, minItems, maxItems, and uniqueItems
 package schema
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"reflect"
 )
@@ -96,3 +97,92 @@ func (v *MaxItemsValidator[C]) ValidateConfig(propPath string, config map[string
 	_, err := getNonNegativeIntConfig(propPath, "maxItems", "value", config)
 	return err
 }
+
+// UniqueItemsValidator validates that array items are unique
+// With the 'by' config, items must be objects and are compared on the value at that key path,
+// e.g. "port" for a list of port mappings; otherwise whole items are compared when 'value' is true.
+// Each duplicate is reported at its own index.
+type UniqueItemsValidator[C any] struct{}
+
+func (v *UniqueItemsValidator[C]) Validate(ctx context.Context, schemaCtx C, operation Operation, propPath string, oldValue, newValue any, config map[string]any) error {
+	arr, ok := newValue.([]any)
+	if !ok {
+		return fmt.Errorf("%s: expected array for uniqueItems validator", propPath)
+	}
+
+	by, _ := config["by"].(string)
+	if by == "" {
+		if enabled, _ := config["value"].(bool); !enabled {
+			return nil
+		}
+	}
+
+	var details []ValidationErrorDetail
+	seen := make(map[string]int, len(arr))
+	for i, item := range arr {
+		index := fmt.Sprintf("[%d]", i)
+		key := item
+		if by != "" {
+			obj, ok := item.(map[string]any)
+			if !ok {
+				details = append(details, ValidationErrorDetail{
+					Path:    index,
+					Message: fmt.Sprintf("expected object item to check uniqueness by %s, got %T", by, item),
+				})
+				continue
+			}
+			if key, ok = lookupPropertyPath(obj, by); !ok {
+				details = append(details, ValidationErrorDetail{
+					Path:    joinPath(index, by),
+					Message: fmt.Sprintf("%s is required to check item uniqueness", by),
+				})
+				continue
+			}
+		}
+
+		encoded, err := json.Marshal(key)
+		if err != nil {
+			return fmt.Errorf("%s: cannot compare item %d: %w", propPath, i, err)
+		}
+		first, duplicate := seen[string(encoded)]
+		if !duplicate {
+			seen[string(encoded)] = i
+			continue
+		}
+		if by != "" {
+			details = append(details, ValidationErrorDetail{
+				Path:    joinPath(index, by),
+				Message: fmt.Sprintf("duplicate %s %s, already used by item %d", by, encoded, first),
+			})
+		} else {
+			details = append(details, ValidationErrorDetail{
+				Path:    index,
+				Message: fmt.Sprintf("duplicate item, same as item %d", first),
+			})
+		}
+	}
+
+	if len(details) > 0 {
+		return NewValidationError(details)
+	}
+	return nil
+}
+
+func (v *UniqueItemsValidator[C]) ValidateConfig(propPath string, config map[string]any) error {
+	if value, ok := config["value"]; ok {
+		if _, ok := value.(bool); !ok {
+			return fmt.Errorf("%s: uniqueItems 'value' config must be a boolean", propPath)
+		}
+	}
+	if by, ok := config["by"]; ok {
+		if str, ok := by.(string); !ok || str == "" {
+			return fmt.Errorf("%s: uniqueItems 'by' config must be a non-empty key path", propPath)
+		}
+	}
+	if _, hasValue := config["value"]; !hasValue {
+		if _, hasBy := config["by"]; !hasBy {
+			return fmt.Errorf("%s: uniqueItems validator requires 'value' or 'by' config", propPath)
+		}
+	}
+	return nil
+}
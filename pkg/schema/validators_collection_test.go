@@ -2,6 +2,7 @@ package schema
 
 import (
 	"context"
+	"reflect"
 	"testing"
 )
 
@@ -288,3 +289,140 @@ func TestMaxItemsValidator_ValidateConfig(t *testing.T) {
 		})
 	}
 }
+
+func TestUniqueItemsValidator_Validate(t *testing.T) {
+	validator := &UniqueItemsValidator[TestContext]{}
+	ctx := context.Background()
+	testCtx := TestContext{Actor: "user"}
+
+	port := func(port any, protocol string) map[string]any {
+		return map[string]any{"port": port, "protocol": protocol}
+	}
+
+	tests := []struct {
+		name        string
+		value       any
+		config      map[string]any
+		wantDetails []ValidationErrorDetail
+		wantErr     bool
+	}{
+		{
+			name:   "valid - unique items",
+			value:  []any{"a", "b", "c"},
+			config: map[string]any{"value": true},
+		},
+		{
+			name:        "invalid - duplicate items",
+			value:       []any{"a", "b", "a", "a"},
+			config:      map[string]any{"value": true},
+			wantDetails: []ValidationErrorDetail{{Path: "[2]", Message: "duplicate item, same as item 0"}, {Path: "[3]", Message: "duplicate item, same as item 0"}},
+		},
+		{
+			name:   "valid - disabled",
+			value:  []any{"a", "a"},
+			config: map[string]any{"value": false},
+		},
+		{
+			name:   "valid - unique by key",
+			value:  []any{port(80, "tcp"), port(443, "tcp")},
+			config: map[string]any{"by": "port"},
+		},
+		{
+			name:        "invalid - duplicate keys with mixed number types",
+			value:       []any{port(80, "tcp"), port(443, "tcp"), port(float64(80), "udp")},
+			config:      map[string]any{"by": "port"},
+			wantDetails: []ValidationErrorDetail{{Path: "[2].port", Message: "duplicate port 80, already used by item 0"}},
+		},
+		{
+			name: "invalid - duplicate nested keys",
+			value: []any{
+				map[string]any{"target": map[string]any{"host": "a"}},
+				map[string]any{"target": map[string]any{"host": "a"}},
+			},
+			config:      map[string]any{"by": "target.host"},
+			wantDetails: []ValidationErrorDetail{{Path: "[1].target.host", Message: `duplicate target.host "a", already used by item 0`}},
+		},
+		{
+			name:        "invalid - missing key",
+			value:       []any{port(80, "tcp"), map[string]any{"protocol": "tcp"}},
+			config:      map[string]any{"by": "port"},
+			wantDetails: []ValidationErrorDetail{{Path: "[1].port", Message: "port is required to check item uniqueness"}},
+		},
+		{
+			name:        "invalid - non-object item",
+			value:       []any{port(80, "tcp"), "8080"},
+			config:      map[string]any{"by": "port"},
+			wantDetails: []ValidationErrorDetail{{Path: "[1]", Message: "expected object item to check uniqueness by port, got string"}},
+		},
+		{
+			name:    "invalid - non-array value",
+			value:   "not-an-array",
+			config:  map[string]any{"value": true},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validator.Validate(ctx, testCtx, OperationCreate, "ports", nil, tt.value, tt.config)
+			if tt.wantDetails == nil {
+				if (err != nil) != tt.wantErr {
+					t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+				}
+				return
+			}
+			validationErr, ok := err.(ValidationError)
+			if !ok {
+				t.Fatalf("expected ValidationError, got %T: %v", err, err)
+			}
+			if !reflect.DeepEqual(validationErr.Errors, tt.wantDetails) {
+				t.Errorf("Validate() details = %v, want %v", validationErr.Errors, tt.wantDetails)
+			}
+		})
+	}
+}
+
+func TestUniqueItemsValidator_ValidateConfig(t *testing.T) {
+	validator := &UniqueItemsValidator[TestContext]{}
+
+	tests := []struct {
+		name    string
+		config  map[string]any
+		wantErr bool
+	}{
+		{
+			name:    "valid config - value",
+			config:  map[string]any{"value": true},
+			wantErr: false,
+		},
+		{
+			name:    "valid config - by",
+			config:  map[string]any{"by": "port"},
+			wantErr: false,
+		},
+		{
+			name:    "invalid - empty config",
+			config:  map[string]any{},
+			wantErr: true,
+		},
+		{
+			name:    "invalid - non-boolean value",
+			config:  map[string]any{"value": "yes"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid - empty by",
+			config:  map[string]any{"by": ""},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validator.ValidateConfig("ports", tt.config)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
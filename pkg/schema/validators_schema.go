@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strings"
 )
 
 // ExactlyOneValidator ensures exactly one property from a group must be provided
@@ -156,3 +157,129 @@ func (v *UniqueValuesValidator[C]) ValidateConfig(config map[string]any) error {
 
 	return nil
 }
+
+// compareOperators maps the operators of the compare validator to their description
+var compareOperators = map[string]string{
+	"eq":  "equal to",
+	"ne":  "different from",
+	"gt":  "greater than",
+	"gte": "greater than or equal to",
+	"lt":  "less than",
+	"lte": "less than or equal to",
+}
+
+// CompareValidator ensures a relationship between two properties holds, e.g. maxReplicas >= minReplicas
+// Properties are referenced by dotted paths; the rule is skipped when either operand is missing
+// or when the operands cannot be compared, as type errors are reported by the property validators
+type CompareValidator[C any] struct{}
+
+func (v *CompareValidator[C]) Validate(ctx context.Context, schemaCtx C, operation Operation, oldProperties, newProperties map[string]any, config map[string]any) error {
+	field, _ := config["field"].(string)
+	other, _ := config["other"].(string)
+	operator, _ := config["operator"].(string)
+
+	fieldValue, ok := lookupPropertyPath(newProperties, field)
+	if !ok {
+		return nil
+	}
+	otherValue, ok := lookupPropertyPath(newProperties, other)
+	if !ok {
+		return nil
+	}
+
+	holds, comparable := compareValues(operator, fieldValue, otherValue)
+	if !comparable || holds {
+		return nil
+	}
+
+	return NewValidationError([]ValidationErrorDetail{{
+		Path:    field,
+		Message: fmt.Sprintf("%s must be %s %s (%v vs %v)", field, compareOperators[operator], other, fieldValue, otherValue),
+	}})
+}
+
+func (v *CompareValidator[C]) ValidateConfig(config map[string]any) error {
+	field, ok := config["field"].(string)
+	if !ok || field == "" {
+		return fmt.Errorf("compare validator requires 'field' config as string")
+	}
+	other, ok := config["other"].(string)
+	if !ok || other == "" {
+		return fmt.Errorf("compare validator requires 'other' config as string")
+	}
+	if field == other {
+		return fmt.Errorf("compare validator 'field' and 'other' must be different properties")
+	}
+	operator, ok := config["operator"].(string)
+	if !ok {
+		return fmt.Errorf("compare validator requires 'operator' config as string")
+	}
+	if _, ok := compareOperators[operator]; !ok {
+		return fmt.Errorf("compare validator: unsupported operator %q, must be one of eq, ne, gt, gte, lt, lte", operator)
+	}
+	return nil
+}
+
+// lookupPropertyPath returns the non-nil value at a dotted path of nested objects
+func lookupPropertyPath(properties map[string]any, path string) (any, bool) {
+	var value any = properties
+	for _, key := range strings.Split(path, ".") {
+		obj, ok := value.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		if value, ok = obj[key]; !ok || value == nil {
+			return nil, false
+		}
+	}
+	return value, true
+}
+
+// compareValues evaluates the operator on two values
+// Numbers and strings support every operator, other values only eq and ne;
+// the second result is false when the values cannot be compared with the operator
+func compareValues(operator string, a, b any) (bool, bool) {
+	var cmp int
+	aNum, aErr := convertToFloat64("", "compare", a)
+	bNum, bErr := convertToFloat64("", "compare", b)
+	aStr, aIsStr := a.(string)
+	bStr, bIsStr := b.(string)
+	switch {
+	case aErr == nil && bErr == nil:
+		cmp = cmpFloat(aNum, bNum)
+	case aIsStr && bIsStr:
+		cmp = strings.Compare(aStr, bStr)
+	case operator == "eq":
+		return jsonEqual(a, b), true
+	case operator == "ne":
+		return !jsonEqual(a, b), true
+	default:
+		return false, false
+	}
+
+	switch operator {
+	case "eq":
+		return cmp == 0, true
+	case "ne":
+		return cmp != 0, true
+	case "gt":
+		return cmp > 0, true
+	case "gte":
+		return cmp >= 0, true
+	case "lt":
+		return cmp < 0, true
+	case "lte":
+		return cmp <= 0, true
+	}
+	return false, false
+}
+
+func cmpFloat(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
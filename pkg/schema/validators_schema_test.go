@@ -288,3 +288,148 @@ func TestUniqueValuesValidator_ValidateConfig(t *testing.T) {
 		})
 	}
 }
+
+func TestCompareValidator_Validate(t *testing.T) {
+	validator := &CompareValidator[TestContext]{}
+	ctx := context.Background()
+	testCtx := TestContext{Actor: "user"}
+
+	tests := []struct {
+		name          string
+		newProperties map[string]any
+		config        map[string]any
+		wantErr       string
+	}{
+		{
+			name:          "valid - greater",
+			newProperties: map[string]any{"maxReplicas": 5, "minReplicas": 3},
+			config:        map[string]any{"field": "maxReplicas", "operator": "gte", "other": "minReplicas"},
+		},
+		{
+			name:          "valid - equal with mixed number types",
+			newProperties: map[string]any{"maxReplicas": float64(3), "minReplicas": 3},
+			config:        map[string]any{"field": "maxReplicas", "operator": "gte", "other": "minReplicas"},
+		},
+		{
+			name:          "invalid - lower",
+			newProperties: map[string]any{"maxReplicas": 2, "minReplicas": 3},
+			config:        map[string]any{"field": "maxReplicas", "operator": "gte", "other": "minReplicas"},
+			wantErr:       "maxReplicas must be greater than or equal to minReplicas (2 vs 3)",
+		},
+		{
+			name:          "invalid - strings",
+			newProperties: map[string]any{"start": "2024-02-01", "end": "2024-01-01"},
+			config:        map[string]any{"field": "end", "operator": "gt", "other": "start"},
+			wantErr:       "end must be greater than start (2024-01-01 vs 2024-02-01)",
+		},
+		{
+			name:          "invalid - ne on booleans",
+			newProperties: map[string]any{"primary": true, "secondary": true},
+			config:        map[string]any{"field": "secondary", "operator": "ne", "other": "primary"},
+			wantErr:       "secondary must be different from primary (true vs true)",
+		},
+		{
+			name: "invalid - nested paths",
+			newProperties: map[string]any{
+				"scaling": map[string]any{"max": 1, "min": 2},
+			},
+			config:  map[string]any{"field": "scaling.max", "operator": "gte", "other": "scaling.min"},
+			wantErr: "scaling.max must be greater than or equal to scaling.min (1 vs 2)",
+		},
+		{
+			name:          "skipped - missing field",
+			newProperties: map[string]any{"minReplicas": 3},
+			config:        map[string]any{"field": "maxReplicas", "operator": "gte", "other": "minReplicas"},
+		},
+		{
+			name:          "skipped - nil other",
+			newProperties: map[string]any{"maxReplicas": 2, "minReplicas": nil},
+			config:        map[string]any{"field": "maxReplicas", "operator": "gte", "other": "minReplicas"},
+		},
+		{
+			name:          "skipped - missing nested parent",
+			newProperties: map[string]any{"scaling": "not-an-object"},
+			config:        map[string]any{"field": "scaling.max", "operator": "gte", "other": "scaling.min"},
+		},
+		{
+			name:          "skipped - types cannot be ordered",
+			newProperties: map[string]any{"maxReplicas": "many", "minReplicas": 3},
+			config:        map[string]any{"field": "maxReplicas", "operator": "gte", "other": "minReplicas"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validator.Validate(ctx, testCtx, OperationCreate, nil, tt.newProperties, tt.config)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Validate() unexpected error = %v", err)
+				}
+				return
+			}
+			validationErr, ok := err.(ValidationError)
+			if !ok {
+				t.Fatalf("expected ValidationError, got %T: %v", err, err)
+			}
+			if len(validationErr.Errors) != 1 {
+				t.Fatalf("expected 1 error, got %v", validationErr.Errors)
+			}
+			if validationErr.Errors[0].Path != tt.config["field"] {
+				t.Errorf("expected path %v, got %s", tt.config["field"], validationErr.Errors[0].Path)
+			}
+			if validationErr.Errors[0].Message != tt.wantErr {
+				t.Errorf("expected message %q, got %q", tt.wantErr, validationErr.Errors[0].Message)
+			}
+		})
+	}
+}
+
+func TestCompareValidator_ValidateConfig(t *testing.T) {
+	validator := &CompareValidator[TestContext]{}
+
+	tests := []struct {
+		name    string
+		config  map[string]any
+		wantErr bool
+	}{
+		{
+			name:    "valid config",
+			config:  map[string]any{"field": "maxReplicas", "operator": "gte", "other": "minReplicas"},
+			wantErr: false,
+		},
+		{
+			name:    "invalid - missing field",
+			config:  map[string]any{"operator": "gte", "other": "minReplicas"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid - missing other",
+			config:  map[string]any{"field": "maxReplicas", "operator": "gte"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid - same property",
+			config:  map[string]any{"field": "maxReplicas", "operator": "gte", "other": "maxReplicas"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid - missing operator",
+			config:  map[string]any{"field": "maxReplicas", "other": "minReplicas"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid - unsupported operator",
+			config:  map[string]any{"field": "maxReplicas", "operator": ">=", "other": "minReplicas"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validator.ValidateConfig(tt.config)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -1,11 +1,18 @@
-// String validators for minLength, maxLength, and pattern
+// String validators for minLength, maxLength, pattern, and format
 package schema
 
 import (
 	"context"
 	"fmt"
+	"net/mail"
+	"net/netip"
+	"net/url"
 	"regexp"
+	"slices"
+	"strings"
 	"sync"
+
+	"github.com/google/uuid"
 )
 
 // MinLengthValidator validates minimum string length
@@ -61,10 +68,24 @@ func (v *MaxLengthValidator[C]) ValidateConfig(propPath string, config map[strin
 }
 
 // PatternValidator validates regex pattern
+// Patterns are compiled once, when the schema is validated or on first use, and cached by pattern string
 type PatternValidator[C any] struct {
 	cache sync.Map // map[string]*regexp.Regexp
 }
 
+// compile returns the cached compiled pattern, compiling and caching it on first use
+func (v *PatternValidator[C]) compile(pattern string) (*regexp.Regexp, error) {
+	if cached, ok := v.cache.Load(pattern); ok {
+		return cached.(*regexp.Regexp), nil
+	}
+	regex, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	v.cache.Store(pattern, regex)
+	return regex, nil
+}
+
 func (v *PatternValidator[C]) Validate(ctx context.Context, schemaCtx C, operation Operation, propPath string, oldValue, newValue any, config map[string]any) error {
 	str, ok := newValue.(string)
 	if !ok {
@@ -76,18 +97,9 @@ func (v *PatternValidator[C]) Validate(ctx context.Context, schemaCtx C, operati
 		return fmt.Errorf("%s: pattern validator requires 'pattern' config", propPath)
 	}
 
-	// Try to load from cache
-	var regex *regexp.Regexp
-	if cached, ok := v.cache.Load(pattern); ok {
-		regex = cached.(*regexp.Regexp)
-	} else {
-		// Compile and cache
-		var err error
-		regex, err = regexp.Compile(pattern)
-		if err != nil {
-			return fmt.Errorf("%s: invalid regex pattern: %s", propPath, pattern)
-		}
-		v.cache.Store(pattern, regex)
+	regex, err := v.compile(pattern)
+	if err != nil {
+		return fmt.Errorf("%s: invalid regex pattern: %s", propPath, pattern)
 	}
 
 	if !regex.MatchString(str) {
@@ -103,11 +115,123 @@ func (v *PatternValidator[C]) ValidateConfig(propPath string, config map[string]
 		return fmt.Errorf("%s: pattern validator requires 'pattern' config", propPath)
 	}
 
-	// Validate that the pattern is a valid regex
-	_, err := regexp.Compile(pattern)
+	// Validate that the pattern is a valid regex, caching it for the property validations
+	_, err := v.compile(pattern)
 	if err != nil {
 		return fmt.Errorf("%s: invalid regex pattern: %w", propPath, err)
 	}
 
 	return nil
 }
+
+// stringFormat checks a string against a named format
+type stringFormat struct {
+	valid   func(string) bool
+	message string
+}
+
+// stringFormats are the formats supported by the format validator
+var stringFormats = map[string]stringFormat{
+	"email":    {valid: isEmail, message: MsgFormatEmail},
+	"ipv4":     {valid: isIPv4, message: MsgFormatIPv4},
+	"ipv6":     {valid: isIPv6, message: MsgFormatIPv6},
+	"cidr":     {valid: isCIDR, message: MsgFormatCIDR},
+	"hostname": {valid: isHostname, message: MsgFormatHostname},
+	"uuid":     {valid: isUUID, message: MsgFormatUUID},
+	"uri":      {valid: isURI, message: MsgFormatURI},
+}
+
+// FormatValidator validates strings against a named format (email, ipv4, ipv6, cidr, hostname, uuid, uri)
+type FormatValidator[C any] struct{}
+
+func (v *FormatValidator[C]) Validate(ctx context.Context, schemaCtx C, operation Operation, propPath string, oldValue, newValue any, config map[string]any) error {
+	str, ok := newValue.(string)
+	if !ok {
+		return fmt.Errorf("%s: expected string for format validator", propPath)
+	}
+
+	format, err := getFormatConfig(propPath, config)
+	if err != nil {
+		return err
+	}
+
+	if !format.valid(str) {
+		return fmt.Errorf(format.message, propPath)
+	}
+
+	return nil
+}
+
+func (v *FormatValidator[C]) ValidateConfig(propPath string, config map[string]any) error {
+	_, err := getFormatConfig(propPath, config)
+	return err
+}
+
+// getFormatConfig returns the format named by the 'value' config
+func getFormatConfig(propPath string, config map[string]any) (stringFormat, error) {
+	name, ok := config["value"].(string)
+	if !ok {
+		return stringFormat{}, fmt.Errorf("%s: format validator requires 'value' config as string", propPath)
+	}
+	format, ok := stringFormats[name]
+	if !ok {
+		names := make([]string, 0, len(stringFormats))
+		for n := range stringFormats {
+			names = append(names, n)
+		}
+		slices.Sort(names)
+		return stringFormat{}, fmt.Errorf("%s: unknown format '%s', must be one of: %s", propPath, name, strings.Join(names, ", "))
+	}
+	return format, nil
+}
+
+// isEmail accepts a bare address, without display name or angle brackets
+func isEmail(s string) bool {
+	addr, err := mail.ParseAddress(s)
+	return err == nil && addr.Address == s
+}
+
+func isIPv4(s string) bool {
+	addr, err := netip.ParseAddr(s)
+	return err == nil && addr.Is4()
+}
+
+func isIPv6(s string) bool {
+	addr, err := netip.ParseAddr(s)
+	return err == nil && addr.Is6() && addr.Zone() == ""
+}
+
+func isCIDR(s string) bool {
+	_, err := netip.ParsePrefix(s)
+	return err == nil
+}
+
+// isHostname accepts RFC 1123 host names
+func isHostname(s string) bool {
+	if len(s) == 0 || len(s) > 253 {
+		return false
+	}
+	for _, label := range strings.Split(s, ".") {
+		if len(label) == 0 || len(label) > 63 || label[0] == '-' || label[len(label)-1] == '-' {
+			return false
+		}
+		for _, r := range label {
+			if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-') {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// isUUID accepts the canonical hyphenated form only
+func isUUID(s string) bool {
+	_, err := uuid.Parse(s)
+	return err == nil && len(s) == 36
+}
+
+// isURI accepts absolute URIs
+func isURI(s string) bool {
+	u, err := url.Parse(s)
+	return err == nil && u.Scheme != ""
+}
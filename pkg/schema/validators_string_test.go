@@ -297,3 +297,131 @@ func TestPatternValidator_CachesCompiledRegex(t *testing.T) {
 	// Verify cache has entry (we can't directly inspect sync.Map, but we tested it works)
 	// If caching wasn't working, the second call would still work but be slower
 }
+
+func TestPatternValidator_ValidateConfigCachesCompiledRegex(t *testing.T) {
+	validator := &PatternValidator[TestContext]{}
+
+	if err := validator.ValidateConfig("prop", map[string]any{"pattern": "^[a-z]+$"}); err != nil {
+		t.Fatalf("ValidateConfig failed: %v", err)
+	}
+	cached, ok := validator.cache.Load("^[a-z]+$")
+	if !ok {
+		t.Fatal("expected the pattern to be cached by ValidateConfig")
+	}
+
+	regex, err := validator.compile("^[a-z]+$")
+	if err != nil {
+		t.Fatalf("compile failed: %v", err)
+	}
+	if regex != cached {
+		t.Error("expected compile to reuse the cached regex")
+	}
+
+	if err := validator.ValidateConfig("prop", map[string]any{"pattern": "[invalid(regex"}); err == nil {
+		t.Fatal("expected an error for an invalid pattern")
+	}
+	if _, ok := validator.cache.Load("[invalid(regex"); ok {
+		t.Error("invalid patterns must not be cached")
+	}
+}
+
+func TestFormatValidator_Validate(t *testing.T) {
+	validator := &FormatValidator[TestContext]{}
+	ctx := context.Background()
+	testCtx := TestContext{Actor: "user"}
+
+	tests := []struct {
+		name    string
+		value   any
+		format  string
+		wantErr string
+	}{
+		{name: "valid - email", value: "ops@example.com", format: "email"},
+		{name: "invalid - email with display name", value: "Ops <ops@example.com>", format: "email", wantErr: "testProp: value is not a valid email address"},
+		{name: "invalid - email without domain", value: "ops", format: "email", wantErr: "testProp: value is not a valid email address"},
+		{name: "valid - ipv4", value: "10.0.0.1", format: "ipv4"},
+		{name: "invalid - ipv4 out of range", value: "10.0.0.256", format: "ipv4", wantErr: "testProp: value is not a valid IPv4 address"},
+		{name: "invalid - ipv6 as ipv4", value: "::1", format: "ipv4", wantErr: "testProp: value is not a valid IPv4 address"},
+		{name: "valid - ipv6", value: "2001:db8::1", format: "ipv6"},
+		{name: "invalid - ipv4 as ipv6", value: "10.0.0.1", format: "ipv6", wantErr: "testProp: value is not a valid IPv6 address"},
+		{name: "valid - ipv4 cidr", value: "10.0.0.0/24", format: "cidr"},
+		{name: "valid - ipv6 cidr", value: "2001:db8::/32", format: "cidr"},
+		{name: "invalid - cidr without prefix", value: "10.0.0.0", format: "cidr", wantErr: "testProp: value is not a valid CIDR block"},
+		{name: "invalid - cidr prefix too long", value: "10.0.0.0/33", format: "cidr", wantErr: "testProp: value is not a valid CIDR block"},
+		{name: "valid - hostname", value: "web-01.example.com", format: "hostname"},
+		{name: "invalid - hostname with leading hyphen", value: "-web.example.com", format: "hostname", wantErr: "testProp: value is not a valid hostname"},
+		{name: "invalid - hostname with underscore", value: "web_01", format: "hostname", wantErr: "testProp: value is not a valid hostname"},
+		{name: "invalid - hostname with empty label", value: "web..example.com", format: "hostname", wantErr: "testProp: value is not a valid hostname"},
+		{name: "valid - uuid", value: "123e4567-e89b-12d3-a456-426614174000", format: "uuid"},
+		{name: "invalid - uuid urn form", value: "urn:uuid:123e4567-e89b-12d3-a456-426614174000", format: "uuid", wantErr: "testProp: value is not a valid UUID"},
+		{name: "invalid - uuid", value: "not-a-uuid", format: "uuid", wantErr: "testProp: value is not a valid UUID"},
+		{name: "valid - uri", value: "https://example.com/path?q=1", format: "uri"},
+		{name: "invalid - relative uri", value: "/path", format: "uri", wantErr: "testProp: value is not a valid URI"},
+		{name: "invalid - non-string value", value: 123, format: "email", wantErr: "testProp: expected string for format validator"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validator.Validate(ctx, testCtx, OperationCreate, "testProp", nil, tt.value, map[string]any{"value": tt.format})
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Validate() unexpected error = %v", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("Validate() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestFormatValidator_ValidateConfig(t *testing.T) {
+	validator := &FormatValidator[TestContext]{}
+
+	tests := []struct {
+		name    string
+		config  map[string]any
+		wantErr bool
+	}{
+		{
+			name:    "valid config",
+			config:  map[string]any{"value": "cidr"},
+			wantErr: false,
+		},
+		{
+			name:    "invalid - unknown format",
+			config:  map[string]any{"value": "mac"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid - missing value",
+			config:  map[string]any{},
+			wantErr: true,
+		},
+		{
+			name:    "invalid - wrong type",
+			config:  map[string]any{"value": 1},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validator.ValidateConfig("testProp", tt.config)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestFormatValidator_MessagesAreDistinct(t *testing.T) {
+	seen := map[string]string{}
+	for name, format := range stringFormats {
+		if other, ok := seen[format.message]; ok {
+			t.Errorf("formats %s and %s share the message %q", name, other, format.message)
+		}
+		seen[format.message] = name
+	}
+}
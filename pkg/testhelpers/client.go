@@ -75,9 +75,10 @@ func MustDelete(t *testing.T, c *resty.Client, path string, id properties.UUID)
 }
 
 // AssertGone asserts the row at path/{id} is no longer accessible. Accepts
-// either 404 or 403: this API's authz middleware can't determine scope on a
-// missing row and returns 403 with a "resource not found" body — semantically
-// "gone from your perspective". Either status proves the delete happened.
+// either 404 or 403: the authz middleware answers 404 when the scope loader
+// reports the row as not found, but loaders that fail otherwise on a missing
+// row yield 403 with a "resource not found" body — semantically "gone from
+// your perspective". Either status proves the delete happened.
 func AssertGone(t *testing.T, c *resty.Client, path string, id properties.UUID) {
 	t.Helper()
 	resp, err := c.R().
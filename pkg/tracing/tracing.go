@@ -0,0 +1,129 @@
+// Package tracing correlates the log lines and the records caused by a request, an agent call or
+// a worker run through the request ID carried by their context
+package tracing
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// HeaderRequestID is the header carrying the request ID, echoed in the responses
+	HeaderRequestID = "X-Request-Id"
+	// HeaderTraceParent is the W3C trace context header set by the OpenTelemetry instrumentations
+	HeaderTraceParent = "traceparent"
+	// LogKey is the attribute holding the request ID in the log lines
+	LogKey = "requestId"
+
+	// maxRequestIDLength bounds the request IDs accepted from the clients
+	maxRequestIDLength = 128
+)
+
+type requestIDKey struct{}
+
+// WithRequestID returns a context carrying the request ID
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID returns the request ID of the context, empty when it has none
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// NewRequestID generates a request ID
+func NewRequestID() string {
+	return uuid.NewString()
+}
+
+// ResolveRequestID returns the request ID of an incoming request: the request ID sent by the client,
+// else the trace ID of its trace context so the logs join the traces, else a new one
+// The IDs sent by the clients are ignored when too long or not made of visible ASCII characters,
+// so they cannot forge log lines
+func ResolveRequestID(requestID string, traceParent string) string {
+	if isValidRequestID(requestID) {
+		return requestID
+	}
+	if traceID, ok := TraceID(traceParent); ok {
+		return traceID
+	}
+	return NewRequestID()
+}
+
+// isValidRequestID reports whether a request ID sent by a client can be used
+func isValidRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, c := range id {
+		if c <= ' ' || c > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// TraceID returns the trace ID of a W3C trace context traceparent header, as version-traceid-parentid-flags
+// in lowercase hex, e.g. 00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01
+func TraceID(traceParent string) (string, bool) {
+	parts := strings.Split(strings.TrimSpace(traceParent), "-")
+	if len(parts) < 4 {
+		return "", false
+	}
+	version, traceID, parentID, flags := parts[0], parts[1], parts[2], parts[3]
+	// Version ff is forbidden, the version 00 has exactly four fields
+	if !isHex(version, 2) || version == "ff" || (version == "00" && len(parts) != 4) {
+		return "", false
+	}
+	if !isHex(traceID, 32) || !isHex(parentID, 16) || !isHex(flags, 2) {
+		return "", false
+	}
+	// All zeros trace and parent IDs are invalid
+	if strings.Trim(traceID, "0") == "" || strings.Trim(parentID, "0") == "" {
+		return "", false
+	}
+	return traceID, true
+}
+
+// isHex reports whether the value is made of length lowercase hex digits
+func isHex(value string, length int) bool {
+	if len(value) != length {
+		return false
+	}
+	for _, c := range value {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+// LogHandler adds the request ID of the context to the log records, the lines logged with a context
+// such as slog.InfoContext are correlated with the request
+type LogHandler struct {
+	slog.Handler
+}
+
+// NewLogHandler wraps a handler to add the request IDs
+func NewLogHandler(handler slog.Handler) *LogHandler {
+	return &LogHandler{Handler: handler}
+}
+
+func (h *LogHandler) Handle(ctx context.Context, record slog.Record) error {
+	if id := RequestID(ctx); id != "" {
+		record.AddAttrs(slog.String(LogKey, id))
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h *LogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &LogHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+func (h *LogHandler) WithGroup(name string) slog.Handler {
+	return &LogHandler{Handler: h.Handler.WithGroup(name)}
+}
@@ -0,0 +1,70 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveRequestID(t *testing.T) {
+	traceParent := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+	assert.Equal(t, "req-42", ResolveRequestID("req-42", traceParent), "the client request ID comes first")
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", ResolveRequestID("", traceParent))
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", ResolveRequestID("forged\nline", traceParent))
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", ResolveRequestID(strings.Repeat("a", 129), traceParent))
+
+	generated := ResolveRequestID("", "")
+	_, err := uuid.Parse(generated)
+	assert.NoError(t, err)
+	assert.NotEqual(t, generated, ResolveRequestID("", ""))
+}
+
+func TestTraceID(t *testing.T) {
+	tests := []struct {
+		name        string
+		traceParent string
+		want        string
+	}{
+		{name: "Sampled", traceParent: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", want: "4bf92f3577b34da6a3ce929d0e0e4736"},
+		{name: "Future version with more fields", traceParent: "01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00-extra", want: "4bf92f3577b34da6a3ce929d0e0e4736"},
+		{name: "Empty"},
+		{name: "Forbidden version", traceParent: "ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"},
+		{name: "Version 00 with more fields", traceParent: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra"},
+		{name: "Zero trace ID", traceParent: "00-00000000000000000000000000000000-00f067aa0ba902b7-01"},
+		{name: "Zero parent ID", traceParent: "00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01"},
+		{name: "Uppercase", traceParent: "00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01"},
+		{name: "Short trace ID", traceParent: "00-4bf92f3577b34da6-00f067aa0ba902b7-01"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			traceID, ok := TraceID(tc.traceParent)
+			assert.Equal(t, tc.want != "", ok)
+			assert.Equal(t, tc.want, traceID)
+		})
+	}
+}
+
+func TestLogHandler(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(NewLogHandler(slog.NewJSONHandler(&buf, nil))).With("component", "test")
+
+	logger.InfoContext(WithRequestID(context.Background(), "req-42"), "with request")
+	logger.InfoContext(context.Background(), "without request")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 2)
+	var first, second map[string]any
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &first))
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &second))
+	assert.Equal(t, "req-42", first[LogKey])
+	assert.Equal(t, "test", first["component"])
+	assert.NotContains(t, second, LogKey)
+}
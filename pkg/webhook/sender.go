@@ -0,0 +1,61 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/fulcrumproject/core/pkg/domain"
+	"github.com/fulcrumproject/core/pkg/tracing"
+)
+
+const (
+	HeaderSubscriberID = "X-Fulcrum-Subscriber-Id"
+	HeaderEventID      = "X-Fulcrum-Event-Id"
+)
+
+// Sender implements domain.WebhookSender posting JSON payloads over HTTP
+type Sender struct {
+	client *http.Client
+}
+
+// NewSender creates a sender whose requests fail after timeout
+func NewSender(timeout time.Duration) *Sender {
+	return &Sender{
+		client: &http.Client{Timeout: timeout},
+	}
+}
+
+// Send posts the event payload to the callback and returns the response status code
+func (s *Sender) Send(ctx context.Context, req domain.WebhookRequest) (int, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, req.URL, bytes.NewReader(req.Body))
+	if err != nil {
+		return 0, err
+	}
+	contentType := req.ContentType
+	if contentType == "" {
+		contentType = "application/json"
+	}
+	httpReq.Header.Set("Content-Type", contentType)
+	httpReq.Header.Set(HeaderSubscriberID, req.SubscriberID)
+	httpReq.Header.Set(HeaderEventID, req.EventID.String())
+	// The subscribers can correlate the event with the request that caused it
+	if req.RequestID != "" {
+		httpReq.Header.Set(tracing.HeaderRequestID, req.RequestID)
+	}
+	if req.Secret != "" {
+		httpReq.Header.Set(HeaderSignature, SignWebhookPayload(req.Secret, req.Body, time.Now()))
+	}
+
+	resp, err := s.client.Do(httpReq)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	// Drain the body so the connection can be reused
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,99 @@
+package webhook
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/fulcrumproject/core/pkg/domain"
+	"github.com/fulcrumproject/core/pkg/properties"
+	"github.com/fulcrumproject/core/pkg/tracing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSenderSend(t *testing.T) {
+	eventID := properties.NewUUID()
+
+	t.Run("Posts the payload", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, http.MethodPost, r.Method)
+			assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+			assert.Equal(t, "sub-1", r.Header.Get(HeaderSubscriberID))
+			assert.Equal(t, eventID.String(), r.Header.Get(HeaderEventID))
+			assert.Equal(t, "req-42", r.Header.Get(tracing.HeaderRequestID))
+			body, _ := io.ReadAll(r.Body)
+			assert.JSONEq(t, `{"id":1}`, string(body))
+			w.WriteHeader(http.StatusAccepted)
+		}))
+		defer server.Close()
+
+		status, err := NewSender(time.Second).Send(context.Background(), domain.WebhookRequest{
+			URL: server.URL, SubscriberID: "sub-1", EventID: eventID, RequestID: "req-42", Body: []byte(`{"id":1}`),
+		})
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusAccepted, status)
+	})
+
+	t.Run("Sets the content type of the payload", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, domain.CloudEventsContentType, r.Header.Get("Content-Type"))
+			w.WriteHeader(http.StatusOK)
+		}))
+		defer server.Close()
+
+		status, err := NewSender(time.Second).Send(context.Background(), domain.WebhookRequest{
+			URL: server.URL, SubscriberID: "sub-1", EventID: eventID, Body: []byte(`{}`), ContentType: domain.CloudEventsContentType,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, status)
+	})
+
+	t.Run("Signs the payload when a secret is set", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			assert.NoError(t, VerifyWebhookSignature("secret", body, r.Header.Get(HeaderSignature)))
+		}))
+		defer server.Close()
+
+		status, err := NewSender(time.Second).Send(context.Background(), domain.WebhookRequest{
+			URL: server.URL, EventID: eventID, Body: []byte(`{"id":1}`), Secret: "secret",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, status)
+	})
+
+	t.Run("Does not sign without a secret", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Empty(t, r.Header.Get(HeaderSignature))
+		}))
+		defer server.Close()
+
+		_, err := NewSender(time.Second).Send(context.Background(), domain.WebhookRequest{URL: server.URL, EventID: eventID})
+		require.NoError(t, err)
+	})
+
+	t.Run("Returns error statuses", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}))
+		defer server.Close()
+
+		status, err := NewSender(time.Second).Send(context.Background(), domain.WebhookRequest{URL: server.URL, EventID: eventID})
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusServiceUnavailable, status)
+	})
+
+	t.Run("Times out", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			time.Sleep(200 * time.Millisecond)
+		}))
+		defer server.Close()
+
+		_, err := NewSender(50*time.Millisecond).Send(context.Background(), domain.WebhookRequest{URL: server.URL, EventID: eventID})
+		assert.Error(t, err)
+	})
+}
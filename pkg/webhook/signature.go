@@ -0,0 +1,85 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// HeaderSignature carries the timestamp and the HMAC-SHA256 of a signed payload
+	// formatted as t=<unix seconds>,v1=<hex signature>
+	HeaderSignature = "X-Fulcrum-Signature"
+
+	// SignatureTolerance is the maximum age of a signature accepted by VerifyWebhookSignature
+	SignatureTolerance = 5 * time.Minute
+)
+
+var (
+	ErrInvalidSignatureHeader = errors.New("invalid signature header")
+	ErrSignatureMismatch      = errors.New("signature mismatch")
+	ErrSignatureExpired       = errors.New("signature timestamp outside tolerance")
+)
+
+// SignWebhookPayload returns the signature header value of a body sent at the given time
+// The HMAC covers "<timestamp>.<body>" so a captured signature cannot be replayed later
+func SignWebhookPayload(secret string, body []byte, timestamp time.Time) string {
+	t := strconv.FormatInt(timestamp.Unix(), 10)
+	return "t=" + t + ",v1=" + computeSignature(secret, t, body)
+}
+
+// VerifyWebhookSignature checks that the signature header was computed with the secret over
+// the raw request body and that its timestamp is within SignatureTolerance of the current time
+func VerifyWebhookSignature(secret string, body []byte, header string) error {
+	return verifyWebhookSignature(secret, body, header, time.Now())
+}
+
+func verifyWebhookSignature(secret string, body []byte, header string, now time.Time) error {
+	var t string
+	var signatures []string
+	for _, part := range strings.Split(header, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			return ErrInvalidSignatureHeader
+		}
+		switch key {
+		case "t":
+			t = value
+		case "v1":
+			signatures = append(signatures, value)
+		}
+	}
+	if t == "" || len(signatures) == 0 {
+		return ErrInvalidSignatureHeader
+	}
+
+	unix, err := strconv.ParseInt(t, 10, 64)
+	if err != nil {
+		return fmt.Errorf("%w: invalid timestamp %s", ErrInvalidSignatureHeader, t)
+	}
+	age := now.Sub(time.Unix(unix, 0))
+	if age > SignatureTolerance || age < -SignatureTolerance {
+		return ErrSignatureExpired
+	}
+
+	expected := computeSignature(secret, t, body)
+	for _, signature := range signatures {
+		if hmac.Equal([]byte(signature), []byte(expected)) {
+			return nil
+		}
+	}
+	return ErrSignatureMismatch
+}
+
+func computeSignature(secret string, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
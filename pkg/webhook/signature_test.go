@@ -0,0 +1,51 @@
+package webhook
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerifyWebhookSignature(t *testing.T) {
+	body := []byte(`{"id":"1"}`)
+	now := time.Unix(1700000000, 0)
+	header := SignWebhookPayload("secret", body, now)
+
+	tests := []struct {
+		name          string
+		secret        string
+		body          []byte
+		header        string
+		now           time.Time
+		expectedError error
+	}{
+		{name: "Valid", secret: "secret", body: body, header: header, now: now},
+		{name: "Within tolerance", secret: "secret", body: body, header: header, now: now.Add(SignatureTolerance)},
+		{name: "Additional signatures", secret: "secret", body: body, header: "t=1700000000,v1=00," + header[len("t=1700000000,"):], now: now},
+		{name: "Wrong secret", secret: "other", body: body, header: header, now: now, expectedError: ErrSignatureMismatch},
+		{name: "Tampered body", secret: "secret", body: []byte(`{"id":"2"}`), header: header, now: now, expectedError: ErrSignatureMismatch},
+		{name: "Replayed", secret: "secret", body: body, header: header, now: now.Add(SignatureTolerance + time.Second), expectedError: ErrSignatureExpired},
+		{name: "From the future", secret: "secret", body: body, header: header, now: now.Add(-SignatureTolerance - time.Second), expectedError: ErrSignatureExpired},
+		{name: "Missing timestamp", secret: "secret", body: body, header: "v1=abc", now: now, expectedError: ErrInvalidSignatureHeader},
+		{name: "Missing signature", secret: "secret", body: body, header: "t=1700000000", now: now, expectedError: ErrInvalidSignatureHeader},
+		{name: "Malformed", secret: "secret", body: body, header: "garbage", now: now, expectedError: ErrInvalidSignatureHeader},
+		{name: "Invalid timestamp", secret: "secret", body: body, header: "t=abc,v1=abc", now: now, expectedError: ErrInvalidSignatureHeader},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := verifyWebhookSignature(tc.secret, tc.body, tc.header, tc.now)
+			if tc.expectedError != nil {
+				assert.ErrorIs(t, err, tc.expectedError)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestVerifyWebhookSignatureNow(t *testing.T) {
+	body := []byte(`{}`)
+	assert.NoError(t, VerifyWebhookSignature("secret", body, SignWebhookPayload("secret", body, time.Now())))
+}
@@ -0,0 +1,49 @@
+terraform {
+  required_providers {
+    fulcrum = {
+      source = "fulcrumproject/fulcrum"
+    }
+  }
+}
+
+variable "consumer_id" {
+  type = string
+}
+
+variable "provider_id" {
+  type = string
+}
+
+variable "agent_type_id" {
+  type = string
+}
+
+variable "service_type_id" {
+  type = string
+}
+
+provider "fulcrum" {}
+
+resource "fulcrum_agent" "eu" {
+  name          = "eu-west-agent"
+  provider_id   = var.provider_id
+  agent_type_id = var.agent_type_id
+  tags          = ["eu-west"]
+}
+
+resource "fulcrum_service_group" "web" {
+  name        = "web"
+  consumer_id = var.consumer_id
+}
+
+resource "fulcrum_service" "frontend" {
+  name            = "frontend"
+  group_id        = fulcrum_service_group.web.id
+  service_type_id = var.service_type_id
+  agent_id        = fulcrum_agent.eu.id
+
+  properties = jsonencode({
+    cpu    = 2
+    memory = 4096
+  })
+}
@@ -0,0 +1,98 @@
+// Package client is a minimal client of the Fulcrum Core REST API used by the provider
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"resty.dev/v3"
+)
+
+// ErrNotFound is returned when the API reports the resource as missing
+var ErrNotFound = errors.New("resource not found")
+
+// APIError is an error response of the API
+type APIError struct {
+	StatusCode int    `json:"-"`
+	Status     string `json:"status"`
+	Message    string `json:"error"`
+}
+
+func (e *APIError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("%d %s", e.StatusCode, e.Status)
+	}
+	return fmt.Sprintf("%d %s: %s", e.StatusCode, e.Status, e.Message)
+}
+
+// Client calls the Fulcrum Core REST API with a bearer token
+type Client struct {
+	rest *resty.Client
+}
+
+// New creates a client of the API served at endpoint, e.g. https://fulcrum.example.com
+func New(endpoint, token string) *Client {
+	return &Client{
+		rest: resty.New().
+			SetBaseURL(endpoint + "/api/v1").
+			SetAuthToken(token).
+			SetTimeout(30 * time.Second),
+	}
+}
+
+// Close releases the resources of the client
+func (c *Client) Close() error {
+	return c.rest.Close()
+}
+
+func (c *Client) get(ctx context.Context, path string, id string, out any) error {
+	return c.do(ctx, http.MethodGet, path+"/{id}", id, nil, out)
+}
+
+func (c *Client) create(ctx context.Context, path string, body any, out any) error {
+	return c.do(ctx, http.MethodPost, path, "", body, out)
+}
+
+func (c *Client) update(ctx context.Context, path string, id string, body any, out any) error {
+	return c.do(ctx, http.MethodPatch, path+"/{id}", id, body, out)
+}
+
+func (c *Client) delete(ctx context.Context, path string, id string) error {
+	return c.do(ctx, http.MethodDelete, path+"/{id}", id, nil, nil)
+}
+
+// do sends the request and decodes the response in out, a 404 is reported as ErrNotFound
+func (c *Client) do(ctx context.Context, method, path, id string, body, out any) error {
+	req := c.rest.R().SetContext(ctx).SetError(&APIError{})
+	if id != "" {
+		req.SetPathParam("id", id)
+	}
+	if body != nil {
+		req.SetBody(body)
+	}
+	if out != nil {
+		req.SetResult(out)
+	}
+	resp, err := req.Execute(method, path)
+	if err != nil {
+		return err
+	}
+	if !resp.IsError() {
+		return nil
+	}
+	if resp.StatusCode() == http.StatusNotFound {
+		return ErrNotFound
+	}
+	apiErr, ok := resp.Error().(*APIError)
+	if !ok || apiErr == nil {
+		apiErr = &APIError{}
+	}
+	apiErr.StatusCode = resp.StatusCode()
+	if apiErr.Status == "" {
+		apiErr.Status = http.StatusText(resp.StatusCode())
+	}
+	return apiErr
+}
@@ -0,0 +1,64 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/api/v1/service-groups/found":
+			json.NewEncoder(w).Encode(ServiceGroup{ID: "found", Name: "web", ConsumerID: "consumer"})
+		case r.Method == http.MethodGet:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"status":"Resource not found","error":"service_groups missing"}`))
+		case r.Method == http.MethodPatch:
+			var req UpdateServiceGroupReq
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			json.NewEncoder(w).Encode(ServiceGroup{ID: "found", Name: *req.Name})
+		case r.Method == http.MethodDelete:
+			w.WriteHeader(http.StatusConflict)
+			w.Write([]byte(`{"status":"Conflict","error":"cannot delete service group with associated services"}`))
+		}
+	}))
+	defer server.Close()
+
+	c := New(server.URL, "secret")
+	defer c.Close()
+	ctx := context.Background()
+
+	t.Run("Get", func(t *testing.T) {
+		group, err := c.GetServiceGroup(ctx, "found")
+		require.NoError(t, err)
+		assert.Equal(t, "web", group.Name)
+	})
+
+	t.Run("Not found", func(t *testing.T) {
+		_, err := c.GetServiceGroup(ctx, "missing")
+		assert.ErrorIs(t, err, ErrNotFound)
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		name := "api"
+		group, err := c.UpdateServiceGroup(ctx, "found", UpdateServiceGroupReq{Name: &name})
+		require.NoError(t, err)
+		assert.Equal(t, "api", group.Name)
+	})
+
+	t.Run("Error response", func(t *testing.T) {
+		err := c.DeleteServiceGroup(ctx, "found")
+		var apiErr *APIError
+		require.ErrorAs(t, err, &apiErr)
+		assert.Equal(t, http.StatusConflict, apiErr.StatusCode)
+		assert.Equal(t, "409 Conflict: cannot delete service group with associated services", apiErr.Error())
+	})
+}
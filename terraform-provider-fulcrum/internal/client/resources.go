@@ -0,0 +1,154 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+)
+
+const (
+	serviceGroupsPath = "/service-groups"
+	agentsPath        = "/agents"
+	servicesPath      = "/services"
+)
+
+// ServiceGroup is a service group as returned by the API
+type ServiceGroup struct {
+	ID         string `json:"id"`
+	Name       string `json:"name"`
+	ConsumerID string `json:"consumerId"`
+	CreatedAt  string `json:"createdAt"`
+	UpdatedAt  string `json:"updatedAt"`
+}
+
+type CreateServiceGroupReq struct {
+	Name       string `json:"name"`
+	ConsumerID string `json:"consumerId"`
+}
+
+type UpdateServiceGroupReq struct {
+	Name *string `json:"name,omitempty"`
+}
+
+func (c *Client) GetServiceGroup(ctx context.Context, id string) (*ServiceGroup, error) {
+	var out ServiceGroup
+	return &out, c.get(ctx, serviceGroupsPath, id, &out)
+}
+
+func (c *Client) CreateServiceGroup(ctx context.Context, req CreateServiceGroupReq) (*ServiceGroup, error) {
+	var out ServiceGroup
+	return &out, c.create(ctx, serviceGroupsPath, req, &out)
+}
+
+func (c *Client) UpdateServiceGroup(ctx context.Context, id string, req UpdateServiceGroupReq) (*ServiceGroup, error) {
+	var out ServiceGroup
+	return &out, c.update(ctx, serviceGroupsPath, id, req, &out)
+}
+
+func (c *Client) DeleteServiceGroup(ctx context.Context, id string) error {
+	return c.delete(ctx, serviceGroupsPath, id)
+}
+
+// Agent is an agent as returned by the API
+type Agent struct {
+	ID                string          `json:"id"`
+	Name              string          `json:"name"`
+	Status            string          `json:"status"`
+	ProviderID        string          `json:"providerId"`
+	AgentTypeID       string          `json:"agentTypeId"`
+	Tags              []string        `json:"tags"`
+	Capabilities      []string        `json:"capabilities"`
+	Configuration     json.RawMessage `json:"configuration,omitempty"`
+	ServicePoolSetID  *string         `json:"servicePoolSetId,omitempty"`
+	MaxConcurrentJobs *int64          `json:"maxConcurrentJobs,omitempty"`
+	CreatedAt         string          `json:"createdAt"`
+	UpdatedAt         string          `json:"updatedAt"`
+}
+
+type CreateAgentReq struct {
+	Name              string          `json:"name"`
+	ProviderID        string          `json:"providerId"`
+	AgentTypeID       string          `json:"agentTypeId"`
+	Tags              []string        `json:"tags"`
+	Capabilities      []string        `json:"capabilities,omitempty"`
+	Configuration     json.RawMessage `json:"configuration,omitempty"`
+	ServicePoolSetID  *string         `json:"servicePoolSetId,omitempty"`
+	MaxConcurrentJobs *int64          `json:"maxConcurrentJobs,omitempty"`
+}
+
+type UpdateAgentReq struct {
+	Name              *string         `json:"name,omitempty"`
+	Tags              *[]string       `json:"tags,omitempty"`
+	Capabilities      *[]string       `json:"capabilities,omitempty"`
+	Configuration     json.RawMessage `json:"configuration,omitempty"`
+	ServicePoolSetID  *string         `json:"servicePoolSetId,omitempty"`
+	MaxConcurrentJobs *int64          `json:"maxConcurrentJobs,omitempty"`
+}
+
+func (c *Client) GetAgent(ctx context.Context, id string) (*Agent, error) {
+	var out Agent
+	return &out, c.get(ctx, agentsPath, id, &out)
+}
+
+func (c *Client) CreateAgent(ctx context.Context, req CreateAgentReq) (*Agent, error) {
+	var out Agent
+	return &out, c.create(ctx, agentsPath, req, &out)
+}
+
+func (c *Client) UpdateAgent(ctx context.Context, id string, req UpdateAgentReq) (*Agent, error) {
+	var out Agent
+	return &out, c.update(ctx, agentsPath, id, req, &out)
+}
+
+func (c *Client) DeleteAgent(ctx context.Context, id string) error {
+	return c.delete(ctx, agentsPath, id)
+}
+
+// Service is a service as returned by the API
+type Service struct {
+	ID              string          `json:"id"`
+	Name            string          `json:"name"`
+	Status          string          `json:"status"`
+	GroupID         string          `json:"groupId"`
+	AgentID         string          `json:"agentId"`
+	ServiceTypeID   string          `json:"serviceTypeId"`
+	ProviderID      string          `json:"providerId"`
+	ConsumerID      string          `json:"consumerId"`
+	AgentInstanceID *string         `json:"agentInstanceId,omitempty"`
+	Properties      json.RawMessage `json:"properties,omitempty"`
+	CreatedAt       string          `json:"createdAt"`
+	UpdatedAt       string          `json:"updatedAt"`
+}
+
+type CreateServiceReq struct {
+	Name          string          `json:"name"`
+	GroupID       string          `json:"groupId"`
+	ServiceTypeID string          `json:"serviceTypeId"`
+	AgentID       *string         `json:"agentId,omitempty"`
+	AgentTags     []string        `json:"agentTags,omitempty"`
+	Properties    json.RawMessage `json:"properties"`
+}
+
+type UpdateServiceReq struct {
+	Name       *string         `json:"name,omitempty"`
+	Properties json.RawMessage `json:"properties,omitempty"`
+}
+
+func (c *Client) GetService(ctx context.Context, id string) (*Service, error) {
+	var out Service
+	return &out, c.get(ctx, servicesPath, id, &out)
+}
+
+func (c *Client) CreateService(ctx context.Context, req CreateServiceReq) (*Service, error) {
+	var out Service
+	return &out, c.create(ctx, servicesPath, req, &out)
+}
+
+func (c *Client) UpdateService(ctx context.Context, id string, req UpdateServiceReq) (*Service, error) {
+	var out Service
+	return &out, c.update(ctx, servicesPath, id, req, &out)
+}
+
+// DeleteService requests the deletion of the service, carried out by its agent
+func (c *Client) DeleteService(ctx context.Context, id string) error {
+	return c.delete(ctx, servicesPath, id)
+}
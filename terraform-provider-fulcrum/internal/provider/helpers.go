@@ -0,0 +1,115 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"github.com/fulcrumproject/core/terraform-provider-fulcrum/internal/client"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// configureClient returns the API client set up by the provider, nil before the provider is configured
+func configureClient(req resource.ConfigureRequest, resp *resource.ConfigureResponse) *client.Client {
+	if req.ProviderData == nil {
+		return nil
+	}
+	c, ok := req.ProviderData.(*client.Client)
+	if !ok {
+		resp.Diagnostics.AddError("Unexpected provider data", fmt.Sprintf("Expected *client.Client, got %T.", req.ProviderData))
+		return nil
+	}
+	return c
+}
+
+// jsonAttribute returns the JSON value of an attribute to send to the API, nil when not set
+func jsonAttribute(value types.String) (json.RawMessage, error) {
+	if value.IsNull() || value.IsUnknown() {
+		return nil, nil
+	}
+	raw := json.RawMessage(value.ValueString())
+	if !json.Valid(raw) {
+		return nil, fmt.Errorf("invalid JSON: %s", value.ValueString())
+	}
+	return raw, nil
+}
+
+// jsonState returns the state of a JSON object attribute from the value returned by the API
+// The API completes the objects with defaults, so only the keys of the prior value are compared
+// and the prior value is kept as is when they match, keeping the diff clean. Without prior value,
+// as on import, the whole object is returned.
+func jsonState(prior types.String, current json.RawMessage) (types.String, error) {
+	var currentMap map[string]any
+	if len(current) > 0 {
+		if err := json.Unmarshal(current, &currentMap); err != nil {
+			return types.StringNull(), err
+		}
+	}
+	if prior.IsNull() || prior.IsUnknown() {
+		if currentMap == nil {
+			return types.StringNull(), nil
+		}
+		return marshalJSONState(currentMap)
+	}
+
+	var priorMap map[string]any
+	if err := json.Unmarshal([]byte(prior.ValueString()), &priorMap); err != nil {
+		return types.StringNull(), err
+	}
+	restricted := make(map[string]any, len(priorMap))
+	for key := range priorMap {
+		if value, ok := currentMap[key]; ok {
+			restricted[key] = value
+		}
+	}
+	if reflect.DeepEqual(restricted, priorMap) {
+		return prior, nil
+	}
+	return marshalJSONState(restricted)
+}
+
+func marshalJSONState(value map[string]any) (types.String, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return types.StringNull(), err
+	}
+	return types.StringValue(string(data)), nil
+}
+
+// stringList converts a list attribute to the strings to send to the API, nil when not set
+func stringList(ctx context.Context, value types.List) ([]string, diag.Diagnostics) {
+	if value.IsNull() || value.IsUnknown() {
+		return nil, nil
+	}
+	var out []string
+	diags := value.ElementsAs(ctx, &out, false)
+	return out, diags
+}
+
+// listValue converts the strings returned by the API to a list attribute, an empty list for none
+func listValue(ctx context.Context, values []string) (types.List, diag.Diagnostics) {
+	if values == nil {
+		values = []string{}
+	}
+	return types.ListValueFrom(ctx, types.StringType, values)
+}
+
+// optionalString converts an optional string returned by the API to an attribute
+func optionalString(value *string) types.String {
+	if value == nil {
+		return types.StringNull()
+	}
+	return types.StringValue(*value)
+}
+
+// stringPointer converts an optional attribute to the string to send to the API, nil when not set
+func stringPointer(value types.String) *string {
+	if value.IsNull() || value.IsUnknown() {
+		return nil
+	}
+	s := value.ValueString()
+	return &s
+}
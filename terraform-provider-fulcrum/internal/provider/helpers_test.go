@@ -0,0 +1,72 @@
+package provider
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJSONState(t *testing.T) {
+	current := json.RawMessage(`{"cpu":2,"memory":512,"zone":"eu"}`)
+
+	tests := []struct {
+		name     string
+		prior    types.String
+		current  json.RawMessage
+		expected types.String
+	}{
+		{
+			name:     "Keeps the prior value when its keys match",
+			prior:    types.StringValue(`{ "zone": "eu", "cpu": 2 }`),
+			current:  current,
+			expected: types.StringValue(`{ "zone": "eu", "cpu": 2 }`),
+		},
+		{
+			name:     "Reports the changed values of the prior keys",
+			prior:    types.StringValue(`{"cpu":4,"zone":"eu"}`),
+			current:  current,
+			expected: types.StringValue(`{"cpu":2,"zone":"eu"}`),
+		},
+		{
+			name:     "Reports removed keys",
+			prior:    types.StringValue(`{"cpu":2,"disk":10}`),
+			current:  current,
+			expected: types.StringValue(`{"cpu":2}`),
+		},
+		{
+			name:     "Returns the whole object without prior value",
+			prior:    types.StringNull(),
+			current:  current,
+			expected: types.StringValue(`{"cpu":2,"memory":512,"zone":"eu"}`),
+		},
+		{
+			name:     "Returns null without value",
+			prior:    types.StringUnknown(),
+			expected: types.StringNull(),
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			state, err := jsonState(tc.prior, tc.current)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, state)
+		})
+	}
+}
+
+func TestJSONAttribute(t *testing.T) {
+	raw, err := jsonAttribute(types.StringValue(`{"cpu":2}`))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"cpu":2}`, string(raw))
+
+	raw, err = jsonAttribute(types.StringNull())
+	require.NoError(t, err)
+	assert.Nil(t, raw)
+
+	_, err = jsonAttribute(types.StringValue(`{"cpu":`))
+	assert.Error(t, err)
+}
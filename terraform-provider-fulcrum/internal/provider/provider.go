@@ -0,0 +1,101 @@
+// Package provider implements the Fulcrum Terraform provider
+package provider
+
+import (
+	"context"
+	"os"
+
+	"github.com/fulcrumproject/core/terraform-provider-fulcrum/internal/client"
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/provider"
+	"github.com/hashicorp/terraform-plugin-framework/provider/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+const (
+	envEndpoint = "FULCRUM_ENDPOINT"
+	envToken    = "FULCRUM_TOKEN"
+)
+
+var _ provider.Provider = &fulcrumProvider{}
+
+type fulcrumProvider struct {
+	version string
+}
+
+type providerModel struct {
+	Endpoint types.String `tfsdk:"endpoint"`
+	Token    types.String `tfsdk:"token"`
+}
+
+// New returns a constructor of the provider for the given version
+func New(version string) func() provider.Provider {
+	return func() provider.Provider {
+		return &fulcrumProvider{version: version}
+	}
+}
+
+func (p *fulcrumProvider) Metadata(_ context.Context, _ provider.MetadataRequest, resp *provider.MetadataResponse) {
+	resp.TypeName = "fulcrum"
+	resp.Version = p.version
+}
+
+func (p *fulcrumProvider) Schema(_ context.Context, _ provider.SchemaRequest, resp *provider.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Manages Fulcrum Core resources.",
+		Attributes: map[string]schema.Attribute{
+			"endpoint": schema.StringAttribute{
+				Description: "URL of the Fulcrum Core API, e.g. https://fulcrum.example.com. Defaults to the " + envEndpoint + " environment variable.",
+				Optional:    true,
+			},
+			"token": schema.StringAttribute{
+				Description: "Bearer token used to call the API. Defaults to the " + envToken + " environment variable.",
+				Optional:    true,
+				Sensitive:   true,
+			},
+		},
+	}
+}
+
+func (p *fulcrumProvider) Configure(ctx context.Context, req provider.ConfigureRequest, resp *provider.ConfigureResponse) {
+	var config providerModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &config)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	endpoint := os.Getenv(envEndpoint)
+	if !config.Endpoint.IsNull() {
+		endpoint = config.Endpoint.ValueString()
+	}
+	token := os.Getenv(envToken)
+	if !config.Token.IsNull() {
+		token = config.Token.ValueString()
+	}
+	if endpoint == "" {
+		resp.Diagnostics.AddError("Missing endpoint", "Set the endpoint attribute or the "+envEndpoint+" environment variable.")
+	}
+	if token == "" {
+		resp.Diagnostics.AddError("Missing token", "Set the token attribute or the "+envToken+" environment variable.")
+	}
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	c := client.New(endpoint, token)
+	resp.ResourceData = c
+	resp.DataSourceData = c
+}
+
+func (p *fulcrumProvider) Resources(_ context.Context) []func() resource.Resource {
+	return []func() resource.Resource{
+		newServiceGroupResource,
+		newAgentResource,
+		newServiceResource,
+	}
+}
+
+func (p *fulcrumProvider) DataSources(_ context.Context) []func() datasource.DataSource {
+	return nil
+}
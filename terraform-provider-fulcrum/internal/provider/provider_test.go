@@ -0,0 +1,24 @@
+package provider
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/providerserver"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProviderSchema(t *testing.T) {
+	server := providerserver.NewProtocol6(New("test")())()
+
+	resp, err := server.GetProviderSchema(context.Background(), &tfprotov6.GetProviderSchemaRequest{})
+	require.NoError(t, err)
+	for _, d := range resp.Diagnostics {
+		t.Errorf("%s: %s", d.Summary, d.Detail)
+	}
+	assert.Contains(t, resp.ResourceSchemas, "fulcrum_service")
+	assert.Contains(t, resp.ResourceSchemas, "fulcrum_service_group")
+	assert.Contains(t, resp.ResourceSchemas, "fulcrum_agent")
+}
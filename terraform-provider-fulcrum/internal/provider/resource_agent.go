@@ -0,0 +1,261 @@
+package provider
+
+import (
+	"context"
+	"errors"
+
+	"github.com/fulcrumproject/core/terraform-provider-fulcrum/internal/client"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/int64planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/listplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+var (
+	_ resource.ResourceWithConfigure   = &agentResource{}
+	_ resource.ResourceWithImportState = &agentResource{}
+)
+
+type agentResource struct {
+	client *client.Client
+}
+
+type agentModel struct {
+	ID                types.String `tfsdk:"id"`
+	Name              types.String `tfsdk:"name"`
+	ProviderID        types.String `tfsdk:"provider_id"`
+	AgentTypeID       types.String `tfsdk:"agent_type_id"`
+	Tags              types.List   `tfsdk:"tags"`
+	Capabilities      types.List   `tfsdk:"capabilities"`
+	Configuration     types.String `tfsdk:"configuration"`
+	ServicePoolSetID  types.String `tfsdk:"service_pool_set_id"`
+	MaxConcurrentJobs types.Int64  `tfsdk:"max_concurrent_jobs"`
+	Status            types.String `tfsdk:"status"`
+	CreatedAt         types.String `tfsdk:"created_at"`
+	UpdatedAt         types.String `tfsdk:"updated_at"`
+}
+
+func newAgentResource() resource.Resource {
+	return &agentResource{}
+}
+
+func (r *agentResource) Metadata(_ context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_agent"
+}
+
+func (r *agentResource) Schema(_ context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "An agent of a provider, running the services on its infrastructure.",
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Description:   "ID of the agent.",
+				Computed:      true,
+				PlanModifiers: []planmodifier.String{stringplanmodifier.UseStateForUnknown()},
+			},
+			"name": schema.StringAttribute{
+				Description: "Name of the agent.",
+				Required:    true,
+			},
+			"provider_id": schema.StringAttribute{
+				Description:   "ID of the provider participant owning the agent.",
+				Required:      true,
+				PlanModifiers: []planmodifier.String{stringplanmodifier.RequiresReplace()},
+			},
+			"agent_type_id": schema.StringAttribute{
+				Description:   "ID of the agent type.",
+				Required:      true,
+				PlanModifiers: []planmodifier.String{stringplanmodifier.RequiresReplace()},
+			},
+			"tags": schema.ListAttribute{
+				Description:   "Tags matched against the agent tags of the services.",
+				ElementType:   types.StringType,
+				Optional:      true,
+				Computed:      true,
+				PlanModifiers: []planmodifier.List{listplanmodifier.UseStateForUnknown()},
+			},
+			"capabilities": schema.ListAttribute{
+				Description:   "Capabilities of the agent, among the ones of its agent type.",
+				ElementType:   types.StringType,
+				Optional:      true,
+				Computed:      true,
+				PlanModifiers: []planmodifier.List{listplanmodifier.UseStateForUnknown()},
+			},
+			"configuration": schema.StringAttribute{
+				Description:   "JSON configuration of the agent, validated against the configuration schema of the agent type.",
+				Optional:      true,
+				Computed:      true,
+				PlanModifiers: []planmodifier.String{stringplanmodifier.UseStateForUnknown()},
+			},
+			"service_pool_set_id": schema.StringAttribute{
+				Description: "ID of the service pool set the agent allocates values from.",
+				Optional:    true,
+			},
+			"max_concurrent_jobs": schema.Int64Attribute{
+				Description:   "Maximum number of jobs the agent processes at once, defaults to the limit of the agent type.",
+				Optional:      true,
+				Computed:      true,
+				PlanModifiers: []planmodifier.Int64{int64planmodifier.UseStateForUnknown()},
+			},
+			"status": schema.StringAttribute{
+				Description: "Status of the agent.",
+				Computed:    true,
+			},
+			"created_at": schema.StringAttribute{
+				Computed:      true,
+				PlanModifiers: []planmodifier.String{stringplanmodifier.UseStateForUnknown()},
+			},
+			"updated_at": schema.StringAttribute{
+				Computed: true,
+			},
+		},
+	}
+}
+
+func (r *agentResource) Configure(_ context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	r.client = configureClient(req, resp)
+}
+
+func (r *agentResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var plan agentModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	tags, diags := stringList(ctx, plan.Tags)
+	resp.Diagnostics.Append(diags...)
+	capabilities, diags := stringList(ctx, plan.Capabilities)
+	resp.Diagnostics.Append(diags...)
+	configuration, err := jsonAttribute(plan.Configuration)
+	if err != nil {
+		resp.Diagnostics.AddAttributeError(path.Root("configuration"), "Invalid configuration", err.Error())
+	}
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	agent, err := r.client.CreateAgent(ctx, client.CreateAgentReq{
+		Name:              plan.Name.ValueString(),
+		ProviderID:        plan.ProviderID.ValueString(),
+		AgentTypeID:       plan.AgentTypeID.ValueString(),
+		Tags:              tags,
+		Capabilities:      capabilities,
+		Configuration:     configuration,
+		ServicePoolSetID:  stringPointer(plan.ServicePoolSetID),
+		MaxConcurrentJobs: plan.MaxConcurrentJobs.ValueInt64Pointer(),
+	})
+	if err != nil {
+		resp.Diagnostics.AddError("Cannot create agent", err.Error())
+		return
+	}
+	resp.Diagnostics.Append(plan.fromAPI(ctx, agent)...)
+	resp.Diagnostics.Append(resp.State.Set(ctx, plan)...)
+}
+
+func (r *agentResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var state agentModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	agent, err := r.client.GetAgent(ctx, state.ID.ValueString())
+	if errors.Is(err, client.ErrNotFound) {
+		resp.State.RemoveResource(ctx)
+		return
+	}
+	if err != nil {
+		resp.Diagnostics.AddError("Cannot read agent", err.Error())
+		return
+	}
+	resp.Diagnostics.Append(state.fromAPI(ctx, agent)...)
+	resp.Diagnostics.Append(resp.State.Set(ctx, state)...)
+}
+
+func (r *agentResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var plan agentModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// The API applies only the given fields and saves nothing when they repeat the current values
+	update := client.UpdateAgentReq{
+		Name:              stringPointer(plan.Name),
+		ServicePoolSetID:  stringPointer(plan.ServicePoolSetID),
+		MaxConcurrentJobs: plan.MaxConcurrentJobs.ValueInt64Pointer(),
+	}
+	if !plan.Tags.IsUnknown() {
+		tags, diags := stringList(ctx, plan.Tags)
+		resp.Diagnostics.Append(diags...)
+		update.Tags = &tags
+	}
+	if !plan.Capabilities.IsUnknown() {
+		capabilities, diags := stringList(ctx, plan.Capabilities)
+		resp.Diagnostics.Append(diags...)
+		update.Capabilities = &capabilities
+	}
+	configuration, err := jsonAttribute(plan.Configuration)
+	if err != nil {
+		resp.Diagnostics.AddAttributeError(path.Root("configuration"), "Invalid configuration", err.Error())
+	}
+	update.Configuration = configuration
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	agent, err := r.client.UpdateAgent(ctx, plan.ID.ValueString(), update)
+	if err != nil {
+		resp.Diagnostics.AddError("Cannot update agent", err.Error())
+		return
+	}
+	resp.Diagnostics.Append(plan.fromAPI(ctx, agent)...)
+	resp.Diagnostics.Append(resp.State.Set(ctx, plan)...)
+}
+
+func (r *agentResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	var state agentModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	err := r.client.DeleteAgent(ctx, state.ID.ValueString())
+	if err != nil && !errors.Is(err, client.ErrNotFound) {
+		resp.Diagnostics.AddError("Cannot delete agent", err.Error())
+	}
+}
+
+func (r *agentResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	resource.ImportStatePassthroughID(ctx, path.Root("id"), req, resp)
+}
+
+func (m *agentModel) fromAPI(ctx context.Context, agent *client.Agent) diag.Diagnostics {
+	var diags diag.Diagnostics
+	m.ID = types.StringValue(agent.ID)
+	m.Name = types.StringValue(agent.Name)
+	m.ProviderID = types.StringValue(agent.ProviderID)
+	m.AgentTypeID = types.StringValue(agent.AgentTypeID)
+	m.ServicePoolSetID = optionalString(agent.ServicePoolSetID)
+	m.MaxConcurrentJobs = types.Int64PointerValue(agent.MaxConcurrentJobs)
+	m.Status = types.StringValue(agent.Status)
+	m.CreatedAt = types.StringValue(agent.CreatedAt)
+	m.UpdatedAt = types.StringValue(agent.UpdatedAt)
+
+	var d diag.Diagnostics
+	m.Tags, d = listValue(ctx, agent.Tags)
+	diags.Append(d...)
+	m.Capabilities, d = listValue(ctx, agent.Capabilities)
+	diags.Append(d...)
+	configuration, err := jsonState(m.Configuration, agent.Configuration)
+	if err != nil {
+		diags.AddAttributeError(path.Root("configuration"), "Invalid configuration returned by the API", err.Error())
+	}
+	m.Configuration = configuration
+	return diags
+}
@@ -0,0 +1,292 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/fulcrumproject/core/terraform-provider-fulcrum/internal/client"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/listplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+const (
+	// serviceDeleteTimeout bounds the wait for the agent to carry out the deletion of a service
+	serviceDeleteTimeout = 30 * time.Minute
+	// serviceDeletePollInterval is the interval between the checks of a service being deleted
+	serviceDeletePollInterval = 5 * time.Second
+)
+
+var (
+	_ resource.ResourceWithConfigure   = &serviceResource{}
+	_ resource.ResourceWithImportState = &serviceResource{}
+)
+
+type serviceResource struct {
+	client *client.Client
+}
+
+type serviceModel struct {
+	ID              types.String `tfsdk:"id"`
+	Name            types.String `tfsdk:"name"`
+	GroupID         types.String `tfsdk:"group_id"`
+	ServiceTypeID   types.String `tfsdk:"service_type_id"`
+	AgentID         types.String `tfsdk:"agent_id"`
+	AgentTags       types.List   `tfsdk:"agent_tags"`
+	Properties      types.String `tfsdk:"properties"`
+	ProviderID      types.String `tfsdk:"provider_id"`
+	ConsumerID      types.String `tfsdk:"consumer_id"`
+	AgentInstanceID types.String `tfsdk:"agent_instance_id"`
+	Status          types.String `tfsdk:"status"`
+	CreatedAt       types.String `tfsdk:"created_at"`
+	UpdatedAt       types.String `tfsdk:"updated_at"`
+}
+
+func newServiceResource() resource.Resource {
+	return &serviceResource{}
+}
+
+func (r *serviceResource) Metadata(_ context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_service"
+}
+
+func (r *serviceResource) Schema(_ context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "A service of a consumer, provisioned by an agent. " +
+			"Changes are carried out asynchronously by the agent through jobs.",
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Description:   "ID of the service.",
+				Computed:      true,
+				PlanModifiers: []planmodifier.String{stringplanmodifier.UseStateForUnknown()},
+			},
+			"name": schema.StringAttribute{
+				Description: "Name of the service.",
+				Required:    true,
+			},
+			"group_id": schema.StringAttribute{
+				Description:   "ID of the service group of the service.",
+				Required:      true,
+				PlanModifiers: []planmodifier.String{stringplanmodifier.RequiresReplace()},
+			},
+			"service_type_id": schema.StringAttribute{
+				Description:   "ID of the service type.",
+				Required:      true,
+				PlanModifiers: []planmodifier.String{stringplanmodifier.RequiresReplace()},
+			},
+			"agent_id": schema.StringAttribute{
+				Description: "ID of the agent running the service, selected from agent_tags when not set.",
+				Optional:    true,
+				Computed:    true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"agent_tags": schema.ListAttribute{
+				Description:   "Tags the selected agent must have, used when agent_id is not set.",
+				ElementType:   types.StringType,
+				Optional:      true,
+				PlanModifiers: []planmodifier.List{listplanmodifier.RequiresReplace()},
+			},
+			"properties": schema.StringAttribute{
+				Description: "JSON properties of the service, validated against the property schema of the service type. " +
+					"Only the properties set here are compared with the ones of the service.",
+				Optional:      true,
+				Computed:      true,
+				PlanModifiers: []planmodifier.String{stringplanmodifier.UseStateForUnknown()},
+			},
+			"provider_id": schema.StringAttribute{
+				Computed:      true,
+				PlanModifiers: []planmodifier.String{stringplanmodifier.UseStateForUnknown()},
+			},
+			"consumer_id": schema.StringAttribute{
+				Computed:      true,
+				PlanModifiers: []planmodifier.String{stringplanmodifier.UseStateForUnknown()},
+			},
+			"agent_instance_id": schema.StringAttribute{
+				Description: "ID of the service instance on the agent, once created.",
+				Computed:    true,
+			},
+			"status": schema.StringAttribute{
+				Description: "Status of the service.",
+				Computed:    true,
+			},
+			"created_at": schema.StringAttribute{
+				Computed:      true,
+				PlanModifiers: []planmodifier.String{stringplanmodifier.UseStateForUnknown()},
+			},
+			"updated_at": schema.StringAttribute{
+				Computed: true,
+			},
+		},
+	}
+}
+
+func (r *serviceResource) Configure(_ context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	r.client = configureClient(req, resp)
+}
+
+func (r *serviceResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var plan serviceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	agentTags, diags := stringList(ctx, plan.AgentTags)
+	resp.Diagnostics.Append(diags...)
+	props, err := jsonAttribute(plan.Properties)
+	if err != nil {
+		resp.Diagnostics.AddAttributeError(path.Root("properties"), "Invalid properties", err.Error())
+	}
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	if props == nil {
+		props = []byte("{}")
+	}
+
+	svc, err := r.client.CreateService(ctx, client.CreateServiceReq{
+		Name:          plan.Name.ValueString(),
+		GroupID:       plan.GroupID.ValueString(),
+		ServiceTypeID: plan.ServiceTypeID.ValueString(),
+		AgentID:       stringPointer(plan.AgentID),
+		AgentTags:     agentTags,
+		Properties:    props,
+	})
+	if err != nil {
+		resp.Diagnostics.AddError("Cannot create service", err.Error())
+		return
+	}
+	resp.Diagnostics.Append(plan.fromAPI(svc)...)
+	resp.Diagnostics.Append(resp.State.Set(ctx, plan)...)
+}
+
+func (r *serviceResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var state serviceModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	svc, err := r.client.GetService(ctx, state.ID.ValueString())
+	if errors.Is(err, client.ErrNotFound) {
+		resp.State.RemoveResource(ctx)
+		return
+	}
+	if err != nil {
+		resp.Diagnostics.AddError("Cannot read service", err.Error())
+		return
+	}
+	resp.Diagnostics.Append(state.fromAPI(svc)...)
+	resp.Diagnostics.Append(resp.State.Set(ctx, state)...)
+}
+
+func (r *serviceResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var plan, state serviceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// The API only updates the properties whose values change, the others do not trigger a job
+	update := client.UpdateServiceReq{Name: stringPointer(plan.Name)}
+	if !plan.Properties.Equal(state.Properties) {
+		props, err := jsonAttribute(plan.Properties)
+		if err != nil {
+			resp.Diagnostics.AddAttributeError(path.Root("properties"), "Invalid properties", err.Error())
+			return
+		}
+		update.Properties = props
+	}
+
+	svc, err := r.client.UpdateService(ctx, plan.ID.ValueString(), update)
+	if err != nil {
+		resp.Diagnostics.AddError("Cannot update service", err.Error())
+		return
+	}
+	resp.Diagnostics.Append(plan.fromAPI(svc)...)
+	resp.Diagnostics.Append(resp.State.Set(ctx, plan)...)
+}
+
+func (r *serviceResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	var state serviceModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	id := state.ID.ValueString()
+	err := r.client.DeleteService(ctx, id)
+	if errors.Is(err, client.ErrNotFound) {
+		return
+	}
+	if err != nil {
+		resp.Diagnostics.AddError("Cannot delete service", err.Error())
+		return
+	}
+	if err := r.waitDeleted(ctx, id); err != nil {
+		resp.Diagnostics.AddError("Cannot delete service", err.Error())
+	}
+}
+
+// waitDeleted waits for the agent to delete the service, deleted services are not returned by the API
+func (r *serviceResource) waitDeleted(ctx context.Context, id string) error {
+	ctx, cancel := context.WithTimeout(ctx, serviceDeleteTimeout)
+	defer cancel()
+
+	ticker := time.NewTicker(serviceDeletePollInterval)
+	defer ticker.Stop()
+	for {
+		_, err := r.client.GetService(ctx, id)
+		if errors.Is(err, client.ErrNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("service %s still not deleted: %w", id, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+func (r *serviceResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	resource.ImportStatePassthroughID(ctx, path.Root("id"), req, resp)
+}
+
+func (m *serviceModel) fromAPI(svc *client.Service) diag.Diagnostics {
+	var diags diag.Diagnostics
+	m.ID = types.StringValue(svc.ID)
+	m.Name = types.StringValue(svc.Name)
+	m.GroupID = types.StringValue(svc.GroupID)
+	m.ServiceTypeID = types.StringValue(svc.ServiceTypeID)
+	m.AgentID = types.StringValue(svc.AgentID)
+	m.ProviderID = types.StringValue(svc.ProviderID)
+	m.ConsumerID = types.StringValue(svc.ConsumerID)
+	m.AgentInstanceID = optionalString(svc.AgentInstanceID)
+	m.Status = types.StringValue(svc.Status)
+	m.CreatedAt = types.StringValue(svc.CreatedAt)
+	m.UpdatedAt = types.StringValue(svc.UpdatedAt)
+	if m.AgentTags.IsUnknown() {
+		m.AgentTags = types.ListNull(types.StringType)
+	}
+
+	props, err := jsonState(m.Properties, svc.Properties)
+	if err != nil {
+		diags.AddAttributeError(path.Root("properties"), "Invalid properties returned by the API", err.Error())
+	}
+	m.Properties = props
+	return diags
+}
@@ -0,0 +1,154 @@
+package provider
+
+import (
+	"context"
+	"errors"
+
+	"github.com/fulcrumproject/core/terraform-provider-fulcrum/internal/client"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+var (
+	_ resource.ResourceWithConfigure   = &serviceGroupResource{}
+	_ resource.ResourceWithImportState = &serviceGroupResource{}
+)
+
+type serviceGroupResource struct {
+	client *client.Client
+}
+
+type serviceGroupModel struct {
+	ID         types.String `tfsdk:"id"`
+	Name       types.String `tfsdk:"name"`
+	ConsumerID types.String `tfsdk:"consumer_id"`
+	CreatedAt  types.String `tfsdk:"created_at"`
+	UpdatedAt  types.String `tfsdk:"updated_at"`
+}
+
+func newServiceGroupResource() resource.Resource {
+	return &serviceGroupResource{}
+}
+
+func (r *serviceGroupResource) Metadata(_ context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_service_group"
+}
+
+func (r *serviceGroupResource) Schema(_ context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "A group of related services of a consumer.",
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Description:   "ID of the service group.",
+				Computed:      true,
+				PlanModifiers: []planmodifier.String{stringplanmodifier.UseStateForUnknown()},
+			},
+			"name": schema.StringAttribute{
+				Description: "Name of the service group.",
+				Required:    true,
+			},
+			"consumer_id": schema.StringAttribute{
+				Description:   "ID of the consumer participant owning the group.",
+				Required:      true,
+				PlanModifiers: []planmodifier.String{stringplanmodifier.RequiresReplace()},
+			},
+			"created_at": schema.StringAttribute{
+				Computed:      true,
+				PlanModifiers: []planmodifier.String{stringplanmodifier.UseStateForUnknown()},
+			},
+			"updated_at": schema.StringAttribute{
+				Computed: true,
+			},
+		},
+	}
+}
+
+func (r *serviceGroupResource) Configure(_ context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	r.client = configureClient(req, resp)
+}
+
+func (r *serviceGroupResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var plan serviceGroupModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	group, err := r.client.CreateServiceGroup(ctx, client.CreateServiceGroupReq{
+		Name:       plan.Name.ValueString(),
+		ConsumerID: plan.ConsumerID.ValueString(),
+	})
+	if err != nil {
+		resp.Diagnostics.AddError("Cannot create service group", err.Error())
+		return
+	}
+	plan.fromAPI(group)
+	resp.Diagnostics.Append(resp.State.Set(ctx, plan)...)
+}
+
+func (r *serviceGroupResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var state serviceGroupModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	group, err := r.client.GetServiceGroup(ctx, state.ID.ValueString())
+	if errors.Is(err, client.ErrNotFound) {
+		resp.State.RemoveResource(ctx)
+		return
+	}
+	if err != nil {
+		resp.Diagnostics.AddError("Cannot read service group", err.Error())
+		return
+	}
+	state.fromAPI(group)
+	resp.Diagnostics.Append(resp.State.Set(ctx, state)...)
+}
+
+func (r *serviceGroupResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var plan serviceGroupModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	group, err := r.client.UpdateServiceGroup(ctx, plan.ID.ValueString(), client.UpdateServiceGroupReq{
+		Name: stringPointer(plan.Name),
+	})
+	if err != nil {
+		resp.Diagnostics.AddError("Cannot update service group", err.Error())
+		return
+	}
+	plan.fromAPI(group)
+	resp.Diagnostics.Append(resp.State.Set(ctx, plan)...)
+}
+
+func (r *serviceGroupResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	var state serviceGroupModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	err := r.client.DeleteServiceGroup(ctx, state.ID.ValueString())
+	if err != nil && !errors.Is(err, client.ErrNotFound) {
+		resp.Diagnostics.AddError("Cannot delete service group", err.Error())
+	}
+}
+
+func (r *serviceGroupResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	resource.ImportStatePassthroughID(ctx, path.Root("id"), req, resp)
+}
+
+func (m *serviceGroupModel) fromAPI(group *client.ServiceGroup) {
+	m.ID = types.StringValue(group.ID)
+	m.Name = types.StringValue(group.Name)
+	m.ConsumerID = types.StringValue(group.ConsumerID)
+	m.CreatedAt = types.StringValue(group.CreatedAt)
+	m.UpdatedAt = types.StringValue(group.UpdatedAt)
+}
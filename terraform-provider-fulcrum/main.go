@@ -0,0 +1,27 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+
+	"github.com/fulcrumproject/core/terraform-provider-fulcrum/internal/provider"
+	"github.com/hashicorp/terraform-plugin-framework/providerserver"
+)
+
+// version is set by the release build
+var version = "dev"
+
+func main() {
+	var debug bool
+	flag.BoolVar(&debug, "debug", false, "run the provider with support for debuggers")
+	flag.Parse()
+
+	err := providerserver.Serve(context.Background(), provider.New(version), providerserver.ServeOpts{
+		Address: "registry.terraform.io/fulcrumproject/fulcrum",
+		Debug:   debug,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+}